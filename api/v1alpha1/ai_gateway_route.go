@@ -206,6 +206,480 @@ type AIGatewayRouteSpec struct {
 	// +optional
 	// +kubebuilder:validation:MaxItems=36
 	LLMRequestCosts []LLMRequestCost `json:"llmRequestCosts,omitempty"`
+
+	// PostProcessing configures a small user-supplied filter that runs on the traffic of this route,
+	// for quick tweaks such as tagging or removing response headers without building a custom
+	// external processor image.
+	//
+	// The AI Gateway extension server attaches the filter to every xDS route generated from this
+	// AIGatewayRoute before it is sent to the data plane.
+	//
+	// +optional
+	PostProcessing *AIGatewayRoutePostProcessing `json:"postProcessing,omitempty"`
+	// Experiment assigns each request of this route to one of the variants of an A/B experiment, for example to
+	// compare two models with the existing GenAI metrics.
+	//
+	// The assignment is sticky: the requests with the same value of the StickyHeader, e.g. the requests of the
+	// same user, are always assigned the same variant. The assigned variant is returned to the client in the
+	// "x-ai-eg-experiment-variant" response header, stored in the "experiment" and "experiment_variant" keys of
+	// the dynamic metadata in the "io.envoy.ai_gateway" namespace, and reported as the "experiment.name" and
+	// "experiment.variant" attributes of the GenAI metrics and spans.
+	//
+	// +optional
+	Experiment *AIGatewayRouteExperiment `json:"experiment,omitempty"`
+
+	// HeaderLimits configures the limits on the size and the number of the HTTP headers of the traffic of this
+	// route, e.g. to accept the large metadata headers of long tool calling conversations. Envoy defaults to
+	// 60 KiB of request headers and 100 headers.
+	//
+	// The AI Gateway extension server sets the limits on the listeners serving this route and on the clusters
+	// generated from it. Since a listener is shared by all the routes attached to it, the listener uses the
+	// largest limits of those routes.
+	//
+	// +optional
+	HeaderLimits *AIGatewayRouteHeaderLimits `json:"headerLimits,omitempty"`
+
+	// ModelVisibility restricts the tenants to which the models declared by the rules of this route are listed
+	// in the "/v1/models" endpoint, so that each tenant only discovers the models it is allowed to call. The
+	// models of the routes without ModelVisibility are listed to every caller.
+	//
+	// The tenant of a request is the value of the TenantHeader. To use a JWT claim as the tenant, map the claim
+	// to the header with the claimToHeaders of the JWT provider of an Envoy Gateway SecurityPolicy.
+	//
+	// This only filters the model list. Restricting the calls to the models themselves is up to the
+	// authorization of the route, e.g. with a SecurityPolicy.
+	//
+	// +optional
+	ModelVisibility *AIGatewayRouteModelVisibility `json:"modelVisibility,omitempty"`
+
+	// ParameterOverrides allows the callers of this route to override the sampling parameters of the requests
+	// with the "x-aigw-param-*" request headers without modifying the request body, e.g. for test tooling and
+	// traffic replays. The supported headers are "x-aigw-param-temperature" and "x-aigw-param-max-tokens".
+	//
+	// The requests overriding a parameter that is not configured here, or with a value out of its bounds, are
+	// rejected with 400 Bad Request. The overrides apply to the chat completions, completions, responses and
+	// messages endpoints, and the headers are ignored by the other endpoints and by the routes without
+	// ParameterOverrides. The headers are never sent to the backends.
+	//
+	// +optional
+	ParameterOverrides *AIGatewayRouteParameterOverrides `json:"parameterOverrides,omitempty"`
+
+	// BackendOverride allows the callers of this route to pin the backend of a request with the "x-aigw-backend"
+	// request header set to the namespace/name of one of the AIServiceBackends of the matched rule, e.g.
+	// "default/openai", to debug or test a single provider in production. The header must be accompanied by a valid
+	// "x-aigw-backend-signature" header signed with the key of this BackendOverride, so that the callers can't
+	// bypass the load balancing at will.
+	//
+	// The signature is "<expiry>:<hex>", where expiry is a Unix time in seconds and hex is the hex-encoded
+	// HMAC-SHA256 of "<namespace>/<name>:<expiry>" with the key. The requests with a pinned backend and a missing,
+	// invalid or expired signature are rejected with 403 Forbidden. Both headers are removed before the request is
+	// sent to the backend, and ignored by the routes without BackendOverride.
+	//
+	// +optional
+	BackendOverride *AIGatewayRouteBackendOverride `json:"backendOverride,omitempty"`
+
+	// UnsupportedParameterBehavior is the behavior of this route on the parameters of the chat completion requests
+	// that the selected backend cannot honor, e.g. "logit_bias" or "seed" sent to an AWS Bedrock or Anthropic
+	// backend, which the translation to the schema of the backend drops. Defaults to Drop.
+	//
+	// With Warn, the dropped parameters are listed in the "x-ai-eg-unsupported-parameters" response header. With
+	// Error, the requests are rejected with 400 Bad Request and an OpenAI "invalid_request_error" listing them, so
+	// that the strict clients don't silently get a different behavior from each backend. The parameters set to the
+	// value that has no effect, e.g. "n" set to 1, are not reported.
+	//
+	// +optional
+	UnsupportedParameterBehavior UnsupportedParameterBehavior `json:"unsupportedParameterBehavior,omitempty"`
+
+	// HistoryPolicy bounds the conversation history of the chat completion requests of this route before they
+	// are sent to the backend, controlling the costs of the chatty clients resending long conversations.
+	//
+	// The history is elided by whole turns, a turn being a user message and the messages following it, from the
+	// oldest one, so that a tool result is never separated from the call of the tool. The system and developer
+	// messages and the last turn are always kept.
+	//
+	// +optional
+	HistoryPolicy *AIGatewayRouteHistoryPolicy `json:"historyPolicy,omitempty"`
+
+	// ReasoningBudget caps the reasoning of the reasoning models, e.g. the OpenAI o-series or the Claude and Gemini
+	// thinking models, on the requests of this route independently of their output tokens, since the reasoning
+	// tokens are usually the dominant cost of these models.
+	//
+	// The reasoning parameters of the requests above the caps are lowered to them before the requests are sent to
+	// the backend. This is experimental and may change in the future versions.
+	//
+	// +optional
+	ReasoningBudget *AIGatewayRouteReasoningBudget `json:"reasoningBudget,omitempty"`
+
+	// ToolCallLoopGuard protects the spend of this route from the agents stuck calling the same function with the
+	// same arguments over and over, which the models sometimes do when a tool keeps returning a result they don't
+	// expect.
+	//
+	// The function calls of the conversation carried by the chat completion requests, or by the responses requests
+	// including the conversations restored by the gateway from their "previous_response_id", are counted. Once the
+	// most recent function call has been made MaxRepetitions times with identical arguments, the request is handled
+	// according to the Action. A user message following the call ends the loop.
+	//
+	// +optional
+	ToolCallLoopGuard *AIGatewayRouteToolCallLoopGuard `json:"toolCallLoopGuard,omitempty"`
+
+	// AllowedEndpoints is the list of the API endpoints served by this route, which limits the blast radius of the
+	// routes serving a single purpose, e.g. an embeddings only route can't be used for the chat completions.
+	//
+	// The requests to the other endpoints are rejected with 404 Not Found before they are translated and sent to
+	// the backend, and the models of this route are not listed by the "/models" endpoint unless it allows Models.
+	// If this field is not set, all the endpoints are allowed.
+	//
+	// +optional
+	// +listType=set
+	// +kubebuilder:validation:MaxItems=8
+	AllowedEndpoints []AIGatewayRouteEndpoint `json:"allowedEndpoints,omitempty"`
+}
+
+// AIGatewayRouteReasoningBudget configures the caps on the reasoning of the requests of an AIGatewayRoute.
+//
+// +kubebuilder:validation:XValidation:rule="has(self.maxReasoningTokens) || has(self.maxEffort)",message="at least one of maxReasoningTokens or maxEffort must be specified"
+// +kubebuilder:validation:XValidation:rule="!has(self.hardStop) || !self.hardStop || has(self.maxReasoningTokens)",message="maxReasoningTokens must be specified with hardStop"
+type AIGatewayRouteReasoningBudget struct {
+	// MaxReasoningTokens is the maximum number of the reasoning tokens of a request. The "budget_tokens" of the
+	// enabled "thinking" of the chat completion and Anthropic messages requests above it is lowered to it.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	MaxReasoningTokens *int32 `json:"maxReasoningTokens,omitempty"`
+
+	// MaxEffort is the maximum reasoning effort of a request. The "reasoning_effort" of the chat completion requests
+	// and the "reasoning.effort" of the responses requests above it are lowered to it.
+	//
+	// +optional
+	MaxEffort ReasoningEffort `json:"maxEffort,omitempty"`
+
+	// HardStop terminates the streaming chat completions once their reasoning exceeds MaxReasoningTokens, for the
+	// backends that don't honor the thinking budget or the models whose reasoning is only bounded by the effort.
+	//
+	// The reasoning tokens are estimated from the reasoning content streamed so far, about four characters per
+	// token, unless the backend reports more in the usage. The stream is terminated with a chunk finishing the
+	// choices with the "length" finish reason, followed by a "reasoning_budget_exceeded" event and the [DONE]
+	// event. The non-streaming requests are not stopped.
+	//
+	// +optional
+	HardStop *bool `json:"hardStop,omitempty"`
+}
+
+// ReasoningEffort is the reasoning effort of the requests to the reasoning models.
+//
+// +kubebuilder:validation:Enum=none;minimal;low;medium;high;xhigh
+type ReasoningEffort string
+
+// AIGatewayRouteToolCallLoopGuard configures the detection of the tool call loops in the conversations of the requests
+// of an AIGatewayRoute.
+type AIGatewayRouteToolCallLoopGuard struct {
+	// MaxRepetitions is the number of the identical calls of a function, i.e. with the same name and arguments, in a
+	// conversation at which it is considered looping.
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Minimum=2
+	MaxRepetitions int32 `json:"maxRepetitions"`
+
+	// Action is what is done with the requests of the looping conversations. Defaults to Reject.
+	//
+	// +optional
+	// +kubebuilder:default=Reject
+	Action ToolCallLoopAction `json:"action,omitempty"`
+}
+
+// ToolCallLoopAction is what is done with the requests of the conversations stuck in a tool call loop.
+//
+// +kubebuilder:validation:Enum=Reject;Hint
+type ToolCallLoopAction string
+
+const (
+	// ToolCallLoopActionReject rejects the requests with 400 Bad Request and an OpenAI "invalid_request_error" of the
+	// "tool_call_loop_detected" code, so that the agent stops rather than paying for another turn of the loop.
+	ToolCallLoopActionReject ToolCallLoopAction = "Reject"
+	// ToolCallLoopActionHint appends a system message to the conversation telling the model that it is repeating
+	// the call, and sets the "x-ai-eg-tool-call-loop" response header to the name of the function.
+	ToolCallLoopActionHint ToolCallLoopAction = "Hint"
+)
+
+// AIGatewayRouteHistoryPolicy configures the bounds of the conversation history of the chat completion requests of
+// an AIGatewayRoute, and how the history beyond them is elided.
+//
+// +kubebuilder:validation:XValidation:rule="has(self.maxTurns) || has(self.maxInputTokens)",message="at least one of maxTurns or maxInputTokens must be specified"
+// +kubebuilder:validation:XValidation:rule="!has(self.strategy) || self.strategy != 'Summarize' || has(self.summarization)",message="summarization must be specified with the Summarize strategy"
+type AIGatewayRouteHistoryPolicy struct {
+	// MaxTurns is the maximum number of the turns of the conversation sent to the backend.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	MaxTurns *int32 `json:"maxTurns,omitempty"`
+
+	// MaxInputTokens is the maximum number of the input tokens of the messages sent to the backend. The tokens are
+	// estimated from the size of the messages, about four bytes per token, since the tokenizers differ between the
+	// models.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	MaxInputTokens *int32 `json:"maxInputTokens,omitempty"`
+
+	// Strategy is how the turns beyond the bounds are elided. Defaults to Truncate.
+	//
+	// +optional
+	// +kubebuilder:default=Truncate
+	Strategy HistoryElisionStrategy `json:"strategy,omitempty"`
+
+	// Summarization configures the model summarizing the elided turns with the Summarize strategy.
+	//
+	// +optional
+	Summarization *HistorySummarization `json:"summarization,omitempty"`
+}
+
+// HistoryElisionStrategy is how the turns of a conversation beyond the bounds of a history policy are elided.
+//
+// +kubebuilder:validation:Enum=Truncate;Summarize
+type HistoryElisionStrategy string
+
+const (
+	// HistoryElisionStrategyTruncate drops the elided turns.
+	HistoryElisionStrategyTruncate HistoryElisionStrategy = "Truncate"
+	// HistoryElisionStrategySummarize replaces the elided turns with a system message summarizing them, keeping
+	// the start and the end of the conversation. The turns are dropped if the summarization fails.
+	HistoryElisionStrategySummarize HistoryElisionStrategy = "Summarize"
+)
+
+// HistorySummarization configures the model summarizing the elided turns of the conversations.
+type HistorySummarization struct {
+	// URL is the URL of the OpenAI compatible chat completions endpoint of the model, e.g.
+	// "http://envoy-default-ai-gateway.envoy-gateway-system.svc/v1/chat/completions" to call a cheap model served
+	// by a route of the gateway, so that the credentials of its backends apply.
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern=`^https?://.+`
+	URL string `json:"url"`
+
+	// Model is the name of the model summarizing the turns.
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Model string `json:"model"`
+
+	// Timeout is the timeout of the summarization of a request, after which the turns are dropped instead.
+	// Defaults to 5s.
+	//
+	// +optional
+	Timeout *gwapiv1.Duration `json:"timeout,omitempty"`
+}
+
+// AIGatewayRouteEndpoint is a group of the API endpoints served by an AIGatewayRoute.
+//
+// +kubebuilder:validation:Enum=Chat;Embeddings;Images;Audio;Moderations;Rerank;Tokenize;Models
+type AIGatewayRouteEndpoint string
+
+const (
+	// AIGatewayRouteEndpointChat is the text generation endpoints: the OpenAI chat completions, completions and
+	// responses, and the Anthropic messages including the token counting.
+	AIGatewayRouteEndpointChat AIGatewayRouteEndpoint = "Chat"
+	// AIGatewayRouteEndpointEmbeddings is the OpenAI embeddings endpoint.
+	AIGatewayRouteEndpointEmbeddings AIGatewayRouteEndpoint = "Embeddings"
+	// AIGatewayRouteEndpointImages is the OpenAI image generation endpoint.
+	AIGatewayRouteEndpointImages AIGatewayRouteEndpoint = "Images"
+	// AIGatewayRouteEndpointAudio is the OpenAI speech, transcription and translation endpoints.
+	AIGatewayRouteEndpointAudio AIGatewayRouteEndpoint = "Audio"
+	// AIGatewayRouteEndpointModerations is the OpenAI moderations endpoint.
+	AIGatewayRouteEndpointModerations AIGatewayRouteEndpoint = "Moderations"
+	// AIGatewayRouteEndpointRerank is the Cohere rerank endpoints.
+	AIGatewayRouteEndpointRerank AIGatewayRouteEndpoint = "Rerank"
+	// AIGatewayRouteEndpointTokenize is the tokenize endpoint.
+	AIGatewayRouteEndpointTokenize AIGatewayRouteEndpoint = "Tokenize"
+	// AIGatewayRouteEndpointModels is the listing of the models of the route by the OpenAI models endpoint.
+	AIGatewayRouteEndpointModels AIGatewayRouteEndpoint = "Models"
+)
+
+// UnsupportedParameterBehavior is the behavior of an AIGatewayRoute on the request parameters that the selected
+// backend cannot honor.
+//
+// +kubebuilder:validation:Enum=Drop;Warn;Error
+type UnsupportedParameterBehavior string
+
+const (
+	// UnsupportedParameterBehaviorDrop silently drops the parameters.
+	UnsupportedParameterBehaviorDrop UnsupportedParameterBehavior = "Drop"
+	// UnsupportedParameterBehaviorWarn drops the parameters and lists them in the response headers.
+	UnsupportedParameterBehaviorWarn UnsupportedParameterBehavior = "Warn"
+	// UnsupportedParameterBehaviorError rejects the requests with the parameters.
+	UnsupportedParameterBehaviorError UnsupportedParameterBehavior = "Error"
+)
+
+// AIGatewayRouteBackendOverride configures the key verifying the signatures of the requests pinning a backend.
+type AIGatewayRouteBackendOverride struct {
+	// SecretRef is the reference to the Secret in the namespace of the route holding the HMAC key.
+	// ai-gateway must be given the permission to read this secret.
+	// The key of the secret should be "hmacKey".
+	//
+	// +kubebuilder:validation:Required
+	SecretRef *gwapiv1.SecretObjectReference `json:"secretRef"`
+}
+
+// AIGatewayRouteParameterOverrides configures the sampling parameters that the callers of an AIGatewayRoute can
+// override with request headers, and their bounds.
+//
+// +kubebuilder:validation:XValidation:rule="has(self.temperature) || has(self.maxTokens)",message="at least one of temperature or maxTokens must be specified"
+type AIGatewayRouteParameterOverrides struct {
+	// Temperature allows overriding the temperature of the requests with the "x-aigw-param-temperature" header.
+	//
+	// +optional
+	Temperature *AIGatewayRouteTemperatureBounds `json:"temperature,omitempty"`
+
+	// MaxTokens allows overriding the maximum number of the output tokens of the requests with the
+	// "x-aigw-param-max-tokens" header. This sets the "max_completion_tokens" of the chat completions requests
+	// that use it instead of "max_tokens", the "max_output_tokens" of the responses requests, and "max_tokens"
+	// otherwise.
+	//
+	// +optional
+	MaxTokens *AIGatewayRouteMaxTokensBounds `json:"maxTokens,omitempty"`
+}
+
+// AIGatewayRouteTemperatureBounds are the inclusive bounds of the temperature overridden by the callers.
+//
+// +kubebuilder:validation:XValidation:rule="!has(self.min) || double(self.min) <= double(self.max)",message="min must be less than or equal to max"
+type AIGatewayRouteTemperatureBounds struct {
+	// Min is the minimum temperature as a decimal number, e.g. "0.2". Defaults to "0".
+	//
+	// +optional
+	// +kubebuilder:validation:Pattern=`^[0-9]+(\.[0-9]+)?$`
+	Min string `json:"min,omitempty"`
+
+	// Max is the maximum temperature as a decimal number, e.g. "1.5".
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern=`^[0-9]+(\.[0-9]+)?$`
+	Max string `json:"max"`
+}
+
+// AIGatewayRouteMaxTokensBounds are the inclusive bounds of the maximum number of the output tokens overridden
+// by the callers.
+//
+// +kubebuilder:validation:XValidation:rule="!has(self.min) || self.min <= self.max",message="min must be less than or equal to max"
+type AIGatewayRouteMaxTokensBounds struct {
+	// Min is the minimum number of the output tokens. Defaults to 1.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	Min *int32 `json:"min,omitempty"`
+
+	// Max is the maximum number of the output tokens.
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Minimum=1
+	Max int32 `json:"max"`
+}
+
+// AIGatewayRouteModelVisibility restricts the listing of the models of an AIGatewayRoute to a set of tenants.
+type AIGatewayRouteModelVisibility struct {
+	// TenantHeader is the name of the request header carrying the tenant of the caller, e.g. "x-tenant-id".
+	// The models are hidden from the requests without this header.
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	TenantHeader string `json:"tenantHeader"`
+
+	// AllowedTenants are the tenants to which the models of this route are listed.
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=64
+	AllowedTenants []string `json:"allowedTenants"`
+}
+
+// AIGatewayRouteHeaderLimits configures the limits on the HTTP headers of the traffic of an AIGatewayRoute.
+//
+// +kubebuilder:validation:XValidation:rule="has(self.maxRequestHeadersKiB) || has(self.maxHeadersCount)",message="at least one of maxRequestHeadersKiB or maxHeadersCount must be specified"
+type AIGatewayRouteHeaderLimits struct {
+	// MaxRequestHeadersKiB is the maximum total size of the request headers in KiB. Requests exceeding the limit
+	// are rejected with 431 Request Header Fields Too Large.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=8192
+	MaxRequestHeadersKiB *int32 `json:"maxRequestHeadersKiB,omitempty"`
+
+	// MaxHeadersCount is the maximum number of the headers of the requests from the clients, and of the
+	// responses from the backends of this route.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	MaxHeadersCount *int32 `json:"maxHeadersCount,omitempty"`
+}
+
+// AIGatewayRouteExperiment is an A/B experiment splitting the requests of an AIGatewayRoute into variants.
+//
+// +kubebuilder:validation:XValidation:rule="self.variants.map(v, v.percentage).sum() == 100", message="the percentages of the variants must sum to 100"
+// +kubebuilder:validation:XValidation:rule="self.variants.all(v1, self.variants.exists_one(v2, v1.name == v2.name))", message="variant names must be unique"
+type AIGatewayRouteExperiment struct {
+	// Name is the name of the experiment. The requests are assigned independently for each experiment name,
+	// so renaming the experiment reshuffles the assignment.
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=63
+	Name string `json:"name"`
+	// StickyHeader is the name of the request header identifying the user, e.g. "x-user-id". The requests with
+	// the same value of this header are assigned the same variant. The requests without this header are
+	// assigned randomly.
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	StickyHeader string `json:"stickyHeader"`
+	// Variants is the list of the variants of the experiment.
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinItems=2
+	// +kubebuilder:validation:MaxItems=16
+	Variants []AIGatewayRouteExperimentVariant `json:"variants"`
+}
+
+// AIGatewayRouteExperimentVariant is a variant of an AIGatewayRouteExperiment.
+type AIGatewayRouteExperimentVariant struct {
+	// Name is the name of the variant reported in the response header, the dynamic metadata and the telemetry.
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=63
+	Name string `json:"name"`
+	// Percentage is the percentage of the users assigned to this variant.
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	Percentage int32 `json:"percentage"`
+	// ModelNameOverride is the name of the model sent to the backend for the requests assigned to this variant.
+	// This takes precedence over the ModelNameOverride of the backend reference. When empty, the requests of
+	// this variant are sent as without the experiment.
+	//
+	// +optional
+	ModelNameOverride string `json:"modelNameOverride,omitempty"`
+}
+
+// AIGatewayRoutePostProcessing configures the filter attached to the routes generated from an AIGatewayRoute.
+type AIGatewayRoutePostProcessing struct {
+	// Lua is the Lua script run by the Envoy Lua filter on the requests and responses of the route.
+	//
+	// The filter runs after the AI Gateway filter: the requests carry the "x-ai-eg-model" header, and the
+	// responses are the ones sent to the client, i.e. already translated into the schema of the client.
+	//
+	// +kubebuilder:validation:Required
+	Lua *AIGatewayRouteLuaScript `json:"lua"`
+}
+
+// AIGatewayRouteLuaScript is a Lua script run by the Envoy Lua filter.
+type AIGatewayRouteLuaScript struct {
+	// InlineCode is the source code of the Lua script. It defines the "envoy_on_request" and/or
+	// "envoy_on_response" functions as described in
+	// https://www.envoyproxy.io/docs/envoy/latest/configuration/http/http_filters/lua_filter
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=65536
+	InlineCode string `json:"inlineCode"`
 }
 
 // AIGatewayRouteRule is a rule that defines the routing behavior of the AIGatewayRoute.
@@ -213,6 +687,7 @@ type AIGatewayRouteSpec struct {
 // +kubebuilder:validation:XValidation:rule="!has(self.name) || self.name != 'route-not-found'", message="rule name route-not-found is reserved"
 // +kubebuilder:validation:XValidation:rule="!has(self.backendRefs) || size(self.backendRefs) == 0 || (self.backendRefs.all(ref, !has(ref.group) && !has(ref.kind)) || self.backendRefs.all(ref, has(ref.group) && has(ref.kind)))", message="cannot mix InferencePool and AIServiceBackend references in the same rule"
 // +kubebuilder:validation:XValidation:rule="!has(self.backendRefs) || size(self.backendRefs) == 0 || !self.backendRefs.exists(ref, has(ref.group) && has(ref.kind)) || size(self.backendRefs) == 1", message="only one InferencePool backend is allowed per rule"
+// +kubebuilder:validation:XValidation:rule="!has(self.migration) || !has(self.backendRefs) || !self.backendRefs.exists(ref, has(ref.group) && has(ref.kind))", message="migration is not supported for InferencePool backends"
 type AIGatewayRouteRule struct {
 	// Name is the name of the route rule. This name must be unique within the route.
 	// When specified, it is copied to the generated HTTPRoute rule name.
@@ -280,6 +755,21 @@ type AIGatewayRouteRule struct {
 	// +optional
 	StreamIdleTimeout *gwapiv1.Duration `json:"streamIdleTimeout,omitempty"`
 
+	// StreamStallTimeout is the maximum time the streaming chat completions of this rule wait for the next token
+	// from the backend. Unlike StreamIdleTimeout, the events without any token, e.g. the keep-alive pings or the
+	// empty chunks, don't reset it, so a backend keeping the connection alive without generating is also detected.
+	//
+	// A stalled stream is terminated with a chunk finishing the choices with the "length" finish reason, followed by
+	// a "stream_stalled" event and the [DONE] event, and counted by the gen_ai.response.truncated metric with the
+	// "stalled" reason. When the backend sends nothing at all, no chunk reaches the gateway to be finished, so the AI
+	// Gateway extension server also bounds the route.retry_policy.per_try_idle_timeout of every xDS route generated
+	// from this rule to this value. Envoy then resets the stream, which is still counted as stalled.
+	//
+	// If this field is not set, the streams are only bounded by StreamIdleTimeout and Timeouts.Request.
+	//
+	// +optional
+	StreamStallTimeout *gwapiv1.Duration `json:"streamStallTimeout,omitempty"`
+
 	// ModelsOwnedBy represents the owner of the running models serving by the backends,
 	// which will be exported as the field of "OwnedBy" in openai-compatible API "/models".
 	//
@@ -306,6 +796,141 @@ type AIGatewayRouteRule struct {
 	// +optional
 	// +kubebuilder:validation:Format=date-time
 	ModelsCreatedAt *metav1.Time `json:"modelsCreatedAt,omitempty"`
+
+	// ModelsContextWindows is the context windows in tokens of the running models serving by the backends, which
+	// are kept in the model catalog of the gateway along with ModelsOwnedBy and ModelsCreatedAt.
+	//
+	// Each entry applies to the model of the same name sent to a backend of this rule, i.e. the ModelNameOverride
+	// of the backend reference or of the experiment variant if set, and otherwise the model of the "x-ai-eg-model"
+	// header matching of this rule. The entries of the other models are ignored. The requests whose input tokens
+	// estimated by the gateway exceed the context window of the model sent to the selected backend are rejected
+	// with a 400 error whose code is "context_length_exceeded", as OpenAI does, before they are sent. The estimate
+	// counts the messages, the prompt or the input of the request depending on the endpoint.
+	//
+	// When the rule falls back to a backend with a ModelNameOverride to a larger-context model, the context window
+	// of the larger model applies to the requests sent to it. The requests for the models without an entry are not
+	// checked.
+	//
+	// +optional
+	// +listType=map
+	// +listMapKey=model
+	// +kubebuilder:validation:MaxItems=128
+	ModelsContextWindows []AIGatewayRouteRuleModelContextWindow `json:"modelsContextWindows,omitempty"`
+
+	// ContextLengthRetry retries once the requests rejected by a backend because the prompt exceeds the context
+	// length of the model, either on the fallback backend of this rule serving a larger-context model, or with the
+	// oldest messages of the conversation dropped.
+	//
+	// The context length errors are detected from the error responses of the backends, and the AI Gateway extension
+	// server adds the retry on them to the retry policy of every xDS route generated from this rule. The retried
+	// requests have the "x-ai-eg-context-length-retry" response header set to the strategy, and an event recorded
+	// on their span.
+	//
+	// +optional
+	ContextLengthRetry *AIGatewayRouteRuleContextLengthRetry `json:"contextLengthRetry,omitempty"`
+
+	// Migration compares the backends of this rule with another backend before switching the rule to it, e.g. from
+	// one provider to another. A sample of the requests of this rule is also sent to the other backend, and its
+	// responses are compared with the ones returned to the clients for their similarity and their latency. The
+	// clients only ever receive the responses of the backends of this rule.
+	//
+	// Only the non-streaming chat completion requests are sampled. Once the response of a sampled request is
+	// returned to the client, the request is sent again through the gateway with the "x-ai-eg-migration-rule"
+	// request header, which a rule of the generated HTTPRoute matches to route it to the other backend. The
+	// comparison is reported in the Migrations of the status of the route.
+	//
+	// +optional
+	Migration *AIGatewayRouteRuleMigration `json:"migration,omitempty"`
+
+	// FailoverRetry re-dispatches the requests failing mid-flight, e.g. while a provider or a backend version is
+	// rolled out, to another backend of this rule, so that the maintenance windows are nearly invisible to the clients.
+	//
+	// Only the failures occurring before any byte of the response is sent to the client are retried: the connection
+	// failures, the resets before the response headers, and the 502 and 503 responses, which are held back by the
+	// gateway. This covers a non-streaming request until its backend responds, but a streaming request whose response
+	// has started is never retried, since the client already received a part of it.
+	//
+	// Nothing is persisted. The request body is only kept in the memory of the gateway until the request completes,
+	// and each attempt is translated again for the backend it is sent to, so the requests in flight on a gateway
+	// that restarts are not re-dispatched.
+	//
+	// The AI Gateway extension server adds the retry on these failures to the retry policy of every xDS route
+	// generated from this rule. The retries skip the hosts and the priorities already attempted, so that a rule with
+	// the new version of a backend at a lower priority fails over to it. The re-dispatched requests have the
+	// "x-ai-eg-failover-retry" response header set to the number of their retries.
+	//
+	// +optional
+	FailoverRetry *AIGatewayRouteRuleFailoverRetry `json:"failoverRetry,omitempty"`
+}
+
+// AIGatewayRouteRuleMigration configures the comparison of the backends of a rule with the backend it is migrated
+// to.
+//
+// +kubebuilder:validation:XValidation:rule="!has(self.backendRef.group) && !has(self.backendRef.kind)", message="only AIServiceBackend references are supported"
+type AIGatewayRouteRuleMigration struct {
+	// BackendRef is the AIServiceBackend the rule is migrated to. Its weight and priority are ignored.
+	//
+	// +kubebuilder:validation:Required
+	BackendRef AIGatewayRouteRuleBackendRef `json:"backendRef"`
+
+	// SamplePercent is the percentage of the requests of the rule also sent to the migration backend.
+	// Defaults to 10.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	// +kubebuilder:default=10
+	SamplePercent *int32 `json:"samplePercent,omitempty"`
+}
+
+// ContextLengthRetryStrategy is how a request exceeding the context length of the model is retried.
+//
+// +kubebuilder:validation:Enum=Fallback;DropOldestMessages
+type ContextLengthRetryStrategy string
+
+const (
+	// ContextLengthRetryStrategyFallback retries the request on the backend of the next priority of the rule, e.g.
+	// a backend with a ModelNameOverride to a larger-context model.
+	ContextLengthRetryStrategyFallback ContextLengthRetryStrategy = "Fallback"
+	// ContextLengthRetryStrategyDropOldestMessages retries the chat completion request on the same backend with the
+	// oldest half of the messages dropped. The system and developer messages and the last message are kept. The
+	// requests to the other endpoints are not retried.
+	ContextLengthRetryStrategyDropOldestMessages ContextLengthRetryStrategy = "DropOldestMessages"
+)
+
+// AIGatewayRouteRuleModelContextWindow is the context window of a model of an AIGatewayRouteRule.
+type AIGatewayRouteRuleModelContextWindow struct {
+	// Model is the name of the model, i.e. the value of an "x-ai-eg-model" header match of the rule.
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Model string `json:"model"`
+	// Tokens is the context window of the model in tokens.
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Minimum=1
+	Tokens int32 `json:"tokens"`
+}
+
+// AIGatewayRouteRuleContextLengthRetry configures the retry of the requests exceeding the context length of the
+// model.
+type AIGatewayRouteRuleContextLengthRetry struct {
+	// Strategy is how the request is retried.
+	//
+	// +kubebuilder:validation:Required
+	Strategy ContextLengthRetryStrategy `json:"strategy"`
+}
+
+// AIGatewayRouteRuleFailoverRetry configures the re-dispatch of the requests failing mid-flight to another backend.
+type AIGatewayRouteRuleFailoverRetry struct {
+	// MaxRetries is the retry budget of a request, i.e. the maximum number of times it is re-dispatched. The retry
+	// budget of the retry policy of the route, e.g. configured with a BackendTrafficPolicy, is kept if larger.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=10
+	// +kubebuilder:default=2
+	MaxRetries *int32 `json:"maxRetries,omitempty"`
 }
 
 // AIGatewayRouteRuleBackendRef is a reference to a backend with a weight.
@@ -57,8 +57,55 @@ type QuotaPolicySpec struct {
 	// +kubebuilder:validation:MaxItems=128
 	// +optional
 	PerModelQuotas []PerModelQuota `json:"perModelQuotas,omitempty"`
+	// Mode determines whether the quotas of this policy are enforced.
+	// In the "Enforce" mode a response with 429 HTTP status code is sent back to the client when the
+	// quota is exceeded.
+	// In the "Shadow" mode all quotas of this policy run in shadow mode as if "ShadowMode" was set on
+	// every bucket: the quota checks are performed and the rate limit service reports the requests that
+	// would have been limited in its shadow mode statistics, but the requests always succeed and the
+	// streaming responses are never cut off. This allows validating the quota sizing before enforcing it.
+	// Defaults to "Enforce".
+	//
+	// +optional
+	// +kubebuilder:default=Enforce
+	Mode QuotaPolicyMode `json:"mode,omitempty"`
+	// LocalFallback configures the rate limiting applied by each gateway pod on its own while the quota rate limit
+	// service is unreachable. When unset, the requests are not limited by this policy during an outage of the
+	// rate limit service, unless the controller is configured to deny them.
+	//
+	// When set, the gateway pods probe the health of the rate limit service, and during an outage enforce the
+	// "DefaultBucket" of each of the "PerModelQuotas" with a local token bucket. The "BucketRules" are not
+	// enforced during an outage. This is ignored in the "Shadow" mode.
+	//
+	// +optional
+	LocalFallback *QuotaLocalFallback `json:"localFallback,omitempty"`
+}
+
+// QuotaLocalFallback configures the local rate limiting applied while the quota rate limit service is unreachable.
+type QuotaLocalFallback struct {
+	// Replicas is the number of gateway pods sharing the quotas. Since the pods don't share their counters
+	// during an outage, each pod enforces the limits divided by this number, rounded up, to approximate the
+	// quotas of the whole gateway.
+	// Defaults to 1.
+	//
+	// +optional
+	// +kubebuilder:default=1
+	// +kubebuilder:validation:Minimum=1
+	Replicas int32 `json:"replicas,omitempty"`
 }
 
+// QuotaPolicyMode specifies whether the quotas of a QuotaPolicy are enforced.
+//
+// +kubebuilder:validation:Enum=Enforce;Shadow
+type QuotaPolicyMode string
+
+const (
+	// QuotaPolicyModeEnforce rejects the requests exceeding the quota.
+	QuotaPolicyModeEnforce QuotaPolicyMode = "Enforce"
+	// QuotaPolicyModeShadow only reports the requests that would have exceeded the quota.
+	QuotaPolicyModeShadow QuotaPolicyMode = "Shadow"
+)
+
 type ServiceQuotaDefinition struct {
 	// CostExpression specifies a CEL expression for computing the quota burndown of the LLM-related request.
 	// If no expression is specified the "total_tokens" value is used.
@@ -121,8 +168,88 @@ type QuotaDefinition struct {
 	//
 	// +optional
 	BucketRules []QuotaRule `json:"bucketRules,omitempty"`
+	// StreamingMode determines how the quota is enforced on streaming chat completion responses.
+	// In the "Overrun" mode the stream is always delivered in full and its cost is charged once it
+	// completes, which may take the remaining quota below zero.
+	// In the "CutOff" mode the gateway terminates the stream as soon as its cost reaches the remaining
+	// quota reported by the rate limit service. The client then receives a final chunk with the
+	// "length" finish reason followed by a "quota_exceeded" event, and only the remaining quota is charged.
+	// The "CutOff" mode requires all the buckets of the model in the QuotaPolicy to count the same unit other
+	// than "Requests", since the rate limit service reports the remaining quota of the most restrictive bucket.
+	// Defaults to "Overrun".
+	//
+	// +optional
+	// +kubebuilder:default=Overrun
+	StreamingMode QuotaStreamingMode `json:"streamingMode,omitempty"`
+	// Schedules vary the limit of the "DefaultBucket" with the time of the request, e.g. to allow more
+	// traffic off-peak than during business hours. While the window of a schedule is active, its limit and
+	// duration replace those of the "DefaultBucket", which applies outside of every window. When the windows
+	// of several schedules overlap, the first one in the list takes precedence.
+	//
+	// Each window is counted separately, so the quota consumed before a window becomes active isn't charged
+	// to it. The "LocalFallback" of the QuotaPolicy enforces the "DefaultBucket" regardless of the schedules.
+	//
+	// +optional
+	// +kubebuilder:validation:MaxItems=16
+	// +listType=map
+	// +listMapKey=name
+	Schedules []QuotaSchedule `json:"schedules,omitempty"`
 }
 
+// QuotaSchedule is a recurring window of time during which a different limit applies to the default bucket.
+type QuotaSchedule struct {
+	// Name identifies the window in the rate limit descriptors. Renaming a schedule resets its counters.
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Name string `json:"name"`
+	// Days are the days of the week on which the window starts. Empty means every day.
+	//
+	// +optional
+	// +kubebuilder:validation:MaxItems=7
+	Days []QuotaScheduleDay `json:"days,omitempty"`
+	// Start is the time of the day at which the window starts, inclusive, in the "HH:MM" 24-hour format.
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern=`^([01][0-9]|2[0-3]):[0-5][0-9]$`
+	Start string `json:"start"`
+	// End is the time of the day at which the window ends, exclusive, in the "HH:MM" 24-hour format.
+	// A window whose end isn't after its start spans midnight and ends on the next day, so "22:00" to
+	// "06:00" covers the night and an equal start and end covers the whole day.
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern=`^([01][0-9]|2[0-3]):[0-5][0-9]$`
+	End string `json:"end"`
+	// TimeZone is the IANA time zone of the days and the times of the window, e.g. "America/New_York".
+	// Defaults to "UTC".
+	//
+	// +optional
+	TimeZone string `json:"timeZone,omitempty"`
+	// Limit is the limit of the default bucket during the window, counted in the unit of the "DefaultBucket".
+	Limit uint `json:"limit"`
+	// Time window of the limit. Must be exactly one of: "1s" (1 second), "1m" (1 minute), "1h" (1 hour), or "1d" (1 day).
+	//
+	// +kubebuilder:validation:Enum="1s";"1m";"1h";"1d"
+	Duration string `json:"duration"`
+}
+
+// QuotaScheduleDay is a day of the week.
+//
+// +kubebuilder:validation:Enum=Monday;Tuesday;Wednesday;Thursday;Friday;Saturday;Sunday
+type QuotaScheduleDay string
+
+const (
+	QuotaScheduleDayMonday    QuotaScheduleDay = "Monday"
+	QuotaScheduleDayTuesday   QuotaScheduleDay = "Tuesday"
+	QuotaScheduleDayWednesday QuotaScheduleDay = "Wednesday"
+	QuotaScheduleDayThursday  QuotaScheduleDay = "Thursday"
+	QuotaScheduleDayFriday    QuotaScheduleDay = "Friday"
+	QuotaScheduleDaySaturday  QuotaScheduleDay = "Saturday"
+	QuotaScheduleDaySunday    QuotaScheduleDay = "Sunday"
+)
+
 // QuotaBucketMode specifies whether the default and per request buckets values are exclusive or inclusive.
 //
 // TODO: Add Exclusive mode in the future.
@@ -133,6 +260,18 @@ const (
 	QuotaBucketModeShared QuotaBucketMode = "Shared"
 )
 
+// QuotaStreamingMode specifies how the quota is enforced on streaming responses.
+//
+// +kubebuilder:validation:Enum=Overrun;CutOff
+type QuotaStreamingMode string
+
+const (
+	// QuotaStreamingModeOverrun delivers streams in full and charges their cost once they complete.
+	QuotaStreamingModeOverrun QuotaStreamingMode = "Overrun"
+	// QuotaStreamingModeCutOff terminates streams once their cost reaches the remaining quota.
+	QuotaStreamingModeCutOff QuotaStreamingMode = "CutOff"
+)
+
 type QuotaRule struct {
 	// ClientSelectors holds the list of conditions to select
 	// specific clients using attributes from the traffic flow.
@@ -169,8 +308,37 @@ type QuotaValue struct {
 	//
 	// +kubebuilder:validation:Enum="1s";"1m";"1h";"1d"
 	Duration string `json:"duration"`
+	// Unit specifies what the limit counts.
+	// The "Cost" unit counts the cost computed with the "CostExpression", while the other units count
+	// the requests or the tokens regardless of the "CostExpression".
+	//
+	// The limits of different units are enforced independently, so the requests per minute and the tokens
+	// per minute of a model can be limited together by specifying a PerModelQuota per unit for the same model.
+	// Defaults to "Cost".
+	//
+	// +optional
+	// +kubebuilder:default=Cost
+	Unit QuotaUnit `json:"unit,omitempty"`
 }
 
+// QuotaUnit specifies what a quota limit counts.
+//
+// +kubebuilder:validation:Enum=Requests;InputTokens;OutputTokens;TotalTokens;Cost
+type QuotaUnit string
+
+const (
+	// QuotaUnitRequests counts the requests. A request is counted when it is admitted.
+	QuotaUnitRequests QuotaUnit = "Requests"
+	// QuotaUnitInputTokens counts the input tokens of the completed requests.
+	QuotaUnitInputTokens QuotaUnit = "InputTokens"
+	// QuotaUnitOutputTokens counts the output tokens of the completed requests.
+	QuotaUnitOutputTokens QuotaUnit = "OutputTokens"
+	// QuotaUnitTotalTokens counts the total tokens of the completed requests.
+	QuotaUnitTotalTokens QuotaUnit = "TotalTokens"
+	// QuotaUnitCost counts the cost of the completed requests computed with the CostExpression.
+	QuotaUnitCost QuotaUnit = "Cost"
+)
+
 // QuotaPolicyList contains a list of QuotaPolicy
 //
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
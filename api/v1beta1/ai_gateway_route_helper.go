@@ -24,10 +24,26 @@ const (
 
 // GetStreamIdleTimeout returns the configured stream idle timeout for this rule, or zero when not configured.
 func (r *AIGatewayRouteRule) GetStreamIdleTimeout() time.Duration {
-	if r == nil || r.StreamIdleTimeout == nil {
+	if r == nil {
+		return 0
+	}
+	return positiveDuration(r.StreamIdleTimeout)
+}
+
+// GetStreamStallTimeout returns the configured stream stall timeout for this rule, or zero when not configured.
+func (r *AIGatewayRouteRule) GetStreamStallTimeout() time.Duration {
+	if r == nil {
+		return 0
+	}
+	return positiveDuration(r.StreamStallTimeout)
+}
+
+// positiveDuration returns the parsed duration, or zero when it is unset, malformed or not positive.
+func positiveDuration(duration *gwapiv1.Duration) time.Duration {
+	if duration == nil {
 		return 0
 	}
-	d, err := time.ParseDuration(string(*r.StreamIdleTimeout))
+	d, err := time.ParseDuration(string(*duration))
 	if err != nil || d <= 0 {
 		return 0
 	}
@@ -45,6 +45,13 @@ func TestAIGatewayRouteRule_GetStreamIdleTimeout(t *testing.T) {
 	}
 }
 
+func TestAIGatewayRouteRule_GetStreamStallTimeout(t *testing.T) {
+	require.Zero(t, (*AIGatewayRouteRule)(nil).GetStreamStallTimeout())
+	require.Zero(t, (&AIGatewayRouteRule{}).GetStreamStallTimeout())
+	require.Zero(t, (&AIGatewayRouteRule{StreamStallTimeout: ptr.To(gwapiv1.Duration("0s"))}).GetStreamStallTimeout())
+	require.Equal(t, 30*time.Second, (&AIGatewayRouteRule{StreamStallTimeout: ptr.To(gwapiv1.Duration("30s"))}).GetStreamStallTimeout())
+}
+
 func TestAIGatewayRouteRule_GetTimeoutsWithDefaults(t *testing.T) {
 	tests := []struct {
 		name     string
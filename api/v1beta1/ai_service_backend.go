@@ -76,6 +76,382 @@ type AIServiceBackendSpec struct {
 	// +optional
 	BodyMutation *HTTPBodyMutation `json:"bodyMutation,omitempty"`
 
+	// PIITokenization enables the reversible tokenization of personally identifiable information (PII)
+	// in the requests sent to this backend.
+	//
+	// When configured, the detected PII in the JSON string values of the request body is replaced with
+	// stable placeholders such as "[PII_EMAIL_1]" before the request is sent to the backend, and the
+	// placeholders found in the response body are restored to the original values before the response
+	// is returned to the client. The mapping between the placeholders and the original values is kept
+	// only in the memory of the request and is never persisted nor logged.
+	//
+	// For streaming responses, the placeholders split across the deltas of consecutive events are restored
+	// as well: an event ending with the beginning of a placeholder is delayed until the next event.
+	//
+	// +optional
+	PIITokenization *PIITokenization `json:"piiTokenization,omitempty"`
+
+	// VLLMExtensions specifies how the vLLM extensions to the OpenAI API in the request body are handled for
+	// this backend. The extensions are the guided decoding fields "guided_json", "guided_regex",
+	// "guided_choice" and "guided_grammar", as well as "best_of" and "use_beam_search".
+	//
+	// Set this to "Strip" for the OpenAI-compatible backends that reject or misinterpret these fields, e.g.
+	// api.openai.com or Azure OpenAI, while the same route serves the self-hosted vLLM backends with
+	// "Passthrough". Note that "Strip" also removes "best_of" from the legacy completions requests.
+	//
+	// This only applies to the OpenAI and AzureOpenAI schemas: the translators for the other schemas never
+	// forward these fields. The GCPVertexAI translator emulates "guided_json", "guided_choice" and "guided_regex"
+	// with the Gemini response schema, i.e. as the JSON schema, the enum and the pattern of the response, and
+	// ignores "guided_grammar", "best_of" and "use_beam_search" since Gemini has no equivalent.
+	//
+	// Defaults to "Passthrough".
+	//
+	// +optional
+	// +kubebuilder:default=Passthrough
+	VLLMExtensions VLLMExtensionsPolicy `json:"vllmExtensions,omitempty"`
+
+	// ExtraBody configures the extra fields of the request bodies that this backend accepts, following the
+	// "extra_body" convention of the OpenAI SDKs: the top-level fields of a request body that are not part of the
+	// API of its endpoint, e.g. the vendor-specific sampling parameters such as "top_k" or "repetition_penalty".
+	//
+	// When configured, the extra fields in the AllowedFields are sent to this backend as-is, including to the
+	// backends whose schema the request is translated to, and the other extra fields are handled according to the
+	// Policy. When unset, the extra fields are forwarded to the backends with the OpenAI and AzureOpenAI schemas
+	// when the request body is not modified, and dropped by the translation to the other schemas.
+	//
+	// The multipart request bodies, e.g. of the audio transcriptions, are not affected.
+	//
+	// +optional
+	ExtraBody *BackendExtraBody `json:"extraBody,omitempty"`
+
+	// RequestCompression specifies how the compressed request bodies are sent to this backend when they are
+	// modified by the gateway, e.g. translated to the schema of this backend. The request bodies compressed by
+	// the clients with the "gzip" or "deflate" content encoding are decompressed for the translation, and a request
+	// body that is not modified is always sent as compressed by the client.
+	//
+	// Set this to "Recompress" for the backends accepting the compressed request bodies, which compresses the
+	// modified request body again with the content encoding of the client. With "Decompress", the modified request
+	// body is sent uncompressed without the content-encoding header.
+	//
+	// Defaults to "Decompress".
+	//
+	// +optional
+	// +kubebuilder:default=Decompress
+	RequestCompression RequestCompressionPolicy `json:"requestCompression,omitempty"`
+
+	// TraceContextPropagation specifies how the trace context of the requests is propagated to this backend when
+	// tracing is enabled.
+	//
+	// With "TraceContext", the W3C "traceparent" and "tracestate" headers are sent to the backend. "ProviderHeaders"
+	// additionally sets the correlation header of the provider derived from the trace context: "x-amzn-trace-id"
+	// for the AWSBedrock and AWSAnthropic schemas, "x-cloud-trace-context" for the GCPVertexAI and GCPAnthropic
+	// schemas, "x-client-request-id" for the OpenAI schema and "x-ms-client-request-id" for the AzureOpenAI schema.
+	// "None" removes the trace context headers, e.g. for the third-party providers that must not see the trace IDs.
+	//
+	// Regardless of this setting, the request IDs returned by the provider in the "x-request-id", "request-id",
+	// "x-amzn-requestid" and "apim-request-id" response headers are recorded on the span of the request.
+	//
+	// Defaults to "TraceContext".
+	//
+	// +optional
+	// +kubebuilder:default=TraceContext
+	TraceContextPropagation TraceContextPropagationPolicy `json:"traceContextPropagation,omitempty"`
+
+	// OutlierDetection configures the passive health checking of the endpoints of this backend. The endpoints
+	// returning consecutive 5xx responses or failing to connect are ejected from the load balancing for a while,
+	// so that a failing provider endpoint doesn't stay in rotation until a manual intervention.
+	//
+	// The AI Gateway extension server sets the outlier detection on the clusters generated for the AIGatewayRoute
+	// rules referencing this backend. When a cluster holds several backends of a rule, the outlier detection of
+	// the first of them configuring it applies to the whole cluster. The outlier detection configured by an
+	// Envoy Gateway BackendTrafficPolicy takes precedence.
+	//
+	// +optional
+	OutlierDetection *BackendOutlierDetection `json:"outlierDetection,omitempty"`
+
+	// Retry configures the retries of the requests failed on this backend, which are sent to the other endpoints
+	// of the rule when the failing endpoint is ejected by the OutlierDetection or has a lower priority.
+	//
+	// The AI Gateway extension server sets the retry policy on the routes generated for the AIGatewayRoute rules
+	// referencing this backend. Since the retry policy applies to a whole rule, a rule uses the largest number of
+	// retries and per-try timeout and all the status codes of its backends configuring Retry. The retry policy
+	// configured by an Envoy Gateway BackendTrafficPolicy takes precedence.
+	//
+	// +optional
+	Retry *BackendRetry `json:"retry,omitempty"`
+
+	// Timeouts splits the deadline of each attempt of the requests to this backend into the budgets of its
+	// phases, so that a slow connection to the provider is told apart from a slow generation. The requests
+	// exceeding one of them fail with a 504 error whose code names the phase, e.g. "first_byte_timeout", and the
+	// duration of the phases is recorded in the "gen_ai.server.phase.duration" histogram.
+	//
+	// The AI Gateway extension server sets the connect timeout on the clusters generated for the AIGatewayRoute
+	// rules referencing this backend, and the other timeouts on their routes. The timeouts are merged like the
+	// Retry: a cluster holding several backends uses the connect timeout of the first of them configuring it, and a
+	// rule uses the largest first byte and completion timeouts of its backends.
+	//
+	// +optional
+	Timeouts *BackendTimeouts `json:"timeouts,omitempty"`
+
+	// LoadReporting configures the load reported by the self-hosted inference servers of this backend, e.g. vLLM
+	// or TGI, in the headers of their responses. The last report of the backend is kept for the ReportTTL, and the
+	// attempts of the requests to the backend are shed with a 503 response while it exceeds one of the thresholds,
+	// instead of waiting in the queue of an overloaded server.
+	//
+	// The AI Gateway extension server adds the retry of the shed attempts to the retry policy of the routes
+	// generated for the AIGatewayRoute rules referencing this backend, and makes the retries select another endpoint
+	// of the rule than the ones already attempted.
+	//
+	// +optional
+	LoadReporting *BackendLoadReporting `json:"loadReporting,omitempty"`
+
 	// TODO: maybe add backend-level LLMRequestCost configuration that overrides the AIGatewayRoute-level LLMRequestCost.
 	// 	That may be useful for the backend that has a different cost calculation logic.
 }
+
+// BackendOutlierDetection configures the passive health checking of the endpoints of an AIServiceBackend.
+type BackendOutlierDetection struct {
+	// Consecutive5xxErrors is the number of the consecutive 5xx responses or connection failures after which an
+	// endpoint is ejected. Defaults to 5.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	Consecutive5xxErrors *int32 `json:"consecutive5xxErrors,omitempty"`
+
+	// Interval is the interval between the ejection analysis sweeps. Defaults to 3s.
+	//
+	// +optional
+	Interval *gwapiv1.Duration `json:"interval,omitempty"`
+
+	// BaseEjectionTime is the base duration for which an endpoint is ejected. The actual duration is the base
+	// duration multiplied by the number of times the endpoint has been ejected. Defaults to 30s.
+	//
+	// +optional
+	BaseEjectionTime *gwapiv1.Duration `json:"baseEjectionTime,omitempty"`
+
+	// MaxEjectionPercent is the maximum percentage of the endpoints of the cluster that can be ejected at once.
+	// At least one endpoint can be ejected regardless of this value. Defaults to 10.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	MaxEjectionPercent *int32 `json:"maxEjectionPercent,omitempty"`
+}
+
+// BackendRetry configures the retries of the requests failed on an AIServiceBackend. The requests are retried
+// on the connection failures and the resets as well as on the responses with the StatusCodes.
+type BackendRetry struct {
+	// NumRetries is the maximum number of the retries of a request. Defaults to 2.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=10
+	NumRetries *int32 `json:"numRetries,omitempty"`
+
+	// StatusCodes are the status codes of the responses that are retried. Defaults to 500, 502, 503 and 504.
+	//
+	// +optional
+	// +kubebuilder:validation:MaxItems=16
+	// +kubebuilder:validation:items:Minimum=400
+	// +kubebuilder:validation:items:Maximum=599
+	StatusCodes []int32 `json:"statusCodes,omitempty"`
+
+	// PerTryTimeout is the timeout of each attempt of a request, including the first one. Unset means that the
+	// attempts are only limited by the timeout of the route.
+	//
+	// +optional
+	PerTryTimeout *gwapiv1.Duration `json:"perTryTimeout,omitempty"`
+}
+
+// BackendTimeouts configures the timeouts of the phases of each attempt of the requests to an AIServiceBackend.
+type BackendTimeouts struct {
+	// Connect is the timeout of the establishment of a connection to an endpoint of the backend. This takes
+	// precedence over the connect timeout of an Envoy Gateway BackendTrafficPolicy.
+	//
+	// +optional
+	Connect *gwapiv1.Duration `json:"connect,omitempty"`
+
+	// FirstByte is the timeout between the request sent to the backend and the first byte of its response, i.e.
+	// the time to first token of the streaming responses. It is enforced as the per-try idle timeout of the route,
+	// so it also bounds the time between two chunks of a streaming response. The StreamIdleTimeout of the
+	// AIGatewayRoute rule and the per-try idle timeout of a BackendTrafficPolicy take precedence.
+	//
+	// +optional
+	FirstByte *gwapiv1.Duration `json:"firstByte,omitempty"`
+
+	// Completion is the timeout between the request sent to the backend and the end of its response. It is
+	// enforced as the per-try timeout of the route. The PerTryTimeout of the Retry and the per-try timeout of a
+	// BackendTrafficPolicy take precedence.
+	//
+	// +optional
+	Completion *gwapiv1.Duration `json:"completion,omitempty"`
+}
+
+// BackendLoadReporting configures the load reported by an AIServiceBackend in the headers of its responses.
+type BackendLoadReporting struct {
+	// QueueDepthHeader is the response header holding the number of the requests waiting in the queue of the
+	// backend. Defaults to "x-queue-depth".
+	//
+	// +optional
+	// +kubebuilder:default=x-queue-depth
+	// +kubebuilder:validation:MinLength=1
+	QueueDepthHeader string `json:"queueDepthHeader,omitempty"`
+
+	// LoadHeader is the response header holding the load of the backend, either as a ratio between 0 and 1 or as
+	// a percentage suffixed with "%". Defaults to "x-inference-load".
+	//
+	// +optional
+	// +kubebuilder:default=x-inference-load
+	// +kubebuilder:validation:MinLength=1
+	LoadHeader string `json:"loadHeader,omitempty"`
+
+	// MaxQueueDepth is the queue depth from which the attempts of the requests to the backend are shed. Unset
+	// means that the queue depth doesn't shed the attempts.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	MaxQueueDepth *int32 `json:"maxQueueDepth,omitempty"`
+
+	// MaxLoadPercent is the load percentage from which the attempts of the requests to the backend are shed.
+	// Unset means that the load doesn't shed the attempts.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	MaxLoadPercent *int32 `json:"maxLoadPercent,omitempty"`
+
+	// ReportTTL is the duration for which the last report of the backend is used. Once it has expired, the
+	// attempts are sent to the backend again until it reports a load below the thresholds. Defaults to 10s.
+	//
+	// +optional
+	ReportTTL *gwapiv1.Duration `json:"reportTTL,omitempty"`
+}
+
+// PIITokenization configures the reversible tokenization of personally identifiable information (PII).
+//
+// +kubebuilder:validation:XValidation:rule="(has(self.detectors) && size(self.detectors) > 0) || (has(self.customPatterns) && size(self.customPatterns) > 0)",message="at least one of detectors or customPatterns must be specified"
+type PIITokenization struct {
+	// Detectors is the list of built-in PII detectors to enable.
+	//
+	// +optional
+	// +listType=set
+	// +kubebuilder:validation:MaxItems=8
+	Detectors []PIIDetectorType `json:"detectors,omitempty"`
+
+	// CustomPatterns is the list of user-defined PII detectors backed by regular expressions.
+	//
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	// +kubebuilder:validation:MaxItems=16
+	CustomPatterns []PIIPattern `json:"customPatterns,omitempty"`
+}
+
+// PIIDetectorType specifies the built-in PII detector.
+//
+// +kubebuilder:validation:Enum=Email;PhoneNumber;CreditCard;USSocialSecurityNumber;IPv4Address
+type PIIDetectorType string
+
+const (
+	// PIIDetectorTypeEmail detects email addresses. Placeholder category: EMAIL.
+	PIIDetectorTypeEmail PIIDetectorType = "Email"
+	// PIIDetectorTypePhoneNumber detects North American style phone numbers. Placeholder category: PHONE.
+	PIIDetectorTypePhoneNumber PIIDetectorType = "PhoneNumber"
+	// PIIDetectorTypeCreditCard detects credit card numbers passing the Luhn checksum. Placeholder category: CREDIT_CARD.
+	PIIDetectorTypeCreditCard PIIDetectorType = "CreditCard"
+	// PIIDetectorTypeUSSocialSecurityNumber detects US social security numbers. Placeholder category: SSN.
+	PIIDetectorTypeUSSocialSecurityNumber PIIDetectorType = "USSocialSecurityNumber"
+	// PIIDetectorTypeIPv4Address detects IPv4 addresses. Placeholder category: IPV4.
+	PIIDetectorTypeIPv4Address PIIDetectorType = "IPv4Address"
+)
+
+// VLLMExtensionsPolicy specifies how the vLLM extensions to the OpenAI API are handled.
+//
+// +kubebuilder:validation:Enum=Passthrough;Strip
+type VLLMExtensionsPolicy string
+
+const (
+	// VLLMExtensionsPolicyPassthrough forwards the vLLM extensions to the backend as-is.
+	VLLMExtensionsPolicyPassthrough VLLMExtensionsPolicy = "Passthrough"
+	// VLLMExtensionsPolicyStrip removes the vLLM extensions from the request body before it is sent to the backend.
+	VLLMExtensionsPolicyStrip VLLMExtensionsPolicy = "Strip"
+)
+
+// BackendExtraBody configures the extra fields of the request bodies accepted by an AIServiceBackend.
+type BackendExtraBody struct {
+	// AllowedFields is the list of the names of the top-level extra fields sent to the backend as-is.
+	//
+	// +optional
+	// +listType=set
+	// +kubebuilder:validation:MaxItems=64
+	// +kubebuilder:validation:items:MinLength=1
+	AllowedFields []string `json:"allowedFields,omitempty"`
+
+	// Policy specifies how the extra fields that are not in the AllowedFields are handled. With "Strip", they are
+	// removed from the request body sent to the backend. With "Reject", the request is rejected with a 400 error.
+	//
+	// Defaults to "Strip".
+	//
+	// +optional
+	// +kubebuilder:default=Strip
+	Policy ExtraBodyPolicy `json:"policy,omitempty"`
+}
+
+// ExtraBodyPolicy specifies how the extra fields of a request body that are not allowed by a backend are handled.
+//
+// +kubebuilder:validation:Enum=Strip;Reject
+type ExtraBodyPolicy string
+
+const (
+	// ExtraBodyPolicyStrip removes the extra fields that are not allowed from the request body.
+	ExtraBodyPolicyStrip ExtraBodyPolicy = "Strip"
+	// ExtraBodyPolicyReject rejects the requests with the extra fields that are not allowed.
+	ExtraBodyPolicyReject ExtraBodyPolicy = "Reject"
+)
+
+// RequestCompressionPolicy specifies how the compressed request bodies modified by the gateway are sent to the
+// backend.
+//
+// +kubebuilder:validation:Enum=Decompress;Recompress
+type RequestCompressionPolicy string
+
+const (
+	// RequestCompressionPolicyDecompress sends the modified request body uncompressed.
+	RequestCompressionPolicyDecompress RequestCompressionPolicy = "Decompress"
+	// RequestCompressionPolicyRecompress compresses the modified request body with the content encoding of the client.
+	RequestCompressionPolicyRecompress RequestCompressionPolicy = "Recompress"
+)
+
+// TraceContextPropagationPolicy specifies how the trace context of the requests is propagated to the backend.
+//
+// +kubebuilder:validation:Enum=TraceContext;ProviderHeaders;None
+type TraceContextPropagationPolicy string
+
+const (
+	// TraceContextPropagationPolicyTraceContext sends the W3C trace context headers to the backend.
+	TraceContextPropagationPolicyTraceContext TraceContextPropagationPolicy = "TraceContext"
+	// TraceContextPropagationPolicyProviderHeaders sends the W3C trace context headers and the correlation header
+	// of the provider to the backend.
+	TraceContextPropagationPolicyProviderHeaders TraceContextPropagationPolicy = "ProviderHeaders"
+	// TraceContextPropagationPolicyNone removes the trace context headers from the requests to the backend.
+	TraceContextPropagationPolicyNone TraceContextPropagationPolicy = "None"
+)
+
+// PIIPattern is a user-defined PII detector backed by a regular expression.
+type PIIPattern struct {
+	// Name is the name of the pattern. The upper-cased name is used as the placeholder category,
+	// e.g. the name "employeeID" results in placeholders such as "[PII_EMPLOYEEID_1]".
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=32
+	// +kubebuilder:validation:Pattern=`^[A-Za-z][A-Za-z0-9_]*$`
+	Name string `json:"name"`
+
+	// Regex is the RE2 regular expression matching the PII.
+	// See https://github.com/google/re2/wiki/Syntax for the syntax.
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Regex string `json:"regex"`
+}
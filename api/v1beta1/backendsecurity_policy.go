@@ -16,12 +16,14 @@ import (
 type BackendSecurityPolicyType string
 
 const (
-	BackendSecurityPolicyTypeAPIKey           BackendSecurityPolicyType = "APIKey"
-	BackendSecurityPolicyTypeAWSCredentials   BackendSecurityPolicyType = "AWSCredentials"
-	BackendSecurityPolicyTypeAzureAPIKey      BackendSecurityPolicyType = "AzureAPIKey"
-	BackendSecurityPolicyTypeAnthropicAPIKey  BackendSecurityPolicyType = "AnthropicAPIKey" // #nosec G101
-	BackendSecurityPolicyTypeAzureCredentials BackendSecurityPolicyType = "AzureCredentials"
-	BackendSecurityPolicyTypeGCPCredentials   BackendSecurityPolicyType = "GCPCredentials"
+	BackendSecurityPolicyTypeAPIKey               BackendSecurityPolicyType = "APIKey"
+	BackendSecurityPolicyTypeAWSCredentials       BackendSecurityPolicyType = "AWSCredentials"
+	BackendSecurityPolicyTypeAzureAPIKey          BackendSecurityPolicyType = "AzureAPIKey"
+	BackendSecurityPolicyTypeAnthropicAPIKey      BackendSecurityPolicyType = "AnthropicAPIKey" // #nosec G101
+	BackendSecurityPolicyTypeAzureCredentials     BackendSecurityPolicyType = "AzureCredentials"
+	BackendSecurityPolicyTypeGCPCredentials       BackendSecurityPolicyType = "GCPCredentials"
+	BackendSecurityPolicyTypeIBMCloudAPIKey       BackendSecurityPolicyType = "IBMCloudAPIKey" // #nosec G101
+	BackendSecurityPolicyTypeSAPAICoreCredentials BackendSecurityPolicyType = "SAPAICoreCredentials"
 )
 
 // BackendSecurityPolicy specifies configuration for authentication and authorization rules on the traffic
@@ -46,13 +48,15 @@ type BackendSecurityPolicy struct {
 // Only one mechanism to access a backend(s) can be specified.
 //
 // Only one type of BackendSecurityPolicy can be defined.
-// +kubebuilder:validation:MaxProperties=4
-// +kubebuilder:validation:XValidation:rule="self.type == 'APIKey' ? (has(self.apiKey) && !has(self.awsCredentials) && !has(self.azureAPIKey) && !has(self.azureCredentials) && !has(self.gcpCredentials) && !has(self.anthropicAPIKey)) : true",message="When type is APIKey, only apiKey field should be set"
-// +kubebuilder:validation:XValidation:rule="self.type == 'AWSCredentials' ? (has(self.awsCredentials) && !has(self.apiKey) && !has(self.azureAPIKey) && !has(self.azureCredentials) && !has(self.gcpCredentials) && !has(self.anthropicAPIKey)) : true",message="When type is AWSCredentials, only awsCredentials field should be set"
-// +kubebuilder:validation:XValidation:rule="self.type == 'AzureAPIKey' ? (has(self.azureAPIKey) && !has(self.apiKey) && !has(self.awsCredentials) && !has(self.azureCredentials) && !has(self.gcpCredentials) && !has(self.anthropicAPIKey)) : true",message="When type is AzureAPIKey, only azureAPIKey field should be set"
-// +kubebuilder:validation:XValidation:rule="self.type == 'AzureCredentials' ? (has(self.azureCredentials) && !has(self.apiKey) && !has(self.awsCredentials) && !has(self.azureAPIKey) && !has(self.gcpCredentials) && !has(self.anthropicAPIKey)) : true",message="When type is AzureCredentials, only azureCredentials field should be set"
-// +kubebuilder:validation:XValidation:rule="self.type == 'GCPCredentials' ? (has(self.gcpCredentials) && !has(self.apiKey) && !has(self.awsCredentials) && !has(self.azureAPIKey) && !has(self.azureCredentials) && !has(self.anthropicAPIKey)) : true",message="When type is GCPCredentials, only gcpCredentials field should be set"
-// +kubebuilder:validation:XValidation:rule="self.type == 'AnthropicAPIKey' ? (has(self.anthropicAPIKey) && !has(self.apiKey) && !has(self.awsCredentials) && !has(self.azureAPIKey) && !has(self.azureCredentials) && !has(self.gcpCredentials)) : true",message="When type is AnthropicAPIKey, only anthropicAPIKey field should be set"
+// +kubebuilder:validation:MaxProperties=5
+// +kubebuilder:validation:XValidation:rule="self.type == 'APIKey' ? (has(self.apiKey) && !has(self.awsCredentials) && !has(self.azureAPIKey) && !has(self.azureCredentials) && !has(self.gcpCredentials) && !has(self.anthropicAPIKey) && !has(self.ibmCloudAPIKey) && !has(self.sapAICoreCredentials)) : true",message="When type is APIKey, only apiKey field should be set"
+// +kubebuilder:validation:XValidation:rule="self.type == 'AWSCredentials' ? (has(self.awsCredentials) && !has(self.apiKey) && !has(self.azureAPIKey) && !has(self.azureCredentials) && !has(self.gcpCredentials) && !has(self.anthropicAPIKey) && !has(self.ibmCloudAPIKey) && !has(self.sapAICoreCredentials)) : true",message="When type is AWSCredentials, only awsCredentials field should be set"
+// +kubebuilder:validation:XValidation:rule="self.type == 'AzureAPIKey' ? (has(self.azureAPIKey) && !has(self.apiKey) && !has(self.awsCredentials) && !has(self.azureCredentials) && !has(self.gcpCredentials) && !has(self.anthropicAPIKey) && !has(self.ibmCloudAPIKey) && !has(self.sapAICoreCredentials)) : true",message="When type is AzureAPIKey, only azureAPIKey field should be set"
+// +kubebuilder:validation:XValidation:rule="self.type == 'AzureCredentials' ? (has(self.azureCredentials) && !has(self.apiKey) && !has(self.awsCredentials) && !has(self.azureAPIKey) && !has(self.gcpCredentials) && !has(self.anthropicAPIKey) && !has(self.ibmCloudAPIKey) && !has(self.sapAICoreCredentials)) : true",message="When type is AzureCredentials, only azureCredentials field should be set"
+// +kubebuilder:validation:XValidation:rule="self.type == 'GCPCredentials' ? (has(self.gcpCredentials) && !has(self.apiKey) && !has(self.awsCredentials) && !has(self.azureAPIKey) && !has(self.azureCredentials) && !has(self.anthropicAPIKey) && !has(self.ibmCloudAPIKey) && !has(self.sapAICoreCredentials)) : true",message="When type is GCPCredentials, only gcpCredentials field should be set"
+// +kubebuilder:validation:XValidation:rule="self.type == 'AnthropicAPIKey' ? (has(self.anthropicAPIKey) && !has(self.apiKey) && !has(self.awsCredentials) && !has(self.azureAPIKey) && !has(self.azureCredentials) && !has(self.gcpCredentials) && !has(self.ibmCloudAPIKey) && !has(self.sapAICoreCredentials)) : true",message="When type is AnthropicAPIKey, only anthropicAPIKey field should be set"
+// +kubebuilder:validation:XValidation:rule="self.type == 'IBMCloudAPIKey' ? (has(self.ibmCloudAPIKey) && !has(self.apiKey) && !has(self.awsCredentials) && !has(self.azureAPIKey) && !has(self.azureCredentials) && !has(self.gcpCredentials) && !has(self.anthropicAPIKey) && !has(self.sapAICoreCredentials)) : true",message="When type is IBMCloudAPIKey, only ibmCloudAPIKey field should be set"
+// +kubebuilder:validation:XValidation:rule="self.type == 'SAPAICoreCredentials' ? (has(self.sapAICoreCredentials) && !has(self.apiKey) && !has(self.awsCredentials) && !has(self.azureAPIKey) && !has(self.azureCredentials) && !has(self.gcpCredentials) && !has(self.anthropicAPIKey) && !has(self.ibmCloudAPIKey)) : true",message="When type is SAPAICoreCredentials, only sapAICoreCredentials field should be set"
 // +kubebuilder:validation:XValidation:rule="!has(self.credentialOverride) || self.type != 'AWSCredentials'",message="credentialOverride is not supported for AWSCredentials"
 type BackendSecurityPolicySpec struct {
 	// TargetRefs are the names of the AIServiceBackend or InferencePool resources this BackendSecurityPolicy is being attached to.
@@ -66,7 +70,7 @@ type BackendSecurityPolicySpec struct {
 
 	// Type specifies the type of the backend security policy.
 	//
-	// +kubebuilder:validation:Enum=APIKey;AWSCredentials;AzureAPIKey;AzureCredentials;GCPCredentials;AnthropicAPIKey
+	// +kubebuilder:validation:Enum=APIKey;AWSCredentials;AzureAPIKey;AzureCredentials;GCPCredentials;AnthropicAPIKey;IBMCloudAPIKey;SAPAICoreCredentials
 	Type BackendSecurityPolicyType `json:"type"`
 
 	// APIKey is a mechanism to access a backend(s). The API key will be injected into the Authorization header.
@@ -100,11 +104,50 @@ type BackendSecurityPolicySpec struct {
 	// +optional
 	AnthropicAPIKey *BackendSecurityPolicyAnthropicAPIKey `json:"anthropicAPIKey,omitempty"`
 
+	// IBMCloudAPIKey is a mechanism to access IBM watsonx.ai backend(s). The API key is exchanged for an IBM Cloud
+	// IAM access token, which will be injected into the Authorization header.
+	//
+	// +optional
+	IBMCloudAPIKey *BackendSecurityPolicyIBMCloudAPIKey `json:"ibmCloudAPIKey,omitempty"`
+
+	// SAPAICoreCredentials is a mechanism to access SAP AI Core backend(s). The client credentials of the SAP AI Core
+	// service key are exchanged for an access token, which will be injected into the Authorization header along with
+	// the "AI-Resource-Group" header.
+	//
+	// +optional
+	SAPAICoreCredentials *BackendSecurityPolicySAPAICoreCredentials `json:"sapAICoreCredentials,omitempty"`
+
 	// CredentialOverride, when set, sources the upstream credential per-request instead of using
 	// the static credential configured above. Supported for all types except AWSCredentials.
 	//
 	// +optional
 	CredentialOverride *BackendSecurityPolicyCredentialOverride `json:"credentialOverride,omitempty"`
+
+	// Egress configures how the ai-gateway controller reaches the OIDC issuers, AWS STS, Microsoft Entra ID and
+	// GCP STS to rotate the credentials of this policy, e.g. through a TLS-intercepting proxy in air-gapped
+	// environments. This does not apply to the requests sent by the Gateway to the backends.
+	//
+	// +optional
+	Egress *BackendSecurityPolicyEgress `json:"egress,omitempty"`
+}
+
+// BackendSecurityPolicyEgress configures the HTTP client of the ai-gateway controller for the credential rotation.
+type BackendSecurityPolicyEgress struct {
+	// ProxyURL is the URL of the HTTP proxy through which the requests are sent, e.g. "http://proxy.corp:3128".
+	// When unset, the HTTPS_PROXY, HTTP_PROXY and NO_PROXY environment variables of the controller are honored.
+	//
+	// +optional
+	// +kubebuilder:validation:Pattern=`^https?://`
+	ProxyURL string `json:"proxyURL,omitempty"`
+
+	// CACertificateRefs references the ConfigMaps or Secrets in the namespace of this policy containing
+	// the PEM-encoded CA certificates under the "ca.crt" key, e.g. the CA of a TLS-intercepting proxy.
+	// The certificates are trusted in addition to the system certificate pool of the controller.
+	//
+	// +optional
+	// +kubebuilder:validation:MaxItems=8
+	// +kubebuilder:validation:XValidation:rule="self.all(ref, ref.group == '' && (ref.kind == 'ConfigMap' || ref.kind == 'Secret'))",message="caCertificateRefs must reference ConfigMap or Secret resources"
+	CACertificateRefs []gwapiv1.LocalObjectReference `json:"caCertificateRefs,omitempty"`
 }
 
 // BackendSecurityPolicyList contains a list of BackendSecurityPolicy
@@ -118,11 +161,81 @@ type BackendSecurityPolicyList struct {
 }
 
 // BackendSecurityPolicyAPIKey specifies the API key.
+//
+// +kubebuilder:validation:XValidation:rule="has(self.secretRef) != has(self.pool)",message="exactly one of secretRef or pool must be set"
 type BackendSecurityPolicyAPIKey struct {
 	// SecretRef is the reference to the secret containing the API key.
 	// ai-gateway must be given the permission to read this secret.
 	// The key of the secret should be "apiKey".
+	//
+	// +optional
+	SecretRef *gwapiv1.SecretObjectReference `json:"secretRef,omitempty"`
+
+	// Pool is the list of the API keys of several accounts of the provider across which the requests are spread,
+	// instead of the single API key of SecretRef, e.g. to go beyond the rate limits of a single account. The key of
+	// each request is selected with a weighted round-robin.
+	//
+	// A key is removed from the rotation for five minutes as soon as the backend rejects a request authenticated
+	// with it with 401 Unauthorized, and is used again afterward. The requests keep being sent with the removed keys
+	// only when all the keys were removed.
+	//
+	// The requests sent with each key are counted in the backend_auth.api_key.requests metric, and the removals in
+	// the backend_auth.api_key.removals metric, both with the "namespace/policy/name" of the key.
+	//
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=64
+	// +kubebuilder:validation:XValidation:rule="self.exists(k, !has(k.weight) || k.weight > 0)",message="at least one key must have a positive weight"
+	Pool []BackendSecurityPolicyAPIKeyPoolEntry `json:"pool,omitempty"`
+
+	// Organization is the OpenAI organization ID, e.g. "org-abc123", sent in the "OpenAI-Organization" header
+	// of the requests authenticated with this API key. When set, it replaces the header sent by the client, which
+	// is needed when the API key is shared by the clients but belongs to an organization of the provider.
+	//
+	// The header sent by the client is still available to the AIGatewayRoute matches and the QuotaPolicy
+	// client selectors, which are evaluated before the backend is selected.
+	//
+	// +optional
+	// +kubebuilder:validation:MaxLength=256
+	Organization string `json:"organization,omitempty"`
+
+	// Project is the OpenAI project ID, e.g. "proj_abc123", sent in the "OpenAI-Project" header of the requests
+	// authenticated with this API key. When set, it replaces the header sent by the client in the same way
+	// as Organization.
+	//
+	// +optional
+	// +kubebuilder:validation:MaxLength=256
+	Project string `json:"project,omitempty"`
+}
+
+// BackendSecurityPolicyAPIKeyPoolEntry is an API key of a BackendSecurityPolicyAPIKey pool.
+type BackendSecurityPolicyAPIKeyPoolEntry struct {
+	// Name is the name of the key, unique in the pool, e.g. the name of the account of the provider. It identifies
+	// the key in the metrics.
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^[a-zA-Z0-9]([-_.a-zA-Z0-9]*[a-zA-Z0-9])?$`
+	Name string `json:"name"`
+
+	// SecretRef is the reference to the secret containing the API key.
+	// ai-gateway must be given the permission to read this secret.
+	// The key of the secret should be "apiKey".
+	//
+	// +kubebuilder:validation:Required
 	SecretRef *gwapiv1.SecretObjectReference `json:"secretRef"`
+
+	// Weight is the proportion of the requests sent with this key relative to the other keys of the pool.
+	// A key with a zero weight is not used. Defaults to 1.
+	//
+	// +optional
+	// +kubebuilder:default=1
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=1000
+	Weight *int32 `json:"weight,omitempty"`
 }
 
 // BackendSecurityPolicyAzureAPIKey specifies the Azure OpenAI API key.
@@ -245,10 +358,10 @@ type BackendSecurityPolicyGCPCredentials struct {
 }
 
 // BackendSecurityPolicyAzureCredentials contains the supported authentication mechanisms to access Azure.
-// Only one of ClientSecretRef or OIDCExchangeToken must be specified. Credentials will not be generated if
-// neither are set.
+// Only one of ClientSecretRef, OIDCExchangeToken or WorkloadIdentity must be specified. Credentials will not
+// be generated if none are set.
 //
-// +kubebuilder:validation:XValidation:rule="(has(self.clientSecretRef) && !has(self.oidcExchangeToken)) || (!has(self.clientSecretRef) && has(self.oidcExchangeToken))",message="Exactly one of clientSecretRef or oidcExchangeToken must be specified"
+// +kubebuilder:validation:XValidation:rule="[has(self.clientSecretRef), has(self.oidcExchangeToken), has(self.workloadIdentity)].filter(x, x).size() == 1",message="Exactly one of clientSecretRef, oidcExchangeToken or workloadIdentity must be specified"
 type BackendSecurityPolicyAzureCredentials struct {
 	// ClientID is a unique identifier for an application in Azure.
 	//
@@ -274,6 +387,25 @@ type BackendSecurityPolicyAzureCredentials struct {
 	//
 	// +optional
 	OIDCExchangeToken *AzureOIDCExchangeToken `json:"oidcExchangeToken,omitempty"`
+
+	// WorkloadIdentity enables the Microsoft Entra Workload ID federation, e.g. on AKS, so that no client
+	// secret needs to exist in the cluster. The service account token of the ai-gateway controller projected
+	// by the Azure Workload Identity webhook is exchanged for the Azure access token of the ClientID
+	// application, which must have a federated identity credential trusting that service account.
+	//
+	// +optional
+	WorkloadIdentity *AzureWorkloadIdentity `json:"workloadIdentity,omitempty"`
+}
+
+// AzureWorkloadIdentity specifies the federated token used to obtain the Azure access token via the
+// Microsoft Entra Workload ID federation.
+type AzureWorkloadIdentity struct {
+	// TokenFilePath is the path of the federated service account token file in the ai-gateway controller pod.
+	// Defaults to the AZURE_FEDERATED_TOKEN_FILE environment variable set by the Azure Workload Identity
+	// webhook when the controller pod is labeled with "azure.workload.identity/use: true".
+	//
+	// +optional
+	TokenFilePath string `json:"tokenFilePath,omitempty"`
 }
 
 // AzureOIDCExchangeToken specifies credentials to obtain oidc token from a sso server.
@@ -303,6 +435,18 @@ type BackendSecurityPolicyAWSCredentials struct {
 	// +kubebuilder:validation:MinLength=1
 	Region string `json:"region"`
 
+	// RegionSet is the set of the AWS regions the requests are signed for with SigV4a (multi-region) instead of
+	// SigV4, e.g. us-east-1 and us-west-2, or * for any region. This is required to use a Bedrock
+	// cross-region inference profile or the global endpoint that may serve the request from another region
+	// than the Region of the endpoint.
+	//
+	// Region is still used as the region of the Bedrock endpoint.
+	//
+	// +optional
+	// +kubebuilder:validation:MaxItems=32
+	// +kubebuilder:validation:items:MinLength=1
+	RegionSet []string `json:"regionSet,omitempty"`
+
 	// CredentialsFile specifies the credentials file to use for the AWS provider.
 	// When specified, this takes precedence over the default credential chain.
 	//
@@ -362,6 +506,56 @@ type BackendSecurityPolicyAnthropicAPIKey struct {
 	SecretRef *gwapiv1.SecretObjectReference `json:"secretRef"`
 }
 
+// BackendSecurityPolicyIBMCloudAPIKey specifies the IBM Cloud API key used to access IBM watsonx.ai.
+// The controller exchanges the API key for an IBM Cloud IAM access token, and stores it in a secret
+// rotated before the token expires.
+// https://cloud.ibm.com/docs/account?topic=account-iamtoken_from_apikey
+type BackendSecurityPolicyIBMCloudAPIKey struct {
+	// SecretRef is the reference to the secret containing the IBM Cloud API key.
+	// ai-gateway must be given the permission to read this secret.
+	// The key of the secret should be "apiKey".
+	SecretRef *gwapiv1.SecretObjectReference `json:"secretRef"`
+
+	// IAMURL is the base URL of the IBM Cloud IAM service the API key is exchanged with, e.g. the private
+	// endpoint "https://private.iam.cloud.ibm.com".
+	//
+	// +optional
+	// +kubebuilder:default="https://iam.cloud.ibm.com"
+	// +kubebuilder:validation:Pattern=`^https?://`
+	IAMURL string `json:"iamURL,omitempty"`
+}
+
+// BackendSecurityPolicySAPAICoreCredentials specifies the client credentials of an SAP AI Core service key.
+// The controller exchanges them for an access token with the OAuth 2.0 client credentials flow of the SAP
+// Authorization and Trust Management service (XSUAA), and stores it in a secret rotated before the token expires.
+// https://help.sap.com/docs/sap-ai-core/sap-ai-core-service-guide/create-service-key
+type BackendSecurityPolicySAPAICoreCredentials struct {
+	// AuthURL is the "url" of the service key, e.g. "https://<subdomain>.authentication.eu10.hana.ondemand.com".
+	// The access token is requested from its "/oauth/token" endpoint.
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern=`^https?://`
+	AuthURL string `json:"authURL"`
+
+	// ClientID is the "clientid" of the service key.
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	ClientID string `json:"clientID"`
+
+	// ClientSecretRef is the reference to the secret containing the "clientsecret" of the service key.
+	// ai-gateway must be given the permission to read this secret.
+	// The key of the secret should be "client-secret".
+	ClientSecretRef *gwapiv1.SecretObjectReference `json:"clientSecretRef"`
+
+	// ResourceGroup is the SAP AI Core resource group of the deployments, sent in the "AI-Resource-Group" header.
+	//
+	// +optional
+	// +kubebuilder:default=default
+	// +kubebuilder:validation:MinLength=1
+	ResourceGroup string `json:"resourceGroup,omitempty"`
+}
+
 // BackendSecurityPolicyCredentialOverride configures per-request credential sourcing.
 // A trusted upstream filter (ext_authz or a preceding ext_proc) resolves the caller's
 // credential and delivers it via one of the two sources below.
@@ -394,6 +588,8 @@ type CredentialOverrideFromRequestHeaders struct {
 	//   AzureAPIKey     → x-aigw-azure-api-key
 	//   AzureCredentials → x-aigw-azure-access-token
 	//   GCPCredentials  → x-aigw-gcp-access-token
+	//   IBMCloudAPIKey  → x-aigw-ibm-cloud-access-token
+	//   SAPAICoreCredentials → x-aigw-sap-ai-core-access-token
 	//
 	// +optional
 	Header string `json:"header,omitempty"`
@@ -7,7 +7,10 @@ package v1beta1
 
 import (
 	egv1a1 "github.com/envoyproxy/gateway/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
 )
 
 // GatewayConfig provides configuration for the AI Gateway external processor
@@ -80,14 +83,253 @@ type GatewayConfigSpec struct {
 	// +listType=map
 	// +listMapKey=metadataKey
 	GlobalLLMRequestCosts []LLMRequestCost `json:"globalLLMRequestCosts,omitempty"`
+
+	// StreamConcurrencyLimits limits the number of concurrent streaming requests per client, such as a user
+	// or a tenant, on the Gateways referencing this GatewayConfig.
+	//
+	// Unlike token based quotas, this protects the backends from clients holding many long-running streams,
+	// each of which occupies a provider slot for minutes. A streaming request exceeding any of the limits is
+	// rejected with 429 Too Many Requests and a Retry-After header. Non-streaming requests are not counted.
+	//
+	// The limits are enforced by each external processor independently, i.e. per Envoy proxy replica.
+	//
+	// +optional
+	// +kubebuilder:validation:MaxItems=8
+	StreamConcurrencyLimits []StreamConcurrencyLimit `json:"streamConcurrencyLimits,omitempty"`
+
+	// TruncatedStreamErrorEvent enables sending an error event to the client when a streaming chat completion
+	// ends without its terminating event, i.e. neither the [DONE] event nor a chunk with a finish reason, for
+	// example because the backend closed the stream early. The error event carries an OpenAI error object of
+	// type "stream_truncated", so that the OpenAI SDKs raise an error instead of returning a partial response.
+	//
+	// Truncated streams are reported in the gen_ai.response.truncated metric and in the request span regardless
+	// of this setting.
+	//
+	// +optional
+	TruncatedStreamErrorEvent bool `json:"truncatedStreamErrorEvent,omitempty"`
+
+	// StreamCoalescing coalesces the small events of the streaming responses, e.g. the token-by-token deltas of
+	// some providers, into fewer and larger body chunks sent to the clients, to reduce the per-chunk overhead of
+	// the proxy and the syscalls. The events are neither reordered nor modified, and the end of the stream, with
+	// the final usage chunk, is always sent at once.
+	//
+	// Since a chunk can only be sent when the next upstream chunk is received, the events held back are delayed
+	// until the next upstream chunk or the end of the stream, so this trades the latency of the individual tokens
+	// for throughput.
+	//
+	// +optional
+	StreamCoalescing *StreamCoalescing `json:"streamCoalescing,omitempty"`
+
+	// PromptInjectionDetection enables scoring the requests on the Gateways referencing this GatewayConfig for
+	// prompt injection and jailbreak attempts, such as instructions to ignore the previous instructions or to
+	// reveal the system prompt.
+	//
+	// The score is computed with lightweight pattern and heuristic rules on the content of the request body,
+	// excluding the system and developer messages set by the application. It is set in the dynamic metadata, in the
+	// request span and in the gen_ai.prompt_injection.score metric, so that it can be used for security monitoring.
+	//
+	// +optional
+	PromptInjectionDetection *PromptInjectionDetection `json:"promptInjectionDetection,omitempty"`
+
+	// FallbackResponse configures a static synthetic response returned to the clients instead of the error
+	// responses when no backend can serve a request on the Gateways referencing this GatewayConfig, e.g. when every
+	// backend is unhealthy or over quota, so that the clients degrade gracefully during total provider outages.
+	//
+	// The replaced responses are still reported as failed requests in the metrics and the request span.
+	//
+	// +optional
+	FallbackResponse *FallbackResponse `json:"fallbackResponse,omitempty"`
+
+	// ResponseAttestation enables attaching a signed attestation to the responses of the backends on the Gateways
+	// referencing this GatewayConfig, so that the downstream systems can verify that a response transited the
+	// gateway, e.g. as compliance evidence.
+	//
+	// The attestation is set in the "x-ai-eg-attestation" response header. It is a JWS in the compact serialization
+	// signed with EdDSA (Ed25519), whose claims are the gateway identity ("iss"), the signing time ("iat"), the
+	// model of the request ("model") and the hex-encoded SHA-256 of the request body as sent by the client
+	// ("request_sha256").
+	//
+	// +optional
+	ResponseAttestation *ResponseAttestation `json:"responseAttestation,omitempty"`
+
+	// DebugEcho enables the debug mode returning the request as translated for the backend instead of sending it,
+	// to inspect the output of the translation in environments with the same configuration as production. The
+	// mode is requested per request with the "x-ai-eg-debug-echo: true" header or the "echo=true" query parameter,
+	// and is ignored unless this is set.
+	//
+	// The response is a JSON object with the name of the selected backend ("backend"), the request headers as sent
+	// to the backend ("headers"), including the pseudo-headers and with the values of the credentials redacted, and
+	// the request body ("body"), which is embedded as is if it is JSON and base64-encoded otherwise.
+	//
+	// +optional
+	DebugEcho bool `json:"debugEcho,omitempty"`
+}
+
+// ResponseAttestation configures the signing of the response attestations.
+type ResponseAttestation struct {
+	// Identity is the identity of the gateway set in the "iss" claim of the attestations, e.g.
+	// "ai-gateway.prod.example.com".
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=253
+	Identity string `json:"identity"`
+
+	// SecretRef is the reference to the Secret in the namespace of the GatewayConfig holding the PEM-encoded
+	// PKCS #8 Ed25519 private key signing the attestations.
+	// ai-gateway must be given the permission to read this secret.
+	// The key of the secret should be "privateKey".
+	//
+	// +kubebuilder:validation:Required
+	SecretRef *gwapiv1.SecretObjectReference `json:"secretRef"`
+}
+
+// FallbackResponseType is the type of a FallbackResponse.
+//
+// +kubebuilder:validation:Enum=Error;Message
+type FallbackResponseType string
+
+const (
+	// FallbackResponseTypeError returns an OpenAI error object with the message, keeping the status code of the
+	// replaced response.
+	FallbackResponseTypeError FallbackResponseType = "Error"
+	// FallbackResponseTypeMessage returns a successful chat completion whose assistant message is the message to
+	// the chat completion requests, streamed if the request is a streaming one. The other endpoints get the
+	// Error response.
+	FallbackResponseTypeMessage FallbackResponseType = "Message"
+)
+
+// FallbackResponse configures the synthetic response replacing the error responses returned when no backend can
+// serve a request.
+type FallbackResponse struct {
+	// StatusCodes are the status codes of the responses replaced by the fallback response. Defaults to 429 and
+	// 503, which are returned when the backends are over quota or when none of them is healthy.
+	//
+	// +optional
+	// +kubebuilder:validation:MaxItems=16
+	// +kubebuilder:validation:items:Minimum=400
+	// +kubebuilder:validation:items:Maximum=599
+	StatusCodes []int32 `json:"statusCodes,omitempty"`
+
+	// Type is the type of the fallback response. Defaults to Error.
+	//
+	// +optional
+	// +kubebuilder:default=Error
+	Type FallbackResponseType `json:"type,omitempty"`
+
+	// Message is the message of the fallback response. It is a Go text/template executed with the .Model of the
+	// request and the .StatusCode of the replaced response, e.g.
+	// "{{ .Model }} is temporarily unavailable, please retry later."
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=4096
+	Message string `json:"message"`
+}
+
+// StreamCoalescing configures when the coalesced events of a streaming response are sent to the client. The
+// events are sent as soon as either condition is met.
+//
+// +kubebuilder:validation:XValidation:rule="has(self.minBytes) || has(self.flushInterval)",message="at least one of minBytes or flushInterval must be set"
+type StreamCoalescing struct {
+	// MinBytes is the size of the coalesced events at or above which they are sent.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65536
+	MinBytes *int32 `json:"minBytes,omitempty"`
+
+	// FlushInterval is the time since the events were last sent after which the coalesced events are sent,
+	// e.g. 50ms.
+	//
+	// +optional
+	FlushInterval *gwapiv1.Duration `json:"flushInterval,omitempty"`
+}
+
+// PromptInjectionDetection configures the heuristic detection of prompt injection and jailbreak attempts.
+type PromptInjectionDetection struct {
+	// BlockThreshold is the risk score from 1 to 100 at or above which the requests are rejected with
+	// 403 Forbidden. When unset, the requests are only annotated with their score.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	BlockThreshold *int32 `json:"blockThreshold,omitempty"`
+}
+
+// StreamConcurrencyLimit limits the number of concurrent streaming requests per client identified by request headers.
+type StreamConcurrencyLimit struct {
+	// Headers is the list of request header names whose values identify the client, e.g. "x-user-id" or
+	// "x-tenant-id". The limit applies to each distinct combination of the header values.
+	//
+	// Requests that don't have all of the headers are not subject to this limit.
+	//
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=4
+	Headers []gwapiv1.HeaderName `json:"headers"`
+
+	// MaxConcurrentStreams is the maximum number of concurrent streaming requests per client.
+	//
+	// +kubebuilder:validation:Minimum=1
+	MaxConcurrentStreams int32 `json:"maxConcurrentStreams"`
+
+	// RetryAfter is the duration set in the Retry-After header of the rejected requests, rounded up to seconds.
+	// Defaults to 1s.
+	//
+	// +optional
+	RetryAfter *gwapiv1.Duration `json:"retryAfter,omitempty"`
 }
 
 // GatewayConfigExtProc holds runtime-specific configuration for the external processor.
 type GatewayConfigExtProc struct {
 	// Kubernetes defines the configuration for running the external processor as a Kubernetes container.
 	//
+	// The container runs as a non-root user with a read-only root filesystem, all the capabilities dropped and the
+	// RuntimeDefault seccomp profile. The fields set in the SecurityContext override these defaults one by one, e.g.
+	// setting only the seccompProfile keeps the others. The temporary files are written to an emptyDir volume
+	// mounted at /tmp.
+	//
 	// +optional
 	Kubernetes *egv1a1.KubernetesContainerSpec `json:"kubernetes,omitempty"`
+
+	// PodDisruptionBudget configures the PodDisruptionBudget managed by the controller for the Envoy proxy pods
+	// running the external processor of the Gateways referencing this GatewayConfig.
+	//
+	// Since the external processor runs in the Envoy proxy pods, this limits the number of pods that can be
+	// voluntarily evicted at the same time, e.g. during node drains, so that a route doesn't lose all of its
+	// capacity at once. The PodDisruptionBudget is deleted when this field is unset.
+	//
+	// +optional
+	PodDisruptionBudget *GatewayConfigExtProcPodDisruptionBudget `json:"podDisruptionBudget,omitempty"`
+
+	// TopologySpreadConstraints is the list of topology spread constraints set on the Envoy proxy pods
+	// running the external processor, so that the pods are spread across nodes or zones and a single
+	// failure domain doesn't take down all the capacity.
+	//
+	// When the LabelSelector of a constraint is not set, it defaults to the labels selecting the Envoy proxy
+	// pods of the Gateway. The constraints are only applied when the pod doesn't already have topology spread
+	// constraints, e.g. configured via the EnvoyProxy resource of Envoy Gateway.
+	// Changes take effect when the pods are recreated, e.g. on the next rollout.
+	//
+	// +optional
+	// +kubebuilder:validation:MaxItems=8
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+}
+
+// GatewayConfigExtProcPodDisruptionBudget configures the PodDisruptionBudget of the Envoy proxy pods running
+// the external processor. Exactly one of MinAvailable or MaxUnavailable must be set.
+//
+// +kubebuilder:validation:XValidation:rule="has(self.minAvailable) != has(self.maxUnavailable)", message="exactly one of minAvailable or maxUnavailable must be set"
+type GatewayConfigExtProcPodDisruptionBudget struct {
+	// MinAvailable is the minimum number or percentage of the pods that must be available after an eviction.
+	//
+	// +optional
+	MinAvailable *intstr.IntOrString `json:"minAvailable,omitempty"`
+
+	// MaxUnavailable is the maximum number or percentage of the pods that can be unavailable after an eviction.
+	//
+	// +optional
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
 }
 
 // GatewayConfigStatus defines the observed state of GatewayConfig.
@@ -19,13 +19,24 @@ import (
 type VersionedAPISchema struct {
 	// Name is the name of the API schema of the AIGatewayRoute or AIServiceBackend.
 	//
-	// +kubebuilder:validation:Enum=OpenAI;Cohere;AWSBedrock;AzureOpenAI;GCPVertexAI;GCPAnthropic;Anthropic;AWSAnthropic
+	// This is one of the built-in schemas: OpenAI, Cohere, AWSBedrock, AzureOpenAI, GCPVertexAI, GCPAnthropic,
+	// Anthropic, AWSAnthropic, SAPAICore, IBMWatsonx and Mock, or the name of a custom schema whose translators are
+	// registered with the pkg/translatorsdk package in the external processor. The requests to a backend with
+	// a schema that has no translator in the external processor fail.
+	//
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^[A-Za-z][A-Za-z0-9]*$`
 	Name APISchema `json:"name"`
 
 	// Version is the version of the API schema.
 	//
 	// When the name is set to AzureOpenAI, this version maps to "API Version" in the
 	// Azure OpenAI API documentation (https://learn.microsoft.com/en-us/azure/ai-services/openai/reference#rest-api-versioning).
+	// When the name is set to SAPAICore, this version maps to the "api-version" query parameter of the
+	// deployment-scoped inference endpoints of the OpenAI models hosted on SAP AI Core.
+	// When the name is set to IBMWatsonx, this version maps to the "version" query parameter of the
+	// watsonx.ai API (https://cloud.ibm.com/apidocs/watsonx-ai#api-versioning), and defaults to "2024-05-31".
 	// This field is ignored for OpenAI, AWSBedrock, GCPVertexAI, and Anthropic.
 	// For OpenAI and Anthropic, use prefix to configure custom request paths.
 	//
@@ -93,6 +104,26 @@ const (
 	// https://aws.amazon.com/bedrock/anthropic/
 	// https://docs.claude.com/en/api/claude-on-amazon-bedrock
 	APISchemaAWSAnthropic APISchema = "AWSAnthropic"
+	// APISchemaSAPAICore is the schema for the OpenAI models hosted on SAP AI Core.
+	// Requests are sent to the deployment-scoped inference endpoints, where the deployment ID is taken from
+	// the model name, which is usually set by the ModelNameOverride of the backend reference.
+	// The backends are authenticated with the SAPAICoreCredentials BackendSecurityPolicy, which also sets the
+	// "AI-Resource-Group" header of the deployments.
+	//
+	// https://help.sap.com/docs/sap-ai-core/sap-ai-core-service-guide/consume-generative-ai-models-using-sap-ai-core
+	APISchemaSAPAICore APISchema = "SAPAICore"
+	// APISchemaIBMWatsonx is the schema for the models deployed on IBM watsonx.ai.
+	// Requests are sent to the deployment-scoped text chat endpoints, where the deployment ID or serving name
+	// is taken from the model name, which is usually set by the ModelNameOverride of the backend reference.
+	// The backends are authenticated with the IBMCloudAPIKey BackendSecurityPolicy.
+	//
+	// https://cloud.ibm.com/apidocs/watsonx-ai#deployments-text-chat
+	APISchemaIBMWatsonx APISchema = "IBMWatsonx"
+	// APISchemaMock is the schema of the mock OpenAI provider built into the external processor, which returns
+	// deterministic completions for the e2e tests, the demos and the canary environments. The mock provider is
+	// served on a unix domain socket shared with Envoy when the controller enables it, and is referenced by a
+	// Backend with a unix endpoint at that path.
+	APISchemaMock APISchema = "Mock"
 )
 
 const (
@@ -127,6 +158,9 @@ type LLMRequestCost struct {
 	//	* output_tokens: the number of output tokens. Type: unsigned integer.
 	//	* total_tokens: the total number of tokens. Type: unsigned integer.
 	//	* reasoning_tokens: the number of reasoning tokens. Type: unsigned integer.
+	//	* image_count: the number of generated images. Type: unsigned integer.
+	//	* image_size: the size of the generated images, e.g. "1024x1024". Type: string.
+	//	* image_quality: the quality tier of the generated images, "low", "standard" or "high". Type: string.
 	//
 	// For example, the following expressions are valid:
 	//
@@ -135,6 +169,7 @@ type LLMRequestCost struct {
 	//	* "backend == 'bar.default' ?  (input_tokens - cached_input_tokens) + cached_input_tokens * 0.1 + cache_creation_input_tokens * 1.25 + output_tokens : total_tokens"
 	//	* "input_tokens + output_tokens + total_tokens"
 	//	* "input_tokens * output_tokens"
+	//	* "image_quality == 'high' ? image_count * 80u : image_count * 40u"
 	//
 	// +optional
 	CEL *string `json:"cel,omitempty"`
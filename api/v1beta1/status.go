@@ -14,15 +14,56 @@ const (
 	// ConditionTypeNotAccepted is a condition type for the reconciliation result
 	// where resources are not accepted.
 	ConditionTypeNotAccepted = "NotAccepted"
+	// ConditionTypeDegraded is a condition type set along with the Accepted condition of a route when the
+	// resources generated from it were modified out of band, e.g. with kubectl edit, and reverted by the controller.
+	// It is kept until the next change of the route.
+	ConditionTypeDegraded = "Degraded"
 )
 
 // AIGatewayRouteStatus contains the conditions by the reconciliation result.
 type AIGatewayRouteStatus struct {
 	// Conditions is the list of conditions by the reconciliation result.
-	// Currently, at most one condition is set.
+	// Currently, at most one condition is set, along with the Degraded condition when the generated resources
+	// were modified out of band.
 	//
-	// Known .status.conditions.type are: "Accepted", "NotAccepted".
+	// Known .status.conditions.type are: "Accepted", "NotAccepted", "Degraded".
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// Migrations is the comparison of the backends of the rules with a Migration with the backends they are
+	// migrated to, over the requests sampled since the start of the external processors currently running. It is
+	// periodically updated by the controller.
+	//
+	// +optional
+	// +kubebuilder:validation:MaxItems=128
+	Migrations []AIGatewayRouteMigrationStatus `json:"migrations,omitempty"`
+}
+
+// AIGatewayRouteMigrationStatus is the comparison of the backends of a rule with the backend it is migrated to.
+type AIGatewayRouteMigrationStatus struct {
+	// RuleIndex is the index of the rule in the rules of the route.
+	RuleIndex int32 `json:"ruleIndex"`
+
+	// BackendName is the name of the AIServiceBackend the rule is migrated to.
+	BackendName string `json:"backendName"`
+
+	// SampledRequests is the number of requests sent to the migration backend.
+	SampledRequests int64 `json:"sampledRequests"`
+
+	// ComparedRequests is the number of sampled requests successfully served by the migration backend, whose
+	// responses were compared. The other sampled requests failed on the migration backend.
+	ComparedRequests int64 `json:"comparedRequests"`
+
+	// AverageSimilarityPercent is the average similarity of the compared responses, from 0 for the responses
+	// without any word in common to 100 for the responses with the same words.
+	AverageSimilarityPercent int32 `json:"averageSimilarityPercent"`
+
+	// AverageLatencyMilliseconds and AverageMigrationLatencyMilliseconds are the average latencies of the compared
+	// requests on the backends of the rule and on the migration backend.
+	AverageLatencyMilliseconds          int64 `json:"averageLatencyMilliseconds"`
+	AverageMigrationLatencyMilliseconds int64 `json:"averageMigrationLatencyMilliseconds"`
+
+	// LastUpdateTime is the last time the comparison was updated.
+	LastUpdateTime metav1.Time `json:"lastUpdateTime"`
 }
 
 // AIServiceBackendStatus contains the conditions by the reconciliation result.
@@ -32,6 +73,53 @@ type AIServiceBackendStatus struct {
 	//
 	// Known .status.conditions.type are: "Accepted", "NotAccepted".
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// Capabilities is the result of the last probe of the models served by this backend and their features. It is
+	// periodically updated by the controller when the capability probing is enabled, for the backends of the OpenAI
+	// schema without a BackendSecurityPolicy or with an APIKey one.
+	//
+	// +optional
+	Capabilities *AIServiceBackendCapabilities `json:"capabilities,omitempty"`
+}
+
+// AIServiceBackendCapabilities is the result of a probe of the models served by a backend and their features.
+type AIServiceBackendCapabilities struct {
+	// Models are the models listed by the backend. The features are only probed for the models the AIGatewayRoutes
+	// route to the backend, i.e. the ModelNameOverride of their backend references or the model matched by the rule.
+	//
+	// +optional
+	// +kubebuilder:validation:MaxItems=64
+	Models []AIServiceBackendModelCapabilities `json:"models,omitempty"`
+
+	// Message is the error of the last probe, in which case the Models of the previous probe are kept.
+	//
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// LastProbeTime is the last time the backend was probed.
+	LastProbeTime metav1.Time `json:"lastProbeTime"`
+}
+
+// AIServiceBackendModelCapabilities is the features supported by a model served by a backend. The features are
+// unset when they were not probed.
+type AIServiceBackendModelCapabilities struct {
+	// Name is the name of the model in the backend.
+	Name string `json:"name"`
+
+	// Tools is true if the model accepts the chat completions with function tools.
+	//
+	// +optional
+	Tools *bool `json:"tools,omitempty"`
+
+	// Vision is true if the model accepts the chat completions with image inputs.
+	//
+	// +optional
+	Vision *bool `json:"vision,omitempty"`
+
+	// JSONMode is true if the model accepts the chat completions with the JSON object response format.
+	//
+	// +optional
+	JSONMode *bool `json:"jsonMode,omitempty"`
 }
 
 // BackendSecurityPolicyStatus contains the conditions by the reconciliation result.
@@ -41,13 +129,57 @@ type BackendSecurityPolicyStatus struct {
 	//
 	// Known .status.conditions.type are: "Accepted", "NotAccepted".
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// UsageReconciliation is the result of the last comparison of the token usage recorded by the gateway for the
+	// backends of this policy with the usage billed by the provider for the credential of this policy. It is only set
+	// when the usage reconciliation of the controller is enabled and the policy has the
+	// aigateway.envoyproxy.io/provider-usage-key annotation.
+	//
+	// +optional
+	UsageReconciliation *BackendSecurityPolicyUsageReconciliation `json:"usageReconciliation,omitempty"`
+}
+
+// BackendSecurityPolicyUsageReconciliation is the comparison of the token usage recorded by the gateway with the
+// usage billed by the provider over a window.
+type BackendSecurityPolicyUsageReconciliation struct {
+	// WindowStart and WindowEnd delimit the window [WindowStart, WindowEnd) of the compared usage.
+	WindowStart metav1.Time `json:"windowStart"`
+	WindowEnd   metav1.Time `json:"windowEnd"`
+
+	// Models is the usage compared per model. The usage of the providers not reporting the models, e.g. the AWS
+	// Cost and Usage Reports, is compared across all the models under an empty model.
+	//
+	// +optional
+	// +kubebuilder:validation:MaxItems=64
+	Models []BackendSecurityPolicyModelUsage `json:"models,omitempty"`
+}
+
+// BackendSecurityPolicyModelUsage is the token usage of a model recorded by the gateway and billed by the provider.
+type BackendSecurityPolicyModelUsage struct {
+	// Model is the name of the model, or empty for all the models.
+	//
+	// +optional
+	Model string `json:"model,omitempty"`
+
+	// GatewayInputTokens and GatewayOutputTokens are the tokens recorded by the gateway.
+	GatewayInputTokens  int64 `json:"gatewayInputTokens"`
+	GatewayOutputTokens int64 `json:"gatewayOutputTokens"`
+
+	// ProviderInputTokens and ProviderOutputTokens are the tokens billed by the provider.
+	ProviderInputTokens  int64 `json:"providerInputTokens"`
+	ProviderOutputTokens int64 `json:"providerOutputTokens"`
+
+	// Drifted is true when the usage billed by the provider differs from the usage recorded by the gateway by
+	// more than the drift threshold of the controller, e.g. because the credential is shared outside of the gateway.
+	Drifted bool `json:"drifted"`
 }
 
 // MCPRouteStatus contains the conditions by the reconciliation result.
 type MCPRouteStatus struct {
 	// Conditions is the list of conditions by the reconciliation result.
-	// Currently, at most one condition is set.
+	// Currently, at most one condition is set, along with the Degraded condition when the generated resources
+	// were modified out of band.
 	//
-	// Known .status.conditions.type are: "Accepted", "NotAccepted".
+	// Known .status.conditions.type are: "Accepted", "NotAccepted", "Degraded".
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
@@ -11,8 +11,10 @@ package v1beta1
 
 import (
 	"github.com/envoyproxy/gateway/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"sigs.k8s.io/gateway-api/apis/v1"
 	"sigs.k8s.io/gateway-api/apis/v1alpha2"
 )
@@ -44,6 +46,116 @@ func (in *AIGatewayRoute) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayRouteBackendOverride) DeepCopyInto(out *AIGatewayRouteBackendOverride) {
+	*out = *in
+	if in.SecretRef != nil {
+		in, out := &in.SecretRef, &out.SecretRef
+		*out = new(v1.SecretObjectReference)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteBackendOverride.
+func (in *AIGatewayRouteBackendOverride) DeepCopy() *AIGatewayRouteBackendOverride {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayRouteBackendOverride)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayRouteExperiment) DeepCopyInto(out *AIGatewayRouteExperiment) {
+	*out = *in
+	if in.Variants != nil {
+		in, out := &in.Variants, &out.Variants
+		*out = make([]AIGatewayRouteExperimentVariant, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteExperiment.
+func (in *AIGatewayRouteExperiment) DeepCopy() *AIGatewayRouteExperiment {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayRouteExperiment)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayRouteExperimentVariant) DeepCopyInto(out *AIGatewayRouteExperimentVariant) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteExperimentVariant.
+func (in *AIGatewayRouteExperimentVariant) DeepCopy() *AIGatewayRouteExperimentVariant {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayRouteExperimentVariant)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayRouteHeaderLimits) DeepCopyInto(out *AIGatewayRouteHeaderLimits) {
+	*out = *in
+	if in.MaxRequestHeadersKiB != nil {
+		in, out := &in.MaxRequestHeadersKiB, &out.MaxRequestHeadersKiB
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxHeadersCount != nil {
+		in, out := &in.MaxHeadersCount, &out.MaxHeadersCount
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteHeaderLimits.
+func (in *AIGatewayRouteHeaderLimits) DeepCopy() *AIGatewayRouteHeaderLimits {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayRouteHeaderLimits)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayRouteHistoryPolicy) DeepCopyInto(out *AIGatewayRouteHistoryPolicy) {
+	*out = *in
+	if in.MaxTurns != nil {
+		in, out := &in.MaxTurns, &out.MaxTurns
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxInputTokens != nil {
+		in, out := &in.MaxInputTokens, &out.MaxInputTokens
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Summarization != nil {
+		in, out := &in.Summarization, &out.Summarization
+		*out = new(HistorySummarization)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteHistoryPolicy.
+func (in *AIGatewayRouteHistoryPolicy) DeepCopy() *AIGatewayRouteHistoryPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayRouteHistoryPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AIGatewayRouteList) DeepCopyInto(out *AIGatewayRouteList) {
 	*out = *in
@@ -76,6 +188,147 @@ func (in *AIGatewayRouteList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayRouteLuaScript) DeepCopyInto(out *AIGatewayRouteLuaScript) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteLuaScript.
+func (in *AIGatewayRouteLuaScript) DeepCopy() *AIGatewayRouteLuaScript {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayRouteLuaScript)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayRouteMaxTokensBounds) DeepCopyInto(out *AIGatewayRouteMaxTokensBounds) {
+	*out = *in
+	if in.Min != nil {
+		in, out := &in.Min, &out.Min
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteMaxTokensBounds.
+func (in *AIGatewayRouteMaxTokensBounds) DeepCopy() *AIGatewayRouteMaxTokensBounds {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayRouteMaxTokensBounds)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayRouteMigrationStatus) DeepCopyInto(out *AIGatewayRouteMigrationStatus) {
+	*out = *in
+	in.LastUpdateTime.DeepCopyInto(&out.LastUpdateTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteMigrationStatus.
+func (in *AIGatewayRouteMigrationStatus) DeepCopy() *AIGatewayRouteMigrationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayRouteMigrationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayRouteModelVisibility) DeepCopyInto(out *AIGatewayRouteModelVisibility) {
+	*out = *in
+	if in.AllowedTenants != nil {
+		in, out := &in.AllowedTenants, &out.AllowedTenants
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteModelVisibility.
+func (in *AIGatewayRouteModelVisibility) DeepCopy() *AIGatewayRouteModelVisibility {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayRouteModelVisibility)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayRouteParameterOverrides) DeepCopyInto(out *AIGatewayRouteParameterOverrides) {
+	*out = *in
+	if in.Temperature != nil {
+		in, out := &in.Temperature, &out.Temperature
+		*out = new(AIGatewayRouteTemperatureBounds)
+		**out = **in
+	}
+	if in.MaxTokens != nil {
+		in, out := &in.MaxTokens, &out.MaxTokens
+		*out = new(AIGatewayRouteMaxTokensBounds)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteParameterOverrides.
+func (in *AIGatewayRouteParameterOverrides) DeepCopy() *AIGatewayRouteParameterOverrides {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayRouteParameterOverrides)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayRoutePostProcessing) DeepCopyInto(out *AIGatewayRoutePostProcessing) {
+	*out = *in
+	if in.Lua != nil {
+		in, out := &in.Lua, &out.Lua
+		*out = new(AIGatewayRouteLuaScript)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRoutePostProcessing.
+func (in *AIGatewayRoutePostProcessing) DeepCopy() *AIGatewayRoutePostProcessing {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayRoutePostProcessing)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayRouteReasoningBudget) DeepCopyInto(out *AIGatewayRouteReasoningBudget) {
+	*out = *in
+	if in.MaxReasoningTokens != nil {
+		in, out := &in.MaxReasoningTokens, &out.MaxReasoningTokens
+		*out = new(int32)
+		**out = **in
+	}
+	if in.HardStop != nil {
+		in, out := &in.HardStop, &out.HardStop
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteReasoningBudget.
+func (in *AIGatewayRouteReasoningBudget) DeepCopy() *AIGatewayRouteReasoningBudget {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayRouteReasoningBudget)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AIGatewayRouteRule) DeepCopyInto(out *AIGatewayRouteRule) {
 	*out = *in
@@ -108,6 +361,11 @@ func (in *AIGatewayRouteRule) DeepCopyInto(out *AIGatewayRouteRule) {
 		*out = new(v1.Duration)
 		**out = **in
 	}
+	if in.StreamStallTimeout != nil {
+		in, out := &in.StreamStallTimeout, &out.StreamStallTimeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
 	if in.ModelsOwnedBy != nil {
 		in, out := &in.ModelsOwnedBy, &out.ModelsOwnedBy
 		*out = new(string)
@@ -117,6 +375,26 @@ func (in *AIGatewayRouteRule) DeepCopyInto(out *AIGatewayRouteRule) {
 		in, out := &in.ModelsCreatedAt, &out.ModelsCreatedAt
 		*out = (*in).DeepCopy()
 	}
+	if in.ModelsContextWindows != nil {
+		in, out := &in.ModelsContextWindows, &out.ModelsContextWindows
+		*out = make([]AIGatewayRouteRuleModelContextWindow, len(*in))
+		copy(*out, *in)
+	}
+	if in.ContextLengthRetry != nil {
+		in, out := &in.ContextLengthRetry, &out.ContextLengthRetry
+		*out = new(AIGatewayRouteRuleContextLengthRetry)
+		**out = **in
+	}
+	if in.Migration != nil {
+		in, out := &in.Migration, &out.Migration
+		*out = new(AIGatewayRouteRuleMigration)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.FailoverRetry != nil {
+		in, out := &in.FailoverRetry, &out.FailoverRetry
+		*out = new(AIGatewayRouteRuleFailoverRetry)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteRule.
@@ -179,6 +457,41 @@ func (in *AIGatewayRouteRuleBackendRef) DeepCopy() *AIGatewayRouteRuleBackendRef
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayRouteRuleContextLengthRetry) DeepCopyInto(out *AIGatewayRouteRuleContextLengthRetry) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteRuleContextLengthRetry.
+func (in *AIGatewayRouteRuleContextLengthRetry) DeepCopy() *AIGatewayRouteRuleContextLengthRetry {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayRouteRuleContextLengthRetry)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayRouteRuleFailoverRetry) DeepCopyInto(out *AIGatewayRouteRuleFailoverRetry) {
+	*out = *in
+	if in.MaxRetries != nil {
+		in, out := &in.MaxRetries, &out.MaxRetries
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteRuleFailoverRetry.
+func (in *AIGatewayRouteRuleFailoverRetry) DeepCopy() *AIGatewayRouteRuleFailoverRetry {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayRouteRuleFailoverRetry)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AIGatewayRouteRuleMatch) DeepCopyInto(out *AIGatewayRouteRuleMatch) {
 	*out = *in
@@ -201,6 +514,42 @@ func (in *AIGatewayRouteRuleMatch) DeepCopy() *AIGatewayRouteRuleMatch {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayRouteRuleMigration) DeepCopyInto(out *AIGatewayRouteRuleMigration) {
+	*out = *in
+	in.BackendRef.DeepCopyInto(&out.BackendRef)
+	if in.SamplePercent != nil {
+		in, out := &in.SamplePercent, &out.SamplePercent
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteRuleMigration.
+func (in *AIGatewayRouteRuleMigration) DeepCopy() *AIGatewayRouteRuleMigration {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayRouteRuleMigration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayRouteRuleModelContextWindow) DeepCopyInto(out *AIGatewayRouteRuleModelContextWindow) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteRuleModelContextWindow.
+func (in *AIGatewayRouteRuleModelContextWindow) DeepCopy() *AIGatewayRouteRuleModelContextWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayRouteRuleModelContextWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AIGatewayRouteSpec) DeepCopyInto(out *AIGatewayRouteSpec) {
 	*out = *in
@@ -230,6 +579,56 @@ func (in *AIGatewayRouteSpec) DeepCopyInto(out *AIGatewayRouteSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.PostProcessing != nil {
+		in, out := &in.PostProcessing, &out.PostProcessing
+		*out = new(AIGatewayRoutePostProcessing)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Experiment != nil {
+		in, out := &in.Experiment, &out.Experiment
+		*out = new(AIGatewayRouteExperiment)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.HeaderLimits != nil {
+		in, out := &in.HeaderLimits, &out.HeaderLimits
+		*out = new(AIGatewayRouteHeaderLimits)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ModelVisibility != nil {
+		in, out := &in.ModelVisibility, &out.ModelVisibility
+		*out = new(AIGatewayRouteModelVisibility)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ParameterOverrides != nil {
+		in, out := &in.ParameterOverrides, &out.ParameterOverrides
+		*out = new(AIGatewayRouteParameterOverrides)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.BackendOverride != nil {
+		in, out := &in.BackendOverride, &out.BackendOverride
+		*out = new(AIGatewayRouteBackendOverride)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.HistoryPolicy != nil {
+		in, out := &in.HistoryPolicy, &out.HistoryPolicy
+		*out = new(AIGatewayRouteHistoryPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ReasoningBudget != nil {
+		in, out := &in.ReasoningBudget, &out.ReasoningBudget
+		*out = new(AIGatewayRouteReasoningBudget)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ToolCallLoopGuard != nil {
+		in, out := &in.ToolCallLoopGuard, &out.ToolCallLoopGuard
+		*out = new(AIGatewayRouteToolCallLoopGuard)
+		**out = **in
+	}
+	if in.AllowedEndpoints != nil {
+		in, out := &in.AllowedEndpoints, &out.AllowedEndpoints
+		*out = make([]AIGatewayRouteEndpoint, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteSpec.
@@ -252,6 +651,13 @@ func (in *AIGatewayRouteStatus) DeepCopyInto(out *AIGatewayRouteStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Migrations != nil {
+		in, out := &in.Migrations, &out.Migrations
+		*out = make([]AIGatewayRouteMigrationStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteStatus.
@@ -264,6 +670,36 @@ func (in *AIGatewayRouteStatus) DeepCopy() *AIGatewayRouteStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayRouteTemperatureBounds) DeepCopyInto(out *AIGatewayRouteTemperatureBounds) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteTemperatureBounds.
+func (in *AIGatewayRouteTemperatureBounds) DeepCopy() *AIGatewayRouteTemperatureBounds {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayRouteTemperatureBounds)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayRouteToolCallLoopGuard) DeepCopyInto(out *AIGatewayRouteToolCallLoopGuard) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteToolCallLoopGuard.
+func (in *AIGatewayRouteToolCallLoopGuard) DeepCopy() *AIGatewayRouteToolCallLoopGuard {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayRouteToolCallLoopGuard)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AIServiceBackend) DeepCopyInto(out *AIServiceBackend) {
 	*out = *in
@@ -291,6 +727,29 @@ func (in *AIServiceBackend) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIServiceBackendCapabilities) DeepCopyInto(out *AIServiceBackendCapabilities) {
+	*out = *in
+	if in.Models != nil {
+		in, out := &in.Models, &out.Models
+		*out = make([]AIServiceBackendModelCapabilities, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.LastProbeTime.DeepCopyInto(&out.LastProbeTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIServiceBackendCapabilities.
+func (in *AIServiceBackendCapabilities) DeepCopy() *AIServiceBackendCapabilities {
+	if in == nil {
+		return nil
+	}
+	out := new(AIServiceBackendCapabilities)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AIServiceBackendList) DeepCopyInto(out *AIServiceBackendList) {
 	*out = *in
@@ -323,6 +782,36 @@ func (in *AIServiceBackendList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIServiceBackendModelCapabilities) DeepCopyInto(out *AIServiceBackendModelCapabilities) {
+	*out = *in
+	if in.Tools != nil {
+		in, out := &in.Tools, &out.Tools
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Vision != nil {
+		in, out := &in.Vision, &out.Vision
+		*out = new(bool)
+		**out = **in
+	}
+	if in.JSONMode != nil {
+		in, out := &in.JSONMode, &out.JSONMode
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIServiceBackendModelCapabilities.
+func (in *AIServiceBackendModelCapabilities) DeepCopy() *AIServiceBackendModelCapabilities {
+	if in == nil {
+		return nil
+	}
+	out := new(AIServiceBackendModelCapabilities)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AIServiceBackendSpec) DeepCopyInto(out *AIServiceBackendSpec) {
 	*out = *in
@@ -338,6 +827,36 @@ func (in *AIServiceBackendSpec) DeepCopyInto(out *AIServiceBackendSpec) {
 		*out = new(HTTPBodyMutation)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.PIITokenization != nil {
+		in, out := &in.PIITokenization, &out.PIITokenization
+		*out = new(PIITokenization)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ExtraBody != nil {
+		in, out := &in.ExtraBody, &out.ExtraBody
+		*out = new(BackendExtraBody)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.OutlierDetection != nil {
+		in, out := &in.OutlierDetection, &out.OutlierDetection
+		*out = new(BackendOutlierDetection)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Retry != nil {
+		in, out := &in.Retry, &out.Retry
+		*out = new(BackendRetry)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Timeouts != nil {
+		in, out := &in.Timeouts, &out.Timeouts
+		*out = new(BackendTimeouts)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LoadReporting != nil {
+		in, out := &in.LoadReporting, &out.LoadReporting
+		*out = new(BackendLoadReporting)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIServiceBackendSpec.
@@ -360,6 +879,11 @@ func (in *AIServiceBackendStatus) DeepCopyInto(out *AIServiceBackendStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Capabilities != nil {
+		in, out := &in.Capabilities, &out.Capabilities
+		*out = new(AIServiceBackendCapabilities)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIServiceBackendStatus.
@@ -424,6 +948,136 @@ func (in *AzureOIDCExchangeToken) DeepCopy() *AzureOIDCExchangeToken {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AzureWorkloadIdentity) DeepCopyInto(out *AzureWorkloadIdentity) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzureWorkloadIdentity.
+func (in *AzureWorkloadIdentity) DeepCopy() *AzureWorkloadIdentity {
+	if in == nil {
+		return nil
+	}
+	out := new(AzureWorkloadIdentity)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackendExtraBody) DeepCopyInto(out *BackendExtraBody) {
+	*out = *in
+	if in.AllowedFields != nil {
+		in, out := &in.AllowedFields, &out.AllowedFields
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackendExtraBody.
+func (in *BackendExtraBody) DeepCopy() *BackendExtraBody {
+	if in == nil {
+		return nil
+	}
+	out := new(BackendExtraBody)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackendLoadReporting) DeepCopyInto(out *BackendLoadReporting) {
+	*out = *in
+	if in.MaxQueueDepth != nil {
+		in, out := &in.MaxQueueDepth, &out.MaxQueueDepth
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxLoadPercent != nil {
+		in, out := &in.MaxLoadPercent, &out.MaxLoadPercent
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ReportTTL != nil {
+		in, out := &in.ReportTTL, &out.ReportTTL
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackendLoadReporting.
+func (in *BackendLoadReporting) DeepCopy() *BackendLoadReporting {
+	if in == nil {
+		return nil
+	}
+	out := new(BackendLoadReporting)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackendOutlierDetection) DeepCopyInto(out *BackendOutlierDetection) {
+	*out = *in
+	if in.Consecutive5xxErrors != nil {
+		in, out := &in.Consecutive5xxErrors, &out.Consecutive5xxErrors
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.BaseEjectionTime != nil {
+		in, out := &in.BaseEjectionTime, &out.BaseEjectionTime
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.MaxEjectionPercent != nil {
+		in, out := &in.MaxEjectionPercent, &out.MaxEjectionPercent
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackendOutlierDetection.
+func (in *BackendOutlierDetection) DeepCopy() *BackendOutlierDetection {
+	if in == nil {
+		return nil
+	}
+	out := new(BackendOutlierDetection)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackendRetry) DeepCopyInto(out *BackendRetry) {
+	*out = *in
+	if in.NumRetries != nil {
+		in, out := &in.NumRetries, &out.NumRetries
+		*out = new(int32)
+		**out = **in
+	}
+	if in.StatusCodes != nil {
+		in, out := &in.StatusCodes, &out.StatusCodes
+		*out = make([]int32, len(*in))
+		copy(*out, *in)
+	}
+	if in.PerTryTimeout != nil {
+		in, out := &in.PerTryTimeout, &out.PerTryTimeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackendRetry.
+func (in *BackendRetry) DeepCopy() *BackendRetry {
+	if in == nil {
+		return nil
+	}
+	out := new(BackendRetry)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BackendSecurityPolicy) DeepCopyInto(out *BackendSecurityPolicy) {
 	*out = *in
@@ -459,14 +1113,46 @@ func (in *BackendSecurityPolicyAPIKey) DeepCopyInto(out *BackendSecurityPolicyAP
 		*out = new(v1.SecretObjectReference)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Pool != nil {
+		in, out := &in.Pool, &out.Pool
+		*out = make([]BackendSecurityPolicyAPIKeyPoolEntry, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackendSecurityPolicyAPIKey.
+func (in *BackendSecurityPolicyAPIKey) DeepCopy() *BackendSecurityPolicyAPIKey {
+	if in == nil {
+		return nil
+	}
+	out := new(BackendSecurityPolicyAPIKey)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackendSecurityPolicyAPIKeyPoolEntry) DeepCopyInto(out *BackendSecurityPolicyAPIKeyPoolEntry) {
+	*out = *in
+	if in.SecretRef != nil {
+		in, out := &in.SecretRef, &out.SecretRef
+		*out = new(v1.SecretObjectReference)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Weight != nil {
+		in, out := &in.Weight, &out.Weight
+		*out = new(int32)
+		**out = **in
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackendSecurityPolicyAPIKey.
-func (in *BackendSecurityPolicyAPIKey) DeepCopy() *BackendSecurityPolicyAPIKey {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackendSecurityPolicyAPIKeyPoolEntry.
+func (in *BackendSecurityPolicyAPIKeyPoolEntry) DeepCopy() *BackendSecurityPolicyAPIKeyPoolEntry {
 	if in == nil {
 		return nil
 	}
-	out := new(BackendSecurityPolicyAPIKey)
+	out := new(BackendSecurityPolicyAPIKeyPoolEntry)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -474,6 +1160,11 @@ func (in *BackendSecurityPolicyAPIKey) DeepCopy() *BackendSecurityPolicyAPIKey {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BackendSecurityPolicyAWSCredentials) DeepCopyInto(out *BackendSecurityPolicyAWSCredentials) {
 	*out = *in
+	if in.RegionSet != nil {
+		in, out := &in.RegionSet, &out.RegionSet
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.CredentialsFile != nil {
 		in, out := &in.CredentialsFile, &out.CredentialsFile
 		*out = new(AWSCredentialsFile)
@@ -549,6 +1240,11 @@ func (in *BackendSecurityPolicyAzureCredentials) DeepCopyInto(out *BackendSecuri
 		*out = new(AzureOIDCExchangeToken)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.WorkloadIdentity != nil {
+		in, out := &in.WorkloadIdentity, &out.WorkloadIdentity
+		*out = new(AzureWorkloadIdentity)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackendSecurityPolicyAzureCredentials.
@@ -586,6 +1282,26 @@ func (in *BackendSecurityPolicyCredentialOverride) DeepCopy() *BackendSecurityPo
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackendSecurityPolicyEgress) DeepCopyInto(out *BackendSecurityPolicyEgress) {
+	*out = *in
+	if in.CACertificateRefs != nil {
+		in, out := &in.CACertificateRefs, &out.CACertificateRefs
+		*out = make([]v1.LocalObjectReference, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackendSecurityPolicyEgress.
+func (in *BackendSecurityPolicyEgress) DeepCopy() *BackendSecurityPolicyEgress {
+	if in == nil {
+		return nil
+	}
+	out := new(BackendSecurityPolicyEgress)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BackendSecurityPolicyGCPCredentials) DeepCopyInto(out *BackendSecurityPolicyGCPCredentials) {
 	*out = *in
@@ -611,6 +1327,26 @@ func (in *BackendSecurityPolicyGCPCredentials) DeepCopy() *BackendSecurityPolicy
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackendSecurityPolicyIBMCloudAPIKey) DeepCopyInto(out *BackendSecurityPolicyIBMCloudAPIKey) {
+	*out = *in
+	if in.SecretRef != nil {
+		in, out := &in.SecretRef, &out.SecretRef
+		*out = new(v1.SecretObjectReference)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackendSecurityPolicyIBMCloudAPIKey.
+func (in *BackendSecurityPolicyIBMCloudAPIKey) DeepCopy() *BackendSecurityPolicyIBMCloudAPIKey {
+	if in == nil {
+		return nil
+	}
+	out := new(BackendSecurityPolicyIBMCloudAPIKey)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BackendSecurityPolicyList) DeepCopyInto(out *BackendSecurityPolicyList) {
 	*out = *in
@@ -643,6 +1379,21 @@ func (in *BackendSecurityPolicyList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackendSecurityPolicyModelUsage) DeepCopyInto(out *BackendSecurityPolicyModelUsage) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackendSecurityPolicyModelUsage.
+func (in *BackendSecurityPolicyModelUsage) DeepCopy() *BackendSecurityPolicyModelUsage {
+	if in == nil {
+		return nil
+	}
+	out := new(BackendSecurityPolicyModelUsage)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BackendSecurityPolicyOIDC) DeepCopyInto(out *BackendSecurityPolicyOIDC) {
 	*out = *in
@@ -659,6 +1410,26 @@ func (in *BackendSecurityPolicyOIDC) DeepCopy() *BackendSecurityPolicyOIDC {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackendSecurityPolicySAPAICoreCredentials) DeepCopyInto(out *BackendSecurityPolicySAPAICoreCredentials) {
+	*out = *in
+	if in.ClientSecretRef != nil {
+		in, out := &in.ClientSecretRef, &out.ClientSecretRef
+		*out = new(v1.SecretObjectReference)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackendSecurityPolicySAPAICoreCredentials.
+func (in *BackendSecurityPolicySAPAICoreCredentials) DeepCopy() *BackendSecurityPolicySAPAICoreCredentials {
+	if in == nil {
+		return nil
+	}
+	out := new(BackendSecurityPolicySAPAICoreCredentials)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BackendSecurityPolicySpec) DeepCopyInto(out *BackendSecurityPolicySpec) {
 	*out = *in
@@ -697,11 +1468,26 @@ func (in *BackendSecurityPolicySpec) DeepCopyInto(out *BackendSecurityPolicySpec
 		*out = new(BackendSecurityPolicyAnthropicAPIKey)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.IBMCloudAPIKey != nil {
+		in, out := &in.IBMCloudAPIKey, &out.IBMCloudAPIKey
+		*out = new(BackendSecurityPolicyIBMCloudAPIKey)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SAPAICoreCredentials != nil {
+		in, out := &in.SAPAICoreCredentials, &out.SAPAICoreCredentials
+		*out = new(BackendSecurityPolicySAPAICoreCredentials)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.CredentialOverride != nil {
 		in, out := &in.CredentialOverride, &out.CredentialOverride
 		*out = new(BackendSecurityPolicyCredentialOverride)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Egress != nil {
+		in, out := &in.Egress, &out.Egress
+		*out = new(BackendSecurityPolicyEgress)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackendSecurityPolicySpec.
@@ -724,6 +1510,11 @@ func (in *BackendSecurityPolicyStatus) DeepCopyInto(out *BackendSecurityPolicySt
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.UsageReconciliation != nil {
+		in, out := &in.UsageReconciliation, &out.UsageReconciliation
+		*out = new(BackendSecurityPolicyUsageReconciliation)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackendSecurityPolicyStatus.
@@ -736,6 +1527,58 @@ func (in *BackendSecurityPolicyStatus) DeepCopy() *BackendSecurityPolicyStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackendSecurityPolicyUsageReconciliation) DeepCopyInto(out *BackendSecurityPolicyUsageReconciliation) {
+	*out = *in
+	in.WindowStart.DeepCopyInto(&out.WindowStart)
+	in.WindowEnd.DeepCopyInto(&out.WindowEnd)
+	if in.Models != nil {
+		in, out := &in.Models, &out.Models
+		*out = make([]BackendSecurityPolicyModelUsage, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackendSecurityPolicyUsageReconciliation.
+func (in *BackendSecurityPolicyUsageReconciliation) DeepCopy() *BackendSecurityPolicyUsageReconciliation {
+	if in == nil {
+		return nil
+	}
+	out := new(BackendSecurityPolicyUsageReconciliation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackendTimeouts) DeepCopyInto(out *BackendTimeouts) {
+	*out = *in
+	if in.Connect != nil {
+		in, out := &in.Connect, &out.Connect
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.FirstByte != nil {
+		in, out := &in.FirstByte, &out.FirstByte
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.Completion != nil {
+		in, out := &in.Completion, &out.Completion
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackendTimeouts.
+func (in *BackendTimeouts) DeepCopy() *BackendTimeouts {
+	if in == nil {
+		return nil
+	}
+	out := new(BackendTimeouts)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CredentialOverrideFromDynamicMetadata) DeepCopyInto(out *CredentialOverrideFromDynamicMetadata) {
 	*out = *in
@@ -776,6 +1619,26 @@ func (in *CredentialOverrideFromRequestHeaders) DeepCopy() *CredentialOverrideFr
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FallbackResponse) DeepCopyInto(out *FallbackResponse) {
+	*out = *in
+	if in.StatusCodes != nil {
+		in, out := &in.StatusCodes, &out.StatusCodes
+		*out = make([]int32, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FallbackResponse.
+func (in *FallbackResponse) DeepCopy() *FallbackResponse {
+	if in == nil {
+		return nil
+	}
+	out := new(FallbackResponse)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GCPCredentialsFile) DeepCopyInto(out *GCPCredentialsFile) {
 	*out = *in
@@ -899,6 +1762,18 @@ func (in *GatewayConfigExtProc) DeepCopyInto(out *GatewayConfigExtProc) {
 		*out = new(v1alpha1.KubernetesContainerSpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.PodDisruptionBudget != nil {
+		in, out := &in.PodDisruptionBudget, &out.PodDisruptionBudget
+		*out = new(GatewayConfigExtProcPodDisruptionBudget)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TopologySpreadConstraints != nil {
+		in, out := &in.TopologySpreadConstraints, &out.TopologySpreadConstraints
+		*out = make([]corev1.TopologySpreadConstraint, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GatewayConfigExtProc.
@@ -911,6 +1786,31 @@ func (in *GatewayConfigExtProc) DeepCopy() *GatewayConfigExtProc {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GatewayConfigExtProcPodDisruptionBudget) DeepCopyInto(out *GatewayConfigExtProcPodDisruptionBudget) {
+	*out = *in
+	if in.MinAvailable != nil {
+		in, out := &in.MinAvailable, &out.MinAvailable
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+	if in.MaxUnavailable != nil {
+		in, out := &in.MaxUnavailable, &out.MaxUnavailable
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GatewayConfigExtProcPodDisruptionBudget.
+func (in *GatewayConfigExtProcPodDisruptionBudget) DeepCopy() *GatewayConfigExtProcPodDisruptionBudget {
+	if in == nil {
+		return nil
+	}
+	out := new(GatewayConfigExtProcPodDisruptionBudget)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GatewayConfigList) DeepCopyInto(out *GatewayConfigList) {
 	*out = *in
@@ -958,6 +1858,33 @@ func (in *GatewayConfigSpec) DeepCopyInto(out *GatewayConfigSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.StreamConcurrencyLimits != nil {
+		in, out := &in.StreamConcurrencyLimits, &out.StreamConcurrencyLimits
+		*out = make([]StreamConcurrencyLimit, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.StreamCoalescing != nil {
+		in, out := &in.StreamCoalescing, &out.StreamCoalescing
+		*out = new(StreamCoalescing)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PromptInjectionDetection != nil {
+		in, out := &in.PromptInjectionDetection, &out.PromptInjectionDetection
+		*out = new(PromptInjectionDetection)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.FallbackResponse != nil {
+		in, out := &in.FallbackResponse, &out.FallbackResponse
+		*out = new(FallbackResponse)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ResponseAttestation != nil {
+		in, out := &in.ResponseAttestation, &out.ResponseAttestation
+		*out = new(ResponseAttestation)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GatewayConfigSpec.
@@ -1057,6 +1984,26 @@ func (in *HTTPHeaderMutation) DeepCopy() *HTTPHeaderMutation {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HistorySummarization) DeepCopyInto(out *HistorySummarization) {
+	*out = *in
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HistorySummarization.
+func (in *HistorySummarization) DeepCopy() *HistorySummarization {
+	if in == nil {
+		return nil
+	}
+	out := new(HistorySummarization)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *JWKS) DeepCopyInto(out *JWKS) {
 	*out = *in
@@ -1568,6 +2515,66 @@ func (in *MCPToolFilter) DeepCopy() *MCPToolFilter {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PIIPattern) DeepCopyInto(out *PIIPattern) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PIIPattern.
+func (in *PIIPattern) DeepCopy() *PIIPattern {
+	if in == nil {
+		return nil
+	}
+	out := new(PIIPattern)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PIITokenization) DeepCopyInto(out *PIITokenization) {
+	*out = *in
+	if in.Detectors != nil {
+		in, out := &in.Detectors, &out.Detectors
+		*out = make([]PIIDetectorType, len(*in))
+		copy(*out, *in)
+	}
+	if in.CustomPatterns != nil {
+		in, out := &in.CustomPatterns, &out.CustomPatterns
+		*out = make([]PIIPattern, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PIITokenization.
+func (in *PIITokenization) DeepCopy() *PIITokenization {
+	if in == nil {
+		return nil
+	}
+	out := new(PIITokenization)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PromptInjectionDetection) DeepCopyInto(out *PromptInjectionDetection) {
+	*out = *in
+	if in.BlockThreshold != nil {
+		in, out := &in.BlockThreshold, &out.BlockThreshold
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PromptInjectionDetection.
+func (in *PromptInjectionDetection) DeepCopy() *PromptInjectionDetection {
+	if in == nil {
+		return nil
+	}
+	out := new(PromptInjectionDetection)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ProtectedResourceMetadata) DeepCopyInto(out *ProtectedResourceMetadata) {
 	*out = *in
@@ -1608,6 +2615,76 @@ func (in *ProtectedResourceMetadata) DeepCopy() *ProtectedResourceMetadata {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResponseAttestation) DeepCopyInto(out *ResponseAttestation) {
+	*out = *in
+	if in.SecretRef != nil {
+		in, out := &in.SecretRef, &out.SecretRef
+		*out = new(v1.SecretObjectReference)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResponseAttestation.
+func (in *ResponseAttestation) DeepCopy() *ResponseAttestation {
+	if in == nil {
+		return nil
+	}
+	out := new(ResponseAttestation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StreamCoalescing) DeepCopyInto(out *StreamCoalescing) {
+	*out = *in
+	if in.MinBytes != nil {
+		in, out := &in.MinBytes, &out.MinBytes
+		*out = new(int32)
+		**out = **in
+	}
+	if in.FlushInterval != nil {
+		in, out := &in.FlushInterval, &out.FlushInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StreamCoalescing.
+func (in *StreamCoalescing) DeepCopy() *StreamCoalescing {
+	if in == nil {
+		return nil
+	}
+	out := new(StreamCoalescing)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StreamConcurrencyLimit) DeepCopyInto(out *StreamConcurrencyLimit) {
+	*out = *in
+	if in.Headers != nil {
+		in, out := &in.Headers, &out.Headers
+		*out = make([]v1.HeaderName, len(*in))
+		copy(*out, *in)
+	}
+	if in.RetryAfter != nil {
+		in, out := &in.RetryAfter, &out.RetryAfter
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StreamConcurrencyLimit.
+func (in *StreamConcurrencyLimit) DeepCopy() *StreamConcurrencyLimit {
+	if in == nil {
+		return nil
+	}
+	out := new(StreamConcurrencyLimit)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ToolCall) DeepCopyInto(out *ToolCall) {
 	*out = *in
@@ -0,0 +1,370 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/json"
+)
+
+const (
+	// benchWorkloadChat sends chat completions, a part of which are streamed.
+	benchWorkloadChat = "chat"
+	// benchWorkloadEmbeddings sends embeddings requests.
+	benchWorkloadEmbeddings = "embeddings"
+
+	// benchErrorUnreachable is the error of a request that failed before receiving a response.
+	benchErrorUnreachable = "unreachable"
+	// benchErrorInvalidResponse is the error of a successful response that couldn't be read.
+	benchErrorInvalidResponse = "invalid response"
+)
+
+// benchWords are the words the synthetic prompts are made of. Each of them is a single token for the common
+// tokenizers, so the number of words of a prompt approximates its number of tokens.
+var benchWords = []string{
+	"the", "gateway", "routes", "each", "request", "to", "a", "model", "and", "reports", "how", "many",
+	"tokens", "it", "used", "while", "streaming", "data", "from", "cloud", "providers", "with", "low", "latency",
+}
+
+// benchResult is the result of a request sent by `aigw bench`.
+type benchResult struct {
+	latency time.Duration
+	// ttft is the time to the first token of a streamed chat completion, zero otherwise.
+	ttft                      time.Duration
+	inputTokens, outputTokens int
+	// err is the class of the error of a failed request, e.g. "HTTP 429", empty on success.
+	err string
+}
+
+// benchPercentiles are the percentiles of the values measured by `aigw bench`.
+type benchPercentiles struct {
+	P50 float64 `json:"p50"`
+	P90 float64 `json:"p90"`
+	P99 float64 `json:"p99"`
+	Max float64 `json:"max"`
+}
+
+// benchReport is the report of `aigw bench`.
+type benchReport struct {
+	Workload              string  `json:"workload"`
+	Requests              int     `json:"requests"`
+	Succeeded             int     `json:"succeeded"`
+	Failed                int     `json:"failed"`
+	Streamed              int     `json:"streamed"`
+	DurationMs            int64   `json:"durationMs"`
+	RequestsPerSecond     float64 `json:"requestsPerSecond"`
+	InputTokensPerSecond  float64 `json:"inputTokensPerSecond"`
+	OutputTokensPerSecond float64 `json:"outputTokensPerSecond"`
+	// LatencyMs are the percentiles of the latency of the successful requests.
+	LatencyMs benchPercentiles `json:"latencyMs"`
+	// TimeToFirstTokenMs are the percentiles of the time to first token of the successful streamed requests.
+	TimeToFirstTokenMs *benchPercentiles `json:"timeToFirstTokenMs,omitempty"`
+	// OutputTokenRate are the percentiles of the output tokens per second of the successful chat completions,
+	// measured after the first token for the streamed ones.
+	OutputTokenRate *benchPercentiles `json:"outputTokenRate,omitempty"`
+	// Errors are the number of failed requests per class of error.
+	Errors map[string]int `json:"errors,omitempty"`
+}
+
+// bench sends the synthetic workload configured by c to the gateway, writes the report to stdout, and returns
+// an error if any of the requests failed.
+func bench(ctx context.Context, c *cmdBench, stdout, _ io.Writer) error {
+	report := runBench(ctx, c, &http.Client{Timeout: c.Timeout})
+	if err := writeBenchReport(stdout, c.Output, report); err != nil {
+		return err
+	}
+	if report.Requests == 0 {
+		return errors.New("no requests were sent")
+	}
+	if report.Failed > 0 {
+		return fmt.Errorf("%d of %d requests failed", report.Failed, report.Requests)
+	}
+	return nil
+}
+
+// runBench sends the requests with c.Concurrency workers until c.Requests are sent or c.Duration elapsed,
+// whichever comes first, and returns the report of their results.
+func runBench(ctx context.Context, c *cmdBench, client *http.Client) *benchReport {
+	if c.Duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Duration)
+		defer cancel()
+	}
+	endpoint := strings.TrimSuffix(c.Endpoint, "/")
+
+	var (
+		next    atomic.Int64
+		mu      sync.Mutex
+		results []benchResult
+		wg      sync.WaitGroup
+	)
+	start := time.Now()
+	for range c.Concurrency {
+		wg.Go(func() {
+			for ctx.Err() == nil {
+				i := int(next.Add(1) - 1)
+				if c.Requests > 0 && i >= c.Requests {
+					return
+				}
+				result := sendBenchRequest(ctx, c, client, endpoint, i)
+				if ctx.Err() != nil && result.err != "" {
+					return // The request was interrupted at the end of the benchmark.
+				}
+				mu.Lock()
+				results = append(results, result)
+				mu.Unlock()
+			}
+		})
+	}
+	wg.Wait()
+	return newBenchReport(c, results, time.Since(start))
+}
+
+// sendBenchRequest sends the i-th request of the workload.
+func sendBenchRequest(ctx context.Context, c *cmdBench, client *http.Client, endpoint string, i int) benchResult {
+	model := c.Models[i%len(c.Models)]
+	if c.Workload == benchWorkloadEmbeddings {
+		body, _ := json.Marshal(openai.EmbeddingCompletionRequest{
+			EmbeddingBaseRequest: openai.EmbeddingBaseRequest{Model: model},
+			Input:                openai.EmbeddingRequestInput{Value: benchPrompt(c.PromptTokens)},
+		})
+		return doBenchRequest(ctx, c, client, endpoint+"/v1/embeddings", body, false)
+	}
+
+	maxTokens := int64(c.MaxTokens)
+	req := openai.ChatCompletionRequest{
+		Model: model,
+		Messages: []openai.ChatCompletionMessageParamUnion{{
+			OfUser: &openai.ChatCompletionUserMessageParam{
+				Role:    openai.ChatMessageRoleUser,
+				Content: openai.StringOrUserRoleContentUnion{Value: benchPrompt(c.PromptTokens)},
+			},
+		}},
+		MaxTokens: &maxTokens,
+	}
+	// The requests are streamed evenly, so that any number of requests has the closest ratio of streamed ones.
+	if math.Floor(float64(i+1)*c.StreamRatio) > math.Floor(float64(i)*c.StreamRatio) {
+		req.Stream = true
+		req.StreamOptions = &openai.StreamOptions{IncludeUsage: true}
+	}
+	body, _ := json.Marshal(req)
+	return doBenchRequest(ctx, c, client, endpoint+"/v1/chat/completions", body, req.Stream)
+}
+
+// benchPrompt returns a prompt of random words, so that the responses are not served from a prompt cache.
+func benchPrompt(tokens int) string {
+	words := make([]string, max(tokens, 1))
+	for i := range words {
+		words[i] = benchWords[rand.IntN(len(benchWords))] //nolint:gosec // not used for security.
+	}
+	return strings.Join(words, " ")
+}
+
+// doBenchRequest sends the request and measures its response.
+func doBenchRequest(ctx context.Context, c *cmdBench, client *http.Client, url string, body []byte, stream bool) benchResult {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return benchResult{err: benchErrorUnreachable}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return benchResult{err: benchErrorUnreachable}
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return benchResult{err: fmt.Sprintf("HTTP %d", resp.StatusCode)}
+	}
+
+	var result benchResult
+	if stream {
+		err = readBenchStream(resp.Body, start, &result)
+	} else {
+		err = readBenchResponse(resp.Body, &result)
+	}
+	result.latency = time.Since(start)
+	if err != nil {
+		return benchResult{err: benchErrorInvalidResponse}
+	}
+	return result
+}
+
+// readBenchResponse reads the token usage of a chat completion or embeddings response.
+func readBenchResponse(body io.Reader, result *benchResult) error {
+	raw, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	// The chat completion and the embeddings responses have the same usage fields.
+	var resp struct {
+		Usage *openai.Usage `json:"usage"`
+	}
+	if err = json.Unmarshal(raw, &resp); err != nil {
+		return err
+	}
+	if resp.Usage != nil {
+		result.inputTokens, result.outputTokens = resp.Usage.PromptTokens, resp.Usage.CompletionTokens
+	}
+	return nil
+}
+
+// readBenchStream reads the server-sent events of a streamed chat completion, recording the time to the first
+// token and the token usage. Without the usage in the stream, the content chunks are counted as output tokens.
+func readBenchStream(body io.Reader, start time.Time, result *benchResult) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	var contentChunks int
+	var usage *openai.Usage
+	for scanner.Scan() {
+		data, ok := bytes.CutPrefix(scanner.Bytes(), []byte("data:"))
+		if !ok {
+			continue
+		}
+		data = bytes.TrimSpace(data)
+		if string(data) == "[DONE]" {
+			break
+		}
+		var chunk openai.ChatCompletionResponseChunk
+		if err := json.Unmarshal(data, &chunk); err != nil {
+			return err
+		}
+		for _, choice := range chunk.Choices {
+			if choice.Delta != nil && choice.Delta.Content != nil && *choice.Delta.Content != "" {
+				if result.ttft == 0 {
+					result.ttft = time.Since(start)
+				}
+				contentChunks++
+			}
+		}
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if usage != nil {
+		result.inputTokens, result.outputTokens = usage.PromptTokens, usage.CompletionTokens
+	} else {
+		result.outputTokens = contentChunks
+	}
+	return nil
+}
+
+// newBenchReport aggregates the results of the requests sent during the elapsed time.
+func newBenchReport(c *cmdBench, results []benchResult, elapsed time.Duration) *benchReport {
+	report := &benchReport{Workload: c.Workload, Requests: len(results), DurationMs: elapsed.Milliseconds()}
+	var latencies, ttfts, tokenRates []float64
+	var inputTokens, outputTokens int
+	for _, r := range results {
+		if r.err != "" {
+			report.Failed++
+			if report.Errors == nil {
+				report.Errors = make(map[string]int)
+			}
+			report.Errors[r.err]++
+			continue
+		}
+		report.Succeeded++
+		inputTokens += r.inputTokens
+		outputTokens += r.outputTokens
+		latencies = append(latencies, float64(r.latency.Microseconds())/1000)
+		generation := r.latency
+		if r.ttft > 0 {
+			report.Streamed++
+			ttfts = append(ttfts, float64(r.ttft.Microseconds())/1000)
+			generation -= r.ttft
+		}
+		if c.Workload == benchWorkloadChat && r.outputTokens > 0 && generation > 0 {
+			tokenRates = append(tokenRates, float64(r.outputTokens)/generation.Seconds())
+		}
+	}
+	if seconds := elapsed.Seconds(); seconds > 0 {
+		report.RequestsPerSecond = float64(report.Succeeded) / seconds
+		report.InputTokensPerSecond = float64(inputTokens) / seconds
+		report.OutputTokensPerSecond = float64(outputTokens) / seconds
+	}
+	report.LatencyMs = newBenchPercentiles(latencies)
+	if len(ttfts) > 0 {
+		p := newBenchPercentiles(ttfts)
+		report.TimeToFirstTokenMs = &p
+	}
+	if len(tokenRates) > 0 {
+		p := newBenchPercentiles(tokenRates)
+		report.OutputTokenRate = &p
+	}
+	return report
+}
+
+// newBenchPercentiles returns the nearest-rank percentiles of the values.
+func newBenchPercentiles(values []float64) benchPercentiles {
+	if len(values) == 0 {
+		return benchPercentiles{}
+	}
+	slices.Sort(values)
+	percentile := func(p float64) float64 {
+		return values[max(int(math.Ceil(p*float64(len(values))))-1, 0)]
+	}
+	return benchPercentiles{P50: percentile(0.5), P90: percentile(0.9), P99: percentile(0.99), Max: values[len(values)-1]}
+}
+
+// writeBenchReport writes the report as a table or as JSON.
+func writeBenchReport(w io.Writer, format string, report *benchReport) error {
+	if format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(tw, "Workload:\t%s\n", report.Workload)
+	_, _ = fmt.Fprintf(tw, "Requests:\t%d (%d succeeded, %d failed, %d streamed)\n", report.Requests, report.Succeeded, report.Failed, report.Streamed)
+	_, _ = fmt.Fprintf(tw, "Duration:\t%s\n", (time.Duration(report.DurationMs) * time.Millisecond).String())
+	_, _ = fmt.Fprintf(tw, "Throughput:\t%.2f requests/s\n", report.RequestsPerSecond)
+	_, _ = fmt.Fprintf(tw, "Token rate:\t%.1f input tokens/s, %.1f output tokens/s\n", report.InputTokensPerSecond, report.OutputTokensPerSecond)
+	_, _ = fmt.Fprintln(tw)
+	_, _ = fmt.Fprintln(tw, "METRIC\tP50\tP90\tP99\tMAX")
+	writeRow := func(name, unit string, p benchPercentiles) {
+		_, _ = fmt.Fprintf(tw, "%s\t%.1f%s\t%.1f%s\t%.1f%s\t%.1f%s\n", name, p.P50, unit, p.P90, unit, p.P99, unit, p.Max, unit)
+	}
+	writeRow("latency", "ms", report.LatencyMs)
+	if report.TimeToFirstTokenMs != nil {
+		writeRow("time to first token", "ms", *report.TimeToFirstTokenMs)
+	}
+	if report.OutputTokenRate != nil {
+		writeRow("output tokens/s", "", *report.OutputTokenRate)
+	}
+	if len(report.Errors) > 0 {
+		_, _ = fmt.Fprintln(tw)
+		_, _ = fmt.Fprintln(tw, "ERROR\tCOUNT")
+		for _, e := range slices.Sorted(maps.Keys(report.Errors)) {
+			_, _ = fmt.Fprintf(tw, "%s\t%d\n", e, report.Errors[e])
+		}
+	}
+	return tw.Flush()
+}
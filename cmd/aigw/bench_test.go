@@ -0,0 +1,167 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package main
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/json"
+)
+
+func Test_bench(t *testing.T) {
+	var streamed, failing atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "Bearer test-key", r.Header.Get("Authorization"))
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		switch r.URL.Path {
+		case "/v1/embeddings":
+			var req openai.EmbeddingCompletionRequest
+			require.NoError(t, json.Unmarshal(body, &req))
+			require.Len(t, strings.Fields(req.Input.Value.(string)), 16)
+			_, _ = w.Write([]byte(`{"object":"list","data":[],"model":"text-embedding-3-small","usage":{"prompt_tokens":16,"total_tokens":16}}`))
+		case "/v1/chat/completions":
+			var req openai.ChatCompletionRequest
+			require.NoError(t, json.Unmarshal(body, &req))
+			require.Equal(t, int64(32), *req.MaxTokens)
+			if req.Model == "failing" && failing.Add(1) == 1 {
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			if !req.Stream {
+				_, _ = w.Write([]byte(`{"choices":[],"usage":{"prompt_tokens":16,"completion_tokens":10,"total_tokens":26}}`))
+				return
+			}
+			streamed.Add(1)
+			require.True(t, req.StreamOptions.IncludeUsage)
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = w.Write([]byte("data: {\"choices\":[{\"index\":0,\"delta\":{\"role\":\"assistant\"}}]}\n\n"))
+			_, _ = w.Write([]byte("data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hello\"}}]}\n\n"))
+			_, _ = w.Write([]byte("data: {\"choices\":[],\"usage\":{\"prompt_tokens\":16,\"completion_tokens\":5,\"total_tokens\":21}}\n\n"))
+			_, _ = w.Write([]byte("data: [DONE]\n\n"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	newCmd := func() *cmdBench {
+		return &cmdBench{
+			Endpoint:     srv.URL + "/",
+			Models:       []string{"gpt-4o"},
+			Workload:     benchWorkloadChat,
+			Requests:     8,
+			Concurrency:  3,
+			PromptTokens: 16,
+			MaxTokens:    32,
+			StreamRatio:  0.25,
+			APIKey:       "test-key",
+			Output:       "json",
+			Timeout:      time.Minute,
+		}
+	}
+
+	t.Run("chat", func(t *testing.T) {
+		var out bytes.Buffer
+		require.NoError(t, bench(t.Context(), newCmd(), &out, io.Discard))
+		var report benchReport
+		require.NoError(t, json.Unmarshal(out.Bytes(), &report))
+		require.Equal(t, benchWorkloadChat, report.Workload)
+		require.Equal(t, 8, report.Requests)
+		require.Equal(t, 8, report.Succeeded)
+		require.Equal(t, 2, report.Streamed)
+		require.Equal(t, int32(2), streamed.Load())
+		require.Positive(t, report.RequestsPerSecond)
+		require.Positive(t, report.OutputTokensPerSecond)
+		require.NotNil(t, report.TimeToFirstTokenMs)
+		require.NotNil(t, report.OutputTokenRate)
+		require.Empty(t, report.Errors)
+	})
+	t.Run("embeddings", func(t *testing.T) {
+		c := newCmd()
+		c.Workload = benchWorkloadEmbeddings
+		var out bytes.Buffer
+		require.NoError(t, bench(t.Context(), c, &out, io.Discard))
+		var report benchReport
+		require.NoError(t, json.Unmarshal(out.Bytes(), &report))
+		require.Equal(t, 8, report.Succeeded)
+		require.Zero(t, report.Streamed)
+		require.Positive(t, report.InputTokensPerSecond)
+		require.Zero(t, report.OutputTokensPerSecond)
+		require.Nil(t, report.TimeToFirstTokenMs)
+		require.Nil(t, report.OutputTokenRate)
+	})
+	t.Run("failures", func(t *testing.T) {
+		c := newCmd()
+		c.Models = []string{"gpt-4o", "failing"}
+		c.Output = "table"
+		var out bytes.Buffer
+		require.EqualError(t, bench(t.Context(), c, &out, io.Discard), "1 of 8 requests failed")
+		require.Contains(t, out.String(), "8 (7 succeeded, 1 failed,")
+		require.Contains(t, out.String(), "HTTP 429")
+	})
+	t.Run("duration", func(t *testing.T) {
+		c := newCmd()
+		c.Requests = 0
+		c.Duration = 100 * time.Millisecond
+		report := runBench(t.Context(), c, http.DefaultClient)
+		require.Positive(t, report.Requests)
+		require.Zero(t, report.Failed)
+	})
+	t.Run("unreachable", func(t *testing.T) {
+		c := newCmd()
+		c.Endpoint = "http://127.0.0.1:1"
+		report := runBench(t.Context(), c, http.DefaultClient)
+		require.Equal(t, map[string]int{benchErrorUnreachable: 8}, report.Errors)
+	})
+}
+
+func Test_newBenchPercentiles(t *testing.T) {
+	values := make([]float64, 100)
+	for i := range values {
+		values[i] = float64(100 - i)
+	}
+	require.Equal(t, benchPercentiles{P50: 50, P90: 90, P99: 99, Max: 100}, newBenchPercentiles(values))
+	require.Equal(t, benchPercentiles{P50: 7, P90: 7, P99: 7, Max: 7}, newBenchPercentiles([]float64{7}))
+	require.Equal(t, benchPercentiles{}, newBenchPercentiles(nil))
+}
+
+func TestCmdBench_Validate(t *testing.T) {
+	valid := cmdBench{Models: []string{"gpt-4o"}, Requests: 1, Concurrency: 1, PromptTokens: 1, MaxTokens: 1, StreamRatio: 0.5}
+	require.NoError(t, valid.Validate())
+
+	for _, tc := range []struct {
+		name   string
+		modify func(*cmdBench)
+		expErr string
+	}{
+		{name: "no models", modify: func(c *cmdBench) { c.Models = nil }, expErr: "at least one model is required"},
+		{name: "no requests nor duration", modify: func(c *cmdBench) { c.Requests = 0 }, expErr: "requests must be positive unless a duration is set"},
+		{name: "no concurrency", modify: func(c *cmdBench) { c.Concurrency = 0 }, expErr: "concurrency must be at least 1"},
+		{name: "stream ratio", modify: func(c *cmdBench) { c.StreamRatio = 1.5 }, expErr: "stream-ratio must be between 0 and 1"},
+		{name: "prompt tokens", modify: func(c *cmdBench) { c.PromptTokens = 0 }, expErr: "prompt-tokens and max-tokens must be at least 1"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := valid
+			tc.modify(&c)
+			require.EqualError(t, c.Validate(), tc.expErr)
+		})
+	}
+
+	duration := valid
+	duration.Requests, duration.Duration = 0, time.Second
+	require.NoError(t, duration.Validate())
+}
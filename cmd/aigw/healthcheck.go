@@ -6,17 +6,71 @@
 package main
 
 import (
+	"bytes"
+	"cmp"
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
 	"io"
 	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
 	"time"
 
 	"github.com/tetratelabs/func-e/experimental/admin"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	aigv1b1 "github.com/envoyproxy/ai-gateway/api/v1beta1"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+	"github.com/envoyproxy/ai-gateway/internal/json"
+)
+
+const (
+	// healthcheckStatusOK is the status of a check that succeeded.
+	healthcheckStatusOK = "ok"
+	// healthcheckStatusUnauthorized is the status of a check rejected with 401 or 403, which usually means that
+	// either the API key sent to the gateway or the credentials of the backend are invalid.
+	healthcheckStatusUnauthorized = "unauthorized"
+	// healthcheckStatusUnreachable is the status of a check that failed before receiving a response.
+	healthcheckStatusUnreachable = "unreachable"
+	// healthcheckStatusError is the status of a check that received any other error response.
+	healthcheckStatusError = "error"
+	// healthcheckStatusMissing is the status of a model that is not listed by the gateway.
+	healthcheckStatusMissing = "missing"
+	// healthcheckStatusSkipped is the status of a backend that cannot be pinned, which is not a failure.
+	healthcheckStatusSkipped = "skipped"
+
+	// healthcheckMaxErrorLength is the maximum length of the error body reported in the results.
+	healthcheckMaxErrorLength = 200
+	// healthcheckBackendOverrideTTL is the validity of the signatures of the requests pinning a backend.
+	healthcheckBackendOverrideTTL = 5 * time.Minute
 )
 
-// healthcheck performs looks up the Envoy subprocess, gets its admin port,
-// and returns no error when ready.
-func healthcheck(ctx context.Context, _, stderr io.Writer) error {
+// healthcheckResult is the result of checking a model, or a backend of a route, served by a running gateway.
+type healthcheckResult struct {
+	Route      string        `json:"route,omitempty"`
+	Model      string        `json:"model"`
+	Backend    string        `json:"backend,omitempty"`
+	Status     string        `json:"status"`
+	StatusCode int           `json:"statusCode,omitempty"`
+	Latency    time.Duration `json:"-"`
+	LatencyMs  int64         `json:"latencyMs"`
+	Error      string        `json:"error,omitempty"`
+}
+
+// healthcheck checks the running gateway at the endpoint if set. Otherwise, this looks up the Envoy subprocess,
+// gets its admin port, and returns no error when ready.
+func healthcheck(ctx context.Context, c *cmdHealthcheck, stdout, stderr io.Writer) error {
+	if c.Endpoint != "" {
+		return endpointHealthcheck(ctx, c, &http.Client{Timeout: c.Timeout}, stdout)
+	}
+
 	// Give up to 1 second for the health check
 	ctx, cancel := context.WithTimeout(ctx, 1*time.Second)
 	defer cancel()
@@ -36,3 +90,280 @@ func doHealthcheck(ctx context.Context, aigwPid int, logger *slog.Logger) error
 	}
 	return nil
 }
+
+// healthcheckTarget is a model to check, or a backend of an AIGatewayRoute rule serving the model.
+type healthcheckTarget struct {
+	route, model, backend string
+	// pin is true if the backend must be pinned with the backend override headers, i.e. the rule has several backends.
+	pin bool
+	// pinnedBackend is the AIServiceBackend (format "namespace/name") pinned with the backend override headers.
+	pinnedBackend string
+	// skipReason is set if the backend cannot be pinned.
+	skipReason string
+}
+
+// endpointHealthcheck checks each model served by the gateway at c.Endpoint, or each backend of the routes of
+// c.Config if set, writes the results to stdout, and returns an error if any of the checks failed.
+func endpointHealthcheck(ctx context.Context, c *cmdHealthcheck, client *http.Client, stdout io.Writer) error {
+	endpoint := strings.TrimSuffix(c.Endpoint, "/")
+	var targets []healthcheckTarget
+	if c.Config != "" {
+		var err error
+		if targets, err = healthcheckBackendTargets(c.Config, c.BackendOverrideKey,
+			cmp.Or(c.ModelNameHeaderKey, internalapi.ModelNameHeaderKeyDefault)); err != nil {
+			return err
+		}
+	}
+	listed, listResult := listModels(ctx, c, client, endpoint)
+	if listResult.Status != healthcheckStatusOK && ((len(c.Models) == 0 && c.Config == "") || c.Probe == "models") {
+		if err := writeHealthcheckResults(stdout, c.Output, []healthcheckResult{listResult}); err != nil {
+			return err
+		}
+		return fmt.Errorf("failed to list the models of %s: %s", endpoint, listResult.Status)
+	}
+
+	if c.Config != "" {
+		if len(c.Models) > 0 {
+			targets = slices.DeleteFunc(targets, func(t healthcheckTarget) bool { return !slices.Contains(c.Models, t.model) })
+		}
+		if len(targets) == 0 {
+			return fmt.Errorf("no backends of the models are configured in %s", c.Config)
+		}
+	} else {
+		models := c.Models
+		if len(models) == 0 {
+			models = listed
+		}
+		if len(models) == 0 {
+			return fmt.Errorf("no models are served by %s", endpoint)
+		}
+		for _, model := range models {
+			targets = append(targets, healthcheckTarget{model: model})
+		}
+	}
+
+	results := make([]healthcheckResult, 0, len(targets))
+	for _, target := range targets {
+		var result healthcheckResult
+		switch {
+		case c.Probe == "models":
+			result = listResult
+			if !slices.Contains(listed, target.model) {
+				result.Status = healthcheckStatusMissing
+			}
+		case target.skipReason != "":
+			result = healthcheckResult{Status: healthcheckStatusSkipped, Error: target.skipReason}
+		default:
+			result = probeChatCompletion(ctx, c, client, endpoint, target)
+		}
+		result.Route, result.Model, result.Backend = target.route, target.model, target.backend
+		results = append(results, result)
+	}
+	if err := writeHealthcheckResults(stdout, c.Output, results); err != nil {
+		return err
+	}
+
+	var failed, checked int
+	for _, r := range results {
+		switch r.Status {
+		case healthcheckStatusOK:
+		case healthcheckStatusSkipped:
+			continue
+		default:
+			failed++
+		}
+		checked++
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d checks failed", failed, checked)
+	}
+	return nil
+}
+
+// healthcheckBackendTargets returns a target per backend of each AIGatewayRoute rule in the configuration at path,
+// with the first model matched by the rule. The rules without a model are not checked since the requests can't be
+// routed to them by model. The backends of the rules with several backends are pinned with the backend override
+// headers, which requires the BackendOverride of the route and its key. The modelNameHeaderKey is the model name
+// header matched by the rules.
+func healthcheckBackendTargets(path, backendOverrideKey, modelNameHeaderKey string) ([]healthcheckTarget, error) {
+	yamlInput, err := readYamlsAsString([]string{path})
+	if err != nil {
+		return nil, err
+	}
+	routes, _, _, _, _, _, _, _, err := collectObjects(yamlInput, io.Discard, slog.New(slog.DiscardHandler))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the configuration %s: %w", path, err)
+	}
+	var targets []healthcheckTarget
+	for _, route := range routes {
+		for i := range route.Spec.Rules {
+			rule := &route.Spec.Rules[i]
+			model := ruleModel(rule.Matches, modelNameHeaderKey)
+			if model == "" {
+				continue
+			}
+			for _, ref := range rule.BackendRefs {
+				target := healthcheckTarget{
+					route: route.Name, model: model, backend: ref.Name, pin: len(rule.BackendRefs) > 1,
+					pinnedBackend: ref.GetNamespace(route.Namespace) + "/" + ref.Name,
+				}
+				switch {
+				case !target.pin:
+				case route.Spec.BackendOverride == nil:
+					target.skipReason = "the route has no BackendOverride to pin the backend"
+				case backendOverrideKey == "":
+					target.skipReason = "--backend-override-key is required to pin the backend"
+				}
+				targets = append(targets, target)
+			}
+		}
+	}
+	return targets, nil
+}
+
+// ruleModel returns the first model exactly matched by the model name header of the matches, or empty if none.
+func ruleModel(matches []aigv1b1.AIGatewayRouteRuleMatch, modelNameHeaderKey string) string {
+	for _, m := range matches {
+		for _, h := range m.Headers {
+			if strings.EqualFold(string(h.Name), modelNameHeaderKey) &&
+				(h.Type == nil || *h.Type == gwapiv1.HeaderMatchExact) {
+				return h.Value
+			}
+		}
+	}
+	return ""
+}
+
+// backendOverrideSignature returns the value of the signature header pinning the backend until expiresAt, see
+// the BackendOverride of AIGatewayRoute.
+func backendOverrideSignature(key, backend string, expiresAt time.Time) string {
+	expiry := strconv.FormatInt(expiresAt.Unix(), 10)
+	h := hmac.New(sha256.New, []byte(key))
+	_, _ = h.Write([]byte(backend + ":" + expiry))
+	return expiry + ":" + hex.EncodeToString(h.Sum(nil))
+}
+
+// listModels returns the models listed by the /v1/models endpoint of the gateway along with the result of the request.
+func listModels(ctx context.Context, c *cmdHealthcheck, client *http.Client, endpoint string) ([]string, healthcheckResult) {
+	var models []string
+	result := doHealthcheckRequest(ctx, c, client, http.MethodGet, endpoint+"/v1/models", nil, nil, func(body []byte) error {
+		var list openai.ModelList
+		if err := json.Unmarshal(body, &list); err != nil {
+			return fmt.Errorf("invalid models list: %w", err)
+		}
+		for _, m := range list.Data {
+			models = append(models, m.ID)
+		}
+		return nil
+	})
+	result.Model = "-"
+	return models, result
+}
+
+// probeChatCompletion sends a minimal chat completion for the model of the target to the gateway, pinning the
+// backend of the target if needed.
+func probeChatCompletion(ctx context.Context, c *cmdHealthcheck, client *http.Client, endpoint string, target healthcheckTarget) healthcheckResult {
+	maxTokens := int64(1)
+	body, _ := json.Marshal(openai.ChatCompletionRequest{
+		Model: target.model,
+		Messages: []openai.ChatCompletionMessageParamUnion{{
+			OfUser: &openai.ChatCompletionUserMessageParam{
+				Role:    openai.ChatMessageRoleUser,
+				Content: openai.StringOrUserRoleContentUnion{Value: "ping"},
+			},
+		}},
+		MaxTokens: &maxTokens,
+	})
+	header := http.Header{}
+	if target.pin {
+		header.Set(internalapi.BackendOverrideHeader, target.pinnedBackend)
+		header.Set(internalapi.BackendOverrideSignatureHeader,
+			backendOverrideSignature(c.BackendOverrideKey, target.pinnedBackend, time.Now().Add(healthcheckBackendOverrideTTL)))
+	}
+	return doHealthcheckRequest(ctx, c, client, http.MethodPost, endpoint+"/v1/chat/completions", header, body, nil)
+}
+
+// doHealthcheckRequest sends the request with the additional header and classifies the response. When set, check
+// validates the body of a successful response.
+func doHealthcheckRequest(ctx context.Context, c *cmdHealthcheck, client *http.Client, method, url string, header http.Header,
+	body []byte, check func([]byte) error,
+) (result healthcheckResult) {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return healthcheckResult{Status: healthcheckStatusError, Error: err.Error()}
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	}
+
+	start := time.Now()
+	defer func() {
+		result.Latency = time.Since(start)
+		result.LatencyMs = result.Latency.Milliseconds()
+	}()
+	resp, err := client.Do(req)
+	if err != nil {
+		return healthcheckResult{Status: healthcheckStatusUnreachable, Error: err.Error()}
+	}
+	defer func() { _ = resp.Body.Close() }()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return healthcheckResult{Status: healthcheckStatusUnreachable, StatusCode: resp.StatusCode, Error: err.Error()}
+	}
+
+	result.StatusCode = resp.StatusCode
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		result.Status = healthcheckStatusUnauthorized
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		result.Status = healthcheckStatusError
+	default:
+		result.Status = healthcheckStatusOK
+		if check != nil {
+			if err = check(respBody); err != nil {
+				result.Status = healthcheckStatusError
+				result.Error = err.Error()
+			}
+		}
+		return
+	}
+	result.Error = strings.TrimSpace(string(respBody[:min(len(respBody), healthcheckMaxErrorLength)]))
+	return
+}
+
+// writeHealthcheckResults writes the results as a table or as JSON.
+func writeHealthcheckResults(w io.Writer, format string, results []healthcheckResult) error {
+	if format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+	}
+	// The route and backend columns are only shown when checking the backends.
+	backends := slices.ContainsFunc(results, func(r healthcheckResult) bool { return r.Backend != "" })
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if backends {
+		_, _ = fmt.Fprint(tw, "ROUTE\tMODEL\tBACKEND\t")
+	} else {
+		_, _ = fmt.Fprint(tw, "MODEL\t")
+	}
+	_, _ = fmt.Fprintln(tw, "STATUS\tCODE\tLATENCY\tERROR")
+	for _, r := range results {
+		code := "-"
+		if r.StatusCode != 0 {
+			code = fmt.Sprint(r.StatusCode)
+		}
+		if backends {
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t", r.Route, r.Model, r.Backend)
+		} else {
+			_, _ = fmt.Fprintf(tw, "%s\t", r.Model)
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", r.Status, code, r.Latency.Round(time.Millisecond), r.Error)
+	}
+	return tw.Flush()
+}
@@ -10,7 +10,12 @@ import (
 	"context"
 	"io"
 	"log/slog"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -18,6 +23,8 @@ import (
 	func_e "github.com/tetratelabs/func-e"
 	"github.com/tetratelabs/func-e/api"
 	"github.com/tetratelabs/func-e/experimental/admin"
+
+	"github.com/envoyproxy/ai-gateway/internal/json"
 )
 
 func Test_healthcheck(t *testing.T) {
@@ -64,3 +71,303 @@ func Test_healthcheck(t *testing.T) {
 		require.Empty(t, log)
 	})
 }
+
+func Test_endpointHealthcheck(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":{"message":"invalid api key"}}`))
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/models":
+			_, _ = w.Write([]byte(`{"object":"list","data":[{"id":"gpt-4o","object":"model"},{"id":"llama3","object":"model"}]}`))
+		case "/v1/chat/completions":
+			body, err := io.ReadAll(r.Body)
+			require.NoError(t, err)
+			require.Contains(t, string(body), `"max_tokens":1`)
+			if strings.Contains(string(body), "llama3") {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				_, _ = w.Write([]byte("no healthy upstream"))
+				return
+			}
+			_, _ = w.Write([]byte(`{"choices":[]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	for _, tc := range []struct {
+		name   string
+		cmd    cmdHealthcheck
+		expErr string
+		expOut []healthcheckResult
+	}{
+		{
+			name:   "chat probe of all the listed models",
+			cmd:    cmdHealthcheck{Endpoint: srv.URL + "/", APIKey: "test-key", Probe: "chat"},
+			expErr: "1 of 2 checks failed",
+			expOut: []healthcheckResult{
+				{Model: "gpt-4o", Status: healthcheckStatusOK, StatusCode: http.StatusOK},
+				{Model: "llama3", Status: healthcheckStatusError, StatusCode: http.StatusServiceUnavailable, Error: "no healthy upstream"},
+			},
+		},
+		{
+			name: "chat probe of the given models",
+			cmd:  cmdHealthcheck{Endpoint: srv.URL, APIKey: "test-key", Probe: "chat", Models: []string{"gpt-4o"}},
+			expOut: []healthcheckResult{
+				{Model: "gpt-4o", Status: healthcheckStatusOK, StatusCode: http.StatusOK},
+			},
+		},
+		{
+			name:   "models probe",
+			cmd:    cmdHealthcheck{Endpoint: srv.URL, APIKey: "test-key", Probe: "models", Models: []string{"llama3", "mistral"}},
+			expErr: "1 of 2 checks failed",
+			expOut: []healthcheckResult{
+				{Model: "llama3", Status: healthcheckStatusOK, StatusCode: http.StatusOK},
+				{Model: "mistral", Status: healthcheckStatusMissing, StatusCode: http.StatusOK},
+			},
+		},
+		{
+			name:   "invalid api key",
+			cmd:    cmdHealthcheck{Endpoint: srv.URL, APIKey: "wrong", Probe: "chat"},
+			expErr: "failed to list the models of " + srv.URL + ": unauthorized",
+			expOut: []healthcheckResult{
+				{Model: "-", Status: healthcheckStatusUnauthorized, StatusCode: http.StatusUnauthorized, Error: `{"error":{"message":"invalid api key"}}`},
+			},
+		},
+		{
+			name:   "unreachable",
+			cmd:    cmdHealthcheck{Endpoint: "http://127.0.0.1:1", Probe: "chat", Models: []string{"gpt-4o"}},
+			expErr: "1 of 1 checks failed",
+			expOut: []healthcheckResult{
+				{Model: "gpt-4o", Status: healthcheckStatusUnreachable},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.cmd.Output = "json"
+			var out bytes.Buffer
+			err := endpointHealthcheck(t.Context(), &tc.cmd, &http.Client{Timeout: time.Second}, &out)
+			if tc.expErr != "" {
+				require.EqualError(t, err, tc.expErr)
+			} else {
+				require.NoError(t, err)
+			}
+			var results []healthcheckResult
+			require.NoError(t, json.Unmarshal(out.Bytes(), &results))
+			require.Len(t, results, len(tc.expOut))
+			for i := range results {
+				if tc.expOut[i].Status == healthcheckStatusUnreachable {
+					require.NotEmpty(t, results[i].Error)
+					results[i].Error = ""
+				}
+				results[i].LatencyMs = 0
+				require.Equal(t, tc.expOut[i], results[i])
+			}
+		})
+	}
+}
+
+func Test_endpointHealthcheck_backends(t *testing.T) {
+	const key = "some-hmac-key"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/models" {
+			_, _ = w.Write([]byte(`{"object":"list","data":[{"id":"gpt-4o","object":"model"},{"id":"llama3","object":"model"}]}`))
+			return
+		}
+		var req struct {
+			Model string `json:"model"`
+		}
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(body, &req))
+		backend := r.Header.Get("x-aigw-backend")
+		switch req.Model {
+		case "gpt-4o":
+			// The backends are pinned by their namespace/name.
+			require.Contains(t, []string{"team-a/openai", "team-b/azure"}, backend)
+			expiry, _, _ := strings.Cut(r.Header.Get("x-aigw-backend-signature"), ":")
+			expiresAt, err := strconv.ParseInt(expiry, 10, 64)
+			require.NoError(t, err)
+			require.Equal(t, backendOverrideSignature(key, backend, time.Unix(expiresAt, 0)), r.Header.Get("x-aigw-backend-signature"))
+			if backend == "team-b/azure" {
+				w.WriteHeader(http.StatusUnauthorized)
+				_, _ = w.Write([]byte("invalid credentials"))
+				return
+			}
+		case "llama3":
+			require.Empty(t, backend, "a single backend is not pinned")
+		}
+		_, _ = w.Write([]byte(`{"choices":[]}`))
+	}))
+	defer srv.Close()
+
+	config := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(config, []byte(`apiVersion: aigateway.envoyproxy.io/v1beta1
+kind: AIGatewayRoute
+metadata:
+  name: openai
+  namespace: team-a
+spec:
+  backendOverride:
+    secretRef:
+      name: backend-override
+  rules:
+    - matches:
+        - headers:
+            - name: x-ai-eg-model
+              value: gpt-4o
+      backendRefs:
+        - name: openai
+        - name: azure
+          namespace: team-b
+    - backendRefs:
+        - name: fallback
+---
+apiVersion: aigateway.envoyproxy.io/v1beta1
+kind: AIGatewayRoute
+metadata:
+  name: self-hosted
+spec:
+  rules:
+    - matches:
+        - headers:
+            - type: Exact
+              name: x-ai-eg-model
+              value: llama3
+      backendRefs:
+        - name: vllm
+    - matches:
+        - headers:
+            - name: x-ai-eg-model
+              value: mistral
+      backendRefs:
+        - name: vllm-a
+        - name: vllm-b
+`), 0o600))
+
+	for _, tc := range []struct {
+		name   string
+		cmd    cmdHealthcheck
+		expErr string
+		expOut []healthcheckResult
+	}{
+		{
+			name:   "each backend",
+			cmd:    cmdHealthcheck{Endpoint: srv.URL, Probe: "chat", Config: config, BackendOverrideKey: key},
+			expErr: "1 of 3 checks failed",
+			expOut: []healthcheckResult{
+				{Route: "openai", Model: "gpt-4o", Backend: "openai", Status: healthcheckStatusOK, StatusCode: http.StatusOK},
+				{Route: "openai", Model: "gpt-4o", Backend: "azure", Status: healthcheckStatusUnauthorized, StatusCode: http.StatusUnauthorized, Error: "invalid credentials"},
+				{Route: "self-hosted", Model: "llama3", Backend: "vllm", Status: healthcheckStatusOK, StatusCode: http.StatusOK},
+				{Route: "self-hosted", Model: "mistral", Backend: "vllm-a", Status: healthcheckStatusSkipped, Error: "the route has no BackendOverride to pin the backend"},
+				{Route: "self-hosted", Model: "mistral", Backend: "vllm-b", Status: healthcheckStatusSkipped, Error: "the route has no BackendOverride to pin the backend"},
+			},
+		},
+		{
+			name: "backends of the given models without the key",
+			cmd:  cmdHealthcheck{Endpoint: srv.URL, Probe: "chat", Config: config, Models: []string{"gpt-4o", "llama3"}},
+			expOut: []healthcheckResult{
+				{Route: "openai", Model: "gpt-4o", Backend: "openai", Status: healthcheckStatusSkipped, Error: "--backend-override-key is required to pin the backend"},
+				{Route: "openai", Model: "gpt-4o", Backend: "azure", Status: healthcheckStatusSkipped, Error: "--backend-override-key is required to pin the backend"},
+				{Route: "self-hosted", Model: "llama3", Backend: "vllm", Status: healthcheckStatusOK, StatusCode: http.StatusOK},
+			},
+		},
+		{
+			name:   "models probe",
+			cmd:    cmdHealthcheck{Endpoint: srv.URL, Probe: "models", Config: config, Models: []string{"mistral"}},
+			expErr: "2 of 2 checks failed",
+			expOut: []healthcheckResult{
+				{Route: "self-hosted", Model: "mistral", Backend: "vllm-a", Status: healthcheckStatusMissing, StatusCode: http.StatusOK},
+				{Route: "self-hosted", Model: "mistral", Backend: "vllm-b", Status: healthcheckStatusMissing, StatusCode: http.StatusOK},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.cmd.Output = "json"
+			var out bytes.Buffer
+			err := endpointHealthcheck(t.Context(), &tc.cmd, &http.Client{Timeout: time.Second}, &out)
+			if tc.expErr != "" {
+				require.EqualError(t, err, tc.expErr)
+			} else {
+				require.NoError(t, err)
+			}
+			var results []healthcheckResult
+			require.NoError(t, json.Unmarshal(out.Bytes(), &results))
+			for i := range results {
+				results[i].LatencyMs = 0
+			}
+			require.Equal(t, tc.expOut, results)
+		})
+	}
+
+	t.Run("no backends", func(t *testing.T) {
+		err := endpointHealthcheck(t.Context(), &cmdHealthcheck{Endpoint: srv.URL, Probe: "chat", Config: config, Models: []string{"unknown"}},
+			&http.Client{Timeout: time.Second}, io.Discard)
+		require.EqualError(t, err, "no backends of the models are configured in "+config)
+	})
+
+	t.Run("custom model name header", func(t *testing.T) {
+		customConfig := filepath.Join(t.TempDir(), "config.yaml")
+		require.NoError(t, os.WriteFile(customConfig, []byte(`apiVersion: aigateway.envoyproxy.io/v1beta1
+kind: AIGatewayRoute
+metadata:
+  name: self-hosted
+spec:
+  rules:
+    - matches:
+        - headers:
+            - name: x-model
+              value: llama3
+      backendRefs:
+        - name: vllm
+`), 0o600))
+
+		err := endpointHealthcheck(t.Context(), &cmdHealthcheck{Endpoint: srv.URL, Probe: "chat", Config: customConfig},
+			&http.Client{Timeout: time.Second}, io.Discard)
+		require.EqualError(t, err, "no backends of the models are configured in "+customConfig)
+
+		var out bytes.Buffer
+		err = endpointHealthcheck(t.Context(), &cmdHealthcheck{
+			Endpoint: srv.URL, Probe: "chat", Config: customConfig, ModelNameHeaderKey: "x-model", Output: "json",
+		}, &http.Client{Timeout: time.Second}, &out)
+		require.NoError(t, err)
+		var results []healthcheckResult
+		require.NoError(t, json.Unmarshal(out.Bytes(), &results))
+		require.Len(t, results, 1)
+		require.Equal(t, "llama3", results[0].Model)
+		require.Equal(t, healthcheckStatusOK, results[0].Status)
+	})
+
+	t.Run("invalid config", func(t *testing.T) {
+		err := endpointHealthcheck(t.Context(), &cmdHealthcheck{Endpoint: srv.URL, Probe: "chat", Config: filepath.Join(t.TempDir(), "missing.yaml")},
+			&http.Client{Timeout: time.Second}, io.Discard)
+		require.ErrorContains(t, err, "error reading file")
+	})
+}
+
+func Test_writeHealthcheckResults(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, writeHealthcheckResults(&out, "table", []healthcheckResult{
+		{Model: "gpt-4o", Status: healthcheckStatusOK, StatusCode: http.StatusOK, Latency: 123 * time.Millisecond},
+		{Model: "llama3", Status: healthcheckStatusUnreachable, Latency: 5 * time.Millisecond, Error: "connection refused"},
+	}))
+	require.Equal(t, `MODEL   STATUS       CODE  LATENCY  ERROR
+gpt-4o  ok           200   123ms    
+llama3  unreachable  -     5ms      connection refused
+`, out.String())
+}
+
+func Test_writeHealthcheckResults_backends(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, writeHealthcheckResults(&out, "table", []healthcheckResult{
+		{Route: "openai", Model: "gpt-4o", Backend: "openai", Status: healthcheckStatusOK, StatusCode: http.StatusOK, Latency: 123 * time.Millisecond},
+		{Route: "openai", Model: "gpt-4o", Backend: "azure", Status: healthcheckStatusSkipped, Error: "--backend-override-key is required to pin the backend"},
+	}))
+	require.Equal(t, `ROUTE   MODEL   BACKEND  STATUS   CODE  LATENCY  ERROR
+openai  gpt-4o  openai   ok       200   123ms    
+openai  gpt-4o  azure    skipped  -     0s       --backend-override-key is required to pin the backend
+`, out.String())
+}
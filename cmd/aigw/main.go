@@ -36,8 +36,10 @@ type (
 		Version struct{} `cmd:"" help:"Show version."`
 		// Run is the sub-command parsed by the `cmdRun` struct.
 		Run cmdRun `cmd:"" help:"Run the AI Gateway locally for given configuration."`
-		// Healthcheck is the sub-command to check if the aigw server is healthy.
-		Healthcheck cmdHealthcheck `cmd:"" help:"Docker HEALTHCHECK command."`
+		// Healthcheck is the sub-command to check if the aigw server or a running gateway is healthy.
+		Healthcheck cmdHealthcheck `cmd:"" help:"Check the aigw server, or the models of a running gateway."`
+		// Bench is the sub-command to benchmark a running gateway with a synthetic workload.
+		Bench cmdBench `cmd:"" help:"Benchmark a running gateway with a synthetic workload."`
 		// DownloadEnvoy downloads the Envoy binary used by Envoy Gateway.
 		DownloadEnvoy cmdDownloadEnvoy `cmd:"" help:"Download Envoy binary for the Envoy Gateway default version."`
 	}
@@ -57,7 +59,35 @@ type (
 		runOpts   *runOpts               `kong:"-"` // Internal field: run options, set by Validate
 	}
 	// cmdHealthcheck corresponds to `aigw healthcheck` command.
-	cmdHealthcheck struct{}
+	//
+	// Without an endpoint, this is the Docker HEALTHCHECK command of `aigw run`.
+	cmdHealthcheck struct {
+		Endpoint string        `help:"Base URL of a running gateway, e.g. http://localhost:1975. When set, the models served by the gateway are checked instead of the local aigw server."`
+		Models   []string      `help:"Models to check. Defaults to all the models listed by the /v1/models endpoint of the gateway."`
+		Probe    string        `enum:"chat,models" default:"chat" help:"Request sent per model: a minimal chat completion, or only the lookup of the model in the models list."`
+		APIKey   string        `name:"api-key" env:"AIGW_API_KEY" help:"API key sent to the gateway as a bearer token."`
+		Output   string        `short:"o" enum:"table,json" default:"table" help:"Output format of the results."`
+		Timeout  time.Duration `default:"30s" help:"Timeout of each request to the gateway."`
+		Config   string        `type:"path" help:"Path to the AI Gateway configuration of the gateway. When set, each backend of each AIGatewayRoute rule is checked instead of each model."`
+
+		BackendOverrideKey string `name:"backend-override-key" env:"AIGW_BACKEND_OVERRIDE_KEY" help:"HMAC key of the BackendOverride of the AIGatewayRoutes, signing the requests pinning each backend of the rules with several backends."`
+		ModelNameHeaderKey string `name:"model-name-header-key" help:"Model name header matched by the rules of the AIGatewayRoutes in the configuration, when the gateway is installed with a custom one. Defaults to x-ai-eg-model."`
+	}
+	// cmdBench corresponds to `aigw bench` command.
+	cmdBench struct {
+		Endpoint     string        `default:"http://localhost:1975" help:"Base URL of the gateway."`
+		Models       []string      `help:"Models to send the requests to, in turn. At least one is required."`
+		Workload     string        `enum:"chat,embeddings" default:"chat" help:"Requests to send: chat completions or embeddings."`
+		Requests     int           `short:"n" default:"100" help:"Number of requests to send. Zero sends requests until the duration elapsed."`
+		Duration     time.Duration `help:"Maximum duration of the benchmark, e.g. 1m."`
+		Concurrency  int           `short:"c" default:"10" help:"Number of requests sent concurrently."`
+		PromptTokens int           `name:"prompt-tokens" default:"128" help:"Approximate number of tokens of each prompt."`
+		MaxTokens    int           `name:"max-tokens" default:"128" help:"Maximum number of output tokens of each chat completion."`
+		StreamRatio  float64       `name:"stream-ratio" default:"0.5" help:"Ratio of the chat completions that are streamed, from 0 to 1."`
+		APIKey       string        `name:"api-key" env:"AIGW_API_KEY" help:"API key sent to the gateway as a bearer token."`
+		Output       string        `short:"o" enum:"table,json" default:"table" help:"Output format of the report."`
+		Timeout      time.Duration `default:"60s" help:"Timeout of each request to the gateway."`
+	}
 	// cmdDownloadEnvoy corresponds to `aigw download-envoy` command.
 	cmdDownloadEnvoy struct {
 		dataHome string `kong:"-"`
@@ -160,14 +190,35 @@ func (c *cmdRun) Validate() error {
 	return nil
 }
 
+// Validate is called by Kong after parsing to validate the cmdBench arguments.
+func (c *cmdBench) Validate() error {
+	if len(c.Models) == 0 {
+		return fmt.Errorf("at least one model is required")
+	}
+	if c.Requests < 0 || (c.Requests == 0 && c.Duration <= 0) {
+		return fmt.Errorf("requests must be positive unless a duration is set")
+	}
+	if c.Concurrency < 1 {
+		return fmt.Errorf("concurrency must be at least 1")
+	}
+	if c.StreamRatio < 0 || c.StreamRatio > 1 {
+		return fmt.Errorf("stream-ratio must be between 0 and 1")
+	}
+	if c.PromptTokens < 1 || c.MaxTokens < 1 {
+		return fmt.Errorf("prompt-tokens and max-tokens must be at least 1")
+	}
+	return nil
+}
+
 type (
 	runFn           func(context.Context, *cmdRun, *runOpts, io.Writer, io.Writer) error
-	healthcheckFn   func(context.Context, io.Writer, io.Writer) error
+	healthcheckFn   func(context.Context, *cmdHealthcheck, io.Writer, io.Writer) error
+	benchFn         func(context.Context, *cmdBench, io.Writer, io.Writer) error
 	downloadEnvoyFn func(context.Context, *cmdDownloadEnvoy, io.Writer, io.Writer) error
 )
 
 func main() {
-	doMain(ctrl.SetupSignalHandler(), os.Stdout, os.Stderr, os.Args[1:], os.Exit, run, healthcheck, downloadEnvoyCmd)
+	doMain(ctrl.SetupSignalHandler(), os.Stdout, os.Stderr, os.Args[1:], os.Exit, run, healthcheck, bench, downloadEnvoyCmd)
 }
 
 // doMain is the main entry point for the CLI. It parses the command line arguments and executes the appropriate command.
@@ -177,9 +228,11 @@ func main() {
 //   - `args` are the command line arguments without the program name.
 //   - exitFn is the function to call to exit the program during the parsing of the command line arguments. Mainly for testing.
 //   - rf is the function to call to run the AI Gateway locally. Mainly for testing.
+//   - hf, bf and df are the functions to call for the healthcheck, bench and download-envoy commands. Mainly for testing.
 func doMain(ctx context.Context, stdout, stderr io.Writer, args []string, exitFn func(int),
 	rf runFn,
 	hf healthcheckFn,
+	bf benchFn,
 	df downloadEnvoyFn,
 ) {
 	var c cmd
@@ -204,10 +257,15 @@ func doMain(ctx context.Context, stdout, stderr io.Writer, args []string, exitFn
 			log.Fatalf("Error running: %v", err)
 		}
 	case "healthcheck":
-		err = hf(ctx, stdout, stderr)
+		err = hf(ctx, &c.Healthcheck, stdout, stderr)
 		if err != nil {
 			log.Fatalf("Health check failed: %v", err)
 		}
+	case "bench":
+		err = bf(ctx, &c.Bench, stdout, stderr)
+		if err != nil {
+			log.Fatalf("Benchmark failed: %v", err)
+		}
 	case "download-envoy":
 		err = df(ctx, &c.DownloadEnvoy, stdout, stderr)
 		if err != nil {
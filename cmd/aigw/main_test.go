@@ -13,6 +13,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"k8s.io/utils/ptr"
@@ -28,6 +29,7 @@ func Test_doMain(t *testing.T) {
 		env          map[string]string
 		rf           runFn
 		hf           healthcheckFn
+		bf           benchFn
 		df           downloadEnvoyFn
 		expOut       string
 		expPanicCode *int
@@ -58,7 +60,10 @@ Commands:
     Run the AI Gateway locally for given configuration.
 
   healthcheck [flags]
-    Docker HEALTHCHECK command.
+    Check the aigw server, or the models of a running gateway.
+
+  bench [flags]
+    Benchmark a running gateway with a synthetic workload.
 
   download-envoy [flags]
     Download Envoy binary for the Envoy Gateway default version.
@@ -160,6 +165,58 @@ Flags:
 				return nil
 			},
 		},
+		{
+			name: "healthcheck with endpoint",
+			args: []string{"healthcheck", "--endpoint", "http://localhost:1975", "--models", "gpt-4o,llama3", "-o", "json"},
+			env:  map[string]string{"AIGW_API_KEY": "dummy-key"},
+			hf: func(_ context.Context, c *cmdHealthcheck, _, _ io.Writer) error {
+				require.Equal(t, "http://localhost:1975", c.Endpoint)
+				require.Equal(t, []string{"gpt-4o", "llama3"}, c.Models)
+				require.Equal(t, "chat", c.Probe)
+				require.Equal(t, "dummy-key", c.APIKey)
+				require.Equal(t, "json", c.Output)
+				require.Equal(t, 30*time.Second, c.Timeout)
+				return nil
+			},
+		},
+		{
+			name: "healthcheck of each backend",
+			args: []string{"healthcheck", "--endpoint", "http://localhost:1975", "--config", "./config.yaml"},
+			env:  map[string]string{"AIGW_BACKEND_OVERRIDE_KEY": "dummy-key"},
+			hf: func(_ context.Context, c *cmdHealthcheck, _, _ io.Writer) error {
+				abs, err := filepath.Abs("./config.yaml")
+				require.NoError(t, err)
+				require.Equal(t, abs, c.Config)
+				require.Equal(t, "dummy-key", c.BackendOverrideKey)
+				return nil
+			},
+		},
+		{
+			name: "bench",
+			args: []string{"bench", "--models", "gpt-4o,llama3", "-c", "4", "--duration", "1m", "--stream-ratio", "0.25"},
+			env:  map[string]string{"AIGW_API_KEY": "dummy-key"},
+			bf: func(_ context.Context, c *cmdBench, _, _ io.Writer) error {
+				require.Equal(t, "http://localhost:1975", c.Endpoint)
+				require.Equal(t, []string{"gpt-4o", "llama3"}, c.Models)
+				require.Equal(t, "chat", c.Workload)
+				require.Equal(t, 100, c.Requests)
+				require.Equal(t, time.Minute, c.Duration)
+				require.Equal(t, 4, c.Concurrency)
+				require.Equal(t, 128, c.PromptTokens)
+				require.Equal(t, 128, c.MaxTokens)
+				require.Equal(t, 0.25, c.StreamRatio)
+				require.Equal(t, "dummy-key", c.APIKey)
+				require.Equal(t, "table", c.Output)
+				require.Equal(t, 60*time.Second, c.Timeout)
+				return nil
+			},
+		},
+		{
+			name:         "bench without models",
+			args:         []string{"bench"},
+			bf:           func(context.Context, *cmdBench, io.Writer, io.Writer) error { return nil },
+			expPanicCode: ptr.To(80),
+		},
 		{
 			name: "download-envoy",
 			args: []string{"download-envoy"},
@@ -198,10 +255,10 @@ Flags:
 			out := &bytes.Buffer{}
 			if tt.expPanicCode != nil {
 				require.PanicsWithValue(t, *tt.expPanicCode, func() {
-					doMain(t.Context(), out, os.Stderr, tt.args, func(code int) { panic(code) }, tt.rf, tt.hf, tt.df)
+					doMain(t.Context(), out, os.Stderr, tt.args, func(code int) { panic(code) }, tt.rf, tt.hf, tt.bf, tt.df)
 				})
 			} else {
-				doMain(t.Context(), out, os.Stderr, tt.args, nil, tt.rf, tt.hf, tt.df)
+				doMain(t.Context(), out, os.Stderr, tt.args, nil, tt.rf, tt.hf, tt.bf, tt.df)
 			}
 			fmt.Println(out.String())
 			require.Equal(t, tt.expOut, out.String())
@@ -174,16 +174,16 @@ func run(ctx context.Context, c *cmdRun, o *runOpts, stdout, stderr io.Writer) e
 	if err != nil {
 		return fmt.Errorf("failed to listen: %w", err)
 	}
-	s := grpc.NewServer()
 	requestHeaderAttributes := envOptional("OTEL_AIGW_REQUEST_HEADER_ATTRIBUTES")
 	logRequestHeaderAttributes := envOptional("OTEL_AIGW_LOG_REQUEST_HEADER_ATTRIBUTES")
 	quotaRateLimitServiceAddr := "envoy-ai-gateway-ratelimit.envoy-gateway-system"
 	const quotaRateLimitTimeout = 5
 	const quotaRateLimitFailureModeDeny = false
-	extSrv, err := extensionserver.New(fakeClient, ctrl.Log, o.extprocUDSPath, true, requestHeaderAttributes, logRequestHeaderAttributes, quotaRateLimitServiceAddr, quotaRateLimitTimeout, quotaRateLimitFailureModeDeny)
+	extSrv, err := extensionserver.New(fakeClient, ctrl.Log, o.extprocUDSPath, true, requestHeaderAttributes, logRequestHeaderAttributes, quotaRateLimitServiceAddr, quotaRateLimitTimeout, quotaRateLimitFailureModeDeny, "", "")
 	if err != nil {
 		return err
 	}
+	s := grpc.NewServer(grpc.UnaryInterceptor(extSrv.CompatibilityInterceptor()))
 	egextension.RegisterEnvoyGatewayExtensionServer(s, extSrv)
 	grpc_health_v1.RegisterHealthServer(s, extSrv)
 
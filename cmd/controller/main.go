@@ -8,12 +8,14 @@ package main
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"flag"
 	"fmt"
 	"log/slog"
 	"net"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -23,13 +25,19 @@ import (
 	"google.golang.org/grpc/health/grpc_health_v1"
 	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/certwatcher"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/config"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
+	"github.com/envoyproxy/ai-gateway/internal/configstream"
 	"github.com/envoyproxy/ai-gateway/internal/controller"
 	"github.com/envoyproxy/ai-gateway/internal/extensionserver"
 	"github.com/envoyproxy/ai-gateway/internal/internalapi"
@@ -41,6 +49,7 @@ type flags struct {
 	envoyGatewayNamespace          string
 	extProcLogLevel                string
 	extProcEnableRedaction         bool
+	extProcMockProvider            bool
 	extProcImage                   string
 	extProcImagePullPolicy         corev1.PullPolicy
 	enableLeaderElection           bool
@@ -69,10 +78,36 @@ type flags struct {
 	mcpSessionEncryptionIterations         int
 	mcpFallbackSessionEncryptionIterations int
 	watchNamespaces                        []string
+	watchNamespaceSelector                 labels.Selector
 	cacheSyncTimeout                       time.Duration
 	quotaRateLimitServiceAddr              string
 	quotaRateLimitTimeout                  int64
 	quotaRateLimitFailureModeDeny          bool
+	// configStreamAddr is the host:port address of the config stream server advertised to the external processors.
+	configStreamAddr string
+	// usageReconciliationInterval, usageReconciliationDelay, usageReconciliationDriftThreshold and
+	// usageReconciliationCURDir configure the usage reconciliation. A zero interval disables it.
+	usageReconciliationInterval       time.Duration
+	usageReconciliationDelay          time.Duration
+	usageReconciliationDriftThreshold float64
+	usageReconciliationCURDir         string
+	// migrationStatusInterval is the period of the collection of the migration status. Zero disables it.
+	migrationStatusInterval time.Duration
+	// capabilityProbingInterval is the period of the probing of the capabilities of the backends. Zero disables it.
+	capabilityProbingInterval time.Duration
+	// observability configures the dashboard and alerts ConfigMaps generated per AIGatewayRoute.
+	observability controller.ObservabilityOptions
+	// sharding configures the sharding of the AIGatewayRoute reconciliation.
+	sharding controller.ShardingOptions
+	// impersonation configures the service account impersonated to write the generated resources.
+	impersonation controller.ImpersonationOptions
+	// modelNameHeaderKey is the request header set to the model name for the routing.
+	modelNameHeaderKey string
+	// metadataNamespace is the namespace of the dynamic metadata emitted by the external processor.
+	metadataNamespace string
+	// selectedBackendHeaderKey is the request header selecting the backend on the AIGatewayRoutes configuring a
+	// BackendOverride.
+	selectedBackendHeaderKey string
 }
 
 func setOptionalString(dst **string) func(string) error {
@@ -124,6 +159,12 @@ func parseAndValidateFlags(args []string) (*flags, error) {
 		false,
 		"Enable redaction of sensitive information in debug logs for the external processor.",
 	)
+	extProcMockProviderPtr := fs.Bool(
+		"extProcMockProvider",
+		false,
+		"Serve the mock OpenAI provider of the AIServiceBackends with the Mock API schema from the external processor. "+
+			"This is meant for the e2e tests, the demos and the canary environments.",
+	)
 	extProcImagePtr := fs.String(
 		"extProcImage",
 		"docker.io/envoyproxy/ai-gateway-extproc:latest",
@@ -229,6 +270,12 @@ func parseAndValidateFlags(args []string) (*flags, error) {
 		"",
 		"Comma-separated list of namespaces to watch. If not set, the controller watches all namespaces.",
 	)
+	watchNamespaceSelector := fs.String(
+		"watchNamespaceSelector",
+		"",
+		"Label selector for the namespaces whose AI Gateway resources are reconciled by this controller, e.g. \"team=a\". "+
+			"If not set, resources in all the watched namespaces are reconciled.",
+	)
 	cacheSyncTimeout := fs.Duration(
 		"cacheSyncTimeout",
 		2*time.Minute, // This is the controller-runtime default
@@ -248,6 +295,63 @@ func parseAndValidateFlags(args []string) (*flags, error) {
 		"Timeout in seconds for the quota rate limit service.")
 	quotaRateLimitFailureModeDeny := fs.Bool("quotaRateLimitFailureModeDeny", false,
 		"If true, the rate limit filter will deny requests when the rate limit service is unavailable.")
+	configStreamAddr := fs.String("configStreamAddr", "",
+		"The host:port address of the config stream server advertised to the external processors, e.g. "+
+			"\"envoy-ai-gateway-controller.envoy-ai-gateway-system.svc:1065\". When set, the controller pushes the filter "+
+			"configurations to the external processors over gRPC, and listens on the port of the address with the "+
+			"same TLS certificate as the webhook server. If not set, the external processors only watch the mounted Secrets.")
+	usageReconciliationInterval := fs.Duration("usageReconciliationInterval", 0,
+		"The period of the comparison of the token usage recorded by the external processors with the usage exports "+
+			"of the providers, as well as the window of the compared usage, e.g. 24h. Zero disables the usage reconciliation.")
+	usageReconciliationDelay := fs.Duration("usageReconciliationDelay", time.Hour,
+		"The time the usage exports of the providers lag behind. The window ending before now minus this delay is compared.")
+	usageReconciliationDriftThreshold := fs.Float64("usageReconciliationDriftThreshold", 0.05,
+		"The relative difference between the usage billed by the provider and the usage recorded by the gateway above "+
+			"which a model is reported as drifted.")
+	usageReconciliationCURDir := fs.String("usageReconciliationCURDir", "",
+		"The directory of the CSV files of the AWS Cost and Usage Reports compared with the usage of the AWSCredentials "+
+			"BackendSecurityPolicies. If not set, these policies are not reconciled.")
+	migrationStatusInterval := fs.Duration("migrationStatusInterval", time.Minute,
+		"The period at which the comparisons of the migrated rules of the AIGatewayRoutes with their migration "+
+			"backends are collected from the external processors into the status of the routes. Zero disables it.")
+	capabilityProbingInterval := fs.Duration("capabilityProbingInterval", 0,
+		"The period at which the models of the AIServiceBackends of the OpenAI schema and their support of the tools, "+
+			"the vision and the JSON mode are probed into the status of the backends, e.g. 24h. Each probe sends a few "+
+			"small chat completion requests per routed model. Zero disables the probing.")
+	observabilityConfigMaps := fs.Bool("observabilityConfigMaps", false,
+		"If true, a Grafana dashboard and Prometheus alerting rules are generated for each AIGatewayRoute into "+
+			"ConfigMaps labeled grafana_dashboard and prometheus_rule respectively.")
+	observabilityErrorRateThreshold := fs.Float64("observabilityErrorRateThreshold", 0.05,
+		"The ratio of the failed requests of an AIGatewayRoute above which the generated error rate alert fires.")
+	observabilityTimeToFirstTokenSLO := fs.Duration("observabilityTimeToFirstTokenSLO", 2*time.Second,
+		"The 95th percentile of the time to first token of an AIGatewayRoute above which the generated alert fires.")
+	observabilityQuotaSaturationThreshold := fs.Float64("observabilityQuotaSaturationThreshold", 0.9,
+		"The used ratio of a provider rate limit of the backends of an AIGatewayRoute above which the generated "+
+			"quota saturation alert fires.")
+	aiGatewayRouteShards := fs.Int("aiGatewayRouteShards", 1,
+		"The number of shards the AIGatewayRoutes are reconciled in, each by the replica leading it. "+
+			"One disables the sharding.")
+	aiGatewayRouteShardIndex := fs.Int("aiGatewayRouteShardIndex", -1,
+		"The shard this replica is a candidate of. If negative, this is the ordinal of the StatefulSet pod, "+
+			"taken from the POD_NAME environment variable or the hostname, modulo the number of shards.")
+	aiGatewayRouteShardKey := fs.String("aiGatewayRouteShardKey", string(controller.ShardKeyNamespace),
+		"What the AIGatewayRoutes are assigned to the shards by: namespace or name.")
+	generatedResourcesServiceAccount := fs.String("generatedResourcesServiceAccount", "",
+		"The service account impersonated to write the resources generated from the AIGatewayRoutes and MCPRoutes, "+
+			"such as the HTTPRoutes, so that its RBAC constrains what the controller can touch in the tenant namespaces. "+
+			"A name impersonates the service account of that name in the namespace of each resource, and a "+
+			"namespace/name impersonates the same service account everywhere. Empty disables the impersonation.")
+	modelNameHeaderKey := fs.String("modelNameHeaderKey", internalapi.ModelNameHeaderKeyDefault,
+		"The request header set to the model name by the external processor and matched by the rules of the "+
+			"AIGatewayRoutes to route the models. This allows avoiding a collision with the existing Envoy filter chains.")
+	metadataNamespace := fs.String("metadataNamespace", internalapi.AIGatewayFilterMetadataNamespace,
+		"The namespace of the dynamic metadata emitted by the external processor, such as the token usage and the "+
+			"costs read by the rate limits and the access logs. This allows avoiding a collision with the existing Envoy "+
+			"filter chains.")
+	selectedBackendHeaderKey := fs.String("selectedBackendHeaderKey", internalapi.BackendOverrideHeader,
+		"The request header selecting the backend on the AIGatewayRoutes configuring a BackendOverride. Its signature "+
+			"is in the header of the same name suffixed with \"-signature\". This allows avoiding a collision with the "+
+			"existing Envoy filter chains.")
 
 	if err := fs.Parse(args); err != nil {
 		err = fmt.Errorf("failed to parse flags: %w", err)
@@ -333,10 +437,63 @@ func parseAndValidateFlags(args []string) (*flags, error) {
 		return nil, fmt.Errorf("mcp fallback session encryption iterations must be positive: %d", *mcpFallbackSessionEncryptionIterations)
 	}
 
+	var namespaceSelector labels.Selector
+	if *watchNamespaceSelector != "" {
+		namespaceSelector, err = labels.Parse(*watchNamespaceSelector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid watch namespace selector: %w", err)
+		}
+	}
+
+	if *configStreamAddr != "" {
+		if _, _, err = net.SplitHostPort(*configStreamAddr); err != nil {
+			return nil, fmt.Errorf("invalid config stream address: %w", err)
+		}
+	}
+
+	if *usageReconciliationInterval < 0 || *usageReconciliationDelay < 0 {
+		return nil, fmt.Errorf("usage reconciliation interval and delay must not be negative")
+	}
+	if *usageReconciliationDriftThreshold < 0 {
+		return nil, fmt.Errorf("usage reconciliation drift threshold must not be negative: %v", *usageReconciliationDriftThreshold)
+	}
+	if *migrationStatusInterval < 0 {
+		return nil, fmt.Errorf("migration status interval must not be negative: %v", *migrationStatusInterval)
+	}
+	if *capabilityProbingInterval < 0 {
+		return nil, fmt.Errorf("capability probing interval must not be negative: %v", *capabilityProbingInterval)
+	}
+	if *observabilityErrorRateThreshold <= 0 || *observabilityErrorRateThreshold > 1 ||
+		*observabilityQuotaSaturationThreshold <= 0 || *observabilityQuotaSaturationThreshold > 1 {
+		return nil, fmt.Errorf("observability error rate and quota saturation thresholds must be in (0, 1]")
+	}
+	if *observabilityTimeToFirstTokenSLO <= 0 {
+		return nil, fmt.Errorf("observability time to first token SLO must be positive: %v", *observabilityTimeToFirstTokenSLO)
+	}
+	sharding, err := parseSharding(*aiGatewayRouteShards, *aiGatewayRouteShardIndex, *aiGatewayRouteShardKey)
+	if err != nil {
+		return nil, err
+	}
+	impersonation, err := parseImpersonation(*generatedResourcesServiceAccount)
+	if err != nil {
+		return nil, err
+	}
+	if errs := validation.IsHTTPHeaderName(*modelNameHeaderKey); len(errs) > 0 || *modelNameHeaderKey != strings.ToLower(*modelNameHeaderKey) {
+		return nil, fmt.Errorf("invalid model name header key %q: must be a lowercase HTTP header name", *modelNameHeaderKey)
+	}
+	if errs := validation.IsHTTPHeaderName(*selectedBackendHeaderKey); len(errs) > 0 || *selectedBackendHeaderKey != strings.ToLower(*selectedBackendHeaderKey) {
+		return nil, fmt.Errorf("invalid selected backend header key %q: must be a lowercase HTTP header name", *selectedBackendHeaderKey)
+	}
+	// The namespace is separated from the keys by a colon in the Envoy substitution formatters.
+	if *metadataNamespace == "" || strings.ContainsAny(*metadataNamespace, ": ") {
+		return nil, fmt.Errorf("invalid metadata namespace %q: must be non-empty without colons or spaces", *metadataNamespace)
+	}
+
 	return &flags{
 		envoyGatewayNamespace:                  *envoyGatewayNamespace,
 		extProcLogLevel:                        *extProcLogLevelPtr,
 		extProcEnableRedaction:                 *extProcEnableRedactionPtr,
+		extProcMockProvider:                    *extProcMockProviderPtr,
 		extProcImage:                           *extProcImagePtr,
 		extProcImagePullPolicy:                 extProcPullPolicy,
 		enableLeaderElection:                   *enableLeaderElectionPtr,
@@ -358,6 +515,7 @@ func parseAndValidateFlags(args []string) (*flags, error) {
 		extProcMaxRecvMsgSize:                  *extProcMaxRecvMsgSize,
 		maxRecvMsgSize:                         *maxRecvMsgSize,
 		watchNamespaces:                        parseWatchNamespaces(*watchNamespaces),
+		watchNamespaceSelector:                 namespaceSelector,
 		cacheSyncTimeout:                       *cacheSyncTimeout,
 		mcpSessionEncryptionSeed:               *mcpSessionEncryptionSeed,
 		mcpFallbackSessionEncryptionSeed:       *mcpFallbackSessionEncryptionSeed,
@@ -366,9 +524,89 @@ func parseAndValidateFlags(args []string) (*flags, error) {
 		quotaRateLimitServiceAddr:              *quotaRateLimitServiceAddr,
 		quotaRateLimitTimeout:                  *quotaRateLimitTimeout,
 		quotaRateLimitFailureModeDeny:          *quotaRateLimitFailureModeDeny,
+		configStreamAddr:                       *configStreamAddr,
+		usageReconciliationInterval:            *usageReconciliationInterval,
+		usageReconciliationDelay:               *usageReconciliationDelay,
+		usageReconciliationDriftThreshold:      *usageReconciliationDriftThreshold,
+		usageReconciliationCURDir:              *usageReconciliationCURDir,
+		migrationStatusInterval:                *migrationStatusInterval,
+		capabilityProbingInterval:              *capabilityProbingInterval,
+		observability: controller.ObservabilityOptions{
+			Enabled:                  *observabilityConfigMaps,
+			ErrorRateThreshold:       *observabilityErrorRateThreshold,
+			TimeToFirstTokenSLO:      *observabilityTimeToFirstTokenSLO,
+			QuotaSaturationThreshold: *observabilityQuotaSaturationThreshold,
+		},
+		sharding:                 sharding,
+		impersonation:            impersonation,
+		modelNameHeaderKey:       *modelNameHeaderKey,
+		metadataNamespace:        *metadataNamespace,
+		selectedBackendHeaderKey: *selectedBackendHeaderKey,
 	}, nil
 }
 
+// parseImpersonation validates the service account impersonated to write the generated resources, either a name or
+// a namespace/name.
+func parseImpersonation(serviceAccount string) (controller.ImpersonationOptions, error) {
+	if serviceAccount == "" {
+		return controller.ImpersonationOptions{}, nil
+	}
+	namespace, name, found := strings.Cut(serviceAccount, "/")
+	if !found {
+		namespace, name = "", serviceAccount
+	}
+	if (found && len(validation.IsDNS1123Label(namespace)) > 0) || len(validation.IsDNS1123Subdomain(name)) > 0 {
+		return controller.ImpersonationOptions{}, fmt.Errorf("invalid generated resources service account %q: must be a name or namespace/name", serviceAccount)
+	}
+	return controller.ImpersonationOptions{ServiceAccountName: name, ServiceAccountNamespace: namespace}, nil
+}
+
+// parseSharding validates the sharding flags of the AIGatewayRoute reconciliation. A negative index is derived
+// from the ordinal suffix of the StatefulSet pod name.
+func parseSharding(shards, index int, key string) (controller.ShardingOptions, error) {
+	if shards < 1 {
+		return controller.ShardingOptions{}, fmt.Errorf("the number of AIGatewayRoute shards must be positive: %d", shards)
+	}
+	switch k := controller.ShardKey(key); k {
+	case controller.ShardKeyNamespace, controller.ShardKeyName:
+	default:
+		return controller.ShardingOptions{}, fmt.Errorf("invalid AIGatewayRoute shard key %q: must be namespace or name", key)
+	}
+	if shards == 1 {
+		return controller.ShardingOptions{Shards: 1, Key: controller.ShardKey(key)}, nil
+	}
+	if index >= shards {
+		return controller.ShardingOptions{}, fmt.Errorf("the AIGatewayRoute shard index %d must be less than the number of shards %d", index, shards)
+	}
+	if index < 0 {
+		podName := os.Getenv("POD_NAME")
+		if podName == "" {
+			podName, _ = os.Hostname()
+		}
+		ordinal, err := podOrdinal(podName)
+		if err != nil {
+			return controller.ShardingOptions{}, fmt.Errorf("failed to derive the AIGatewayRoute shard index: %w", err)
+		}
+		index = ordinal % shards
+	}
+	return controller.ShardingOptions{
+		Shards: shards, Index: index, Key: controller.ShardKey(key), LeaseNamespace: os.Getenv("POD_NAMESPACE"),
+	}, nil
+}
+
+// podOrdinal returns the ordinal of the StatefulSet pod, e.g. 2 for "ai-gateway-controller-2".
+func podOrdinal(podName string) (int, error) {
+	i := strings.LastIndexByte(podName, '-')
+	if i < 0 {
+		return 0, fmt.Errorf("pod name %q has no ordinal suffix", podName)
+	}
+	ordinal, err := strconv.Atoi(podName[i+1:])
+	if err != nil || ordinal < 0 {
+		return 0, fmt.Errorf("pod name %q has no ordinal suffix", podName)
+	}
+	return ordinal, nil
+}
+
 func main() {
 	setupLog := ctrl.Log.WithName("setup")
 
@@ -387,7 +625,8 @@ func main() {
 		os.Exit(1)
 	}
 
-	setupLog.Info("configuring kubernetes cache", "watch-namespaces", parsedFlags.watchNamespaces, "sync-timeout", parsedFlags.cacheSyncTimeout)
+	setupLog.Info("configuring kubernetes cache", "watch-namespaces", parsedFlags.watchNamespaces,
+		"watch-namespace-selector", parsedFlags.watchNamespaceSelector, "sync-timeout", parsedFlags.cacheSyncTimeout)
 
 	ctx := ctrl.SetupSignalHandler()
 	pprof.Run(ctx)
@@ -422,12 +661,12 @@ func main() {
 
 	// Start the extension server running alongside the controller.
 	const extProcUDSPath = "/etc/ai-gateway-extproc-uds/run.sock"
-	s := grpc.NewServer(grpc.MaxRecvMsgSize(parsedFlags.maxRecvMsgSize))
-	extSrv, err := extensionserver.New(mgr.GetClient(), ctrl.Log, extProcUDSPath, false, parsedFlags.requestHeaderAttributes, parsedFlags.logRequestHeaderAttributes, parsedFlags.quotaRateLimitServiceAddr, parsedFlags.quotaRateLimitTimeout, parsedFlags.quotaRateLimitFailureModeDeny)
+	extSrv, err := extensionserver.New(mgr.GetClient(), ctrl.Log, extProcUDSPath, false, parsedFlags.requestHeaderAttributes, parsedFlags.logRequestHeaderAttributes, parsedFlags.quotaRateLimitServiceAddr, parsedFlags.quotaRateLimitTimeout, parsedFlags.quotaRateLimitFailureModeDeny, parsedFlags.metadataNamespace, parsedFlags.selectedBackendHeaderKey)
 	if err != nil {
 		setupLog.Error(err, "failed to create extension server")
 		os.Exit(1)
 	}
+	s := grpc.NewServer(grpc.MaxRecvMsgSize(parsedFlags.maxRecvMsgSize), grpc.UnaryInterceptor(extSrv.CompatibilityInterceptor()))
 	egextension.RegisterEnvoyGatewayExtensionServer(s, extSrv)
 	grpc_health_v1.RegisterHealthServer(s, extSrv)
 	go func() {
@@ -449,6 +688,17 @@ func main() {
 		}
 	}()
 
+	// Start the config stream server on every replica.
+	var configStreamServer *configstream.Server
+	var configStreamCA []byte
+	if parsedFlags.configStreamAddr != "" {
+		configStreamServer, configStreamCA, err = setupConfigStreamServer(mgr, k8sConfig, parsedFlags)
+		if err != nil {
+			setupLog.Error(err, "failed to set up config stream server")
+			os.Exit(1)
+		}
+	}
+
 	// Start the controller.
 	if err := controller.StartControllers(ctx, mgr, k8sConfig, ctrl.Log.WithName("controller"), &controller.Options{
 		EnvoyGatewayNamespace:                  parsedFlags.envoyGatewayNamespace,
@@ -456,6 +706,7 @@ func main() {
 		ExtProcImagePullPolicy:                 parsedFlags.extProcImagePullPolicy,
 		ExtProcLogLevel:                        parsedFlags.extProcLogLevel,
 		ExtProcEnableRedaction:                 parsedFlags.extProcEnableRedaction,
+		ExtProcMockProvider:                    parsedFlags.extProcMockProvider,
 		EnableLeaderElection:                   parsedFlags.enableLeaderElection,
 		UDSPath:                                extProcUDSPath,
 		RequestHeaderAttributes:                parsedFlags.requestHeaderAttributes,
@@ -472,11 +723,79 @@ func main() {
 		MCPFallbackSessionEncryptionSeed:       parsedFlags.mcpFallbackSessionEncryptionSeed,
 		MCPFallbackSessionEncryptionIterations: parsedFlags.mcpFallbackSessionEncryptionIterations,
 		RateLimitRunner:                        rlRunner,
+		WatchNamespaceSelector:                 parsedFlags.watchNamespaceSelector,
+		ConfigStreamServer:                     configStreamServer,
+		ConfigStreamAddr:                       parsedFlags.configStreamAddr,
+		ConfigStreamCA:                         string(configStreamCA),
+		QuotaRateLimitServiceAddr:              parsedFlags.quotaRateLimitServiceAddr,
+		UsageReconciliation: controller.UsageReconciliationOptions{
+			Interval:       parsedFlags.usageReconciliationInterval,
+			Delay:          parsedFlags.usageReconciliationDelay,
+			DriftThreshold: parsedFlags.usageReconciliationDriftThreshold,
+			OpenAIAdminKey: os.Getenv(usageReconciliationOpenAIAdminKeyEnv),
+			CURDir:         parsedFlags.usageReconciliationCURDir,
+		},
+		MigrationStatusInterval:   parsedFlags.migrationStatusInterval,
+		CapabilityProbingInterval: parsedFlags.capabilityProbingInterval,
+		Observability:             parsedFlags.observability,
+		Sharding:                  parsedFlags.sharding,
+		Impersonation:             parsedFlags.impersonation,
+		ModelNameHeaderKey:        parsedFlags.modelNameHeaderKey,
+		MetadataNamespace:         parsedFlags.metadataNamespace,
+		SelectedBackendHeaderKey:  parsedFlags.selectedBackendHeaderKey,
 	}); err != nil {
 		setupLog.Error(err, "failed to start controller")
 	}
 }
 
+// usageReconciliationOpenAIAdminKeyEnv is the environment variable holding the admin key of the OpenAI usage API
+// compared with the usage of the APIKey BackendSecurityPolicies.
+const usageReconciliationOpenAIAdminKeyEnv = "AI_GATEWAY_USAGE_RECONCILIATION_OPENAI_ADMIN_KEY" //nolint:gosec
+
+// setupConfigStreamServer creates the config stream server and registers it to the manager.
+// This returns the server and the CA certificate for the external processors to verify the server.
+func setupConfigStreamServer(mgr ctrl.Manager, k8sConfig *rest.Config, f *flags) (*configstream.Server, []byte, error) {
+	_, port, _ := net.SplitHostPort(f.configStreamAddr) // Validated in parseAndValidateFlags.
+	ca, err := os.ReadFile(filepath.Join(f.tlsCertDir, f.caBundleName))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read CA bundle: %w", err)
+	}
+	// The certificate is reloaded on rotation, as the webhook server does.
+	certWatcher, err := certwatcher.New(filepath.Join(f.tlsCertDir, f.tlsCertName), filepath.Join(f.tlsCertDir, f.tlsKeyName))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+	if err = mgr.Add(certWatcher); err != nil {
+		return nil, nil, fmt.Errorf("failed to add config stream certificate watcher: %w", err)
+	}
+	kube, err := kubernetes.NewForConfig(k8sConfig)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create kubernetes client: %w", err)
+	}
+	server := configstream.NewServer(ctrl.Log, configstream.NewServiceAccountAuthenticator(kube, f.envoyGatewayNamespace))
+	tlsConfig := &tls.Config{GetCertificate: certWatcher.GetCertificate, MinVersion: tls.VersionTLS12}
+	if err = mgr.Add(&configStreamRunnable{server: server, addr: ":" + port, tlsConfig: tlsConfig}); err != nil {
+		return nil, nil, fmt.Errorf("failed to add config stream server: %w", err)
+	}
+	return server, ca, nil
+}
+
+// configStreamRunnable runs the config stream server on every replica, since the Service routes the external
+// processors to any of them. The replicas other than the leader serve the configs written by the leader.
+type configStreamRunnable struct {
+	server    *configstream.Server
+	addr      string
+	tlsConfig *tls.Config
+}
+
+// Start implements [manager.Runnable].
+func (r *configStreamRunnable) Start(ctx context.Context) error {
+	return r.server.Start(ctx, r.addr, r.tlsConfig)
+}
+
+// NeedLeaderElection implements [manager.LeaderElectionRunnable].
+func (r *configStreamRunnable) NeedLeaderElection() bool { return false }
+
 // This should be the name of the mutating webhook configuration in helm chart.
 const mutatingWebhookConfigurationName = "envoy-ai-gateway-gateway-pod-mutator"
 
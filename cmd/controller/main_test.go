@@ -14,9 +14,12 @@ import (
 	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/envoyproxy/ai-gateway/internal/controller"
 )
 
 func Test_parseAndValidateFlags(t *testing.T) {
@@ -70,6 +73,8 @@ func Test_parseAndValidateFlags(t *testing.T) {
 					tc.dash + "mcpSessionEncryptionIterations=100",
 					tc.dash + "mcpFallbackSessionEncryptionSeed=my-fallback-seed",
 					tc.dash + "mcpFallbackSessionEncryptionIterations=200",
+					tc.dash + "observabilityConfigMaps=true",
+					tc.dash + "observabilityTimeToFirstTokenSLO=5s",
 				}
 				f, err := parseAndValidateFlags(args)
 				require.Equal(t, "eg-system", f.envoyGatewayNamespace)
@@ -96,6 +101,12 @@ func Test_parseAndValidateFlags(t *testing.T) {
 				require.Equal(t, 100, f.mcpSessionEncryptionIterations)
 				require.Equal(t, "my-fallback-seed", f.mcpFallbackSessionEncryptionSeed)
 				require.Equal(t, 200, f.mcpFallbackSessionEncryptionIterations)
+				require.Equal(t, controller.ObservabilityOptions{
+					Enabled:                  true,
+					ErrorRateThreshold:       0.05,
+					TimeToFirstTokenSLO:      5 * time.Second,
+					QuotaSaturationThreshold: 0.9,
+				}, f.observability)
 				require.NoError(t, err)
 			})
 		}
@@ -162,6 +173,51 @@ func Test_parseAndValidateFlags(t *testing.T) {
 				flags:  []string{"--endpointPrefixes=openai"},
 				expErr: "invalid endpoint prefixes",
 			},
+			{
+				name:   "invalid watchNamespaceSelector",
+				flags:  []string{"--watchNamespaceSelector=team in (a"},
+				expErr: "invalid watch namespace selector",
+			},
+			{
+				name:   "invalid configStreamAddr",
+				flags:  []string{"--configStreamAddr=controller"},
+				expErr: "invalid config stream address",
+			},
+			{
+				name:   "negative usageReconciliationInterval",
+				flags:  []string{"--usageReconciliationInterval=-1h"},
+				expErr: "usage reconciliation interval and delay must not be negative",
+			},
+			{
+				name:   "negative usageReconciliationDriftThreshold",
+				flags:  []string{"--usageReconciliationDriftThreshold=-0.1"},
+				expErr: "usage reconciliation drift threshold must not be negative: -0.1",
+			},
+			{
+				name:   "negative migrationStatusInterval",
+				flags:  []string{"--migrationStatusInterval=-1m"},
+				expErr: "migration status interval must not be negative: -1m0s",
+			},
+			{
+				name:   "negative capabilityProbingInterval",
+				flags:  []string{"--capabilityProbingInterval=-1h"},
+				expErr: "capability probing interval must not be negative: -1h0m0s",
+			},
+			{
+				name:   "observabilityErrorRateThreshold above one",
+				flags:  []string{"--observabilityErrorRateThreshold=1.5"},
+				expErr: "observability error rate and quota saturation thresholds must be in (0, 1]",
+			},
+			{
+				name:   "zero observabilityQuotaSaturationThreshold",
+				flags:  []string{"--observabilityQuotaSaturationThreshold=0"},
+				expErr: "observability error rate and quota saturation thresholds must be in (0, 1]",
+			},
+			{
+				name:   "zero observabilityTimeToFirstTokenSLO",
+				flags:  []string{"--observabilityTimeToFirstTokenSLO=0s"},
+				expErr: "observability time to first token SLO must be positive: 0s",
+			},
 			{
 				name:   "invalid mcp session encryption iterations",
 				flags:  []string{"--mcpSessionEncryptionIterations=invalid"},
@@ -320,6 +376,134 @@ func Test_parseAndValidateFlags_watchNamespaces(t *testing.T) {
 	}
 }
 
+func Test_parseAndValidateFlags_watchNamespaceSelector(t *testing.T) {
+	t.Run("not set", func(t *testing.T) {
+		f, err := parseAndValidateFlags([]string{})
+		require.NoError(t, err)
+		require.Nil(t, f.watchNamespaceSelector)
+	})
+	t.Run("set", func(t *testing.T) {
+		f, err := parseAndValidateFlags([]string{"--watchNamespaceSelector=team=a,env!=prod"})
+		require.NoError(t, err)
+		require.NotNil(t, f.watchNamespaceSelector)
+		require.True(t, f.watchNamespaceSelector.Matches(labels.Set{"team": "a", "env": "dev"}))
+		require.False(t, f.watchNamespaceSelector.Matches(labels.Set{"team": "a", "env": "prod"}))
+		require.False(t, f.watchNamespaceSelector.Matches(labels.Set{"team": "b"}))
+	})
+}
+
+func Test_parseAndValidateFlags_configStreamAddr(t *testing.T) {
+	f, err := parseAndValidateFlags([]string{})
+	require.NoError(t, err)
+	require.Empty(t, f.configStreamAddr)
+
+	f, err = parseAndValidateFlags([]string{"--configStreamAddr=envoy-ai-gateway-controller.envoy-ai-gateway-system.svc:1065"})
+	require.NoError(t, err)
+	require.Equal(t, "envoy-ai-gateway-controller.envoy-ai-gateway-system.svc:1065", f.configStreamAddr)
+}
+
+func Test_parseAndValidateFlags_sharding(t *testing.T) {
+	f, err := parseAndValidateFlags([]string{})
+	require.NoError(t, err)
+	require.Equal(t, controller.ShardingOptions{Shards: 1, Key: controller.ShardKeyNamespace}, f.sharding)
+
+	t.Setenv("POD_NAMESPACE", "envoy-ai-gateway-system")
+	f, err = parseAndValidateFlags([]string{"--aiGatewayRouteShards=3", "--aiGatewayRouteShardIndex=2", "--aiGatewayRouteShardKey=name"})
+	require.NoError(t, err)
+	require.Equal(t, controller.ShardingOptions{
+		Shards: 3, Index: 2, Key: controller.ShardKeyName, LeaseNamespace: "envoy-ai-gateway-system",
+	}, f.sharding)
+
+	// The index defaults to the ordinal of the StatefulSet pod.
+	t.Setenv("POD_NAME", "ai-gateway-controller-4")
+	f, err = parseAndValidateFlags([]string{"--aiGatewayRouteShards=3"})
+	require.NoError(t, err)
+	require.Equal(t, 1, f.sharding.Index)
+
+	for _, tc := range []struct {
+		name    string
+		podName string
+		flags   []string
+		expErr  string
+	}{
+		{name: "zero shards", flags: []string{"--aiGatewayRouteShards=0"}, expErr: "the number of AIGatewayRoute shards must be positive: 0"},
+		{name: "invalid key", flags: []string{"--aiGatewayRouteShardKey=label"}, expErr: `invalid AIGatewayRoute shard key "label"`},
+		{
+			name:   "index out of range",
+			flags:  []string{"--aiGatewayRouteShards=2", "--aiGatewayRouteShardIndex=2"},
+			expErr: "the AIGatewayRoute shard index 2 must be less than the number of shards 2",
+		},
+		{
+			name:    "no ordinal",
+			podName: "ai-gateway-controller-7d9f8c6b5-x2x9z",
+			flags:   []string{"--aiGatewayRouteShards=2"},
+			expErr:  `failed to derive the AIGatewayRoute shard index: pod name "ai-gateway-controller-7d9f8c6b5-x2x9z" has no ordinal suffix`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("POD_NAME", tc.podName)
+			_, err := parseAndValidateFlags(tc.flags)
+			require.ErrorContains(t, err, tc.expErr)
+		})
+	}
+}
+
+func Test_parseAndValidateFlags_impersonation(t *testing.T) {
+	f, err := parseAndValidateFlags([]string{})
+	require.NoError(t, err)
+	require.Equal(t, controller.ImpersonationOptions{}, f.impersonation)
+
+	f, err = parseAndValidateFlags([]string{"--generatedResourcesServiceAccount=ai-gateway-generator"})
+	require.NoError(t, err)
+	require.Equal(t, controller.ImpersonationOptions{ServiceAccountName: "ai-gateway-generator"}, f.impersonation)
+
+	f, err = parseAndValidateFlags([]string{"--generatedResourcesServiceAccount=envoy-ai-gateway-system/ai-gateway-generator"})
+	require.NoError(t, err)
+	require.Equal(t, controller.ImpersonationOptions{
+		ServiceAccountName: "ai-gateway-generator", ServiceAccountNamespace: "envoy-ai-gateway-system",
+	}, f.impersonation)
+
+	for _, sa := range []string{"a/b/c", "/name", "namespace/", "Name"} {
+		t.Run(sa, func(t *testing.T) {
+			_, err := parseAndValidateFlags([]string{"--generatedResourcesServiceAccount=" + sa})
+			require.ErrorContains(t, err, "invalid generated resources service account")
+		})
+	}
+}
+
+func Test_parseAndValidateFlags_installationNames(t *testing.T) {
+	f, err := parseAndValidateFlags([]string{})
+	require.NoError(t, err)
+	require.Equal(t, "x-ai-eg-model", f.modelNameHeaderKey)
+	require.Equal(t, "io.envoy.ai_gateway", f.metadataNamespace)
+	require.Equal(t, "x-aigw-backend", f.selectedBackendHeaderKey)
+
+	f, err = parseAndValidateFlags([]string{
+		"--modelNameHeaderKey=x-llm-model", "--metadataNamespace=com.example.llm", "--selectedBackendHeaderKey=x-llm-backend",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "x-llm-model", f.modelNameHeaderKey)
+	require.Equal(t, "com.example.llm", f.metadataNamespace)
+	require.Equal(t, "x-llm-backend", f.selectedBackendHeaderKey)
+
+	for _, key := range []string{"", "X-Model", "x model"} {
+		t.Run("header "+key, func(t *testing.T) {
+			_, err := parseAndValidateFlags([]string{"--modelNameHeaderKey=" + key})
+			require.ErrorContains(t, err, "invalid model name header key")
+		})
+		t.Run("selected backend header "+key, func(t *testing.T) {
+			_, err := parseAndValidateFlags([]string{"--selectedBackendHeaderKey=" + key})
+			require.ErrorContains(t, err, "invalid selected backend header key")
+		})
+	}
+	for _, ns := range []string{"", "a:b", "a b"} {
+		t.Run("namespace "+ns, func(t *testing.T) {
+			_, err := parseAndValidateFlags([]string{"--metadataNamespace=" + ns})
+			require.ErrorContains(t, err, "invalid metadata namespace")
+		})
+	}
+}
+
 func TestSetupCache(t *testing.T) {
 	t.Run("default", func(t *testing.T) {
 		c := setupCache(&flags{})
@@ -19,6 +19,9 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/envoyproxy/ai-gateway/internal/billing"
+	"github.com/envoyproxy/ai-gateway/internal/extproc"
 )
 
 // newGrpcClient creates a gRPC client connection for the provided address.
@@ -31,22 +34,27 @@ func newGrpcClient(addr net.Addr, opts ...grpc.DialOption) (*grpc.ClientConn, er
 	default:
 		prefix = ""
 	}
-	opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	// The transport credentials in opts, if any, take precedence.
+	opts = append([]grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}, opts...)
 	return grpc.NewClient(prefix+addr.String(), opts...)
 }
 
 // startAdminServer starts an HTTP admin server on the provided listener for
-// serving Prometheus metrics and health checks. It exposes two endpoints:
-//   - /metrics: Serves Prometheus metrics using the provided registry.
-//   - /health: Same check Envoy uses: this ExternalProcessorServer.
+// serving Prometheus metrics and health checks. It exposes the following endpoints:
+//   - /metrics: Serves Prometheus metrics using the provided registry, in the OpenMetrics format if requested.
+//   - /health: Same check Envoy uses: this ExternalProcessorServer, which is not serving until the first config is loaded.
+//   - /v1/usage: Serves the aggregated usage of the billing export, if usage is not nil.
+//   - /v1/migrations: Serves the comparisons of the migrated rules with their migration backends.
 //
 // The server returned is running in a goroutine.
-func startAdminServer(lis net.Listener, logger *slog.Logger, registry prometheus.Gatherer, extprocHealth grpc_health_v1.HealthClient) *http.Server {
+func startAdminServer(lis net.Listener, logger *slog.Logger, registry prometheus.Gatherer, extprocHealth grpc_health_v1.HealthClient, usage http.Handler) *http.Server {
 	mux := http.NewServeMux()
 
+	// The OpenMetrics format is negotiated so that the trace ID exemplars of the histograms are exposed to the
+	// scrapers requesting it, e.g. Prometheus with the exemplar storage enabled.
 	mux.Handle("/metrics", promhttp.HandlerFor(
 		registry,
-		promhttp.HandlerOpts{},
+		promhttp.HandlerOpts{EnableOpenMetrics: true},
 	))
 
 	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
@@ -66,6 +74,11 @@ func startAdminServer(lis net.Listener, logger *slog.Logger, registry prometheus
 		_, _ = w.Write([]byte("OK\n"))
 	})
 
+	if usage != nil {
+		mux.Handle(billing.UsagePath, usage)
+	}
+	mux.Handle(extproc.MigrationsPath, extproc.MigrationsHandler())
+
 	server := &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second}
 
 	go func() {
@@ -136,7 +136,7 @@ gen_ai_server_request_duration_seconds_count{gen_ai_operation_name="chat",gen_ai
 			}
 			mockRegistry := &mockPrometheusGatherer{metricFamilies: tt.metricFamilies}
 
-			s := startAdminServer(lis, slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{})), mockRegistry, mockHealthClient)
+			s := startAdminServer(lis, slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{})), mockRegistry, mockHealthClient, nil)
 			defer s.Shutdown(context.Background()) //nolint:errcheck
 
 			rr := httptest.NewRecorder()
@@ -149,6 +149,31 @@ gen_ai_server_request_duration_seconds_count{gen_ai_operation_name="chat",gen_ai
 	}
 }
 
+func TestStartAdminServer_OpenMetrics(t *testing.T) {
+	lis, err := listen(t.Context(), t.Name(), "tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer lis.Close() //nolint:errcheck
+
+	mockHealthClient := &mockHealthClient{
+		checkResp: &grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_SERVING},
+	}
+	s := startAdminServer(lis, slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{})), &mockPrometheusGatherer{}, mockHealthClient, nil)
+	defer s.Shutdown(context.Background()) //nolint:errcheck
+
+	// The exemplars are only exposed in the OpenMetrics format, which is served when requested.
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Accept", "application/openmetrics-text; version=1.0.0")
+	s.Handler.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Contains(t, rr.Header().Get("Content-Type"), "application/openmetrics-text")
+
+	rr = httptest.NewRecorder()
+	s.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Contains(t, rr.Header().Get("Content-Type"), "text/plain")
+}
+
 func TestStartAdminServer_Health(t *testing.T) {
 	tests := []struct {
 		name               string
@@ -189,7 +214,7 @@ func TestStartAdminServer_Health(t *testing.T) {
 			defer lis.Close() //nolint:errcheck
 
 			mockRegistry := &mockPrometheusGatherer{metricFamilies: []*prometheusmodel.MetricFamily{}}
-			s := startAdminServer(lis, slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{})), mockRegistry, tt.healthClient)
+			s := startAdminServer(lis, slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{})), mockRegistry, tt.healthClient, nil)
 			defer s.Shutdown(context.Background()) //nolint:errcheck
 
 			rr := httptest.NewRecorder()
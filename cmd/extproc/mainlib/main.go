@@ -15,23 +15,33 @@ import (
 	"math"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"path"
+	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/config"
 	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
 	"github.com/prometheus/client_golang/prometheus"
 	otelprom "go.opentelemetry.io/otel/exporters/prometheus"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/health/grpc_health_v1"
 
+	"github.com/envoyproxy/ai-gateway/internal/backendauth"
+	"github.com/envoyproxy/ai-gateway/internal/billing"
+	"github.com/envoyproxy/ai-gateway/internal/configstream"
+	"github.com/envoyproxy/ai-gateway/internal/conversationstore"
 	"github.com/envoyproxy/ai-gateway/internal/endpointspec"
 	"github.com/envoyproxy/ai-gateway/internal/extproc"
 	"github.com/envoyproxy/ai-gateway/internal/filterapi"
 	"github.com/envoyproxy/ai-gateway/internal/internalapi"
 	"github.com/envoyproxy/ai-gateway/internal/mcpproxy"
 	"github.com/envoyproxy/ai-gateway/internal/metrics"
+	"github.com/envoyproxy/ai-gateway/internal/mockprovider"
 	"github.com/envoyproxy/ai-gateway/internal/requestheaderattrs"
 	"github.com/envoyproxy/ai-gateway/internal/tracing"
 	"github.com/envoyproxy/ai-gateway/internal/version"
@@ -42,7 +52,11 @@ type extProcFlags struct {
 	configPath                             string        // path to the configuration file.
 	configBundlePath                       string        // path to the sharded configuration bundle directory.
 	extProcAddr                            string        // gRPC address for the external processor.
+	extProcTLSCertFile                     string        // path to the TLS certificate of the gRPC listener. Optional.
+	extProcTLSKeyFile                      string        // path to the TLS private key of the gRPC listener. Optional.
+	extProcTLSClientCAFile                 string        // path to the CA verifying the client certificates. Optional.
 	logLevel                               slog.Level    // log level for the external processor.
+	logFormat                              string        // log format for the external processor, either "text" or "json".
 	enableRedaction                        bool          // enable redaction of sensitive information in debug logs.
 	adminPort                              int           // HTTP port for the admin server (metrics and health).
 	requestHeaderAttributes                *string       // comma-separated key-value pairs for mapping HTTP request headers to otel attributes shared across metrics, spans, and access logs.
@@ -61,6 +75,83 @@ type extProcFlags struct {
 	maxRecvMsgSize int
 	// endpointPrefixes is the comma-separated key-value pairs for endpoint prefixes.
 	endpointPrefixes string
+	// configStreamAddr is the address of the controller's config stream server. Optional.
+	configStreamAddr string
+	// configStreamResourceName is the name of the config stream resource to subscribe to.
+	configStreamResourceName string
+	// configStreamTokenPath is the path to the service account token presented to the config stream server.
+	configStreamTokenPath string
+	// configStreamInsecure connects to the config stream server without TLS.
+	configStreamInsecure bool
+	// requestPhaseTimeout and responsePhaseTimeout are the maximum durations of processing a single message of
+	// the request and the response. Zero disables the timeout.
+	requestPhaseTimeout  time.Duration
+	responsePhaseTimeout time.Duration
+	// maxDecompressedRequestBodySize is the maximum size in bytes of a compressed request body after decompression.
+	maxDecompressedRequestBodySize int64
+	// responseSpillThreshold is the size in bytes above which a non-streaming response is buffered in a temporary
+	// file in responseSpillDir. Zero disables the spill. responseSpillMaxResponseSize and responseSpillMaxTotalSize
+	// limit the size of a spilled response and the total size of the temporary files.
+	responseSpillThreshold       int64
+	responseSpillDir             string
+	responseSpillMaxResponseSize int64
+	responseSpillMaxTotalSize    int64
+	// billingExportWindow is the window over which the usage is aggregated for the billing export.
+	billingExportWindow time.Duration
+	// billingExportDir is the directory where the usage reports are written. Optional.
+	billingExportDir string
+	// billingExportFormat is the format of the usage reports written to the directory or the object storage.
+	billingExportFormat string
+	// billingExportObjectStorageURL is the location the usage reports are uploaded to, as s3://bucket/prefix. Optional.
+	billingExportObjectStorageURL string
+	// billingExportObjectStorageRegion is the region of the bucket. Defaults to the region of the AWS config.
+	billingExportObjectStorageRegion string
+	// billingExportObjectStorageEndpoint is the URL of the S3 compatible API. Defaults to Amazon S3.
+	billingExportObjectStorageEndpoint string
+	// billingExportMeteringURL is the event ingestion URL of the metering API the usage is pushed to. Optional.
+	billingExportMeteringURL string
+	// billingExportTenantHeader is the request header holding the tenant the usage is aggregated per.
+	billingExportTenantHeader string
+	// billingExportInstance identifies this replica in the usage reports. Defaults to the pod name.
+	billingExportInstance string
+	// usageLedgerURL is the URL of the ledger of the usage shared by the replicas and served by the usage API. Optional.
+	usageLedgerURL string
+	// usageLedgerRetention is the duration after which the usage of a window is deleted from the ledger.
+	usageLedgerRetention time.Duration
+	// conversationStoreURL is the URL of the store of the Responses API conversations. Optional.
+	conversationStoreURL string
+	// conversationStoreRetention is the duration after which a stored conversation expires.
+	conversationStoreRetention time.Duration
+	// conversationStoreTenantHeader is the request header holding the tenant the conversations are isolated per.
+	conversationStoreTenantHeader string
+	// conversationStoreTimeout bounds each operation of the conversation store.
+	conversationStoreTimeout time.Duration
+	// mockProviderAddr is the address (TCP or UDS) of the mock OpenAI provider serving the backends with the Mock
+	// API schema. Optional.
+	mockProviderAddr string
+	// mockProviderLatency and mockProviderTokensPerSecond are the default latency and token rate of the mock provider.
+	mockProviderLatency         time.Duration
+	mockProviderTokensPerSecond float64
+	// printConfigSchema prints the JSON Schema of the configuration and exits.
+	printConfigSchema bool
+	// validateConfig validates the configuration file or bundle and exits.
+	validateConfig bool
+}
+
+const (
+	// logFormatText is the logfmt-style format of [slog.TextHandler].
+	logFormatText = "text"
+	// logFormatJSON is the structured JSON format of [slog.JSONHandler], one object per line.
+	logFormatJSON = "json"
+)
+
+// newLogger creates the logger writing to w in the given format. The format is expected to be validated.
+func newLogger(w io.Writer, format string, level slog.Level) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: level}
+	if format == logFormatJSON {
+		return slog.New(slog.NewJSONHandler(w, opts))
+	}
+	return slog.New(slog.NewTextHandler(w, opts))
 }
 
 func setOptionalString(dst **string) func(string) error {
@@ -94,11 +185,24 @@ func parseAndValidateFlags(args []string) (extProcFlags, error) {
 		":1063",
 		"gRPC address for the external processor. For example, :1063 or unix:///tmp/ext_proc.sock.",
 	)
+	fs.StringVar(&flags.extProcTLSCertFile, "extProcTLSCertFile", "",
+		"The path to the PEM-encoded TLS certificate of the gRPC listener of the external processor. When set with "+
+			"extProcTLSKeyFile, the listener serves TLS, and the files are reloaded when they change. Optional.")
+	fs.StringVar(&flags.extProcTLSKeyFile, "extProcTLSKeyFile", "",
+		"The path to the PEM-encoded private key of the extProcTLSCertFile certificate. Optional.")
+	fs.StringVar(&flags.extProcTLSClientCAFile, "extProcTLSClientCAFile", "",
+		"The path to the PEM-encoded CA certificates verifying the client certificates of Envoy. When set, the "+
+			"listener requires the client certificates, i.e. mutual TLS. Optional.")
 	logLevelPtr := fs.String(
 		"logLevel",
 		"info",
 		"log level for the external processor. One of 'debug', 'info', 'warn', or 'error'.",
 	)
+	fs.StringVar(&flags.logFormat,
+		"logFormat",
+		logFormatText,
+		"log format for the external processor. One of 'text' or 'json'.",
+	)
 	fs.BoolVar(&flags.enableRedaction, "enableRedaction", false,
 		"Enable redaction of sensitive information in debug logs.")
 	fs.IntVar(&flags.adminPort, "adminPort", 1064, "HTTP port for the admin server (serves /metrics and /health endpoints).")
@@ -144,17 +248,140 @@ func parseAndValidateFlags(args []string) (extProcFlags, error) {
 		"Number of iterations used in the fallback PBKDF2 key derivation for MCP session encryption.")
 	fs.DurationVar(&flags.mcpWriteTimeout, "mcpWriteTimeout", 120*time.Second,
 		"The maximum duration before timing out writes of the MCP response")
+	fs.StringVar(&flags.configStreamAddr, "configStreamAddr", "",
+		"The host:port address of the controller's config stream server. When set, configuration updates are pushed "+
+			"by the controller in addition to watching the configuration files. The CA certificate of the server is read "+
+			"from the "+configstream.CAEnvVar+" environment variable. Optional.")
+	fs.StringVar(&flags.configStreamResourceName, "configStreamResourceName", "",
+		"The name of the config stream resource to subscribe to, in the form of <gateway namespace>/<gateway name>.")
+	fs.StringVar(&flags.configStreamTokenPath, "configStreamTokenPath", "",
+		"The path to the service account token presented to the config stream server.")
+	fs.BoolVar(&flags.configStreamInsecure, "configStreamInsecure", false,
+		"Connect to the config stream server without TLS when no CA certificate is provided. Only meant for testing.")
+	fs.Int64Var(&flags.maxDecompressedRequestBodySize, "maxDecompressedRequestBodySize", 64<<20,
+		"The maximum size in bytes of a request body compressed by the client after decompression. Larger request bodies are rejected with 413.")
+	fs.Int64Var(&flags.responseSpillThreshold, "responseSpillThreshold", 0,
+		"The size in bytes above which a non-streaming response is buffered in a temporary file rather than in memory. Zero disables the spill.")
+	fs.StringVar(&flags.responseSpillDir, "responseSpillDir", "",
+		"The directory of the temporary files of the spilled responses. Defaults to the directory for temporary files of the OS.")
+	fs.Int64Var(&flags.responseSpillMaxResponseSize, "responseSpillMaxResponseSize", 256<<20,
+		"The maximum size in bytes of a spilled response. Larger responses fail.")
+	fs.Int64Var(&flags.responseSpillMaxTotalSize, "responseSpillMaxTotalSize", 1<<30,
+		"The maximum total size in bytes of the temporary files of the spilled responses. The responses exceeding it fail.")
+	fs.DurationVar(&flags.requestPhaseTimeout, "requestPhaseTimeout", 0,
+		"The maximum duration of processing the request headers or the request body, including fetching the credentials of the backend. Zero disables the timeout.")
+	fs.DurationVar(&flags.responsePhaseTimeout, "responsePhaseTimeout", 0,
+		"The maximum duration of processing the response headers or a chunk of the response body. Zero disables the timeout.")
+	fs.DurationVar(&flags.billingExportWindow, "billingExportWindow", time.Hour,
+		"The window over which the usage is aggregated per tenant, model and backend for the billing export.")
+	fs.StringVar(&flags.billingExportDir, "billingExportDir", "",
+		"The directory where the usage report of each billing export window is written. Optional.")
+	fs.StringVar(&flags.billingExportFormat, "billingExportFormat", string(billing.ReportFormatCSV),
+		"The format of the usage reports written to billingExportDir or uploaded to billingExportObjectStorageURL, csv or parquet.")
+	fs.StringVar(&flags.billingExportObjectStorageURL, "billingExportObjectStorageURL", "",
+		"The location in an S3 compatible object storage the usage report of each billing export window is uploaded to, "+
+			"as s3://<bucket>/<prefix>. The credentials are read by the default AWS credential chain, e.g. from IRSA or the "+
+			"AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY environment variables. Optional.")
+	fs.StringVar(&flags.billingExportObjectStorageRegion, "billingExportObjectStorageRegion", "",
+		"The region of the bucket of billingExportObjectStorageURL. Defaults to the region of the AWS config, e.g. AWS_REGION.")
+	fs.StringVar(&flags.billingExportObjectStorageEndpoint, "billingExportObjectStorageEndpoint", "",
+		"The URL of the S3 compatible API of the object storage, e.g. https://storage.googleapis.com for Google Cloud "+
+			"Storage. Defaults to Amazon S3.")
+	fs.StringVar(&flags.billingExportMeteringURL, "billingExportMeteringURL", "",
+		"The event ingestion URL of a metering API such as OpenMeter the usage of each billing export window is pushed to "+
+			"as CloudEvents. The bearer token is read from the "+billingExportMeteringTokenEnvVar+" environment variable. Optional.")
+	fs.StringVar(&flags.billingExportInstance, "billingExportInstance", "",
+		"The identity of this replica in the usage reports of the billing export, which must be unique among the replicas. "+
+			"Defaults to the POD_NAME environment variable or the hostname.")
+	fs.StringVar(&flags.billingExportTenantHeader, "billingExportTenantHeader", "x-tenant-id",
+		"The request header holding the tenant the usage is aggregated per for the billing export.")
+	fs.StringVar(&flags.usageLedgerURL, "usageLedgerURL", "",
+		"The URL of the ledger the usage of all the replicas is stored in, which is served at "+billing.UsagePath+
+			" on the admin port. One of memory:, redis://host:port/db or postgres://host:port/db. The memory: ledger only "+
+			"holds the usage of this replica. The password is read from the "+usageLedgerPasswordEnvVar+
+			" environment variable if set. Optional.")
+	fs.DurationVar(&flags.usageLedgerRetention, "usageLedgerRetention", 90*24*time.Hour,
+		"The duration after the end of a billing export window its usage is deleted from the usage ledger.")
+	fs.StringVar(&flags.conversationStoreURL, "conversationStoreURL", "",
+		"The URL of the store of the Responses API conversations, which serves the requests with previous_response_id "+
+			"for the backends that keep no state. One of memory:, redis://host:port/db or postgres://host:port/db. The "+
+			"password is read from the "+conversationStorePasswordEnvVar+" environment variable if set. Optional.")
+	fs.DurationVar(&flags.conversationStoreRetention, "conversationStoreRetention", 30*24*time.Hour,
+		"The duration after which a stored Responses API conversation expires.")
+	fs.StringVar(&flags.conversationStoreTenantHeader, "conversationStoreTenantHeader", "x-tenant-id",
+		"The request header holding the tenant the Responses API conversations are isolated per. The requests without "+
+			"it share the conversations of the empty tenant.")
+	fs.DurationVar(&flags.conversationStoreTimeout, "conversationStoreTimeout", time.Second,
+		"The maximum duration of reading or writing a Responses API conversation in the store.")
+	fs.StringVar(&flags.mockProviderAddr, "mockProviderAddr", "",
+		"The address (TCP or UDS) of the mock OpenAI provider serving the backends with the Mock API schema, such as "+
+			":1065 or unix:///tmp/mock-provider.sock. Optional.")
+	fs.DurationVar(&flags.mockProviderLatency, "mockProviderLatency", 0,
+		"The default latency of the mock provider before the first token, overridden by the "+mockprovider.LatencyHeader+" request header.")
+	fs.Float64Var(&flags.mockProviderTokensPerSecond, "mockProviderTokensPerSecond", 0,
+		"The default rate of the tokens generated by the mock provider, overridden by the "+mockprovider.TokensPerSecondHeader+
+			" request header. Zero generates the tokens at once.")
+
+	fs.BoolVar(&flags.printConfigSchema, "printConfigSchema", false,
+		"Print the JSON Schema of the configuration file to stdout and exit.")
+	fs.BoolVar(&flags.validateConfig, "validateConfig", false,
+		"Validate the configuration file or bundle and exit without starting the external processor.")
 
 	if err := fs.Parse(args); err != nil {
 		return extProcFlags{}, fmt.Errorf("failed to parse extProcFlags: %w", err)
 	}
 
-	if flags.configPath == "" && flags.configBundlePath == "" {
+	if flags.configPath == "" && flags.configBundlePath == "" && !flags.printConfigSchema {
 		errs = append(errs, fmt.Errorf("either configPath or configBundlePath must be provided"))
 	}
+	if flags.maxDecompressedRequestBodySize <= 0 {
+		errs = append(errs, fmt.Errorf("maxDecompressedRequestBodySize must be positive"))
+	}
+	if flags.responseSpillThreshold < 0 || flags.responseSpillMaxResponseSize <= 0 || flags.responseSpillMaxTotalSize <= 0 {
+		errs = append(errs, fmt.Errorf("responseSpillThreshold must not be negative and the response spill sizes must be positive"))
+	}
+	if flags.requestPhaseTimeout < 0 || flags.responsePhaseTimeout < 0 {
+		errs = append(errs, fmt.Errorf("phase timeouts must not be negative"))
+	}
+	if flags.billingExportWindow <= 0 {
+		errs = append(errs, fmt.Errorf("billingExportWindow must be positive"))
+	}
+	if !slices.Contains(billing.ReportFormats, billing.ReportFormat(flags.billingExportFormat)) {
+		errs = append(errs, fmt.Errorf("billingExportFormat must be one of %v", billing.ReportFormats))
+	}
+	if flags.billingExportObjectStorageURL != "" {
+		if _, _, err := parseObjectStorageURL(flags.billingExportObjectStorageURL); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if (flags.billingExportDir != "" || flags.billingExportMeteringURL != "" || flags.billingExportObjectStorageURL != "" ||
+		flags.usageLedgerURL != "") && flags.billingExportTenantHeader == "" {
+		errs = append(errs, fmt.Errorf("billingExportTenantHeader must be provided when the billing export is enabled"))
+	}
+	if flags.usageLedgerRetention <= 0 {
+		errs = append(errs, fmt.Errorf("usageLedgerRetention must be positive"))
+	}
+	if flags.conversationStoreRetention <= 0 || flags.conversationStoreTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("conversationStoreRetention and conversationStoreTimeout must be positive"))
+	}
+	if flags.mockProviderLatency < 0 || flags.mockProviderTokensPerSecond < 0 {
+		errs = append(errs, fmt.Errorf("mockProviderLatency and mockProviderTokensPerSecond must not be negative"))
+	}
+	if (flags.extProcTLSCertFile == "") != (flags.extProcTLSKeyFile == "") {
+		errs = append(errs, fmt.Errorf("extProcTLSCertFile and extProcTLSKeyFile must be provided together"))
+	}
+	if flags.extProcTLSClientCAFile != "" && flags.extProcTLSCertFile == "" {
+		errs = append(errs, fmt.Errorf("extProcTLSClientCAFile requires extProcTLSCertFile and extProcTLSKeyFile"))
+	}
+	if flags.configStreamAddr != "" && flags.configStreamResourceName == "" {
+		errs = append(errs, fmt.Errorf("configStreamResourceName must be provided when configStreamAddr is set"))
+	}
 	if err := flags.logLevel.UnmarshalText([]byte(*logLevelPtr)); err != nil {
 		errs = append(errs, fmt.Errorf("failed to unmarshal log level: %w", err))
 	}
+	if flags.logFormat != logFormatText && flags.logFormat != logFormatJSON {
+		errs = append(errs, fmt.Errorf("invalid log format: %q, must be one of %q or %q", flags.logFormat, logFormatText, logFormatJSON))
+	}
 	if flags.requestHeaderAttributes != nil && *flags.requestHeaderAttributes != "" {
 		if _, err := internalapi.ParseRequestHeaderAttributeMapping(*flags.requestHeaderAttributes); err != nil {
 			errs = append(errs, fmt.Errorf("failed to parse request header mapping: %w", err))
@@ -205,7 +432,14 @@ func Main(ctx context.Context, args []string, stderr io.Writer) (err error) {
 		return fmt.Errorf("failed to parse and validate extProcFlags: %w", err)
 	}
 
-	l := slog.New(slog.NewTextHandler(stderr, &slog.HandlerOptions{Level: flags.logLevel}))
+	if flags.printConfigSchema {
+		return printConfigSchema(os.Stdout)
+	}
+	if flags.validateConfig {
+		return validateConfig(&flags, stderr)
+	}
+
+	l := newLogger(stderr, flags.logFormat, flags.logLevel)
 
 	l.Info("starting external processor",
 		slog.String("version", version.Parse()),
@@ -249,6 +483,23 @@ func Main(ctx context.Context, args []string, stderr io.Writer) (err error) {
 		l.Info("MCP proxy is enabled", "address", flags.mcpAddr)
 	}
 
+	var mockProviderLis net.Listener
+	if flags.mockProviderAddr != "" {
+		mockProviderNetwork, mockProviderAddress := listenAddress(flags.mockProviderAddr)
+		mockProviderLis, err = listen(ctx, "mock provider", mockProviderNetwork, mockProviderAddress)
+		if err != nil {
+			return err
+		}
+		if mockProviderNetwork == "unix" {
+			// Change the permission of the UDS to 0775 so that the envoy process (the same group) can access it.
+			err = os.Chmod(mockProviderAddress, 0o775)
+			if err != nil {
+				return fmt.Errorf("failed to change UDS permission: %w", err)
+			}
+		}
+		l.Info("mock provider is enabled", "address", flags.mockProviderAddr)
+	}
+
 	spanRequestHeaderAttributes, metricsRequestHeaderAttributes, logRequestHeaderAttributes, err := requestheaderattrs.ResolveAll(
 		flags.requestHeaderAttributes,
 		flags.spanRequestHeaderAttributes,
@@ -294,14 +545,75 @@ func Main(ctx context.Context, args []string, stderr io.Writer) (err error) {
 	translationMetricsFactory := metrics.NewMetricsFactory(meter, metricsRequestHeaderAttributes, metrics.GenAIOperationTranslation)
 	rerankMetricsFactory := metrics.NewMetricsFactory(meter, metricsRequestHeaderAttributes, metrics.GenAIOperationRerank)
 	tokenizeMetricsFactory := metrics.NewMetricsFactory(meter, metricsRequestHeaderAttributes, metrics.GenAIOperationTokenize)
+	moderationMetricsFactory := metrics.NewMetricsFactory(meter, metricsRequestHeaderAttributes, metrics.GenAIOperationModeration)
+	countTokensMetricsFactory := metrics.NewMetricsFactory(meter, metricsRequestHeaderAttributes, metrics.GenAIOperationCountTokens)
 	mcpMetrics := metrics.NewMCP(meter, metricsRequestHeaderAttributes)
 
+	var usageLedger billing.Ledger
+	if flags.usageLedgerURL != "" {
+		usageLedger, err = billing.NewLedger(ctx, billing.LedgerConfig{
+			URL:       flags.usageLedgerURL,
+			Password:  os.Getenv(usageLedgerPasswordEnvVar),
+			Retention: flags.usageLedgerRetention,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create the usage ledger: %w", err)
+		}
+	}
+	billingAggregator, ledgerExporter, err := newBillingAggregator(ctx, &flags, usageLedger, l)
+	if err != nil {
+		return err
+	}
+	if billingAggregator != nil {
+		for _, f := range []*metrics.Factory{
+			&chatCompletionMetricsFactory, &messagesMetricsFactory, &completionMetricsFactory, &embeddingsMetricsFactory,
+			&imageGenerationMetricsFactory, &responsesMetricsFactory, &speechMetricsFactory, &transcriptionMetricsFactory,
+			&translationMetricsFactory, &rerankMetricsFactory, &tokenizeMetricsFactory, &moderationMetricsFactory,
+			&countTokensMetricsFactory,
+		} {
+			*f = billing.NewMetricsFactory(*f, billingAggregator, flags.billingExportTenantHeader)
+		}
+		go billingAggregator.Run(ctx)
+	}
+	if ledgerExporter != nil {
+		// Store the windows in progress as well, so that the usage API serves the usage of the current window.
+		go ledgerExporter.SyncCurrent(ctx, billingAggregator, min(flags.billingExportWindow, time.Minute),
+			l.With("component", "billing-export"))
+	}
+
 	extproc.LogRequestHeaderAttributes = logRequestHeaderAttributes
+	backendauth.Metrics = metrics.NewBackendAuth(meter)
+	extproc.MaxDecompressedRequestBodySize = flags.maxDecompressedRequestBodySize
+	extproc.ResponseSpill = extproc.ResponseSpillConfig{
+		Threshold:       flags.responseSpillThreshold,
+		Dir:             flags.responseSpillDir,
+		MaxResponseSize: flags.responseSpillMaxResponseSize,
+		MaxTotalSize:    flags.responseSpillMaxTotalSize,
+	}
+	if flags.conversationStoreURL != "" {
+		store, err := conversationstore.New(ctx, conversationstore.Config{
+			URL:       flags.conversationStoreURL,
+			Password:  os.Getenv(conversationStorePasswordEnvVar),
+			Retention: flags.conversationStoreRetention,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create the conversation store: %w", err)
+		}
+		defer func() { _ = store.Close() }()
+		extproc.ResponsesConversations = extproc.ResponsesConversationsConfig{
+			Store:        store,
+			TenantHeader: flags.conversationStoreTenantHeader,
+			Timeout:      flags.conversationStoreTimeout,
+		}
+	}
 
 	server, err := extproc.NewServer(l, flags.enableRedaction)
 	if err != nil {
 		return fmt.Errorf("failed to create external processor server: %w", err)
 	}
+	server.SetQuotaFallbackMetrics(metrics.NewQuotaFallback(meter))
+	server.SetPromptInjectionMetrics(metrics.NewPromptInjection(meter))
+	server.SetPhaseTimeouts(extproc.PhaseTimeouts{Request: flags.requestPhaseTimeout, Response: flags.responsePhaseTimeout})
 	server.Register(path.Join(flags.rootPrefix, endpointPrefixes.OpenAI, "/v1/chat/completions"), extproc.NewFactory(
 		chatCompletionMetricsFactory, tracing.ChatCompletionTracer(), endpointspec.ChatCompletionsEndpointSpec{}))
 	server.Register(path.Join(flags.rootPrefix, endpointPrefixes.OpenAI, "/v1/completions"), extproc.NewFactory(
@@ -318,11 +630,18 @@ func Main(ctx context.Context, args []string, stderr io.Writer) (err error) {
 		translationMetricsFactory, tracing.TranslationTracer(), endpointspec.TranslationEndpointSpec{}))
 	server.Register(path.Join(flags.rootPrefix, endpointPrefixes.OpenAI, "/v1/images/generations"), extproc.NewFactory(
 		imageGenerationMetricsFactory, tracing.ImageGenerationTracer(), endpointspec.ImageGenerationEndpointSpec{}))
+	server.Register(path.Join(flags.rootPrefix, endpointPrefixes.OpenAI, "/v1/moderations"), extproc.NewFactory(
+		moderationMetricsFactory, tracing.ModerationTracer(), endpointspec.ModerationsEndpointSpec{}))
 	server.Register(path.Join(flags.rootPrefix, endpointPrefixes.Cohere, "/v2/rerank"), extproc.NewFactory(
 		rerankMetricsFactory, tracing.RerankTracer(), endpointspec.RerankEndpointSpec{}))
+	// The Cohere v2 request body is also accepted at /v1/rerank, which is where most Cohere-compatible clients send it.
+	server.Register(path.Join(flags.rootPrefix, endpointPrefixes.Cohere, "/v1/rerank"), extproc.NewFactory(
+		rerankMetricsFactory, tracing.RerankTracer(), endpointspec.RerankEndpointSpec{}))
 	server.Register(path.Join(flags.rootPrefix, endpointPrefixes.OpenAI, "/v1/models"), extproc.NewModelsProcessor)
 	server.Register(path.Join(flags.rootPrefix, endpointPrefixes.Anthropic, "/v1/messages"), extproc.NewFactory(
 		messagesMetricsFactory, tracing.MessageTracer(), endpointspec.MessagesEndpointSpec{}))
+	server.Register(path.Join(flags.rootPrefix, endpointPrefixes.Anthropic, "/v1/messages/count_tokens"), extproc.NewFactory(
+		countTokensMetricsFactory, tracing.CountTokensTracer(), endpointspec.CountTokensEndpointSpec{}))
 	// Use /tokenize to be consistent with vLLM: https://github.com/vllm-project/vllm/blob/344b50d5258d7cf3f136416e1dbcd9b5ee99bb00/vllm/entrypoints/serve/tokenize/api_router.py#L37
 	server.Register(path.Join(flags.rootPrefix, endpointPrefixes.OpenAI, "/tokenize"), extproc.NewFactory(
 		tokenizeMetricsFactory, tracing.TokenizeTracer(), endpointspec.TokenizeEndpointSpec{}))
@@ -369,21 +688,58 @@ func Main(ctx context.Context, args []string, stderr io.Writer) (err error) {
 		}()
 	}
 
-	s := grpc.NewServer(grpc.MaxRecvMsgSize(flags.maxRecvMsgSize))
+	var mockProviderServer *http.Server
+	if mockProviderLis != nil {
+		mockProviderServer = &http.Server{
+			Handler: mockprovider.NewHandler(l.With("component", "mock-provider"), mockprovider.Config{
+				Latency:         flags.mockProviderLatency,
+				TokensPerSecond: flags.mockProviderTokensPerSecond,
+			}),
+			ReadHeaderTimeout: 120 * time.Second,
+		}
+		go func() {
+			l.Info("Starting mock provider", "addr", mockProviderLis.Addr())
+			if err2 := mockProviderServer.Serve(mockProviderLis); err2 != nil && !errors.Is(err2, http.ErrServerClosed) {
+				l.Error("mock provider failed", "error", err2)
+			}
+		}()
+	}
+
+	serverOpts := []grpc.ServerOption{grpc.MaxRecvMsgSize(flags.maxRecvMsgSize)}
+	var healthCheckOpts []grpc.DialOption
+	if flags.extProcTLSCertFile != "" {
+		var reloader *tlsReloader
+		reloader, err = newTLSReloader(flags.extProcTLSCertFile, flags.extProcTLSKeyFile, flags.extProcTLSClientCAFile, l)
+		if err != nil {
+			return err
+		}
+		if err = reloader.watch(ctx); err != nil {
+			return err
+		}
+		serverOpts = append(serverOpts, grpc.Creds(credentials.NewTLS(reloader.serverConfig())))
+		healthCheckOpts = append(healthCheckOpts, grpc.WithTransportCredentials(credentials.NewTLS(reloader.clientConfig())))
+	}
+	s := grpc.NewServer(serverOpts...)
 	extprocv3.RegisterExternalProcessorServer(s, server)
 	grpc_health_v1.RegisterHealthServer(s, server)
 
 	// Create a gRPC client connection for the above ExternalProcessorServer.
 	// This ensures Docker HEALTHCHECK and Kubernetes readiness probes pass
 	// only when Envoy considers this external processor healthy.
-	healthCheckConn, err := newGrpcClient(extProcLis.Addr())
+	healthCheckConn, err := newGrpcClient(extProcLis.Addr(), healthCheckOpts...)
 	if err != nil {
 		return fmt.Errorf("failed to create health check client: %w", err)
 	}
 	healthClient := grpc_health_v1.NewHealthClient(healthCheckConn)
 
+	// The usage API serves the usage of all the replicas from the usage ledger.
+	var usageHandler http.Handler
+	if usageLedger != nil {
+		usageHandler = &billing.UsageHandler{Ledger: usageLedger}
+	}
+
 	// Start HTTP admin server for metrics and health checks.
-	adminServer := startAdminServer(adminLis, l, promRegistry, healthClient)
+	adminServer := startAdminServer(adminLis, l, promRegistry, healthClient, usageHandler)
 
 	go func() {
 		<-ctx.Done()
@@ -403,11 +759,27 @@ func Main(ctx context.Context, args []string, stderr io.Writer) (err error) {
 		if err := metricsShutdown(shutdownCtx); err != nil {
 			l.Error("Failed to shutdown metrics gracefully", "error", err)
 		}
+		if billingAggregator != nil {
+			// Export the usage of the last, partial window.
+			if err := billingAggregator.Flush(shutdownCtx); err != nil {
+				l.Error("Failed to export the usage report", "error", err)
+			}
+		}
+		if usageLedger != nil {
+			if err := usageLedger.Close(); err != nil {
+				l.Error("Failed to close the usage ledger", "error", err)
+			}
+		}
 		if mcpServer != nil {
 			if err := mcpServer.Shutdown(shutdownCtx); err != nil {
 				l.Error("Failed to shutdown mcp proxy server gracefully", "error", err)
 			}
 		}
+		if mockProviderServer != nil {
+			if err := mockProviderServer.Shutdown(shutdownCtx); err != nil {
+				l.Error("Failed to shutdown mock provider gracefully", "error", err)
+			}
+		}
 	}()
 
 	// Emit startup message to stderr when all listeners are ready.
@@ -418,12 +790,171 @@ func Main(ctx context.Context, args []string, stderr io.Writer) (err error) {
 	return s.Serve(extProcLis)
 }
 
+// billingExportMeteringTokenEnvVar is the environment variable holding the bearer token of the metering API.
+const billingExportMeteringTokenEnvVar = "AI_GATEWAY_BILLING_EXPORT_METERING_TOKEN"
+
+// usageLedgerPasswordEnvVar is the environment variable holding the password of the usage ledger.
+const usageLedgerPasswordEnvVar = "AI_GATEWAY_USAGE_LEDGER_PASSWORD"
+
+// conversationStorePasswordEnvVar is the environment variable holding the password of the conversation store.
+const conversationStorePasswordEnvVar = "AI_GATEWAY_CONVERSATION_STORE_PASSWORD"
+
+// newBillingAggregator returns the aggregator of the billing export configured by the flags and the exporter to the
+// usage ledger if any, or nil if the billing export is disabled.
+func newBillingAggregator(ctx context.Context, flags *extProcFlags, ledger billing.Ledger, l *slog.Logger) (
+	*billing.Aggregator, *billing.LedgerExporter, error,
+) {
+	if flags.billingExportDir == "" && flags.billingExportMeteringURL == "" && flags.billingExportObjectStorageURL == "" &&
+		ledger == nil {
+		return nil, nil, nil
+	}
+	instance := billingExportInstance(flags)
+	format := billing.ReportFormat(flags.billingExportFormat)
+	var exporters []billing.Exporter
+	var ledgerExporter *billing.LedgerExporter
+	if ledger != nil {
+		ledgerExporter = billing.NewLedgerExporter(ledger, instance)
+		exporters = append(exporters, ledgerExporter)
+	}
+	if flags.billingExportDir != "" {
+		exporters = append(exporters, &billing.FileExporter{Dir: flags.billingExportDir, Instance: instance, Format: format})
+	}
+	if flags.billingExportObjectStorageURL != "" {
+		bucket, prefix, err := parseObjectStorageURL(flags.billingExportObjectStorageURL)
+		if err != nil {
+			return nil, nil, err
+		}
+		var opts []func(*config.LoadOptions) error
+		if flags.billingExportObjectStorageRegion != "" {
+			opts = append(opts, config.WithRegion(flags.billingExportObjectStorageRegion))
+		}
+		cfg, err := config.LoadDefaultConfig(ctx, opts...)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load the AWS config of the billing export object storage: %w", err)
+		}
+		if cfg.Region == "" {
+			return nil, nil, fmt.Errorf("billingExportObjectStorageRegion must be provided when the AWS config has no region")
+		}
+		exporters = append(exporters, &billing.ObjectStorageExporter{
+			Bucket:      bucket,
+			Prefix:      prefix,
+			Region:      cfg.Region,
+			Endpoint:    flags.billingExportObjectStorageEndpoint,
+			Instance:    instance,
+			Format:      format,
+			Credentials: cfg.Credentials,
+			Client:      &http.Client{Timeout: 30 * time.Second},
+		})
+	}
+	if flags.billingExportMeteringURL != "" {
+		exporters = append(exporters, &billing.MeteringExporter{
+			Instance: instance,
+			URL:      flags.billingExportMeteringURL,
+			Token:    os.Getenv(billingExportMeteringTokenEnvVar),
+			Client:   &http.Client{Timeout: 30 * time.Second},
+		})
+	}
+	l.Info("billing export is enabled", "window", flags.billingExportWindow, "instance", instance,
+		"dir", flags.billingExportDir, "format", format, "objectStorageURL", flags.billingExportObjectStorageURL,
+		"meteringURL", flags.billingExportMeteringURL, "usageLedger", ledger != nil)
+	return billing.NewAggregator(flags.billingExportWindow, l.With("component", "billing-export"), exporters...), ledgerExporter, nil
+}
+
+// parseObjectStorageURL returns the bucket and the object name prefix of the s3://<bucket>/<prefix> URL. The prefix
+// ends with a slash unless empty.
+func parseObjectStorageURL(raw string) (bucket, prefix string, err error) {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "s3" || u.Host == "" {
+		return "", "", fmt.Errorf("billingExportObjectStorageURL must be s3://<bucket>/<prefix>, got %q", raw)
+	}
+	prefix = strings.Trim(u.Path, "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+	return u.Host, prefix, nil
+}
+
+// billingExportInstance returns the identity of this replica in the usage reports. The extproc runs as a sidecar of
+// the Envoy pod, whose hostname is the pod name unless overridden.
+func billingExportInstance(flags *extProcFlags) string {
+	if flags.billingExportInstance != "" {
+		return flags.billingExportInstance
+	}
+	if podName := os.Getenv("POD_NAME"); podName != "" {
+		return podName
+	}
+	hostname, _ := os.Hostname()
+	return hostname
+}
+
 func startConfigWatcher(ctx context.Context, flags *extProcFlags, rcv filterapi.ConfigReceiver, l *slog.Logger, tick time.Duration) error {
+	if flags.configStreamAddr != "" {
+		// Both the file watcher and the config stream load the same configs, so skip the ones already loaded.
+		rcv = configstream.Dedup(rcv)
+	}
+	var err error
 	if flags.configBundlePath != "" {
-		return filterapi.StartConfigBundleWatcher(ctx, flags.configBundlePath, rcv, l, tick)
+		err = filterapi.StartConfigBundleWatcher(ctx, flags.configBundlePath, rcv, l, tick)
+	} else {
+		// TODO(huabing): the legacy config watcher can be removed in the next release
+		err = filterapi.StartLegacyConfigWatcher(ctx, flags.configPath, rcv, l, tick)
+	}
+	if err != nil || flags.configStreamAddr == "" {
+		return err
+	}
+	return configstream.StartClient(ctx, configstream.ClientConfig{
+		Addr:         flags.configStreamAddr,
+		ResourceName: flags.configStreamResourceName,
+		CACert:       []byte(os.Getenv(configstream.CAEnvVar)),
+		TokenPath:    flags.configStreamTokenPath,
+		Insecure:     flags.configStreamInsecure,
+	}, rcv, l)
+}
+
+// printConfigSchema writes the JSON Schema of the configuration to w.
+func printConfigSchema(w io.Writer) error {
+	schema, err := filterapi.JSONSchema()
+	if err != nil {
+		return fmt.Errorf("failed to generate the config schema: %w", err)
+	}
+	_, err = fmt.Fprintf(w, "%s\n", schema)
+	return err
+}
+
+// validateConfig validates the configuration file or bundle given by the flags, and reports the result to w.
+func validateConfig(flags *extProcFlags, w io.Writer) error {
+	var (
+		cfg *filterapi.Config
+		err error
+	)
+	if flags.configBundlePath != "" {
+		cfg, err = loadConfigBundle(flags.configBundlePath)
+	} else {
+		cfg, err = filterapi.UnmarshalConfigYaml(flags.configPath)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if err = filterapi.Validate(cfg); err != nil {
+		return fmt.Errorf("invalid config:\n%w", err)
+	}
+	_, err = fmt.Fprintf(w, "config is valid\n")
+	return err
+}
+
+// loadConfigBundle reads the configuration from the bundle directory at dir.
+func loadConfigBundle(dir string) (*filterapi.Config, error) {
+	indexRaw, err := os.ReadFile(filepath.Join(dir, filterapi.ConfigBundleIndexFileName))
+	if err != nil {
+		return nil, err
+	}
+	index, err := filterapi.UnmarshalConfigBundleIndex(indexRaw)
+	if err != nil {
+		return nil, err
 	}
-	// TODO(huabing): the legacy config watcher can be removed in the next release
-	return filterapi.StartLegacyConfigWatcher(ctx, flags.configPath, rcv, l, tick)
+	return filterapi.ReassembleBundleConfig(index, func(part filterapi.ConfigBundlePart) ([]byte, error) {
+		return os.ReadFile(filepath.Join(dir, filepath.FromSlash(part.Path)))
+	})
 }
 
 func listen(ctx context.Context, name, network, address string) (net.Listener, error) {
@@ -7,6 +7,7 @@ package mainlib
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"io"
 	"log/slog"
@@ -18,6 +19,9 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/internal/billing"
+	"github.com/envoyproxy/ai-gateway/internal/json"
 )
 
 func Test_parseAndValidateFlags(t *testing.T) {
@@ -181,6 +185,186 @@ func Test_parseAndValidateFlags(t *testing.T) {
 		}
 	})
 
+	t.Run("log format", func(t *testing.T) {
+		flags, err := parseAndValidateFlags([]string{"-configPath", "/path/to/config.yaml"})
+		require.NoError(t, err)
+		require.Equal(t, "text", flags.logFormat)
+
+		flags, err = parseAndValidateFlags([]string{"-configPath", "/path/to/config.yaml", "-logFormat", "json"})
+		require.NoError(t, err)
+		require.Equal(t, "json", flags.logFormat)
+	})
+
+	t.Run("config stream", func(t *testing.T) {
+		flags, err := parseAndValidateFlags([]string{
+			"-configBundlePath", "/path/to/config-bundle",
+			"-configStreamAddr", "controller:1065",
+			"-configStreamResourceName", "ns/gw",
+			"-configStreamTokenPath", "/var/run/secrets/config-stream/token",
+			"-configStreamInsecure",
+		})
+		require.NoError(t, err)
+		require.Equal(t, "controller:1065", flags.configStreamAddr)
+		require.Equal(t, "ns/gw", flags.configStreamResourceName)
+		require.Equal(t, "/var/run/secrets/config-stream/token", flags.configStreamTokenPath)
+		require.True(t, flags.configStreamInsecure)
+	})
+
+	t.Run("max decompressed request body size", func(t *testing.T) {
+		flags, err := parseAndValidateFlags([]string{"-configPath", "/path/to/config.yaml"})
+		require.NoError(t, err)
+		require.Equal(t, int64(64<<20), flags.maxDecompressedRequestBodySize)
+
+		flags, err = parseAndValidateFlags([]string{"-configPath", "/path/to/config.yaml", "-maxDecompressedRequestBodySize", "1024"})
+		require.NoError(t, err)
+		require.Equal(t, int64(1024), flags.maxDecompressedRequestBodySize)
+	})
+
+	t.Run("response spill", func(t *testing.T) {
+		flags, err := parseAndValidateFlags([]string{"-configPath", "/path/to/config.yaml"})
+		require.NoError(t, err)
+		require.Zero(t, flags.responseSpillThreshold)
+		require.Equal(t, int64(256<<20), flags.responseSpillMaxResponseSize)
+		require.Equal(t, int64(1<<30), flags.responseSpillMaxTotalSize)
+
+		flags, err = parseAndValidateFlags([]string{
+			"-configPath", "/path/to/config.yaml",
+			"-responseSpillThreshold", "1048576",
+			"-responseSpillDir", "/var/spill",
+			"-responseSpillMaxResponseSize", "2048",
+			"-responseSpillMaxTotalSize", "4096",
+		})
+		require.NoError(t, err)
+		require.Equal(t, int64(1048576), flags.responseSpillThreshold)
+		require.Equal(t, "/var/spill", flags.responseSpillDir)
+		require.Equal(t, int64(2048), flags.responseSpillMaxResponseSize)
+		require.Equal(t, int64(4096), flags.responseSpillMaxTotalSize)
+	})
+
+	t.Run("phase timeouts", func(t *testing.T) {
+		flags, err := parseAndValidateFlags([]string{"-configPath", "/path/to/config.yaml"})
+		require.NoError(t, err)
+		require.Zero(t, flags.requestPhaseTimeout)
+		require.Zero(t, flags.responsePhaseTimeout)
+
+		flags, err = parseAndValidateFlags([]string{
+			"-configPath", "/path/to/config.yaml",
+			"-requestPhaseTimeout", "10s",
+			"-responsePhaseTimeout", "1m",
+		})
+		require.NoError(t, err)
+		require.Equal(t, 10*time.Second, flags.requestPhaseTimeout)
+		require.Equal(t, time.Minute, flags.responsePhaseTimeout)
+	})
+
+	t.Run("billing export", func(t *testing.T) {
+		flags, err := parseAndValidateFlags([]string{"-configPath", "/path/to/config.yaml"})
+		require.NoError(t, err)
+		require.Equal(t, time.Hour, flags.billingExportWindow)
+		require.Equal(t, "x-tenant-id", flags.billingExportTenantHeader)
+		require.Empty(t, flags.usageLedgerURL)
+		require.Equal(t, 90*24*time.Hour, flags.usageLedgerRetention)
+		require.Equal(t, "csv", flags.billingExportFormat)
+		aggregator, ledgerExporter, err := newBillingAggregator(t.Context(), &flags, nil, slog.Default())
+		require.NoError(t, err)
+		require.Nil(t, aggregator)
+		require.Nil(t, ledgerExporter)
+		t.Setenv("POD_NAME", "envoy-default-xyz")
+		require.Equal(t, "envoy-default-xyz", billingExportInstance(&flags))
+
+		flags, err = parseAndValidateFlags([]string{
+			"-configPath", "/path/to/config.yaml",
+			"-billingExportWindow", "15m",
+			"-billingExportDir", "/var/lib/usage",
+			"-billingExportFormat", "parquet",
+			"-billingExportObjectStorageURL", "s3://billing/usage/gateway",
+			"-billingExportObjectStorageRegion", "auto",
+			"-billingExportObjectStorageEndpoint", "https://storage.googleapis.com",
+			"-billingExportMeteringURL", "https://openmeter.cloud/api/v1/events",
+			"-billingExportTenantHeader", "x-org-id",
+			"-billingExportInstance", "envoy-default-abc",
+			"-usageLedgerURL", "memory:",
+			"-usageLedgerRetention", "720h",
+		})
+		require.NoError(t, err)
+		require.Equal(t, "envoy-default-abc", billingExportInstance(&flags))
+		require.Equal(t, 15*time.Minute, flags.billingExportWindow)
+		require.Equal(t, "/var/lib/usage", flags.billingExportDir)
+		require.Equal(t, "parquet", flags.billingExportFormat)
+		require.Equal(t, "s3://billing/usage/gateway", flags.billingExportObjectStorageURL)
+		require.Equal(t, "auto", flags.billingExportObjectStorageRegion)
+		require.Equal(t, "https://storage.googleapis.com", flags.billingExportObjectStorageEndpoint)
+		bucket, prefix, err := parseObjectStorageURL(flags.billingExportObjectStorageURL)
+		require.NoError(t, err)
+		require.Equal(t, "billing", bucket)
+		require.Equal(t, "usage/gateway/", prefix)
+		require.Equal(t, "https://openmeter.cloud/api/v1/events", flags.billingExportMeteringURL)
+		require.Equal(t, "x-org-id", flags.billingExportTenantHeader)
+		require.Equal(t, "memory:", flags.usageLedgerURL)
+		require.Equal(t, 720*time.Hour, flags.usageLedgerRetention)
+		ledger, err := billing.NewLedger(t.Context(), billing.LedgerConfig{URL: flags.usageLedgerURL, Retention: flags.usageLedgerRetention})
+		require.NoError(t, err)
+		aggregator, ledgerExporter, err = newBillingAggregator(t.Context(), &flags, ledger, slog.Default())
+		require.NoError(t, err)
+		require.NotNil(t, aggregator)
+		require.NotNil(t, ledgerExporter)
+	})
+
+	t.Run("conversation store", func(t *testing.T) {
+		flags, err := parseAndValidateFlags([]string{"-configPath", "/path/to/config.yaml"})
+		require.NoError(t, err)
+		require.Empty(t, flags.conversationStoreURL)
+		require.Equal(t, 30*24*time.Hour, flags.conversationStoreRetention)
+		require.Equal(t, "x-tenant-id", flags.conversationStoreTenantHeader)
+		require.Equal(t, time.Second, flags.conversationStoreTimeout)
+
+		flags, err = parseAndValidateFlags([]string{
+			"-configPath", "/path/to/config.yaml",
+			"-conversationStoreURL", "redis://redis.default:6379/0",
+			"-conversationStoreRetention", "24h",
+			"-conversationStoreTenantHeader", "x-org-id",
+			"-conversationStoreTimeout", "500ms",
+		})
+		require.NoError(t, err)
+		require.Equal(t, "redis://redis.default:6379/0", flags.conversationStoreURL)
+		require.Equal(t, 24*time.Hour, flags.conversationStoreRetention)
+		require.Equal(t, "x-org-id", flags.conversationStoreTenantHeader)
+		require.Equal(t, 500*time.Millisecond, flags.conversationStoreTimeout)
+	})
+
+	t.Run("mock provider", func(t *testing.T) {
+		flags, err := parseAndValidateFlags([]string{
+			"-configPath", "/path/to/config.yaml",
+			"-mockProviderAddr", "unix:///tmp/mock-provider.sock",
+			"-mockProviderLatency", "200ms",
+			"-mockProviderTokensPerSecond", "25",
+		})
+		require.NoError(t, err)
+		require.Equal(t, "unix:///tmp/mock-provider.sock", flags.mockProviderAddr)
+		require.Equal(t, 200*time.Millisecond, flags.mockProviderLatency)
+		require.Equal(t, 25.0, flags.mockProviderTokensPerSecond)
+	})
+
+	t.Run("tls", func(t *testing.T) {
+		flags, err := parseAndValidateFlags([]string{
+			"-configPath", "/path/to/config.yaml",
+			"-extProcTLSCertFile", "/certs/tls.crt",
+			"-extProcTLSKeyFile", "/certs/tls.key",
+			"-extProcTLSClientCAFile", "/certs/ca.crt",
+		})
+		require.NoError(t, err)
+		require.Equal(t, "/certs/tls.crt", flags.extProcTLSCertFile)
+		require.Equal(t, "/certs/tls.key", flags.extProcTLSKeyFile)
+		require.Equal(t, "/certs/ca.crt", flags.extProcTLSClientCAFile)
+	})
+
+	t.Run("print config schema", func(t *testing.T) {
+		// The config path is not needed to print the schema.
+		flags, err := parseAndValidateFlags([]string{"-printConfigSchema"})
+		require.NoError(t, err)
+		require.True(t, flags.printConfigSchema)
+	})
+
 	t.Run("invalid extProcFlags", func(t *testing.T) {
 		tests := []struct {
 			name          string
@@ -192,6 +376,76 @@ func Test_parseAndValidateFlags(t *testing.T) {
 				args:          []string{"-logLevel", "invalid"},
 				expectedError: "either configPath or configBundlePath must be provided\nfailed to unmarshal log level: slog: level string \"invalid\": unknown name",
 			},
+			{
+				name:          "invalid log format",
+				args:          []string{"-configPath", "/path/to/config.yaml", "-logFormat", "yaml"},
+				expectedError: "invalid log format: \"yaml\", must be one of \"text\" or \"json\"",
+			},
+			{
+				name:          "zero max decompressed request body size",
+				args:          []string{"-configPath", "/path/to/config.yaml", "-maxDecompressedRequestBodySize", "0"},
+				expectedError: "maxDecompressedRequestBodySize must be positive",
+			},
+			{
+				name:          "negative response spill threshold",
+				args:          []string{"-configPath", "/path/to/config.yaml", "-responseSpillThreshold", "-1"},
+				expectedError: "responseSpillThreshold must not be negative and the response spill sizes must be positive",
+			},
+			{
+				name:          "negative phase timeout",
+				args:          []string{"-configPath", "/path/to/config.yaml", "-requestPhaseTimeout", "-1s"},
+				expectedError: "phase timeouts must not be negative",
+			},
+			{
+				name:          "zero billing export window",
+				args:          []string{"-configPath", "/path/to/config.yaml", "-billingExportWindow", "0s"},
+				expectedError: "billingExportWindow must be positive",
+			},
+			{
+				name:          "billing export without tenant header",
+				args:          []string{"-configPath", "/path/to/config.yaml", "-billingExportDir", "/tmp", "-billingExportTenantHeader", ""},
+				expectedError: "billingExportTenantHeader must be provided when the billing export is enabled",
+			},
+			{
+				name:          "invalid billing export format",
+				args:          []string{"-configPath", "/path/to/config.yaml", "-billingExportFormat", "json"},
+				expectedError: "billingExportFormat must be one of [csv parquet]",
+			},
+			{
+				name:          "invalid billing export object storage URL",
+				args:          []string{"-configPath", "/path/to/config.yaml", "-billingExportObjectStorageURL", "gs://billing"},
+				expectedError: `billingExportObjectStorageURL must be s3://<bucket>/<prefix>, got "gs://billing"`,
+			},
+			{
+				name:          "zero usage ledger retention",
+				args:          []string{"-configPath", "/path/to/config.yaml", "-usageLedgerRetention", "0s"},
+				expectedError: "usageLedgerRetention must be positive",
+			},
+			{
+				name:          "zero conversation store retention",
+				args:          []string{"-configPath", "/path/to/config.yaml", "-conversationStoreRetention", "0s"},
+				expectedError: "conversationStoreRetention and conversationStoreTimeout must be positive",
+			},
+			{
+				name:          "negative mock provider latency",
+				args:          []string{"-configPath", "/path/to/config.yaml", "-mockProviderLatency", "-1s"},
+				expectedError: "mockProviderLatency and mockProviderTokensPerSecond must not be negative",
+			},
+			{
+				name:          "tls certificate without key",
+				args:          []string{"-configPath", "/path/to/config.yaml", "-extProcTLSCertFile", "/certs/tls.crt"},
+				expectedError: "extProcTLSCertFile and extProcTLSKeyFile must be provided together",
+			},
+			{
+				name:          "tls client ca without certificate",
+				args:          []string{"-configPath", "/path/to/config.yaml", "-extProcTLSClientCAFile", "/certs/ca.crt"},
+				expectedError: "extProcTLSClientCAFile requires extProcTLSCertFile and extProcTLSKeyFile",
+			},
+			{
+				name:          "config stream without resource name",
+				args:          []string{"-configPath", "/path/to/config.yaml", "-configStreamAddr", "controller:1065"},
+				expectedError: "configStreamResourceName must be provided when configStreamAddr is set",
+			},
 			{
 				name:          "invalid endpoint prefixes - unknown key",
 				args:          []string{"-configPath", "/path/to/config.yaml", "-endpointPrefixes", "foo:/x"},
@@ -223,6 +477,22 @@ func Test_parseAndValidateFlags(t *testing.T) {
 	})
 }
 
+func TestNewLogger(t *testing.T) {
+	var buf bytes.Buffer
+	newLogger(&buf, logFormatJSON, slog.LevelInfo).With("request_id", "abc").Info("hello", "model", "gpt-4")
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	require.Equal(t, "hello", entry["msg"])
+	require.Equal(t, "abc", entry["request_id"])
+	require.Equal(t, "gpt-4", entry["model"])
+
+	buf.Reset()
+	newLogger(&buf, logFormatText, slog.LevelInfo).Debug("dropped")
+	newLogger(&buf, logFormatText, slog.LevelInfo).Info("hello", "model", "gpt-4")
+	require.Contains(t, buf.String(), "msg=hello model=gpt-4")
+	require.NotContains(t, buf.String(), "dropped")
+}
+
 func TestListenAddress(t *testing.T) {
 	unixPath := t.TempDir() + "/extproc.sock"
 	// Create a stale file to ensure that removing the file works correctly.
@@ -252,6 +522,42 @@ func TestListenAddress(t *testing.T) {
 	require.ErrorIs(t, err, os.ErrNotExist, "expected the stale socket file to be removed")
 }
 
+func TestPrintConfigSchema(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, printConfigSchema(&buf))
+	var schema map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &schema))
+	require.Equal(t, "object", schema["type"])
+}
+
+func TestValidateConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	validPath := filepath.Join(tmpDir, "valid.yaml")
+	require.NoError(t, os.WriteFile(validPath, []byte(`
+version: dev
+backends:
+- name: openai
+  schema:
+    name: OpenAI
+`), 0o600))
+	invalidPath := filepath.Join(tmpDir, "invalid.yaml")
+	require.NoError(t, os.WriteFile(invalidPath, []byte(`
+version: dev
+backends:
+- name: openai
+`), 0o600))
+
+	var buf bytes.Buffer
+	require.NoError(t, validateConfig(&extProcFlags{configPath: validPath}, &buf))
+	require.Equal(t, "config is valid\n", buf.String())
+
+	err := validateConfig(&extProcFlags{configPath: invalidPath}, &buf)
+	require.EqualError(t, err, "invalid config:\nbackends[0].schema.name: must not be empty")
+
+	err = validateConfig(&extProcFlags{configPath: filepath.Join(tmpDir, "missing.yaml")}, &buf)
+	require.ErrorContains(t, err, "failed to load config")
+}
+
 // TestExtProcStartupMessage ensures other programs can rely on the startup message to STDERR.
 func TestExtProcStartupMessage(t *testing.T) {
 	// Create a temporary config file.
@@ -0,0 +1,136 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package mainlib
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// tlsReloader serves the TLS configuration of the external processor loaded from the certificate, key and client CA
+// files, and reloads it when they change. The connections established keep the configuration of their handshake,
+// and the next ones get the reloaded configuration.
+type tlsReloader struct {
+	certFile, keyFile, clientCAFile string
+	logger                          *slog.Logger
+	current                         atomic.Pointer[tls.Config]
+}
+
+// newTLSReloader loads the TLS configuration from the files. The client certificates are required and verified
+// against the client CA if clientCAFile is not empty.
+func newTLSReloader(certFile, keyFile, clientCAFile string, logger *slog.Logger) (*tlsReloader, error) {
+	r := &tlsReloader{certFile: certFile, keyFile: keyFile, clientCAFile: clientCAFile, logger: logger}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// load loads the TLS configuration from the files.
+func (r *tlsReloader) load() error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load the TLS certificate: %w", err)
+	}
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+		// The configuration returned for the connections replaces the one gRPC sets the ALPN protocol on.
+		NextProtos: []string{"h2"},
+	}
+	if r.clientCAFile != "" {
+		pem, err := os.ReadFile(r.clientCAFile)
+		if err != nil {
+			return fmt.Errorf("failed to read the client CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificate found in the client CA %s", r.clientCAFile)
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	r.current.Store(config)
+	return nil
+}
+
+// serverConfig returns the TLS configuration of the gRPC server, which serves the current configuration to each
+// connection.
+func (r *tlsReloader) serverConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return r.current.Load(), nil
+		},
+	}
+}
+
+// clientConfig returns the TLS configuration of the health check client connecting to the server itself, which
+// presents the serving certificate when the client certificates are required.
+func (r *tlsReloader) clientConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		// The health check only connects to the listener of this process.
+		InsecureSkipVerify: true, // #nosec G402
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return &r.current.Load().Certificates[0], nil
+		},
+	}
+}
+
+// watch reloads the TLS configuration when the files change until ctx is done. The directories of the files are
+// watched since the files of a mounted Kubernetes Secret are symlinks into a directory swapped atomically on update.
+// The previous configuration is kept if the reload fails.
+func (r *tlsReloader) watch(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create the TLS file watcher: %w", err)
+	}
+	dirs := map[string]struct{}{}
+	for _, f := range []string{r.certFile, r.keyFile, r.clientCAFile} {
+		if f != "" {
+			dirs[filepath.Dir(filepath.Clean(f))] = struct{}{}
+		}
+	}
+	for dir := range dirs {
+		if err = watcher.Add(dir); err != nil {
+			_ = watcher.Close()
+			return fmt.Errorf("failed to watch %s: %w", dir, err)
+		}
+	}
+	go func() {
+		defer func() { _ = watcher.Close() }()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case _, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if err := r.load(); err != nil {
+					r.logger.Error("failed to reload the TLS configuration, keeping the previous one", slog.String("error", err.Error()))
+					continue
+				}
+				r.logger.Info("reloaded the TLS configuration")
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				r.logger.Error("TLS file watcher error", slog.String("error", err.Error()))
+			}
+		}
+	}()
+	return nil
+}
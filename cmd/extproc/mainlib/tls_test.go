@@ -0,0 +1,126 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package mainlib
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"log/slog"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// writeSelfSignedCert writes a self-signed certificate and its key with the given common name into dir, and returns
+// the certificate.
+func writeSelfSignedCert(t *testing.T, dir, commonName string) *x509.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: commonName},
+		DNSNames:              []string{"localhost"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	// The files are written next to each other and renamed, as the kubelet updates the mounted Secrets atomically.
+	for name, block := range map[string]*pem.Block{
+		"tls.crt": {Type: "CERTIFICATE", Bytes: der},
+		"tls.key": {Type: "EC PRIVATE KEY", Bytes: keyDER},
+		"ca.crt":  {Type: "CERTIFICATE", Bytes: der},
+	} {
+		tmp := filepath.Join(dir, "."+name)
+		require.NoError(t, os.WriteFile(tmp, pem.EncodeToMemory(block), 0o600))
+		require.NoError(t, os.Rename(tmp, filepath.Join(dir, name)))
+	}
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert
+}
+
+func TestTLSReloader(t *testing.T) {
+	dir := t.TempDir()
+	writeSelfSignedCert(t, dir, "first")
+	reloader, err := newTLSReloader(filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key"), filepath.Join(dir, "ca.crt"), slog.Default())
+	require.NoError(t, err)
+	require.NoError(t, reloader.watch(t.Context()))
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := grpc.NewServer(grpc.Creds(credentials.NewTLS(reloader.serverConfig())))
+	grpc_health_v1.RegisterHealthServer(s, health.NewServer())
+	go func() { _ = s.Serve(lis) }()
+	t.Cleanup(s.Stop)
+
+	// check returns the common name of the server certificate, or the error of the health check.
+	check := func(config *tls.Config) (string, error) {
+		var commonName string
+		config.VerifyConnection = func(state tls.ConnectionState) error {
+			commonName = state.PeerCertificates[0].Subject.CommonName
+			return nil
+		}
+		conn, err := newGrpcClient(lis.Addr(), grpc.WithTransportCredentials(credentials.NewTLS(config)))
+		require.NoError(t, err)
+		defer func() { _ = conn.Close() }()
+		_, err = grpc_health_v1.NewHealthClient(conn).Check(t.Context(), &grpc_health_v1.HealthCheckRequest{})
+		return commonName, err
+	}
+
+	commonName, err := check(reloader.clientConfig())
+	require.NoError(t, err)
+	require.Equal(t, "first", commonName)
+
+	// The client certificate is required.
+	_, err = check(&tls.Config{InsecureSkipVerify: true}) // #nosec G402
+	require.Error(t, err)
+
+	// The new certificate and client CA are served once the files change.
+	writeSelfSignedCert(t, dir, "second")
+	require.Eventually(t, func() bool {
+		commonName, err = check(reloader.clientConfig())
+		return err == nil && commonName == "second"
+	}, 5*time.Second, 50*time.Millisecond)
+
+	// The previous configuration is kept if the files are invalid.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "tls.key"), []byte("invalid"), 0o600))
+	time.Sleep(100 * time.Millisecond)
+	commonName, err = check(reloader.clientConfig())
+	require.NoError(t, err)
+	require.Equal(t, "second", commonName)
+}
+
+func TestNewTLSReloader_Errors(t *testing.T) {
+	dir := t.TempDir()
+	_, err := newTLSReloader(filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key"), "", slog.Default())
+	require.ErrorContains(t, err, "failed to load the TLS certificate")
+
+	writeSelfSignedCert(t, dir, "cert")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "empty.crt"), nil, 0o600))
+	_, err = newTLSReloader(filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key"), filepath.Join(dir, "empty.crt"), slog.Default())
+	require.ErrorContains(t, err, "no certificate found in the client CA")
+}
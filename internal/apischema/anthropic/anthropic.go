@@ -1613,6 +1613,14 @@ type Usage struct {
 	OutputTokens float64 `json:"output_tokens"`
 }
 
+// CountTokensResponse represents a response from the Anthropic count tokens API. The request of the API is
+// the subset of [MessagesRequest] affecting the number of input tokens, so it is parsed as a [MessagesRequest].
+// https://docs.claude.com/en/api/messages-count-tokens
+type CountTokensResponse struct {
+	// InputTokens is the total number of tokens across the messages, the system prompt and the tools.
+	InputTokens int64 `json:"input_tokens"`
+}
+
 // MessagesStreamChunk represents a single event in the streaming response from the Anthropic Messages API.
 // https://docs.claude.com/en/docs/build-with-claude/streaming
 type MessagesStreamChunk struct {
@@ -593,6 +593,9 @@ type ToolSpecification struct {
 	//
 	// Name is a required field.
 	Name *string `json:"name"`
+
+	// Strict enables the constrained decoding of the tool input, so that the input always matches InputSchema.
+	Strict *bool `json:"strict,omitempty"`
 }
 
 // CachePointBlock defines a cache checkpoint for prompt caching.
@@ -655,3 +658,103 @@ type CountTokensResponse struct {
 	// The total number of input tokens that were counted
 	InputTokens int `json:"inputTokens"`
 }
+
+// RerankRequest is the request body for the rerank models, e.g. amazon.rerank-v1:0 and cohere.rerank-v3-5:0,
+// via the AWS Bedrock InvokeModel API.
+//
+// See https://docs.aws.amazon.com/bedrock/latest/userguide/rerank-use.html
+type RerankRequest struct {
+	// Query is the query to rank the documents against. Required.
+	Query string `json:"query"`
+
+	// Documents are the documents to rank. Required.
+	Documents []string `json:"documents"`
+
+	// TopN is the number of the most relevant documents to return.
+	TopN *int `json:"top_n,omitempty"`
+
+	// APIVersion is the version of the Cohere API. Required by the Cohere models, which only support 2.
+	APIVersion int `json:"api_version,omitempty"`
+
+	// MaxTokensPerDoc truncates the documents to this many tokens. Only supported by the Cohere models.
+	MaxTokensPerDoc *int `json:"max_tokens_per_doc,omitempty"`
+}
+
+// RerankResponse is the response body returned by the rerank models via the InvokeModel API.
+//
+// See https://docs.aws.amazon.com/bedrock/latest/userguide/rerank-use.html
+type RerankResponse struct {
+	// ID is the ID of the request. Only returned by the Cohere models.
+	ID *string `json:"id,omitempty"`
+
+	// Results are the ranked documents.
+	Results []RerankResult `json:"results"`
+}
+
+// RerankResult is a single ranked document in the RerankResponse.
+type RerankResult struct {
+	// Index is the position of the document in the request.
+	Index int `json:"index"`
+
+	// RelevanceScore is the relevance of the document to the query.
+	RelevanceScore float64 `json:"relevance_score"`
+}
+
+// ApplyGuardrailRequest is the request body of the ApplyGuardrail API, which assesses the content with a guardrail
+// without invoking a model.
+//
+// See https://docs.aws.amazon.com/bedrock/latest/APIReference/API_runtime_ApplyGuardrail.html
+type ApplyGuardrailRequest struct {
+	// Source is the source of the content, either INPUT or OUTPUT. Required.
+	Source string `json:"source"`
+
+	// Content is the content to assess. Required.
+	Content []ApplyGuardrailContentBlock `json:"content"`
+}
+
+// ApplyGuardrailContentBlock is a content block of the ApplyGuardrailRequest.
+type ApplyGuardrailContentBlock struct {
+	// Text is the text to assess.
+	Text *GuardrailConverseTextBlock `json:"text,omitempty"`
+}
+
+// ApplyGuardrailResponse is the response body of the ApplyGuardrail API.
+type ApplyGuardrailResponse struct {
+	// Action is the action taken by the guardrail, either NONE or GUARDRAIL_INTERVENED.
+	Action string `json:"action"`
+
+	// Assessments are the assessments of the content by the policies of the guardrail.
+	Assessments []GuardrailAssessment `json:"assessments"`
+}
+
+const (
+	// GuardrailActionIntervened is the ApplyGuardrailResponse.Action when the guardrail blocked or masked the content.
+	GuardrailActionIntervened = "GUARDRAIL_INTERVENED"
+	// GuardrailSourceInput is the ApplyGuardrailRequest.Source of the content sent to a model.
+	GuardrailSourceInput = "INPUT"
+)
+
+// GuardrailAssessment is the assessment of the content by the policies of a guardrail. Only the content policy is
+// decoded, since the other policies don't score the content.
+type GuardrailAssessment struct {
+	// ContentPolicy is the assessment of the content filters.
+	ContentPolicy *GuardrailContentPolicyAssessment `json:"contentPolicy,omitempty"`
+}
+
+// GuardrailContentPolicyAssessment is the assessment of the content filters of a guardrail.
+type GuardrailContentPolicyAssessment struct {
+	// Filters are the content filters that detected the content.
+	Filters []GuardrailContentFilter `json:"filters"`
+}
+
+// GuardrailContentFilter is a content filter of a guardrail that detected the content.
+type GuardrailContentFilter struct {
+	// Type is the category of the filter: HATE, INSULTS, SEXUAL, VIOLENCE, MISCONDUCT or PROMPT_ATTACK.
+	Type string `json:"type"`
+
+	// Confidence is the confidence of the detection: NONE, LOW, MEDIUM or HIGH.
+	Confidence string `json:"confidence"`
+
+	// Action is the action of the filter, either BLOCKED or NONE.
+	Action string `json:"action"`
+}
@@ -0,0 +1,63 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+// Package azure contains the API schema of the Azure AI services that are not compatible with the OpenAI API.
+package azure
+
+// AnalyzeTextRequest is the request body of the text:analyze API of Azure AI Content Safety.
+//
+// See https://learn.microsoft.com/en-us/rest/api/contentsafety/text-operations/analyze-text
+type AnalyzeTextRequest struct {
+	// Text is the text to analyze, up to 10K characters. Required.
+	Text string `json:"text"`
+
+	// Categories are the harm categories to analyze. All categories are analyzed by default.
+	Categories []string `json:"categories,omitempty"`
+
+	// OutputType is the granularity of the severities, either FourSeverityLevels (0, 2, 4, 6) or
+	// EightSeverityLevels (0 to 7). Defaults to FourSeverityLevels.
+	OutputType string `json:"outputType,omitempty"`
+}
+
+// AnalyzeTextResponse is the response body of the text:analyze API.
+type AnalyzeTextResponse struct {
+	// BlocklistsMatch are the matches of the text in the blocklists of the request.
+	BlocklistsMatch []TextBlocklistMatch `json:"blocklistsMatch,omitempty"`
+
+	// CategoriesAnalysis are the severities of the text in the harm categories.
+	CategoriesAnalysis []TextCategoriesAnalysis `json:"categoriesAnalysis"`
+}
+
+// TextBlocklistMatch is a match of the text in a blocklist.
+type TextBlocklistMatch struct {
+	// BlocklistName is the name of the blocklist.
+	BlocklistName string `json:"blocklistName"`
+
+	// BlocklistItemText is the matched item of the blocklist.
+	BlocklistItemText string `json:"blocklistItemText"`
+}
+
+// TextCategoriesAnalysis is the severity of the text in a harm category.
+type TextCategoriesAnalysis struct {
+	// Category is the harm category: Hate, SelfHarm, Sexual or Violence.
+	Category string `json:"category"`
+
+	// Severity is the severity of the text in the category.
+	Severity int `json:"severity"`
+}
+
+const (
+	// ContentSafetyOutputTypeEightSeverityLevels is the AnalyzeTextRequest.OutputType of the severities from 0 to 7.
+	ContentSafetyOutputTypeEightSeverityLevels = "EightSeverityLevels"
+
+	// ContentSafetyCategoryHate is the Hate harm category.
+	ContentSafetyCategoryHate = "Hate"
+	// ContentSafetyCategorySelfHarm is the SelfHarm harm category.
+	ContentSafetyCategorySelfHarm = "SelfHarm"
+	// ContentSafetyCategorySexual is the Sexual harm category.
+	ContentSafetyCategorySexual = "Sexual"
+	// ContentSafetyCategoryViolence is the Violence harm category.
+	ContentSafetyCategoryViolence = "Violence"
+)
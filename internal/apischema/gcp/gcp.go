@@ -39,6 +39,11 @@ type GenerateContentRequest struct {
 	//
 	// https://github.com/googleapis/go-genai/blob/6a8184fcaf8bf15f0c566616a7b356560309be9b/types.go#L1057
 	SafetySettings []*genai.SafetySetting `json:"safetySettings,omitempty"`
+	// Optional. The name of the cached content used as context to serve the prediction.
+	// Format: projects/{project}/locations/{location}/cachedContents/{cachedContent}
+	//
+	// https://cloud.google.com/vertex-ai/docs/reference/rest/v1/projects.locations.endpoints/generateContent
+	CachedContent string `json:"cachedContent,omitempty"`
 }
 
 // https://docs.cloud.google.com/vertex-ai/generative-ai/docs/model-reference/text-embeddings-api#syntax
@@ -171,3 +176,39 @@ type CountTokenRequest struct {
 	// Optional. Configuration that the model uses to generate the response.
 	GenerationConfig *genai.GenerationConfig `json:"generationConfig,omitempty"`
 }
+
+// RankRequest is the request body for the Vertex AI Ranking API, served by the Discovery Engine.
+//
+// See https://cloud.google.com/generative-ai-app-builder/docs/reference/rest/v1/projects.locations.rankingConfigs/rank
+type RankRequest struct {
+	// Model is the ranking model, e.g. "semantic-ranker-default@latest".
+	Model string `json:"model,omitempty"`
+	// Query is the query to rank the records against.
+	Query string `json:"query"`
+	// Records are the records to rank.
+	Records []RankingRecord `json:"records"`
+	// TopN is the number of the most relevant records to return.
+	TopN int `json:"topN,omitempty"`
+	// IgnoreRecordDetailsInResponse omits the title and content of the records in the response.
+	IgnoreRecordDetailsInResponse bool `json:"ignoreRecordDetailsInResponse,omitempty"`
+}
+
+// RankingRecord is a record of the RankRequest and the RankResponse.
+type RankingRecord struct {
+	// ID is the unique ID of the record.
+	ID string `json:"id"`
+	// Title is the title of the record.
+	Title string `json:"title,omitempty"`
+	// Content is the content of the record.
+	Content string `json:"content,omitempty"`
+	// Score is the relevance of the record to the query between 0 and 1. Only set in the response.
+	Score float64 `json:"score,omitempty"`
+}
+
+// RankResponse is the response of the Vertex AI Ranking API.
+//
+// See https://cloud.google.com/generative-ai-app-builder/docs/reference/rest/v1/projects.locations.rankingConfigs/rank
+type RankResponse struct {
+	// Records are the ranked records, sorted by descending score.
+	Records []RankingRecord `json:"records"`
+}
@@ -2013,6 +2013,13 @@ type GCPVertexAIVendorFields struct {
 	//
 	// https://cloud.google.com/vertex-ai/docs/reference/rest/v1/SafetySetting
 	SafetySettings []*genai.SafetySetting `json:"safetySettings,omitzero"`
+
+	// CachedContent is the resource name of a context cache created in Vertex AI whose content is used as the
+	// prefix of the request, in the form of "projects/{project}/locations/{location}/cachedContents/{id}".
+	// The tokens read from the cache are reported in usage.prompt_tokens_details.cached_tokens.
+	//
+	// https://cloud.google.com/vertex-ai/generative-ai/docs/context-cache/context-cache-use
+	CachedContent string `json:"cachedContent,omitempty"`
 }
 
 // GCPVertexAIGenerationConfig represents Gemini generation configuration options.
@@ -8790,3 +8797,126 @@ const (
 type TranslationResponse struct {
 	Text string `json:"text"`
 }
+
+// ModerationRequest represents a request to the /v1/moderations endpoint.
+// https://platform.openai.com/docs/api-reference/moderations/create
+type ModerationRequest struct {
+	// Input is the text, the texts or the multi-modal inputs to classify.
+	Input ModerationInput `json:"input"`
+	// Model is the moderation model, e.g. omni-moderation-latest. Optional.
+	Model string `json:"model,omitempty"`
+}
+
+// ModerationInput is the input of a ModerationRequest. Exactly one of the fields is set.
+type ModerationInput struct {
+	OfString      *string
+	OfStringArray []string
+	OfMultiModal  []ModerationMultiModalInput
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (m *ModerationInput) UnmarshalJSON(data []byte) error {
+	switch data = bytes.TrimSpace(data); {
+	case len(data) > 0 && data[0] == '"':
+		return json.Unmarshal(data, &m.OfString)
+	case len(data) > 0 && data[0] == '[':
+		if err := json.Unmarshal(data, &m.OfStringArray); err == nil {
+			return nil
+		}
+		m.OfStringArray = nil
+		return json.Unmarshal(data, &m.OfMultiModal)
+	default:
+		return errors.New("moderation input must be a string or an array")
+	}
+}
+
+// MarshalJSON implements json.Marshaler.
+func (m ModerationInput) MarshalJSON() ([]byte, error) {
+	switch {
+	case m.OfString != nil:
+		return json.Marshal(*m.OfString)
+	case m.OfStringArray != nil:
+		return json.Marshal(m.OfStringArray)
+	default:
+		return json.Marshal(m.OfMultiModal)
+	}
+}
+
+// Texts returns the texts of the input, or false if it has images.
+func (m *ModerationInput) Texts() ([]string, bool) {
+	switch {
+	case m.OfString != nil:
+		return []string{*m.OfString}, true
+	case m.OfStringArray != nil:
+		return m.OfStringArray, true
+	}
+	texts := make([]string, 0, len(m.OfMultiModal))
+	for _, in := range m.OfMultiModal {
+		if in.Type != ModerationInputTypeText {
+			return nil, false
+		}
+		texts = append(texts, in.Text)
+	}
+	return texts, true
+}
+
+// ModerationMultiModalInput is a text or an image to classify.
+type ModerationMultiModalInput struct {
+	// Type is either text or image_url.
+	Type string `json:"type"`
+	// Text is the text of the text input.
+	Text string `json:"text,omitempty"`
+	// ImageURL is the image of the image_url input.
+	ImageURL *ModerationImageURL `json:"image_url,omitempty"`
+}
+
+// ModerationImageURL is the URL or the base64 encoded data URL of an image to classify.
+type ModerationImageURL struct {
+	URL string `json:"url"`
+}
+
+// ModerationResponse represents the response from the /v1/moderations endpoint.
+type ModerationResponse struct {
+	// ID is the unique identifier of the moderation request.
+	ID string `json:"id"`
+	// Model is the model used to classify the input.
+	Model string `json:"model"`
+	// Results are the classifications of the inputs, in the order of the inputs.
+	Results []ModerationResult `json:"results"`
+}
+
+// ModerationResult is the classification of an input of a ModerationRequest. The keys of the maps are the
+// ModerationCategory* values.
+type ModerationResult struct {
+	// Flagged is true if the input is flagged in any of the categories.
+	Flagged bool `json:"flagged"`
+	// Categories tells whether the input is flagged in each category.
+	Categories map[string]bool `json:"categories"`
+	// CategoryScores are the scores of the categories, between 0 and 1.
+	CategoryScores map[string]float64 `json:"category_scores"`
+	// CategoryAppliedInputTypes are the input types, text or image, each category score applies to.
+	CategoryAppliedInputTypes map[string][]string `json:"category_applied_input_types,omitempty"`
+}
+
+// Moderation input types.
+const (
+	ModerationInputTypeText     = "text"
+	ModerationInputTypeImageURL = "image_url"
+)
+
+// Moderation categories.
+const (
+	ModerationCategoryHarassment            = "harassment"
+	ModerationCategoryHarassmentThreatening = "harassment/threatening"
+	ModerationCategoryHate                  = "hate"
+	ModerationCategoryHateThreatening       = "hate/threatening"
+	ModerationCategoryIllicit               = "illicit"
+	ModerationCategoryIllicitViolent        = "illicit/violent"
+	ModerationCategorySelfHarm              = "self-harm"
+	ModerationCategorySelfHarmIntent        = "self-harm/intent"
+	ModerationCategorySelfHarmInstructions  = "self-harm/instructions"
+	ModerationCategorySexual                = "sexual"
+	ModerationCategorySexualMinors          = "sexual/minors"
+	ModerationCategoryViolence              = "violence"
+	ModerationCategoryViolenceGraphic       = "violence/graphic"
+)
@@ -682,6 +682,64 @@ func TestEmbeddingRequestInputMarshal(t *testing.T) {
 	}
 }
 
+func TestModerationInputJSON(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		data     string
+		expected ModerationInput
+		expTexts []string
+		expOK    bool
+	}{
+		{
+			name:     "string",
+			data:     `"some text"`,
+			expected: ModerationInput{OfString: ptr.To("some text")},
+			expTexts: []string{"some text"},
+			expOK:    true,
+		},
+		{
+			name:     "string array",
+			data:     `["a","b"]`,
+			expected: ModerationInput{OfStringArray: []string{"a", "b"}},
+			expTexts: []string{"a", "b"},
+			expOK:    true,
+		},
+		{
+			name: "multi-modal",
+			data: `[{"type":"text","text":"a"},{"type":"image_url","image_url":{"url":"https://example.com/a.png"}}]`,
+			expected: ModerationInput{OfMultiModal: []ModerationMultiModalInput{
+				{Type: ModerationInputTypeText, Text: "a"},
+				{Type: ModerationInputTypeImageURL, ImageURL: &ModerationImageURL{URL: "https://example.com/a.png"}},
+			}},
+		},
+		{
+			name:     "multi-modal text",
+			data:     `[{"type":"text","text":"a"}]`,
+			expected: ModerationInput{OfMultiModal: []ModerationMultiModalInput{{Type: ModerationInputTypeText, Text: "a"}}},
+			expTexts: []string{"a"},
+			expOK:    true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var input ModerationInput
+			require.NoError(t, json.Unmarshal([]byte(tc.data), &input))
+			require.Equal(t, tc.expected, input)
+			texts, ok := input.Texts()
+			require.Equal(t, tc.expOK, ok)
+			require.Equal(t, tc.expTexts, texts)
+
+			data, err := json.Marshal(input)
+			require.NoError(t, err)
+			require.JSONEq(t, tc.data, string(data))
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		var input ModerationInput
+		require.ErrorContains(t, json.Unmarshal([]byte(`{"text":"a"}`), &input), "moderation input must be a string or an array")
+	})
+}
+
 func TestStringOrUserRoleContentUnionMarshal(t *testing.T) {
 	testCases := []struct {
 		name     string
@@ -1353,6 +1411,33 @@ func TestChatCompletionRequest(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "cached content vendor field",
+			jsonStr: `{
+				"model": "gemini-1.5-pro",
+				"messages": [
+					{
+						"role": "user",
+						"content": "Summarize the cached document"
+					}
+				],
+				"cachedContent": "projects/test-project/locations/us-central1/cachedContents/123"
+			}`,
+			expected: &ChatCompletionRequest{
+				Model: "gemini-1.5-pro",
+				Messages: []ChatCompletionMessageParamUnion{
+					{
+						OfUser: &ChatCompletionUserMessageParam{
+							Role:    ChatMessageRoleUser,
+							Content: StringOrUserRoleContentUnion{Value: "Summarize the cached document"},
+						},
+					},
+				},
+				GCPVertexAIVendorFields: &GCPVertexAIVendorFields{
+					CachedContent: "projects/test-project/locations/us-central1/cachedContents/123",
+				},
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -14,19 +14,47 @@ import (
 	"github.com/envoyproxy/ai-gateway/internal/internalapi"
 )
 
+const (
+	// openAIOrganizationHeader and openAIProjectHeader are the headers selecting the OpenAI organization and
+	// project of the request.
+	openAIOrganizationHeader = "openai-organization"
+	openAIProjectHeader      = "openai-project"
+)
+
 // apiKeyHandler implements [Handler] for api key authz.
 type apiKeyHandler struct {
-	apiKey string
+	apiKey       string
+	organization string
+	project      string
 }
 
 func newAPIKeyHandler(auth *filterapi.APIKeyAuth) (filterapi.BackendAuthHandler, error) {
-	return &apiKeyHandler{apiKey: strings.TrimSpace(auth.Key)}, nil
+	return &apiKeyHandler{
+		apiKey:       strings.TrimSpace(auth.Key),
+		organization: strings.TrimSpace(auth.Organization),
+		project:      strings.TrimSpace(auth.Project),
+	}, nil
 }
 
 // Do implements [Handler.Do].
 //
-// Extracts the api key from the local file and set it as an authorization header.
+// Extracts the api key from the local file and set it as an authorization header. The OpenAI organization and
+// project of the api key, if configured, replace the ones sent by the client. They are not written to the
+// requestHeaders so that the values sent by the client are still reported in the metrics.
 func (a *apiKeyHandler) Do(_ context.Context, requestHeaders map[string]string, _ []byte) ([]internalapi.Header, error) {
-	requestHeaders["Authorization"] = fmt.Sprintf("Bearer %s", a.apiKey)
-	return []internalapi.Header{{"Authorization", fmt.Sprintf("Bearer %s", a.apiKey)}}, nil
+	return apiKeyHeaders(requestHeaders, a.apiKey, a.organization, a.project), nil
+}
+
+// apiKeyHeaders sets the api key as the authorization header of the request, and returns the headers to set on the
+// upstream request, including the OpenAI organization and project, if any.
+func apiKeyHeaders(requestHeaders map[string]string, apiKey, organization, project string) []internalapi.Header {
+	requestHeaders["Authorization"] = fmt.Sprintf("Bearer %s", apiKey)
+	headers := []internalapi.Header{{"Authorization", fmt.Sprintf("Bearer %s", apiKey)}}
+	if organization != "" {
+		headers = append(headers, internalapi.Header{openAIOrganizationHeader, organization})
+	}
+	if project != "" {
+		headers = append(headers, internalapi.Header{openAIProjectHeader, project})
+	}
+	return headers
 }
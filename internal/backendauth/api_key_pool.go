@@ -0,0 +1,141 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package backendauth
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/envoyproxy/ai-gateway/internal/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+)
+
+// apiKeyRejectionCooldown is how long an API key rejected by the backend stays out of the rotation of its pool. The
+// key is used again afterward, so that a key rejected by a transient error of the backend recovers.
+const apiKeyRejectionCooldown = 5 * time.Minute
+
+// pooledAPIKey is an API key of the pool of an [apiKeyPoolHandler].
+type pooledAPIKey struct {
+	name   string
+	key    string
+	weight int
+	// current is the current weight of the smooth weighted round-robin.
+	current int
+	// rejectedUntil is the end of the cooldown of the key rejected by the backend. Zero if not rejected.
+	rejectedUntil time.Time
+}
+
+// apiKeyPoolHandler implements [filterapi.BackendAuthHandler] for the API key authz rotating across the API keys of
+// a pool with a smooth weighted round-robin. It also implements [filterapi.BackendAuthResponseObserver] to remove the
+// keys rejected by the backend from the rotation for apiKeyRejectionCooldown.
+//
+// The rejections are kept per pool, so they are forgotten when the handler is recreated with the reloaded
+// configuration.
+type apiKeyPoolHandler struct {
+	organization string
+	project      string
+	cooldown     time.Duration
+	// now is time.Now, replaced in tests.
+	now func() time.Time
+
+	mu   sync.Mutex
+	keys []*pooledAPIKey
+}
+
+func newAPIKeyPoolHandler(auth *filterapi.APIKeyAuth) (filterapi.BackendAuthHandler, error) {
+	h := &apiKeyPoolHandler{
+		organization: strings.TrimSpace(auth.Organization),
+		project:      strings.TrimSpace(auth.Project),
+		cooldown:     apiKeyRejectionCooldown,
+		now:          time.Now,
+	}
+	for i := range auth.Pool {
+		key := strings.TrimSpace(auth.Pool[i].Key)
+		h.keys = append(h.keys, &pooledAPIKey{
+			name:   auth.Pool[i].Name,
+			key:    key,
+			weight: auth.Pool[i].Weight,
+		})
+	}
+	return h, nil
+}
+
+// Do implements [filterapi.BackendAuthHandler.Do].
+//
+// Sets the next API key of the rotation as the authorization header, the same way [apiKeyHandler] does.
+func (a *apiKeyPoolHandler) Do(ctx context.Context, requestHeaders map[string]string, _ []byte) ([]internalapi.Header, error) {
+	k := a.next()
+	if Metrics != nil {
+		Metrics.RecordAPIKeyRequest(ctx, k.name)
+	}
+	return apiKeyHeaders(requestHeaders, k.key, a.organization, a.project), nil
+}
+
+// next returns the next API key of the smooth weighted round-robin across the keys not rejected by the backend
+// within their cooldown. When all of them were rejected, the rotation goes on across all the keys rather than
+// failing the requests.
+func (a *apiKeyPoolHandler) next() *pooledAPIKey {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	now := a.now()
+	eligible := make([]*pooledAPIKey, 0, len(a.keys))
+	for _, k := range a.keys {
+		if k.weight > 0 && !now.Before(k.rejectedUntil) {
+			eligible = append(eligible, k)
+		}
+	}
+	if len(eligible) == 0 {
+		for _, k := range a.keys {
+			if k.weight > 0 {
+				eligible = append(eligible, k)
+			}
+		}
+	}
+
+	var best *pooledAPIKey
+	total := 0
+	for _, k := range eligible {
+		k.current += k.weight
+		total += k.weight
+		if best == nil || k.current > best.current {
+			best = k
+		}
+	}
+	best.current -= total
+	return best
+}
+
+// ObserveResponse implements [filterapi.BackendAuthResponseObserver.ObserveResponse].
+//
+// Removes the API key of the request from the rotation for the cooldown when the backend rejects it with 401, and
+// re-admits the key as soon as the backend accepts a request authenticated with it.
+func (a *apiKeyPoolHandler) ObserveResponse(ctx context.Context, requestHeaders map[string]string, status int) {
+	key := strings.TrimPrefix(requestHeaders["Authorization"], "Bearer ")
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, k := range a.keys {
+		if k.key != key {
+			continue
+		}
+		switch {
+		case status == http.StatusUnauthorized:
+			now := a.now()
+			if now.Before(k.rejectedUntil) {
+				return
+			}
+			k.rejectedUntil = now.Add(a.cooldown)
+			if Metrics != nil {
+				Metrics.RecordAPIKeyRemoval(ctx, k.name)
+			}
+		case status < http.StatusBadRequest:
+			k.rejectedUntil = time.Time{}
+		}
+		return
+	}
+}
@@ -0,0 +1,155 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package backendauth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/internal/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+)
+
+func newTestAPIKeyPoolHandler(t *testing.T) (filterapi.BackendAuthHandler, *fakeBackendAuthMetrics) {
+	m := &fakeBackendAuthMetrics{}
+	Metrics = m
+	t.Cleanup(func() { Metrics = nil })
+	h, err := NewHandler(t.Context(), &filterapi.BackendAuth{APIKey: &filterapi.APIKeyAuth{
+		Project: "proj",
+		Pool: []filterapi.APIKeyPoolEntry{
+			{Name: "ns/bsp/a", Key: "key-a\n", Weight: 2},
+			{Name: "ns/bsp/b", Key: "key-b", Weight: 1},
+			{Name: "ns/bsp/c", Key: "key-c", Weight: 0},
+		},
+	}})
+	require.NoError(t, err)
+	return h, m
+}
+
+func doAPIKeyPool(t *testing.T, h filterapi.BackendAuthHandler) (string, map[string]string) {
+	requestHeaders := map[string]string{}
+	hdrs, err := h.Do(t.Context(), requestHeaders, nil)
+	require.NoError(t, err)
+	require.Equal(t, internalapi.Header{"openai-project", "proj"}, hdrs[1])
+	require.Equal(t, hdrs[0][1], requestHeaders["Authorization"])
+	return hdrs[0][1], requestHeaders
+}
+
+func TestAPIKeyPoolHandler_Do(t *testing.T) {
+	h, m := newTestAPIKeyPoolHandler(t)
+	var keys []string
+	for range 6 {
+		key, _ := doAPIKeyPool(t, h)
+		keys = append(keys, key)
+	}
+	// The keys are interleaved by weight, and the keys of zero weight are never used.
+	require.Equal(t, []string{
+		"Bearer key-a", "Bearer key-b", "Bearer key-a",
+		"Bearer key-a", "Bearer key-b", "Bearer key-a",
+	}, keys)
+	require.Equal(t, []string{"ns/bsp/a", "ns/bsp/b", "ns/bsp/a", "ns/bsp/a", "ns/bsp/b", "ns/bsp/a"}, m.requests)
+}
+
+func TestAPIKeyPoolHandler_ObserveResponse(t *testing.T) {
+	h, m := newTestAPIKeyPoolHandler(t)
+	o, ok := h.(filterapi.BackendAuthResponseObserver)
+	require.True(t, ok)
+
+	key, requestHeaders := doAPIKeyPool(t, h)
+	require.Equal(t, "Bearer key-a", key)
+	o.ObserveResponse(t.Context(), requestHeaders, 429)
+	require.Empty(t, m.removals)
+	o.ObserveResponse(t.Context(), requestHeaders, 401)
+	o.ObserveResponse(t.Context(), requestHeaders, 401)
+	require.Equal(t, []string{"ns/bsp/a"}, m.removals)
+	for range 3 {
+		key, _ = doAPIKeyPool(t, h)
+		require.Equal(t, "Bearer key-b", key)
+	}
+
+	// The removals are kept per pool.
+	h2, err := NewHandler(t.Context(), &filterapi.BackendAuth{APIKey: &filterapi.APIKeyAuth{
+		Pool: []filterapi.APIKeyPoolEntry{{Name: "ns/bsp/a", Key: "key-a", Weight: 1}, {Name: "ns/bsp/b", Key: "key-b", Weight: 1}},
+	}})
+	require.NoError(t, err)
+	requestHeaders = map[string]string{}
+	hdrs, err := h2.Do(t.Context(), requestHeaders, nil)
+	require.NoError(t, err)
+	require.Equal(t, "Bearer key-a", hdrs[0][1])
+
+	// When all the keys are rejected, the rotation goes on across all of them.
+	_, requestHeaders = doAPIKeyPool(t, h)
+	o.ObserveResponse(t.Context(), requestHeaders, 401)
+	require.Equal(t, []string{"ns/bsp/a", "ns/bsp/b"}, m.removals)
+	key, _ = doAPIKeyPool(t, h)
+	require.Contains(t, []string{"Bearer key-a", "Bearer key-b"}, key)
+
+	// The keys not in the pool are ignored.
+	o.ObserveResponse(t.Context(), map[string]string{"Authorization": "Bearer other"}, 401)
+	require.Len(t, m.removals, 2)
+}
+
+func TestAPIKeyPoolHandler_ObserveResponse_recovery(t *testing.T) {
+	h, m := newTestAPIKeyPoolHandler(t)
+	now := time.Now()
+	pool := h.(*apiKeyPoolHandler)
+	pool.now = func() time.Time { return now }
+	doKeys := func() []string {
+		var keys []string
+		for range 3 {
+			key, _ := doAPIKeyPool(t, h)
+			keys = append(keys, key)
+		}
+		return keys
+	}
+
+	_, requestHeaders := doAPIKeyPool(t, h)
+	pool.ObserveResponse(t.Context(), requestHeaders, 401)
+	require.Equal(t, []string{"ns/bsp/a"}, m.removals)
+	require.NotContains(t, doKeys(), "Bearer key-a")
+
+	// The key is used again after the cooldown, and removed again if rejected again.
+	now = now.Add(apiKeyRejectionCooldown)
+	require.Contains(t, doKeys(), "Bearer key-a")
+	pool.ObserveResponse(t.Context(), map[string]string{"Authorization": "Bearer key-a"}, 401)
+	require.Equal(t, []string{"ns/bsp/a", "ns/bsp/a"}, m.removals)
+	require.NotContains(t, doKeys(), "Bearer key-a")
+
+	// The key accepted within its cooldown, e.g. by a request in flight, is re-admitted right away.
+	pool.ObserveResponse(t.Context(), map[string]string{"Authorization": "Bearer key-a"}, 200)
+	require.Contains(t, doKeys(), "Bearer key-a")
+}
+
+func TestAPIKeyPoolHandler_CredentialOverride(t *testing.T) {
+	_, m := newTestAPIKeyPoolHandler(t)
+	h, err := NewHandler(t.Context(), &filterapi.BackendAuth{
+		APIKey: &filterapi.APIKeyAuth{Pool: []filterapi.APIKeyPoolEntry{{Name: "ns/bsp/a", Key: "key-a", Weight: 1}}},
+		CredentialOverride: &filterapi.CredentialOverride{
+			HeaderName:           "x-upstream-key",
+			FallbackToConfigured: true,
+		},
+	})
+	require.NoError(t, err)
+	requestHeaders := map[string]string{}
+	_, err = h.Do(t.Context(), requestHeaders, nil)
+	require.NoError(t, err)
+	require.Equal(t, "Bearer key-a", requestHeaders["Authorization"])
+	h.(filterapi.BackendAuthResponseObserver).ObserveResponse(t.Context(), requestHeaders, 401)
+	require.Equal(t, []string{"ns/bsp/a"}, m.removals)
+}
+
+func TestValidate_APIKeyPool(t *testing.T) {
+	err := Validate(&filterapi.BackendAuth{APIKey: &filterapi.APIKeyAuth{
+		Pool: []filterapi.APIKeyPoolEntry{{Name: "ns/bsp/a", Weight: 1}},
+	}})
+	require.EqualError(t, err, `API key of the pool entry "ns/bsp/a" is required`)
+	err = Validate(&filterapi.BackendAuth{APIKey: &filterapi.APIKeyAuth{
+		Pool: []filterapi.APIKeyPoolEntry{{Name: "ns/bsp/a", Key: "k"}},
+	}})
+	require.EqualError(t, err, "at least one API key of the pool must have a positive weight")
+}
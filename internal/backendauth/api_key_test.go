@@ -11,6 +11,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/envoyproxy/ai-gateway/internal/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
 )
 
 func TestNewAPIKeyHandler(t *testing.T) {
@@ -40,3 +41,20 @@ func TestApiKeyHandler_Do(t *testing.T) {
 	require.Equal(t, "Authorization", hdrs[0][0])
 	require.Equal(t, "Bearer test", hdrs[0][1])
 }
+
+func TestApiKeyHandler_Do_OpenAIOrganizationAndProject(t *testing.T) {
+	auth := filterapi.APIKeyAuth{Key: "test", Organization: "org-abc123", Project: " proj_abc123\n"}
+	handler, err := newAPIKeyHandler(&auth)
+	require.NoError(t, err)
+
+	requestHeaders := map[string]string{openAIOrganizationHeader: "org-client", ":path": "/v1/chat/completions"}
+	hdrs, err := handler.Do(t.Context(), requestHeaders, nil)
+	require.NoError(t, err)
+	require.Equal(t, []internalapi.Header{
+		{"Authorization", "Bearer test"},
+		{"openai-organization", "org-abc123"},
+		{"openai-project", "proj_abc123"},
+	}, hdrs)
+	// The value sent by the client is kept in the request headers.
+	require.Equal(t, "org-client", requestHeaders[openAIOrganizationHeader])
+}
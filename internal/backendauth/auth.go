@@ -8,15 +8,27 @@ package backendauth
 import (
 	"context"
 	"errors"
+	"fmt"
 
 	"github.com/envoyproxy/ai-gateway/internal/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/metrics"
 )
 
+// Metrics records the reloads of the credentials loaded from files, and the usage and the removals of the API keys
+// of the pools. Nil disables the metrics.
+//
+// The credentials inlined in the filter configuration, such as the API keys, are not reloaded here since the handlers
+// are recreated with the configuration whenever it changes.
+var Metrics metrics.BackendAuthMetrics
+
 // NewHandler returns a new implementation of [filterapi.BackendAuthHandler] based on the configuration.
 // When config.CredentialOverride is set, the returned handler sources the upstream credential
 // per-request and falls back to the static credential (or returns 401) as configured.
 // AWS is not supported with CredentialOverride (SigV4 requires three inputs, not one).
 func NewHandler(ctx context.Context, config *filterapi.BackendAuth) (filterapi.BackendAuthHandler, error) {
+	if err := Validate(config); err != nil {
+		return nil, err
+	}
 	var (
 		inner   filterapi.BackendAuthHandler
 		applyFn applyCredentialFn
@@ -27,6 +39,9 @@ func NewHandler(ctx context.Context, config *filterapi.BackendAuth) (filterapi.B
 	case config.AWSAuth != nil:
 		// AWS uses SigV4 signing; CredentialOverride is not supported.
 		return newAWSHandler(ctx, config.AWSAuth)
+	case config.APIKey != nil && len(config.APIKey.Pool) > 0:
+		inner, err = newAPIKeyPoolHandler(config.APIKey)
+		applyFn = applyBearerCredential
 	case config.APIKey != nil:
 		inner, err = newAPIKeyHandler(config.APIKey)
 		applyFn = applyBearerCredential
@@ -42,8 +57,12 @@ func NewHandler(ctx context.Context, config *filterapi.BackendAuth) (filterapi.B
 	case config.AnthropicAPIKey != nil:
 		inner, err = newAnthropicAPIKeyHandler(config.AnthropicAPIKey)
 		applyFn = applyAnthropicCredential
-	default:
-		return nil, errors.New("no backend auth handler found")
+	case config.IBMCloudAuth != nil:
+		inner, err = newIBMCloudHandler(config.IBMCloudAuth)
+		applyFn = applyBearerCredential
+	case config.SAPAICoreAuth != nil:
+		inner, err = newSAPAICoreHandler(config.SAPAICoreAuth)
+		applyFn = makeSAPAICoreApplyFn(config.SAPAICoreAuth.ResourceGroup)
 	}
 
 	if err != nil {
@@ -59,3 +78,38 @@ func NewHandler(ctx context.Context, config *filterapi.BackendAuth) (filterapi.B
 	}
 	return inner, nil
 }
+
+// Validate checks the configuration the same way [NewHandler] does, without loading the credentials, so that the
+// misconfigurations can be reported before the configuration reaches the external processor.
+func Validate(config *filterapi.BackendAuth) error {
+	switch {
+	case config.AWSAuth != nil:
+		if config.AWSAuth.Region == "" && len(config.AWSAuth.RegionSet) == 0 {
+			return errors.New("aws region is required")
+		}
+	case config.AzureAPIKey != nil:
+		if config.AzureAPIKey.Key == "" {
+			return errors.New("azure API key is required")
+		}
+	case config.APIKey != nil && len(config.APIKey.Pool) > 0:
+		weighted := false
+		for i := range config.APIKey.Pool {
+			if config.APIKey.Pool[i].Key == "" {
+				return fmt.Errorf("API key of the pool entry %q is required", config.APIKey.Pool[i].Name)
+			}
+			weighted = weighted || config.APIKey.Pool[i].Weight > 0
+		}
+		if !weighted {
+			return errors.New("at least one API key of the pool must have a positive weight")
+		}
+	case config.SAPAICoreAuth != nil:
+		if config.SAPAICoreAuth.ResourceGroup == "" {
+			return errors.New("SAP AI Core resource group is required")
+		}
+	case config.APIKey != nil, config.AzureAuth != nil, config.GCPAuth != nil, config.AnthropicAPIKey != nil,
+		config.IBMCloudAuth != nil:
+	default:
+		return errors.New("no backend auth handler found")
+	}
+	return nil
+}
@@ -56,6 +56,18 @@ aws_secret_access_key = test
 				AnthropicAPIKey: &filterapi.AnthropicAPIKeyAuth{Key: "TEST"},
 			},
 		},
+		{
+			name: "IBMCloudAuth",
+			config: &filterapi.BackendAuth{
+				IBMCloudAuth: &filterapi.IBMCloudAuth{AccessToken: "some-access-token"},
+			},
+		},
+		{
+			name: "SAPAICoreAuth",
+			config: &filterapi.BackendAuth{
+				SAPAICoreAuth: &filterapi.SAPAICoreAuth{AccessToken: "some-access-token", ResourceGroup: "default"},
+			},
+		},
 	} {
 		t.Run(tt.name, func(t *testing.T) {
 			_, err := NewHandler(t.Context(), tt.config)
@@ -63,3 +75,55 @@ aws_secret_access_key = test
 		})
 	}
 }
+
+func TestValidate(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		config *filterapi.BackendAuth
+		expErr string
+	}{
+		{
+			name:   "AWSAuth",
+			config: &filterapi.BackendAuth{AWSAuth: &filterapi.AWSAuth{Region: "us-west-2"}},
+		},
+		{
+			name:   "AWSAuth with region set",
+			config: &filterapi.BackendAuth{AWSAuth: &filterapi.AWSAuth{RegionSet: []string{"us-west-2", "us-east-1"}}},
+		},
+		{
+			name:   "AWSAuth without region",
+			config: &filterapi.BackendAuth{AWSAuth: &filterapi.AWSAuth{CredentialFileLiteral: "creds"}},
+			expErr: "aws region is required",
+		},
+		{
+			name:   "AzureAPIKey without key",
+			config: &filterapi.BackendAuth{AzureAPIKey: &filterapi.AzureAPIKeyAuth{}},
+			expErr: "azure API key is required",
+		},
+		{
+			name:   "APIKey",
+			config: &filterapi.BackendAuth{APIKey: &filterapi.APIKeyAuth{Key: "TEST"}},
+		},
+		{
+			name:   "SAPAICoreAuth without resource group",
+			config: &filterapi.BackendAuth{SAPAICoreAuth: &filterapi.SAPAICoreAuth{AccessToken: "token"}},
+			expErr: "SAP AI Core resource group is required",
+		},
+		{
+			name:   "empty",
+			config: &filterapi.BackendAuth{},
+			expErr: "no backend auth handler found",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.config)
+			if tt.expErr != "" {
+				require.EqualError(t, err, tt.expErr)
+				_, err = NewHandler(t.Context(), tt.config)
+				require.EqualError(t, err, tt.expErr)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
@@ -7,6 +7,7 @@ package backendauth
 
 import (
 	"bytes"
+	"cmp"
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
@@ -29,6 +30,9 @@ type awsHandler struct {
 	credentialsProvider aws.CredentialsProvider
 	signer              *v4.Signer
 	region              string
+	// regionSet is the set of the regions the requests are signed for with sigV4a instead of signer, if not empty.
+	regionSet []string
+	sigV4a    *sigV4aSigner
 }
 
 func newAWSHandler(ctx context.Context, awsAuth *filterapi.AWSAuth) (filterapi.BackendAuthHandler, error) {
@@ -63,19 +67,52 @@ func newAWSHandler(ctx context.Context, awsAuth *filterapi.AWSAuth) (filterapi.B
 			return nil, fmt.Errorf("cannot load from credentials file: %w", err)
 		}
 	} else {
-		// Use default credential chain (supports IRSA, EKS Pod Identity, etc.)
-		cfg, err = config.LoadDefaultConfig(
-			ctx,
-			config.WithRegion(awsAuth.Region),
-		)
+		// Use default credential chain (supports IRSA, EKS Pod Identity, etc.). The shared files of the chain, e.g.
+		// mounted from a Secret, are reloaded when they change. The web identity token file of IRSA is already
+		// read again by the SDK on every refresh.
+		var credentials *reloadableCredentials[aws.CredentialsProvider]
+		credentials, err = newReloadableCredentials(ctx, "aws", awsSharedFiles(),
+			func(ctx context.Context) (aws.CredentialsProvider, error) {
+				loaded, loadErr := config.LoadDefaultConfig(ctx, config.WithRegion(awsAuth.Region))
+				if loadErr != nil {
+					return nil, loadErr
+				}
+				return loaded.Credentials, nil
+			})
 		if err != nil {
 			return nil, fmt.Errorf("cannot load AWS config: %w", err)
 		}
+		cfg.Credentials = &reloadingCredentialsProvider{credentials: credentials}
 	}
 
 	signer := v4.NewSigner()
 
-	return &awsHandler{credentialsProvider: cfg.Credentials, signer: signer, region: awsAuth.Region}, nil
+	return &awsHandler{
+		credentialsProvider: cfg.Credentials,
+		signer:              signer,
+		region:              awsAuth.Region,
+		regionSet:           awsAuth.RegionSet,
+		sigV4a:              &sigV4aSigner{},
+	}, nil
+}
+
+// awsSharedFiles returns the paths of the shared credentials and config files of the default credential chain.
+func awsSharedFiles() []string {
+	return []string{
+		cmp.Or(os.Getenv("AWS_SHARED_CREDENTIALS_FILE"), config.DefaultSharedCredentialsFilename()),
+		cmp.Or(os.Getenv("AWS_CONFIG_FILE"), config.DefaultSharedConfigFilename()),
+	}
+}
+
+// reloadingCredentialsProvider implements [aws.CredentialsProvider] with the provider of the default credential
+// chain, which is recreated when its files change.
+type reloadingCredentialsProvider struct {
+	credentials *reloadableCredentials[aws.CredentialsProvider]
+}
+
+// Retrieve implements [aws.CredentialsProvider.Retrieve].
+func (r *reloadingCredentialsProvider) Retrieve(ctx context.Context) (aws.Credentials, error) {
+	return r.credentials.get(ctx).Retrieve(ctx)
 }
 
 // Do implements [Handler.Do].
@@ -112,8 +149,14 @@ func (a *awsHandler) Do(ctx context.Context, requestHeaders map[string]string, m
 		return nil, fmt.Errorf("cannot retrieve AWS credentials: %w", err)
 	}
 
-	err = a.signer.SignHTTP(ctx, credentials, req,
-		hex.EncodeToString(payloadHash[:]), "bedrock", a.region, time.Now())
+	if len(a.regionSet) > 0 {
+		// The cross-region inference profiles and the global endpoint require SigV4a to serve the request from
+		// any of the regions.
+		err = a.sigV4a.signHTTP(credentials, req, hex.EncodeToString(payloadHash[:]), "bedrock", a.regionSet, time.Now())
+	} else {
+		err = a.signer.SignHTTP(ctx, credentials, req,
+			hex.EncodeToString(payloadHash[:]), "bedrock", a.region, time.Now())
+	}
 	if err != nil {
 		return nil, fmt.Errorf("cannot sign request: %w", err)
 	}
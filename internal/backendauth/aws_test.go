@@ -213,6 +213,27 @@ func TestAWSHandler_Do(t *testing.T) {
 		require.Contains(t, headers, "X-Amz-Date")
 	})
 
+	t.Run("sigv4a", func(t *testing.T) {
+		awsFileBody := "[default]\naws_access_key_id=test\naws_secret_access_key=secret\naws_session_token=token\n"
+		handler, err := newAWSHandler(t.Context(), &filterapi.AWSAuth{
+			CredentialFileLiteral: awsFileBody,
+			Region:                "us-east-1",
+			RegionSet:             []string{"us-east-1", "us-west-2"},
+		})
+		require.NoError(t, err)
+
+		hdrs, err := handler.Do(t.Context(), map[string]string{
+			":method": "POST", ":path": "/model/us.anthropic.claude-3-5-sonnet-20241022-v2:0/converse",
+		}, []byte(`{"test": "data"}`))
+		require.NoError(t, err)
+
+		headers := stringPairsToMap(hdrs)
+		require.Equal(t, "us-east-1,us-west-2", headers["X-Amz-Region-Set"])
+		require.Equal(t, "token", headers["X-Amz-Security-Token"])
+		require.Regexp(t, `^AWS4-ECDSA-P256-SHA256 Credential=test/\d{8}/bedrock/aws4_request, `+
+			`SignedHeaders=host;x-amz-date;x-amz-region-set;x-amz-security-token, Signature=[0-9a-f]+$`, headers["Authorization"])
+	})
+
 	t.Run("multiple regions", func(t *testing.T) {
 		awsFileBody := "[default]\naws_access_key_id=test\naws_secret_access_key=secret\n"
 		regions := []string{"us-east-1", "eu-west-1", "ap-southeast-1"}
@@ -106,6 +106,14 @@ func (h *credentialOverrideHandler) Do(ctx context.Context, requestHeaders map[s
 	return h.applyFn(requestHeaders, credential)
 }
 
+// ObserveResponse implements [filterapi.BackendAuthResponseObserver.ObserveResponse] by forwarding the response to
+// the inner handler, if it observes them.
+func (h *credentialOverrideHandler) ObserveResponse(ctx context.Context, requestHeaders map[string]string, status int) {
+	if o, ok := h.inner.(filterapi.BackendAuthResponseObserver); ok {
+		o.ObserveResponse(ctx, requestHeaders, status)
+	}
+}
+
 // resolveCredential reads the per-request credential from the configured source.
 // Returns an empty string when the source is absent.
 func (h *credentialOverrideHandler) resolveCredential(ctx context.Context, requestHeaders map[string]string) string {
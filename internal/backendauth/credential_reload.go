@@ -0,0 +1,138 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package backendauth
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// credentialFiles watches the directories of the credential files, and counts the changes per directory.
+//
+// The directories rather than the files are watched since the files of a mounted Kubernetes Secret are symlinks
+// into a directory swapped atomically on update, so the events are never reported on the files themselves.
+var credentialFiles = &credentialFileWatcher{generations: make(map[string]*atomic.Uint64)}
+
+// credentialFileWatcher is the type of credentialFiles.
+type credentialFileWatcher struct {
+	mu          sync.Mutex
+	watcher     *fsnotify.Watcher
+	generations map[string]*atomic.Uint64
+}
+
+// watch starts watching the directory of the file if it exists, and returns the counter of the changes in it.
+// It returns nil if the directory cannot be watched, in which case the credentials are never reloaded.
+func (w *credentialFileWatcher) watch(file string) *atomic.Uint64 {
+	dir := filepath.Dir(filepath.Clean(file))
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if gen, ok := w.generations[dir]; ok {
+		return gen
+	}
+	if w.watcher == nil {
+		watcher, err := fsnotify.NewWatcher()
+		if err != nil {
+			return nil
+		}
+		w.watcher = watcher
+		go w.run()
+	}
+	if err := w.watcher.Add(dir); err != nil {
+		return nil
+	}
+	gen := &atomic.Uint64{}
+	w.generations[dir] = gen
+	return gen
+}
+
+// run counts the changes in the watched directories for the life of the process.
+func (w *credentialFileWatcher) run() {
+	for {
+		select {
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			w.mu.Lock()
+			gen := w.generations[filepath.Dir(event.Name)]
+			w.mu.Unlock()
+			if gen != nil {
+				gen.Add(1)
+			}
+		case _, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+		}
+	}
+}
+
+// reloadableCredentials holds the credentials loaded from files, and reloads them on the first use after the files
+// changed. The requests in flight keep the credentials they already got, and the next ones get the new credentials.
+type reloadableCredentials[T any] struct {
+	authType    string
+	load        func(ctx context.Context) (T, error)
+	generations []*atomic.Uint64
+
+	mu        sync.Mutex
+	loadedGen atomic.Uint64
+	current   atomic.Pointer[T]
+}
+
+// newReloadableCredentials loads the credentials and watches the files for the changes. The files that do not
+// exist are ignored.
+func newReloadableCredentials[T any](ctx context.Context, authType string, files []string, load func(ctx context.Context) (T, error)) (*reloadableCredentials[T], error) {
+	r := &reloadableCredentials[T]{authType: authType, load: load}
+	for _, f := range files {
+		if gen := credentialFiles.watch(f); gen != nil {
+			r.generations = append(r.generations, gen)
+		}
+	}
+	// Take the generation before loading so that a change while loading triggers another reload.
+	r.loadedGen.Store(r.generation())
+	v, err := load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	r.current.Store(&v)
+	return r, nil
+}
+
+// generation returns the sum of the change counters of the files, which only grows.
+func (r *reloadableCredentials[T]) generation() (sum uint64) {
+	for _, gen := range r.generations {
+		sum += gen.Load()
+	}
+	return
+}
+
+// get returns the current credentials, reloading them first if the files changed since they were loaded. The
+// previous credentials are kept if the reload fails, and the reload is retried on the next change.
+func (r *reloadableCredentials[T]) get(ctx context.Context) T {
+	gen := r.generation()
+	if gen == r.loadedGen.Load() {
+		return *r.current.Load()
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if gen = r.generation(); gen == r.loadedGen.Load() {
+		return *r.current.Load()
+	}
+	r.loadedGen.Store(gen)
+	// The credentials outlive the request, e.g. the token sources refreshing the tokens with the context.
+	v, err := r.load(context.WithoutCancel(ctx))
+	if err == nil {
+		r.current.Store(&v)
+	}
+	if Metrics != nil {
+		Metrics.RecordCredentialReload(ctx, r.authType, err == nil)
+	}
+	return *r.current.Load()
+}
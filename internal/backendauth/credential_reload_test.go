@@ -0,0 +1,92 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package backendauth
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeBackendAuthMetrics struct {
+	mu       sync.Mutex
+	reloads  []bool
+	requests []string
+	removals []string
+}
+
+func (f *fakeBackendAuthMetrics) RecordAPIKeyRequest(_ context.Context, name string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests = append(f.requests, name)
+}
+
+func (f *fakeBackendAuthMetrics) RecordAPIKeyRemoval(_ context.Context, name string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.removals = append(f.removals, name)
+}
+
+func (f *fakeBackendAuthMetrics) RecordCredentialReload(_ context.Context, authType string, success bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if authType == "test" {
+		f.reloads = append(f.reloads, success)
+	}
+}
+
+func TestReloadableCredentials(t *testing.T) {
+	m := &fakeBackendAuthMetrics{}
+	Metrics = m
+	t.Cleanup(func() { Metrics = nil })
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "credentials")
+	require.NoError(t, os.WriteFile(file, []byte("v1"), 0o600))
+	r, err := newReloadableCredentials(t.Context(), "test", []string{file, filepath.Join(t.TempDir(), "missing", "file")},
+		func(context.Context) (string, error) {
+			content, readErr := os.ReadFile(file)
+			if string(content) == "invalid" {
+				return "", errors.New("invalid credentials")
+			}
+			return string(content), readErr
+		})
+	require.NoError(t, err)
+	require.Equal(t, "v1", r.get(t.Context()))
+
+	// The credentials are swapped on the first use after the change.
+	writeAtomic(t, file, "v2")
+	require.Eventually(t, func() bool { return r.get(t.Context()) == "v2" }, 5*time.Second, 10*time.Millisecond)
+
+	// The previous credentials are kept when the reload fails.
+	writeAtomic(t, file, "invalid")
+	require.Eventually(t, func() bool {
+		require.Equal(t, "v2", r.get(t.Context()))
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		return len(m.reloads) > 0 && !m.reloads[len(m.reloads)-1]
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Contains(t, m.reloads, true)
+
+	_, err = newReloadableCredentials(t.Context(), "test", nil, func(context.Context) (string, error) {
+		return "", errors.New("boom")
+	})
+	require.ErrorContains(t, err, "boom")
+}
+
+// writeAtomic replaces the file with the content by a rename, as the files of the mounted Secrets are updated, so
+// that the file is never read partially written.
+func writeAtomic(t *testing.T, file, content string) {
+	tmp := file + ".tmp"
+	require.NoError(t, os.WriteFile(tmp, []byte(content), 0o600))
+	require.NoError(t, os.Rename(tmp, file))
+}
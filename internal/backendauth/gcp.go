@@ -9,6 +9,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"os"
 	"time"
 
 	"golang.org/x/oauth2"
@@ -47,18 +48,45 @@ func newGCPHandler(ctx context.Context, gcpAuth *filterapi.GCPAuth) (filterapi.B
 		handler.gcpAccessToken = gcpAuth.AccessToken
 	} else {
 		// Use ADC for GKE Workload Identity. TokenSource auto-refreshes in Do().
-		// Inject HTTP client with proxy support into context for token operations.
-		ctx = context.WithValue(ctx, oauth2.HTTPClient, gcpHTTPClient)
-		creds, err := google.FindDefaultCredentials(ctx, "https://www.googleapis.com/auth/cloud-platform")
+		// The credentials file of ADC, e.g. mounted from a Secret, is reloaded when it changes.
+		credentials, err := newReloadableCredentials(ctx, "gcp", gcpCredentialFiles(),
+			func(ctx context.Context) (oauth2.TokenSource, error) {
+				// Inject HTTP client with proxy support into context for token operations.
+				ctx = context.WithValue(ctx, oauth2.HTTPClient, gcpHTTPClient)
+				creds, findErr := google.FindDefaultCredentials(ctx, "https://www.googleapis.com/auth/cloud-platform")
+				if findErr != nil {
+					return nil, findErr
+				}
+				return creds.TokenSource, nil
+			})
 		if err != nil {
 			return nil, fmt.Errorf("failed to find GCP default credentials: %w", err)
 		}
-		handler.tokenSource = creds.TokenSource
+		handler.tokenSource = &reloadingTokenSource{credentials: credentials}
 	}
 
 	return handler, nil
 }
 
+// gcpCredentialFiles returns the path of the credentials file of ADC, if any.
+func gcpCredentialFiles() []string {
+	if f := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS"); f != "" {
+		return []string{f}
+	}
+	return nil
+}
+
+// reloadingTokenSource implements [oauth2.TokenSource] with the token source of ADC, which is recreated when its
+// credentials file changes.
+type reloadingTokenSource struct {
+	credentials *reloadableCredentials[oauth2.TokenSource]
+}
+
+// Token implements [oauth2.TokenSource.Token].
+func (r *reloadingTokenSource) Token() (*oauth2.Token, error) {
+	return r.credentials.get(context.Background()).Token()
+}
+
 // Do implements [Handler.Do].
 //
 // This method updates the request headers to:
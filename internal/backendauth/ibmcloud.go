@@ -0,0 +1,29 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package backendauth
+
+import (
+	"context"
+	"strings"
+
+	"github.com/envoyproxy/ai-gateway/internal/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+)
+
+type ibmCloudHandler struct {
+	accessToken string
+}
+
+func newIBMCloudHandler(auth *filterapi.IBMCloudAuth) (filterapi.BackendAuthHandler, error) {
+	return &ibmCloudHandler{accessToken: strings.TrimSpace(auth.AccessToken)}, nil
+}
+
+// Do implements [Handler.Do].
+//
+// Sets the IBM Cloud IAM access token as the bearer token of the authorization header.
+func (i *ibmCloudHandler) Do(_ context.Context, requestHeaders map[string]string, _ []byte) ([]internalapi.Header, error) {
+	return applyBearerCredential(requestHeaders, i.accessToken)
+}
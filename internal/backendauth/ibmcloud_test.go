@@ -0,0 +1,26 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package backendauth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/internal/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+)
+
+func TestIBMCloudHandler_Do(t *testing.T) {
+	handler, err := newIBMCloudHandler(&filterapi.IBMCloudAuth{AccessToken: " some-access-token \n"})
+	require.NoError(t, err)
+
+	requestHeaders := map[string]string{":path": "/ml/v1/deployments/granite/text/chat?version=2024-05-31"}
+	headers, err := handler.Do(t.Context(), requestHeaders, nil)
+	require.NoError(t, err)
+	require.Equal(t, "Bearer some-access-token", requestHeaders["Authorization"])
+	require.Equal(t, []internalapi.Header{{"Authorization", "Bearer some-access-token"}}, headers)
+}
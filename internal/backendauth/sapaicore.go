@@ -0,0 +1,50 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package backendauth
+
+import (
+	"context"
+	"strings"
+
+	"github.com/envoyproxy/ai-gateway/internal/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+)
+
+// sapAICoreResourceGroupHeader is the header selecting the resource group of the SAP AI Core deployments.
+const sapAICoreResourceGroupHeader = "ai-resource-group"
+
+type sapAICoreHandler struct {
+	accessToken string
+	applyFn     applyCredentialFn
+}
+
+func newSAPAICoreHandler(auth *filterapi.SAPAICoreAuth) (filterapi.BackendAuthHandler, error) {
+	return &sapAICoreHandler{
+		accessToken: strings.TrimSpace(auth.AccessToken),
+		applyFn:     makeSAPAICoreApplyFn(auth.ResourceGroup),
+	}, nil
+}
+
+// Do implements [Handler.Do].
+//
+// Sets the SAP AI Core access token as the bearer token of the authorization header, and the resource group of
+// the deployments.
+func (s *sapAICoreHandler) Do(_ context.Context, requestHeaders map[string]string, _ []byte) ([]internalapi.Header, error) {
+	return s.applyFn(requestHeaders, s.accessToken)
+}
+
+// makeSAPAICoreApplyFn returns an applyCredentialFn for SAP AI Core that also sets the "AI-Resource-Group" header,
+// matching the behaviour of sapAICoreHandler.Do().
+func makeSAPAICoreApplyFn(resourceGroup string) applyCredentialFn {
+	return func(requestHeaders map[string]string, credential string) ([]internalapi.Header, error) {
+		headers, err := applyBearerCredential(requestHeaders, credential)
+		if err != nil {
+			return nil, err
+		}
+		requestHeaders[sapAICoreResourceGroupHeader] = resourceGroup
+		return append(headers, internalapi.Header{sapAICoreResourceGroupHeader, resourceGroup}), nil
+	}
+}
@@ -0,0 +1,30 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package backendauth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/internal/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+)
+
+func TestSAPAICoreHandler_Do(t *testing.T) {
+	handler, err := newSAPAICoreHandler(&filterapi.SAPAICoreAuth{AccessToken: " some-access-token \n", ResourceGroup: "team-a"})
+	require.NoError(t, err)
+
+	requestHeaders := map[string]string{":path": "/v2/inference/deployments/d123/chat/completions?api-version=2024-10-21"}
+	headers, err := handler.Do(t.Context(), requestHeaders, nil)
+	require.NoError(t, err)
+	require.Equal(t, "Bearer some-access-token", requestHeaders["Authorization"])
+	require.Equal(t, "team-a", requestHeaders["ai-resource-group"])
+	require.Equal(t, []internalapi.Header{
+		{"Authorization", "Bearer some-access-token"},
+		{"ai-resource-group", "team-a"},
+	}, headers)
+}
@@ -0,0 +1,203 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package backendauth
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// The AWS SDK only implements SigV4a in an internal package, so the subset of it needed to sign the Bedrock
+// requests is implemented here following
+// https://docs.aws.amazon.com/IAM/latest/UserGuide/reference_sigv-create-signed-request.html.
+
+const (
+	sigV4aAlgorithm     = "AWS4-ECDSA-P256-SHA256"
+	amzRegionSetHeader  = "X-Amz-Region-Set"
+	sigV4aTimeFormat    = "20060102T150405Z"
+	sigV4aDateFormat    = "20060102"
+	sigV4aKeyDerivation = "AWS4A"
+)
+
+// sigV4aIgnoredHeaders are the headers never signed, as the AWS SDK does.
+var sigV4aIgnoredHeaders = []string{"Authorization", "User-Agent", "X-Amzn-Trace-Id", "Transfer-Encoding"}
+
+// sigV4aSigner signs the requests with SigV4a. The ECDSA key derived from the credentials is cached since the
+// derivation is expensive, and derived again when the credentials change.
+type sigV4aSigner struct {
+	mu          sync.Mutex
+	accessKeyID string
+	secret      string
+	key         *ecdsa.PrivateKey
+}
+
+// signHTTP signs the request for the service in the regions with the credentials, setting the Authorization,
+// X-Amz-Date, X-Amz-Region-Set and X-Amz-Security-Token headers. payloadHash is the hex-encoded SHA-256 of the body.
+func (s *sigV4aSigner) signHTTP(credentials aws.Credentials, req *http.Request, payloadHash, service string, regionSet []string, signingTime time.Time) error {
+	key, err := s.privateKey(credentials)
+	if err != nil {
+		return err
+	}
+	signingTime = signingTime.UTC()
+	req.Header.Set(amzRegionSetHeader, strings.Join(regionSet, ","))
+	req.Header.Set("X-Amz-Date", signingTime.Format(sigV4aTimeFormat))
+	if credentials.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", credentials.SessionToken)
+	}
+
+	scope := signingTime.Format(sigV4aDateFormat) + "/" + service + "/aws4_request"
+	stringToSign, signedHeaders := sigV4aStringToSign(req, payloadHash, scope, signingTime)
+	digest := sha256.Sum256([]byte(stringToSign))
+	signature, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	if err != nil {
+		return fmt.Errorf("cannot sign the request: %w", err)
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		sigV4aAlgorithm, credentials.AccessKeyID, scope, signedHeaders, hex.EncodeToString(signature)))
+	return nil
+}
+
+// sigV4aStringToSign returns the string to sign of the request and the list of its signed headers.
+func sigV4aStringToSign(req *http.Request, payloadHash, scope string, signingTime time.Time) (stringToSign, signedHeaders string) {
+	host := req.URL.Host
+	if req.Host != "" {
+		host = req.Host
+	}
+	values := map[string][]string{"host": {host}}
+	for k, v := range req.Header {
+		if slices.Contains(sigV4aIgnoredHeaders, http.CanonicalHeaderKey(k)) {
+			continue
+		}
+		lower := strings.ToLower(k)
+		values[lower] = append(values[lower], v...)
+	}
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		trimmed := make([]string, len(values[name]))
+		for i, v := range values[name] {
+			trimmed[i] = strings.Join(strings.Fields(v), " ")
+		}
+		fmt.Fprintf(&canonicalHeaders, "%s:%s\n", name, strings.Join(trimmed, ","))
+	}
+	signedHeaders = strings.Join(names, ";")
+
+	query := req.URL.Query()
+	for k := range query {
+		slices.Sort(query[k])
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		sigV4aEscapePath(req.URL.EscapedPath()),
+		strings.ReplaceAll(query.Encode(), "+", "%20"),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	canonicalRequestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign = strings.Join([]string{
+		sigV4aAlgorithm,
+		signingTime.Format(sigV4aTimeFormat),
+		scope,
+		hex.EncodeToString(canonicalRequestHash[:]),
+	}, "\n")
+	return
+}
+
+// sigV4aEscapePath escapes the already escaped path once more, as the canonical request of the services other than
+// S3 requires, keeping only the unreserved characters and the slashes.
+func sigV4aEscapePath(path string) string {
+	if path == "" {
+		return "/"
+	}
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') ||
+			c == '-' || c == '.' || c == '_' || c == '~' || c == '/' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// privateKey returns the ECDSA key of the credentials, deriving it if the credentials changed.
+func (s *sigV4aSigner) privateKey(credentials aws.Credentials) (*ecdsa.PrivateKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.key != nil && s.accessKeyID == credentials.AccessKeyID && s.secret == credentials.SecretAccessKey {
+		return s.key, nil
+	}
+	key, err := deriveSigV4aKey(credentials.AccessKeyID, credentials.SecretAccessKey)
+	if err != nil {
+		return nil, err
+	}
+	s.accessKeyID, s.secret, s.key = credentials.AccessKeyID, credentials.SecretAccessKey, key
+	return key, nil
+}
+
+// deriveSigV4aKey derives the NIST P-256 key of the access key pair following FIPS 186-4 Appendix B.4.2, with the
+// candidates generated by the NIST SP 800-108 KDF in counter mode with HMAC-SHA256.
+func deriveSigV4aKey(accessKeyID, secretAccessKey string) (*ecdsa.PrivateKey, error) {
+	curve := elliptic.P256()
+	nMinusTwo := new(big.Int).Sub(curve.Params().N, big.NewInt(2)).FillBytes(make([]byte, 32))
+	inputKey := []byte(sigV4aKeyDerivation + secretAccessKey)
+	for counter := 1; counter <= 0xff; counter++ {
+		// The fixed input of the KDF: label || 0x00 || context || the length of the output in bits.
+		var fixedInput bytes.Buffer
+		fixedInput.WriteString(sigV4aAlgorithm)
+		fixedInput.WriteByte(0)
+		fixedInput.WriteString(accessKeyID)
+		fixedInput.WriteByte(byte(counter))
+		fixedInput.Write(binary.BigEndian.AppendUint32(nil, 256))
+		// A single block of HMAC-SHA256 is the 256 bits of the candidate.
+		mac := hmac.New(sha256.New, inputKey)
+		mac.Write(binary.BigEndian.AppendUint32(nil, 1))
+		mac.Write(fixedInput.Bytes())
+		candidate := mac.Sum(nil)
+		if constantTimeLess(candidate, nMinusTwo) {
+			d := new(big.Int).SetBytes(candidate)
+			d.Add(d, big.NewInt(1))
+			return ecdsa.ParseRawPrivateKey(curve, d.FillBytes(make([]byte, 32)))
+		}
+	}
+	return nil, fmt.Errorf("cannot derive the SigV4a key: exhausted the counter")
+}
+
+// constantTimeLess returns whether the big-endian number x is less than y of the same length, in constant time.
+func constantTimeLess(x, y []byte) bool {
+	less, greater := 0, 0
+	for i := range x {
+		xi, yi := int(x[i]), int(y[i])
+		lt := subtle.ConstantTimeLessOrEq(xi+1, yi)
+		gt := subtle.ConstantTimeLessOrEq(yi+1, xi)
+		less |= lt &^ greater
+		greater |= gt &^ less
+	}
+	return less == 1
+}
@@ -0,0 +1,76 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package backendauth
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeriveSigV4aKey(t *testing.T) {
+	// The test vector of the SigV4a implementation of the AWS SDK.
+	key, err := deriveSigV4aKey("AKISORANDOMAASORANDOM", "q+jcrXGc+0zWN6uzclKVhvMmUsIfRPa4rlRandom")
+	require.NoError(t, err)
+	pub, err := key.PublicKey.Bytes()
+	require.NoError(t, err)
+	require.Equal(t, "04"+
+		"15d242ceebf8d8169fd6a8b5a746c41140414c3b07579038da06af89190fffcb"+
+		"0515242cedd82e94799482e4c0514b505afccf2c0c98d6a553bf539f424c5ec0",
+		hex.EncodeToString(pub))
+}
+
+func TestSigV4aSigner(t *testing.T) {
+	creds := aws.Credentials{AccessKeyID: "AKISORANDOMAASORANDOM", SecretAccessKey: "q+jcrXGc+0zWN6uzclKVhvMmUsIfRPa4rlRandom"}
+	req, err := http.NewRequest(http.MethodPost,
+		"https://bedrock-runtime.us-east-1.amazonaws.com/model/arn:aws:bedrock:us-east-1::inference-profile%2Fglobal.x/converse", nil)
+	require.NoError(t, err)
+	req.Header.Set("User-Agent", "ignored")
+	signingTime := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	payloadHash := hex.EncodeToString(sha256.New().Sum(nil))
+
+	s := &sigV4aSigner{}
+	require.NoError(t, s.signHTTP(creds, req, payloadHash, "bedrock", []string{"*"}, signingTime))
+	require.Equal(t, "*", req.Header.Get("X-Amz-Region-Set"))
+	require.Equal(t, "20250102T030405Z", req.Header.Get("X-Amz-Date"))
+	require.Empty(t, req.Header.Get("X-Amz-Security-Token"))
+
+	prefix := "AWS4-ECDSA-P256-SHA256 Credential=AKISORANDOMAASORANDOM/20250102/bedrock/aws4_request, " +
+		"SignedHeaders=host;x-amz-date;x-amz-region-set, Signature="
+	authorization := req.Header.Get("Authorization")
+	require.True(t, strings.HasPrefix(authorization, prefix), authorization)
+	signature, err := hex.DecodeString(strings.TrimPrefix(authorization, prefix))
+	require.NoError(t, err)
+
+	stringToSign, _ := sigV4aStringToSign(req, payloadHash, "20250102/bedrock/aws4_request", signingTime)
+	canonicalRequestHash := sha256.Sum256([]byte(strings.Join([]string{
+		"POST",
+		// The path is escaped twice.
+		"/model/arn%3Aaws%3Abedrock%3Aus-east-1%3A%3Ainference-profile%252Fglobal.x/converse",
+		"",
+		"host:bedrock-runtime.us-east-1.amazonaws.com\nx-amz-date:20250102T030405Z\nx-amz-region-set:*\n",
+		"host;x-amz-date;x-amz-region-set",
+		payloadHash,
+	}, "\n")))
+	require.Equal(t, "AWS4-ECDSA-P256-SHA256\n20250102T030405Z\n20250102/bedrock/aws4_request\n"+
+		hex.EncodeToString(canonicalRequestHash[:]), stringToSign)
+	digest := sha256.Sum256([]byte(stringToSign))
+	key, err := s.privateKey(creds)
+	require.NoError(t, err)
+	require.True(t, ecdsa.VerifyASN1(&key.PublicKey, digest[:], signature))
+
+	// The key is derived again for the new credentials.
+	rotated, err := s.privateKey(aws.Credentials{AccessKeyID: "rotated", SecretAccessKey: "rotated"})
+	require.NoError(t, err)
+	require.False(t, key.Equal(rotated))
+}
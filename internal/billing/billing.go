@@ -0,0 +1,187 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+// Package billing aggregates the usage of the requests per tenant, model and backend over fixed windows, and exports
+// the usage reports of the windows for the downstream billing, e.g. as CSV or Parquet files, to an object storage or
+// to a metering API.
+//
+// The usage is the same as the one recorded in the metrics, so the reports match the token usage metrics.
+package billing
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/envoyproxy/ai-gateway/internal/metrics"
+)
+
+// Key identifies the usage aggregated in a window.
+type Key struct {
+	// Tenant is the value of the tenant header of the request, or empty if the request has none.
+	Tenant string
+	// Model is the model sent to the backend, after the model name override if any.
+	Model string
+	// Backend is the name of the backend that served the request.
+	Backend string
+	// Instance is the instance that aggregated the usage. It is only set in the reports of a Ledger.
+	Instance string
+}
+
+// Usage is the usage of a Key aggregated in a window.
+type Usage struct {
+	Key
+	// Requests is the number of requests that reported the token usage.
+	Requests uint64
+	// InputTokens, CachedInputTokens, CacheCreationInputTokens and OutputTokens are the sums of the token usage
+	// reported by the backend.
+	InputTokens              uint64
+	CachedInputTokens        uint64
+	CacheCreationInputTokens uint64
+	OutputTokens             uint64
+}
+
+// add adds the usage of o to u.
+func (u *Usage) add(o *Usage) {
+	u.Requests += o.Requests
+	u.InputTokens += o.InputTokens
+	u.CachedInputTokens += o.CachedInputTokens
+	u.CacheCreationInputTokens += o.CacheCreationInputTokens
+	u.OutputTokens += o.OutputTokens
+}
+
+// Report is the usage aggregated in the window [Start, End).
+type Report struct {
+	Start, End time.Time
+	// Usage is sorted by the tenant, the model, the backend and the instance.
+	Usage []Usage
+}
+
+// Exporter exports the usage reports.
+type Exporter interface {
+	// Export exports the report. The report is never empty.
+	Export(ctx context.Context, r *Report) error
+}
+
+// Aggregator aggregates the usage over the windows and passes the report of each window to the exporters.
+type Aggregator struct {
+	window    time.Duration
+	exporters []Exporter
+	logger    *slog.Logger
+	// now is replaced in the tests.
+	now func() time.Time
+
+	mu    sync.Mutex
+	start time.Time
+	usage map[Key]*Usage
+}
+
+// NewAggregator creates a new Aggregator of the windows of the given duration. The windows are aligned to the
+// duration, e.g. an hour window starts at the beginning of the hour.
+func NewAggregator(window time.Duration, logger *slog.Logger, exporters ...Exporter) *Aggregator {
+	a := &Aggregator{window: window, exporters: exporters, logger: logger, now: time.Now}
+	a.start = a.now().Truncate(window)
+	a.usage = make(map[Key]*Usage)
+	return a
+}
+
+// Record adds the token usage of a request to the current window.
+func (a *Aggregator) Record(key Key, usage *metrics.TokenUsage) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	u, ok := a.usage[key]
+	if !ok {
+		u = &Usage{Key: key}
+		a.usage[key] = u
+	}
+	u.Requests++
+	if v, ok := usage.InputTokens(); ok {
+		u.InputTokens += uint64(v)
+	}
+	if v, ok := usage.CachedInputTokens(); ok {
+		u.CachedInputTokens += uint64(v)
+	}
+	if v, ok := usage.CacheCreationInputTokens(); ok {
+		u.CacheCreationInputTokens += uint64(v)
+	}
+	if v, ok := usage.OutputTokens(); ok {
+		u.OutputTokens += uint64(v)
+	}
+}
+
+// Current returns the usage of the current window so far, which is not exported yet. The End of the report is now.
+func (a *Aggregator) Current() Report {
+	a.mu.Lock()
+	r := Report{Start: a.start, End: a.now()}
+	for _, u := range a.usage {
+		r.Usage = append(r.Usage, *u)
+	}
+	a.mu.Unlock()
+	sortUsage(r.Usage)
+	return r
+}
+
+// Run exports the report at the end of every window until the context is done. The usage of the last, partial
+// window is exported by Flush.
+func (a *Aggregator) Run(ctx context.Context) {
+	for {
+		a.mu.Lock()
+		next := a.start.Truncate(a.window).Add(a.window)
+		a.mu.Unlock()
+		timer := time.NewTimer(next.Sub(a.now()))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		if err := a.Flush(ctx); err != nil {
+			a.logger.Error("failed to export the usage report", slog.String("error", err.Error()))
+		}
+	}
+}
+
+// Flush ends the current window and exports its report. The report is not retried when an exporter fails, so that
+// the exporters that succeeded never report the usage twice.
+func (a *Aggregator) Flush(ctx context.Context) error {
+	a.mu.Lock()
+	end := a.now()
+	if boundary := end.Truncate(a.window); boundary.After(a.start) {
+		// The window is over, so end it at its boundary rather than when the timer fired. Otherwise, it is flushed
+		// within the window, e.g. on shutdown, and the next window continues from now.
+		end = boundary
+	}
+	r := &Report{Start: a.start, End: end}
+	for _, u := range a.usage {
+		r.Usage = append(r.Usage, *u)
+	}
+	a.start = end
+	a.usage = make(map[Key]*Usage)
+	a.mu.Unlock()
+
+	if len(r.Usage) == 0 {
+		return nil
+	}
+	sortUsage(r.Usage)
+	var errs []error
+	for _, e := range a.exporters {
+		if err := e.Export(ctx, r); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// sortUsage sorts the usage by the tenant, the model, the backend and the instance.
+func sortUsage(usage []Usage) {
+	slices.SortFunc(usage, func(x, y Usage) int {
+		return cmp.Or(cmp.Compare(x.Tenant, y.Tenant), cmp.Compare(x.Model, y.Model), cmp.Compare(x.Backend, y.Backend),
+			cmp.Compare(x.Instance, y.Instance))
+	})
+}
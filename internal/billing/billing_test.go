@@ -0,0 +1,110 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package billing
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/internal/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+	"github.com/envoyproxy/ai-gateway/internal/metrics"
+)
+
+type fakeExporter struct {
+	reports []*Report
+	err     error
+}
+
+func (f *fakeExporter) Export(_ context.Context, r *Report) error {
+	f.reports = append(f.reports, r)
+	return f.err
+}
+
+func tokenUsage(input, output uint32) *metrics.TokenUsage {
+	var u metrics.TokenUsage
+	u.SetInputTokens(input)
+	u.SetOutputTokens(output)
+	return &u
+}
+
+func TestAggregator(t *testing.T) {
+	now := time.Date(2025, 1, 1, 10, 15, 0, 0, time.UTC)
+	e := &fakeExporter{}
+	a := NewAggregator(time.Hour, slog.Default(), e)
+	a.now = func() time.Time { return now }
+	a.start = now.Truncate(time.Hour)
+
+	// Nothing is exported for an empty window.
+	now = now.Add(time.Hour)
+	require.NoError(t, a.Flush(t.Context()))
+	require.Empty(t, e.reports)
+
+	a.Record(Key{Tenant: "b", Model: "gpt-4o", Backend: "openai"}, tokenUsage(10, 5))
+	a.Record(Key{Tenant: "a", Model: "gpt-4o", Backend: "openai"}, tokenUsage(1, 2))
+	cached := tokenUsage(3, 4)
+	cached.SetCachedInputTokens(2)
+	a.Record(Key{Tenant: "b", Model: "gpt-4o", Backend: "openai"}, cached)
+
+	now = time.Date(2025, 1, 1, 12, 0, 1, 0, time.UTC)
+	require.NoError(t, a.Flush(t.Context()))
+	require.Equal(t, []*Report{{
+		Start: time.Date(2025, 1, 1, 11, 0, 0, 0, time.UTC),
+		End:   time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC),
+		Usage: []Usage{
+			{Key: Key{Tenant: "a", Model: "gpt-4o", Backend: "openai"}, Requests: 1, InputTokens: 1, OutputTokens: 2},
+			{Key: Key{Tenant: "b", Model: "gpt-4o", Backend: "openai"}, Requests: 2, InputTokens: 13, CachedInputTokens: 2, OutputTokens: 9},
+		},
+	}}, e.reports)
+
+	// Flushed within a window, e.g. on shutdown.
+	a.Record(Key{Tenant: "a"}, tokenUsage(1, 1))
+	now = now.Add(10 * time.Minute)
+	e.err = errors.New("boom")
+	require.ErrorContains(t, a.Flush(t.Context()), "boom")
+	require.Len(t, e.reports, 2)
+	require.Equal(t, time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC), e.reports[1].Start)
+	require.Equal(t, now, e.reports[1].End)
+	require.Equal(t, now, a.start)
+}
+
+type fakeMetrics struct {
+	metrics.Metrics
+	usage int
+}
+
+func (f *fakeMetrics) SetRequestModel(internalapi.RequestModel) {}
+
+func (f *fakeMetrics) SetBackend(*filterapi.Backend) {}
+
+func (f *fakeMetrics) RecordTokenUsage(context.Context, metrics.TokenUsage, map[string]string) {
+	f.usage++
+}
+
+type fakeFactory struct{ m *fakeMetrics }
+
+func (f fakeFactory) NewMetrics() metrics.Metrics { return f.m }
+
+func TestMetricsFactory(t *testing.T) {
+	m := &fakeMetrics{}
+	a := NewAggregator(time.Hour, slog.Default())
+	bm := NewMetricsFactory(fakeFactory{m: m}, a, "x-tenant-id").NewMetrics()
+	bm.SetRequestModel("gpt-4o-mini")
+	bm.SetBackend(&filterapi.Backend{Name: "openai"})
+	bm.RecordTokenUsage(t.Context(), *tokenUsage(7, 8), map[string]string{"x-tenant-id": "acme"})
+	require.Equal(t, 1, m.usage)
+	require.Equal(t, map[Key]*Usage{
+		{Tenant: "acme", Model: "gpt-4o-mini", Backend: "openai"}: {
+			Key:      Key{Tenant: "acme", Model: "gpt-4o-mini", Backend: "openai"},
+			Requests: 1, InputTokens: 7, OutputTokens: 8,
+		},
+	}, a.usage)
+}
@@ -0,0 +1,36 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package billing
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+)
+
+// csvHeader is the header row of the CSV usage reports.
+var csvHeader = []string{
+	"window_start", "window_end", "tenant", "model", "backend", "requests",
+	"input_tokens", "cached_input_tokens", "cache_creation_input_tokens", "output_tokens",
+}
+
+// writeCSVReport writes the report as CSV with the window times in RFC 3339.
+func writeCSVReport(w io.Writer, r *Report) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write(csvHeader)
+	start, end := r.Start.UTC().Format(time.RFC3339), r.End.UTC().Format(time.RFC3339)
+	for i := range r.Usage {
+		u := &r.Usage[i]
+		_ = cw.Write([]string{
+			start, end, u.Tenant, u.Model, u.Backend, strconv.FormatUint(u.Requests, 10),
+			strconv.FormatUint(u.InputTokens, 10), strconv.FormatUint(u.CachedInputTokens, 10),
+			strconv.FormatUint(u.CacheCreationInputTokens, 10), strconv.FormatUint(u.OutputTokens, 10),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,174 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package billing
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/internal/json"
+)
+
+var testReport = &Report{
+	Start: time.Date(2025, 1, 1, 11, 0, 0, 0, time.UTC),
+	End:   time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC),
+	Usage: []Usage{
+		{Key: Key{Tenant: "acme", Model: "gpt-4o", Backend: "openai"}, Requests: 2, InputTokens: 13, CachedInputTokens: 2, OutputTokens: 9},
+	},
+}
+
+func TestFileExporter(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, (&FileExporter{Dir: dir}).Export(t.Context(), testReport))
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, "usage-20250101T110000Z-20250101T120000Z.csv", entries[0].Name())
+	content, err := os.ReadFile(filepath.Join(dir, entries[0].Name()))
+	require.NoError(t, err)
+	require.Equal(t, `window_start,window_end,tenant,model,backend,requests,input_tokens,cached_input_tokens,cache_creation_input_tokens,output_tokens
+2025-01-01T11:00:00Z,2025-01-01T12:00:00Z,acme,gpt-4o,openai,2,13,2,0,9
+`, string(content))
+
+	// The reports of the replicas sharing the directory don't overwrite each other.
+	require.NoError(t, (&FileExporter{Dir: dir, Instance: "envoy-default-a"}).Export(t.Context(), testReport))
+	require.NoError(t, (&FileExporter{Dir: dir, Instance: "envoy-default-b"}).Export(t.Context(), testReport))
+	entries, err = os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	require.Equal(t, "usage-20250101T110000Z-20250101T120000Z-envoy-default-a.csv", entries[0].Name())
+	require.Equal(t, "usage-20250101T110000Z-20250101T120000Z-envoy-default-b.csv", entries[1].Name())
+
+	require.NoError(t, (&FileExporter{Dir: dir, Format: ReportFormatParquet}).Export(t.Context(), testReport))
+	rows, err := parquet.ReadFile[parquetRow](filepath.Join(dir, "usage-20250101T110000Z-20250101T120000Z.parquet"))
+	require.NoError(t, err)
+	require.Equal(t, []parquetRow{{
+		WindowStart: testReport.Start, WindowEnd: testReport.End, Tenant: "acme", Model: "gpt-4o", Backend: "openai",
+		Requests: 2, InputTokens: 13, CachedInputTokens: 2, OutputTokens: 9,
+	}}, rows)
+
+	require.ErrorContains(t, (&FileExporter{Dir: filepath.Join(dir, "missing")}).Export(t.Context(), testReport),
+		"failed to create the usage report")
+	require.ErrorContains(t, (&FileExporter{Dir: dir, Format: "json"}).Export(t.Context(), testReport),
+		`failed to write the usage report: unsupported report format "json"`)
+}
+
+func TestObjectStorageExporter(t *testing.T) {
+	var path, contentType string
+	var body []byte
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPut, r.Method)
+		require.True(t, strings.HasPrefix(r.Header.Get("Authorization"),
+			"AWS4-HMAC-SHA256 Credential=AKID/20"), r.Header.Get("Authorization"))
+		require.Contains(t, r.Header.Get("Authorization"), "/auto/s3/aws4_request")
+		body, _ = io.ReadAll(r.Body)
+		hash := sha256.Sum256(body)
+		require.Equal(t, hex.EncodeToString(hash[:]), r.Header.Get("X-Amz-Content-Sha256"))
+		path, contentType = r.URL.Path, r.Header.Get("Content-Type")
+		if strings.Contains(r.URL.Path, "denied") {
+			http.Error(w, "AccessDenied", http.StatusForbidden)
+		}
+	}))
+	defer s.Close()
+
+	e := &ObjectStorageExporter{
+		Bucket:   "billing",
+		Prefix:   "usage/",
+		Region:   "auto",
+		Endpoint: s.URL + "/",
+		Instance: "envoy-default-a",
+		Format:   ReportFormatParquet,
+		Credentials: aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+			return aws.Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret"}, nil
+		}),
+		Client: s.Client(),
+	}
+	require.NoError(t, e.Export(t.Context(), testReport))
+	require.Equal(t, "/billing/usage/usage-20250101T110000Z-20250101T120000Z-envoy-default-a.parquet", path)
+	require.Equal(t, "application/vnd.apache.parquet", contentType)
+	rows, err := parquet.Read[parquetRow](bytes.NewReader(body), int64(len(body)))
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	require.Equal(t, uint64(13), rows[0].InputTokens)
+
+	e.Format = ""
+	require.NoError(t, e.Export(t.Context(), testReport))
+	require.Equal(t, "/billing/usage/usage-20250101T110000Z-20250101T120000Z-envoy-default-a.csv", path)
+	require.Equal(t, "text/csv", contentType)
+
+	e.Prefix = "denied/"
+	require.ErrorContains(t, e.Export(t.Context(), testReport), "status 403: AccessDenied")
+
+	// Amazon S3 is addressed by the virtual-hosted endpoint of the region.
+	e.Endpoint, e.Region = "", "us-east-1"
+	require.Equal(t, "https://billing.s3.us-east-1.amazonaws.com/usage/usage%20report.csv", e.objectURL("usage/usage report.csv"))
+}
+
+func TestMeteringExporter(t *testing.T) {
+	var body []byte
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "application/cloudevents-batch+json", r.Header.Get("Content-Type"))
+		require.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		body, _ = io.ReadAll(r.Body)
+		if r.URL.Path == "/fail" {
+			http.Error(w, "invalid event", http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer s.Close()
+
+	// The ID is derived from the instance, the window and the key, so it is stable across the pushes.
+	id := sha256.Sum256([]byte("\x002025-01-01T11:00:00Z\x002025-01-01T12:00:00Z\x00acme\x00gpt-4o\x00openai"))
+	e := &MeteringExporter{URL: s.URL + "/api/v1/events", Token: "token", Client: s.Client()}
+	require.NoError(t, e.Export(t.Context(), testReport))
+	require.JSONEq(t, `[{
+		"specversion": "1.0",
+		"id": "`+hex.EncodeToString(id[:16])+`",
+		"source": "envoy-ai-gateway",
+		"type": "ai-gateway.token-usage",
+		"subject": "acme",
+		"time": "2025-01-01T12:00:00Z",
+		"data": {
+			"model": "gpt-4o",
+			"backend": "openai",
+			"window_start": "2025-01-01T11:00:00Z",
+			"window_end": "2025-01-01T12:00:00Z",
+			"requests": 2,
+			"input_tokens": 13,
+			"cached_input_tokens": 2,
+			"cache_creation_input_tokens": 0,
+			"output_tokens": 9
+		}
+	}]`, string(body))
+
+	// The events of the other replicas have their own source and IDs, so they are not deduplicated.
+	e.Instance = "envoy-default-a"
+	require.NoError(t, e.Export(t.Context(), testReport))
+	var events []meteringEvent
+	require.NoError(t, json.Unmarshal(body, &events))
+	require.Len(t, events, 1)
+	instanceID := sha256.Sum256([]byte("envoy-default-a\x002025-01-01T11:00:00Z\x002025-01-01T12:00:00Z\x00acme\x00gpt-4o\x00openai"))
+	require.Equal(t, hex.EncodeToString(instanceID[:16]), events[0].ID)
+	require.Equal(t, "envoy-ai-gateway/envoy-default-a", events[0].Source)
+
+	e.URL = s.URL + "/fail"
+	require.ErrorContains(t, e.Export(t.Context(), testReport), "status 400: invalid event")
+}
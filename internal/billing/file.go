@@ -0,0 +1,77 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package billing
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileExporter writes each report to a file named usage-<window start>-<window end>-<instance>.<format> in Dir, or
+// usage-<window start>-<window end>.<format> without an Instance.
+//
+// The file is written to a temporary file first and renamed, so the collectors of the directory, e.g. a sidecar
+// uploading the reports to an object storage, never see a partial report.
+type FileExporter struct {
+	Dir string
+	// Instance identifies the instance aggregating the usage, e.g. the pod name. Each replica of the external
+	// processor only aggregates its own share of the usage, so it must be unique among the replicas writing to the
+	// same directory for their reports not to overwrite each other.
+	Instance string
+	// Format is the format of the reports. Defaults to ReportFormatCSV.
+	Format ReportFormat
+}
+
+// Export implements [Exporter.Export].
+func (e *FileExporter) Export(_ context.Context, r *Report) error {
+	name := reportName(r.Start, r.End, e.Instance, e.Format)
+	f, err := os.CreateTemp(e.Dir, "."+name+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create the usage report: %w", err)
+	}
+	defer func() {
+		// No-op once renamed.
+		_ = os.Remove(f.Name())
+	}()
+
+	// CreateTemp creates the file only readable by the owner, but the collectors may run as another user.
+	if err = f.Chmod(0o644); err != nil { // #nosec G302 - the reports are not secrets.
+		_ = f.Close()
+		return fmt.Errorf("failed to change the usage report permission: %w", err)
+	}
+
+	w := bufio.NewWriter(f)
+	if err = encodeReport(w, e.Format, r); err == nil {
+		err = w.Flush()
+	}
+	if err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write the usage report: %w", err)
+	}
+	if err = f.Close(); err != nil {
+		return fmt.Errorf("failed to write the usage report: %w", err)
+	}
+	if err = os.Rename(f.Name(), filepath.Join(e.Dir, name)); err != nil {
+		return fmt.Errorf("failed to rename the usage report: %w", err)
+	}
+	return nil
+}
+
+// reportNameLayout is the layout of the window times in the names of the usage reports.
+const reportNameLayout = "20060102T150405Z"
+
+// reportName returns the name of the usage report of the window and the instance in the format.
+func reportName(start, end time.Time, instance string, format ReportFormat) string {
+	name := "usage-" + start.UTC().Format(reportNameLayout) + "-" + end.UTC().Format(reportNameLayout)
+	if instance != "" {
+		name += "-" + instance
+	}
+	return name + "." + string(format.orDefault())
+}
@@ -0,0 +1,177 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package billing
+
+import (
+	"cmp"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"slices"
+	"time"
+)
+
+// Ledger stores the usage reports of all the instances of the gateway, so that the usage API serves the usage of
+// the whole gateway rather than the one of a single replica.
+//
+// The usage is stored per writer, i.e. per run of an instance, and window. A writer stores the usage of the window in
+// progress several times as it grows, so the stored usage of a Key is only replaced by a larger one, which makes the
+// writes idempotent and their order irrelevant.
+type Ledger interface {
+	// Put stores the usage of the report written by the writer.
+	Put(ctx context.Context, writer LedgerWriter, r *Report) error
+	// Reports returns the usage of all the writers whose window starts in [start, end), one report per window start
+	// sorted by the window start. The usage of the runs of an instance is summed, and its Instance is set.
+	Reports(ctx context.Context, start, end time.Time) ([]Report, error)
+	// Close releases the resources of the ledger.
+	Close() error
+}
+
+// LedgerWriter identifies the writer of the usage in a Ledger.
+type LedgerWriter struct {
+	// Instance identifies the instance aggregating the usage, e.g. the pod name.
+	Instance string
+	// Run identifies the run of the instance, so that the usage of a restarted instance is added to the usage of its
+	// previous runs in the same window rather than replacing it.
+	Run string
+}
+
+// LedgerConfig is the configuration of a Ledger.
+type LedgerConfig struct {
+	// URL locates the ledger. The scheme selects the implementation:
+	//   - "memory:" keeps the usage in the memory of the process, which is only suitable for a single replica.
+	//   - "redis://" and "rediss://" store it in Redis, e.g. "redis://redis.default:6379/0".
+	//   - "postgres://" and "postgresql://" store it in a PostgreSQL table created if missing.
+	URL string
+	// Password overrides the password of the URL if set, so that it can be passed separately from the URL.
+	Password string
+	// Retention is the duration after the end of a window its usage is deleted.
+	Retention time.Duration
+}
+
+// NewLedger returns the Ledger configured by the config.
+func NewLedger(ctx context.Context, config LedgerConfig) (Ledger, error) {
+	if config.Retention <= 0 {
+		return nil, fmt.Errorf("retention must be positive")
+	}
+	u, err := url.Parse(config.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid usage ledger URL: %w", err)
+	}
+	if config.Password != "" && u.Host != "" {
+		u.User = url.UserPassword(u.User.Username(), config.Password)
+	}
+	switch u.Scheme {
+	case "memory":
+		return newMemoryLedger(config.Retention), nil
+	case "redis", "rediss":
+		return newRedisLedger(u.String(), config.Retention)
+	case "postgres", "postgresql":
+		return newPostgresLedger(ctx, u.String(), config.Retention)
+	default:
+		return nil, fmt.Errorf("unsupported usage ledger scheme %q", u.Scheme)
+	}
+}
+
+// LedgerExporter implements [Exporter] by storing the reports in a Ledger.
+type LedgerExporter struct {
+	ledger Ledger
+	writer LedgerWriter
+}
+
+// NewLedgerExporter returns a new LedgerExporter of the instance, which must be unique among the replicas.
+func NewLedgerExporter(ledger Ledger, instance string) *LedgerExporter {
+	run := make([]byte, 8)
+	_, _ = rand.Read(run)
+	return &LedgerExporter{ledger: ledger, writer: LedgerWriter{Instance: instance, Run: hex.EncodeToString(run)}}
+}
+
+// Export implements [Exporter.Export].
+func (e *LedgerExporter) Export(ctx context.Context, r *Report) error {
+	if err := e.ledger.Put(ctx, e.writer, r); err != nil {
+		return fmt.Errorf("failed to store the usage in the ledger: %w", err)
+	}
+	return nil
+}
+
+// SyncCurrent stores the usage of the current window of the aggregator at the interval until the context is done,
+// so that the usage API serves the windows in progress of all the instances. The Aggregator exports the complete
+// window at its end.
+func (e *LedgerExporter) SyncCurrent(ctx context.Context, a *Aggregator, interval time.Duration, logger *slog.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if r := a.Current(); len(r.Usage) > 0 {
+			if err := e.Export(ctx, &r); err != nil {
+				logger.Error("failed to store the current usage", slog.String("error", err.Error()))
+			}
+		}
+	}
+}
+
+// ledgerEntry is the usage of a Key in a window written by a writer, as stored in a Ledger.
+type ledgerEntry struct {
+	writer LedgerWriter
+	start  time.Time
+	end    time.Time
+	usage  Usage
+}
+
+// supersedes returns true if the entry is a later write of the same usage than the stored one. The usage of a Key
+// only grows within a window, and the end of the window is the time of the write until the window is complete.
+func (e *ledgerEntry) supersedes(stored *ledgerEntry) bool {
+	return e.usage.Requests > stored.usage.Requests ||
+		(e.usage.Requests == stored.usage.Requests && e.end.After(stored.end))
+}
+
+// ledgerReports sums the usage of the entries per window start and instance into the reports sorted by the window
+// start.
+func ledgerReports(entries []ledgerEntry) []Report {
+	type windowKey struct {
+		start time.Time
+		key   Key
+	}
+	windows := make(map[int64]*Report)
+	groups := make(map[windowKey]*Usage)
+	for i := range entries {
+		e := &entries[i]
+		r, ok := windows[e.start.Unix()]
+		if !ok {
+			r = &Report{Start: e.start.UTC(), End: e.end.UTC()}
+			windows[e.start.Unix()] = r
+		}
+		if e.end.After(r.End) {
+			r.End = e.end.UTC()
+		}
+		key := e.usage.Key
+		key.Instance = e.writer.Instance
+		g, ok := groups[windowKey{start: r.Start, key: key}]
+		if !ok {
+			g = &Usage{Key: key}
+			groups[windowKey{start: r.Start, key: key}] = g
+		}
+		g.add(&e.usage)
+	}
+	for k, g := range groups {
+		r := windows[k.start.Unix()]
+		r.Usage = append(r.Usage, *g)
+	}
+	reports := make([]Report, 0, len(windows))
+	for _, r := range windows {
+		sortUsage(r.Usage)
+		reports = append(reports, *r)
+	}
+	slices.SortFunc(reports, func(x, y Report) int { return cmp.Compare(x.Start.Unix(), y.Start.Unix()) })
+	return reports
+}
@@ -0,0 +1,69 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package billing
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// memoryLedgerKey identifies a ledgerEntry in the memoryLedger.
+type memoryLedgerKey struct {
+	writer LedgerWriter
+	start  int64
+	key    Key
+}
+
+// memoryLedger implements [Ledger] in the memory of the process.
+type memoryLedger struct {
+	retention time.Duration
+	now       func() time.Time
+
+	mu      sync.Mutex
+	entries map[memoryLedgerKey]ledgerEntry
+}
+
+func newMemoryLedger(retention time.Duration) *memoryLedger {
+	return &memoryLedger{retention: retention, now: time.Now, entries: make(map[memoryLedgerKey]ledgerEntry)}
+}
+
+// Put implements [Ledger.Put].
+func (m *memoryLedger) Put(_ context.Context, writer LedgerWriter, r *Report) error {
+	expired := m.now().Add(-m.retention)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for k, e := range m.entries {
+		if e.end.Before(expired) {
+			delete(m.entries, k)
+		}
+	}
+	for _, u := range r.Usage {
+		u.Instance = ""
+		e := ledgerEntry{writer: writer, start: r.Start, end: r.End, usage: u}
+		k := memoryLedgerKey{writer: writer, start: r.Start.Unix(), key: u.Key}
+		if stored, ok := m.entries[k]; !ok || e.supersedes(&stored) {
+			m.entries[k] = e
+		}
+	}
+	return nil
+}
+
+// Reports implements [Ledger.Reports].
+func (m *memoryLedger) Reports(_ context.Context, start, end time.Time) ([]Report, error) {
+	m.mu.Lock()
+	var entries []ledgerEntry
+	for _, e := range m.entries {
+		if !e.start.Before(start) && e.start.Before(end) {
+			entries = append(entries, e)
+		}
+	}
+	m.mu.Unlock()
+	return ledgerReports(entries), nil
+}
+
+// Close implements [Ledger.Close].
+func (m *memoryLedger) Close() error { return nil }
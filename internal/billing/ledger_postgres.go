@@ -0,0 +1,143 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package billing
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// postgresLedgerCreateTable creates the table of the usage. The rows of the expired windows are deleted
+	// periodically.
+	postgresLedgerCreateTable = `CREATE TABLE IF NOT EXISTS aigw_usage (
+	window_start TIMESTAMPTZ NOT NULL,
+	window_end TIMESTAMPTZ NOT NULL,
+	instance TEXT NOT NULL,
+	run TEXT NOT NULL,
+	tenant TEXT NOT NULL,
+	model TEXT NOT NULL,
+	backend TEXT NOT NULL,
+	requests BIGINT NOT NULL,
+	input_tokens BIGINT NOT NULL,
+	cached_input_tokens BIGINT NOT NULL,
+	cache_creation_input_tokens BIGINT NOT NULL,
+	output_tokens BIGINT NOT NULL,
+	PRIMARY KEY (window_start, instance, run, tenant, model, backend)
+)`
+	// postgresLedgerPut only replaces the stored usage by a larger one.
+	postgresLedgerPut = `INSERT INTO aigw_usage (window_start, window_end, instance, run, tenant, model, backend,
+	requests, input_tokens, cached_input_tokens, cache_creation_input_tokens, output_tokens)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+ON CONFLICT (window_start, instance, run, tenant, model, backend) DO UPDATE SET
+	window_end = EXCLUDED.window_end,
+	requests = EXCLUDED.requests,
+	input_tokens = EXCLUDED.input_tokens,
+	cached_input_tokens = EXCLUDED.cached_input_tokens,
+	cache_creation_input_tokens = EXCLUDED.cache_creation_input_tokens,
+	output_tokens = EXCLUDED.output_tokens
+WHERE (aigw_usage.requests, aigw_usage.window_end) < (EXCLUDED.requests, EXCLUDED.window_end)`
+	postgresLedgerReports = `SELECT window_start, window_end, instance, run, tenant, model, backend,
+	requests, input_tokens, cached_input_tokens, cache_creation_input_tokens, output_tokens
+FROM aigw_usage WHERE window_start >= $1 AND window_start < $2`
+	postgresLedgerDeleteExpired = `DELETE FROM aigw_usage WHERE window_end <= now() - $1 * interval '1 second'`
+)
+
+// postgresLedgerCleanupInterval is the maximum interval between the deletions of the expired usage.
+const postgresLedgerCleanupInterval = time.Hour
+
+// postgresLedger implements [Ledger] with a PostgreSQL table.
+type postgresLedger struct {
+	pool      *pgxpool.Pool
+	retention time.Duration
+	stop      context.CancelFunc
+	done      chan struct{}
+}
+
+func newPostgresLedger(ctx context.Context, rawURL string, retention time.Duration) (*postgresLedger, error) {
+	pool, err := pgxpool.New(ctx, rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid PostgreSQL URL: %w", err)
+	}
+	if _, err = pool.Exec(ctx, postgresLedgerCreateTable); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("failed to create the usage table: %w", err)
+	}
+	cleanupCtx, stop := context.WithCancel(context.Background())
+	p := &postgresLedger{pool: pool, retention: retention, stop: stop, done: make(chan struct{})}
+	go p.deleteExpired(cleanupCtx, min(retention, postgresLedgerCleanupInterval))
+	return p, nil
+}
+
+// deleteExpired deletes the expired usage at the interval until the context is done.
+func (p *postgresLedger) deleteExpired(ctx context.Context, interval time.Duration) {
+	defer close(p.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// A failure is retried at the next tick.
+			_, _ = p.pool.Exec(ctx, postgresLedgerDeleteExpired, p.retention.Seconds())
+		}
+	}
+}
+
+// Put implements [Ledger.Put].
+func (p *postgresLedger) Put(ctx context.Context, writer LedgerWriter, r *Report) error {
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+	for i := range r.Usage {
+		u := &r.Usage[i]
+		if _, err = tx.Exec(ctx, postgresLedgerPut, r.Start, r.End, writer.Instance, writer.Run, u.Tenant, u.Model,
+			u.Backend, int64(u.Requests), int64(u.InputTokens), int64(u.CachedInputTokens), //nolint:gosec
+			int64(u.CacheCreationInputTokens), int64(u.OutputTokens)); err != nil { //nolint:gosec
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// Reports implements [Ledger.Reports].
+func (p *postgresLedger) Reports(ctx context.Context, start, end time.Time) ([]Report, error) {
+	rows, err := p.pool.Query(ctx, postgresLedgerReports, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var entries []ledgerEntry
+	for rows.Next() {
+		var e ledgerEntry
+		var requests, input, cached, creation, output int64
+		if err = rows.Scan(&e.start, &e.end, &e.writer.Instance, &e.writer.Run, &e.usage.Tenant, &e.usage.Model,
+			&e.usage.Backend, &requests, &input, &cached, &creation, &output); err != nil {
+			return nil, err
+		}
+		e.usage.Requests, e.usage.InputTokens, e.usage.CachedInputTokens = uint64(requests), uint64(input), uint64(cached) //nolint:gosec
+		e.usage.CacheCreationInputTokens, e.usage.OutputTokens = uint64(creation), uint64(output)                          //nolint:gosec
+		entries = append(entries, e)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return ledgerReports(entries), nil
+}
+
+// Close implements [Ledger.Close].
+func (p *postgresLedger) Close() error {
+	p.stop()
+	<-p.done
+	p.pool.Close()
+	return nil
+}
@@ -0,0 +1,175 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package billing
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// redisLedgerWindowsKey is the key of the sorted set of the window starts in Redis, scored by the window start.
+	redisLedgerWindowsKey = "aigw:usage:windows"
+	// redisLedgerWindowKeyPrefix is the prefix of the keys of the hashes of the usage of a window, followed by the
+	// window start in Unix seconds. The fields are the redisLedgerField and the values are the redisLedgerValue.
+	redisLedgerWindowKeyPrefix = "aigw:usage:window:"
+)
+
+// redisLedgerPut stores the usage of a window, only replacing the stored usage of a field by a larger one.
+//
+// KEYS[1] is the hash of the window and KEYS[2] the sorted set of the windows. ARGV[1] is the TTL of the hash in
+// seconds, ARGV[2] the window start, ARGV[3] the window start before which the windows are expired, and the rest
+// are the pairs of the fields and the values.
+var redisLedgerPut = redis.NewScript(`
+local function parse(v)
+	local requests, ended = string.match(v, "^(%d+),%d+,%d+,%d+,%d+,(%d+)$")
+	return tonumber(requests), tonumber(ended)
+end
+for i = 4, #ARGV, 2 do
+	local stored = redis.call("HGET", KEYS[1], ARGV[i])
+	local write = true
+	if stored then
+		local storedRequests, storedEnd = parse(stored)
+		local requests, ended = parse(ARGV[i + 1])
+		write = requests > storedRequests or (requests == storedRequests and ended > storedEnd)
+	end
+	if write then
+		redis.call("HSET", KEYS[1], ARGV[i], ARGV[i + 1])
+	end
+end
+redis.call("EXPIRE", KEYS[1], ARGV[1])
+redis.call("ZADD", KEYS[2], ARGV[2], ARGV[2])
+redis.call("ZREMRANGEBYSCORE", KEYS[2], "-inf", "(" .. ARGV[3])
+return 0
+`)
+
+// redisLedger implements [Ledger] with Redis, where the usage of a window expires with the TTL of its hash.
+type redisLedger struct {
+	client    *redis.Client
+	retention time.Duration
+	now       func() time.Time
+}
+
+func newRedisLedger(rawURL string, retention time.Duration) (*redisLedger, error) {
+	opts, err := redis.ParseURL(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Redis URL: %w", err)
+	}
+	return &redisLedger{client: redis.NewClient(opts), retention: retention, now: time.Now}, nil
+}
+
+// redisLedgerField returns the hash field of the usage of the writer. The parts are escaped so that they can't
+// contain the separator.
+func redisLedgerField(writer LedgerWriter, key Key) string {
+	parts := []string{writer.Instance, writer.Run, key.Tenant, key.Model, key.Backend}
+	for i := range parts {
+		parts[i] = url.QueryEscape(parts[i])
+	}
+	return strings.Join(parts, ":")
+}
+
+// redisLedgerValue returns the hash value of the usage, which ends with the window end in Unix milliseconds.
+func redisLedgerValue(end time.Time, u *Usage) string {
+	return fmt.Sprintf("%d,%d,%d,%d,%d,%d", u.Requests, u.InputTokens, u.CachedInputTokens, u.CacheCreationInputTokens,
+		u.OutputTokens, end.UnixMilli())
+}
+
+// parseRedisLedgerEntry parses the hash field and value of the usage in the window starting at start.
+func parseRedisLedgerEntry(start time.Time, field, value string) (ledgerEntry, error) {
+	parts := strings.Split(field, ":")
+	if len(parts) != 5 {
+		return ledgerEntry{}, fmt.Errorf("invalid usage ledger field %q", field)
+	}
+	for i := range parts {
+		var err error
+		if parts[i], err = url.QueryUnescape(parts[i]); err != nil {
+			return ledgerEntry{}, fmt.Errorf("invalid usage ledger field %q: %w", field, err)
+		}
+	}
+	values := strings.Split(value, ",")
+	if len(values) != 6 {
+		return ledgerEntry{}, fmt.Errorf("invalid usage ledger value %q", value)
+	}
+	numbers := make([]uint64, len(values))
+	for i := range values {
+		var err error
+		if numbers[i], err = strconv.ParseUint(values[i], 10, 64); err != nil {
+			return ledgerEntry{}, fmt.Errorf("invalid usage ledger value %q: %w", value, err)
+		}
+	}
+	return ledgerEntry{
+		writer: LedgerWriter{Instance: parts[0], Run: parts[1]},
+		start:  start,
+		end:    time.UnixMilli(int64(numbers[5])).UTC(), //nolint:gosec
+		usage: Usage{
+			Key:                      Key{Tenant: parts[2], Model: parts[3], Backend: parts[4]},
+			Requests:                 numbers[0],
+			InputTokens:              numbers[1],
+			CachedInputTokens:        numbers[2],
+			CacheCreationInputTokens: numbers[3],
+			OutputTokens:             numbers[4],
+		},
+	}, nil
+}
+
+// Put implements [Ledger.Put].
+func (r *redisLedger) Put(ctx context.Context, writer LedgerWriter, report *Report) error {
+	now := r.now()
+	ttl := max(report.End.Add(r.retention).Sub(now), time.Second)
+	expired := now.Add(-r.retention - report.End.Sub(report.Start))
+	args := []any{int64(ttl.Seconds()), report.Start.Unix(), expired.Unix()}
+	for i := range report.Usage {
+		args = append(args, redisLedgerField(writer, report.Usage[i].Key), redisLedgerValue(report.End, &report.Usage[i]))
+	}
+	keys := []string{redisLedgerWindowKeyPrefix + strconv.FormatInt(report.Start.Unix(), 10), redisLedgerWindowsKey}
+	return redisLedgerPut.Run(ctx, r.client, keys, args...).Err()
+}
+
+// Reports implements [Ledger.Reports].
+func (r *redisLedger) Reports(ctx context.Context, start, end time.Time) ([]Report, error) {
+	windows, err := r.client.ZRangeByScore(ctx, redisLedgerWindowsKey, &redis.ZRangeBy{
+		Min: strconv.FormatInt(start.Unix(), 10),
+		Max: "(" + strconv.FormatInt(end.Unix(), 10),
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+	pipe := r.client.Pipeline()
+	cmds := make([]*redis.MapStringStringCmd, len(windows))
+	for i, w := range windows {
+		cmds[i] = pipe.HGetAll(ctx, redisLedgerWindowKeyPrefix+w)
+	}
+	if len(windows) > 0 {
+		if _, err = pipe.Exec(ctx); err != nil {
+			return nil, err
+		}
+	}
+	var entries []ledgerEntry
+	for i, w := range windows {
+		windowStart, parseErr := strconv.ParseInt(w, 10, 64)
+		if parseErr != nil {
+			return nil, fmt.Errorf("invalid usage ledger window %q: %w", w, parseErr)
+		}
+		// The hash of an expired window is gone while its start is still in the sorted set.
+		for field, value := range cmds[i].Val() {
+			e, parseErr := parseRedisLedgerEntry(time.Unix(windowStart, 0).UTC(), field, value)
+			if parseErr != nil {
+				return nil, parseErr
+			}
+			entries = append(entries, e)
+		}
+	}
+	return ledgerReports(entries), nil
+}
+
+// Close implements [Ledger.Close].
+func (r *redisLedger) Close() error { return r.client.Close() }
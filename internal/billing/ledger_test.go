@@ -0,0 +1,144 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package billing
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewLedger(t *testing.T) {
+	l, err := NewLedger(t.Context(), LedgerConfig{URL: "memory:", Retention: time.Hour})
+	require.NoError(t, err)
+	require.IsType(t, &memoryLedger{}, l)
+
+	l, err = NewLedger(t.Context(), LedgerConfig{URL: "redis://redis.default:6379/0", Password: "secret", Retention: time.Hour})
+	require.NoError(t, err)
+	require.IsType(t, &redisLedger{}, l)
+	require.Equal(t, "secret", l.(*redisLedger).client.Options().Password)
+	require.NoError(t, l.Close())
+
+	for _, tc := range []struct {
+		name   string
+		config LedgerConfig
+		expErr string
+	}{
+		{name: "no retention", config: LedgerConfig{URL: "memory:"}, expErr: "retention must be positive"},
+		{name: "unknown scheme", config: LedgerConfig{URL: "mysql://db", Retention: time.Hour}, expErr: `unsupported usage ledger scheme "mysql"`},
+		{name: "invalid URL", config: LedgerConfig{URL: "redis://%zz", Retention: time.Hour}, expErr: "invalid usage ledger URL"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewLedger(t.Context(), tc.config)
+			require.ErrorContains(t, err, tc.expErr)
+		})
+	}
+}
+
+func TestMemoryLedger(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	now := start.Add(time.Hour)
+	m := newMemoryLedger(24 * time.Hour)
+	m.now = func() time.Time { return now }
+	key := Key{Tenant: "acme", Model: "gpt-4o", Backend: "openai"}
+	report := func(end time.Time, requests uint64) *Report {
+		return &Report{Start: start, End: end, Usage: []Usage{{Key: key, Requests: requests, InputTokens: 10 * requests, OutputTokens: requests}}}
+	}
+	run1 := LedgerWriter{Instance: "envoy-default-a", Run: "1"}
+
+	// The complete window replaces the snapshot of the window in progress, but not the other way around.
+	require.NoError(t, m.Put(ctx, run1, report(start.Add(10*time.Minute), 1)))
+	require.NoError(t, m.Put(ctx, run1, report(start.Add(time.Hour), 3)))
+	require.NoError(t, m.Put(ctx, run1, report(start.Add(20*time.Minute), 2)))
+	reports, err := m.Reports(ctx, start, now)
+	require.NoError(t, err)
+	instanceKey := key
+	instanceKey.Instance = "envoy-default-a"
+	require.Equal(t, []Report{{Start: start, End: start.Add(time.Hour), Usage: []Usage{
+		{Key: instanceKey, Requests: 3, InputTokens: 30, OutputTokens: 3},
+	}}}, reports)
+
+	// The usage of the runs of an instance is summed, and the instances are kept apart.
+	require.NoError(t, m.Put(ctx, LedgerWriter{Instance: "envoy-default-a", Run: "2"}, report(start.Add(time.Hour), 1)))
+	require.NoError(t, m.Put(ctx, LedgerWriter{Instance: "envoy-default-b", Run: "1"}, report(start.Add(time.Hour), 2)))
+	reports, err = m.Reports(ctx, start, now)
+	require.NoError(t, err)
+	otherKey := key
+	otherKey.Instance = "envoy-default-b"
+	require.Equal(t, []Report{{Start: start, End: start.Add(time.Hour), Usage: []Usage{
+		{Key: instanceKey, Requests: 4, InputTokens: 40, OutputTokens: 4},
+		{Key: otherKey, Requests: 2, InputTokens: 20, OutputTokens: 2},
+	}}}, reports)
+
+	reports, err = m.Reports(ctx, start.Add(time.Hour), now.Add(time.Hour))
+	require.NoError(t, err)
+	require.Empty(t, reports)
+
+	// The usage is deleted after the retention.
+	now = now.Add(25 * time.Hour)
+	start = now.Truncate(time.Hour)
+	require.NoError(t, m.Put(ctx, run1, report(now, 1)))
+	require.Len(t, m.entries, 1)
+}
+
+func TestRedisLedgerEntry(t *testing.T) {
+	start := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	end := start.Add(30 * time.Minute)
+	writer := LedgerWriter{Instance: "envoy-default-a", Run: "1"}
+	u := Usage{
+		Key:      Key{Tenant: "acme:eu", Model: "gpt-4o", Backend: "openai"},
+		Requests: 2, InputTokens: 13, CachedInputTokens: 2, CacheCreationInputTokens: 1, OutputTokens: 9,
+	}
+	field, value := redisLedgerField(writer, u.Key), redisLedgerValue(end, &u)
+	require.Equal(t, "envoy-default-a:1:acme%3Aeu:gpt-4o:openai", field)
+	require.Equal(t, "2,13,2,1,9,1735727400000", value)
+
+	e, err := parseRedisLedgerEntry(start, field, value)
+	require.NoError(t, err)
+	require.Equal(t, ledgerEntry{writer: writer, start: start, end: end, usage: u}, e)
+
+	_, err = parseRedisLedgerEntry(start, "envoy-default-a:1", value)
+	require.ErrorContains(t, err, "invalid usage ledger field")
+	_, err = parseRedisLedgerEntry(start, field, "2,13,x,1,9,1735727400000")
+	require.ErrorContains(t, err, "invalid usage ledger value")
+}
+
+func TestLedgerExporter(t *testing.T) {
+	m := newMemoryLedger(time.Hour)
+	e := NewLedgerExporter(m, "envoy-default-a")
+	require.Equal(t, "envoy-default-a", e.writer.Instance)
+	require.Len(t, e.writer.Run, 16)
+	require.NotEqual(t, e.writer.Run, NewLedgerExporter(m, "envoy-default-a").writer.Run)
+
+	// The current window is stored while in progress.
+	a := NewAggregator(time.Hour, slog.Default(), e)
+	a.Record(Key{Tenant: "acme", Model: "gpt-4o", Backend: "openai"}, tokenUsage(7, 3))
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		e.SyncCurrent(ctx, a, 10*time.Millisecond, slog.Default())
+	}()
+	require.Eventually(t, func() bool {
+		reports, err := m.Reports(t.Context(), a.Current().Start, time.Now().Add(time.Hour))
+		return err == nil && len(reports) == 1 && reports[0].Usage[0].Requests == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	cancel()
+	<-done
+
+	// The complete window is stored by the Aggregator.
+	a.Record(Key{Tenant: "acme", Model: "gpt-4o", Backend: "openai"}, tokenUsage(7, 3))
+	start := a.Current().Start
+	require.NoError(t, a.Flush(t.Context()))
+	reports, err := m.Reports(t.Context(), start, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	require.Len(t, reports, 1)
+	require.Equal(t, uint64(2), reports[0].Usage[0].Requests)
+}
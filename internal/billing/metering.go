@@ -0,0 +1,125 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package billing
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/envoyproxy/ai-gateway/internal/json"
+)
+
+const (
+	// MeteringEventType is the CloudEvents type of the usage events pushed by the MeteringExporter.
+	MeteringEventType = "ai-gateway.token-usage"
+	// meteringEventSource is the CloudEvents source of the usage events, followed by the instance if any.
+	meteringEventSource = "envoy-ai-gateway"
+)
+
+// MeteringExporter pushes each report as a batch of CloudEvents, one event per Usage with the tenant as the subject,
+// to the ingestion endpoint of a metering API such as OpenMeter (https://openmeter.io).
+//
+// The ID of an event is derived from the Instance, the window and the Key, so the metering APIs deduplicating the
+// events by ID count a report pushed twice only once, while the reports of the other instances are all counted.
+type MeteringExporter struct {
+	// Instance identifies the instance aggregating the usage, e.g. the pod name. Each replica of the external
+	// processor only aggregates its own share of the usage, so it must be unique among the replicas.
+	Instance string
+	// URL is the URL of the event ingestion endpoint, e.g. https://openmeter.cloud/api/v1/events.
+	URL string
+	// Token is the bearer token of the requests. Optional.
+	Token string
+	// Client is the HTTP client of the requests.
+	Client *http.Client
+}
+
+// meteringEvent is a CloudEvent in the JSON format.
+type meteringEvent struct {
+	SpecVersion string            `json:"specversion"`
+	ID          string            `json:"id"`
+	Source      string            `json:"source"`
+	Type        string            `json:"type"`
+	Subject     string            `json:"subject"`
+	Time        time.Time         `json:"time"`
+	Data        meteringEventData `json:"data"`
+}
+
+// meteringEventData is the data of a usage event.
+type meteringEventData struct {
+	Model                    string `json:"model"`
+	Backend                  string `json:"backend"`
+	WindowStart              string `json:"window_start"`
+	WindowEnd                string `json:"window_end"`
+	Requests                 uint64 `json:"requests"`
+	InputTokens              uint64 `json:"input_tokens"`
+	CachedInputTokens        uint64 `json:"cached_input_tokens"`
+	CacheCreationInputTokens uint64 `json:"cache_creation_input_tokens"`
+	OutputTokens             uint64 `json:"output_tokens"`
+}
+
+// Export implements [Exporter.Export].
+func (e *MeteringExporter) Export(ctx context.Context, r *Report) error {
+	start, end := r.Start.UTC().Format(time.RFC3339), r.End.UTC().Format(time.RFC3339)
+	source := meteringEventSource
+	if e.Instance != "" {
+		source += "/" + e.Instance
+	}
+	events := make([]meteringEvent, 0, len(r.Usage))
+	for i := range r.Usage {
+		u := &r.Usage[i]
+		id := sha256.Sum256(fmt.Appendf(nil, "%s\x00%s\x00%s\x00%s\x00%s\x00%s", e.Instance, start, end, u.Tenant, u.Model, u.Backend))
+		events = append(events, meteringEvent{
+			SpecVersion: "1.0",
+			ID:          hex.EncodeToString(id[:16]),
+			Source:      source,
+			Type:        MeteringEventType,
+			Subject:     u.Tenant,
+			Time:        r.End.UTC(),
+			Data: meteringEventData{
+				Model:                    u.Model,
+				Backend:                  u.Backend,
+				WindowStart:              start,
+				WindowEnd:                end,
+				Requests:                 u.Requests,
+				InputTokens:              u.InputTokens,
+				CachedInputTokens:        u.CachedInputTokens,
+				CacheCreationInputTokens: u.CacheCreationInputTokens,
+				OutputTokens:             u.OutputTokens,
+			},
+		})
+	}
+	body, err := json.Marshal(events)
+	if err != nil {
+		return fmt.Errorf("failed to marshal the usage events: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create the metering request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/cloudevents-batch+json")
+	if e.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+e.Token)
+	}
+	resp, err := e.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to push the usage events: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("failed to push the usage events: status %d: %s", resp.StatusCode, msg)
+	}
+	return nil
+}
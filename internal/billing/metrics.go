@@ -0,0 +1,58 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package billing
+
+import (
+	"context"
+
+	"github.com/envoyproxy/ai-gateway/internal/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+	"github.com/envoyproxy/ai-gateway/internal/metrics"
+)
+
+// NewMetricsFactory returns a metrics.Factory whose Metrics record the token usage in the aggregator in addition to
+// the metrics of the given factory. tenantHeader is the request header holding the tenant of the request.
+func NewMetricsFactory(f metrics.Factory, a *Aggregator, tenantHeader string) metrics.Factory {
+	return &metricsFactory{Factory: f, aggregator: a, tenantHeader: tenantHeader}
+}
+
+type metricsFactory struct {
+	metrics.Factory
+	aggregator   *Aggregator
+	tenantHeader string
+}
+
+// NewMetrics implements [metrics.Factory.NewMetrics].
+func (f *metricsFactory) NewMetrics() metrics.Metrics {
+	return &billingMetrics{Metrics: f.Factory.NewMetrics(), aggregator: f.aggregator, tenantHeader: f.tenantHeader}
+}
+
+// billingMetrics records the token usage of a request in the aggregator.
+type billingMetrics struct {
+	metrics.Metrics
+	aggregator   *Aggregator
+	tenantHeader string
+	requestModel internalapi.RequestModel
+	backend      string
+}
+
+// SetRequestModel implements [metrics.Metrics.SetRequestModel].
+func (b *billingMetrics) SetRequestModel(requestModel internalapi.RequestModel) {
+	b.requestModel = requestModel
+	b.Metrics.SetRequestModel(requestModel)
+}
+
+// SetBackend implements [metrics.Metrics.SetBackend].
+func (b *billingMetrics) SetBackend(backend *filterapi.Backend) {
+	b.backend = backend.Name
+	b.Metrics.SetBackend(backend)
+}
+
+// RecordTokenUsage implements [metrics.Metrics.RecordTokenUsage].
+func (b *billingMetrics) RecordTokenUsage(ctx context.Context, usage metrics.TokenUsage, requestHeaders map[string]string) {
+	b.aggregator.Record(Key{Tenant: requestHeaders[b.tenantHeader], Model: b.requestModel, Backend: b.backend}, &usage)
+	b.Metrics.RecordTokenUsage(ctx, usage, requestHeaders)
+}
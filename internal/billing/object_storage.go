@@ -0,0 +1,97 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package billing
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+)
+
+// ObjectStorageExporter uploads each report to an S3 compatible object storage, as the object named as the reports
+// of the FileExporter under Prefix in Bucket. The requests are signed with AWS Signature Version 4, so this also
+// uploads to the S3 compatible APIs of the other object storages, e.g. Google Cloud Storage with an HMAC key or MinIO.
+//
+// The object of a report is overwritten when uploaded twice, so a report is never counted twice.
+type ObjectStorageExporter struct {
+	// Bucket is the name of the bucket.
+	Bucket string
+	// Prefix is prepended to the names of the objects, e.g. "usage/". Optional.
+	Prefix string
+	// Region is the region of the bucket, used to sign the requests.
+	Region string
+	// Endpoint is the URL of the S3 compatible API, e.g. https://storage.googleapis.com, where the objects are
+	// addressed by path. Defaults to the virtual-hosted endpoint of Amazon S3 in the Region.
+	Endpoint string
+	// Instance identifies the instance aggregating the usage, e.g. the pod name. It must be unique among the
+	// replicas uploading to the same Prefix for their reports not to overwrite each other.
+	Instance string
+	// Format is the format of the reports. Defaults to ReportFormatCSV.
+	Format ReportFormat
+	// Credentials provides the credentials the requests are signed with.
+	Credentials aws.CredentialsProvider
+	// Client is the HTTP client of the requests.
+	Client *http.Client
+}
+
+// Export implements [Exporter.Export].
+func (e *ObjectStorageExporter) Export(ctx context.Context, r *Report) error {
+	var body bytes.Buffer
+	if err := encodeReport(&body, e.Format, r); err != nil {
+		return fmt.Errorf("failed to encode the usage report: %w", err)
+	}
+	key := e.Prefix + reportName(r.Start, r.End, e.Instance, e.Format)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, e.objectURL(key), bytes.NewReader(body.Bytes()))
+	if err != nil {
+		return fmt.Errorf("failed to create the usage report upload request: %w", err)
+	}
+	req.Header.Set("Content-Type", e.Format.contentType())
+	payloadHash := sha256.Sum256(body.Bytes())
+	// Amazon S3 requires the hash of the payload in a header as well.
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(payloadHash[:]))
+
+	credentials, err := e.Credentials.Retrieve(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve the object storage credentials: %w", err)
+	}
+	if err = v4.NewSigner().SignHTTP(ctx, credentials, req, hex.EncodeToString(payloadHash[:]), "s3", e.Region, time.Now()); err != nil {
+		return fmt.Errorf("failed to sign the usage report upload request: %w", err)
+	}
+
+	resp, err := e.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to upload the usage report: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode/100 != 2 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("failed to upload the usage report %s: status %d: %s", key, resp.StatusCode, respBody)
+	}
+	return nil
+}
+
+// objectURL returns the URL of the object of the key.
+func (e *ObjectStorageExporter) objectURL(key string) string {
+	segments := strings.Split(key, "/")
+	for i := range segments {
+		segments[i] = url.PathEscape(segments[i])
+	}
+	path := strings.Join(segments, "/")
+	if e.Endpoint == "" {
+		return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", e.Bucket, e.Region, path)
+	}
+	return strings.TrimSuffix(e.Endpoint, "/") + "/" + url.PathEscape(e.Bucket) + "/" + path
+}
@@ -0,0 +1,53 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package billing
+
+import (
+	"io"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// parquetRow is a row of the Parquet usage reports, with the same columns as the CSV usage reports.
+type parquetRow struct {
+	WindowStart              time.Time `parquet:"window_start,timestamp(millisecond)"`
+	WindowEnd                time.Time `parquet:"window_end,timestamp(millisecond)"`
+	Tenant                   string    `parquet:"tenant,dict"`
+	Model                    string    `parquet:"model,dict"`
+	Backend                  string    `parquet:"backend,dict"`
+	Requests                 uint64    `parquet:"requests"`
+	InputTokens              uint64    `parquet:"input_tokens"`
+	CachedInputTokens        uint64    `parquet:"cached_input_tokens"`
+	CacheCreationInputTokens uint64    `parquet:"cache_creation_input_tokens"`
+	OutputTokens             uint64    `parquet:"output_tokens"`
+}
+
+// writeParquetReport writes the report as a Parquet file compressed with Snappy, with the window times as UTC
+// timestamps in milliseconds.
+func writeParquetReport(w io.Writer, r *Report) error {
+	rows := make([]parquetRow, len(r.Usage))
+	for i := range r.Usage {
+		u := &r.Usage[i]
+		rows[i] = parquetRow{
+			WindowStart:              r.Start.UTC(),
+			WindowEnd:                r.End.UTC(),
+			Tenant:                   u.Tenant,
+			Model:                    u.Model,
+			Backend:                  u.Backend,
+			Requests:                 u.Requests,
+			InputTokens:              u.InputTokens,
+			CachedInputTokens:        u.CachedInputTokens,
+			CacheCreationInputTokens: u.CacheCreationInputTokens,
+			OutputTokens:             u.OutputTokens,
+		}
+	}
+	pw := parquet.NewGenericWriter[parquetRow](w, parquet.Compression(&parquet.Snappy))
+	if _, err := pw.Write(rows); err != nil {
+		return err
+	}
+	return pw.Close()
+}
@@ -0,0 +1,55 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package billing
+
+import (
+	"fmt"
+	"io"
+)
+
+// ReportFormat is the file format of the usage reports, which is also the extension of their names.
+type ReportFormat string
+
+const (
+	// ReportFormatCSV writes the reports as CSV with a header row.
+	ReportFormatCSV ReportFormat = "csv"
+	// ReportFormatParquet writes the reports as Apache Parquet, e.g. to be queried from an object storage by Athena,
+	// BigQuery or Spark.
+	ReportFormatParquet ReportFormat = "parquet"
+)
+
+// ReportFormats are the supported report formats.
+var ReportFormats = []ReportFormat{ReportFormatCSV, ReportFormatParquet}
+
+// orDefault returns the format, or ReportFormatCSV if empty.
+func (f ReportFormat) orDefault() ReportFormat {
+	if f == "" {
+		return ReportFormatCSV
+	}
+	return f
+}
+
+// contentType returns the media type of the reports in the format.
+func (f ReportFormat) contentType() string {
+	if f.orDefault() == ReportFormatParquet {
+		return "application/vnd.apache.parquet"
+	}
+	return "text/csv"
+}
+
+// encodeReport writes the report to w in the format. Each row is the usage of a Key in the window, with the columns
+// window_start, window_end, tenant, model, backend, requests, input_tokens, cached_input_tokens,
+// cache_creation_input_tokens and output_tokens.
+func encodeReport(w io.Writer, format ReportFormat, r *Report) error {
+	switch format.orDefault() {
+	case ReportFormatCSV:
+		return writeCSVReport(w, r)
+	case ReportFormatParquet:
+		return writeParquetReport(w, r)
+	default:
+		return fmt.Errorf("unsupported report format %q", format)
+	}
+}
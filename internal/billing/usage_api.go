@@ -0,0 +1,262 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package billing
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/envoyproxy/ai-gateway/internal/json"
+)
+
+// UsagePath is the path of the usage API served by the UsageHandler.
+const UsagePath = "/v1/usage"
+
+// maxUsageBuckets is the maximum number of buckets of a usage API response.
+const maxUsageBuckets = 1440
+
+// usageBucketWidths are the bucket widths of the usage API, the same as the OpenAI usage API.
+var usageBucketWidths = map[string]time.Duration{"1m": time.Minute, "1h": time.Hour, "1d": 24 * time.Hour}
+
+// UsageHandler serves the usage of the whole gateway aggregated per tenant, model, backend and instance over time
+// buckets, in the shape of the OpenAI usage API (https://platform.openai.com/docs/api-reference/usage), from the
+// Ledger shared by the replicas of the external processor. Every replica serves the same usage.
+//
+// The usage of a window is counted in the bucket its window starts in, so the bucket width should be a multiple of
+// the window of the Aggregator. The windows in progress are included as far as the replicas stored them.
+type UsageHandler struct {
+	// Ledger is the ledger of the usage of all the replicas.
+	Ledger Ledger
+	// now is replaced in the tests.
+	now func() time.Time
+}
+
+// usagePage is the response of the usage API.
+type usagePage struct {
+	Object   string        `json:"object"`
+	Data     []usageBucket `json:"data"`
+	HasMore  bool          `json:"has_more"`
+	NextPage *string       `json:"next_page"`
+}
+
+// usageBucket is the usage of a time bucket.
+type usageBucket struct {
+	Object    string        `json:"object"`
+	StartTime int64         `json:"start_time"`
+	EndTime   int64         `json:"end_time"`
+	Results   []usageResult `json:"results"`
+}
+
+// usageResult is the usage of a group in a bucket. The fields not grouped by are null.
+type usageResult struct {
+	Object                   string  `json:"object"`
+	Tenant                   *string `json:"tenant"`
+	Model                    *string `json:"model"`
+	Backend                  *string `json:"backend"`
+	Instance                 *string `json:"instance"`
+	NumModelRequests         uint64  `json:"num_model_requests"`
+	InputTokens              uint64  `json:"input_tokens"`
+	InputCachedTokens        uint64  `json:"input_cached_tokens"`
+	InputCacheCreationTokens uint64  `json:"input_cache_creation_tokens"`
+	OutputTokens             uint64  `json:"output_tokens"`
+}
+
+// usageQuery is the parsed query of a usage API request.
+type usageQuery struct {
+	start, end  time.Time
+	bucketWidth time.Duration
+	// groupBy is the set of the Key fields the results are grouped by.
+	groupBy map[string]bool
+	// tenants, models, backends and instances filter the usage when not empty.
+	tenants, models, backends, instances []string
+}
+
+// ServeHTTP implements [http.Handler.ServeHTTP].
+func (h *UsageHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	q, err := h.parseQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	reports, err := h.Ledger.Reports(r.Context(), q.start, q.end)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to read the usage ledger: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	page := usagePage{Object: "page", Data: []usageBucket{}}
+	for bucketStart := q.start; bucketStart.Before(q.end); bucketStart = bucketStart.Add(q.bucketWidth) {
+		bucketEnd := bucketStart.Add(q.bucketWidth)
+		groups := make(map[Key]*Usage)
+		for i := range reports {
+			if reports[i].Start.Before(bucketStart) || !reports[i].Start.Before(bucketEnd) {
+				continue
+			}
+			for j := range reports[i].Usage {
+				q.add(groups, &reports[i].Usage[j])
+			}
+		}
+		usage := make([]Usage, 0, len(groups))
+		for _, u := range groups {
+			usage = append(usage, *u)
+		}
+		sortUsage(usage)
+		bucket := usageBucket{Object: "bucket", StartTime: bucketStart.Unix(), EndTime: bucketEnd.Unix(), Results: []usageResult{}}
+		for i := range usage {
+			bucket.Results = append(bucket.Results, q.result(&usage[i]))
+		}
+		page.Data = append(page.Data, bucket)
+	}
+
+	body, err := json.Marshal(page)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to marshal the usage: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(body)
+}
+
+// parseQuery parses the query of the request.
+//
+// The query parameters are the same as the OpenAI usage API, with tenant, model, backend and instance to group by:
+//   - start_time: The start time in Unix seconds, inclusive. Required.
+//   - end_time: The end time in Unix seconds, exclusive. Defaults to now.
+//   - bucket_width: 1m, 1h or 1d. Defaults to 1d.
+//   - group_by: The fields to group by, among tenant, model, backend and instance. Defaults to none.
+//   - tenants, models, backends, instances: Only include the usage of these tenants, models, backends or instances.
+//
+// The list parameters are either repeated or comma-separated.
+func (h *UsageHandler) parseQuery(r *http.Request) (*usageQuery, error) {
+	values := r.URL.Query()
+	q := &usageQuery{bucketWidth: usageBucketWidths["1d"], groupBy: make(map[string]bool)}
+
+	startStr := values.Get("start_time")
+	if startStr == "" {
+		return nil, fmt.Errorf("start_time is required")
+	}
+	start, err := strconv.ParseInt(startStr, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start_time: %w", err)
+	}
+	q.start = time.Unix(start, 0).UTC()
+	now := time.Now
+	if h.now != nil {
+		now = h.now
+	}
+	q.end = now().UTC()
+	if endStr := values.Get("end_time"); endStr != "" {
+		end, parseErr := strconv.ParseInt(endStr, 10, 64)
+		if parseErr != nil {
+			return nil, fmt.Errorf("invalid end_time: %w", parseErr)
+		}
+		q.end = time.Unix(end, 0).UTC()
+	}
+	if !q.start.Before(q.end) {
+		return nil, fmt.Errorf("start_time must be before end_time")
+	}
+
+	if widthStr := values.Get("bucket_width"); widthStr != "" {
+		width, ok := usageBucketWidths[widthStr]
+		if !ok {
+			return nil, fmt.Errorf("invalid bucket_width %q: must be one of 1m, 1h or 1d", widthStr)
+		}
+		q.bucketWidth = width
+	}
+	if buckets := (q.end.Sub(q.start) + q.bucketWidth - 1) / q.bucketWidth; buckets > maxUsageBuckets {
+		return nil, fmt.Errorf("the time range spans %d buckets, more than the maximum of %d", buckets, maxUsageBuckets)
+	}
+
+	for _, field := range listParam(values["group_by"]) {
+		switch field {
+		case "tenant", "model", "backend", "instance":
+			q.groupBy[field] = true
+		default:
+			return nil, fmt.Errorf("invalid group_by %q: must be tenant, model, backend or instance", field)
+		}
+	}
+	q.tenants = listParam(values["tenants"])
+	q.models = listParam(values["models"])
+	q.backends = listParam(values["backends"])
+	q.instances = listParam(values["instances"])
+	return q, nil
+}
+
+// listParam returns the values of a list query parameter, either repeated or comma-separated.
+func listParam(values []string) []string {
+	var list []string
+	for _, v := range values {
+		for item := range strings.SplitSeq(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+	}
+	return list
+}
+
+// add adds the usage to its group unless it is filtered out.
+func (q *usageQuery) add(groups map[Key]*Usage, u *Usage) {
+	if (len(q.tenants) > 0 && !slices.Contains(q.tenants, u.Tenant)) ||
+		(len(q.models) > 0 && !slices.Contains(q.models, u.Model)) ||
+		(len(q.backends) > 0 && !slices.Contains(q.backends, u.Backend)) ||
+		(len(q.instances) > 0 && !slices.Contains(q.instances, u.Instance)) {
+		return
+	}
+	var key Key
+	if q.groupBy["tenant"] {
+		key.Tenant = u.Tenant
+	}
+	if q.groupBy["model"] {
+		key.Model = u.Model
+	}
+	if q.groupBy["backend"] {
+		key.Backend = u.Backend
+	}
+	if q.groupBy["instance"] {
+		key.Instance = u.Instance
+	}
+	g, ok := groups[key]
+	if !ok {
+		g = &Usage{Key: key}
+		groups[key] = g
+	}
+	g.add(u)
+}
+
+// result returns the result of a group, with the fields not grouped by set to null.
+func (q *usageQuery) result(u *Usage) usageResult {
+	res := usageResult{
+		Object:                   "usage.result",
+		NumModelRequests:         u.Requests,
+		InputTokens:              u.InputTokens,
+		InputCachedTokens:        u.CachedInputTokens,
+		InputCacheCreationTokens: u.CacheCreationInputTokens,
+		OutputTokens:             u.OutputTokens,
+	}
+	if q.groupBy["tenant"] {
+		res.Tenant = &u.Tenant
+	}
+	if q.groupBy["model"] {
+		res.Model = &u.Model
+	}
+	if q.groupBy["backend"] {
+		res.Backend = &u.Backend
+	}
+	if q.groupBy["instance"] {
+		res.Instance = &u.Instance
+	}
+	return res
+}
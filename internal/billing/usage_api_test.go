@@ -0,0 +1,117 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package billing
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsageHandler(t *testing.T) {
+	now := time.Date(2025, 1, 1, 11, 30, 0, 0, time.UTC)
+	ledger := newMemoryLedger(24 * time.Hour)
+	ledger.now = func() time.Time { return now }
+	require.NoError(t, ledger.Put(t.Context(), LedgerWriter{Instance: "envoy-default-a", Run: "1"}, &Report{
+		Start: time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC),
+		End:   time.Date(2025, 1, 1, 11, 0, 0, 0, time.UTC),
+		Usage: []Usage{
+			{Key: Key{Tenant: "acme", Model: "gpt-4o", Backend: "openai"}, Requests: 2, InputTokens: 13, CachedInputTokens: 2, OutputTokens: 9},
+			{Key: Key{Tenant: "acme", Model: "gpt-4o", Backend: "azure"}, Requests: 1, InputTokens: 5, OutputTokens: 1},
+			{Key: Key{Tenant: "globex", Model: "gpt-4o-mini", Backend: "openai"}, Requests: 1, InputTokens: 1, OutputTokens: 1},
+		},
+	}))
+	// The usage of the other replicas is served as well.
+	require.NoError(t, ledger.Put(t.Context(), LedgerWriter{Instance: "envoy-default-b", Run: "1"}, &Report{
+		Start: time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC),
+		End:   time.Date(2025, 1, 1, 11, 0, 0, 0, time.UTC),
+		Usage: []Usage{{Key: Key{Tenant: "acme", Model: "gpt-4o", Backend: "openai"}, Requests: 1, InputTokens: 4, OutputTokens: 2}},
+	}))
+
+	// The window in progress is served as far as it is stored.
+	a := NewAggregator(time.Hour, slog.Default())
+	a.now = func() time.Time { return now }
+	a.start = now.Truncate(time.Hour)
+	a.Record(Key{Tenant: "acme", Model: "gpt-4o", Backend: "openai"}, tokenUsage(7, 3))
+	current := a.Current()
+	require.NoError(t, NewLedgerExporter(ledger, "envoy-default-a").Export(t.Context(), &current))
+	h := &UsageHandler{Ledger: ledger, now: func() time.Time { return now }}
+
+	for _, tc := range []struct {
+		name, query string
+		expStatus   int
+		expBody     string
+	}{
+		{
+			name:      "grouped by tenant and model",
+			query:     "start_time=1735725600&bucket_width=1h&group_by=tenant,model",
+			expStatus: http.StatusOK,
+			expBody: `{"object":"page","has_more":false,"next_page":null,"data":[
+				{"object":"bucket","start_time":1735725600,"end_time":1735729200,"results":[
+					{"object":"usage.result","tenant":"acme","model":"gpt-4o","backend":null,"instance":null,"num_model_requests":4,"input_tokens":22,"input_cached_tokens":2,"input_cache_creation_tokens":0,"output_tokens":12},
+					{"object":"usage.result","tenant":"globex","model":"gpt-4o-mini","backend":null,"instance":null,"num_model_requests":1,"input_tokens":1,"input_cached_tokens":0,"input_cache_creation_tokens":0,"output_tokens":1}
+				]},
+				{"object":"bucket","start_time":1735729200,"end_time":1735732800,"results":[
+					{"object":"usage.result","tenant":"acme","model":"gpt-4o","backend":null,"instance":null,"num_model_requests":1,"input_tokens":7,"input_cached_tokens":0,"input_cache_creation_tokens":0,"output_tokens":3}
+				]}
+			]}`,
+		},
+		{
+			name:      "filtered and not grouped",
+			query:     "start_time=1735689600&end_time=1735776000&tenants=acme&backends=openai",
+			expStatus: http.StatusOK,
+			expBody: `{"object":"page","has_more":false,"next_page":null,"data":[
+				{"object":"bucket","start_time":1735689600,"end_time":1735776000,"results":[
+					{"object":"usage.result","tenant":null,"model":null,"backend":null,"instance":null,"num_model_requests":4,"input_tokens":24,"input_cached_tokens":2,"input_cache_creation_tokens":0,"output_tokens":14}
+				]}
+			]}`,
+		},
+		{
+			name:      "grouped by instance",
+			query:     "start_time=1735689600&end_time=1735776000&group_by=instance&instances=envoy-default-b",
+			expStatus: http.StatusOK,
+			expBody: `{"object":"page","has_more":false,"next_page":null,"data":[
+				{"object":"bucket","start_time":1735689600,"end_time":1735776000,"results":[
+					{"object":"usage.result","tenant":null,"model":null,"backend":null,"instance":"envoy-default-b","num_model_requests":1,"input_tokens":4,"input_cached_tokens":0,"input_cache_creation_tokens":0,"output_tokens":2}
+				]}
+			]}`,
+		},
+		{
+			name:      "empty bucket",
+			query:     "start_time=1735718400&end_time=1735722000&bucket_width=1h",
+			expStatus: http.StatusOK,
+			expBody: `{"object":"page","has_more":false,"next_page":null,"data":[
+				{"object":"bucket","start_time":1735718400,"end_time":1735722000,"results":[]}
+			]}`,
+		},
+		{name: "missing start_time", query: "", expStatus: http.StatusBadRequest, expBody: "start_time is required\n"},
+		{name: "invalid bucket_width", query: "start_time=0&bucket_width=1w", expStatus: http.StatusBadRequest, expBody: "invalid bucket_width \"1w\": must be one of 1m, 1h or 1d\n"},
+		{name: "invalid group_by", query: "start_time=1735725600&group_by=user", expStatus: http.StatusBadRequest, expBody: "invalid group_by \"user\": must be tenant, model, backend or instance\n"},
+		{name: "too many buckets", query: "start_time=0&bucket_width=1m", expStatus: http.StatusBadRequest, expBody: "the time range spans 28928850 buckets, more than the maximum of 1440\n"},
+		{name: "end before start", query: "start_time=1735732800&end_time=1735725600", expStatus: http.StatusBadRequest, expBody: "start_time must be before end_time\n"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, UsagePath+"?"+tc.query, nil))
+			require.Equal(t, tc.expStatus, rec.Code)
+			if tc.expStatus == http.StatusOK {
+				require.JSONEq(t, tc.expBody, rec.Body.String())
+			} else {
+				require.Equal(t, tc.expBody, rec.Body.String())
+			}
+		})
+	}
+
+	t.Run("method not allowed", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, UsagePath, nil))
+		require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	})
+}
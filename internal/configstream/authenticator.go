@@ -0,0 +1,88 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package configstream
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// podNameExtraKey and podUIDExtraKey are the extra user info of the TokenReview holding the pod the
+	// projected service account token is bound to.
+	podNameExtraKey = "authentication.kubernetes.io/pod-name"
+	podUIDExtraKey  = "authentication.kubernetes.io/pod-uid"
+	// owningGatewayNameLabel and owningGatewayNamespaceLabel are the labels set by Envoy Gateway on the
+	// proxy pods of a Gateway.
+	owningGatewayNameLabel      = "gateway.envoyproxy.io/owning-gateway-name"
+	owningGatewayNamespaceLabel = "gateway.envoyproxy.io/owning-gateway-namespace"
+)
+
+// serviceAccountAuthenticator implements [Authenticator] by validating the service account tokens
+// with the Kubernetes TokenReview API.
+type serviceAccountAuthenticator struct {
+	kube      kubernetes.Interface
+	namespace string
+	// usernamePrefix is the prefix of the usernames of the allowed service accounts.
+	usernamePrefix string
+}
+
+// NewServiceAccountAuthenticator returns an [Authenticator] that only accepts the tokens issued for
+// [TokenAudience] to the service accounts in the given namespace. This is the namespace where the Envoy
+// Gateway proxies run, and the one where the filter config Secrets are created.
+//
+// The token must be a projected token bound to an Envoy Gateway proxy pod, and it is only allowed to subscribe to
+// the resource of the Gateway owning the pod, so that a proxy cannot read the config of the other Gateways.
+func NewServiceAccountAuthenticator(kube kubernetes.Interface, namespace string) Authenticator {
+	return &serviceAccountAuthenticator{
+		kube:           kube,
+		namespace:      namespace,
+		usernamePrefix: "system:serviceaccount:" + namespace + ":",
+	}
+}
+
+// Authenticate implements [Authenticator.Authenticate].
+func (a *serviceAccountAuthenticator) Authenticate(ctx context.Context, token string) (string, error) {
+	review, err := a.kube.AuthenticationV1().TokenReviews().Create(ctx, &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{Token: token, Audiences: []string{TokenAudience}},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to review token: %w", err)
+	}
+	if !review.Status.Authenticated {
+		return "", fmt.Errorf("token is not authenticated: %s", review.Status.Error)
+	}
+	if !slices.Contains(review.Status.Audiences, TokenAudience) {
+		return "", fmt.Errorf("token is not issued for audience %s", TokenAudience)
+	}
+	if !strings.HasPrefix(review.Status.User.Username, a.usernamePrefix) {
+		return "", fmt.Errorf("user %s is not allowed", review.Status.User.Username)
+	}
+
+	podName := review.Status.User.Extra[podNameExtraKey]
+	if len(podName) != 1 {
+		return "", fmt.Errorf("token of user %s is not bound to a pod", review.Status.User.Username)
+	}
+	pod, err := a.kube.CoreV1().Pods(a.namespace).Get(ctx, podName[0], metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to get pod %s: %w", podName[0], err)
+	}
+	if podUID := review.Status.User.Extra[podUIDExtraKey]; len(podUID) == 1 && pod.UID != types.UID(podUID[0]) {
+		return "", fmt.Errorf("pod %s was replaced", podName[0])
+	}
+	gatewayName, gatewayNamespace := pod.Labels[owningGatewayNameLabel], pod.Labels[owningGatewayNamespaceLabel]
+	if gatewayName == "" || gatewayNamespace == "" {
+		return "", fmt.Errorf("pod %s is not owned by a Gateway", podName[0])
+	}
+	return ResourceName(gatewayName, gatewayNamespace), nil
+}
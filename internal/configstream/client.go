@@ -0,0 +1,241 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package configstream
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	discoveryv3 "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	"google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"sigs.k8s.io/yaml"
+
+	"github.com/envoyproxy/ai-gateway/internal/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/version"
+)
+
+const (
+	minRetryInterval = time.Second
+	maxRetryInterval = 30 * time.Second
+)
+
+// ClientConfig is the configuration of the config stream client.
+type ClientConfig struct {
+	// Addr is the host:port address of the config stream server.
+	Addr string
+	// ResourceName is the name of the resource to subscribe to. See [ResourceName].
+	ResourceName string
+	// CACert is the PEM-encoded CA certificate to verify the server. Required unless Insecure is set.
+	CACert []byte
+	// Insecure connects to the server without TLS, exposing the configs and the token to the network.
+	// This is only meant for testing.
+	Insecure bool
+	// TokenPath is the path to the service account token presented to the server. Optional.
+	// The file is read on every connection attempt so that the rotated token is picked up.
+	TokenPath string
+}
+
+// StartClient subscribes to the config stream and calls the receiver's LoadConfig on every pushed config.
+// The stream is re-established with backoff on failures until ctx is cancelled.
+//
+// This returns immediately after validating the configuration. The initial config is expected to be
+// loaded by the file-based watcher, which also serves as a fallback while the stream is unavailable.
+func StartClient(ctx context.Context, cfg ClientConfig, rcv filterapi.ConfigReceiver, l *slog.Logger) error {
+	if cfg.Addr == "" || cfg.ResourceName == "" {
+		return errors.New("config stream address and resource name must be provided")
+	}
+	var creds credentials.TransportCredentials
+	switch {
+	case cfg.Insecure:
+		l.Warn("connecting to the config stream without TLS")
+		creds = insecure.NewCredentials()
+	case len(cfg.CACert) == 0:
+		return errors.New("config stream CA certificate must be provided unless insecure is set")
+	default:
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(cfg.CACert) {
+			return errors.New("failed to parse the config stream CA certificate")
+		}
+		host, _, err := net.SplitHostPort(cfg.Addr)
+		if err != nil {
+			return fmt.Errorf("invalid config stream address %q: %w", cfg.Addr, err)
+		}
+		creds = credentials.NewTLS(&tls.Config{RootCAs: pool, ServerName: host, MinVersion: tls.VersionTLS12})
+	}
+	conn, err := grpc.NewClient(cfg.Addr, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return fmt.Errorf("failed to create config stream client: %w", err)
+	}
+	c := &client{
+		cfg:        cfg,
+		ads:        discoveryv3.NewAggregatedDiscoveryServiceClient(conn),
+		rcv:        rcv,
+		l:          l.With(slog.String("resource", cfg.ResourceName)),
+		versionStr: version.Parse(),
+	}
+	go func() {
+		defer func() { _ = conn.Close() }()
+		c.run(ctx)
+	}()
+	l.Info("start subscribing to the config stream", slog.String("addr", cfg.Addr), slog.String("resource", cfg.ResourceName))
+	return nil
+}
+
+type client struct {
+	cfg        ClientConfig
+	ads        discoveryv3.AggregatedDiscoveryServiceClient
+	rcv        filterapi.ConfigReceiver
+	l          *slog.Logger
+	versionStr string
+}
+
+// run keeps the stream open until ctx is cancelled.
+func (c *client) run(ctx context.Context) {
+	retry := minRetryInterval
+	for {
+		received, err := c.stream(ctx)
+		if ctx.Err() != nil {
+			c.l.Info("stop subscribing to the config stream")
+			return
+		}
+		if received {
+			retry = minRetryInterval
+		}
+		c.l.Warn("config stream disconnected, retrying", slog.String("error", err.Error()),
+			slog.String("interval", retry.String()))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(retry):
+		}
+		retry = min(retry*2, maxRetryInterval)
+	}
+}
+
+// stream runs a single stream until it fails. This returns true if at least one response was received.
+func (c *client) stream(ctx context.Context) (received bool, err error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if c.cfg.TokenPath != "" {
+		token, err := os.ReadFile(c.cfg.TokenPath)
+		if err != nil {
+			return false, fmt.Errorf("failed to read token: %w", err)
+		}
+		ctx = metadata.AppendToOutgoingContext(ctx, authorizationMetadataKey, bearerPrefix+strings.TrimSpace(string(token)))
+	}
+	s, err := c.ads.StreamAggregatedResources(ctx)
+	if err != nil {
+		return false, err
+	}
+	req := &discoveryv3.DiscoveryRequest{
+		Node:          &corev3.Node{Id: c.cfg.ResourceName},
+		ResourceNames: []string{c.cfg.ResourceName},
+		TypeUrl:       TypeURL,
+	}
+	if err = s.Send(req); err != nil {
+		return false, err
+	}
+	var ackedVersion string
+	for {
+		resp, err := s.Recv()
+		if err != nil {
+			return received, err
+		}
+		received = true
+		req = &discoveryv3.DiscoveryRequest{
+			Node:          &corev3.Node{Id: c.cfg.ResourceName},
+			ResourceNames: []string{c.cfg.ResourceName},
+			TypeUrl:       TypeURL,
+			ResponseNonce: resp.Nonce,
+		}
+		if err = c.load(ctx, resp); err != nil {
+			c.l.Error("failed to load config from the stream", slog.String("version", resp.VersionInfo),
+				slog.String("error", err.Error()))
+			// NACK keeps the last accepted version.
+			req.VersionInfo = ackedVersion
+			req.ErrorDetail = &status.Status{Code: int32(codes.InvalidArgument), Message: err.Error()}
+		} else {
+			ackedVersion = resp.VersionInfo
+			req.VersionInfo = ackedVersion
+		}
+		if err = s.Send(req); err != nil {
+			return received, err
+		}
+	}
+}
+
+// load decodes the config in the response and passes it to the receiver.
+func (c *client) load(ctx context.Context, resp *discoveryv3.DiscoveryResponse) error {
+	if resp.TypeUrl != TypeURL || len(resp.Resources) != 1 {
+		return fmt.Errorf("expected a single resource of type %s", TypeURL)
+	}
+	var raw wrapperspb.BytesValue
+	if err := resp.Resources[0].UnmarshalTo(&raw); err != nil {
+		return fmt.Errorf("failed to unmarshal resource: %w", err)
+	}
+	var cfg filterapi.Config
+	if err := yaml.Unmarshal(raw.Value, &cfg); err != nil {
+		return fmt.Errorf("failed to unmarshal config: %w", err)
+	}
+	if cfg.Version != c.versionStr {
+		return fmt.Errorf(`config version mismatch: expected %q, got %q. Likely in the middle of rolling update`,
+			c.versionStr, cfg.Version)
+	}
+	c.l.Info("loading a new config from the stream", slog.String("version", resp.VersionInfo))
+	return c.rcv.LoadConfig(ctx, &cfg)
+}
+
+// Dedup wraps the receiver so that a config with the same UUID as one of the recently loaded configs is
+// not loaded again. This is used when the same receiver is fed by both the config stream and the file
+// watcher, so that the file watcher does not reload, or roll back to, a config already pushed by the stream.
+func Dedup(rcv filterapi.ConfigReceiver) filterapi.ConfigReceiver {
+	return &dedupReceiver{rcv: rcv}
+}
+
+// dedupRecentUUIDs is the number of recently loaded UUIDs remembered by the dedupReceiver.
+const dedupRecentUUIDs = 16
+
+type dedupReceiver struct {
+	rcv    filterapi.ConfigReceiver
+	mu     sync.Mutex
+	recent []string
+}
+
+// LoadConfig implements [filterapi.ConfigReceiver.LoadConfig].
+func (d *dedupReceiver) LoadConfig(ctx context.Context, cfg *filterapi.Config) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if cfg.UUID != "" && slices.Contains(d.recent, cfg.UUID) {
+		return nil
+	}
+	if err := d.rcv.LoadConfig(ctx, cfg); err != nil {
+		return err
+	}
+	if cfg.UUID != "" {
+		d.recent = append(d.recent, cfg.UUID)
+		if len(d.recent) > dedupRecentUUIDs {
+			d.recent = d.recent[1:]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,326 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package configstream
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	discoveryv3 "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/envoyproxy/ai-gateway/internal/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/version"
+)
+
+type recordingReceiver struct {
+	mu      sync.Mutex
+	configs []*filterapi.Config
+	err     error
+}
+
+func (r *recordingReceiver) LoadConfig(_ context.Context, cfg *filterapi.Config) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return r.err
+	}
+	r.configs = append(r.configs, cfg)
+	return nil
+}
+
+func (r *recordingReceiver) uuids() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var ret []string
+	for _, c := range r.configs {
+		ret = append(ret, c.UUID)
+	}
+	return ret
+}
+
+type tokenAuthenticator string
+
+func (a tokenAuthenticator) Authenticate(_ context.Context, token string) (string, error) {
+	if token != string(a) {
+		return "", errors.New("invalid token")
+	}
+	return ResourceName("gw", "ns"), nil
+}
+
+func startServer(t *testing.T, authenticator Authenticator) (*Server, string) {
+	var lc net.ListenConfig
+	lis, err := lc.Listen(t.Context(), "tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := lis.Addr().String()
+	require.NoError(t, lis.Close())
+
+	s := NewServer(logr.Discard(), authenticator)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = s.Start(ctx, addr, nil)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return s, addr
+}
+
+func configYAML(uuid string) []byte {
+	return []byte("version: " + version.Parse() + "\nuuid: " + uuid + "\n")
+}
+
+func TestServerAndClient(t *testing.T) {
+	s, addr := startServer(t, tokenAuthenticator("secret"))
+	name := ResourceName("gw", "ns")
+	require.Equal(t, "ns/gw", name)
+	s.Publish(name, configYAML("first"))
+
+	tokenPath := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenPath, []byte("secret\n"), 0o600))
+	rcv := &recordingReceiver{}
+	require.NoError(t, StartClient(t.Context(), ClientConfig{
+		Addr: addr, ResourceName: name, TokenPath: tokenPath, Insecure: true,
+	}, rcv, slog.Default()))
+
+	require.Eventually(t, func() bool {
+		return len(rcv.uuids()) == 1
+	}, 10*time.Second, 50*time.Millisecond)
+	require.Equal(t, []string{"first"}, rcv.uuids())
+
+	// Publishing the same config must not trigger a push.
+	s.Publish(name, configYAML("first"))
+	// Configs of other Gateways must not be pushed.
+	s.Publish(ResourceName("other", "ns"), configYAML("other"))
+	s.Publish(name, configYAML("second"))
+	require.Eventually(t, func() bool {
+		return len(rcv.uuids()) == 2
+	}, 10*time.Second, 50*time.Millisecond)
+	require.Equal(t, []string{"first", "second"}, rcv.uuids())
+}
+
+func TestServer_Delete(t *testing.T) {
+	s, addr := startServer(t, nil)
+	name := ResourceName("gw", "ns")
+	s.Publish(name, configYAML("first"))
+	s.Publish(name, configYAML("second"))
+
+	rcv := &recordingReceiver{}
+	require.NoError(t, StartClient(t.Context(), ClientConfig{Addr: addr, ResourceName: name, Insecure: true}, rcv, slog.Default()))
+	require.Eventually(t, func() bool {
+		return len(rcv.uuids()) == 1
+	}, 10*time.Second, 50*time.Millisecond)
+
+	s.Delete(name)
+	s.mu.Lock()
+	require.Empty(t, s.resources)
+	s.mu.Unlock()
+
+	// The config of the Gateway recreated with the same name is pushed even though it restarts from scratch.
+	s.Publish(name, configYAML("recreated"))
+	require.Eventually(t, func() bool {
+		return len(rcv.uuids()) == 2
+	}, 10*time.Second, 50*time.Millisecond)
+	require.Equal(t, []string{"second", "recreated"}, rcv.uuids())
+}
+
+func TestClient_versionMismatch(t *testing.T) {
+	s, addr := startServer(t, nil)
+	name := ResourceName("gw", "ns")
+	s.Publish(name, []byte("version: mismatched\nuuid: first\n"))
+
+	rcv := &recordingReceiver{}
+	require.NoError(t, StartClient(t.Context(), ClientConfig{Addr: addr, ResourceName: name, Insecure: true}, rcv, slog.Default()))
+	// The NACKed config must not break the stream for subsequent configs.
+	time.Sleep(200 * time.Millisecond)
+	s.Publish(name, configYAML("second"))
+	require.Eventually(t, func() bool {
+		return len(rcv.uuids()) == 1
+	}, 10*time.Second, 50*time.Millisecond)
+	require.Equal(t, []string{"second"}, rcv.uuids())
+}
+
+func TestStartClient_invalid(t *testing.T) {
+	rcv := &recordingReceiver{}
+	err := StartClient(t.Context(), ClientConfig{}, rcv, slog.Default())
+	require.ErrorContains(t, err, "config stream address and resource name must be provided")
+	err = StartClient(t.Context(), ClientConfig{Addr: "localhost:1065", ResourceName: "ns/gw", CACert: []byte("bad")}, rcv, slog.Default())
+	require.ErrorContains(t, err, "failed to parse the config stream CA certificate")
+	err = StartClient(t.Context(), ClientConfig{Addr: "localhost:1065", ResourceName: "ns/gw"}, rcv, slog.Default())
+	require.ErrorContains(t, err, "config stream CA certificate must be provided unless insecure is set")
+}
+
+func TestServer_authenticate(t *testing.T) {
+	s := NewServer(logr.Discard(), tokenAuthenticator("secret"))
+	_, err := s.authenticate(t.Context())
+	require.ErrorContains(t, err, "missing bearer token")
+}
+
+func TestServer_otherResource(t *testing.T) {
+	s, addr := startServer(t, tokenAuthenticator("secret"))
+	s.Publish(ResourceName("other", "ns"), configYAML("other"))
+
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer func() { _ = conn.Close() }()
+	ctx := metadata.AppendToOutgoingContext(t.Context(), authorizationMetadataKey, bearerPrefix+"secret")
+	stream, err := discoveryv3.NewAggregatedDiscoveryServiceClient(conn).StreamAggregatedResources(ctx)
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&discoveryv3.DiscoveryRequest{
+		ResourceNames: []string{ResourceName("other", "ns")},
+		TypeUrl:       TypeURL,
+	}))
+	_, err = stream.Recv()
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func TestDedup(t *testing.T) {
+	inner := &recordingReceiver{}
+	d := Dedup(inner)
+	require.NoError(t, d.LoadConfig(t.Context(), &filterapi.Config{UUID: "a"}))
+	require.NoError(t, d.LoadConfig(t.Context(), &filterapi.Config{UUID: "a"}))
+	require.NoError(t, d.LoadConfig(t.Context(), &filterapi.Config{UUID: "b"}))
+	// The older config loaded again, e.g. from a stale file, must be skipped.
+	require.NoError(t, d.LoadConfig(t.Context(), &filterapi.Config{UUID: "a"}))
+	// Configs without UUID are always loaded.
+	require.NoError(t, d.LoadConfig(t.Context(), &filterapi.Config{}))
+	require.NoError(t, d.LoadConfig(t.Context(), &filterapi.Config{}))
+	require.Equal(t, []string{"a", "b", "", ""}, inner.uuids())
+
+	// Failed loads are not remembered.
+	inner.err = errors.New("failed")
+	require.Error(t, d.LoadConfig(t.Context(), &filterapi.Config{UUID: "c"}))
+	inner.err = nil
+	require.NoError(t, d.LoadConfig(t.Context(), &filterapi.Config{UUID: "c"}))
+	require.Equal(t, []string{"a", "b", "", "", "c"}, inner.uuids())
+}
+
+func TestServiceAccountAuthenticator(t *testing.T) {
+	const username = "system:serviceaccount:envoy-gateway-system:envoy-default-gw"
+	boundTo := func(pod, uid string) map[string]authenticationv1.ExtraValue {
+		return map[string]authenticationv1.ExtraValue{podNameExtraKey: {pod}, podUIDExtraKey: {uid}}
+	}
+	for _, tc := range []struct {
+		name        string
+		status      authenticationv1.TokenReviewStatus
+		expResource string
+		expErr      string
+	}{
+		{
+			name: "ok",
+			status: authenticationv1.TokenReviewStatus{
+				Authenticated: true,
+				Audiences:     []string{TokenAudience},
+				User:          authenticationv1.UserInfo{Username: username, Extra: boundTo("envoy-gw", "uid")},
+			},
+			expResource: "default/gw",
+		},
+		{
+			name:   "not authenticated",
+			status: authenticationv1.TokenReviewStatus{Error: "expired"},
+			expErr: "token is not authenticated: expired",
+		},
+		{
+			name: "wrong audience",
+			status: authenticationv1.TokenReviewStatus{
+				Authenticated: true,
+				Audiences:     []string{"kubernetes"},
+				User:          authenticationv1.UserInfo{Username: username, Extra: boundTo("envoy-gw", "uid")},
+			},
+			expErr: "token is not issued for audience",
+		},
+		{
+			name: "wrong namespace",
+			status: authenticationv1.TokenReviewStatus{
+				Authenticated: true,
+				Audiences:     []string{TokenAudience},
+				User:          authenticationv1.UserInfo{Username: "system:serviceaccount:default:attacker"},
+			},
+			expErr: "user system:serviceaccount:default:attacker is not allowed",
+		},
+		{
+			name: "not bound to a pod",
+			status: authenticationv1.TokenReviewStatus{
+				Authenticated: true,
+				Audiences:     []string{TokenAudience},
+				User:          authenticationv1.UserInfo{Username: username},
+			},
+			expErr: "token of user " + username + " is not bound to a pod",
+		},
+		{
+			name: "pod replaced",
+			status: authenticationv1.TokenReviewStatus{
+				Authenticated: true,
+				Audiences:     []string{TokenAudience},
+				User:          authenticationv1.UserInfo{Username: username, Extra: boundTo("envoy-gw", "old-uid")},
+			},
+			expErr: "pod envoy-gw was replaced",
+		},
+		{
+			name: "pod not owned by a Gateway",
+			status: authenticationv1.TokenReviewStatus{
+				Authenticated: true,
+				Audiences:     []string{TokenAudience},
+				User:          authenticationv1.UserInfo{Username: username, Extra: boundTo("other", "other-uid")},
+			},
+			expErr: "pod other is not owned by a Gateway",
+		},
+		{
+			name: "pod not found",
+			status: authenticationv1.TokenReviewStatus{
+				Authenticated: true,
+				Audiences:     []string{TokenAudience},
+				User:          authenticationv1.UserInfo{Username: username, Extra: boundTo("missing", "uid")},
+			},
+			expErr: "failed to get pod missing",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			kube := fake.NewClientset(
+				&corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+					Name: "envoy-gw", Namespace: "envoy-gateway-system", UID: "uid",
+					Labels: map[string]string{owningGatewayNameLabel: "gw", owningGatewayNamespaceLabel: "default"},
+				}},
+				&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "envoy-gateway-system", UID: "other-uid"}},
+			)
+			kube.PrependReactor("create", "tokenreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				review := action.(k8stesting.CreateAction).GetObject().(*authenticationv1.TokenReview)
+				require.Equal(t, "token", review.Spec.Token)
+				require.Equal(t, []string{TokenAudience}, review.Spec.Audiences)
+				return true, &authenticationv1.TokenReview{Status: tc.status}, nil
+			})
+			resource, err := NewServiceAccountAuthenticator(kube, "envoy-gateway-system").Authenticate(t.Context(), "token")
+			if tc.expErr != "" {
+				require.ErrorContains(t, err, tc.expErr)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tc.expResource, resource)
+			}
+		})
+	}
+}
@@ -0,0 +1,301 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+// Package configstream implements an xDS-like gRPC stream that pushes the filter configuration from the
+// controller to the external processors. This reduces the propagation latency of configuration changes
+// compared to watching the filter config Secret mounted into the Gateway pods, which is only synced by
+// kubelet periodically.
+//
+// The stream reuses the Envoy Aggregated Discovery Service (ADS) protocol. Each external processor
+// subscribes to a single resource named after its Gateway, see [ResourceName]. The resource is the
+// filter config YAML wrapped in a google.protobuf.BytesValue. The version of each resource is bumped on
+// every change, and the external processor ACKs or NACKs each response with the standard xDS semantics.
+package configstream
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	discoveryv3 "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	"github.com/go-logr/logr"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+const (
+	// TypeURL is the type URL of the resources served by the config stream.
+	TypeURL = "type.googleapis.com/google.protobuf.BytesValue"
+	// TokenAudience is the audience of the service account token presented by the external processors.
+	TokenAudience = "envoy-ai-gateway-config-stream"
+	// CAEnvVar is the environment variable of the external processor holding the PEM-encoded CA certificate
+	// to verify the config stream server.
+	CAEnvVar = "AI_GATEWAY_CONFIG_STREAM_CA"
+
+	authorizationMetadataKey = "authorization"
+	bearerPrefix             = "Bearer "
+)
+
+// ResourceName returns the name of the config stream resource for the given Gateway.
+func ResourceName(gatewayName, gatewayNamespace string) string {
+	return gatewayNamespace + "/" + gatewayName
+}
+
+// Authenticator authenticates the bearer token presented by an external processor.
+type Authenticator interface {
+	// Authenticate returns the name of the only resource the token is allowed to subscribe to, or an error if
+	// the token is not allowed to subscribe to the config stream.
+	Authenticate(ctx context.Context, token string) (string, error)
+}
+
+// resource is the latest config of a single Gateway.
+type resource struct {
+	version uint64
+	config  []byte
+}
+
+// Server serves the filter configs to the external processors over the ADS protocol.
+type Server struct {
+	discoveryv3.UnimplementedAggregatedDiscoveryServiceServer
+
+	logger        logr.Logger
+	authenticator Authenticator
+
+	mu        sync.Mutex
+	resources map[string]*resource
+	// version is the latest version of all the resources. This is shared by the resources so that a resource
+	// deleted and published again never goes back to a version the subscribers already received.
+	version uint64
+	// subscribers are notified when the resource of the key is updated.
+	subscribers map[string]map[chan struct{}]struct{}
+}
+
+// NewServer creates a new Server. The authenticator can be nil to allow unauthenticated streams,
+// which is only meant for testing.
+func NewServer(logger logr.Logger, authenticator Authenticator) *Server {
+	return &Server{
+		logger:        logger.WithName("config-stream"),
+		authenticator: authenticator,
+		resources:     make(map[string]*resource),
+		subscribers:   make(map[string]map[chan struct{}]struct{}),
+	}
+}
+
+// Publish updates the config of the given resource and pushes it to the subscribed external processors.
+// Publishing the same config as the current one is a no-op.
+func (s *Server) Publish(name string, config []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.resources[name]
+	if !ok {
+		r = &resource{}
+		s.resources[name] = r
+	} else if string(r.config) == string(config) {
+		return
+	}
+	s.version++
+	r.version = s.version
+	r.config = config
+	for ch := range s.subscribers[name] {
+		select {
+		case ch <- struct{}{}:
+		default: // Already notified.
+		}
+	}
+}
+
+// Delete deletes the config of the given resource, e.g. when its Gateway is deleted. The subscribed external
+// processors keep their current config, and receive the config published again for the resource if any.
+func (s *Server) Delete(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.resources, name)
+}
+
+// Start starts the gRPC server on the given address. If tlsConfig is nil, the server is started without TLS.
+// This blocks until ctx is cancelled.
+func (s *Server) Start(ctx context.Context, addr string, tlsConfig *tls.Config) error {
+	var opts []grpc.ServerOption
+	if tlsConfig != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	grpcServer := grpc.NewServer(opts...)
+	discoveryv3.RegisterAggregatedDiscoveryServiceServer(grpcServer, s)
+
+	var lc net.ListenConfig
+	lis, err := lc.Listen(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	go func() {
+		<-ctx.Done()
+		s.logger.Info("shutting down config stream server")
+		grpcServer.GracefulStop()
+	}()
+
+	s.logger.Info("starting config stream server", "address", addr, "tls", tlsConfig != nil)
+	if err = grpcServer.Serve(lis); err != nil {
+		return fmt.Errorf("failed to serve config stream: %w", err)
+	}
+	return nil
+}
+
+// StreamAggregatedResources implements [discoveryv3.AggregatedDiscoveryServiceServer].
+func (s *Server) StreamAggregatedResources(stream discoveryv3.AggregatedDiscoveryService_StreamAggregatedResourcesServer) error {
+	ctx := stream.Context()
+	allowed, err := s.authenticate(ctx)
+	if err != nil {
+		return err
+	}
+
+	req, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	if req.TypeUrl != TypeURL || len(req.ResourceNames) != 1 {
+		return status.Errorf(codes.InvalidArgument, "expected a single resource of type %s", TypeURL)
+	}
+	name := req.ResourceNames[0]
+	if s.authenticator != nil && name != allowed {
+		s.logger.Info("rejected config stream subscription to another resource", "resource", name, "allowed", allowed)
+		return status.Error(codes.PermissionDenied, "permission denied")
+	}
+	logger := s.logger.WithValues("resource", name, "node", req.GetNode().GetId())
+	logger.Info("config stream subscribed")
+
+	notify := s.subscribe(name)
+	defer s.unsubscribe(name, notify)
+
+	// Receive the ACKs and NACKs in a separate goroutine so that the pushes are not blocked.
+	reqs := make(chan *discoveryv3.DiscoveryRequest)
+	recvErr := make(chan error, 1)
+	go func() {
+		for {
+			r, err := stream.Recv()
+			if err != nil {
+				recvErr <- err
+				return
+			}
+			select {
+			case reqs <- r:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var sentVersion, nonce uint64
+	// The first push is triggered by the subscription request itself.
+	pending := true
+	for {
+		if pending {
+			pending = false
+			resp, version := s.response(name, sentVersion, &nonce)
+			if resp != nil {
+				if err = stream.Send(resp); err != nil {
+					return err
+				}
+				sentVersion = version
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case err = <-recvErr:
+			if errors.Is(err, context.Canceled) || status.Code(err) == codes.Canceled {
+				return nil
+			}
+			return err
+		case <-notify:
+			pending = true
+		case r := <-reqs:
+			if r.ErrorDetail != nil {
+				logger.Error(errors.New(r.ErrorDetail.GetMessage()), "config rejected by the external processor",
+					"version", r.VersionInfo, "nonce", r.ResponseNonce)
+			} else {
+				logger.V(1).Info("config acknowledged by the external processor",
+					"version", r.VersionInfo, "nonce", r.ResponseNonce)
+			}
+		}
+	}
+}
+
+// response returns the response for the resource if its version is newer than sentVersion.
+func (s *Server) response(name string, sentVersion uint64, nonce *uint64) (*discoveryv3.DiscoveryResponse, uint64) {
+	s.mu.Lock()
+	r, ok := s.resources[name]
+	if !ok || r.version <= sentVersion {
+		s.mu.Unlock()
+		return nil, 0
+	}
+	version, config := r.version, r.config
+	s.mu.Unlock()
+
+	a, err := anypb.New(wrapperspb.Bytes(config))
+	if err != nil {
+		// This never happens since BytesValue can always be marshaled.
+		panic(err)
+	}
+	*nonce++
+	return &discoveryv3.DiscoveryResponse{
+		VersionInfo: strconv.FormatUint(version, 10),
+		Resources:   []*anypb.Any{a},
+		TypeUrl:     TypeURL,
+		Nonce:       strconv.FormatUint(*nonce, 10),
+	}, version
+}
+
+func (s *Server) subscribe(name string) chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ch := make(chan struct{}, 1)
+	if s.subscribers[name] == nil {
+		s.subscribers[name] = make(map[chan struct{}]struct{})
+	}
+	s.subscribers[name][ch] = struct{}{}
+	return ch
+}
+
+func (s *Server) unsubscribe(name string, ch chan struct{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.subscribers[name], ch)
+	if len(s.subscribers[name]) == 0 {
+		delete(s.subscribers, name)
+	}
+}
+
+// authenticate checks the bearer token in the metadata of the stream, and returns the name of the only resource
+// the stream is allowed to subscribe to.
+func (s *Server) authenticate(ctx context.Context) (string, error) {
+	if s.authenticator == nil {
+		return "", nil
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(authorizationMetadataKey)
+	if len(values) != 1 {
+		return "", status.Error(codes.Unauthenticated, "missing bearer token")
+	}
+	token, ok := strings.CutPrefix(values[0], bearerPrefix)
+	if !ok || token == "" {
+		return "", status.Error(codes.Unauthenticated, "missing bearer token")
+	}
+	allowed, err := s.authenticator.Authenticate(ctx, token)
+	if err != nil {
+		s.logger.Info("rejected config stream", "error", err.Error())
+		return "", status.Error(codes.PermissionDenied, "permission denied")
+	}
+	return allowed, nil
+}
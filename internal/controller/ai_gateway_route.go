@@ -8,10 +8,13 @@ package controller
 import (
 	"context"
 	"fmt"
+	"slices"
+	"strconv"
 	"strings"
 
 	egv1a1 "github.com/envoyproxy/gateway/api/v1alpha1"
 	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
@@ -45,6 +48,10 @@ const (
 	egOwningGatewayNamespaceLabel                      = egAnnotationPrefix + "owning-gateway-namespace"
 	// apiKeyInSecret is the key to store OpenAI API key.
 	apiKeyInSecret = "apiKey"
+	// hmacKeyInSecret is the key to store the HMAC key of the AIGatewayRoute BackendOverride.
+	hmacKeyInSecret = "hmacKey"
+	// privateKeyInSecret is the key to store the private key of the GatewayConfig ResponseAttestation.
+	privateKeyInSecret = "privateKey"
 	// GatewayConfigAnnotationKey is the annotation key used on Gateway objects to reference a GatewayConfig.
 	// The value should be the name of the GatewayConfig resource in the same namespace as the Gateway.
 	GatewayConfigAnnotationKey = "aigateway.envoyproxy.io/gateway-config"
@@ -65,6 +72,14 @@ type AIGatewayRouteController struct {
 	rootPrefix string
 	// referenceGrantValidator validates cross-namespace references using ReferenceGrant.
 	referenceGrantValidator *referenceGrantValidator
+	// observability configures the dashboard and alerts ConfigMaps generated per route.
+	observability ObservabilityOptions
+	// modelNameHeaderKey is the request header matched by the rules of the routes to declare their models.
+	modelNameHeaderKey string
+	// shard is the shard of the AIGatewayRoutes reconciled by this replica. Nil if the routes are not sharded.
+	shard *routeShard
+	// generated tracks the HTTPRoutes, HTTPRouteFilters and ConfigMaps generated from the routes.
+	generated *generatedResources
 }
 
 // NewAIGatewayRouteController creates a new reconcile.TypedReconciler[reconcile.Request] for the AIGatewayRoute resource.
@@ -80,11 +95,16 @@ func NewAIGatewayRouteController(
 		gatewayEventChan:        gatewayEventChan,
 		rootPrefix:              rootPrefix,
 		referenceGrantValidator: newReferenceGrantValidator(client),
+		modelNameHeaderKey:      internalapi.ModelNameHeaderKeyDefault,
+		generated:               newGeneratedResources(),
 	}
 }
 
 // Reconcile implements [reconcile.TypedReconciler].
 func (c *AIGatewayRouteController) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	if c.shard != nil && !c.shard.owns(req.Namespace, req.Name) {
+		return reconcile.Result{}, c.forwardUnowned(ctx, req)
+	}
 	c.logger.Info("Reconciling AIGatewayRoute", "namespace", req.Namespace, "name", req.Name)
 
 	var aiGatewayRoute aigv1b1.AIGatewayRoute
@@ -92,6 +112,7 @@ func (c *AIGatewayRouteController) Reconcile(ctx context.Context, req reconcile.
 		if client.IgnoreNotFound(err) == nil {
 			c.logger.Info("Deleting AIGatewayRoute",
 				"namespace", req.Namespace, "name", req.Name)
+			c.generated.forget(req.NamespacedName)
 			return ctrl.Result{}, nil
 		}
 		return ctrl.Result{}, err
@@ -106,6 +127,20 @@ func (c *AIGatewayRouteController) Reconcile(ctx context.Context, req reconcile.
 	return reconcile.Result{}, nil
 }
 
+// forwardUnowned handles the AIGatewayRoute of the shard led by another replica. The route itself is reconciled by
+// the leader of its shard, but the events of the backends and secrets referenced by the route are only sent to this
+// controller on the leader of the controller manager, which forwards them to the Gateways of the route.
+func (c *AIGatewayRouteController) forwardUnowned(ctx context.Context, req reconcile.Request) error {
+	if !c.shard.isManagerLeader() {
+		return nil
+	}
+	var aiGatewayRoute aigv1b1.AIGatewayRoute
+	if err := c.client.Get(ctx, req.NamespacedName, &aiGatewayRoute); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	return c.syncGateways(ctx, &aiGatewayRoute)
+}
+
 func getHostRewriteFilterName(baseName string) string {
 	return fmt.Sprintf("%s-%s", hostRewriteHTTPFilterName, baseName)
 }
@@ -177,9 +212,25 @@ func (c *AIGatewayRouteController) syncAIGatewayRoute(ctx context.Context, aiGat
 					return fmt.Errorf("failed to create HTTPRouteFilter %s: %w", base.Name, err)
 				}
 				c.logger.Info("Created HTTPRouteFilter", "name", base.Name, "namespace", base.Namespace)
+				if err = c.generated.written(ctx, c.client, base); err != nil {
+					return err
+				}
 			} else {
 				return fmt.Errorf("failed to get HTTPRouteFilter %s: %w", base.Name, err)
 			}
+		} else if !equality.Semantic.DeepEqual(f.Spec, base.Spec) {
+			// The filter is static, so any difference is an out-of-band edit which is reverted here.
+			c.generated.observe(aiGatewayRoute, &f)
+			f.Spec = base.Spec
+			if err = c.client.Update(ctx, &f); err != nil {
+				return fmt.Errorf("failed to update HTTPRouteFilter %s: %w", base.Name, err)
+			}
+			c.logger.Info("Reverted the modified HTTPRouteFilter", "name", base.Name, "namespace", base.Namespace)
+			if err = c.generated.written(ctx, c.client, &f); err != nil {
+				return err
+			}
+		} else if err = c.generated.written(ctx, c.client, &f); err != nil {
+			return err
 		}
 	}
 
@@ -188,6 +239,9 @@ func (c *AIGatewayRouteController) syncAIGatewayRoute(ctx context.Context, aiGat
 	var httpRoute gwapiv1.HTTPRoute
 	err := c.client.Get(ctx, client.ObjectKey{Name: aiGatewayRoute.Name, Namespace: aiGatewayRoute.Namespace}, &httpRoute)
 	existingRoute := err == nil
+	if existingRoute {
+		c.generated.observe(aiGatewayRoute, &httpRoute)
+	}
 	if apierrors.IsNotFound(err) {
 		// This means that this AIGatewayRoute is a new one.
 		httpRoute = gwapiv1.HTTPRoute{
@@ -232,6 +286,15 @@ func (c *AIGatewayRouteController) syncAIGatewayRoute(ctx context.Context, aiGat
 			return fmt.Errorf("failed to create HTTPRoute: %w", err)
 		}
 	}
+	if err = c.generated.written(ctx, c.client, &httpRoute); err != nil {
+		return err
+	}
+
+	if c.observability.Enabled {
+		if err = c.syncObservabilityConfigMaps(ctx, aiGatewayRoute); err != nil {
+			return fmt.Errorf("failed to sync the observability ConfigMaps: %w", err)
+		}
+	}
 
 	err = c.syncGateways(ctx, aiGatewayRoute)
 	if err != nil {
@@ -250,82 +313,14 @@ func (c *AIGatewayRouteController) newHTTPRoute(ctx context.Context, dst *gwapiv
 			Name:  gwapiv1.ObjectName(getHostRewriteFilterName(aiGatewayRoute.Name)),
 		},
 	}}
-	rules := make([]gwapiv1.HTTPRouteRule, 0, len(aiGatewayRoute.Spec.Rules)+1) // +1 for the default rule.
+	migrationRules := internalapi.MigrationRules(aiGatewayRoute.Spec.Rules)
+	rules := make([]gwapiv1.HTTPRouteRule, 0, len(aiGatewayRoute.Spec.Rules)+1+len(migrationRules)) // +1 for the default rule.
 	for i := range aiGatewayRoute.Spec.Rules {
-		rule := &aiGatewayRoute.Spec.Rules[i]
-		var backendRefs []gwapiv1.HTTPBackendRef
-		for j := range rule.BackendRefs {
-			br := &rule.BackendRefs[j]
-			backendNamespace := br.GetNamespace(aiGatewayRoute.Namespace)
-			dstName := fmt.Sprintf("%s.%s", br.Name, backendNamespace)
-
-			if br.IsInferencePool() {
-				// Handle InferencePool backend reference, honoring the (optionally cross-namespace)
-				// namespace specified on the backendRef.
-				if br.IsCrossNamespace(aiGatewayRoute.Namespace) {
-					if err := c.referenceGrantValidator.validateInferencePoolReference(
-						ctx,
-						aiGatewayRoute.Namespace,
-						backendNamespace,
-						br.Name,
-					); err != nil {
-						return err
-					}
-				}
-				ns := gwapiv1.Namespace(backendNamespace)
-				backendRefs = append(backendRefs,
-					gwapiv1.HTTPBackendRef{BackendRef: gwapiv1.BackendRef{
-						BackendObjectReference: gwapiv1.BackendObjectReference{
-							Group:     (*gwapiv1.Group)(br.Group),
-							Kind:      (*gwapiv1.Kind)(br.Kind),
-							Name:      gwapiv1.ObjectName(br.Name),
-							Namespace: &ns,
-						},
-						Weight: br.Weight,
-					}},
-				)
-			} else {
-				// Handle AIServiceBackend reference with cross-namespace validation.
-				backend, err := c.validateAndGetBackend(ctx, aiGatewayRoute, br)
-				if err != nil {
-					return fmt.Errorf("failed to get AIServiceBackend %s: %w", dstName, err)
-				}
-
-				// Copy the BackendObjectReference from the AIServiceBackend.
-				backendObjRef := backend.Spec.BackendRef
-
-				// Ensure the namespace is explicitly set in the BackendObjectReference
-				// only for cross-namespace references.
-				// If the AIServiceBackend is in a different namespace than the AIGatewayRoute,
-				// the Backend it references is also in that namespace, and we need to set
-				// the namespace explicitly in the HTTPRoute's backendRef.
-				if backendObjRef.Namespace == nil && backend.Namespace != "" && backend.Namespace != aiGatewayRoute.Namespace {
-					ns := gwapiv1.Namespace(backend.Namespace)
-					backendObjRef.Namespace = &ns
-				}
-
-				backendRefs = append(backendRefs,
-					gwapiv1.HTTPBackendRef{BackendRef: gwapiv1.BackendRef{
-						BackendObjectReference: backendObjRef,
-						Weight:                 br.Weight,
-					}},
-				)
-			}
-		}
-		var matches []gwapiv1.HTTPRouteMatch
-		for j := range rule.Matches {
-			matches = append(matches, gwapiv1.HTTPRouteMatch{
-				Headers: rule.Matches[j].Headers,
-				Path:    &gwapiv1.HTTPPathMatch{Value: &c.rootPrefix},
-			})
+		rule, err := c.newHTTPRouteRule(ctx, aiGatewayRoute, &aiGatewayRoute.Spec.Rules[i], rewriteFilters)
+		if err != nil {
+			return err
 		}
-		rules = append(rules, gwapiv1.HTTPRouteRule{
-			Name:        rule.Name,
-			BackendRefs: backendRefs,
-			Matches:     matches,
-			Filters:     rewriteFilters,
-			Timeouts:    rule.GetTimeoutsOrDefault(),
-		})
+		rules = append(rules, rule)
 	}
 
 	rules = append(rules, gwapiv1.HTTPRouteRule{
@@ -341,6 +336,30 @@ func (c *AIGatewayRouteController) newHTTPRoute(ctx context.Context, dst *gwapiv
 		}},
 	})
 
+	// The migration rules only match the requests sent again by the external processor to the migration backends.
+	for i := range migrationRules {
+		m := &migrationRules[i]
+		rule, err := c.newHTTPRouteRule(ctx, aiGatewayRoute, &m.Rule, rewriteFilters)
+		if err != nil {
+			return err
+		}
+		if rule.Name != nil {
+			rule.Name = ptr.To(*rule.Name + "-migration")
+		}
+		header := gwapiv1.HTTPHeaderMatch{
+			Type:  ptr.To(gwapiv1.HeaderMatchExact),
+			Name:  internalapi.MigrationRuleHeader,
+			Value: strconv.Itoa(m.RuleIndex),
+		}
+		if len(rule.Matches) == 0 {
+			rule.Matches = []gwapiv1.HTTPRouteMatch{{Path: &gwapiv1.HTTPPathMatch{Value: &c.rootPrefix}}}
+		}
+		for j := range rule.Matches {
+			rule.Matches[j].Headers = append(slices.Clone(rule.Matches[j].Headers), header)
+		}
+		rules = append(rules, rule)
+	}
+
 	dst.Spec.Rules = rules
 
 	// Initialize labels and annotations maps if they don't exist.
@@ -371,6 +390,83 @@ func (c *AIGatewayRouteController) newHTTPRoute(ctx context.Context, dst *gwapiv
 	return nil
 }
 
+// newHTTPRouteRule converts the rule of the AIGatewayRoute to the rule of the generated HTTPRoute.
+func (c *AIGatewayRouteController) newHTTPRouteRule(ctx context.Context, aiGatewayRoute *aigv1b1.AIGatewayRoute, rule *aigv1b1.AIGatewayRouteRule, rewriteFilters []gwapiv1.HTTPRouteFilter) (gwapiv1.HTTPRouteRule, error) {
+	var backendRefs []gwapiv1.HTTPBackendRef
+	for j := range rule.BackendRefs {
+		br := &rule.BackendRefs[j]
+		backendNamespace := br.GetNamespace(aiGatewayRoute.Namespace)
+		dstName := fmt.Sprintf("%s.%s", br.Name, backendNamespace)
+
+		if br.IsInferencePool() {
+			// Handle InferencePool backend reference, honoring the (optionally cross-namespace)
+			// namespace specified on the backendRef.
+			if br.IsCrossNamespace(aiGatewayRoute.Namespace) {
+				if err := c.referenceGrantValidator.validateInferencePoolReference(
+					ctx,
+					aiGatewayRoute.Namespace,
+					backendNamespace,
+					br.Name,
+				); err != nil {
+					return gwapiv1.HTTPRouteRule{}, err
+				}
+			}
+			ns := gwapiv1.Namespace(backendNamespace)
+			backendRefs = append(backendRefs,
+				gwapiv1.HTTPBackendRef{BackendRef: gwapiv1.BackendRef{
+					BackendObjectReference: gwapiv1.BackendObjectReference{
+						Group:     (*gwapiv1.Group)(br.Group),
+						Kind:      (*gwapiv1.Kind)(br.Kind),
+						Name:      gwapiv1.ObjectName(br.Name),
+						Namespace: &ns,
+					},
+					Weight: br.Weight,
+				}},
+			)
+		} else {
+			// Handle AIServiceBackend reference with cross-namespace validation.
+			backend, err := c.validateAndGetBackend(ctx, aiGatewayRoute, br)
+			if err != nil {
+				return gwapiv1.HTTPRouteRule{}, fmt.Errorf("failed to get AIServiceBackend %s: %w", dstName, err)
+			}
+
+			// Copy the BackendObjectReference from the AIServiceBackend.
+			backendObjRef := backend.Spec.BackendRef
+
+			// Ensure the namespace is explicitly set in the BackendObjectReference
+			// only for cross-namespace references.
+			// If the AIServiceBackend is in a different namespace than the AIGatewayRoute,
+			// the Backend it references is also in that namespace, and we need to set
+			// the namespace explicitly in the HTTPRoute's backendRef.
+			if backendObjRef.Namespace == nil && backend.Namespace != "" && backend.Namespace != aiGatewayRoute.Namespace {
+				ns := gwapiv1.Namespace(backend.Namespace)
+				backendObjRef.Namespace = &ns
+			}
+
+			backendRefs = append(backendRefs,
+				gwapiv1.HTTPBackendRef{BackendRef: gwapiv1.BackendRef{
+					BackendObjectReference: backendObjRef,
+					Weight:                 br.Weight,
+				}},
+			)
+		}
+	}
+	var matches []gwapiv1.HTTPRouteMatch
+	for j := range rule.Matches {
+		matches = append(matches, gwapiv1.HTTPRouteMatch{
+			Headers: rule.Matches[j].Headers,
+			Path:    &gwapiv1.HTTPPathMatch{Value: &c.rootPrefix},
+		})
+	}
+	return gwapiv1.HTTPRouteRule{
+		Name:        rule.Name,
+		BackendRefs: backendRefs,
+		Matches:     matches,
+		Filters:     rewriteFilters,
+		Timeouts:    rule.GetTimeoutsOrDefault(),
+	}, nil
+}
+
 // syncGateways synchronizes the gateways referenced by the AIGatewayRoute by sending events to the gateway controller.
 func (c *AIGatewayRouteController) syncGateways(ctx context.Context, aiGatewayRoute *aigv1b1.AIGatewayRoute) error {
 	for _, p := range aiGatewayRoute.Spec.ParentRefs {
@@ -399,6 +495,11 @@ func (c *AIGatewayRouteController) syncGateway(ctx context.Context, namespace, n
 		c.logger.Error(err, "failed to get Gateway", "namespace", namespace, "name", name)
 		return fmt.Errorf("failed to get Gateway %s/%s: %w", namespace, name, err)
 	}
+	if c.shard != nil && !c.shard.isManagerLeader() {
+		// The Gateway controller only runs on the leader of the controller manager, which watches the AIGatewayRoutes
+		// of all the shards.
+		return nil
+	}
 	c.logger.Info("syncing Gateway", "namespace", gw.Namespace, "name", gw.Name)
 	c.gatewayEventChan <- event.GenericEvent{Object: &gw}
 	return nil
@@ -444,6 +545,7 @@ func (c *AIGatewayRouteController) validateAndGetBackend(
 
 // updateAIGatewayRouteStatus updates the status of the AIGatewayRoute.
 func (c *AIGatewayRouteController) updateAIGatewayRouteStatus(ctx context.Context, route *aigv1b1.AIGatewayRoute, conditionType string, message string) {
+	modified := c.generated.takeModified(route)
 	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
 		if err := c.client.Get(ctx, client.ObjectKey{Name: route.Name, Namespace: route.Namespace}, route); err != nil {
 			if apierrors.IsNotFound(err) {
@@ -452,7 +554,8 @@ func (c *AIGatewayRouteController) updateAIGatewayRouteStatus(ctx context.Contex
 			return err
 		}
 
-		route.Status.Conditions = newConditions(conditionType, message)
+		route.Status.Conditions = withDegradedCondition(newConditions(conditionType, message), route.Status.Conditions,
+			route.Generation, modified)
 		return c.client.Status().Update(ctx, route)
 	})
 	if err != nil {
@@ -8,6 +8,7 @@ package controller
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"testing"
 
 	egv1a1 "github.com/envoyproxy/gateway/api/v1alpha1"
@@ -28,6 +29,7 @@ import (
 	gwapiv1b1 "sigs.k8s.io/gateway-api/apis/v1beta1"
 
 	aigv1b1 "github.com/envoyproxy/ai-gateway/api/v1beta1"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
 	internaltesting "github.com/envoyproxy/ai-gateway/internal/testing"
 )
 
@@ -188,6 +190,51 @@ func TestAIGatewayRouterController_syncAIGatewayRoute(t *testing.T) {
 		ok, _ = ctrlutil.HasOwnerReference(notFoundFilter.OwnerReferences, route, fakeClient.Scheme())
 		require.True(t, ok, "expected notFoundFilter to have owner reference to AIGatewayRoute")
 	})
+
+	t.Run("reverts out-of-band edits", func(t *testing.T) {
+		var route aigv1b1.AIGatewayRoute
+		require.NoError(t, fakeClient.Get(t.Context(), client.ObjectKey{Name: "myroute", Namespace: "ns1"}, &route))
+
+		var f egv1a1.HTTPRouteFilter
+		hostRewriteName := fmt.Sprintf("%s-%s", hostRewriteHTTPFilterName, route.Name)
+		require.NoError(t, fakeClient.Get(t.Context(), client.ObjectKey{Name: hostRewriteName, Namespace: "ns1"}, &f))
+		f.Spec.URLRewrite = nil
+		require.NoError(t, fakeClient.Update(t.Context(), &f))
+
+		var httpRoute gwapiv1.HTTPRoute
+		require.NoError(t, fakeClient.Get(t.Context(), client.ObjectKey{Name: "myroute", Namespace: "ns1"}, &httpRoute))
+		delete(httpRoute.Annotations, httpRouteAnnotationForAIGatewayGeneratedIndication)
+		httpRoute.Spec.Rules = nil
+		require.NoError(t, fakeClient.Update(t.Context(), &httpRoute))
+
+		// The edits are detected from the versions recorded in the generated resources, e.g. after a restart.
+		s := NewAIGatewayRouteController(fakeClient, kube, logr.Discard(), eventCh.Ch, "/")
+		req := reconcile.Request{NamespacedName: types.NamespacedName{Name: "myroute", Namespace: "ns1"}}
+		_, err := s.Reconcile(t.Context(), req)
+		require.NoError(t, err)
+
+		require.NoError(t, fakeClient.Get(t.Context(), client.ObjectKey{Name: hostRewriteName, Namespace: "ns1"}, &f))
+		require.Equal(t, generateHTTPRouteFilters(&route)[0].Spec, f.Spec)
+		require.NoError(t, fakeClient.Get(t.Context(), client.ObjectKey{Name: "myroute", Namespace: "ns1"}, &httpRoute))
+		require.Equal(t, "true", httpRoute.Annotations[httpRouteAnnotationForAIGatewayGeneratedIndication])
+		require.Len(t, httpRoute.Spec.Rules, 2)
+
+		require.NoError(t, fakeClient.Get(t.Context(), client.ObjectKey{Name: "myroute", Namespace: "ns1"}, &route))
+		require.Len(t, route.Status.Conditions, 2)
+		require.Equal(t, aigv1b1.ConditionTypeAccepted, route.Status.Conditions[0].Type)
+		degraded := route.Status.Conditions[1]
+		require.Equal(t, aigv1b1.ConditionTypeDegraded, degraded.Type)
+		require.Equal(t, metav1.ConditionTrue, degraded.Status)
+		require.Equal(t, "reverted the out-of-band edits to the generated resources: HTTPRouteFilter ns1/"+hostRewriteName+
+			", HTTPRoute ns1/myroute", degraded.Message)
+
+		// The reconciliation triggered by reverting the edits keeps the condition.
+		_, err = s.Reconcile(t.Context(), req)
+		require.NoError(t, err)
+		require.NoError(t, fakeClient.Get(t.Context(), client.ObjectKey{Name: "myroute", Namespace: "ns1"}, &route))
+		require.Len(t, route.Status.Conditions, 2)
+		require.Equal(t, degraded.Message, route.Status.Conditions[1].Message)
+	})
 }
 
 func Test_newHTTPRoute(t *testing.T) {
@@ -827,6 +874,63 @@ func Test_newHTTPRoute_Hostnames_Set(t *testing.T) {
 	require.Equal(t, expected, httpRoute.Spec.Hostnames)
 }
 
+func Test_newHTTPRoute_Migration(t *testing.T) {
+	c := requireNewFakeClientWithIndexes(t)
+	for _, name := range []string{"openai", "anthropic"} {
+		require.NoError(t, c.Create(t.Context(), &aigv1b1.AIServiceBackend{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test-ns"},
+			Spec: aigv1b1.AIServiceBackendSpec{
+				BackendRef: gwapiv1.BackendObjectReference{Name: gwapiv1.ObjectName(name + "-backend")},
+			},
+		}))
+	}
+
+	modelHeader := gwapiv1.HTTPHeaderMatch{Name: internalapi.ModelNameHeaderKeyDefault, Value: "gpt-4o"}
+	aiGatewayRoute := &aigv1b1.AIGatewayRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-route", Namespace: "test-ns"},
+		Spec: aigv1b1.AIGatewayRouteSpec{
+			Rules: []aigv1b1.AIGatewayRouteRule{
+				{
+					Name:        ptr.To[gwapiv1.SectionName]("chat"),
+					Matches:     []aigv1b1.AIGatewayRouteRuleMatch{{Headers: []gwapiv1.HTTPHeaderMatch{modelHeader}}},
+					BackendRefs: []aigv1b1.AIGatewayRouteRuleBackendRef{{Name: "openai", Weight: ptr.To[int32](100)}},
+					Migration: &aigv1b1.AIGatewayRouteRuleMigration{
+						BackendRef: aigv1b1.AIGatewayRouteRuleBackendRef{Name: "anthropic", Weight: ptr.To[int32](50)},
+					},
+				},
+				{
+					BackendRefs: []aigv1b1.AIGatewayRouteRuleBackendRef{{Name: "openai"}},
+					Migration:   &aigv1b1.AIGatewayRouteRuleMigration{BackendRef: aigv1b1.AIGatewayRouteRuleBackendRef{Name: "anthropic"}},
+				},
+			},
+		},
+	}
+
+	controller := &AIGatewayRouteController{client: c, logger: logr.Discard(), rootPrefix: "/"}
+	httpRoute := &gwapiv1.HTTPRoute{ObjectMeta: metav1.ObjectMeta{Name: "test-route", Namespace: "test-ns"}}
+	require.NoError(t, controller.newHTTPRoute(t.Context(), httpRoute, aiGatewayRoute))
+
+	// The rules of the route, the route-not-found rule, then the migration rules.
+	require.Len(t, httpRoute.Spec.Rules, 5)
+	for i, rule := range httpRoute.Spec.Rules[3:] {
+		require.Len(t, rule.BackendRefs, 1)
+		require.Equal(t, gwapiv1.ObjectName("anthropic-backend"), rule.BackendRefs[0].Name)
+		require.Nil(t, rule.BackendRefs[0].Weight)
+		require.Len(t, rule.Matches, 1)
+		require.Contains(t, rule.Matches[0].Headers, gwapiv1.HTTPHeaderMatch{
+			Type:  ptr.To(gwapiv1.HeaderMatchExact),
+			Name:  internalapi.MigrationRuleHeader,
+			Value: strconv.Itoa(i),
+		})
+	}
+	require.Equal(t, ptr.To[gwapiv1.SectionName]("chat-migration"), httpRoute.Spec.Rules[3].Name)
+	require.Equal(t, modelHeader, httpRoute.Spec.Rules[3].Matches[0].Headers[0])
+	require.Nil(t, httpRoute.Spec.Rules[4].Name)
+	require.Equal(t, "/", *httpRoute.Spec.Rules[4].Matches[0].Path.Value)
+	// The matches of the migrated rule are left as is.
+	require.Equal(t, []gwapiv1.HTTPHeaderMatch{modelHeader}, httpRoute.Spec.Rules[0].Matches[0].Headers)
+}
+
 func TestAIGatewayRouteController_syncGateways_NamespaceDetermination(t *testing.T) {
 	fakeClient := requireNewFakeClientWithIndexes(t)
 	eventCh := internaltesting.NewControllerEventChan[*gwapiv1.Gateway]()
@@ -6,8 +6,15 @@
 package controller
 
 import (
+	"cmp"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"sync"
 	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
@@ -22,6 +29,7 @@ import (
 	ctrlutil "sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	gwaiev1 "sigs.k8s.io/gateway-api-inference-extension/api/v1"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
 
 	aigv1b1 "github.com/envoyproxy/ai-gateway/api/v1beta1"
 	"github.com/envoyproxy/ai-gateway/internal/controller/rotators"
@@ -43,14 +51,26 @@ const (
 	// clientSecretKey is key used to store Azure and OIDC client secret in Kubernetes secrets.
 	clientSecretKey = "client-secret"
 
+	// defaultIBMCloudIAMURL is the base URL of the public IBM Cloud IAM service.
+	defaultIBMCloudIAMURL = "https://iam.cloud.ibm.com"
+
 	// azureScopeURL specifies Microsoft Azure OAuth 2.0 scope to authenticate and authorize when accessing Azure OpenAI.
 	azureScopeURL = "https://cognitiveservices.azure.com/.default"
 
 	// preRotationWindow specifies how long before expiry to rotate credentials.
 	// Temporarily a fixed duration.
 	preRotationWindow = 5 * time.Minute
+
+	// egressCACertKey is the key of the PEM-encoded CA certificates in the ConfigMaps and Secrets referenced by
+	// the egress configuration of BackendSecurityPolicy.
+	egressCACertKey = "ca.crt"
 )
 
+// RotateNowAnnotationKey is the annotation on a BackendSecurityPolicy that forces the rotation of its credentials
+// on the next reconciliation when set to "true", regardless of their expiration, e.g. when they are suspected
+// to be compromised. The annotation is removed once the credentials are rotated.
+const RotateNowAnnotationKey = "aigateway.envoyproxy.io/rotate-now"
+
 // BackendSecurityPolicyController implements [reconcile.TypedReconciler] for [aigv1b1.BackendSecurityPolicy].
 //
 // Exported for testing purposes.
@@ -60,6 +80,29 @@ type BackendSecurityPolicyController struct {
 	logger                    logr.Logger
 	aiServiceBackendEventChan chan event.GenericEvent
 	inferencePoolEventChan    chan event.GenericEvent
+	// azureWorkloadIdentityProviders caches the Azure workload identity token providers, and hence their tokens,
+	// across the reconciliations. Keyed by the namespaced name of the BackendSecurityPolicy, with the value of
+	// *azureWorkloadIdentityProvider.
+	azureWorkloadIdentityProviders sync.Map
+	// egressHTTPClients caches the HTTP clients of the egress configurations across the reconciliations. Keyed by
+	// the namespaced name of the BackendSecurityPolicy, with the value of *egressHTTPClient.
+	egressHTTPClients sync.Map
+}
+
+// azureWorkloadIdentityProvider is the cached token provider of a BackendSecurityPolicy along with the
+// configuration it was created with.
+type azureWorkloadIdentityProvider struct {
+	tenantID, clientID, tokenFilePath string
+	provider                          tokenprovider.TokenProvider
+}
+
+// egressHTTPClient is the cached HTTP client of a BackendSecurityPolicy along with the egress configuration it
+// was created with.
+type egressHTTPClient struct {
+	proxyURL string
+	// caCerts are the PEM-encoded CA certificates of the CACertificateRefs, in order.
+	caCerts []string
+	client  *http.Client
 }
 
 func NewBackendSecurityPolicyController(client client.Client, kube kubernetes.Interface, logger logr.Logger, aiServiceBackendEventChan chan event.GenericEvent, inferencePoolEventChan chan event.GenericEvent) *BackendSecurityPolicyController {
@@ -79,6 +122,8 @@ func (c *BackendSecurityPolicyController) Reconcile(ctx context.Context, req ctr
 		if apierrors.IsNotFound(err) {
 			c.logger.Info("Deleting backend security policy",
 				"namespace", req.Namespace, "name", req.Name)
+			c.azureWorkloadIdentityProviders.Delete(backendSecurityPolicyKey(req.Namespace, req.Name))
+			c.deleteEgressHTTPClient(backendSecurityPolicyKey(req.Namespace, req.Name))
 			return ctrl.Result{}, nil
 		}
 		return ctrl.Result{}, err
@@ -98,6 +143,8 @@ func (c *BackendSecurityPolicyController) Reconcile(ctx context.Context, req ctr
 // reconcile reconciles BackendSecurityPolicy but extracted from Reconcile to centralize error handling.
 func (c *BackendSecurityPolicyController) reconcile(ctx context.Context, bsp *aigv1b1.BackendSecurityPolicy) (res ctrl.Result, err error) {
 	if handleFinalizer(ctx, c.client, c.logger, bsp, c.syncBackendSecurityPolicy) { // Propagate the bsp deletion all the way to relevant Gateways.
+		c.azureWorkloadIdentityProviders.Delete(backendSecurityPolicyKey(bsp.Namespace, bsp.Name))
+		c.deleteEgressHTTPClient(backendSecurityPolicyKey(bsp.Namespace, bsp.Name))
 		return res, nil
 	}
 	// Determine if credential rotation is needed
@@ -123,14 +170,50 @@ func (c *BackendSecurityPolicyController) reconcile(ctx context.Context, bsp *ai
 			return res, err
 		}
 	}
+	if rotateNowRequested(bsp) {
+		if !requiresRotation {
+			c.logger.Info("Ignoring the rotate-now annotation as the credentials are not rotated by the controller",
+				"namespace", bsp.Namespace, "name", bsp.Name)
+		}
+		if err = c.removeRotateNowAnnotation(ctx, bsp); err != nil {
+			return res, err
+		}
+	}
 	err = c.syncBackendSecurityPolicy(ctx, bsp)
 	return res, err
 }
 
+// rotateNowRequested returns true if the rotation of the credentials of the given BackendSecurityPolicy is forced
+// with the [RotateNowAnnotationKey] annotation.
+func rotateNowRequested(bsp *aigv1b1.BackendSecurityPolicy) bool {
+	return bsp.Annotations[RotateNowAnnotationKey] == "true"
+}
+
+// removeRotateNowAnnotation removes the [RotateNowAnnotationKey] annotation from the given BackendSecurityPolicy
+// so that the forced rotation happens only once.
+func (c *BackendSecurityPolicyController) removeRotateNowAnnotation(ctx context.Context, bsp *aigv1b1.BackendSecurityPolicy) error {
+	patch := client.MergeFrom(bsp.DeepCopy())
+	delete(bsp.Annotations, RotateNowAnnotationKey)
+	if err := c.client.Patch(ctx, bsp, patch); err != nil {
+		return fmt.Errorf("failed to remove the %s annotation from backend security policy %s/%s: %w",
+			RotateNowAnnotationKey, bsp.Namespace, bsp.Name, err)
+	}
+	return nil
+}
+
 // rotateCredential rotates the credentials using the access token from OIDC provider and return the requeue time for next rotation.
 func (c *BackendSecurityPolicyController) rotateCredential(ctx context.Context, bsp *aigv1b1.BackendSecurityPolicy) (res ctrl.Result, err error) {
 	var rotator rotators.Rotator
 
+	if bsp.Spec.Egress != nil {
+		var hc *http.Client
+		hc, err = c.egressHTTPClient(ctx, bsp)
+		if err != nil {
+			return ctrl.Result{}, fmt.Errorf("invalid egress configuration: %w", err)
+		}
+		ctx = tokenprovider.WithHTTPClient(ctx, hc)
+	}
+
 	switch bsp.Spec.Type {
 	case aigv1b1.BackendSecurityPolicyTypeAWSCredentials:
 		oidc := getBackendSecurityPolicyAuthOIDC(&bsp.Spec)
@@ -178,12 +261,17 @@ func (c *BackendSecurityPolicyController) rotateCredential(ctx context.Context,
 				return ctrl.Result{}, fmt.Errorf("missing azure client secret key %s", clientSecretKey)
 			}
 			clientSecret := string(secretValue)
-			provider, err = tokenprovider.NewAzureClientSecretTokenProvider(tenantID, clientID, clientSecret, options)
+			provider, err = tokenprovider.NewAzureClientSecretTokenProvider(ctx, tenantID, clientID, clientSecret, options)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+		} else if workloadIdentity := bsp.Spec.AzureCredentials.WorkloadIdentity; workloadIdentity != nil {
+			provider, err = c.azureWorkloadIdentityProvider(bsp, workloadIdentity, options)
 			if err != nil {
 				return ctrl.Result{}, err
 			}
 		} else {
-			return ctrl.Result{}, fmt.Errorf("one of secret ref or oidc must be defined, namespace %s name %s", bsp.Namespace, bsp.Name)
+			return ctrl.Result{}, fmt.Errorf("one of secret ref, oidc or workload identity must be defined, namespace %s name %s", bsp.Namespace, bsp.Name)
 		}
 
 		rotator, err = rotators.NewAzureTokenRotator(c.client, c.kube, c.logger, bsp.Namespace, bsp.Name, preRotationWindow, provider)
@@ -238,6 +326,23 @@ func (c *BackendSecurityPolicyController) rotateCredential(ctx context.Context,
 				"namespace", bsp.Namespace, "name", bsp.Name)
 			return ctrl.Result{}, nil
 		}
+	case aigv1b1.BackendSecurityPolicyTypeIBMCloudAPIKey:
+		var apiKey []byte
+		apiKey, err = c.lookupSecretValue(ctx, bsp.Namespace, bsp.Spec.IBMCloudAPIKey.SecretRef, apiKeyInSecret)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		provider := tokenprovider.NewIBMCloudIAMTokenProvider(cmp.Or(bsp.Spec.IBMCloudAPIKey.IAMURL, defaultIBMCloudIAMURL), string(apiKey))
+		rotator = rotators.NewAccessTokenRotator(c.client, c.logger, bsp.Namespace, bsp.Name, preRotationWindow, provider, rotators.IBMCloudAccessTokenKey)
+	case aigv1b1.BackendSecurityPolicyTypeSAPAICoreCredentials:
+		sap := bsp.Spec.SAPAICoreCredentials
+		var clientSecret []byte
+		clientSecret, err = c.lookupSecretValue(ctx, bsp.Namespace, sap.ClientSecretRef, clientSecretKey)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		provider := tokenprovider.NewSAPAICoreTokenProvider(sap.AuthURL, sap.ClientID, string(clientSecret))
+		rotator = rotators.NewAccessTokenRotator(c.client, c.logger, bsp.Namespace, bsp.Name, preRotationWindow, provider, rotators.SAPAICoreAccessTokenKey)
 
 	default:
 		err = fmt.Errorf("backend security type %s does not support OIDC token exchange", bsp.Spec.Type)
@@ -272,6 +377,128 @@ func (c *BackendSecurityPolicyController) rotateCredential(ctx context.Context,
 	return res, nil
 }
 
+// lookupSecretValue returns the value of the given key of the secret referenced by a BackendSecurityPolicy in the
+// given namespace, honoring the namespace of the reference if set.
+func (c *BackendSecurityPolicyController) lookupSecretValue(ctx context.Context, namespace string, secretRef *gwapiv1.SecretObjectReference, key string) ([]byte, error) {
+	if secretRef.Namespace != nil {
+		namespace = string(*secretRef.Namespace)
+	}
+	name := string(secretRef.Name)
+	secret, err := rotators.LookupSecret(ctx, c.client, namespace, name)
+	if err != nil {
+		c.logger.Error(err, "failed to lookup secret", "namespace", namespace, "name", name)
+		return nil, err
+	}
+	value, ok := secret.Data[key]
+	if !ok {
+		return nil, fmt.Errorf("missing key %s in secret %s/%s", key, namespace, name)
+	}
+	return value, nil
+}
+
+// egressHTTPClient returns the HTTP client used to reach the identity providers of the given BackendSecurityPolicy,
+// going through the configured proxy and trusting the configured CA certificates in addition to the system ones.
+//
+// The client is cached per BackendSecurityPolicy and only rebuilt when the proxy URL or the content of the CA
+// certificates changes, so that the connections to the identity providers are reused across the reconciliations.
+func (c *BackendSecurityPolicyController) egressHTTPClient(ctx context.Context, bsp *aigv1b1.BackendSecurityPolicy) (*http.Client, error) {
+	egress := bsp.Spec.Egress
+	cached := egressHTTPClient{proxyURL: egress.ProxyURL}
+	for _, ref := range egress.CACertificateRefs {
+		var caCert string
+		switch ref.Kind {
+		case "ConfigMap":
+			var cm corev1.ConfigMap
+			if err := c.client.Get(ctx, client.ObjectKey{Namespace: bsp.Namespace, Name: string(ref.Name)}, &cm); err != nil {
+				return nil, fmt.Errorf("failed to get ConfigMap %s/%s: %w", bsp.Namespace, ref.Name, err)
+			}
+			caCert = cm.Data[egressCACertKey]
+		case "Secret":
+			secret, err := rotators.LookupSecret(ctx, c.client, bsp.Namespace, string(ref.Name))
+			if err != nil {
+				return nil, fmt.Errorf("failed to get Secret %s/%s: %w", bsp.Namespace, ref.Name, err)
+			}
+			caCert = string(secret.Data[egressCACertKey])
+		default:
+			return nil, fmt.Errorf("unsupported CA certificate reference kind %s", ref.Kind)
+		}
+		if caCert == "" {
+			return nil, fmt.Errorf("missing %s key in %s %s/%s", egressCACertKey, ref.Kind, bsp.Namespace, ref.Name)
+		}
+		if !x509.NewCertPool().AppendCertsFromPEM([]byte(caCert)) {
+			return nil, fmt.Errorf("no valid PEM certificate found in %s %s/%s", ref.Kind, bsp.Namespace, ref.Name)
+		}
+		cached.caCerts = append(cached.caCerts, caCert)
+	}
+
+	key := backendSecurityPolicyKey(bsp.Namespace, bsp.Name)
+	if v, ok := c.egressHTTPClients.Load(key); ok {
+		if existing := v.(*egressHTTPClient); existing.proxyURL == cached.proxyURL && slices.Equal(existing.caCerts, cached.caCerts) {
+			return existing.client, nil
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if cached.proxyURL != "" {
+		proxyURL, err := url.Parse(cached.proxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL %q: %w", cached.proxyURL, err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+	if len(cached.caCerts) > 0 {
+		rootCAs, err := x509.SystemCertPool()
+		if err != nil {
+			rootCAs = x509.NewCertPool()
+		}
+		for _, caCert := range cached.caCerts {
+			rootCAs.AppendCertsFromPEM([]byte(caCert))
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: rootCAs, MinVersion: tls.VersionTLS12}
+	}
+	cached.client = &http.Client{Transport: transport, Timeout: time.Minute}
+	if v, loaded := c.egressHTTPClients.Swap(key, &cached); loaded {
+		// The connections of the replaced client are not reused anymore.
+		v.(*egressHTTPClient).client.CloseIdleConnections()
+	}
+	return cached.client, nil
+}
+
+// deleteEgressHTTPClient removes the cached HTTP client of the given BackendSecurityPolicy, closing its idle connections.
+func (c *BackendSecurityPolicyController) deleteEgressHTTPClient(key string) {
+	if v, loaded := c.egressHTTPClients.LoadAndDelete(key); loaded {
+		v.(*egressHTTPClient).client.CloseIdleConnections()
+	}
+}
+
+// azureWorkloadIdentityProvider returns the cached Azure workload identity token provider of the given
+// BackendSecurityPolicy, creating a new one if the configuration has changed. The cached token is refreshed
+// once it enters the pre-rotation window so that each rotation stores a token valid beyond that window.
+func (c *BackendSecurityPolicyController) azureWorkloadIdentityProvider(bsp *aigv1b1.BackendSecurityPolicy,
+	workloadIdentity *aigv1b1.AzureWorkloadIdentity, options policy.TokenRequestOptions,
+) (tokenprovider.TokenProvider, error) {
+	key := backendSecurityPolicyKey(bsp.Namespace, bsp.Name)
+	cached := azureWorkloadIdentityProvider{
+		tenantID:      bsp.Spec.AzureCredentials.TenantID,
+		clientID:      bsp.Spec.AzureCredentials.ClientID,
+		tokenFilePath: workloadIdentity.TokenFilePath,
+	}
+	if v, ok := c.azureWorkloadIdentityProviders.Load(key); ok {
+		if existing := v.(*azureWorkloadIdentityProvider); existing.tenantID == cached.tenantID &&
+			existing.clientID == cached.clientID && existing.tokenFilePath == cached.tokenFilePath {
+			return existing.provider, nil
+		}
+	}
+	provider, err := tokenprovider.NewAzureWorkloadIdentityTokenProvider(cached.tenantID, cached.clientID,
+		cached.tokenFilePath, preRotationWindow, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create azure workload identity token provider: %w", err)
+	}
+	cached.provider = provider
+	c.azureWorkloadIdentityProviders.Store(key, &cached)
+	return provider, nil
+}
+
 func (c *BackendSecurityPolicyController) executeRotation(ctx context.Context, rotator rotators.Rotator, bsp *aigv1b1.BackendSecurityPolicy) (res ctrl.Result, err error) {
 	requeue := time.Minute
 	var rotationTime time.Time
@@ -279,7 +506,11 @@ func (c *BackendSecurityPolicyController) executeRotation(ctx context.Context, r
 	if err != nil && !apierrors.IsNotFound(err) {
 		c.logger.Error(err, "failed to get rotation time, retry in one minute")
 	} else {
-		if rotator.IsExpired(rotationTime) {
+		if force := rotateNowRequested(bsp); force || rotator.IsExpired(rotationTime) {
+			if force {
+				c.logger.Info("Forcing credential rotation as requested by the rotate-now annotation",
+					"namespace", bsp.Namespace, "name", bsp.Name)
+			}
 			var expirationTime time.Time
 			expirationTime, err = rotator.Rotate(ctx)
 			if err != nil {
@@ -423,7 +654,7 @@ func getBSPGeneratedSecretName(bsp *aigv1b1.BackendSecurityPolicy) string {
 			return ""
 		}
 	case aigv1b1.BackendSecurityPolicyTypeAzureCredentials:
-		if bsp.Spec.AzureCredentials.OIDCExchangeToken == nil {
+		if bsp.Spec.AzureCredentials.OIDCExchangeToken == nil && bsp.Spec.AzureCredentials.WorkloadIdentity == nil {
 			return ""
 		}
 	case aigv1b1.BackendSecurityPolicyTypeGCPCredentials:
@@ -434,6 +665,8 @@ func getBSPGeneratedSecretName(bsp *aigv1b1.BackendSecurityPolicy) string {
 		aigv1b1.BackendSecurityPolicyTypeAzureAPIKey,
 		aigv1b1.BackendSecurityPolicyTypeAnthropicAPIKey:
 		return "" // APIKey does not require rotation.
+	case aigv1b1.BackendSecurityPolicyTypeIBMCloudAPIKey,
+		aigv1b1.BackendSecurityPolicyTypeSAPAICoreCredentials:
 	default:
 		panic("BUG: unsupported backend security policy type: " + string(bsp.Spec.Type))
 	}
@@ -7,12 +7,14 @@ package controller
 
 import (
 	"context"
+	"encoding/pem"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/sts"
 	stsTypes "github.com/aws/aws-sdk-go-v2/service/sts/types"
@@ -36,6 +38,7 @@ import (
 
 	aigv1b1 "github.com/envoyproxy/ai-gateway/api/v1beta1"
 	"github.com/envoyproxy/ai-gateway/internal/controller/rotators"
+	"github.com/envoyproxy/ai-gateway/internal/controller/tokenprovider"
 	"github.com/envoyproxy/ai-gateway/internal/json"
 	internaltesting "github.com/envoyproxy/ai-gateway/internal/testing"
 )
@@ -561,6 +564,55 @@ func TestNewBackendSecurityPolicyController_ReconcileAzureMissingSecretData(t *t
 	require.Equal(t, time.Duration(0), res.RequeueAfter)
 }
 
+func TestNewBackendSecurityPolicyController_ReconcileAzureWorkloadIdentity(t *testing.T) {
+	eventCh := internaltesting.NewControllerEventChan[*aigv1b1.AIServiceBackend]()
+	cl := fake.NewClientBuilder().WithScheme(Scheme).Build()
+	c := NewBackendSecurityPolicyController(cl, fake2.NewClientset(), ctrl.Log, eventCh.Ch, nil)
+	bspName := "my-azure-workload-identity-policy"
+
+	bsp := &aigv1b1.BackendSecurityPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: bspName, Namespace: "default"},
+		Spec: aigv1b1.BackendSecurityPolicySpec{
+			Type: aigv1b1.BackendSecurityPolicyTypeAzureCredentials,
+			AzureCredentials: &aigv1b1.BackendSecurityPolicyAzureCredentials{
+				ClientID:         "some-client-id",
+				TenantID:         "some-tenant-id",
+				WorkloadIdentity: &aigv1b1.AzureWorkloadIdentity{},
+			},
+		},
+	}
+	require.NoError(t, cl.Create(t.Context(), bsp))
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: bspName}}
+
+	t.Run("missing token file", func(t *testing.T) {
+		t.Setenv(tokenprovider.AzureFederatedTokenFileEnv, "")
+		_, err := c.Reconcile(t.Context(), req)
+		require.ErrorContains(t, err, "failed to create azure workload identity token provider")
+	})
+
+	t.Run("provider cached across reconciliations", func(t *testing.T) {
+		options := policy.TokenRequestOptions{Scopes: []string{azureScopeURL}}
+		wi := &aigv1b1.AzureWorkloadIdentity{TokenFilePath: "/var/run/secrets/azure/tokens/azure-identity-token"}
+		p1, err := c.azureWorkloadIdentityProvider(bsp, wi, options)
+		require.NoError(t, err)
+		p2, err := c.azureWorkloadIdentityProvider(bsp, wi, options)
+		require.NoError(t, err)
+		require.Same(t, p1, p2)
+
+		// Changing the configuration creates a new provider.
+		p3, err := c.azureWorkloadIdentityProvider(bsp, &aigv1b1.AzureWorkloadIdentity{TokenFilePath: "/other"}, options)
+		require.NoError(t, err)
+		require.NotSame(t, p1, p3)
+
+		// Deleting the BackendSecurityPolicy drops the cached provider.
+		require.NoError(t, cl.Delete(t.Context(), bsp))
+		_, err = c.Reconcile(t.Context(), req)
+		require.NoError(t, err)
+		_, ok := c.azureWorkloadIdentityProviders.Load(backendSecurityPolicyKey("default", bspName))
+		require.False(t, ok)
+	})
+}
+
 func TestNewBackendSecurityPolicyController_RotateCredentialInvalidType(t *testing.T) {
 	eventCh := internaltesting.NewControllerEventChan[*aigv1b1.AIServiceBackend]()
 	cl := fake.NewClientBuilder().WithScheme(Scheme).Build()
@@ -769,6 +821,42 @@ func TestBackendSecurityPolicyController_ExecutionRotation(t *testing.T) {
 	res, err := c.executeRotation(ctx, rotator, bsp)
 	require.NoError(t, err)
 	require.Less(t, res.RequeueAfter, time.Hour)
+
+	// The rotated credentials have not expired, but the rotate-now annotation forces another rotation.
+	rotator, err = rotators.NewAWSOIDCRotator(ctx, cl, &mockSTSClient{now.Add(2 * time.Hour)}, fake2.NewClientset(),
+		ctrl.Log, bspNamespace, bsp.Name, preRotationWindow, &oidc, "placeholder", "us-east-1")
+	require.NoError(t, err)
+	res, err = c.executeRotation(ctx, rotator, bsp)
+	require.NoError(t, err)
+	require.Less(t, res.RequeueAfter, time.Hour)
+	bsp.Annotations = map[string]string{RotateNowAnnotationKey: "true"}
+	res, err = c.executeRotation(ctx, rotator, bsp)
+	require.NoError(t, err)
+	require.Greater(t, res.RequeueAfter, time.Hour)
+}
+
+func TestBackendSecurityPolicyController_Reconcile_RemovesRotateNowAnnotation(t *testing.T) {
+	fakeClient := requireNewFakeClientWithIndexes(t)
+	c := NewBackendSecurityPolicyController(fakeClient, fake2.NewClientset(), ctrl.Log, nil, nil)
+	require.NoError(t, fakeClient.Create(t.Context(), &aigv1b1.BackendSecurityPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "rotate-now", Namespace: "default",
+			Annotations: map[string]string{RotateNowAnnotationKey: "true", "foo": "bar"},
+		},
+		Spec: aigv1b1.BackendSecurityPolicySpec{
+			Type: aigv1b1.BackendSecurityPolicyTypeAPIKey,
+			APIKey: &aigv1b1.BackendSecurityPolicyAPIKey{
+				SecretRef: &gwapiv1.SecretObjectReference{Name: "mysecret"},
+			},
+		},
+	}))
+	_, err := c.Reconcile(t.Context(), reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "rotate-now"}})
+	require.NoError(t, err)
+
+	var bsp aigv1b1.BackendSecurityPolicy
+	require.NoError(t, fakeClient.Get(t.Context(), types.NamespacedName{Namespace: "default", Name: "rotate-now"}, &bsp))
+	require.Equal(t, map[string]string{"foo": "bar"}, bsp.Annotations)
+	require.Equal(t, aigv1b1.ConditionTypeAccepted, bsp.Status.Conditions[0].Type)
 }
 
 func TestValidateGCPCredentialsParams(t *testing.T) {
@@ -1170,6 +1258,93 @@ func TestBackendSecurityPolicyController_RotateCredential_GCPCredentials_Missing
 	require.Equal(t, ctrl.Result{}, res)
 }
 
+func TestBackendSecurityPolicyController_RotateCredential_IBMCloudAPIKey(t *testing.T) {
+	iamServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/identity/token", r.URL.Path)
+		require.NoError(t, r.ParseForm())
+		require.Equal(t, "some-api-key", r.PostForm.Get("apikey"))
+		_, _ = w.Write([]byte(`{"access_token":"some-ibm-token","expires_in":3600}`))
+	}))
+	defer iamServer.Close()
+
+	cl := fake.NewClientBuilder().WithScheme(Scheme).Build()
+	c := NewBackendSecurityPolicyController(cl, fake2.NewClientset(), ctrl.Log, nil, nil)
+	bsp := &aigv1b1.BackendSecurityPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "ibm-policy", Namespace: "default"},
+		Spec: aigv1b1.BackendSecurityPolicySpec{
+			Type: aigv1b1.BackendSecurityPolicyTypeIBMCloudAPIKey,
+			IBMCloudAPIKey: &aigv1b1.BackendSecurityPolicyIBMCloudAPIKey{
+				SecretRef: &gwapiv1.SecretObjectReference{Name: "ibm-api-key"},
+				IAMURL:    iamServer.URL,
+			},
+		},
+	}
+	require.NoError(t, cl.Create(t.Context(), bsp))
+
+	_, err := c.rotateCredential(t.Context(), bsp)
+	require.ErrorContains(t, err, `"ibm-api-key" not found`)
+
+	require.NoError(t, cl.Create(t.Context(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "ibm-api-key", Namespace: "default"},
+		Data:       map[string][]byte{"apiKey": []byte("some-api-key")},
+	}))
+	res, err := c.rotateCredential(t.Context(), bsp)
+	require.NoError(t, err)
+	require.WithinDuration(t, time.Now().Add(time.Hour-preRotationWindow), time.Now().Add(res.RequeueAfter), time.Minute)
+
+	secret, err := rotators.LookupSecret(t.Context(), cl, "default", rotators.GetBSPSecretName("ibm-policy"))
+	require.NoError(t, err)
+	require.Equal(t, "some-ibm-token", string(secret.Data[rotators.IBMCloudAccessTokenKey]))
+	ok, _ := ctrlutil.HasOwnerReference(secret.OwnerReferences, bsp, cl.Scheme())
+	require.True(t, ok)
+}
+
+func TestBackendSecurityPolicyController_RotateCredential_SAPAICoreCredentials(t *testing.T) {
+	xsuaaServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/oauth/token", r.URL.Path)
+		clientID, clientSecret, _ := r.BasicAuth()
+		require.Equal(t, "some-client-id", clientID)
+		require.Equal(t, "some-client-secret", clientSecret)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"some-sap-token","token_type":"bearer","expires_in":43199}`))
+	}))
+	defer xsuaaServer.Close()
+
+	cl := fake.NewClientBuilder().WithScheme(Scheme).Build()
+	c := NewBackendSecurityPolicyController(cl, fake2.NewClientset(), ctrl.Log, nil, nil)
+	bsp := &aigv1b1.BackendSecurityPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "sap-policy", Namespace: "default"},
+		Spec: aigv1b1.BackendSecurityPolicySpec{
+			Type: aigv1b1.BackendSecurityPolicyTypeSAPAICoreCredentials,
+			SAPAICoreCredentials: &aigv1b1.BackendSecurityPolicySAPAICoreCredentials{
+				AuthURL:         xsuaaServer.URL,
+				ClientID:        "some-client-id",
+				ClientSecretRef: &gwapiv1.SecretObjectReference{Name: "sap-client-secret", Namespace: ptr.To[gwapiv1.Namespace]("secrets")},
+			},
+		},
+	}
+	require.NoError(t, cl.Create(t.Context(), bsp))
+
+	// The client secret is looked up in the namespace of the reference.
+	require.NoError(t, cl.Create(t.Context(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "sap-client-secret", Namespace: "secrets"},
+		Data:       map[string][]byte{"clientsecret": []byte("some-client-secret")},
+	}))
+	_, err := c.rotateCredential(t.Context(), bsp)
+	require.ErrorContains(t, err, "missing key client-secret in secret secrets/sap-client-secret")
+
+	secret, err := rotators.LookupSecret(t.Context(), cl, "secrets", "sap-client-secret")
+	require.NoError(t, err)
+	secret.Data = map[string][]byte{clientSecretKey: []byte("some-client-secret")}
+	require.NoError(t, cl.Update(t.Context(), secret))
+	_, err = c.rotateCredential(t.Context(), bsp)
+	require.NoError(t, err)
+
+	secret, err = rotators.LookupSecret(t.Context(), cl, "default", rotators.GetBSPSecretName("sap-policy"))
+	require.NoError(t, err)
+	require.Equal(t, "some-sap-token", string(secret.Data[rotators.SAPAICoreAccessTokenKey]))
+}
+
 func TestGetBSPGeneratedSecretName(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -1252,6 +1427,21 @@ func TestGetBSPGeneratedSecretName(t *testing.T) {
 			},
 			expectedName: "ai-eg-bsp-azure-oidc-bsp",
 		},
+		{
+			name: "Azure with WorkloadIdentity",
+			bsp: &aigv1b1.BackendSecurityPolicy{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "azure-wi-bsp",
+				},
+				Spec: aigv1b1.BackendSecurityPolicySpec{
+					Type: aigv1b1.BackendSecurityPolicyTypeAzureCredentials,
+					AzureCredentials: &aigv1b1.BackendSecurityPolicyAzureCredentials{
+						WorkloadIdentity: &aigv1b1.AzureWorkloadIdentity{},
+					},
+				},
+			},
+			expectedName: "ai-eg-bsp-azure-wi-bsp",
+		},
 		{
 			name: "GCP type",
 			bsp: &aigv1b1.BackendSecurityPolicy{
@@ -1300,6 +1490,30 @@ func TestGetBSPGeneratedSecretName(t *testing.T) {
 			},
 			expectedName: "",
 		},
+		{
+			name: "IBMCloudAPIKey type",
+			bsp: &aigv1b1.BackendSecurityPolicy{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "ibm-bsp",
+				},
+				Spec: aigv1b1.BackendSecurityPolicySpec{
+					Type: aigv1b1.BackendSecurityPolicyTypeIBMCloudAPIKey,
+				},
+			},
+			expectedName: "ai-eg-bsp-ibm-bsp",
+		},
+		{
+			name: "SAPAICoreCredentials type",
+			bsp: &aigv1b1.BackendSecurityPolicy{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "sap-bsp",
+				},
+				Spec: aigv1b1.BackendSecurityPolicySpec{
+					Type: aigv1b1.BackendSecurityPolicyTypeSAPAICoreCredentials,
+				},
+			},
+			expectedName: "ai-eg-bsp-sap-bsp",
+		},
 	}
 
 	for _, tt := range tests {
@@ -1311,3 +1525,131 @@ func TestGetBSPGeneratedSecretName(t *testing.T) {
 		})
 	}
 }
+
+func TestBackendSecurityPolicyController_EgressHTTPClient(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	caCert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+
+	cl := fake.NewClientBuilder().WithScheme(Scheme).WithObjects(
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "ca-configmap", Namespace: "default"},
+			Data:       map[string]string{egressCACertKey: string(caCert)},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "ca-secret", Namespace: "default"},
+			Data:       map[string][]byte{egressCACertKey: caCert},
+		},
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "invalid-ca", Namespace: "default"},
+			Data:       map[string]string{egressCACertKey: "not a certificate"},
+		},
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "missing-key", Namespace: "default"},
+			Data:       map[string]string{"other": string(caCert)},
+		},
+	).Build()
+	c := NewBackendSecurityPolicyController(cl, fake2.NewClientset(), ctrl.Log,
+		internaltesting.NewControllerEventChan[*aigv1b1.AIServiceBackend]().Ch, nil)
+	newBSP := func(egress *aigv1b1.BackendSecurityPolicyEgress) *aigv1b1.BackendSecurityPolicy {
+		return &aigv1b1.BackendSecurityPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "bsp", Namespace: "default"},
+			Spec:       aigv1b1.BackendSecurityPolicySpec{Egress: egress},
+		}
+	}
+
+	t.Run("proxy", func(t *testing.T) {
+		hc, err := c.egressHTTPClient(t.Context(), newBSP(&aigv1b1.BackendSecurityPolicyEgress{ProxyURL: "http://proxy.corp:3128"}))
+		require.NoError(t, err)
+		req, err := http.NewRequest(http.MethodPost, "https://sts.amazonaws.com", nil)
+		require.NoError(t, err)
+		proxyURL, err := hc.Transport.(*http.Transport).Proxy(req)
+		require.NoError(t, err)
+		require.Equal(t, "http://proxy.corp:3128", proxyURL.String())
+	})
+
+	for _, kind := range []string{"ConfigMap", "Secret"} {
+		t.Run("ca certificate from "+kind, func(t *testing.T) {
+			name := "ca-configmap"
+			if kind == "Secret" {
+				name = "ca-secret"
+			}
+			hc, err := c.egressHTTPClient(t.Context(), newBSP(&aigv1b1.BackendSecurityPolicyEgress{
+				CACertificateRefs: []gwapiv1.LocalObjectReference{{Kind: gwapiv1.Kind(kind), Name: gwapiv1.ObjectName(name)}},
+			}))
+			require.NoError(t, err)
+			resp, err := hc.Get(server.URL)
+			require.NoError(t, err)
+			_ = resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+		})
+	}
+
+	t.Run("cached until the configuration changes", func(t *testing.T) {
+		bsp := newBSP(&aigv1b1.BackendSecurityPolicyEgress{
+			ProxyURL:          "http://proxy.corp:3128",
+			CACertificateRefs: []gwapiv1.LocalObjectReference{{Kind: "ConfigMap", Name: "ca-configmap"}},
+		})
+		hc, err := c.egressHTTPClient(t.Context(), bsp)
+		require.NoError(t, err)
+		same, err := c.egressHTTPClient(t.Context(), bsp)
+		require.NoError(t, err)
+		require.Same(t, hc, same)
+
+		bsp.Spec.Egress.ProxyURL = "http://other-proxy.corp:3128"
+		changed, err := c.egressHTTPClient(t.Context(), bsp)
+		require.NoError(t, err)
+		require.NotSame(t, hc, changed)
+
+		// The content of the CA certificates is compared, not only the references.
+		var cm corev1.ConfigMap
+		require.NoError(t, cl.Get(t.Context(), client.ObjectKey{Namespace: "default", Name: "ca-configmap"}, &cm))
+		cm.Data[egressCACertKey] += string(caCert)
+		require.NoError(t, cl.Update(t.Context(), &cm))
+		rotated, err := c.egressHTTPClient(t.Context(), bsp)
+		require.NoError(t, err)
+		require.NotSame(t, changed, rotated)
+
+		c.deleteEgressHTTPClient(backendSecurityPolicyKey("default", "bsp"))
+		_, ok := c.egressHTTPClients.Load(backendSecurityPolicyKey("default", "bsp"))
+		require.False(t, ok)
+	})
+
+	t.Run("untrusted without ca certificate", func(t *testing.T) {
+		hc, err := c.egressHTTPClient(t.Context(), newBSP(&aigv1b1.BackendSecurityPolicyEgress{}))
+		require.NoError(t, err)
+		_, err = hc.Get(server.URL)
+		require.ErrorContains(t, err, "certificate")
+	})
+
+	for _, tc := range []struct {
+		name   string
+		ref    gwapiv1.LocalObjectReference
+		expErr string
+	}{
+		{
+			name:   "missing configmap",
+			ref:    gwapiv1.LocalObjectReference{Kind: "ConfigMap", Name: "missing"},
+			expErr: "failed to get ConfigMap default/missing",
+		},
+		{
+			name:   "invalid certificate",
+			ref:    gwapiv1.LocalObjectReference{Kind: "ConfigMap", Name: "invalid-ca"},
+			expErr: "no valid PEM certificate found in ConfigMap default/invalid-ca",
+		},
+		{
+			name:   "missing key",
+			ref:    gwapiv1.LocalObjectReference{Kind: "ConfigMap", Name: "missing-key"},
+			expErr: "missing ca.crt key in ConfigMap default/missing-key",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := c.egressHTTPClient(t.Context(), newBSP(&aigv1b1.BackendSecurityPolicyEgress{
+				CACertificateRefs: []gwapiv1.LocalObjectReference{tc.ref},
+			}))
+			require.ErrorContains(t, err, tc.expErr)
+		})
+	}
+}
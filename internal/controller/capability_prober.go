@@ -0,0 +1,366 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package controller
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	egv1a1 "github.com/envoyproxy/gateway/api/v1alpha1"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	aigv1b1 "github.com/envoyproxy/ai-gateway/api/v1beta1"
+	"github.com/envoyproxy/ai-gateway/internal/json"
+)
+
+// maxProbedModels is the maximum number of models in the capabilities of an AIServiceBackend.
+const maxProbedModels = 64
+
+// capabilityProbeImage is a 1x1 PNG image sent to probe the vision of the models.
+const capabilityProbeImage = "data:image/png;base64,iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAYAAAAfFcSJAAAADUlEQVR42mP8z8BQDwAEhQGAhKmMIQAAAABJRU5ErkJggg=="
+
+// capabilityProbes are the chat completion requests probing the features of a model, without the model. A model
+// supports a feature if it accepts the request.
+var capabilityProbes = []struct {
+	feature string
+	body    string
+	set     func(m *aigv1b1.AIServiceBackendModelCapabilities, supported bool)
+}{
+	{
+		feature: "tools",
+		body: `"messages":[{"role":"user","content":"ping"}],` +
+			`"tools":[{"type":"function","function":{"name":"ping","parameters":{"type":"object","properties":{}}}}]`,
+		set: func(m *aigv1b1.AIServiceBackendModelCapabilities, supported bool) { m.Tools = &supported },
+	},
+	{
+		feature: "vision",
+		body: `"messages":[{"role":"user","content":[{"type":"text","text":"ping"},` +
+			`{"type":"image_url","image_url":{"url":"` + capabilityProbeImage + `"}}]}]`,
+		set: func(m *aigv1b1.AIServiceBackendModelCapabilities, supported bool) { m.Vision = &supported },
+	},
+	{
+		feature: "JSON mode",
+		body:    `"messages":[{"role":"user","content":"Reply with an empty JSON object."}],"response_format":{"type":"json_object"}`,
+		set:     func(m *aigv1b1.AIServiceBackendModelCapabilities, supported bool) { m.JSONMode = &supported },
+	},
+}
+
+// capabilityProber periodically probes the AIServiceBackends of the OpenAI schema for the models they serve and the
+// features of the models routed to them, and reports them in the status of the backends. This keeps the capabilities
+// in sync with the providers instead of relying on the manual declarations going stale.
+//
+// The models are listed with the models endpoint of the backend, and each feature is probed with a minimal chat
+// completion request using it, which costs a few tokens per model and feature at each probe.
+type capabilityProber struct {
+	client client.Client
+	// reader reads the Secrets of the API keys without the cache.
+	reader     client.Reader
+	logger     logr.Logger
+	httpClient *http.Client
+	interval   time.Duration
+	// modelNameHeaderKey is the request header matched by the rules of the AIGatewayRoutes to route the models.
+	modelNameHeaderKey string
+	// now and backendURL are replaced in the tests.
+	now        func() time.Time
+	backendURL func(backend *egv1a1.Backend) (string, error)
+}
+
+// newCapabilityProber creates the runnable of the capability probing.
+func newCapabilityProber(c client.Client, reader client.Reader, logger logr.Logger, interval time.Duration, modelNameHeaderKey string) *capabilityProber {
+	return &capabilityProber{
+		client:             c,
+		reader:             reader,
+		logger:             logger,
+		httpClient:         &http.Client{Timeout: time.Minute},
+		interval:           interval,
+		modelNameHeaderKey: modelNameHeaderKey,
+		now:                time.Now,
+		backendURL:         envoyGatewayBackendURL,
+	}
+}
+
+// Start implements [manager.Runnable].
+func (r *capabilityProber) Start(ctx context.Context) error {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		if err := r.reconcile(ctx); err != nil {
+			r.logger.Error(err, "failed to probe the capabilities of the backends")
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// NeedLeaderElection implements [manager.LeaderElectionRunnable], so that only the leader probes the backends.
+func (r *capabilityProber) NeedLeaderElection() bool { return true }
+
+// reconcile probes the backends of the OpenAI schema and updates their status.
+func (r *capabilityProber) reconcile(ctx context.Context) error {
+	var backends aigv1b1.AIServiceBackendList
+	if err := r.client.List(ctx, &backends); err != nil {
+		return fmt.Errorf("failed to list AIServiceBackends: %w", err)
+	}
+	var routes aigv1b1.AIGatewayRouteList
+	if err := r.client.List(ctx, &routes); err != nil {
+		return fmt.Errorf("failed to list AIGatewayRoutes: %w", err)
+	}
+	routed := routedModels(routes.Items, r.modelNameHeaderKey)
+
+	for i := range backends.Items {
+		backend := &backends.Items[i]
+		if backend.Spec.APISchema.Name != aigv1b1.APISchemaOpenAI {
+			continue
+		}
+		key := client.ObjectKeyFromObject(backend)
+		models, probeErr := r.probe(ctx, backend, routed[key])
+		if probeErr != nil {
+			r.logger.Info("failed to probe the capabilities of the backend", "namespace", key.Namespace, "name", key.Name,
+				"error", probeErr.Error())
+		}
+		r.updateStatus(ctx, backend, models, probeErr)
+	}
+	return nil
+}
+
+// routedModels returns the models routed by the AIGatewayRoutes to each AIServiceBackend, i.e. the ModelNameOverride
+// of the backend references, or else the models matched by the exact model name header matches of the rule.
+func routedModels(routes []aigv1b1.AIGatewayRoute, modelNameHeaderKey string) map[client.ObjectKey][]string {
+	routed := make(map[client.ObjectKey][]string)
+	for i := range routes {
+		route := &routes[i]
+		for j := range route.Spec.Rules {
+			rule := &route.Spec.Rules[j]
+			var matched []string
+			for _, m := range rule.Matches {
+				for _, h := range m.Headers {
+					if string(h.Name) == modelNameHeaderKey && ptr.Deref(h.Type, gwapiv1.HeaderMatchExact) == gwapiv1.HeaderMatchExact {
+						matched = append(matched, h.Value)
+					}
+				}
+			}
+			for k := range rule.BackendRefs {
+				ref := &rule.BackendRefs[k]
+				if !ref.IsAIServiceBackend() {
+					continue
+				}
+				key := client.ObjectKey{Namespace: ref.GetNamespace(route.Namespace), Name: ref.Name}
+				if ref.ModelNameOverride != "" {
+					routed[key] = append(routed[key], ref.ModelNameOverride)
+				} else {
+					routed[key] = append(routed[key], matched...)
+				}
+			}
+		}
+	}
+	for key, models := range routed {
+		slices.Sort(models)
+		routed[key] = slices.Compact(models)
+	}
+	return routed
+}
+
+// probe lists the models of the backend and probes the features of the routed ones.
+func (r *capabilityProber) probe(ctx context.Context, backend *aigv1b1.AIServiceBackend, routed []string) ([]aigv1b1.AIServiceBackendModelCapabilities, error) {
+	baseURL, apiKey, err := r.resolveBackend(ctx, backend)
+	if err != nil {
+		return nil, err
+	}
+
+	var list struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err = getJSON(ctx, r.httpClient, baseURL+"/models", apiKey, &list); err != nil {
+		return nil, fmt.Errorf("failed to list the models: %w", err)
+	}
+	// The routed models are kept first when the backend lists too many models.
+	var names, others []string
+	for _, m := range list.Data {
+		if _, ok := slices.BinarySearch(routed, m.ID); ok {
+			names = append(names, m.ID)
+		} else {
+			others = append(others, m.ID)
+		}
+	}
+	slices.Sort(names)
+	names = slices.Compact(names)
+	probed := len(names)
+	slices.Sort(others)
+	names = append(names, slices.Compact(others)...)
+	if len(names) > maxProbedModels {
+		names = names[:maxProbedModels]
+	}
+
+	models := make([]aigv1b1.AIServiceBackendModelCapabilities, len(names))
+	for i, name := range names {
+		models[i].Name = name
+		if i >= probed {
+			continue
+		}
+		for _, p := range capabilityProbes {
+			supported, probeErr := r.probeFeature(ctx, baseURL, apiKey, name, p.body)
+			if probeErr != nil {
+				return nil, fmt.Errorf("failed to probe the %s of model %s: %w", p.feature, name, probeErr)
+			}
+			p.set(&models[i], supported)
+		}
+	}
+	slices.SortFunc(models, func(a, b aigv1b1.AIServiceBackendModelCapabilities) int { return strings.Compare(a.Name, b.Name) })
+	return models, nil
+}
+
+// probeFeature sends the chat completion request of a probe to the model. The feature is supported if the request
+// is accepted, and not supported if it is rejected as invalid. The other responses, e.g. the rate limited ones, fail
+// the probe.
+func (r *capabilityProber) probeFeature(ctx context.Context, baseURL, apiKey, model, probe string) (bool, error) {
+	modelJSON, err := json.Marshal(model)
+	if err != nil {
+		return false, err
+	}
+	body := `{"model":` + string(modelJSON) + `,"max_completion_tokens":16,` + probe + `}`
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, baseURL+"/chat/completions", bytes.NewReader([]byte(body)))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return true, nil
+	case resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusNotFound ||
+		resp.StatusCode == http.StatusUnsupportedMediaType || resp.StatusCode == http.StatusUnprocessableEntity:
+		return false, nil
+	default:
+		return false, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, respBody)
+	}
+}
+
+// resolveBackend returns the base URL of the OpenAI API of the backend and its API key, if any.
+func (r *capabilityProber) resolveBackend(ctx context.Context, backend *aigv1b1.AIServiceBackend) (baseURL, apiKey string, err error) {
+	ref := backend.Spec.BackendRef
+	if ptr.Deref(ref.Kind, "Backend") != "Backend" {
+		return "", "", fmt.Errorf("unsupported backend kind %s", *ref.Kind)
+	}
+	var egBackend egv1a1.Backend
+	egBackendKey := client.ObjectKey{Namespace: string(ptr.Deref(ref.Namespace, gwapiv1.Namespace(backend.Namespace))), Name: string(ref.Name)}
+	if err = r.client.Get(ctx, egBackendKey, &egBackend); err != nil {
+		return "", "", fmt.Errorf("failed to get Backend %s: %w", egBackendKey, err)
+	}
+	if baseURL, err = r.backendURL(&egBackend); err != nil {
+		return "", "", err
+	}
+	prefix := strings.Trim(ptr.Deref(backend.Spec.APISchema.Prefix, ""), "/")
+	if prefix == "" {
+		prefix = "v1"
+	}
+	baseURL += "/" + prefix
+
+	var bsps aigv1b1.BackendSecurityPolicyList
+	if err = r.client.List(ctx, &bsps, client.InNamespace(backend.Namespace),
+		client.MatchingFields{k8sClientIndexAIServiceBackendToTargetingBackendSecurityPolicy: fmt.Sprintf("%s.%s", backend.Name, backend.Namespace)}); err != nil {
+		return "", "", fmt.Errorf("failed to list BackendSecurityPolicies: %w", err)
+	}
+	if len(bsps.Items) == 0 {
+		return baseURL, "", nil
+	}
+	spec := &bsps.Items[0].Spec
+	if spec.Type != aigv1b1.BackendSecurityPolicyTypeAPIKey || spec.APIKey == nil {
+		return "", "", fmt.Errorf("unsupported BackendSecurityPolicy type %s", spec.Type)
+	}
+	// The backends authenticated with a pool of API keys are probed with the first one.
+	var secretName string
+	if spec.APIKey.SecretRef != nil {
+		secretName = string(spec.APIKey.SecretRef.Name)
+	} else if len(spec.APIKey.Pool) > 0 {
+		secretName = string(spec.APIKey.Pool[0].SecretRef.Name)
+	}
+	if secretName == "" {
+		return baseURL, "", nil
+	}
+	var secret corev1.Secret
+	if err = r.reader.Get(ctx, client.ObjectKey{Namespace: backend.Namespace, Name: secretName}, &secret); err != nil {
+		return "", "", fmt.Errorf("failed to get the API key secret %s: %w", secretName, err)
+	}
+	apiKey = string(secret.Data[apiKeyInSecret])
+	return baseURL, apiKey, nil
+}
+
+// envoyGatewayBackendURL returns the URL of the first endpoint of the Envoy Gateway Backend. HTTPS is used if the
+// Backend configures TLS or the endpoint listens on the port 443.
+func envoyGatewayBackendURL(backend *egv1a1.Backend) (string, error) {
+	for _, e := range backend.Spec.Endpoints {
+		var host string
+		var port int32
+		switch {
+		case e.FQDN != nil:
+			host, port = e.FQDN.Hostname, e.FQDN.Port
+		case e.IP != nil:
+			host, port = e.IP.Address, e.IP.Port
+		default:
+			continue
+		}
+		scheme := "http"
+		if backend.Spec.TLS != nil || port == 443 {
+			scheme = "https"
+		}
+		return scheme + "://" + net.JoinHostPort(host, strconv.Itoa(int(port))), nil
+	}
+	return "", fmt.Errorf("no FQDN or IP endpoint in Backend %s/%s", backend.Namespace, backend.Name)
+}
+
+// updateStatus sets the capabilities in the status of the AIServiceBackend. The models of the previous probe are kept
+// if the probe failed.
+func (r *capabilityProber) updateStatus(ctx context.Context, backend *aigv1b1.AIServiceBackend, models []aigv1b1.AIServiceBackendModelCapabilities, probeErr error) {
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if err := r.client.Get(ctx, client.ObjectKey{Name: backend.Name, Namespace: backend.Namespace}, backend); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil
+			}
+			return err
+		}
+		capabilities := &aigv1b1.AIServiceBackendCapabilities{Models: models, LastProbeTime: metav1.NewTime(r.now())}
+		if probeErr != nil {
+			capabilities.Message = probeErr.Error()
+			if previous := backend.Status.Capabilities; previous != nil {
+				capabilities.Models = previous.Models
+			}
+		}
+		backend.Status.Capabilities = capabilities
+		return r.client.Status().Update(ctx, backend)
+	})
+	if err != nil {
+		r.logger.Error(err, "failed to update the capabilities of AIServiceBackend",
+			"namespace", backend.Namespace, "name", backend.Name)
+	}
+}
@@ -0,0 +1,213 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package controller
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	egv1a1 "github.com/envoyproxy/gateway/api/v1alpha1"
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gwapiv1a2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+
+	aigv1b1 "github.com/envoyproxy/ai-gateway/api/v1beta1"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+)
+
+func TestCapabilityProber(t *testing.T) {
+	c := requireNewFakeClientWithIndexes(t)
+	for _, obj := range []client.Object{
+		&aigv1b1.AIServiceBackend{
+			ObjectMeta: metav1.ObjectMeta{Name: "openai", Namespace: "default"},
+			Spec: aigv1b1.AIServiceBackendSpec{
+				APISchema:  aigv1b1.VersionedAPISchema{Name: aigv1b1.APISchemaOpenAI},
+				BackendRef: gwapiv1.BackendObjectReference{Name: "openai", Kind: ptr.To(gwapiv1.Kind("Backend")), Group: ptr.To(gwapiv1.Group("gateway.envoyproxy.io"))},
+			},
+		},
+		&aigv1b1.AIServiceBackend{
+			ObjectMeta: metav1.ObjectMeta{Name: "bedrock", Namespace: "default"},
+			Spec: aigv1b1.AIServiceBackendSpec{
+				APISchema:  aigv1b1.VersionedAPISchema{Name: aigv1b1.APISchemaAWSBedrock},
+				BackendRef: gwapiv1.BackendObjectReference{Name: "bedrock"},
+			},
+		},
+		&egv1a1.Backend{
+			ObjectMeta: metav1.ObjectMeta{Name: "openai", Namespace: "default"},
+			Spec: egv1a1.BackendSpec{Endpoints: []egv1a1.BackendEndpoint{
+				{FQDN: &egv1a1.FQDNEndpoint{Hostname: "api.openai.com", Port: 443}},
+			}},
+		},
+		&aigv1b1.BackendSecurityPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "openai", Namespace: "default"},
+			Spec: aigv1b1.BackendSecurityPolicySpec{
+				Type: aigv1b1.BackendSecurityPolicyTypeAPIKey,
+				TargetRefs: []gwapiv1a2.LocalPolicyTargetReference{
+					{Group: aiServiceBackendGroup, Kind: aiServiceBackendKind, Name: "openai"},
+				},
+				APIKey: &aigv1b1.BackendSecurityPolicyAPIKey{SecretRef: &gwapiv1.SecretObjectReference{Name: "openai-key"}},
+			},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "openai-key", Namespace: "default"},
+			Data:       map[string][]byte{apiKeyInSecret: []byte("sk-test")},
+		},
+		&aigv1b1.AIGatewayRoute{
+			ObjectMeta: metav1.ObjectMeta{Name: "route", Namespace: "default"},
+			Spec: aigv1b1.AIGatewayRouteSpec{Rules: []aigv1b1.AIGatewayRouteRule{
+				{
+					Matches: []aigv1b1.AIGatewayRouteRuleMatch{{Headers: []gwapiv1.HTTPHeaderMatch{
+						{Name: internalapi.ModelNameHeaderKeyDefault, Value: "gpt-4o"},
+						{Name: internalapi.ModelNameHeaderKeyDefault, Value: "gpt-3.5-turbo"},
+					}}},
+					BackendRefs: []aigv1b1.AIGatewayRouteRuleBackendRef{{Name: "openai"}},
+				},
+			}},
+		},
+	} {
+		require.NoError(t, c.Create(t.Context(), obj))
+	}
+
+	var failing bool
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "Bearer sk-test", r.Header.Get("Authorization"))
+		if failing {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/models":
+			_, _ = w.Write([]byte(`{"object":"list","data":[{"id":"gpt-4o"},{"id":"gpt-3.5-turbo"},{"id":"dall-e-3"}]}`))
+		case "/v1/chat/completions":
+			body, err := io.ReadAll(r.Body)
+			require.NoError(t, err)
+			// gpt-3.5-turbo only supports the tools.
+			if strings.Contains(string(body), `"gpt-3.5-turbo"`) && !strings.Contains(string(body), `"tools"`) {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			_, _ = w.Write([]byte(`{"choices":[]}`))
+		default:
+			t.Fatalf("unexpected path %s", r.URL.Path)
+		}
+	}))
+	defer backend.Close()
+
+	now := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+	r := newCapabilityProber(c, c, logr.Discard(), time.Hour, internalapi.ModelNameHeaderKeyDefault)
+	r.now = func() time.Time { return now }
+	r.backendURL = func(b *egv1a1.Backend) (string, error) {
+		require.Equal(t, "openai", b.Name)
+		return backend.URL, nil
+	}
+	require.NoError(t, r.reconcile(t.Context()))
+
+	var got aigv1b1.AIServiceBackend
+	require.NoError(t, c.Get(t.Context(), client.ObjectKey{Namespace: "default", Name: "openai"}, &got))
+	expModels := []aigv1b1.AIServiceBackendModelCapabilities{
+		{Name: "dall-e-3"},
+		{Name: "gpt-3.5-turbo", Tools: ptr.To(true), Vision: ptr.To(false), JSONMode: ptr.To(false)},
+		{Name: "gpt-4o", Tools: ptr.To(true), Vision: ptr.To(true), JSONMode: ptr.To(true)},
+	}
+	require.Equal(t, expModels, got.Status.Capabilities.Models)
+	require.Empty(t, got.Status.Capabilities.Message)
+	require.Equal(t, now, got.Status.Capabilities.LastProbeTime.UTC())
+
+	// The backends of the other schemas are not probed.
+	require.NoError(t, c.Get(t.Context(), client.ObjectKey{Namespace: "default", Name: "bedrock"}, &got))
+	require.Nil(t, got.Status.Capabilities)
+
+	t.Run("probe error keeps the previous models", func(t *testing.T) {
+		failing = true
+		now = now.Add(time.Hour)
+		require.NoError(t, r.reconcile(t.Context()))
+		require.NoError(t, c.Get(t.Context(), client.ObjectKey{Namespace: "default", Name: "openai"}, &got))
+		require.Equal(t, expModels, got.Status.Capabilities.Models)
+		require.Equal(t, now, got.Status.Capabilities.LastProbeTime.UTC())
+		require.Contains(t, got.Status.Capabilities.Message, "failed to list the models: ")
+	})
+}
+
+func Test_routedModels(t *testing.T) {
+	routes := []aigv1b1.AIGatewayRoute{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "ns1"},
+			Spec: aigv1b1.AIGatewayRouteSpec{Rules: []aigv1b1.AIGatewayRouteRule{
+				{
+					Matches: []aigv1b1.AIGatewayRouteRuleMatch{{Headers: []gwapiv1.HTTPHeaderMatch{
+						{Name: internalapi.ModelNameHeaderKeyDefault, Value: "b"},
+						{Name: internalapi.ModelNameHeaderKeyDefault, Value: "a"},
+						{Name: internalapi.ModelNameHeaderKeyDefault, Value: "gpt-.*", Type: ptr.To(gwapiv1.HeaderMatchRegularExpression)},
+						{Name: "x-other", Value: "c"},
+					}}},
+					BackendRefs: []aigv1b1.AIGatewayRouteRuleBackendRef{
+						{Name: "backend"},
+						{Name: "override", ModelNameOverride: "o"},
+					},
+				},
+			}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "b", Namespace: "ns2"},
+			Spec: aigv1b1.AIGatewayRouteSpec{Rules: []aigv1b1.AIGatewayRouteRule{
+				{
+					Matches: []aigv1b1.AIGatewayRouteRuleMatch{{Headers: []gwapiv1.HTTPHeaderMatch{
+						{Name: internalapi.ModelNameHeaderKeyDefault, Value: "a"},
+					}}},
+					BackendRefs: []aigv1b1.AIGatewayRouteRuleBackendRef{{Name: "backend", Namespace: ptr.To(gwapiv1.Namespace("ns1"))}},
+				},
+			}},
+		},
+	}
+	require.Equal(t, map[client.ObjectKey][]string{
+		{Namespace: "ns1", Name: "backend"}:  {"a", "b"},
+		{Namespace: "ns1", Name: "override"}: {"o"},
+	}, routedModels(routes, internalapi.ModelNameHeaderKeyDefault))
+}
+
+func Test_envoyGatewayBackendURL(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		spec   egv1a1.BackendSpec
+		exp    string
+		expErr string
+	}{
+		{
+			name: "fqdn https",
+			spec: egv1a1.BackendSpec{Endpoints: []egv1a1.BackendEndpoint{{FQDN: &egv1a1.FQDNEndpoint{Hostname: "api.openai.com", Port: 443}}}},
+			exp:  "https://api.openai.com:443",
+		},
+		{
+			name: "ip http",
+			spec: egv1a1.BackendSpec{Endpoints: []egv1a1.BackendEndpoint{{IP: &egv1a1.IPEndpoint{Address: "10.0.0.1", Port: 8080}}}},
+			exp:  "http://10.0.0.1:8080",
+		},
+		{
+			name:   "no endpoint",
+			spec:   egv1a1.BackendSpec{Endpoints: []egv1a1.BackendEndpoint{{Unix: &egv1a1.UnixSocket{Path: "/tmp/sock"}}}},
+			expErr: "no FQDN or IP endpoint in Backend default/b",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			url, err := envoyGatewayBackendURL(&egv1a1.Backend{ObjectMeta: metav1.ObjectMeta{Name: "b", Namespace: "default"}, Spec: tc.spec})
+			if tc.expErr != "" {
+				require.EqualError(t, err, tc.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.exp, url)
+		})
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	"github.com/envoyproxy/ai-gateway/internal/configstream"
+	"github.com/envoyproxy/ai-gateway/internal/filterapi"
+)
+
+// configStreamFollower serves the filter configs over the config stream of a replica that is not the leader of the
+// controller manager, since the Service of the config stream routes the external processors to every replica.
+//
+// The leader publishes the filter configs as it writes them, while the other replicas publish the ones read from
+// the filter config bundle Secrets written by the leader. This implements [manager.Runnable] on every replica.
+type configStreamFollower struct {
+	kube      kubernetes.Interface
+	gateways  cache.Informers
+	logger    logr.Logger
+	publisher filterConfigPublisher
+	// elected is closed once this replica is the leader of the controller manager.
+	elected <-chan struct{}
+}
+
+func newConfigStreamFollower(kube kubernetes.Interface, gateways cache.Informers, logger logr.Logger,
+	publisher filterConfigPublisher, elected <-chan struct{},
+) *configStreamFollower {
+	return &configStreamFollower{kube: kube, gateways: gateways, logger: logger, publisher: publisher, elected: elected}
+}
+
+// NeedLeaderElection implements [manager.LeaderElectionRunnable]. The configs are served on every replica.
+func (f *configStreamFollower) NeedLeaderElection() bool { return false }
+
+// Start implements [manager.Runnable]. This watches the filter config bundle index Secrets until ctx is done.
+func (f *configStreamFollower) Start(ctx context.Context) error {
+	gwInformer, err := f.gateways.GetInformer(ctx, &gwapiv1.Gateway{})
+	if err != nil {
+		return fmt.Errorf("failed to get the Gateway informer: %w", err)
+	}
+	// The leader deletes the configs of the deleted Gateways itself, but doing so on every replica is harmless.
+	if _, err = gwInformer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		DeleteFunc: func(obj any) {
+			if tombstone, ok := obj.(toolscache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if gw, ok := obj.(*gwapiv1.Gateway); ok {
+				f.publisher.Delete(configstream.ResourceName(gw.Name, gw.Namespace))
+			}
+		},
+	}); err != nil {
+		return fmt.Errorf("failed to watch the Gateways: %w", err)
+	}
+
+	factory := informers.NewSharedInformerFactoryWithOptions(f.kube, 0,
+		informers.WithTweakListOptions(func(o *metav1.ListOptions) { o.LabelSelector = filterConfigBundleIndexLabel }))
+	if _, err = factory.Core().V1().Secrets().Informer().AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj any) { f.publish(ctx, obj) },
+		UpdateFunc: func(_, obj any) { f.publish(ctx, obj) },
+	}); err != nil {
+		return fmt.Errorf("failed to watch the filter config Secrets: %w", err)
+	}
+	factory.Start(ctx.Done())
+	<-ctx.Done()
+	factory.Shutdown()
+	return nil
+}
+
+// isLeader returns true if this replica is the leader of the controller manager, which publishes the configs itself.
+// Publishing the configs read from the Secrets there could roll back a newer config published meanwhile.
+func (f *configStreamFollower) isLeader() bool {
+	select {
+	case <-f.elected:
+		return true
+	default:
+		return false
+	}
+}
+
+// publish publishes the filter config of the bundle described by the index Secret.
+func (f *configStreamFollower) publish(ctx context.Context, obj any) {
+	secret, ok := obj.(*corev1.Secret)
+	if !ok || f.isLeader() {
+		return
+	}
+	name := secret.Annotations[filterConfigGatewayAnnotation]
+	if name == "" {
+		return
+	}
+	indexRaw, ok := secret.Data[FilterConfigBundleIndexKey]
+	if !ok {
+		// The index is written as StringData, which is only moved into the Data by the API server.
+		indexRaw = []byte(secret.StringData[FilterConfigBundleIndexKey])
+	}
+	index, err := filterapi.UnmarshalConfigBundleIndex(indexRaw)
+	if err != nil {
+		f.logger.Error(err, "failed to read the filter config bundle index", "secret", secret.Name, "resource", name)
+		return
+	}
+	raw, err := filterapi.ReassembleBundle(index, func(part filterapi.ConfigBundlePart) ([]byte, error) {
+		p, err := f.kube.CoreV1().Secrets(secret.Namespace).Get(ctx, part.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return p.Data[FilterConfigBundlePartKey], nil
+	})
+	if err != nil {
+		// The parts are updated before the index, so this is retried on the next update of the index.
+		f.logger.Error(err, "failed to read the filter config bundle", "secret", secret.Name, "resource", name)
+		return
+	}
+	f.publisher.Publish(name, raw)
+}
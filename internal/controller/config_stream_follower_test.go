@@ -0,0 +1,111 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package controller
+
+import (
+	"context"
+	"maps"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fake2 "k8s.io/client-go/kubernetes/fake"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache/informertest"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+// syncFilterConfigPublisher is a fakeFilterConfigPublisher safe for the concurrent use by the informers.
+type syncFilterConfigPublisher struct {
+	mu      sync.Mutex
+	configs fakeFilterConfigPublisher
+}
+
+func (p *syncFilterConfigPublisher) Publish(name string, config []byte) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.configs.Publish(name, config)
+}
+
+func (p *syncFilterConfigPublisher) Delete(name string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.configs.Delete(name)
+}
+
+func (p *syncFilterConfigPublisher) snapshot() fakeFilterConfigPublisher {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return maps.Clone(p.configs)
+}
+
+func TestConfigStreamFollower(t *testing.T) {
+	kube := fake2.NewClientset()
+	leader := NewGatewayController(requireNewFakeClientWithIndexes(t), kube, ctrl.Log, "envoy-gateway-system",
+		"docker.io/envoyproxy/ai-gateway-extproc:latest", "info", false, nil, true)
+	leaderPublisher := fakeFilterConfigPublisher{}
+	leader.configPublisher = leaderPublisher
+	_, err := leader.reconcileFilterConfigSecret(t.Context(), "gw", "ns", "envoy-gateway-system", nil, nil, "first", nil)
+	require.NoError(t, err)
+
+	informers := &informertest.FakeInformers{Scheme: Scheme}
+	publisher := &syncFilterConfigPublisher{configs: fakeFilterConfigPublisher{}}
+	f := newConfigStreamFollower(kube, informers, ctrl.Log, publisher, make(chan struct{}))
+	require.False(t, f.NeedLeaderElection())
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		require.NoError(t, f.Start(ctx))
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	// The existing config and its updates are published as the leader published them.
+	require.Eventually(t, func() bool {
+		return string(publisher.snapshot()["ns/gw"]) == string(leaderPublisher["ns/gw"])
+	}, 10*time.Second, 50*time.Millisecond)
+	_, err = leader.reconcileFilterConfigSecret(t.Context(), "gw", "ns", "envoy-gateway-system", nil, nil, "second", nil)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		return string(publisher.snapshot()["ns/gw"]) == string(leaderPublisher["ns/gw"])
+	}, 10*time.Second, 50*time.Millisecond)
+	require.Contains(t, string(publisher.snapshot()["ns/gw"]), "uuid: second")
+
+	// The config of the deleted Gateway is deleted.
+	gwInformer, err := informers.FakeInformerFor(t.Context(), &gwapiv1.Gateway{})
+	require.NoError(t, err)
+	gwInformer.Delete(&gwapiv1.Gateway{ObjectMeta: metav1.ObjectMeta{Name: "gw", Namespace: "ns"}})
+	require.Empty(t, publisher.snapshot())
+}
+
+func TestConfigStreamFollower_leader(t *testing.T) {
+	kube := fake2.NewClientset()
+	leader := NewGatewayController(requireNewFakeClientWithIndexes(t), kube, ctrl.Log, "envoy-gateway-system",
+		"docker.io/envoyproxy/ai-gateway-extproc:latest", "info", false, nil, true)
+	_, err := leader.reconcileFilterConfigSecret(t.Context(), "gw", "ns", "envoy-gateway-system", nil, nil, "first", nil)
+	require.NoError(t, err)
+
+	elected := make(chan struct{})
+	close(elected)
+	publisher := &syncFilterConfigPublisher{configs: fakeFilterConfigPublisher{}}
+	f := newConfigStreamFollower(kube, &informertest.FakeInformers{Scheme: Scheme}, ctrl.Log, publisher, elected)
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		require.NoError(t, f.Start(ctx))
+	}()
+	// The leader publishes the configs itself.
+	time.Sleep(200 * time.Millisecond)
+	cancel()
+	<-done
+	require.Empty(t, publisher.snapshot())
+}
@@ -6,31 +6,40 @@
 package controller
 
 import (
+	"cmp"
 	"context"
 	"fmt"
+	"net"
 	"strconv"
+	"time"
 
 	egv1a1 "github.com/envoyproxy/gateway/api/v1alpha1"
 	"github.com/go-logr/logr"
 	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	corev1 "k8s.io/api/core/v1"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	apiextensionsclientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/version"
 	"k8s.io/client-go/kubernetes"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
+	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	ctrlutil "sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
@@ -41,6 +50,8 @@ import (
 
 	aigv1a1 "github.com/envoyproxy/ai-gateway/api/v1alpha1"
 	aigv1b1 "github.com/envoyproxy/ai-gateway/api/v1beta1"
+	"github.com/envoyproxy/ai-gateway/internal/configstream"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
 	"github.com/envoyproxy/ai-gateway/internal/ratelimit/runner"
 )
 
@@ -67,6 +78,9 @@ type Options struct {
 	ExtProcLogLevel string
 	// ExtProcEnableRedaction enables redaction of sensitive information in debug logs for the external processor.
 	ExtProcEnableRedaction bool
+	// ExtProcMockProvider makes the external processor serve the mock OpenAI provider of the AIServiceBackends with
+	// the Mock API schema on a UDS next to the one of the external processor.
+	ExtProcMockProvider bool
 	// ExtProcImage is the image for the external processor set on Deployment.
 	ExtProcImage string
 	// ExtProcImagePullPolicy is the image pull policy for the external processor set on Deployment.
@@ -108,6 +122,46 @@ type Options struct {
 	RateLimitRunner *runner.Runner
 	// EnvoyGatewayNamespace is the namespace where Envoy Gateway is deployed.
 	EnvoyGatewayNamespace string
+	// WatchNamespaceSelector is the label selector for the namespaces whose resources are reconciled by this controller.
+	// A nil selector means all namespaces. This allows multiple controller instances to run in the same cluster,
+	// each managing its own set of namespaces.
+	WatchNamespaceSelector labels.Selector
+	// ConfigStreamServer pushes the filter configs to the external processors. Nil disables the config stream.
+	// This is expected to run on every replica, which serves the configs written by the leader.
+	ConfigStreamServer *configstream.Server
+	// ConfigStreamAddr is the address of the ConfigStreamServer advertised to the external processors.
+	ConfigStreamAddr string
+	// ConfigStreamCA is the PEM-encoded CA certificate for the external processors to verify the ConfigStreamServer.
+	ConfigStreamCA string
+	// QuotaRateLimitServiceAddr is the host or host:port of the quota rate limit service probed by the external
+	// processors to enforce the local fallback of the QuotaPolicies. The port defaults to 8081 if not specified.
+	QuotaRateLimitServiceAddr string
+	// UsageReconciliation configures the comparison of the token usage recorded by the external processors with the
+	// usage exports of the providers. A zero interval disables it.
+	UsageReconciliation UsageReconciliationOptions
+	// MigrationStatusInterval is the period at which the comparisons of the migrated rules of the AIGatewayRoutes
+	// are collected from the external processors into the status of the routes. Zero disables it.
+	MigrationStatusInterval time.Duration
+	// Impersonation configures the service account impersonated to write the resources generated from the
+	// AIGatewayRoutes and MCPRoutes. The zero value writes them with the identity of the controller.
+	Impersonation ImpersonationOptions
+	// CapabilityProbingInterval is the period at which the models of the AIServiceBackends and their features are
+	// probed into the status of the backends. Zero disables it.
+	CapabilityProbingInterval time.Duration
+	// Observability configures the Grafana dashboard and the Prometheus alerting rules ConfigMaps generated per
+	// AIGatewayRoute.
+	Observability ObservabilityOptions
+	// Sharding configures the sharding of the AIGatewayRoute reconciliation across the controller replicas.
+	Sharding ShardingOptions
+	// ModelNameHeaderKey is the request header set to the model name by the external processor and matched by the
+	// rules of the AIGatewayRoutes. Empty means internalapi.ModelNameHeaderKeyDefault.
+	ModelNameHeaderKey string
+	// MetadataNamespace is the namespace of the dynamic metadata emitted by the external processor. Empty means
+	// internalapi.AIGatewayFilterMetadataNamespace.
+	MetadataNamespace string
+	// SelectedBackendHeaderKey is the request header selecting the backend on the AIGatewayRoutes configuring a
+	// BackendOverride. Empty means internalapi.BackendOverrideHeader.
+	SelectedBackendHeaderKey string
 }
 
 // StartControllers starts the controllers for the AI Gateway.
@@ -127,43 +181,109 @@ func StartControllers(ctx context.Context, mgr manager.Manager, config *rest.Con
 		return fmt.Errorf("failed to get server version: %w", err)
 	}
 
+	// scoped wraps a reconciler so that it only handles the objects in the namespaces selected by the options.
+	scoped := func(r reconcile.TypedReconciler[reconcile.Request]) reconcile.TypedReconciler[reconcile.Request] {
+		return newNamespaceScopedReconciler(c, logger.WithName("namespace-selector"), options.WatchNamespaceSelector, r)
+	}
+	// instrumented additionally records the reconciliation metrics labeled by the given CRD kind.
+	instrumented := func(kind string, r reconcile.TypedReconciler[reconcile.Request]) reconcile.TypedReconciler[reconcile.Request] {
+		return newInstrumentedReconciler(kind, scoped(r))
+	}
+
 	gatewayEventChan := make(chan event.GenericEvent, 100)
 	gatewayC := NewGatewayController(c, kubernetes.NewForConfigOrDie(config),
 		logger.WithName("gateway"), options.EnvoyGatewayNamespace, options.ExtProcImage, options.ExtProcLogLevel,
 		false, uuid.NewString, isKubernetes133OrLater(versionInfo, logger))
-	if err = TypedControllerBuilderForCRD(mgr, &gwapiv1.Gateway{}).
+	if options.ConfigStreamServer != nil {
+		gatewayC.configPublisher = options.ConfigStreamServer
+		if err = mgr.Add(newConfigStreamFollower(kube, mgr.GetCache(), logger.WithName("config-stream-follower"),
+			options.ConfigStreamServer, mgr.Elected())); err != nil {
+			return fmt.Errorf("failed to add the config stream follower: %w", err)
+		}
+	}
+	if addr := options.QuotaRateLimitServiceAddr; addr != "" {
+		if _, _, splitErr := net.SplitHostPort(addr); splitErr != nil {
+			addr = net.JoinHostPort(addr, "8081")
+		}
+		gatewayC.quotaRateLimitServiceAddr = addr
+	}
+	gatewayC.modelNameHeaderKey = options.ModelNameHeaderKey
+	gatewayC.metadataNamespace = options.MetadataNamespace
+	gatewayC.selectedBackendHeaderKey = options.SelectedBackendHeaderKey
+	gatewayC.namespaceSelector = options.WatchNamespaceSelector
+	gatewayBuilder := TypedControllerBuilderForCRD(mgr, &gwapiv1.Gateway{}).
 		WatchesRawSource(source.Channel(
 			gatewayEventChan,
 			&handler.EnqueueRequestForObject{},
 		)).
-		Complete(gatewayC); err != nil {
+		// The filter config Secrets change on every reconciliation of their Gateway, so only their out-of-band edits
+		// trigger another one, which reverts them and sets the Degraded condition of the attached routes.
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(filterConfigSecretToGateway), generatedDataPredicates,
+			builder.WithPredicates(filterConfigSecretModifiedPredicate))
+	gatewayBuilder = watchNamespaces(gatewayBuilder, options.WatchNamespaceSelector, namespaceToGateways(c, logger))
+	if options.Sharding.enabled() {
+		// The AIGatewayRoutes reconciled by the other replicas do not send events to this controller.
+		gatewayBuilder = gatewayBuilder.Watches(&aigv1b1.AIGatewayRoute{},
+			handler.EnqueueRequestsFromMapFunc(aiGatewayRouteToGateways),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{}))
+	}
+	if err = gatewayBuilder.Complete(scoped(gatewayC)); err != nil {
 		return fmt.Errorf("failed to create controller for Gateway: %w", err)
 	}
 
+	// generated writes the resources generated from the AIGatewayRoutes and MCPRoutes.
+	generated := newImpersonatingClient(c, config, options.Impersonation)
+
 	aiGatewayRouteEventChan := make(chan event.GenericEvent, 100)
-	routeC := NewAIGatewayRouteController(c, kubernetes.NewForConfigOrDie(config), logger.WithName("ai-gateway-route"),
+	routeC := NewAIGatewayRouteController(generated, kubernetes.NewForConfigOrDie(config), logger.WithName("ai-gateway-route"),
 		gatewayEventChan, options.RootPrefix,
 	)
-	if err = TypedControllerBuilderForCRD(mgr, &aigv1b1.AIGatewayRoute{}).
-		Owns(&gwapiv1.HTTPRoute{}).
-		Owns(&egv1a1.HTTPRouteFilter{}).
+	routeC.observability = options.Observability
+	routeC.modelNameHeaderKey = cmp.Or(options.ModelNameHeaderKey, internalapi.ModelNameHeaderKeyDefault)
+	routeOptions := instrumentedQueueOptions("AIGatewayRoute", logger)
+	routeBuilder := TypedControllerBuilderForCRD(mgr, &aigv1b1.AIGatewayRoute{}).
+		Owns(&gwapiv1.HTTPRoute{}, generatedResourcePredicates).
+		Owns(&egv1a1.HTTPRouteFilter{}, generatedResourcePredicates).
 		WatchesRawSource(source.Channel(
 			aiGatewayRouteEventChan,
 			&handler.EnqueueRequestForObject{},
-		)).
-		Complete(routeC); err != nil {
+		))
+	routeBuilder = watchNamespaces(routeBuilder, options.WatchNamespaceSelector, namespaceToObjects(c, logger,
+		func() client.ObjectList { return &aigv1b1.AIGatewayRouteList{} }))
+	if options.Observability.Enabled {
+		routeBuilder = routeBuilder.Owns(&corev1.ConfigMap{}, generatedDataPredicates)
+	}
+	if options.Sharding.enabled() {
+		// Every replica runs the AIGatewayRoute controller, which only reconciles the routes of the shard it leads.
+		routeOptions.NeedLeaderElection = ptr.To(false)
+		routeC.shard = newRouteShard(c, kube, logger.WithName("ai-gateway-route-shard"), options.Sharding,
+			options.EnableLeaderElection, mgr.Elected(), aiGatewayRouteEventChan)
+		if err = mgr.Add(routeC.shard); err != nil {
+			return fmt.Errorf("failed to add the AIGatewayRoute shard: %w", err)
+		}
+		// The events of the referenced objects are otherwise only sent by the controllers on the leader of the
+		// controller manager.
+		routeBuilder = routeBuilder.
+			Watches(&aigv1b1.AIServiceBackend{}, routeC.shard.handler(routeC.shard.backendAIGatewayRoutes),
+				builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+			Watches(&gwapiv1b1.ReferenceGrant{}, routeC.shard.handler(routeC.shard.referenceGrantAIGatewayRoutes),
+				builder.WithPredicates(predicate.GenerationChangedPredicate{}))
+	}
+	if err = routeBuilder.WithOptions(routeOptions).Complete(instrumented("AIGatewayRoute", routeC)); err != nil {
 		return fmt.Errorf("failed to create controller for AIGatewayRoute: %w", err)
 	}
 
 	aiServiceBackendEventChan := make(chan event.GenericEvent, 100)
 	backendC := NewAIServiceBackendController(c, kubernetes.NewForConfigOrDie(config), logger.
 		WithName("ai-service-backend"), aiGatewayRouteEventChan)
-	if err = TypedControllerBuilderForCRD(mgr, &aigv1b1.AIServiceBackend{}).
+	if err = watchNamespaces(TypedControllerBuilderForCRD(mgr, &aigv1b1.AIServiceBackend{}), options.WatchNamespaceSelector,
+		namespaceToObjects(c, logger, func() client.ObjectList { return &aigv1b1.AIServiceBackendList{} })).
+		WithOptions(instrumentedQueueOptions("AIServiceBackend", logger)).
 		WatchesRawSource(source.Channel(
 			aiServiceBackendEventChan,
 			&handler.EnqueueRequestForObject{},
 		)).
-		Complete(backendC); err != nil {
+		Complete(instrumented("AIServiceBackend", backendC)); err != nil {
 		return fmt.Errorf("failed to create controller for AIServiceBackend: %w", err)
 	}
 
@@ -171,13 +291,20 @@ func StartControllers(ctx context.Context, mgr manager.Manager, config *rest.Con
 	inferencePoolEventChan := make(chan event.GenericEvent, 100)
 	backendSecurityPolicyC := NewBackendSecurityPolicyController(c, kubernetes.NewForConfigOrDie(config), logger.
 		WithName("backend-security-policy"), aiServiceBackendEventChan, inferencePoolEventChan)
-	if err = TypedControllerBuilderForCRD(mgr, &aigv1b1.BackendSecurityPolicy{}).
+	if err = watchNamespaces(TypedControllerBuilderForCRD(mgr, &aigv1b1.BackendSecurityPolicy{}), options.WatchNamespaceSelector,
+		namespaceToObjects(c, logger, func() client.ObjectList { return &aigv1b1.BackendSecurityPolicyList{} })).
+		WithOptions(instrumentedQueueOptions("BackendSecurityPolicy", logger)).
 		WatchesRawSource(source.Channel(
 			backendSecurityPolicyEventChan,
 			&handler.EnqueueRequestForObject{},
 		)).
-		Owns(&corev1.Secret{}).
-		Complete(backendSecurityPolicyC); err != nil {
+		Owns(&corev1.Secret{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		// The annotation forcing the rotation does not change the generation.
+		Watches(&aigv1b1.BackendSecurityPolicy{}, &handler.EnqueueRequestForObject{},
+			builder.WithPredicates(predicate.NewPredicateFuncs(func(o client.Object) bool {
+				return o.GetAnnotations()[RotateNowAnnotationKey] == "true"
+			}))).
+		Complete(instrumented("BackendSecurityPolicy", backendSecurityPolicyC)); err != nil {
 		return fmt.Errorf("failed to create controller for BackendSecurityPolicy: %w", err)
 	}
 
@@ -198,21 +325,26 @@ func StartControllers(ctx context.Context, mgr manager.Manager, config *rest.Con
 		// CRD exists, create the controller.
 		inferencePoolC := NewInferencePoolController(c, kubernetes.NewForConfigOrDie(config), logger.
 			WithName("inference-pool"), inferencePoolEventChan)
-		if err = TypedControllerBuilderForCRD(mgr, &gwaiev1.InferencePool{}).
-			Watches(&gwapiv1.Gateway{}, handler.EnqueueRequestsFromMapFunc(inferencePoolC.gatewayEventHandler)).
-			Watches(&aigv1b1.AIGatewayRoute{}, handler.EnqueueRequestsFromMapFunc(inferencePoolC.aiGatewayRouteEventHandler)).
-			Watches(&gwapiv1.HTTPRoute{}, handler.EnqueueRequestsFromMapFunc(inferencePoolC.httpRouteEventHandler)).
+		if err = watchNamespaces(TypedControllerBuilderForCRD(mgr, &gwaiev1.InferencePool{}), options.WatchNamespaceSelector,
+			namespaceToObjects(c, logger, func() client.ObjectList { return &gwaiev1.InferencePoolList{} })).
+			Watches(&gwapiv1.Gateway{}, handler.EnqueueRequestsFromMapFunc(inferencePoolC.gatewayEventHandler),
+				builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+			Watches(&aigv1b1.AIGatewayRoute{}, handler.EnqueueRequestsFromMapFunc(inferencePoolC.aiGatewayRouteEventHandler),
+				builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+			Watches(&gwapiv1.HTTPRoute{}, handler.EnqueueRequestsFromMapFunc(inferencePoolC.httpRouteEventHandler),
+				builder.WithPredicates(predicate.GenerationChangedPredicate{})).
 			WatchesRawSource(source.Channel(
 				inferencePoolEventChan,
 				&handler.EnqueueRequestForObject{},
 			)).
-			Complete(inferencePoolC); err != nil {
+			Complete(scoped(inferencePoolC)); err != nil {
 			return fmt.Errorf("failed to create controller for InferencePool: %w", err)
 		}
 	}
 	mcpRouteEventChan := make(chan event.GenericEvent, 100)
+	gatewayConfigEventChan := make(chan event.GenericEvent, 100)
 	secretC := NewSecretController(c, kubernetes.NewForConfigOrDie(config), logger.
-		WithName("secret"), backendSecurityPolicyEventChan, mcpRouteEventChan)
+		WithName("secret"), backendSecurityPolicyEventChan, mcpRouteEventChan, aiGatewayRouteEventChan, gatewayConfigEventChan)
 	// Do not use TypedControllerBuilderForCRD for secret, as changing a secret content doesn't change the generation.
 	if err = ctrl.NewControllerManagedBy(mgr).
 		For(&corev1.Secret{}).
@@ -220,32 +352,45 @@ func StartControllers(ctx context.Context, mgr manager.Manager, config *rest.Con
 		return fmt.Errorf("failed to create controller for Secret: %w", err)
 	}
 
-	mcpRouteC := NewMCPRouteController(c, kubernetes.NewForConfigOrDie(config), logger.WithName("ai-gateway-mcp-route"),
+	mcpRouteC := NewMCPRouteController(generated, kubernetes.NewForConfigOrDie(config), logger.WithName("ai-gateway-mcp-route"),
 		gatewayEventChan,
 	)
-	if err = TypedControllerBuilderForCRD(mgr, &aigv1b1.MCPRoute{}).
-		Owns(&gwapiv1.HTTPRoute{}).
+	if err = watchNamespaces(TypedControllerBuilderForCRD(mgr, &aigv1b1.MCPRoute{}), options.WatchNamespaceSelector,
+		namespaceToObjects(c, logger, func() client.ObjectList { return &aigv1b1.MCPRouteList{} })).
+		Owns(&gwapiv1.HTTPRoute{}, generatedResourcePredicates).
+		Owns(&egv1a1.HTTPRouteFilter{}, generatedResourcePredicates).
+		Owns(&egv1a1.SecurityPolicy{}, generatedResourcePredicates).
+		Owns(&egv1a1.BackendTrafficPolicy{}, generatedResourcePredicates).
+		Owns(&corev1.Secret{}, generatedDataPredicates).
 		WatchesRawSource(source.Channel(
 			mcpRouteEventChan,
 			&handler.EnqueueRequestForObject{},
 		)).
-		Complete(mcpRouteC); err != nil {
+		Complete(scoped(mcpRouteC)); err != nil {
 		return fmt.Errorf("failed to create controller for MCPRoute: %w", err)
 	}
 
 	// GatewayConfig controller for gateway-scoped configuration.
 	gatewayConfigC := NewGatewayConfigController(c, logger.WithName("gateway-config"), gatewayEventChan)
-	if err = TypedControllerBuilderForCRD(mgr, &aigv1b1.GatewayConfig{}).
-		Complete(gatewayConfigC); err != nil {
+	if err = watchNamespaces(TypedControllerBuilderForCRD(mgr, &aigv1b1.GatewayConfig{}), options.WatchNamespaceSelector,
+		namespaceToObjects(c, logger, func() client.ObjectList { return &aigv1b1.GatewayConfigList{} })).
+		WatchesRawSource(source.Channel(
+			gatewayConfigEventChan,
+			&handler.EnqueueRequestForObject{},
+		)).
+		Complete(scoped(gatewayConfigC)); err != nil {
 		return fmt.Errorf("failed to create controller for GatewayConfig: %w", err)
 	}
 
 	// QuotaPolicy controller for backend quota rate limiting.
 	if options.RateLimitRunner != nil {
 		quotaPolicyC := NewQuotaPolicyController(c, kube, logger.WithName("quota-policy"), options.RateLimitRunner, aiGatewayRouteEventChan)
-		if err = TypedControllerBuilderForCRD(mgr, &aigv1a1.QuotaPolicy{}).
-			Watches(&aigv1b1.AIServiceBackend{}, handler.EnqueueRequestsFromMapFunc(quotaPolicyC.BackendToQuotaPolicy)).
-			Complete(quotaPolicyC); err != nil {
+		if err = watchNamespaces(TypedControllerBuilderForCRD(mgr, &aigv1a1.QuotaPolicy{}), options.WatchNamespaceSelector,
+			namespaceToObjects(c, logger, func() client.ObjectList { return &aigv1a1.QuotaPolicyList{} })).
+			WithOptions(instrumentedQueueOptions("QuotaPolicy", logger)).
+			Watches(&aigv1b1.AIServiceBackend{}, handler.EnqueueRequestsFromMapFunc(quotaPolicyC.BackendToQuotaPolicy),
+				builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+			Complete(instrumented("QuotaPolicy", quotaPolicyC)); err != nil {
 			return fmt.Errorf("failed to create controller for QuotaPolicy: %w", err)
 		}
 	}
@@ -257,8 +402,29 @@ func StartControllers(ctx context.Context, mgr manager.Manager, config *rest.Con
 		return fmt.Errorf("failed to create controller for ReferenceGrant: %w", err)
 	}
 
+	if options.UsageReconciliation.Interval > 0 {
+		if err = mgr.Add(newUsageReconciler(c, mgr.GetAPIReader(), logger.WithName("usage-reconciler"),
+			options.UsageReconciliation)); err != nil {
+			return fmt.Errorf("failed to add usage reconciler: %w", err)
+		}
+	}
+
+	if options.MigrationStatusInterval > 0 {
+		if err = mgr.Add(newMigrationStatusReconciler(c, mgr.GetAPIReader(), logger.WithName("migration-status-reconciler"),
+			options.MigrationStatusInterval)); err != nil {
+			return fmt.Errorf("failed to add migration status reconciler: %w", err)
+		}
+	}
+
+	if options.CapabilityProbingInterval > 0 {
+		if err = mgr.Add(newCapabilityProber(c, mgr.GetAPIReader(), logger.WithName("capability-prober"),
+			options.CapabilityProbingInterval, cmp.Or(options.ModelNameHeaderKey, internalapi.ModelNameHeaderKeyDefault))); err != nil {
+			return fmt.Errorf("failed to add capability prober: %w", err)
+		}
+	}
+
 	if !options.DisableMutatingWebhook {
-		h := admission.WithCustomDefaulter(Scheme, &corev1.Pod{}, newGatewayMutator(c, mgr.GetAPIReader(), kube,
+		mutator := newGatewayMutator(c, mgr.GetAPIReader(), kube,
 			logger.WithName("gateway-mutator"),
 			options.ExtProcImage,
 			options.ExtProcImagePullPolicy,
@@ -279,10 +445,23 @@ func StartControllers(ctx context.Context, mgr manager.Manager, config *rest.Con
 			options.MCPSessionEncryptionIterations,
 			options.MCPFallbackSessionEncryptionSeed,
 			options.MCPFallbackSessionEncryptionIterations,
-		))
+		)
+		if options.ConfigStreamServer != nil {
+			mutator.configStreamAddr = options.ConfigStreamAddr
+			mutator.configStreamCA = options.ConfigStreamCA
+		}
+		mutator.mockProvider = options.ExtProcMockProvider
+		h := admission.WithCustomDefaulter(Scheme, &corev1.Pod{}, mutator)
 		mgr.GetWebhookServer().Register("/mutate", &webhook.Admission{Handler: h})
 	}
 
+	// The exemplars of the reconciliation metrics are only exposed in the OpenMetrics format, which the default
+	// metrics endpoint doesn't negotiate.
+	if err = mgr.AddMetricsServerExtraHandler("/openmetrics", promhttp.HandlerFor(ctrlmetrics.Registry,
+		promhttp.HandlerOpts{EnableOpenMetrics: true})); err != nil {
+		return fmt.Errorf("failed to add OpenMetrics handler: %w", err)
+	}
+
 	if err = mgr.Start(ctx); err != nil { // This blocks until the manager is stopped.
 		return fmt.Errorf("failed to start controller manager: %w", err)
 	}
@@ -292,13 +471,41 @@ func StartControllers(ctx context.Context, mgr manager.Manager, config *rest.Con
 // TypedControllerBuilderForCRD returns a new controller builder for the given CRD object type.
 //
 // This is to share the common logic for setting up a controller for a given object type.
+// The other watched objects must set their own predicates.
 //
 // Exported for testing purposes in tests/controller_test.go.
 func TypedControllerBuilderForCRD(mgr ctrl.Manager, obj client.Object) *ctrl.Builder {
 	return ctrl.NewControllerManagedBy(mgr).
-		For(obj).
 		// We do not need to watch for changes in the status subresource.
-		WithEventFilter(predicate.GenerationChangedPredicate{})
+		For(obj, builder.WithPredicates(predicate.GenerationChangedPredicate{}))
+}
+
+// generatedResourcePredicates are the predicates for the resources with a spec generated by the route controllers,
+// such as the HTTPRoutes generated from the AIGatewayRoutes. The out-of-band edits of their spec trigger the
+// reconciliation of the owner, which reverts them and sets the Degraded condition of the owner.
+//
+// Unlike the spec, edits to the labels and annotations do not change the generation, yet an out-of-band edit of
+// them on a generated resource can break the routing, e.g. removing the annotation indicating an AI Gateway
+// generated HTTPRoute. Such edits trigger the reconciliation of the owner too, which reverts them.
+var generatedResourcePredicates = builder.WithPredicates(predicate.Or(
+	predicate.GenerationChangedPredicate{},
+	predicate.LabelChangedPredicate{},
+	predicate.AnnotationChangedPredicate{},
+))
+
+// generatedDataPredicates are the predicates for the resources without a spec generated by the route controllers,
+// such as the ConfigMaps and Secrets, whose generation does not change with their data.
+var generatedDataPredicates = builder.WithPredicates(predicate.ResourceVersionChangedPredicate{})
+
+// filterConfigSecretModifiedPredicate passes the events of the filter config Secrets modified or deleted out of band.
+var filterConfigSecretModifiedPredicate = predicate.Funcs{
+	CreateFunc: func(e event.CreateEvent) bool { return isFilterConfigSecretModified(e.Object) },
+	UpdateFunc: func(e event.UpdateEvent) bool { return isFilterConfigSecretModified(e.ObjectNew) },
+	DeleteFunc: func(e event.DeleteEvent) bool { return e.Object.GetAnnotations()[filterConfigGatewayAnnotation] != "" },
+}
+
+func isFilterConfigSecretModified(obj client.Object) bool {
+	return obj.GetAnnotations()[filterConfigGatewayAnnotation] != "" && isModifiedOutOfBand(obj)
 }
 
 const (
@@ -313,6 +520,12 @@ const (
 	// k8sClientIndexSecretToReferencingMCPRoute is the index name that maps
 	// from a Secret to the MCPRoute that references it.
 	k8sClientIndexSecretToReferencingMCPRoute = "SecretToReferencingMCPRoute"
+	// k8sClientIndexSecretToReferencingAIGatewayRoute is the index name that maps
+	// from a Secret to the AIGatewayRoute that references it.
+	k8sClientIndexSecretToReferencingAIGatewayRoute = "SecretToReferencingAIGatewayRoute"
+	// k8sClientIndexSecretToReferencingGatewayConfig is the index name that maps
+	// from a Secret to the GatewayConfig that references it.
+	k8sClientIndexSecretToReferencingGatewayConfig = "SecretToReferencingGatewayConfig"
 	// k8sClientIndexBackendToReferencingAIGatewayRoute is the index name that maps from a Backend to the
 	// AIGatewayRoute that references it.
 	k8sClientIndexBackendToReferencingAIGatewayRoute = "BackendToReferencingAIGatewayRoute"
@@ -354,6 +567,16 @@ func ApplyIndexing(ctx context.Context, indexer func(ctx context.Context, obj cl
 	if err != nil {
 		return fmt.Errorf("failed to create index from Gateway to AIGatewayRoute: %w", err)
 	}
+	err = indexer(ctx, &aigv1b1.AIGatewayRoute{},
+		k8sClientIndexSecretToReferencingAIGatewayRoute, aiGatewayRouteToReferencedSecret)
+	if err != nil {
+		return fmt.Errorf("failed to create index from Secret to AIGatewayRoute: %w", err)
+	}
+	err = indexer(ctx, &aigv1b1.GatewayConfig{},
+		k8sClientIndexSecretToReferencingGatewayConfig, gatewayConfigToReferencedSecret)
+	if err != nil {
+		return fmt.Errorf("failed to create index from Secret to GatewayConfig: %w", err)
+	}
 	err = indexer(ctx, &aigv1b1.BackendSecurityPolicy{},
 		k8sClientIndexSecretToReferencingBackendSecurityPolicy, backendSecurityPolicyIndexFunc)
 	if err != nil {
@@ -433,6 +656,24 @@ func mcpRouteToReferencedSecret(o client.Object) []string {
 	return ret
 }
 
+func aiGatewayRouteToReferencedSecret(o client.Object) []string {
+	aiGatewayRoute := o.(*aigv1b1.AIGatewayRoute)
+	if override := aiGatewayRoute.Spec.BackendOverride; override != nil && override.SecretRef != nil {
+		// The secret is always in the namespace of the route.
+		return []string{fmt.Sprintf("%s.%s", override.SecretRef.Name, aiGatewayRoute.Namespace)}
+	}
+	return nil
+}
+
+func gatewayConfigToReferencedSecret(o client.Object) []string {
+	gatewayConfig := o.(*aigv1b1.GatewayConfig)
+	if a := gatewayConfig.Spec.ResponseAttestation; a != nil && a.SecretRef != nil {
+		// The secret is always in the namespace of the GatewayConfig.
+		return []string{fmt.Sprintf("%s.%s", a.SecretRef.Name, gatewayConfig.Namespace)}
+	}
+	return nil
+}
+
 func httpRouteToOwnerMCPRouteIndexFunc(o client.Object) []string {
 	owner := metav1.GetControllerOf(o)
 	if owner == nil || owner.Kind != "MCPRoute" {
@@ -485,6 +726,13 @@ func backendSecurityPolicyIndexFunc(o client.Object) []string {
 	switch backendSecurityPolicy.Spec.Type {
 	case aigv1b1.BackendSecurityPolicyTypeAPIKey:
 		apiKey := backendSecurityPolicy.Spec.APIKey
+		if len(apiKey.Pool) > 0 {
+			keys := make([]string, 0, len(apiKey.Pool))
+			for i := range apiKey.Pool {
+				keys = append(keys, getSecretNameAndNamespace(apiKey.Pool[i].SecretRef, backendSecurityPolicy.Namespace))
+			}
+			return keys
+		}
 		key = getSecretNameAndNamespace(apiKey.SecretRef, backendSecurityPolicy.Namespace)
 	case aigv1b1.BackendSecurityPolicyTypeAWSCredentials:
 		awsCreds := backendSecurityPolicy.Spec.AWSCredentials
@@ -504,11 +752,17 @@ func backendSecurityPolicyIndexFunc(o client.Object) []string {
 	case aigv1b1.BackendSecurityPolicyTypeAnthropicAPIKey:
 		apiKey := backendSecurityPolicy.Spec.AnthropicAPIKey
 		key = getSecretNameAndNamespace(apiKey.SecretRef, backendSecurityPolicy.Namespace)
+	case aigv1b1.BackendSecurityPolicyTypeIBMCloudAPIKey:
+		apiKey := backendSecurityPolicy.Spec.IBMCloudAPIKey
+		key = getSecretNameAndNamespace(apiKey.SecretRef, backendSecurityPolicy.Namespace)
+	case aigv1b1.BackendSecurityPolicyTypeSAPAICoreCredentials:
+		sapCreds := backendSecurityPolicy.Spec.SAPAICoreCredentials
+		key = getSecretNameAndNamespace(sapCreds.ClientSecretRef, backendSecurityPolicy.Namespace)
 	case aigv1b1.BackendSecurityPolicyTypeAzureCredentials:
 		azureCreds := backendSecurityPolicy.Spec.AzureCredentials
 		if azureCreds.ClientSecretRef != nil {
 			key = getSecretNameAndNamespace(azureCreds.ClientSecretRef, backendSecurityPolicy.Namespace)
-		} else if azureCreds.OIDCExchangeToken != nil {
+		} else if azureCreds.OIDCExchangeToken != nil || azureCreds.WorkloadIdentity != nil {
 			key = backendSecurityPolicyKey(backendSecurityPolicy.Namespace, backendSecurityPolicy.Name)
 		}
 	}
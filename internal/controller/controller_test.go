@@ -105,6 +105,22 @@ func Test_backendSecurityPolicyIndexFunc(t *testing.T) {
 			},
 			expKey: "some-secret2.ns",
 		},
+		{
+			name: "api key pool",
+			backendSecurityPolicy: &aigv1b1.BackendSecurityPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: "some-backend-security-policy-pool", Namespace: "ns"},
+				Spec: aigv1b1.BackendSecurityPolicySpec{
+					Type: aigv1b1.BackendSecurityPolicyTypeAPIKey,
+					APIKey: &aigv1b1.BackendSecurityPolicyAPIKey{
+						Pool: []aigv1b1.BackendSecurityPolicyAPIKeyPoolEntry{
+							{Name: "a", SecretRef: &gwapiv1.SecretObjectReference{Name: "some-pool-secret-a"}},
+							{Name: "b", SecretRef: &gwapiv1.SecretObjectReference{Name: "some-pool-secret-b"}},
+						},
+					},
+				},
+			},
+			expKey: "some-pool-secret-b.ns",
+		},
 		{
 			name: "aws credentials with namespace",
 			backendSecurityPolicy: &aigv1b1.BackendSecurityPolicy{
@@ -236,6 +252,32 @@ func Test_backendSecurityPolicyIndexFunc(t *testing.T) {
 			},
 			expKey: "some-aaaa.ns",
 		},
+		{
+			name: "ibm cloud api key",
+			backendSecurityPolicy: &aigv1b1.BackendSecurityPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: "some-backend-security-policy-3", Namespace: "ns"},
+				Spec: aigv1b1.BackendSecurityPolicySpec{
+					Type: aigv1b1.BackendSecurityPolicyTypeIBMCloudAPIKey,
+					IBMCloudAPIKey: &aigv1b1.BackendSecurityPolicyIBMCloudAPIKey{
+						SecretRef: &gwapiv1.SecretObjectReference{Name: "some-ibm"},
+					},
+				},
+			},
+			expKey: "some-ibm.ns",
+		},
+		{
+			name: "sap ai core credentials",
+			backendSecurityPolicy: &aigv1b1.BackendSecurityPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: "some-backend-security-policy-4", Namespace: "ns"},
+				Spec: aigv1b1.BackendSecurityPolicySpec{
+					Type: aigv1b1.BackendSecurityPolicyTypeSAPAICoreCredentials,
+					SAPAICoreCredentials: &aigv1b1.BackendSecurityPolicySAPAICoreCredentials{
+						ClientSecretRef: &gwapiv1.SecretObjectReference{Name: "some-sap", Namespace: ptr.To[gwapiv1.Namespace]("foo")},
+					},
+				},
+			},
+			expKey: "some-sap.foo",
+		},
 	} {
 		t.Run(bsp.name, func(t *testing.T) {
 			c := fake.NewClientBuilder().
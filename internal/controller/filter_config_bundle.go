@@ -8,11 +8,18 @@ package controller
 import (
 	"context"
 	"fmt"
+	"maps"
+	"strings"
 
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	"github.com/envoyproxy/ai-gateway/internal/configstream"
 	"github.com/envoyproxy/ai-gateway/internal/filterapi"
 	"github.com/envoyproxy/ai-gateway/internal/version"
 )
@@ -21,13 +28,41 @@ const (
 	FilterConfigBundleIndexKey = filterapi.ConfigBundleIndexFileName
 	FilterConfigBundlePartKey  = "chunk"
 
+	// filterConfigBundleIndexLabel labels the index Secrets, which the replicas other than the leader watch to
+	// serve the filter configs over the config stream.
+	filterConfigBundleIndexLabel = "aigateway.envoyproxy.io/filter-config-bundle-index"
+	// filterConfigGatewayAnnotation is the config stream resource name of the Gateway of the filter config Secrets,
+	// see [configstream.ResourceName]. This maps the Secrets modified out of band back to their Gateway.
+	filterConfigGatewayAnnotation = "aigateway.envoyproxy.io/gateway"
+
 	// Keep each part comfortably below Kubernetes object size limits.
 	filterConfigBundlePartSizeBytes = 700 * 1024
 	// Fixed number of bundle slots mounted in the pod so shard count changes never require remounting.
 	// We can make this configurable in the future if needed.
 	maxFilterConfigBundleSlots = 8
+	// The stored size of the filter config above which a warning is logged, as it is approaching the capacity of the slots.
+	filterConfigBundleWarnSizeBytes = maxFilterConfigBundleSlots * filterConfigBundlePartSizeBytes * 8 / 10
 )
 
+// setFilterConfigSecretAnnotations sets the annotations of the filter config Secret about to be written by the
+// Gateway controller, which map the Secret to its Gateway and record its generated version.
+func setFilterConfigSecretAnnotations(secret *corev1.Secret, gatewayName, gatewayNamespace string) {
+	if secret.Annotations == nil {
+		secret.Annotations = make(map[string]string, 2)
+	}
+	secret.Annotations[filterConfigGatewayAnnotation] = configstream.ResourceName(gatewayName, gatewayNamespace)
+	setGeneratedVersion(secret)
+}
+
+// filterConfigSecretToGateway maps the filter config Secret to the reconcile request of its Gateway.
+func filterConfigSecretToGateway(_ context.Context, obj client.Object) []reconcile.Request {
+	namespace, name, ok := strings.Cut(obj.GetAnnotations()[filterConfigGatewayAnnotation], "/")
+	if !ok {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: client.ObjectKey{Namespace: namespace, Name: name}}}
+}
+
 func splitBytes(raw []byte, chunkSize int) [][]byte {
 	if len(raw) == 0 {
 		return [][]byte{{}}
@@ -43,17 +78,41 @@ func splitBytes(raw []byte, chunkSize int) [][]byte {
 	return chunks
 }
 
-func (c *GatewayController) writeFilterConfigBundle(ctx context.Context, gatewayName, gatewayNamespace, configSecretNamespace string, payload []byte, uuid string) error {
+// writeFilterConfigBundle writes the filter config split into the part Secrets, and the index Secret describing them.
+//
+// The config is gzip compressed when it doesn't fit in a single part, so that large installations with many routes
+// and backends need fewer parts. The readers decompress it transparently based on the index.
+func (c *GatewayController) writeFilterConfigBundle(ctx context.Context, gatewayName, gatewayNamespace, configSecretNamespace string, raw []byte, uuid string) error {
 	indexSecretName := FilterConfigBundleIndexSecretName(gatewayName, gatewayNamespace)
+	payload, encoding := raw, ""
+	if len(raw) > filterConfigBundlePartSizeBytes {
+		compressed, err := filterapi.CompressConfigBundle(raw)
+		if err != nil {
+			return fmt.Errorf("failed to compress filter config: %w", err)
+		}
+		payload, encoding = compressed, filterapi.ConfigBundleEncodingGzip
+	}
+	gatewayLabel := gatewayNamespace + "/" + gatewayName
+	gateway := &gwapiv1.Gateway{ObjectMeta: metav1.ObjectMeta{Name: gatewayName, Namespace: gatewayNamespace}}
+	filterConfigSizeBytes.WithLabelValues(gatewayLabel).Set(float64(len(raw)))
+	filterConfigStoredSizeBytes.WithLabelValues(gatewayLabel).Set(float64(len(payload)))
+
 	chunks := splitBytes(payload, filterConfigBundlePartSizeBytes)
 	if len(chunks) > maxFilterConfigBundleSlots {
-		return fmt.Errorf("filter config requires %d shards, exceeds max supported slots %d", len(chunks), maxFilterConfigBundleSlots)
+		return fmt.Errorf("filter config of %d bytes (%d bytes stored) requires %d shards, exceeds max supported slots %d",
+			len(raw), len(payload), len(chunks), maxFilterConfigBundleSlots)
+	}
+	if len(payload) > filterConfigBundleWarnSizeBytes {
+		c.logger.Info("filter config is approaching the maximum supported size",
+			"gateway", gatewayLabel, "sizeBytes", len(raw), "storedSizeBytes", len(payload),
+			"maxStoredSizeBytes", maxFilterConfigBundleSlots*filterConfigBundlePartSizeBytes)
 	}
 	index := &filterapi.ConfigBundleIndex{
 		Version:  version.Parse(),
 		UUID:     uuid,
 		Checksum: filterapi.ConfigBundleChecksum(payload),
 		Parts:    make([]filterapi.ConfigBundlePart, 0, len(chunks)),
+		Encoding: encoding,
 	}
 
 	// Create parts Secrets
@@ -85,11 +144,14 @@ func (c *GatewayController) writeFilterConfigBundle(ctx context.Context, gateway
 				ObjectMeta: metav1.ObjectMeta{Name: partName, Namespace: configSecretNamespace},
 				Data:       partData,
 			}
+			setFilterConfigSecretAnnotations(secret, gatewayName, gatewayNamespace)
 			if _, err = c.kube.CoreV1().Secrets(configSecretNamespace).Create(ctx, secret, metav1.CreateOptions{}); err != nil {
 				return fmt.Errorf("failed to create filter config part secret %s: %w", partName, err)
 			}
 		case err == nil: // found
+			c.generated.observe(gateway, secret)
 			secret.Data = partData
+			setFilterConfigSecretAnnotations(secret, gatewayName, gatewayNamespace)
 			if _, err = c.kube.CoreV1().Secrets(configSecretNamespace).Update(ctx, secret, metav1.UpdateOptions{}); err != nil {
 				return fmt.Errorf("failed to update filter config part secret %s: %w", partName, err)
 			}
@@ -102,6 +164,7 @@ func (c *GatewayController) writeFilterConfigBundle(ctx context.Context, gateway
 		return fmt.Errorf("failed to marshal config bundle index: %w", err)
 	}
 	indexStringData := map[string]string{FilterConfigBundleIndexKey: string(indexRaw)}
+	indexLabels := map[string]string{filterConfigBundleIndexLabel: "true"}
 
 	indexSecret, err := c.kube.CoreV1().Secrets(configSecretNamespace).Get(ctx, indexSecretName, metav1.GetOptions{})
 	switch {
@@ -109,14 +172,21 @@ func (c *GatewayController) writeFilterConfigBundle(ctx context.Context, gateway
 		return fmt.Errorf("failed to get filter config index secret %s: %w", indexSecretName, err)
 	case err != nil && apierrors.IsNotFound(err): // not found
 		indexSecret = &corev1.Secret{
-			ObjectMeta: metav1.ObjectMeta{Name: indexSecretName, Namespace: configSecretNamespace},
+			ObjectMeta: metav1.ObjectMeta{Name: indexSecretName, Namespace: configSecretNamespace, Labels: indexLabels},
 			StringData: indexStringData,
 		}
+		setFilterConfigSecretAnnotations(indexSecret, gatewayName, gatewayNamespace)
 		if _, err = c.kube.CoreV1().Secrets(configSecretNamespace).Create(ctx, indexSecret, metav1.CreateOptions{}); err != nil {
 			return fmt.Errorf("failed to create filter config index secret %s: %w", indexSecretName, err)
 		}
 	case err == nil: // found
+		c.generated.observe(gateway, indexSecret)
 		indexSecret.StringData = indexStringData
+		if indexSecret.Labels == nil {
+			indexSecret.Labels = make(map[string]string, len(indexLabels))
+		}
+		maps.Copy(indexSecret.Labels, indexLabels)
+		setFilterConfigSecretAnnotations(indexSecret, gatewayName, gatewayNamespace)
 		if _, err = c.kube.CoreV1().Secrets(configSecretNamespace).Update(ctx, indexSecret, metav1.UpdateOptions{}); err != nil {
 			return fmt.Errorf("failed to update filter config index secret %s: %w", indexSecretName, err)
 		}
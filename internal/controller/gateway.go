@@ -9,7 +9,12 @@ import (
 	"cmp"
 	"context"
 	"fmt"
+	"maps"
+	"net/http"
+	"regexp"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -18,10 +23,15 @@ import (
 	"github.com/google/uuid"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
 	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -30,10 +40,13 @@ import (
 
 	aigv1a1 "github.com/envoyproxy/ai-gateway/api/v1alpha1"
 	aigv1b1 "github.com/envoyproxy/ai-gateway/api/v1beta1"
+	"github.com/envoyproxy/ai-gateway/internal/backendauth"
+	"github.com/envoyproxy/ai-gateway/internal/configstream"
 	"github.com/envoyproxy/ai-gateway/internal/controller/rotators"
 	"github.com/envoyproxy/ai-gateway/internal/filterapi"
 	"github.com/envoyproxy/ai-gateway/internal/internalapi"
 	"github.com/envoyproxy/ai-gateway/internal/llmcostcel"
+	"github.com/envoyproxy/ai-gateway/internal/ratelimit/translator"
 	"github.com/envoyproxy/ai-gateway/internal/version"
 )
 
@@ -42,6 +55,13 @@ const (
 	FilterConfigKeyInSecret = "filter-config.yaml" //nolint: gosec
 	// defaultOwnedBy is the default value for the ModelsOwnedBy field in the filter config.
 	defaultOwnedBy = "Envoy AI Gateway"
+	// defaultQueueDepthHeader, defaultLoadHeader and defaultLoadReportTTL are the defaults of the
+	// BackendLoadReporting fields.
+	defaultQueueDepthHeader = "x-queue-depth"
+	defaultLoadHeader       = "x-inference-load"
+	defaultLoadReportTTL    = 10 * time.Second
+	// defaultHistorySummarizationTimeout is the default of HistorySummarization.Timeout.
+	defaultHistorySummarizationTimeout = 5 * time.Second
 )
 
 // NewGatewayController creates a new reconcile.TypedReconciler for gwapiv1.Gateway.
@@ -66,6 +86,7 @@ func NewGatewayController(
 		standAlone:            standAlone,
 		uuidFn:                uf,
 		extProcAsSideCar:      extProcAsSideCar,
+		generated:             newGeneratedResources(),
 	}
 }
 
@@ -83,6 +104,25 @@ type GatewayController struct {
 	// Whether to run the extProc container as a sidecar (true) as a normal container (false).
 	// This is essentially a workaround for old k8s versions, and we can remove this in the future.
 	extProcAsSideCar bool
+	// configPublisher pushes the filter configs to the external processors. Optional.
+	configPublisher filterConfigPublisher
+	// quotaRateLimitServiceAddr is the host:port of the quota rate limit service probed by the external processors
+	// to enforce the local fallback of the QuotaPolicies. Optional.
+	quotaRateLimitServiceAddr string
+	// modelNameHeaderKey, metadataNamespace and selectedBackendHeaderKey are the names of the model name header, of
+	// the dynamic metadata namespace and of the selected backend header of the installation, propagated to the filter
+	// configs. Empty means the defaults.
+	modelNameHeaderKey, metadataNamespace, selectedBackendHeaderKey string
+	// namespaceSelector selects the namespaces of the routes included in the filter configs. Nil means all namespaces.
+	namespaceSelector labels.Selector
+	// generated tracks the out-of-band edits of the filter config Secrets, which are reported on the attached routes.
+	generated *generatedResources
+}
+
+// filterConfigPublisher is implemented by [configstream.Server].
+type filterConfigPublisher interface {
+	Publish(name string, config []byte)
+	Delete(name string)
 }
 
 // Reconcile implements the reconcile.Reconciler for gwapiv1.Gateway.
@@ -90,6 +130,13 @@ func (c *GatewayController) Reconcile(ctx context.Context, req ctrl.Request) (ct
 	gw := &gwapiv1.Gateway{}
 	if err := c.client.Get(ctx, req.NamespacedName, gw); err != nil {
 		if apierrors.IsNotFound(err) {
+			c.generated.forget(req.NamespacedName)
+			if c.configPublisher != nil {
+				c.configPublisher.Delete(configstream.ResourceName(req.Name, req.Namespace))
+			}
+			if !c.standAlone {
+				return ctrl.Result{}, c.deleteExtProcPodDisruptionBudgets(ctx, req.Name, req.Namespace)
+			}
 			return ctrl.Result{}, nil
 		}
 		return ctrl.Result{}, err
@@ -111,6 +158,14 @@ func (c *GatewayController) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		return ctrl.Result{}, err
 	}
 
+	// The routes in the namespaces that aren't selected are not reconciled by this controller.
+	if aiRoutes.Items, err = filterSelectedNamespaces(ctx, c.client, c.namespaceSelector, aiRoutes.Items); err != nil {
+		return ctrl.Result{}, err
+	}
+	if mcpRoutes.Items, err = filterSelectedNamespaces(ctx, c.client, c.namespaceSelector, mcpRoutes.Items); err != nil {
+		return ctrl.Result{}, err
+	}
+
 	// Sort MCPRoutes by CreationTimestamp (earliest first) for deterministic prioritization.
 	sort.Slice(mcpRoutes.Items, func(i, j int) bool {
 		return mcpRoutes.Items[i].CreationTimestamp.Before(&mcpRoutes.Items[j].CreationTimestamp)
@@ -139,18 +194,33 @@ func (c *GatewayController) Reconcile(ctx context.Context, req ctrl.Request) (ct
 	if err != nil {
 		return ctrl.Result{}, err
 	}
-	var defaultLLMCosts []aigv1b1.LLMRequestCost
+	var gwConfigSpec *aigv1b1.GatewayConfigSpec
 	if gwConfig != nil {
-		defaultLLMCosts = gwConfig.Spec.GlobalLLMRequestCosts
+		gwConfigSpec = &gwConfig.Spec
 	}
 
 	// We need to create the filter config in Envoy Gateway system namespace because the sidecar extproc need
 	// to access it.
 	var hasEffectiveRoutes bool // indicates whether the filter config is effective (i.e., there is at least one active route).
-	hasEffectiveRoutes, err = c.reconcileFilterConfigSecret(ctx, gw.Name, gw.Namespace, namespace, aiRoutes.Items, mcpRoutes.Items, uid, defaultLLMCosts)
+	hasEffectiveRoutes, err = c.reconcileFilterConfigSecret(ctx, gw.Name, gw.Namespace, namespace, aiRoutes.Items, mcpRoutes.Items, uid, gwConfigSpec)
 	if err != nil {
 		return ctrl.Result{}, err
 	}
+	if modified := c.generated.takeModified(gw); len(modified) > 0 {
+		c.logger.Info("Reverted the modified filter config Secrets", "namespace", gw.Namespace, "name", gw.Name, "secrets", modified)
+		c.reportModifiedFilterConfig(ctx, aiRoutes.Items, mcpRoutes.Items, modified)
+	}
+
+	if !c.standAlone {
+		var pdb *aigv1b1.GatewayConfigExtProcPodDisruptionBudget
+		if gwConfig != nil && gwConfig.Spec.ExtProc != nil {
+			pdb = gwConfig.Spec.ExtProc.PodDisruptionBudget
+		}
+		if err = c.reconcileExtProcPodDisruptionBudget(ctx, gw.Name, gw.Namespace, namespace,
+			extProcPodDisruptionBudgetOwner(deployments, daemonSets), pdb); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
 
 	// Finally, we need to annotate the pods of the gateway deployment with the new uuid to propagate the filter config Secret update faster.
 	// If the pod doesn't have the extproc container, it will roll out the deployment altogether which eventually ends up
@@ -175,6 +245,100 @@ func schemaToFilterAPI(schema aigv1b1.VersionedAPISchema) filterapi.VersionedAPI
 	return ret
 }
 
+// streamConcurrencyLimitsToFilterAPI converts the aigv1b1.StreamConcurrencyLimit list to the filterapi representation.
+func streamConcurrencyLimitsToFilterAPI(limits []aigv1b1.StreamConcurrencyLimit) []filterapi.StreamConcurrencyLimit {
+	if len(limits) == 0 {
+		return nil
+	}
+	ret := make([]filterapi.StreamConcurrencyLimit, 0, len(limits))
+	for i := range limits {
+		l := &limits[i]
+		retryAfter := time.Second
+		if l.RetryAfter != nil {
+			if d, err := time.ParseDuration(string(*l.RetryAfter)); err == nil && d > 0 {
+				retryAfter = d
+			}
+		}
+		fl := filterapi.StreamConcurrencyLimit{
+			MaxConcurrentStreams: int(l.MaxConcurrentStreams),
+			RetryAfterSeconds:    int((retryAfter + time.Second - 1) / time.Second),
+		}
+		for _, h := range l.Headers {
+			fl.Headers = append(fl.Headers, strings.ToLower(string(h)))
+		}
+		ret = append(ret, fl)
+	}
+	return ret
+}
+
+// streamCoalescingToFilterAPI converts the aigv1b1.StreamCoalescing to the filterapi representation. The invalid
+// flush interval is ignored as it is rejected by the CRD validation.
+func streamCoalescingToFilterAPI(c *aigv1b1.StreamCoalescing) *filterapi.StreamCoalescing {
+	if c == nil {
+		return nil
+	}
+	ret := &filterapi.StreamCoalescing{MinBytes: int(ptr.Deref(c.MinBytes, 0))}
+	if c.FlushInterval != nil {
+		if d, err := time.ParseDuration(string(*c.FlushInterval)); err == nil && d > 0 {
+			ret.FlushIntervalMilliseconds = int(d.Milliseconds())
+		}
+	}
+	return ret
+}
+
+// loadReportingToFilterAPI converts the aigv1b1.BackendLoadReporting to the filterapi representation, applying the
+// defaults of the optional fields. The invalid report TTL is ignored as it is rejected by the CRD validation.
+func loadReportingToFilterAPI(l *aigv1b1.BackendLoadReporting) *filterapi.BackendLoadReporting {
+	if l == nil {
+		return nil
+	}
+	ttl := defaultLoadReportTTL
+	if l.ReportTTL != nil {
+		if d, err := time.ParseDuration(string(*l.ReportTTL)); err == nil && d > 0 {
+			ttl = d
+		}
+	}
+	return &filterapi.BackendLoadReporting{
+		QueueDepthHeader:      strings.ToLower(cmp.Or(l.QueueDepthHeader, defaultQueueDepthHeader)),
+		LoadHeader:            strings.ToLower(cmp.Or(l.LoadHeader, defaultLoadHeader)),
+		MaxQueueDepth:         int(ptr.Deref(l.MaxQueueDepth, 0)),
+		MaxLoadPercent:        int(ptr.Deref(l.MaxLoadPercent, 0)),
+		ReportTTLMilliseconds: int(ttl.Milliseconds()),
+	}
+}
+
+// extraBodyToFilterAPI converts the aigv1b1.BackendExtraBody to the filterapi representation, applying the default
+// policy.
+func extraBodyToFilterAPI(e *aigv1b1.BackendExtraBody) *filterapi.ExtraBody {
+	if e == nil {
+		return nil
+	}
+	return &filterapi.ExtraBody{
+		AllowedFields: e.AllowedFields,
+		Policy:        filterapi.ExtraBodyPolicy(cmp.Or(e.Policy, aigv1b1.ExtraBodyPolicyStrip)),
+	}
+}
+
+// fallbackResponseToFilterAPI converts the aigv1b1.FallbackResponse to the filterapi representation, applying the
+// defaults of the optional fields.
+func fallbackResponseToFilterAPI(f *aigv1b1.FallbackResponse) *filterapi.FallbackResponse {
+	if f == nil {
+		return nil
+	}
+	ret := &filterapi.FallbackResponse{
+		StatusCodes: []int{http.StatusTooManyRequests, http.StatusServiceUnavailable},
+		Type:        filterapi.FallbackResponseType(cmp.Or(f.Type, aigv1b1.FallbackResponseTypeError)),
+		Message:     f.Message,
+	}
+	if len(f.StatusCodes) > 0 {
+		ret.StatusCodes = make([]int, len(f.StatusCodes))
+		for i, c := range f.StatusCodes {
+			ret.StatusCodes[i] = int(c)
+		}
+	}
+	return ret
+}
+
 // headerMutationToFilterAPI converts an aigv1b1.HTTPHeaderMutation to filterapi.HTTPHeaderMutation.
 func headerMutationToFilterAPI(m *aigv1b1.HTTPHeaderMutation) *filterapi.HTTPHeaderMutation {
 	if m == nil {
@@ -205,6 +369,49 @@ func bodyMutationToFilterAPI(m *aigv1b1.HTTPBodyMutation) *filterapi.HTTPBodyMut
 	return ret
 }
 
+// vllmExtensionFields are the top-level request body fields of the vLLM extensions to the OpenAI API.
+// https://docs.vllm.ai/en/latest/serving/openai_compatible_server.html#extra-parameters
+var vllmExtensionFields = []string{
+	"guided_json", "guided_regex", "guided_choice", "guided_grammar", "best_of", "use_beam_search",
+}
+
+// stripVLLMExtensions adds the vLLM extension fields to the remove list of the given body mutation for the
+// schemas whose request body is forwarded as-is. The other schemas are translated and never carry these fields.
+func stripVLLMExtensions(m *filterapi.HTTPBodyMutation, schema aigv1b1.APISchema) *filterapi.HTTPBodyMutation {
+	if schema != aigv1b1.APISchemaOpenAI && schema != aigv1b1.APISchemaAzureOpenAI {
+		return m
+	}
+	if m == nil {
+		m = &filterapi.HTTPBodyMutation{}
+	}
+	for _, field := range vllmExtensionFields {
+		if !slices.Contains(m.Remove, field) {
+			m.Remove = append(m.Remove, field)
+		}
+	}
+	return m
+}
+
+// piiTokenizationToFilterAPI converts an aigv1b1.PIITokenization to filterapi.PIITokenization.
+// This returns an error if any of the custom patterns is not a valid regular expression, since otherwise
+// the external processor would reject the whole configuration.
+func piiTokenizationToFilterAPI(t *aigv1b1.PIITokenization) (*filterapi.PIITokenization, error) {
+	if t == nil {
+		return nil, nil
+	}
+	ret := &filterapi.PIITokenization{}
+	for _, d := range t.Detectors {
+		ret.Detectors = append(ret.Detectors, string(d))
+	}
+	for _, p := range t.CustomPatterns {
+		if _, err := regexp.Compile(p.Regex); err != nil {
+			return nil, fmt.Errorf("invalid regex for PII pattern %s: %w", p.Name, err)
+		}
+		ret.CustomPatterns = append(ret.CustomPatterns, filterapi.PIIPattern{Name: p.Name, Regex: p.Regex})
+	}
+	return ret, nil
+}
+
 // validateCELExpression validates and returns a CEL expression for cost calculation.
 func validateCELExpression(cost aigv1b1.LLMRequestCost) (string, error) {
 	if cost.CEL == nil {
@@ -250,6 +457,142 @@ func aigwLLMRequestCostToFilterAPI(cost aigv1b1.LLMRequestCost, routeName string
 	return out, nil
 }
 
+// aigwExperimentToFilterAPI converts the experiment of the AIGatewayRoute (routeName is "namespace/name") to
+// filter API form.
+func aigwExperimentToFilterAPI(experiment *aigv1b1.AIGatewayRouteExperiment, routeName string) filterapi.Experiment {
+	out := filterapi.Experiment{
+		RouteName: routeName,
+		Name:      experiment.Name,
+		// The request headers are lower-cased in the filter.
+		StickyHeader: strings.ToLower(experiment.StickyHeader),
+		Variants:     make([]filterapi.ExperimentVariant, 0, len(experiment.Variants)),
+	}
+	for _, v := range experiment.Variants {
+		out.Variants = append(out.Variants, filterapi.ExperimentVariant{
+			Name:              v.Name,
+			Percentage:        v.Percentage,
+			ModelNameOverride: v.ModelNameOverride,
+		})
+	}
+	return out
+}
+
+// aigwParameterOverridesToFilterAPI converts the parameter overrides of the AIGatewayRoute (routeName is
+// "namespace/name") to filter API form, applying the default lower bounds.
+func aigwParameterOverridesToFilterAPI(overrides *aigv1b1.AIGatewayRouteParameterOverrides, routeName string) (filterapi.ParameterOverrides, error) {
+	out := filterapi.ParameterOverrides{RouteName: routeName}
+	if t := overrides.Temperature; t != nil {
+		out.Temperature = &filterapi.TemperatureBounds{}
+		if t.Min != "" {
+			v, err := strconv.ParseFloat(t.Min, 64)
+			if err != nil {
+				return out, fmt.Errorf("invalid temperature min %q: %w", t.Min, err)
+			}
+			out.Temperature.Min = v
+		}
+		v, err := strconv.ParseFloat(t.Max, 64)
+		if err != nil {
+			return out, fmt.Errorf("invalid temperature max %q: %w", t.Max, err)
+		}
+		out.Temperature.Max = v
+	}
+	if m := overrides.MaxTokens; m != nil {
+		out.MaxTokens = &filterapi.MaxTokensBounds{Min: int(ptr.Deref(m.Min, 1)), Max: int(m.Max)}
+	}
+	return out, nil
+}
+
+// aigwHistoryPolicyToFilterAPI converts the history policy of the AIGatewayRoute (routeName is "namespace/name") to
+// filter API form, applying the defaults of the optional fields.
+func aigwHistoryPolicyToFilterAPI(policy *aigv1b1.AIGatewayRouteHistoryPolicy, routeName string) filterapi.HistoryPolicy {
+	out := filterapi.HistoryPolicy{
+		RouteName:      routeName,
+		MaxTurns:       int(ptr.Deref(policy.MaxTurns, 0)),
+		MaxInputTokens: int(ptr.Deref(policy.MaxInputTokens, 0)),
+		Strategy:       filterapi.HistoryElisionStrategy(cmp.Or(policy.Strategy, aigv1b1.HistoryElisionStrategyTruncate)),
+	}
+	if s := policy.Summarization; s != nil {
+		timeout := defaultHistorySummarizationTimeout
+		if s.Timeout != nil {
+			if d, err := time.ParseDuration(string(*s.Timeout)); err == nil && d > 0 {
+				timeout = d
+			}
+		}
+		out.Summarization = &filterapi.HistorySummarization{
+			URL:                 s.URL,
+			Model:               s.Model,
+			TimeoutMilliseconds: int(timeout.Milliseconds()),
+		}
+	}
+	return out
+}
+
+// aigwReasoningBudgetToFilterAPI converts the reasoning budget of the AIGatewayRoute (routeName is "namespace/name")
+// to filter API form.
+func aigwReasoningBudgetToFilterAPI(budget *aigv1b1.AIGatewayRouteReasoningBudget, routeName string) filterapi.ReasoningBudget {
+	return filterapi.ReasoningBudget{
+		RouteName:          routeName,
+		MaxReasoningTokens: int(ptr.Deref(budget.MaxReasoningTokens, 0)),
+		MaxEffort:          string(budget.MaxEffort),
+		HardStop:           ptr.Deref(budget.HardStop, false),
+	}
+}
+
+// aigwToolCallLoopGuardToFilterAPI converts the tool call loop guard of the AIGatewayRoute (routeName is
+// "namespace/name") to filter API form, applying the default action.
+func aigwToolCallLoopGuardToFilterAPI(guard *aigv1b1.AIGatewayRouteToolCallLoopGuard, routeName string) filterapi.ToolCallLoopGuard {
+	return filterapi.ToolCallLoopGuard{
+		RouteName:      routeName,
+		MaxRepetitions: int(guard.MaxRepetitions),
+		Action:         filterapi.ToolCallLoopAction(cmp.Or(guard.Action, aigv1b1.ToolCallLoopActionReject)),
+	}
+}
+
+// aigwAllowedEndpointsToFilterAPI converts the allowed endpoints of the AIGatewayRoute (routeName is "namespace/name")
+// to filter API form.
+func aigwAllowedEndpointsToFilterAPI(endpoints []aigv1b1.AIGatewayRouteEndpoint, routeName string) filterapi.AllowedEndpoints {
+	a := filterapi.AllowedEndpoints{RouteName: routeName, Endpoints: make([]filterapi.Endpoint, 0, len(endpoints))}
+	for _, e := range endpoints {
+		a.Endpoints = append(a.Endpoints, filterapi.Endpoint(e))
+	}
+	return a
+}
+
+// aigwBackendOverrideToFilterAPI converts the backend override of the AIGatewayRoute (routeName is "namespace/name")
+// to filter API form. The override is kept without a key when the secret cannot be read, so that the requests
+// pinning a backend are rejected rather than routed without verification.
+func (c *GatewayController) aigwBackendOverrideToFilterAPI(ctx context.Context, route *aigv1b1.AIGatewayRoute, routeName string) filterapi.BackendOverride {
+	out := filterapi.BackendOverride{RouteName: routeName}
+	ref := route.Spec.BackendOverride.SecretRef
+	if ref == nil {
+		return out
+	}
+	key, err := c.getSecretData(ctx, route.Namespace, string(ref.Name), hmacKeyInSecret)
+	if err != nil {
+		c.logger.Error(err, "failed to get the HMAC key of the backend override, rejecting the requests pinning a backend",
+			"namespace", route.Namespace, "name", route.Name)
+		return out
+	}
+	out.HMACKey = key
+	return out
+}
+
+// responseAttestationToFilterAPI converts the response attestation of the GatewayConfig in the given namespace to
+// filter API form, reading its private key from the secret. The responses aren't attested when the secret cannot be
+// read.
+func (c *GatewayController) responseAttestationToFilterAPI(ctx context.Context, namespace string, a *aigv1b1.ResponseAttestation) *filterapi.ResponseAttestation {
+	if a == nil || a.SecretRef == nil {
+		return nil
+	}
+	key, err := c.getSecretData(ctx, namespace, string(a.SecretRef.Name), privateKeyInSecret)
+	if err != nil {
+		c.logger.Error(err, "failed to get the private key of the response attestation, the responses are not attested",
+			"namespace", namespace, "name", a.SecretRef.Name)
+		return nil
+	}
+	return &filterapi.ResponseAttestation{Identity: a.Identity, PrivateKey: key}
+}
+
 // mergeBodyMutations merges route-level and backend-level BodyMutation with route-level taking precedence.
 // Returns the merged BodyMutation where route-level operations override backend-level operations for conflicting body fields.
 func mergeBodyMutations(routeLevel, backendLevel *aigv1b1.HTTPBodyMutation) *aigv1b1.HTTPBodyMutation {
@@ -345,6 +688,7 @@ func mergeHeaderMutations(routeLevel, backendLevel *aigv1b1.HTTPHeaderMutation)
 }
 
 // reconcileFilterConfigSecret updates the filter config secret for the external processor.
+// gwConfigSpec is the spec of the GatewayConfig attached to the Gateway, or nil if there is none.
 func (c *GatewayController) reconcileFilterConfigSecret(
 	ctx context.Context,
 	gatewayName,
@@ -353,23 +697,43 @@ func (c *GatewayController) reconcileFilterConfigSecret(
 	aiGatewayRoutes []aigv1b1.AIGatewayRoute,
 	mcpRoutes []aigv1b1.MCPRoute,
 	uuid string,
-	defaultLLMCosts []aigv1b1.LLMRequestCost,
+	gwConfigSpec *aigv1b1.GatewayConfigSpec,
 ) (hasEffectiveRoute bool, _ error) {
 	// Precondition: aiGatewayRoutes is not empty as we early return if it is empty.
 	ec := &filterapi.Config{UUID: uuid, Version: version.Parse()}
 	var err error
+	if gwConfigSpec == nil {
+		// No GatewayConfig is attached to the Gateway, hence all the defaults.
+		gwConfigSpec = &aigv1b1.GatewayConfigSpec{}
+	}
 
 	// Process global LLM request costs from GatewayConfig.
 	// These have no RouteName and serve as defaults.
 	// Note: The CRD enforces uniqueness via +listType=map and +listMapKey=metadataKey,
 	// so we don't need to deduplicate here.
-	for _, cost := range defaultLLMCosts {
+	for _, cost := range gwConfigSpec.GlobalLLMRequestCosts {
 		fc, convErr := aigwGlobalLLMRequestCostToFilterAPI(cost)
 		if convErr != nil {
 			return false, fmt.Errorf("failed to convert global LLMRequestCosts: %w", convErr)
 		}
 		ec.GlobalLLMRequestCosts = append(ec.GlobalLLMRequestCosts, fc)
 	}
+	ec.StreamConcurrencyLimits = streamConcurrencyLimitsToFilterAPI(gwConfigSpec.StreamConcurrencyLimits)
+	ec.TruncatedStreamErrorEvent = gwConfigSpec.TruncatedStreamErrorEvent
+	ec.StreamCoalescing = streamCoalescingToFilterAPI(gwConfigSpec.StreamCoalescing)
+	if promptInjection := gwConfigSpec.PromptInjectionDetection; promptInjection != nil {
+		ec.PromptInjectionDetection = &filterapi.PromptInjectionDetection{
+			BlockThreshold: int(ptr.Deref(promptInjection.BlockThreshold, 0)),
+		}
+	}
+	ec.FallbackResponse = fallbackResponseToFilterAPI(gwConfigSpec.FallbackResponse)
+	ec.ResponseAttestation = c.responseAttestationToFilterAPI(ctx, gatewayNamespace, gwConfigSpec.ResponseAttestation)
+	ec.DebugEcho = gwConfigSpec.DebugEcho
+	ec.ModelNameHeaderKey = c.modelNameHeaderKey
+	ec.MetadataNamespace = c.metadataNamespace
+	ec.SelectedBackendHeaderKey = c.selectedBackendHeaderKey
+	modelNameHeaderKey := cmp.Or(c.modelNameHeaderKey, internalapi.ModelNameHeaderKeyDefault)
+	authErrs := &backendAuthErrors{}
 
 	// Models contributed by routes with no Spec.Hostnames. We only promote these to
 	// ec.UnscopedModels (and merge them into ec.ModelsByHost) when at least one route
@@ -389,48 +753,90 @@ func (c *GatewayController) reconcileFilterConfigSecret(
 		routeBackendNamesSet := map[string]struct{}{}
 		routeBackendNames := []string{}
 		injectedQuotaCosts := make(map[string]struct{})
-		for ruleIndex := range spec.Rules {
-			rule := &spec.Rules[ruleIndex]
-			for _, m := range rule.Matches {
-				for _, h := range m.Headers {
-					// If explicitly set to something that is not an exact match, skip.
-					// If not set, we assume it's an exact match.
-					//
-					// Also, we only care about the AIModel header to declare models.
-					if (h.Type != nil && *h.Type != gwapiv1.HeaderMatchExact) || string(h.Name) != internalapi.ModelNameHeaderKeyDefault {
-						continue
-					}
-					model := filterapi.Model{
-						Name:      h.Value,
-						CreatedAt: ptr.Deref[metav1.Time](rule.ModelsCreatedAt, aiGatewayRoute.CreationTimestamp).UTC(),
-						OwnedBy:   ptr.Deref(rule.ModelsOwnedBy, defaultOwnedBy),
-					}
-					ec.Models = append(ec.Models, model)
-					if len(hostnames) > 0 {
-						if ec.ModelsByHost == nil {
-							ec.ModelsByHost = make(map[string][]filterapi.Model)
+		var modelVisibility *filterapi.ModelVisibility
+		if mv := spec.ModelVisibility; mv != nil {
+			modelVisibility = &filterapi.ModelVisibility{TenantHeader: strings.ToLower(mv.TenantHeader), Tenants: mv.AllowedTenants}
+		}
+		// The backends of the migration rules are named after the index of their rule in the generated HTTPRoute, like
+		// the ones of the rules of the route.
+		migrationRules := internalapi.MigrationRules(spec.Rules)
+		for i := range len(spec.Rules) + len(migrationRules) {
+			ruleIndex, isMigration := i, i >= len(spec.Rules)
+			var rule *aigv1b1.AIGatewayRouteRule
+			if isMigration {
+				m := &migrationRules[i-len(spec.Rules)]
+				ruleIndex, rule = m.HTTPRouteRuleIndex, &m.Rule
+				var timeout time.Duration
+				if t := rule.GetTimeoutsOrDefault().Request; t != nil {
+					timeout, _ = time.ParseDuration(string(*t)) // Validated by the CRD.
+				}
+				ec.Migrations = append(ec.Migrations, filterapi.Migration{
+					RouteName:           routeName,
+					RuleIndex:           m.RuleIndex,
+					BackendName:         internalapi.PerRouteRuleRefBackendName(aiGatewayRoute.Namespace, rule.BackendRefs[0].Name, aiGatewayRoute.Name, ruleIndex, 0),
+					SamplePercent:       int(ptr.Deref(spec.Rules[m.RuleIndex].Migration.SamplePercent, 10)),
+					TimeoutMilliseconds: int(timeout.Milliseconds()),
+				})
+			} else {
+				rule = &spec.Rules[i]
+			}
+			// The models are only declared by the rules of the route, since the migration rules have the same matches,
+			// and only listed if the route allows the models endpoint.
+			if !isMigration {
+				listModels := len(spec.AllowedEndpoints) == 0 || slices.Contains(spec.AllowedEndpoints, aigv1b1.AIGatewayRouteEndpointModels)
+				for _, m := range rule.Matches {
+					for _, h := range m.Headers {
+						// If explicitly set to something that is not an exact match, skip.
+						// If not set, we assume it's an exact match.
+						//
+						// Also, we only care about the AIModel header to declare models.
+						if (h.Type != nil && *h.Type != gwapiv1.HeaderMatchExact) || string(h.Name) != modelNameHeaderKey {
+							continue
+						}
+						if !listModels {
+							continue
+						}
+						model := filterapi.Model{
+							Name:       h.Value,
+							CreatedAt:  ptr.Deref[metav1.Time](rule.ModelsCreatedAt, aiGatewayRoute.CreationTimestamp).UTC(),
+							OwnedBy:    ptr.Deref(rule.ModelsOwnedBy, defaultOwnedBy),
+							Visibility: modelVisibility,
 						}
-						for _, hn := range hostnames {
-							ec.ModelsByHost[string(hn)] = append(ec.ModelsByHost[string(hn)], model)
+						ec.Models = append(ec.Models, model)
+						if len(hostnames) > 0 {
+							if ec.ModelsByHost == nil {
+								ec.ModelsByHost = make(map[string][]filterapi.Model)
+							}
+							for _, hn := range hostnames {
+								ec.ModelsByHost[string(hn)] = append(ec.ModelsByHost[string(hn)], model)
+							}
+						} else {
+							// Routes without hostnames are "unscoped": they apply to every host.
+							// Tracked in unscopedModels for now; only promoted to ec.UnscopedModels
+							// after the loop if at least one scoped route is also present.
+							unscopedModels = append(unscopedModels, model)
 						}
-					} else {
-						// Routes without hostnames are "unscoped": they apply to every host.
-						// Tracked in unscopedModels for now; only promoted to ec.UnscopedModels
-						// after the loop if at least one scoped route is also present.
-						unscopedModels = append(unscopedModels, model)
 					}
 				}
+				ec.ContextWindows = append(ec.ContextWindows, ruleContextWindows(rule, spec.Experiment, routeName, modelNameHeaderKey)...)
 			}
 			for backendRefIndex := range rule.BackendRefs {
 				backendRef := &rule.BackendRefs[backendRefIndex]
 				b := filterapi.Backend{}
 				b.Name = internalapi.PerRouteRuleRefBackendName(aiGatewayRoute.Namespace, backendRef.Name, aiGatewayRoute.Name, ruleIndex, backendRefIndex)
 				b.ModelNameOverride = backendRef.ModelNameOverride
+				if rule.ContextLengthRetry != nil {
+					b.ContextLengthRetry = filterapi.ContextLengthRetryStrategy(rule.ContextLengthRetry.Strategy)
+				}
+				b.FailoverRetry = rule.FailoverRetry != nil
+				b.StreamStallTimeoutMilliseconds = int(rule.GetStreamStallTimeout().Milliseconds())
 
 				var bsp *aigv1b1.BackendSecurityPolicy
 				backendNamespace := backendRef.GetNamespace(aiGatewayRoute.Namespace)
+				backendKind := "AIServiceBackend"
 
 				if backendRef.IsInferencePool() {
+					backendKind = "InferencePool"
 					// We assume that InferencePools are all OpenAI schema.
 					b.Schema = filterapi.VersionedAPISchema{
 						Name: filterapi.APISchemaOpenAI,
@@ -446,6 +852,9 @@ func (c *GatewayController) reconcileFilterConfigSecret(
 						continue
 					}
 				} else {
+					if spec.BackendOverride != nil {
+						b.BackendOverrideName = backendNamespace + "/" + backendRef.Name
+					}
 					var backendObj *aigv1b1.AIServiceBackend
 					backendObj, bsp, err = c.backendWithMaybeBSP(ctx, backendNamespace, backendRef.Name)
 					if err != nil {
@@ -470,16 +879,39 @@ func (c *GatewayController) reconcileFilterConfigSecret(
 					// Merge with route-level taking precedence over backend-level
 					mergedBodyMutation := mergeBodyMutations(routeBodyMutation, backendBodyMutation)
 					b.BodyMutation = bodyMutationToFilterAPI(mergedBodyMutation)
+					if backendObj.Spec.VLLMExtensions == aigv1b1.VLLMExtensionsPolicyStrip {
+						b.BodyMutation = stripVLLMExtensions(b.BodyMutation, backendObj.Spec.APISchema.Name)
+					}
+
+					b.RecompressRequest = backendObj.Spec.RequestCompression == aigv1b1.RequestCompressionPolicyRecompress
+					b.TraceContextPropagation = filterapi.TraceContextPropagation(backendObj.Spec.TraceContextPropagation)
+					b.LoadReporting = loadReportingToFilterAPI(backendObj.Spec.LoadReporting)
+					b.ExtraBody = extraBodyToFilterAPI(backendObj.Spec.ExtraBody)
+
+					b.PIITokenization, err = piiTokenizationToFilterAPI(backendObj.Spec.PIITokenization)
+					if err != nil {
+						c.logger.Error(err, "failed to convert PII tokenization. Skipping this backend.",
+							"backend_name", backendRef.Name, "aigatewayroute", aiGatewayRoute.Name,
+							"namespace", backendNamespace)
+						continue
+					}
 
 					b.Schema = schemaToFilterAPI(backendObj.Spec.APISchema)
 				}
 
 				if bsp != nil {
 					b.Auth, err = c.bspToFilterAPIBackendAuth(ctx, bsp)
+					if err == nil {
+						// Check the auth the same way the external processor does when loading the config, since a single
+						// invalid backend auth fails the whole config there.
+						err = backendauth.Validate(b.Auth)
+					}
 					if err != nil {
 						c.logger.Error(err, "failed to get backend auth from backend security policy. Skipping this backend.",
 							"backend_name", backendRef.Name, "backend_security_policy", bsp.Name,
 							"aigatewayroute", aiGatewayRoute.Name, "namespace", aiGatewayRoute.Namespace)
+						authErrs.add(client.ObjectKeyFromObject(aiGatewayRoute), backendKind,
+							client.ObjectKey{Namespace: backendNamespace, Name: backendRef.Name}, bsp.Name, err)
 						continue
 					}
 					// For header-source credential override, strip the x-aigw-* input header before
@@ -520,6 +952,38 @@ func (c *GatewayController) reconcileFilterConfigSecret(
 				ec.LLMRequestCosts = append(ec.LLMRequestCosts, fc)
 			}
 		}
+		if spec.Experiment != nil {
+			ec.Experiments = append(ec.Experiments, aigwExperimentToFilterAPI(spec.Experiment, routeName))
+		}
+		if spec.ParameterOverrides != nil {
+			overrides, convErr := aigwParameterOverridesToFilterAPI(spec.ParameterOverrides, routeName)
+			if convErr != nil {
+				return false, fmt.Errorf("failed to convert ParameterOverrides for route %s: %w", aiGatewayRoute.Name, convErr)
+			}
+			ec.ParameterOverrides = append(ec.ParameterOverrides, overrides)
+		}
+		if spec.BackendOverride != nil {
+			ec.BackendOverrides = append(ec.BackendOverrides, c.aigwBackendOverrideToFilterAPI(ctx, aiGatewayRoute, routeName))
+		}
+		// Drop is the behavior of the translators, hence only the other behaviors are configured.
+		if b := spec.UnsupportedParameterBehavior; b != "" && b != aigv1b1.UnsupportedParameterBehaviorDrop {
+			ec.UnsupportedParameters = append(ec.UnsupportedParameters, filterapi.UnsupportedParameters{
+				RouteName: routeName,
+				Behavior:  filterapi.UnsupportedParameterBehavior(b),
+			})
+		}
+		if spec.HistoryPolicy != nil {
+			ec.HistoryPolicies = append(ec.HistoryPolicies, aigwHistoryPolicyToFilterAPI(spec.HistoryPolicy, routeName))
+		}
+		if spec.ReasoningBudget != nil {
+			ec.ReasoningBudgets = append(ec.ReasoningBudgets, aigwReasoningBudgetToFilterAPI(spec.ReasoningBudget, routeName))
+		}
+		if spec.ToolCallLoopGuard != nil {
+			ec.ToolCallLoopGuards = append(ec.ToolCallLoopGuards, aigwToolCallLoopGuardToFilterAPI(spec.ToolCallLoopGuard, routeName))
+		}
+		if len(spec.AllowedEndpoints) > 0 {
+			ec.AllowedEndpoints = append(ec.AllowedEndpoints, aigwAllowedEndpointsToFilterAPI(spec.AllowedEndpoints, routeName))
+		}
 	}
 
 	// If at least one route is hostname-scoped, promote the unscoped models to ec.UnscopedModels
@@ -534,6 +998,8 @@ func (c *GatewayController) reconcileFilterConfigSecret(
 		}
 	}
 
+	c.reportBackendAuthErrors(ctx, authErrs)
+
 	// Configuration for MCP processor.
 	var effectiveMCPRoute bool
 	ec.MCPConfig, effectiveMCPRoute = mcpConfig(mcpRoutes)
@@ -550,9 +1016,107 @@ func (c *GatewayController) reconcileFilterConfigSecret(
 	if err = c.writeLegacyFilterConfigSecret(ctx, gatewayName, gatewayNamespace, configSecretNamespace, marshaled); err != nil {
 		return false, err
 	}
+	if c.configPublisher != nil {
+		c.configPublisher.Publish(configstream.ResourceName(gatewayName, gatewayNamespace), marshaled)
+	}
 	return hasEffectiveRoute, nil
 }
 
+// backendAuthErrors collects the backend auth misconfigurations found while generating the filter config, per
+// AIGatewayRoute and per AIServiceBackend, to report them in their status.
+type backendAuthErrors struct {
+	routes, backends map[client.ObjectKey][]string
+}
+
+// add records the error of the auth of the backend of the kind referenced by the route, from the BackendSecurityPolicy.
+func (e *backendAuthErrors) add(route client.ObjectKey, backendKind string, backend client.ObjectKey, bspName string, err error) {
+	if e.routes == nil {
+		e.routes, e.backends = make(map[client.ObjectKey][]string), make(map[client.ObjectKey][]string)
+	}
+	appendUnique := func(m map[client.ObjectKey][]string, key client.ObjectKey, message string) {
+		if !slices.Contains(m[key], message) {
+			m[key] = append(m[key], message)
+		}
+	}
+	appendUnique(e.routes, route, fmt.Sprintf("invalid backend auth of %s %s from BackendSecurityPolicy %s: %v", backendKind, backend, bspName, err))
+	// The status of the InferencePools is managed by Envoy Gateway.
+	if backendKind == "AIServiceBackend" {
+		appendUnique(e.backends, backend, fmt.Sprintf("invalid backend auth from BackendSecurityPolicy %s: %v", bspName, err))
+	}
+}
+
+// reportBackendAuthErrors sets the NotAccepted condition of the AIGatewayRoutes and AIServiceBackends whose backend
+// auth is invalid, since the backends are left out of the filter config.
+//
+// The Gateway is reconciled after the AIGatewayRoutes and AIServiceBackends it depends on, so the conditions set here
+// are kept until those are reconciled again, e.g. after the BackendSecurityPolicy or its Secret is fixed.
+func (c *GatewayController) reportBackendAuthErrors(ctx context.Context, errs *backendAuthErrors) {
+	for key, messages := range errs.routes {
+		c.updateStatusConditions(ctx, key, &aigv1b1.AIGatewayRoute{}, strings.Join(messages, "; "), func(o client.Object, conditions []metav1.Condition) {
+			o.(*aigv1b1.AIGatewayRoute).Status.Conditions = conditions
+		})
+	}
+	for key, messages := range errs.backends {
+		c.updateStatusConditions(ctx, key, &aigv1b1.AIServiceBackend{}, strings.Join(messages, "; "), func(o client.Object, conditions []metav1.Condition) {
+			o.(*aigv1b1.AIServiceBackend).Status.Conditions = conditions
+		})
+	}
+}
+
+// reportModifiedFilterConfig sets the Degraded condition of the routes attached to the Gateway whose filter config
+// Secrets were modified out of band. The route controllers keep the condition until the routes change.
+func (c *GatewayController) reportModifiedFilterConfig(ctx context.Context, aiRoutes []aigv1b1.AIGatewayRoute,
+	mcpRoutes []aigv1b1.MCPRoute, modified []string,
+) {
+	for i := range aiRoutes {
+		c.setDegradedCondition(ctx, client.ObjectKeyFromObject(&aiRoutes[i]), &aigv1b1.AIGatewayRoute{}, modified,
+			func(o client.Object) *[]metav1.Condition { return &o.(*aigv1b1.AIGatewayRoute).Status.Conditions })
+	}
+	for i := range mcpRoutes {
+		c.setDegradedCondition(ctx, client.ObjectKeyFromObject(&mcpRoutes[i]), &aigv1b1.MCPRoute{}, modified,
+			func(o client.Object) *[]metav1.Condition { return &o.(*aigv1b1.MCPRoute).Status.Conditions })
+	}
+}
+
+// setDegradedCondition sets the Degraded condition for the modified resources on the object of the key, keeping
+// its other conditions.
+func (c *GatewayController) setDegradedCondition(ctx context.Context, key client.ObjectKey, obj client.Object, modified []string,
+	conditions func(client.Object) *[]metav1.Condition,
+) {
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if err := c.client.Get(ctx, key, obj); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil
+			}
+			return err
+		}
+		meta.SetStatusCondition(conditions(obj), newDegradedCondition(obj.GetGeneration(), modified))
+		return c.client.Status().Update(ctx, obj)
+	})
+	if err != nil {
+		c.logger.Error(err, "failed to update status", "namespace", key.Namespace, "name", key.Name)
+	}
+}
+
+// updateStatusConditions sets the NotAccepted condition with the message on the object of the key.
+func (c *GatewayController) updateStatusConditions(ctx context.Context, key client.ObjectKey, obj client.Object, message string,
+	setConditions func(client.Object, []metav1.Condition),
+) {
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if err := c.client.Get(ctx, key, obj); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil
+			}
+			return err
+		}
+		setConditions(obj, newConditions(aigv1b1.ConditionTypeNotAccepted, message))
+		return c.client.Status().Update(ctx, obj)
+	})
+	if err != nil {
+		c.logger.Error(err, "failed to update status", "namespace", key.Namespace, "name", key.Name)
+	}
+}
+
 func (c *GatewayController) writeLegacyFilterConfigSecret(
 	ctx context.Context,
 	gatewayName,
@@ -575,6 +1139,7 @@ func (c *GatewayController) writeLegacyFilterConfigSecret(
 				ObjectMeta: metav1.ObjectMeta{Name: legacySecretName, Namespace: configSecretNamespace},
 				StringData: data,
 			}
+			setFilterConfigSecretAnnotations(secret, gatewayName, gatewayNamespace)
 			if _, err = c.kube.CoreV1().Secrets(configSecretNamespace).Create(ctx, secret, metav1.CreateOptions{}); err != nil {
 				return fmt.Errorf("failed to create secret %s: %w", legacySecretName, err)
 			}
@@ -583,7 +1148,9 @@ func (c *GatewayController) writeLegacyFilterConfigSecret(
 		return fmt.Errorf("failed to get secret %s: %w", legacySecretName, err)
 	}
 
+	c.generated.observe(&gwapiv1.Gateway{ObjectMeta: metav1.ObjectMeta{Name: gatewayName, Namespace: gatewayNamespace}}, secret)
 	secret.StringData = data
+	setFilterConfigSecretAnnotations(secret, gatewayName, gatewayNamespace)
 	if _, err := c.kube.CoreV1().Secrets(configSecretNamespace).Update(ctx, secret, metav1.UpdateOptions{}); err != nil {
 		return fmt.Errorf("failed to update secret %s: %w", secret.Name, err)
 	}
@@ -717,6 +1284,10 @@ func defaultOverrideHeaderName(t aigv1b1.BackendSecurityPolicyType) string {
 		return "x-aigw-azure-access-token"
 	case aigv1b1.BackendSecurityPolicyTypeGCPCredentials:
 		return "x-aigw-gcp-access-token"
+	case aigv1b1.BackendSecurityPolicyTypeIBMCloudAPIKey:
+		return "x-aigw-ibm-cloud-access-token"
+	case aigv1b1.BackendSecurityPolicyTypeSAPAICoreCredentials:
+		return "x-aigw-sap-ai-core-access-token"
 	default:
 		return ""
 	}
@@ -772,12 +1343,30 @@ func (c *GatewayController) bspToFilterAPIBackendAuth(ctx context.Context, backe
 
 	switch spec.Type {
 	case aigv1b1.BackendSecurityPolicyTypeAPIKey:
-		secretName := string(spec.APIKey.SecretRef.Name)
-		apiKey, getErr := c.getSecretData(ctx, namespace, secretName, apiKeyInSecret)
-		if getErr != nil {
-			return nil, fmt.Errorf("failed to get secret %s: %w", secretName, getErr)
+		auth = &filterapi.BackendAuth{APIKey: &filterapi.APIKeyAuth{
+			Organization: spec.APIKey.Organization,
+			Project:      spec.APIKey.Project,
+		}}
+		if spec.APIKey.SecretRef != nil {
+			secretName := string(spec.APIKey.SecretRef.Name)
+			auth.APIKey.Key, err = c.getSecretData(ctx, namespace, secretName, apiKeyInSecret)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get secret %s: %w", secretName, err)
+			}
+		}
+		for i := range spec.APIKey.Pool {
+			entry := &spec.APIKey.Pool[i]
+			secretName := string(entry.SecretRef.Name)
+			apiKey, getErr := c.getSecretData(ctx, namespace, secretName, apiKeyInSecret)
+			if getErr != nil {
+				return nil, fmt.Errorf("failed to get secret %s of the pool entry %s: %w", secretName, entry.Name, getErr)
+			}
+			auth.APIKey.Pool = append(auth.APIKey.Pool, filterapi.APIKeyPoolEntry{
+				Name:   fmt.Sprintf("%s/%s/%s", namespace, backendSecurityPolicy.Name, entry.Name),
+				Key:    apiKey,
+				Weight: int(ptr.Deref(entry.Weight, 1)),
+			})
 		}
-		auth = &filterapi.BackendAuth{APIKey: &filterapi.APIKeyAuth{Key: apiKey}}
 		hasStaticCred = true
 	case aigv1b1.BackendSecurityPolicyTypeAzureAPIKey:
 		secretName := string(spec.AzureAPIKey.SecretRef.Name)
@@ -803,7 +1392,8 @@ func (c *GatewayController) bspToFilterAPIBackendAuth(ctx context.Context, backe
 		if awsCred.CredentialsFile == nil && awsCred.OIDCExchangeToken == nil {
 			return &filterapi.BackendAuth{
 				AWSAuth: &filterapi.AWSAuth{
-					Region: awsCred.Region,
+					Region:    awsCred.Region,
+					RegionSet: awsCred.RegionSet,
 				},
 			}, nil
 		}
@@ -824,6 +1414,7 @@ func (c *GatewayController) bspToFilterAPIBackendAuth(ctx context.Context, backe
 			AWSAuth: &filterapi.AWSAuth{
 				CredentialFileLiteral: credentialsLiteral,
 				Region:                awsCred.Region,
+				RegionSet:             awsCred.RegionSet,
 			},
 		}, nil
 	case aigv1b1.BackendSecurityPolicyTypeAzureCredentials:
@@ -863,6 +1454,25 @@ func (c *GatewayController) bspToFilterAPIBackendAuth(ctx context.Context, backe
 			}
 			hasStaticCred = true
 		}
+	case aigv1b1.BackendSecurityPolicyTypeIBMCloudAPIKey:
+		secretName := rotators.GetBSPSecretName(backendSecurityPolicy.Name)
+		ibmCloudAccessToken, getErr := c.getSecretData(ctx, namespace, secretName, rotators.IBMCloudAccessTokenKey)
+		if getErr != nil {
+			return nil, fmt.Errorf("failed to get secret %s: %w", secretName, getErr)
+		}
+		auth = &filterapi.BackendAuth{IBMCloudAuth: &filterapi.IBMCloudAuth{AccessToken: ibmCloudAccessToken}}
+		hasStaticCred = true
+	case aigv1b1.BackendSecurityPolicyTypeSAPAICoreCredentials:
+		secretName := rotators.GetBSPSecretName(backendSecurityPolicy.Name)
+		sapAICoreAccessToken, getErr := c.getSecretData(ctx, namespace, secretName, rotators.SAPAICoreAccessTokenKey)
+		if getErr != nil {
+			return nil, fmt.Errorf("failed to get secret %s: %w", secretName, getErr)
+		}
+		auth = &filterapi.BackendAuth{SAPAICoreAuth: &filterapi.SAPAICoreAuth{
+			AccessToken:   sapAICoreAccessToken,
+			ResourceGroup: cmp.Or(spec.SAPAICoreCredentials.ResourceGroup, "default"),
+		}}
+		hasStaticCred = true
 	default:
 		return nil, fmt.Errorf("invalid backend security type %s for policy %s", spec.Type, backendSecurityPolicy.Name)
 	}
@@ -939,7 +1549,7 @@ func (c *GatewayController) injectQuotaPolicyCostExpressions(
 			continue
 		}
 
-		for _, pmq := range qp.Spec.PerModelQuotas {
+		for qIdx, pmq := range qp.Spec.PerModelQuotas {
 			if pmq.ModelName == nil {
 				continue
 			}
@@ -951,39 +1561,225 @@ func (c *GatewayController) injectQuotaPolicyCostExpressions(
 			if pmq.Quota.CostExpression != nil {
 				expr = *pmq.Quota.CostExpression
 			}
-			if _, err := llmcostcel.NewProgram(expr); err != nil {
-				c.logger.Error(err, "invalid QuotaPolicy cost expression, skipping",
-					"policy", qp.Name, "model", *pmq.ModelName, "expression", expr)
-				continue
+			// The buckets counting tokens read the consumed tokens of their unit from a dedicated
+			// metadata key, and the buckets counting requests don't need any.
+			costs := make(map[string]filterapi.LLMRequestCost)
+			streamCutOff := quotaStreamCutOff(qp, &pmq)
+			for _, unit := range quotaUnits(&pmq.Quota) {
+				metadataKey := translator.QuotaUnitMetadataKey(unit)
+				if _, ok := costs[metadataKey]; ok || metadataKey == "" {
+					continue
+				}
+				switch unit {
+				case aigv1a1.QuotaUnitInputTokens:
+					costs[metadataKey] = filterapi.LLMRequestCost{Type: filterapi.LLMRequestCostTypeInputToken, StreamCutOff: streamCutOff}
+				case aigv1a1.QuotaUnitOutputTokens:
+					costs[metadataKey] = filterapi.LLMRequestCost{Type: filterapi.LLMRequestCostTypeOutputToken, StreamCutOff: streamCutOff}
+				case aigv1a1.QuotaUnitTotalTokens:
+					costs[metadataKey] = filterapi.LLMRequestCost{Type: filterapi.LLMRequestCostTypeTotalToken, StreamCutOff: streamCutOff}
+				default:
+					if _, err := llmcostcel.NewProgram(expr); err != nil {
+						c.logger.Error(err, "invalid QuotaPolicy cost expression, skipping",
+							"policy", qp.Name, "model", *pmq.ModelName, "expression", expr)
+						continue
+					}
+					costs[metadataKey] = filterapi.LLMRequestCost{
+						Type:         filterapi.LLMRequestCostTypeCEL,
+						CEL:          expr,
+						StreamCutOff: streamCutOff,
+					}
+				}
 			}
+			metadataKeys := slices.Sorted(maps.Keys(costs))
 			// One LLMRequestCost per target backend with the Backend and Model filters.
 			// ext_proc only evaluates the entry matching the serving backend and model,
 			// storing the result under the shared metadata key.
 			for _, ref := range qp.Spec.TargetRefs {
 				backendKey := route.Namespace + "/" + string(ref.Name)
-				dedupeKey := QuotaCostMetadataKey + "\x00" + *pmq.ModelName + "\x00" + backendKey
-				if _, exists := injectedQuotaCosts[dedupeKey]; exists {
-					continue
+				for _, metadataKey := range metadataKeys {
+					dedupeKey := metadataKey + "\x00" + *pmq.ModelName + "\x00" + backendKey
+					if _, exists := injectedQuotaCosts[dedupeKey]; exists {
+						continue
+					}
+					cost := costs[metadataKey]
+					cost.MetadataKey = metadataKey
+					cost.Backend = backendKey
+					cost.RouteName = routeName
+					cost.Model = *pmq.ModelName
+					ec.LLMRequestCosts = append(ec.LLMRequestCosts, cost)
+					injectedQuotaCosts[dedupeKey] = struct{}{}
 				}
-				ec.LLMRequestCosts = append(ec.LLMRequestCosts, filterapi.LLMRequestCost{
-					Type:        filterapi.LLMRequestCostTypeCEL,
-					MetadataKey: QuotaCostMetadataKey,
-					CEL:         expr,
-					Backend:     backendKey,
-					RouteName:   routeName,
-					Model:       *pmq.ModelName,
-				})
-				injectedQuotaCosts[dedupeKey] = struct{}{}
+			}
+			if qp.Spec.LocalFallback != nil && qp.Spec.Mode != aigv1a1.QuotaPolicyModeShadow {
+				c.injectQuotaFallbackLimits(route, ec, injectedQuotaCosts, routeName, qp, &pmq)
+			}
+			injectQuotaSchedule(ec, injectedQuotaCosts, qp, qIdx, &pmq)
+		}
+	}
+}
+
+// injectQuotaFallbackLimits adds the local fallback limits approximating the DefaultBucket of the PerModelQuota
+// for each target backend of the QuotaPolicy. The limit is divided by the number of replicas since the external
+// processors don't share their counters.
+func (c *GatewayController) injectQuotaFallbackLimits(
+	route *aigv1b1.AIGatewayRoute,
+	ec *filterapi.Config,
+	injectedQuotaCosts map[string]struct{},
+	routeName string,
+	qp *aigv1a1.QuotaPolicy,
+	pmq *aigv1a1.PerModelQuota,
+) {
+	if c.quotaRateLimitServiceAddr == "" {
+		return
+	}
+	bucket := &pmq.Quota.DefaultBucket
+	window, ok := translator.QuotaWindowSeconds(bucket.Duration)
+	if bucket.Limit == 0 || !ok {
+		return
+	}
+	replicas := uint64(max(qp.Spec.LocalFallback.Replicas, 1))
+	limit := (uint64(bucket.Limit) + replicas - 1) / replicas
+	metadataKey := translator.QuotaUnitMetadataKey(bucket.Unit)
+	for _, ref := range qp.Spec.TargetRefs {
+		backendKey := route.Namespace + "/" + string(ref.Name)
+		dedupeKey := "fallback\x00" + metadataKey + "\x00" + *pmq.ModelName + "\x00" + backendKey
+		if _, exists := injectedQuotaCosts[dedupeKey]; exists {
+			continue
+		}
+		if ec.QuotaFallback == nil {
+			ec.QuotaFallback = &filterapi.QuotaFallback{RateLimitServiceAddress: c.quotaRateLimitServiceAddr}
+		}
+		ec.QuotaFallback.Limits = append(ec.QuotaFallback.Limits, filterapi.QuotaFallbackLimit{
+			RouteName:     routeName,
+			Backend:       backendKey,
+			Model:         *pmq.ModelName,
+			MetadataKey:   metadataKey,
+			Limit:         limit,
+			WindowSeconds: window,
+		})
+		injectedQuotaCosts[dedupeKey] = struct{}{}
+	}
+}
+
+// injectQuotaSchedule adds the schedule of the PerModelQuota at index qIdx of the QuotaPolicy, if any, so that
+// ext_proc stores its active window in the dynamic metadata read by the rate limit actions of its default bucket.
+func injectQuotaSchedule(
+	ec *filterapi.Config,
+	injectedQuotaCosts map[string]struct{},
+	qp *aigv1a1.QuotaPolicy,
+	qIdx int,
+	pmq *aigv1a1.PerModelQuota,
+) {
+	if len(pmq.Quota.Schedules) == 0 {
+		return
+	}
+	metadataKey := translator.QuotaScheduleMetadataKey(qp.Namespace, qp.Name, qIdx)
+	dedupeKey := "schedule\x00" + metadataKey
+	if _, exists := injectedQuotaCosts[dedupeKey]; exists {
+		return
+	}
+	schedule := filterapi.QuotaSchedule{MetadataKey: metadataKey, DefaultWindow: translator.DefaultQuotaScheduleWindow}
+	for i := range pmq.Quota.Schedules {
+		s := &pmq.Quota.Schedules[i]
+		window := filterapi.QuotaScheduleWindow{Name: s.Name, Start: s.Start, End: s.End, TimeZone: s.TimeZone}
+		for _, day := range s.Days {
+			window.Days = append(window.Days, string(day))
+		}
+		schedule.Windows = append(schedule.Windows, window)
+	}
+	ec.QuotaSchedules = append(ec.QuotaSchedules, schedule)
+	injectedQuotaCosts[dedupeKey] = struct{}{}
+}
+
+// quotaUnits returns the units of the buckets with a limit in the quota definition.
+func quotaUnits(quota *aigv1a1.QuotaDefinition) []aigv1a1.QuotaUnit {
+	var units []aigv1a1.QuotaUnit
+	if quota.DefaultBucket.Limit > 0 {
+		units = append(units, quota.DefaultBucket.Unit)
+	}
+	for i := range quota.BucketRules {
+		units = append(units, quota.BucketRules[i].Quota.Unit)
+	}
+	return units
+}
+
+// ruleContextWindows returns the context windows of the models of the rule, i.e. the models of its exact matches of
+// the model name header and the models sent to its backends by the ModelNameOverride of the backend references and
+// of the variants of the experiment of the route. The extproc checks the window of the model sent to the backend.
+func ruleContextWindows(rule *aigv1b1.AIGatewayRouteRule, experiment *aigv1b1.AIGatewayRouteExperiment,
+	routeName, modelNameHeaderKey string,
+) []filterapi.ContextWindow {
+	if len(rule.ModelsContextWindows) == 0 {
+		return nil
+	}
+	models := make(map[string]struct{})
+	for _, m := range rule.Matches {
+		for _, h := range m.Headers {
+			if (h.Type == nil || *h.Type == gwapiv1.HeaderMatchExact) && string(h.Name) == modelNameHeaderKey {
+				models[h.Value] = struct{}{}
+			}
+		}
+	}
+	for i := range rule.BackendRefs {
+		if o := rule.BackendRefs[i].ModelNameOverride; o != "" {
+			models[o] = struct{}{}
+		}
+	}
+	if experiment != nil {
+		for _, v := range experiment.Variants {
+			if v.ModelNameOverride != "" {
+				models[v.ModelNameOverride] = struct{}{}
 			}
 		}
 	}
+	var windows []filterapi.ContextWindow
+	for _, w := range rule.ModelsContextWindows {
+		if _, ok := models[w.Model]; ok {
+			windows = append(windows, filterapi.ContextWindow{RouteName: routeName, Model: w.Model, Tokens: int(w.Tokens)})
+		}
+	}
+	return windows
+}
+
+// quotaStreamCutOff returns true if the streams are cut off at the remaining quota of the PerModelQuota. The
+// QuotaPolicyController doesn't accept the CutOff mode with buckets counting different units, whose remaining quota
+// can't be compared with the cost of the stream, so these are never cut off.
+func quotaStreamCutOff(qp *aigv1a1.QuotaPolicy, pmq *aigv1a1.PerModelQuota) bool {
+	return pmq.Quota.StreamingMode == aigv1a1.QuotaStreamingModeCutOff && qp.Spec.Mode != aigv1a1.QuotaPolicyModeShadow &&
+		validateQuotaStreamingMode(qp, pmq) == nil
+}
+
+// validateQuotaStreamingMode returns an error if the PerModelQuota of the QuotaPolicy has the CutOff streaming mode
+// while the buckets of its model count different units or the requests. The rate limit service reports the remaining
+// quota of the most restrictive bucket whatever its unit, so the cost of a stream can only be compared with it when
+// all the buckets of the model count the same cost.
+func validateQuotaStreamingMode(qp *aigv1a1.QuotaPolicy, pmq *aigv1a1.PerModelQuota) error {
+	if pmq.Quota.StreamingMode != aigv1a1.QuotaStreamingModeCutOff {
+		return nil
+	}
+	var units []aigv1a1.QuotaUnit
+	for i := range qp.Spec.PerModelQuotas {
+		if other := &qp.Spec.PerModelQuotas[i]; ptr.Deref(other.ModelName, "") == ptr.Deref(pmq.ModelName, "") {
+			units = append(units, quotaUnits(&other.Quota)...)
+		}
+	}
+	for _, unit := range units {
+		if unit == aigv1a1.QuotaUnitRequests {
+			return fmt.Errorf("the CutOff streaming mode doesn't support the %s unit", unit)
+		}
+		if unit != units[0] {
+			return fmt.Errorf("the CutOff streaming mode requires all the buckets of the model to count the same unit, got %s and %s",
+				units[0], unit)
+		}
+	}
+	return nil
 }
 
 // QuotaCostMetadataKey is the dynamic metadata key used to store a
 // QuotaPolicy's computed cost. A single key suffices because only one model
 // is active per request, and ext_proc filters cost entries by Model before
 // writing to this key.
-const QuotaCostMetadataKey = "quota_cost"
+const QuotaCostMetadataKey = translator.QuotaCostMetadataKey
 
 // backendWithMaybeBSP retrieves the AIServiceBackend and its associated BackendSecurityPolicy if it exists.
 func (c *GatewayController) backendWithMaybeBSP(ctx context.Context, namespace, name string) (backend *aigv1b1.AIServiceBackend, bsp *aigv1b1.BackendSecurityPolicy, err error) {
@@ -1246,6 +2042,105 @@ func (c *GatewayController) annotateGatewayPods(ctx context.Context,
 	return ctrl.Result{}, nil
 }
 
+// reconcileExtProcPodDisruptionBudget creates or updates the PodDisruptionBudget of the Envoy proxy pods running
+// the external processor of the Gateway with the given controller owner, or deletes it when pdb is nil.
+func (c *GatewayController) reconcileExtProcPodDisruptionBudget(
+	ctx context.Context,
+	gatewayName,
+	gatewayNamespace,
+	podNamespace string,
+	owner *metav1.OwnerReference,
+	pdb *aigv1b1.GatewayConfigExtProcPodDisruptionBudget,
+) error {
+	name := extProcPodDisruptionBudgetName(gatewayName, gatewayNamespace)
+	pdbs := c.kube.PolicyV1().PodDisruptionBudgets(podNamespace)
+	current, err := pdbs.Get(ctx, name, metav1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to get PodDisruptionBudget %s: %w", name, err)
+	}
+	exists := err == nil
+
+	if pdb == nil {
+		if !exists {
+			return nil
+		}
+		c.logger.Info("deleting PodDisruptionBudget", "namespace", podNamespace, "name", name)
+		if err = pdbs.Delete(ctx, name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete PodDisruptionBudget %s: %w", name, err)
+		}
+		return nil
+	}
+
+	spec := policyv1.PodDisruptionBudgetSpec{
+		MinAvailable:   pdb.MinAvailable,
+		MaxUnavailable: pdb.MaxUnavailable,
+		Selector:       &metav1.LabelSelector{MatchLabels: envoyPodLabels(gatewayName, gatewayNamespace)},
+	}
+	var ownerReferences []metav1.OwnerReference
+	if owner != nil {
+		ownerReferences = []metav1.OwnerReference{*owner}
+	}
+	if !exists {
+		c.logger.Info("creating PodDisruptionBudget", "namespace", podNamespace, "name", name)
+		_, err = pdbs.Create(ctx, &policyv1.PodDisruptionBudget{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: podNamespace, OwnerReferences: ownerReferences},
+			Spec:       spec,
+		}, metav1.CreateOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to create PodDisruptionBudget %s: %w", name, err)
+		}
+		return nil
+	}
+	if equality.Semantic.DeepEqual(current.Spec, spec) && equality.Semantic.DeepEqual(current.OwnerReferences, ownerReferences) {
+		return nil
+	}
+	current.Spec = spec
+	current.OwnerReferences = ownerReferences
+	if _, err = pdbs.Update(ctx, current, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update PodDisruptionBudget %s: %w", name, err)
+	}
+	return nil
+}
+
+// extProcPodDisruptionBudgetOwner returns the controller reference to the Envoy Deployment or DaemonSet of a Gateway
+// to be set on its extproc PodDisruptionBudget, or nil if there is none. The Gateway itself cannot own it since the
+// PodDisruptionBudget lives in the namespace of the Envoy pods, which is the Envoy Gateway system namespace unless
+// the Gateway namespace deployment mode is used, whereas the Envoy resources are in the same namespace and are
+// deleted by Envoy Gateway along with the Gateway.
+func extProcPodDisruptionBudgetOwner(deployments []appsv1.Deployment, daemonSets []appsv1.DaemonSet) *metav1.OwnerReference {
+	if len(deployments) > 0 {
+		return metav1.NewControllerRef(&deployments[0], appsv1.SchemeGroupVersion.WithKind("Deployment"))
+	}
+	if len(daemonSets) > 0 {
+		return metav1.NewControllerRef(&daemonSets[0], appsv1.SchemeGroupVersion.WithKind("DaemonSet"))
+	}
+	return nil
+}
+
+// deleteExtProcPodDisruptionBudgets deletes the extproc PodDisruptionBudget of a deleted Gateway from the namespaces
+// the Envoy pods may run in, in case it was created without an owner to be garbage collected with.
+func (c *GatewayController) deleteExtProcPodDisruptionBudgets(ctx context.Context, gatewayName, gatewayNamespace string) error {
+	name := extProcPodDisruptionBudgetName(gatewayName, gatewayNamespace)
+	for _, ns := range []string{gatewayNamespace, c.envoyGatewayNamespace} {
+		err := c.kube.PolicyV1().PodDisruptionBudgets(ns).Delete(ctx, name, metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete PodDisruptionBudget %s/%s: %w", ns, name, err)
+		}
+		if err == nil {
+			c.logger.Info("deleted PodDisruptionBudget of deleted Gateway", "namespace", ns, "name", name)
+		}
+	}
+	return nil
+}
+
+// envoyPodLabels returns the labels set by Envoy Gateway on the Envoy proxy pods of the given Gateway.
+func envoyPodLabels(gatewayName, gatewayNamespace string) map[string]string {
+	return map[string]string{
+		egOwningGatewayNameLabel:      gatewayName,
+		egOwningGatewayNamespaceLabel: gatewayNamespace,
+	}
+}
+
 // getObjectsForGateway retrieves the pods, deployments, and daemonsets for a given Gateway.
 // They are all created and managed by the Envoy Gateway controller. Depending on the deployment strategy of Envoy Gateway,
 // the namespace is either the same as the Gateway's namespace or the Envoy Gateway system namespace.
@@ -26,6 +26,7 @@ import (
 	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
 
 	aigv1b1 "github.com/envoyproxy/ai-gateway/api/v1beta1"
+	"github.com/envoyproxy/ai-gateway/internal/configstream"
 	"github.com/envoyproxy/ai-gateway/internal/filterapi"
 	"github.com/envoyproxy/ai-gateway/internal/internalapi"
 )
@@ -67,6 +68,15 @@ type gatewayMutator struct {
 	// Whether to run the extProc container as a sidecar (true) as a normal container (false).
 	// This is essentially a workaround for old k8s versions, and we can remove this in the future.
 	extProcAsSideCar bool
+
+	// configStreamAddr is the address of the controller's config stream server. Empty disables the config stream.
+	configStreamAddr string
+	// configStreamCA is the PEM-encoded CA certificate to verify the config stream server.
+	configStreamCA string
+
+	// mockProvider makes the extProc serve the mock provider on the mockProviderSocketName UDS in the directory
+	// of udsPath, which is shared with Envoy.
+	mockProvider bool
 }
 
 func newGatewayMutator(c client.Client, noCacheReader client.Reader, kube kubernetes.Interface, logger logr.Logger,
@@ -195,14 +205,45 @@ func (g *gatewayMutator) buildExtProcArgs(filterConfigFullPath, configBundlePath
 		args = append(args, "-enableRedaction")
 	}
 
+	if g.mockProvider {
+		args = append(args, "-mockProviderAddr", "unix://"+filepath.Join(filepath.Dir(g.udsPath), mockProviderSocketName))
+	}
+
 	return args
 }
 
 const (
 	mutationNamePrefix   = "ai-gateway-"
 	extProcContainerName = mutationNamePrefix + "extproc"
+	// extProcAdminPort is the port of the admin server of the external processor, serving the health check and the
+	// usage API among others.
+	extProcAdminPort = 1064
+
+	configStreamTokenVolumeName = mutationNamePrefix + "config-stream-token"
+	configStreamTokenMountPath  = "/var/run/secrets/ai-gateway-config-stream"
+	configStreamTokenFileName   = "token"
+
+	// extProcStartupProbeFailureThreshold is the number of the startup probes, one per second, failing before the
+	// extProc is restarted for not loading its first config.
+	extProcStartupProbeFailureThreshold = 120
+
+	// mockProviderSocketName is the name of the UDS of the mock provider, e.g.
+	// /etc/ai-gateway-extproc-uds/mock-provider.sock, which the Backends of the mock provider point to.
+	mockProviderSocketName = "mock-provider.sock"
 )
 
+// extProcHealthProbeHandler returns the handler probing the health check of the admin server of the extProc, which
+// fails until the extProc loads its first config.
+func extProcHealthProbeHandler() corev1.ProbeHandler {
+	return corev1.ProbeHandler{
+		HTTPGet: &corev1.HTTPGetAction{
+			Port:   intstr.FromInt32(extProcAdminPort),
+			Path:   "/health",
+			Scheme: corev1.URISchemeHTTP,
+		},
+	}
+}
+
 // ParseExtraEnvVars parses semicolon-separated key=value pairs into a list of
 // environment variables. The input delimiter is a semicolon (';') to allow
 // values to contain commas without escaping.
@@ -404,7 +445,10 @@ func (g *gatewayMutator) mutatePod(ctx context.Context, pod *corev1.Pod, gateway
 	// Now we construct the AI Gateway managed containers and volumes.
 	filterConfigVolumeName := legacyFilterConfigVolumeName(gatewayName, gatewayNamespace)
 	filterConfigBundleVolumeName := filterConfigBundleVolumeName(gatewayName, gatewayNamespace)
-	const extProcUDSVolumeName = mutationNamePrefix + "extproc-uds"
+	const (
+		extProcUDSVolumeName = mutationNamePrefix + "extproc-uds"
+		extProcTmpVolumeName = mutationNamePrefix + "extproc-tmp"
+	)
 	volumes := []corev1.Volume{
 		{
 			Name: extProcUDSVolumeName,
@@ -412,6 +456,13 @@ func (g *gatewayMutator) mutatePod(ctx context.Context, pod *corev1.Pod, gateway
 				EmptyDir: &corev1.EmptyDirVolumeSource{},
 			},
 		},
+		{
+			// The temporary files, e.g. the spilled responses, are written here since the root filesystem is read-only.
+			Name: extProcTmpVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{},
+			},
+		},
 	}
 	if hasLegacyConfig {
 		volumes = append(volumes, corev1.Volume{
@@ -478,31 +529,39 @@ func (g *gatewayMutator) mutatePod(ctx context.Context, pod *corev1.Pod, gateway
 
 	// Merge env vars with GatewayConfig overriding global.
 	envVars := g.mergeEnvVars(gatewayConfig)
+	if g.configStreamAddr != "" {
+		// The service account token is projected with the dedicated audience, so that the token presented to the
+		// controller cannot be used against the API server. This doesn't rely on the token automount of the pod.
+		podspec.Volumes = append(podspec.Volumes, corev1.Volume{
+			Name: configStreamTokenVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				Projected: &corev1.ProjectedVolumeSource{Sources: []corev1.VolumeProjection{{
+					ServiceAccountToken: &corev1.ServiceAccountTokenProjection{
+						Audience:          configstream.TokenAudience,
+						ExpirationSeconds: ptr.To(int64(3600)),
+						Path:              configStreamTokenFileName,
+					},
+				}}},
+			},
+		})
+		if g.configStreamCA != "" {
+			envVars = append(envVars, corev1.EnvVar{Name: configstream.CAEnvVar, Value: g.configStreamCA})
+		}
+	}
 	image := g.resolveExtProcImage(extProcSpec)
 
 	const (
-		extProcAdminPort            = 1064
 		filterConfigMountPath       = "/etc/filter-config"
 		filterConfigFullPath        = filterConfigMountPath + "/" + FilterConfigKeyInSecret
 		filterConfigBundleMountPath = "/etc/filter-config-bundle"
+		extProcTmpMountPath         = "/tmp"
 	)
 	udsMountPath := filepath.Dir(g.udsPath)
-	securityContext := &corev1.SecurityContext{
-		AllowPrivilegeEscalation: ptr.To(false),
-		Capabilities: &corev1.Capabilities{
-			Drop: []corev1.Capability{"ALL"},
-		},
-		Privileged:   ptr.To(false),
-		RunAsGroup:   ptr.To(int64(65532)),
-		RunAsNonRoot: ptr.To(true),
-		RunAsUser:    ptr.To(int64(65532)),
-		SeccompProfile: &corev1.SeccompProfile{
-			Type: corev1.SeccompProfileTypeRuntimeDefault,
-		},
-	}
-	if kubernetesExtProc != nil && kubernetesExtProc.SecurityContext != nil {
+	var securityContext *corev1.SecurityContext
+	if kubernetesExtProc != nil {
 		securityContext = kubernetesExtProc.SecurityContext
 	}
+	securityContext = extProcSecurityContext(securityContext)
 
 	container := corev1.Container{
 		Name:            extProcContainerName,
@@ -520,7 +579,15 @@ func (g *gatewayMutator) mutatePod(ctx context.Context, pod *corev1.Pod, gateway
 			if !hasBundleConfig { // for backward compatibility when upgrade from the previous version and the secret is created by the previous version
 				configPath = filterConfigFullPath
 			}
-			return g.buildExtProcArgs(configPath, bundlePath, extProcAdminPort, len(mcpRoutes.Items) > 0)
+			args := g.buildExtProcArgs(configPath, bundlePath, extProcAdminPort, len(mcpRoutes.Items) > 0)
+			if g.configStreamAddr != "" {
+				args = append(args,
+					"-configStreamAddr", g.configStreamAddr,
+					"-configStreamResourceName", configstream.ResourceName(gatewayName, gatewayNamespace),
+					"-configStreamTokenPath", configStreamTokenMountPath+"/"+configStreamTokenFileName,
+				)
+			}
+			return args
 		}(),
 		Env: envVars,
 		VolumeMounts: []corev1.VolumeMount{
@@ -529,16 +596,24 @@ func (g *gatewayMutator) mutatePod(ctx context.Context, pod *corev1.Pod, gateway
 				MountPath: udsMountPath,
 				ReadOnly:  false,
 			},
+			{
+				Name:      extProcTmpVolumeName,
+				MountPath: extProcTmpMountPath,
+			},
 		},
 		SecurityContext: securityContext,
+		// The health check fails until the extProc loads its first config. As a sidecar, Envoy isn't started until
+		// the startup probe succeeds, and the readiness probe keeps the pod out of the endpoints of the gateway
+		// otherwise, so that the requests that would all fail during the startup aren't routed to the pod.
+		StartupProbe: &corev1.Probe{
+			ProbeHandler:     extProcHealthProbeHandler(),
+			TimeoutSeconds:   1,
+			PeriodSeconds:    1,
+			SuccessThreshold: 1,
+			FailureThreshold: extProcStartupProbeFailureThreshold,
+		},
 		ReadinessProbe: &corev1.Probe{
-			ProbeHandler: corev1.ProbeHandler{
-				HTTPGet: &corev1.HTTPGetAction{
-					Port:   intstr.FromInt32(extProcAdminPort),
-					Path:   "/health",
-					Scheme: corev1.URISchemeHTTP,
-				},
-			},
+			ProbeHandler:        extProcHealthProbeHandler(),
 			InitialDelaySeconds: 2,
 			TimeoutSeconds:      5,
 			PeriodSeconds:       10,
@@ -568,6 +643,14 @@ func (g *gatewayMutator) mutatePod(ctx context.Context, pod *corev1.Pod, gateway
 		})
 	}
 
+	if g.configStreamAddr != "" {
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+			Name:      configStreamTokenVolumeName,
+			MountPath: configStreamTokenMountPath,
+			ReadOnly:  true,
+		})
+	}
+
 	if g.extProcAsSideCar {
 		// When running as a sidecar, we want to ensure the extProc container is shutdown last after Envoy is shutdown.
 		container.RestartPolicy = ptr.To(corev1.ContainerRestartPolicyAlways)
@@ -576,6 +659,12 @@ func (g *gatewayMutator) mutatePod(ctx context.Context, pod *corev1.Pod, gateway
 		podspec.Containers = append(podspec.Containers, container)
 	}
 
+	// Spread the pods so that a single node or zone failure doesn't take down all the extproc capacity.
+	// The constraints configured on the pod by other means, e.g. EnvoyProxy, take precedence.
+	if extProcSpec != nil && len(extProcSpec.TopologySpreadConstraints) > 0 && len(podspec.TopologySpreadConstraints) == 0 {
+		podspec.TopologySpreadConstraints = extProcTopologySpreadConstraints(extProcSpec.TopologySpreadConstraints, gatewayName, gatewayNamespace)
+	}
+
 	// Lastly, we need to mount the Envoy container with the extproc socket.
 	for i := range podspec.Containers {
 		c := &podspec.Containers[i]
@@ -590,6 +679,19 @@ func (g *gatewayMutator) mutatePod(ctx context.Context, pod *corev1.Pod, gateway
 	return nil
 }
 
+// extProcTopologySpreadConstraints returns a copy of the given constraints where the unset label selectors
+// default to the labels selecting the Envoy proxy pods of the given Gateway.
+func extProcTopologySpreadConstraints(constraints []corev1.TopologySpreadConstraint, gatewayName, gatewayNamespace string) []corev1.TopologySpreadConstraint {
+	ret := make([]corev1.TopologySpreadConstraint, len(constraints))
+	for i := range constraints {
+		constraints[i].DeepCopyInto(&ret[i])
+		if ret[i].LabelSelector == nil {
+			ret[i].LabelSelector = &metav1.LabelSelector{MatchLabels: envoyPodLabels(gatewayName, gatewayNamespace)}
+		}
+	}
+	return ret
+}
+
 // fetchGatewayConfig returns the referenced GatewayConfig (if present) for the given Gateway.
 // Returns (nil, nil) if: Gateway not found, no annotation, empty annotation, or GatewayConfig not found.
 // Returns (nil, error) for transient failures (API errors) to trigger mutation retry.
@@ -628,6 +730,58 @@ func (g *gatewayMutator) fetchGatewayConfig(ctx context.Context, gatewayName, ga
 	return &gatewayConfig, nil
 }
 
+// extProcSecurityContext returns the security context of the extproc container. The fields set in the given security
+// context of the GatewayConfig override the hardened defaults, so that a single field can be adjusted, e.g. a
+// Localhost seccomp profile, without dropping the others.
+func extProcSecurityContext(override *corev1.SecurityContext) *corev1.SecurityContext {
+	securityContext := &corev1.SecurityContext{
+		AllowPrivilegeEscalation: ptr.To(false),
+		Capabilities: &corev1.Capabilities{
+			Drop: []corev1.Capability{"ALL"},
+		},
+		Privileged:             ptr.To(false),
+		ReadOnlyRootFilesystem: ptr.To(true),
+		RunAsGroup:             ptr.To(int64(65532)),
+		RunAsNonRoot:           ptr.To(true),
+		RunAsUser:              ptr.To(int64(65532)),
+		SeccompProfile: &corev1.SeccompProfile{
+			Type: corev1.SeccompProfileTypeRuntimeDefault,
+		},
+	}
+	if override == nil {
+		return securityContext
+	}
+	if override.AllowPrivilegeEscalation != nil {
+		securityContext.AllowPrivilegeEscalation = override.AllowPrivilegeEscalation
+	}
+	if override.Capabilities != nil {
+		securityContext.Capabilities = override.Capabilities
+	}
+	if override.Privileged != nil {
+		securityContext.Privileged = override.Privileged
+	}
+	if override.ReadOnlyRootFilesystem != nil {
+		securityContext.ReadOnlyRootFilesystem = override.ReadOnlyRootFilesystem
+	}
+	if override.RunAsGroup != nil {
+		securityContext.RunAsGroup = override.RunAsGroup
+	}
+	if override.RunAsNonRoot != nil {
+		securityContext.RunAsNonRoot = override.RunAsNonRoot
+	}
+	if override.RunAsUser != nil {
+		securityContext.RunAsUser = override.RunAsUser
+	}
+	if override.SeccompProfile != nil {
+		securityContext.SeccompProfile = override.SeccompProfile
+	}
+	securityContext.AppArmorProfile = override.AppArmorProfile
+	securityContext.ProcMount = override.ProcMount
+	securityContext.SELinuxOptions = override.SELinuxOptions
+	securityContext.WindowsOptions = override.WindowsOptions
+	return securityContext
+}
+
 // mergeEnvVars merges env vars; GatewayConfig overrides global while preserving order.
 func (g *gatewayMutator) mergeEnvVars(gatewayConfig *aigv1b1.GatewayConfig) []corev1.EnvVar {
 	result := make([]corev1.EnvVar, 0, len(g.extProcExtraEnvVars))
@@ -26,6 +26,7 @@ import (
 	gwapiv1a2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
 
 	aigv1b1 "github.com/envoyproxy/ai-gateway/api/v1beta1"
+	"github.com/envoyproxy/ai-gateway/internal/configstream"
 	"github.com/envoyproxy/ai-gateway/internal/filterapi"
 	"github.com/envoyproxy/ai-gateway/internal/internalapi"
 )
@@ -92,6 +93,8 @@ func TestGatewayMutator_mutatePod(t *testing.T) {
 			name: "basic extproc container",
 			extprocTest: func(t *testing.T, container corev1.Container) {
 				require.Empty(t, container.Env)
+				require.True(t, *container.SecurityContext.ReadOnlyRootFilesystem)
+				require.Contains(t, container.VolumeMounts, corev1.VolumeMount{Name: "ai-gateway-extproc-tmp", MountPath: "/tmp"})
 			},
 			podTest: func(t *testing.T, pod corev1.Pod) {
 				require.Empty(t, pod.Spec.ImagePullSecrets)
@@ -469,6 +472,12 @@ func TestGatewayMutator_mutatePod(t *testing.T) {
 					require.Equal(t, "ai-gateway-extproc", extProcContainer.Name)
 					require.Contains(t, extProcContainer.Args, "-configBundlePath")
 					require.NotContains(t, extProcContainer.Args, "-configPath")
+					// Both the startup and the readiness of the pod wait for the extProc to load the config.
+					require.NotNil(t, extProcContainer.StartupProbe)
+					require.Equal(t, "/health", extProcContainer.StartupProbe.HTTPGet.Path)
+					require.Equal(t, int32(extProcStartupProbeFailureThreshold), extProcContainer.StartupProbe.FailureThreshold)
+					require.NotNil(t, extProcContainer.ReadinessProbe)
+					require.Equal(t, "/health", extProcContainer.ReadinessProbe.HTTPGet.Path)
 					tt.extprocTest(t, extProcContainer)
 					if tt.podTest != nil {
 						tt.podTest(t, *pod)
@@ -542,6 +551,160 @@ func TestGatewayMutator_mutatePod_BundleOnly(t *testing.T) {
 	}
 }
 
+func TestGatewayMutator_mutatePod_ConfigStream(t *testing.T) {
+	fakeClient := requireNewFakeClientWithIndexes(t)
+	fakeKube := fake2.NewClientset()
+	g := newTestGatewayMutator(fakeClient, fakeKube, nil, nil, nil, nil, "", "", "", false)
+	g.configStreamAddr = "controller.envoy-ai-gateway-system.svc:1065"
+	g.configStreamCA = "ca-pem"
+
+	const gwName, gwNamespace = "test-gateway", "test-namespace"
+	err := fakeClient.Create(t.Context(), &aigv1b1.AIGatewayRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: gwName, Namespace: gwNamespace},
+		Spec: aigv1b1.AIGatewayRouteSpec{
+			ParentRefs: []gwapiv1a2.ParentReference{
+				{
+					Name:  gwName,
+					Kind:  ptr.To(gwapiv1a2.Kind("Gateway")),
+					Group: ptr.To(gwapiv1a2.Group("gateway.networking.k8s.io")),
+				},
+			},
+			Rules: []aigv1b1.AIGatewayRouteRule{{BackendRefs: []aigv1b1.AIGatewayRouteRuleBackendRef{{Name: "apple"}}}},
+		},
+	})
+	require.NoError(t, err)
+	_, err = g.kube.CoreV1().Secrets(gwNamespace).Create(t.Context(),
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: legacyFilterConfigSecretName(gwName, gwNamespace), Namespace: gwNamespace},
+			StringData: map[string]string{FilterConfigKeyInSecret: "version: dev\n"},
+		}, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-pod", Namespace: gwNamespace},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "envoy"}}},
+	}
+	require.NoError(t, g.mutatePod(t.Context(), pod, gwName, gwNamespace))
+	require.Len(t, pod.Spec.Containers, 2)
+
+	extProcContainer := pod.Spec.Containers[1]
+	require.Subset(t, extProcContainer.Args, []string{
+		"-configStreamAddr", "controller.envoy-ai-gateway-system.svc:1065",
+		"-configStreamResourceName", "test-namespace/test-gateway",
+		"-configStreamTokenPath", "/var/run/secrets/ai-gateway-config-stream/token",
+	})
+	require.Contains(t, extProcContainer.Env, corev1.EnvVar{Name: configstream.CAEnvVar, Value: "ca-pem"})
+	require.Contains(t, extProcContainer.VolumeMounts, corev1.VolumeMount{
+		Name: configStreamTokenVolumeName, MountPath: configStreamTokenMountPath, ReadOnly: true,
+	})
+
+	var tokenVolume *corev1.Volume
+	for i := range pod.Spec.Volumes {
+		if pod.Spec.Volumes[i].Name == configStreamTokenVolumeName {
+			tokenVolume = &pod.Spec.Volumes[i]
+		}
+	}
+	require.NotNil(t, tokenVolume)
+	require.NotNil(t, tokenVolume.Projected)
+	require.Len(t, tokenVolume.Projected.Sources, 1)
+	require.Equal(t, configstream.TokenAudience, tokenVolume.Projected.Sources[0].ServiceAccountToken.Audience)
+}
+
+func TestGatewayMutator_buildExtProcArgs_MockProvider(t *testing.T) {
+	fakeClient := requireNewFakeClientWithIndexes(t)
+	g := newTestGatewayMutator(fakeClient, fake2.NewClientset(), nil, nil, nil, nil, "", "", "", false)
+	require.NotContains(t, g.buildExtProcArgs("/etc/filter-config/config.yaml", "", extProcAdminPort, false), "-mockProviderAddr")
+
+	// The UDS of the mock provider is next to the one of the external processor, so that Envoy can reach it.
+	g.mockProvider = true
+	require.Subset(t, g.buildExtProcArgs("/etc/filter-config/config.yaml", "", extProcAdminPort, false),
+		[]string{"-mockProviderAddr", "unix:///tmp/mock-provider.sock"})
+}
+
+func TestGatewayMutator_mutatePod_TopologySpreadConstraints(t *testing.T) {
+	const gwName, gwNamespace = "test-gateway", "test-namespace"
+	constraints := []corev1.TopologySpreadConstraint{
+		{MaxSkew: 1, TopologyKey: "topology.kubernetes.io/zone", WhenUnsatisfiable: corev1.ScheduleAnyway},
+		{
+			MaxSkew: 1, TopologyKey: "kubernetes.io/hostname", WhenUnsatisfiable: corev1.DoNotSchedule,
+			LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "envoy"}},
+		},
+	}
+	for _, tc := range []struct {
+		name     string
+		existing []corev1.TopologySpreadConstraint
+		expected []corev1.TopologySpreadConstraint
+	}{
+		{
+			name: "set from GatewayConfig",
+			expected: []corev1.TopologySpreadConstraint{
+				{
+					MaxSkew: 1, TopologyKey: "topology.kubernetes.io/zone", WhenUnsatisfiable: corev1.ScheduleAnyway,
+					LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{
+						egOwningGatewayNameLabel:      gwName,
+						egOwningGatewayNamespaceLabel: gwNamespace,
+					}},
+				},
+				constraints[1],
+			},
+		},
+		{
+			name:     "pod constraints take precedence",
+			existing: []corev1.TopologySpreadConstraint{{MaxSkew: 2, TopologyKey: "kubernetes.io/hostname"}},
+			expected: []corev1.TopologySpreadConstraint{{MaxSkew: 2, TopologyKey: "kubernetes.io/hostname"}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fakeClient := requireNewFakeClientWithIndexes(t)
+			fakeKube := fake2.NewClientset()
+			g := newTestGatewayMutator(fakeClient, fakeKube, nil, nil, nil, nil, "", "", "", false)
+
+			require.NoError(t, fakeClient.Create(t.Context(), &aigv1b1.AIGatewayRoute{
+				ObjectMeta: metav1.ObjectMeta{Name: gwName, Namespace: gwNamespace},
+				Spec: aigv1b1.AIGatewayRouteSpec{
+					ParentRefs: []gwapiv1a2.ParentReference{
+						{
+							Name:  gwName,
+							Kind:  ptr.To(gwapiv1a2.Kind("Gateway")),
+							Group: ptr.To(gwapiv1a2.Group("gateway.networking.k8s.io")),
+						},
+					},
+					Rules: []aigv1b1.AIGatewayRouteRule{{BackendRefs: []aigv1b1.AIGatewayRouteRuleBackendRef{{Name: "apple"}}}},
+				},
+			}))
+			require.NoError(t, fakeClient.Create(t.Context(), &aigv1b1.GatewayConfig{
+				ObjectMeta: metav1.ObjectMeta{Name: "config", Namespace: gwNamespace},
+				Spec: aigv1b1.GatewayConfigSpec{
+					ExtProc: &aigv1b1.GatewayConfigExtProc{TopologySpreadConstraints: constraints},
+				},
+			}))
+			require.NoError(t, fakeClient.Create(t.Context(), &gwapiv1.Gateway{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: gwName, Namespace: gwNamespace,
+					Annotations: map[string]string{GatewayConfigAnnotationKey: "config"},
+				},
+				Spec: gwapiv1.GatewaySpec{GatewayClassName: "test-class"},
+			}))
+			_, err := g.kube.CoreV1().Secrets(gwNamespace).Create(t.Context(),
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: legacyFilterConfigSecretName(gwName, gwNamespace), Namespace: gwNamespace},
+					StringData: map[string]string{FilterConfigKeyInSecret: "version: dev\n"},
+				}, metav1.CreateOptions{})
+			require.NoError(t, err)
+
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-pod", Namespace: gwNamespace},
+				Spec: corev1.PodSpec{
+					Containers:                []corev1.Container{{Name: "envoy"}},
+					TopologySpreadConstraints: tc.existing,
+				},
+			}
+			require.NoError(t, g.mutatePod(t.Context(), pod, gwName, gwNamespace))
+			require.Equal(t, tc.expected, pod.Spec.TopologySpreadConstraints)
+		})
+	}
+}
+
 func TestGatewayMutator_mutatePod_LegacyOnly(t *testing.T) {
 	fakeClient := requireNewFakeClientWithIndexes(t)
 	fakeKube := fake2.NewClientset()
@@ -863,6 +1026,33 @@ func TestGatewayMutator_mergeEnvVars(t *testing.T) {
 	}
 }
 
+func Test_extProcSecurityContext(t *testing.T) {
+	defaults := &corev1.SecurityContext{
+		AllowPrivilegeEscalation: ptr.To(false),
+		Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+		Privileged:               ptr.To(false),
+		ReadOnlyRootFilesystem:   ptr.To(true),
+		RunAsGroup:               ptr.To(int64(65532)),
+		RunAsNonRoot:             ptr.To(true),
+		RunAsUser:                ptr.To(int64(65532)),
+		SeccompProfile:           &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+	}
+	require.Equal(t, defaults, extProcSecurityContext(nil))
+	require.Equal(t, defaults, extProcSecurityContext(&corev1.SecurityContext{}))
+
+	localhostProfile := &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeLocalhost, LocalhostProfile: ptr.To("profiles/extproc.json")}
+	actual := extProcSecurityContext(&corev1.SecurityContext{
+		ReadOnlyRootFilesystem: ptr.To(false),
+		RunAsUser:              ptr.To(int64(1000)),
+		SeccompProfile:         localhostProfile,
+	})
+	expected := defaults.DeepCopy()
+	expected.ReadOnlyRootFilesystem = ptr.To(false)
+	expected.RunAsUser = ptr.To(int64(1000))
+	expected.SeccompProfile = localhostProfile
+	require.Equal(t, expected, actual)
+}
+
 func TestGatewayMutator_resolveExtProcImage(t *testing.T) {
 	tests := []struct {
 		name     string
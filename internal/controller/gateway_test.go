@@ -7,8 +7,11 @@ package controller
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"fmt"
+	"io"
+	"math/rand/v2"
 	"strconv"
 	"strings"
 	"testing"
@@ -20,22 +23,32 @@ import (
 	"go.uber.org/zap/zapcore"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/kubernetes"
 	fake2 "k8s.io/client-go/kubernetes/fake"
 	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
 	gwapiv1a2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+	"sigs.k8s.io/yaml"
 
+	aigv1a1 "github.com/envoyproxy/ai-gateway/api/v1alpha1"
 	aigv1b1 "github.com/envoyproxy/ai-gateway/api/v1beta1"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
 	"github.com/envoyproxy/ai-gateway/internal/controller/rotators"
+	"github.com/envoyproxy/ai-gateway/internal/endpointspec"
 	"github.com/envoyproxy/ai-gateway/internal/filterapi"
 	"github.com/envoyproxy/ai-gateway/internal/internalapi"
 	"github.com/envoyproxy/ai-gateway/internal/llmcostcel"
+	"github.com/envoyproxy/ai-gateway/pkg/translatorsdk"
 )
 
 // requireLLMRequestCostsEqual asserts two LLMRequestCost slices are equal, printing a go-cmp diff on failure.
@@ -367,7 +380,7 @@ func TestGatewayController_reconcileFilterConfigSecret(t *testing.T) {
 
 		catProg, err := llmcostcel.NewProgram(wantLLMRequestCosts[6].CEL)
 		require.NoError(t, err)
-		catVal, err := llmcostcel.EvaluateProgram(catProg, "model", "foo.default", "ns/route2", 3, 0, 0, 4, 7, 0)
+		catVal, err := llmcostcel.EvaluateProgram(catProg, "model", "foo.default", "ns/route2", 3, 0, 0, 4, 7, 0, 0, "", "")
 		require.NoError(t, err)
 		require.Equal(t, uint64(7), catVal)
 
@@ -388,6 +401,89 @@ func TestGatewayController_reconcileFilterConfigSecret(t *testing.T) {
 //     route's models remain visible on host-matched /v1/models requests), and
 //   - UnscopedModels is populated separately so unmatched hosts can fall back to it without leaking
 //     host-scoped models.
+func TestGatewayController_reconcileFilterConfigSecret_BackendAuthErrors(t *testing.T) {
+	fakeClient := requireNewFakeClientWithIndexes(t)
+	kube := fake2.NewClientset()
+	c := NewGatewayController(fakeClient, kube, ctrl.Log, "envoy-gateway-system",
+		"docker.io/envoyproxy/ai-gateway-extproc:latest", "info", false, nil, true)
+
+	const gwNamespace = "ns"
+	route := &aigv1b1.AIGatewayRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "route", Namespace: gwNamespace},
+		Spec: aigv1b1.AIGatewayRouteSpec{
+			Rules: []aigv1b1.AIGatewayRouteRule{
+				{BackendRefs: []aigv1b1.AIGatewayRouteRuleBackendRef{{Name: "apple"}, {Name: "no-region"}, {Name: "no-secret"}}},
+			},
+		},
+	}
+	require.NoError(t, fakeClient.Create(t.Context(), route))
+	for _, name := range []string{"apple", "no-region", "no-secret"} {
+		require.NoError(t, fakeClient.Create(t.Context(), &aigv1b1.AIServiceBackend{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: gwNamespace},
+			Spec: aigv1b1.AIServiceBackendSpec{
+				BackendRef: gwapiv1.BackendObjectReference{Name: "some-backend", Namespace: ptr.To[gwapiv1.Namespace](gwNamespace)},
+			},
+		}))
+	}
+	for _, bsp := range []*aigv1b1.BackendSecurityPolicy{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "no-region-bsp", Namespace: gwNamespace},
+			Spec: aigv1b1.BackendSecurityPolicySpec{
+				Type:           aigv1b1.BackendSecurityPolicyTypeAWSCredentials,
+				AWSCredentials: &aigv1b1.BackendSecurityPolicyAWSCredentials{},
+				TargetRefs: []gwapiv1a2.LocalPolicyTargetReference{
+					{Kind: "AIServiceBackend", Group: "aigateway.envoyproxy.io", Name: "no-region"},
+				},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "no-secret-bsp", Namespace: gwNamespace},
+			Spec: aigv1b1.BackendSecurityPolicySpec{
+				Type:   aigv1b1.BackendSecurityPolicyTypeAPIKey,
+				APIKey: &aigv1b1.BackendSecurityPolicyAPIKey{SecretRef: &gwapiv1.SecretObjectReference{Name: "non-existent-secret"}},
+				TargetRefs: []gwapiv1a2.LocalPolicyTargetReference{
+					{Kind: "AIServiceBackend", Group: "aigateway.envoyproxy.io", Name: "no-secret"},
+				},
+			},
+		},
+	} {
+		require.NoError(t, fakeClient.Create(t.Context(), bsp))
+	}
+
+	const someNamespace = "some-namespace"
+	_, err := c.reconcileFilterConfigSecret(t.Context(), "gw", gwNamespace, someNamespace,
+		[]aigv1b1.AIGatewayRoute{*route}, nil, "foouuid", nil)
+	require.NoError(t, err)
+
+	// The backends with the invalid auth are left out of the filter config.
+	fc := requireFilterConfigFromBundle(t, kube, someNamespace, "gw", gwNamespace)
+	require.Len(t, fc.Backends, 1)
+	require.Equal(t, "ns/apple/route/route/rule/0/ref/0", fc.Backends[0].Name)
+
+	var updatedRoute aigv1b1.AIGatewayRoute
+	require.NoError(t, fakeClient.Get(t.Context(), client.ObjectKeyFromObject(route), &updatedRoute))
+	require.Len(t, updatedRoute.Status.Conditions, 1)
+	require.Equal(t, aigv1b1.ConditionTypeNotAccepted, updatedRoute.Status.Conditions[0].Type)
+	require.Contains(t, updatedRoute.Status.Conditions[0].Message,
+		"invalid backend auth of AIServiceBackend ns/no-region from BackendSecurityPolicy no-region-bsp: aws region is required")
+	require.Contains(t, updatedRoute.Status.Conditions[0].Message,
+		"invalid backend auth of AIServiceBackend ns/no-secret from BackendSecurityPolicy no-secret-bsp: failed to get secret non-existent-secret")
+
+	for name, expMessage := range map[string]string{
+		"no-region": "invalid backend auth from BackendSecurityPolicy no-region-bsp: aws region is required",
+		"no-secret": "invalid backend auth from BackendSecurityPolicy no-secret-bsp: failed to get secret non-existent-secret",
+	} {
+		var backend aigv1b1.AIServiceBackend
+		require.NoError(t, fakeClient.Get(t.Context(), client.ObjectKey{Namespace: gwNamespace, Name: name}, &backend))
+		require.Len(t, backend.Status.Conditions, 1)
+		require.Equal(t, aigv1b1.ConditionTypeNotAccepted, backend.Status.Conditions[0].Type)
+		require.Contains(t, backend.Status.Conditions[0].Message, expMessage)
+	}
+	var apple aigv1b1.AIServiceBackend
+	require.NoError(t, fakeClient.Get(t.Context(), client.ObjectKey{Namespace: gwNamespace, Name: "apple"}, &apple))
+	require.Empty(t, apple.Status.Conditions)
+}
+
 func TestGatewayController_reconcileFilterConfigSecret_HostnameScopedModels(t *testing.T) {
 	fakeClient := requireNewFakeClientWithIndexes(t)
 	kube := fake2.NewClientset()
@@ -401,6 +497,10 @@ func TestGatewayController_reconcileFilterConfigSecret_HostnameScopedModels(t *t
 			ObjectMeta: metav1.ObjectMeta{Name: "scoped-route", Namespace: gwNamespace},
 			Spec: aigv1b1.AIGatewayRouteSpec{
 				Hostnames: []gwapiv1.Hostname{"api.example.com"},
+				ModelVisibility: &aigv1b1.AIGatewayRouteModelVisibility{
+					TenantHeader:   "X-Tenant-ID",
+					AllowedTenants: []string{"team-a"},
+				},
 				Rules: []aigv1b1.AIGatewayRouteRule{
 					{
 						BackendRefs: []aigv1b1.AIGatewayRouteRuleBackendRef{{Name: "apple"}},
@@ -472,6 +572,152 @@ func TestGatewayController_reconcileFilterConfigSecret_HostnameScopedModels(t *t
 		gotHostModels = append(gotHostModels, m.Name)
 	}
 	require.ElementsMatch(t, []string{"scoped-model", "unscoped-model"}, gotHostModels)
+
+	// The ModelVisibility of a route applies to its own models only.
+	for _, m := range fc.Models {
+		if m.Name == "scoped-model" {
+			require.Equal(t, &filterapi.ModelVisibility{TenantHeader: "x-tenant-id", Tenants: []string{"team-a"}}, m.Visibility)
+		} else {
+			require.Nil(t, m.Visibility)
+		}
+	}
+}
+
+func TestGatewayController_reconcileFilterConfigSecret_AllowedEndpoints(t *testing.T) {
+	fakeClient := requireNewFakeClientWithIndexes(t)
+	kube := fake2.NewClientset()
+	c := NewGatewayController(fakeClient, kube, ctrl.Log, "envoy-gateway-system",
+		"docker.io/envoyproxy/ai-gateway-extproc:latest", "info", false, nil, true)
+
+	const gwNamespace = "ns"
+	newRoute := func(name, model string, endpoints ...aigv1b1.AIGatewayRouteEndpoint) aigv1b1.AIGatewayRoute {
+		return aigv1b1.AIGatewayRoute{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: gwNamespace},
+			Spec: aigv1b1.AIGatewayRouteSpec{
+				AllowedEndpoints: endpoints,
+				Rules: []aigv1b1.AIGatewayRouteRule{{
+					BackendRefs: []aigv1b1.AIGatewayRouteRuleBackendRef{{Name: "apple"}},
+					Matches: []aigv1b1.AIGatewayRouteRuleMatch{{Headers: []gwapiv1.HTTPHeaderMatch{
+						{Name: internalapi.ModelNameHeaderKeyDefault, Value: model},
+					}}},
+				}},
+			},
+		}
+	}
+	routes := []aigv1b1.AIGatewayRoute{
+		newRoute("embeddings", "embedding-model", aigv1b1.AIGatewayRouteEndpointEmbeddings),
+		newRoute("chat", "chat-model", aigv1b1.AIGatewayRouteEndpointChat, aigv1b1.AIGatewayRouteEndpointModels),
+		newRoute("all", "any-model"),
+	}
+	// The context windows are kept per model even if the route does not list its models.
+	routes[0].Spec.Rules[0].ModelsContextWindows = []aigv1b1.AIGatewayRouteRuleModelContextWindow{
+		{Model: "embedding-model", Tokens: 8192},
+	}
+	routes[1].Spec.Rules[0].Matches = append(routes[1].Spec.Rules[0].Matches, aigv1b1.AIGatewayRouteRuleMatch{
+		Headers: []gwapiv1.HTTPHeaderMatch{{Name: internalapi.ModelNameHeaderKeyDefault, Value: "chat-model-mini"}},
+	})
+	routes[1].Spec.Rules[0].ModelsContextWindows = []aigv1b1.AIGatewayRouteRuleModelContextWindow{
+		{Model: "chat-model", Tokens: 128000},
+		{Model: "chat-model-mini", Tokens: 16384},
+		// The models not matched by the rule are ignored.
+		{Model: "embedding-model", Tokens: 4096},
+	}
+	require.NoError(t, fakeClient.Create(t.Context(), &aigv1b1.AIServiceBackend{
+		ObjectMeta: metav1.ObjectMeta{Name: "apple", Namespace: gwNamespace},
+		Spec: aigv1b1.AIServiceBackendSpec{
+			BackendRef: gwapiv1.BackendObjectReference{Name: "some-backend1", Namespace: ptr.To[gwapiv1.Namespace](gwNamespace)},
+		},
+	}))
+
+	const someNamespace = "some-namespace"
+	_, err := c.reconcileFilterConfigSecret(t.Context(), "gw", gwNamespace, someNamespace, routes, nil, "foouuid", nil)
+	require.NoError(t, err)
+
+	fc := requireFilterConfigFromBundle(t, kube, someNamespace, "gw", gwNamespace)
+	require.Equal(t, []filterapi.AllowedEndpoints{
+		{RouteName: "ns/embeddings", Endpoints: []filterapi.Endpoint{filterapi.EndpointEmbeddings}},
+		{RouteName: "ns/chat", Endpoints: []filterapi.Endpoint{filterapi.EndpointChat, filterapi.EndpointModels}},
+	}, fc.AllowedEndpoints)
+	// The models of the routes not allowing the models endpoint are not listed.
+	models := make([]string, 0, len(fc.Models))
+	for _, m := range fc.Models {
+		models = append(models, m.Name)
+	}
+	require.ElementsMatch(t, []string{"chat-model", "chat-model-mini", "any-model"}, models)
+	require.ElementsMatch(t, []filterapi.ContextWindow{
+		{RouteName: "ns/embeddings", Model: "embedding-model", Tokens: 8192},
+		{RouteName: "ns/chat", Model: "chat-model", Tokens: 128000},
+		{RouteName: "ns/chat", Model: "chat-model-mini", Tokens: 16384},
+	}, fc.ContextWindows)
+}
+
+// customSchemaTranslator is the translator of a custom API schema registered with the translatorsdk package.
+type customSchemaTranslator struct{ schema translatorsdk.APISchema }
+
+func (c *customSchemaTranslator) RequestBody(_ []byte, req *translatorsdk.ChatCompletionRequest, _ bool) ([]translatorsdk.Header, []byte, error) {
+	return []translatorsdk.Header{{":path", "/" + c.schema.Version + "/generate"}}, []byte(`{"prompt_model":"` + req.Model + `"}`), nil
+}
+
+func (c *customSchemaTranslator) ResponseHeaders(map[string]string) ([]translatorsdk.Header, error) {
+	return nil, nil
+}
+
+func (c *customSchemaTranslator) ResponseBody(map[string]string, io.Reader, bool) ([]translatorsdk.Header, []byte, translatorsdk.TokenUsage, string, error) {
+	return nil, nil, translatorsdk.TokenUsage{}, "", nil
+}
+
+func (c *customSchemaTranslator) ResponseError(map[string]string, io.Reader) ([]translatorsdk.Header, []byte, error) {
+	return nil, nil, nil
+}
+
+// TestGatewayController_reconcileFilterConfigSecret_CustomAPISchema checks that a backend with a custom API schema
+// reaches the translator registered for it with the translatorsdk package, which the external processor uses.
+func TestGatewayController_reconcileFilterConfigSecret_CustomAPISchema(t *testing.T) {
+	const schemaName = "ControllerTestProvider"
+	require.NoError(t, translatorsdk.RegisterChatCompletionTranslator(schemaName,
+		func(schema translatorsdk.APISchema, _ string) (translatorsdk.ChatCompletionTranslator, error) {
+			return &customSchemaTranslator{schema: schema}, nil
+		}))
+
+	fakeClient := requireNewFakeClientWithIndexes(t)
+	kube := fake2.NewClientset()
+	c := NewGatewayController(fakeClient, kube, ctrl.Log, "envoy-gateway-system",
+		"docker.io/envoyproxy/ai-gateway-extproc:latest", "info", false, nil, true)
+
+	const gwNamespace = "ns"
+	routes := []aigv1b1.AIGatewayRoute{{
+		ObjectMeta: metav1.ObjectMeta{Name: "route", Namespace: gwNamespace},
+		Spec: aigv1b1.AIGatewayRouteSpec{
+			Rules: []aigv1b1.AIGatewayRouteRule{{
+				BackendRefs: []aigv1b1.AIGatewayRouteRuleBackendRef{{Name: "custom"}},
+				Matches: []aigv1b1.AIGatewayRouteRuleMatch{{Headers: []gwapiv1.HTTPHeaderMatch{
+					{Name: internalapi.ModelNameHeaderKeyDefault, Value: "custom-model"},
+				}}},
+			}},
+		},
+	}}
+	require.NoError(t, fakeClient.Create(t.Context(), &aigv1b1.AIServiceBackend{
+		ObjectMeta: metav1.ObjectMeta{Name: "custom", Namespace: gwNamespace},
+		Spec: aigv1b1.AIServiceBackendSpec{
+			APISchema:  aigv1b1.VersionedAPISchema{Name: schemaName, Version: ptr.To("v3")},
+			BackendRef: gwapiv1.BackendObjectReference{Name: "some-backend1", Namespace: ptr.To[gwapiv1.Namespace](gwNamespace)},
+		},
+	}))
+
+	const someNamespace = "some-namespace"
+	_, err := c.reconcileFilterConfigSecret(t.Context(), "gw", gwNamespace, someNamespace, routes, nil, "foouuid", nil)
+	require.NoError(t, err)
+
+	fc := requireFilterConfigFromBundle(t, kube, someNamespace, "gw", gwNamespace)
+	require.Len(t, fc.Backends, 1)
+	require.Equal(t, filterapi.VersionedAPISchema{Name: schemaName, Version: "v3"}, fc.Backends[0].Schema)
+
+	tr, err := endpointspec.ChatCompletionsEndpointSpec{}.GetTranslator(fc.Backends[0].Schema, "")
+	require.NoError(t, err)
+	headers, body, err := tr.RequestBody(nil, &openai.ChatCompletionRequest{Model: "custom-model"}, false)
+	require.NoError(t, err)
+	require.Equal(t, []internalapi.Header{{":path", "/v3/generate"}}, headers)
+	require.JSONEq(t, `{"prompt_model":"custom-model"}`, string(body))
 }
 
 // TestGatewayController_reconcileFilterConfigSecret_AllUnscopedRoutesLeaveUnscopedModelsEmpty
@@ -611,16 +857,111 @@ func TestGatewayController_reconcileFilterConfigSecret_RouteLevelLLMRequestCostA
 
 	freeProg, err := llmcostcel.NewProgram(wantLLMRequestCosts[0].CEL)
 	require.NoError(t, err)
-	val, err := llmcostcel.EvaluateProgram(freeProg, "model", "free-backend", "ns/free-model-route", 10, 0, 0, 5, 15, 0)
+	val, err := llmcostcel.EvaluateProgram(freeProg, "model", "free-backend", "ns/free-model-route", 10, 0, 0, 5, 15, 0, 0, "", "")
 	require.NoError(t, err)
 	require.Equal(t, uint64(0), val)
 	paidProg, err := llmcostcel.NewProgram(wantLLMRequestCosts[1].CEL)
 	require.NoError(t, err)
-	val, err = llmcostcel.EvaluateProgram(paidProg, "model", "paid-backend", "ns/paid-model-route", 10, 0, 0, 5, 15, 0)
+	val, err = llmcostcel.EvaluateProgram(paidProg, "model", "paid-backend", "ns/paid-model-route", 10, 0, 0, 5, 15, 0, 0, "", "")
 	require.NoError(t, err)
 	require.Equal(t, uint64(15), val)
 }
 
+func TestGatewayController_reconcileFilterConfigSecret_Migrations(t *testing.T) {
+	fakeClient := requireNewFakeClientWithIndexes(t)
+	kube := fake2.NewClientset()
+	c := NewGatewayController(fakeClient, kube, ctrl.Log, "envoy-gateway-system",
+		"docker.io/envoyproxy/ai-gateway-extproc:latest", "info", false, nil, true)
+
+	const gwNamespace = "ns"
+	for _, name := range []string{"openai", "anthropic"} {
+		require.NoError(t, fakeClient.Create(t.Context(), &aigv1b1.AIServiceBackend{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: gwNamespace},
+			Spec: aigv1b1.AIServiceBackendSpec{
+				BackendRef: gwapiv1.BackendObjectReference{Name: "some-backend", Namespace: ptr.To[gwapiv1.Namespace](gwNamespace)},
+			},
+		}))
+	}
+	routes := []aigv1b1.AIGatewayRoute{{
+		ObjectMeta: metav1.ObjectMeta{Name: "route", Namespace: gwNamespace},
+		Spec: aigv1b1.AIGatewayRouteSpec{
+			Rules: []aigv1b1.AIGatewayRouteRule{
+				{BackendRefs: []aigv1b1.AIGatewayRouteRuleBackendRef{{Name: "openai"}}},
+				{
+					Matches: []aigv1b1.AIGatewayRouteRuleMatch{{Headers: []gwapiv1.HTTPHeaderMatch{
+						{Name: internalapi.ModelNameHeaderKeyDefault, Value: "gpt-4o"},
+					}}},
+					BackendRefs: []aigv1b1.AIGatewayRouteRuleBackendRef{{Name: "openai"}},
+					Migration: &aigv1b1.AIGatewayRouteRuleMigration{
+						BackendRef:    aigv1b1.AIGatewayRouteRuleBackendRef{Name: "anthropic"},
+						SamplePercent: ptr.To[int32](25),
+					},
+				},
+			},
+		},
+	}}
+
+	const someNamespace = "some-namespace"
+	effective, err := c.reconcileFilterConfigSecret(t.Context(), "gw", gwNamespace, someNamespace, routes, nil, "foouuid", nil)
+	require.NoError(t, err)
+	require.True(t, effective)
+	fc := requireFilterConfigFromBundle(t, kube, someNamespace, "gw", gwNamespace)
+
+	// The migration backend is named after the migration rule, placed after the route-not-found rule.
+	migrationBackend := internalapi.PerRouteRuleRefBackendName(gwNamespace, "anthropic", "route", 3, 0)
+	require.Equal(t, []filterapi.Migration{
+		{RouteName: "ns/route", RuleIndex: 1, BackendName: migrationBackend, SamplePercent: 25, TimeoutMilliseconds: 60000},
+	}, fc.Migrations)
+	backendNames := make([]string, 0, len(fc.Backends))
+	for _, b := range fc.Backends {
+		backendNames = append(backendNames, b.Name)
+	}
+	require.Contains(t, backendNames, migrationBackend)
+	// The migration rule doesn't declare the model again.
+	require.Len(t, fc.Models, 1)
+}
+
+func TestGatewayController_reconcileFilterConfigSecret_InstallationNames(t *testing.T) {
+	fakeClient := requireNewFakeClientWithIndexes(t)
+	kube := fake2.NewClientset()
+	c := NewGatewayController(fakeClient, kube, ctrl.Log, "envoy-gateway-system",
+		"docker.io/envoyproxy/ai-gateway-extproc:latest", "info", false, nil, true)
+	c.modelNameHeaderKey = "x-model"
+	c.metadataNamespace = "io.example.gateway"
+	c.selectedBackendHeaderKey = "x-backend"
+
+	const gwNamespace = "ns"
+	require.NoError(t, fakeClient.Create(t.Context(), &aigv1b1.AIServiceBackend{
+		ObjectMeta: metav1.ObjectMeta{Name: "openai", Namespace: gwNamespace},
+		Spec: aigv1b1.AIServiceBackendSpec{
+			BackendRef: gwapiv1.BackendObjectReference{Name: "some-backend", Namespace: ptr.To[gwapiv1.Namespace](gwNamespace)},
+		},
+	}))
+	routes := []aigv1b1.AIGatewayRoute{{
+		ObjectMeta: metav1.ObjectMeta{Name: "route", Namespace: gwNamespace},
+		Spec: aigv1b1.AIGatewayRouteSpec{
+			Rules: []aigv1b1.AIGatewayRouteRule{{
+				Matches: []aigv1b1.AIGatewayRouteRuleMatch{{Headers: []gwapiv1.HTTPHeaderMatch{
+					{Name: "x-model", Value: "gpt-4o"},
+					// The default header is not the model name header of the installation.
+					{Name: internalapi.ModelNameHeaderKeyDefault, Value: "gpt-4o-mini"},
+				}}},
+				BackendRefs: []aigv1b1.AIGatewayRouteRuleBackendRef{{Name: "openai"}},
+			}},
+		},
+	}}
+
+	const someNamespace = "some-namespace"
+	_, err := c.reconcileFilterConfigSecret(t.Context(), "gw", gwNamespace, someNamespace, routes, nil, "foouuid", nil)
+	require.NoError(t, err)
+	fc := requireFilterConfigFromBundle(t, kube, someNamespace, "gw", gwNamespace)
+	require.Equal(t, "x-model", fc.ModelNameHeaderKey)
+	require.Equal(t, "io.example.gateway", fc.MetadataNamespace)
+	require.Equal(t, "x-backend", fc.SelectedBackendHeaderKey)
+	require.Len(t, fc.Models, 1)
+	require.Equal(t, "gpt-4o", fc.Models[0].Name)
+}
+
 // TestGatewayController_reconcileFilterConfigSecret_RouteLevelLLMRequestCostAggregation_DuplicateMetadataKey
 // verifies that duplicate metadata keys keep "last definition wins" semantics.
 func TestGatewayController_reconcileFilterConfigSecret_RouteLevelLLMRequestCostAggregation_DuplicateMetadataKey(t *testing.T) {
@@ -862,6 +1203,29 @@ func TestGatewayController_bspToFilterAPIBackendAuth(t *testing.T) {
 				},
 			},
 		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "bsp-apikey-openai-org", Namespace: namespace},
+			Spec: aigv1b1.BackendSecurityPolicySpec{
+				Type: aigv1b1.BackendSecurityPolicyTypeAPIKey,
+				APIKey: &aigv1b1.BackendSecurityPolicyAPIKey{
+					SecretRef:    &gwapiv1.SecretObjectReference{Name: "api-key-secret"},
+					Organization: "org-abc123",
+					Project:      "proj_abc123",
+				},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "bsp-apikey-pool", Namespace: namespace},
+			Spec: aigv1b1.BackendSecurityPolicySpec{
+				Type: aigv1b1.BackendSecurityPolicyTypeAPIKey,
+				APIKey: &aigv1b1.BackendSecurityPolicyAPIKey{
+					Pool: []aigv1b1.BackendSecurityPolicyAPIKeyPoolEntry{
+						{Name: "a", SecretRef: &gwapiv1.SecretObjectReference{Name: "api-key-secret"}, Weight: ptr.To[int32](3)},
+						{Name: "b", SecretRef: &gwapiv1.SecretObjectReference{Name: "api-key-secret-b"}},
+					},
+				},
+			},
+		},
 		{
 			ObjectMeta: metav1.ObjectMeta{Name: "aws-credentials-file", Namespace: namespace},
 			Spec: aigv1b1.BackendSecurityPolicySpec{
@@ -892,6 +1256,16 @@ func TestGatewayController_bspToFilterAPIBackendAuth(t *testing.T) {
 				},
 			},
 		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "aws-multi-region", Namespace: namespace},
+			Spec: aigv1b1.BackendSecurityPolicySpec{
+				Type: aigv1b1.BackendSecurityPolicyTypeAWSCredentials,
+				AWSCredentials: &aigv1b1.BackendSecurityPolicyAWSCredentials{
+					Region:    "us-east-1",
+					RegionSet: []string{"us-east-1", "us-west-2"},
+				},
+			},
+		},
 		{
 			ObjectMeta: metav1.ObjectMeta{Name: "azure-oidc", Namespace: namespace},
 			Spec: aigv1b1.BackendSecurityPolicySpec{
@@ -940,6 +1314,27 @@ func TestGatewayController_bspToFilterAPIBackendAuth(t *testing.T) {
 				},
 			},
 		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "ibm-cloud", Namespace: namespace},
+			Spec: aigv1b1.BackendSecurityPolicySpec{
+				Type: aigv1b1.BackendSecurityPolicyTypeIBMCloudAPIKey,
+				IBMCloudAPIKey: &aigv1b1.BackendSecurityPolicyIBMCloudAPIKey{
+					SecretRef: &gwapiv1.SecretObjectReference{Name: "api-key-secret"},
+				},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "sap-ai-core", Namespace: namespace},
+			Spec: aigv1b1.BackendSecurityPolicySpec{
+				Type: aigv1b1.BackendSecurityPolicyTypeSAPAICoreCredentials,
+				SAPAICoreCredentials: &aigv1b1.BackendSecurityPolicySAPAICoreCredentials{
+					AuthURL:         "https://example.authentication.eu10.hana.ondemand.com",
+					ClientID:        "some-client-id",
+					ClientSecretRef: &gwapiv1.SecretObjectReference{Name: "sap-client-secret"},
+					ResourceGroup:   "team-a",
+				},
+			},
+		},
 	} {
 		require.NoError(t, fakeClient.Create(t.Context(), bsp))
 	}
@@ -948,6 +1343,10 @@ func TestGatewayController_bspToFilterAPIBackendAuth(t *testing.T) {
 			ObjectMeta: metav1.ObjectMeta{Name: "api-key-secret", Namespace: namespace},
 			StringData: map[string]string{apiKeyInSecret: "thisisapikey"},
 		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "api-key-secret-b", Namespace: namespace},
+			StringData: map[string]string{apiKeyInSecret: "thisisapikeyb"},
+		},
 		{
 			ObjectMeta: metav1.ObjectMeta{Name: "aws-credentials-file-secret", Namespace: namespace},
 			StringData: map[string]string{rotators.AwsCredentialsKey: "thisisawscredentials"},
@@ -968,6 +1367,14 @@ func TestGatewayController_bspToFilterAPIBackendAuth(t *testing.T) {
 			ObjectMeta: metav1.ObjectMeta{Name: rotators.GetBSPSecretName("gcp-wif"), Namespace: namespace},
 			StringData: map[string]string{rotators.GCPAccessTokenKey: "thisisgcpcredentials"},
 		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: rotators.GetBSPSecretName("ibm-cloud"), Namespace: namespace},
+			StringData: map[string]string{rotators.IBMCloudAccessTokenKey: "thisisibmcloudcredentials"},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: rotators.GetBSPSecretName("sap-ai-core"), Namespace: namespace},
+			StringData: map[string]string{rotators.SAPAICoreAccessTokenKey: "thisissapaicorecredentials"},
+		},
 	} {
 		_, err := kube.CoreV1().Secrets(namespace).Create(t.Context(), s, metav1.CreateOptions{})
 		require.NoError(t, err)
@@ -981,6 +1388,19 @@ func TestGatewayController_bspToFilterAPIBackendAuth(t *testing.T) {
 			bspName: "bsp-apikey",
 			exp:     &filterapi.BackendAuth{APIKey: &filterapi.APIKeyAuth{Key: "thisisapikey"}},
 		},
+		{
+			bspName: "bsp-apikey-openai-org",
+			exp: &filterapi.BackendAuth{APIKey: &filterapi.APIKeyAuth{
+				Key: "thisisapikey", Organization: "org-abc123", Project: "proj_abc123",
+			}},
+		},
+		{
+			bspName: "bsp-apikey-pool",
+			exp: &filterapi.BackendAuth{APIKey: &filterapi.APIKeyAuth{Pool: []filterapi.APIKeyPoolEntry{
+				{Name: "ns/bsp-apikey-pool/a", Key: "thisisapikey", Weight: 3},
+				{Name: "ns/bsp-apikey-pool/b", Key: "thisisapikeyb", Weight: 1},
+			}}},
+		},
 		{
 			bspName: "aws-credentials-file",
 			exp: &filterapi.BackendAuth{
@@ -1004,6 +1424,12 @@ func TestGatewayController_bspToFilterAPIBackendAuth(t *testing.T) {
 				},
 			},
 		},
+		{
+			bspName: "aws-multi-region",
+			exp: &filterapi.BackendAuth{
+				AWSAuth: &filterapi.AWSAuth{Region: "us-east-1", RegionSet: []string{"us-east-1", "us-west-2"}},
+			},
+		},
 		{
 			bspName: "azure-oidc",
 			exp: &filterapi.BackendAuth{
@@ -1031,6 +1457,18 @@ func TestGatewayController_bspToFilterAPIBackendAuth(t *testing.T) {
 				AnthropicAPIKey: &filterapi.AnthropicAPIKeyAuth{Key: "thisisapikey"},
 			},
 		},
+		{
+			bspName: "ibm-cloud",
+			exp: &filterapi.BackendAuth{
+				IBMCloudAuth: &filterapi.IBMCloudAuth{AccessToken: "thisisibmcloudcredentials"},
+			},
+		},
+		{
+			bspName: "sap-ai-core",
+			exp: &filterapi.BackendAuth{
+				SAPAICoreAuth: &filterapi.SAPAICoreAuth{AccessToken: "thisissapaicorecredentials", ResourceGroup: "team-a"},
+			},
+		},
 	} {
 		t.Run(tc.bspName, func(t *testing.T) {
 			bsp := &aigv1b1.BackendSecurityPolicy{}
@@ -2317,7 +2755,181 @@ func TestGatewayController_backendWithMaybeBSP(t *testing.T) {
 	require.ErrorContains(t, err, "multiple BackendSecurityPolicies found for backend bar")
 }
 
+func TestGatewayController_reconcileExtProcPodDisruptionBudget(t *testing.T) {
+	fakeClient := requireNewFakeClientWithIndexes(t)
+	kube := fake2.NewClientset()
+	c := NewGatewayController(fakeClient, kube, ctrl.Log, "envoy-gateway-system",
+		"docker.io/envoyproxy/ai-gateway-extproc:latest", "info", false, nil, true)
+
+	const podNamespace = "envoy-gateway-system"
+	name := extProcPodDisruptionBudgetName("gw", "ns")
+	owner := extProcPodDisruptionBudgetOwner([]appsv1.Deployment{
+		{ObjectMeta: metav1.ObjectMeta{Name: "envoy-ns-gw", Namespace: podNamespace, UID: "deployment-uid"}},
+	}, nil)
+
+	// Nothing to delete.
+	require.NoError(t, c.reconcileExtProcPodDisruptionBudget(t.Context(), "gw", "ns", podNamespace, owner, nil))
+
+	// Create.
+	require.NoError(t, c.reconcileExtProcPodDisruptionBudget(t.Context(), "gw", "ns", podNamespace, owner,
+		&aigv1b1.GatewayConfigExtProcPodDisruptionBudget{MinAvailable: ptr.To(intstr.FromInt32(1))}))
+	pdb, err := kube.PolicyV1().PodDisruptionBudgets(podNamespace).Get(t.Context(), name, metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, ptr.To(intstr.FromInt32(1)), pdb.Spec.MinAvailable)
+	require.Nil(t, pdb.Spec.MaxUnavailable)
+	require.Equal(t, map[string]string{
+		egOwningGatewayNameLabel:      "gw",
+		egOwningGatewayNamespaceLabel: "ns",
+	}, pdb.Spec.Selector.MatchLabels)
+	require.Equal(t, []metav1.OwnerReference{{
+		APIVersion:         "apps/v1",
+		Kind:               "Deployment",
+		Name:               "envoy-ns-gw",
+		UID:                "deployment-uid",
+		Controller:         ptr.To(true),
+		BlockOwnerDeletion: ptr.To(true),
+	}}, pdb.OwnerReferences)
+
+	// Update, including the owner, e.g. when switching to a DaemonSet.
+	owner = extProcPodDisruptionBudgetOwner(nil, []appsv1.DaemonSet{
+		{ObjectMeta: metav1.ObjectMeta{Name: "envoy-ns-gw", Namespace: podNamespace, UID: "daemonset-uid"}},
+	})
+	require.NoError(t, c.reconcileExtProcPodDisruptionBudget(t.Context(), "gw", "ns", podNamespace, owner,
+		&aigv1b1.GatewayConfigExtProcPodDisruptionBudget{MaxUnavailable: ptr.To(intstr.FromString("25%"))}))
+	pdb, err = kube.PolicyV1().PodDisruptionBudgets(podNamespace).Get(t.Context(), name, metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Nil(t, pdb.Spec.MinAvailable)
+	require.Equal(t, ptr.To(intstr.FromString("25%")), pdb.Spec.MaxUnavailable)
+	require.Len(t, pdb.OwnerReferences, 1)
+	require.Equal(t, "DaemonSet", pdb.OwnerReferences[0].Kind)
+	require.Equal(t, types.UID("daemonset-uid"), pdb.OwnerReferences[0].UID)
+
+	// Delete.
+	require.NoError(t, c.reconcileExtProcPodDisruptionBudget(t.Context(), "gw", "ns", podNamespace, owner, nil))
+	_, err = kube.PolicyV1().PodDisruptionBudgets(podNamespace).Get(t.Context(), name, metav1.GetOptions{})
+	require.True(t, apierrors.IsNotFound(err))
+
+	// No owner without the Envoy Deployment or DaemonSet.
+	require.Nil(t, extProcPodDisruptionBudgetOwner(nil, nil))
+}
+
+func TestGatewayController_Reconcile_deletesExtProcPodDisruptionBudget(t *testing.T) {
+	fakeClient := requireNewFakeClientWithIndexes(t)
+	kube := fake2.NewClientset()
+	c := NewGatewayController(fakeClient, kube, ctrl.Log, "envoy-gateway-system",
+		"docker.io/envoyproxy/ai-gateway-extproc:latest", "info", false, nil, true)
+
+	// The PodDisruptionBudgets of the deleted Gateway are deleted from both the Gateway and the Envoy Gateway
+	// namespaces, while the ones of the other Gateways are kept.
+	for _, ns := range []string{"ns", "envoy-gateway-system"} {
+		for _, gw := range []string{"gw", "other"} {
+			_, err := kube.PolicyV1().PodDisruptionBudgets(ns).Create(t.Context(), &policyv1.PodDisruptionBudget{
+				ObjectMeta: metav1.ObjectMeta{Name: extProcPodDisruptionBudgetName(gw, "ns"), Namespace: ns},
+			}, metav1.CreateOptions{})
+			require.NoError(t, err)
+		}
+	}
+	res, err := c.Reconcile(t.Context(), ctrl.Request{NamespacedName: client.ObjectKey{Name: "gw", Namespace: "ns"}})
+	require.NoError(t, err)
+	require.Equal(t, ctrl.Result{}, res)
+	for _, ns := range []string{"ns", "envoy-gateway-system"} {
+		_, err = kube.PolicyV1().PodDisruptionBudgets(ns).Get(t.Context(), extProcPodDisruptionBudgetName("gw", "ns"), metav1.GetOptions{})
+		require.True(t, apierrors.IsNotFound(err))
+		_, err = kube.PolicyV1().PodDisruptionBudgets(ns).Get(t.Context(), extProcPodDisruptionBudgetName("other", "ns"), metav1.GetOptions{})
+		require.NoError(t, err)
+	}
+}
+
 // Ensure MCP-only routes produce a correct MCPConfig in the filter Secret.
+type fakeFilterConfigPublisher map[string][]byte
+
+func (f fakeFilterConfigPublisher) Publish(name string, config []byte) { f[name] = config }
+
+func (f fakeFilterConfigPublisher) Delete(name string) { delete(f, name) }
+
+func TestGatewayController_reconcileFilterConfigSecret_PublishesToConfigStream(t *testing.T) {
+	fakeClient := requireNewFakeClientWithIndexes(t)
+	kube := fake2.NewClientset()
+	c := NewGatewayController(fakeClient, kube, ctrl.Log, "envoy-gateway-system",
+		"docker.io/envoyproxy/ai-gateway-extproc:latest", "info", false, nil, true)
+	publisher := fakeFilterConfigPublisher{}
+	c.configPublisher = publisher
+
+	_, err := c.reconcileFilterConfigSecret(t.Context(), "gw", "ns", "some-namespace", nil, nil, "stream-uuid", &aigv1b1.GatewayConfigSpec{
+		TruncatedStreamErrorEvent: true,
+		StreamCoalescing:          &aigv1b1.StreamCoalescing{MinBytes: ptr.To[int32](512), FlushInterval: ptr.To(gwapiv1.Duration("50ms"))},
+		PromptInjectionDetection:  &aigv1b1.PromptInjectionDetection{BlockThreshold: ptr.To[int32](80)},
+		FallbackResponse:          &aigv1b1.FallbackResponse{Message: "{{ .Model }} is unavailable"},
+		DebugEcho:                 true,
+	})
+	require.NoError(t, err)
+	require.Len(t, publisher, 1)
+	var fc filterapi.Config
+	require.NoError(t, yaml.Unmarshal(publisher["ns/gw"], &fc))
+	require.Equal(t, "stream-uuid", fc.UUID)
+	require.True(t, fc.TruncatedStreamErrorEvent)
+	require.True(t, fc.DebugEcho)
+	require.Equal(t, &filterapi.StreamCoalescing{MinBytes: 512, FlushIntervalMilliseconds: 50}, fc.StreamCoalescing)
+	require.Equal(t, &filterapi.PromptInjectionDetection{BlockThreshold: 80}, fc.PromptInjectionDetection)
+	require.Equal(t, &filterapi.FallbackResponse{
+		StatusCodes: []int{429, 503},
+		Type:        filterapi.FallbackResponseTypeError,
+		Message:     "{{ .Model }} is unavailable",
+	}, fc.FallbackResponse)
+}
+
+func TestGatewayController_Reconcile_deletesPublishedConfig(t *testing.T) {
+	fakeClient := requireNewFakeClientWithIndexes(t)
+	c := NewGatewayController(fakeClient, fake2.NewClientset(), ctrl.Log, "envoy-gateway-system",
+		"docker.io/envoyproxy/ai-gateway-extproc:latest", "info", false, nil, true)
+	publisher := fakeFilterConfigPublisher{"ns/gw": []byte("gw"), "ns/other": []byte("other")}
+	c.configPublisher = publisher
+
+	_, err := c.Reconcile(t.Context(), ctrl.Request{NamespacedName: client.ObjectKey{Name: "gw", Namespace: "ns"}})
+	require.NoError(t, err)
+	require.Equal(t, fakeFilterConfigPublisher{"ns/other": []byte("other")}, publisher)
+}
+
+func TestGatewayController_revertsModifiedFilterConfigSecret(t *testing.T) {
+	fakeClient := requireNewFakeClientWithIndexes(t)
+	kube := fake2.NewClientset()
+	c := NewGatewayController(fakeClient, kube, ctrl.Log, "envoy-gateway-system",
+		"docker.io/envoyproxy/ai-gateway-extproc:latest", "info", false, nil, true)
+	gw := &gwapiv1.Gateway{ObjectMeta: metav1.ObjectMeta{Name: "gw", Namespace: "ns"}}
+	route := aigv1b1.AIGatewayRoute{ObjectMeta: metav1.ObjectMeta{Name: "route", Namespace: "ns"}}
+	require.NoError(t, fakeClient.Create(t.Context(), &route))
+
+	_, err := c.reconcileFilterConfigSecret(t.Context(), "gw", "ns", "envoy-gateway-system", nil, nil, "first", nil)
+	require.NoError(t, err)
+	require.Empty(t, c.generated.takeModified(gw))
+
+	partName := filterConfigBundlePartSecretName("gw", "ns", 0)
+	part, err := kube.CoreV1().Secrets("envoy-gateway-system").Get(t.Context(), partName, metav1.GetOptions{})
+	require.NoError(t, err)
+	require.False(t, isFilterConfigSecretModified(part))
+	require.Equal(t, []reconcile.Request{{NamespacedName: client.ObjectKeyFromObject(gw)}}, filterConfigSecretToGateway(t.Context(), part))
+
+	part.Data[FilterConfigBundlePartKey] = []byte("edited")
+	part, err = kube.CoreV1().Secrets("envoy-gateway-system").Update(t.Context(), part, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	require.True(t, isFilterConfigSecretModified(part))
+
+	_, err = c.reconcileFilterConfigSecret(t.Context(), "gw", "ns", "envoy-gateway-system", nil, nil, "second", nil)
+	require.NoError(t, err)
+	part, err = kube.CoreV1().Secrets("envoy-gateway-system").Get(t.Context(), partName, metav1.GetOptions{})
+	require.NoError(t, err)
+	require.False(t, isFilterConfigSecretModified(part))
+	modified := c.generated.takeModified(gw)
+	require.Equal(t, []string{"Secret envoy-gateway-system/" + partName}, modified)
+
+	c.reportModifiedFilterConfig(t.Context(), []aigv1b1.AIGatewayRoute{route}, nil, modified)
+	require.NoError(t, fakeClient.Get(t.Context(), client.ObjectKeyFromObject(&route), &route))
+	degraded := meta.FindStatusCondition(route.Status.Conditions, aigv1b1.ConditionTypeDegraded)
+	require.NotNil(t, degraded)
+	require.Equal(t, "reverted the out-of-band edits to the generated resources: Secret envoy-gateway-system/"+partName,
+		degraded.Message)
+}
+
 func TestGatewayController_reconcileFilterMCPConfigSecret(t *testing.T) {
 	fakeClient := requireNewFakeClientWithIndexes(t)
 	kube := fake2.NewClientset()
@@ -2412,7 +3024,8 @@ func TestGatewayController_writeFilterConfigBundleShards(t *testing.T) {
 	namespace := "ns"
 	gatewayName := "cfg-gw"
 	gatewayNamespace := "cfg-ns"
-	payload := append([]byte(strings.Repeat("x", filterConfigBundlePartSizeBytes*2+10)), []byte("中文字符")...)
+	// Random bytes are not compressible, so the compressed config still needs three parts.
+	payload := append(randomBytes(filterConfigBundlePartSizeBytes*2+10), []byte("中文字符")...)
 	err := c.writeFilterConfigBundle(t.Context(), gatewayName, gatewayNamespace, namespace, payload, "uuid-1")
 	require.NoError(t, err)
 
@@ -2430,6 +3043,7 @@ func TestGatewayController_writeFilterConfigBundleShards(t *testing.T) {
 	index, err := filterapi.UnmarshalConfigBundleIndex([]byte(indexRaw))
 	require.NoError(t, err)
 	require.Len(t, index.Parts, 3)
+	require.Equal(t, filterapi.ConfigBundleEncodingGzip, index.Encoding)
 
 	var reassembled bytes.Buffer
 	for _, part := range index.Parts {
@@ -2441,7 +3055,8 @@ func TestGatewayController_writeFilterConfigBundleShards(t *testing.T) {
 		require.False(t, stringDataOK)
 		reassembled.Write(chunk)
 	}
-	require.Equal(t, payload, reassembled.Bytes())
+	require.Equal(t, index.Checksum, filterapi.ConfigBundleChecksum(reassembled.Bytes()))
+	require.Equal(t, payload, requireGunzip(t, reassembled.Bytes()))
 	_, err = kube.CoreV1().Secrets(namespace).Get(t.Context(),
 		filterConfigBundlePartSecretName(gatewayName, gatewayNamespace, maxFilterConfigBundleSlots-1), metav1.GetOptions{})
 	require.True(t, apierrors.IsNotFound(err))
@@ -2456,11 +3071,62 @@ func TestGatewayController_writeFilterConfigBundleShards_Overflow(t *testing.T)
 	c := NewGatewayController(fakeClient, kube, ctrl.Log, "envoy-gateway-system",
 		"docker.io/envoyproxy/ai-gateway-extproc:latest", "info", false, nil, true)
 
-	payload := []byte(strings.Repeat("x", filterConfigBundlePartSizeBytes*(maxFilterConfigBundleSlots+1)))
+	payload := randomBytes(filterConfigBundlePartSizeBytes * (maxFilterConfigBundleSlots + 1))
 	err := c.writeFilterConfigBundle(t.Context(), "cfg-gw", "cfg-ns", "ns", payload, "uuid-1")
 	require.ErrorContains(t, err, "exceeds max supported slots")
 }
 
+func TestGatewayController_writeFilterConfigBundleShards_Compression(t *testing.T) {
+	fakeClient := requireNewFakeClientWithIndexes(t)
+	kube := fake2.NewClientset()
+	c := NewGatewayController(fakeClient, kube, ctrl.Log, "envoy-gateway-system",
+		"docker.io/envoyproxy/ai-gateway-extproc:latest", "info", false, nil, true)
+
+	readIndex := func() *filterapi.ConfigBundleIndex {
+		indexSecret, err := kube.CoreV1().Secrets("ns").Get(t.Context(),
+			FilterConfigBundleIndexSecretName("cfg-gw", "cfg-ns"), metav1.GetOptions{})
+		require.NoError(t, err)
+		index, err := filterapi.UnmarshalConfigBundleIndex([]byte(indexSecret.StringData[FilterConfigBundleIndexKey]))
+		require.NoError(t, err)
+		return index
+	}
+
+	// The config fitting in a single part is stored as is.
+	small := []byte("version: dev\n")
+	require.NoError(t, c.writeFilterConfigBundle(t.Context(), "cfg-gw", "cfg-ns", "ns", small, "uuid-1"))
+	index := readIndex()
+	require.Empty(t, index.Encoding)
+	require.Len(t, index.Parts, 1)
+
+	// The large config fits in a single part after the compression, as the configs of many routes are repetitive.
+	large := []byte(strings.Repeat("x", filterConfigBundlePartSizeBytes*(maxFilterConfigBundleSlots+1)))
+	require.NoError(t, c.writeFilterConfigBundle(t.Context(), "cfg-gw", "cfg-ns", "ns", large, "uuid-2"))
+	index = readIndex()
+	require.Equal(t, filterapi.ConfigBundleEncodingGzip, index.Encoding)
+	require.Len(t, index.Parts, 1)
+	part, err := kube.CoreV1().Secrets("ns").Get(t.Context(), index.Parts[0].Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, large, requireGunzip(t, part.Data[FilterConfigBundlePartKey]))
+}
+
+func randomBytes(n int) []byte {
+	r := rand.New(rand.NewPCG(1, 2)) //nolint:gosec
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte(r.Uint32())
+	}
+	return b
+}
+
+func requireGunzip(t *testing.T, b []byte) []byte {
+	t.Helper()
+	r, err := gzip.NewReader(bytes.NewReader(b))
+	require.NoError(t, err)
+	out, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return out
+}
+
 func Test_mcpConfig_ToolSelectorExclude(t *testing.T) {
 	mcpRoutes := []aigv1b1.MCPRoute{
 		{
@@ -2796,6 +3462,130 @@ func Test_bodyMutationToFilterAPI(t *testing.T) {
 	}
 }
 
+func Test_stripVLLMExtensions(t *testing.T) {
+	allFields := []string{"guided_json", "guided_regex", "guided_choice", "guided_grammar", "best_of", "use_beam_search"}
+	tests := []struct {
+		name     string
+		input    *filterapi.HTTPBodyMutation
+		schema   aigv1b1.APISchema
+		expected *filterapi.HTTPBodyMutation
+	}{
+		{
+			name:     "nil mutation",
+			schema:   aigv1b1.APISchemaOpenAI,
+			expected: &filterapi.HTTPBodyMutation{Remove: allFields},
+		},
+		{
+			name: "merged with the existing mutation",
+			input: &filterapi.HTTPBodyMutation{
+				Set:    []filterapi.HTTPBodyField{{Path: "service_tier", Value: "\"scale\""}},
+				Remove: []string{"internal_flag", "best_of"},
+			},
+			schema: aigv1b1.APISchemaAzureOpenAI,
+			expected: &filterapi.HTTPBodyMutation{
+				Set:    []filterapi.HTTPBodyField{{Path: "service_tier", Value: "\"scale\""}},
+				Remove: []string{"internal_flag", "best_of", "guided_json", "guided_regex", "guided_choice", "guided_grammar", "use_beam_search"},
+			},
+		},
+		{
+			name:     "translated schema",
+			schema:   aigv1b1.APISchemaGCPVertexAI,
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := stripVLLMExtensions(tt.input, tt.schema)
+			if d := cmp.Diff(tt.expected, result); d != "" {
+				t.Errorf("stripVLLMExtensions() mismatch (-expected +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func Test_streamConcurrencyLimitsToFilterAPI(t *testing.T) {
+	require.Nil(t, streamConcurrencyLimitsToFilterAPI(nil))
+	require.Equal(t, []filterapi.StreamConcurrencyLimit{
+		{Headers: []string{"x-user-id"}, MaxConcurrentStreams: 2, RetryAfterSeconds: 1},
+		{Headers: []string{"x-tenant-id", "x-user-id"}, MaxConcurrentStreams: 10, RetryAfterSeconds: 2},
+	}, streamConcurrencyLimitsToFilterAPI([]aigv1b1.StreamConcurrencyLimit{
+		{Headers: []gwapiv1.HeaderName{"X-User-Id"}, MaxConcurrentStreams: 2},
+		{
+			Headers:              []gwapiv1.HeaderName{"x-tenant-id", "x-user-id"},
+			MaxConcurrentStreams: 10,
+			RetryAfter:           ptr.To(gwapiv1.Duration("1500ms")),
+		},
+	}))
+}
+
+func Test_fallbackResponseToFilterAPI(t *testing.T) {
+	require.Nil(t, fallbackResponseToFilterAPI(nil))
+	require.Equal(t, &filterapi.FallbackResponse{
+		StatusCodes: []int{500, 502},
+		Type:        filterapi.FallbackResponseTypeMessage,
+		Message:     "sorry",
+	}, fallbackResponseToFilterAPI(&aigv1b1.FallbackResponse{
+		StatusCodes: []int32{500, 502},
+		Type:        aigv1b1.FallbackResponseTypeMessage,
+		Message:     "sorry",
+	}))
+}
+
+func Test_loadReportingToFilterAPI(t *testing.T) {
+	require.Nil(t, loadReportingToFilterAPI(nil))
+	require.Equal(t, &filterapi.BackendLoadReporting{
+		QueueDepthHeader:      "x-queue-depth",
+		LoadHeader:            "x-inference-load",
+		MaxQueueDepth:         8,
+		ReportTTLMilliseconds: 10000,
+	}, loadReportingToFilterAPI(&aigv1b1.BackendLoadReporting{MaxQueueDepth: ptr.To[int32](8)}))
+	require.Equal(t, &filterapi.BackendLoadReporting{
+		QueueDepthHeader:      "x-vllm-waiting",
+		LoadHeader:            "x-kv-cache-usage",
+		MaxLoadPercent:        90,
+		ReportTTLMilliseconds: 500,
+	}, loadReportingToFilterAPI(&aigv1b1.BackendLoadReporting{
+		QueueDepthHeader: "X-VLLM-Waiting",
+		LoadHeader:       "X-KV-Cache-Usage",
+		MaxLoadPercent:   ptr.To[int32](90),
+		ReportTTL:        ptr.To(gwapiv1.Duration("500ms")),
+	}))
+}
+
+func Test_extraBodyToFilterAPI(t *testing.T) {
+	require.Nil(t, extraBodyToFilterAPI(nil))
+	require.Equal(t, &filterapi.ExtraBody{AllowedFields: []string{"top_k"}, Policy: filterapi.ExtraBodyPolicyStrip},
+		extraBodyToFilterAPI(&aigv1b1.BackendExtraBody{AllowedFields: []string{"top_k"}}))
+	require.Equal(t, &filterapi.ExtraBody{Policy: filterapi.ExtraBodyPolicyReject},
+		extraBodyToFilterAPI(&aigv1b1.BackendExtraBody{Policy: aigv1b1.ExtraBodyPolicyReject}))
+}
+
+func Test_piiTokenizationToFilterAPI(t *testing.T) {
+	t.Run("nil", func(t *testing.T) {
+		result, err := piiTokenizationToFilterAPI(nil)
+		require.NoError(t, err)
+		require.Nil(t, result)
+	})
+	t.Run("ok", func(t *testing.T) {
+		result, err := piiTokenizationToFilterAPI(&aigv1b1.PIITokenization{
+			Detectors:      []aigv1b1.PIIDetectorType{aigv1b1.PIIDetectorTypeEmail, aigv1b1.PIIDetectorTypeCreditCard},
+			CustomPatterns: []aigv1b1.PIIPattern{{Name: "employee", Regex: `E-\d{6}`}},
+		})
+		require.NoError(t, err)
+		require.Equal(t, &filterapi.PIITokenization{
+			Detectors:      []string{"Email", "CreditCard"},
+			CustomPatterns: []filterapi.PIIPattern{{Name: "employee", Regex: `E-\d{6}`}},
+		}, result)
+	})
+	t.Run("invalid regex", func(t *testing.T) {
+		_, err := piiTokenizationToFilterAPI(&aigv1b1.PIITokenization{
+			CustomPatterns: []aigv1b1.PIIPattern{{Name: "bad", Regex: "("}},
+		})
+		require.ErrorContains(t, err, "invalid regex for PII pattern bad")
+	})
+}
+
 // TestGatewayController_reconcileFilterConfigSecret_GlobalDefaults tests that
 // global LLM request costs from GatewayConfig are properly included in the filter config
 // when no routes override them.
@@ -2911,7 +3701,7 @@ func TestGatewayController_reconcileFilterConfigSecret_GlobalDefaults(t *testing
 			require.NoError(t, err)
 
 			const someNamespace = "some-namespace"
-			effective, err := c.reconcileFilterConfigSecret(t.Context(), "gw", gwNamespace, someNamespace, tt.routes, nil, "test-uuid", tt.globalCosts)
+			effective, err := c.reconcileFilterConfigSecret(t.Context(), "gw", gwNamespace, someNamespace, tt.routes, nil, "test-uuid", &aigv1b1.GatewayConfigSpec{GlobalLLMRequestCosts: tt.globalCosts})
 			require.NoError(t, err)
 			require.True(t, effective)
 
@@ -2931,6 +3721,200 @@ func TestGatewayController_reconcileFilterConfigSecret_GlobalDefaults(t *testing
 	}
 }
 
+func Test_aigwExperimentToFilterAPI(t *testing.T) {
+	got := aigwExperimentToFilterAPI(&aigv1b1.AIGatewayRouteExperiment{
+		Name:         "gpt-5-rollout",
+		StickyHeader: "X-User-ID",
+		Variants: []aigv1b1.AIGatewayRouteExperimentVariant{
+			{Name: "control", Percentage: 90},
+			{Name: "treatment", Percentage: 10, ModelNameOverride: "gpt-5"},
+		},
+	}, "default/route")
+	require.Equal(t, filterapi.Experiment{
+		RouteName:    "default/route",
+		Name:         "gpt-5-rollout",
+		StickyHeader: "x-user-id",
+		Variants: []filterapi.ExperimentVariant{
+			{Name: "control", Percentage: 90},
+			{Name: "treatment", Percentage: 10, ModelNameOverride: "gpt-5"},
+		},
+	}, got)
+}
+
+func Test_aigwParameterOverridesToFilterAPI(t *testing.T) {
+	got, err := aigwParameterOverridesToFilterAPI(&aigv1b1.AIGatewayRouteParameterOverrides{
+		Temperature: &aigv1b1.AIGatewayRouteTemperatureBounds{Max: "1.5"},
+		MaxTokens:   &aigv1b1.AIGatewayRouteMaxTokensBounds{Max: 4096},
+	}, "default/route")
+	require.NoError(t, err)
+	require.Equal(t, filterapi.ParameterOverrides{
+		RouteName:   "default/route",
+		Temperature: &filterapi.TemperatureBounds{Min: 0, Max: 1.5},
+		MaxTokens:   &filterapi.MaxTokensBounds{Min: 1, Max: 4096},
+	}, got)
+
+	got, err = aigwParameterOverridesToFilterAPI(&aigv1b1.AIGatewayRouteParameterOverrides{
+		Temperature: &aigv1b1.AIGatewayRouteTemperatureBounds{Min: "0.2", Max: "0.8"},
+	}, "default/route")
+	require.NoError(t, err)
+	require.Equal(t, &filterapi.TemperatureBounds{Min: 0.2, Max: 0.8}, got.Temperature)
+	require.Nil(t, got.MaxTokens)
+
+	_, err = aigwParameterOverridesToFilterAPI(&aigv1b1.AIGatewayRouteParameterOverrides{
+		Temperature: &aigv1b1.AIGatewayRouteTemperatureBounds{Max: "high"},
+	}, "default/route")
+	require.ErrorContains(t, err, `invalid temperature max "high"`)
+}
+
+func Test_aigwHistoryPolicyToFilterAPI(t *testing.T) {
+	require.Equal(t, filterapi.HistoryPolicy{
+		RouteName: "default/route",
+		MaxTurns:  20,
+		Strategy:  filterapi.HistoryElisionStrategyTruncate,
+	}, aigwHistoryPolicyToFilterAPI(&aigv1b1.AIGatewayRouteHistoryPolicy{MaxTurns: ptr.To[int32](20)}, "default/route"))
+
+	require.Equal(t, filterapi.HistoryPolicy{
+		RouteName:      "default/route",
+		MaxInputTokens: 8000,
+		Strategy:       filterapi.HistoryElisionStrategySummarize,
+		Summarization: &filterapi.HistorySummarization{
+			URL:                 "http://summarizer.default.svc/v1/chat/completions",
+			Model:               "gpt-4o-mini",
+			TimeoutMilliseconds: 5000,
+		},
+	}, aigwHistoryPolicyToFilterAPI(&aigv1b1.AIGatewayRouteHistoryPolicy{
+		MaxInputTokens: ptr.To[int32](8000),
+		Strategy:       aigv1b1.HistoryElisionStrategySummarize,
+		Summarization: &aigv1b1.HistorySummarization{
+			URL:   "http://summarizer.default.svc/v1/chat/completions",
+			Model: "gpt-4o-mini",
+		},
+	}, "default/route"))
+
+	got := aigwHistoryPolicyToFilterAPI(&aigv1b1.AIGatewayRouteHistoryPolicy{
+		MaxTurns:      ptr.To[int32](5),
+		Strategy:      aigv1b1.HistoryElisionStrategySummarize,
+		Summarization: &aigv1b1.HistorySummarization{URL: "http://s", Model: "m", Timeout: ptr.To(gwapiv1.Duration("1500ms"))},
+	}, "default/route")
+	require.Equal(t, 1500, got.Summarization.TimeoutMilliseconds)
+}
+
+func Test_aigwReasoningBudgetToFilterAPI(t *testing.T) {
+	require.Equal(t, filterapi.ReasoningBudget{RouteName: "default/route", MaxEffort: "medium"},
+		aigwReasoningBudgetToFilterAPI(&aigv1b1.AIGatewayRouteReasoningBudget{MaxEffort: "medium"}, "default/route"))
+	require.Equal(t, filterapi.ReasoningBudget{RouteName: "default/route", MaxReasoningTokens: 4096, HardStop: true},
+		aigwReasoningBudgetToFilterAPI(&aigv1b1.AIGatewayRouteReasoningBudget{
+			MaxReasoningTokens: ptr.To[int32](4096),
+			HardStop:           ptr.To(true),
+		}, "default/route"))
+}
+
+func Test_aigwToolCallLoopGuardToFilterAPI(t *testing.T) {
+	require.Equal(t, filterapi.ToolCallLoopGuard{RouteName: "default/route", MaxRepetitions: 3, Action: filterapi.ToolCallLoopActionReject},
+		aigwToolCallLoopGuardToFilterAPI(&aigv1b1.AIGatewayRouteToolCallLoopGuard{MaxRepetitions: 3}, "default/route"))
+	require.Equal(t, filterapi.ToolCallLoopGuard{RouteName: "default/route", MaxRepetitions: 5, Action: filterapi.ToolCallLoopActionHint},
+		aigwToolCallLoopGuardToFilterAPI(&aigv1b1.AIGatewayRouteToolCallLoopGuard{
+			MaxRepetitions: 5,
+			Action:         aigv1b1.ToolCallLoopActionHint,
+		}, "default/route"))
+}
+
+func TestGatewayController_reconcileFilterConfigSecret_BackendOverrideName(t *testing.T) {
+	fakeClient := requireNewFakeClientWithIndexes(t)
+	kube := fake2.NewClientset()
+	c := NewGatewayController(fakeClient, kube, ctrl.Log, "envoy-gateway-system",
+		"docker.io/envoyproxy/ai-gateway-extproc:latest", "info", false, nil, true)
+
+	// The backends of the same name in two namespaces are pinned by their namespace/name.
+	for _, ns := range []string{"team-a", "team-b"} {
+		require.NoError(t, fakeClient.Create(t.Context(), &aigv1b1.AIServiceBackend{
+			ObjectMeta: metav1.ObjectMeta{Name: "openai", Namespace: ns},
+			Spec: aigv1b1.AIServiceBackendSpec{
+				BackendRef: gwapiv1.BackendObjectReference{Name: "some-backend", Namespace: ptr.To[gwapiv1.Namespace](gwapiv1.Namespace(ns))},
+			},
+		}))
+	}
+	routes := []aigv1b1.AIGatewayRoute{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "pinned", Namespace: "team-a"},
+			Spec: aigv1b1.AIGatewayRouteSpec{
+				Rules: []aigv1b1.AIGatewayRouteRule{{BackendRefs: []aigv1b1.AIGatewayRouteRuleBackendRef{
+					{Name: "openai"},
+					{Name: "openai", Namespace: ptr.To[gwapiv1.Namespace]("team-b")},
+				}}},
+				BackendOverride: &aigv1b1.AIGatewayRouteBackendOverride{SecretRef: &gwapiv1.SecretObjectReference{Name: "key"}},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "unpinned", Namespace: "team-a"},
+			Spec: aigv1b1.AIGatewayRouteSpec{
+				Rules: []aigv1b1.AIGatewayRouteRule{{BackendRefs: []aigv1b1.AIGatewayRouteRuleBackendRef{{Name: "openai"}}}},
+			},
+		},
+	}
+	_, err := c.reconcileFilterConfigSecret(t.Context(), "gw", "team-a", "some-namespace", routes, nil, "foouuid", nil)
+	require.NoError(t, err)
+
+	fc := requireFilterConfigFromBundle(t, kube, "some-namespace", "gw", "team-a")
+	names := make(map[string]string, len(fc.Backends))
+	for _, b := range fc.Backends {
+		names[b.Name] = b.BackendOverrideName
+	}
+	require.Equal(t, map[string]string{
+		internalapi.PerRouteRuleRefBackendName("team-a", "openai", "pinned", 0, 0):   "team-a/openai",
+		internalapi.PerRouteRuleRefBackendName("team-a", "openai", "pinned", 0, 1):   "team-b/openai",
+		internalapi.PerRouteRuleRefBackendName("team-a", "openai", "unpinned", 0, 0): "",
+	}, names)
+}
+
+func TestGatewayController_aigwBackendOverrideToFilterAPI(t *testing.T) {
+	kube := fake2.NewClientset()
+	c := NewGatewayController(requireNewFakeClientWithIndexes(t), kube, ctrl.Log, "envoy-gateway-system",
+		"docker.io/envoyproxy/ai-gateway-extproc:latest", "info", false, nil, true)
+	_, err := kube.CoreV1().Secrets("default").Create(t.Context(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "hmac", Namespace: "default"},
+		Data:       map[string][]byte{hmacKeyInSecret: []byte("secret-key")},
+	}, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	route := &aigv1b1.AIGatewayRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "route", Namespace: "default"},
+		Spec: aigv1b1.AIGatewayRouteSpec{BackendOverride: &aigv1b1.AIGatewayRouteBackendOverride{
+			SecretRef: &gwapiv1.SecretObjectReference{Name: "hmac"},
+		}},
+	}
+	require.Equal(t, filterapi.BackendOverride{RouteName: "default/route", HMACKey: "secret-key"},
+		c.aigwBackendOverrideToFilterAPI(t.Context(), route, "default/route"))
+
+	// The override is kept without a key when the secret is missing.
+	route.Spec.BackendOverride.SecretRef.Name = "missing"
+	require.Equal(t, filterapi.BackendOverride{RouteName: "default/route"},
+		c.aigwBackendOverrideToFilterAPI(t.Context(), route, "default/route"))
+}
+
+func TestGatewayController_responseAttestationToFilterAPI(t *testing.T) {
+	kube := fake2.NewClientset()
+	c := NewGatewayController(requireNewFakeClientWithIndexes(t), kube, ctrl.Log, "envoy-gateway-system",
+		"docker.io/envoyproxy/ai-gateway-extproc:latest", "info", false, nil, true)
+	_, err := kube.CoreV1().Secrets("default").Create(t.Context(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "attestation", Namespace: "default"},
+		Data:       map[string][]byte{privateKeyInSecret: []byte("pem")},
+	}, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	require.Nil(t, c.responseAttestationToFilterAPI(t.Context(), "default", nil))
+	a := &aigv1b1.ResponseAttestation{
+		Identity:  "gw.example.com",
+		SecretRef: &gwapiv1.SecretObjectReference{Name: "attestation"},
+	}
+	require.Equal(t, &filterapi.ResponseAttestation{Identity: "gw.example.com", PrivateKey: "pem"},
+		c.responseAttestationToFilterAPI(t.Context(), "default", a))
+
+	// The responses aren't attested when the secret is missing.
+	a.SecretRef.Name = "missing"
+	require.Nil(t, c.responseAttestationToFilterAPI(t.Context(), "default", a))
+}
+
 func Test_mergeBodyMutations(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -3155,3 +4139,213 @@ func TestGatewayController_getObjectsForGatewaySameNamespace(t *testing.T) {
 	require.Len(t, pods, 1)
 	require.Len(t, deployments, 1)
 }
+
+func TestGatewayController_injectQuotaFallbackLimits(t *testing.T) {
+	route := &aigv1b1.AIGatewayRoute{ObjectMeta: metav1.ObjectMeta{Name: "route", Namespace: "ns"}}
+	qp := &aigv1a1.QuotaPolicy{Spec: aigv1a1.QuotaPolicySpec{
+		TargetRefs: []gwapiv1a2.LocalPolicyTargetReference{
+			{Group: "aigateway.envoyproxy.io", Kind: "AIServiceBackend", Name: "openai"},
+			{Group: "aigateway.envoyproxy.io", Kind: "AIServiceBackend", Name: "azure"},
+		},
+		LocalFallback: &aigv1a1.QuotaLocalFallback{Replicas: 3},
+	}}
+	pmq := &aigv1a1.PerModelQuota{ModelName: ptr.To("gpt-4o"), Quota: aigv1a1.QuotaDefinition{
+		DefaultBucket: aigv1a1.QuotaValue{Limit: 1000, Duration: "1m", Unit: aigv1a1.QuotaUnitTotalTokens},
+	}}
+
+	t.Run("no rate limit service", func(t *testing.T) {
+		c := &GatewayController{}
+		ec := &filterapi.Config{}
+		c.injectQuotaFallbackLimits(route, ec, map[string]struct{}{}, "ns/route", qp, pmq)
+		require.Nil(t, ec.QuotaFallback)
+	})
+
+	t.Run("per-pod limits", func(t *testing.T) {
+		c := &GatewayController{quotaRateLimitServiceAddr: "ratelimit.envoy-gateway-system:8081"}
+		ec := &filterapi.Config{}
+		injected := map[string]struct{}{}
+		c.injectQuotaFallbackLimits(route, ec, injected, "ns/route", qp, pmq)
+		// The same limits are not added twice.
+		c.injectQuotaFallbackLimits(route, ec, injected, "ns/route", qp, pmq)
+		require.Equal(t, &filterapi.QuotaFallback{
+			RateLimitServiceAddress: "ratelimit.envoy-gateway-system:8081",
+			Limits: []filterapi.QuotaFallbackLimit{
+				{RouteName: "ns/route", Backend: "ns/openai", Model: "gpt-4o", MetadataKey: "quota_total_tokens", Limit: 334, WindowSeconds: 60},
+				{RouteName: "ns/route", Backend: "ns/azure", Model: "gpt-4o", MetadataKey: "quota_total_tokens", Limit: 334, WindowSeconds: 60},
+			},
+		}, ec.QuotaFallback)
+	})
+
+	t.Run("no default bucket", func(t *testing.T) {
+		c := &GatewayController{quotaRateLimitServiceAddr: "ratelimit.envoy-gateway-system:8081"}
+		ec := &filterapi.Config{}
+		c.injectQuotaFallbackLimits(route, ec, map[string]struct{}{}, "ns/route", qp, &aigv1a1.PerModelQuota{ModelName: ptr.To("gpt-4o")})
+		require.Nil(t, ec.QuotaFallback)
+	})
+}
+
+func Test_ruleContextWindows(t *testing.T) {
+	rule := &aigv1b1.AIGatewayRouteRule{
+		Matches: []aigv1b1.AIGatewayRouteRuleMatch{{Headers: []gwapiv1.HTTPHeaderMatch{
+			{Name: internalapi.ModelNameHeaderKeyDefault, Value: "chat"},
+			{Name: internalapi.ModelNameHeaderKeyDefault, Value: "chat-.*", Type: ptr.To(gwapiv1.HeaderMatchRegularExpression)},
+		}}},
+		BackendRefs: []aigv1b1.AIGatewayRouteRuleBackendRef{
+			{Name: "primary", ModelNameOverride: "small-model"},
+			{Name: "fallback", ModelNameOverride: "large-model"},
+		},
+		ModelsContextWindows: []aigv1b1.AIGatewayRouteRuleModelContextWindow{
+			{Model: "chat", Tokens: 8192},
+			{Model: "small-model", Tokens: 8192},
+			{Model: "large-model", Tokens: 128000},
+			{Model: "variant-model", Tokens: 32768},
+			// The models neither matched by the rule nor sent to its backends are ignored.
+			{Model: "chat-.*", Tokens: 4096},
+			{Model: "other-model", Tokens: 4096},
+		},
+	}
+	experiment := &aigv1b1.AIGatewayRouteExperiment{Variants: []aigv1b1.AIGatewayRouteExperimentVariant{
+		{Name: "control"}, {Name: "treatment", ModelNameOverride: "variant-model"},
+	}}
+
+	require.Equal(t, []filterapi.ContextWindow{
+		{RouteName: "ns/route", Model: "chat", Tokens: 8192},
+		{RouteName: "ns/route", Model: "small-model", Tokens: 8192},
+		{RouteName: "ns/route", Model: "large-model", Tokens: 128000},
+		{RouteName: "ns/route", Model: "variant-model", Tokens: 32768},
+	}, ruleContextWindows(rule, experiment, "ns/route", internalapi.ModelNameHeaderKeyDefault))
+	require.Equal(t, []filterapi.ContextWindow{
+		{RouteName: "ns/route", Model: "chat", Tokens: 8192},
+		{RouteName: "ns/route", Model: "small-model", Tokens: 8192},
+		{RouteName: "ns/route", Model: "large-model", Tokens: 128000},
+	}, ruleContextWindows(rule, nil, "ns/route", internalapi.ModelNameHeaderKeyDefault))
+	require.Nil(t, ruleContextWindows(&aigv1b1.AIGatewayRouteRule{}, experiment, "ns/route", internalapi.ModelNameHeaderKeyDefault))
+}
+
+func Test_quotaStreamCutOff(t *testing.T) {
+	bucket := func(unit aigv1a1.QuotaUnit) aigv1a1.QuotaValue {
+		return aigv1a1.QuotaValue{Limit: 1000, Duration: "1m", Unit: unit}
+	}
+	for _, tc := range []struct {
+		name   string
+		mode   aigv1a1.QuotaPolicyMode
+		quota  aigv1a1.QuotaDefinition
+		others []aigv1a1.PerModelQuota
+		exp    bool
+		expErr string
+	}{
+		{name: "overrun", quota: aigv1a1.QuotaDefinition{DefaultBucket: bucket(aigv1a1.QuotaUnitCost)}},
+		{
+			name: "cost",
+			quota: aigv1a1.QuotaDefinition{
+				StreamingMode: aigv1a1.QuotaStreamingModeCutOff, DefaultBucket: bucket(aigv1a1.QuotaUnitCost),
+			},
+			exp: true,
+		},
+		{
+			name: "tokens",
+			quota: aigv1a1.QuotaDefinition{
+				StreamingMode: aigv1a1.QuotaStreamingModeCutOff,
+				DefaultBucket: bucket(aigv1a1.QuotaUnitOutputTokens),
+				BucketRules:   []aigv1a1.QuotaRule{{Quota: bucket(aigv1a1.QuotaUnitOutputTokens)}},
+			},
+			exp: true,
+		},
+		{
+			name: "shadow",
+			mode: aigv1a1.QuotaPolicyModeShadow,
+			quota: aigv1a1.QuotaDefinition{
+				StreamingMode: aigv1a1.QuotaStreamingModeCutOff, DefaultBucket: bucket(aigv1a1.QuotaUnitTotalTokens),
+			},
+		},
+		{
+			name: "requests",
+			quota: aigv1a1.QuotaDefinition{
+				StreamingMode: aigv1a1.QuotaStreamingModeCutOff, DefaultBucket: bucket(aigv1a1.QuotaUnitRequests),
+			},
+			expErr: "the CutOff streaming mode doesn't support the Requests unit",
+		},
+		{
+			name: "mixed units",
+			quota: aigv1a1.QuotaDefinition{
+				StreamingMode: aigv1a1.QuotaStreamingModeCutOff,
+				DefaultBucket: bucket(aigv1a1.QuotaUnitTotalTokens),
+				BucketRules:   []aigv1a1.QuotaRule{{Quota: bucket(aigv1a1.QuotaUnitInputTokens)}},
+			},
+			expErr: "the CutOff streaming mode requires all the buckets of the model to count the same unit, got TotalTokens and InputTokens",
+		},
+		{
+			name: "requests of the model in another quota",
+			quota: aigv1a1.QuotaDefinition{
+				StreamingMode: aigv1a1.QuotaStreamingModeCutOff, DefaultBucket: bucket(aigv1a1.QuotaUnitTotalTokens),
+			},
+			others: []aigv1a1.PerModelQuota{
+				{ModelName: ptr.To("gpt-4o"), Quota: aigv1a1.QuotaDefinition{DefaultBucket: bucket(aigv1a1.QuotaUnitRequests)}},
+				{ModelName: ptr.To("gpt-5"), Quota: aigv1a1.QuotaDefinition{DefaultBucket: bucket(aigv1a1.QuotaUnitInputTokens)}},
+			},
+			expErr: "the CutOff streaming mode doesn't support the Requests unit",
+		},
+		{
+			name: "other models",
+			quota: aigv1a1.QuotaDefinition{
+				StreamingMode: aigv1a1.QuotaStreamingModeCutOff, DefaultBucket: bucket(aigv1a1.QuotaUnitTotalTokens),
+			},
+			others: []aigv1a1.PerModelQuota{
+				{ModelName: ptr.To("gpt-5"), Quota: aigv1a1.QuotaDefinition{DefaultBucket: bucket(aigv1a1.QuotaUnitRequests)}},
+			},
+			exp: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			qp := &aigv1a1.QuotaPolicy{Spec: aigv1a1.QuotaPolicySpec{
+				Mode:           tc.mode,
+				PerModelQuotas: append([]aigv1a1.PerModelQuota{{ModelName: ptr.To("gpt-4o"), Quota: tc.quota}}, tc.others...),
+			}}
+			pmq := &qp.Spec.PerModelQuotas[0]
+			require.Equal(t, tc.exp, quotaStreamCutOff(qp, pmq))
+			if tc.expErr != "" {
+				require.EqualError(t, validateQuotaStreamingMode(qp, pmq), tc.expErr)
+			} else {
+				require.NoError(t, validateQuotaStreamingMode(qp, pmq))
+			}
+		})
+	}
+}
+
+func Test_injectQuotaSchedule(t *testing.T) {
+	qp := &aigv1a1.QuotaPolicy{ObjectMeta: metav1.ObjectMeta{Name: "policy", Namespace: "ns"}}
+	pmq := &aigv1a1.PerModelQuota{ModelName: ptr.To("gpt-4o"), Quota: aigv1a1.QuotaDefinition{
+		DefaultBucket: aigv1a1.QuotaValue{Limit: 1000, Duration: "1h"},
+		Schedules: []aigv1a1.QuotaSchedule{
+			{
+				Name:     "business-hours",
+				Days:     []aigv1a1.QuotaScheduleDay{aigv1a1.QuotaScheduleDayMonday, aigv1a1.QuotaScheduleDayFriday},
+				Start:    "09:00",
+				End:      "17:00",
+				TimeZone: "Europe/Paris",
+				Limit:    100,
+				Duration: "1h",
+			},
+		},
+	}}
+
+	ec := &filterapi.Config{}
+	injected := map[string]struct{}{}
+	injectQuotaSchedule(ec, injected, qp, 1, pmq)
+	// The same schedule is not added twice.
+	injectQuotaSchedule(ec, injected, qp, 1, pmq)
+	require.Equal(t, []filterapi.QuotaSchedule{
+		{
+			MetadataKey:   "quota_schedule/ns/policy/1",
+			DefaultWindow: "__default",
+			Windows: []filterapi.QuotaScheduleWindow{
+				{Name: "business-hours", Days: []string{"Monday", "Friday"}, Start: "09:00", End: "17:00", TimeZone: "Europe/Paris"},
+			},
+		},
+	}, ec.QuotaSchedules)
+
+	// Nothing is added without schedules.
+	ec = &filterapi.Config{}
+	injectQuotaSchedule(ec, injected, qp, 0, &aigv1a1.PerModelQuota{ModelName: ptr.To("gpt-4o")})
+	require.Empty(t, ec.QuotaSchedules)
+}
@@ -0,0 +1,187 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"strings"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aigv1b1 "github.com/envoyproxy/ai-gateway/api/v1beta1"
+)
+
+// generatedVersionAnnotationKey is the annotation of the generated resources that holds their version as last written
+// by the controller. See generatedResourceVersion.
+const generatedVersionAnnotationKey = "aigateway.envoyproxy.io/generated-version"
+
+// generatedResources tracks the resources generated by a route controller to detect their out-of-band edits, i.e.
+// the changes to their content made by anyone else than the controller since it last wrote them.
+//
+// The version last written by the controller is kept in the generatedVersionAnnotationKey annotation of each
+// generated resource, so the edits made while the controller is not running, or on another replica, are detected as
+// well. Only the resources found modified are kept in memory until the status of their owner is updated.
+// A nil generatedResources tracks nothing.
+type generatedResources struct {
+	mu sync.Mutex
+	// modified are the generated resources found modified since the last reconciliation of their owner, keyed by
+	// the owner.
+	modified map[client.ObjectKey][]string
+}
+
+func newGeneratedResources() *generatedResources {
+	return &generatedResources{modified: make(map[client.ObjectKey][]string)}
+}
+
+// generatedResourceVersion returns the version of obj that changes with its content. This is the hash of the spec of
+// the resources with a spec, and the hash of the data of the ConfigMaps and Secrets.
+//
+// The spec is hashed as read back from the API server, which fills in its defaults that the controller does not write.
+func generatedResourceVersion(obj client.Object) string {
+	switch o := obj.(type) {
+	case *corev1.ConfigMap:
+		return generatedDataHash(o.Data, o.BinaryData)
+	case *corev1.Secret:
+		// The StringData is only moved into the Data by the API server, so the hash is the same before and after.
+		data := maps.Clone(o.Data)
+		if data == nil {
+			data = make(map[string][]byte, len(o.StringData))
+		}
+		for k, v := range o.StringData {
+			data[k] = []byte(v)
+		}
+		return generatedDataHash(data)
+	default:
+		u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		if err != nil {
+			panic(fmt.Errorf("BUG: failed to convert the generated resource: %w", err))
+		}
+		return generatedDataHash(u["spec"])
+	}
+}
+
+func generatedDataHash(data ...any) string {
+	// Marshaling the maps sorts their keys, so the hash is stable.
+	raw, err := json.Marshal(data)
+	if err != nil {
+		panic(fmt.Errorf("BUG: failed to marshal the generated data: %w", err))
+	}
+	return shortStableHash(string(raw))
+}
+
+// setGeneratedVersion sets the version of the ConfigMap or Secret obj about to be written by the controller, whose
+// version only depends on its data.
+func setGeneratedVersion(obj client.Object) {
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string, 1)
+	}
+	annotations[generatedVersionAnnotationKey] = generatedResourceVersion(obj)
+	obj.SetAnnotations(annotations)
+}
+
+// isModifiedOutOfBand returns true if obj was modified since the controller last wrote it. The resources never
+// written by the controller with their version, such as the ones written before the upgrade, are not reported.
+func isModifiedOutOfBand(obj client.Object) bool {
+	v, ok := obj.GetAnnotations()[generatedVersionAnnotationKey]
+	return ok && v != generatedResourceVersion(obj)
+}
+
+// observe records that current, as read before being rewritten by the reconciliation of owner, was modified out of
+// band.
+func (g *generatedResources) observe(owner, current client.Object) {
+	if g == nil || !isModifiedOutOfBand(current) {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	ownerKey := client.ObjectKeyFromObject(owner)
+	g.modified[ownerKey] = append(g.modified[ownerKey],
+		fmt.Sprintf("%s %s", reflect.TypeOf(current).Elem().Name(), client.ObjectKeyFromObject(current)))
+}
+
+// written records the version of obj as just created or updated by the controller in its annotation. The defaulted
+// spec is only known after the write, so this patches the annotation of the resources with a spec. The ConfigMaps and
+// Secrets passed to setGeneratedVersion before the write are left as is.
+func (g *generatedResources) written(ctx context.Context, c client.Client, obj client.Object) error {
+	if g == nil {
+		return nil
+	}
+	version := generatedResourceVersion(obj)
+	if obj.GetAnnotations()[generatedVersionAnnotationKey] == version {
+		return nil
+	}
+	patch, err := json.Marshal(map[string]any{
+		"metadata": map[string]any{"annotations": map[string]string{generatedVersionAnnotationKey: version}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal the generated version patch: %w", err)
+	}
+	if err = c.Patch(ctx, obj, client.RawPatch(types.MergePatchType, patch)); err != nil {
+		return fmt.Errorf("failed to record the generated version of %s %s: %w",
+			reflect.TypeOf(obj).Elem().Name(), client.ObjectKeyFromObject(obj), err)
+	}
+	return nil
+}
+
+// takeModified returns and forgets the generated resources of owner found modified since its last reconciliation.
+func (g *generatedResources) takeModified(owner client.Object) []string {
+	if g == nil {
+		return nil
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	ownerKey := client.ObjectKeyFromObject(owner)
+	modified := g.modified[ownerKey]
+	delete(g.modified, ownerKey)
+	return modified
+}
+
+// forget forgets the generated resources of the deleted owner.
+func (g *generatedResources) forget(owner client.ObjectKey) {
+	if g == nil {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.modified, owner)
+}
+
+// withDegradedCondition returns the conditions with the Degraded condition of the route added when its generated
+// resources were modified out of band. The previous Degraded condition is kept until the route changes, since
+// reverting the edits triggers another reconciliation which finds nothing modified.
+func withDegradedCondition(conditions, previous []metav1.Condition, generation int64, modified []string) []metav1.Condition {
+	if len(modified) > 0 {
+		return append(conditions, newDegradedCondition(generation, modified))
+	}
+	if c := meta.FindStatusCondition(previous, aigv1b1.ConditionTypeDegraded); c != nil && c.ObservedGeneration == generation {
+		return append(conditions, *c)
+	}
+	return conditions
+}
+
+// newDegradedCondition returns the Degraded condition of the route of the generation whose generated resources were
+// modified out of band.
+func newDegradedCondition(generation int64, modified []string) metav1.Condition {
+	return metav1.Condition{
+		Type:               aigv1b1.ConditionTypeDegraded,
+		Status:             metav1.ConditionTrue,
+		Reason:             "GeneratedResourcesModified",
+		Message:            "reverted the out-of-band edits to the generated resources: " + strings.Join(modified, ", "),
+		ObservedGeneration: generation,
+		LastTransitionTime: metav1.Now(),
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package controller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	aigv1b1 "github.com/envoyproxy/ai-gateway/api/v1beta1"
+)
+
+func TestGeneratedResources(t *testing.T) {
+	c := requireNewFakeClientWithIndexes(t)
+	g := newGeneratedResources()
+	owner := &aigv1b1.AIGatewayRoute{ObjectMeta: metav1.ObjectMeta{Name: "route", Namespace: "ns"}}
+	httpRoute := &gwapiv1.HTTPRoute{ObjectMeta: metav1.ObjectMeta{Name: "route", Namespace: "ns"}}
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "route", Namespace: "ns"}, Data: map[string]string{"a": "b"}}
+	require.NoError(t, c.Create(t.Context(), httpRoute))
+
+	// The resources never written with their version by the controller are not reported.
+	g.observe(owner, httpRoute)
+	g.observe(owner, cm)
+	require.Empty(t, g.takeModified(owner))
+
+	require.NoError(t, g.written(t.Context(), c, httpRoute))
+	setGeneratedVersion(cm)
+	require.NoError(t, c.Create(t.Context(), cm))
+	require.NoError(t, g.written(t.Context(), c, cm))
+	require.NoError(t, c.Get(t.Context(), client.ObjectKeyFromObject(httpRoute), httpRoute))
+	require.Equal(t, generatedResourceVersion(httpRoute), httpRoute.Annotations[generatedVersionAnnotationKey])
+	g.observe(owner, httpRoute)
+	g.observe(owner, cm)
+	require.Empty(t, g.takeModified(owner))
+
+	// The edits of the metadata do not change the version.
+	httpRoute.Labels = map[string]string{"foo": "bar"}
+	require.NoError(t, c.Update(t.Context(), httpRoute))
+	g.observe(owner, httpRoute)
+	require.Empty(t, g.takeModified(owner))
+
+	httpRoute.Spec.Hostnames = []gwapiv1.Hostname{"example.com"}
+	require.NoError(t, c.Update(t.Context(), httpRoute))
+	cm.Data["a"] = "c"
+	g.observe(owner, httpRoute)
+	g.observe(owner, cm)
+	require.Equal(t, []string{"HTTPRoute ns/route", "ConfigMap ns/route"}, g.takeModified(owner))
+	require.Empty(t, g.takeModified(owner))
+
+	// The owner deleted before its status is updated is forgotten.
+	g.observe(owner, httpRoute)
+	g.forget(client.ObjectKeyFromObject(owner))
+	require.Empty(t, g.modified)
+
+	var nilResources *generatedResources
+	require.NoError(t, nilResources.written(t.Context(), c, httpRoute))
+	nilResources.observe(owner, httpRoute)
+	nilResources.forget(client.ObjectKeyFromObject(owner))
+	require.Empty(t, nilResources.takeModified(owner))
+}
+
+func TestGeneratedResourceVersion_secret(t *testing.T) {
+	// The StringData written by the controller is read back as the Data.
+	written := &corev1.Secret{
+		Data:       map[string][]byte{"a": []byte("old"), "b": []byte("b")},
+		StringData: map[string]string{"a": "a"},
+	}
+	read := &corev1.Secret{Data: map[string][]byte{"a": []byte("a"), "b": []byte("b")}}
+	require.Equal(t, generatedResourceVersion(read), generatedResourceVersion(written))
+
+	setGeneratedVersion(written)
+	read.Annotations = written.Annotations
+	require.False(t, isModifiedOutOfBand(read))
+	read.Data["b"] = []byte("edited")
+	require.True(t, isModifiedOutOfBand(read))
+}
+
+func TestWithDegradedCondition(t *testing.T) {
+	accepted := newConditions(aigv1b1.ConditionTypeAccepted, "ok")
+
+	require.Equal(t, accepted, withDegradedCondition(accepted, nil, 1, nil))
+
+	conditions := withDegradedCondition(accepted, nil, 1, []string{"HTTPRoute ns/route", "HTTPRouteFilter ns/filter"})
+	require.Len(t, conditions, 2)
+	degraded := conditions[1]
+	require.Equal(t, aigv1b1.ConditionTypeDegraded, degraded.Type)
+	require.Equal(t, metav1.ConditionTrue, degraded.Status)
+	require.Equal(t, int64(1), degraded.ObservedGeneration)
+	require.Equal(t, "reverted the out-of-band edits to the generated resources: HTTPRoute ns/route, HTTPRouteFilter ns/filter",
+		degraded.Message)
+
+	// The condition is kept until the route changes.
+	require.Equal(t, conditions, withDegradedCondition(accepted, conditions, 1, nil))
+	require.Equal(t, accepted, withDegradedCondition(accepted, conditions, 2, nil))
+}
@@ -0,0 +1,156 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+
+	aigv1b1 "github.com/envoyproxy/ai-gateway/api/v1beta1"
+)
+
+// ImpersonationOptions configures the service account impersonated by the controllers to write the resources they
+// generate from the AIGatewayRoutes and MCPRoutes, e.g. the HTTPRoutes, HTTPRouteFilters and the Envoy Gateway
+// policies. This allows the cluster operators to constrain what the controller is permitted to touch in the tenant
+// namespaces with the RBAC of the impersonated service account, rather than with the one of the controller.
+type ImpersonationOptions struct {
+	// ServiceAccountName is the name of the impersonated service account. Empty disables the impersonation.
+	ServiceAccountName string
+	// ServiceAccountNamespace is the namespace of the impersonated service account. Empty means the namespace of each
+	// written resource, i.e. a service account with the same name is impersonated in each tenant namespace.
+	ServiceAccountNamespace string
+}
+
+// enabled returns true if the generated resources are written with impersonation.
+func (o *ImpersonationOptions) enabled() bool { return o.ServiceAccountName != "" }
+
+// userName returns the user name of the service account impersonated to write a resource in the namespace.
+func (o *ImpersonationOptions) userName(namespace string) string {
+	if o.ServiceAccountNamespace != "" {
+		namespace = o.ServiceAccountNamespace
+	}
+	return "system:serviceaccount:" + namespace + ":" + o.ServiceAccountName
+}
+
+// impersonatingClient is a client whose writes impersonate the service account of the options. The reads, including
+// the ones from the cache, and the status updates use the identity of the controller, as do the writes of the AI
+// Gateway resources themselves, e.g. the finalizers of the routes, and of the cluster-scoped resources when a service
+// account is impersonated per namespace.
+type impersonatingClient struct {
+	client.Client
+	options ImpersonationOptions
+	// newClient creates the client impersonating the user. This is replaced in the tests.
+	newClient func(userName string) (client.Client, error)
+
+	mu      sync.Mutex
+	clients map[string]client.Client
+}
+
+// newImpersonatingClient returns c as-is if the impersonation is disabled. Otherwise, it returns c wrapped by an
+// impersonatingClient creating the impersonating clients from the config.
+func newImpersonatingClient(c client.Client, config *rest.Config, options ImpersonationOptions) client.Client {
+	if !options.enabled() {
+		return c
+	}
+	return &impersonatingClient{
+		Client:  c,
+		options: options,
+		newClient: func(userName string) (client.Client, error) {
+			impersonated := rest.CopyConfig(config)
+			impersonated.Impersonate = rest.ImpersonationConfig{UserName: userName}
+			return client.New(impersonated, client.Options{Scheme: c.Scheme(), Mapper: c.RESTMapper()})
+		},
+		clients: make(map[string]client.Client),
+	}
+}
+
+// writer returns the client writing the object.
+func (c *impersonatingClient) writer(obj client.Object) (client.Client, error) {
+	gvk, err := apiutil.GVKForObject(obj, c.Scheme())
+	if err != nil {
+		return nil, err
+	}
+	if gvk.Group == aigv1b1.GroupName {
+		return c.Client, nil
+	}
+	return c.namespaceWriter(obj.GetNamespace())
+}
+
+// namespaceWriter returns the client writing the generated resources in the namespace.
+func (c *impersonatingClient) namespaceWriter(namespace string) (client.Client, error) {
+	if namespace == "" && c.options.ServiceAccountNamespace == "" {
+		return c.Client, nil
+	}
+	userName := c.options.userName(namespace)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if w, ok := c.clients[userName]; ok {
+		return w, nil
+	}
+	w, err := c.newClient(userName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the client impersonating %s: %w", userName, err)
+	}
+	c.clients[userName] = w
+	return w, nil
+}
+
+// Create implements [client.Writer].
+func (c *impersonatingClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	w, err := c.writer(obj)
+	if err != nil {
+		return err
+	}
+	return w.Create(ctx, obj, opts...)
+}
+
+// Update implements [client.Writer].
+func (c *impersonatingClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	w, err := c.writer(obj)
+	if err != nil {
+		return err
+	}
+	return w.Update(ctx, obj, opts...)
+}
+
+// Patch implements [client.Writer].
+func (c *impersonatingClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	w, err := c.writer(obj)
+	if err != nil {
+		return err
+	}
+	return w.Patch(ctx, obj, patch, opts...)
+}
+
+// Delete implements [client.Writer].
+func (c *impersonatingClient) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	w, err := c.writer(obj)
+	if err != nil {
+		return err
+	}
+	return w.Delete(ctx, obj, opts...)
+}
+
+// DeleteAllOf implements [client.Writer].
+func (c *impersonatingClient) DeleteAllOf(ctx context.Context, obj client.Object, opts ...client.DeleteAllOfOption) error {
+	gvk, err := apiutil.GVKForObject(obj, c.Scheme())
+	if err != nil {
+		return err
+	}
+	w := c.Client
+	if gvk.Group != aigv1b1.GroupName {
+		w, err = c.namespaceWriter((&client.DeleteAllOfOptions{}).ApplyOptions(opts).Namespace)
+	}
+	if err != nil {
+		return err
+	}
+	return w.DeleteAllOf(ctx, obj, opts...)
+}
@@ -0,0 +1,103 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package controller
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	aigv1b1 "github.com/envoyproxy/ai-gateway/api/v1beta1"
+)
+
+func Test_newImpersonatingClient(t *testing.T) {
+	c := requireNewFakeClientWithIndexes(t)
+	require.Same(t, c, newImpersonatingClient(c, &rest.Config{}, ImpersonationOptions{}))
+	require.IsType(t, &impersonatingClient{}, newImpersonatingClient(c, &rest.Config{}, ImpersonationOptions{ServiceAccountName: "sa"}))
+}
+
+func TestImpersonatingClient(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		options ImpersonationOptions
+		// expUsers are the users writing the HTTPRoutes in ns1 and ns2.
+		expUsers [2]string
+	}{
+		{
+			name:     "per namespace",
+			options:  ImpersonationOptions{ServiceAccountName: "generator"},
+			expUsers: [2]string{"system:serviceaccount:ns1:generator", "system:serviceaccount:ns2:generator"},
+		},
+		{
+			name:     "fixed",
+			options:  ImpersonationOptions{ServiceAccountName: "generator", ServiceAccountNamespace: "system"},
+			expUsers: [2]string{"system:serviceaccount:system:generator", "system:serviceaccount:system:generator"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			base := requireNewFakeClientWithIndexes(t)
+			impersonated := map[string]client.Client{}
+			c := &impersonatingClient{
+				Client:  base,
+				options: tc.options,
+				newClient: func(userName string) (client.Client, error) {
+					w := fakeclient.NewClientBuilder().WithScheme(Scheme).Build()
+					impersonated[userName] = w
+					return w, nil
+				},
+				clients: map[string]client.Client{},
+			}
+
+			for i, ns := range []string{"ns1", "ns2"} {
+				route := &gwapiv1.HTTPRoute{ObjectMeta: metav1.ObjectMeta{Name: "route", Namespace: ns}}
+				require.NoError(t, c.Create(t.Context(), route))
+				route.Labels = map[string]string{"updated": "true"}
+				require.NoError(t, c.Update(t.Context(), route))
+
+				var got gwapiv1.HTTPRoute
+				require.NoError(t, impersonated[tc.expUsers[i]].Get(t.Context(), client.ObjectKeyFromObject(route), &got))
+				require.Equal(t, "true", got.Labels["updated"])
+				// The reads use the identity of the controller.
+				require.Error(t, c.Get(t.Context(), client.ObjectKeyFromObject(route), &got))
+
+				require.NoError(t, c.Delete(t.Context(), route))
+				require.Error(t, impersonated[tc.expUsers[i]].Get(t.Context(), client.ObjectKeyFromObject(route), &got))
+			}
+			require.Len(t, impersonated, len(map[string]struct{}{tc.expUsers[0]: {}, tc.expUsers[1]: {}}))
+
+			// The AI Gateway resources are written with the identity of the controller.
+			aiGatewayRoute := &aigv1b1.AIGatewayRoute{ObjectMeta: metav1.ObjectMeta{Name: "route", Namespace: "ns1"}}
+			require.NoError(t, c.Create(t.Context(), aiGatewayRoute))
+			require.NoError(t, base.Get(t.Context(), client.ObjectKeyFromObject(aiGatewayRoute), aiGatewayRoute))
+		})
+	}
+
+	t.Run("cluster-scoped", func(t *testing.T) {
+		base := requireNewFakeClientWithIndexes(t)
+		c := &impersonatingClient{Client: base, options: ImpersonationOptions{ServiceAccountName: "generator"}}
+		namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns"}}
+		require.NoError(t, c.Create(t.Context(), namespace))
+		require.NoError(t, base.Get(t.Context(), client.ObjectKeyFromObject(namespace), namespace))
+	})
+
+	t.Run("client error", func(t *testing.T) {
+		c := &impersonatingClient{
+			Client:    requireNewFakeClientWithIndexes(t),
+			options:   ImpersonationOptions{ServiceAccountName: "generator"},
+			newClient: func(string) (client.Client, error) { return nil, errors.New("boom") },
+			clients:   map[string]client.Client{},
+		}
+		err := c.Create(t.Context(), &gwapiv1.HTTPRoute{ObjectMeta: metav1.ObjectMeta{Name: "route", Namespace: "ns1"}})
+		require.EqualError(t, err, "failed to create the client impersonating system:serviceaccount:ns1:generator: boom")
+	})
+}
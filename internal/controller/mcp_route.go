@@ -45,6 +45,8 @@ type MCPRouteController struct {
 	logger logr.Logger
 	// gatewayEventChan is a channel to send events to the gateway controller.
 	gatewayEventChan chan event.GenericEvent
+	// generated tracks the HTTPRoutes, HTTPRouteFilters and policies generated from the routes.
+	generated *generatedResources
 }
 
 // NewMCPRouteController creates a new reconcile.TypedReconciler[reconcile.Request] for the MCPRoute resource.
@@ -57,6 +59,7 @@ func NewMCPRouteController(
 		kube:             kube,
 		logger:           logger,
 		gatewayEventChan: gatewayEventChan,
+		generated:        newGeneratedResources(),
 	}
 }
 
@@ -69,6 +72,7 @@ func (c *MCPRouteController) Reconcile(ctx context.Context, req reconcile.Reques
 		if client.IgnoreNotFound(err) == nil {
 			c.logger.Info("Deleting MCPRoute",
 				"namespace", req.Namespace, "name", req.Name)
+			c.generated.forget(req.NamespacedName)
 			return ctrl.Result{}, nil
 		}
 		return ctrl.Result{}, err
@@ -110,6 +114,9 @@ func (c *MCPRouteController) syncMCPRoute(ctx context.Context, mcpRoute *aigv1b1
 	if err != nil {
 		return fmt.Errorf("failed to get or create HTTPRoute: %w", err)
 	}
+	if existing {
+		c.generated.observe(mcpRoute, mainHTTPRoute)
+	}
 	if err = c.newMainHTTPRoute(mainHTTPRoute, mcpRoute, sharedBackendName); err != nil {
 		return fmt.Errorf("failed to construct a new HTTPRoute: %w", err)
 	}
@@ -133,7 +140,9 @@ func (c *MCPRouteController) syncMCPRoute(ctx context.Context, mcpRoute *aigv1b1
 		ref := &mcpRoute.Spec.BackendRefs[i]
 		name := mcpPerBackendRefHTTPRouteName(mcpRoute.Name, ref.Name)
 		httpRoute, existing := existingPerBackendRoutes[name]
-		if !existing {
+		if existing {
+			c.generated.observe(mcpRoute, httpRoute)
+		} else {
 			httpRoute, err = c.newHTTPRoute(mcpRoute, name)
 			if err != nil {
 				return fmt.Errorf("failed to construct a new HTTPRoute for backend %s: %w", ref.Name, err)
@@ -180,7 +189,7 @@ func (c *MCPRouteController) createOrUpdateHTTPRoute(ctx context.Context, httpRo
 			return fmt.Errorf("failed to create HTTPRoute: %w", err)
 		}
 	}
-	return nil
+	return c.generated.written(ctx, c.client, httpRoute)
 }
 
 func (c *MCPRouteController) getOrNewHTTPRouteRoute(ctx context.Context, mcpRoute *aigv1b1.MCPRoute, routeName string) (*gwapiv1.HTTPRoute, bool, error) {
@@ -507,6 +516,7 @@ func (c *MCPRouteController) syncGateway(ctx context.Context, namespace, name st
 
 // updateMCPRouteStatus updates the status of the MCPRoute.
 func (c *MCPRouteController) updateMCPRouteStatus(ctx context.Context, route *aigv1b1.MCPRoute, conditionType string, message string) {
+	modified := c.generated.takeModified(route)
 	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
 		if err := c.client.Get(ctx, client.ObjectKey{Name: route.Name, Namespace: route.Namespace}, route); err != nil {
 			if apierrors.IsNotFound(err) {
@@ -515,7 +525,8 @@ func (c *MCPRouteController) updateMCPRouteStatus(ctx context.Context, route *ai
 			return err
 		}
 
-		route.Status.Conditions = newConditions(conditionType, message)
+		route.Status.Conditions = withDegradedCondition(newConditions(conditionType, message), route.Status.Conditions,
+			route.Generation, modified)
 		return c.client.Status().Update(ctx, route)
 	})
 	if err != nil {
@@ -829,7 +840,11 @@ func (c *MCPRouteController) ensureMCPBackendRefHTTPFilter(ctx context.Context,
 		if err = c.client.Create(ctx, filter); err != nil {
 			return fmt.Errorf("failed to create HTTPRouteFilter: %w", err)
 		}
+		if err = c.generated.written(ctx, c.client, filter); err != nil {
+			return err
+		}
 	} else {
+		c.generated.observe(mcpRoute, &existingFilter)
 		previousCredentialSecretName := ""
 		if existingFilter.Spec.CredentialInjection != nil {
 			previousCredentialSecretName = string(existingFilter.Spec.CredentialInjection.Credential.ValueRef.Name)
@@ -848,6 +863,9 @@ func (c *MCPRouteController) ensureMCPBackendRefHTTPFilter(ctx context.Context,
 		if err = c.client.Update(ctx, &existingFilter); err != nil {
 			return fmt.Errorf("failed to update HTTPRouteFilter: %w", err)
 		}
+		if err = c.generated.written(ctx, c.client, &existingFilter); err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -882,6 +900,7 @@ func (c *MCPRouteController) ensureCredentialSecret(ctx context.Context, secretN
 	if setRefErr != nil {
 		return fmt.Errorf("failed to set controller reference for credential secret: %w", setRefErr)
 	}
+	setGeneratedVersion(desired)
 
 	existing, err := c.kube.CoreV1().Secrets(mcpRoute.Namespace).Get(ctx, secretName, metav1.GetOptions{})
 	if err != nil {
@@ -895,9 +914,16 @@ func (c *MCPRouteController) ensureCredentialSecret(ctx context.Context, secretN
 		return nil
 	}
 
-	// Update if the credential value changed.
-	if string(existing.Data[egv1a1.InjectedCredentialKey]) != credentialValue {
+	// Update if the credential value changed, or to record the version of the secret written before the upgrade.
+	version := desired.Annotations[generatedVersionAnnotationKey]
+	if string(existing.Data[egv1a1.InjectedCredentialKey]) != credentialValue ||
+		existing.Annotations[generatedVersionAnnotationKey] != version {
+		c.generated.observe(mcpRoute, existing)
 		existing.Data = desired.Data
+		if existing.Annotations == nil {
+			existing.Annotations = make(map[string]string, 1)
+		}
+		existing.Annotations[generatedVersionAnnotationKey] = version
 		c.logger.Info("Updating credential secret", "namespace", mcpRoute.Namespace, "name", secretName)
 		if _, err = c.kube.CoreV1().Secrets(mcpRoute.Namespace).Update(ctx, existing, metav1.UpdateOptions{}); err != nil {
 			return fmt.Errorf("failed to update credential secret: %w", err)
@@ -88,6 +88,9 @@ func (c *MCPRouteController) ensureSecurityPolicy(ctx context.Context, mcpRoute
 	securityPolicyName := internalapi.MCPGeneratedResourceCommonPrefix + mcpRoute.Name
 	err := c.client.Get(ctx, client.ObjectKey{Name: securityPolicyName, Namespace: mcpRoute.Namespace}, &securityPolicy)
 	existingPolicy := err == nil
+	if existingPolicy {
+		c.generated.observe(mcpRoute, &securityPolicy)
+	}
 
 	if apierrors.IsNotFound(err) {
 		// SecurityPolicy doesn't exist, create it.
@@ -194,8 +197,7 @@ func (c *MCPRouteController) ensureSecurityPolicy(ctx context.Context, mcpRoute
 			return fmt.Errorf("failed to create SecurityPolicy: %w", err)
 		}
 	}
-
-	return nil
+	return c.generated.written(ctx, c.client, &securityPolicy)
 }
 
 // ensureOAuthProtectedResourceMetadataBTP ensures that the BackendTrafficPolicy resource exists with response override for WWW-Authenticate header.
@@ -204,6 +206,9 @@ func (c *MCPRouteController) ensureOAuthProtectedResourceMetadataBTP(ctx context
 	backendTrafficPolicyName := oauthProtectedResourceMetadataName(mcpRoute.Name)
 	err := c.client.Get(ctx, client.ObjectKey{Name: backendTrafficPolicyName, Namespace: mcpRoute.Namespace}, &backendTrafficPolicy)
 	existingPolicy := err == nil
+	if existingPolicy {
+		c.generated.observe(mcpRoute, &backendTrafficPolicy)
+	}
 
 	if apierrors.IsNotFound(err) {
 		// BackendTrafficPolicy doesn't exist, create it.
@@ -278,8 +283,7 @@ func (c *MCPRouteController) ensureOAuthProtectedResourceMetadataBTP(ctx context
 			return fmt.Errorf("failed to create BackendTrafficPolicy: %w", err)
 		}
 	}
-
-	return nil
+	return c.generated.written(ctx, c.client, &backendTrafficPolicy)
 }
 
 // buildResourceMetadataURL constructs the OAuth protected resource metadata URL using the resource identifier.
@@ -347,6 +351,9 @@ func (c *MCPRouteController) ensureOAuthProtectedResourceMetadataHRF(ctx context
 	httpRouteFilterName := oauthProtectedResourceMetadataName(mcpRoute.Name)
 	err := c.client.Get(ctx, client.ObjectKey{Name: httpRouteFilterName, Namespace: mcpRoute.Namespace}, &httpRouteFilter)
 	existingFilter := err == nil
+	if existingFilter {
+		c.generated.observe(mcpRoute, &httpRouteFilter)
+	}
 
 	if apierrors.IsNotFound(err) {
 		// HTTPRouteFilter doesn't exist, create it.
@@ -392,8 +399,7 @@ func (c *MCPRouteController) ensureOAuthProtectedResourceMetadataHRF(ctx context
 			return fmt.Errorf("failed to create HTTPRouteFilter: %w", err)
 		}
 	}
-
-	return nil
+	return c.generated.written(ctx, c.client, &httpRouteFilter)
 }
 
 // ensureCORSHeaders ensures that the HTTPHeaderFilter resource exists with CORS headers.
@@ -418,6 +424,9 @@ func (c *MCPRouteController) ensureOAuthAuthServerMetadataHRF(ctx context.Contex
 	authServerFilterName := oauthAuthServerMetadataFilterName(mcpRoute.Name)
 	err := c.client.Get(ctx, client.ObjectKey{Name: authServerFilterName, Namespace: mcpRoute.Namespace}, &httpRouteFilter)
 	existingFilter := err == nil
+	if existingFilter {
+		c.generated.observe(mcpRoute, &httpRouteFilter)
+	}
 
 	if apierrors.IsNotFound(err) {
 		// HTTPRouteFilter doesn't exist, create it.
@@ -463,8 +472,7 @@ func (c *MCPRouteController) ensureOAuthAuthServerMetadataHRF(ctx context.Contex
 			return fmt.Errorf("failed to create HTTPRouteFilter: %w", err)
 		}
 	}
-
-	return nil
+	return c.generated.written(ctx, c.client, &httpRouteFilter)
 }
 
 // buildOAuthProtectedResourceMetadataJSON constructs the OAuth protected resource metadata JSON response.
@@ -148,6 +148,21 @@ func TestMCPRouteController_Reconcile(t *testing.T) {
 		require.Equal(t, route.Spec.ParentRefs, httpRoute.Spec.ParentRefs)
 	}
 
+	// The out-of-band edits to the generated HTTPRoutes are reverted and reported.
+	mainHTTPRoute.Spec.Rules[0].Matches[0].Path.Value = ptr.To("/edited")
+	require.NoError(t, fakeClient.Update(t.Context(), &mainHTTPRoute))
+	_, err = c.Reconcile(t.Context(), reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "myroute"}})
+	require.NoError(t, err)
+	err = fakeClient.Get(t.Context(), client.ObjectKey{Name: internalapi.MCPMainHTTPRoutePrefix + "myroute", Namespace: "default"}, &mainHTTPRoute)
+	require.NoError(t, err)
+	require.Equal(t, "/mcp", *mainHTTPRoute.Spec.Rules[0].Matches[0].Path.Value)
+	err = fakeClient.Get(t.Context(), types.NamespacedName{Namespace: "default", Name: "myroute"}, &current)
+	require.NoError(t, err)
+	require.Len(t, current.Status.Conditions, 2)
+	require.Equal(t, aigv1b1.ConditionTypeDegraded, current.Status.Conditions[1].Type)
+	require.Equal(t, "reverted the out-of-band edits to the generated resources: HTTPRoute default/"+
+		internalapi.MCPMainHTTPRoutePrefix+"myroute", current.Status.Conditions[1].Message)
+
 	// Let's update the route to remove one backend and change path.
 	current.Spec.BackendRefs = current.Spec.BackendRefs[:1]
 	current.Spec.Path = ptr.To("/custom/")
@@ -0,0 +1,212 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/priorityqueue"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// The results of a reconciliation recorded by reconcileDuration.
+const (
+	reconcileResultSuccess = "success"
+	reconcileResultRequeue = "requeue"
+	reconcileResultError   = "error"
+)
+
+var (
+	// reconcileDuration records the duration of the reconciliations per CRD kind and result. The observations
+	// carry the trace ID of the reconciliation span as an exemplar when the span is sampled.
+	reconcileDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "ai_gateway_controller_reconcile_duration_seconds",
+		Help:    "Duration of the reconciliations of the AI Gateway resources in seconds.",
+		Buckets: prometheus.ExponentialBuckets(0.001, 2, 16),
+	}, []string{"kind", "result"})
+
+	// reconcileErrors counts the failed reconciliations per CRD kind and failure reason.
+	reconcileErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "ai_gateway_controller_reconcile_errors_total",
+		Help: "Total number of failed reconciliations of the AI Gateway resources.",
+	}, []string{"kind", "reason"})
+
+	// filterConfigSizeBytes reports the size of the filter config of each Gateway, and filterConfigStoredSizeBytes
+	// the size stored in the Secrets after the compression. They tell how close the config is to the size limit.
+	filterConfigSizeBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "ai_gateway_controller_filter_config_size_bytes",
+		Help: "Size of the filter config of the Gateway in bytes.",
+	}, []string{"gateway"})
+	filterConfigStoredSizeBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "ai_gateway_controller_filter_config_stored_size_bytes",
+		Help: "Size of the filter config of the Gateway stored in the Secrets in bytes, after the compression.",
+	}, []string{"gateway"})
+
+	// queueDepths reports the number of the requests waiting in the work queue of each CRD kind.
+	queueDepths = &queueDepthCollector{
+		desc: prometheus.NewDesc("ai_gateway_controller_queue_depth",
+			"Number of the AI Gateway resources waiting to be reconciled.", []string{"kind"}, nil),
+		queues: make(map[string]func() int),
+	}
+)
+
+func init() {
+	ctrlmetrics.Registry.MustRegister(reconcileDuration, reconcileErrors, queueDepths,
+		filterConfigSizeBytes, filterConfigStoredSizeBytes)
+}
+
+// instrumentedReconciler wraps a reconciler to record the metrics and the trace span of each reconciliation,
+// labeled by the kind of the reconciled CRD.
+//
+// This complements the metrics exposed by controller-runtime, which are labeled by the controller name and
+// don't tell why the reconciliations failed.
+type instrumentedReconciler struct {
+	kind   string
+	tracer trace.Tracer
+	inner  reconcile.TypedReconciler[reconcile.Request]
+}
+
+// newInstrumentedReconciler returns the inner reconciler wrapped by an instrumentedReconciler for the given kind.
+// The spans are created with the global tracer provider.
+func newInstrumentedReconciler(kind string, inner reconcile.TypedReconciler[reconcile.Request]) reconcile.TypedReconciler[reconcile.Request] {
+	return &instrumentedReconciler{
+		kind:   kind,
+		tracer: otel.Tracer("github.com/envoyproxy/ai-gateway/internal/controller"),
+		inner:  inner,
+	}
+}
+
+// Reconcile implements [reconcile.TypedReconciler].
+func (r *instrumentedReconciler) Reconcile(ctx context.Context, req reconcile.Request) (res reconcile.Result, err error) {
+	ctx, span := r.tracer.Start(ctx, "Reconcile "+r.kind, trace.WithAttributes(
+		attribute.String("k8s.resource.kind", r.kind),
+		attribute.String("k8s.namespace.name", req.Namespace),
+		attribute.String("k8s.resource.name", req.Name),
+	))
+	defer span.End()
+
+	start := time.Now()
+	res, err = r.inner.Reconcile(ctx, req)
+	elapsed := time.Since(start).Seconds()
+
+	result := reconcileResultSuccess
+	switch {
+	case err != nil:
+		result = reconcileResultError
+		reason := reconcileErrorReason(err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, reason)
+		addWithExemplar(reconcileErrors.WithLabelValues(r.kind, reason), span.SpanContext())
+	case res.RequeueAfter > 0:
+		result = reconcileResultRequeue
+	}
+	observeWithExemplar(reconcileDuration.WithLabelValues(r.kind, result), elapsed, span.SpanContext())
+	return res, err
+}
+
+// reconcileErrorReason classifies the error returned by a reconciler into a low-cardinality reason.
+func reconcileErrorReason(err error) string {
+	switch {
+	case errors.Is(err, reconcile.TerminalError(nil)):
+		return "Terminal"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "Timeout"
+	case errors.Is(err, context.Canceled):
+		return "Canceled"
+	}
+	if reason := apierrors.ReasonForError(err); reason != "" {
+		return string(reason)
+	}
+	return "Unknown"
+}
+
+// exemplarLabels returns the exemplar labels linking to the given span, or nil if the span is not sampled.
+func exemplarLabels(sc trace.SpanContext) prometheus.Labels {
+	if !sc.IsSampled() {
+		return nil
+	}
+	return prometheus.Labels{"trace_id": sc.TraceID().String()}
+}
+
+func observeWithExemplar(o prometheus.Observer, v float64, sc trace.SpanContext) {
+	if labels := exemplarLabels(sc); labels != nil {
+		if eo, ok := o.(prometheus.ExemplarObserver); ok {
+			eo.ObserveWithExemplar(v, labels)
+			return
+		}
+	}
+	o.Observe(v)
+}
+
+func addWithExemplar(c prometheus.Counter, sc trace.SpanContext) {
+	if labels := exemplarLabels(sc); labels != nil {
+		if ea, ok := c.(prometheus.ExemplarAdder); ok {
+			ea.AddWithExemplar(1, labels)
+			return
+		}
+	}
+	c.Inc()
+}
+
+// instrumentedQueueOptions returns the controller options whose work queue reports its depth for the given kind.
+//
+// The queue is the priority queue that controller-runtime uses by default.
+func instrumentedQueueOptions(kind string, logger logr.Logger) controller.Options {
+	return controller.Options{
+		NewQueue: func(controllerName string, rateLimiter workqueue.TypedRateLimiter[reconcile.Request]) workqueue.TypedRateLimitingInterface[reconcile.Request] {
+			q := priorityqueue.New(controllerName, func(o *priorityqueue.Opts[reconcile.Request]) {
+				o.Log = logger.WithValues("controller", controllerName)
+				o.RateLimiter = rateLimiter
+			})
+			queueDepths.set(kind, q.Len)
+			return q
+		},
+	}
+}
+
+// queueDepthCollector collects the depths of the work queues per kind.
+//
+// This is a collector rather than a gauge so that the depths are read at the scrape time, and so that the queue of
+// a kind can be replaced when the controllers are restarted in the same process.
+type queueDepthCollector struct {
+	desc *prometheus.Desc
+
+	mu     sync.Mutex
+	queues map[string]func() int
+}
+
+func (c *queueDepthCollector) set(kind string, length func() int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.queues[kind] = length
+}
+
+// Describe implements [prometheus.Collector].
+func (c *queueDepthCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+// Collect implements [prometheus.Collector].
+func (c *queueDepthCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for kind, length := range c.queues {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(length()), kind)
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+type fixedResultReconciler struct {
+	res reconcile.Result
+	err error
+}
+
+func (r *fixedResultReconciler) Reconcile(context.Context, reconcile.Request) (reconcile.Result, error) {
+	return r.res, r.err
+}
+
+func TestReconcileErrorReason(t *testing.T) {
+	for _, tc := range []struct {
+		err    error
+		expect string
+	}{
+		{err: reconcile.TerminalError(errors.New("invalid")), expect: "Terminal"},
+		{err: fmt.Errorf("wrapped: %w", context.DeadlineExceeded), expect: "Timeout"},
+		{err: context.Canceled, expect: "Canceled"},
+		{err: apierrors.NewConflict(schema.GroupResource{Resource: "aigatewayroutes"}, "route", errors.New("conflict")), expect: "Conflict"},
+		{err: fmt.Errorf("failed to get: %w", apierrors.NewNotFound(schema.GroupResource{Resource: "secrets"}, "s")), expect: "NotFound"},
+		{err: errors.New("some error"), expect: "Unknown"},
+	} {
+		t.Run(tc.expect, func(t *testing.T) {
+			require.Equal(t, tc.expect, reconcileErrorReason(tc.err))
+		})
+	}
+}
+
+func TestInstrumentedReconciler_Reconcile(t *testing.T) {
+	const kind = "TestInstrumentedKind"
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	t.Cleanup(func() { _ = tp.Shutdown(context.Background()) })
+
+	newReconciler := func(res reconcile.Result, err error) reconcile.TypedReconciler[reconcile.Request] {
+		r := newInstrumentedReconciler(kind, &fixedResultReconciler{res: res, err: err}).(*instrumentedReconciler)
+		r.tracer = tp.Tracer("test")
+		return r
+	}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "ns", Name: "route"}}
+
+	_, err := newReconciler(ctrl.Result{}, nil).Reconcile(t.Context(), req)
+	require.NoError(t, err)
+	_, err = newReconciler(ctrl.Result{RequeueAfter: time.Minute}, nil).Reconcile(t.Context(), req)
+	require.NoError(t, err)
+	_, err = newReconciler(ctrl.Result{}, context.DeadlineExceeded).Reconcile(t.Context(), req)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	for _, result := range []string{reconcileResultSuccess, reconcileResultRequeue, reconcileResultError} {
+		require.Equal(t, uint64(1), histogramOf(t, reconcileDuration.WithLabelValues(kind, result)).GetSampleCount(), result)
+	}
+	require.Equal(t, 1.0, testutil.ToFloat64(reconcileErrors.WithLabelValues(kind, "Timeout")))
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 3)
+	require.Equal(t, "Reconcile "+kind, spans[0].Name)
+
+	// The observation of the failed reconciliation links to its span.
+	var exemplarTraceIDs []string
+	for _, b := range histogramOf(t, reconcileDuration.WithLabelValues(kind, reconcileResultError)).GetBucket() {
+		for _, l := range b.GetExemplar().GetLabel() {
+			exemplarTraceIDs = append(exemplarTraceIDs, l.GetValue())
+		}
+	}
+	require.Equal(t, []string{spans[2].SpanContext.TraceID().String()}, exemplarTraceIDs)
+}
+
+func histogramOf(t *testing.T, o prometheus.Observer) *dto.Histogram {
+	var m dto.Metric
+	require.NoError(t, o.(prometheus.Metric).Write(&m))
+	return m.GetHistogram()
+}
+
+func TestQueueDepthCollector(t *testing.T) {
+	c := &queueDepthCollector{
+		desc:   prometheus.NewDesc("test_queue_depth", "test", []string{"kind"}, nil),
+		queues: make(map[string]func() int),
+	}
+	depth := 3
+	c.set("AIGatewayRoute", func() int { return depth })
+	require.Equal(t, 3.0, testutil.ToFloat64(c))
+
+	// The depth is read at the collection time, and a restarted controller replaces the queue of its kind.
+	depth = 5
+	require.Equal(t, 5.0, testutil.ToFloat64(c))
+	c.set("AIGatewayRoute", func() int { return 1 })
+	require.Equal(t, 1.0, testutil.ToFloat64(c))
+}
@@ -0,0 +1,206 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aigv1b1 "github.com/envoyproxy/ai-gateway/api/v1beta1"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+)
+
+// migrationStatusReconciler periodically collects the comparisons of the migrated rules of the AIGatewayRoutes with
+// their migration backends from the external processors, and reports them in the status of the routes.
+type migrationStatusReconciler struct {
+	client client.Client
+	// podReader lists the pods without the cache, since the pods are not watched otherwise.
+	podReader  client.Reader
+	logger     logr.Logger
+	httpClient *http.Client
+	interval   time.Duration
+	// now and gatewayMigrationsURL are replaced in the tests.
+	now                  func() time.Time
+	gatewayMigrationsURL func(pod *corev1.Pod) string
+}
+
+// newMigrationStatusReconciler creates the runnable of the migration status reconciliation.
+func newMigrationStatusReconciler(c client.Client, podReader client.Reader, logger logr.Logger, interval time.Duration) *migrationStatusReconciler {
+	return &migrationStatusReconciler{
+		client:     c,
+		podReader:  podReader,
+		logger:     logger,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		interval:   interval,
+		now:        time.Now,
+		gatewayMigrationsURL: func(pod *corev1.Pod) string {
+			return fmt.Sprintf("http://%s/v1/migrations", net.JoinHostPort(pod.Status.PodIP, strconv.Itoa(extProcAdminPort)))
+		},
+	}
+}
+
+// Start implements [manager.Runnable].
+func (r *migrationStatusReconciler) Start(ctx context.Context) error {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		if err := r.reconcile(ctx); err != nil {
+			r.logger.Error(err, "failed to reconcile the migration status")
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// NeedLeaderElection implements [manager.LeaderElectionRunnable], so that only the leader updates the status.
+func (r *migrationStatusReconciler) NeedLeaderElection() bool { return true }
+
+// gatewayMigrationStats is the subset of the response of the migrations API of the external processors used here.
+type gatewayMigrationStats struct {
+	Route                           string  `json:"route"`
+	RuleIndex                       int     `json:"rule_index"`
+	Backend                         string  `json:"backend"`
+	SampledRequests                 int64   `json:"sampled_requests"`
+	ComparedRequests                int64   `json:"compared_requests"`
+	SimilaritySum                   float64 `json:"similarity_sum"`
+	LatencyMillisecondsSum          int64   `json:"latency_ms_sum"`
+	MigrationLatencyMillisecondsSum int64   `json:"migration_latency_ms_sum"`
+}
+
+// migrationStatsKey identifies the comparison of a rule with its migration backend across the external processors.
+type migrationStatsKey struct {
+	route     string
+	ruleIndex int
+	backend   string
+}
+
+// reconcile updates the status of the routes with a migrated rule, and clears the status of the routes that no
+// longer have one.
+func (r *migrationStatusReconciler) reconcile(ctx context.Context) error {
+	var routes aigv1b1.AIGatewayRouteList
+	if err := r.client.List(ctx, &routes); err != nil {
+		return fmt.Errorf("failed to list AIGatewayRoutes: %w", err)
+	}
+	var targets []*aigv1b1.AIGatewayRoute
+	var migrated bool
+	for i := range routes.Items {
+		route := &routes.Items[i]
+		rules := internalapi.MigrationRules(route.Spec.Rules)
+		if len(rules) == 0 && len(route.Status.Migrations) == 0 {
+			continue
+		}
+		migrated = migrated || len(rules) > 0
+		targets = append(targets, route)
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+
+	var stats map[migrationStatsKey]*gatewayMigrationStats
+	if migrated {
+		var err error
+		if stats, err = r.fetchGatewayMigrations(ctx); err != nil {
+			return err
+		}
+	}
+	now := metav1.NewTime(r.now())
+	for _, route := range targets {
+		var statuses []aigv1b1.AIGatewayRouteMigrationStatus
+		for _, rule := range internalapi.MigrationRules(route.Spec.Rules) {
+			backendName := string(rule.Rule.BackendRefs[0].Name)
+			s := stats[migrationStatsKey{
+				route:     route.Namespace + "/" + route.Name,
+				ruleIndex: rule.RuleIndex,
+				backend:   internalapi.PerRouteRuleRefBackendName(route.Namespace, backendName, route.Name, rule.HTTPRouteRuleIndex, 0),
+			}]
+			if s == nil {
+				s = &gatewayMigrationStats{}
+			}
+			status := aigv1b1.AIGatewayRouteMigrationStatus{
+				RuleIndex:        int32(rule.RuleIndex), // #nosec G115
+				BackendName:      backendName,
+				SampledRequests:  s.SampledRequests,
+				ComparedRequests: s.ComparedRequests,
+				LastUpdateTime:   now,
+			}
+			if s.ComparedRequests > 0 {
+				status.AverageSimilarityPercent = int32(math.Round(s.SimilaritySum * 100 / float64(s.ComparedRequests)))
+				status.AverageLatencyMilliseconds = s.LatencyMillisecondsSum / s.ComparedRequests
+				status.AverageMigrationLatencyMilliseconds = s.MigrationLatencyMillisecondsSum / s.ComparedRequests
+			}
+			statuses = append(statuses, status)
+		}
+		r.updateStatus(ctx, route, statuses)
+	}
+	return nil
+}
+
+// updateStatus sets the migrations in the status of the AIGatewayRoute.
+func (r *migrationStatusReconciler) updateStatus(ctx context.Context, route *aigv1b1.AIGatewayRoute, statuses []aigv1b1.AIGatewayRouteMigrationStatus) {
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if err := r.client.Get(ctx, client.ObjectKey{Name: route.Name, Namespace: route.Namespace}, route); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil
+			}
+			return err
+		}
+		route.Status.Migrations = statuses
+		return r.client.Status().Update(ctx, route)
+	})
+	if err != nil {
+		r.logger.Error(err, "failed to update the migration status of AIGatewayRoute",
+			"namespace", route.Namespace, "name", route.Name)
+	}
+}
+
+// fetchGatewayMigrations sums the comparisons served by the external processors. It fails if any of the external
+// processors fails, since the comparisons would be undercounted otherwise.
+func (r *migrationStatusReconciler) fetchGatewayMigrations(ctx context.Context) (map[migrationStatsKey]*gatewayMigrationStats, error) {
+	var pods corev1.PodList
+	if err := r.podReader.List(ctx, &pods); err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	stats := make(map[migrationStatsKey]*gatewayMigrationStats)
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Status.Phase != corev1.PodRunning || pod.Status.PodIP == "" || !hasExtProcContainer(pod) {
+			continue
+		}
+		var page []gatewayMigrationStats
+		if err := getJSON(ctx, r.httpClient, r.gatewayMigrationsURL(pod), "", &page); err != nil {
+			return nil, fmt.Errorf("failed to get the migrations of pod %s/%s: %w", pod.Namespace, pod.Name, err)
+		}
+		for _, p := range page {
+			key := migrationStatsKey{route: p.Route, ruleIndex: p.RuleIndex, backend: p.Backend}
+			s, ok := stats[key]
+			if !ok {
+				s = &gatewayMigrationStats{}
+				stats[key] = s
+			}
+			s.SampledRequests += p.SampledRequests
+			s.ComparedRequests += p.ComparedRequests
+			s.SimilaritySum += p.SimilaritySum
+			s.LatencyMillisecondsSum += p.LatencyMillisecondsSum
+			s.MigrationLatencyMillisecondsSum += p.MigrationLatencyMillisecondsSum
+		}
+	}
+	return stats, nil
+}
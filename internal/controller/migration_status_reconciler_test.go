@@ -0,0 +1,114 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aigv1b1 "github.com/envoyproxy/ai-gateway/api/v1beta1"
+)
+
+func TestMigrationStatusReconciler(t *testing.T) {
+	c := requireNewFakeClientWithIndexes(t)
+	migrated := &aigv1b1.AIGatewayRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "chat", Namespace: "default"},
+		Spec: aigv1b1.AIGatewayRouteSpec{
+			Rules: []aigv1b1.AIGatewayRouteRule{
+				{
+					BackendRefs: []aigv1b1.AIGatewayRouteRuleBackendRef{{Name: "openai"}},
+					Migration:   &aigv1b1.AIGatewayRouteRuleMigration{BackendRef: aigv1b1.AIGatewayRouteRuleBackendRef{Name: "anthropic"}},
+				},
+				{BackendRefs: []aigv1b1.AIGatewayRouteRuleBackendRef{{Name: "openai"}}},
+				{
+					BackendRefs: []aigv1b1.AIGatewayRouteRuleBackendRef{{Name: "openai"}},
+					Migration:   &aigv1b1.AIGatewayRouteRuleMigration{BackendRef: aigv1b1.AIGatewayRouteRuleBackendRef{Name: "gemini"}},
+				},
+			},
+		},
+	}
+	stale := &aigv1b1.AIGatewayRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "stale", Namespace: "default"},
+		Spec: aigv1b1.AIGatewayRouteSpec{
+			Rules: []aigv1b1.AIGatewayRouteRule{{BackendRefs: []aigv1b1.AIGatewayRouteRuleBackendRef{{Name: "openai"}}}},
+		},
+	}
+	for _, route := range []*aigv1b1.AIGatewayRoute{migrated, stale} {
+		require.NoError(t, c.Create(t.Context(), route))
+	}
+	stale.Status.Migrations = []aigv1b1.AIGatewayRouteMigrationStatus{{RuleIndex: 0, BackendName: "anthropic", SampledRequests: 1}}
+	require.NoError(t, c.Status().Update(t.Context(), stale))
+	for _, pod := range []*corev1.Pod{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "envoy-1", Namespace: "envoy-gateway-system"},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "envoy"}, {Name: extProcContainerName}}},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning, PodIP: "10.0.0.1"},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "envoy-2", Namespace: "envoy-gateway-system"},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "envoy"}, {Name: extProcContainerName}}},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning, PodIP: "10.0.0.2"},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "default"},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning, PodIP: "10.0.0.3"},
+		},
+	} {
+		require.NoError(t, c.Create(t.Context(), pod))
+	}
+
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/v1/migrations", r.URL.Path)
+		_, _ = w.Write([]byte(`[
+{"route":"default/chat","rule_index":0,"backend":"default/anthropic/route/chat/rule/4/ref/0","sampled_requests":3,"compared_requests":2,"similarity_sum":1.5,"latency_ms_sum":200,"migration_latency_ms_sum":300},
+{"route":"default/other","rule_index":0,"backend":"default/anthropic/route/other/rule/2/ref/0","sampled_requests":1,"compared_requests":1,"similarity_sum":1,"latency_ms_sum":100,"migration_latency_ms_sum":100}
+]`))
+	}))
+	defer gateway.Close()
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := newMigrationStatusReconciler(c, c, logr.Discard(), time.Minute)
+	r.now = func() time.Time { return now }
+	r.gatewayMigrationsURL = func(pod *corev1.Pod) string {
+		require.Contains(t, []string{"10.0.0.1", "10.0.0.2"}, pod.Status.PodIP)
+		return gateway.URL + "/v1/migrations"
+	}
+	require.NoError(t, r.reconcile(t.Context()))
+
+	var route aigv1b1.AIGatewayRoute
+	require.NoError(t, c.Get(t.Context(), client.ObjectKey{Namespace: "default", Name: "chat"}, &route))
+	require.Len(t, route.Status.Migrations, 2)
+	for i := range route.Status.Migrations {
+		require.True(t, route.Status.Migrations[i].LastUpdateTime.Equal(ptrTime(now)))
+		route.Status.Migrations[i].LastUpdateTime = metav1.Time{}
+	}
+	require.Equal(t, []aigv1b1.AIGatewayRouteMigrationStatus{
+		{
+			RuleIndex: 0, BackendName: "anthropic", SampledRequests: 6, ComparedRequests: 4, AverageSimilarityPercent: 75,
+			AverageLatencyMilliseconds: 100, AverageMigrationLatencyMilliseconds: 150,
+		},
+		{RuleIndex: 2, BackendName: "gemini"},
+	}, route.Status.Migrations)
+
+	require.NoError(t, c.Get(t.Context(), client.ObjectKey{Namespace: "default", Name: "stale"}, &route))
+	require.Empty(t, route.Status.Migrations)
+
+	t.Run("gateway migrations unavailable", func(t *testing.T) {
+		notFound := httptest.NewServer(http.NotFoundHandler())
+		defer notFound.Close()
+		r.gatewayMigrationsURL = func(*corev1.Pod) string { return notFound.URL + "/v1/migrations" }
+		require.ErrorContains(t, r.reconcile(t.Context()), "failed to get the migrations of pod envoy-gateway-system/envoy-")
+	})
+}
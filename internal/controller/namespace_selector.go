@@ -0,0 +1,173 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	aigv1b1 "github.com/envoyproxy/ai-gateway/api/v1beta1"
+)
+
+// namespaceScopedReconciler wraps a reconciler so that only the requests for objects living in a namespace whose
+// labels match the selector are reconciled. This allows multiple controller instances to manage disjoint sets of
+// namespaces in the same cluster.
+//
+// The check is done at the reconciler level rather than with an event predicate, so that the requests enqueued
+// through the event channels between the controllers are filtered as well.
+type namespaceScopedReconciler struct {
+	client   client.Client
+	logger   logr.Logger
+	selector labels.Selector
+	inner    reconcile.TypedReconciler[reconcile.Request]
+}
+
+// newNamespaceScopedReconciler returns the inner reconciler as-is if the selector is nil or matches everything.
+// Otherwise, it returns the inner reconciler wrapped by a namespaceScopedReconciler.
+func newNamespaceScopedReconciler(c client.Client, logger logr.Logger, selector labels.Selector,
+	inner reconcile.TypedReconciler[reconcile.Request],
+) reconcile.TypedReconciler[reconcile.Request] {
+	if selector == nil || selector.Empty() {
+		return inner
+	}
+	return &namespaceScopedReconciler{client: c, logger: logger, selector: selector, inner: inner}
+}
+
+// Reconcile implements [reconcile.TypedReconciler].
+func (n *namespaceScopedReconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	ok, err := namespaceMatchesSelector(ctx, n.client, req.Namespace, n.selector)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if !ok {
+		n.logger.V(1).Info("Skipping reconciliation for object outside of the selected namespaces",
+			"namespace", req.Namespace, "name", req.Name)
+		return ctrl.Result{}, nil
+	}
+	return n.inner.Reconcile(ctx, req)
+}
+
+// namespaceMatchesSelector reports whether the labels of the given namespace match the selector.
+// Cluster-scoped objects, i.e. an empty namespace, always match.
+func namespaceMatchesSelector(ctx context.Context, c client.Client, namespace string, selector labels.Selector) (bool, error) {
+	if namespace == "" || selector == nil {
+		return true, nil
+	}
+	var ns corev1.Namespace
+	if err := c.Get(ctx, client.ObjectKey{Name: namespace}, &ns); err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get namespace %s: %w", namespace, err)
+	}
+	return selector.Matches(labels.Set(ns.Labels)), nil
+}
+
+// watchNamespaces adds to the builder a watch of the Namespaces, so that the objects returned by the map function
+// are reconciled again when the labels of a namespace change, i.e. when it enters or leaves the selection. This is
+// a no-op if the selector is nil or matches everything.
+func watchNamespaces(b *ctrl.Builder, selector labels.Selector, fn handler.MapFunc) *ctrl.Builder {
+	if selector == nil || selector.Empty() {
+		return b
+	}
+	return b.Watches(&corev1.Namespace{}, handler.EnqueueRequestsFromMapFunc(fn),
+		builder.WithPredicates(predicate.LabelChangedPredicate{}))
+}
+
+// namespaceToObjects returns the map function enqueueing all the objects of the list type living in the namespace.
+func namespaceToObjects(c client.Client, logger logr.Logger, newList func() client.ObjectList) handler.MapFunc {
+	return func(ctx context.Context, obj client.Object) []reconcile.Request {
+		list := newList()
+		if err := c.List(ctx, list, client.InNamespace(obj.GetName())); err != nil {
+			logger.Error(err, "failed to list the objects of the namespace", "namespace", obj.GetName())
+			return nil
+		}
+		var requests []reconcile.Request
+		_ = meta.EachListItem(list, func(o runtime.Object) error {
+			if item, ok := o.(client.Object); ok {
+				requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(item)})
+			}
+			return nil
+		})
+		return requests
+	}
+}
+
+// namespaceToGateways returns the map function enqueueing the Gateways living in the namespace, and the Gateways the
+// AIGatewayRoutes and MCPRoutes of the namespace are attached to, since their filter configs only include the routes
+// of the selected namespaces.
+func namespaceToGateways(c client.Client, logger logr.Logger) handler.MapFunc {
+	gateways := namespaceToObjects(c, logger, func() client.ObjectList { return &gwapiv1.GatewayList{} })
+	return func(ctx context.Context, obj client.Object) []reconcile.Request {
+		requests := gateways(ctx, obj)
+		var aiRoutes aigv1b1.AIGatewayRouteList
+		if err := c.List(ctx, &aiRoutes, client.InNamespace(obj.GetName())); err != nil {
+			logger.Error(err, "failed to list the AIGatewayRoutes of the namespace", "namespace", obj.GetName())
+			return requests
+		}
+		for i := range aiRoutes.Items {
+			requests = append(requests, aiGatewayRouteToGateways(ctx, &aiRoutes.Items[i])...)
+		}
+		var mcpRoutes aigv1b1.MCPRouteList
+		if err := c.List(ctx, &mcpRoutes, client.InNamespace(obj.GetName())); err != nil {
+			logger.Error(err, "failed to list the MCPRoutes of the namespace", "namespace", obj.GetName())
+			return requests
+		}
+		for i := range mcpRoutes.Items {
+			route := &mcpRoutes.Items[i]
+			for _, p := range route.Spec.ParentRefs {
+				namespace := route.Namespace
+				if p.Namespace != nil {
+					namespace = string(*p.Namespace)
+				}
+				requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKey{Namespace: namespace, Name: string(p.Name)}})
+			}
+		}
+		return requests
+	}
+}
+
+// filterSelectedNamespaces returns the items living in a namespace matching the selector. The items are returned
+// as-is if the selector is nil or matches everything.
+func filterSelectedNamespaces[T any, PT interface {
+	*T
+	client.Object
+}](ctx context.Context, c client.Client, selector labels.Selector, items []T) ([]T, error) {
+	if selector == nil || selector.Empty() {
+		return items, nil
+	}
+	matches := make(map[string]bool)
+	filtered := make([]T, 0, len(items))
+	for i := range items {
+		namespace := PT(&items[i]).GetNamespace()
+		ok, cached := matches[namespace]
+		if !cached {
+			var err error
+			if ok, err = namespaceMatchesSelector(ctx, c, namespace, selector); err != nil {
+				return nil, err
+			}
+			matches[namespace] = ok
+		}
+		if ok {
+			filtered = append(filtered, items[i])
+		}
+	}
+	return filtered, nil
+}
@@ -0,0 +1,139 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	aigv1b1 "github.com/envoyproxy/ai-gateway/api/v1beta1"
+)
+
+type recordingReconciler struct {
+	reqs []reconcile.Request
+}
+
+func (r *recordingReconciler) Reconcile(_ context.Context, req reconcile.Request) (reconcile.Result, error) {
+	r.reqs = append(r.reqs, req)
+	return ctrl.Result{}, nil
+}
+
+func Test_newNamespaceScopedReconciler(t *testing.T) {
+	inner := &recordingReconciler{}
+	c := fake.NewClientBuilder().WithScheme(Scheme).Build()
+
+	t.Run("nil selector", func(t *testing.T) {
+		require.Same(t, inner, newNamespaceScopedReconciler(c, ctrl.Log, nil, inner))
+	})
+	t.Run("empty selector", func(t *testing.T) {
+		require.Same(t, inner, newNamespaceScopedReconciler(c, ctrl.Log, labels.Everything(), inner))
+	})
+	t.Run("non-empty selector", func(t *testing.T) {
+		r := newNamespaceScopedReconciler(c, ctrl.Log, labels.SelectorFromSet(labels.Set{"team": "a"}), inner)
+		require.IsType(t, &namespaceScopedReconciler{}, r)
+	})
+}
+
+func TestNamespaceScopedReconciler_Reconcile(t *testing.T) {
+	c := fake.NewClientBuilder().WithScheme(Scheme).WithObjects(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a", Labels: map[string]string{"team": "a"}}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-b", Labels: map[string]string{"team": "b"}}},
+	).Build()
+	inner := &recordingReconciler{}
+	r := newNamespaceScopedReconciler(c, ctrl.Log, labels.SelectorFromSet(labels.Set{"team": "a"}), inner)
+
+	for _, req := range []reconcile.Request{
+		{NamespacedName: types.NamespacedName{Namespace: "team-a", Name: "route"}},
+		{NamespacedName: types.NamespacedName{Namespace: "team-b", Name: "route"}},
+		{NamespacedName: types.NamespacedName{Namespace: "non-existent", Name: "route"}},
+		{NamespacedName: types.NamespacedName{Name: "cluster-scoped"}},
+	} {
+		res, err := r.Reconcile(t.Context(), req)
+		require.NoError(t, err)
+		require.Equal(t, ctrl.Result{}, res)
+	}
+	require.Equal(t, []reconcile.Request{
+		{NamespacedName: types.NamespacedName{Namespace: "team-a", Name: "route"}},
+		{NamespacedName: types.NamespacedName{Name: "cluster-scoped"}},
+	}, inner.reqs)
+}
+
+func Test_namespaceToObjects(t *testing.T) {
+	c := fake.NewClientBuilder().WithScheme(Scheme).WithObjects(
+		&aigv1b1.AIServiceBackend{ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "backend1"}},
+		&aigv1b1.AIServiceBackend{ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "backend2"}},
+		&aigv1b1.AIServiceBackend{ObjectMeta: metav1.ObjectMeta{Namespace: "team-b", Name: "backend3"}},
+	).Build()
+	fn := namespaceToObjects(c, ctrl.Log, func() client.ObjectList { return &aigv1b1.AIServiceBackendList{} })
+
+	requests := fn(t.Context(), &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}})
+	require.ElementsMatch(t, []reconcile.Request{
+		{NamespacedName: types.NamespacedName{Namespace: "team-a", Name: "backend1"}},
+		{NamespacedName: types.NamespacedName{Namespace: "team-a", Name: "backend2"}},
+	}, requests)
+	require.Empty(t, fn(t.Context(), &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-c"}}))
+}
+
+func Test_namespaceToGateways(t *testing.T) {
+	c := fake.NewClientBuilder().WithScheme(Scheme).WithObjects(
+		&gwapiv1.Gateway{ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "gw1"}},
+		&gwapiv1.Gateway{ObjectMeta: metav1.ObjectMeta{Namespace: "infra", Name: "gw2"}},
+		&aigv1b1.AIGatewayRoute{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "route"},
+			Spec: aigv1b1.AIGatewayRouteSpec{ParentRefs: []gwapiv1.ParentReference{
+				{Name: "gw2", Namespace: ptr.To(gwapiv1.Namespace("infra"))},
+			}},
+		},
+		&aigv1b1.MCPRoute{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "mcp-route"},
+			Spec:       aigv1b1.MCPRouteSpec{ParentRefs: []gwapiv1.ParentReference{{Name: "gw3"}}},
+		},
+	).Build()
+
+	requests := namespaceToGateways(c, ctrl.Log)(t.Context(), &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}})
+	require.ElementsMatch(t, []reconcile.Request{
+		{NamespacedName: types.NamespacedName{Namespace: "team-a", Name: "gw1"}},
+		{NamespacedName: types.NamespacedName{Namespace: "infra", Name: "gw2"}},
+		{NamespacedName: types.NamespacedName{Namespace: "team-a", Name: "gw3"}},
+	}, requests)
+}
+
+func Test_filterSelectedNamespaces(t *testing.T) {
+	c := fake.NewClientBuilder().WithScheme(Scheme).WithObjects(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a", Labels: map[string]string{"team": "a"}}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-b", Labels: map[string]string{"team": "b"}}},
+	).Build()
+	routes := []aigv1b1.AIGatewayRoute{
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "route1"}},
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "team-b", Name: "route2"}},
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "route3"}},
+	}
+
+	t.Run("nil selector", func(t *testing.T) {
+		filtered, err := filterSelectedNamespaces(t.Context(), c, nil, routes)
+		require.NoError(t, err)
+		require.Len(t, filtered, 3)
+	})
+	t.Run("selector", func(t *testing.T) {
+		filtered, err := filterSelectedNamespaces(t.Context(), c, labels.SelectorFromSet(labels.Set{"team": "a"}), routes)
+		require.NoError(t, err)
+		require.Len(t, filtered, 2)
+		require.Equal(t, "route1", filtered[0].Name)
+		require.Equal(t, "route3", filtered[1].Name)
+	})
+}
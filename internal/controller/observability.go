@@ -0,0 +1,384 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlutil "sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+	"sigs.k8s.io/yaml"
+
+	aigv1b1 "github.com/envoyproxy/ai-gateway/api/v1beta1"
+)
+
+const (
+	// grafanaDashboardLabel is the label of the ConfigMaps holding the Grafana dashboards, as discovered by the
+	// dashboard sidecar of the Grafana Helm chart.
+	grafanaDashboardLabel = "grafana_dashboard"
+	// prometheusRuleLabel is the label of the ConfigMaps holding the Prometheus alerting rules.
+	prometheusRuleLabel = "prometheus_rule"
+	// dashboardConfigMapPrefix and alertsConfigMapPrefix prefix the names of the ConfigMaps generated per
+	// AIGatewayRoute.
+	dashboardConfigMapPrefix = "ai-eg-dashboard"
+	alertsConfigMapPrefix    = "ai-eg-alerts"
+)
+
+// ObservabilityOptions configures the Grafana dashboard and the Prometheus alerting rules generated per
+// AIGatewayRoute, so that the monitoring of the routes follows their models.
+type ObservabilityOptions struct {
+	// Enabled enables the generation of the ConfigMaps.
+	Enabled bool
+	// ErrorRateThreshold is the ratio of the failed requests above which the error rate alert fires.
+	ErrorRateThreshold float64
+	// TimeToFirstTokenSLO is the 95th percentile of the time to first token above which the latency alert fires.
+	TimeToFirstTokenSLO time.Duration
+	// QuotaSaturationThreshold is the used ratio of a provider rate limit above which the saturation alert fires.
+	QuotaSaturationThreshold float64
+}
+
+// syncObservabilityConfigMaps creates or updates the dashboard and alerts ConfigMaps of the AIGatewayRoute. They are
+// owned by the route, hence deleted with it. The ConfigMaps of a route without models are deleted, since its
+// metrics can't be told apart from the ones of the other routes.
+func (c *AIGatewayRouteController) syncObservabilityConfigMaps(ctx context.Context, aiGatewayRoute *aigv1b1.AIGatewayRoute) error {
+	models := routeModelNames(aiGatewayRoute, c.modelNameHeaderKey)
+	if len(models) == 0 {
+		for _, prefix := range []string{dashboardConfigMapPrefix, alertsConfigMapPrefix} {
+			cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+				Name: prefix + "-" + aiGatewayRoute.Name, Namespace: aiGatewayRoute.Namespace,
+			}}
+			if err := c.client.Delete(ctx, cm); client.IgnoreNotFound(err) != nil {
+				return fmt.Errorf("failed to delete ConfigMap %s: %w", cm.Name, err)
+			}
+		}
+		return nil
+	}
+	configMaps, err := observabilityConfigMaps(aiGatewayRoute, models, c.observability)
+	if err != nil {
+		return err
+	}
+	for _, desired := range configMaps {
+		setGeneratedVersion(desired)
+		var cm corev1.ConfigMap
+		err = c.client.Get(ctx, client.ObjectKey{Name: desired.Name, Namespace: desired.Namespace}, &cm)
+		switch {
+		case apierrors.IsNotFound(err):
+			if err = ctrlutil.SetControllerReference(aiGatewayRoute, desired, c.client.Scheme()); err != nil {
+				panic(fmt.Errorf("BUG: failed to set controller reference for ConfigMap: %w", err))
+			}
+			if err = c.client.Create(ctx, desired); err != nil {
+				return fmt.Errorf("failed to create ConfigMap %s: %w", desired.Name, err)
+			}
+			c.logger.Info("Created ConfigMap", "name", desired.Name, "namespace", desired.Namespace)
+		case err != nil:
+			return fmt.Errorf("failed to get ConfigMap %s: %w", desired.Name, err)
+		case !maps.Equal(cm.Data, desired.Data) || !labelsContain(cm.Labels, desired.Labels) ||
+			!labelsContain(cm.Annotations, desired.Annotations):
+			c.generated.observe(aiGatewayRoute, &cm)
+			cm.Data = desired.Data
+			if cm.Labels == nil {
+				cm.Labels = make(map[string]string)
+			}
+			maps.Copy(cm.Labels, desired.Labels)
+			if cm.Annotations == nil {
+				cm.Annotations = make(map[string]string)
+			}
+			maps.Copy(cm.Annotations, desired.Annotations)
+			if err = c.client.Update(ctx, &cm); err != nil {
+				return fmt.Errorf("failed to update ConfigMap %s: %w", desired.Name, err)
+			}
+			c.logger.Info("Updated ConfigMap", "name", desired.Name, "namespace", desired.Namespace)
+		}
+	}
+	return nil
+}
+
+// labelsContain returns true if labels contain all the wanted labels.
+func labelsContain(labels, wanted map[string]string) bool {
+	for k, v := range wanted {
+		if got, ok := labels[k]; !ok || got != v {
+			return false
+		}
+	}
+	return true
+}
+
+// routeModelNames returns the sorted models matched by the rules of the AIGatewayRoute, in the same way as the
+// models declared in the filter config.
+func routeModelNames(aiGatewayRoute *aigv1b1.AIGatewayRoute, modelNameHeaderKey string) []string {
+	var models []string
+	for i := range aiGatewayRoute.Spec.Rules {
+		for _, m := range aiGatewayRoute.Spec.Rules[i].Matches {
+			for _, h := range m.Headers {
+				if (h.Type != nil && *h.Type != gwapiv1.HeaderMatchExact) || string(h.Name) != modelNameHeaderKey {
+					continue
+				}
+				models = append(models, h.Value)
+			}
+		}
+	}
+	slices.Sort(models)
+	return slices.Compact(models)
+}
+
+// observabilityConfigMaps returns the dashboard and the alerts ConfigMaps of the AIGatewayRoute matching the models.
+func observabilityConfigMaps(aiGatewayRoute *aigv1b1.AIGatewayRoute, models []string, options ObservabilityOptions) ([]*corev1.ConfigMap, error) {
+	q := newRouteQueries(aiGatewayRoute, models)
+	key := aiGatewayRoute.Namespace + "-" + aiGatewayRoute.Name
+
+	dashboard, err := json.MarshalIndent(routeDashboard(aiGatewayRoute, q), "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal the dashboard: %w", err)
+	}
+	alerts, err := yaml.Marshal(routeAlertRules(aiGatewayRoute, q, options))
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal the alerting rules: %w", err)
+	}
+	newConfigMap := func(prefix, label, dataKey string, data []byte) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      prefix + "-" + aiGatewayRoute.Name,
+				Namespace: aiGatewayRoute.Namespace,
+				Labels:    map[string]string{managedByLabel: managedByValue, label: "1"},
+			},
+			Data: map[string]string{dataKey: string(data)},
+		}
+	}
+	return []*corev1.ConfigMap{
+		newConfigMap(dashboardConfigMapPrefix, grafanaDashboardLabel, key+".json", dashboard),
+		newConfigMap(alertsConfigMapPrefix, prometheusRuleLabel, key+".rules.yaml", alerts),
+	}, nil
+}
+
+// routeQueries are the PromQL label matchers of the metrics of an AIGatewayRoute.
+type routeQueries struct {
+	// models matches the gen_ai metrics of the models of the route. These metrics are not labeled by route, so the
+	// models shared with another route are counted in both.
+	models string
+	// backends matches the metrics labeled by the backends of the rules of the route.
+	backends string
+}
+
+func newRouteQueries(aiGatewayRoute *aigv1b1.AIGatewayRoute, models []string) routeQueries {
+	quoted := make([]string, len(models))
+	for i, m := range models {
+		quoted[i] = regexp.QuoteMeta(m)
+	}
+	backends := fmt.Sprintf("%s/[^/]+/route/%s/rule/.+",
+		regexp.QuoteMeta(aiGatewayRoute.Namespace), regexp.QuoteMeta(aiGatewayRoute.Name))
+	return routeQueries{
+		// The PromQL string literals are escaped like the Go ones.
+		models:   `gen_ai_original_model=~` + strconv.Quote(strings.Join(quoted, "|")),
+		backends: `backend=~` + strconv.Quote(backends),
+	}
+}
+
+func (q routeQueries) requestRate(by string) string {
+	return fmt.Sprintf(`sum by (%s) (rate(gen_ai_server_request_duration_seconds_count{%s}[$__rate_interval]))`, by, q.models)
+}
+
+func (q routeQueries) errorRatio(window string) string {
+	return fmt.Sprintf(`sum(rate(gen_ai_server_request_duration_seconds_count{%[1]s,error_type!=""}[%[2]s])) / sum(rate(gen_ai_server_request_duration_seconds_count{%[1]s}[%[2]s]))`,
+		q.models, window)
+}
+
+func (q routeQueries) quantile(metric, by, window string) string {
+	return fmt.Sprintf(`histogram_quantile(0.95, sum by (%s) (rate(%s_bucket{%s}[%s])))`, by, metric, q.models, window)
+}
+
+func (q routeQueries) rateLimitUsage() string {
+	return fmt.Sprintf(`1 - gen_ai_provider_ratelimit_remaining{%[1]s} / gen_ai_provider_ratelimit_limit{%[1]s}`, q.backends)
+}
+
+// grafanaDashboard is the subset of the Grafana dashboard JSON model used here.
+type grafanaDashboard struct {
+	UID           string            `json:"uid"`
+	Title         string            `json:"title"`
+	Tags          []string          `json:"tags"`
+	Editable      bool              `json:"editable"`
+	SchemaVersion int               `json:"schemaVersion"`
+	Refresh       string            `json:"refresh"`
+	Time          grafanaTimeRange  `json:"time"`
+	Templating    grafanaTemplating `json:"templating"`
+	Panels        []grafanaPanel    `json:"panels"`
+}
+
+type grafanaTimeRange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type grafanaTemplating struct {
+	List []grafanaVariable `json:"list"`
+}
+
+type grafanaVariable struct {
+	Name  string `json:"name"`
+	Label string `json:"label"`
+	Type  string `json:"type"`
+	Query string `json:"query"`
+}
+
+type grafanaPanel struct {
+	ID          int                `json:"id"`
+	Title       string             `json:"title"`
+	Type        string             `json:"type"`
+	Datasource  grafanaDatasource  `json:"datasource"`
+	GridPos     grafanaGridPos     `json:"gridPos"`
+	FieldConfig grafanaFieldConfig `json:"fieldConfig"`
+	Targets     []grafanaTarget    `json:"targets"`
+}
+
+type grafanaDatasource struct {
+	Type string `json:"type"`
+	UID  string `json:"uid"`
+}
+
+type grafanaGridPos struct {
+	H int `json:"h"`
+	W int `json:"w"`
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+type grafanaFieldConfig struct {
+	Defaults struct {
+		Unit string `json:"unit"`
+	} `json:"defaults"`
+}
+
+type grafanaTarget struct {
+	RefID        string            `json:"refId"`
+	Datasource   grafanaDatasource `json:"datasource"`
+	Expr         string            `json:"expr"`
+	LegendFormat string            `json:"legendFormat"`
+}
+
+// routeDashboard returns the Grafana dashboard of the AIGatewayRoute. The data source is chosen with the
+// "datasource" variable.
+func routeDashboard(aiGatewayRoute *aigv1b1.AIGatewayRoute, q routeQueries) grafanaDashboard {
+	ds := grafanaDatasource{Type: "prometheus", UID: "${datasource}"}
+	var panels []grafanaPanel
+	addPanel := func(title, unit, expr, legend string) {
+		p := grafanaPanel{
+			ID:         len(panels) + 1,
+			Title:      title,
+			Type:       "timeseries",
+			Datasource: ds,
+			// Two panels per row.
+			GridPos: grafanaGridPos{H: 8, W: 12, X: len(panels) % 2 * 12, Y: len(panels) / 2 * 8},
+			Targets: []grafanaTarget{{RefID: "A", Datasource: ds, Expr: expr, LegendFormat: legend}},
+		}
+		p.FieldConfig.Defaults.Unit = unit
+		panels = append(panels, p)
+	}
+	addPanel("Requests", "reqps", q.requestRate("gen_ai_original_model"), "{{gen_ai_original_model}}")
+	addPanel("Error Rate", "percentunit", q.errorRatio("$__rate_interval"), "errors")
+	addPanel("Request Duration (p95)", "s",
+		q.quantile("gen_ai_server_request_duration_seconds", "le, gen_ai_original_model", "$__rate_interval"), "{{gen_ai_original_model}}")
+	addPanel("Time to First Token (p95)", "s",
+		q.quantile("gen_ai_server_time_to_first_token_seconds", "le, gen_ai_original_model", "$__rate_interval"), "{{gen_ai_original_model}}")
+	addPanel("Tokens", "short",
+		fmt.Sprintf(`sum by (gen_ai_token_type) (rate(gen_ai_client_token_usage_sum{%s}[$__rate_interval]))`, q.models), "{{gen_ai_token_type}}")
+	addPanel("Provider Rate Limit Usage", "percentunit", q.rateLimitUsage(), "{{backend}} {{ratelimit_type}}")
+
+	uid := sha256.Sum256([]byte(aiGatewayRoute.Namespace + "/" + aiGatewayRoute.Name))
+	return grafanaDashboard{
+		// The UID is limited to 40 characters by Grafana.
+		UID:           "aigw-" + hex.EncodeToString(uid[:])[:32],
+		Title:         fmt.Sprintf("AI Gateway / %s/%s", aiGatewayRoute.Namespace, aiGatewayRoute.Name),
+		Tags:          []string{"envoy-ai-gateway"},
+		SchemaVersion: 39,
+		Refresh:       "1m",
+		Time:          grafanaTimeRange{From: "now-6h", To: "now"},
+		Templating: grafanaTemplating{List: []grafanaVariable{
+			{Name: "datasource", Label: "Data source", Type: "datasource", Query: "prometheus"},
+		}},
+		Panels: panels,
+	}
+}
+
+// prometheusRuleFile is the Prometheus rule file format.
+type prometheusRuleFile struct {
+	Groups []prometheusRuleGroup `json:"groups"`
+}
+
+type prometheusRuleGroup struct {
+	Name  string           `json:"name"`
+	Rules []prometheusRule `json:"rules"`
+}
+
+type prometheusRule struct {
+	Alert       string            `json:"alert"`
+	Expr        string            `json:"expr"`
+	For         string            `json:"for"`
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+// routeAlertRules returns the alerting rules of the error rate, the time to first token and the provider rate limit
+// saturation of the AIGatewayRoute.
+func routeAlertRules(aiGatewayRoute *aigv1b1.AIGatewayRoute, q routeQueries, options ObservabilityOptions) prometheusRuleFile {
+	route := aiGatewayRoute.Namespace + "/" + aiGatewayRoute.Name
+	labels := map[string]string{
+		"severity":         "warning",
+		"namespace":        aiGatewayRoute.Namespace,
+		"ai_gateway_route": aiGatewayRoute.Name,
+	}
+	return prometheusRuleFile{Groups: []prometheusRuleGroup{{
+		Name: "ai-gateway-route-" + aiGatewayRoute.Namespace + "-" + aiGatewayRoute.Name,
+		Rules: []prometheusRule{
+			{
+				Alert:  "AIGatewayRouteHighErrorRate",
+				Expr:   fmt.Sprintf("(%s) > %s", q.errorRatio("5m"), formatFloat(options.ErrorRateThreshold)),
+				For:    "5m",
+				Labels: labels,
+				Annotations: map[string]string{
+					"summary": fmt.Sprintf("The error rate of AIGatewayRoute %s is above %s%%.", route, formatFloat(options.ErrorRateThreshold*100)),
+				},
+			},
+			{
+				Alert: "AIGatewayRouteTimeToFirstTokenSLO",
+				Expr: fmt.Sprintf("%s > %s",
+					q.quantile("gen_ai_server_time_to_first_token_seconds", "le", "5m"), formatFloat(options.TimeToFirstTokenSLO.Seconds())),
+				For:    "10m",
+				Labels: labels,
+				Annotations: map[string]string{
+					"summary": fmt.Sprintf("The 95th percentile of the time to first token of AIGatewayRoute %s is above %s.", route, options.TimeToFirstTokenSLO),
+				},
+			},
+			{
+				Alert:  "AIGatewayRouteQuotaSaturation",
+				Expr:   fmt.Sprintf("(%s) > %s", q.rateLimitUsage(), formatFloat(options.QuotaSaturationThreshold)),
+				For:    "5m",
+				Labels: labels,
+				Annotations: map[string]string{
+					"summary": fmt.Sprintf("The {{ $labels.ratelimit_type }} rate limit of backend {{ $labels.backend }} of AIGatewayRoute %s is more than %s%% used.",
+						route, formatFloat(options.QuotaSaturationThreshold*100)),
+				},
+			},
+		},
+	}}}
+}
+
+// formatFloat formats the threshold, rounded so that e.g. 0.07*100 is formatted as 7.
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', 6, 64)
+}
@@ -0,0 +1,137 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package controller
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fake2 "k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlutil "sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+	"sigs.k8s.io/yaml"
+
+	aigv1b1 "github.com/envoyproxy/ai-gateway/api/v1beta1"
+	internaltesting "github.com/envoyproxy/ai-gateway/internal/testing"
+)
+
+var testObservabilityOptions = ObservabilityOptions{
+	Enabled:                  true,
+	ErrorRateThreshold:       0.05,
+	TimeToFirstTokenSLO:      2 * time.Second,
+	QuotaSaturationThreshold: 0.9,
+}
+
+func modelRule(models ...string) aigv1b1.AIGatewayRouteRule {
+	var rule aigv1b1.AIGatewayRouteRule
+	for _, m := range models {
+		rule.Matches = append(rule.Matches, aigv1b1.AIGatewayRouteRuleMatch{
+			Headers: []gwapiv1.HTTPHeaderMatch{{Name: aigv1b1.AIModelHeaderKey, Value: m}},
+		})
+	}
+	return rule
+}
+
+func TestAIGatewayRouteController_syncObservabilityConfigMaps(t *testing.T) {
+	fakeClient := requireNewFakeClientWithIndexes(t)
+	eventCh := internaltesting.NewControllerEventChan[*gwapiv1.Gateway]()
+	c := NewAIGatewayRouteController(fakeClient, fake2.NewClientset(), logr.Discard(), eventCh.Ch, "/")
+	c.observability = testObservabilityOptions
+
+	route := &aigv1b1.AIGatewayRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "myroute", Namespace: "ns1"},
+		Spec:       aigv1b1.AIGatewayRouteSpec{Rules: []aigv1b1.AIGatewayRouteRule{modelRule("gpt-4o")}},
+	}
+	require.NoError(t, fakeClient.Create(t.Context(), route))
+
+	requireConfigMap := func(name, label, key, contains string) *corev1.ConfigMap {
+		var cm corev1.ConfigMap
+		require.NoError(t, fakeClient.Get(t.Context(), client.ObjectKey{Name: name, Namespace: "ns1"}, &cm))
+		require.Equal(t, "1", cm.Labels[label])
+		require.Equal(t, managedByValue, cm.Labels[managedByLabel])
+		ok, _ := ctrlutil.HasOwnerReference(cm.OwnerReferences, route, fakeClient.Scheme())
+		require.True(t, ok, "expected the ConfigMap to be owned by the AIGatewayRoute")
+		require.Contains(t, cm.Data[key], contains)
+		return &cm
+	}
+
+	require.NoError(t, c.syncObservabilityConfigMaps(t.Context(), route))
+	requireConfigMap("ai-eg-dashboard-myroute", grafanaDashboardLabel, "ns1-myroute.json", `gen_ai_original_model=~\"gpt-4o\"`)
+	requireConfigMap("ai-eg-alerts-myroute", prometheusRuleLabel, "ns1-myroute.rules.yaml", `gen_ai_original_model=~"gpt-4o"`)
+
+	// The ConfigMaps follow the models of the route, and the out-of-band edits are reverted.
+	cm := requireConfigMap("ai-eg-alerts-myroute", prometheusRuleLabel, "ns1-myroute.rules.yaml", "gpt-4o")
+	delete(cm.Labels, prometheusRuleLabel)
+	require.NoError(t, fakeClient.Update(t.Context(), cm))
+	route.Spec.Rules = append(route.Spec.Rules, modelRule("claude-sonnet-4"))
+	require.NoError(t, c.syncObservabilityConfigMaps(t.Context(), route))
+	requireConfigMap("ai-eg-dashboard-myroute", grafanaDashboardLabel, "ns1-myroute.json", `claude-sonnet-4|gpt-4o`)
+	requireConfigMap("ai-eg-alerts-myroute", prometheusRuleLabel, "ns1-myroute.rules.yaml", `claude-sonnet-4|gpt-4o`)
+
+	// The ConfigMaps are deleted once the route has no models.
+	route.Spec.Rules = []aigv1b1.AIGatewayRouteRule{{}}
+	require.NoError(t, c.syncObservabilityConfigMaps(t.Context(), route))
+	for _, name := range []string{"ai-eg-dashboard-myroute", "ai-eg-alerts-myroute"} {
+		err := fakeClient.Get(t.Context(), client.ObjectKey{Name: name, Namespace: "ns1"}, &corev1.ConfigMap{})
+		require.True(t, apierrors.IsNotFound(err), "expected %s to be deleted: %v", name, err)
+	}
+	require.NoError(t, c.syncObservabilityConfigMaps(t.Context(), route))
+}
+
+func Test_routeModelNames(t *testing.T) {
+	regex := gwapiv1.HeaderMatchRegularExpression
+	rule := modelRule("b", "a")
+	rule.Matches = append(rule.Matches, aigv1b1.AIGatewayRouteRuleMatch{Headers: []gwapiv1.HTTPHeaderMatch{
+		{Name: aigv1b1.AIModelHeaderKey, Value: "c.*", Type: &regex},
+		{Name: "x-other", Value: "d"},
+	}})
+	route := &aigv1b1.AIGatewayRoute{Spec: aigv1b1.AIGatewayRouteSpec{Rules: []aigv1b1.AIGatewayRouteRule{rule, modelRule("a")}}}
+	require.Equal(t, []string{"a", "b"}, routeModelNames(route, aigv1b1.AIModelHeaderKey))
+	require.Equal(t, []string{"d"}, routeModelNames(route, "x-other"))
+}
+
+func Test_observabilityConfigMaps(t *testing.T) {
+	route := &aigv1b1.AIGatewayRoute{ObjectMeta: metav1.ObjectMeta{Name: "my.route", Namespace: "ns1"}}
+	configMaps, err := observabilityConfigMaps(route, []string{"gpt-4.1", "o3"}, testObservabilityOptions)
+	require.NoError(t, err)
+	require.Len(t, configMaps, 2)
+
+	var dashboard grafanaDashboard
+	require.NoError(t, json.Unmarshal([]byte(configMaps[0].Data["ns1-my.route.json"]), &dashboard))
+	require.Equal(t, "AI Gateway / ns1/my.route", dashboard.Title)
+	require.Len(t, dashboard.UID, 37)
+	require.Len(t, dashboard.Panels, 6)
+	require.Equal(t, grafanaGridPos{H: 8, W: 12, X: 12, Y: 16}, dashboard.Panels[5].GridPos)
+	require.Equal(t,
+		`sum by (gen_ai_original_model) (rate(gen_ai_server_request_duration_seconds_count{gen_ai_original_model=~"gpt-4\\.1|o3"}[$__rate_interval]))`,
+		dashboard.Panels[0].Targets[0].Expr)
+
+	var rules prometheusRuleFile
+	require.NoError(t, yaml.Unmarshal([]byte(configMaps[1].Data["ns1-my.route.rules.yaml"]), &rules))
+	require.Len(t, rules.Groups, 1)
+	require.Equal(t, "ai-gateway-route-ns1-my.route", rules.Groups[0].Name)
+	exprs := map[string]string{}
+	for _, r := range rules.Groups[0].Rules {
+		exprs[r.Alert] = r.Expr
+		require.Equal(t, "my.route", r.Labels["ai_gateway_route"])
+	}
+	require.Equal(t, map[string]string{
+		"AIGatewayRouteHighErrorRate": `(sum(rate(gen_ai_server_request_duration_seconds_count{gen_ai_original_model=~"gpt-4\\.1|o3",error_type!=""}[5m])) / ` +
+			`sum(rate(gen_ai_server_request_duration_seconds_count{gen_ai_original_model=~"gpt-4\\.1|o3"}[5m]))) > 0.05`,
+		"AIGatewayRouteTimeToFirstTokenSLO": `histogram_quantile(0.95, sum by (le) (rate(gen_ai_server_time_to_first_token_seconds_bucket{gen_ai_original_model=~"gpt-4\\.1|o3"}[5m]))) > 2`,
+		"AIGatewayRouteQuotaSaturation": `(1 - gen_ai_provider_ratelimit_remaining{backend=~"ns1/[^/]+/route/my\\.route/rule/.+"} / ` +
+			`gen_ai_provider_ratelimit_limit{backend=~"ns1/[^/]+/route/my\\.route/rule/.+"}) > 0.9`,
+	}, exprs)
+	require.Equal(t, "The error rate of AIGatewayRoute ns1/my.route is above 5%.",
+		rules.Groups[0].Rules[0].Annotations["summary"])
+}
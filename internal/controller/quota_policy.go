@@ -95,6 +95,11 @@ func (c *QuotaPolicyController) Reconcile(ctx context.Context, req reconcile.Req
 // for the changed QuotaPolicy only, updates the cache, and pushes the merged
 // configs to the xDS runner.
 func (c *QuotaPolicyController) syncQuotaPolicy(ctx context.Context, policy *aigv1a1.QuotaPolicy) error {
+	for i := range policy.Spec.PerModelQuotas {
+		if err := validateQuotaStreamingMode(policy, &policy.Spec.PerModelQuotas[i]); err != nil {
+			return fmt.Errorf("invalid quota %d of QuotaPolicy %s/%s: %w", i, policy.Namespace, policy.Name, err)
+		}
+	}
 	// Resolve target backends for this policy.
 	var backends []*aigv1b1.AIServiceBackend
 	for _, ref := range policy.Spec.TargetRefs {
@@ -223,6 +223,39 @@ func TestQuotaPolicyController_Reconcile_InvalidDuration(t *testing.T) {
 	require.Equal(t, aigv1a1.ConditionTypeNotAccepted, updatedQP.Status.Conditions[0].Type)
 }
 
+func TestQuotaPolicyController_Reconcile_MixedUnitsCutOff(t *testing.T) {
+	fakeClient := requireNewFakeClientWithIndexesForQuotaPolicy(t)
+	c := NewQuotaPolicyController(fakeClient, fake2.NewClientset(), ctrl.Log, newTestRunner(t), make(chan event.GenericEvent, 100))
+	qp := &aigv1a1.QuotaPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "qp-mixed-units", Namespace: "default"},
+		Spec: aigv1a1.QuotaPolicySpec{
+			TargetRefs: []gwapiv1a2.LocalPolicyTargetReference{
+				{Kind: "AIServiceBackend", Group: "aigateway.envoyproxy.io", Name: "backend"},
+			},
+			PerModelQuotas: []aigv1a1.PerModelQuota{{
+				ModelName: ptrTo("gpt-4o"),
+				Quota: aigv1a1.QuotaDefinition{
+					StreamingMode: aigv1a1.QuotaStreamingModeCutOff,
+					DefaultBucket: aigv1a1.QuotaValue{Limit: 60, Duration: "1m", Unit: aigv1a1.QuotaUnitRequests},
+					BucketRules: []aigv1a1.QuotaRule{
+						{Quota: aigv1a1.QuotaValue{Limit: 1000, Duration: "1m", Unit: aigv1a1.QuotaUnitTotalTokens}},
+					},
+				},
+			}},
+		},
+	}
+	require.NoError(t, fakeClient.Create(t.Context(), qp))
+
+	// The remaining requests reported by the rate limit service can't be compared with the tokens of the stream.
+	_, err := c.Reconcile(t.Context(), reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: qp.Name}})
+	require.ErrorContains(t, err, "invalid quota 0 of QuotaPolicy default/qp-mixed-units: the CutOff streaming mode doesn't support the Requests unit")
+
+	var updatedQP aigv1a1.QuotaPolicy
+	require.NoError(t, fakeClient.Get(t.Context(), types.NamespacedName{Namespace: "default", Name: qp.Name}, &updatedQP))
+	require.Len(t, updatedQP.Status.Conditions, 1)
+	require.Equal(t, aigv1a1.ConditionTypeNotAccepted, updatedQP.Status.Conditions[0].Type)
+}
+
 func TestQuotaPolicyController_Reconcile_Deletion(t *testing.T) {
 	fakeClient := requireNewFakeClientWithIndexesForQuotaPolicy(t)
 	rateLimitRunner := newTestRunner(t)
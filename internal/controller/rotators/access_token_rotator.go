@@ -0,0 +1,146 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package rotators
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/envoyproxy/ai-gateway/internal/controller/tokenprovider"
+)
+
+const (
+	// IBMCloudAccessTokenKey is the key used to store IBM Cloud IAM access token in Kubernetes secrets.
+	IBMCloudAccessTokenKey = "ibmCloudAccessToken"
+	// SAPAICoreAccessTokenKey is the key used to store SAP AI Core access token in Kubernetes secrets.
+	SAPAICoreAccessTokenKey = "sapAICoreAccessToken"
+)
+
+// accessTokenRotator implements Rotator interface for the bearer access tokens obtained by a token provider
+// and stored under a single key of the generated secret.
+type accessTokenRotator struct {
+	// client is used for Kubernetes API operations.
+	client client.Client
+	// logger is used for structured logging.
+	logger logr.Logger
+	// backendSecurityPolicyName provides name of backend security policy.
+	backendSecurityPolicyName string
+	// backendSecurityPolicyNamespace provides namespace of backend security policy.
+	backendSecurityPolicyNamespace string
+	// preRotationWindow specifies how long before expiry to rotate.
+	preRotationWindow time.Duration
+	// tokenProvider specifies provider to fetch the access token.
+	tokenProvider tokenprovider.TokenProvider
+	// secretKey is the key of the access token in the generated secret.
+	secretKey string
+}
+
+// NewAccessTokenRotator creates a new Rotator storing the access tokens of the given provider under secretKey.
+func NewAccessTokenRotator(
+	client client.Client,
+	logger logr.Logger,
+	backendSecurityPolicyNamespace string,
+	backendSecurityPolicyName string,
+	preRotationWindow time.Duration,
+	tokenProvider tokenprovider.TokenProvider,
+	secretKey string,
+) Rotator {
+	return &accessTokenRotator{
+		client:                         client,
+		logger:                         logger.WithName("access-token-rotator"),
+		backendSecurityPolicyNamespace: backendSecurityPolicyNamespace,
+		backendSecurityPolicyName:      backendSecurityPolicyName,
+		preRotationWindow:              preRotationWindow,
+		tokenProvider:                  tokenProvider,
+		secretKey:                      secretKey,
+	}
+}
+
+// IsExpired implements Rotator.IsExpired method to check if the preRotation time is before the current time.
+func (r *accessTokenRotator) IsExpired(preRotationExpirationTime time.Time) bool {
+	return IsBufferedTimeExpired(0, preRotationExpirationTime)
+}
+
+// GetPreRotationTime implements Rotator.GetPreRotationTime method to retrieve the pre-rotation time for the access token.
+func (r *accessTokenRotator) GetPreRotationTime(ctx context.Context) (time.Time, error) {
+	secret, err := LookupSecret(ctx, r.client, r.backendSecurityPolicyNamespace, GetBSPSecretName(r.backendSecurityPolicyName))
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return time.Time{}, nil
+		}
+		return time.Time{}, err
+	}
+	// The token of a different kind is rotated right away when the type of the policy changes.
+	if _, ok := secret.Data[r.secretKey]; !ok {
+		return time.Time{}, nil
+	}
+	expirationTime, err := GetExpirationSecretAnnotation(secret)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return expirationTime.Add(-r.preRotationWindow), nil
+}
+
+// Rotate implements Rotator.Rotate method to rotate the access token and updates the Kubernetes secret.
+func (r *accessTokenRotator) Rotate(ctx context.Context) (time.Time, error) {
+	bspNamespace := r.backendSecurityPolicyNamespace
+	bspName := r.backendSecurityPolicyName
+	secretName := GetBSPSecretName(bspName)
+
+	r.logger.Info("start rotating access token", "namespace", bspNamespace, "name", bspName, "key", r.secretKey)
+
+	token, err := r.tokenProvider.GetToken(ctx)
+	if err != nil {
+		r.logger.Error(err, "failed to get access token", "namespace", bspNamespace, "name", bspName)
+		return time.Time{}, err
+	}
+	secret, err := LookupSecret(ctx, r.client, bspNamespace, secretName)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			r.logger.Info("creating a new access token into secret", "namespace", bspNamespace, "name", bspName)
+			secret = &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      secretName,
+					Namespace: bspNamespace,
+				},
+				Type: corev1.SecretTypeOpaque,
+				Data: make(map[string][]byte),
+			}
+			r.populateAccessToken(secret, &token)
+			if err = r.client.Create(ctx, secret); err != nil {
+				r.logger.Error(err, "failed to create access token secret", "namespace", bspNamespace, "name", bspName)
+				return time.Time{}, err
+			}
+			return token.ExpiresAt, nil
+		}
+		r.logger.Error(err, "failed to lookup access token secret", "namespace", bspNamespace, "name", bspName)
+		return time.Time{}, err
+	}
+	r.logger.Info("updating access token secret", "namespace", bspNamespace, "name", bspName)
+
+	r.populateAccessToken(secret, &token)
+	if err = r.client.Update(ctx, secret); err != nil {
+		r.logger.Error(err, "failed to update access token secret", "namespace", bspNamespace, "name", bspName)
+		return time.Time{}, err
+	}
+	return token.ExpiresAt, nil
+}
+
+// populateAccessToken updates the secret with the access token.
+func (r *accessTokenRotator) populateAccessToken(secret *corev1.Secret, token *tokenprovider.TokenExpiry) {
+	updateExpirationSecretAnnotation(secret, token.ExpiresAt)
+
+	if secret.Data == nil {
+		secret.Data = make(map[string][]byte)
+	}
+	secret.Data[r.secretKey] = []byte(token.Token)
+}
@@ -0,0 +1,77 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package rotators
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/envoyproxy/ai-gateway/internal/controller/tokenprovider"
+)
+
+func TestAccessTokenRotator(t *testing.T) {
+	scheme := runtime.NewScheme()
+	scheme.AddKnownTypes(corev1.SchemeGroupVersion, &corev1.Secret{})
+	client := fake.NewClientBuilder().WithScheme(scheme).Build()
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Second).UTC()
+
+	t.Run("failed to get token", func(t *testing.T) {
+		provider := tokenprovider.NewMockTokenProvider("", time.Time{}, fmt.Errorf("invalid api key"))
+		rotator := NewAccessTokenRotator(client, logr.Discard(), "default", "test-policy", 5*time.Minute, provider, IBMCloudAccessTokenKey)
+		_, err := rotator.Rotate(t.Context())
+		require.ErrorContains(t, err, "invalid api key")
+	})
+
+	provider := tokenprovider.NewMockTokenProvider("first-token", expiresAt, nil)
+	rotator := NewAccessTokenRotator(client, logr.Discard(), "default", "test-policy", 5*time.Minute, provider, IBMCloudAccessTokenKey)
+
+	t.Run("secret does not exist", func(t *testing.T) {
+		preRotationTime, err := rotator.GetPreRotationTime(t.Context())
+		require.NoError(t, err)
+		require.True(t, rotator.IsExpired(preRotationTime))
+
+		expiration, err := rotator.Rotate(t.Context())
+		require.NoError(t, err)
+		require.Equal(t, expiresAt, expiration)
+		secret, err := LookupSecret(t.Context(), client, "default", GetBSPSecretName("test-policy"))
+		require.NoError(t, err)
+		require.Equal(t, "first-token", string(secret.Data[IBMCloudAccessTokenKey]))
+
+		preRotationTime, err = rotator.GetPreRotationTime(t.Context())
+		require.NoError(t, err)
+		require.Equal(t, expiresAt.Add(-5*time.Minute), preRotationTime)
+		require.False(t, rotator.IsExpired(preRotationTime))
+	})
+
+	t.Run("secret exists", func(t *testing.T) {
+		secret, err := LookupSecret(t.Context(), client, "default", GetBSPSecretName("test-policy"))
+		require.NoError(t, err)
+		secret.Annotations[ExpirationTimeAnnotationKey] = time.Now().Add(-time.Hour).Format(time.RFC3339)
+		require.NoError(t, client.Update(t.Context(), secret))
+
+		rotator.(*accessTokenRotator).tokenProvider = tokenprovider.NewMockTokenProvider("second-token", expiresAt, nil)
+		_, err = rotator.Rotate(t.Context())
+		require.NoError(t, err)
+		secret, err = LookupSecret(t.Context(), client, "default", GetBSPSecretName("test-policy"))
+		require.NoError(t, err)
+		require.Equal(t, "second-token", string(secret.Data[IBMCloudAccessTokenKey]))
+		require.Equal(t, expiresAt.Format(time.RFC3339), secret.Annotations[ExpirationTimeAnnotationKey])
+	})
+
+	t.Run("token of another kind", func(t *testing.T) {
+		sapRotator := NewAccessTokenRotator(client, logr.Discard(), "default", "test-policy", 5*time.Minute, provider, SAPAICoreAccessTokenKey)
+		preRotationTime, err := sapRotator.GetPreRotationTime(t.Context())
+		require.NoError(t, err)
+		require.True(t, sapRotator.IsExpired(preRotationTime))
+	})
+}
@@ -71,7 +71,9 @@ func NewAWSOIDCRotator(
 		return nil, fmt.Errorf("failed to load AWS config: %w", err)
 	}
 	cfg.Region = region
-	if proxyURL := os.Getenv("AI_GATEWAY_STS_PROXY_URL"); proxyURL != "" {
+	if hc := tokenprovider.HTTPClientFromContext(ctx); hc != nil {
+		cfg.HTTPClient = hc
+	} else if proxyURL := os.Getenv("AI_GATEWAY_STS_PROXY_URL"); proxyURL != "" {
 		cfg.HTTPClient = &http.Client{
 			Transport: &http.Transport{
 				Proxy: func(*http.Request) (*url.URL, error) {
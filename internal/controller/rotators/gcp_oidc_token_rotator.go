@@ -268,7 +268,7 @@ var _ stsTokenGenerator = exchangeJWTForSTSToken
 func exchangeJWTForSTSToken(ctx context.Context, jwtToken string, wifConfig *aigv1b1.GCPWorkloadIdentityFederationConfig, opts ...option.ClientOption) (*tokenprovider.TokenExpiry, error) {
 	// This step does not pass the token via the auth header.
 	// The empty string implies that the auth header will be skipped.
-	roundTripper, err := newBearerAuthRoundTripper("")
+	roundTripper, err := newBearerAuthRoundTripper(ctx, "")
 	if err != nil {
 		return nil, fmt.Errorf("error creating HTTP transport for STS token exchange: %w", err)
 	}
@@ -323,9 +323,15 @@ type bearerAuthRoundTripper struct {
 	token string
 }
 
-func newBearerAuthRoundTripper(token string) (http.RoundTripper, error) {
+// newBearerAuthRoundTripper returns a RoundTripper adding the token to the requests. The transport of the HTTP
+// client set by tokenprovider.WithHTTPClient on ctx, if any, is used instead of the shared GCP transport.
+func newBearerAuthRoundTripper(ctx context.Context, token string) (http.RoundTripper, error) {
+	base := sharedGCPTransport
+	if hc := tokenprovider.HTTPClientFromContext(ctx); hc != nil && hc.Transport != nil {
+		base = hc.Transport
+	}
 	return &bearerAuthRoundTripper{
-		base:  sharedGCPTransport,
+		base:  base,
 		token: token,
 	}, nil
 }
@@ -362,7 +368,7 @@ func impersonateServiceAccount(ctx context.Context, stsToken string, saConfig ai
 
 	// Use the STS token as the source token for impersonation.
 	// Create an HTTP client with a custom RoundTripper that adds the Bearer token Authorization header.
-	roundTripper, err := newBearerAuthRoundTripper(stsToken)
+	roundTripper, err := newBearerAuthRoundTripper(ctx, stsToken)
 	if err != nil {
 		return nil, fmt.Errorf("error creating BearerAuthRoundTripper: %w", err)
 	}
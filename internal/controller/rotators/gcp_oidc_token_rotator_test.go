@@ -881,7 +881,7 @@ func TestNewBearerAuthRoundTripper(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			roundTripper, err := newBearerAuthRoundTripper(tt.token)
+			roundTripper, err := newBearerAuthRoundTripper(context.Background(), tt.token)
 
 			require.NoError(t, err)
 			require.NotNil(t, roundTripper)
@@ -895,6 +895,16 @@ func TestNewBearerAuthRoundTripper(t *testing.T) {
 	}
 }
 
+func TestNewBearerAuthRoundTripper_HTTPClientFromContext(t *testing.T) {
+	transport := &http.Transport{}
+	ctx := tokenprovider.WithHTTPClient(context.Background(), &http.Client{Transport: transport})
+	roundTripper, err := newBearerAuthRoundTripper(ctx, "test-token")
+	require.NoError(t, err)
+	bearerRT, ok := roundTripper.(*bearerAuthRoundTripper)
+	require.True(t, ok)
+	require.Same(t, transport, bearerRT.base)
+}
+
 func TestBearerAuthRoundTripper_RoundTrip(t *testing.T) {
 	tests := []struct {
 		name             string
@@ -929,7 +939,7 @@ func TestBearerAuthRoundTripper_RoundTrip(t *testing.T) {
 			defer server.Close()
 
 			// Create the round tripper.
-			roundTripper, err := newBearerAuthRoundTripper(tt.token)
+			roundTripper, err := newBearerAuthRoundTripper(context.Background(), tt.token)
 			require.NoError(t, err)
 
 			// Create a request to the test server.
@@ -23,10 +23,11 @@ import (
 
 // secretController implements reconcile.TypedReconciler for corev1.Secret.
 type secretController struct {
-	client                                            client.Client
-	kubeClient                                        kubernetes.Interface
-	logger                                            logr.Logger
-	backendSecurityPolicyEventChan, mcpRouteEventChan chan event.GenericEvent
+	client                                                                     client.Client
+	kubeClient                                                                 kubernetes.Interface
+	logger                                                                     logr.Logger
+	backendSecurityPolicyEventChan, mcpRouteEventChan, aiGatewayRouteEventChan chan event.GenericEvent
+	gatewayConfigEventChan                                                     chan event.GenericEvent
 }
 
 // NewSecretController creates a new reconcile.TypedReconciler[reconcile.Request] for corev1.Secret.
@@ -34,6 +35,8 @@ func NewSecretController(client client.Client, kubeClient kubernetes.Interface,
 	logger logr.Logger,
 	backendSecurityPolicyEventChan chan event.GenericEvent,
 	mcpRouteEventChan chan event.GenericEvent,
+	aiGatewayRouteEventChan chan event.GenericEvent,
+	gatewayConfigEventChan chan event.GenericEvent,
 ) reconcile.TypedReconciler[reconcile.Request] {
 	return &secretController{
 		client:                         client,
@@ -41,6 +44,8 @@ func NewSecretController(client client.Client, kubeClient kubernetes.Interface,
 		logger:                         logger,
 		backendSecurityPolicyEventChan: backendSecurityPolicyEventChan,
 		mcpRouteEventChan:              mcpRouteEventChan,
+		aiGatewayRouteEventChan:        aiGatewayRouteEventChan,
+		gatewayConfigEventChan:         gatewayConfigEventChan,
 	}
 }
 
@@ -93,5 +98,37 @@ func (c *secretController) syncSecret(ctx context.Context, namespace, name strin
 			"namespace", mcpRoute.Namespace, "name", mcpRoute.Name)
 		c.mcpRouteEventChan <- event.GenericEvent{Object: mcpRoute}
 	}
+
+	var aiGatewayRoutes aigv1b1.AIGatewayRouteList
+	err = c.client.List(ctx, &aiGatewayRoutes,
+		client.MatchingFields{
+			k8sClientIndexSecretToReferencingAIGatewayRoute: fmt.Sprintf("%s.%s", name, namespace),
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to list AIGatewayRouteList: %w", err)
+	}
+	for i := range aiGatewayRoutes.Items {
+		aiGatewayRoute := &aiGatewayRoutes.Items[i]
+		c.logger.Info("Syncing AIGatewayRoute",
+			"namespace", aiGatewayRoute.Namespace, "name", aiGatewayRoute.Name)
+		c.aiGatewayRouteEventChan <- event.GenericEvent{Object: aiGatewayRoute}
+	}
+
+	var gatewayConfigs aigv1b1.GatewayConfigList
+	err = c.client.List(ctx, &gatewayConfigs,
+		client.MatchingFields{
+			k8sClientIndexSecretToReferencingGatewayConfig: fmt.Sprintf("%s.%s", name, namespace),
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to list GatewayConfigList: %w", err)
+	}
+	for i := range gatewayConfigs.Items {
+		gatewayConfig := &gatewayConfigs.Items[i]
+		c.logger.Info("Syncing GatewayConfig",
+			"namespace", gatewayConfig.Namespace, "name", gatewayConfig.Name)
+		c.gatewayConfigEventChan <- event.GenericEvent{Object: gatewayConfig}
+	}
 	return nil
 }
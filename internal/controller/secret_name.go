@@ -73,6 +73,13 @@ func filterConfigBundleVolumeName(gwName, gwNamespace string) string {
 	return base + suffix
 }
 
+// example: ai-gateway-gateway-ns1-c6d39be275c7
+func extProcPodDisruptionBudgetName(gwName, gwNamespace string) string {
+	rawIdentity := fmt.Sprintf("%s/%s", gwNamespace, gwName)
+	base := fmt.Sprintf("%s%s-%s", mutationNamePrefix, gwName, gwNamespace)
+	return truncateAndAppendHash(base, shortStableHash(rawIdentity), k8sObjectNameMaxLen)
+}
+
 // example: gateway-ns1
 func legacyFilterConfigSecretName(gwName, gwNamespace string) string {
 	return fmt.Sprintf("%s-%s", gwName, gwNamespace)
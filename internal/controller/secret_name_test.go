@@ -216,3 +216,10 @@ func TestLegacyFilterConfigVolumeName(t *testing.T) {
 		})
 	}
 }
+
+func TestExtProcPodDisruptionBudgetName(t *testing.T) {
+	require.Equal(t, "ai-gateway-gateway-ns1-c6d39be275c7", extProcPodDisruptionBudgetName("gateway", "ns1"))
+	got := extProcPodDisruptionBudgetName(strings.Repeat("gw", 200), "default")
+	require.LessOrEqual(t, len(got), k8sObjectNameMaxLen)
+	require.True(t, strings.HasPrefix(got, mutationNamePrefix))
+}
@@ -27,8 +27,10 @@ import (
 func TestSecretController_Reconcile(t *testing.T) {
 	bspCh := internaltesting.NewControllerEventChan[*aigv1b1.BackendSecurityPolicy]()
 	mcpRouteCh := internaltesting.NewControllerEventChan[*aigv1b1.MCPRoute]()
+	aiGatewayRouteCh := internaltesting.NewControllerEventChan[*aigv1b1.AIGatewayRoute]()
+	gatewayConfigCh := internaltesting.NewControllerEventChan[*aigv1b1.GatewayConfig]()
 	fakeClient := requireNewFakeClientWithIndexes(t)
-	c := NewSecretController(fakeClient, fake2.NewClientset(), ctrl.Log, bspCh.Ch, mcpRouteCh.Ch)
+	c := NewSecretController(fakeClient, fake2.NewClientset(), ctrl.Log, bspCh.Ch, mcpRouteCh.Ch, aiGatewayRouteCh.Ch, gatewayConfigCh.Ch)
 
 	err := fakeClient.Create(t.Context(), &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{Name: "mysecret", Namespace: "default"},
@@ -73,6 +75,27 @@ func TestSecretController_Reconcile(t *testing.T) {
 	}
 	require.NoError(t, fakeClient.Create(t.Context(), mcp))
 
+	// Create an AIGatewayRoute that references the secret via BackendOverride.
+	route := &aigv1b1.AIGatewayRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-route", Namespace: "default"},
+		Spec: aigv1b1.AIGatewayRouteSpec{
+			BackendOverride: &aigv1b1.AIGatewayRouteBackendOverride{SecretRef: &gwapiv1.SecretObjectReference{Name: "mysecret"}},
+		},
+	}
+	require.NoError(t, fakeClient.Create(t.Context(), route))
+
+	// Create a GatewayConfig that references the secret via ResponseAttestation.
+	gatewayConfig := &aigv1b1.GatewayConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-config", Namespace: "default"},
+		Spec: aigv1b1.GatewayConfigSpec{
+			ResponseAttestation: &aigv1b1.ResponseAttestation{
+				Identity:  "gw.example.com",
+				SecretRef: &gwapiv1.SecretObjectReference{Name: "mysecret"},
+			},
+		},
+	}
+	require.NoError(t, fakeClient.Create(t.Context(), gatewayConfig))
+
 	_, err = c.Reconcile(t.Context(), reconcile.Request{NamespacedName: types.NamespacedName{
 		Namespace: "default", Name: "mysecret",
 	}})
@@ -91,6 +114,12 @@ func TestSecretController_Reconcile(t *testing.T) {
 	mcpActual := mcpRouteCh.RequireItemsEventually(t, 1)
 	require.Equal(t, mcp, mcpActual[0])
 
+	routeActual := aiGatewayRouteCh.RequireItemsEventually(t, 1)
+	require.Equal(t, route, routeActual[0])
+
+	gatewayConfigActual := gatewayConfigCh.RequireItemsEventually(t, 1)
+	require.Equal(t, gatewayConfig, gatewayConfigActual[0])
+
 	// Test the case where the Secret is being deleted.
 	err = fakeClient.Delete(t.Context(), &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{Name: "mysecret", Namespace: "default"},
@@ -0,0 +1,267 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/google/uuid"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	gwapiv1b1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	aigv1b1 "github.com/envoyproxy/ai-gateway/api/v1beta1"
+)
+
+// ShardKey is what the AIGatewayRoutes are assigned to the shards by.
+type ShardKey string
+
+const (
+	// ShardKeyNamespace assigns all the AIGatewayRoutes of a namespace to the same shard.
+	ShardKeyNamespace ShardKey = "namespace"
+	// ShardKeyName assigns the AIGatewayRoutes to the shards by their namespaced name.
+	ShardKeyName ShardKey = "name"
+)
+
+const (
+	// routeShardLeasePrefix prefixes the names of the leases electing the leader of each shard.
+	routeShardLeasePrefix = "envoy-ai-gateway-controller-route-shard-"
+	// The timings of the leader election of the shards, which are the defaults of the controller manager.
+	routeShardLeaseDuration = 15 * time.Second
+	routeShardRenewDeadline = 10 * time.Second
+	routeShardRetryPeriod   = 2 * time.Second
+)
+
+// ShardingOptions configures the sharding of the AIGatewayRoute reconciliation across the controller replicas.
+//
+// Each replica is a candidate of the shard at Index, and the leader of the shard, elected with a lease, reconciles
+// the AIGatewayRoutes assigned to it. The other controllers, including the Gateway controller aggregating the routes
+// into the filter configs, still run only on the leader of the controller manager.
+type ShardingOptions struct {
+	// Shards is the number of shards. Sharding is disabled if this is less than two.
+	Shards int
+	// Index is the shard this replica is a candidate of, in [0, Shards).
+	Index int
+	// Key is what the AIGatewayRoutes are assigned to the shards by. Defaults to ShardKeyNamespace.
+	Key ShardKey
+	// LeaseNamespace is the namespace of the leases electing the leader of each shard.
+	LeaseNamespace string
+}
+
+// enabled returns true if the AIGatewayRoutes are sharded.
+func (o *ShardingOptions) enabled() bool { return o.Shards > 1 }
+
+// shardOf returns the shard of the AIGatewayRoute among the given number of shards. The shards are assigned with a
+// consistent hash, so that only about 1/n of the routes move when the number of shards grows to n.
+func shardOf(key ShardKey, shards int, namespace, name string) int {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(namespace))
+	if key == ShardKeyName {
+		_, _ = h.Write([]byte{'/'})
+		_, _ = h.Write([]byte(name))
+	}
+	return jumpHash(h.Sum64(), shards)
+}
+
+// jumpHash is the jump consistent hash of Lamping and Veach, see https://arxiv.org/abs/1406.2294.
+func jumpHash(key uint64, buckets int) int {
+	var b, j int64 = -1, 0
+	for j < int64(buckets) {
+		b = j
+		key = key*2862933555777941757 + 1
+		j = int64(float64(b+1) * (float64(int64(1)<<31) / float64((key>>33)+1)))
+	}
+	return int(b)
+}
+
+// routeShard tracks whether this replica leads its shard of the AIGatewayRoutes.
+//
+// This implements [manager.Runnable] running the leader election of the shard on every replica, independently of
+// the leader election of the controller manager.
+type routeShard struct {
+	client  client.Client
+	kube    kubernetes.Interface
+	logger  logr.Logger
+	options ShardingOptions
+	// leaderElection is false if the leader election is disabled, in which case this replica leads its shard.
+	leaderElection bool
+	// elected is closed once this replica is the leader of the controller manager.
+	elected <-chan struct{}
+	// aiGatewayRouteEventChan re-enqueues the AIGatewayRoutes of the shard once this replica leads it, since their
+	// events were ignored until then.
+	aiGatewayRouteEventChan chan event.GenericEvent
+	// identity is the holder identity of this replica in the lease.
+	identity string
+	leading  atomic.Bool
+}
+
+func newRouteShard(c client.Client, kube kubernetes.Interface, logger logr.Logger, options ShardingOptions,
+	leaderElection bool, elected <-chan struct{}, aiGatewayRouteEventChan chan event.GenericEvent,
+) *routeShard {
+	hostname, _ := os.Hostname()
+	return &routeShard{
+		client:                  c,
+		kube:                    kube,
+		logger:                  logger,
+		options:                 options,
+		leaderElection:          leaderElection,
+		elected:                 elected,
+		aiGatewayRouteEventChan: aiGatewayRouteEventChan,
+		identity:                hostname + "_" + uuid.NewString(),
+	}
+}
+
+// owns returns true if this replica leads the shard of the AIGatewayRoute.
+func (s *routeShard) owns(namespace, name string) bool {
+	return s.leading.Load() && shardOf(s.options.Key, s.options.Shards, namespace, name) == s.options.Index
+}
+
+// isManagerLeader returns true if this replica is the leader of the controller manager, i.e. runs the Gateway
+// controller.
+func (s *routeShard) isManagerLeader() bool {
+	select {
+	case <-s.elected:
+		return true
+	default:
+		return false
+	}
+}
+
+// NeedLeaderElection implements [manager.LeaderElectionRunnable]. The shards are elected on every replica.
+func (s *routeShard) NeedLeaderElection() bool { return false }
+
+// Start implements [manager.Runnable]. This campaigns for the lease of the shard until ctx is done.
+func (s *routeShard) Start(ctx context.Context) error {
+	if !s.leaderElection {
+		s.startLeading(ctx)
+		<-ctx.Done()
+		return nil
+	}
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta:  metav1.ObjectMeta{Namespace: s.options.LeaseNamespace, Name: fmt.Sprintf("%s%d", routeShardLeasePrefix, s.options.Index)},
+		Client:     s.kube.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{Identity: s.identity},
+	}
+	elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock:            lock,
+		LeaseDuration:   routeShardLeaseDuration,
+		RenewDeadline:   routeShardRenewDeadline,
+		RetryPeriod:     routeShardRetryPeriod,
+		ReleaseOnCancel: true,
+		Name:            lock.LeaseMeta.Name,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: s.startLeading,
+			OnStoppedLeading: func() {
+				s.leading.Store(false)
+				s.logger.Info("stopped leading the AIGatewayRoute shard", "shard", s.options.Index)
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create the leader elector of the AIGatewayRoute shard %d: %w", s.options.Index, err)
+	}
+	// Run returns once the lease is lost, after which this replica campaigns again.
+	for ctx.Err() == nil {
+		elector.Run(ctx)
+	}
+	return nil
+}
+
+// startLeading marks the shard as led by this replica and enqueues its AIGatewayRoutes.
+func (s *routeShard) startLeading(ctx context.Context) {
+	s.leading.Store(true)
+	s.logger.Info("started leading the AIGatewayRoute shard", "shard", s.options.Index)
+	var routes aigv1b1.AIGatewayRouteList
+	if err := s.client.List(ctx, &routes); err != nil {
+		s.logger.Error(err, "failed to list the AIGatewayRoutes of the shard", "shard", s.options.Index)
+		return
+	}
+	for i := range routes.Items {
+		route := &routes.Items[i]
+		if !s.owns(route.Namespace, route.Name) {
+			continue
+		}
+		select {
+		case s.aiGatewayRouteEventChan <- event.GenericEvent{Object: route}:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// handler returns the handler enqueuing the AIGatewayRoutes referencing the changed object, as listed by the given
+// function. This lets the leaders of the shards follow the changes of the objects reconciled only on the leader of
+// the controller manager, whose controllers only notify the AIGatewayRoute controller of the latter.
+func (s *routeShard) handler(list func(context.Context, client.Object) ([]*aigv1b1.AIGatewayRoute, error)) handler.EventHandler {
+	return handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, obj client.Object) []reconcile.Request {
+		routes, err := list(ctx, obj)
+		if err != nil {
+			s.logger.Error(err, "failed to list the AIGatewayRoutes referencing the object",
+				"namespace", obj.GetNamespace(), "name", obj.GetName())
+			return nil
+		}
+		var requests []reconcile.Request
+		for _, route := range routes {
+			if s.owns(route.Namespace, route.Name) {
+				requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(route)})
+			}
+		}
+		return requests
+	})
+}
+
+// backendAIGatewayRoutes lists the AIGatewayRoutes referencing the AIServiceBackend.
+func (s *routeShard) backendAIGatewayRoutes(ctx context.Context, obj client.Object) ([]*aigv1b1.AIGatewayRoute, error) {
+	var routes aigv1b1.AIGatewayRouteList
+	key := fmt.Sprintf("%s.%s", obj.GetName(), obj.GetNamespace())
+	if err := s.client.List(ctx, &routes, client.MatchingFields{k8sClientIndexBackendToReferencingAIGatewayRoute: key}); err != nil {
+		return nil, fmt.Errorf("failed to list AIGatewayRoutes referencing AIServiceBackend %s: %w", key, err)
+	}
+	ret := make([]*aigv1b1.AIGatewayRoute, len(routes.Items))
+	for i := range routes.Items {
+		ret[i] = &routes.Items[i]
+	}
+	return ret, nil
+}
+
+// referenceGrantAIGatewayRoutes lists the AIGatewayRoutes whose cross-namespace references the ReferenceGrant may
+// allow.
+func (s *routeShard) referenceGrantAIGatewayRoutes(ctx context.Context, obj client.Object) ([]*aigv1b1.AIGatewayRoute, error) {
+	grant, ok := obj.(*gwapiv1b1.ReferenceGrant)
+	if !ok {
+		return nil, nil
+	}
+	return (&ReferenceGrantController{client: s.client, logger: s.logger}).getAffectedAIGatewayRoutes(ctx, grant)
+}
+
+// aiGatewayRouteToGateways maps the AIGatewayRoute to the Gateways it is attached to.
+func aiGatewayRouteToGateways(_ context.Context, obj client.Object) []reconcile.Request {
+	route, ok := obj.(*aigv1b1.AIGatewayRoute)
+	if !ok {
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(route.Spec.ParentRefs))
+	for _, p := range route.Spec.ParentRefs {
+		namespace := route.Namespace
+		if p.Namespace != nil {
+			namespace = string(*p.Namespace)
+		}
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKey{Namespace: namespace, Name: string(p.Name)}})
+	}
+	return requests
+}
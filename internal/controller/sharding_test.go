@@ -0,0 +1,144 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package controller
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	fake2 "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gwapiv1a2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+
+	aigv1b1 "github.com/envoyproxy/ai-gateway/api/v1beta1"
+	internaltesting "github.com/envoyproxy/ai-gateway/internal/testing"
+)
+
+func TestShardOf(t *testing.T) {
+	const routes = 10000
+	counts := make([]int, 4)
+	moved := 0
+	for i := range routes {
+		namespace := fmt.Sprintf("ns-%d", i)
+		shard := shardOf(ShardKeyNamespace, 4, namespace, "route")
+		require.Equal(t, shard, shardOf(ShardKeyNamespace, 4, namespace, "other"), "the namespace key ignores the name")
+		counts[shard]++
+		// Growing the shards only moves the routes to the new shard.
+		if grown := shardOf(ShardKeyNamespace, 5, namespace, "route"); grown != shard {
+			require.Equal(t, 4, grown)
+			moved++
+		}
+	}
+	for _, count := range counts {
+		require.InDelta(t, routes/4, count, routes/20)
+	}
+	require.InDelta(t, routes/5, moved, routes/20)
+
+	require.Zero(t, shardOf(ShardKeyName, 1, "default", "route"))
+	require.NotEqual(t,
+		[]int{shardOf(ShardKeyName, 8, "default", "a"), shardOf(ShardKeyName, 8, "default", "b"), shardOf(ShardKeyName, 8, "default", "c")},
+		[]int{shardOf(ShardKeyNamespace, 8, "default", "a"), shardOf(ShardKeyNamespace, 8, "default", "b"), shardOf(ShardKeyNamespace, 8, "default", "c")})
+}
+
+// namespacesOfShards returns a namespace of each of the two shards.
+func namespacesOfShards(t *testing.T) (owned, unowned string) {
+	for i := 0; owned == "" || unowned == ""; i++ {
+		require.Less(t, i, 100)
+		namespace := fmt.Sprintf("ns-%d", i)
+		if shardOf(ShardKeyNamespace, 2, namespace, "") == 0 {
+			owned = namespace
+		} else {
+			unowned = namespace
+		}
+	}
+	return
+}
+
+func TestRouteShard_owns(t *testing.T) {
+	owned, unowned := namespacesOfShards(t)
+	s := &routeShard{options: ShardingOptions{Shards: 2, Index: 0, Key: ShardKeyNamespace}}
+	require.False(t, s.owns(owned, "route"), "the shard is not led yet")
+	s.leading.Store(true)
+	require.True(t, s.owns(owned, "route"))
+	require.False(t, s.owns(unowned, "route"))
+}
+
+func TestRouteShard_startLeading(t *testing.T) {
+	owned, unowned := namespacesOfShards(t)
+	fakeClient := requireNewFakeClientWithIndexes(t)
+	for _, namespace := range []string{owned, unowned} {
+		require.NoError(t, fakeClient.Create(t.Context(), &aigv1b1.AIGatewayRoute{
+			ObjectMeta: metav1.ObjectMeta{Name: "route", Namespace: namespace},
+		}))
+	}
+	ch := make(chan event.GenericEvent, 10)
+	s := newRouteShard(fakeClient, nil, logr.Discard(), ShardingOptions{Shards: 2, Key: ShardKeyNamespace}, false, nil, ch)
+	s.startLeading(t.Context())
+	require.Len(t, ch, 1)
+	require.Equal(t, owned, (<-ch).Object.GetNamespace())
+}
+
+func TestAIGatewayRouteController_Reconcile_Sharded(t *testing.T) {
+	owned, unowned := namespacesOfShards(t)
+	fakeClient := requireNewFakeClientWithIndexes(t)
+	eventCh := internaltesting.NewControllerEventChan[*gwapiv1.Gateway]()
+	c := NewAIGatewayRouteController(fakeClient, fake2.NewClientset(), logr.Discard(), eventCh.Ch, "/")
+	elected := make(chan struct{})
+	c.shard = newRouteShard(fakeClient, nil, logr.Discard(), ShardingOptions{Shards: 2, Key: ShardKeyNamespace}, false, elected, nil)
+	c.shard.leading.Store(true)
+
+	require.NoError(t, fakeClient.Create(t.Context(), &gwapiv1.Gateway{ObjectMeta: metav1.ObjectMeta{Name: "gw", Namespace: "default"}}))
+	for _, namespace := range []string{owned, unowned} {
+		require.NoError(t, fakeClient.Create(t.Context(), &aigv1b1.AIGatewayRoute{
+			ObjectMeta: metav1.ObjectMeta{Name: "route", Namespace: namespace},
+			Spec: aigv1b1.AIGatewayRouteSpec{ParentRefs: []gwapiv1a2.ParentReference{
+				{Name: "gw", Namespace: ptr.To(gwapiv1a2.Namespace("default"))},
+			}},
+		}))
+	}
+	reconcileRoute := func(namespace string) *aigv1b1.AIGatewayRoute {
+		_, err := c.Reconcile(t.Context(), reconcile.Request{NamespacedName: types.NamespacedName{Namespace: namespace, Name: "route"}})
+		require.NoError(t, err)
+		var route aigv1b1.AIGatewayRoute
+		require.NoError(t, fakeClient.Get(t.Context(), types.NamespacedName{Namespace: namespace, Name: "route"}, &route))
+		return &route
+	}
+
+	// The route of the shard is reconciled, but the Gateway is not synced by the replica not leading the manager.
+	require.NotEmpty(t, reconcileRoute(owned).Status.Conditions)
+	require.Empty(t, eventCh.Ch)
+	// The route of another shard is left to the leader of that shard.
+	require.Empty(t, reconcileRoute(unowned).Status.Conditions)
+	require.Empty(t, eventCh.Ch)
+
+	// The leader of the manager forwards the events of the other shards to the Gateways.
+	close(elected)
+	require.Empty(t, reconcileRoute(unowned).Status.Conditions)
+	gateways := eventCh.RequireItemsEventually(t, 1)
+	require.Equal(t, "gw", gateways[0].Name)
+	// Deleted routes are ignored.
+	_, err := c.Reconcile(t.Context(), reconcile.Request{NamespacedName: types.NamespacedName{Namespace: unowned, Name: "deleted"}})
+	require.NoError(t, err)
+}
+
+func TestAIGatewayRouteToGateways(t *testing.T) {
+	require.Equal(t, []reconcile.Request{
+		{NamespacedName: types.NamespacedName{Namespace: "ns", Name: "a"}},
+		{NamespacedName: types.NamespacedName{Namespace: "other", Name: "b"}},
+	}, aiGatewayRouteToGateways(t.Context(), &aigv1b1.AIGatewayRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "route", Namespace: "ns"},
+		Spec: aigv1b1.AIGatewayRouteSpec{ParentRefs: []gwapiv1a2.ParentReference{
+			{Name: "a"}, {Name: "b", Namespace: ptr.To(gwapiv1a2.Namespace("other"))},
+		}},
+	}))
+}
@@ -23,8 +23,12 @@ type azureClientSecretTokenProvider struct {
 }
 
 // NewAzureClientSecretTokenProvider creates a new TokenProvider with the given tenant ID, client ID, client secret, and token request options.
-func NewAzureClientSecretTokenProvider(tenantID, clientID, clientSecret string, tokenOption policy.TokenRequestOptions) (TokenProvider, error) {
+// The HTTP client set by WithHTTPClient on ctx, if any, is used to reach Microsoft Entra ID.
+func NewAzureClientSecretTokenProvider(ctx context.Context, tenantID, clientID, clientSecret string, tokenOption policy.TokenRequestOptions) (TokenProvider, error) {
 	clientOptions := GetClientSecretCredentialOptions()
+	if hc := HTTPClientFromContext(ctx); hc != nil {
+		clientOptions = &azidentity.ClientSecretCredentialOptions{ClientOptions: azcore.ClientOptions{Transport: hc}}
+	}
 	credential, err := azidentity.NewClientSecretCredential(tenantID, clientID, clientSecret, clientOptions)
 	if err != nil {
 		return nil, err
@@ -15,14 +15,14 @@ import (
 )
 
 func TestNewAzureClientSecretTokenProvider(t *testing.T) {
-	_, err := NewAzureClientSecretTokenProvider("tenantID", "clientID", "", policy.TokenRequestOptions{})
+	_, err := NewAzureClientSecretTokenProvider(context.Background(), "tenantID", "clientID", "", policy.TokenRequestOptions{})
 	require.Error(t, err)
 	require.Contains(t, err.Error(), "secret can't be empty string")
 }
 
 func TestNewAzureClientSecretTokenProvider_GetToken(t *testing.T) {
 	t.Run("missing azure scope", func(t *testing.T) {
-		provider, err := NewAzureClientSecretTokenProvider("tenantID", "clientID", "clientSecret", policy.TokenRequestOptions{})
+		provider, err := NewAzureClientSecretTokenProvider(context.Background(), "tenantID", "clientID", "clientSecret", policy.TokenRequestOptions{})
 		require.NoError(t, err)
 
 		tokenExpiry, err := provider.GetToken(context.Background())
@@ -34,7 +34,7 @@ func TestNewAzureClientSecretTokenProvider_GetToken(t *testing.T) {
 
 	t.Run("invalid azure credential info", func(t *testing.T) {
 		scopes := []string{"some-azure-scope"}
-		provider, err := NewAzureClientSecretTokenProvider("invalidTenantID", "invalidClientID", "invalidClientSecret", policy.TokenRequestOptions{Scopes: scopes})
+		provider, err := NewAzureClientSecretTokenProvider(context.Background(), "invalidTenantID", "invalidClientID", "invalidClientSecret", policy.TokenRequestOptions{Scopes: scopes})
 		require.NoError(t, err)
 
 		_, err = provider.GetToken(context.Background())
@@ -23,8 +23,12 @@ type azureTokenProvider struct {
 }
 
 // NewAzureTokenProvider creates a new TokenProvider with the given tenant ID, client ID, tokenProvider, and token request options.
-func NewAzureTokenProvider(_ context.Context, tenantID, clientID string, tokenProvider TokenProvider, tokenOption policy.TokenRequestOptions) (TokenProvider, error) {
+// The HTTP client set by WithHTTPClient on ctx, if any, is used to reach Microsoft Entra ID.
+func NewAzureTokenProvider(ctx context.Context, tenantID, clientID string, tokenProvider TokenProvider, tokenOption policy.TokenRequestOptions) (TokenProvider, error) {
 	clientOptions := GetClientAssertionCredentialOptions()
+	if hc := HTTPClientFromContext(ctx); hc != nil {
+		clientOptions = &azidentity.ClientAssertionCredentialOptions{ClientOptions: azcore.ClientOptions{Transport: hc}}
+	}
 	credential, err := azidentity.NewClientAssertionCredential(tenantID, clientID, func(ctx context.Context) (string, error) {
 		token, err := tokenProvider.GetToken(ctx)
 		if err != nil {
@@ -0,0 +1,93 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package tokenprovider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+)
+
+// AzureFederatedTokenFileEnv is the environment variable set by the Azure Workload Identity webhook to the path of
+// the projected service account token.
+const AzureFederatedTokenFileEnv = "AZURE_FEDERATED_TOKEN_FILE"
+
+// azureWorkloadIdentityTokenProvider is a provider implements TokenProvider interface for Azure access tokens
+// obtained with the Microsoft Entra Workload ID federation.
+//
+// The token is cached and reused until it enters the refresh window. A new credential is created for each refresh
+// so that the projected service account token, which is rotated by the kubelet, is read again and the Azure SDK
+// cache, which only refreshes the token a few minutes before the expiry, does not return a token that is already
+// within the refresh window.
+type azureWorkloadIdentityTokenProvider struct {
+	newCredential func(hc *http.Client) (azcore.TokenCredential, error)
+	tokenOption   policy.TokenRequestOptions
+	refreshWindow time.Duration
+
+	mu     sync.Mutex
+	cached TokenExpiry
+}
+
+// NewAzureWorkloadIdentityTokenProvider creates a new TokenProvider with the given tenant ID, client ID, federated
+// token file path, refresh window and token request options. The token file path defaults to the value of
+// AzureFederatedTokenFileEnv if empty.
+func NewAzureWorkloadIdentityTokenProvider(tenantID, clientID, tokenFilePath string, refreshWindow time.Duration, tokenOption policy.TokenRequestOptions) (TokenProvider, error) {
+	if tokenFilePath == "" {
+		tokenFilePath = os.Getenv(AzureFederatedTokenFileEnv)
+	}
+	if tokenFilePath == "" {
+		return nil, fmt.Errorf("federated token file path is not specified and %s is not set", AzureFederatedTokenFileEnv)
+	}
+	newCredential := func(hc *http.Client) (azcore.TokenCredential, error) {
+		options := &azidentity.WorkloadIdentityCredentialOptions{
+			ClientID:      clientID,
+			TenantID:      tenantID,
+			TokenFilePath: tokenFilePath,
+		}
+		if clientOptions := GetClientAssertionCredentialOptions(); clientOptions != nil {
+			options.ClientOptions = clientOptions.ClientOptions
+		}
+		if hc != nil {
+			options.ClientOptions = azcore.ClientOptions{Transport: hc}
+		}
+		return azidentity.NewWorkloadIdentityCredential(options)
+	}
+	if _, err := newCredential(nil); err != nil {
+		return nil, err
+	}
+	return &azureWorkloadIdentityTokenProvider{
+		newCredential: newCredential,
+		tokenOption:   tokenOption,
+		refreshWindow: refreshWindow,
+	}, nil
+}
+
+// GetToken implements TokenProvider.GetToken method to retrieve an Azure access token and its expiration time.
+// The HTTP client set by WithHTTPClient on ctx, if any, is used to reach Microsoft Entra ID.
+func (a *azureWorkloadIdentityTokenProvider) GetToken(ctx context.Context) (TokenExpiry, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.cached.Token != "" && time.Until(a.cached.ExpiresAt) > a.refreshWindow {
+		return a.cached, nil
+	}
+	credential, err := a.newCredential(HTTPClientFromContext(ctx))
+	if err != nil {
+		return TokenExpiry{}, err
+	}
+	azureToken, err := credential.GetToken(ctx, a.tokenOption)
+	if err != nil {
+		return TokenExpiry{}, err
+	}
+	a.cached = TokenExpiry{Token: azureToken.Token, ExpiresAt: azureToken.ExpiresOn}
+	return a.cached, nil
+}
@@ -0,0 +1,108 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package tokenprovider
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTokenCredential returns the tokens in order and counts the calls.
+type fakeTokenCredential struct {
+	tokens []azcore.AccessToken
+	calls  *int
+	err    error
+}
+
+func (f *fakeTokenCredential) GetToken(context.Context, policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	if f.err != nil {
+		return azcore.AccessToken{}, f.err
+	}
+	token := f.tokens[*f.calls]
+	*f.calls++
+	return token, nil
+}
+
+func TestNewAzureWorkloadIdentityTokenProvider(t *testing.T) {
+	t.Run("missing token file", func(t *testing.T) {
+		t.Setenv(AzureFederatedTokenFileEnv, "")
+		_, err := NewAzureWorkloadIdentityTokenProvider("tenantID", "clientID", "", time.Minute, policy.TokenRequestOptions{})
+		require.ErrorContains(t, err, "federated token file path is not specified and AZURE_FEDERATED_TOKEN_FILE is not set")
+	})
+
+	t.Run("token file from env", func(t *testing.T) {
+		t.Setenv(AzureFederatedTokenFileEnv, "/var/run/secrets/azure/tokens/azure-identity-token")
+		provider, err := NewAzureWorkloadIdentityTokenProvider("tenantID", "clientID", "", time.Minute, policy.TokenRequestOptions{})
+		require.NoError(t, err)
+		require.NotNil(t, provider)
+	})
+
+	t.Run("missing token file content", func(t *testing.T) {
+		provider, err := NewAzureWorkloadIdentityTokenProvider("tenantID", "clientID", t.TempDir()+"/missing", time.Minute,
+			policy.TokenRequestOptions{Scopes: []string{"some-azure-scope"}})
+		require.NoError(t, err)
+		_, err = provider.GetToken(context.Background())
+		require.Error(t, err)
+	})
+}
+
+func TestAzureWorkloadIdentityTokenProvider_GetToken(t *testing.T) {
+	now := time.Now()
+
+	t.Run("cached until the refresh window", func(t *testing.T) {
+		calls := 0
+		credential := &fakeTokenCredential{calls: &calls, tokens: []azcore.AccessToken{
+			{Token: "token1", ExpiresOn: now.Add(time.Hour)},
+			{Token: "token2", ExpiresOn: now.Add(2 * time.Hour)},
+		}}
+		provider := &azureWorkloadIdentityTokenProvider{
+			newCredential: func(*http.Client) (azcore.TokenCredential, error) { return credential, nil },
+			refreshWindow: 5 * time.Minute,
+		}
+
+		token, err := provider.GetToken(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, "token1", token.Token)
+		token, err = provider.GetToken(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, "token1", token.Token)
+		require.Equal(t, 1, calls)
+
+		// Entering the refresh window refreshes the token.
+		provider.refreshWindow = 2 * time.Hour
+		token, err = provider.GetToken(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, "token2", token.Token)
+		require.Equal(t, now.Add(2*time.Hour), token.ExpiresAt)
+		require.Equal(t, 2, calls)
+	})
+
+	t.Run("credential error", func(t *testing.T) {
+		provider := &azureWorkloadIdentityTokenProvider{
+			newCredential: func(*http.Client) (azcore.TokenCredential, error) { return nil, errors.New("invalid credential") },
+		}
+		_, err := provider.GetToken(context.Background())
+		require.ErrorContains(t, err, "invalid credential")
+	})
+
+	t.Run("token error", func(t *testing.T) {
+		provider := &azureWorkloadIdentityTokenProvider{
+			newCredential: func(*http.Client) (azcore.TokenCredential, error) {
+				return &fakeTokenCredential{err: errors.New("token exchange failed")}, nil
+			},
+		}
+		token, err := provider.GetToken(context.Background())
+		require.ErrorContains(t, err, "token exchange failed")
+		require.Empty(t, token.Token)
+	})
+}
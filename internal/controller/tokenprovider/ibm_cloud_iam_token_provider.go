@@ -0,0 +1,85 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package tokenprovider
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/envoyproxy/ai-gateway/internal/json"
+)
+
+// ibmCloudIAMAPIKeyGrantType is the grant type of the IBM Cloud IAM access tokens exchanged for an API key.
+const ibmCloudIAMAPIKeyGrantType = "urn:ibm:params:oauth:grant-type:apikey" // #nosec G101
+
+// ibmCloudIAMTokenProvider is a provider implements TokenProvider interface for the IBM Cloud IAM access tokens
+// exchanged for an API key.
+type ibmCloudIAMTokenProvider struct {
+	iamURL string
+	apiKey string
+}
+
+// ibmCloudIAMTokenResponse is the response of the IBM Cloud IAM token endpoint.
+type ibmCloudIAMTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	// Expiration is the expiration time of the access token in seconds since the epoch.
+	Expiration int64 `json:"expiration"`
+	ExpiresIn  int64 `json:"expires_in"`
+}
+
+// NewIBMCloudIAMTokenProvider creates a new TokenProvider exchanging the given API key for an access token with
+// the IBM Cloud IAM service at the given base URL, e.g. "https://iam.cloud.ibm.com".
+// https://cloud.ibm.com/docs/account?topic=account-iamtoken_from_apikey
+func NewIBMCloudIAMTokenProvider(iamURL, apiKey string) TokenProvider {
+	return &ibmCloudIAMTokenProvider{iamURL: strings.TrimSuffix(iamURL, "/"), apiKey: apiKey}
+}
+
+// GetToken implements TokenProvider.GetToken method to retrieve an IBM Cloud IAM access token and its expiration time.
+// The HTTP client set by WithHTTPClient on ctx, if any, is used to reach IBM Cloud IAM.
+func (p *ibmCloudIAMTokenProvider) GetToken(ctx context.Context) (TokenExpiry, error) {
+	form := url.Values{"grant_type": {ibmCloudIAMAPIKeyGrantType}, "apikey": {p.apiKey}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.iamURL+"/identity/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return TokenExpiry{}, fmt.Errorf("failed to create IBM Cloud IAM token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	hc := HTTPClientFromContext(ctx)
+	if hc == nil {
+		hc = &http.Client{Timeout: time.Minute}
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return TokenExpiry{}, fmt.Errorf("failed to get IBM Cloud IAM token: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return TokenExpiry{}, fmt.Errorf("failed to read IBM Cloud IAM token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return TokenExpiry{}, fmt.Errorf("failed to get IBM Cloud IAM token: status %d: %s", resp.StatusCode, body)
+	}
+
+	var token ibmCloudIAMTokenResponse
+	if err = json.Unmarshal(body, &token); err != nil {
+		return TokenExpiry{}, fmt.Errorf("failed to decode IBM Cloud IAM token response: %w", err)
+	}
+	if token.AccessToken == "" {
+		return TokenExpiry{}, fmt.Errorf("no access token in IBM Cloud IAM token response")
+	}
+	expiresAt := time.Unix(token.Expiration, 0)
+	if token.Expiration == 0 {
+		expiresAt = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+	return TokenExpiry{Token: token.AccessToken, ExpiresAt: expiresAt}, nil
+}
@@ -0,0 +1,76 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package tokenprovider
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIBMCloudIAMTokenProvider_GetToken(t *testing.T) {
+	var status int
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "/identity/token", r.URL.Path)
+		require.Equal(t, "application/x-www-form-urlencoded", r.Header.Get("Content-Type"))
+		require.NoError(t, r.ParseForm())
+		require.Equal(t, "urn:ibm:params:oauth:grant-type:apikey", r.PostForm.Get("grant_type"))
+		require.Equal(t, "some-api-key", r.PostForm.Get("apikey"))
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	defer server.Close()
+	provider := NewIBMCloudIAMTokenProvider(server.URL+"/", "some-api-key")
+
+	t.Run("expiration", func(t *testing.T) {
+		status, body = http.StatusOK, `{"access_token":"some-token","token_type":"Bearer","expires_in":3600,"expiration":1700003600}`
+		token, err := provider.GetToken(t.Context())
+		require.NoError(t, err)
+		require.Equal(t, "some-token", token.Token)
+		require.Equal(t, time.Unix(1700003600, 0), token.ExpiresAt)
+	})
+
+	t.Run("expires in", func(t *testing.T) {
+		status, body = http.StatusOK, `{"access_token":"some-token","expires_in":3600}`
+		token, err := provider.GetToken(t.Context())
+		require.NoError(t, err)
+		require.WithinDuration(t, time.Now().Add(time.Hour), token.ExpiresAt, time.Minute)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		status, body = http.StatusBadRequest, `{"errorCode":"BXNIM0415E","errorMessage":"Provided API key could not be found."}`
+		_, err := provider.GetToken(t.Context())
+		require.ErrorContains(t, err, "status 400")
+		require.ErrorContains(t, err, "Provided API key could not be found.")
+	})
+
+	t.Run("no access token", func(t *testing.T) {
+		status, body = http.StatusOK, `{}`
+		_, err := provider.GetToken(t.Context())
+		require.ErrorContains(t, err, "no access token")
+	})
+
+	t.Run("custom http client", func(t *testing.T) {
+		status, body = http.StatusOK, `{"access_token":"some-token","expires_in":3600}`
+		var used bool
+		hc := &http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			used = true
+			return http.DefaultTransport.RoundTrip(r)
+		})}
+		_, err := provider.GetToken(WithHTTPClient(t.Context(), hc))
+		require.NoError(t, err)
+		require.True(t, used)
+	})
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
@@ -0,0 +1,133 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package tokenprovider
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/prometheus/client_golang/prometheus"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	// oidcDiscoveryTTL is how long a cached discovery document is served before it is refreshed.
+	oidcDiscoveryTTL = 10 * time.Minute
+	// oidcDiscoveryRefreshTimeout bounds the background refresh of a stale discovery document.
+	oidcDiscoveryRefreshTimeout = 30 * time.Second
+)
+
+var (
+	// oidcDiscoveryFetchFailures counts the failed fetches of the OIDC discovery documents per issuer.
+	oidcDiscoveryFetchFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "ai_gateway_oidc_discovery_fetch_failures_total",
+		Help: "Total number of failed OIDC discovery document fetches.",
+	}, []string{"issuer"})
+
+	// defaultOIDCDiscoveryCache is shared by all the OIDC token providers so that the reconciliations of the
+	// BackendSecurityPolicies using the same issuer don't re-fetch the discovery document.
+	defaultOIDCDiscoveryCache = newOIDCDiscoveryCache(oidcDiscoveryTTL, oidc.NewProvider)
+)
+
+func init() {
+	ctrlmetrics.Registry.MustRegister(oidcDiscoveryFetchFailures)
+}
+
+// oidcDiscoveryCache caches the OIDC providers built from the discovery documents per issuer, as fetched with the
+// default HTTP client.
+//
+// The provider also holds the JWKS of the issuer, which is fetched lazily and cached by the provider itself,
+// so sharing the provider shares the JWKS as well.
+//
+// A discovery document older than the TTL is still served while it is refreshed in the background, so that the
+// reconciliations don't wait on the issuer. When the refresh fails, the stale document keeps being served and
+// the refresh is retried on the next lookup.
+type oidcDiscoveryCache struct {
+	ttl         time.Duration
+	newProvider func(ctx context.Context, issuer string) (*oidc.Provider, error)
+	now         func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*oidcDiscoveryEntry
+}
+
+type oidcDiscoveryEntry struct {
+	provider  *oidc.Provider
+	fetchedAt time.Time
+	// refreshing is true while a background refresh is in flight.
+	refreshing bool
+}
+
+func newOIDCDiscoveryCache(ttl time.Duration, newProvider func(ctx context.Context, issuer string) (*oidc.Provider, error)) *oidcDiscoveryCache {
+	return &oidcDiscoveryCache{
+		ttl:         ttl,
+		newProvider: newProvider,
+		now:         time.Now,
+		entries:     make(map[string]*oidcDiscoveryEntry),
+	}
+}
+
+// get returns the provider of the issuer, fetching the discovery document if it is not cached yet.
+//
+// The lookups with an HTTP client set by WithHTTPClient on ctx, e.g. going through the egress proxy of a
+// BackendSecurityPolicy, bypass the cache: the provider keeps the client it was created with to fetch the JWKS,
+// and the issuer may not even be reachable without the proxy or trusted without the CA certificates.
+func (c *oidcDiscoveryCache) get(ctx context.Context, issuer string) (*oidc.Provider, error) {
+	if HTTPClientFromContext(ctx) != nil {
+		return c.fetch(ctx, issuer)
+	}
+	c.mu.Lock()
+	if e, ok := c.entries[issuer]; ok {
+		if !e.refreshing && c.now().Sub(e.fetchedAt) >= c.ttl {
+			e.refreshing = true
+			// The refresh must outlive the reconciliation that triggered it, while keeping the context values
+			// such as the HTTP client.
+			go c.refresh(context.WithoutCancel(ctx), issuer)
+		}
+		c.mu.Unlock()
+		return e.provider, nil
+	}
+	c.mu.Unlock()
+
+	provider, err := c.fetch(ctx, issuer)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[issuer] = &oidcDiscoveryEntry{provider: provider, fetchedAt: c.now()}
+	return provider, nil
+}
+
+// refresh re-fetches the discovery document of the issuer and replaces the cached provider on success.
+func (c *oidcDiscoveryCache) refresh(ctx context.Context, issuer string) {
+	ctx, cancel := context.WithTimeout(ctx, oidcDiscoveryRefreshTimeout)
+	defer cancel()
+	provider, err := c.fetch(ctx, issuer)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[issuer]
+	if !ok {
+		return
+	}
+	e.refreshing = false
+	if err == nil {
+		e.provider = provider
+		e.fetchedAt = c.now()
+	}
+}
+
+func (c *oidcDiscoveryCache) fetch(ctx context.Context, issuer string) (*oidc.Provider, error) {
+	provider, err := c.newProvider(ctx, issuer)
+	if err != nil {
+		oidcDiscoveryFetchFailures.WithLabelValues(issuer).Inc()
+		return nil, err
+	}
+	return provider, nil
+}
@@ -0,0 +1,112 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package tokenprovider
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOIDCDiscoveryCache(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		fetches int
+		fetchFn = func(ctx context.Context, issuer string) (*oidc.Provider, error) {
+			return (&oidc.ProviderConfig{IssuerURL: issuer, TokenURL: issuer + "/token"}).NewProvider(ctx), nil
+		}
+	)
+	now := time.Now()
+	c := newOIDCDiscoveryCache(time.Minute, func(ctx context.Context, issuer string) (*oidc.Provider, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		fetches++
+		return fetchFn(ctx, issuer)
+	})
+	c.now = func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+	fetchCount := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return fetches
+	}
+	advance := func(d time.Duration) {
+		mu.Lock()
+		defer mu.Unlock()
+		now = now.Add(d)
+	}
+
+	// The first lookup fetches the discovery document, and the following ones are served from the cache.
+	first, err := c.get(t.Context(), "https://issuer.example.com")
+	require.NoError(t, err)
+	second, err := c.get(t.Context(), "https://issuer.example.com")
+	require.NoError(t, err)
+	require.Same(t, first, second)
+	require.Equal(t, 1, fetchCount())
+
+	// Other issuers are cached separately.
+	_, err = c.get(t.Context(), "https://other.example.com")
+	require.NoError(t, err)
+	require.Equal(t, 2, fetchCount())
+
+	// The lookups with a custom HTTP client always fetch the discovery document, and don't replace the cached one.
+	custom := WithHTTPClient(t.Context(), &http.Client{})
+	for range 2 {
+		p, err := c.get(custom, "https://issuer.example.com")
+		require.NoError(t, err)
+		require.NotSame(t, first, p)
+	}
+	require.Equal(t, 4, fetchCount())
+	cached, err := c.get(t.Context(), "https://issuer.example.com")
+	require.NoError(t, err)
+	require.Same(t, first, cached)
+
+	// Once stale, the cached provider is still served while it is refreshed in the background.
+	advance(2 * time.Minute)
+	stale, err := c.get(t.Context(), "https://issuer.example.com")
+	require.NoError(t, err)
+	require.Same(t, first, stale)
+	require.Eventually(t, func() bool {
+		p, _ := c.get(t.Context(), "https://issuer.example.com")
+		return p != first
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, 5, fetchCount())
+
+	// A failed refresh keeps serving the stale provider and counts the failure.
+	refreshed, err := c.get(t.Context(), "https://issuer.example.com")
+	require.NoError(t, err)
+	mu.Lock()
+	fetchFn = func(context.Context, string) (*oidc.Provider, error) { return nil, errors.New("unavailable") }
+	mu.Unlock()
+	failures := testutil.ToFloat64(oidcDiscoveryFetchFailures.WithLabelValues("https://issuer.example.com"))
+	advance(2 * time.Minute)
+	p, err := c.get(t.Context(), "https://issuer.example.com")
+	require.NoError(t, err)
+	require.Same(t, refreshed, p)
+	require.Eventually(t, func() bool {
+		return testutil.ToFloat64(oidcDiscoveryFetchFailures.WithLabelValues("https://issuer.example.com")) == failures+1
+	}, 5*time.Second, 10*time.Millisecond)
+	p, err = c.get(t.Context(), "https://issuer.example.com")
+	require.NoError(t, err)
+	require.Same(t, refreshed, p)
+
+	// A failed initial fetch is returned and not cached.
+	_, err = c.get(t.Context(), "https://unknown.example.com")
+	require.ErrorContains(t, err, "unavailable")
+	c.mu.Lock()
+	require.NotContains(t, c.entries, "https://unknown.example.com")
+	c.mu.Unlock()
+}
@@ -32,7 +32,7 @@ func NewOidcTokenProvider(ctx context.Context, client client.Client, oidcConfig
 	}
 
 	issuerURL := oidcConfig.Provider.Issuer
-	oidcProvider, err := oidc.NewProvider(ctx, issuerURL)
+	oidcProvider, err := defaultOIDCDiscoveryCache.get(ctx, issuerURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create oidc config: %q, %w", issuerURL, err)
 	}
@@ -100,7 +100,9 @@ func (o *oidcTokenProvider) GetToken(ctx context.Context) (TokenExpiry, error) {
 	}
 
 	// Underlying token call will apply http client timeout.
-	ctx = context.WithValue(ctx, oauth2.HTTPClient, &http.Client{Timeout: time.Minute})
+	if HTTPClientFromContext(ctx) == nil {
+		ctx = context.WithValue(ctx, oauth2.HTTPClient, &http.Client{Timeout: time.Minute})
+	}
 
 	token, err := oauth2Config.Token(ctx)
 	if err != nil {
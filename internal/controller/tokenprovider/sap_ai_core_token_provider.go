@@ -0,0 +1,49 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package tokenprovider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+// sapAICoreTokenProvider is a provider implements TokenProvider interface for the SAP AI Core access tokens
+// obtained with the client credentials of a service key.
+type sapAICoreTokenProvider struct {
+	config clientcredentials.Config
+}
+
+// NewSAPAICoreTokenProvider creates a new TokenProvider obtaining the access tokens from the SAP Authorization and
+// Trust Management service (XSUAA) at the given "url" of the service key with the OAuth 2.0 client credentials flow.
+// https://help.sap.com/docs/sap-ai-core/sap-ai-core-service-guide/access-sap-ai-core-via-api
+func NewSAPAICoreTokenProvider(authURL, clientID, clientSecret string) TokenProvider {
+	return &sapAICoreTokenProvider{config: clientcredentials.Config{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		TokenURL:     strings.TrimSuffix(authURL, "/") + "/oauth/token",
+		AuthStyle:    oauth2.AuthStyleInHeader,
+	}}
+}
+
+// GetToken implements TokenProvider.GetToken method to retrieve an SAP AI Core access token and its expiration time.
+// The HTTP client set by WithHTTPClient on ctx, if any, is used to reach XSUAA.
+func (p *sapAICoreTokenProvider) GetToken(ctx context.Context) (TokenExpiry, error) {
+	// Underlying token call will apply http client timeout.
+	if HTTPClientFromContext(ctx) == nil {
+		ctx = context.WithValue(ctx, oauth2.HTTPClient, &http.Client{Timeout: time.Minute})
+	}
+	token, err := p.config.Token(ctx)
+	if err != nil {
+		return TokenExpiry{}, fmt.Errorf("failed to get SAP AI Core token: %w", err)
+	}
+	return TokenExpiry{Token: token.AccessToken, ExpiresAt: token.Expiry}, nil
+}
@@ -0,0 +1,47 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package tokenprovider
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSAPAICoreTokenProvider_GetToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/oauth/token", r.URL.Path)
+		clientID, clientSecret, ok := r.BasicAuth()
+		require.True(t, ok)
+		require.NoError(t, r.ParseForm())
+		require.Equal(t, "client_credentials", r.PostForm.Get("grant_type"))
+		if clientID != "some-client-id" || clientSecret != "some-client-secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":"unauthorized","error_description":"Bad credentials"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"some-token","token_type":"bearer","expires_in":43199}`))
+	}))
+	defer server.Close()
+
+	t.Run("ok", func(t *testing.T) {
+		provider := NewSAPAICoreTokenProvider(server.URL+"/", "some-client-id", "some-client-secret")
+		token, err := provider.GetToken(t.Context())
+		require.NoError(t, err)
+		require.Equal(t, "some-token", token.Token)
+		require.WithinDuration(t, time.Now().Add(43199*time.Second), token.ExpiresAt, time.Minute)
+	})
+
+	t.Run("bad credentials", func(t *testing.T) {
+		provider := NewSAPAICoreTokenProvider(server.URL, "some-client-id", "wrong")
+		_, err := provider.GetToken(t.Context())
+		require.ErrorContains(t, err, "failed to get SAP AI Core token")
+	})
+}
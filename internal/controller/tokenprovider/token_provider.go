@@ -7,7 +7,11 @@ package tokenprovider
 
 import (
 	"context"
+	"net/http"
 	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
 )
 
 const (
@@ -27,6 +31,19 @@ type TokenProvider interface {
 	GetToken(ctx context.Context) (TokenExpiry, error)
 }
 
+// WithHTTPClient returns a copy of ctx carrying the HTTP client used by the token providers and rotators to reach
+// the identity providers, e.g. to go through a proxy or to trust a custom CA bundle.
+func WithHTTPClient(ctx context.Context, client *http.Client) context.Context {
+	ctx = oidc.ClientContext(ctx, client)
+	return context.WithValue(ctx, oauth2.HTTPClient, client)
+}
+
+// HTTPClientFromContext returns the HTTP client set by WithHTTPClient, or nil if not set.
+func HTTPClientFromContext(ctx context.Context) *http.Client {
+	client, _ := ctx.Value(oauth2.HTTPClient).(*http.Client)
+	return client
+}
+
 // mockTokenProvider is used for unit tests to allow passing in a token string and expiry.
 type mockTokenProvider struct {
 	token     string    // The mock token string.
@@ -8,10 +8,12 @@ package tokenprovider
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
 )
 
 func TestMockTokenProvider_GetToken(t *testing.T) {
@@ -33,3 +35,12 @@ func TestMockTokenProvider_GetToken(t *testing.T) {
 		require.Equal(t, "failed to get token", err.Error())
 	})
 }
+
+func TestWithHTTPClient(t *testing.T) {
+	require.Nil(t, HTTPClientFromContext(context.Background()))
+
+	hc := &http.Client{Timeout: time.Second}
+	ctx := WithHTTPClient(context.Background(), hc)
+	require.Same(t, hc, HTTPClientFromContext(ctx))
+	require.Same(t, hc, ctx.Value(oauth2.HTTPClient))
+}
@@ -0,0 +1,453 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	aigv1b1 "github.com/envoyproxy/ai-gateway/api/v1beta1"
+	"github.com/envoyproxy/ai-gateway/internal/json"
+)
+
+// ProviderUsageKeyAnnotationKey is the annotation key used on BackendSecurityPolicy objects to identify their
+// credential in the usage exports of the provider, so that the usage reconciliation compares the usage of the
+// credential with the usage recorded by the gateway. The value is the API key ID of the OpenAI usage API, e.g.
+// "key_abc", for the APIKey policies, and the AWS account ID of the Cost and Usage Reports for the AWSCredentials
+// policies.
+const ProviderUsageKeyAnnotationKey = "aigateway.envoyproxy.io/provider-usage-key"
+
+// maxUsageReconciliationModels is the maximum number of models in the status of a BackendSecurityPolicy.
+const maxUsageReconciliationModels = 64
+
+// usageDriftRatio reports the drift of the usage billed by the provider from the usage recorded by the gateway,
+// (provider - gateway) / max(provider, gateway), per BackendSecurityPolicy, model and token type. A positive drift
+// means the credential is used outside of the gateway, and a negative one that the gateway over-counts the usage.
+var usageDriftRatio = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "ai_gateway_controller_usage_drift_ratio",
+	Help: "Drift of the token usage billed by the provider from the token usage recorded by the gateway.",
+}, []string{"backend_security_policy", "model", "token_type"})
+
+func init() {
+	ctrlmetrics.Registry.MustRegister(usageDriftRatio)
+}
+
+// UsageReconciliationOptions configures the usage reconciliation.
+type UsageReconciliationOptions struct {
+	// Interval is the period of the reconciliation as well as the window of the compared usage. The windows are
+	// aligned to the interval, e.g. to the day. Zero disables the reconciliation.
+	Interval time.Duration
+	// Delay is the time the usage exports of the providers lag behind, so the window ending before now minus
+	// the delay is compared.
+	Delay time.Duration
+	// DriftThreshold is the relative difference of the usage above which a model is reported as drifted.
+	DriftThreshold float64
+	// OpenAIAdminKey is the admin key of the OpenAI usage API. Empty skips the APIKey policies.
+	OpenAIAdminKey string
+	// OpenAIBaseURL is the base URL of the OpenAI API. Defaults to https://api.openai.com/v1.
+	OpenAIBaseURL string
+	// CURDir is the directory of the CSV files of the AWS Cost and Usage Reports. Empty skips the AWSCredentials
+	// policies.
+	CURDir string
+}
+
+// usageReconciler periodically compares the token usage recorded by the external processors with the usage exports
+// of the providers for the BackendSecurityPolicies annotated with ProviderUsageKeyAnnotationKey, and reports the
+// result in their status and in usageDriftRatio. This catches the credentials shared outside of the gateway as well
+// as the accounting bugs.
+//
+// The usage recorded by the gateway is read from the usage API of the external processors, which requires the CSV
+// usage export of the external processors to be enabled.
+type usageReconciler struct {
+	client client.Client
+	// podReader lists the pods without the cache, since the pods are not watched otherwise.
+	podReader  client.Reader
+	logger     logr.Logger
+	httpClient *http.Client
+	options    UsageReconciliationOptions
+	// now and gatewayUsageURL are replaced in the tests.
+	now             func() time.Time
+	gatewayUsageURL func(pod *corev1.Pod) string
+}
+
+// newUsageReconciler creates the runnable of the usage reconciliation.
+func newUsageReconciler(c client.Client, podReader client.Reader, logger logr.Logger, options UsageReconciliationOptions) *usageReconciler {
+	if options.OpenAIBaseURL == "" {
+		options.OpenAIBaseURL = "https://api.openai.com/v1"
+	}
+	return &usageReconciler{
+		client:     c,
+		podReader:  podReader,
+		logger:     logger,
+		httpClient: &http.Client{Timeout: time.Minute},
+		options:    options,
+		now:        time.Now,
+		gatewayUsageURL: func(pod *corev1.Pod) string {
+			return fmt.Sprintf("http://%s/v1/usage", net.JoinHostPort(pod.Status.PodIP, strconv.Itoa(extProcAdminPort)))
+		},
+	}
+}
+
+// Start implements [manager.Runnable].
+func (r *usageReconciler) Start(ctx context.Context) error {
+	ticker := time.NewTicker(r.options.Interval)
+	defer ticker.Stop()
+	for {
+		if err := r.reconcile(ctx); err != nil {
+			r.logger.Error(err, "failed to reconcile the usage")
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// NeedLeaderElection implements [manager.LeaderElectionRunnable], so that only the leader reconciles the usage.
+func (r *usageReconciler) NeedLeaderElection() bool { return true }
+
+// tokens is the token usage of a model.
+type tokens struct {
+	input, output int64
+}
+
+// reconcile compares the usage of the last complete window.
+func (r *usageReconciler) reconcile(ctx context.Context) error {
+	end := r.now().Add(-r.options.Delay).Truncate(r.options.Interval)
+	start := end.Add(-r.options.Interval)
+
+	var bsps aigv1b1.BackendSecurityPolicyList
+	if err := r.client.List(ctx, &bsps); err != nil {
+		return fmt.Errorf("failed to list BackendSecurityPolicies: %w", err)
+	}
+	var openAIKeys, awsAccounts []string
+	var targets []*aigv1b1.BackendSecurityPolicy
+	for i := range bsps.Items {
+		bsp := &bsps.Items[i]
+		key := bsp.Annotations[ProviderUsageKeyAnnotationKey]
+		switch {
+		case key == "":
+			continue
+		case bsp.Spec.Type == aigv1b1.BackendSecurityPolicyTypeAPIKey && r.options.OpenAIAdminKey != "":
+			openAIKeys = append(openAIKeys, key)
+		case bsp.Spec.Type == aigv1b1.BackendSecurityPolicyTypeAWSCredentials && r.options.CURDir != "":
+			awsAccounts = append(awsAccounts, key)
+		default:
+			r.logger.Info("skipping the usage reconciliation of the BackendSecurityPolicy without a usage export",
+				"namespace", bsp.Namespace, "name", bsp.Name, "type", bsp.Spec.Type)
+			continue
+		}
+		targets = append(targets, bsp)
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+
+	gatewayUsage, err := r.fetchGatewayUsage(ctx, start, end)
+	if err != nil {
+		return err
+	}
+	var openAIUsage map[string]map[string]*tokens
+	if len(openAIKeys) > 0 {
+		if openAIUsage, err = r.fetchOpenAIUsage(ctx, start, end, openAIKeys); err != nil {
+			return err
+		}
+	}
+	var awsUsage map[string]*tokens
+	if len(awsAccounts) > 0 {
+		if awsUsage, err = readCURUsage(r.options.CURDir, start, end); err != nil {
+			return err
+		}
+	}
+
+	for _, bsp := range targets {
+		// The usage recorded by the gateway for the backends targeted by the policy.
+		gateway := make(map[string]*tokens)
+		for _, ref := range bsp.Spec.TargetRefs {
+			if ref.Group != aiServiceBackendGroup || ref.Kind != aiServiceBackendKind {
+				continue
+			}
+			for model, t := range gatewayUsage[bsp.Namespace+"/"+string(ref.Name)] {
+				addTokens(gateway, model, t.input, t.output)
+			}
+		}
+		key := bsp.Annotations[ProviderUsageKeyAnnotationKey]
+		var provider map[string]*tokens
+		if bsp.Spec.Type == aigv1b1.BackendSecurityPolicyTypeAWSCredentials {
+			// The Cost and Usage Reports don't tell the model IDs apart, so the usage is compared across the models.
+			var total tokens
+			for _, t := range gateway {
+				total.input += t.input
+				total.output += t.output
+			}
+			gateway = map[string]*tokens{"": &total}
+			provider = map[string]*tokens{"": awsUsage[key]}
+		} else {
+			provider = openAIUsage[key]
+		}
+		r.updateStatus(ctx, bsp, start, end, r.compareUsage(bsp, gateway, provider))
+	}
+	return nil
+}
+
+// compareUsage compares the usage per model and records the drifts.
+func (r *usageReconciler) compareUsage(bsp *aigv1b1.BackendSecurityPolicy, gateway, provider map[string]*tokens) []aigv1b1.BackendSecurityPolicyModelUsage {
+	policy := bsp.Namespace + "/" + bsp.Name
+	usageDriftRatio.DeletePartialMatch(prometheus.Labels{"backend_security_policy": policy})
+
+	models := make([]string, 0, len(gateway)+len(provider))
+	for model := range gateway {
+		models = append(models, model)
+	}
+	for model := range provider {
+		if _, ok := gateway[model]; !ok {
+			models = append(models, model)
+		}
+	}
+	slices.Sort(models)
+
+	result := make([]aigv1b1.BackendSecurityPolicyModelUsage, 0, len(models))
+	for _, model := range models {
+		g, p := gateway[model], provider[model]
+		if g == nil {
+			g = &tokens{}
+		}
+		if p == nil {
+			p = &tokens{}
+		}
+		inputDrift, outputDrift := usageDrift(p.input, g.input), usageDrift(p.output, g.output)
+		usageDriftRatio.WithLabelValues(policy, model, "input").Set(inputDrift)
+		usageDriftRatio.WithLabelValues(policy, model, "output").Set(outputDrift)
+		result = append(result, aigv1b1.BackendSecurityPolicyModelUsage{
+			Model:                model,
+			GatewayInputTokens:   g.input,
+			GatewayOutputTokens:  g.output,
+			ProviderInputTokens:  p.input,
+			ProviderOutputTokens: p.output,
+			Drifted:              math.Abs(inputDrift) > r.options.DriftThreshold || math.Abs(outputDrift) > r.options.DriftThreshold,
+		})
+	}
+	if len(result) > maxUsageReconciliationModels {
+		result = result[:maxUsageReconciliationModels]
+	}
+	return result
+}
+
+// usageDrift returns (provider - gateway) / max(provider, gateway), or zero when both are zero.
+func usageDrift(provider, gateway int64) float64 {
+	if provider == 0 && gateway == 0 {
+		return 0
+	}
+	return float64(provider-gateway) / float64(max(provider, gateway))
+}
+
+// updateStatus sets the usage reconciliation in the status of the BackendSecurityPolicy.
+func (r *usageReconciler) updateStatus(ctx context.Context, bsp *aigv1b1.BackendSecurityPolicy, start, end time.Time, models []aigv1b1.BackendSecurityPolicyModelUsage) {
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if err := r.client.Get(ctx, client.ObjectKey{Name: bsp.Name, Namespace: bsp.Namespace}, bsp); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil
+			}
+			return err
+		}
+		bsp.Status.UsageReconciliation = &aigv1b1.BackendSecurityPolicyUsageReconciliation{
+			WindowStart: metav1.NewTime(start),
+			WindowEnd:   metav1.NewTime(end),
+			Models:      models,
+		}
+		return r.client.Status().Update(ctx, bsp)
+	})
+	if err != nil {
+		r.logger.Error(err, "failed to update the usage reconciliation of BackendSecurityPolicy",
+			"namespace", bsp.Namespace, "name", bsp.Name)
+	}
+}
+
+// gatewayUsagePage is the subset of the response of the usage API of the external processors used here.
+type gatewayUsagePage struct {
+	Data []struct {
+		StartTime int64 `json:"start_time"`
+		Results   []struct {
+			Model        *string `json:"model"`
+			Backend      *string `json:"backend"`
+			Instance     *string `json:"instance"`
+			InputTokens  int64   `json:"input_tokens"`
+			OutputTokens int64   `json:"output_tokens"`
+		} `json:"results"`
+	} `json:"data"`
+}
+
+// fetchGatewayUsage sums the usage served by the external processors per AIServiceBackend, as "namespace/name",
+// and model. It fails if any of the external processors fails, since the usage would be undercounted otherwise.
+//
+// The external processors sharing a usage ledger serve the same usage of all of them, so the usage is deduplicated
+// per instance, time bucket, backend and model across the pods.
+func (r *usageReconciler) fetchGatewayUsage(ctx context.Context, start, end time.Time) (map[string]map[string]*tokens, error) {
+	var pods corev1.PodList
+	if err := r.podReader.List(ctx, &pods); err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	query := url.Values{
+		"start_time":   {strconv.FormatInt(start.Unix(), 10)},
+		"end_time":     {strconv.FormatInt(end.Unix(), 10)},
+		"bucket_width": {"1h"},
+		"group_by":     {"model,backend,instance"},
+	}
+	type resultKey struct {
+		start                    int64
+		instance, backend, model string
+	}
+	results := make(map[resultKey]tokens)
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Status.Phase != corev1.PodRunning || pod.Status.PodIP == "" || !hasExtProcContainer(pod) {
+			continue
+		}
+		var page gatewayUsagePage
+		if err := getJSON(ctx, r.httpClient, r.gatewayUsageURL(pod)+"?"+query.Encode(), "", &page); err != nil {
+			return nil, fmt.Errorf("failed to get the usage of pod %s/%s: %w", pod.Namespace, pod.Name, err)
+		}
+		for _, bucket := range page.Data {
+			for _, res := range bucket.Results {
+				if res.Backend == nil || res.Model == nil || res.Instance == nil {
+					continue
+				}
+				results[resultKey{start: bucket.StartTime, instance: *res.Instance, backend: *res.Backend, model: *res.Model}] =
+					tokens{input: res.InputTokens, output: res.OutputTokens}
+			}
+		}
+	}
+	usage := make(map[string]map[string]*tokens)
+	for k, t := range results {
+		// The backend names are "namespace/name/route/...", see internalapi.PerRouteRuleRefBackendName.
+		parts := strings.SplitN(k.backend, "/", 3)
+		if len(parts) < 2 {
+			continue
+		}
+		backend := parts[0] + "/" + parts[1]
+		if usage[backend] == nil {
+			usage[backend] = make(map[string]*tokens)
+		}
+		addTokens(usage[backend], k.model, t.input, t.output)
+	}
+	return usage, nil
+}
+
+// hasExtProcContainer returns true if the pod runs the external processor, either as a container or as a sidecar.
+func hasExtProcContainer(pod *corev1.Pod) bool {
+	for _, containers := range [][]corev1.Container{pod.Spec.Containers, pod.Spec.InitContainers} {
+		for i := range containers {
+			if containers[i].Name == extProcContainerName {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// openAIUsagePage is the subset of a page of the completions usage of the OpenAI usage API used here.
+// See https://platform.openai.com/docs/api-reference/usage/completions.
+type openAIUsagePage struct {
+	Data []struct {
+		Results []struct {
+			APIKeyID     *string `json:"api_key_id"`
+			Model        *string `json:"model"`
+			InputTokens  int64   `json:"input_tokens"`
+			OutputTokens int64   `json:"output_tokens"`
+		} `json:"results"`
+	} `json:"data"`
+	HasMore  bool    `json:"has_more"`
+	NextPage *string `json:"next_page"`
+}
+
+// fetchOpenAIUsage returns the completions usage of the API keys per API key ID and model.
+func (r *usageReconciler) fetchOpenAIUsage(ctx context.Context, start, end time.Time, keyIDs []string) (map[string]map[string]*tokens, error) {
+	query := url.Values{
+		"start_time":   {strconv.FormatInt(start.Unix(), 10)},
+		"end_time":     {strconv.FormatInt(end.Unix(), 10)},
+		"bucket_width": {"1h"},
+		"limit":        {"168"},
+		"group_by":     {"api_key_id", "model"},
+		"api_key_ids":  keyIDs,
+	}
+	usage := make(map[string]map[string]*tokens)
+	for {
+		var page openAIUsagePage
+		if err := getJSON(ctx, r.httpClient, r.options.OpenAIBaseURL+"/organization/usage/completions?"+query.Encode(),
+			r.options.OpenAIAdminKey, &page); err != nil {
+			return nil, fmt.Errorf("failed to get the OpenAI usage: %w", err)
+		}
+		for _, bucket := range page.Data {
+			for _, res := range bucket.Results {
+				if res.APIKeyID == nil || res.Model == nil {
+					continue
+				}
+				if usage[*res.APIKeyID] == nil {
+					usage[*res.APIKeyID] = make(map[string]*tokens)
+				}
+				addTokens(usage[*res.APIKeyID], *res.Model, res.InputTokens, res.OutputTokens)
+			}
+		}
+		if !page.HasMore || page.NextPage == nil {
+			return usage, nil
+		}
+		query.Set("page", *page.NextPage)
+	}
+}
+
+// getJSON gets the JSON response of the URL into v, with the bearer token if not empty.
+func getJSON(ctx context.Context, httpClient *http.Client, u, token string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, body)
+	}
+	return json.Unmarshal(body, v)
+}
+
+// addTokens adds the tokens to the usage of the model.
+func addTokens(usage map[string]*tokens, model string, input, output int64) {
+	t, ok := usage[model]
+	if !ok {
+		t = &tokens{}
+		usage[model] = t
+	}
+	t.input += input
+	t.output += output
+}
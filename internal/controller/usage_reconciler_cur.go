@@ -0,0 +1,146 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package controller
+
+import (
+	"compress/gzip"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The columns of the AWS Cost and Usage Reports read by readCURUsage, normalized by normalizeCURColumn so that both
+// the legacy columns, e.g. "lineItem/UsageAccountId", and the CUR 2.0 ones, e.g. "line_item_usage_account_id", match.
+const (
+	curColumnAccountID   = "lineitemusageaccountid"
+	curColumnStartDate   = "lineitemusagestartdate"
+	curColumnProductCode = "lineitemproductcode"
+	curColumnUsageType   = "lineitemusagetype"
+	curColumnUsageAmount = "lineitemusageamount"
+	curColumnPricingUnit = "pricingunit"
+)
+
+// curBedrockProductCode is the product code of the Amazon Bedrock line items.
+const curBedrockProductCode = "AmazonBedrock"
+
+// normalizeCURColumn lowercases the column name and removes the separators.
+func normalizeCURColumn(name string) string {
+	return strings.NewReplacer("/", "", "_", "").Replace(strings.ToLower(strings.TrimSpace(name)))
+}
+
+// readCURUsage sums the Amazon Bedrock input and output tokens of the line items starting in [start, end) per usage
+// account ID, from the CSV files of the Cost and Usage Reports in dir, optionally gzipped. The reports are expected
+// to be synced to the directory, e.g. from S3 by a sidecar, as the reports are not downloaded here.
+//
+// The usage types of the token line items end with "input-tokens" or "output-tokens", e.g.
+// "USE1-Claude3.5Sonnet-input-tokens", and the amounts priced per thousand tokens are converted to tokens.
+func readCURUsage(dir string, start, end time.Time) (map[string]*tokens, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the Cost and Usage Reports directory: %w", err)
+	}
+	usage := make(map[string]*tokens)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || (!strings.HasSuffix(name, ".csv") && !strings.HasSuffix(name, ".csv.gz")) {
+			continue
+		}
+		if err = readCURFile(filepath.Join(dir, name), start, end, usage); err != nil {
+			return nil, fmt.Errorf("failed to read the Cost and Usage Report %s: %w", name, err)
+		}
+	}
+	return usage, nil
+}
+
+// readCURFile adds the usage of the CSV file to usage.
+func readCURFile(path string, start, end time.Time, usage map[string]*tokens) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		var gz *gzip.Reader
+		if gz, err = gzip.NewReader(f); err != nil {
+			return err
+		}
+		defer func() { _ = gz.Close() }()
+		r = gz
+	}
+
+	cr := csv.NewReader(r)
+	cr.ReuseRecord = true
+	header, err := cr.Read()
+	if err != nil {
+		return err
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[normalizeCURColumn(name)] = i
+	}
+	for _, column := range []string{curColumnAccountID, curColumnStartDate, curColumnProductCode, curColumnUsageType, curColumnUsageAmount} {
+		if _, ok := columns[column]; !ok {
+			return fmt.Errorf("missing column %s", column)
+		}
+	}
+	pricingUnit, hasPricingUnit := columns[curColumnPricingUnit]
+
+	for {
+		record, readErr := cr.Read()
+		if errors.Is(readErr, io.EOF) {
+			return nil
+		} else if readErr != nil {
+			return readErr
+		}
+		if record[columns[curColumnProductCode]] != curBedrockProductCode {
+			continue
+		}
+		usageType := strings.ToLower(record[columns[curColumnUsageType]])
+		isInput, isOutput := strings.HasSuffix(usageType, "input-tokens"), strings.HasSuffix(usageType, "output-tokens")
+		if !isInput && !isOutput {
+			continue
+		}
+		startDate, parseErr := parseCURTime(record[columns[curColumnStartDate]])
+		if parseErr != nil {
+			return fmt.Errorf("invalid usage start date %q: %w", record[columns[curColumnStartDate]], parseErr)
+		}
+		if startDate.Before(start) || !startDate.Before(end) {
+			continue
+		}
+		amount, parseErr := strconv.ParseFloat(record[columns[curColumnUsageAmount]], 64)
+		if parseErr != nil {
+			return fmt.Errorf("invalid usage amount %q: %w", record[columns[curColumnUsageAmount]], parseErr)
+		}
+		if hasPricingUnit && strings.Contains(strings.ToLower(record[pricingUnit]), "1k") {
+			amount *= 1000
+		}
+		if isInput {
+			addTokens(usage, record[columns[curColumnAccountID]], int64(amount), 0)
+		} else {
+			addTokens(usage, record[columns[curColumnAccountID]], 0, int64(amount))
+		}
+	}
+}
+
+// parseCURTime parses the times of the legacy reports, e.g. "2025-01-01T00:00:00Z", as well as those of the CUR 2.0
+// exports, e.g. "2025-01-01 00:00:00.000", in UTC.
+func parseCURTime(value string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		var err2 error
+		if t, err2 = time.Parse("2006-01-02 15:04:05", value); err2 != nil {
+			return time.Time{}, err
+		}
+	}
+	return t, nil
+}
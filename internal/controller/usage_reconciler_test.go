@@ -0,0 +1,195 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package controller
+
+import (
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gwapiv1a2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+
+	aigv1b1 "github.com/envoyproxy/ai-gateway/api/v1beta1"
+)
+
+const testCUR = `identity/LineItemId,lineItem/UsageAccountId,lineItem/UsageStartDate,lineItem/ProductCode,lineItem/UsageType,lineItem/UsageAmount,pricing/unit
+1,123456789012,2025-01-01T10:00:00Z,AmazonBedrock,USE1-Claude3.5Sonnet-input-tokens,1.5,1K tokens
+2,123456789012,2025-01-01T11:00:00Z,AmazonBedrock,USE1-Claude3.5Sonnet-input-tokens,0.5,1K tokens
+3,123456789012,2025-01-01T11:00:00Z,AmazonBedrock,USE1-Claude3.5Sonnet-output-tokens,0.2,1K tokens
+4,123456789012,2025-01-02T10:00:00Z,AmazonBedrock,USE1-Claude3.5Sonnet-input-tokens,9,1K tokens
+5,123456789012,2025-01-01T10:00:00Z,AmazonEC2,USE1-BoxUsage:t3.micro,24,Hrs
+`
+
+func TestUsageReconciler(t *testing.T) {
+	c := requireNewFakeClientWithIndexes(t)
+	for _, bsp := range []*aigv1b1.BackendSecurityPolicy{
+		newUsageReconcilerTestPolicy("openai", aigv1b1.BackendSecurityPolicyTypeAPIKey, "key_abc"),
+		newUsageReconcilerTestPolicy("bedrock", aigv1b1.BackendSecurityPolicyTypeAWSCredentials, "123456789012"),
+		newUsageReconcilerTestPolicy("ignored", aigv1b1.BackendSecurityPolicyTypeAPIKey, ""),
+	} {
+		require.NoError(t, c.Create(t.Context(), bsp))
+	}
+	for _, pod := range []*corev1.Pod{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "envoy", Namespace: "envoy-gateway-system"},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "envoy"}, {Name: extProcContainerName}}},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning, PodIP: "10.0.0.1"},
+		},
+		{
+			// Serves the same usage from the shared usage ledger.
+			ObjectMeta: metav1.ObjectMeta{Name: "envoy-2", Namespace: "envoy-gateway-system"},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "envoy"}, {Name: extProcContainerName}}},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning, PodIP: "10.0.0.3"},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "default"},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning, PodIP: "10.0.0.2"},
+		},
+	} {
+		require.NoError(t, c.Create(t.Context(), pod))
+	}
+
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/v1/usage", r.URL.Path)
+		require.Equal(t, "1735689600", r.URL.Query().Get("start_time"))
+		require.Equal(t, "1735776000", r.URL.Query().Get("end_time"))
+		require.Equal(t, "model,backend,instance", r.URL.Query().Get("group_by"))
+		_, _ = w.Write([]byte(`{"object":"page","data":[{"object":"bucket","start_time":1735689600,"end_time":1735693200,"results":[
+{"object":"usage","model":"gpt-4o","backend":"default/openai/route/r/rule/0/ref/0","instance":"envoy","input_tokens":600,"output_tokens":60},
+{"object":"usage","model":"gpt-4o","backend":"default/openai/route/r/rule/0/ref/0","instance":"envoy-2","input_tokens":400,"output_tokens":40},
+{"object":"usage","model":"gpt-4o-mini","backend":"default/openai/route/r/rule/1/ref/0","instance":"envoy","input_tokens":500,"output_tokens":50},
+{"object":"usage","model":"anthropic.claude-3-5-sonnet","backend":"default/bedrock/route/r/rule/0/ref/0","instance":"envoy-2","input_tokens":2000,"output_tokens":200}
+]}],"has_more":false,"next_page":null}`))
+	}))
+	defer gateway.Close()
+
+	openAI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/v1/organization/usage/completions", r.URL.Path)
+		require.Equal(t, "Bearer admin-key", r.Header.Get("Authorization"))
+		require.Equal(t, []string{"key_abc"}, r.URL.Query()["api_key_ids"])
+		if r.URL.Query().Get("page") == "" {
+			_, _ = w.Write([]byte(`{"object":"page","data":[{"object":"bucket","results":[
+{"object":"organization.usage.completions.result","api_key_id":"key_abc","model":"gpt-4o","input_tokens":1000,"output_tokens":100}
+]}],"has_more":true,"next_page":"page_2"}`))
+			return
+		}
+		require.Equal(t, "page_2", r.URL.Query().Get("page"))
+		_, _ = w.Write([]byte(`{"object":"page","data":[{"object":"bucket","results":[
+{"object":"organization.usage.completions.result","api_key_id":"key_abc","model":"gpt-4o-mini","input_tokens":800,"output_tokens":50}
+]}],"has_more":false,"next_page":null}`))
+	}))
+	defer openAI.Close()
+
+	curDir := t.TempDir()
+	f, err := os.Create(filepath.Join(curDir, "report-00001.csv.gz"))
+	require.NoError(t, err)
+	gz := gzip.NewWriter(f)
+	_, err = gz.Write([]byte(testCUR))
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+	require.NoError(t, f.Close())
+
+	r := newUsageReconciler(c, c, logr.Discard(), UsageReconciliationOptions{
+		Interval:       24 * time.Hour,
+		Delay:          time.Hour,
+		DriftThreshold: 0.05,
+		OpenAIAdminKey: "admin-key",
+		OpenAIBaseURL:  openAI.URL + "/v1",
+		CURDir:         curDir,
+	})
+	r.now = func() time.Time { return time.Date(2025, 1, 3, 0, 30, 0, 0, time.UTC) }
+	r.gatewayUsageURL = func(pod *corev1.Pod) string {
+		require.Contains(t, []string{"10.0.0.1", "10.0.0.3"}, pod.Status.PodIP)
+		return gateway.URL + "/v1/usage"
+	}
+	require.NoError(t, r.reconcile(t.Context()))
+
+	requireUsage := func(name string, expected []aigv1b1.BackendSecurityPolicyModelUsage) {
+		var bsp aigv1b1.BackendSecurityPolicy
+		require.NoError(t, c.Get(t.Context(), client.ObjectKey{Namespace: "default", Name: name}, &bsp))
+		require.NotNil(t, bsp.Status.UsageReconciliation)
+		require.True(t, bsp.Status.UsageReconciliation.WindowStart.Equal(ptrTime(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))))
+		require.True(t, bsp.Status.UsageReconciliation.WindowEnd.Equal(ptrTime(time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC))))
+		require.Equal(t, expected, bsp.Status.UsageReconciliation.Models)
+	}
+	requireUsage("openai", []aigv1b1.BackendSecurityPolicyModelUsage{
+		{Model: "gpt-4o", GatewayInputTokens: 1000, GatewayOutputTokens: 100, ProviderInputTokens: 1000, ProviderOutputTokens: 100},
+		{Model: "gpt-4o-mini", GatewayInputTokens: 500, GatewayOutputTokens: 50, ProviderInputTokens: 800, ProviderOutputTokens: 50, Drifted: true},
+	})
+	requireUsage("bedrock", []aigv1b1.BackendSecurityPolicyModelUsage{
+		{GatewayInputTokens: 2000, GatewayOutputTokens: 200, ProviderInputTokens: 2000, ProviderOutputTokens: 200},
+	})
+	require.InDelta(t, 0.375, testutil.ToFloat64(usageDriftRatio.WithLabelValues("default/openai", "gpt-4o-mini", "input")), 1e-9)
+	require.Zero(t, testutil.ToFloat64(usageDriftRatio.WithLabelValues("default/openai", "gpt-4o", "output")))
+
+	var ignored aigv1b1.BackendSecurityPolicy
+	require.NoError(t, c.Get(t.Context(), client.ObjectKey{Namespace: "default", Name: "ignored"}, &ignored))
+	require.Nil(t, ignored.Status.UsageReconciliation)
+
+	t.Run("gateway usage unavailable", func(t *testing.T) {
+		notFound := httptest.NewServer(http.NotFoundHandler())
+		defer notFound.Close()
+		r.gatewayUsageURL = func(*corev1.Pod) string { return notFound.URL + "/v1/usage" }
+		require.ErrorContains(t, r.reconcile(t.Context()), "failed to get the usage of pod envoy-gateway-system/envoy")
+	})
+}
+
+func newUsageReconcilerTestPolicy(name string, typ aigv1b1.BackendSecurityPolicyType, key string) *aigv1b1.BackendSecurityPolicy {
+	bsp := &aigv1b1.BackendSecurityPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: aigv1b1.BackendSecurityPolicySpec{
+			Type: typ,
+			TargetRefs: []gwapiv1a2.LocalPolicyTargetReference{
+				{Group: aiServiceBackendGroup, Kind: aiServiceBackendKind, Name: gwapiv1.ObjectName(name)},
+			},
+		},
+	}
+	if key != "" {
+		bsp.Annotations = map[string]string{ProviderUsageKeyAnnotationKey: key}
+	}
+	return bsp
+}
+
+func ptrTime(t time.Time) *metav1.Time {
+	mt := metav1.NewTime(t)
+	return &mt
+}
+
+func Test_usageDrift(t *testing.T) {
+	require.Zero(t, usageDrift(0, 0))
+	require.Equal(t, 0.5, usageDrift(200, 100))
+	require.Equal(t, -0.5, usageDrift(100, 200))
+	require.Equal(t, 1.0, usageDrift(100, 0))
+}
+
+func Test_readCURUsage(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "cur2.csv"), []byte(
+		"line_item_usage_account_id,line_item_usage_start_date,line_item_product_code,line_item_usage_type,line_item_usage_amount\n"+
+			"111111111111,2025-01-01 10:00:00.000,AmazonBedrock,USW2-NovaPro-input-tokens,1200\n"+
+			"111111111111,2025-01-01 10:00:00.000,AmazonBedrock,USW2-NovaPro-output-tokens,300\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.txt"), []byte("not a report"), 0o600))
+
+	usage, err := readCURUsage(dir, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Equal(t, map[string]*tokens{"111111111111": {input: 1200, output: 300}}, usage)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "invalid.csv"), []byte("a,b\n1,2\n"), 0o600))
+	_, err = readCURUsage(dir, time.Time{}, time.Now())
+	require.ErrorContains(t, err, "missing column lineitemusageaccountid")
+}
@@ -0,0 +1,67 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package conversationstore
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// memoryKey identifies a conversation in the memoryStore.
+type memoryKey struct{ tenant, responseID string }
+
+// memoryEntry is a conversation of the memoryStore with its expiration time.
+type memoryEntry struct {
+	items     []byte
+	expiresAt time.Time
+}
+
+// memoryStore implements [Store] in the memory of the process.
+type memoryStore struct {
+	retention time.Duration
+	now       func() time.Time
+
+	mu      sync.Mutex
+	entries map[memoryKey]memoryEntry
+	// nextSweep is the time after which the expired entries are removed by the next Put.
+	nextSweep time.Time
+}
+
+func newMemoryStore(retention time.Duration) *memoryStore {
+	return &memoryStore{retention: retention, now: time.Now, entries: make(map[memoryKey]memoryEntry)}
+}
+
+// Get implements [Store.Get].
+func (m *memoryStore) Get(_ context.Context, tenant, responseID string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.entries[memoryKey{tenant, responseID}]
+	if !ok || !m.now().Before(e.expiresAt) {
+		return nil, ErrNotFound
+	}
+	return e.items, nil
+}
+
+// Put implements [Store.Put].
+func (m *memoryStore) Put(_ context.Context, tenant, responseID string, items []byte) error {
+	now := m.now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if now.After(m.nextSweep) {
+		for k, e := range m.entries {
+			if !now.Before(e.expiresAt) {
+				delete(m.entries, k)
+			}
+		}
+		m.nextSweep = now.Add(m.retention)
+	}
+	m.entries[memoryKey{tenant, responseID}] = memoryEntry{items: items, expiresAt: now.Add(m.retention)}
+	return nil
+}
+
+// Close implements [Store.Close].
+func (m *memoryStore) Close() error { return nil }
@@ -0,0 +1,100 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package conversationstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// postgresCreateTable creates the table of the conversations. The expired rows are deleted periodically, and
+	// ignored until then.
+	postgresCreateTable = `CREATE TABLE IF NOT EXISTS aigw_conversations (
+	tenant TEXT NOT NULL,
+	response_id TEXT NOT NULL,
+	items JSONB NOT NULL,
+	expires_at TIMESTAMPTZ NOT NULL,
+	PRIMARY KEY (tenant, response_id)
+)`
+	postgresGet = `SELECT items FROM aigw_conversations
+WHERE tenant = $1 AND response_id = $2 AND expires_at > now()`
+	postgresPut = `INSERT INTO aigw_conversations (tenant, response_id, items, expires_at)
+VALUES ($1, $2, $3, now() + $4 * interval '1 second')
+ON CONFLICT (tenant, response_id) DO UPDATE SET items = EXCLUDED.items, expires_at = EXCLUDED.expires_at`
+	postgresDeleteExpired = `DELETE FROM aigw_conversations WHERE expires_at <= now()`
+)
+
+// postgresCleanupInterval is the maximum interval between the deletions of the expired conversations.
+const postgresCleanupInterval = time.Hour
+
+// postgresStore implements [Store] with a PostgreSQL table.
+type postgresStore struct {
+	pool      *pgxpool.Pool
+	retention time.Duration
+	stop      context.CancelFunc
+	done      chan struct{}
+}
+
+func newPostgresStore(ctx context.Context, rawURL string, retention time.Duration) (*postgresStore, error) {
+	pool, err := pgxpool.New(ctx, rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid PostgreSQL URL: %w", err)
+	}
+	if _, err = pool.Exec(ctx, postgresCreateTable); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("failed to create the conversations table: %w", err)
+	}
+	cleanupCtx, stop := context.WithCancel(context.Background())
+	p := &postgresStore{pool: pool, retention: retention, stop: stop, done: make(chan struct{})}
+	go p.deleteExpired(cleanupCtx, min(retention, postgresCleanupInterval))
+	return p, nil
+}
+
+// deleteExpired deletes the expired conversations at the interval until the context is done.
+func (p *postgresStore) deleteExpired(ctx context.Context, interval time.Duration) {
+	defer close(p.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// A failure is retried at the next tick, and the expired rows are ignored by Get meanwhile.
+			_, _ = p.pool.Exec(ctx, postgresDeleteExpired)
+		}
+	}
+}
+
+// Get implements [Store.Get].
+func (p *postgresStore) Get(ctx context.Context, tenant, responseID string) ([]byte, error) {
+	var items []byte
+	err := p.pool.QueryRow(ctx, postgresGet, tenant, responseID).Scan(&items)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return items, err
+}
+
+// Put implements [Store.Put].
+func (p *postgresStore) Put(ctx context.Context, tenant, responseID string, items []byte) error {
+	_, err := p.pool.Exec(ctx, postgresPut, tenant, responseID, string(items), p.retention.Seconds())
+	return err
+}
+
+// Close implements [Store.Close].
+func (p *postgresStore) Close() error {
+	p.stop()
+	<-p.done
+	p.pool.Close()
+	return nil
+}
@@ -0,0 +1,55 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package conversationstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisKeyPrefix is the prefix of the keys of the conversations in Redis.
+const redisKeyPrefix = "aigw:conversation:"
+
+// redisStore implements [Store] with Redis, where the conversations expire with the TTL of their keys.
+type redisStore struct {
+	client    *redis.Client
+	retention time.Duration
+}
+
+func newRedisStore(rawURL string, retention time.Duration) (*redisStore, error) {
+	opts, err := redis.ParseURL(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Redis URL: %w", err)
+	}
+	return &redisStore{client: redis.NewClient(opts), retention: retention}, nil
+}
+
+// redisKey returns the key of the conversation. The tenant is escaped so that it can't contain the separator.
+func redisKey(tenant, responseID string) string {
+	return redisKeyPrefix + url.QueryEscape(tenant) + ":" + responseID
+}
+
+// Get implements [Store.Get].
+func (r *redisStore) Get(ctx context.Context, tenant, responseID string) ([]byte, error) {
+	items, err := r.client.Get(ctx, redisKey(tenant, responseID)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrNotFound
+	}
+	return items, err
+}
+
+// Put implements [Store.Put].
+func (r *redisStore) Put(ctx context.Context, tenant, responseID string, items []byte) error {
+	return r.client.Set(ctx, redisKey(tenant, responseID), items, r.retention).Err()
+}
+
+// Close implements [Store.Close].
+func (r *redisStore) Close() error { return r.client.Close() }
@@ -0,0 +1,70 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+// Package conversationstore stores the conversations of the Responses API requests, so that the gateway can serve
+// the requests continuing a previous response with "previous_response_id" even when the backend keeps no state.
+//
+// A conversation is stored under the ID of its last response as the JSON array of its input and output items, and
+// each tenant only reads its own conversations. The conversations expire after the retention period of the store.
+package conversationstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// ErrNotFound is returned by [Store.Get] when the response is unknown to the tenant or has expired.
+var ErrNotFound = errors.New("conversation not found")
+
+// Store stores the conversations of the tenants.
+type Store interface {
+	// Get returns the items of the conversation ending with the response of the tenant, or ErrNotFound.
+	Get(ctx context.Context, tenant, responseID string) ([]byte, error)
+	// Put stores the items of the conversation ending with the response of the tenant until the end of the
+	// retention period, replacing the existing ones if any.
+	Put(ctx context.Context, tenant, responseID string, items []byte) error
+	// Close releases the resources of the store.
+	Close() error
+}
+
+// Config is the configuration of a Store.
+type Config struct {
+	// URL locates the store. The scheme selects the implementation:
+	//   - "memory:" keeps the conversations in the memory of the process, which is only suitable for a single replica.
+	//   - "redis://" and "rediss://" store them in Redis, e.g. "redis://redis.default:6379/0".
+	//   - "postgres://" and "postgresql://" store them in a PostgreSQL table created if missing.
+	URL string
+	// Password overrides the password of the URL if set, so that it can be passed separately from the URL.
+	Password string
+	// Retention is the duration after which a conversation expires.
+	Retention time.Duration
+}
+
+// New returns the Store configured by the config.
+func New(ctx context.Context, config Config) (Store, error) {
+	if config.Retention <= 0 {
+		return nil, fmt.Errorf("retention must be positive")
+	}
+	u, err := url.Parse(config.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid conversation store URL: %w", err)
+	}
+	if config.Password != "" && u.Host != "" {
+		u.User = url.UserPassword(u.User.Username(), config.Password)
+	}
+	switch u.Scheme {
+	case "memory":
+		return newMemoryStore(config.Retention), nil
+	case "redis", "rediss":
+		return newRedisStore(u.String(), config.Retention)
+	case "postgres", "postgresql":
+		return newPostgresStore(ctx, u.String(), config.Retention)
+	default:
+		return nil, fmt.Errorf("unsupported conversation store scheme %q", u.Scheme)
+	}
+}
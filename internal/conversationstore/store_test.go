@@ -0,0 +1,74 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package conversationstore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	s, err := New(t.Context(), Config{URL: "memory:", Retention: time.Hour})
+	require.NoError(t, err)
+	require.IsType(t, &memoryStore{}, s)
+
+	s, err = New(t.Context(), Config{URL: "redis://redis.default:6379/0", Password: "secret", Retention: time.Hour})
+	require.NoError(t, err)
+	require.IsType(t, &redisStore{}, s)
+	require.Equal(t, "secret", s.(*redisStore).client.Options().Password)
+	require.NoError(t, s.Close())
+
+	for _, tc := range []struct {
+		name   string
+		config Config
+		expErr string
+	}{
+		{name: "no retention", config: Config{URL: "memory:"}, expErr: "retention must be positive"},
+		{name: "unknown scheme", config: Config{URL: "mysql://db", Retention: time.Hour}, expErr: `unsupported conversation store scheme "mysql"`},
+		{name: "invalid URL", config: Config{URL: "redis://%zz", Retention: time.Hour}, expErr: "invalid conversation store URL"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := New(t.Context(), tc.config)
+			require.ErrorContains(t, err, tc.expErr)
+		})
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1_700_000_000, 0)
+	m := newMemoryStore(time.Hour)
+	m.now = func() time.Time { return now }
+
+	_, err := m.Get(ctx, "acme", "resp_1")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	require.NoError(t, m.Put(ctx, "acme", "resp_1", []byte(`[{"role":"user","content":"hi"}]`)))
+	items, err := m.Get(ctx, "acme", "resp_1")
+	require.NoError(t, err)
+	require.JSONEq(t, `[{"role":"user","content":"hi"}]`, string(items))
+
+	// The conversations of the other tenants are not visible.
+	_, err = m.Get(ctx, "other", "resp_1")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	// The conversations expire after the retention, and are removed by a later Put.
+	now = now.Add(time.Hour)
+	_, err = m.Get(ctx, "acme", "resp_1")
+	require.ErrorIs(t, err, ErrNotFound)
+	now = now.Add(time.Second)
+	require.NoError(t, m.Put(ctx, "acme", "resp_2", []byte(`[]`)))
+	require.Len(t, m.entries, 1)
+}
+
+func TestRedisKey(t *testing.T) {
+	require.Equal(t, "aigw:conversation:acme:resp_1", redisKey("acme", "resp_1"))
+	require.Equal(t, "aigw:conversation:a%3Ab:resp_1", redisKey("a:b", "resp_1"))
+	require.Equal(t, "aigw:conversation::resp_1", redisKey("", "resp_1"))
+}
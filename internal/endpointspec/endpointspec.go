@@ -106,6 +106,8 @@ type (
 	ResponsesEndpointSpec struct{}
 	// MessagesEndpointSpec implements EndpointSpec for /v1/messages.
 	MessagesEndpointSpec struct{}
+	// ModerationsEndpointSpec implements EndpointSpec for /v1/moderations.
+	ModerationsEndpointSpec struct{}
 	// RerankEndpointSpec implements EndpointSpec for /v2/rerank.
 	RerankEndpointSpec struct{}
 	// SpeechEndpointSpec implements EndpointSpec for /v1/audio/speech.
@@ -116,8 +118,13 @@ type (
 	TranslationEndpointSpec struct{}
 	// TokenizeEndpointSpec implements EndpointSpec for /tokenize.
 	TokenizeEndpointSpec struct{}
+	// CountTokensEndpointSpec implements EndpointSpec for /v1/messages/count_tokens.
+	CountTokensEndpointSpec struct{}
 )
 
+// mockProviderPrefix is the path prefix of the endpoints of the mock provider built into the external processor.
+const mockProviderPrefix = "v1"
+
 var errMultipartNotSupported = fmt.Errorf("%w: multipart body not supported for this endpoint", internalapi.ErrMalformedRequest)
 
 // ParseBody implements [EndpointSpec.ParseBody].
@@ -170,7 +177,16 @@ func (ChatCompletionsEndpointSpec) GetTranslator(schema filterapi.VersionedAPISc
 		return translator.NewChatCompletionOpenAIToGCPVertexAITranslator(modelNameOverride), nil
 	case filterapi.APISchemaGCPAnthropic:
 		return translator.NewChatCompletionOpenAIToGCPAnthropicTranslator(schema.Version, modelNameOverride), nil
+	case filterapi.APISchemaSAPAICore:
+		return translator.NewChatCompletionOpenAIToSAPAICoreTranslator(schema.Version, modelNameOverride), nil
+	case filterapi.APISchemaIBMWatsonx:
+		return translator.NewChatCompletionOpenAIToIBMWatsonxTranslator(schema.Version, modelNameOverride), nil
+	case filterapi.APISchemaMock:
+		return translator.NewChatCompletionOpenAIToOpenAITranslator(mockProviderPrefix, modelNameOverride), nil
 	default:
+		if t, ok, err := chatCompletionTranslators.newTranslator(schema, modelNameOverride); ok {
+			return t, err
+		}
 		return nil, fmt.Errorf("unsupported API schema: backend=%s", schema)
 	}
 }
@@ -222,6 +238,8 @@ func (CompletionsEndpointSpec) GetTranslator(schema filterapi.VersionedAPISchema
 	switch schema.Name {
 	case filterapi.APISchemaOpenAI:
 		return translator.NewCompletionOpenAIToOpenAITranslator(schema.OpenAIPrefix(), modelNameOverride), nil
+	case filterapi.APISchemaMock:
+		return translator.NewCompletionOpenAIToOpenAITranslator(mockProviderPrefix, modelNameOverride), nil
 	default:
 		return nil, fmt.Errorf("unsupported API schema: backend=%s", schema)
 	}
@@ -261,7 +279,14 @@ func (EmbeddingsEndpointSpec) GetTranslator(schema filterapi.VersionedAPISchema,
 		return translator.NewEmbeddingOpenAIToGCPVertexAITranslator("", modelNameOverride), nil
 	case filterapi.APISchemaAWSBedrock:
 		return translator.NewEmbeddingOpenAIToAWSBedrockTranslator(modelNameOverride), nil
+	case filterapi.APISchemaSAPAICore:
+		return translator.NewEmbeddingOpenAIToSAPAICoreTranslator(schema.Version, modelNameOverride), nil
+	case filterapi.APISchemaMock:
+		return translator.NewEmbeddingOpenAIToOpenAITranslator(mockProviderPrefix, modelNameOverride), nil
 	default:
+		if t, ok, err := embeddingTranslators.newTranslator(schema, modelNameOverride); ok {
+			return t, err
+		}
 		return nil, fmt.Errorf("unsupported API schema: backend=%s", schema)
 	}
 }
@@ -272,6 +297,64 @@ func (EmbeddingsEndpointSpec) RedactSensitiveInfoFromRequest(req *openai.Embeddi
 	return req, nil
 }
 
+// ParseBody implements [EndpointSpec.ParseBody].
+func (ModerationsEndpointSpec) ParseBody(
+	body []byte,
+	_ bool,
+) (internalapi.OriginalModel, *openai.ModerationRequest, bool, []byte, error) {
+	var openAIReq openai.ModerationRequest
+	if err := json.Unmarshal(body, &openAIReq); err != nil {
+		return "", nil, false, nil, fmt.Errorf("%w: failed to parse JSON for /v1/moderations: %w", internalapi.ErrMalformedRequest, err)
+	}
+	return openAIReq.Model, &openAIReq, false, nil, nil
+}
+
+// ParseMultipartBody implements [Spec.ParseMultipartBody].
+func (ModerationsEndpointSpec) ParseMultipartBody([]byte, string, bool) (internalapi.OriginalModel, *openai.ModerationRequest, bool, []byte, error) {
+	return "", nil, false, nil, errMultipartNotSupported
+}
+
+// GetTranslator implements [EndpointSpec.GetTranslator].
+//
+// The moderations are sent to the safety provider of the backend schema: the OpenAI moderation models, Azure AI
+// Content Safety for the Azure OpenAI schema and the AWS Bedrock Guardrails for the AWS Bedrock one.
+func (ModerationsEndpointSpec) GetTranslator(schema filterapi.VersionedAPISchema, modelNameOverride string) (translator.OpenAIModerationTranslator, error) {
+	switch schema.Name {
+	case filterapi.APISchemaOpenAI:
+		return translator.NewModerationOpenAIToOpenAITranslator(schema.OpenAIPrefix(), modelNameOverride), nil
+	case filterapi.APISchemaAzureOpenAI:
+		return translator.NewModerationOpenAIToAzureContentSafetyTranslator(modelNameOverride), nil
+	case filterapi.APISchemaAWSBedrock:
+		return translator.NewModerationOpenAIToAWSBedrockTranslator(modelNameOverride), nil
+	default:
+		if t, ok, err := moderationTranslators.newTranslator(schema, modelNameOverride); ok {
+			return t, err
+		}
+		return nil, fmt.Errorf("unsupported API schema: backend=%s", schema)
+	}
+}
+
+// RedactSensitiveInfoFromRequest implements [EndpointSpec.RedactSensitiveInfoFromRequest].
+func (ModerationsEndpointSpec) RedactSensitiveInfoFromRequest(req *openai.ModerationRequest) (redactedReq *openai.ModerationRequest, err error) {
+	redacted := *req
+	redacted.Input = openai.ModerationInput{}
+	if req.Input.OfString != nil {
+		s := redaction.RedactString(*req.Input.OfString)
+		redacted.Input.OfString = &s
+	}
+	for _, s := range req.Input.OfStringArray {
+		redacted.Input.OfStringArray = append(redacted.Input.OfStringArray, redaction.RedactString(s))
+	}
+	for _, in := range req.Input.OfMultiModal {
+		in.Text = redaction.RedactString(in.Text)
+		if in.ImageURL != nil {
+			in.ImageURL = &openai.ModerationImageURL{URL: redaction.RedactString(in.ImageURL.URL)}
+		}
+		redacted.Input.OfMultiModal = append(redacted.Input.OfMultiModal, in)
+	}
+	return &redacted, nil
+}
+
 func (ImageGenerationEndpointSpec) ParseBody(
 	body []byte,
 	_ bool,
@@ -410,6 +493,10 @@ func (RerankEndpointSpec) GetTranslator(schema filterapi.VersionedAPISchema, mod
 	switch schema.Name {
 	case filterapi.APISchemaCohere:
 		return translator.NewRerankCohereToCohereTranslator(schema.Version, modelNameOverride), nil
+	case filterapi.APISchemaAWSBedrock:
+		return translator.NewRerankCohereToAWSBedrockTranslator(modelNameOverride), nil
+	case filterapi.APISchemaGCPVertexAI:
+		return translator.NewRerankCohereToGCPVertexAITranslator(modelNameOverride), nil
 	default:
 		return nil, fmt.Errorf("unsupported API schema: backend=%s", schema)
 	}
@@ -475,6 +562,55 @@ func (TokenizeEndpointSpec) ParseMultipartBody([]byte, string, bool) (internalap
 	return "", nil, false, nil, errMultipartNotSupported
 }
 
+// ParseBody implements [EndpointSpec.ParseBody].
+func (CountTokensEndpointSpec) ParseBody(
+	body []byte,
+	_ bool,
+) (internalapi.OriginalModel, *anthropic.MessagesRequest, bool, []byte, error) {
+	// The count tokens request is the subset of the messages request affecting the number of input tokens.
+	var anthropicReq anthropic.MessagesRequest
+	if err := json.Unmarshal(body, &anthropicReq); err != nil {
+		return "", nil, false, nil, fmt.Errorf("%w: failed to parse JSON for /v1/messages/count_tokens: %w", internalapi.ErrMalformedRequest, err)
+	}
+	if anthropicReq.Model == "" {
+		return "", nil, false, nil, fmt.Errorf("%w: model field is required", internalapi.ErrInvalidRequestBody)
+	}
+	// Count tokens requests are never streaming.
+	return anthropicReq.Model, &anthropicReq, false, nil, nil
+}
+
+// ParseMultipartBody implements [Spec.ParseMultipartBody].
+func (CountTokensEndpointSpec) ParseMultipartBody([]byte, string, bool) (internalapi.OriginalModel, *anthropic.MessagesRequest, bool, []byte, error) {
+	return "", nil, false, nil, errMultipartNotSupported
+}
+
+// GetTranslator implements [EndpointSpec.GetTranslator].
+//
+// The backends with a native count tokens API are proxied to it, and the others are emulated with their
+// tokenize translator.
+func (CountTokensEndpointSpec) GetTranslator(schema filterapi.VersionedAPISchema, modelNameOverride string) (translator.AnthropicCountTokensTranslator, error) {
+	switch schema.Name {
+	case filterapi.APISchemaAnthropic:
+		return translator.NewAnthropicCountTokensToAnthropicTranslator(schema.AnthropicPrefix(), modelNameOverride), nil
+	case filterapi.APISchemaGCPAnthropic:
+		return translator.NewAnthropicCountTokensToGCPAnthropicTranslator(schema.Version, modelNameOverride), nil
+	case filterapi.APISchemaAWSAnthropic:
+		return translator.NewAnthropicCountTokensToAWSAnthropicTranslator(schema.Version, modelNameOverride), nil
+	case filterapi.APISchemaOpenAI:
+		return translator.NewAnthropicCountTokensToTokenizeTranslator(translator.NewTokenizeTranslator(modelNameOverride), modelNameOverride), nil
+	case filterapi.APISchemaGCPVertexAI:
+		return translator.NewAnthropicCountTokensToTokenizeTranslator(translator.NewTokenizeToGCPVertexAITranslator(modelNameOverride), modelNameOverride), nil
+	default:
+		return nil, fmt.Errorf("unsupported API schema for count tokens endpoint: backend=%s", schema.Name)
+	}
+}
+
+// RedactSensitiveInfoFromRequest implements [EndpointSpec.RedactSensitiveInfoFromRequest].
+func (CountTokensEndpointSpec) RedactSensitiveInfoFromRequest(req *anthropic.MessagesRequest) (redactedReq *anthropic.MessagesRequest, err error) {
+	// Placeholder if redaction is required in future
+	return req, nil
+}
+
 // redactMessage redacts sensitive content from a chat message while preserving its type and structure.
 // This dispatches to role-specific redaction functions based on the message type.
 func redactMessage(msg openai.ChatCompletionMessageParamUnion) openai.ChatCompletionMessageParamUnion {
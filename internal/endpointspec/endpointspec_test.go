@@ -20,6 +20,7 @@ import (
 	"github.com/envoyproxy/ai-gateway/internal/internalapi"
 	"github.com/envoyproxy/ai-gateway/internal/json"
 	"github.com/envoyproxy/ai-gateway/internal/redaction"
+	"github.com/envoyproxy/ai-gateway/internal/translator"
 )
 
 func TestChatCompletionsEndpointSpec_ParseBody(t *testing.T) {
@@ -89,6 +90,9 @@ func TestChatCompletionsEndpointSpec_GetTranslator(t *testing.T) {
 		{Name: filterapi.APISchemaAzureOpenAI, Version: "2024-02-01"},
 		{Name: filterapi.APISchemaGCPVertexAI},
 		{Name: filterapi.APISchemaGCPAnthropic, Version: "2024-05-01"},
+		{Name: filterapi.APISchemaSAPAICore, Version: "2024-10-21"},
+		{Name: filterapi.APISchemaIBMWatsonx},
+		{Name: filterapi.APISchemaMock},
 	}
 
 	for _, schema := range supported {
@@ -134,6 +138,8 @@ func TestCompletionsEndpointSpec_GetTranslator(t *testing.T) {
 
 	_, err := spec.GetTranslator(filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}, "override")
 	require.NoError(t, err)
+	_, err = spec.GetTranslator(filterapi.VersionedAPISchema{Name: filterapi.APISchemaMock}, "override")
+	require.NoError(t, err)
 
 	_, err = spec.GetTranslator(filterapi.VersionedAPISchema{Name: filterapi.APISchemaAWSBedrock}, "override")
 	require.ErrorContains(t, err, "unsupported API schema")
@@ -186,6 +192,8 @@ func TestEmbeddingsEndpointSpec_GetTranslator(t *testing.T) {
 		{Name: filterapi.APISchemaAzureOpenAI},
 		{Name: filterapi.APISchemaGCPVertexAI},
 		{Name: filterapi.APISchemaAWSBedrock},
+		{Name: filterapi.APISchemaSAPAICore},
+		{Name: filterapi.APISchemaMock},
 	}
 	for _, schema := range supported {
 		s := schema
@@ -234,6 +242,77 @@ func TestImageGenerationEndpointSpec_GetTranslator(t *testing.T) {
 	require.ErrorContains(t, err, "unsupported API schema")
 }
 
+func TestModerationsEndpointSpec_ParseBody(t *testing.T) {
+	spec := ModerationsEndpointSpec{}
+
+	t.Run("invalid json", func(t *testing.T) {
+		_, _, _, _, err := spec.ParseBody([]byte("{"), false)
+		require.ErrorContains(t, err, "malformed request")
+	})
+
+	t.Run("success", func(t *testing.T) {
+		model, parsed, stream, mutated, err := spec.ParseBody([]byte(`{"model":"omni-moderation-latest","input":["a","b"]}`), false)
+		require.NoError(t, err)
+		require.Equal(t, "omni-moderation-latest", model)
+		require.False(t, stream)
+		require.Equal(t, []string{"a", "b"}, parsed.Input.OfStringArray)
+		require.Nil(t, mutated)
+	})
+
+	t.Run("multipart", func(t *testing.T) {
+		_, _, _, _, err := spec.ParseMultipartBody(nil, "multipart/form-data", false)
+		require.ErrorIs(t, err, errMultipartNotSupported)
+	})
+}
+
+func TestModerationsEndpointSpec_GetTranslator(t *testing.T) {
+	spec := ModerationsEndpointSpec{}
+
+	for _, name := range []filterapi.APISchemaName{filterapi.APISchemaOpenAI, filterapi.APISchemaAzureOpenAI, filterapi.APISchemaAWSBedrock} {
+		tr, err := spec.GetTranslator(filterapi.VersionedAPISchema{Name: name}, "override")
+		require.NoError(t, err)
+		require.NotNil(t, tr)
+	}
+
+	_, err := spec.GetTranslator(filterapi.VersionedAPISchema{Name: filterapi.APISchemaCohere}, "override")
+	require.ErrorContains(t, err, "unsupported API schema")
+
+	t.Run("registered", func(t *testing.T) {
+		var gotOverride string
+		require.NoError(t, RegisterModerationTranslator("FakeSafety", func(_ filterapi.VersionedAPISchema, modelNameOverride string) (translator.OpenAIModerationTranslator, error) {
+			gotOverride = modelNameOverride
+			return translator.NewModerationOpenAIToOpenAITranslator("v1", modelNameOverride), nil
+		}))
+		tr, err := spec.GetTranslator(filterapi.VersionedAPISchema{Name: "FakeSafety"}, "override")
+		require.NoError(t, err)
+		require.NotNil(t, tr)
+		require.Equal(t, "override", gotOverride)
+	})
+}
+
+func TestModerationsEndpointSpec_RedactSensitiveInfoFromRequest(t *testing.T) {
+	spec := ModerationsEndpointSpec{}
+	text := "sensitive text"
+	req := &openai.ModerationRequest{
+		Model: "omni-moderation-latest",
+		Input: openai.ModerationInput{OfString: &text},
+	}
+	redacted, err := spec.RedactSensitiveInfoFromRequest(req)
+	require.NoError(t, err)
+	require.Contains(t, *redacted.Input.OfString, "[REDACTED LENGTH=")
+	require.Equal(t, "sensitive text", *req.Input.OfString)
+
+	req = &openai.ModerationRequest{Input: openai.ModerationInput{OfMultiModal: []openai.ModerationMultiModalInput{
+		{Type: openai.ModerationInputTypeText, Text: "sensitive text"},
+		{Type: openai.ModerationInputTypeImageURL, ImageURL: &openai.ModerationImageURL{URL: "data:image/png;base64,abc"}},
+	}}}
+	redacted, err = spec.RedactSensitiveInfoFromRequest(req)
+	require.NoError(t, err)
+	require.Contains(t, redacted.Input.OfMultiModal[0].Text, "[REDACTED LENGTH=")
+	require.Contains(t, redacted.Input.OfMultiModal[1].ImageURL.URL, "[REDACTED LENGTH=")
+	require.Equal(t, "data:image/png;base64,abc", req.Input.OfMultiModal[1].ImageURL.URL)
+}
+
 func TestMessagesEndpointSpec_ParseBody(t *testing.T) {
 	spec := MessagesEndpointSpec{}
 
@@ -305,10 +384,12 @@ func TestRerankEndpointSpec_ParseBody(t *testing.T) {
 func TestRerankEndpointSpec_GetTranslator(t *testing.T) {
 	spec := RerankEndpointSpec{}
 
-	_, err := spec.GetTranslator(filterapi.VersionedAPISchema{Name: filterapi.APISchemaCohere}, "override")
-	require.NoError(t, err)
+	for _, name := range []filterapi.APISchemaName{filterapi.APISchemaCohere, filterapi.APISchemaAWSBedrock, filterapi.APISchemaGCPVertexAI} {
+		_, err := spec.GetTranslator(filterapi.VersionedAPISchema{Name: name}, "override")
+		require.NoError(t, err)
+	}
 
-	_, err = spec.GetTranslator(filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}, "override")
+	_, err := spec.GetTranslator(filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}, "override")
 	require.ErrorContains(t, err, "unsupported API schema")
 }
 
@@ -499,6 +580,52 @@ func TestTokenizeEndpointSpec_GetTranslator(t *testing.T) {
 	})
 }
 
+func TestCountTokensEndpointSpec_ParseBody(t *testing.T) {
+	spec := CountTokensEndpointSpec{}
+
+	t.Run("invalid json", func(t *testing.T) {
+		_, _, _, _, err := spec.ParseBody([]byte("not-json"), false)
+		require.ErrorIs(t, err, internalapi.ErrMalformedRequest)
+		require.ErrorContains(t, err, "failed to parse JSON for /v1/messages/count_tokens")
+	})
+
+	t.Run("model required", func(t *testing.T) {
+		_, _, _, _, err := spec.ParseBody([]byte(`{"messages":[]}`), false)
+		require.ErrorIs(t, err, internalapi.ErrInvalidRequestBody)
+	})
+
+	t.Run("valid", func(t *testing.T) {
+		model, parsed, stream, mutated, err := spec.ParseBody([]byte(`{"model":"claude-sonnet-4","messages":[{"role":"user","content":"Hello"}],"stream":true}`), true)
+		require.NoError(t, err)
+		require.Equal(t, "claude-sonnet-4", model)
+		require.Len(t, parsed.Messages, 1)
+		require.False(t, stream)
+		require.Nil(t, mutated)
+	})
+}
+
+func TestCountTokensEndpointSpec_GetTranslator(t *testing.T) {
+	spec := CountTokensEndpointSpec{}
+	for _, schema := range []filterapi.VersionedAPISchema{
+		{Name: filterapi.APISchemaAnthropic},
+		{Name: filterapi.APISchemaGCPAnthropic},
+		{Name: filterapi.APISchemaAWSAnthropic},
+		{Name: filterapi.APISchemaOpenAI},
+		{Name: filterapi.APISchemaGCPVertexAI},
+	} {
+		t.Run("supported_"+string(schema.Name), func(t *testing.T) {
+			translator, err := spec.GetTranslator(schema, "override")
+			require.NoError(t, err)
+			require.NotNil(t, translator)
+		})
+	}
+
+	t.Run("unsupported", func(t *testing.T) {
+		_, err := spec.GetTranslator(filterapi.VersionedAPISchema{Name: filterapi.APISchemaAWSBedrock}, "override")
+		require.ErrorContains(t, err, "unsupported API schema for count tokens endpoint")
+	})
+}
+
 func TestChatCompletionsEndpointSpec_RedactSensitiveInfoFromRequest(t *testing.T) {
 	spec := ChatCompletionsEndpointSpec{}
 
@@ -0,0 +1,96 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package endpointspec
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/envoyproxy/ai-gateway/internal/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/translator"
+)
+
+// TranslatorFactory creates a translator for the given backend schema. The modelNameOverride, if non-empty,
+// replaces the model name in the request.
+type TranslatorFactory[T any] func(schema filterapi.VersionedAPISchema, modelNameOverride string) (T, error)
+
+// translatorRegistry holds the translator factories registered for the API schemas that are not built in.
+type translatorRegistry[T any] struct {
+	mu        sync.RWMutex
+	factories map[filterapi.APISchemaName]TranslatorFactory[T]
+}
+
+var (
+	chatCompletionTranslators = &translatorRegistry[translator.OpenAIChatCompletionTranslator]{}
+	embeddingTranslators      = &translatorRegistry[translator.OpenAIEmbeddingTranslator]{}
+	moderationTranslators     = &translatorRegistry[translator.OpenAIModerationTranslator]{}
+)
+
+// builtinAPISchemas are the API schemas whose translators are built in, which cannot be replaced by the registered ones.
+var builtinAPISchemas = []filterapi.APISchemaName{
+	filterapi.APISchemaOpenAI,
+	filterapi.APISchemaCohere,
+	filterapi.APISchemaAWSBedrock,
+	filterapi.APISchemaAzureOpenAI,
+	filterapi.APISchemaGCPVertexAI,
+	filterapi.APISchemaGCPAnthropic,
+	filterapi.APISchemaAnthropic,
+	filterapi.APISchemaAWSAnthropic,
+	filterapi.APISchemaSAPAICore,
+	filterapi.APISchemaIBMWatsonx,
+	filterapi.APISchemaMock,
+}
+
+// RegisterChatCompletionTranslator registers the factory of the /v1/chat/completions translator for the API schema
+// that is not built in. This is expected to be called at the initialization, before serving any request.
+func RegisterChatCompletionTranslator(name filterapi.APISchemaName, factory TranslatorFactory[translator.OpenAIChatCompletionTranslator]) error {
+	return chatCompletionTranslators.register(name, factory)
+}
+
+// RegisterEmbeddingTranslator registers the factory of the /v1/embeddings translator for the API schema
+// that is not built in. This is expected to be called at the initialization, before serving any request.
+func RegisterEmbeddingTranslator(name filterapi.APISchemaName, factory TranslatorFactory[translator.OpenAIEmbeddingTranslator]) error {
+	return embeddingTranslators.register(name, factory)
+}
+
+// RegisterModerationTranslator registers the factory of the /v1/moderations translator for the API schema
+// that is not built in, e.g. a proprietary safety provider. This is expected to be called at the initialization,
+// before serving any request.
+func RegisterModerationTranslator(name filterapi.APISchemaName, factory TranslatorFactory[translator.OpenAIModerationTranslator]) error {
+	return moderationTranslators.register(name, factory)
+}
+
+func (r *translatorRegistry[T]) register(name filterapi.APISchemaName, factory TranslatorFactory[T]) error {
+	if name == "" || factory == nil {
+		return fmt.Errorf("API schema name and translator factory must be set")
+	}
+	if slices.Contains(builtinAPISchemas, name) {
+		return fmt.Errorf("API schema %s is built in", name)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.factories[name]; ok {
+		return fmt.Errorf("translator for API schema %s is already registered", name)
+	}
+	if r.factories == nil {
+		r.factories = make(map[filterapi.APISchemaName]TranslatorFactory[T])
+	}
+	r.factories[name] = factory
+	return nil
+}
+
+// newTranslator creates the translator with the factory registered for the schema, or returns false if none is.
+func (r *translatorRegistry[T]) newTranslator(schema filterapi.VersionedAPISchema, modelNameOverride string) (t T, ok bool, err error) {
+	r.mu.RLock()
+	factory, ok := r.factories[schema.Name]
+	r.mu.RUnlock()
+	if !ok {
+		return t, false, nil
+	}
+	t, err = factory(schema, modelNameOverride)
+	return t, true, err
+}
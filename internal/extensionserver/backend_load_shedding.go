@@ -0,0 +1,137 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extensionserver
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	routev3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	previous_hostsv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/retry/host/previous_hosts/v3"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aigv1b1 "github.com/envoyproxy/ai-gateway/api/v1beta1"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+)
+
+const (
+	// previousHostsRetryHostPredicate is the retry host predicate rejecting the hosts already attempted.
+	previousHostsRetryHostPredicate = "envoy.retry_host_predicates.previous_hosts"
+	// loadSheddingHostSelectionRetryMaxAttempts is the number of times the load balancer selects a host again when
+	// the selected one was already attempted.
+	loadSheddingHostSelectionRetryMaxAttempts = 5
+)
+
+// applyLoadSheddingRetries walks the generated route configurations and adds the retry of the requests shed by the
+// upstream filter to the retry policy of the routes generated from the AIGatewayRoute rules referencing the
+// AIServiceBackends shedding the requests with LoadReporting. It must run after applyBackendRetryPolicies, which
+// only sets the retry policies of the routes without any.
+func (s *Server) applyLoadSheddingRetries(ctx context.Context, routeConfigs []*routev3.RouteConfiguration) error {
+	routeCache := make(map[client.ObjectKey]*aigv1b1.AIGatewayRoute)
+	backendCache := make(map[client.ObjectKey]*aigv1b1.AIServiceBackend)
+	return s.patchRoutes(routeConfigs, "load_shedding_retry", func(_ *routev3.RouteConfiguration, route *routev3.Route) error {
+		return s.maybeSetLoadSheddingRetry(ctx, route, routeCache, backendCache)
+	})
+}
+
+// maybeSetLoadSheddingRetry adds the load shedding retry to route.retry_policy if one of the backends of the rule
+// the route is generated from sheds the requests, keeping the retry policy already configured on the route.
+func (s *Server) maybeSetLoadSheddingRetry(
+	ctx context.Context,
+	route *routev3.Route,
+	routeCache map[client.ObjectKey]*aigv1b1.AIGatewayRoute,
+	backendCache map[client.ObjectKey]*aigv1b1.AIServiceBackend,
+) error {
+	action := route.GetRoute()
+	if action == nil {
+		return nil
+	}
+
+	// Route name format: "httproute/<namespace>/<name>/rule/<index>/match/<...>".
+	parts := strings.Split(route.Name, "/")
+	if len(parts) < 5 || parts[0] != "httproute" || parts[3] != "rule" || parts[1] == "" || parts[2] == "" {
+		return nil
+	}
+	ruleIndex, err := strconv.Atoi(parts[4])
+	if err != nil {
+		return nil
+	}
+	aigwRoute, err := s.retrieveAndCacheAIGatewayRoute(ctx, routeCache, client.ObjectKey{Namespace: parts[1], Name: parts[2]})
+	if err != nil {
+		return err
+	}
+	if aigwRoute == nil || ruleIndex >= len(aigwRoute.Spec.Rules) {
+		return nil
+	}
+
+	var sheds bool
+	for i := range aigwRoute.Spec.Rules[ruleIndex].BackendRefs {
+		key, ok := aiServiceBackendKey(aigwRoute.Namespace, &aigwRoute.Spec.Rules[ruleIndex].BackendRefs[i])
+		if !ok {
+			continue
+		}
+		var backend *aigv1b1.AIServiceBackend
+		backend, err = s.retrieveAndCacheAIServiceBackend(ctx, backendCache, key)
+		if err != nil {
+			return err
+		}
+		if backend != nil && shedsRequests(backend.Spec.LoadReporting) {
+			sheds = true
+			break
+		}
+	}
+	if !sheds {
+		return nil
+	}
+
+	if action.RetryPolicy == nil {
+		action.RetryPolicy = &routev3.RetryPolicy{}
+	}
+	return mergeLoadSheddingRetry(action.RetryPolicy)
+}
+
+// shedsRequests returns true if the load reporting sets one of the thresholds shedding the requests.
+func shedsRequests(l *aigv1b1.BackendLoadReporting) bool {
+	return l != nil && (l.MaxQueueDepth != nil || l.MaxLoadPercent != nil)
+}
+
+// mergeLoadSheddingRetry adds the retry of the responses having the BackendOverloadedHeader to the policy, and makes
+// the retries avoid the hosts already attempted unless the policy already selects the hosts of the retries.
+func mergeLoadSheddingRetry(policy *routev3.RetryPolicy) error {
+	switch {
+	case policy.RetryOn == "":
+		policy.RetryOn = retriableHeadersRetryOn
+	case !slices.Contains(strings.Split(policy.RetryOn, ","), retriableHeadersRetryOn):
+		policy.RetryOn += "," + retriableHeadersRetryOn
+	}
+	policy.RetriableHeaders = append(policy.RetriableHeaders, &routev3.HeaderMatcher{
+		Name:                 internalapi.BackendOverloadedHeader,
+		HeaderMatchSpecifier: &routev3.HeaderMatcher_PresentMatch{PresentMatch: true},
+	})
+	if policy.GetNumRetries().GetValue() == 0 {
+		policy.NumRetries = wrapperspb.UInt32(1)
+	}
+
+	if len(policy.RetryHostPredicate) > 0 {
+		return nil
+	}
+	config, err := toAny(&previous_hostsv3.PreviousHostsPredicate{})
+	if err != nil {
+		return fmt.Errorf("failed to marshal PreviousHostsPredicate to Any: %w", err)
+	}
+	policy.RetryHostPredicate = []*routev3.RetryPolicy_RetryHostPredicate{{
+		Name:       previousHostsRetryHostPredicate,
+		ConfigType: &routev3.RetryPolicy_RetryHostPredicate_TypedConfig{TypedConfig: config},
+	}}
+	if policy.HostSelectionRetryMaxAttempts == 0 {
+		policy.HostSelectionRetryMaxAttempts = loadSheddingHostSelectionRetryMaxAttempts
+	}
+	return nil
+}
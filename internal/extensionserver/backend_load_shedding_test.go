@@ -0,0 +1,93 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extensionserver
+
+import (
+	"testing"
+
+	routev3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	previous_hostsv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/retry/host/previous_hosts/v3"
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	aigv1b1 "github.com/envoyproxy/ai-gateway/api/v1beta1"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+)
+
+func TestMergeLoadSheddingRetry(t *testing.T) {
+	overloaded := &routev3.HeaderMatcher{
+		Name:                 internalapi.BackendOverloadedHeader,
+		HeaderMatchSpecifier: &routev3.HeaderMatcher_PresentMatch{PresentMatch: true},
+	}
+
+	t.Run("empty", func(t *testing.T) {
+		policy := &routev3.RetryPolicy{}
+		require.NoError(t, mergeLoadSheddingRetry(policy))
+		require.Equal(t, retriableHeadersRetryOn, policy.RetryOn)
+		require.Equal(t, wrapperspb.UInt32(1), policy.NumRetries)
+		require.Equal(t, []*routev3.HeaderMatcher{overloaded}, policy.RetriableHeaders)
+		require.Len(t, policy.RetryHostPredicate, 1)
+		require.Equal(t, previousHostsRetryHostPredicate, policy.RetryHostPredicate[0].Name)
+		require.NoError(t, policy.RetryHostPredicate[0].GetTypedConfig().UnmarshalTo(&previous_hostsv3.PreviousHostsPredicate{}))
+		require.Equal(t, int64(loadSheddingHostSelectionRetryMaxAttempts), policy.HostSelectionRetryMaxAttempts)
+	})
+
+	t.Run("existing retries and host predicate", func(t *testing.T) {
+		predicates := []*routev3.RetryPolicy_RetryHostPredicate{{Name: "custom"}}
+		policy := &routev3.RetryPolicy{
+			RetryOn:            backendRetryOn,
+			NumRetries:         wrapperspb.UInt32(3),
+			RetryHostPredicate: predicates,
+		}
+		require.NoError(t, mergeLoadSheddingRetry(policy))
+		require.Equal(t, backendRetryOn+","+retriableHeadersRetryOn, policy.RetryOn)
+		require.Equal(t, wrapperspb.UInt32(3), policy.NumRetries)
+		require.Equal(t, []*routev3.HeaderMatcher{overloaded}, policy.RetriableHeaders)
+		require.Equal(t, predicates, policy.RetryHostPredicate)
+		require.Zero(t, policy.HostSelectionRetryMaxAttempts)
+	})
+}
+
+func TestApplyLoadSheddingRetries(t *testing.T) {
+	c := newFakeClient()
+	require.NoError(t, c.Create(t.Context(), &aigv1b1.AIServiceBackend{
+		ObjectMeta: metav1.ObjectMeta{Name: "vllm", Namespace: "default"},
+		Spec:       aigv1b1.AIServiceBackendSpec{LoadReporting: &aigv1b1.BackendLoadReporting{MaxQueueDepth: ptr.To[int32](8)}},
+	}))
+	require.NoError(t, c.Create(t.Context(), &aigv1b1.AIServiceBackend{
+		ObjectMeta: metav1.ObjectMeta{Name: "tgi", Namespace: "default"},
+		// The load is only reported, so nothing is shed.
+		Spec: aigv1b1.AIServiceBackendSpec{LoadReporting: &aigv1b1.BackendLoadReporting{}},
+	}))
+	require.NoError(t, c.Create(t.Context(), &aigv1b1.AIGatewayRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "route", Namespace: "default"},
+		Spec: aigv1b1.AIGatewayRouteSpec{
+			Rules: []aigv1b1.AIGatewayRouteRule{
+				{BackendRefs: []aigv1b1.AIGatewayRouteRuleBackendRef{{Name: "missing"}, {Name: "vllm"}}},
+				{BackendRefs: []aigv1b1.AIGatewayRouteRuleBackendRef{{Name: "tgi"}}},
+			},
+		},
+	}))
+	s, err := New(c, logr.Discard(), udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false, "", "")
+	require.NoError(t, err)
+
+	forwarding := func(name string) *routev3.Route {
+		return &routev3.Route{Name: name, Action: &routev3.Route_Route{Route: &routev3.RouteAction{}}}
+	}
+	shedding := forwarding("httproute/default/route/rule/0/match/0")
+	reporting := forwarding("httproute/default/route/rule/1/match/0")
+	other := forwarding("some-other-route")
+	require.NoError(t, s.applyLoadSheddingRetries(t.Context(), []*routev3.RouteConfiguration{{
+		VirtualHosts: []*routev3.VirtualHost{{Routes: []*routev3.Route{shedding, reporting, other}}},
+	}}))
+	require.Equal(t, retriableHeadersRetryOn, shedding.GetRoute().GetRetryPolicy().GetRetryOn())
+	require.Equal(t, previousHostsRetryHostPredicate, shedding.GetRoute().GetRetryPolicy().GetRetryHostPredicate()[0].GetName())
+	require.Nil(t, reporting.GetRoute().RetryPolicy)
+	require.Nil(t, other.GetRoute().RetryPolicy)
+}
@@ -0,0 +1,187 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extensionserver
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	clusterv3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	endpointv3 "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
+	listenerv3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	routev3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	htomv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/header_to_metadata/v3"
+	httpconnectionmanagerv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	"google.golang.org/protobuf/types/known/structpb"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aigv1b1 "github.com/envoyproxy/ai-gateway/api/v1beta1"
+)
+
+const (
+	// envoyLbMetadataNamespace is the metadata namespace matched by the subset load balancer of Envoy, both in the
+	// endpoint metadata and in the dynamic metadata of the request.
+	envoyLbMetadataNamespace = "envoy.lb"
+	// backendOverrideMetadataKey is the key of the AIServiceBackend (format "namespace/name") in the envoy.lb metadata.
+	backendOverrideMetadataKey = "aigw_backend"
+)
+
+// setBackendOverrideSubsets partitions the endpoints of the cluster of an AIGatewayRoute rule with BackendOverride
+// by AIServiceBackend, so that the requests pinning a backend are load balanced among its endpoints only. The
+// other requests, as well as the ones pinning a backend that is not in the cluster, use all the endpoints.
+//
+// The subsets are only supported with the load balancing policies configured with the legacy lb_policy field,
+// which Envoy Gateway uses unless a BackendTrafficPolicy sets a typed load balancing policy. It returns false if the
+// load balancing policy of the cluster doesn't support them.
+func setBackendOverrideSubsets(cluster *clusterv3.Cluster) bool {
+	if cluster.LoadBalancingPolicy != nil {
+		return false
+	}
+	cluster.LbSubsetConfig = &clusterv3.Cluster_LbSubsetConfig{
+		FallbackPolicy: clusterv3.Cluster_LbSubsetConfig_ANY_ENDPOINT,
+		SubsetSelectors: []*clusterv3.Cluster_LbSubsetConfig_LbSubsetSelector{
+			{Keys: []string{backendOverrideMetadataKey}},
+		},
+	}
+	return true
+}
+
+// setEndpointMetadataBackendOverride sets the AIServiceBackend (format "namespace/name") on the envoy.lb metadata of
+// the endpoint.
+func setEndpointMetadataBackendOverride(endpoint *endpointv3.LbEndpoint, name string) {
+	if endpoint.Metadata == nil {
+		endpoint.Metadata = &corev3.Metadata{}
+	}
+	if endpoint.Metadata.FilterMetadata == nil {
+		endpoint.Metadata.FilterMetadata = make(map[string]*structpb.Struct)
+	}
+	m, ok := endpoint.Metadata.FilterMetadata[envoyLbMetadataNamespace]
+	if !ok {
+		m = &structpb.Struct{}
+		endpoint.Metadata.FilterMetadata[envoyLbMetadataNamespace] = m
+	}
+	if m.Fields == nil {
+		m.Fields = make(map[string]*structpb.Value)
+	}
+	m.Fields[backendOverrideMetadataKey] = structpb.NewStringValue(name)
+}
+
+// applyBackendOverrides copies the selected backend request header to the envoy.lb dynamic metadata on the listeners
+// serving the AIGatewayRoutes that configure BackendOverride, which makes the subset load balancer of their clusters
+// pick the pinned backend. The signature of the header is verified by the upstream filter.
+func (s *Server) applyBackendOverrides(ctx context.Context, listeners []*listenerv3.Listener, routeConfigs []*routev3.RouteConfiguration) error {
+	cache := make(map[client.ObjectKey]*aigv1b1.AIGatewayRoute)
+	routeConfigsWithOverride := make(map[string]struct{})
+	for _, rc := range routeConfigs {
+		for _, vh := range rc.VirtualHosts {
+			for _, route := range vh.Routes {
+				// Route name format: "httproute/<namespace>/<name>/rule/<index>/match/<...>".
+				parts := strings.Split(route.Name, "/")
+				if len(parts) < 3 || parts[0] != "httproute" || parts[1] == "" || parts[2] == "" {
+					continue
+				}
+				aigwRoute, err := s.retrieveAndCacheAIGatewayRoute(ctx, cache, client.ObjectKey{Namespace: parts[1], Name: parts[2]})
+				if err != nil {
+					return err
+				}
+				if aigwRoute != nil && aigwRoute.Spec.BackendOverride != nil {
+					routeConfigsWithOverride[rc.Name] = struct{}{}
+				}
+			}
+		}
+	}
+	if len(routeConfigsWithOverride) == 0 {
+		return nil
+	}
+
+	for _, listener := range listeners {
+		for _, name := range findListenerRouteConfigs(listener) {
+			if _, ok := routeConfigsWithOverride[name]; !ok {
+				continue
+			}
+			if err := s.patchResource(patchKindListener, listener.Name, "backend_override", listener, func() error {
+				return insertBackendOverrideHeaderToMetadataRule(listener, s.selectedBackendHeaderKey)
+			}); err != nil {
+				return fmt.Errorf("failed to insert the backend override header to metadata rule on listener %s: %w", listener.Name, err)
+			}
+			break
+		}
+	}
+	return nil
+}
+
+// backendOverrideHeaderToMetadataRule is the header_to_metadata rule copying the selected backend request header
+// to the envoy.lb dynamic metadata.
+func backendOverrideHeaderToMetadataRule(header string) *htomv3.Config_Rule {
+	return &htomv3.Config_Rule{
+		Header: header,
+		OnHeaderPresent: &htomv3.Config_KeyValuePair{
+			MetadataNamespace: envoyLbMetadataNamespace,
+			Key:               backendOverrideMetadataKey,
+			Type:              htomv3.Config_STRING,
+		},
+	}
+}
+
+// insertBackendOverrideHeaderToMetadataRule adds the backend override rule of the header to the header_to_metadata
+// filter of every HCM of the listener, inserting the filter before the router if there's none.
+func insertBackendOverrideHeaderToMetadataRule(listener *listenerv3.Listener, header string) error {
+	filterChains := listener.GetFilterChains()
+	if listener.DefaultFilterChain != nil {
+		filterChains = append(filterChains, listener.DefaultFilterChain)
+	}
+	for _, currChain := range filterChains {
+		httpConManager, hcmIndex, err := findHCM(currChain)
+		if err != nil {
+			continue
+		}
+		cfg := &htomv3.Config{}
+		filterIndex, filter := findHeaderToMetadataFilter(httpConManager.HttpFilters)
+		if filter != nil {
+			typedConfig := filter.GetTypedConfig()
+			if typedConfig == nil {
+				return malformedResourceError("header_to_metadata filter missing typed_config")
+			}
+			if err = typedConfig.UnmarshalTo(cfg); err != nil {
+				return err
+			}
+			if hasHeaderToMetadataRule(cfg, header) {
+				continue
+			}
+		}
+		cfg.RequestRules = append(cfg.RequestRules, backendOverrideHeaderToMetadataRule(header))
+		cfgAny, err := toAny(cfg)
+		if err != nil {
+			return err
+		}
+		if filter != nil {
+			httpConManager.HttpFilters[filterIndex].ConfigType = &httpconnectionmanagerv3.HttpFilter_TypedConfig{TypedConfig: cfgAny}
+		} else if err = insertHeaderToMetadataFilter(httpConManager, &httpconnectionmanagerv3.HttpFilter{
+			Name:       headerToMetadataFilterName,
+			ConfigType: &httpconnectionmanagerv3.HttpFilter_TypedConfig{TypedConfig: cfgAny},
+		}); err != nil {
+			return err
+		}
+		hcmAny, err := toAny(httpConManager)
+		if err != nil {
+			return err
+		}
+		currChain.Filters[hcmIndex].ConfigType = &listenerv3.Filter_TypedConfig{TypedConfig: hcmAny}
+	}
+	return nil
+}
+
+// hasHeaderToMetadataRule returns true if the header_to_metadata config has a request rule for the header.
+func hasHeaderToMetadataRule(cfg *htomv3.Config, header string) bool {
+	for _, rule := range cfg.RequestRules {
+		if strings.EqualFold(rule.GetHeader(), header) {
+			return true
+		}
+	}
+	return false
+}
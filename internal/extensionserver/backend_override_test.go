@@ -0,0 +1,152 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extensionserver
+
+import (
+	"testing"
+
+	clusterv3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	endpointv3 "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
+	listenerv3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	routev3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	htomv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/header_to_metadata/v3"
+	httpconnectionmanagerv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	aigv1b1 "github.com/envoyproxy/ai-gateway/api/v1beta1"
+)
+
+func TestApplyBackendOverrides(t *testing.T) {
+	c := newFakeClient()
+	for name, override := range map[string]*aigv1b1.AIGatewayRouteBackendOverride{
+		"pinnable": {SecretRef: &gwapiv1.SecretObjectReference{Name: "hmac"}},
+		"plain":    nil,
+	} {
+		require.NoError(t, c.Create(t.Context(), &aigv1b1.AIGatewayRoute{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec:       aigv1b1.AIGatewayRouteSpec{BackendOverride: override},
+		}))
+	}
+	s, err := New(c, logr.Discard(), udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false, "", "")
+	require.NoError(t, err)
+
+	newListener := func(name, routeConfigName string) *listenerv3.Listener {
+		hcm := &httpconnectionmanagerv3.HttpConnectionManager{
+			RouteSpecifier: &httpconnectionmanagerv3.HttpConnectionManager_Rds{
+				Rds: &httpconnectionmanagerv3.Rds{RouteConfigName: routeConfigName},
+			},
+			HttpFilters: []*httpconnectionmanagerv3.HttpFilter{{Name: wellknown.Router}},
+		}
+		return &listenerv3.Listener{
+			Name: name,
+			FilterChains: []*listenerv3.FilterChain{{
+				Filters: []*listenerv3.Filter{{
+					Name:       wellknown.HTTPConnectionManager,
+					ConfigType: &listenerv3.Filter_TypedConfig{TypedConfig: mustToAny(t, hcm)},
+				}},
+			}},
+		}
+	}
+	routeConfigs := []*routev3.RouteConfiguration{
+		{Name: "pinnable-rc", VirtualHosts: []*routev3.VirtualHost{{Routes: []*routev3.Route{
+			{Name: "httproute/default/pinnable/rule/0/match/0"},
+		}}}},
+		{Name: "plain-rc", VirtualHosts: []*routev3.VirtualHost{{Routes: []*routev3.Route{
+			{Name: "httproute/default/plain/rule/0/match/0"},
+		}}}},
+	}
+	pinnableListener := newListener("pinnable-listener", "pinnable-rc")
+	plainListener := newListener("plain-listener", "plain-rc")
+	listeners := []*listenerv3.Listener{pinnableListener, plainListener}
+
+	// Applying twice doesn't duplicate the rule.
+	for range 2 {
+		require.NoError(t, s.applyBackendOverrides(t.Context(), listeners, routeConfigs))
+	}
+
+	hcm, _, err := findHCM(pinnableListener.FilterChains[0])
+	require.NoError(t, err)
+	require.Len(t, hcm.HttpFilters, 2)
+	require.Equal(t, headerToMetadataFilterName, hcm.HttpFilters[0].Name)
+	require.Equal(t, wellknown.Router, hcm.HttpFilters[1].Name)
+	cfg := &htomv3.Config{}
+	require.NoError(t, hcm.HttpFilters[0].GetTypedConfig().UnmarshalTo(cfg))
+	require.Len(t, cfg.RequestRules, 1)
+	require.Equal(t, "x-aigw-backend", cfg.RequestRules[0].Header)
+	require.Equal(t, "envoy.lb", cfg.RequestRules[0].OnHeaderPresent.MetadataNamespace)
+	require.Equal(t, "aigw_backend", cfg.RequestRules[0].OnHeaderPresent.Key)
+
+	hcm, _, err = findHCM(plainListener.FilterChains[0])
+	require.NoError(t, err)
+	require.Len(t, hcm.HttpFilters, 1)
+
+	// The selected backend header of the installation is copied instead of the default one.
+	s, err = New(c, logr.Discard(), udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false, "", "x-backend")
+	require.NoError(t, err)
+	pinnableListener = newListener("pinnable-listener", "pinnable-rc")
+	require.NoError(t, s.applyBackendOverrides(t.Context(), []*listenerv3.Listener{pinnableListener}, routeConfigs))
+	hcm, _, err = findHCM(pinnableListener.FilterChains[0])
+	require.NoError(t, err)
+	cfg = &htomv3.Config{}
+	require.NoError(t, hcm.HttpFilters[0].GetTypedConfig().UnmarshalTo(cfg))
+	require.Len(t, cfg.RequestRules, 1)
+	require.Equal(t, "x-backend", cfg.RequestRules[0].Header)
+}
+
+func TestSetBackendOverrideSubsets(t *testing.T) {
+	cluster := &clusterv3.Cluster{}
+	require.True(t, setBackendOverrideSubsets(cluster))
+	require.Equal(t, clusterv3.Cluster_LbSubsetConfig_ANY_ENDPOINT, cluster.LbSubsetConfig.FallbackPolicy)
+	require.Equal(t, []string{"aigw_backend"}, cluster.LbSubsetConfig.SubsetSelectors[0].Keys)
+
+	cluster = &clusterv3.Cluster{LoadBalancingPolicy: &clusterv3.LoadBalancingPolicy{}}
+	require.False(t, setBackendOverrideSubsets(cluster))
+	require.Nil(t, cluster.LbSubsetConfig)
+}
+
+func TestSetEndpointMetadataBackendOverride(t *testing.T) {
+	endpoint := &endpointv3.LbEndpoint{}
+	setEndpointMetadataBackendName(endpoint, "default", "openai", "route", 0, 1)
+	setEndpointMetadataBackendOverride(endpoint, "default/openai")
+	require.Equal(t, "default/openai", endpoint.Metadata.FilterMetadata["envoy.lb"].Fields["aigw_backend"].GetStringValue())
+	require.Equal(t, "default/openai/route/route/rule/0/ref/1",
+		endpoint.Metadata.FilterMetadata["aigateway.envoy.io"].Fields["per_route_rule_backend_name"].GetStringValue())
+}
+
+func TestMaybeModifyCluster_backendOverride(t *testing.T) {
+	c := newFakeClient()
+	require.NoError(t, c.Create(t.Context(), &aigv1b1.AIGatewayRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "route", Namespace: "team-a"},
+		Spec: aigv1b1.AIGatewayRouteSpec{
+			Rules: []aigv1b1.AIGatewayRouteRule{{BackendRefs: []aigv1b1.AIGatewayRouteRuleBackendRef{
+				{Name: "openai"},
+				{Name: "openai", Namespace: ptr.To[gwapiv1.Namespace]("team-b")},
+			}}},
+			BackendOverride: &aigv1b1.AIGatewayRouteBackendOverride{SecretRef: &gwapiv1.SecretObjectReference{Name: "hmac"}},
+		},
+	}))
+	s, err := New(c, logr.Discard(), udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false, "", "")
+	require.NoError(t, err)
+
+	cluster := &clusterv3.Cluster{
+		Name: "httproute/team-a/route/rule/0",
+		LoadAssignment: &endpointv3.ClusterLoadAssignment{Endpoints: []*endpointv3.LocalityLbEndpoints{
+			{LbEndpoints: []*endpointv3.LbEndpoint{{}}},
+			{LbEndpoints: []*endpointv3.LbEndpoint{{}}},
+		}},
+	}
+	require.NoError(t, s.maybeModifyCluster(t.Context(), cluster))
+	// The backends of the same name in two namespaces are pinned by their namespace/name.
+	for i, expected := range []string{"team-a/openai", "team-b/openai"} {
+		endpoint := cluster.LoadAssignment.Endpoints[i].LbEndpoints[0]
+		require.Equal(t, expected, endpoint.Metadata.FilterMetadata["envoy.lb"].Fields["aigw_backend"].GetStringValue())
+	}
+}
@@ -0,0 +1,329 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extensionserver
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	clusterv3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	routev3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	aigv1b1 "github.com/envoyproxy/ai-gateway/api/v1beta1"
+)
+
+const (
+	// backendRetryOn are the retry conditions of the retry policies set from the AIServiceBackend Retry, in
+	// addition to the status codes.
+	backendRetryOn = "connect-failure,refused-stream,reset,retriable-status-codes"
+	// defaultBackendNumRetries is the default of BackendRetry.NumRetries.
+	defaultBackendNumRetries = 2
+	// defaultConsecutive5xxErrors, defaultOutlierDetectionInterval, defaultBaseEjectionTime and
+	// defaultMaxEjectionPercent are the defaults of the BackendOutlierDetection fields.
+	defaultConsecutive5xxErrors     = 5
+	defaultOutlierDetectionInterval = 3 * time.Second
+	defaultBaseEjectionTime         = 30 * time.Second
+	defaultMaxEjectionPercent       = 10
+)
+
+// defaultBackendRetryStatusCodes is the default of BackendRetry.StatusCodes.
+var defaultBackendRetryStatusCodes = []uint32{500, 502, 503, 504}
+
+// retrieveAndCacheAIServiceBackend returns the AIServiceBackend for the key and saves the result.
+// If the backend is not found it will be cached as nil, so one translation pass hits the API server at most once per backend.
+func (s *Server) retrieveAndCacheAIServiceBackend(ctx context.Context, cache map[client.ObjectKey]*aigv1b1.AIServiceBackend, key client.ObjectKey) (*aigv1b1.AIServiceBackend, error) {
+	if cached, ok := cache[key]; ok {
+		return cached, nil
+	}
+	var backend aigv1b1.AIServiceBackend
+	if err := s.k8sClient.Get(ctx, key, &backend); err != nil {
+		if apierrors.IsNotFound(err) {
+			cache[key] = nil
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get AIServiceBackend %s/%s: %w", key.Namespace, key.Name, err)
+	}
+	cache[key] = &backend
+	return &backend, nil
+}
+
+// aiServiceBackendKey returns the key of the AIServiceBackend referenced by the backendRef of an AIGatewayRoute in
+// the namespace, or false if the backendRef refers to an InferencePool.
+func aiServiceBackendKey(namespace string, ref *aigv1b1.AIGatewayRouteRuleBackendRef) (client.ObjectKey, bool) {
+	if !ref.IsAIServiceBackend() {
+		return client.ObjectKey{}, false
+	}
+	return client.ObjectKey{Namespace: ref.GetNamespace(namespace), Name: ref.Name}, true
+}
+
+// maybeSetOutlierDetection sets the OutlierDetection of the AIServiceBackends held by a cluster generated for the
+// rule of an AIGatewayRoute. backendRefIndex is the index of the backend of the cluster, or noBackendRefIndex if the
+// cluster holds all the backends of the rule, in which case the first backend configuring it is used. The outlier
+// detection already set on the cluster, e.g. by a BackendTrafficPolicy, is kept.
+func (s *Server) maybeSetOutlierDetection(ctx context.Context, cluster *clusterv3.Cluster, aigwRoute *aigv1b1.AIGatewayRoute, rule *aigv1b1.AIGatewayRouteRule, backendRefIndex int) error {
+	if cluster.OutlierDetection != nil {
+		return nil
+	}
+	refs := rule.BackendRefs
+	if backendRefIndex != noBackendRefIndex {
+		refs = refs[backendRefIndex : backendRefIndex+1]
+	}
+	cache := make(map[client.ObjectKey]*aigv1b1.AIServiceBackend)
+	for i := range refs {
+		key, ok := aiServiceBackendKey(aigwRoute.Namespace, &refs[i])
+		if !ok {
+			continue
+		}
+		backend, err := s.retrieveAndCacheAIServiceBackend(ctx, cache, key)
+		if err != nil {
+			return err
+		}
+		if backend != nil && backend.Spec.OutlierDetection != nil {
+			cluster.OutlierDetection = outlierDetectionToEnvoy(backend.Spec.OutlierDetection)
+			return nil
+		}
+	}
+	return nil
+}
+
+// outlierDetectionToEnvoy converts the BackendOutlierDetection to the Envoy cluster outlier detection, applying the
+// defaults of the optional fields.
+func outlierDetectionToEnvoy(od *aigv1b1.BackendOutlierDetection) *clusterv3.OutlierDetection {
+	return &clusterv3.OutlierDetection{
+		Consecutive_5Xx:    wrapperspb.UInt32(uint32(ptr.Deref(od.Consecutive5xxErrors, defaultConsecutive5xxErrors))), // #nosec G115 - validated to be positive by the CRD
+		Interval:           durationpb.New(parseDurationOr(od.Interval, defaultOutlierDetectionInterval)),
+		BaseEjectionTime:   durationpb.New(parseDurationOr(od.BaseEjectionTime, defaultBaseEjectionTime)),
+		MaxEjectionPercent: wrapperspb.UInt32(uint32(ptr.Deref(od.MaxEjectionPercent, defaultMaxEjectionPercent))), // #nosec G115 - validated to be within 0-100 by the CRD
+	}
+}
+
+// parseDurationOr returns the positive duration d, or def if d is unset or invalid.
+func parseDurationOr(d *gwapiv1.Duration, def time.Duration) time.Duration {
+	if d == nil {
+		return def
+	}
+	if parsed, err := time.ParseDuration(string(*d)); err == nil && parsed > 0 {
+		return parsed
+	}
+	return def
+}
+
+// applyBackendRetryPolicies walks the generated route configurations and sets the retry policy of the
+// AIServiceBackends configuring Retry on the routes generated from the AIGatewayRoute rules referencing them.
+func (s *Server) applyBackendRetryPolicies(ctx context.Context, routeConfigs []*routev3.RouteConfiguration) error {
+	routeCache := make(map[client.ObjectKey]*aigv1b1.AIGatewayRoute)
+	backendCache := make(map[client.ObjectKey]*aigv1b1.AIServiceBackend)
+	return s.patchRoutes(routeConfigs, "backend_retry_policy", func(_ *routev3.RouteConfiguration, route *routev3.Route) error {
+		return s.maybeSetBackendRetryPolicy(ctx, route, routeCache, backendCache)
+	})
+}
+
+// maybeSetBackendRetryPolicy sets route.retry_policy from the Retry of the backends of the rule the route is
+// generated from. The retry policy already configured on the route, e.g. by a BackendTrafficPolicy, is kept.
+func (s *Server) maybeSetBackendRetryPolicy(
+	ctx context.Context,
+	route *routev3.Route,
+	routeCache map[client.ObjectKey]*aigv1b1.AIGatewayRoute,
+	backendCache map[client.ObjectKey]*aigv1b1.AIServiceBackend,
+) error {
+	action := route.GetRoute()
+	if action == nil || action.GetRetryPolicy().GetRetryOn() != "" {
+		return nil
+	}
+
+	// Route name format: "httproute/<namespace>/<name>/rule/<index>/match/<...>".
+	parts := strings.Split(route.Name, "/")
+	if len(parts) < 5 || parts[0] != "httproute" || parts[3] != "rule" || parts[1] == "" || parts[2] == "" {
+		return nil
+	}
+	ruleIndex, err := strconv.Atoi(parts[4])
+	if err != nil {
+		return nil
+	}
+	aigwRoute, err := s.retrieveAndCacheAIGatewayRoute(ctx, routeCache, client.ObjectKey{Namespace: parts[1], Name: parts[2]})
+	if err != nil {
+		return err
+	}
+	if aigwRoute == nil || ruleIndex >= len(aigwRoute.Spec.Rules) {
+		return nil
+	}
+
+	var retries []*aigv1b1.BackendRetry
+	for i := range aigwRoute.Spec.Rules[ruleIndex].BackendRefs {
+		key, ok := aiServiceBackendKey(aigwRoute.Namespace, &aigwRoute.Spec.Rules[ruleIndex].BackendRefs[i])
+		if !ok {
+			continue
+		}
+		var backend *aigv1b1.AIServiceBackend
+		backend, err = s.retrieveAndCacheAIServiceBackend(ctx, backendCache, key)
+		if err != nil {
+			return err
+		}
+		if backend != nil && backend.Spec.Retry != nil {
+			retries = append(retries, backend.Spec.Retry)
+		}
+	}
+	if len(retries) == 0 {
+		return nil
+	}
+
+	// Keep the per-try idle timeout set from the StreamIdleTimeout of the rule.
+	if action.RetryPolicy == nil {
+		action.RetryPolicy = &routev3.RetryPolicy{}
+	}
+	mergeBackendRetries(action.RetryPolicy, retries)
+	return nil
+}
+
+// mergeBackendRetries sets the retry policy of the backends of a rule on the policy. The largest number of retries
+// and per-try timeout and all the status codes of the backends are used.
+func mergeBackendRetries(policy *routev3.RetryPolicy, retries []*aigv1b1.BackendRetry) {
+	var numRetries uint32
+	var perTryTimeout time.Duration
+	var statusCodes []uint32
+	for _, r := range retries {
+		numRetries = max(numRetries, uint32(ptr.Deref(r.NumRetries, defaultBackendNumRetries))) // #nosec G115 - validated to be within 0-10 by the CRD
+		perTryTimeout = max(perTryTimeout, parseDurationOr(r.PerTryTimeout, 0))
+		if len(r.StatusCodes) == 0 {
+			statusCodes = append(statusCodes, defaultBackendRetryStatusCodes...)
+		}
+		for _, c := range r.StatusCodes {
+			statusCodes = append(statusCodes, uint32(c)) // #nosec G115 - validated to be within 400-599 by the CRD
+		}
+	}
+	slices.Sort(statusCodes)
+
+	policy.RetryOn = backendRetryOn
+	policy.NumRetries = wrapperspb.UInt32(numRetries)
+	policy.RetriableStatusCodes = slices.Compact(statusCodes)
+	if perTryTimeout > 0 {
+		policy.PerTryTimeout = durationpb.New(perTryTimeout)
+	}
+}
+
+// maybeSetConnectTimeout sets the connect timeout of the cluster from the Timeouts of the AIServiceBackends held by a
+// cluster generated for the rule of an AIGatewayRoute. backendRefIndex is the same as for maybeSetOutlierDetection.
+// Since Envoy Gateway always sets the connect timeout of the clusters, the connect timeout of the backend overrides
+// the one of a BackendTrafficPolicy.
+func (s *Server) maybeSetConnectTimeout(ctx context.Context, cluster *clusterv3.Cluster, aigwRoute *aigv1b1.AIGatewayRoute, rule *aigv1b1.AIGatewayRouteRule, backendRefIndex int) error {
+	refs := rule.BackendRefs
+	if backendRefIndex != noBackendRefIndex {
+		refs = refs[backendRefIndex : backendRefIndex+1]
+	}
+	cache := make(map[client.ObjectKey]*aigv1b1.AIServiceBackend)
+	for i := range refs {
+		key, ok := aiServiceBackendKey(aigwRoute.Namespace, &refs[i])
+		if !ok {
+			continue
+		}
+		backend, err := s.retrieveAndCacheAIServiceBackend(ctx, cache, key)
+		if err != nil {
+			return err
+		}
+		if backend == nil || backend.Spec.Timeouts == nil {
+			continue
+		}
+		if timeout := parseDurationOr(backend.Spec.Timeouts.Connect, 0); timeout > 0 {
+			cluster.ConnectTimeout = durationpb.New(timeout)
+			return nil
+		}
+	}
+	return nil
+}
+
+// applyBackendTimeouts walks the generated route configurations and sets the first byte and completion timeouts of
+// the AIServiceBackends configuring Timeouts on the routes generated from the AIGatewayRoute rules referencing them.
+// It must run after applyStreamIdleTimeouts and applyBackendRetryPolicies, whose timeouts take precedence.
+func (s *Server) applyBackendTimeouts(ctx context.Context, routeConfigs []*routev3.RouteConfiguration) error {
+	routeCache := make(map[client.ObjectKey]*aigv1b1.AIGatewayRoute)
+	backendCache := make(map[client.ObjectKey]*aigv1b1.AIServiceBackend)
+	return s.patchRoutes(routeConfigs, "backend_timeouts", func(_ *routev3.RouteConfiguration, route *routev3.Route) error {
+		return s.maybeSetBackendTimeouts(ctx, route, routeCache, backendCache)
+	})
+}
+
+// maybeSetBackendTimeouts sets route.retry_policy.per_try_idle_timeout and route.retry_policy.per_try_timeout from
+// the Timeouts of the backends of the rule the route is generated from. The timeouts already configured on the route
+// are kept.
+func (s *Server) maybeSetBackendTimeouts(
+	ctx context.Context,
+	route *routev3.Route,
+	routeCache map[client.ObjectKey]*aigv1b1.AIGatewayRoute,
+	backendCache map[client.ObjectKey]*aigv1b1.AIServiceBackend,
+) error {
+	action := route.GetRoute()
+	if action == nil {
+		return nil
+	}
+
+	// Route name format: "httproute/<namespace>/<name>/rule/<index>/match/<...>".
+	parts := strings.Split(route.Name, "/")
+	if len(parts) < 5 || parts[0] != "httproute" || parts[3] != "rule" || parts[1] == "" || parts[2] == "" {
+		return nil
+	}
+	ruleIndex, err := strconv.Atoi(parts[4])
+	if err != nil {
+		return nil
+	}
+	aigwRoute, err := s.retrieveAndCacheAIGatewayRoute(ctx, routeCache, client.ObjectKey{Namespace: parts[1], Name: parts[2]})
+	if err != nil {
+		return err
+	}
+	if aigwRoute == nil || ruleIndex >= len(aigwRoute.Spec.Rules) {
+		return nil
+	}
+
+	var timeouts []*aigv1b1.BackendTimeouts
+	for i := range aigwRoute.Spec.Rules[ruleIndex].BackendRefs {
+		key, ok := aiServiceBackendKey(aigwRoute.Namespace, &aigwRoute.Spec.Rules[ruleIndex].BackendRefs[i])
+		if !ok {
+			continue
+		}
+		var backend *aigv1b1.AIServiceBackend
+		backend, err = s.retrieveAndCacheAIServiceBackend(ctx, backendCache, key)
+		if err != nil {
+			return err
+		}
+		if backend != nil && backend.Spec.Timeouts != nil {
+			timeouts = append(timeouts, backend.Spec.Timeouts)
+		}
+	}
+	if len(timeouts) == 0 {
+		return nil
+	}
+
+	if action.RetryPolicy == nil {
+		action.RetryPolicy = &routev3.RetryPolicy{}
+	}
+	mergeBackendTimeouts(action.RetryPolicy, timeouts)
+	return nil
+}
+
+// mergeBackendTimeouts sets the largest first byte and completion timeouts of the backends of a rule on the policy,
+// unless the policy already sets them.
+func mergeBackendTimeouts(policy *routev3.RetryPolicy, timeouts []*aigv1b1.BackendTimeouts) {
+	var firstByte, completion time.Duration
+	for _, t := range timeouts {
+		firstByte = max(firstByte, parseDurationOr(t.FirstByte, 0))
+		completion = max(completion, parseDurationOr(t.Completion, 0))
+	}
+	if firstByte > 0 && policy.PerTryIdleTimeout == nil {
+		policy.PerTryIdleTimeout = durationpb.New(firstByte)
+	}
+	if completion > 0 && policy.PerTryTimeout == nil {
+		policy.PerTryTimeout = durationpb.New(completion)
+	}
+}
@@ -0,0 +1,178 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extensionserver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	clusterv3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	routev3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	aigv1b1 "github.com/envoyproxy/ai-gateway/api/v1beta1"
+)
+
+func TestOutlierDetectionToEnvoy(t *testing.T) {
+	require.Equal(t, &clusterv3.OutlierDetection{
+		Consecutive_5Xx:    wrapperspb.UInt32(5),
+		Interval:           durationpb.New(3 * time.Second),
+		BaseEjectionTime:   durationpb.New(30 * time.Second),
+		MaxEjectionPercent: wrapperspb.UInt32(10),
+	}, outlierDetectionToEnvoy(&aigv1b1.BackendOutlierDetection{}))
+	require.Equal(t, &clusterv3.OutlierDetection{
+		Consecutive_5Xx:    wrapperspb.UInt32(3),
+		Interval:           durationpb.New(time.Second),
+		BaseEjectionTime:   durationpb.New(time.Minute),
+		MaxEjectionPercent: wrapperspb.UInt32(100),
+	}, outlierDetectionToEnvoy(&aigv1b1.BackendOutlierDetection{
+		Consecutive5xxErrors: ptr.To[int32](3),
+		Interval:             ptr.To(gwapiv1.Duration("1s")),
+		BaseEjectionTime:     ptr.To(gwapiv1.Duration("1m")),
+		MaxEjectionPercent:   ptr.To[int32](100),
+	}))
+}
+
+func TestMergeBackendRetries(t *testing.T) {
+	policy := &routev3.RetryPolicy{PerTryIdleTimeout: durationpb.New(7 * time.Second)}
+	mergeBackendRetries(policy, []*aigv1b1.BackendRetry{
+		{},
+		{NumRetries: ptr.To[int32](4), StatusCodes: []int32{429, 503}, PerTryTimeout: ptr.To(gwapiv1.Duration("20s"))},
+	})
+	require.Equal(t, &routev3.RetryPolicy{
+		RetryOn:              backendRetryOn,
+		NumRetries:           wrapperspb.UInt32(4),
+		RetriableStatusCodes: []uint32{429, 500, 502, 503, 504},
+		PerTryTimeout:        durationpb.New(20 * time.Second),
+		PerTryIdleTimeout:    durationpb.New(7 * time.Second),
+	}, policy)
+}
+
+func TestMergeBackendTimeouts(t *testing.T) {
+	policy := &routev3.RetryPolicy{PerTryTimeout: durationpb.New(20 * time.Second)}
+	mergeBackendTimeouts(policy, []*aigv1b1.BackendTimeouts{
+		{FirstByte: ptr.To(gwapiv1.Duration("5s")), Completion: ptr.To(gwapiv1.Duration("1m"))},
+		{FirstByte: ptr.To(gwapiv1.Duration("10s"))},
+	})
+	require.Equal(t, &routev3.RetryPolicy{
+		PerTryTimeout:     durationpb.New(20 * time.Second),
+		PerTryIdleTimeout: durationpb.New(10 * time.Second),
+	}, policy)
+
+	policy = &routev3.RetryPolicy{}
+	mergeBackendTimeouts(policy, []*aigv1b1.BackendTimeouts{{Completion: ptr.To(gwapiv1.Duration("1m"))}})
+	require.Equal(t, &routev3.RetryPolicy{PerTryTimeout: durationpb.New(time.Minute)}, policy)
+}
+
+func TestBackendResilience(t *testing.T) {
+	c := newFakeClient()
+	for _, b := range []*aigv1b1.AIServiceBackend{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "plain", Namespace: "default"},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "resilient", Namespace: "default"},
+			Spec: aigv1b1.AIServiceBackendSpec{
+				OutlierDetection: &aigv1b1.BackendOutlierDetection{Consecutive5xxErrors: ptr.To[int32](2)},
+				Retry:            &aigv1b1.BackendRetry{NumRetries: ptr.To[int32](3)},
+				Timeouts: &aigv1b1.BackendTimeouts{
+					Connect:   ptr.To(gwapiv1.Duration("2s")),
+					FirstByte: ptr.To(gwapiv1.Duration("15s")),
+				},
+			},
+		},
+	} {
+		require.NoError(t, c.Create(t.Context(), b))
+	}
+	require.NoError(t, c.Create(t.Context(), &aigv1b1.AIGatewayRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "route", Namespace: "default"},
+		Spec: aigv1b1.AIGatewayRouteSpec{
+			Rules: []aigv1b1.AIGatewayRouteRule{
+				{BackendRefs: []aigv1b1.AIGatewayRouteRuleBackendRef{{Name: "plain"}, {Name: "resilient"}}},
+				{BackendRefs: []aigv1b1.AIGatewayRouteRuleBackendRef{{Name: "plain"}}},
+			},
+		},
+	}))
+	s, err := New(c, logr.Discard(), udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false, "", "")
+	require.NoError(t, err)
+	var route aigv1b1.AIGatewayRoute
+	require.NoError(t, c.Get(t.Context(), client.ObjectKey{Name: "route", Namespace: "default"}, &route))
+
+	t.Run("outlier detection", func(t *testing.T) {
+		cluster := &clusterv3.Cluster{}
+		require.NoError(t, s.maybeSetOutlierDetection(t.Context(), cluster, &route, &route.Spec.Rules[0], noBackendRefIndex))
+		require.Equal(t, wrapperspb.UInt32(2), cluster.OutlierDetection.GetConsecutive_5Xx())
+
+		// The cluster of the backend without the outlier detection.
+		cluster = &clusterv3.Cluster{}
+		require.NoError(t, s.maybeSetOutlierDetection(t.Context(), cluster, &route, &route.Spec.Rules[0], 0))
+		require.Nil(t, cluster.OutlierDetection)
+
+		// The outlier detection of a BackendTrafficPolicy is kept.
+		existing := &clusterv3.OutlierDetection{Consecutive_5Xx: wrapperspb.UInt32(9)}
+		cluster = &clusterv3.Cluster{OutlierDetection: existing}
+		require.NoError(t, s.maybeSetOutlierDetection(t.Context(), cluster, &route, &route.Spec.Rules[0], 1))
+		require.Same(t, existing, cluster.OutlierDetection)
+	})
+
+	t.Run("retry policies", func(t *testing.T) {
+		forwarding := func(name string, policy *routev3.RetryPolicy) *routev3.Route {
+			return &routev3.Route{Name: name, Action: &routev3.Route_Route{Route: &routev3.RouteAction{RetryPolicy: policy}}}
+		}
+		configured := forwarding("httproute/default/route/rule/0/match/0", nil)
+		plain := forwarding("httproute/default/route/rule/1/match/0", nil)
+		btp := forwarding("httproute/default/route/rule/0/match/1", &routev3.RetryPolicy{RetryOn: "5xx"})
+		other := forwarding("some-other-route", nil)
+		require.NoError(t, s.applyBackendRetryPolicies(context.Background(), []*routev3.RouteConfiguration{{
+			VirtualHosts: []*routev3.VirtualHost{{Routes: []*routev3.Route{configured, plain, btp, other}}},
+		}}))
+		require.Equal(t, &routev3.RetryPolicy{
+			RetryOn:              backendRetryOn,
+			NumRetries:           wrapperspb.UInt32(3),
+			RetriableStatusCodes: []uint32{500, 502, 503, 504},
+		}, configured.GetRoute().RetryPolicy)
+		require.Nil(t, plain.GetRoute().RetryPolicy)
+		require.Equal(t, &routev3.RetryPolicy{RetryOn: "5xx"}, btp.GetRoute().RetryPolicy)
+		require.Nil(t, other.GetRoute().RetryPolicy)
+	})
+
+	t.Run("connect timeout", func(t *testing.T) {
+		cluster := &clusterv3.Cluster{ConnectTimeout: durationpb.New(10 * time.Second)}
+		require.NoError(t, s.maybeSetConnectTimeout(t.Context(), cluster, &route, &route.Spec.Rules[0], noBackendRefIndex))
+		require.Equal(t, durationpb.New(2*time.Second), cluster.ConnectTimeout)
+
+		// The cluster of the backend without the timeouts.
+		cluster = &clusterv3.Cluster{ConnectTimeout: durationpb.New(10 * time.Second)}
+		require.NoError(t, s.maybeSetConnectTimeout(t.Context(), cluster, &route, &route.Spec.Rules[0], 0))
+		require.Equal(t, durationpb.New(10*time.Second), cluster.ConnectTimeout)
+	})
+
+	t.Run("timeouts", func(t *testing.T) {
+		forwarding := func(name string, policy *routev3.RetryPolicy) *routev3.Route {
+			return &routev3.Route{Name: name, Action: &routev3.Route_Route{Route: &routev3.RouteAction{RetryPolicy: policy}}}
+		}
+		configured := forwarding("httproute/default/route/rule/0/match/0", &routev3.RetryPolicy{RetryOn: "5xx"})
+		plain := forwarding("httproute/default/route/rule/1/match/0", nil)
+		idle := forwarding("httproute/default/route/rule/0/match/1", &routev3.RetryPolicy{PerTryIdleTimeout: durationpb.New(time.Second)})
+		require.NoError(t, s.applyBackendTimeouts(context.Background(), []*routev3.RouteConfiguration{{
+			VirtualHosts: []*routev3.VirtualHost{{Routes: []*routev3.Route{configured, plain, idle}}},
+		}}))
+		require.Equal(t, &routev3.RetryPolicy{
+			RetryOn:           "5xx",
+			PerTryIdleTimeout: durationpb.New(15 * time.Second),
+		}, configured.GetRoute().RetryPolicy)
+		require.Nil(t, plain.GetRoute().RetryPolicy)
+		require.Equal(t, durationpb.New(time.Second), idle.GetRoute().RetryPolicy.PerTryIdleTimeout)
+	})
+}
@@ -0,0 +1,192 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extensionserver
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	egextension "github.com/envoyproxy/gateway/proto/extension"
+	routev3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/anypb"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	aigv1b1 "github.com/envoyproxy/ai-gateway/api/v1beta1"
+)
+
+const (
+	// maxReportedUnknownFields is the maximum number of the messages with unknown fields named in the error.
+	maxReportedUnknownFields = 5
+	// incompatibleReason is the reason of the NotAccepted condition set on the AIGatewayRoutes when the requests of
+	// Envoy Gateway are not compatible with the extension server.
+	incompatibleReason = "IncompatibleEnvoyGateway"
+)
+
+// incompatibleRequests counts the requests of Envoy Gateway rejected as incompatible, per hook.
+var incompatibleRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "ai_gateway_extension_server_incompatible_requests_total",
+	Help: "Total number of the Envoy Gateway extension requests rejected because Envoy Gateway isn't compatible with the extension server.",
+}, []string{"hook"})
+
+func init() {
+	ctrlmetrics.Registry.MustRegister(incompatibleRequests)
+}
+
+// CompatibilityInterceptor returns the interceptor checking that the requests of the Envoy Gateway extension hooks are
+// compatible with this version of the extension server before they are processed.
+//
+// A newer Envoy Gateway can send the fields of newer extension API or Envoy protos, which are unknown to the protos
+// this server is built with. These requests fail fast with FailedPrecondition, and the AIGatewayRoutes they serve get
+// a NotAccepted condition, since the xDS resources patched without the unknown fields can't be trusted. The panics of
+// the hooks are recovered and returned as Internal, rather than crashing the extension server in the middle of the
+// translation.
+func (s *Server) CompatibilityInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (res any, err error) {
+		if path.Dir(info.FullMethod) != "/"+egextension.EnvoyGatewayExtension_ServiceDesc.ServiceName {
+			return handler(ctx, req)
+		}
+		hook := path.Base(info.FullMethod)
+		if msg, ok := req.(proto.Message); ok {
+			if unknown := messagesWithUnknownFields(msg.ProtoReflect()); len(unknown) > 0 {
+				message := fmt.Sprintf("Envoy Gateway is not compatible with this version of the AI Gateway: the %s request has "+
+					"fields unknown to the extension server in %s; use a version of Envoy Gateway supported by this AI Gateway",
+					hook, strings.Join(unknown, ", "))
+				s.log.Error(nil, "rejected an incompatible Envoy Gateway request", "hook", hook, "messages", unknown)
+				incompatibleRequests.WithLabelValues(hook).Inc()
+				s.reportIncompatibility(ctx, req, message)
+				return nil, status.Error(codes.FailedPrecondition, message)
+			}
+		}
+
+		defer func() {
+			if r := recover(); r != nil {
+				s.log.Error(fmt.Errorf("panic: %v", r), "recovered from a panic of the extension server", "hook", hook)
+				res, err = nil, status.Errorf(codes.Internal, "the extension server failed to process the %s request: %v; "+
+					"this can be caused by a version of Envoy Gateway not supported by this AI Gateway", hook, r)
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// messagesWithUnknownFields returns the full names of the messages having fields unknown to the protos of the
+// server, in the message and in the messages it contains, including the ones packed in the Any fields whose type is
+// known to the server. At most maxReportedUnknownFields names are returned.
+func messagesWithUnknownFields(m protoreflect.Message) []string {
+	var names []string
+	var walk func(m protoreflect.Message)
+	walk = func(m protoreflect.Message) {
+		if len(names) >= maxReportedUnknownFields {
+			return
+		}
+		if len(m.GetUnknown()) > 0 {
+			if name := string(m.Descriptor().FullName()); !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+		if a, ok := m.Interface().(*anypb.Any); ok {
+			// The types of the Any fields unknown to the server, e.g. the configs of other extensions, are skipped.
+			if packed, err := a.UnmarshalNew(); err == nil {
+				walk(packed.ProtoReflect())
+			}
+			return
+		}
+		m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+			switch {
+			case fd.IsList() && fd.Message() != nil:
+				for i, l := 0, v.List(); i < l.Len(); i++ {
+					walk(l.Get(i).Message())
+				}
+			case fd.IsMap() && fd.MapValue().Message() != nil:
+				v.Map().Range(func(_ protoreflect.MapKey, mv protoreflect.Value) bool {
+					walk(mv.Message())
+					return true
+				})
+			case !fd.IsList() && !fd.IsMap() && fd.Message() != nil:
+				walk(v.Message())
+			}
+			return len(names) < maxReportedUnknownFields
+		})
+	}
+	walk(m)
+	return names
+}
+
+// reportIncompatibility sets the NotAccepted condition with the message on the AIGatewayRoutes whose generated routes
+// are in the request, so that the incompatibility is visible on the resources of the users.
+func (s *Server) reportIncompatibility(ctx context.Context, req any, message string) {
+	var routes []*routev3.Route
+	switch r := req.(type) {
+	case *egextension.PostTranslateModifyRequest:
+		for _, rc := range r.Routes {
+			for _, vh := range rc.VirtualHosts {
+				routes = append(routes, vh.Routes...)
+			}
+		}
+	case *egextension.PostVirtualHostModifyRequest:
+		routes = r.GetVirtualHost().GetRoutes()
+	case *egextension.PostRouteModifyRequest:
+		if r.Route != nil {
+			routes = []*routev3.Route{r.Route}
+		}
+	}
+
+	reported := make(map[client.ObjectKey]struct{})
+	for _, route := range routes {
+		key, ok := aiGatewayRouteKeyOf(route.Name)
+		if !ok {
+			continue
+		}
+		if _, ok = reported[key]; ok {
+			continue
+		}
+		reported[key] = struct{}{}
+		err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			aigwRoute := &aigv1b1.AIGatewayRoute{}
+			if err := s.k8sClient.Get(ctx, key, aigwRoute); err != nil {
+				if apierrors.IsNotFound(err) {
+					return nil
+				}
+				return err
+			}
+			aigwRoute.Status.Conditions = []metav1.Condition{{
+				Type:               aigv1b1.ConditionTypeNotAccepted,
+				Status:             metav1.ConditionFalse,
+				Reason:             incompatibleReason,
+				Message:            message,
+				LastTransitionTime: metav1.Now(),
+			}}
+			return s.k8sClient.Status().Update(ctx, aigwRoute)
+		})
+		if err != nil {
+			s.log.Error(err, "failed to update the status of the AIGatewayRoute", "namespace", key.Namespace, "name", key.Name)
+		}
+	}
+}
+
+// aiGatewayRouteKeyOf returns the key of the AIGatewayRoute the route is generated from, if any.
+//
+// Route name format: "httproute/<namespace>/<name>/rule/<index>/match/<...>".
+func aiGatewayRouteKeyOf(routeName string) (client.ObjectKey, bool) {
+	parts := strings.Split(routeName, "/")
+	if len(parts) < 3 || parts[0] != "httproute" || parts[1] == "" || parts[2] == "" {
+		return client.ObjectKey{}, false
+	}
+	return client.ObjectKey{Namespace: parts[1], Name: parts[2]}, true
+}
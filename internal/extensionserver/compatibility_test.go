@@ -0,0 +1,109 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extensionserver
+
+import (
+	"context"
+	"testing"
+
+	egextension "github.com/envoyproxy/gateway/proto/extension"
+	clusterv3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	listenerv3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	routev3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	httpconnectionmanagerv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aigv1b1 "github.com/envoyproxy/ai-gateway/api/v1beta1"
+)
+
+// withUnknownField returns the message with an unknown field, as sent by a newer Envoy Gateway.
+func withUnknownField[M proto.Message](m M) M {
+	m.ProtoReflect().SetUnknown(protowire.AppendVarint(protowire.AppendTag(nil, 9999, protowire.VarintType), 1))
+	return m
+}
+
+func TestMessagesWithUnknownFields(t *testing.T) {
+	require.Empty(t, messagesWithUnknownFields((&egextension.PostTranslateModifyRequest{
+		Clusters: []*clusterv3.Cluster{{Name: "foo"}},
+	}).ProtoReflect()))
+
+	hcm := withUnknownField(&httpconnectionmanagerv3.HttpConnectionManager{StatPrefix: "http"})
+	req := &egextension.PostTranslateModifyRequest{
+		Clusters: []*clusterv3.Cluster{{Name: "foo"}, withUnknownField(&clusterv3.Cluster{Name: "bar"}), withUnknownField(&clusterv3.Cluster{})},
+		Listeners: []*listenerv3.Listener{{
+			FilterChains: []*listenerv3.FilterChain{{Filters: []*listenerv3.Filter{{
+				Name:       "envoy.filters.network.http_connection_manager",
+				ConfigType: &listenerv3.Filter_TypedConfig{TypedConfig: mustToAny(t, hcm)},
+			}}}},
+		}},
+	}
+	require.Equal(t, []string{
+		"envoy.config.cluster.v3.Cluster",
+		"envoy.extensions.filters.network.http_connection_manager.v3.HttpConnectionManager",
+	}, messagesWithUnknownFields(req.ProtoReflect()))
+}
+
+func TestCompatibilityInterceptor(t *testing.T) {
+	c := newFakeClient()
+	route := &aigv1b1.AIGatewayRoute{ObjectMeta: metav1.ObjectMeta{Name: "route", Namespace: "ns"}}
+	require.NoError(t, c.Create(t.Context(), route))
+	s, err := New(c, logr.Discard(), udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false, "", "")
+	require.NoError(t, err)
+	interceptor := s.CompatibilityInterceptor()
+	info := &grpc.UnaryServerInfo{FullMethod: egextension.EnvoyGatewayExtension_PostTranslateModify_FullMethodName}
+
+	var called bool
+	handler := func(context.Context, any) (any, error) {
+		called = true
+		return &egextension.PostTranslateModifyResponse{}, nil
+	}
+	res, err := interceptor(t.Context(), &egextension.PostTranslateModifyRequest{}, info, handler)
+	require.NoError(t, err)
+	require.NotNil(t, res)
+	require.True(t, called)
+
+	// The incompatible requests fail fast, and the AIGatewayRoutes of their routes are not accepted.
+	called = false
+	req := &egextension.PostTranslateModifyRequest{
+		Routes: []*routev3.RouteConfiguration{{VirtualHosts: []*routev3.VirtualHost{{Routes: []*routev3.Route{
+			withUnknownField(&routev3.Route{Name: "httproute/ns/route/rule/0/match/0/foo"}),
+			{Name: "httproute/ns/route/rule/1/match/0/foo"},
+			{Name: "httproute/ns/other/rule/0/match/0/foo"},
+		}}}}},
+	}
+	_, err = interceptor(t.Context(), req, info, handler)
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+	require.ErrorContains(t, err, "the PostTranslateModify request has fields unknown to the extension server in envoy.config.route.v3.Route")
+	require.False(t, called)
+	require.NoError(t, c.Get(t.Context(), client.ObjectKeyFromObject(route), route))
+	require.Len(t, route.Status.Conditions, 1)
+	require.Equal(t, aigv1b1.ConditionTypeNotAccepted, route.Status.Conditions[0].Type)
+	require.Equal(t, incompatibleReason, route.Status.Conditions[0].Reason)
+	require.Equal(t, status.Convert(err).Message(), route.Status.Conditions[0].Message)
+
+	// The panics are returned as errors.
+	_, err = interceptor(t.Context(), &egextension.PostTranslateModifyRequest{}, info, func(context.Context, any) (any, error) {
+		panic("debugstr")
+	})
+	require.Equal(t, codes.Internal, status.Code(err))
+	require.ErrorContains(t, err, "the extension server failed to process the PostTranslateModify request: debugstr")
+
+	// The other services are not checked.
+	called = false
+	_, err = interceptor(t.Context(), withUnknownField(&grpc_health_v1.HealthCheckRequest{}),
+		&grpc.UnaryServerInfo{FullMethod: grpc_health_v1.Health_Check_FullMethodName}, handler)
+	require.NoError(t, err)
+	require.True(t, called)
+}
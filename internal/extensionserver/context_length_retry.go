@@ -0,0 +1,107 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extensionserver
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	routev3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	previous_prioritiesv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/retry/priority/previous_priorities/v3"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aigv1b1 "github.com/envoyproxy/ai-gateway/api/v1beta1"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+)
+
+const (
+	// retriableHeadersRetryOn is the retry condition retrying the responses having one of the retriable headers.
+	retriableHeadersRetryOn = "retriable-headers"
+	// previousPrioritiesRetryPriority is the retry priority plugin excluding the priorities already attempted.
+	previousPrioritiesRetryPriority = "envoy.retry_priorities.previous_priorities"
+)
+
+// applyContextLengthRetries walks the generated route configurations and adds the retry of the responses marked by
+// the upstream filter as context length errors to the retry policy of the routes generated from the AIGatewayRoute
+// rules configuring ContextLengthRetry. It must run after applyBackendRetryPolicies, which only sets the retry
+// policies of the routes without any.
+func (s *Server) applyContextLengthRetries(ctx context.Context, routeConfigs []*routev3.RouteConfiguration) error {
+	cache := make(map[client.ObjectKey]*aigv1b1.AIGatewayRoute)
+	return s.patchRoutes(routeConfigs, "context_length_retry", func(_ *routev3.RouteConfiguration, route *routev3.Route) error {
+		return s.maybeSetContextLengthRetry(ctx, route, cache)
+	})
+}
+
+// maybeSetContextLengthRetry adds the context length retry of the rule the route is generated from to
+// route.retry_policy, keeping the retry policy already configured on the route.
+func (s *Server) maybeSetContextLengthRetry(ctx context.Context, route *routev3.Route, cache map[client.ObjectKey]*aigv1b1.AIGatewayRoute) error {
+	action := route.GetRoute()
+	if action == nil {
+		return nil
+	}
+
+	// Route name format: "httproute/<namespace>/<name>/rule/<index>/match/<...>".
+	parts := strings.Split(route.Name, "/")
+	if len(parts) < 5 || parts[0] != "httproute" || parts[3] != "rule" || parts[1] == "" || parts[2] == "" {
+		return nil
+	}
+	ruleIndex, err := strconv.Atoi(parts[4])
+	if err != nil {
+		return nil
+	}
+	aigwRoute, err := s.retrieveAndCacheAIGatewayRoute(ctx, cache, client.ObjectKey{Namespace: parts[1], Name: parts[2]})
+	if err != nil {
+		return err
+	}
+	if aigwRoute == nil || ruleIndex >= len(aigwRoute.Spec.Rules) {
+		return nil
+	}
+	retry := aigwRoute.Spec.Rules[ruleIndex].ContextLengthRetry
+	if retry == nil {
+		return nil
+	}
+
+	if action.RetryPolicy == nil {
+		action.RetryPolicy = &routev3.RetryPolicy{}
+	}
+	return mergeContextLengthRetry(action.RetryPolicy, retry.Strategy)
+}
+
+// mergeContextLengthRetry adds the retry of the responses having the ContextLengthExceededHeader to the policy.
+// The Fallback strategy also makes the retry skip the priority of the backend rejecting the request, unless the
+// policy already selects the priorities of the retries.
+func mergeContextLengthRetry(policy *routev3.RetryPolicy, strategy aigv1b1.ContextLengthRetryStrategy) error {
+	switch {
+	case policy.RetryOn == "":
+		policy.RetryOn = retriableHeadersRetryOn
+	case !slices.Contains(strings.Split(policy.RetryOn, ","), retriableHeadersRetryOn):
+		policy.RetryOn += "," + retriableHeadersRetryOn
+	}
+	policy.RetriableHeaders = append(policy.RetriableHeaders, &routev3.HeaderMatcher{
+		Name:                 internalapi.ContextLengthExceededHeader,
+		HeaderMatchSpecifier: &routev3.HeaderMatcher_PresentMatch{PresentMatch: true},
+	})
+	if policy.GetNumRetries().GetValue() == 0 {
+		policy.NumRetries = wrapperspb.UInt32(1)
+	}
+
+	if strategy != aigv1b1.ContextLengthRetryStrategyFallback || policy.RetryPriority != nil {
+		return nil
+	}
+	config, err := toAny(&previous_prioritiesv3.PreviousPrioritiesConfig{UpdateFrequency: 1})
+	if err != nil {
+		return fmt.Errorf("failed to marshal PreviousPrioritiesConfig to Any: %w", err)
+	}
+	policy.RetryPriority = &routev3.RetryPolicy_RetryPriority{
+		Name:       previousPrioritiesRetryPriority,
+		ConfigType: &routev3.RetryPolicy_RetryPriority_TypedConfig{TypedConfig: config},
+	}
+	return nil
+}
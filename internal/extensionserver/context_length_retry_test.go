@@ -0,0 +1,96 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extensionserver
+
+import (
+	"testing"
+
+	routev3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	previous_prioritiesv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/retry/priority/previous_priorities/v3"
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	aigv1b1 "github.com/envoyproxy/ai-gateway/api/v1beta1"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+)
+
+func TestMergeContextLengthRetry(t *testing.T) {
+	exceeded := &routev3.HeaderMatcher{
+		Name:                 internalapi.ContextLengthExceededHeader,
+		HeaderMatchSpecifier: &routev3.HeaderMatcher_PresentMatch{PresentMatch: true},
+	}
+
+	t.Run("drop oldest messages", func(t *testing.T) {
+		policy := &routev3.RetryPolicy{}
+		require.NoError(t, mergeContextLengthRetry(policy, aigv1b1.ContextLengthRetryStrategyDropOldestMessages))
+		require.Equal(t, &routev3.RetryPolicy{
+			RetryOn:          retriableHeadersRetryOn,
+			NumRetries:       wrapperspb.UInt32(1),
+			RetriableHeaders: []*routev3.HeaderMatcher{exceeded},
+		}, policy)
+	})
+
+	t.Run("fallback", func(t *testing.T) {
+		policy := &routev3.RetryPolicy{RetryOn: backendRetryOn, NumRetries: wrapperspb.UInt32(3)}
+		require.NoError(t, mergeContextLengthRetry(policy, aigv1b1.ContextLengthRetryStrategyFallback))
+		require.Equal(t, backendRetryOn+","+retriableHeadersRetryOn, policy.RetryOn)
+		require.Equal(t, wrapperspb.UInt32(3), policy.NumRetries)
+		require.Equal(t, []*routev3.HeaderMatcher{exceeded}, policy.RetriableHeaders)
+		require.Equal(t, previousPrioritiesRetryPriority, policy.RetryPriority.Name)
+		var config previous_prioritiesv3.PreviousPrioritiesConfig
+		require.NoError(t, policy.RetryPriority.GetTypedConfig().UnmarshalTo(&config))
+		require.Equal(t, int32(1), config.UpdateFrequency)
+	})
+
+	t.Run("existing retriable headers and priority", func(t *testing.T) {
+		other := &routev3.HeaderMatcher{Name: "x-retry"}
+		priority := &routev3.RetryPolicy_RetryPriority{Name: "custom"}
+		policy := &routev3.RetryPolicy{
+			RetryOn:          "5xx,retriable-headers",
+			RetriableHeaders: []*routev3.HeaderMatcher{other},
+			RetryPriority:    priority,
+		}
+		require.NoError(t, mergeContextLengthRetry(policy, aigv1b1.ContextLengthRetryStrategyFallback))
+		require.Equal(t, "5xx,retriable-headers", policy.RetryOn)
+		require.Equal(t, []*routev3.HeaderMatcher{other, exceeded}, policy.RetriableHeaders)
+		require.Same(t, priority, policy.RetryPriority)
+	})
+}
+
+func TestApplyContextLengthRetries(t *testing.T) {
+	c := newFakeClient()
+	require.NoError(t, c.Create(t.Context(), &aigv1b1.AIGatewayRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "route", Namespace: "default"},
+		Spec: aigv1b1.AIGatewayRouteSpec{
+			Rules: []aigv1b1.AIGatewayRouteRule{
+				{
+					BackendRefs:        []aigv1b1.AIGatewayRouteRuleBackendRef{{Name: "small"}, {Name: "large", Priority: ptr.To[uint32](1)}},
+					ContextLengthRetry: &aigv1b1.AIGatewayRouteRuleContextLengthRetry{Strategy: aigv1b1.ContextLengthRetryStrategyFallback},
+				},
+				{BackendRefs: []aigv1b1.AIGatewayRouteRuleBackendRef{{Name: "small"}}},
+			},
+		},
+	}))
+	s, err := New(c, logr.Discard(), udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false, "", "")
+	require.NoError(t, err)
+
+	forwarding := func(name string) *routev3.Route {
+		return &routev3.Route{Name: name, Action: &routev3.Route_Route{Route: &routev3.RouteAction{}}}
+	}
+	configured := forwarding("httproute/default/route/rule/0/match/0")
+	plain := forwarding("httproute/default/route/rule/1/match/0")
+	other := forwarding("some-other-route")
+	require.NoError(t, s.applyContextLengthRetries(t.Context(), []*routev3.RouteConfiguration{{
+		VirtualHosts: []*routev3.VirtualHost{{Routes: []*routev3.Route{configured, plain, other}}},
+	}}))
+	require.Equal(t, retriableHeadersRetryOn, configured.GetRoute().GetRetryPolicy().GetRetryOn())
+	require.Equal(t, previousPrioritiesRetryPriority, configured.GetRoute().GetRetryPolicy().GetRetryPriority().GetName())
+	require.Nil(t, plain.GetRoute().RetryPolicy)
+	require.Nil(t, other.GetRoute().RetryPolicy)
+}
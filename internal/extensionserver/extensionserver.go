@@ -6,6 +6,7 @@
 package extensionserver
 
 import (
+	"cmp"
 	"context"
 	"fmt"
 	"net"
@@ -20,6 +21,8 @@ import (
 	"google.golang.org/protobuf/types/known/anypb"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	aigv1b1 "github.com/envoyproxy/ai-gateway/api/v1beta1"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
 	"github.com/envoyproxy/ai-gateway/internal/requestheaderattrs"
 )
 
@@ -42,12 +45,17 @@ type Server struct {
 	quotaRateLimitTimeout int64
 	// quotaRateLimitFailureModeDeny sets the failure mode for the rate limit filter.
 	quotaRateLimitFailureModeDeny bool
+	// metadataNamespace is the namespace of the dynamic metadata emitted by the external processor.
+	metadataNamespace string
+	// selectedBackendHeaderKey is the request header selecting the backend on the AIGatewayRoutes configuring a
+	// BackendOverride.
+	selectedBackendHeaderKey string
 }
 
 const serverName = "envoy-gateway-extension-server"
 
 // New creates a new instance of the extension server that implements the EnvoyGatewayExtensionServer interface.
-func New(k8sClient client.Client, logger logr.Logger, udsPath string, isStandAloneMode bool, requestHeaderAttributes, logRequestHeaderAttributes *string, quotaRateLimitServiceAddr string, quotaRateLimitTimeout int64, quotaRateLimitFailureModeDeny bool) (*Server, error) {
+func New(k8sClient client.Client, logger logr.Logger, udsPath string, isStandAloneMode bool, requestHeaderAttributes, logRequestHeaderAttributes *string, quotaRateLimitServiceAddr string, quotaRateLimitTimeout int64, quotaRateLimitFailureModeDeny bool, metadataNamespace, selectedBackendHeaderKey string) (*Server, error) {
 	logger = logger.WithName(serverName)
 	logAttrs, err := requestheaderattrs.ResolveLog(requestHeaderAttributes, logRequestHeaderAttributes)
 	if err != nil {
@@ -69,6 +77,8 @@ func New(k8sClient client.Client, logger logr.Logger, udsPath string, isStandAlo
 		quotaRateLimitServicePort:     port,
 		quotaRateLimitTimeout:         quotaRateLimitTimeout,
 		quotaRateLimitFailureModeDeny: quotaRateLimitFailureModeDeny,
+		metadataNamespace:             cmp.Or(metadataNamespace, aigv1b1.AIGatewayFilterMetadataNamespace),
+		selectedBackendHeaderKey:      cmp.Or(selectedBackendHeaderKey, internalapi.BackendOverrideHeader),
 	}, nil
 }
 
@@ -72,20 +72,27 @@ func newFakeClient() client.Client {
 const udsPath = "/tmp/uds/test.sock"
 
 func TestNew(t *testing.T) {
-	s, err := New(newFakeClient(), logr.Discard(), udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false)
+	s, err := New(newFakeClient(), logr.Discard(), udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false, "", "")
 	require.NoError(t, err)
 	require.NotNil(t, s)
+	require.Equal(t, aigv1b1.AIGatewayFilterMetadataNamespace, s.metadataNamespace)
+	require.Equal(t, internalapi.BackendOverrideHeader, s.selectedBackendHeaderKey)
+
+	s, err = New(newFakeClient(), logr.Discard(), udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false, "io.example.gateway", "x-backend")
+	require.NoError(t, err)
+	require.Equal(t, "io.example.gateway", s.metadataNamespace)
+	require.Equal(t, "x-backend", s.selectedBackendHeaderKey)
 }
 
 func TestCheck(t *testing.T) {
-	s, err := New(newFakeClient(), logr.Discard(), udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false)
+	s, err := New(newFakeClient(), logr.Discard(), udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false, "", "")
 	require.NoError(t, err)
 	_, err = s.Check(t.Context(), nil)
 	require.NoError(t, err)
 }
 
 func TestWatch(t *testing.T) {
-	s, err := New(newFakeClient(), logr.Discard(), udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false)
+	s, err := New(newFakeClient(), logr.Discard(), udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false, "", "")
 	require.NoError(t, err)
 	err = s.Watch(nil, nil)
 	require.Error(t, err)
@@ -94,7 +101,7 @@ func TestWatch(t *testing.T) {
 
 func TestServerPostTranslateModify(t *testing.T) {
 	t.Run("existing", func(t *testing.T) {
-		s, err := New(newFakeClient(), logr.Discard(), udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false)
+		s, err := New(newFakeClient(), logr.Discard(), udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false, "", "")
 		require.NoError(t, err)
 		req := &egextension.PostTranslateModifyRequest{Clusters: []*clusterv3.Cluster{{Name: extProcUDSClusterName}}}
 		res, err := s.PostTranslateModify(t.Context(), req)
@@ -104,7 +111,7 @@ func TestServerPostTranslateModify(t *testing.T) {
 		require.NoError(t, err)
 	})
 	t.Run("not existing", func(t *testing.T) {
-		s, err := New(newFakeClient(), logr.Discard(), udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false)
+		s, err := New(newFakeClient(), logr.Discard(), udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false, "", "")
 		require.NoError(t, err)
 		res, err := s.PostTranslateModify(t.Context(), &egextension.PostTranslateModifyRequest{
 			Clusters: []*clusterv3.Cluster{{Name: "foo"}},
@@ -156,7 +163,7 @@ func Test_maybeModifyCluster(t *testing.T) {
 	} {
 		t.Run("error/"+tc.errLog, func(t *testing.T) {
 			var buf bytes.Buffer
-			s, err := New(c, logr.FromSlogHandler(slog.NewTextHandler(&buf, &slog.HandlerOptions{})), udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false)
+			s, err := New(c, logr.FromSlogHandler(slog.NewTextHandler(&buf, &slog.HandlerOptions{})), udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false, "", "")
 			require.NoError(t, err)
 			err = s.maybeModifyCluster(t.Context(), tc.c)
 			require.NoError(t, err)
@@ -212,6 +219,7 @@ func Test_maybeModifyCluster(t *testing.T) {
 											internalapi.XDSUpstreamHostMetadataBackendNamePath,
 											internalapi.XDSClusterMetadataBackendNamePath,
 											internalapi.XDSRouteMetadataRouteNamePath,
+											internalapi.UpstreamAddressAttribute,
 										},
 										ProcessingMode: &extprocv3.ProcessingMode{
 											RequestHeaderMode:  extprocv3.ProcessingMode_SEND,
@@ -346,6 +354,7 @@ func Test_maybeModifyCluster(t *testing.T) {
 											internalapi.XDSUpstreamHostMetadataBackendNamePath,
 											internalapi.XDSClusterMetadataBackendNamePath,
 											internalapi.XDSRouteMetadataRouteNamePath,
+											internalapi.UpstreamAddressAttribute,
 										},
 										ProcessingMode: &extprocv3.ProcessingMode{
 											RequestHeaderMode:  extprocv3.ProcessingMode_SEND,
@@ -409,7 +418,7 @@ func Test_maybeModifyCluster(t *testing.T) {
 					return a
 				},
 			})
-			s, err := New(c, logr.FromSlogHandler(handler), udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false)
+			s, err := New(c, logr.FromSlogHandler(handler), udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false, "", "")
 			require.NoError(t, err)
 			err = s.maybeModifyCluster(t.Context(), tc.cluster)
 			require.NoError(t, err)
@@ -475,9 +484,12 @@ func TestMaybeModifyClusterPerBackendClusterName(t *testing.T) {
 					{Name: "primary", Priority: ptr.To[uint32](0)},
 					{Name: "fallback", Priority: ptr.To[uint32](1)},
 				},
+				Migration: &aigv1b1.AIGatewayRouteRuleMigration{
+					BackendRef: aigv1b1.AIGatewayRouteRuleBackendRef{Name: "migration", Priority: ptr.To[uint32](1)},
+				},
 			}}},
 		}))
-		s, err := New(c, logr.Discard(), udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false)
+		s, err := New(c, logr.Discard(), udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false, "", "")
 		require.NoError(t, err)
 		return s
 	}
@@ -511,6 +523,21 @@ func TestMaybeModifyClusterPerBackendClusterName(t *testing.T) {
 			internalapi.PerRouteRuleRefBackendName("ns", "primary", "myroute", 0, 0))
 		require.Contains(t, cluster.TypedExtensionProtocolOptions, "envoy.extensions.upstreams.http.v3.HttpProtocolOptions")
 	})
+
+	t.Run("sets endpoint metadata for the migration backend", func(t *testing.T) {
+		// The migration rule follows the rule and the route-not-found rule.
+		cluster := &clusterv3.Cluster{
+			Name: "httproute/ns/myroute/rule/2/backend/0",
+			LoadAssignment: &endpointv3.ClusterLoadAssignment{Endpoints: []*endpointv3.LocalityLbEndpoints{{
+				LbEndpoints: []*endpointv3.LbEndpoint{{}},
+			}}},
+		}
+		require.NoError(t, newServer(t).maybeModifyCluster(t.Context(), cluster))
+		// The priority of the migration backend is ignored.
+		require.Zero(t, cluster.LoadAssignment.Endpoints[0].Priority)
+		assertBackendName(t, cluster.LoadAssignment.Endpoints[0].LbEndpoints[0].Metadata,
+			internalapi.PerRouteRuleRefBackendName("ns", "migration", "myroute", 2, 0))
+	})
 }
 
 // Helper function to create an InferencePool ExtensionResource.
@@ -566,7 +593,7 @@ func TestMaybeModifyClusterExtended(t *testing.T) {
 
 	t.Run("AIGatewayRoute not found", func(t *testing.T) {
 		var buf bytes.Buffer
-		s, err := New(c, logr.FromSlogHandler(slog.NewTextHandler(&buf, &slog.HandlerOptions{})), udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false)
+		s, err := New(c, logr.FromSlogHandler(slog.NewTextHandler(&buf, &slog.HandlerOptions{})), udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false, "", "")
 		require.NoError(t, err)
 		cluster := &clusterv3.Cluster{Name: "httproute/test-ns/nonexistent-route/rule/0", Metadata: &corev3.Metadata{}}
 		err = s.maybeModifyCluster(t.Context(), cluster)
@@ -576,7 +603,7 @@ func TestMaybeModifyClusterExtended(t *testing.T) {
 
 	t.Run("cluster with InferencePool metadata and existing route", func(t *testing.T) {
 		var buf bytes.Buffer
-		s, err := New(c, logr.FromSlogHandler(slog.NewTextHandler(&buf, &slog.HandlerOptions{})), udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false)
+		s, err := New(c, logr.FromSlogHandler(slog.NewTextHandler(&buf, &slog.HandlerOptions{})), udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false, "", "")
 		require.NoError(t, err)
 
 		cluster := &clusterv3.Cluster{
@@ -606,7 +633,7 @@ func TestMaybeModifyClusterExtended(t *testing.T) {
 	})
 
 	t.Run("cluster with existing HttpProtocolOptions", func(t *testing.T) {
-		s, err := New(c, logr.Discard(), udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false)
+		s, err := New(c, logr.Discard(), udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false, "", "")
 		require.NoError(t, err)
 
 		// Create existing HttpProtocolOptions.
@@ -655,7 +682,7 @@ func TestMaybeModifyClusterExtended(t *testing.T) {
 	})
 
 	t.Run("cluster with existing ext_proc filter", func(t *testing.T) {
-		s, err := New(c, logr.Discard(), udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false)
+		s, err := New(c, logr.Discard(), udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false, "", "")
 		require.NoError(t, err)
 
 		// Create HttpProtocolOptions with existing ext_proc filter.
@@ -699,7 +726,7 @@ func TestMaybeModifyClusterExtended(t *testing.T) {
 	})
 
 	t.Run("cluster with no existing HttpFilters", func(t *testing.T) {
-		s, err := New(c, logr.Discard(), udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false)
+		s, err := New(c, logr.Discard(), udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false, "", "")
 		require.NoError(t, err)
 
 		cluster := &clusterv3.Cluster{
@@ -733,7 +760,7 @@ func TestMaybeModifyClusterExtended(t *testing.T) {
 
 	t.Run("invalid HttpProtocolOptions unmarshal", func(t *testing.T) {
 		var buf bytes.Buffer
-		s, err := New(c, logr.FromSlogHandler(slog.NewTextHandler(&buf, &slog.HandlerOptions{})), udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false)
+		s, err := New(c, logr.FromSlogHandler(slog.NewTextHandler(&buf, &slog.HandlerOptions{})), udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false, "", "")
 		require.NoError(t, err)
 
 		// Create invalid Any message.
@@ -764,7 +791,7 @@ func TestMaybeModifyClusterExtended(t *testing.T) {
 
 // TestMaybeModifyListenerAndRoutes tests the maybeModifyListenerAndRoutes function.
 func TestMaybeModifyListenerAndRoutes(t *testing.T) {
-	s, err := New(newFakeClient(), logr.Discard(), udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false)
+	s, err := New(newFakeClient(), logr.Discard(), udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false, "", "")
 	require.NoError(t, err)
 
 	// Helper function to create a basic listener.
@@ -966,7 +993,7 @@ func TestMaybeModifyListenerAndRoutes(t *testing.T) {
 
 // TestPatchListenerWithInferencePoolFilters tests the patchListenerWithInferencePoolFilters function.
 func TestPatchListenerWithInferencePoolFilters(t *testing.T) {
-	s, err := New(newFakeClient(), logr.Discard(), udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false)
+	s, err := New(newFakeClient(), logr.Discard(), udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false, "", "")
 	require.NoError(t, err)
 
 	// Helper function to create an InferencePool.
@@ -1016,7 +1043,7 @@ func TestPatchListenerWithInferencePoolFilters(t *testing.T) {
 
 	t.Run("listener with filter chains but no HCM", func(t *testing.T) {
 		var buf bytes.Buffer
-		server, err := New(newFakeClient(), logr.FromSlogHandler(slog.NewTextHandler(&buf, &slog.HandlerOptions{})), udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false)
+		server, err := New(newFakeClient(), logr.FromSlogHandler(slog.NewTextHandler(&buf, &slog.HandlerOptions{})), udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false, "", "")
 		require.NoError(t, err)
 
 		listener := &listenerv3.Listener{
@@ -1144,7 +1171,7 @@ func TestPatchListenerWithInferencePoolFilters(t *testing.T) {
 
 	t.Run("error marshaling updated HCM", func(_ *testing.T) {
 		var buf bytes.Buffer
-		server, err := New(newFakeClient(), logr.FromSlogHandler(slog.NewTextHandler(&buf, &slog.HandlerOptions{})), udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false)
+		server, err := New(newFakeClient(), logr.FromSlogHandler(slog.NewTextHandler(&buf, &slog.HandlerOptions{})), udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false, "", "")
 		require.NoError(t, err)
 
 		// Create a listener with an HCM that will cause marshaling issues.
@@ -1163,7 +1190,7 @@ func TestPatchListenerWithInferencePoolFilters(t *testing.T) {
 
 // TestPatchVirtualHostWithInferencePool tests the patchVirtualHostWithInferencePool function.
 func TestPatchVirtualHostWithInferencePool(t *testing.T) {
-	s, err := New(newFakeClient(), logr.Discard(), udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false)
+	s, err := New(newFakeClient(), logr.Discard(), udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false, "", "")
 	require.NoError(t, err)
 
 	// Helper function to create an InferencePool.
@@ -1372,7 +1399,7 @@ func TestPatchVirtualHostWithInferencePool(t *testing.T) {
 // TestPostClusterModify tests the PostClusterModify method.
 func TestPostClusterModify(t *testing.T) {
 	logger := logr.Discard()
-	s, err := New(newFakeClient(), logger, udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false)
+	s, err := New(newFakeClient(), logger, udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false, "", "")
 	require.NoError(t, err)
 
 	t.Run("nil cluster", func(t *testing.T) {
@@ -1412,7 +1439,7 @@ func TestPostClusterModify(t *testing.T) {
 		// Use a logger that captures output for debugging.
 		var buf bytes.Buffer
 		logger := logr.FromSlogHandler(slog.NewTextHandler(&buf, &slog.HandlerOptions{}))
-		s, err := New(newFakeClient(), logger, udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false)
+		s, err := New(newFakeClient(), logger, udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false, "", "")
 		require.NoError(t, err)
 
 		cluster := &clusterv3.Cluster{
@@ -1458,7 +1485,7 @@ func TestPostClusterModify(t *testing.T) {
 // TestPostRouteModify tests the PostRouteModify method.
 func TestPostRouteModify(t *testing.T) {
 	logger := logr.Discard()
-	s, err := New(newFakeClient(), logger, udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false)
+	s, err := New(newFakeClient(), logger, udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false, "", "")
 	require.NoError(t, err)
 
 	t.Run("nil route", func(t *testing.T) {
@@ -1560,11 +1587,13 @@ func TestMaybeSetStreamIdleTimeout(t *testing.T) {
 			Rules: []aigv1b1.AIGatewayRouteRule{
 				{StreamIdleTimeout: ptr.To(gwapiv1.Duration("10s"))},
 				{}, // No StreamIdleTimeout.
+				{StreamIdleTimeout: ptr.To(gwapiv1.Duration("10s")), StreamStallTimeout: ptr.To(gwapiv1.Duration("3s"))},
+				{StreamStallTimeout: ptr.To(gwapiv1.Duration("20s"))},
 			},
 		},
 	})
 	require.NoError(t, err)
-	s, err := New(c, logr.Discard(), udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false)
+	s, err := New(c, logr.Discard(), udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false, "", "")
 	require.NoError(t, err)
 
 	forwardingRoute := func(name string) *routev3.Route {
@@ -1596,6 +1625,15 @@ func TestMaybeSetStreamIdleTimeout(t *testing.T) {
 		require.Nil(t, route.GetRoute().RetryPolicy)
 	})
 
+	t.Run("uses the shorter stream stall timeout", func(t *testing.T) {
+		route := forwardingRoute("httproute/default/ttft-route/rule/2/match/0")
+		call(t, route)
+		require.Equal(t, durationpb.New(3*time.Second), route.GetRoute().RetryPolicy.GetPerTryIdleTimeout())
+		route = forwardingRoute("httproute/default/ttft-route/rule/3/match/0")
+		call(t, route)
+		require.Equal(t, durationpb.New(20*time.Second), route.GetRoute().RetryPolicy.GetPerTryIdleTimeout())
+	})
+
 	t.Run("ignores non-forwarding route", func(t *testing.T) {
 		route := &routev3.Route{
 			Name:   "httproute/default/ttft-route/rule/0/match/0",
@@ -1657,7 +1695,7 @@ func TestApplyStreamIdleTimeouts(t *testing.T) {
 			Rules: []aigv1b1.AIGatewayRouteRule{{StreamIdleTimeout: ptr.To(gwapiv1.Duration("7s"))}},
 		},
 	}))
-	s, err := New(c, logr.Discard(), udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false)
+	s, err := New(c, logr.Discard(), udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false, "", "")
 	require.NoError(t, err)
 
 	forwarding := func(name string) *routev3.Route {
@@ -1681,7 +1719,7 @@ func TestApplyStreamIdleTimeouts(t *testing.T) {
 					return errors.New("boom")
 				},
 			}).Build(),
-		logr.Discard(), udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false)
+		logr.Discard(), udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false, "", "")
 	require.NoError(t, err)
 	err = failing.applyStreamIdleTimeouts(context.Background(),
 		[]*routev3.RouteConfiguration{{VirtualHosts: []*routev3.VirtualHost{{Routes: []*routev3.Route{
@@ -1693,7 +1731,7 @@ func TestApplyStreamIdleTimeouts(t *testing.T) {
 // TestConstructInferencePoolsFrom tests the constructInferencePoolsFrom method.
 func TestConstructInferencePoolsFrom(t *testing.T) {
 	logger := logr.Discard()
-	s, err := New(newFakeClient(), logger, udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false)
+	s, err := New(newFakeClient(), logger, udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false, "", "")
 	require.NoError(t, err)
 
 	t.Run("empty resources", func(t *testing.T) {
@@ -2170,7 +2208,7 @@ func TestBuildClustersForInferencePoolEndpointPickers(t *testing.T) {
 // TestPostTranslateModify tests the PostTranslateModify method.
 func TestPostTranslateModify(t *testing.T) {
 	logger := logr.Discard()
-	s, err := New(newFakeClient(), logger, udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false)
+	s, err := New(newFakeClient(), logger, udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false, "", "")
 	require.NoError(t, err)
 
 	t.Run("empty request", func(t *testing.T) {
@@ -2195,7 +2233,7 @@ func TestPostTranslateModify(t *testing.T) {
 	})
 
 	t.Run("with log header mapping inserts header_to_metadata filter", func(t *testing.T) {
-		s, err := New(newFakeClient(), logger, udsPath, false, nil, ptr.To("agent-session-id:session.id"), "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false)
+		s, err := New(newFakeClient(), logger, udsPath, false, nil, ptr.To("agent-session-id:session.id"), "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false, "", "")
 		require.NoError(t, err)
 		hcm := &httpconnectionmanagerv3.HttpConnectionManager{
 			HttpFilters: []*httpconnectionmanagerv3.HttpFilter{{Name: wellknown.Router}},
@@ -2232,7 +2270,7 @@ func TestPostTranslateModify(t *testing.T) {
 	})
 
 	t.Run("with existing header_to_metadata merges log mapping", func(t *testing.T) {
-		s, err := New(newFakeClient(), logger, udsPath, false, nil, ptr.To("agent-session-id:session.id"), "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false)
+		s, err := New(newFakeClient(), logger, udsPath, false, nil, ptr.To("agent-session-id:session.id"), "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false, "", "")
 		require.NoError(t, err)
 		existingCfg := &htomv3.Config{
 			RequestRules: []*htomv3.Config_Rule{
@@ -2285,7 +2323,7 @@ func TestPostTranslateModify(t *testing.T) {
 	})
 
 	t.Run("without log header mapping leaves filters untouched", func(t *testing.T) {
-		s, err := New(newFakeClient(), logger, udsPath, false, nil, ptr.To(""), "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false)
+		s, err := New(newFakeClient(), logger, udsPath, false, nil, ptr.To(""), "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false, "", "")
 		require.NoError(t, err)
 		hcm := &httpconnectionmanagerv3.HttpConnectionManager{
 			HttpFilters: []*httpconnectionmanagerv3.HttpFilter{{Name: wellknown.Router}},
@@ -2317,7 +2355,7 @@ func TestPostTranslateModify(t *testing.T) {
 // TestList tests the List method (health check).
 func TestList(t *testing.T) {
 	logger := logr.Discard()
-	s, err := New(newFakeClient(), logger, udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false)
+	s, err := New(newFakeClient(), logger, udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false, "", "")
 	require.NoError(t, err)
 
 	t.Run("list health statuses", func(t *testing.T) {
@@ -2334,7 +2372,7 @@ func TestQuotaRateLimitConfiguration(t *testing.T) {
 	logger := logr.Discard()
 
 	t.Run("default quota rate limit configuration", func(t *testing.T) {
-		s, err := New(newFakeClient(), logger, udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false)
+		s, err := New(newFakeClient(), logger, udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false, "", "")
 		require.NoError(t, err)
 		require.NotNil(t, s)
 		require.Equal(t, int64(5), s.quotaRateLimitTimeout)
@@ -2344,7 +2382,7 @@ func TestQuotaRateLimitConfiguration(t *testing.T) {
 	})
 
 	t.Run("custom quota rate limit timeout", func(t *testing.T) {
-		s, err := New(newFakeClient(), logger, udsPath, false, nil, nil, "custom-ratelimit-service", 10, false)
+		s, err := New(newFakeClient(), logger, udsPath, false, nil, nil, "custom-ratelimit-service", 10, false, "", "")
 		require.NoError(t, err)
 		require.NotNil(t, s)
 		require.Equal(t, int64(10), s.quotaRateLimitTimeout)
@@ -2354,7 +2392,7 @@ func TestQuotaRateLimitConfiguration(t *testing.T) {
 	})
 
 	t.Run("quota rate limit with failure mode deny enabled", func(t *testing.T) {
-		s, err := New(newFakeClient(), logger, udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, true)
+		s, err := New(newFakeClient(), logger, udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, true, "", "")
 		require.NoError(t, err)
 		require.NotNil(t, s)
 		require.Equal(t, int64(5), s.quotaRateLimitTimeout)
@@ -2364,7 +2402,7 @@ func TestQuotaRateLimitConfiguration(t *testing.T) {
 	})
 
 	t.Run("custom quota rate limit with both parameters", func(t *testing.T) {
-		s, err := New(newFakeClient(), logger, udsPath, false, nil, nil, "my-custom-ratelimit", 30, true)
+		s, err := New(newFakeClient(), logger, udsPath, false, nil, nil, "my-custom-ratelimit", 30, true, "", "")
 		require.NoError(t, err)
 		require.NotNil(t, s)
 		require.Equal(t, int64(30), s.quotaRateLimitTimeout)
@@ -2374,7 +2412,7 @@ func TestQuotaRateLimitConfiguration(t *testing.T) {
 	})
 
 	t.Run("custom quota rate limit host with port", func(t *testing.T) {
-		s, err := New(newFakeClient(), logger, udsPath, false, nil, nil, "my-custom-ratelimit:9090", 5, false)
+		s, err := New(newFakeClient(), logger, udsPath, false, nil, nil, "my-custom-ratelimit:9090", 5, false, "", "")
 		require.NoError(t, err)
 		require.NotNil(t, s)
 		require.Equal(t, "my-custom-ratelimit", s.quotaRateLimitServiceHost)
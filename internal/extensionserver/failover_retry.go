@@ -0,0 +1,128 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extensionserver
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	routev3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	previous_hostsv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/retry/host/previous_hosts/v3"
+	previous_prioritiesv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/retry/priority/previous_priorities/v3"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aigv1b1 "github.com/envoyproxy/ai-gateway/api/v1beta1"
+)
+
+const (
+	// defaultFailoverMaxRetries is the default of AIGatewayRouteRuleFailoverRetry.MaxRetries.
+	defaultFailoverMaxRetries = 2
+	// failoverHostSelectionRetryMaxAttempts is the number of times the load balancer selects a host again when the
+	// selected one was already attempted.
+	failoverHostSelectionRetryMaxAttempts = 5
+)
+
+// failoverRetriableStatusCodes are the status codes of the backends being drained or restarted during a rollout.
+var failoverRetriableStatusCodes = []uint32{502, 503}
+
+// applyFailoverRetries walks the generated route configurations and adds the re-dispatch of the requests failing
+// mid-flight to the retry policy of the routes generated from the AIGatewayRoute rules configuring FailoverRetry. It
+// must run after applyBackendRetryPolicies, which only sets the retry policies of the routes without any.
+func (s *Server) applyFailoverRetries(ctx context.Context, routeConfigs []*routev3.RouteConfiguration) error {
+	cache := make(map[client.ObjectKey]*aigv1b1.AIGatewayRoute)
+	return s.patchRoutes(routeConfigs, "failover_retry", func(_ *routev3.RouteConfiguration, route *routev3.Route) error {
+		return s.maybeSetFailoverRetry(ctx, route, cache)
+	})
+}
+
+// maybeSetFailoverRetry adds the failover retry of the rule the route is generated from to route.retry_policy,
+// keeping the retry policy already configured on the route.
+func (s *Server) maybeSetFailoverRetry(ctx context.Context, route *routev3.Route, cache map[client.ObjectKey]*aigv1b1.AIGatewayRoute) error {
+	action := route.GetRoute()
+	if action == nil {
+		return nil
+	}
+
+	// Route name format: "httproute/<namespace>/<name>/rule/<index>/match/<...>".
+	parts := strings.Split(route.Name, "/")
+	if len(parts) < 5 || parts[0] != "httproute" || parts[3] != "rule" || parts[1] == "" || parts[2] == "" {
+		return nil
+	}
+	ruleIndex, err := strconv.Atoi(parts[4])
+	if err != nil {
+		return nil
+	}
+	aigwRoute, err := s.retrieveAndCacheAIGatewayRoute(ctx, cache, client.ObjectKey{Namespace: parts[1], Name: parts[2]})
+	if err != nil {
+		return err
+	}
+	if aigwRoute == nil || ruleIndex >= len(aigwRoute.Spec.Rules) {
+		return nil
+	}
+	retry := aigwRoute.Spec.Rules[ruleIndex].FailoverRetry
+	if retry == nil {
+		return nil
+	}
+
+	if action.RetryPolicy == nil {
+		action.RetryPolicy = &routev3.RetryPolicy{}
+	}
+	maxRetries := uint32(defaultFailoverMaxRetries)
+	if retry.MaxRetries != nil {
+		maxRetries = uint32(*retry.MaxRetries) // #nosec G115
+	}
+	return mergeFailoverRetry(action.RetryPolicy, maxRetries)
+}
+
+// mergeFailoverRetry adds the retry of the connection failures, the resets and the failoverRetriableStatusCodes to
+// the policy, and raises its number of retries to maxRetries. The retries avoid the hosts and the priorities already
+// attempted unless the policy already selects them.
+func mergeFailoverRetry(policy *routev3.RetryPolicy, maxRetries uint32) error {
+	retryOn := strings.Split(policy.RetryOn, ",")
+	for _, on := range strings.Split(backendRetryOn, ",") {
+		if !slices.Contains(retryOn, on) {
+			retryOn = append(retryOn, on)
+		}
+	}
+	policy.RetryOn = strings.Join(slices.DeleteFunc(retryOn, func(on string) bool { return on == "" }), ",")
+	for _, code := range failoverRetriableStatusCodes {
+		if !slices.Contains(policy.RetriableStatusCodes, code) {
+			policy.RetriableStatusCodes = append(policy.RetriableStatusCodes, code)
+		}
+	}
+	if policy.GetNumRetries().GetValue() < maxRetries {
+		policy.NumRetries = wrapperspb.UInt32(maxRetries)
+	}
+
+	if len(policy.RetryHostPredicate) == 0 {
+		config, err := toAny(&previous_hostsv3.PreviousHostsPredicate{})
+		if err != nil {
+			return fmt.Errorf("failed to marshal PreviousHostsPredicate to Any: %w", err)
+		}
+		policy.RetryHostPredicate = []*routev3.RetryPolicy_RetryHostPredicate{{
+			Name:       previousHostsRetryHostPredicate,
+			ConfigType: &routev3.RetryPolicy_RetryHostPredicate_TypedConfig{TypedConfig: config},
+		}}
+		if policy.HostSelectionRetryMaxAttempts == 0 {
+			policy.HostSelectionRetryMaxAttempts = failoverHostSelectionRetryMaxAttempts
+		}
+	}
+	if policy.RetryPriority == nil {
+		config, err := toAny(&previous_prioritiesv3.PreviousPrioritiesConfig{UpdateFrequency: 1})
+		if err != nil {
+			return fmt.Errorf("failed to marshal PreviousPrioritiesConfig to Any: %w", err)
+		}
+		policy.RetryPriority = &routev3.RetryPolicy_RetryPriority{
+			Name:       previousPrioritiesRetryPriority,
+			ConfigType: &routev3.RetryPolicy_RetryPriority_TypedConfig{TypedConfig: config},
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extensionserver
+
+import (
+	"testing"
+
+	routev3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	aigv1b1 "github.com/envoyproxy/ai-gateway/api/v1beta1"
+)
+
+func TestMergeFailoverRetry(t *testing.T) {
+	t.Run("empty policy", func(t *testing.T) {
+		policy := &routev3.RetryPolicy{}
+		require.NoError(t, mergeFailoverRetry(policy, 2))
+		require.Equal(t, backendRetryOn, policy.RetryOn)
+		require.Equal(t, []uint32{502, 503}, policy.RetriableStatusCodes)
+		require.Equal(t, wrapperspb.UInt32(2), policy.NumRetries)
+		require.Equal(t, previousHostsRetryHostPredicate, policy.RetryHostPredicate[0].Name)
+		require.Equal(t, int64(failoverHostSelectionRetryMaxAttempts), policy.HostSelectionRetryMaxAttempts)
+		require.Equal(t, previousPrioritiesRetryPriority, policy.RetryPriority.Name)
+	})
+
+	t.Run("existing policy", func(t *testing.T) {
+		predicate := &routev3.RetryPolicy_RetryHostPredicate{Name: "custom"}
+		priority := &routev3.RetryPolicy_RetryPriority{Name: "custom"}
+		policy := &routev3.RetryPolicy{
+			RetryOn:              "retriable-headers,reset",
+			RetriableStatusCodes: []uint32{429, 503},
+			NumRetries:           wrapperspb.UInt32(5),
+			RetryHostPredicate:   []*routev3.RetryPolicy_RetryHostPredicate{predicate},
+			RetryPriority:        priority,
+		}
+		require.NoError(t, mergeFailoverRetry(policy, 2))
+		require.Equal(t, "retriable-headers,reset,connect-failure,refused-stream,retriable-status-codes", policy.RetryOn)
+		require.Equal(t, []uint32{429, 503, 502}, policy.RetriableStatusCodes)
+		// The larger retry budget of the policy is kept.
+		require.Equal(t, wrapperspb.UInt32(5), policy.NumRetries)
+		require.Equal(t, []*routev3.RetryPolicy_RetryHostPredicate{predicate}, policy.RetryHostPredicate)
+		require.Zero(t, policy.HostSelectionRetryMaxAttempts)
+		require.Same(t, priority, policy.RetryPriority)
+	})
+}
+
+func TestApplyFailoverRetries(t *testing.T) {
+	c := newFakeClient()
+	require.NoError(t, c.Create(t.Context(), &aigv1b1.AIGatewayRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "route", Namespace: "default"},
+		Spec: aigv1b1.AIGatewayRouteSpec{
+			Rules: []aigv1b1.AIGatewayRouteRule{
+				{
+					BackendRefs:   []aigv1b1.AIGatewayRouteRuleBackendRef{{Name: "blue"}, {Name: "green", Priority: ptr.To[uint32](1)}},
+					FailoverRetry: &aigv1b1.AIGatewayRouteRuleFailoverRetry{MaxRetries: ptr.To[int32](3)},
+				},
+				{
+					BackendRefs:   []aigv1b1.AIGatewayRouteRuleBackendRef{{Name: "blue"}},
+					FailoverRetry: &aigv1b1.AIGatewayRouteRuleFailoverRetry{},
+				},
+				{BackendRefs: []aigv1b1.AIGatewayRouteRuleBackendRef{{Name: "blue"}}},
+			},
+		},
+	}))
+	s, err := New(c, logr.Discard(), udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false, "", "")
+	require.NoError(t, err)
+
+	forwarding := func(name string) *routev3.Route {
+		return &routev3.Route{Name: name, Action: &routev3.Route_Route{Route: &routev3.RouteAction{}}}
+	}
+	configured := forwarding("httproute/default/route/rule/0/match/0")
+	defaulted := forwarding("httproute/default/route/rule/1/match/0")
+	plain := forwarding("httproute/default/route/rule/2/match/0")
+	other := forwarding("some-other-route")
+	require.NoError(t, s.applyFailoverRetries(t.Context(), []*routev3.RouteConfiguration{{
+		VirtualHosts: []*routev3.VirtualHost{{Routes: []*routev3.Route{configured, defaulted, plain, other}}},
+	}}))
+	require.Equal(t, backendRetryOn, configured.GetRoute().GetRetryPolicy().GetRetryOn())
+	require.Equal(t, uint32(3), configured.GetRoute().GetRetryPolicy().GetNumRetries().GetValue())
+	require.Equal(t, uint32(defaultFailoverMaxRetries), defaulted.GetRoute().GetRetryPolicy().GetNumRetries().GetValue())
+	require.Nil(t, plain.GetRoute().RetryPolicy)
+	require.Nil(t, other.GetRoute().RetryPolicy)
+}
@@ -0,0 +1,134 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extensionserver
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	listenerv3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	routev3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	httpv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/upstreams/http/v3"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aigv1b1 "github.com/envoyproxy/ai-gateway/api/v1beta1"
+)
+
+// headerLimits are the header limits applied to a listener, where zero means unset.
+type headerLimits struct {
+	maxRequestHeadersKiB uint32
+	maxHeadersCount      uint32
+}
+
+// merge raises the limits to the HeaderLimits of an AIGatewayRoute.
+func (l *headerLimits) merge(limits *aigv1b1.AIGatewayRouteHeaderLimits) {
+	if limits == nil {
+		return
+	}
+	if v := limits.MaxRequestHeadersKiB; v != nil && *v > 0 {
+		l.maxRequestHeadersKiB = max(l.maxRequestHeadersKiB, uint32(*v))
+	}
+	if v := limits.MaxHeadersCount; v != nil && *v > 0 {
+		l.maxHeadersCount = max(l.maxHeadersCount, uint32(*v))
+	}
+}
+
+// applyHeaderLimits sets the HeaderLimits of each AIGatewayRoute on the HCMs of the listeners serving
+// the routes generated from it. The limits of a listener are the largest ones of its routes.
+func (s *Server) applyHeaderLimits(ctx context.Context, listeners []*listenerv3.Listener, routeConfigs []*routev3.RouteConfiguration) error {
+	cache := make(map[client.ObjectKey]*aigv1b1.AIGatewayRoute)
+	limitsByRouteConfig := make(map[string]headerLimits)
+	for _, rc := range routeConfigs {
+		for _, vh := range rc.VirtualHosts {
+			for _, route := range vh.Routes {
+				// Route name format: "httproute/<namespace>/<name>/rule/<index>/match/<...>".
+				parts := strings.Split(route.Name, "/")
+				if len(parts) < 3 || parts[0] != "httproute" || parts[1] == "" || parts[2] == "" {
+					continue
+				}
+				aigwRoute, err := s.retrieveAndCacheAIGatewayRoute(ctx, cache, client.ObjectKey{Namespace: parts[1], Name: parts[2]})
+				if err != nil {
+					return err
+				}
+				if aigwRoute == nil || aigwRoute.Spec.HeaderLimits == nil {
+					continue
+				}
+				limits := limitsByRouteConfig[rc.Name]
+				limits.merge(aigwRoute.Spec.HeaderLimits)
+				limitsByRouteConfig[rc.Name] = limits
+			}
+		}
+	}
+	if len(limitsByRouteConfig) == 0 {
+		return nil
+	}
+
+	for _, listener := range listeners {
+		var limits headerLimits
+		for _, name := range findListenerRouteConfigs(listener) {
+			if l, ok := limitsByRouteConfig[name]; ok {
+				limits.maxRequestHeadersKiB = max(limits.maxRequestHeadersKiB, l.maxRequestHeadersKiB)
+				limits.maxHeadersCount = max(limits.maxHeadersCount, l.maxHeadersCount)
+			}
+		}
+		if limits == (headerLimits{}) {
+			continue
+		}
+		if err := s.patchResource(patchKindListener, listener.Name, "header_limits", listener, func() error {
+			return setListenerHeaderLimits(listener, limits)
+		}); err != nil {
+			return fmt.Errorf("failed to set header limits on listener %s: %w", listener.Name, err)
+		}
+	}
+	return nil
+}
+
+// setListenerHeaderLimits sets the limits on every HCM of the listener. The limits already configured
+// on an HCM, e.g. by a ClientTrafficPolicy, are kept when they are larger.
+func setListenerHeaderLimits(listener *listenerv3.Listener, limits headerLimits) error {
+	filterChains := listener.GetFilterChains()
+	if listener.DefaultFilterChain != nil {
+		filterChains = append(filterChains, listener.DefaultFilterChain)
+	}
+	for _, currChain := range filterChains {
+		httpConManager, hcmIndex, err := findHCM(currChain)
+		if err != nil {
+			continue
+		}
+
+		if limits.maxRequestHeadersKiB > httpConManager.GetMaxRequestHeadersKb().GetValue() {
+			httpConManager.MaxRequestHeadersKb = wrapperspb.UInt32(limits.maxRequestHeadersKiB)
+		}
+		if limits.maxHeadersCount > httpConManager.GetCommonHttpProtocolOptions().GetMaxHeadersCount().GetValue() {
+			if httpConManager.CommonHttpProtocolOptions == nil {
+				httpConManager.CommonHttpProtocolOptions = &corev3.HttpProtocolOptions{}
+			}
+			httpConManager.CommonHttpProtocolOptions.MaxHeadersCount = wrapperspb.UInt32(limits.maxHeadersCount)
+		}
+
+		hcmAny, err := toAny(httpConManager)
+		if err != nil {
+			return fmt.Errorf("failed to marshal HttpConnectionManager: %w", err)
+		}
+		currChain.Filters[hcmIndex].ConfigType = &listenerv3.Filter_TypedConfig{TypedConfig: hcmAny}
+	}
+	return nil
+}
+
+// setClusterHeaderLimits sets the header count limit of an AIGatewayRoute on the upstream protocol options
+// of a cluster generated from it, which limits the number of the headers of the responses from the backends.
+func setClusterHeaderLimits(po *httpv3.HttpProtocolOptions, limits *aigv1b1.AIGatewayRouteHeaderLimits) {
+	if limits == nil || limits.MaxHeadersCount == nil || *limits.MaxHeadersCount <= 0 {
+		return
+	}
+	if po.CommonHttpProtocolOptions == nil {
+		po.CommonHttpProtocolOptions = &corev3.HttpProtocolOptions{}
+	}
+	po.CommonHttpProtocolOptions.MaxHeadersCount = wrapperspb.UInt32(uint32(*limits.MaxHeadersCount))
+}
@@ -0,0 +1,112 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extensionserver
+
+import (
+	"context"
+	"testing"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	listenerv3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	routev3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	httpconnectionmanagerv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	httpv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/upstreams/http/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	aigv1b1 "github.com/envoyproxy/ai-gateway/api/v1beta1"
+)
+
+func TestApplyHeaderLimits(t *testing.T) {
+	c := newFakeClient()
+	for name, limits := range map[string]*aigv1b1.AIGatewayRouteHeaderLimits{
+		"large-headers": {MaxRequestHeadersKiB: ptr.To[int32](256)},
+		"many-headers":  {MaxRequestHeadersKiB: ptr.To[int32](96), MaxHeadersCount: ptr.To[int32](200)},
+		"plain":         nil,
+	} {
+		require.NoError(t, c.Create(t.Context(), &aigv1b1.AIGatewayRoute{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec:       aigv1b1.AIGatewayRouteSpec{HeaderLimits: limits},
+		}))
+	}
+	s, err := New(c, logr.Discard(), udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false, "", "")
+	require.NoError(t, err)
+
+	newRoute := func(name string) *routev3.Route {
+		return &routev3.Route{Name: name, Action: &routev3.Route_Route{Route: &routev3.RouteAction{}}}
+	}
+	newListener := func(name string, hcm *httpconnectionmanagerv3.HttpConnectionManager) *listenerv3.Listener {
+		hcm.HttpFilters = []*httpconnectionmanagerv3.HttpFilter{{Name: wellknown.Router}}
+		return &listenerv3.Listener{
+			Name: name,
+			FilterChains: []*listenerv3.FilterChain{{
+				Filters: []*listenerv3.Filter{{
+					Name:       wellknown.HTTPConnectionManager,
+					ConfigType: &listenerv3.Filter_TypedConfig{TypedConfig: mustToAny(t, hcm)},
+				}},
+			}},
+		}
+	}
+	rds := func(routeConfigName string) *httpconnectionmanagerv3.HttpConnectionManager_Rds {
+		return &httpconnectionmanagerv3.HttpConnectionManager_Rds{
+			Rds: &httpconnectionmanagerv3.Rds{RouteConfigName: routeConfigName},
+		}
+	}
+
+	routeConfigs := []*routev3.RouteConfiguration{
+		{Name: "shared-rc", VirtualHosts: []*routev3.VirtualHost{{Routes: []*routev3.Route{
+			newRoute("httproute/default/large-headers/rule/0/match/0"),
+			newRoute("httproute/default/many-headers/rule/0/match/0"),
+		}}}},
+		{Name: "plain-rc", VirtualHosts: []*routev3.VirtualHost{{Routes: []*routev3.Route{
+			newRoute("httproute/default/plain/rule/0/match/0"),
+			newRoute("some-other-route"),
+		}}}},
+		{Name: "configured-rc", VirtualHosts: []*routev3.VirtualHost{{Routes: []*routev3.Route{
+			newRoute("httproute/default/many-headers/rule/0/match/0"),
+		}}}},
+	}
+	sharedListener := newListener("shared-listener", &httpconnectionmanagerv3.HttpConnectionManager{RouteSpecifier: rds("shared-rc")})
+	plainListener := newListener("plain-listener", &httpconnectionmanagerv3.HttpConnectionManager{RouteSpecifier: rds("plain-rc")})
+	// The limits configured by the Envoy Gateway are kept when they are larger.
+	configuredListener := newListener("configured-listener", &httpconnectionmanagerv3.HttpConnectionManager{
+		RouteSpecifier:            rds("configured-rc"),
+		MaxRequestHeadersKb:       wrapperspb.UInt32(128),
+		CommonHttpProtocolOptions: &corev3.HttpProtocolOptions{MaxHeadersCount: wrapperspb.UInt32(150)},
+	})
+
+	require.NoError(t, s.applyHeaderLimits(context.Background(),
+		[]*listenerv3.Listener{sharedListener, plainListener, configuredListener}, routeConfigs))
+
+	hcm, _, err := findHCM(sharedListener.FilterChains[0])
+	require.NoError(t, err)
+	require.Equal(t, uint32(256), hcm.GetMaxRequestHeadersKb().GetValue())
+	require.Equal(t, uint32(200), hcm.GetCommonHttpProtocolOptions().GetMaxHeadersCount().GetValue())
+
+	hcm, _, err = findHCM(plainListener.FilterChains[0])
+	require.NoError(t, err)
+	require.Nil(t, hcm.MaxRequestHeadersKb)
+	require.Nil(t, hcm.CommonHttpProtocolOptions)
+
+	hcm, _, err = findHCM(configuredListener.FilterChains[0])
+	require.NoError(t, err)
+	require.Equal(t, uint32(128), hcm.GetMaxRequestHeadersKb().GetValue())
+	require.Equal(t, uint32(200), hcm.GetCommonHttpProtocolOptions().GetMaxHeadersCount().GetValue())
+}
+
+func TestSetClusterHeaderLimits(t *testing.T) {
+	po := &httpv3.HttpProtocolOptions{}
+	setClusterHeaderLimits(po, nil)
+	require.Nil(t, po.CommonHttpProtocolOptions)
+	setClusterHeaderLimits(po, &aigv1b1.AIGatewayRouteHeaderLimits{MaxRequestHeadersKiB: ptr.To[int32](256)})
+	require.Nil(t, po.CommonHttpProtocolOptions)
+	setClusterHeaderLimits(po, &aigv1b1.AIGatewayRouteHeaderLimits{MaxHeadersCount: ptr.To[int32](200)})
+	require.Equal(t, uint32(200), po.GetCommonHttpProtocolOptions().GetMaxHeadersCount().GetValue())
+}
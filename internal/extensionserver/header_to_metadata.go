@@ -14,8 +14,6 @@ import (
 	htomv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/header_to_metadata/v3"
 	httpconnectionmanagerv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
 	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
-
-	aigv1b1 "github.com/envoyproxy/ai-gateway/api/v1beta1"
 )
 
 const headerToMetadataFilterName = "envoy.filters.http.header_to_metadata"
@@ -25,7 +23,9 @@ func (s *Server) insertRequestHeaderToMetadataFilters(listeners []*listenerv3.Li
 		return nil
 	}
 	for _, ln := range listeners {
-		if err := s.insertRequestHeaderToMetadataFilter(ln); err != nil {
+		if err := s.patchResource(patchKindListener, ln.Name, "request_header_to_metadata", ln, func() error {
+			return s.insertRequestHeaderToMetadataFilter(ln)
+		}); err != nil {
 			return err
 		}
 	}
@@ -45,13 +45,13 @@ func (s *Server) insertRequestHeaderToMetadataFilter(listener *listenerv3.Listen
 		if filterIndex, filter := findHeaderToMetadataFilter(httpConManager.HttpFilters); filter != nil {
 			typedConfig := filter.GetTypedConfig()
 			if typedConfig == nil {
-				return errors.New("header_to_metadata filter missing typed_config")
+				return malformedResourceError("header_to_metadata filter missing typed_config")
 			}
 			cfg := &htomv3.Config{}
 			if unmarshalErr := typedConfig.UnmarshalTo(cfg); unmarshalErr != nil {
 				return unmarshalErr
 			}
-			if mergeHeaderToMetadataRules(cfg, s.logRequestHeaderAttributes) {
+			if mergeHeaderToMetadataRules(cfg, s.metadataNamespace, s.logRequestHeaderAttributes) {
 				cfgAny, cfgErr := toAny(cfg)
 				if cfgErr != nil {
 					return cfgErr
@@ -67,7 +67,7 @@ func (s *Server) insertRequestHeaderToMetadataFilter(listener *listenerv3.Listen
 			}
 			continue
 		}
-		filter, err := buildHeaderToMetadataFilter(s.logRequestHeaderAttributes)
+		filter, err := buildHeaderToMetadataFilter(s.metadataNamespace, s.logRequestHeaderAttributes)
 		if err != nil {
 			return err
 		}
@@ -83,7 +83,7 @@ func (s *Server) insertRequestHeaderToMetadataFilter(listener *listenerv3.Listen
 	return nil
 }
 
-func buildHeaderToMetadataFilter(attrs map[string]string) (*httpconnectionmanagerv3.HttpFilter, error) {
+func buildHeaderToMetadataFilter(metadataNamespace string, attrs map[string]string) (*httpconnectionmanagerv3.HttpFilter, error) {
 	if len(attrs) == 0 {
 		return nil, nil
 	}
@@ -98,7 +98,7 @@ func buildHeaderToMetadataFilter(attrs map[string]string) (*httpconnectionmanage
 		cfg.RequestRules = append(cfg.RequestRules, &htomv3.Config_Rule{
 			Header: header,
 			OnHeaderPresent: &htomv3.Config_KeyValuePair{
-				MetadataNamespace: aigv1b1.AIGatewayFilterMetadataNamespace,
+				MetadataNamespace: metadataNamespace,
 				Key:               attrs[header],
 				Type:              htomv3.Config_STRING,
 			},
@@ -136,7 +136,7 @@ func findHeaderToMetadataFilter(filters []*httpconnectionmanagerv3.HttpFilter) (
 	return -1, nil
 }
 
-func mergeHeaderToMetadataRules(cfg *htomv3.Config, attrs map[string]string) bool {
+func mergeHeaderToMetadataRules(cfg *htomv3.Config, metadataNamespace string, attrs map[string]string) bool {
 	if cfg == nil || len(attrs) == 0 {
 		return false
 	}
@@ -159,7 +159,7 @@ func mergeHeaderToMetadataRules(cfg *htomv3.Config, attrs map[string]string) boo
 		cfg.RequestRules = append(cfg.RequestRules, &htomv3.Config_Rule{
 			Header: header,
 			OnHeaderPresent: &htomv3.Config_KeyValuePair{
-				MetadataNamespace: aigv1b1.AIGatewayFilterMetadataNamespace,
+				MetadataNamespace: metadataNamespace,
 				Key:               attrs[header],
 				Type:              htomv3.Config_STRING,
 			},
@@ -19,12 +19,12 @@ import (
 
 func TestBuildHeaderToMetadataFilter(t *testing.T) {
 	t.Run("empty", func(t *testing.T) {
-		filter, err := buildHeaderToMetadataFilter(nil)
+		filter, err := buildHeaderToMetadataFilter(aigv1b1.AIGatewayFilterMetadataNamespace, nil)
 		require.NoError(t, err)
 		require.Nil(t, filter)
 	})
 	t.Run("sorted", func(t *testing.T) {
-		filter, err := buildHeaderToMetadataFilter(map[string]string{
+		filter, err := buildHeaderToMetadataFilter(aigv1b1.AIGatewayFilterMetadataNamespace, map[string]string{
 			"agent-session-id": "session.id",
 			"x-tenant-id":      "tenant.id",
 		})
@@ -42,6 +42,14 @@ func TestBuildHeaderToMetadataFilter(t *testing.T) {
 		require.Equal(t, aigv1b1.AIGatewayFilterMetadataNamespace, cfg.RequestRules[1].GetOnHeaderPresent().MetadataNamespace)
 		require.Equal(t, "tenant.id", cfg.RequestRules[1].GetOnHeaderPresent().Key)
 	})
+	t.Run("metadata namespace", func(t *testing.T) {
+		filter, err := buildHeaderToMetadataFilter("io.example.gateway", map[string]string{"x-tenant-id": "tenant.id"})
+		require.NoError(t, err)
+
+		cfg := &htomv3.Config{}
+		require.NoError(t, filter.GetTypedConfig().UnmarshalTo(cfg))
+		require.Equal(t, "io.example.gateway", cfg.RequestRules[0].GetOnHeaderPresent().MetadataNamespace)
+	})
 }
 
 func Test_insertHeaderToMetadataFilter(t *testing.T) {
@@ -89,16 +97,16 @@ func TestFindHeaderToMetadataFilter(t *testing.T) {
 
 func TestMergeHeaderToMetadataRules(t *testing.T) {
 	t.Run("nil-config", func(t *testing.T) {
-		require.False(t, mergeHeaderToMetadataRules(nil, map[string]string{"x-tenant-id": "tenant.id"}))
+		require.False(t, mergeHeaderToMetadataRules(nil, aigv1b1.AIGatewayFilterMetadataNamespace, map[string]string{"x-tenant-id": "tenant.id"}))
 	})
 	t.Run("empty-attrs", func(t *testing.T) {
-		require.False(t, mergeHeaderToMetadataRules(&htomv3.Config{}, nil))
+		require.False(t, mergeHeaderToMetadataRules(&htomv3.Config{}, aigv1b1.AIGatewayFilterMetadataNamespace, nil))
 	})
 	t.Run("no-missing", func(t *testing.T) {
 		cfg := &htomv3.Config{
 			RequestRules: []*htomv3.Config_Rule{{Header: "x-tenant-id"}},
 		}
-		changed := mergeHeaderToMetadataRules(cfg, map[string]string{"x-tenant-id": "tenant.id"})
+		changed := mergeHeaderToMetadataRules(cfg, aigv1b1.AIGatewayFilterMetadataNamespace, map[string]string{"x-tenant-id": "tenant.id"})
 		require.False(t, changed)
 		require.Len(t, cfg.RequestRules, 1)
 		require.Equal(t, "x-tenant-id", cfg.RequestRules[0].GetHeader())
@@ -107,7 +115,7 @@ func TestMergeHeaderToMetadataRules(t *testing.T) {
 		cfg := &htomv3.Config{
 			RequestRules: []*htomv3.Config_Rule{{Header: "x-tenant-id"}},
 		}
-		changed := mergeHeaderToMetadataRules(cfg, map[string]string{
+		changed := mergeHeaderToMetadataRules(cfg, aigv1b1.AIGatewayFilterMetadataNamespace, map[string]string{
 			"agent-session-id":  "session.id",
 			"x-forwarded-proto": "url.scheme",
 			"x-tenant-id":       "tenant.id",
@@ -0,0 +1,51 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extensionserver
+
+import (
+	"strings"
+
+	routev3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+)
+
+// localRateLimitFilterName is the name of the local rate limit HTTP filter configured by Envoy Gateway.
+const localRateLimitFilterName = "envoy.filters.http.local_ratelimit"
+
+// removeMigrationRateLimits removes the rate limits of the routes generated from the migration rules of the
+// AIGatewayRoutes, which only match the requests sent again by the external processor to the migration backends.
+// These requests are copies of the client requests, which were already rate limited, so they must consume neither
+// the quota of the QuotaPolicies nor the rate limits of the BackendTrafficPolicies of the client. The external
+// processor rejects the requests to these routes without a valid nonce, so that the clients cannot use them to
+// bypass the rate limits. This must run after maybeInjectQuotaRateLimiting.
+func (s *Server) removeMigrationRateLimits(routeConfigs []*routev3.RouteConfiguration) error {
+	return s.patchRoutes(routeConfigs, "migration_rate_limits", func(_ *routev3.RouteConfiguration, route *routev3.Route) error {
+		if !isMigrationRoute(route) {
+			return nil
+		}
+		if action := route.GetRoute(); action != nil {
+			action.RateLimits = nil
+		}
+		for name := range route.TypedPerFilterConfig {
+			if strings.HasPrefix(name, wellknown.HTTPRateLimit) || strings.HasPrefix(name, localRateLimitFilterName) {
+				delete(route.TypedPerFilterConfig, name)
+			}
+		}
+		return nil
+	})
+}
+
+// isMigrationRoute returns true if the route only matches the requests sent again to a migration backend.
+func isMigrationRoute(route *routev3.Route) bool {
+	for _, h := range route.GetMatch().GetHeaders() {
+		if h.Name == internalapi.MigrationRuleHeader {
+			return true
+		}
+	}
+	return false
+}
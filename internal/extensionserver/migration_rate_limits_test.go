@@ -0,0 +1,53 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extensionserver
+
+import (
+	"testing"
+
+	routev3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+)
+
+func TestRemoveMigrationRateLimits(t *testing.T) {
+	s, err := New(newFakeClient(), logr.Discard(), udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false, "", "")
+	require.NoError(t, err)
+
+	newRoute := func(name string, headers ...*routev3.HeaderMatcher) *routev3.Route {
+		return &routev3.Route{
+			Name:  name,
+			Match: &routev3.RouteMatch{Headers: headers},
+			Action: &routev3.Route_Route{Route: &routev3.RouteAction{
+				RateLimits: []*routev3.RateLimit{{Actions: []*routev3.RateLimit_Action{{}}}},
+			}},
+			TypedPerFilterConfig: map[string]*anypb.Any{
+				quotaRateLimitFilterName:       {},
+				"envoy.filters.http.ratelimit": {},
+				localRateLimitFilterName:       {},
+				"envoy.filters.http.ext_authz": {},
+			},
+		}
+	}
+	migration := newRoute("httproute/default/route/rule/2/match/0",
+		&routev3.HeaderMatcher{Name: internalapi.ModelNameHeaderKeyDefault},
+		&routev3.HeaderMatcher{Name: internalapi.MigrationRuleHeader})
+	client := newRoute("httproute/default/route/rule/0/match/0", &routev3.HeaderMatcher{Name: internalapi.ModelNameHeaderKeyDefault})
+
+	require.NoError(t, s.removeMigrationRateLimits([]*routev3.RouteConfiguration{{
+		VirtualHosts: []*routev3.VirtualHost{{Routes: []*routev3.Route{migration, client}}},
+	}}))
+
+	require.Empty(t, migration.GetRoute().RateLimits)
+	require.Len(t, migration.TypedPerFilterConfig, 1)
+	require.Contains(t, migration.TypedPerFilterConfig, "envoy.filters.http.ext_authz")
+	// The routes of the client requests keep their rate limits.
+	require.Len(t, client.GetRoute().RateLimits, 1)
+	require.Len(t, client.TypedPerFilterConfig, 4)
+}
@@ -0,0 +1,98 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extensionserver
+
+import (
+	"errors"
+	"fmt"
+
+	routev3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/protobuf/proto"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// The kinds of the xDS resources patched by PostTranslateModify.
+const (
+	patchKindCluster  = "cluster"
+	patchKindRoute    = "route"
+	patchKindListener = "listener"
+)
+
+// patchErrors counts the xDS resources left unpatched because their patch failed, per kind of resource and patch.
+var patchErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "ai_gateway_extension_server_patch_errors_total",
+	Help: "Total number of the xDS resources skipped by the extension server because their patch failed.",
+}, []string{"kind", "patch"})
+
+func init() {
+	ctrlmetrics.Registry.MustRegister(patchErrors)
+}
+
+// malformedResourceError is returned by the patches when the resource doesn't have the expected structure, e.g.
+// a filter without its typed config.
+type malformedResourceError string
+
+// Error implements [error.Error].
+func (e malformedResourceError) Error() string { return string(e) }
+
+// patchResource applies the patch to the resource. When the patch panics or fails because the resource is
+// malformed, e.g. with an unparsable typed config, the resource is restored from a snapshot taken beforehand and
+// skipped: the failure is logged and counted, and nil is returned so that the other resources are still patched.
+// Otherwise, a single malformed resource would fail the whole translation and block the xDS updates of the Gateway.
+//
+// Any other error, e.g. reading the AI Gateway resources from the API server, is returned after restoring the
+// resource, since the resource would be served without its patch otherwise.
+func (s *Server) patchResource(kind, name, patchName string, resource proto.Message, patch func() error) error {
+	snapshot := proto.Clone(resource)
+	err := func() (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = malformedResourceError(fmt.Sprintf("panic: %v", r))
+			}
+		}()
+		return patch()
+	}()
+	if err == nil {
+		return nil
+	}
+	proto.Reset(resource)
+	proto.Merge(resource, snapshot)
+	if !isMalformedResourceError(err) {
+		return err
+	}
+	s.log.Error(err, "skipped the patch of a malformed resource", "kind", kind, "name", name, "patch", patchName)
+	patchErrors.WithLabelValues(kind, patchName).Inc()
+	return nil
+}
+
+// isMalformedResourceError returns true if the error is caused by the patched resource itself: the decoding
+// errors of its protobuf messages such as the typed configs, the validation errors of the xDS messages, and the
+// malformedResourceError of the patches.
+func isMalformedResourceError(err error) bool {
+	var malformed malformedResourceError
+	var validation interface {
+		Field() string
+		Reason() string
+	}
+	return errors.Is(err, proto.Error) || errors.As(err, &malformed) || errors.As(err, &validation)
+}
+
+// patchRoutes applies the patch to each route of the route configurations with patchResource.
+func (s *Server) patchRoutes(routeConfigs []*routev3.RouteConfiguration, patchName string, patch func(rc *routev3.RouteConfiguration, route *routev3.Route) error) error {
+	for _, rc := range routeConfigs {
+		for _, vh := range rc.VirtualHosts {
+			for _, route := range vh.Routes {
+				if err := s.patchResource(patchKindRoute, route.Name, patchName, route, func() error {
+					return patch(rc, route)
+				}); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extensionserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	clusterv3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	routev3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+func TestPatchResource(t *testing.T) {
+	s, err := New(newFakeClient(), logr.Discard(), udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false, "", "")
+	require.NoError(t, err)
+
+	newCluster := func() *clusterv3.Cluster {
+		return &clusterv3.Cluster{Name: "c", ConnectTimeout: durationpb.New(10 * time.Second)}
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		cluster := newCluster()
+		require.NoError(t, s.patchResource(patchKindCluster, cluster.Name, "test-ok", cluster, func() error {
+			cluster.ConnectTimeout = durationpb.New(20 * time.Second)
+			return nil
+		}))
+		require.Equal(t, int64(20), cluster.ConnectTimeout.Seconds)
+		require.Zero(t, testutil.ToFloat64(patchErrors.WithLabelValues(patchKindCluster, "test-ok")))
+	})
+
+	t.Run("error", func(t *testing.T) {
+		cluster := newCluster()
+		require.NoError(t, s.patchResource(patchKindCluster, cluster.Name, "test-error", cluster, func() error {
+			cluster.ConnectTimeout = durationpb.New(20 * time.Second)
+			return (&anypb.Any{TypeUrl: "type.googleapis.com/envoy.config.cluster.v3.Cluster", Value: []byte{0xff}}).UnmarshalTo(&clusterv3.Cluster{})
+		}))
+		require.True(t, proto.Equal(newCluster(), cluster))
+		require.Equal(t, float64(1), testutil.ToFloat64(patchErrors.WithLabelValues(patchKindCluster, "test-error")))
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		cluster := newCluster()
+		require.NoError(t, s.patchResource(patchKindCluster, cluster.Name, "test-panic", cluster, func() error {
+			cluster.ConnectTimeout = durationpb.New(20 * time.Second)
+			panic("nil typed config")
+		}))
+		require.True(t, proto.Equal(newCluster(), cluster))
+		require.Equal(t, float64(1), testutil.ToFloat64(patchErrors.WithLabelValues(patchKindCluster, "test-panic")))
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		cluster := newCluster()
+		require.NoError(t, s.patchResource(patchKindCluster, cluster.Name, "test-malformed", cluster, func() error {
+			cluster.ConnectTimeout = durationpb.New(20 * time.Second)
+			return fmt.Errorf("failed to patch: %w", malformedResourceError("missing typed_config"))
+		}))
+		require.True(t, proto.Equal(newCluster(), cluster))
+		require.Equal(t, float64(1), testutil.ToFloat64(patchErrors.WithLabelValues(patchKindCluster, "test-malformed")))
+	})
+
+	t.Run("other errors", func(t *testing.T) {
+		for _, other := range []error{
+			errors.New("boom"),
+			apierrors.NewServiceUnavailable("unavailable"),
+			fmt.Errorf("failed to get route: %w", apierrors.NewTimeoutError("timeout", 1)),
+			context.DeadlineExceeded,
+		} {
+			cluster := newCluster()
+			err := s.patchResource(patchKindCluster, cluster.Name, "test-other", cluster, func() error {
+				cluster.ConnectTimeout = durationpb.New(20 * time.Second)
+				return other
+			})
+			require.ErrorIs(t, err, other)
+			require.True(t, proto.Equal(newCluster(), cluster))
+		}
+		require.Zero(t, testutil.ToFloat64(patchErrors.WithLabelValues(patchKindCluster, "test-other")))
+	})
+}
+
+func TestPatchRoutes(t *testing.T) {
+	s, err := New(newFakeClient(), logr.Discard(), udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false, "", "")
+	require.NoError(t, err)
+
+	routeConfigs := []*routev3.RouteConfiguration{{
+		Name: "rc",
+		VirtualHosts: []*routev3.VirtualHost{{
+			Routes: []*routev3.Route{{Name: "malformed"}, {Name: "ok"}},
+		}},
+	}}
+	require.NoError(t, s.patchRoutes(routeConfigs, "test-routes", func(_ *routev3.RouteConfiguration, route *routev3.Route) error {
+		route.StatPrefix = "patched"
+		if route.Name == "malformed" {
+			return malformedResourceError("malformed")
+		}
+		return nil
+	}))
+	routes := routeConfigs[0].VirtualHosts[0].Routes
+	require.Empty(t, routes[0].StatPrefix)
+	require.Equal(t, "patched", routes[1].StatPrefix)
+	require.Equal(t, float64(1), testutil.ToFloat64(patchErrors.WithLabelValues(patchKindRoute, "test-routes")))
+}
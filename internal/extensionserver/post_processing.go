@@ -0,0 +1,163 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extensionserver
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	listenerv3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	routev3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	luav3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/lua/v3"
+	httpconnectionmanagerv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+	"google.golang.org/protobuf/types/known/anypb"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aigv1b1 "github.com/envoyproxy/ai-gateway/api/v1beta1"
+)
+
+// postProcessingLuaFilterName is the name of the Lua filter running the PostProcessing scripts of AIGatewayRoutes.
+const postProcessingLuaFilterName = "envoy.filters.http.lua/ai-gateway-post-processing"
+
+// applyPostProcessing attaches the PostProcessing Lua script of each AIGatewayRoute to the routes
+// generated from it, and inserts the Lua filter into the listeners serving those routes.
+//
+// The filter has no default source code, so it is a no-op on the routes without a script. It cannot
+// be disabled at the HCM level and enabled per route, because the filter chain is created from the
+// initial route match, before the AI Gateway filter clears the route cache.
+func (s *Server) applyPostProcessing(ctx context.Context, listeners []*listenerv3.Listener, routeConfigs []*routev3.RouteConfiguration) error {
+	cache := make(map[client.ObjectKey]*aigv1b1.AIGatewayRoute)
+	patchedRouteConfigs := make(map[string]struct{})
+	err := s.patchRoutes(routeConfigs, "post_processing", func(rc *routev3.RouteConfiguration, route *routev3.Route) error {
+		patched, err := s.maybeSetPostProcessing(ctx, route, cache)
+		if err != nil {
+			return err
+		}
+		if patched {
+			patchedRouteConfigs[rc.Name] = struct{}{}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(patchedRouteConfigs) == 0 {
+		return nil
+	}
+
+	for _, listener := range listeners {
+		for _, name := range findListenerRouteConfigs(listener) {
+			if _, ok := patchedRouteConfigs[name]; ok {
+				if err = s.patchResource(patchKindListener, listener.Name, "post_processing", listener, func() error {
+					return insertPostProcessingLuaFilter(listener)
+				}); err != nil {
+					return fmt.Errorf("failed to insert post processing filter into listener %s: %w", listener.Name, err)
+				}
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// maybeSetPostProcessing sets the per-route Lua config from the PostProcessing of the AIGatewayRoute
+// the route was generated from. It returns true if the route was patched.
+func (s *Server) maybeSetPostProcessing(ctx context.Context, route *routev3.Route, cache map[client.ObjectKey]*aigv1b1.AIGatewayRoute) (bool, error) {
+	if route.GetRoute() == nil {
+		// Not a forwarding route (e.g. DirectResponse, Redirect).
+		return false, nil
+	}
+
+	// Route name format: "httproute/<namespace>/<name>/rule/<index>/match/<...>".
+	parts := strings.Split(route.Name, "/")
+	if len(parts) < 3 || parts[0] != "httproute" || parts[1] == "" || parts[2] == "" {
+		return false, nil
+	}
+
+	aigwRoute, err := s.retrieveAndCacheAIGatewayRoute(ctx, cache, client.ObjectKey{Namespace: parts[1], Name: parts[2]})
+	if err != nil {
+		return false, err
+	}
+	if aigwRoute == nil || aigwRoute.Spec.PostProcessing == nil || aigwRoute.Spec.PostProcessing.Lua == nil {
+		return false, nil
+	}
+
+	perRoute, err := toAny(&luav3.LuaPerRoute{
+		Override: &luav3.LuaPerRoute_SourceCode{
+			SourceCode: &corev3.DataSource{
+				Specifier: &corev3.DataSource_InlineString{InlineString: aigwRoute.Spec.PostProcessing.Lua.InlineCode},
+			},
+		},
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal LuaPerRoute: %w", err)
+	}
+	if route.TypedPerFilterConfig == nil {
+		route.TypedPerFilterConfig = make(map[string]*anypb.Any)
+	}
+	route.TypedPerFilterConfig[postProcessingLuaFilterName] = perRoute
+	return true, nil
+}
+
+// insertPostProcessingLuaFilter inserts the post processing Lua filter before the router filter
+// of every HCM of the listener, unless it is already present.
+func insertPostProcessingLuaFilter(listener *listenerv3.Listener) error {
+	filterChains := listener.GetFilterChains()
+	if listener.DefaultFilterChain != nil {
+		filterChains = append(filterChains, listener.DefaultFilterChain)
+	}
+	for _, currChain := range filterChains {
+		httpConManager, hcmIndex, err := findHCM(currChain)
+		if err != nil {
+			continue
+		}
+
+		alreadyExists := false
+		for _, f := range httpConManager.HttpFilters {
+			if f.Name == postProcessingLuaFilterName {
+				alreadyExists = true
+				break
+			}
+		}
+		if alreadyExists {
+			continue
+		}
+
+		luaAny, err := toAny(&luav3.Lua{})
+		if err != nil {
+			return fmt.Errorf("failed to marshal Lua filter: %w", err)
+		}
+		luaFilter := &httpconnectionmanagerv3.HttpFilter{
+			Name:       postProcessingLuaFilterName,
+			ConfigType: &httpconnectionmanagerv3.HttpFilter_TypedConfig{TypedConfig: luaAny},
+		}
+
+		// Insert before the router filter.
+		inserted := false
+		for i, f := range httpConManager.HttpFilters {
+			if f.Name == wellknown.Router {
+				httpConManager.HttpFilters = append(httpConManager.HttpFilters, nil)
+				copy(httpConManager.HttpFilters[i+1:], httpConManager.HttpFilters[i:])
+				httpConManager.HttpFilters[i] = luaFilter
+				inserted = true
+				break
+			}
+		}
+		if !inserted {
+			httpConManager.HttpFilters = append(httpConManager.HttpFilters, luaFilter)
+		}
+
+		hcmAny, err := toAny(httpConManager)
+		if err != nil {
+			return fmt.Errorf("failed to marshal HttpConnectionManager: %w", err)
+		}
+		currChain.Filters[hcmIndex].ConfigType = &listenerv3.Filter_TypedConfig{TypedConfig: hcmAny}
+	}
+	return nil
+}
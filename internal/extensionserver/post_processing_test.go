@@ -0,0 +1,123 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extensionserver
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	listenerv3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	routev3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	luav3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/lua/v3"
+	httpconnectionmanagerv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	aigv1b1 "github.com/envoyproxy/ai-gateway/api/v1beta1"
+	"github.com/envoyproxy/ai-gateway/internal/controller"
+)
+
+func TestApplyPostProcessing(t *testing.T) {
+	const script = `function envoy_on_response(handle) handle:headers():add("x-tagged", "yes") end`
+	c := newFakeClient()
+	require.NoError(t, c.Create(t.Context(), &aigv1b1.AIGatewayRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "scripted", Namespace: "default"},
+		Spec: aigv1b1.AIGatewayRouteSpec{
+			PostProcessing: &aigv1b1.AIGatewayRoutePostProcessing{Lua: &aigv1b1.AIGatewayRouteLuaScript{InlineCode: script}},
+		},
+	}))
+	require.NoError(t, c.Create(t.Context(), &aigv1b1.AIGatewayRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "plain", Namespace: "default"},
+	}))
+	s, err := New(c, logr.Discard(), udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false, "", "")
+	require.NoError(t, err)
+
+	forwarding := func(name string) *routev3.Route {
+		return &routev3.Route{Name: name, Action: &routev3.Route_Route{Route: &routev3.RouteAction{}}}
+	}
+	newListener := func(name, routeConfigName string) *listenerv3.Listener {
+		hcm := &httpconnectionmanagerv3.HttpConnectionManager{
+			RouteSpecifier: &httpconnectionmanagerv3.HttpConnectionManager_Rds{
+				Rds: &httpconnectionmanagerv3.Rds{RouteConfigName: routeConfigName},
+			},
+			HttpFilters: []*httpconnectionmanagerv3.HttpFilter{{Name: aiGatewayExtProcName}, {Name: wellknown.Router}},
+		}
+		return &listenerv3.Listener{
+			Name: name,
+			FilterChains: []*listenerv3.FilterChain{{
+				Filters: []*listenerv3.Filter{{
+					Name:       wellknown.HTTPConnectionManager,
+					ConfigType: &listenerv3.Filter_TypedConfig{TypedConfig: mustToAny(t, hcm)},
+				}},
+			}},
+		}
+	}
+
+	scripted := forwarding("httproute/default/scripted/rule/0/match/0")
+	plain := forwarding("httproute/default/plain/rule/0/match/0")
+	other := forwarding("some-other-route")
+	redirect := &routev3.Route{Name: "httproute/default/scripted/rule/1/match/0", Action: &routev3.Route_Redirect{}}
+	routeConfigs := []*routev3.RouteConfiguration{
+		{Name: "scripted-rc", VirtualHosts: []*routev3.VirtualHost{{Routes: []*routev3.Route{scripted, redirect}}}},
+		{Name: "plain-rc", VirtualHosts: []*routev3.VirtualHost{{Routes: []*routev3.Route{plain, other}}}},
+	}
+	scriptedListener := newListener("scripted-listener", "scripted-rc")
+	plainListener := newListener("plain-listener", "plain-rc")
+	listeners := []*listenerv3.Listener{scriptedListener, plainListener}
+
+	require.NoError(t, s.applyPostProcessing(context.Background(), listeners, routeConfigs))
+
+	require.Contains(t, scripted.TypedPerFilterConfig, postProcessingLuaFilterName)
+	perRoute := &luav3.LuaPerRoute{}
+	require.NoError(t, scripted.TypedPerFilterConfig[postProcessingLuaFilterName].UnmarshalTo(perRoute))
+	require.Equal(t, script, perRoute.GetSourceCode().GetInlineString())
+	require.Nil(t, redirect.TypedPerFilterConfig)
+	require.Nil(t, plain.TypedPerFilterConfig)
+	require.Nil(t, other.TypedPerFilterConfig)
+
+	hcm, _, err := findHCM(scriptedListener.FilterChains[0])
+	require.NoError(t, err)
+	require.Len(t, hcm.HttpFilters, 3)
+	require.Equal(t, aiGatewayExtProcName, hcm.HttpFilters[0].Name)
+	require.Equal(t, postProcessingLuaFilterName, hcm.HttpFilters[1].Name)
+	require.Equal(t, wellknown.Router, hcm.HttpFilters[2].Name)
+	luaCfg := &luav3.Lua{}
+	require.NoError(t, hcm.HttpFilters[1].GetTypedConfig().UnmarshalTo(luaCfg))
+	require.Nil(t, luaCfg.DefaultSourceCode)
+
+	hcm, _, err = findHCM(plainListener.FilterChains[0])
+	require.NoError(t, err)
+	require.Len(t, hcm.HttpFilters, 2)
+
+	t.Run("idempotent", func(t *testing.T) {
+		require.NoError(t, s.applyPostProcessing(context.Background(), listeners, routeConfigs))
+		hcm, _, err := findHCM(scriptedListener.FilterChains[0])
+		require.NoError(t, err)
+		require.Len(t, hcm.HttpFilters, 3)
+	})
+
+	t.Run("lookup error", func(t *testing.T) {
+		failing, err := New(
+			fake.NewClientBuilder().WithScheme(controller.Scheme).
+				WithInterceptorFuncs(interceptor.Funcs{
+					Get: func(context.Context, client.WithWatch, client.ObjectKey, client.Object, ...client.GetOption) error {
+						return errors.New("boom")
+					},
+				}).Build(),
+			logr.Discard(), udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false, "", "")
+		require.NoError(t, err)
+		err = failing.applyPostProcessing(context.Background(), nil, []*routev3.RouteConfiguration{{
+			VirtualHosts: []*routev3.VirtualHost{{Routes: []*routev3.Route{forwarding("httproute/default/scripted/rule/0/match/0")}}},
+		}})
+		require.ErrorContains(t, err, "boom")
+	})
+}
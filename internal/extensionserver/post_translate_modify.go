@@ -98,8 +98,11 @@ func (s *Server) PostTranslateModify(ctx context.Context, req *egextension.PostT
 	var extProcUDSExist bool
 
 	// Process existing clusters - may add metadata or modify configurations.
+	// A malformed cluster is skipped rather than failing the whole translation, see patchResource.
 	for _, cluster := range req.Clusters {
-		if err := s.maybeModifyCluster(ctx, cluster); err != nil {
+		if err := s.patchResource(patchKindCluster, cluster.Name, "cluster", cluster, func() error {
+			return s.maybeModifyCluster(ctx, cluster)
+		}); err != nil {
 			return nil, fmt.Errorf("failed to modify cluster %s: %w", cluster.Name, err)
 		}
 		extProcUDSExist = extProcUDSExist || cluster.Name == extProcUDSClusterName
@@ -123,6 +126,46 @@ func (s *Server) PostTranslateModify(ctx context.Context, req *egextension.PostT
 		return nil, fmt.Errorf("failed to apply stream idle timeouts: %w", err)
 	}
 
+	// Apply the retry policies of the AIServiceBackends to the generated routes.
+	if err = s.applyBackendRetryPolicies(ctx, req.Routes); err != nil {
+		return nil, fmt.Errorf("failed to apply backend retry policies: %w", err)
+	}
+
+	// Retry the context length errors of the rules configuring ContextLengthRetry.
+	if err = s.applyContextLengthRetries(ctx, req.Routes); err != nil {
+		return nil, fmt.Errorf("failed to apply context length retries: %w", err)
+	}
+
+	// Re-dispatch the requests failing mid-flight on the rules configuring FailoverRetry.
+	if err = s.applyFailoverRetries(ctx, req.Routes); err != nil {
+		return nil, fmt.Errorf("failed to apply failover retries: %w", err)
+	}
+
+	// Retry the requests shed by the AIServiceBackends configuring LoadReporting on the other endpoints.
+	if err = s.applyLoadSheddingRetries(ctx, req.Routes); err != nil {
+		return nil, fmt.Errorf("failed to apply load shedding retries: %w", err)
+	}
+
+	// Apply the first byte and completion timeouts of the AIServiceBackends to the generated routes.
+	if err = s.applyBackendTimeouts(ctx, req.Routes); err != nil {
+		return nil, fmt.Errorf("failed to apply backend timeouts: %w", err)
+	}
+
+	// Attach the PostProcessing scripts of the AIGatewayRoutes to the generated routes.
+	if err = s.applyPostProcessing(ctx, req.Listeners, req.Routes); err != nil {
+		return nil, fmt.Errorf("failed to apply post processing: %w", err)
+	}
+
+	// Raise the header limits of the listeners serving the AIGatewayRoutes that configure HeaderLimits.
+	if err = s.applyHeaderLimits(ctx, req.Listeners, req.Routes); err != nil {
+		return nil, fmt.Errorf("failed to apply header limits: %w", err)
+	}
+
+	// Pin the backends of the requests of the AIGatewayRoutes that configure BackendOverride.
+	if err = s.applyBackendOverrides(ctx, req.Listeners, req.Routes); err != nil {
+		return nil, fmt.Errorf("failed to apply backend overrides: %w", err)
+	}
+
 	// Ensure the AI Gateway external processor UDS cluster exists.
 	// This cluster is used for communication with the AI Gateway's main external processor.
 	if !extProcUDSExist {
@@ -194,32 +237,33 @@ func (s *Server) PostTranslateModify(ctx context.Context, req *egextension.PostT
 	if err != nil {
 		return nil, fmt.Errorf("failed to inject quota rate limiting: %w", err)
 	}
+	// The requests sent again to the migration backends must not consume the rate limits of the client.
+	if err = s.removeMigrationRateLimits(req.Routes); err != nil {
+		return nil, fmt.Errorf("failed to remove the rate limits of the migration routes: %w", err)
+	}
 
 	response := &egextension.PostTranslateModifyResponse{Clusters: req.Clusters, Secrets: req.Secrets, Listeners: req.Listeners, Routes: req.Routes}
 	return response, nil
 }
 
 // applyStreamIdleTimeouts walks the generated route configurations and sets the per-try idle
-// timeout on every AIGatewayRoute route whose rule configures StreamIdleTimeout.
+// timeout on every AIGatewayRoute route whose rule configures StreamIdleTimeout or StreamStallTimeout.
 // Lookups are cached to avoid hitting the API server more than once per route.
 func (s *Server) applyStreamIdleTimeouts(ctx context.Context, routeConfigs []*routev3.RouteConfiguration) error {
 	cache := make(map[client.ObjectKey]*aigv1b1.AIGatewayRoute)
-	for _, rc := range routeConfigs {
-		for _, vh := range rc.VirtualHosts {
-			for _, route := range vh.Routes {
-				if err := s.maybeSetStreamIdleTimeout(ctx, route, cache); err != nil {
-					return err
-				}
-			}
-		}
-	}
-	return nil
+	return s.patchRoutes(routeConfigs, "stream_idle_timeout", func(_ *routev3.RouteConfiguration, route *routev3.Route) error {
+		return s.maybeSetStreamIdleTimeout(ctx, route, cache)
+	})
 }
 
 // maybeSetStreamIdleTimeout sets route.retry_policy.per_try_idle_timeout from the rule's
 // StreamIdleTimeout. Envoy resets the upstream stream when no bytes arrive within that
 // duration, so a retry policy covering `reset` falls over to the next backend before any
 // response reaches the downstream client.
+//
+// When the rule also configures StreamStallTimeout, the shorter of the two is used: the stall
+// watchdog of the external processor only runs when a chunk arrives, so a completely silent
+// backend is bounded by Envoy instead.
 func (s *Server) maybeSetStreamIdleTimeout(ctx context.Context, route *routev3.Route, cache map[client.ObjectKey]*aigv1b1.AIGatewayRoute) error {
 	action := route.GetRoute()
 	if action == nil {
@@ -252,7 +296,11 @@ func (s *Server) maybeSetStreamIdleTimeout(ctx context.Context, route *routev3.R
 		return nil
 	}
 
-	timeout := aigwRoute.Spec.Rules[ruleIndex].GetStreamIdleTimeout()
+	rule := &aigwRoute.Spec.Rules[ruleIndex]
+	timeout := rule.GetStreamIdleTimeout()
+	if stall := rule.GetStreamStallTimeout(); stall > 0 && (timeout <= 0 || stall < timeout) {
+		timeout = stall
+	}
 	if timeout <= 0 {
 		return nil
 	}
@@ -296,6 +344,8 @@ func (s *Server) retrieveAndCacheAIGatewayRoute(ctx context.Context, cache map[c
 //
 // 4. Configures special handling for InferencePool clusters (ORIGINAL_DST type).
 //
+// 5. Sets the outlier detection of the AIServiceBackends of the cluster.
+//
 // The resulting configuration is similar to the envoy.yaml files in tests/data-plane/.
 // Only clusters with names matching the AIGatewayRoute pattern are modified.
 func (s *Server) maybeModifyCluster(ctx context.Context, cluster *clusterv3.Cluster) error {
@@ -324,13 +374,24 @@ func (s *Server) maybeModifyCluster(ctx context.Context, cluster *clusterv3.Clus
 		return err
 	}
 
-	// Get the backend from the HTTPRoute object.
-	if httpRouteRuleIndex >= len(aigwRoute.Spec.Rules) {
+	// Get the backend from the HTTPRoute object. The rules after the rules of the AIGatewayRoute are the
+	// route-not-found rule, without backends, and the migration rules.
+	var httpRouteRule *aigv1b1.AIGatewayRouteRule
+	if httpRouteRuleIndex < len(aigwRoute.Spec.Rules) {
+		httpRouteRule = &aigwRoute.Spec.Rules[httpRouteRuleIndex]
+	} else {
+		for _, m := range internalapi.MigrationRules(aigwRoute.Spec.Rules) {
+			if m.HTTPRouteRuleIndex == httpRouteRuleIndex {
+				httpRouteRule = &m.Rule
+				break
+			}
+		}
+	}
+	if httpRouteRule == nil {
 		s.log.Info("HTTPRoute rule index out of range",
 			"cluster_name", cluster.Name, "rule_index", httpRouteRuleIndex)
 		return nil
 	}
-	httpRouteRule := &aigwRoute.Spec.Rules[httpRouteRuleIndex]
 	if clusterName.backendRefIndex != noBackendRefIndex && clusterName.backendRefIndex >= len(httpRouteRule.BackendRefs) {
 		s.log.Info("HTTPRoute backend index out of range",
 			"cluster_name", cluster.Name, "backend_index", clusterName.backendRefIndex)
@@ -381,8 +442,15 @@ func (s *Server) maybeModifyCluster(ctx context.Context, cluster *clusterv3.Clus
 				}
 				for _, endpoint := range endpoints.LbEndpoints {
 					setEndpointMetadataBackendName(endpoint, namespace, name, aigwRoute.Name, httpRouteRuleIndex, i)
+					if aigwRoute.Spec.BackendOverride != nil {
+						setEndpointMetadataBackendOverride(endpoint, backendRef.GetNamespace(namespace)+"/"+name)
+					}
 				}
 			}
+			if aigwRoute.Spec.BackendOverride != nil && !setBackendOverrideSubsets(cluster) {
+				s.log.Info("Backend override is not supported with the load balancing policy of the cluster",
+					"cluster_name", cluster.Name)
+			}
 		}
 	} else {
 		// we can only specify one backend in a rule for InferencePool.
@@ -394,6 +462,17 @@ func (s *Server) maybeModifyCluster(ctx context.Context, cluster *clusterv3.Clus
 		setClusterMetadataBackendName(cluster, aigwRoute.Namespace, backendRef.Name, aigwRoute.Name, httpRouteRuleIndex, backendRefIndex)
 	}
 
+	if pool == nil {
+		if err = s.maybeSetOutlierDetection(ctx, cluster, &aigwRoute, httpRouteRule, clusterName.backendRefIndex); err != nil {
+			s.log.Error(err, "failed to set outlier detection", "cluster_name", cluster.Name)
+			return err
+		}
+		if err = s.maybeSetConnectTimeout(ctx, cluster, &aigwRoute, httpRouteRule, clusterName.backendRefIndex); err != nil {
+			s.log.Error(err, "failed to set connect timeout", "cluster_name", cluster.Name)
+			return err
+		}
+	}
+
 	if cluster.TypedExtensionProtocolOptions == nil {
 		cluster.TypedExtensionProtocolOptions = make(map[string]*anypb.Any)
 	}
@@ -411,6 +490,7 @@ func (s *Server) maybeModifyCluster(ctx context.Context, cluster *clusterv3.Clus
 			ProtocolConfig: &httpv3.HttpProtocolOptions_ExplicitHttpConfig_HttpProtocolOptions{},
 		}}
 	}
+	setClusterHeaderLimits(po, aigwRoute.Spec.HeaderLimits)
 
 	for _, filter := range po.HttpFilters {
 		if filter.Name == aiGatewayExtProcName {
@@ -422,7 +502,7 @@ func (s *Server) maybeModifyCluster(ctx context.Context, cluster *clusterv3.Clus
 	extProcConfig := &extprocv3.ExternalProcessor{}
 	extProcConfig.MetadataOptions = &extprocv3.MetadataOptions{
 		ReceivingNamespaces: &extprocv3.MetadataOptions_MetadataNamespaces{
-			Untyped: []string{aigv1b1.AIGatewayFilterMetadataNamespace},
+			Untyped: []string{s.metadataNamespace},
 		},
 	}
 	extProcConfig.AllowModeOverride = true
@@ -430,6 +510,7 @@ func (s *Server) maybeModifyCluster(ctx context.Context, cluster *clusterv3.Clus
 		internalapi.XDSUpstreamHostMetadataBackendNamePath,
 		internalapi.XDSClusterMetadataBackendNamePath,
 		internalapi.XDSRouteMetadataRouteNamePath,
+		internalapi.UpstreamAddressAttribute,
 	}
 	extProcConfig.ProcessingMode = &extprocv3.ProcessingMode{
 		RequestHeaderMode: extprocv3.ProcessingMode_SEND,
@@ -468,7 +549,7 @@ func (s *Server) maybeModifyCluster(ctx context.Context, cluster *clusterv3.Clus
 							AppendAction: corev3.HeaderValueOption_ADD_IF_ABSENT,
 							Header: &corev3.HeaderValue{
 								Key:   "content-length",
-								Value: `%DYNAMIC_METADATA(` + aigv1b1.AIGatewayFilterMetadataNamespace + `:content_length)%`,
+								Value: `%DYNAMIC_METADATA(` + s.metadataNamespace + `:content_length)%`,
 							},
 						},
 					},
@@ -774,7 +855,7 @@ func (s *Server) insertRouterLevelAIGatewayExtProc(listener *listenerv3.Listener
 			},
 			MetadataOptions: &extprocv3.MetadataOptions{
 				ReceivingNamespaces: &extprocv3.MetadataOptions_MetadataNamespaces{
-					Untyped: []string{aigv1b1.AIGatewayFilterMetadataNamespace},
+					Untyped: []string{s.metadataNamespace},
 				},
 			},
 			ProcessingMode: &extprocv3.ProcessingMode{
@@ -785,6 +866,13 @@ func (s *Server) insertRouterLevelAIGatewayExtProc(listener *listenerv3.Listener
 				ResponseBodyMode:    extprocv3.ProcessingMode_BUFFERED,
 				ResponseTrailerMode: extprocv3.ProcessingMode_SKIP,
 			},
+			// The response code details tell the upstream timeouts apart to return distinct errors, and the port and
+			// the TLS version of the downstream connection tell where to send the requests to the migration backends.
+			ResponseAttributes: []string{
+				internalapi.ResponseCodeDetailsAttribute,
+				internalapi.DownstreamLocalPortAttribute,
+				internalapi.DownstreamTLSVersionAttribute,
+			},
 			MessageTimeout:    durationpb.New(10 * time.Second),
 			FailureModeAllow:  false,
 			AllowModeOverride: true,
@@ -18,6 +18,7 @@ import (
 	"google.golang.org/protobuf/types/known/structpb"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
+	aigv1b1 "github.com/envoyproxy/ai-gateway/api/v1beta1"
 	"github.com/envoyproxy/ai-gateway/internal/internalapi"
 )
 
@@ -245,7 +246,7 @@ func TestInsertHeaderToMetadataFilter(t *testing.T) {
 	hcm := &httpconnectionmanagerv3.HttpConnectionManager{
 		HttpFilters: []*httpconnectionmanagerv3.HttpFilter{{Name: wellknown.Router}},
 	}
-	filter, err := buildHeaderToMetadataFilter(map[string]string{"agent-session-id": "session.id"})
+	filter, err := buildHeaderToMetadataFilter(aigv1b1.AIGatewayFilterMetadataNamespace, map[string]string{"agent-session-id": "session.id"})
 	require.NoError(t, err)
 	err = insertHeaderToMetadataFilter(hcm, filter)
 	require.NoError(t, err)
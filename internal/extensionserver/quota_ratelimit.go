@@ -50,7 +50,7 @@ const (
 
 	// quotaCostMetadataKey is the dynamic metadata key where ext_proc stores
 	// the computed quota cost for the current request.
-	quotaCostMetadataKey = "quota_cost"
+	quotaCostMetadataKey = translator.QuotaCostMetadataKey
 )
 
 // maybeInjectQuotaRateLimiting injects the rate limit HTTP filter into the HCM
@@ -304,7 +304,7 @@ func (s *Server) patchRoutesWithQuotaRateLimits(
 			policies := s.policiesForRoute(ctx, route, quotaBackendPolicies)
 			modelInfo := s.resolveRouteModelInfo(ctx, route)
 
-			if err := enableQuotaRateLimitOnRoute(s.log, route, policies, modelInfo); err != nil {
+			if err := enableQuotaRateLimitOnRoute(s.log, s.metadataNamespace, route, policies, modelInfo); err != nil {
 				s.log.Error(err, "failed to enable quota rate limit on route", "route", route.Name)
 			}
 			patched = true
@@ -516,7 +516,7 @@ func (s *Server) resolveRouteModelInfo(ctx context.Context, route *routev3.Route
 // modelInfo provides the backend→ModelNameOverride mapping used for filtering (a policy's
 // target and modelName must match a backend override) and for request-time descriptors.
 // If nil, all models are included.
-func enableQuotaRateLimitOnRoute(_ logr.Logger, route *routev3.Route, policies []aigv1a1.QuotaPolicy, modelInfo *routeModelInfo) error {
+func enableQuotaRateLimitOnRoute(_ logr.Logger, metadataNamespace string, route *routev3.Route, policies []aigv1a1.QuotaPolicy, modelInfo *routeModelInfo) error {
 	var rateLimitActions []*routev3.RateLimit
 
 	// streamDoneActions collects the stream-done RateLimit entries built inline during
@@ -533,7 +533,7 @@ func enableQuotaRateLimitOnRoute(_ logr.Logger, route *routev3.Route, policies [
 
 	for i := range policies {
 		policy := &policies[i]
-		for _, pmq := range policy.Spec.PerModelQuotas {
+		for qIdx, pmq := range policy.Spec.PerModelQuotas {
 			if pmq.ModelName == nil {
 				continue
 			}
@@ -563,28 +563,42 @@ func enableQuotaRateLimitOnRoute(_ logr.Logger, route *routev3.Route, policies [
 				}
 			}
 
+			// The default bucket of a quota with schedules selects its limit with the active
+			// window that the ext_proc filter stores in dynamic metadata under scheduleKey.
+			var scheduleKey string
+			if len(pmq.Quota.Schedules) > 0 {
+				scheduleKey = translator.QuotaScheduleMetadataKey(policy.Namespace, policy.Name, qIdx)
+			}
+
 			if len(pmq.Quota.BucketRules) == 0 && pmq.Quota.DefaultBucket.Limit > 0 {
-				entries := buildSimpleModelEntries(modelName, policy.Namespace, policy.Spec.TargetRefs, backendModels)
+				unit := pmq.Quota.DefaultBucket.Unit
+				entries := buildSimpleModelEntries(metadataNamespace, modelName, policy.Namespace, policy.Spec.TargetRefs, backendModels, unit, scheduleKey)
 				rateLimitActions = append(rateLimitActions, entries...)
-				// Simple case: 2-level stream-done (backend_name + model_name_override).
-				// All simple entries are identical (metadata-only actions, same hits_addend).
-				const simpleStreamDoneKey = "_simple_"
-				if !seenStreamDoneKeys[simpleStreamDoneKey] {
+				// Simple case: 2-level stream-done (backend_name + model_name_override), plus the
+				// schedule descriptor if any and the unit descriptor for the units other than Cost.
+				// All simple entries of the same unit and schedule are identical (metadata-only actions, same hits_addend).
+				simpleStreamDoneKey := "_simple_" + scheduleKey + "|" + translator.QuotaUnitDescriptorKey(unit)
+				if hitsAddend := quotaUnitHitsAddend(metadataNamespace, unit); hitsAddend != nil && !seenStreamDoneKeys[simpleStreamDoneKey] {
 					seenStreamDoneKeys[simpleStreamDoneKey] = true
+					actions := append(baseDescriptorActions(metadataNamespace), quotaScheduleActions(metadataNamespace, scheduleKey)...)
 					streamDoneActions = append(streamDoneActions, &routev3.RateLimit{
-						Actions:           baseDescriptorActions(),
-						HitsAddend:        quotaHitsAddend(),
+						Actions:           append(actions, quotaUnitActions(unit)...),
+						HitsAddend:        hitsAddend,
 						ApplyOnStreamDone: true,
 					})
 				}
 			} else if len(pmq.Quota.BucketRules) > 0 {
-				bucketActions := buildBucketRuleLimitEntries(modelName, policy.Namespace, &pmq.Quota, policy.Spec.TargetRefs, backendModels)
+				bucketActions := buildBucketRuleLimitEntries(metadataNamespace, modelName, policy.Namespace, &pmq.Quota, policy.Spec.TargetRefs, backendModels, scheduleKey)
 				rateLimitActions = append(rateLimitActions, bucketActions...)
 				// Bucket rules: one stream-done per unique rule/header structure.
 				// Stream-done actions read backend/model from dynamic metadata and
 				// hits_addend uses a single quota_cost key, so entries are identical
 				// regardless of target or model.
 				for rIdx, rule := range pmq.Quota.BucketRules {
+					hitsAddend := quotaUnitHitsAddend(metadataNamespace, rule.Quota.Unit)
+					if hitsAddend == nil {
+						continue
+					}
 					headers := flattenAndSortClientSelectorHeaders(rule.ClientSelectors)
 					var dupKey string
 					for mIdx, hdr := range headers {
@@ -593,32 +607,37 @@ func enableQuotaRateLimitOnRoute(_ logr.Logger, route *routev3.Route, policies [
 					if len(headers) == 0 {
 						dupKey += "|" + translator.BucketRuleDescriptorKey(rIdx, 0, "", "")
 					}
+					dupKey += "|" + translator.QuotaUnitDescriptorKey(rule.Quota.Unit)
 					if !seenStreamDoneKeys[dupKey] {
 						seenStreamDoneKeys[dupKey] = true
 						clientActions := buildClientSelectorStreamDoneActions(rIdx, rule.ClientSelectors)
+						actions := append(baseDescriptorActions(metadataNamespace), clientActions...)
 						streamDoneActions = append(streamDoneActions, &routev3.RateLimit{
-							Actions:           append(baseDescriptorActions(), clientActions...),
-							HitsAddend:        quotaHitsAddend(),
+							Actions:           append(actions, quotaUnitActions(rule.Quota.Unit)...),
+							HitsAddend:        hitsAddend,
 							ApplyOnStreamDone: true,
 						})
 					}
 				}
 				// Default bucket: 3-level stream-done with GenericKey (always fires).
-				if pmq.Quota.DefaultBucket.Limit > 0 {
+				defaultUnit := pmq.Quota.DefaultBucket.Unit
+				if hitsAddend := quotaUnitHitsAddend(metadataNamespace, defaultUnit); pmq.Quota.DefaultBucket.Limit > 0 && hitsAddend != nil {
 					defaultKey := translator.DefaultBucketDescriptorKey(len(pmq.Quota.BucketRules))
-					dupDefaultKey := defaultKey
+					dupDefaultKey := defaultKey + "|" + scheduleKey + "|" + translator.QuotaUnitDescriptorKey(defaultUnit)
 					if !seenStreamDoneKeys[dupDefaultKey] {
 						seenStreamDoneKeys[dupDefaultKey] = true
-						streamDoneActions = append(streamDoneActions, &routev3.RateLimit{
-							Actions: append(baseDescriptorActions(), &routev3.RateLimit_Action{
-								ActionSpecifier: &routev3.RateLimit_Action_GenericKey_{
-									GenericKey: &routev3.RateLimit_Action_GenericKey{
-										DescriptorKey:   defaultKey,
-										DescriptorValue: defaultKey,
-									},
+						actions := append(baseDescriptorActions(metadataNamespace), &routev3.RateLimit_Action{
+							ActionSpecifier: &routev3.RateLimit_Action_GenericKey_{
+								GenericKey: &routev3.RateLimit_Action_GenericKey{
+									DescriptorKey:   defaultKey,
+									DescriptorValue: defaultKey,
 								},
-							}),
-							HitsAddend:        quotaHitsAddend(),
+							},
+						})
+						actions = append(actions, quotaScheduleActions(metadataNamespace, scheduleKey)...)
+						streamDoneActions = append(streamDoneActions, &routev3.RateLimit{
+							Actions:           append(actions, quotaUnitActions(defaultUnit)...),
+							HitsAddend:        hitsAddend,
 							ApplyOnStreamDone: true,
 						})
 					}
@@ -655,14 +674,14 @@ func enableQuotaRateLimitOnRoute(_ logr.Logger, route *routev3.Route, policies [
 // ai_service_backend_name contains the short "namespace/name" format that matches
 // the rate limit service config, as opposed to backend_name which contains the
 // full route ref path.
-func baseDescriptorActions() []*routev3.RateLimit_Action {
+func baseDescriptorActions(metadataNamespace string) []*routev3.RateLimit_Action {
 	return []*routev3.RateLimit_Action{
 		{
 			ActionSpecifier: &routev3.RateLimit_Action_Metadata{
 				Metadata: &routev3.RateLimit_Action_MetaData{
 					DescriptorKey: translator.BackendNameDescriptorKey,
 					MetadataKey: &metadatav3.MetadataKey{
-						Key: aigv1b1.AIGatewayFilterMetadataNamespace,
+						Key: metadataNamespace,
 						Path: []*metadatav3.MetadataKey_PathSegment{{
 							Segment: &metadatav3.MetadataKey_PathSegment_Key{
 								Key: "ai_service_backend_name",
@@ -678,7 +697,7 @@ func baseDescriptorActions() []*routev3.RateLimit_Action {
 				Metadata: &routev3.RateLimit_Action_MetaData{
 					DescriptorKey: translator.ModelNameDescriptorKey,
 					MetadataKey: &metadatav3.MetadataKey{
-						Key: aigv1b1.AIGatewayFilterMetadataNamespace,
+						Key: metadataNamespace,
 						Path: []*metadatav3.MetadataKey_PathSegment{{
 							Segment: &metadatav3.MetadataKey_PathSegment_Key{
 								Key: "model_name_override",
@@ -694,15 +713,18 @@ func baseDescriptorActions() []*routev3.RateLimit_Action {
 
 // buildSimpleModelEntries creates RateLimit entries for a model with no bucket rules.
 // Produces 2-level descriptors (backend_name, model_name_override) matching the
-// translator's simple case where rate_limit is directly on the model descriptor.
-func buildSimpleModelEntries(modelName, policyNamespace string, targets []gwapiv1a2.LocalPolicyTargetReference, routeModelNames map[string][]string) []*routev3.RateLimit {
+// translator's simple case where rate_limit is directly on the model descriptor, followed by
+// the schedule descriptor if scheduleKey is set.
+func buildSimpleModelEntries(metadataNamespace, modelName, policyNamespace string, targets []gwapiv1a2.LocalPolicyTargetReference, routeModelNames map[string][]string, unit aigv1a1.QuotaUnit, scheduleKey string) []*routev3.RateLimit {
 	var entries []*routev3.RateLimit
 
 	// Request-time entries only. Stream-done is added once per model in enableQuotaRateLimitOnRoute.
 	for _, target := range targets {
 		resolvedModel := resolveModelName(string(target.Name), modelName, routeModelNames)
+		actions := requestTimeBaseActions(policyNamespace, string(target.Name), resolvedModel)
+		actions = append(actions, quotaScheduleActions(metadataNamespace, scheduleKey)...)
 		entries = append(entries, &routev3.RateLimit{
-			Actions: requestTimeBaseActions(policyNamespace, string(target.Name), resolvedModel),
+			Actions: append(actions, quotaUnitActions(unit)...),
 		})
 	}
 
@@ -711,21 +733,74 @@ func buildSimpleModelEntries(modelName, policyNamespace string, targets []gwapiv
 
 // quotaHitsAddend returns the HitsAddend that reads the quota cost from dynamic
 // metadata stored by the ext_proc filter.
-func quotaHitsAddend() *routev3.RateLimit_HitsAddend {
+func quotaHitsAddend(metadataNamespace string) *routev3.RateLimit_HitsAddend {
+	return quotaUnitHitsAddend(metadataNamespace, aigv1a1.QuotaUnitCost)
+}
+
+// quotaUnitHitsAddend returns the HitsAddend of the stream-done entries for the quota unit, which
+// reads the consumed amount of the unit from dynamic metadata stored by the ext_proc filter.
+// Returns nil for the Requests unit, which is only counted by the request-time entries.
+func quotaUnitHitsAddend(metadataNamespace string, unit aigv1a1.QuotaUnit) *routev3.RateLimit_HitsAddend {
+	metadataKey := translator.QuotaUnitMetadataKey(unit)
+	if metadataKey == "" {
+		return nil
+	}
 	return &routev3.RateLimit_HitsAddend{
 		Format: fmt.Sprintf("%%DYNAMIC_METADATA(%s:%s)%%",
-			aigv1b1.AIGatewayFilterMetadataNamespace, quotaCostMetadataKey),
+			metadataNamespace, metadataKey),
 	}
 }
 
+// quotaUnitActions returns the GenericKey action matching the unit descriptor that the rate limit
+// service config nests under the bucket descriptor, or nil for the Cost unit which isn't nested.
+func quotaUnitActions(unit aigv1a1.QuotaUnit) []*routev3.RateLimit_Action {
+	key := translator.QuotaUnitDescriptorKey(unit)
+	if key == "" {
+		return nil
+	}
+	return []*routev3.RateLimit_Action{{
+		ActionSpecifier: &routev3.RateLimit_Action_GenericKey_{
+			GenericKey: &routev3.RateLimit_Action_GenericKey{
+				DescriptorKey:   key,
+				DescriptorValue: key,
+			},
+		},
+	}}
+}
+
+// quotaScheduleActions returns the action reading the active window of a quota schedule from
+// the dynamic metadata key set by the ext_proc filter, or nil if scheduleKey is empty. The
+// default window is used when the metadata isn't set so that the default limit applies.
+func quotaScheduleActions(metadataNamespace, scheduleKey string) []*routev3.RateLimit_Action {
+	if scheduleKey == "" {
+		return nil
+	}
+	return []*routev3.RateLimit_Action{{
+		ActionSpecifier: &routev3.RateLimit_Action_Metadata{
+			Metadata: &routev3.RateLimit_Action_MetaData{
+				DescriptorKey: translator.QuotaScheduleDescriptorKey,
+				MetadataKey: &metadatav3.MetadataKey{
+					Key: metadataNamespace,
+					Path: []*metadatav3.MetadataKey_PathSegment{{
+						Segment: &metadatav3.MetadataKey_PathSegment_Key{Key: scheduleKey},
+					}},
+				},
+				DefaultValue: translator.DefaultQuotaScheduleWindow,
+				Source:       routev3.RateLimit_Action_MetaData_DYNAMIC,
+			},
+		},
+	}}
+}
+
 // buildBucketRuleLimitEntries creates RateLimit entries for a model's bucket rules.
 // Each bucket rule and the default bucket produces one request-time entry per target
 // backend. The model_name_override descriptor uses the resolved ModelNameOverride
 // from the AIGatewayRoute (matching what ext_proc sets in dynamic metadata).
 //
 // Action order matches the translator's service config tree:
-// backend_name (Level 0) → model_name_override (Level 1) → bucket_rule_key (Level 2)
-func buildBucketRuleLimitEntries(modelName, policyNamespace string, quota *aigv1a1.QuotaDefinition, targets []gwapiv1a2.LocalPolicyTargetReference, routeModelNames map[string][]string) []*routev3.RateLimit {
+// backend_name (Level 0) → model_name_override (Level 1) → bucket_rule_key (Level 2),
+// followed by the schedule descriptor for the default bucket if scheduleKey is set.
+func buildBucketRuleLimitEntries(metadataNamespace, modelName, policyNamespace string, quota *aigv1a1.QuotaDefinition, targets []gwapiv1a2.LocalPolicyTargetReference, routeModelNames map[string][]string, scheduleKey string) []*routev3.RateLimit {
 	var entries []*routev3.RateLimit
 
 	for _, target := range targets {
@@ -735,6 +810,7 @@ func buildBucketRuleLimitEntries(modelName, policyNamespace string, quota *aigv1
 			clientActions := buildClientSelectorActions(rIdx, rule.ClientSelectors)
 			actions := requestTimeBaseActions(policyNamespace, string(target.Name), resolvedModel)
 			actions = append(actions, clientActions...)
+			actions = append(actions, quotaUnitActions(rule.Quota.Unit)...)
 			entries = append(entries, &routev3.RateLimit{Actions: actions})
 		}
 
@@ -750,6 +826,8 @@ func buildBucketRuleLimitEntries(modelName, policyNamespace string, quota *aigv1
 			}
 			actions := requestTimeBaseActions(policyNamespace, string(target.Name), resolvedModel)
 			actions = append(actions, defaultAction)
+			actions = append(actions, quotaScheduleActions(metadataNamespace, scheduleKey)...)
+			actions = append(actions, quotaUnitActions(quota.DefaultBucket.Unit)...)
 			entries = append(entries, &routev3.RateLimit{Actions: actions})
 		}
 	}
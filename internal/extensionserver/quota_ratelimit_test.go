@@ -220,7 +220,7 @@ func TestEnableQuotaRateLimitOnRoute(t *testing.T) {
 
 	t.Run("sets per-route rate limit config", func(t *testing.T) {
 		route := &routev3.Route{Name: "test-route"}
-		require.NoError(t, enableQuotaRateLimitOnRoute(logr.Discard(), route, testPolicies, nil))
+		require.NoError(t, enableQuotaRateLimitOnRoute(logr.Discard(), aigv1b1.AIGatewayFilterMetadataNamespace, route, testPolicies, nil))
 		require.NotNil(t, route.TypedPerFilterConfig)
 		require.Contains(t, route.TypedPerFilterConfig, quotaRateLimitFilterName)
 
@@ -233,13 +233,13 @@ func TestEnableQuotaRateLimitOnRoute(t *testing.T) {
 
 	t.Run("nil policies returns nil", func(t *testing.T) {
 		route := &routev3.Route{Name: "test-route"}
-		require.NoError(t, enableQuotaRateLimitOnRoute(logr.Discard(), route, nil, nil))
+		require.NoError(t, enableQuotaRateLimitOnRoute(logr.Discard(), aigv1b1.AIGatewayFilterMetadataNamespace, route, nil, nil))
 		require.Nil(t, route.TypedPerFilterConfig)
 	})
 
 	t.Run("stream-done entry reads backend_name from metadata", func(t *testing.T) {
 		route := &routev3.Route{Name: "test-route"}
-		require.NoError(t, enableQuotaRateLimitOnRoute(logr.Discard(), route, testPolicies, nil))
+		require.NoError(t, enableQuotaRateLimitOnRoute(logr.Discard(), aigv1b1.AIGatewayFilterMetadataNamespace, route, testPolicies, nil))
 
 		perRoute := &ratelimitfilterv3.RateLimitPerRoute{}
 		require.NoError(t, route.TypedPerFilterConfig[quotaRateLimitFilterName].UnmarshalTo(perRoute))
@@ -256,7 +256,7 @@ func TestEnableQuotaRateLimitOnRoute(t *testing.T) {
 
 	t.Run("request-time entry uses GenericKey for backend and model", func(t *testing.T) {
 		route := &routev3.Route{Name: "test-route"}
-		require.NoError(t, enableQuotaRateLimitOnRoute(logr.Discard(), route, testPolicies, nil))
+		require.NoError(t, enableQuotaRateLimitOnRoute(logr.Discard(), aigv1b1.AIGatewayFilterMetadataNamespace, route, testPolicies, nil))
 
 		perRoute := &ratelimitfilterv3.RateLimitPerRoute{}
 		require.NoError(t, route.TypedPerFilterConfig[quotaRateLimitFilterName].UnmarshalTo(perRoute))
@@ -273,7 +273,7 @@ func TestEnableQuotaRateLimitOnRoute(t *testing.T) {
 				"some-other-filter": {},
 			},
 		}
-		require.NoError(t, enableQuotaRateLimitOnRoute(logr.Discard(), route, testPolicies, nil))
+		require.NoError(t, enableQuotaRateLimitOnRoute(logr.Discard(), aigv1b1.AIGatewayFilterMetadataNamespace, route, testPolicies, nil))
 		require.Len(t, route.TypedPerFilterConfig, 2)
 		require.Contains(t, route.TypedPerFilterConfig, "some-other-filter")
 		require.Contains(t, route.TypedPerFilterConfig, quotaRateLimitFilterName)
@@ -389,7 +389,7 @@ func TestEnableQuotaRateLimitOnRoute_DescriptorChain(t *testing.T) {
 			},
 		},
 	}
-	require.NoError(t, enableQuotaRateLimitOnRoute(logr.Discard(), route, policies, nil))
+	require.NoError(t, enableQuotaRateLimitOnRoute(logr.Discard(), aigv1b1.AIGatewayFilterMetadataNamespace, route, policies, nil))
 
 	perRoute := &ratelimitfilterv3.RateLimitPerRoute{}
 	require.NoError(t, route.TypedPerFilterConfig[quotaRateLimitFilterName].UnmarshalTo(perRoute))
@@ -415,16 +415,146 @@ func TestEnableQuotaRateLimitOnRoute_DescriptorChain(t *testing.T) {
 }
 
 func TestQuotaHitsAddend(t *testing.T) {
-	ha := quotaHitsAddend()
+	ha := quotaHitsAddend(aigv1b1.AIGatewayFilterMetadataNamespace)
 	require.NotNil(t, ha)
 	expectedFormat := fmt.Sprintf("%%DYNAMIC_METADATA(%s:%s)%%", aigv1b1.AIGatewayFilterMetadataNamespace, quotaCostMetadataKey)
 	require.Equal(t, expectedFormat, ha.Format)
 }
 
+func TestQuotaUnitHitsAddend(t *testing.T) {
+	require.Nil(t, quotaUnitHitsAddend(aigv1b1.AIGatewayFilterMetadataNamespace, aigv1a1.QuotaUnitRequests))
+	require.Equal(t, quotaHitsAddend(aigv1b1.AIGatewayFilterMetadataNamespace).Format, quotaUnitHitsAddend(aigv1b1.AIGatewayFilterMetadataNamespace, "").Format)
+	ha := quotaUnitHitsAddend(aigv1b1.AIGatewayFilterMetadataNamespace, aigv1a1.QuotaUnitOutputTokens)
+	require.NotNil(t, ha)
+	require.Equal(t, fmt.Sprintf("%%DYNAMIC_METADATA(%s:quota_output_tokens)%%", aigv1b1.AIGatewayFilterMetadataNamespace), ha.Format)
+}
+
+func TestEnableQuotaRateLimitOnRoute_QuotaUnits(t *testing.T) {
+	route := &routev3.Route{Name: "test-route"}
+	policies := []aigv1a1.QuotaPolicy{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default"},
+			Spec: aigv1a1.QuotaPolicySpec{
+				TargetRefs: []gwapiv1a2.LocalPolicyTargetReference{
+					{Name: "test-backend"},
+				},
+				PerModelQuotas: []aigv1a1.PerModelQuota{
+					{
+						ModelName: ptr.To("gpt-4"),
+						Quota: aigv1a1.QuotaDefinition{
+							DefaultBucket: aigv1a1.QuotaValue{Limit: 60, Duration: "1m", Unit: aigv1a1.QuotaUnitRequests},
+						},
+					},
+					{
+						ModelName: ptr.To("gpt-4"),
+						Quota: aigv1a1.QuotaDefinition{
+							DefaultBucket: aigv1a1.QuotaValue{Limit: 100000, Duration: "1m", Unit: aigv1a1.QuotaUnitTotalTokens},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	require.NoError(t, enableQuotaRateLimitOnRoute(logr.Discard(), aigv1b1.AIGatewayFilterMetadataNamespace, route, policies, nil))
+
+	perRoute := &ratelimitfilterv3.RateLimitPerRoute{}
+	require.NoError(t, route.TypedPerFilterConfig[quotaRateLimitFilterName].UnmarshalTo(perRoute))
+
+	// 2 req-time (one per unit) + 1 stream-done for the tokens only.
+	require.Len(t, perRoute.RateLimits, 3)
+	requestsEntry := perRoute.RateLimits[0]
+	require.Nil(t, requestsEntry.HitsAddend)
+	require.Equal(t, "quota-unit-requests", requestsEntry.Actions[len(requestsEntry.Actions)-1].GetGenericKey().DescriptorKey)
+	tokensEntry := perRoute.RateLimits[1]
+	require.Nil(t, tokensEntry.HitsAddend)
+	require.Equal(t, "quota-unit-totaltokens", tokensEntry.Actions[len(tokensEntry.Actions)-1].GetGenericKey().DescriptorKey)
+
+	streamDone := perRoute.RateLimits[2]
+	require.True(t, streamDone.ApplyOnStreamDone)
+	require.Len(t, streamDone.Actions, 3)
+	require.Equal(t, "quota-unit-totaltokens", streamDone.Actions[2].GetGenericKey().DescriptorKey)
+	require.Contains(t, streamDone.HitsAddend.Format, "quota_total_tokens")
+}
+
+func TestEnableQuotaRateLimitOnRoute_QuotaSchedules(t *testing.T) {
+	schedules := []aigv1a1.QuotaSchedule{{Name: "off-peak", Start: "20:00", End: "08:00", Limit: 500, Duration: "1h"}}
+	route := &routev3.Route{Name: "test-route"}
+	policies := []aigv1a1.QuotaPolicy{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "policy"},
+			Spec: aigv1a1.QuotaPolicySpec{
+				TargetRefs: []gwapiv1a2.LocalPolicyTargetReference{
+					{Name: "test-backend"},
+				},
+				PerModelQuotas: []aigv1a1.PerModelQuota{
+					{
+						ModelName: ptr.To("gpt-4"),
+						Quota: aigv1a1.QuotaDefinition{
+							DefaultBucket: aigv1a1.QuotaValue{Limit: 100, Duration: "1h"},
+							Schedules:     schedules,
+						},
+					},
+					{
+						ModelName: ptr.To("gpt-4o"),
+						Quota: aigv1a1.QuotaDefinition{
+							BucketRules: []aigv1a1.QuotaRule{
+								{Quota: aigv1a1.QuotaValue{Limit: 10, Duration: "1h"}},
+							},
+							DefaultBucket: aigv1a1.QuotaValue{Limit: 100, Duration: "1h"},
+							Schedules:     schedules,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	require.NoError(t, enableQuotaRateLimitOnRoute(logr.Discard(), aigv1b1.AIGatewayFilterMetadataNamespace, route, policies, nil))
+
+	perRoute := &ratelimitfilterv3.RateLimitPerRoute{}
+	require.NoError(t, route.TypedPerFilterConfig[quotaRateLimitFilterName].UnmarshalTo(perRoute))
+
+	// 3 req-time (simple, bucket rule, default bucket) + 3 stream-done.
+	require.Len(t, perRoute.RateLimits, 6)
+	requireScheduleAction := func(action *routev3.RateLimit_Action, quotaIndex int) {
+		t.Helper()
+		md := action.GetMetadata()
+		require.NotNil(t, md)
+		require.Equal(t, translator.QuotaScheduleDescriptorKey, md.DescriptorKey)
+		require.Equal(t, translator.DefaultQuotaScheduleWindow, md.DefaultValue)
+		require.Equal(t, aigv1b1.AIGatewayFilterMetadataNamespace, md.MetadataKey.Key)
+		require.Equal(t, translator.QuotaScheduleMetadataKey("default", "policy", quotaIndex), md.MetadataKey.Path[0].GetKey())
+	}
+
+	simple := perRoute.RateLimits[0]
+	require.Len(t, simple.Actions, 3)
+	requireScheduleAction(simple.Actions[2], 0)
+
+	// The bucket rules aren't affected by the schedules.
+	rule := perRoute.RateLimits[1]
+	require.Len(t, rule.Actions, 3)
+	require.Nil(t, rule.Actions[2].GetMetadata())
+
+	defaultBucket := perRoute.RateLimits[2]
+	require.Len(t, defaultBucket.Actions, 4)
+	requireScheduleAction(defaultBucket.Actions[3], 1)
+
+	simpleStreamDone := perRoute.RateLimits[3]
+	require.True(t, simpleStreamDone.ApplyOnStreamDone)
+	require.Len(t, simpleStreamDone.Actions, 3)
+	requireScheduleAction(simpleStreamDone.Actions[2], 0)
+
+	defaultStreamDone := perRoute.RateLimits[5]
+	require.True(t, defaultStreamDone.ApplyOnStreamDone)
+	require.Len(t, defaultStreamDone.Actions, 4)
+	requireScheduleAction(defaultStreamDone.Actions[3], 1)
+}
+
 func TestEnableQuotaRateLimitOnRoute_HitsAddend(t *testing.T) {
 	t.Run("nil policies returns nil without patching route", func(t *testing.T) {
 		route := &routev3.Route{Name: "test-route"}
-		require.NoError(t, enableQuotaRateLimitOnRoute(logr.Discard(), route, nil, nil))
+		require.NoError(t, enableQuotaRateLimitOnRoute(logr.Discard(), aigv1b1.AIGatewayFilterMetadataNamespace, route, nil, nil))
 		require.Nil(t, route.TypedPerFilterConfig)
 	})
 
@@ -452,7 +582,7 @@ func TestEnableQuotaRateLimitOnRoute_HitsAddend(t *testing.T) {
 			},
 		}
 
-		require.NoError(t, enableQuotaRateLimitOnRoute(logr.Discard(), route, policies, nil))
+		require.NoError(t, enableQuotaRateLimitOnRoute(logr.Discard(), aigv1b1.AIGatewayFilterMetadataNamespace, route, policies, nil))
 
 		perRoute := &ratelimitfilterv3.RateLimitPerRoute{}
 		require.NoError(t, route.TypedPerFilterConfig[quotaRateLimitFilterName].UnmarshalTo(perRoute))
@@ -565,7 +695,7 @@ func TestEnableQuotaRateLimitOnRoute_WithBucketRules(t *testing.T) {
 			},
 		}
 
-		require.NoError(t, enableQuotaRateLimitOnRoute(logr.Discard(), route, policies, nil))
+		require.NoError(t, enableQuotaRateLimitOnRoute(logr.Discard(), aigv1b1.AIGatewayFilterMetadataNamespace, route, policies, nil))
 
 		perRoute := &ratelimitfilterv3.RateLimitPerRoute{}
 		require.NoError(t, route.TypedPerFilterConfig[quotaRateLimitFilterName].UnmarshalTo(perRoute))
@@ -628,7 +758,7 @@ func TestEnableQuotaRateLimitOnRoute_WithBucketRules(t *testing.T) {
 			},
 		}
 
-		require.NoError(t, enableQuotaRateLimitOnRoute(logr.Discard(), route, policies, nil))
+		require.NoError(t, enableQuotaRateLimitOnRoute(logr.Discard(), aigv1b1.AIGatewayFilterMetadataNamespace, route, policies, nil))
 
 		perRoute := &ratelimitfilterv3.RateLimitPerRoute{}
 		require.NoError(t, route.TypedPerFilterConfig[quotaRateLimitFilterName].UnmarshalTo(perRoute))
@@ -681,7 +811,7 @@ func TestEnableQuotaRateLimitOnRoute_WithBucketRules(t *testing.T) {
 			},
 		}
 
-		require.NoError(t, enableQuotaRateLimitOnRoute(logr.Discard(), route, policies, nil))
+		require.NoError(t, enableQuotaRateLimitOnRoute(logr.Discard(), aigv1b1.AIGatewayFilterMetadataNamespace, route, policies, nil))
 
 		perRoute := &ratelimitfilterv3.RateLimitPerRoute{}
 		require.NoError(t, route.TypedPerFilterConfig[quotaRateLimitFilterName].UnmarshalTo(perRoute))
@@ -717,7 +847,7 @@ func TestEnableQuotaRateLimitOnRoute_WithBucketRules(t *testing.T) {
 			},
 		}
 
-		require.NoError(t, enableQuotaRateLimitOnRoute(logr.Discard(), route, policies, nil))
+		require.NoError(t, enableQuotaRateLimitOnRoute(logr.Discard(), aigv1b1.AIGatewayFilterMetadataNamespace, route, policies, nil))
 
 		perRoute := &ratelimitfilterv3.RateLimitPerRoute{}
 		require.NoError(t, route.TypedPerFilterConfig[quotaRateLimitFilterName].UnmarshalTo(perRoute))
@@ -764,7 +894,7 @@ func TestEnableQuotaRateLimitOnRoute_WithBucketRules(t *testing.T) {
 			},
 		}
 
-		require.NoError(t, enableQuotaRateLimitOnRoute(logr.Discard(), route, policies, nil))
+		require.NoError(t, enableQuotaRateLimitOnRoute(logr.Discard(), aigv1b1.AIGatewayFilterMetadataNamespace, route, policies, nil))
 
 		perRoute := &ratelimitfilterv3.RateLimitPerRoute{}
 		require.NoError(t, route.TypedPerFilterConfig[quotaRateLimitFilterName].UnmarshalTo(perRoute))
@@ -803,7 +933,7 @@ func TestEnableQuotaRateLimitOnRoute_WithBucketRules(t *testing.T) {
 			},
 		}
 
-		require.NoError(t, enableQuotaRateLimitOnRoute(logr.Discard(), route, policies, nil))
+		require.NoError(t, enableQuotaRateLimitOnRoute(logr.Discard(), aigv1b1.AIGatewayFilterMetadataNamespace, route, policies, nil))
 
 		perRoute := &ratelimitfilterv3.RateLimitPerRoute{}
 		require.NoError(t, route.TypedPerFilterConfig[quotaRateLimitFilterName].UnmarshalTo(perRoute))
@@ -854,7 +984,7 @@ func TestEnableQuotaRateLimitOnRoute_WithBucketRules(t *testing.T) {
 			},
 		}
 
-		require.NoError(t, enableQuotaRateLimitOnRoute(logr.Discard(), route, policies, nil))
+		require.NoError(t, enableQuotaRateLimitOnRoute(logr.Discard(), aigv1b1.AIGatewayFilterMetadataNamespace, route, policies, nil))
 
 		perRoute := &ratelimitfilterv3.RateLimitPerRoute{}
 		require.NoError(t, route.TypedPerFilterConfig[quotaRateLimitFilterName].UnmarshalTo(perRoute))
@@ -881,7 +1011,7 @@ func TestEnableQuotaRateLimitOnRoute_WithBucketRules(t *testing.T) {
 			},
 		}
 
-		require.NoError(t, enableQuotaRateLimitOnRoute(logr.Discard(), route, policies, nil))
+		require.NoError(t, enableQuotaRateLimitOnRoute(logr.Discard(), aigv1b1.AIGatewayFilterMetadataNamespace, route, policies, nil))
 
 		// No entries since nil model name with no bucket rules is skipped; returns nil early.
 		require.Nil(t, route.TypedPerFilterConfig)
@@ -924,7 +1054,7 @@ func TestEnableQuotaRateLimitOnRoute_WithBucketRules(t *testing.T) {
 			},
 		}
 
-		require.NoError(t, enableQuotaRateLimitOnRoute(logr.Discard(), route, policies, nil))
+		require.NoError(t, enableQuotaRateLimitOnRoute(logr.Discard(), aigv1b1.AIGatewayFilterMetadataNamespace, route, policies, nil))
 
 		perRoute := &ratelimitfilterv3.RateLimitPerRoute{}
 		require.NoError(t, route.TypedPerFilterConfig[quotaRateLimitFilterName].UnmarshalTo(perRoute))
@@ -1037,7 +1167,7 @@ func TestEnableQuotaRateLimitOnRoute_WithBucketRules(t *testing.T) {
 			},
 		}
 
-		require.NoError(t, enableQuotaRateLimitOnRoute(logr.Discard(), route, policies, nil))
+		require.NoError(t, enableQuotaRateLimitOnRoute(logr.Discard(), aigv1b1.AIGatewayFilterMetadataNamespace, route, policies, nil))
 
 		perRoute := &ratelimitfilterv3.RateLimitPerRoute{}
 		require.NoError(t, route.TypedPerFilterConfig[quotaRateLimitFilterName].UnmarshalTo(perRoute))
@@ -1125,7 +1255,7 @@ func TestEnableQuotaRateLimitOnRoute_WithBucketRules(t *testing.T) {
 			},
 		}
 
-		require.NoError(t, enableQuotaRateLimitOnRoute(logr.Discard(), route, policies, nil))
+		require.NoError(t, enableQuotaRateLimitOnRoute(logr.Discard(), aigv1b1.AIGatewayFilterMetadataNamespace, route, policies, nil))
 
 		perRoute := &ratelimitfilterv3.RateLimitPerRoute{}
 		require.NoError(t, route.TypedPerFilterConfig[quotaRateLimitFilterName].UnmarshalTo(perRoute))
@@ -1139,13 +1269,13 @@ func TestEnableQuotaRateLimitOnRoute_WithBucketRules(t *testing.T) {
 
 	t.Run("nil policies list", func(t *testing.T) {
 		route := &routev3.Route{Name: "test-route"}
-		require.NoError(t, enableQuotaRateLimitOnRoute(logr.Discard(), route, nil, nil))
+		require.NoError(t, enableQuotaRateLimitOnRoute(logr.Discard(), aigv1b1.AIGatewayFilterMetadataNamespace, route, nil, nil))
 		require.Nil(t, route.TypedPerFilterConfig)
 	})
 
 	t.Run("empty policies list", func(t *testing.T) {
 		route := &routev3.Route{Name: "test-route"}
-		require.NoError(t, enableQuotaRateLimitOnRoute(logr.Discard(), route, []aigv1a1.QuotaPolicy{}, nil))
+		require.NoError(t, enableQuotaRateLimitOnRoute(logr.Discard(), aigv1b1.AIGatewayFilterMetadataNamespace, route, []aigv1a1.QuotaPolicy{}, nil))
 		require.Nil(t, route.TypedPerFilterConfig)
 	})
 }
@@ -1217,7 +1347,7 @@ func TestBuildBucketRuleLimitEntries(t *testing.T) {
 
 	t.Run("no bucket rules returns nil", func(t *testing.T) {
 		quota := &aigv1a1.QuotaDefinition{}
-		entries := buildBucketRuleLimitEntries("gpt-4", "default", quota, oneTarget, nil)
+		entries := buildBucketRuleLimitEntries(aigv1b1.AIGatewayFilterMetadataNamespace, "gpt-4", "default", quota, oneTarget, nil, "")
 		require.Nil(t, entries)
 	})
 
@@ -1229,7 +1359,7 @@ func TestBuildBucketRuleLimitEntries(t *testing.T) {
 				},
 			},
 		}
-		entries := buildBucketRuleLimitEntries("gpt-4", "default", quota, oneTarget, nil)
+		entries := buildBucketRuleLimitEntries(aigv1b1.AIGatewayFilterMetadataNamespace, "gpt-4", "default", quota, oneTarget, nil, "")
 		require.Len(t, entries, 1) // 1 request-time only (stream-done added by enableQuotaRateLimitOnRoute)
 		// Request-time entry: backend_name + model_name + GenericKey = 3 actions
 		require.Len(t, entries[0].Actions, 3)
@@ -1246,7 +1376,7 @@ func TestBuildBucketRuleLimitEntries(t *testing.T) {
 			},
 			DefaultBucket: aigv1a1.QuotaValue{Limit: 10, Duration: "1m"},
 		}
-		entries := buildBucketRuleLimitEntries("gpt-4", "default", quota, oneTarget, nil)
+		entries := buildBucketRuleLimitEntries(aigv1b1.AIGatewayFilterMetadataNamespace, "gpt-4", "default", quota, oneTarget, nil, "")
 		require.Len(t, entries, 2) // 1 bucket req-time + 1 default req-time (no stream-done)
 
 		// Default bucket request-time entry (index 1)
@@ -1265,7 +1395,7 @@ func TestBuildBucketRuleLimitEntries(t *testing.T) {
 			},
 			DefaultBucket: aigv1a1.QuotaValue{Limit: 0},
 		}
-		entries := buildBucketRuleLimitEntries("gpt-4", "default", quota, oneTarget, nil)
+		entries := buildBucketRuleLimitEntries(aigv1b1.AIGatewayFilterMetadataNamespace, "gpt-4", "default", quota, oneTarget, nil, "")
 		require.Len(t, entries, 1) // 1 request-time only (no default, no stream-done)
 	})
 
@@ -1284,7 +1414,7 @@ func TestBuildBucketRuleLimitEntries(t *testing.T) {
 				},
 			},
 		}
-		entries := buildBucketRuleLimitEntries("gpt-4", "default", quota, oneTarget, nil)
+		entries := buildBucketRuleLimitEntries(aigv1b1.AIGatewayFilterMetadataNamespace, "gpt-4", "default", quota, oneTarget, nil, "")
 		require.Len(t, entries, 1) // 1 request-time only (stream-done added by enableQuotaRateLimitOnRoute)
 		// Request-time entry: backend_name + model_name + 1 header match = 3 actions
 		require.Len(t, entries[0].Actions, 3)
@@ -1298,7 +1428,7 @@ func TestBuildBucketRuleLimitEntries(t *testing.T) {
 				{Quota: aigv1a1.QuotaValue{Limit: 100, Duration: "1m"}},
 			},
 		}
-		entries := buildBucketRuleLimitEntries("gpt-4", "default", quota, oneTarget, nil)
+		entries := buildBucketRuleLimitEntries(aigv1b1.AIGatewayFilterMetadataNamespace, "gpt-4", "default", quota, oneTarget, nil, "")
 		require.Len(t, entries, 1) // request-time only (stream-done added by enableQuotaRateLimitOnRoute)
 
 		// Request-time entry: GenericKey actions for backend_name and model_name.
@@ -1341,7 +1471,7 @@ func TestEnableQuotaRateLimitOnRoute_MultiplePerModelQuotas(t *testing.T) {
 		modelInfo := &routeModelInfo{
 			backendModels: map[string][]string{"bedrock-backend": {"claude-sonnet-4-6"}},
 		}
-		require.NoError(t, enableQuotaRateLimitOnRoute(logr.Discard(), route, policies, modelInfo))
+		require.NoError(t, enableQuotaRateLimitOnRoute(logr.Discard(), aigv1b1.AIGatewayFilterMetadataNamespace, route, policies, modelInfo))
 
 		perRoute := &ratelimitfilterv3.RateLimitPerRoute{}
 		require.NoError(t, route.TypedPerFilterConfig[quotaRateLimitFilterName].UnmarshalTo(perRoute))
@@ -1353,7 +1483,7 @@ func TestEnableQuotaRateLimitOnRoute_MultiplePerModelQuotas(t *testing.T) {
 
 	t.Run("nil modelInfo includes all models", func(t *testing.T) {
 		route2 := &routev3.Route{Name: "test-route-2"}
-		require.NoError(t, enableQuotaRateLimitOnRoute(logr.Discard(), route2, policies, nil))
+		require.NoError(t, enableQuotaRateLimitOnRoute(logr.Discard(), aigv1b1.AIGatewayFilterMetadataNamespace, route2, policies, nil))
 
 		perRoute := &ratelimitfilterv3.RateLimitPerRoute{}
 		require.NoError(t, route2.TypedPerFilterConfig[quotaRateLimitFilterName].UnmarshalTo(perRoute))
@@ -1405,7 +1535,7 @@ func TestEnableQuotaRateLimitOnRoute_MultiplePerModelQuotas(t *testing.T) {
 				"bedrock-backend-haiku": {"claude-haiku-4-5"},
 			},
 		}
-		require.NoError(t, enableQuotaRateLimitOnRoute(logr.Discard(), route3, mixedPolicies, modelInfo))
+		require.NoError(t, enableQuotaRateLimitOnRoute(logr.Discard(), aigv1b1.AIGatewayFilterMetadataNamespace, route3, mixedPolicies, modelInfo))
 
 		perRoute := &ratelimitfilterv3.RateLimitPerRoute{}
 		require.NoError(t, route3.TypedPerFilterConfig[quotaRateLimitFilterName].UnmarshalTo(perRoute))
@@ -1612,7 +1742,7 @@ func TestBuildHeaderMatchAction(t *testing.T) {
 }
 
 func TestBaseDescriptorActions(t *testing.T) {
-	actions := baseDescriptorActions()
+	actions := baseDescriptorActions(aigv1b1.AIGatewayFilterMetadataNamespace)
 	require.Len(t, actions, 2)
 
 	verifyMetadataAction(t, actions[0], translator.BackendNameDescriptorKey, "ai_service_backend_name")
@@ -1650,7 +1780,7 @@ func newTestServerWithRoute(t *testing.T, route *aigv1b1.AIGatewayRoute, policie
 	for i := range policies {
 		require.NoError(t, c.Create(t.Context(), &policies[i]))
 	}
-	s, err := New(c, logr.Discard(), udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false)
+	s, err := New(c, logr.Discard(), udsPath, false, nil, nil, "envoy-ai-gateway-ratelimit.envoy-gateway-system", 5, false, "", "")
 	require.NoError(t, err)
 	return s
 }
@@ -0,0 +1,65 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/anthropic"
+	cohereschema "github.com/envoyproxy/ai-gateway/internal/apischema/cohere"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai/tokenize"
+	"github.com/envoyproxy/ai-gateway/internal/filterapi"
+)
+
+// endpointOf returns the endpoint of the request, or empty if the request is not of a known endpoint.
+func endpointOf(req any) filterapi.Endpoint {
+	switch req.(type) {
+	case *openai.ChatCompletionRequest, *openai.CompletionRequest, *openai.ResponseRequest, *anthropic.MessagesRequest:
+		return filterapi.EndpointChat
+	case *openai.EmbeddingRequest:
+		return filterapi.EndpointEmbeddings
+	case *openai.ImageGenerationRequest:
+		return filterapi.EndpointImages
+	case *openai.SpeechRequest, *openai.TranscriptionRequest, *openai.TranslationRequest:
+		return filterapi.EndpointAudio
+	case *openai.ModerationRequest:
+		return filterapi.EndpointModerations
+	case *cohereschema.RerankV2Request:
+		return filterapi.EndpointRerank
+	case *tokenize.RequestUnion:
+		return filterapi.EndpointTokenize
+	default:
+		return ""
+	}
+}
+
+// checkAllowedEndpoint returns the response rejecting the request with 404 Not Found if the AllowedEndpoints of the
+// route don't include the endpoint of the request, or nil if the request can proceed. It is checked before the
+// request is translated, so that nothing of a request to a disabled endpoint reaches the backend.
+func (u *upstreamProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) checkAllowedEndpoint() *extprocv3.ProcessingResponse {
+	rp := u.parent
+	if rp.config == nil {
+		return nil
+	}
+	allowed, ok := rp.config.AllowedEndpoints[u.routeName]
+	if !ok {
+		return nil
+	}
+	endpoint := endpointOf(rp.originalRequestBody)
+	if endpoint != "" && slices.Contains(allowed, endpoint) {
+		return nil
+	}
+	path, _, _ := strings.Cut(u.requestHeaders[":path"], "?")
+	u.logger.Info("rejecting request to an endpoint not allowed on the route",
+		slog.String("route", u.routeName), slog.String("path", path))
+	return createUserFacingErrorResponse(404, "NotFound", fmt.Sprintf("%s is not allowed on this route", path))
+}
@@ -0,0 +1,58 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"testing"
+
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/anthropic"
+	cohereschema "github.com/envoyproxy/ai-gateway/internal/apischema/cohere"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai/tokenize"
+	"github.com/envoyproxy/ai-gateway/internal/filterapi"
+)
+
+func TestEndpointOf(t *testing.T) {
+	for _, tc := range []struct {
+		req any
+		exp filterapi.Endpoint
+	}{
+		{req: &openai.ChatCompletionRequest{}, exp: filterapi.EndpointChat},
+		{req: &openai.ResponseRequest{}, exp: filterapi.EndpointChat},
+		{req: &anthropic.MessagesRequest{}, exp: filterapi.EndpointChat},
+		{req: &openai.EmbeddingRequest{}, exp: filterapi.EndpointEmbeddings},
+		{req: &openai.ImageGenerationRequest{}, exp: filterapi.EndpointImages},
+		{req: &openai.TranscriptionRequest{}, exp: filterapi.EndpointAudio},
+		{req: &openai.ModerationRequest{}, exp: filterapi.EndpointModerations},
+		{req: &cohereschema.RerankV2Request{}, exp: filterapi.EndpointRerank},
+		{req: &tokenize.RequestUnion{}, exp: filterapi.EndpointTokenize},
+		{req: &struct{}{}},
+	} {
+		require.Equal(t, tc.exp, endpointOf(tc.req))
+	}
+}
+
+func Test_chatCompletionProcessorUpstreamFilter_checkAllowedEndpoint(t *testing.T) {
+	config := &filterapi.RuntimeConfig{AllowedEndpoints: map[string][]filterapi.Endpoint{
+		"default/embeddings": {filterapi.EndpointEmbeddings},
+		"default/chat":       {filterapi.EndpointChat, filterapi.EndpointModels},
+	}}
+	for _, routeName := range []string{"default/chat", "default/embeddings", "default/other"} {
+		p := newTestUpstreamFilter(t, config, routeName, `{"model":"m","messages":[]}`)
+		p.requestHeaders[":path"] = "/v1/chat/completions?foo=bar"
+		res := p.checkAllowedEndpoint()
+		// The chat route and the routes without AllowedEndpoints allow the chat endpoint.
+		if routeName != "default/embeddings" {
+			require.Nil(t, res)
+			continue
+		}
+		require.Equal(t, typev3.StatusCode_NotFound, res.GetImmediateResponse().GetStatus().GetCode())
+		require.Contains(t, string(res.GetImmediateResponse().GetBody()), "/v1/chat/completions is not allowed on this route")
+	}
+}
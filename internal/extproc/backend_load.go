@@ -0,0 +1,191 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"google.golang.org/grpc/codes"
+
+	"github.com/envoyproxy/ai-gateway/internal/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+)
+
+// backendLoadProcessor is implemented by the processors that know the backend serving the request after
+// [Processor.SetBackend], along with the upstream host selected by Envoy among its endpoints if known. The router
+// processor returns the backend of the last attempt, if any.
+type backendLoadProcessor interface {
+	backendLoadTarget() (backendName, upstreamHost string, config *filterapi.BackendLoadReporting)
+}
+
+// upstreamHostProcessor is implemented by the upstream processors keeping the upstream host of the request, which
+// is set from the request attributes after [Processor.SetBackend].
+type upstreamHostProcessor interface {
+	setUpstreamHost(host string)
+}
+
+// backendLoad keeps the last load reported by each endpoint of the backends configuring
+// [filterapi.BackendLoadReporting] in the headers of their responses, and sheds the attempts to the endpoints
+// whose fresh report exceeds the thresholds.
+//
+// This is owned by the Server rather than the runtime config so that the reports survive the config reloads.
+type backendLoad struct {
+	now func() time.Time
+
+	mu sync.Mutex
+	// reports are the last reports keyed by backendLoadKey.
+	reports map[string]backendLoadReport
+}
+
+// backendLoadReport is a load reported by a backend. The values that weren't reported are negative.
+type backendLoadReport struct {
+	queueDepth  int
+	loadPercent float64
+	at          time.Time
+}
+
+func newBackendLoad() *backendLoad {
+	return &backendLoad{now: time.Now, reports: make(map[string]backendLoadReport)}
+}
+
+// backendLoadKey returns the key of the reports of the upstream host, so that an overloaded endpoint of a backend
+// doesn't shed the requests to its other endpoints. The reports are keyed by the short name of the backend when the
+// upstream host is unknown, in which case they are shared by all its endpoints.
+func backendLoadKey(backendName, upstreamHost string) string {
+	if upstreamHost != "" {
+		return upstreamHost
+	}
+	return shortBackendName(backendName)
+}
+
+// record stores the load reported in the response headers of the upstream host of the backend. The previous
+// report is kept if the headers don't report any.
+func (b *backendLoad) record(backendName, upstreamHost string, config *filterapi.BackendLoadReporting, headers map[string]string) {
+	report := backendLoadReport{queueDepth: -1, loadPercent: -1}
+	if v, ok := headers[config.QueueDepthHeader]; ok {
+		if depth, err := strconv.Atoi(strings.TrimSpace(v)); err == nil && depth >= 0 {
+			report.queueDepth = depth
+		}
+	}
+	if v, ok := headers[config.LoadHeader]; ok {
+		report.loadPercent = parseLoadPercent(v)
+	}
+	if report.queueDepth < 0 && report.loadPercent < 0 {
+		return
+	}
+	report.at = b.now()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.reports[backendLoadKey(backendName, upstreamHost)] = report
+}
+
+// parseLoadPercent parses the reported load, either a ratio between 0 and 1 or a percentage suffixed with "%".
+// This returns -1 if the load is invalid.
+func parseLoadPercent(v string) float64 {
+	v = strings.TrimSpace(v)
+	percent, isPercent := strings.CutSuffix(v, "%")
+	f, err := strconv.ParseFloat(strings.TrimSpace(percent), 64)
+	if err != nil || f < 0 || math.IsNaN(f) || math.IsInf(f, 0) {
+		return -1
+	}
+	if !isPercent {
+		f *= 100
+	}
+	return f
+}
+
+// overloaded returns true if the last report of the upstream host of the backend is fresh and reaches one of the
+// thresholds, along with the time left until the report expires.
+func (b *backendLoad) overloaded(backendName, upstreamHost string, config *filterapi.BackendLoadReporting) (time.Duration, bool) {
+	b.mu.Lock()
+	report, ok := b.reports[backendLoadKey(backendName, upstreamHost)]
+	b.mu.Unlock()
+	if !ok {
+		return 0, false
+	}
+	left := time.Duration(config.ReportTTLMilliseconds)*time.Millisecond - b.now().Sub(report.at)
+	if left <= 0 {
+		return 0, false
+	}
+	if (config.MaxQueueDepth > 0 && report.queueDepth >= config.MaxQueueDepth) ||
+		(config.MaxLoadPercent > 0 && report.loadPercent >= float64(config.MaxLoadPercent)) {
+		return left, true
+	}
+	return 0, false
+}
+
+// shedOverloadedBackend rejects the upstream request to an endpoint of a backend whose last report exceeds the
+// thresholds of its load reporting. The rejection has the BackendOverloadedHeader, which makes Envoy retry the
+// request on another endpoint of the rule.
+func (s *Server) shedOverloadedBackend(ctx context.Context, p Processor, req *extprocv3.ProcessingRequest, resp *extprocv3.ProcessingResponse) *extprocv3.ProcessingResponse {
+	if req.GetRequestHeaders() == nil {
+		return resp
+	}
+	if _, accepted := resp.GetResponse().(*extprocv3.ProcessingResponse_RequestHeaders); !accepted {
+		return resp
+	}
+	bp, ok := p.(backendLoadProcessor)
+	if !ok {
+		return resp
+	}
+	backendName, upstreamHost, config := bp.backendLoadTarget()
+	if config == nil {
+		return resp
+	}
+	left, overloaded := s.backendLoad.overloaded(backendName, upstreamHost, config)
+	if !overloaded {
+		return resp
+	}
+	loggerFromContext(ctx).Info("shedding request to the overloaded backend",
+		slog.String("backend", backendName), slog.String("upstream_host", upstreamHost))
+	return backendOverloadedResponse(shortBackendName(backendName), left)
+}
+
+// recordBackendLoad stores the load reported in the response headers received by the router filter from the
+// backend of the last attempt.
+func (s *Server) recordBackendLoad(p Processor, req *extprocv3.ProcessingRequest) {
+	headers := req.GetResponseHeaders()
+	if headers == nil {
+		return
+	}
+	bp, ok := p.(backendLoadProcessor)
+	if !ok {
+		return
+	}
+	if backendName, upstreamHost, config := bp.backendLoadTarget(); config != nil {
+		s.backendLoad.record(backendName, upstreamHost, config, headersToMap(headers.Headers))
+	}
+}
+
+// backendOverloadedResponse returns the 503 response shedding the request to the overloaded backend until its
+// report expires.
+func backendOverloadedResponse(backend string, retryAfter time.Duration) *extprocv3.ProcessingResponse {
+	const statusCode = 503
+	body := formatUserFacingErrorJSON("ServiceUnavailable", statusCode, "backend "+backend+" is overloaded")
+	headerMutation := &extprocv3.HeaderMutation{}
+	setHeader(headerMutation, "content-type", "application/json")
+	setHeader(headerMutation, "content-length", strconv.Itoa(len(body)))
+	setHeader(headerMutation, "retry-after", strconv.FormatInt(max(int64(math.Ceil(retryAfter.Seconds())), 1), 10))
+	setHeader(headerMutation, internalapi.BackendOverloadedHeader, "true")
+	return &extprocv3.ProcessingResponse{
+		Response: &extprocv3.ProcessingResponse_ImmediateResponse{
+			ImmediateResponse: &extprocv3.ImmediateResponse{
+				Status:     &typev3.HttpStatus{Code: typev3.StatusCode_ServiceUnavailable},
+				Headers:    headerMutation,
+				Body:       body,
+				GrpcStatus: &extprocv3.GrpcStatus{Status: uint32(codes.Unavailable)},
+			},
+		},
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/internal/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+)
+
+var testLoadReporting = &filterapi.BackendLoadReporting{
+	QueueDepthHeader:      "x-queue-depth",
+	LoadHeader:            "x-inference-load",
+	MaxQueueDepth:         10,
+	MaxLoadPercent:        90,
+	ReportTTLMilliseconds: 10000,
+}
+
+func TestParseLoadPercent(t *testing.T) {
+	for _, tc := range []struct {
+		in  string
+		exp float64
+	}{
+		{in: "0.5", exp: 50},
+		{in: " 1 ", exp: 100},
+		{in: "85%", exp: 85},
+		{in: "12.5 %", exp: 12.5},
+		{in: "", exp: -1},
+		{in: "-0.1", exp: -1},
+		{in: "NaN", exp: -1},
+		{in: "high", exp: -1},
+	} {
+		t.Run(tc.in, func(t *testing.T) {
+			require.Equal(t, tc.exp, parseLoadPercent(tc.in))
+		})
+	}
+}
+
+func TestBackendLoad(t *testing.T) {
+	b := newBackendLoad()
+	now := time.Now()
+	b.now = func() time.Time { return now }
+	const backend = "default/vllm/route/route/rule/0/ref/0"
+
+	_, overloaded := b.overloaded(backend, "", testLoadReporting)
+	require.False(t, overloaded)
+
+	// The reports of the backend are shared by the routes referencing it.
+	b.record("default/vllm/route/other/rule/1/ref/0", "", testLoadReporting, map[string]string{"x-queue-depth": "10"})
+	left, overloaded := b.overloaded(backend, "", testLoadReporting)
+	require.True(t, overloaded)
+	require.Equal(t, 10*time.Second, left)
+
+	// The responses without a report keep the previous one.
+	b.record(backend, "", testLoadReporting, map[string]string{"x-queue-depth": "invalid"})
+	now = now.Add(4 * time.Second)
+	left, overloaded = b.overloaded(backend, "", testLoadReporting)
+	require.True(t, overloaded)
+	require.Equal(t, 6*time.Second, left)
+
+	b.record(backend, "", testLoadReporting, map[string]string{"x-queue-depth": "9", "x-inference-load": "0.5"})
+	_, overloaded = b.overloaded(backend, "", testLoadReporting)
+	require.False(t, overloaded)
+	b.record(backend, "", testLoadReporting, map[string]string{"x-inference-load": "0.95"})
+	_, overloaded = b.overloaded(backend, "", testLoadReporting)
+	require.True(t, overloaded)
+	// A threshold is disabled when unset.
+	_, overloaded = b.overloaded(backend, "", &filterapi.BackendLoadReporting{MaxQueueDepth: 10, ReportTTLMilliseconds: 10000})
+	require.False(t, overloaded)
+
+	// The expired report is ignored.
+	now = now.Add(10 * time.Second)
+	_, overloaded = b.overloaded(backend, "", testLoadReporting)
+	require.False(t, overloaded)
+}
+
+func TestBackendLoad_upstreamHosts(t *testing.T) {
+	b := newBackendLoad()
+	const backend = "default/vllm/route/route/rule/0/ref/0"
+
+	// Only the endpoint reporting the load is shed, not the other endpoints of the backend.
+	b.record(backend, "10.0.0.1:8000", testLoadReporting, map[string]string{"x-queue-depth": "12"})
+	b.record(backend, "10.0.0.2:8000", testLoadReporting, map[string]string{"x-queue-depth": "1"})
+	_, overloaded := b.overloaded(backend, "10.0.0.1:8000", testLoadReporting)
+	require.True(t, overloaded)
+	_, overloaded = b.overloaded(backend, "10.0.0.2:8000", testLoadReporting)
+	require.False(t, overloaded)
+	_, overloaded = b.overloaded(backend, "10.0.0.3:8000", testLoadReporting)
+	require.False(t, overloaded)
+	// The reports of the endpoints are not shared with the requests whose upstream host is unknown.
+	_, overloaded = b.overloaded(backend, "", testLoadReporting)
+	require.False(t, overloaded)
+
+	b.record(backend, "10.0.0.1:8000", testLoadReporting, map[string]string{"x-queue-depth": "2"})
+	_, overloaded = b.overloaded(backend, "10.0.0.1:8000", testLoadReporting)
+	require.False(t, overloaded)
+}
+
+type fakeBackendLoadProcessor struct {
+	passThroughProcessor
+	upstreamHost string
+	config       *filterapi.BackendLoadReporting
+}
+
+func (f fakeBackendLoadProcessor) backendLoadTarget() (string, string, *filterapi.BackendLoadReporting) {
+	return "default/vllm/route/route/rule/0/ref/0", f.upstreamHost, f.config
+}
+
+func TestServer_shedOverloadedBackend(t *testing.T) {
+	s, err := NewServer(slog.Default(), false)
+	require.NoError(t, err)
+	ctx := context.WithValue(t.Context(), loggerContextKey, slog.Default())
+	p := fakeBackendLoadProcessor{upstreamHost: "10.0.0.1:8000", config: testLoadReporting}
+	headersReq := &extprocv3.ProcessingRequest{Request: &extprocv3.ProcessingRequest_RequestHeaders{RequestHeaders: &extprocv3.HttpHeaders{}}}
+	accepted := &extprocv3.ProcessingResponse{Response: &extprocv3.ProcessingResponse_RequestHeaders{}}
+	responseHeadersReq := func(headers ...*corev3.HeaderValue) *extprocv3.ProcessingRequest {
+		return &extprocv3.ProcessingRequest{Request: &extprocv3.ProcessingRequest_ResponseHeaders{
+			ResponseHeaders: &extprocv3.HttpHeaders{Headers: &corev3.HeaderMap{Headers: headers}},
+		}}
+	}
+
+	require.Equal(t, accepted, s.shedOverloadedBackend(ctx, p, headersReq, accepted))
+	s.recordBackendLoad(p, responseHeadersReq(&corev3.HeaderValue{Key: "x-queue-depth", RawValue: []byte("12")}))
+
+	// The processors without the load reporting and the rejected requests are not shed.
+	require.Equal(t, accepted, s.shedOverloadedBackend(ctx, passThroughProcessor{}, headersReq, accepted))
+	require.Equal(t, accepted, s.shedOverloadedBackend(ctx, fakeBackendLoadProcessor{}, headersReq, accepted))
+	rejected := createUserFacingErrorResponse(400, "BadRequest", "bad")
+	require.Equal(t, rejected, s.shedOverloadedBackend(ctx, p, headersReq, rejected))
+
+	resp := s.shedOverloadedBackend(ctx, p, headersReq, accepted)
+	require.Equal(t, typev3.StatusCode_ServiceUnavailable, resp.GetImmediateResponse().GetStatus().GetCode())
+	require.JSONEq(t, `{"type":"error","error":{"type":"ServiceUnavailable","code":"503","message":"backend default/vllm is overloaded"}}`,
+		string(resp.GetImmediateResponse().GetBody()))
+	headers := map[string]string{}
+	for _, h := range resp.GetImmediateResponse().GetHeaders().GetSetHeaders() {
+		headers[h.GetHeader().GetKey()] = string(h.GetHeader().GetRawValue())
+	}
+	require.Equal(t, "true", headers[internalapi.BackendOverloadedHeader])
+	require.Equal(t, "10", headers["retry-after"])
+	// The other endpoints of the backend are not shed.
+	other := fakeBackendLoadProcessor{upstreamHost: "10.0.0.2:8000", config: testLoadReporting}
+	require.Equal(t, accepted, s.shedOverloadedBackend(ctx, other, headersReq, accepted))
+
+	// The backend is admitted again once it reports a load below the thresholds.
+	s.recordBackendLoad(p, responseHeadersReq(&corev3.HeaderValue{Key: "x-queue-depth", RawValue: []byte("1")}))
+	require.Equal(t, accepted, s.shedOverloadedBackend(ctx, p, headersReq, accepted))
+}
@@ -0,0 +1,66 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+
+	"github.com/envoyproxy/ai-gateway/internal/filterapi"
+)
+
+// verifyBackendOverrideSignature verifies the signature of the request pinning the given backend, which is
+// "<expiry>:<hex>" where expiry is a Unix time in seconds and hex is the hex-encoded HMAC-SHA256 of
+// "<backend>:<expiry>" with the key. The signature is read from the given header. The returned error is meant to be
+// returned to the client.
+func verifyBackendOverrideSignature(key, backend, header, signature string, now time.Time) error {
+	if key == "" {
+		return errors.New("backend override is not available on this route")
+	}
+	if signature == "" {
+		return fmt.Errorf("header %s is required to pin a backend", header)
+	}
+	expiry, mac, ok := strings.Cut(signature, ":")
+	if !ok {
+		return fmt.Errorf("header %s must be <expiry>:<hex>", header)
+	}
+	expiresAt, err := strconv.ParseInt(expiry, 10, 64)
+	if err != nil {
+		return fmt.Errorf("header %s has an invalid expiry", header)
+	}
+	if now.Unix() > expiresAt {
+		return fmt.Errorf("header %s has expired", header)
+	}
+	got, err := hex.DecodeString(mac)
+	if err != nil || !hmac.Equal(got, backendOverrideMAC(key, backend, expiry)) {
+		return fmt.Errorf("header %s is invalid", header)
+	}
+	return nil
+}
+
+// backendOverrideMAC returns the HMAC-SHA256 of "<backend>:<expiry>" with the key.
+func backendOverrideMAC(key, backend, expiry string) []byte {
+	h := hmac.New(sha256.New, []byte(key))
+	_, _ = h.Write([]byte(backend + ":" + expiry))
+	return h.Sum(nil)
+}
+
+// removeBackendOverrideHeaders removes the headers pinning the backend from the request sent to the backend.
+func removeBackendOverrideHeaders(config *filterapi.RuntimeConfig, headerMutation *extprocv3.HeaderMutation, requestHeaders map[string]string) {
+	for _, h := range []string{selectedBackendHeaderKey(config), selectedBackendSignatureHeaderKey(config)} {
+		if _, ok := requestHeaders[h]; ok {
+			headerMutation.RemoveHeaders = append(headerMutation.RemoveHeaders, h)
+		}
+	}
+}
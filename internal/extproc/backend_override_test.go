@@ -0,0 +1,162 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"encoding/hex"
+	"strconv"
+	"testing"
+	"time"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/internal/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+)
+
+// signBackendOverride returns the signature of the request pinning the backend until expiry.
+func signBackendOverride(key, backend string, expiry time.Time) string {
+	e := strconv.FormatInt(expiry.Unix(), 10)
+	return e + ":" + hex.EncodeToString(backendOverrideMAC(key, backend, e))
+}
+
+func TestVerifyBackendOverrideSignature(t *testing.T) {
+	now := time.Unix(1735689600, 0)
+	valid := signBackendOverride("key", "openai", now.Add(time.Minute))
+	for _, tc := range []struct {
+		name      string
+		key       string
+		backend   string
+		signature string
+		expErr    string
+	}{
+		{name: "valid", key: "key", backend: "openai", signature: valid},
+		{
+			name: "valid until expiry", key: "key", backend: "openai",
+			signature: signBackendOverride("key", "openai", now),
+		},
+		{
+			name: "no key", backend: "openai", signature: valid,
+			expErr: "backend override is not available on this route",
+		},
+		{
+			name: "missing", key: "key", backend: "openai",
+			expErr: "header x-aigw-backend-signature is required to pin a backend",
+		},
+		{
+			name: "malformed", key: "key", backend: "openai", signature: "abc",
+			expErr: "header x-aigw-backend-signature must be <expiry>:<hex>",
+		},
+		{
+			name: "invalid expiry", key: "key", backend: "openai", signature: "soon:abc",
+			expErr: "header x-aigw-backend-signature has an invalid expiry",
+		},
+		{
+			name: "expired", key: "key", backend: "openai",
+			signature: signBackendOverride("key", "openai", now.Add(-time.Second)),
+			expErr:    "header x-aigw-backend-signature has expired",
+		},
+		{
+			name: "other backend", key: "key", backend: "bedrock", signature: valid,
+			expErr: "header x-aigw-backend-signature is invalid",
+		},
+		{
+			name: "other key", key: "other", backend: "openai", signature: valid,
+			expErr: "header x-aigw-backend-signature is invalid",
+		},
+		{
+			name: "not hex", key: "key", backend: "openai", signature: "1735689660:xyz",
+			expErr: "header x-aigw-backend-signature is invalid",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := verifyBackendOverrideSignature(tc.key, tc.backend, internalapi.BackendOverrideSignatureHeader, tc.signature, now)
+			if tc.expErr != "" {
+				require.EqualError(t, err, tc.expErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestRemoveBackendOverrideHeaders(t *testing.T) {
+	headerMutation := &extprocv3.HeaderMutation{}
+	removeBackendOverrideHeaders(nil, headerMutation, map[string]string{internalapi.BackendOverrideHeader: "openai"})
+	require.Equal(t, []string{internalapi.BackendOverrideHeader}, headerMutation.RemoveHeaders)
+}
+
+func Test_chatCompletionProcessorUpstreamFilter_verifyBackendOverride(t *testing.T) {
+	config := &filterapi.RuntimeConfig{BackendOverrides: map[string]*filterapi.BackendOverride{
+		"team-a/route": {RouteName: "team-a/route", HMACKey: "key"},
+	}}
+	expiresAt := time.Now().Add(time.Minute)
+	signature := signBackendOverride("key", "team-a/openai", expiresAt)
+	for _, tc := range []struct {
+		name      string
+		routeName string
+		headers   map[string]string
+		expStatus typev3.StatusCode
+		expBody   string
+	}{
+		{name: "not pinned", routeName: "team-a/route", headers: map[string]string{}},
+		{
+			name:      "validly signed",
+			routeName: "team-a/route",
+			headers:   map[string]string{internalapi.BackendOverrideHeader: "team-a/openai", internalapi.BackendOverrideSignatureHeader: signature},
+		},
+		{name: "route without override", routeName: "team-a/other", headers: map[string]string{internalapi.BackendOverrideHeader: "team-a/openai"}},
+		{
+			name:      "missing signature",
+			routeName: "team-a/route",
+			headers:   map[string]string{internalapi.BackendOverrideHeader: "team-a/openai"},
+			expStatus: typev3.StatusCode_Forbidden,
+		},
+		{
+			// The signature of a backend doesn't pin the backend of the same name in another namespace.
+			name:      "signature of another namespace",
+			routeName: "team-a/route",
+			headers:   map[string]string{internalapi.BackendOverrideHeader: "team-b/openai", internalapi.BackendOverrideSignatureHeader: signature},
+			expStatus: typev3.StatusCode_Forbidden,
+		},
+		{
+			name:      "backend of another namespace",
+			routeName: "team-a/route",
+			headers: map[string]string{
+				internalapi.BackendOverrideHeader:          "team-b/openai",
+				internalapi.BackendOverrideSignatureHeader: signBackendOverride("key", "team-b/openai", expiresAt),
+			},
+			expStatus: typev3.StatusCode_BadRequest,
+			expBody:   "backend team-b/openai of header x-aigw-backend is not a backend of this route",
+		},
+		{
+			name:      "backend without namespace",
+			routeName: "team-a/route",
+			headers: map[string]string{
+				internalapi.BackendOverrideHeader:          "openai",
+				internalapi.BackendOverrideSignatureHeader: signBackendOverride("key", "openai", expiresAt),
+			},
+			expStatus: typev3.StatusCode_BadRequest,
+			expBody:   "backend openai of header x-aigw-backend is not a backend of this route",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			p := newTestUpstreamFilter(t, config, tc.routeName, "")
+			p.backendName = internalapi.PerRouteRuleRefBackendName("team-a", "openai", "route", 0, 1)
+			p.backendOverrideName = "team-a/openai"
+			p.requestHeaders = tc.headers
+			res := p.verifyBackendOverride()
+			if tc.expStatus == 0 {
+				require.Nil(t, res)
+				return
+			}
+			require.Equal(t, tc.expStatus, res.GetImmediateResponse().GetStatus().GetCode())
+			require.Contains(t, string(res.GetImmediateResponse().GetBody()), tc.expBody)
+		})
+	}
+}
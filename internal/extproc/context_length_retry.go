@@ -0,0 +1,255 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3http "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ext_proc/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+	"github.com/envoyproxy/ai-gateway/internal/tracing/tracingapi"
+)
+
+const (
+	// contextLengthRetryEvent is the span event recorded when a request exceeding the context length of the model
+	// is retried, and contextLengthRetryAttributeStrategy, contextLengthRetryAttributeBackend and
+	// contextLengthRetryAttributeDroppedMessages are its attributes.
+	contextLengthRetryEvent                    = "context_length_retry"
+	contextLengthRetryAttributeStrategy        = "context_length_retry.strategy"
+	contextLengthRetryAttributeBackend         = "context_length_retry.backend"
+	contextLengthRetryAttributeDroppedMessages = "context_length_retry.dropped_messages"
+	// maxContextLengthErrorSize is the maximum size of the error responses read to detect a context length error.
+	maxContextLengthErrorSize = 64 << 10
+)
+
+// contextLengthErrorMessages are the lower-cased fragments of the error responses of the providers rejecting a
+// request for exceeding the context length of the model.
+var contextLengthErrorMessages = [][]byte{
+	[]byte("context_length_exceeded"),               // OpenAI and Azure OpenAI error code.
+	[]byte("maximum context length"),                // OpenAI and vLLM.
+	[]byte("context window"),                        // OpenAI Responses and Mistral.
+	[]byte("prompt is too long"),                    // Anthropic.
+	[]byte("input is too long"),                     // AWS Bedrock.
+	[]byte("too many input tokens"),                 // AWS Bedrock.
+	[]byte("exceeds the maximum number of tokens"),  // Gemini.
+	[]byte("maximum number of tokens allowed"),      // Gemini.
+	[]byte("reduce the length of the messages"),     // OpenAI compatible servers.
+	[]byte("too many tokens"),                       // Cohere.
+	[]byte("input length exceeds the context size"), // Ollama compatible servers.
+}
+
+// isContextLengthExceededError returns true if the response with the status code and the body is a provider
+// rejecting the request for exceeding the context length of the model.
+func isContextLengthExceededError(statusCode int, body []byte) bool {
+	if statusCode != 400 && statusCode != 413 {
+		return false
+	}
+	body = bytes.ToLower(body)
+	for _, m := range contextLengthErrorMessages {
+		if bytes.Contains(body, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// dropOldestMessages drops the oldest half of the messages of the chat completion request body. The system and
+// developer messages and the last message are kept, as well as the order of the kept messages. The tool messages
+// left at the start of the conversation are also dropped since the assistant message calling the tools is gone.
+//
+// This returns the new body and the number of dropped messages, which is zero if nothing can be dropped.
+func dropOldestMessages(body []byte) ([]byte, int, error) {
+	messages := gjson.GetBytes(body, "messages").Array()
+	var conversation []int
+	for i, m := range messages {
+		if role := m.Get("role").String(); role != openai.ChatMessageRoleSystem && role != openai.ChatMessageRoleDeveloper {
+			conversation = append(conversation, i)
+		}
+	}
+	if len(conversation) < 2 {
+		return nil, 0, nil
+	}
+	drop := len(conversation) / 2
+	for drop < len(conversation)-1 && messages[conversation[drop]].Get("role").String() == openai.ChatMessageRoleTool {
+		drop++
+	}
+	dropped := make(map[int]struct{}, drop)
+	for _, i := range conversation[:drop] {
+		dropped[i] = struct{}{}
+	}
+
+	kept := []byte{'['}
+	for i, m := range messages {
+		if _, ok := dropped[i]; ok {
+			continue
+		}
+		if len(kept) > 1 {
+			kept = append(kept, ',')
+		}
+		kept = append(kept, m.Raw...)
+	}
+	kept = append(kept, ']')
+	out, err := sjson.SetRawBytes(body, "messages", kept)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to set the messages: %w", err)
+	}
+	return out, drop, nil
+}
+
+// contextLengthRetryProcessor is implemented by the upstream processors checking the responses of the backends
+// for the context length errors. The upstream filter only receives the responses when such a check is requested
+// by the processing mode returned on the request headers.
+type contextLengthRetryProcessor interface {
+	// checkContextLengthResponseHeaders processes the response headers received by the upstream filter.
+	checkContextLengthResponseHeaders(headers *corev3.HeaderMap) *extprocv3.ProcessingResponse
+	// checkContextLengthResponseBody processes the buffered response body received by the upstream filter.
+	checkContextLengthResponseBody(ctx context.Context, body *extprocv3.HttpBody) (*extprocv3.ProcessingResponse, error)
+}
+
+// processUpstreamResponse processes the response messages received by the upstream filter, which only happens
+// when the processor checks the response for a context length error. The other processors let the response
+// continue unmodified.
+func processUpstreamResponse(ctx context.Context, p Processor, req *extprocv3.ProcessingRequest) (*extprocv3.ProcessingResponse, error) {
+	c, ok := p.(contextLengthRetryProcessor)
+	switch value := req.Request.(type) {
+	case *extprocv3.ProcessingRequest_ResponseHeaders:
+		if ok {
+			return c.checkContextLengthResponseHeaders(value.ResponseHeaders.Headers), nil
+		}
+		return &extprocv3.ProcessingResponse{Response: &extprocv3.ProcessingResponse_ResponseHeaders{}}, nil
+	case *extprocv3.ProcessingRequest_ResponseBody:
+		if ok {
+			return c.checkContextLengthResponseBody(ctx, value.ResponseBody)
+		}
+		return &extprocv3.ProcessingResponse{Response: &extprocv3.ProcessingResponse_ResponseBody{}}, nil
+	default:
+		return nil, fmt.Errorf("unexpected upstream filter response message: %T", value)
+	}
+}
+
+// contextLengthCheckMode returns the processing mode making Envoy send the response of this attempt to the
+// upstream filter so that it is checked for a context length error, or nil if it isn't checked. The request is
+// only retried once, and the oldest messages can only be dropped from the chat completion requests.
+func (u *upstreamProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) contextLengthCheckMode() *extprocv3http.ProcessingMode {
+	if u.contextLengthRetry == "" || u.parent.contextLengthRetry != "" {
+		return nil
+	}
+	if _, isChat := any(u.parent.originalRequestBody).(*openai.ChatCompletionRequest); !isChat &&
+		u.contextLengthRetry == filterapi.ContextLengthRetryStrategyDropOldestMessages {
+		return nil
+	}
+	return &extprocv3http.ProcessingMode{
+		ResponseHeaderMode: extprocv3http.ProcessingMode_SEND,
+		ResponseBodyMode:   extprocv3http.ProcessingMode_BUFFERED,
+	}
+}
+
+// checkContextLengthResponseHeaders implements [contextLengthRetryProcessor.checkContextLengthResponseHeaders].
+//
+// Only the bodies of the error responses which can be context length errors are buffered.
+func (u *upstreamProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) checkContextLengthResponseHeaders(headers *corev3.HeaderMap) *extprocv3.ProcessingResponse {
+	u.contextLengthCheckHeaders = headersToMap(headers)
+	var mode *extprocv3http.ProcessingMode
+	if code, _ := strconv.Atoi(u.contextLengthCheckHeaders[":status"]); code != 400 && code != 413 {
+		mode = &extprocv3http.ProcessingMode{ResponseBodyMode: extprocv3http.ProcessingMode_NONE}
+	}
+	return &extprocv3.ProcessingResponse{
+		Response:     &extprocv3.ProcessingResponse_ResponseHeaders{ResponseHeaders: &extprocv3.HeadersResponse{}},
+		ModeOverride: mode,
+	}
+}
+
+// checkContextLengthResponseBody implements [contextLengthRetryProcessor.checkContextLengthResponseBody].
+//
+// When the response is a context length error, the retry strategy is recorded on the router processor so that
+// the retry uses it, and the ContextLengthExceededHeader is set on the response, which makes Envoy retry it.
+func (u *upstreamProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) checkContextLengthResponseBody(_ context.Context, body *extprocv3.HttpBody) (*extprocv3.ProcessingResponse, error) {
+	resp := &extprocv3.ProcessingResponse{
+		Response: &extprocv3.ProcessingResponse_ResponseBody{ResponseBody: &extprocv3.BodyResponse{}},
+	}
+	code, _ := strconv.Atoi(u.contextLengthCheckHeaders[":status"])
+	// The responses failing to be decoded are passed through as is.
+	decodingResult, err := decodeContentIfNeeded(body.Body, u.contextLengthCheckHeaders["content-encoding"])
+	if err != nil {
+		return resp, nil
+	}
+	decoded, err := io.ReadAll(io.LimitReader(decodingResult.reader, maxContextLengthErrorSize))
+	if err != nil || !isContextLengthExceededError(code, decoded) {
+		return resp, nil
+	}
+
+	rp := u.parent
+	attrs := []attribute.KeyValue{
+		attribute.String(contextLengthRetryAttributeStrategy, string(u.contextLengthRetry)),
+		attribute.String(contextLengthRetryAttributeBackend, u.backendName),
+	}
+	if u.contextLengthRetry == filterapi.ContextLengthRetryStrategyDropOldestMessages {
+		var newBody []byte
+		var dropped int
+		newBody, dropped, err = dropOldestMessages(rp.originalRequestBodyRaw)
+		if err != nil {
+			return nil, err
+		}
+		if dropped == 0 {
+			return resp, nil
+		}
+		// The original body already includes the usage in the streaming responses if the costs are configured.
+		var req *ReqT
+		_, req, _, _, err = rp.eh.ParseBody(newBody, false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the request body with the oldest messages dropped: %w", err)
+		}
+		rp.originalRequestBodyRaw, rp.originalRequestBody = newBody, req
+		rp.forceBodyMutation = true
+		attrs = append(attrs, attribute.Int(contextLengthRetryAttributeDroppedMessages, dropped))
+	}
+	rp.contextLengthRetry = u.contextLengthRetry
+	rp.contextLengthRetryAttempt = rp.upstreamFilterCount
+	if recorder, ok := rp.span.(tracingapi.SpanEventRecorder); ok {
+		recorder.AddEvent(contextLengthRetryEvent, attrs...)
+	}
+	u.logger.Info("retrying request exceeding the context length of the model",
+		slog.String("strategy", string(u.contextLengthRetry)), slog.String("backend", u.backendName))
+
+	resp.GetResponseBody().Response = &extprocv3.CommonResponse{
+		HeaderMutation: &extprocv3.HeaderMutation{
+			SetHeaders: []*corev3.HeaderValueOption{{
+				AppendAction: corev3.HeaderValueOption_OVERWRITE_IF_EXISTS_OR_ADD,
+				Header:       &corev3.HeaderValue{Key: internalapi.ContextLengthExceededHeader, RawValue: []byte("true")},
+			}},
+		},
+	}
+	return resp, nil
+}
+
+// setContextLengthRetryHeader sets the ContextLengthRetryHeader on the response of the request retried after a
+// context length error. The ContextLengthExceededHeader is removed in case Envoy didn't retry the request.
+func (u *upstreamProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) setContextLengthRetryHeader(headerMutation *extprocv3.HeaderMutation) {
+	rp := u.parent
+	if rp.contextLengthRetry == "" {
+		return
+	}
+	headerMutation.RemoveHeaders = append(headerMutation.RemoveHeaders, internalapi.ContextLengthExceededHeader)
+	if rp.upstreamFilterCount > rp.contextLengthRetryAttempt {
+		headerMutation.SetHeaders = append(headerMutation.SetHeaders, &corev3.HeaderValueOption{
+			AppendAction: corev3.HeaderValueOption_OVERWRITE_IF_EXISTS_OR_ADD,
+			Header:       &corev3.HeaderValue{Key: internalapi.ContextLengthRetryHeader, RawValue: []byte(rp.contextLengthRetry)},
+		})
+	}
+}
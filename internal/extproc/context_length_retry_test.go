@@ -0,0 +1,200 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3http "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ext_proc/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+	"github.com/envoyproxy/ai-gateway/internal/testing/testotel"
+)
+
+func TestIsContextLengthExceededError(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		code int
+		body string
+		exp  bool
+	}{
+		{
+			name: "openai",
+			code: 400,
+			body: `{"error":{"message":"This model's maximum context length is 128000 tokens.","code":"context_length_exceeded"}}`,
+			exp:  true,
+		},
+		{
+			name: "anthropic",
+			code: 400,
+			body: `{"type":"error","error":{"type":"invalid_request_error","message":"prompt is too long: 210000 tokens > 200000 maximum"}}`,
+			exp:  true,
+		},
+		{
+			name: "bedrock",
+			code: 400,
+			body: `{"message":"Input is too long for requested model."}`,
+			exp:  true,
+		},
+		{
+			name: "gemini",
+			code: 400,
+			body: `{"error":{"message":"The input token count (1200000) exceeds the maximum number of tokens allowed (1048576)."}}`,
+			exp:  true,
+		},
+		{
+			name: "payload too large",
+			code: 413,
+			body: `{"error":{"message":"Request exceeds the context window of the model"}}`,
+			exp:  true,
+		},
+		{name: "other bad request", code: 400, body: `{"error":{"message":"invalid temperature"}}`},
+		{name: "server error", code: 500, body: `{"error":{"code":"context_length_exceeded"}}`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.exp, isContextLengthExceededError(tc.code, []byte(tc.body)))
+		})
+	}
+}
+
+func TestDropOldestMessages(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		body       string
+		exp        string
+		expDropped int
+	}{
+		{
+			name: "conversation",
+			body: `{"model":"m","messages":[{"role":"system","content":"s"},{"role":"user","content":"1"},{"role":"assistant","content":"2"},{"role":"user","content":"3"},{"role":"assistant","content":"4"},{"role":"user","content":"5"}]}`,
+			exp:  `{"model":"m","messages":[{"role":"system","content":"s"},{"role":"user","content":"3"},{"role":"assistant","content":"4"},{"role":"user","content":"5"}]}`,
+
+			expDropped: 2,
+		},
+		{
+			name: "orphaned tool messages",
+			body: `{"messages":[{"role":"user","content":"1"},{"role":"assistant","tool_calls":[{"id":"a"},{"id":"b"}]},{"role":"tool","content":"a"},{"role":"tool","content":"b"},{"role":"developer","content":"d"},{"role":"user","content":"2"}]}`,
+			exp:  `{"messages":[{"role":"developer","content":"d"},{"role":"user","content":"2"}]}`,
+
+			expDropped: 4,
+		},
+		{
+			name: "single message",
+			body: `{"messages":[{"role":"system","content":"s"},{"role":"user","content":"1"}]}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			body, dropped, err := dropOldestMessages([]byte(tc.body))
+			require.NoError(t, err)
+			require.Equal(t, tc.expDropped, dropped)
+			if tc.exp == "" {
+				require.Nil(t, body)
+				return
+			}
+			require.JSONEq(t, tc.exp, string(body))
+		})
+	}
+}
+
+func Test_chatCompletionProcessorUpstreamFilter_contextLengthRetry(t *testing.T) {
+	const body = `{"model":"m","messages":[{"role":"user","content":"1"},{"role":"assistant","content":"2"},{"role":"user","content":"3"}]}`
+	newProcessors := func(strategy filterapi.ContextLengthRetryStrategy) (*chatCompletionProcessorRouterFilter, *chatCompletionProcessorUpstreamFilter) {
+		r := &chatCompletionProcessorRouterFilter{
+			originalRequestBodyRaw: []byte(body),
+			originalRequestBody:    &openai.ChatCompletionRequest{Model: "m"},
+			span:                   &testotel.MockSpan{},
+			upstreamFilterCount:    1,
+		}
+		return r, &chatCompletionProcessorUpstreamFilter{parent: r, logger: slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{})), backendName: "small", contextLengthRetry: strategy}
+	}
+	errorResponse := func(p *chatCompletionProcessorUpstreamFilter, status, errBody string) *extprocv3.ProcessingResponse {
+		res := p.checkContextLengthResponseHeaders(&corev3.HeaderMap{Headers: []*corev3.HeaderValue{{Key: ":status", RawValue: []byte(status)}}})
+		require.Nil(t, res.ModeOverride)
+		res, err := p.checkContextLengthResponseBody(t.Context(), &extprocv3.HttpBody{Body: []byte(errBody), EndOfStream: true})
+		require.NoError(t, err)
+		return res
+	}
+	const exceeded = `{"error":{"code":"context_length_exceeded"}}`
+
+	t.Run("disabled", func(t *testing.T) {
+		_, p := newProcessors("")
+		require.Nil(t, p.contextLengthCheckMode())
+	})
+
+	t.Run("successful response", func(t *testing.T) {
+		_, p := newProcessors(filterapi.ContextLengthRetryStrategyFallback)
+		require.Equal(t, extprocv3http.ProcessingMode_BUFFERED, p.contextLengthCheckMode().GetResponseBodyMode())
+		res := p.checkContextLengthResponseHeaders(&corev3.HeaderMap{Headers: []*corev3.HeaderValue{{Key: ":status", RawValue: []byte("200")}}})
+		require.Equal(t, extprocv3http.ProcessingMode_NONE, res.ModeOverride.GetResponseBodyMode())
+	})
+
+	t.Run("other error", func(t *testing.T) {
+		r, p := newProcessors(filterapi.ContextLengthRetryStrategyFallback)
+		res := errorResponse(p, "400", `{"error":{"message":"invalid temperature"}}`)
+		require.Nil(t, res.GetResponseBody().GetResponse())
+		require.Empty(t, r.contextLengthRetry)
+	})
+
+	t.Run("fallback", func(t *testing.T) {
+		r, p := newProcessors(filterapi.ContextLengthRetryStrategyFallback)
+		res := errorResponse(p, "400", exceeded)
+		require.Equal(t, internalapi.ContextLengthExceededHeader, res.GetResponseBody().GetResponse().GetHeaderMutation().GetSetHeaders()[0].GetHeader().GetKey())
+		require.Equal(t, filterapi.ContextLengthRetryStrategyFallback, r.contextLengthRetry)
+		require.Equal(t, []byte(body), r.originalRequestBodyRaw)
+		require.Equal(t, []testotel.MockSpanEvent{{
+			Name: contextLengthRetryEvent,
+			Attributes: []attribute.KeyValue{
+				attribute.String(contextLengthRetryAttributeStrategy, "Fallback"),
+				attribute.String(contextLengthRetryAttributeBackend, "small"),
+			},
+		}}, r.span.(*testotel.MockSpan).Events)
+
+		// The retry isn't checked again, and its response has the strategy set.
+		r.upstreamFilterCount++
+		retry := &chatCompletionProcessorUpstreamFilter{parent: r, contextLengthRetry: filterapi.ContextLengthRetryStrategyFallback}
+		require.Nil(t, retry.contextLengthCheckMode())
+		headerMutation := &extprocv3.HeaderMutation{}
+		retry.setContextLengthRetryHeader(headerMutation)
+		require.Equal(t, []string{internalapi.ContextLengthExceededHeader}, headerMutation.RemoveHeaders)
+		require.Equal(t, internalapi.ContextLengthRetryHeader, headerMutation.SetHeaders[0].Header.Key)
+		require.Equal(t, []byte("Fallback"), headerMutation.SetHeaders[0].Header.RawValue)
+	})
+
+	t.Run("drop oldest messages", func(t *testing.T) {
+		r, p := newProcessors(filterapi.ContextLengthRetryStrategyDropOldestMessages)
+		res := errorResponse(p, "413", exceeded)
+		require.NotNil(t, res.GetResponseBody().GetResponse().GetHeaderMutation())
+		require.Equal(t, filterapi.ContextLengthRetryStrategyDropOldestMessages, r.contextLengthRetry)
+		require.JSONEq(t, `{"model":"m","messages":[{"role":"assistant","content":"2"},{"role":"user","content":"3"}]}`, string(r.originalRequestBodyRaw))
+		require.Len(t, r.originalRequestBody.Messages, 2)
+		require.True(t, r.forceBodyMutation)
+		require.Contains(t, r.span.(*testotel.MockSpan).Events[0].Attributes, attribute.Int(contextLengthRetryAttributeDroppedMessages, 1))
+
+		// The response of the same attempt doesn't have the strategy set if Envoy doesn't retry it.
+		headerMutation := &extprocv3.HeaderMutation{}
+		p.setContextLengthRetryHeader(headerMutation)
+		require.Equal(t, []string{internalapi.ContextLengthExceededHeader}, headerMutation.RemoveHeaders)
+		require.Empty(t, headerMutation.SetHeaders)
+	})
+
+	t.Run("drop oldest messages of other endpoints", func(t *testing.T) {
+		p := &messagesProcessorUpstreamFilter{
+			parent:             &messagesProcessorRouterFilter{},
+			contextLengthRetry: filterapi.ContextLengthRetryStrategyDropOldestMessages,
+		}
+		require.Nil(t, p.contextLengthCheckMode())
+		p.contextLengthRetry = filterapi.ContextLengthRetryStrategyFallback
+		require.NotNil(t, p.contextLengthCheckMode())
+	})
+}
@@ -0,0 +1,76 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/tidwall/gjson"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/anthropic"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+// contextLengthExceededErrorCode is the code of the OpenAI error rejecting the requests exceeding the context window
+// of the model.
+const contextLengthExceededErrorCode = "context_length_exceeded"
+
+// estimateInputTokens estimates the input tokens of the JSON request body from the sections of the body sent to the
+// model as input, with the same estimate as the history policy. This also returns the name of the parameter holding
+// the input to report in the error. The requests to the other endpoints are not estimated and return zero.
+func estimateInputTokens(req any, body []byte) (tokens int, param string) {
+	var paths []string
+	switch req.(type) {
+	case *openai.ChatCompletionRequest:
+		paths, param = []string{"messages", "tools"}, "messages"
+	case *anthropic.MessagesRequest:
+		paths, param = []string{"system", "messages", "tools"}, "messages"
+	case *openai.CompletionRequest:
+		paths, param = []string{"prompt"}, "prompt"
+	case *openai.ResponseRequest:
+		paths, param = []string{"instructions", "input", "tools"}, "input"
+	case *openai.EmbeddingRequest:
+		paths, param = []string{"input", "messages"}, "input"
+	default:
+		return 0, ""
+	}
+	for _, section := range gjson.GetManyBytes(body, paths...) {
+		if section.Exists() {
+			tokens += estimateTokens(section.Raw)
+		}
+	}
+	return tokens, param
+}
+
+// checkContextWindow returns the response rejecting the request whose estimated input tokens exceed the context
+// window in the route of the model sent to the backend, i.e. the model name override of the backend if any or the
+// requested model, or nil if the request can proceed. This is checked after the history policy, so that the
+// conversations elided within the window are not rejected.
+func (u *upstreamProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) checkContextWindow(ctx context.Context) (*extprocv3.ProcessingResponse, error) {
+	rp := u.parent
+	if rp.config == nil {
+		return nil, nil
+	}
+	model := cmp.Or(u.modelNameOverride, rp.originalModel)
+	window, ok := rp.config.ContextWindows[u.routeName][model]
+	if !ok {
+		return nil, nil
+	}
+	tokens, param := estimateInputTokens(rp.originalRequestBody, rp.originalRequestBodyRaw)
+	if tokens <= window {
+		return nil, nil
+	}
+	u.metrics.RecordContextLengthExceeded(ctx, u.requestHeaders)
+	u.logger.Info("rejecting request exceeding the context window of the model",
+		slog.String("model", model), slog.Int("context_window", window), slog.Int("estimated_tokens", tokens))
+	return invalidRequestErrorResponse(contextLengthExceededErrorCode,
+		fmt.Sprintf("This model's maximum context length is %d tokens. However, your %s resulted in an estimated %d "+
+			"tokens. Please reduce the length of the %s.", window, param, tokens, param), param)
+}
@@ -0,0 +1,94 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/anthropic"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/filterapi"
+)
+
+func TestEstimateInputTokens(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		req       any
+		body      string
+		expTokens int
+		expParam  string
+	}{
+		{
+			name:      "chat",
+			req:       &openai.ChatCompletionRequest{},
+			body:      `{"model":"m","messages":[{"role":"user","content":"hi"}],"max_tokens":100000}`,
+			expTokens: 8,
+			expParam:  "messages",
+		},
+		{
+			name:      "anthropic with system",
+			req:       &anthropic.MessagesRequest{},
+			body:      `{"model":"m","system":"be brief","messages":[]}`,
+			expTokens: 4,
+			expParam:  "messages",
+		},
+		{name: "completions", req: &openai.CompletionRequest{}, body: `{"model":"m","prompt":"12345678"}`, expTokens: 3, expParam: "prompt"},
+		{name: "responses", req: &openai.ResponseRequest{}, body: `{"model":"m","input":"1234"}`, expTokens: 2, expParam: "input"},
+		{name: "embeddings", req: &openai.EmbeddingRequest{}, body: `{"model":"m","input":["a","b"]}`, expTokens: 3, expParam: "input"},
+		{name: "not estimated", req: &openai.ImageGenerationRequest{}, body: `{"model":"m","prompt":"a cat"}`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tokens, param := estimateInputTokens(tc.req, []byte(tc.body))
+			require.Equal(t, tc.expTokens, tokens)
+			require.Equal(t, tc.expParam, param)
+		})
+	}
+}
+
+func Test_chatCompletionProcessorUpstreamFilter_checkContextWindow(t *testing.T) {
+	const body = `{"model":"small","messages":[{"role":"user","content":"Tell me a long story about the sea."}]}`
+	config := &filterapi.RuntimeConfig{ContextWindows: map[string]map[string]int{
+		"default/route": {"small": 10, "large": 128000},
+	}}
+
+	// The requests within the window and the models without a window proceed. The window is the one of the model
+	// sent to the backend.
+	var m mockMetrics
+	for _, tc := range []struct{ routeName, model, override string }{
+		{routeName: "default/route", model: "large"},
+		{routeName: "default/route", model: "unknown"},
+		{routeName: "default/other", model: "small"},
+		{routeName: "default/route", model: "small", override: "large"},
+	} {
+		p := newTestUpstreamFilter(t, config, tc.routeName, body)
+		p.parent.originalModel, p.modelNameOverride, p.metrics = tc.model, tc.override, &m
+		res, err := p.checkContextWindow(t.Context())
+		require.NoError(t, err)
+		require.Nil(t, res)
+	}
+	require.Zero(t, m.contextLengthExceeded)
+
+	p := newTestUpstreamFilter(t, config, "default/route", body)
+	p.metrics = &m
+	res, err := p.checkContextWindow(t.Context())
+	require.NoError(t, err)
+	ir := res.GetImmediateResponse()
+	require.NotNil(t, ir)
+	require.Equal(t, 400, int(ir.Status.Code))
+	require.JSONEq(t, `{"type":"error","error":{"type":"invalid_request_error","code":"context_length_exceeded",
+"message":"This model's maximum context length is 10 tokens. However, your messages resulted in an estimated 17 tokens. Please reduce the length of the messages.",
+"param":"messages"}}`, string(ir.Body))
+	require.Equal(t, 1, m.contextLengthExceeded)
+
+	p = newTestUpstreamFilter(t, config, "default/route", body)
+	p.parent.originalModel, p.modelNameOverride, p.metrics = "large", "small", &m
+	res, err = p.checkContextWindow(t.Context())
+	require.NoError(t, err)
+	require.NotNil(t, res.GetImmediateResponse())
+	require.Equal(t, 2, m.contextLengthExceeded)
+}
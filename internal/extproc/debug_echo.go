@@ -0,0 +1,95 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"fmt"
+	"maps"
+	"net/url"
+	"strconv"
+	"strings"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"github.com/tidwall/gjson"
+
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+	"github.com/envoyproxy/ai-gateway/internal/json"
+)
+
+// debugEchoResponseBody is the body of the response returning the request as translated for the backend.
+type debugEchoResponseBody struct {
+	// Backend is the name of the backend selected for the request.
+	Backend string `json:"backend"`
+	// Headers are the request headers as sent to the backend, including the pseudo-headers, with the values of the
+	// credentials redacted.
+	Headers map[string]string `json:"headers"`
+	// Body is the request body as sent to the backend. This is the JSON body itself, or the base64 encoding of the
+	// other bodies.
+	Body any `json:"body,omitempty"`
+}
+
+// debugEchoRequested returns true if the request asks for the debug echo mode on a Gateway enabling it, with the
+// [internalapi.DebugEchoHeader] header or the "echo=true" query parameter.
+func (u *upstreamProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) debugEchoRequested() bool {
+	if config := u.parent.config; config == nil || !config.DebugEcho {
+		return false
+	}
+	if u.requestHeaders[internalapi.DebugEchoHeader] == "true" {
+		return true
+	}
+	_, query, ok := strings.Cut(u.requestHeaders[":path"], "?")
+	if !ok {
+		return false
+	}
+	values, err := url.ParseQuery(query)
+	return err == nil && values.Get("echo") == "true"
+}
+
+// debugEchoResponse returns the response with the request as it would have been sent to the backend, i.e. the request
+// headers with the header mutation and the backend auth applied, and the given body.
+func (u *upstreamProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) debugEchoResponse(
+	headerMutation *extprocv3.HeaderMutation, authHeaders []internalapi.Header, body []byte,
+) (*extprocv3.ProcessingResponse, error) {
+	// The headers set by the header mutation are already applied to the request headers.
+	headers := maps.Clone(u.requestHeaders)
+	for _, key := range headerMutation.GetRemoveHeaders() {
+		delete(headers, key)
+	}
+	for key := range headers {
+		if isSensitiveHeader(key, sensitiveHeaderKeys) {
+			headers[key] = string(sensitiveHeaderRedactedValue)
+		}
+	}
+	// The backend auth headers carry the credentials of the backend, whatever their name.
+	for _, h := range authHeaders {
+		headers[h.Key()] = string(sensitiveHeaderRedactedValue)
+	}
+
+	echo := debugEchoResponseBody{Backend: u.backendName, Headers: headers}
+	if gjson.ValidBytes(body) {
+		echo.Body = json.RawMessage(body)
+	} else if len(body) > 0 {
+		echo.Body = body
+	}
+	raw, err := json.Marshal(echo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal the debug echo response: %w", err)
+	}
+	u.logger.Info("returning the translated request in debug echo mode")
+	responseHeaders := &extprocv3.HeaderMutation{}
+	setHeader(responseHeaders, "content-type", "application/json")
+	setHeader(responseHeaders, "content-length", strconv.Itoa(len(raw)))
+	return &extprocv3.ProcessingResponse{
+		Response: &extprocv3.ProcessingResponse_ImmediateResponse{
+			ImmediateResponse: &extprocv3.ImmediateResponse{
+				Status:  &typev3.HttpStatus{Code: typev3.StatusCode_OK},
+				Headers: responseHeaders,
+				Body:    raw,
+			},
+		},
+	}, nil
+}
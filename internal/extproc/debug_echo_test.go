@@ -0,0 +1,65 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"log/slog"
+	"testing"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/internal/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+)
+
+func Test_chatCompletionProcessorUpstreamFilter_debugEchoRequested(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		enabled bool
+		headers map[string]string
+		exp     bool
+	}{
+		{name: "header", enabled: true, headers: map[string]string{internalapi.DebugEchoHeader: "true"}, exp: true},
+		{name: "query", enabled: true, headers: map[string]string{":path": "/v1/chat/completions?echo=true"}, exp: true},
+		{name: "not requested", enabled: true, headers: map[string]string{":path": "/v1/chat/completions?echo=false"}},
+		{name: "disabled", headers: map[string]string{internalapi.DebugEchoHeader: "true", ":path": "/v1/chat/completions?echo=true"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			p := &chatCompletionProcessorUpstreamFilter{
+				parent:         &chatCompletionProcessorRouterFilter{config: &filterapi.RuntimeConfig{DebugEcho: tc.enabled}},
+				requestHeaders: tc.headers,
+			}
+			require.Equal(t, tc.exp, p.debugEchoRequested())
+		})
+	}
+}
+
+func Test_chatCompletionProcessorUpstreamFilter_debugEchoResponse(t *testing.T) {
+	p := &chatCompletionProcessorUpstreamFilter{
+		requestHeaders: map[string]string{
+			":method": "POST", ":path": "/model/m/converse", "authorization": "Bearer client-key",
+			internalapi.DebugEchoHeader: "true", "x-removed": "v",
+		},
+		backendName: "bedrock",
+		logger:      slog.Default(),
+	}
+	headerMutation := &extprocv3.HeaderMutation{RemoveHeaders: []string{"x-removed"}}
+	authHeaders := []internalapi.Header{{"x-goog-api-key", "secret"}}
+
+	res, err := p.debugEchoResponse(headerMutation, authHeaders, []byte(`{"messages":[]}`))
+	require.NoError(t, err)
+	ir := res.GetImmediateResponse()
+	require.NotNil(t, ir)
+	require.Equal(t, 200, int(ir.Status.Code))
+	require.JSONEq(t, `{"backend":"bedrock","headers":{":method":"POST",":path":"/model/m/converse",
+"authorization":"[REDACTED]","x-ai-eg-debug-echo":"true","x-goog-api-key":"[REDACTED]"},"body":{"messages":[]}}`, string(ir.Body))
+
+	// The bodies other than JSON are base64-encoded.
+	res, err = p.debugEchoResponse(headerMutation, nil, []byte{0x1f, 0x8b})
+	require.NoError(t, err)
+	require.Contains(t, string(res.GetImmediateResponse().Body), `"body":"H4s="`)
+}
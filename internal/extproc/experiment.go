@@ -0,0 +1,103 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"hash/fnv"
+	"math/rand/v2"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"go.opentelemetry.io/otel/attribute"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/envoyproxy/ai-gateway/internal/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+)
+
+const (
+	// experimentMetadataKey and experimentVariantMetadataKey are the keys of the experiment and the assigned
+	// variant in the dynamic metadata.
+	experimentMetadataKey        = "experiment"
+	experimentVariantMetadataKey = "experiment_variant"
+	// experimentAttributeName and experimentAttributeVariant are the span attributes of the experiment and the
+	// assigned variant. These match the attributes of the GenAI metrics.
+	experimentAttributeName    = "experiment.name"
+	experimentAttributeVariant = "experiment.variant"
+)
+
+// experimentRandIntn returns a random number in [0, n) for the requests without the sticky header. This is a
+// variable for testing.
+var experimentRandIntn = rand.IntN
+
+// assignExperimentVariant returns the variant of the experiment assigned to the request with the given value of
+// the sticky header. The same value is always assigned the same variant as long as the experiment is unchanged,
+// and the requests without the sticky header are assigned randomly. This returns nil if no variant has a
+// positive percentage.
+func assignExperimentVariant(e *filterapi.Experiment, sticky string) *filterapi.ExperimentVariant {
+	var total int
+	for i := range e.Variants {
+		total += int(max(e.Variants[i].Percentage, 0))
+	}
+	if total == 0 {
+		return nil
+	}
+	var n int
+	if sticky == "" {
+		n = experimentRandIntn(total)
+	} else {
+		// The experiment name is hashed along with the value so that the experiments are assigned independently.
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(e.Name))
+		_, _ = h.Write([]byte{0})
+		_, _ = h.Write([]byte(sticky))
+		n = int(h.Sum64() % uint64(total))
+	}
+	for i := range e.Variants {
+		v := &e.Variants[i]
+		if n -= int(max(v.Percentage, 0)); n < 0 {
+			return v
+		}
+	}
+	return nil
+}
+
+// experimentSpanAttributes returns the span attributes of the experiment and the assigned variant.
+func experimentSpanAttributes(e *filterapi.Experiment, v *filterapi.ExperimentVariant) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String(experimentAttributeName, e.Name),
+		attribute.String(experimentAttributeVariant, v.Name),
+	}
+}
+
+// buildExperimentDynamicMetadata builds the dynamic metadata in the namespace of the experiment variant assigned to
+// the request, if any, from the request headers.
+func buildExperimentDynamicMetadata(namespace string, requestHeaders map[string]string) *structpb.Struct {
+	variant := requestHeaders[internalapi.ExperimentVariantHeader]
+	if variant == "" {
+		return nil
+	}
+	return &structpb.Struct{
+		Fields: map[string]*structpb.Value{
+			namespace: structpb.NewStructValue(&structpb.Struct{
+				Fields: map[string]*structpb.Value{
+					experimentMetadataKey:        structpb.NewStringValue(requestHeaders[internalapi.ExperimentHeader]),
+					experimentVariantMetadataKey: structpb.NewStringValue(variant),
+				},
+			}),
+		},
+	}
+}
+
+// setExperimentVariantHeader sets the header of the experiment variant assigned to the request, if any.
+func setExperimentVariantHeader(headerMutation *extprocv3.HeaderMutation, requestHeaders map[string]string) {
+	if variant := requestHeaders[internalapi.ExperimentVariantHeader]; variant != "" {
+		headerMutation.SetHeaders = append(headerMutation.SetHeaders, &corev3.HeaderValueOption{
+			AppendAction: corev3.HeaderValueOption_OVERWRITE_IF_EXISTS_OR_ADD,
+			Header:       &corev3.HeaderValue{Key: internalapi.ExperimentVariantHeader, RawValue: []byte(variant)},
+		})
+	}
+}
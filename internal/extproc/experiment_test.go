@@ -0,0 +1,125 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/envoyproxy/ai-gateway/internal/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+	"github.com/envoyproxy/ai-gateway/internal/testing/testotel"
+)
+
+func newTestExperiment() *filterapi.Experiment {
+	return &filterapi.Experiment{
+		RouteName:    "default/route",
+		Name:         "gpt-5-rollout",
+		StickyHeader: "x-user-id",
+		Variants: []filterapi.ExperimentVariant{
+			{Name: "control", Percentage: 80},
+			{Name: "treatment", Percentage: 20, ModelNameOverride: "gpt-5"},
+		},
+	}
+}
+
+func TestAssignExperimentVariant(t *testing.T) {
+	e := newTestExperiment()
+
+	t.Run("sticky", func(t *testing.T) {
+		counts := map[string]int{}
+		for i := range 1000 {
+			user := fmt.Sprintf("user-%d", i)
+			v := assignExperimentVariant(e, user)
+			require.NotNil(t, v)
+			// The same user is always assigned the same variant.
+			require.Same(t, v, assignExperimentVariant(e, user))
+			counts[v.Name]++
+		}
+		require.InDelta(t, 800, counts["control"], 60)
+		require.InDelta(t, 200, counts["treatment"], 60)
+	})
+
+	t.Run("random without the sticky header", func(t *testing.T) {
+		orig := experimentRandIntn
+		t.Cleanup(func() { experimentRandIntn = orig })
+		experimentRandIntn = func(n int) int {
+			require.Equal(t, 100, n)
+			return 80
+		}
+		require.Equal(t, "treatment", assignExperimentVariant(e, "").Name)
+		experimentRandIntn = func(int) int { return 79 }
+		require.Equal(t, "control", assignExperimentVariant(e, "").Name)
+	})
+
+	t.Run("zero percentage", func(t *testing.T) {
+		e := newTestExperiment()
+		e.Variants[0].Percentage = 0
+		for i := range 100 {
+			require.Equal(t, "treatment", assignExperimentVariant(e, fmt.Sprintf("user-%d", i)).Name)
+		}
+		e.Variants[1].Percentage = 0
+		require.Nil(t, assignExperimentVariant(e, "user"))
+	})
+}
+
+func TestBuildExperimentDynamicMetadata(t *testing.T) {
+	require.Nil(t, buildExperimentDynamicMetadata(internalapi.AIGatewayFilterMetadataNamespace, map[string]string{}))
+	md := buildExperimentDynamicMetadata(internalapi.AIGatewayFilterMetadataNamespace, map[string]string{
+		internalapi.ExperimentHeader:        "gpt-5-rollout",
+		internalapi.ExperimentVariantHeader: "treatment",
+	})
+	require.Equal(t, map[string]*structpb.Value{
+		"experiment":         structpb.NewStringValue("gpt-5-rollout"),
+		"experiment_variant": structpb.NewStringValue("treatment"),
+	}, md.Fields[internalapi.AIGatewayFilterMetadataNamespace].GetStructValue().Fields)
+}
+
+func Test_chatCompletionProcessorUpstreamFilter_SetBackend_Experiment(t *testing.T) {
+	e := newTestExperiment()
+	e.Variants[0].Percentage, e.Variants[1].Percentage = 0, 100
+	span := &testotel.MockSpan{}
+	r := &chatCompletionProcessorRouterFilter{
+		config:         &filterapi.RuntimeConfig{Experiments: map[string]*filterapi.Experiment{e.RouteName: e}},
+		requestHeaders: map[string]string{"x-user-id": "alice"},
+		span:           span,
+	}
+	backend := &filterapi.RuntimeBackend{Backend: &filterapi.Backend{
+		Name:              "default/openai/route/route/rule/0/ref/0",
+		Schema:            filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI},
+		ModelNameOverride: "gpt-4o",
+	}}
+
+	p := &chatCompletionProcessorUpstreamFilter{requestHeaders: map[string]string{}, metrics: &mockMetrics{}}
+	require.NoError(t, p.SetBackend(t.Context(), backend, e.RouteName, r))
+	// The model of the variant takes precedence over the one of the backend.
+	require.Equal(t, "gpt-5", p.modelNameOverride)
+	require.Equal(t, "gpt-5", p.requestHeaders[internalapi.ModelNameHeaderKeyDefault])
+	require.Equal(t, "gpt-5-rollout", p.requestHeaders[internalapi.ExperimentHeader])
+	require.Equal(t, "treatment", p.requestHeaders[internalapi.ExperimentVariantHeader])
+	require.Equal(t, []attribute.KeyValue{
+		attribute.String("experiment.name", "gpt-5-rollout"),
+		attribute.String("experiment.variant", "treatment"),
+	}, span.Attributes)
+
+	// The variant is kept on the retries even if the experiment changes.
+	e.Variants[0].Percentage, e.Variants[1].Percentage = 100, 0
+	retry := &chatCompletionProcessorUpstreamFilter{requestHeaders: map[string]string{}, metrics: &mockMetrics{}}
+	require.NoError(t, retry.SetBackend(t.Context(), backend, e.RouteName, r))
+	require.Equal(t, "treatment", retry.requestHeaders[internalapi.ExperimentVariantHeader])
+	require.Len(t, span.Attributes, 2)
+
+	// The routes without the experiment are not assigned.
+	other := &chatCompletionProcessorUpstreamFilter{requestHeaders: map[string]string{}, metrics: &mockMetrics{}}
+	require.NoError(t, other.SetBackend(t.Context(), backend, "default/other",
+		&chatCompletionProcessorRouterFilter{config: r.config, requestHeaders: r.requestHeaders}))
+	require.Equal(t, "gpt-4o", other.modelNameOverride)
+	require.NotContains(t, other.requestHeaders, internalapi.ExperimentVariantHeader)
+}
@@ -0,0 +1,165 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"fmt"
+	"log/slog"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+
+	"github.com/envoyproxy/ai-gateway/internal/filterapi"
+)
+
+// extraFieldsErrorCode is the code of the OpenAI error rejecting the requests with extra fields not allowed by the
+// selected backend, which is the one of OpenAI for the unrecognized request arguments.
+const extraFieldsErrorCode = "unknown_parameter"
+
+// requestFields caches the result of knownRequestFields per request type.
+var requestFields sync.Map // map[reflect.Type]map[string]struct{}
+
+// knownRequestFields returns the names of the top-level JSON fields of the request type. The fields of the embedded
+// structs and of the untagged struct fields, e.g. the variants of the request unions, are flattened into it.
+func knownRequestFields(t reflect.Type) map[string]struct{} {
+	if fields, ok := requestFields.Load(t); ok {
+		return fields.(map[string]struct{})
+	}
+	fields := make(map[string]struct{})
+	collectRequestFields(t, fields)
+	requestFields.Store(t, fields)
+	return fields
+}
+
+func collectRequestFields(t reflect.Type, fields map[string]struct{}) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return
+	}
+	for i := range t.NumField() {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		switch {
+		case name == "-":
+		case name != "":
+			fields[name] = struct{}{}
+		case f.Type.Kind() == reflect.Struct || (f.Type.Kind() == reflect.Pointer && f.Type.Elem().Kind() == reflect.Struct):
+			collectRequestFields(f.Type, fields)
+		case f.IsExported():
+			fields[f.Name] = struct{}{}
+		}
+	}
+}
+
+// extraFields returns the top-level fields of the JSON request body that are not part of the request type, in the
+// order of the body.
+func extraFields(t reflect.Type, body []byte) (names []string, values []gjson.Result) {
+	known := knownRequestFields(t)
+	gjson.ParseBytes(body).ForEach(func(key, value gjson.Result) bool {
+		if _, ok := known[key.String()]; !ok {
+			names, values = append(names, key.String()), append(values, value)
+		}
+		return true
+	})
+	return
+}
+
+// disallowedExtraFields returns the extra fields of the original request body that are not allowed by the backend.
+// The multipart request bodies have no extra fields.
+func (u *upstreamProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) disallowedExtraFields() []string {
+	if strings.HasPrefix(strings.ToLower(u.requestHeaders["content-type"]), "multipart/form-data") {
+		return nil
+	}
+	names, _ := extraFields(reflect.TypeFor[ReqT](), u.parent.originalRequestBodyRaw)
+	var disallowed []string
+	for _, name := range names {
+		if !slices.Contains(u.extraBody.AllowedFields, name) {
+			disallowed = append(disallowed, name)
+		}
+	}
+	return disallowed
+}
+
+// checkExtraBody returns the response rejecting the request with the extra fields that are not allowed by the
+// backend if its policy is Reject, or nil if the request can proceed.
+func (u *upstreamProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) checkExtraBody() (*extprocv3.ProcessingResponse, error) {
+	if u.extraBody == nil || u.extraBody.Policy != filterapi.ExtraBodyPolicyReject {
+		return nil, nil
+	}
+	disallowed := u.disallowedExtraFields()
+	if len(disallowed) == 0 {
+		return nil, nil
+	}
+	u.logger.Info("rejecting request with extra fields not allowed by the backend",
+		slog.String("backend", u.backendName), slog.Any("fields", disallowed))
+	return invalidRequestErrorResponse(extraFieldsErrorCode,
+		fmt.Sprintf("the selected backend does not accept the fields: %s", strings.Join(disallowed, ", ")), disallowed[0])
+}
+
+// applyExtraBody makes the request body sent to the backend carry exactly the extra fields of the original request
+// body allowed by the backend: the allowed ones dropped by the translation are set back with their original values,
+// and the other ones forwarded as-is are removed. The body is either the one in the given bodyMutation or the
+// original request body if there's no mutation. The fields set to another value by the translation are left
+// untouched, as are the multipart and the non-object bodies.
+//
+// This returns the new body mutation and true if the body was changed.
+func (u *upstreamProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) applyExtraBody(bodyMutation *extprocv3.BodyMutation) (*extprocv3.BodyMutation, bool) {
+	if strings.HasPrefix(strings.ToLower(u.requestHeaders["content-type"]), "multipart/form-data") {
+		return bodyMutation, false
+	}
+	original := u.parent.originalRequestBodyRaw
+	names, values := extraFields(reflect.TypeFor[ReqT](), original)
+	if len(names) == 0 {
+		return bodyMutation, false
+	}
+	body := bodyMutation.GetBody()
+	if body == nil {
+		body = original
+	}
+	if !gjson.ValidBytes(body) || !gjson.ParseBytes(body).IsObject() {
+		return bodyMutation, false
+	}
+
+	var stripped []string
+	out, changed := body, false
+	for i, name := range names {
+		path := gjson.Escape(name)
+		sent := gjson.GetBytes(out, path)
+		var err error
+		switch {
+		case slices.Contains(u.extraBody.AllowedFields, name):
+			if sent.Exists() {
+				continue
+			}
+			out, err = sjson.SetRawBytes(out, path, []byte(values[i].Raw))
+		case sent.Exists() && sent.Raw == values[i].Raw:
+			out, err = sjson.DeleteBytes(out, path)
+			stripped = append(stripped, name)
+		default:
+			continue
+		}
+		if err != nil {
+			u.logger.Error("failed to apply the extra body", slog.String("field", name), slog.String("error", err.Error()))
+			return bodyMutation, false
+		}
+		changed = true
+	}
+	if len(stripped) > 0 {
+		u.logger.Debug("stripped extra fields not allowed by the backend",
+			slog.String("backend", u.backendName), slog.Any("fields", stripped))
+	}
+	if !changed {
+		return bodyMutation, false
+	}
+	return &extprocv3.BodyMutation{Mutation: &extprocv3.BodyMutation_Body{Body: out}}, true
+}
@@ -0,0 +1,116 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"reflect"
+	"testing"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/filterapi"
+)
+
+func TestKnownRequestFields(t *testing.T) {
+	chat := knownRequestFields(reflect.TypeFor[openai.ChatCompletionRequest]())
+	for _, name := range []string{"model", "messages", "guided_json", "thinking", "generationConfig"} {
+		require.Contains(t, chat, name)
+	}
+	require.NotContains(t, chat, "top_k")
+
+	// The variants of the request unions are flattened.
+	embeddings := knownRequestFields(reflect.TypeFor[openai.EmbeddingRequest]())
+	for _, name := range []string{"model", "input", "messages", "dimensions"} {
+		require.Contains(t, embeddings, name)
+	}
+	require.NotContains(t, embeddings, "OfCompletion")
+}
+
+func TestExtraFields(t *testing.T) {
+	names, values := extraFields(reflect.TypeFor[openai.ChatCompletionRequest](),
+		[]byte(`{"model":"m","top_k":5,"messages":[],"repetition_penalty":1.1}`))
+	require.Equal(t, []string{"top_k", "repetition_penalty"}, names)
+	require.Equal(t, "5", values[0].Raw)
+	require.Equal(t, "1.1", values[1].Raw)
+}
+
+func Test_chatCompletionProcessorUpstreamFilter_checkExtraBody(t *testing.T) {
+	const body = `{"model":"m","top_k":5,"min_p":0.1,"messages":[]}`
+	for _, extraBody := range []*filterapi.ExtraBody{
+		nil,
+		{Policy: filterapi.ExtraBodyPolicyStrip},
+		{AllowedFields: []string{"top_k", "min_p"}, Policy: filterapi.ExtraBodyPolicyReject},
+	} {
+		p := newTestUpstreamFilter(t, nil, "default/route", body)
+		p.requestHeaders["content-type"], p.extraBody = "application/json", extraBody
+		res, err := p.checkExtraBody()
+		require.NoError(t, err)
+		require.Nil(t, res)
+	}
+
+	p := newTestUpstreamFilter(t, nil, "default/route", body)
+	p.requestHeaders["content-type"] = "application/json"
+	p.extraBody = &filterapi.ExtraBody{AllowedFields: []string{"top_k"}, Policy: filterapi.ExtraBodyPolicyReject}
+	res, err := p.checkExtraBody()
+	require.NoError(t, err)
+	ir := res.GetImmediateResponse()
+	require.NotNil(t, ir)
+	require.Equal(t, 400, int(ir.Status.Code))
+	require.JSONEq(t, `{"type":"error","error":{"type":"invalid_request_error","code":"unknown_parameter",
+"message":"the selected backend does not accept the fields: min_p","param":"min_p"}}`, string(ir.Body))
+}
+
+func Test_chatCompletionProcessorUpstreamFilter_applyExtraBody(t *testing.T) {
+	const body = `{"model":"m","top_k":5,"messages":[],"min_p":0.1}`
+	for _, tc := range []struct {
+		name         string
+		contentType  string
+		extraBody    *filterapi.ExtraBody
+		bodyMutation *extprocv3.BodyMutation
+		exp          string
+	}{
+		{
+			name:      "strip the forwarded fields",
+			extraBody: &filterapi.ExtraBody{AllowedFields: []string{"top_k"}, Policy: filterapi.ExtraBodyPolicyStrip},
+			exp:       `{"model":"m","top_k":5,"messages":[]}`,
+		},
+		{
+			name:      "all allowed",
+			extraBody: &filterapi.ExtraBody{AllowedFields: []string{"top_k", "min_p"}, Policy: filterapi.ExtraBodyPolicyStrip},
+		},
+		{
+			name:         "restore the allowed fields dropped by the translation",
+			extraBody:    &filterapi.ExtraBody{AllowedFields: []string{"top_k"}, Policy: filterapi.ExtraBodyPolicyStrip},
+			bodyMutation: &extprocv3.BodyMutation{Mutation: &extprocv3.BodyMutation_Body{Body: []byte(`{"modelId":"m"}`)}},
+			exp:          `{"modelId":"m","top_k":5}`,
+		},
+		{
+			name:         "the fields set by the translation are kept",
+			extraBody:    &filterapi.ExtraBody{Policy: filterapi.ExtraBodyPolicyStrip},
+			bodyMutation: &extprocv3.BodyMutation{Mutation: &extprocv3.BodyMutation_Body{Body: []byte(`{"model":"m","min_p":0.5}`)}},
+		},
+		{
+			name:        "multipart",
+			contentType: "multipart/form-data; boundary=x",
+			extraBody:   &filterapi.ExtraBody{Policy: filterapi.ExtraBodyPolicyStrip},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			p := newTestUpstreamFilter(t, nil, "default/route", body)
+			p.requestHeaders["content-type"], p.extraBody = tc.contentType, tc.extraBody
+			out, changed := p.applyExtraBody(tc.bodyMutation)
+			if tc.exp == "" {
+				require.False(t, changed)
+				require.Equal(t, tc.bodyMutation, out)
+				return
+			}
+			require.True(t, changed)
+			require.JSONEq(t, tc.exp, string(out.GetBody()))
+		})
+	}
+}
@@ -0,0 +1,45 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"log/slog"
+	"strconv"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+)
+
+// failoverRetries returns the number of times the request was re-dispatched by Envoy after failing mid-flight. The
+// retry of a request rejected for exceeding the context length of the model is not counted.
+func (u *upstreamProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) failoverRetries() int {
+	rp := u.parent
+	retries := rp.upstreamFilterCount - 1
+	if rp.contextLengthRetry != "" && rp.upstreamFilterCount > rp.contextLengthRetryAttempt {
+		retries--
+	}
+	return max(retries, 0)
+}
+
+// setFailoverRetryHeader sets the FailoverRetryHeader on the response of the request re-dispatched by the
+// FailoverRetry of its route rule.
+func (u *upstreamProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) setFailoverRetryHeader(headerMutation *extprocv3.HeaderMutation) {
+	if !u.failoverRetry {
+		return
+	}
+	retries := u.failoverRetries()
+	if retries == 0 {
+		return
+	}
+	u.logger.Info("request re-dispatched after failing mid-flight",
+		slog.Int("retries", retries), slog.String("backend", u.backendName))
+	headerMutation.SetHeaders = append(headerMutation.SetHeaders, &corev3.HeaderValueOption{
+		AppendAction: corev3.HeaderValueOption_OVERWRITE_IF_EXISTS_OR_ADD,
+		Header:       &corev3.HeaderValue{Key: internalapi.FailoverRetryHeader, RawValue: []byte(strconv.Itoa(retries))},
+	})
+}
@@ -0,0 +1,61 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"log/slog"
+	"testing"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/internal/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+)
+
+func Test_chatCompletionProcessorUpstreamFilter_setFailoverRetryHeader(t *testing.T) {
+	for _, tc := range []struct {
+		name                      string
+		failoverRetry             bool
+		upstreamFilterCount       int
+		contextLengthRetry        filterapi.ContextLengthRetryStrategy
+		contextLengthRetryAttempt int
+		exp                       string
+	}{
+		{name: "first attempt", failoverRetry: true, upstreamFilterCount: 1},
+		{name: "retried", failoverRetry: true, upstreamFilterCount: 3, exp: "2"},
+		{name: "not configured", upstreamFilterCount: 3},
+		{
+			name: "context length retry", failoverRetry: true, upstreamFilterCount: 2,
+			contextLengthRetry: filterapi.ContextLengthRetryStrategyFallback, contextLengthRetryAttempt: 1,
+		},
+		{
+			name: "retried after context length retry", failoverRetry: true, upstreamFilterCount: 3,
+			contextLengthRetry: filterapi.ContextLengthRetryStrategyFallback, contextLengthRetryAttempt: 2, exp: "1",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			p := &chatCompletionProcessorUpstreamFilter{
+				parent: &chatCompletionProcessorRouterFilter{
+					upstreamFilterCount:       tc.upstreamFilterCount,
+					contextLengthRetry:        tc.contextLengthRetry,
+					contextLengthRetryAttempt: tc.contextLengthRetryAttempt,
+				},
+				failoverRetry: tc.failoverRetry,
+				logger:        slog.Default(),
+			}
+			headerMutation := &extprocv3.HeaderMutation{}
+			p.setFailoverRetryHeader(headerMutation)
+			if tc.exp == "" {
+				require.Empty(t, headerMutation.SetHeaders)
+				return
+			}
+			require.Len(t, headerMutation.SetHeaders, 1)
+			require.Equal(t, internalapi.FailoverRetryHeader, headerMutation.SetHeaders[0].Header.Key)
+			require.Equal(t, tc.exp, string(headerMutation.SetHeaders[0].Header.RawValue))
+		})
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/json"
+)
+
+// fallbackResponseTemplateData is the data the message template of the fallback response is executed with.
+type fallbackResponseTemplateData struct {
+	// Model is the model of the request.
+	Model string
+	// StatusCode is the status code of the replaced response.
+	StatusCode int
+}
+
+// fallbackResponse returns the immediate response replacing the response whose headers are given when its status
+// code is configured in the [filterapi.FallbackResponse], or nil otherwise.
+//
+// This runs at the router filter so that the local replies of Envoy, such as the 503 returned when no backend is
+// healthy, are replaced as well as the errors of the backends after the retries are exhausted.
+func (r *routerProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) fallbackResponse(ctx context.Context, headerMap *corev3.HeaderMap) *extprocv3.ProcessingResponse {
+	if r.config == nil || r.config.FallbackResponse == nil {
+		return nil
+	}
+	f := r.config.FallbackResponse
+	code, _ := strconv.Atoi(headersToMap(headerMap)[":status"])
+	if !slices.Contains(f.StatusCodes, code) {
+		return nil
+	}
+
+	var message bytes.Buffer
+	if err := f.Template.Execute(&message, fallbackResponseTemplateData{Model: r.originalModel, StatusCode: code}); err != nil {
+		// The template is parsed when the config is loaded, so this only fails on the data, e.g. with an unknown
+		// field. Keep the original response rather than returning a broken one.
+		r.logger.Error("failed to execute the fallback response template", slog.String("error", err.Error()))
+		return nil
+	}
+	_, isChat := any(r.originalRequestBody).(*openai.ChatCompletionRequest)
+	status, contentType, body, err := buildFallbackResponse(f.Type, isChat, r.stream, code, r.originalModel, message.String())
+	if err != nil {
+		r.logger.Error("failed to build the fallback response", slog.String("error", err.Error()))
+		return nil
+	}
+
+	r.logger.Info("replacing the error response with the fallback response", slog.Int("status", code))
+	// The request still failed, so it is reported as such in the metrics and the span.
+	if u := r.upstreamFilter; u != nil && !u.responseEnded {
+		u.responseEnded = true
+		u.metrics.RecordRequestCompletion(ctx, false, u.requestHeaders)
+	}
+	if r.span != nil {
+		r.span.EndSpanOnError(code, body)
+	}
+
+	headerMutation := &extprocv3.HeaderMutation{}
+	setHeader(headerMutation, "content-type", contentType)
+	setHeader(headerMutation, "content-length", strconv.Itoa(len(body)))
+	return &extprocv3.ProcessingResponse{
+		Response: &extprocv3.ProcessingResponse_ImmediateResponse{
+			ImmediateResponse: &extprocv3.ImmediateResponse{
+				Status:  &typev3.HttpStatus{Code: typev3.StatusCode(status)}, // #nosec G115 - HTTP status codes are always in valid int32 range
+				Headers: headerMutation,
+				Body:    body,
+			},
+		},
+	}
+}
+
+// buildFallbackResponse returns the status code, the content type and the body of the fallback response of the
+// given type replacing a response with the status code. The Message type only applies to the chat completions,
+// and the other endpoints get the Error type.
+func buildFallbackResponse(typ filterapi.FallbackResponseType, isChat, stream bool, code int, model, message string) (int, string, []byte, error) {
+	if typ != filterapi.FallbackResponseTypeMessage || !isChat {
+		codeStr := strconv.Itoa(code)
+		body, err := json.Marshal(openai.Error{
+			Type: "error",
+			Error: openai.ErrorType{
+				Type:    strings.ReplaceAll(http.StatusText(code), " ", ""),
+				Code:    &codeStr,
+				Message: message,
+			},
+		})
+		return code, "application/json", body, err
+	}
+
+	created := openai.JSONUNIXTime(time.Now())
+	if !stream {
+		body, err := json.Marshal(openai.ChatCompletionResponse{
+			Object:  "chat.completion",
+			Created: created,
+			Model:   model,
+			Choices: []openai.ChatCompletionResponseChoice{{
+				FinishReason: openai.ChatCompletionChoicesFinishReasonStop,
+				Message:      openai.ChatCompletionResponseChoiceMessage{Role: openai.ChatMessageRoleAssistant, Content: &message},
+			}},
+		})
+		return http.StatusOK, "application/json", body, err
+	}
+	chunk, err := json.Marshal(openai.ChatCompletionResponseChunk{
+		Object:  "chat.completion.chunk",
+		Created: created,
+		Model:   model,
+		Choices: []openai.ChatCompletionResponseChunkChoice{{
+			Delta:        &openai.ChatCompletionResponseChunkChoiceDelta{Role: openai.ChatMessageRoleAssistant, Content: &message},
+			FinishReason: openai.ChatCompletionChoicesFinishReasonStop,
+		}},
+	})
+	if err != nil {
+		return 0, "", nil, err
+	}
+	return http.StatusOK, "text/event-stream", fmt.Appendf(nil, "data: %s\n\ndata: %s\n\n", chunk, sseDone), nil
+}
@@ -0,0 +1,94 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"log/slog"
+	"testing"
+	"text/template"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/json"
+)
+
+func TestBuildFallbackResponse(t *testing.T) {
+	t.Run("error", func(t *testing.T) {
+		status, contentType, body, err := buildFallbackResponse(filterapi.FallbackResponseTypeError, true, false, 503, "gpt-4o", `"gpt-4o" is down`)
+		require.NoError(t, err)
+		require.Equal(t, 503, status)
+		require.Equal(t, "application/json", contentType)
+		require.JSONEq(t, `{"type":"error","error":{"type":"ServiceUnavailable","code":"503","message":"\"gpt-4o\" is down"}}`, string(body))
+	})
+	t.Run("message on other endpoints", func(t *testing.T) {
+		status, _, body, err := buildFallbackResponse(filterapi.FallbackResponseTypeMessage, false, false, 429, "m", "busy")
+		require.NoError(t, err)
+		require.Equal(t, 429, status)
+		require.JSONEq(t, `{"type":"error","error":{"type":"TooManyRequests","code":"429","message":"busy"}}`, string(body))
+	})
+	t.Run("message", func(t *testing.T) {
+		status, contentType, body, err := buildFallbackResponse(filterapi.FallbackResponseTypeMessage, true, false, 503, "gpt-4o", "sorry")
+		require.NoError(t, err)
+		require.Equal(t, 200, status)
+		require.Equal(t, "application/json", contentType)
+		var resp openai.ChatCompletionResponse
+		require.NoError(t, json.Unmarshal(body, &resp))
+		require.Equal(t, "gpt-4o", resp.Model)
+		require.Len(t, resp.Choices, 1)
+		require.Equal(t, openai.ChatCompletionChoicesFinishReasonStop, resp.Choices[0].FinishReason)
+		require.Equal(t, "sorry", *resp.Choices[0].Message.Content)
+	})
+	t.Run("message stream", func(t *testing.T) {
+		status, contentType, body, err := buildFallbackResponse(filterapi.FallbackResponseTypeMessage, true, true, 503, "gpt-4o", "sorry")
+		require.NoError(t, err)
+		require.Equal(t, 200, status)
+		require.Equal(t, "text/event-stream", contentType)
+		require.Contains(t, string(body), `"delta":{"content":"sorry","role":"assistant"},"finish_reason":"stop"`)
+		require.Regexp(t, `^data: \{.*\}\n\ndata: \[DONE\]\n\n$`, string(body))
+	})
+}
+
+func Test_routerProcessor_fallbackResponse(t *testing.T) {
+	config := &filterapi.RuntimeConfig{FallbackResponse: &filterapi.RuntimeFallbackResponse{
+		FallbackResponse: &filterapi.FallbackResponse{
+			StatusCodes: []int{429, 503},
+			Type:        filterapi.FallbackResponseTypeError,
+			Message:     "{{ .Model }} is unavailable ({{ .StatusCode }})",
+		},
+		Template: template.Must(template.New("fallback").Parse("{{ .Model }} is unavailable ({{ .StatusCode }})")),
+	}}
+	headers := func(status string) *corev3.HeaderMap {
+		return &corev3.HeaderMap{Headers: []*corev3.HeaderValue{{Key: ":status", Value: status}}}
+	}
+
+	t.Run("replaced", func(t *testing.T) {
+		mm := &mockMetrics{}
+		u := &chatCompletionProcessorUpstreamFilter{metrics: mm}
+		p := &chatCompletionProcessorRouterFilter{config: config, logger: slog.Default(), originalModel: "gpt-4o", upstreamFilter: u}
+		resp, err := p.ProcessResponseHeaders(t.Context(), headers("503"))
+		require.NoError(t, err)
+		ir, ok := resp.Response.(*extprocv3.ProcessingResponse_ImmediateResponse)
+		require.True(t, ok)
+		require.Equal(t, typev3.StatusCode(503), ir.ImmediateResponse.Status.Code)
+		require.JSONEq(t, `{"type":"error","error":{"type":"ServiceUnavailable","code":"503","message":"gpt-4o is unavailable (503)"}}`,
+			string(ir.ImmediateResponse.Body))
+		require.True(t, u.responseEnded)
+		mm.RequireRequestFailure(t)
+	})
+	t.Run("other status", func(t *testing.T) {
+		p := &chatCompletionProcessorRouterFilter{config: config, logger: slog.Default()}
+		require.Nil(t, p.fallbackResponse(t.Context(), headers("500")))
+	})
+	t.Run("not configured", func(t *testing.T) {
+		p := &chatCompletionProcessorRouterFilter{config: &filterapi.RuntimeConfig{}, logger: slog.Default()}
+		require.Nil(t, p.fallbackResponse(t.Context(), headers("503")))
+	})
+}
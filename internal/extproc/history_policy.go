@@ -0,0 +1,255 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+)
+
+const (
+	// historyBytesPerToken is the number of bytes of the messages counted as a token when estimating the input
+	// tokens of a request against HistoryPolicy.MaxInputTokens.
+	historyBytesPerToken = 4
+	// historySummaryPrefix prefixes the content of the system message replacing the summarized turns.
+	historySummaryPrefix = "Summary of the earlier conversation: "
+	// historySummarizationPrompt is the system prompt of the summarization requests.
+	historySummarizationPrompt = "Summarize the following conversation between a user and an assistant in a few " +
+		"sentences. Keep the facts, decisions and open questions needed to continue the conversation. " +
+		"Reply with the summary only."
+	// maxHistorySummaryResponseSize is the maximum size of the summarization responses read.
+	maxHistorySummaryResponseSize = 1 << 20
+)
+
+// historySummarizationClient sends the summarization requests. The timeout of each request is configured by the
+// HistorySummarization of the route.
+var historySummarizationClient = &http.Client{}
+
+// applyHistoryPolicy elides the oldest turns of the conversation of the chat completion request beyond the bounds
+// of the HistoryPolicy of the route, if any. Like the parameter overrides, the rewritten body replaces the original
+// one of the router processor so that it is reused on the retries.
+func (u *upstreamProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) applyHistoryPolicy(ctx context.Context) error {
+	rp := u.parent
+	if rp.config == nil {
+		return nil
+	}
+	policy := rp.config.HistoryPolicies[u.routeName]
+	if policy == nil {
+		return nil
+	}
+	if _, chat := any(rp.originalRequestBody).(*openai.ChatCompletionRequest); !chat {
+		return nil
+	}
+	messages := gjson.GetBytes(rp.originalRequestBodyRaw, "messages").Array()
+	elided := historyToElide(messages, policy)
+	if len(elided) == 0 {
+		return nil
+	}
+
+	var summary string
+	if policy.Strategy == filterapi.HistoryElisionStrategySummarize && policy.Summarization != nil {
+		var err error
+		summary, err = summarizeHistory(ctx, policy.Summarization, messages, elided)
+		if err != nil {
+			u.logger.Warn("failed to summarize the conversation history, dropping the elided turns",
+				slog.String("error", err.Error()))
+		}
+	}
+	body, err := elideMessages(rp.originalRequestBodyRaw, messages, elided, summary)
+	if err != nil {
+		return err
+	}
+	_, req, _, _, err := rp.eh.ParseBody(body, false)
+	if err != nil {
+		return err
+	}
+	rp.originalRequestBodyRaw, rp.originalRequestBody = body, req
+	rp.forceBodyMutation = true
+	rp.historyElided = len(elided)
+	return nil
+}
+
+// historyToElide returns the indexes of the messages of the oldest turns beyond the bounds of the policy, in
+// order. A turn starts at a user message and includes the following assistant and tool messages, so that the tool
+// calls are never separated from their results. The system and developer messages and the last turn are kept.
+func historyToElide(messages []gjson.Result, policy *filterapi.HistoryPolicy) []int {
+	var turns [][]int
+	kept := 0
+	for i, m := range messages {
+		role := m.Get("role").String()
+		switch {
+		case role == openai.ChatMessageRoleSystem || role == openai.ChatMessageRoleDeveloper:
+			kept += estimateTokens(m.Raw)
+		case role == openai.ChatMessageRoleUser || len(turns) == 0:
+			turns = append(turns, []int{i})
+		default:
+			turns[len(turns)-1] = append(turns[len(turns)-1], i)
+		}
+	}
+
+	keep := len(turns)
+	if policy.MaxTurns > 0 {
+		keep = min(keep, policy.MaxTurns)
+	}
+	if policy.MaxInputTokens > 0 {
+		turnTokens := func(turn []int) (tokens int) {
+			for _, i := range turn {
+				tokens += estimateTokens(messages[i].Raw)
+			}
+			return
+		}
+		for _, turn := range turns[len(turns)-keep:] {
+			kept += turnTokens(turn)
+		}
+		for keep > 1 && kept > policy.MaxInputTokens {
+			kept -= turnTokens(turns[len(turns)-keep])
+			keep--
+		}
+	}
+
+	var elided []int
+	for _, turn := range turns[:len(turns)-keep] {
+		elided = append(elided, turn...)
+	}
+	return elided
+}
+
+// estimateTokens estimates the number of tokens of the raw JSON message.
+func estimateTokens(raw string) int {
+	return (len(raw) + historyBytesPerToken - 1) / historyBytesPerToken
+}
+
+// elideMessages returns the body without the elided messages. If the summary isn't empty, a system message with it
+// replaces the elided messages.
+func elideMessages(body []byte, messages []gjson.Result, elided []int, summary string) ([]byte, error) {
+	dropped := make(map[int]struct{}, len(elided))
+	for _, i := range elided {
+		dropped[i] = struct{}{}
+	}
+	var summaryMessage []byte
+	if summary != "" {
+		var err error
+		summaryMessage, err = json.Marshal(map[string]string{
+			"role":    openai.ChatMessageRoleSystem,
+			"content": historySummaryPrefix + summary,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal the summary message: %w", err)
+		}
+	}
+
+	kept := []byte{'['}
+	appendMessage := func(raw []byte) {
+		if len(kept) > 1 {
+			kept = append(kept, ',')
+		}
+		kept = append(kept, raw...)
+	}
+	for i, m := range messages {
+		if _, ok := dropped[i]; !ok {
+			appendMessage([]byte(m.Raw))
+		} else if i == elided[0] && summaryMessage != nil {
+			appendMessage(summaryMessage)
+		}
+	}
+	kept = append(kept, ']')
+	out, err := sjson.SetRawBytes(body, "messages", kept)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set the messages: %w", err)
+	}
+	return out, nil
+}
+
+// summarizeHistory asks the model of the summarization to summarize the elided messages.
+func summarizeHistory(ctx context.Context, s *filterapi.HistorySummarization, messages []gjson.Result, elided []int) (string, error) {
+	var transcript strings.Builder
+	for _, i := range elided {
+		if text := messageText(messages[i]); text != "" {
+			fmt.Fprintf(&transcript, "%s: %s\n", messages[i].Get("role").String(), text)
+		}
+	}
+	if transcript.Len() == 0 {
+		return "", fmt.Errorf("no text to summarize")
+	}
+	reqBody, err := json.Marshal(map[string]any{
+		"model": s.Model,
+		"messages": []map[string]string{
+			{"role": openai.ChatMessageRoleSystem, "content": historySummarizationPrompt},
+			{"role": openai.ChatMessageRoleUser, "content": transcript.String()},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal the summarization request: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(s.TimeoutMilliseconds)*time.Millisecond)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(reqBody))
+	if err != nil {
+		return "", fmt.Errorf("failed to create the summarization request: %w", err)
+	}
+	req.Header.Set("content-type", "application/json")
+	resp, err := historySummarizationClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to send the summarization request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxHistorySummaryResponseSize))
+	if err != nil {
+		return "", fmt.Errorf("failed to read the summarization response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("summarization failed with status %d", resp.StatusCode)
+	}
+	summary := strings.TrimSpace(gjson.GetBytes(respBody, "choices.0.message.content").String())
+	if summary == "" {
+		return "", fmt.Errorf("empty summarization response")
+	}
+	return summary, nil
+}
+
+// messageText returns the text of the content of the chat completion message, joining the text parts.
+func messageText(m gjson.Result) string {
+	content := m.Get("content")
+	if !content.IsArray() {
+		return content.String()
+	}
+	var parts []string
+	for _, part := range content.Array() {
+		if part.Get("type").String() == "text" {
+			parts = append(parts, part.Get("text").String())
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// setHistoryElidedHeader sets the HistoryElidedHeader on the response if messages were elided from the request.
+func setHistoryElidedHeader(headerMutation *extprocv3.HeaderMutation, elided int) {
+	if elided == 0 {
+		return
+	}
+	headerMutation.SetHeaders = append(headerMutation.SetHeaders, &corev3.HeaderValueOption{
+		AppendAction: corev3.HeaderValueOption_OVERWRITE_IF_EXISTS_OR_ADD,
+		Header:       &corev3.HeaderValue{Key: internalapi.HistoryElidedHeader, RawValue: []byte(strconv.Itoa(elided))},
+	})
+}
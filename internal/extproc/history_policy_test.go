@@ -0,0 +1,154 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+)
+
+const historyTestBody = `{"model":"m","messages":[` +
+	`{"role":"system","content":"be brief"},` +
+	`{"role":"user","content":"hi"},` +
+	`{"role":"assistant","content":"hello"},` +
+	`{"role":"user","content":"weather?"},` +
+	`{"role":"assistant","tool_calls":[{"id":"1","type":"function","function":{"name":"weather","arguments":"{}"}}]},` +
+	`{"role":"tool","tool_call_id":"1","content":"sunny"},` +
+	`{"role":"assistant","content":"It is sunny."},` +
+	`{"role":"user","content":[{"type":"text","text":"and tomorrow?"}]}]}`
+
+func TestHistoryToElide(t *testing.T) {
+	messages := gjson.Get(historyTestBody, "messages").Array()
+	for _, tc := range []struct {
+		name   string
+		policy filterapi.HistoryPolicy
+		exp    []int
+	}{
+		{name: "within max turns", policy: filterapi.HistoryPolicy{MaxTurns: 3}},
+		{name: "max turns", policy: filterapi.HistoryPolicy{MaxTurns: 2}, exp: []int{1, 2}},
+		{name: "tool calls are elided with their turn", policy: filterapi.HistoryPolicy{MaxTurns: 1}, exp: []int{1, 2, 3, 4, 5, 6}},
+		{name: "within max input tokens", policy: filterapi.HistoryPolicy{MaxInputTokens: 1000}},
+		{name: "max input tokens", policy: filterapi.HistoryPolicy{MaxInputTokens: 60}, exp: []int{1, 2, 3, 4, 5, 6}},
+		{name: "last turn is kept", policy: filterapi.HistoryPolicy{MaxInputTokens: 1}, exp: []int{1, 2, 3, 4, 5, 6}},
+		{name: "both bounds", policy: filterapi.HistoryPolicy{MaxTurns: 2, MaxInputTokens: 1000}, exp: []int{1, 2}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.exp, historyToElide(messages, &tc.policy))
+		})
+	}
+
+	// The messages before the first user message are part of the first turn.
+	messages = gjson.Parse(`[{"role":"assistant","content":"How can I help?"},{"role":"user","content":"hi"}]`).Array()
+	require.Equal(t, []int{0}, historyToElide(messages, &filterapi.HistoryPolicy{MaxTurns: 1}))
+}
+
+func TestElideMessages(t *testing.T) {
+	messages := gjson.Get(historyTestBody, "messages").Array()
+	out, err := elideMessages([]byte(historyTestBody), messages, []int{1, 2}, "")
+	require.NoError(t, err)
+	require.Equal(t, []string{"system", "user", "assistant", "tool", "assistant", "user"}, roles(out))
+
+	out, err = elideMessages([]byte(historyTestBody), messages, []int{1, 2, 3, 4, 5, 6}, "The user said hi.")
+	require.NoError(t, err)
+	require.Equal(t, []string{"system", "system", "user"}, roles(out))
+	require.Equal(t, "Summary of the earlier conversation: The user said hi.", gjson.GetBytes(out, "messages.1.content").String())
+	require.Equal(t, "m", gjson.GetBytes(out, "model").String())
+}
+
+func roles(body []byte) (roles []string) {
+	for _, m := range gjson.GetBytes(body, "messages").Array() {
+		roles = append(roles, m.Get("role").String())
+	}
+	return
+}
+
+func TestSummarizeHistory(t *testing.T) {
+	messages := gjson.Get(historyTestBody, "messages").Array()
+	var received []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received, _ = io.ReadAll(r.Body)
+		switch gjson.GetBytes(received, "model").String() {
+		case "slow":
+			time.Sleep(200 * time.Millisecond)
+		case "broken":
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":" The user greeted. "}}]}`))
+	}))
+	defer server.Close()
+
+	summarization := &filterapi.HistorySummarization{URL: server.URL, Model: "cheap", TimeoutMilliseconds: 1000}
+	summary, err := summarizeHistory(t.Context(), summarization, messages, []int{1, 2, 3, 4, 5, 6})
+	require.NoError(t, err)
+	require.Equal(t, "The user greeted.", summary)
+	require.Equal(t, "cheap", gjson.GetBytes(received, "model").String())
+	require.Equal(t, "user: hi\nassistant: hello\nuser: weather?\ntool: sunny\nassistant: It is sunny.\n",
+		gjson.GetBytes(received, "messages.1.content").String())
+
+	_, err = summarizeHistory(t.Context(), &filterapi.HistorySummarization{URL: server.URL, Model: "broken", TimeoutMilliseconds: 1000},
+		messages, []int{1})
+	require.ErrorContains(t, err, "summarization failed with status 500")
+	_, err = summarizeHistory(t.Context(), &filterapi.HistorySummarization{URL: server.URL, Model: "slow", TimeoutMilliseconds: 10},
+		messages, []int{1})
+	require.ErrorContains(t, err, "failed to send the summarization request")
+	_, err = summarizeHistory(t.Context(), summarization, messages, []int{4})
+	require.ErrorContains(t, err, "no text to summarize")
+}
+
+func Test_chatCompletionProcessorUpstreamFilter_applyHistoryPolicy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	var req openai.ChatCompletionRequest
+	require.NoError(t, json.Unmarshal([]byte(historyTestBody), &req))
+	r := &chatCompletionProcessorRouterFilter{
+		config: &filterapi.RuntimeConfig{HistoryPolicies: map[string]*filterapi.HistoryPolicy{
+			"default/route": {
+				RouteName:     "default/route",
+				MaxTurns:      1,
+				Strategy:      filterapi.HistoryElisionStrategySummarize,
+				Summarization: &filterapi.HistorySummarization{URL: server.URL, Model: "m", TimeoutMilliseconds: 1000},
+			},
+		}},
+		originalRequestBodyRaw: []byte(historyTestBody),
+		originalRequestBody:    &req,
+	}
+	p := &chatCompletionProcessorUpstreamFilter{parent: r, routeName: "default/route", logger: slog.Default()}
+	// The elided turns are dropped when the summarization fails.
+	require.NoError(t, p.applyHistoryPolicy(t.Context()))
+	require.Equal(t, []string{"system", "user"}, roles(r.originalRequestBodyRaw))
+	require.Len(t, r.originalRequestBody.Messages, 2)
+	require.True(t, r.forceBodyMutation)
+	require.Equal(t, 6, r.historyElided)
+
+	headerMutation := &extprocv3.HeaderMutation{}
+	setHistoryElidedHeader(headerMutation, r.historyElided)
+	require.Equal(t, internalapi.HistoryElidedHeader, headerMutation.SetHeaders[0].Header.Key)
+	require.Equal(t, "6", string(headerMutation.SetHeaders[0].Header.RawValue))
+
+	// The routes without a policy keep the history.
+	r = &chatCompletionProcessorRouterFilter{config: r.config, originalRequestBodyRaw: []byte(historyTestBody), originalRequestBody: &req}
+	p = &chatCompletionProcessorUpstreamFilter{parent: r, routeName: "default/other", logger: slog.Default()}
+	require.NoError(t, p.applyHistoryPolicy(t.Context()))
+	require.Equal(t, historyTestBody, string(r.originalRequestBodyRaw))
+	require.False(t, r.forceBodyMutation)
+}
@@ -0,0 +1,41 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"github.com/envoyproxy/ai-gateway/internal/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+)
+
+// modelNameHeaderKey returns the request header set to the model name for the routing.
+func modelNameHeaderKey(config *filterapi.RuntimeConfig) string {
+	if config != nil && config.ModelNameHeaderKey != "" {
+		return config.ModelNameHeaderKey
+	}
+	return internalapi.ModelNameHeaderKeyDefault
+}
+
+// metadataNamespace returns the namespace of the dynamic metadata emitted by the processors.
+func metadataNamespace(config *filterapi.RuntimeConfig) string {
+	if config != nil && config.MetadataNamespace != "" {
+		return config.MetadataNamespace
+	}
+	return internalapi.AIGatewayFilterMetadataNamespace
+}
+
+// selectedBackendHeaderKey returns the request header selecting the backend on the routes configuring a
+// BackendOverride.
+func selectedBackendHeaderKey(config *filterapi.RuntimeConfig) string {
+	if config != nil && config.SelectedBackendHeaderKey != "" {
+		return config.SelectedBackendHeaderKey
+	}
+	return internalapi.BackendOverrideHeader
+}
+
+// selectedBackendSignatureHeaderKey returns the request header holding the signature of the selectedBackendHeaderKey.
+func selectedBackendSignatureHeaderKey(config *filterapi.RuntimeConfig) string {
+	return selectedBackendHeaderKey(config) + internalapi.BackendOverrideSignatureHeaderSuffix
+}
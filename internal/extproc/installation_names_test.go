@@ -0,0 +1,141 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"io"
+	"log/slog"
+	"maps"
+	"slices"
+	"testing"
+	"time"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/envoyproxy/ai-gateway/internal/endpointspec"
+	"github.com/envoyproxy/ai-gateway/internal/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+	"github.com/envoyproxy/ai-gateway/internal/tracing/tracingapi"
+)
+
+func Test_installationNames(t *testing.T) {
+	for _, config := range []*filterapi.RuntimeConfig{nil, {}} {
+		require.Equal(t, internalapi.ModelNameHeaderKeyDefault, modelNameHeaderKey(config))
+		require.Equal(t, internalapi.AIGatewayFilterMetadataNamespace, metadataNamespace(config))
+		require.Equal(t, internalapi.BackendOverrideHeader, selectedBackendHeaderKey(config))
+		require.Equal(t, internalapi.BackendOverrideSignatureHeader, selectedBackendSignatureHeaderKey(config))
+	}
+
+	config := &filterapi.RuntimeConfig{
+		ModelNameHeaderKey: "x-model", MetadataNamespace: "io.example.gateway", SelectedBackendHeaderKey: "x-backend",
+	}
+	require.Equal(t, "x-model", modelNameHeaderKey(config))
+	require.Equal(t, "io.example.gateway", metadataNamespace(config))
+	require.Equal(t, "x-backend", selectedBackendHeaderKey(config))
+	require.Equal(t, "x-backend-signature", selectedBackendSignatureHeaderKey(config))
+}
+
+// TestServer_installationNames runs a request through the router and upstream filter streams of a Server configured
+// with non-default installation names, which are the only ones exchanged with Envoy.
+func TestServer_installationNames(t *testing.T) {
+	const (
+		routeName   = "default/route"
+		backendName = "default/openai/route/route/rule/0/ref/0"
+	)
+	s, err := NewServer(slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{})), false)
+	require.NoError(t, err)
+	s.config = &filterapi.RuntimeConfig{
+		ModelNameHeaderKey:       "x-model",
+		MetadataNamespace:        "io.example.gateway",
+		SelectedBackendHeaderKey: "x-backend",
+		Backends: map[string]*filterapi.RuntimeBackend{backendName: {Backend: &filterapi.Backend{
+			Name: backendName, Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI},
+			BackendOverrideName: "default/openai",
+		}}},
+		RequestCosts: []filterapi.RuntimeRequestCost{{LLMRequestCost: &filterapi.LLMRequestCost{
+			RouteName: routeName, Model: "gpt-4o", Type: filterapi.LLMRequestCostTypeOutputToken, MetadataKey: "output_tokens",
+		}}},
+		BackendOverrides: map[string]*filterapi.BackendOverride{routeName: {RouteName: routeName, HMACKey: "key"}},
+	}
+	s.Register("/v1/chat/completions", NewFactory(&mockMetricsFactory{}, tracingapi.NoopChatCompletionTracer{}, endpointspec.ChatCompletionsEndpointSpec{}))
+
+	router := s.newProcessStream(&mockExternalProcessingStream{t: t, ctx: t.Context()})
+	_, err = router.handle(&extprocv3.ProcessingRequest{Request: &extprocv3.ProcessingRequest_RequestHeaders{
+		RequestHeaders: &extprocv3.HttpHeaders{Headers: &corev3.HeaderMap{Headers: []*corev3.HeaderValue{
+			{Key: ":path", Value: "/v1/chat/completions"},
+			{Key: "x-request-id", Value: "req"},
+			// The default model name header is only a client header with the names of the installation.
+			{Key: internalapi.ModelNameHeaderKeyDefault, Value: "spoofed"},
+		}}},
+	}})
+	require.NoError(t, err)
+	resp, err := router.handle(&extprocv3.ProcessingRequest{Request: &extprocv3.ProcessingRequest_RequestBody{
+		RequestBody: &extprocv3.HttpBody{Body: []byte(`{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}]}`), EndOfStream: true},
+	}})
+	require.NoError(t, err)
+	setHeaders := map[string]string{}
+	for _, h := range resp.GetRequestBody().GetResponse().GetHeaderMutation().GetSetHeaders() {
+		setHeaders[h.Header.Key] = string(h.Header.RawValue)
+	}
+	require.Equal(t, "gpt-4o", setHeaders["x-model"])
+	require.NotContains(t, setHeaders, internalapi.ModelNameHeaderKeyDefault)
+
+	upstream := s.newProcessStream(&mockExternalProcessingStream{t: t, ctx: t.Context()})
+	upstreamHeaders := func(headers ...*corev3.HeaderValue) *extprocv3.ProcessingRequest {
+		return &extprocv3.ProcessingRequest{
+			Attributes: map[string]*structpb.Struct{"envoy.filters.http.ext_proc": {Fields: map[string]*structpb.Value{
+				internalapi.XDSUpstreamHostMetadataBackendNamePath: structpb.NewStringValue(backendName),
+				internalapi.XDSRouteMetadataRouteNamePath:          structpb.NewStringValue(routeName),
+			}}},
+			Request: &extprocv3.ProcessingRequest_RequestHeaders{RequestHeaders: &extprocv3.HttpHeaders{
+				Headers: &corev3.HeaderMap{Headers: append([]*corev3.HeaderValue{
+					{Key: originalPathHeader, Value: "/v1/chat/completions"},
+					{Key: internalReqIDHeader, Value: router.internalReqID},
+					{Key: "x-model", Value: "gpt-4o"},
+				}, headers...)},
+			}},
+		}
+	}
+	// The backend is selected with the header of the installation, whose signature is verified.
+	resp, err = upstream.handle(upstreamHeaders(&corev3.HeaderValue{Key: "x-backend", Value: "bedrock"}))
+	require.NoError(t, err)
+	require.Equal(t, typev3.StatusCode_Forbidden, resp.GetImmediateResponse().GetStatus().GetCode())
+	require.Contains(t, string(resp.GetImmediateResponse().GetBody()), "header x-backend-signature is required to pin a backend")
+
+	upstream = s.newProcessStream(&mockExternalProcessingStream{t: t, ctx: t.Context()})
+	resp, err = upstream.handle(upstreamHeaders(
+		&corev3.HeaderValue{Key: "x-backend", Value: "default/openai"},
+		&corev3.HeaderValue{Key: "x-backend-signature", Value: signBackendOverride("key", "default/openai", time.Now().Add(time.Minute))},
+		// The default header doesn't select the backend.
+		&corev3.HeaderValue{Key: internalapi.BackendOverrideHeader, Value: "bedrock"},
+	))
+	require.NoError(t, err)
+	require.Nil(t, resp.GetImmediateResponse())
+	require.Subset(t, resp.GetRequestHeaders().GetResponse().GetHeaderMutation().GetRemoveHeaders(),
+		[]string{"x-backend", "x-backend-signature"})
+
+	// The costs of the model of the header are set in the metadata namespace of the installation.
+	_, err = router.handle(&extprocv3.ProcessingRequest{Request: &extprocv3.ProcessingRequest_ResponseHeaders{
+		ResponseHeaders: &extprocv3.HttpHeaders{Headers: &corev3.HeaderMap{Headers: []*corev3.HeaderValue{
+			{Key: ":status", Value: "200"},
+		}}},
+	}})
+	require.NoError(t, err)
+	resp, err = router.handle(&extprocv3.ProcessingRequest{Request: &extprocv3.ProcessingRequest_ResponseBody{
+		ResponseBody: &extprocv3.HttpBody{Body: []byte(`{"id":"chatcmpl-1","object":"chat.completion","model":"gpt-4o",` +
+			`"choices":[{"index":0,"message":{"role":"assistant","content":"hello"},"finish_reason":"stop"}],` +
+			`"usage":{"prompt_tokens":1,"completion_tokens":7,"total_tokens":8}}`), EndOfStream: true},
+	}})
+	require.NoError(t, err)
+	require.Equal(t, []string{"io.example.gateway"}, slices.Collect(maps.Keys(resp.GetDynamicMetadata().GetFields())))
+	md := resp.GetDynamicMetadata().GetFields()["io.example.gateway"].GetStructValue().GetFields()
+	require.Equal(t, float64(7), md["output_tokens"].GetNumberValue())
+	require.Equal(t, "gpt-4o", md["model_name_override"].GetStringValue())
+}
@@ -0,0 +1,94 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// MetadataEmitter contributes the key-values of a request to its dynamic metadata in the namespace of the filter,
+// "io.envoy.ai_gateway" by default, e.g. a business unit code derived from the headers.
+//
+// The emitters are called for each attempt of a request, when it is sent to the backend, so that the key-values are
+// available to the rate limiting, the access logs and the billing of the request.
+type MetadataEmitter interface {
+	// EmitMetadata returns the key-values of the request. The keys set by the AI Gateway itself, such as the costs
+	// and the backend name, take precedence. An error fails the request.
+	EmitMetadata(ctx context.Context, req *MetadataEmitterRequest) (map[string]string, error)
+}
+
+// MetadataEmitterRequest is the request whose metadata is emitted by a [MetadataEmitter].
+type MetadataEmitterRequest struct {
+	// Headers are the headers of the request, including the ones set by the AI Gateway such as the model name.
+	// They must not be modified.
+	Headers map[string]string
+	// BackendName is the name of the backend the request is sent to.
+	BackendName string
+	// RouteName is the name of the route of the request.
+	RouteName string
+	// Model is the model of the request, after the model name override of the backend if any.
+	Model string
+}
+
+// metadataEmitters holds the registered metadata emitters.
+var metadataEmitters struct {
+	mu       sync.RWMutex
+	names    []string
+	emitters []MetadataEmitter
+}
+
+// RegisterMetadataEmitter registers the metadata emitter with the name, which must be unique. The emitters are called
+// in the order of the registration, so the later ones take precedence over the earlier ones for the same keys.
+// This is expected to be called at the initialization, before serving any request.
+func RegisterMetadataEmitter(name string, emitter MetadataEmitter) error {
+	if name == "" || emitter == nil {
+		return fmt.Errorf("metadata emitter name and emitter must be set")
+	}
+	metadataEmitters.mu.Lock()
+	defer metadataEmitters.mu.Unlock()
+	for _, n := range metadataEmitters.names {
+		if n == name {
+			return fmt.Errorf("metadata emitter %s is already registered", name)
+		}
+	}
+	metadataEmitters.names = append(metadataEmitters.names, name)
+	metadataEmitters.emitters = append(metadataEmitters.emitters, emitter)
+	return nil
+}
+
+// buildEmittedDynamicMetadata builds the dynamic metadata in the namespace of the key-values emitted by the registered
+// metadata emitters for the request, or nil if there are none.
+func buildEmittedDynamicMetadata(ctx context.Context, namespace string, req *MetadataEmitterRequest) (*structpb.Struct, error) {
+	metadataEmitters.mu.RLock()
+	names, emitters := metadataEmitters.names, metadataEmitters.emitters
+	metadataEmitters.mu.RUnlock()
+	if len(emitters) == 0 {
+		return nil, nil
+	}
+
+	fields := make(map[string]*structpb.Value)
+	for i, e := range emitters {
+		kvs, err := e.EmitMetadata(ctx, req)
+		if err != nil {
+			return nil, fmt.Errorf("metadata emitter %s failed: %w", names[i], err)
+		}
+		for k, v := range kvs {
+			fields[k] = structpb.NewStringValue(v)
+		}
+	}
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	return &structpb.Struct{
+		Fields: map[string]*structpb.Value{
+			namespace: structpb.NewStructValue(&structpb.Struct{Fields: fields}),
+		},
+	}, nil
+}
@@ -0,0 +1,104 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+	"github.com/envoyproxy/ai-gateway/internal/json"
+)
+
+// metadataEmitterFunc implements [MetadataEmitter] with a function.
+type metadataEmitterFunc func(ctx context.Context, req *MetadataEmitterRequest) (map[string]string, error)
+
+// EmitMetadata implements [MetadataEmitter.EmitMetadata].
+func (f metadataEmitterFunc) EmitMetadata(ctx context.Context, req *MetadataEmitterRequest) (map[string]string, error) {
+	return f(ctx, req)
+}
+
+// resetMetadataEmitters unregisters the metadata emitters at the end of the test.
+func resetMetadataEmitters(t *testing.T) {
+	t.Cleanup(func() {
+		metadataEmitters.mu.Lock()
+		defer metadataEmitters.mu.Unlock()
+		metadataEmitters.names, metadataEmitters.emitters = nil, nil
+	})
+}
+
+func TestRegisterMetadataEmitter(t *testing.T) {
+	resetMetadataEmitters(t)
+	noop := metadataEmitterFunc(func(context.Context, *MetadataEmitterRequest) (map[string]string, error) { return nil, nil })
+
+	require.ErrorContains(t, RegisterMetadataEmitter("", noop), "must be set")
+	require.ErrorContains(t, RegisterMetadataEmitter("noop", nil), "must be set")
+	require.NoError(t, RegisterMetadataEmitter("noop", noop))
+	require.ErrorContains(t, RegisterMetadataEmitter("noop", noop), "metadata emitter noop is already registered")
+}
+
+func TestBuildEmittedDynamicMetadata(t *testing.T) {
+	resetMetadataEmitters(t)
+	req := &MetadataEmitterRequest{Headers: map[string]string{"x-team": "search"}, BackendName: "ns/backend/route/r/rule/0/ref/0", Model: "gpt-4o"}
+
+	md, err := buildEmittedDynamicMetadata(t.Context(), internalapi.AIGatewayFilterMetadataNamespace, req)
+	require.NoError(t, err)
+	require.Nil(t, md)
+
+	require.NoError(t, RegisterMetadataEmitter("team", metadataEmitterFunc(func(_ context.Context, req *MetadataEmitterRequest) (map[string]string, error) {
+		return map[string]string{"business_unit": "bu-" + req.Headers["x-team"], "model": req.Model}, nil
+	})))
+	require.NoError(t, RegisterMetadataEmitter("override", metadataEmitterFunc(func(context.Context, *MetadataEmitterRequest) (map[string]string, error) {
+		return map[string]string{"model": "overridden"}, nil
+	})))
+	md, err = buildEmittedDynamicMetadata(t.Context(), internalapi.AIGatewayFilterMetadataNamespace, req)
+	require.NoError(t, err)
+	fields := md.Fields[internalapi.AIGatewayFilterMetadataNamespace].GetStructValue().GetFields()
+	require.Len(t, fields, 2)
+	require.Equal(t, "bu-search", fields["business_unit"].GetStringValue())
+	require.Equal(t, "overridden", fields["model"].GetStringValue())
+
+	require.NoError(t, RegisterMetadataEmitter("failing", metadataEmitterFunc(func(context.Context, *MetadataEmitterRequest) (map[string]string, error) {
+		return nil, errors.New("boom")
+	})))
+	_, err = buildEmittedDynamicMetadata(t.Context(), internalapi.AIGatewayFilterMetadataNamespace, req)
+	require.ErrorContains(t, err, "metadata emitter failing failed: boom")
+}
+
+func Test_upstreamProcessor_ProcessRequestHeaders_metadataEmitters(t *testing.T) {
+	resetMetadataEmitters(t)
+	require.NoError(t, RegisterMetadataEmitter("team", metadataEmitterFunc(func(_ context.Context, req *MetadataEmitterRequest) (map[string]string, error) {
+		return map[string]string{"business_unit": req.Headers["x-team"], "route": req.RouteName}, nil
+	})))
+
+	someBody := bodyFromModel(t, "some-model", false, nil)
+	var body openai.ChatCompletionRequest
+	require.NoError(t, json.Unmarshal(someBody, &body))
+	p := &chatCompletionProcessorUpstreamFilter{
+		parent: &chatCompletionProcessorRouterFilter{
+			config:                 &filterapi.RuntimeConfig{},
+			logger:                 slog.Default(),
+			originalRequestBodyRaw: someBody,
+			originalRequestBody:    &body,
+			originalModel:          "some-model",
+		},
+		requestHeaders: map[string]string{":path": "/foo", "x-team": "search"},
+		routeName:      "default/route",
+		metrics:        &mockMetrics{},
+		translator:     &mockTranslator{t: t, expRequestBody: &body},
+	}
+	resp, err := p.ProcessRequestHeaders(t.Context(), nil)
+	require.NoError(t, err)
+	fields := resp.DynamicMetadata.Fields[internalapi.AIGatewayFilterMetadataNamespace].GetStructValue().GetFields()
+	require.Equal(t, "search", fields["business_unit"].GetStringValue())
+	require.Equal(t, "default/route", fields["route"].GetStringValue())
+}
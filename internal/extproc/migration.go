@@ -0,0 +1,382 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	cryptorand "crypto/rand"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+	"github.com/envoyproxy/ai-gateway/internal/json"
+)
+
+// MigrationsPath is the path of the comparisons of the rules with the backends they are migrated to, served by
+// MigrationsHandler on the admin server.
+const MigrationsPath = "/v1/migrations"
+
+const (
+	// maxPendingMigrations is the maximum number of the requests sent to the migration backends at a time. The
+	// sampled requests are skipped beyond it, so that a slow migration backend doesn't pile up the requests.
+	maxPendingMigrations = 64
+	// defaultMigrationRequestTimeout is the timeout of the requests sent to the migration backends of the rules
+	// without a request timeout. The timeout of the rule applies otherwise.
+	defaultMigrationRequestTimeout = time.Minute
+)
+
+// migrationRandIntn returns a random number in [0, n) to sample the requests. This is a variable for testing.
+var migrationRandIntn = rand.IntN
+
+// migrations records the comparisons of the rules with the backends they are migrated to.
+var migrations = newMigrationRecorder()
+
+// MigrationsHandler returns the handler serving the comparisons of the rules with the backends they are migrated to,
+// since the start of the process, as a JSON array of [MigrationStats].
+func MigrationsHandler() http.Handler { return migrations }
+
+// MigrationStats is the comparison of a rule with the backend it is migrated to.
+type MigrationStats struct {
+	// Route is the AIGatewayRoute of the rule, as "namespace/name".
+	Route string `json:"route"`
+	// RuleIndex is the index of the rule in the AIGatewayRoute.
+	RuleIndex int `json:"rule_index"`
+	// Backend is the name of the migration backend, see internalapi.PerRouteRuleRefBackendName.
+	Backend string `json:"backend"`
+	// SampledRequests is the number of requests sent to the migration backend, and ComparedRequests the number of
+	// them whose responses were compared.
+	SampledRequests  int64 `json:"sampled_requests"`
+	ComparedRequests int64 `json:"compared_requests"`
+	// SimilaritySum is the sum of the similarities of the compared responses, each between 0 and 1.
+	SimilaritySum float64 `json:"similarity_sum"`
+	// LatencyMillisecondsSum and MigrationLatencyMillisecondsSum are the sums of the latencies of the compared
+	// requests on the backends of the rule and on the migration backend.
+	LatencyMillisecondsSum          int64 `json:"latency_ms_sum"`
+	MigrationLatencyMillisecondsSum int64 `json:"migration_latency_ms_sum"`
+}
+
+// pendingMigration is a request sent to the migration backend, waiting for its response to be compared with the one
+// returned to the client.
+type pendingMigration struct {
+	migration *filterapi.Migration
+	// text and latency are the text and the latency of the response returned to the client.
+	text    string
+	latency time.Duration
+}
+
+// migrationRecorder holds the requests sent to the migration backends by their nonce, and the comparisons of their
+// responses.
+type migrationRecorder struct {
+	mu      sync.Mutex
+	pending map[string]*pendingMigration
+	stats   map[migrationKey]*MigrationStats
+	// clients are the HTTP clients sending the requests to the listeners of Envoy, by TLS server name.
+	clients map[string]*http.Client
+}
+
+type migrationKey struct {
+	route     string
+	ruleIndex int
+	backend   string
+}
+
+func newMigrationRecorder() *migrationRecorder {
+	return &migrationRecorder{
+		pending: make(map[string]*pendingMigration),
+		stats:   make(map[migrationKey]*MigrationStats),
+		clients: make(map[string]*http.Client),
+	}
+}
+
+// start registers the request sent to the migration backend, and returns its nonce. It returns false if there are
+// too many pending requests.
+func (m *migrationRecorder) start(p *pendingMigration) (string, bool) {
+	nonce := cryptorand.Text()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.pending) >= maxPendingMigrations {
+		return "", false
+	}
+	m.pending[nonce] = p
+	m.statsLocked(p.migration).SampledRequests++
+	return nonce, true
+}
+
+// lookup returns the pending request of the nonce, or nil if there is none.
+func (m *migrationRecorder) lookup(nonce string) *pendingMigration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.pending[nonce]
+}
+
+// compare compares the response of the migration backend to the pending request of the nonce with the one returned
+// to the client. The request is no longer pending afterward.
+func (m *migrationRecorder) compare(nonce, text string, latency time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, ok := m.pending[nonce]
+	if !ok {
+		return
+	}
+	delete(m.pending, nonce)
+	s := m.statsLocked(p.migration)
+	s.ComparedRequests++
+	s.SimilaritySum += textSimilarity(p.text, text)
+	s.LatencyMillisecondsSum += p.latency.Milliseconds()
+	s.MigrationLatencyMillisecondsSum += latency.Milliseconds()
+}
+
+// finish ends the pending request of the nonce, if it wasn't compared, i.e. it failed on the migration backend.
+func (m *migrationRecorder) finish(nonce string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.pending, nonce)
+}
+
+func (m *migrationRecorder) statsLocked(migration *filterapi.Migration) *MigrationStats {
+	key := migrationKey{route: migration.RouteName, ruleIndex: migration.RuleIndex, backend: migration.BackendName}
+	s, ok := m.stats[key]
+	if !ok {
+		s = &MigrationStats{Route: key.route, RuleIndex: key.ruleIndex, Backend: key.backend}
+		m.stats[key] = s
+	}
+	return s
+}
+
+// client returns the HTTP client sending the requests to the listener of Envoy, over TLS with the server name if
+// not empty. The certificate of the listener is verified against the server name even though the requests are sent
+// on the loopback interface.
+func (m *migrationRecorder) client(serverName string) *http.Client {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c, ok := m.clients[serverName]
+	if !ok {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = &tls.Config{ServerName: serverName, MinVersion: tls.VersionTLS12}
+		c = &http.Client{Transport: transport}
+		m.clients[serverName] = c
+	}
+	return c
+}
+
+// ServeHTTP implements [http.Handler].
+func (m *migrationRecorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	m.mu.Lock()
+	stats := make([]MigrationStats, 0, len(m.stats))
+	for _, s := range m.stats {
+		stats = append(stats, *s)
+	}
+	m.mu.Unlock()
+	slices.SortFunc(stats, func(a, b MigrationStats) int {
+		return cmp.Or(strings.Compare(a.Route, b.Route), a.RuleIndex-b.RuleIndex, strings.Compare(a.Backend, b.Backend))
+	})
+	body, err := json.Marshal(stats)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("content-type", "application/json")
+	_, _ = w.Write(body)
+}
+
+// textSimilarity returns the Jaccard similarity of the sets of the lower-cased words of the texts, from 0 for the
+// texts without any word in common to 1 for the texts with the same words.
+func textSimilarity(a, b string) float64 {
+	wordsA, wordsB := make(map[string]struct{}), make(map[string]struct{})
+	for _, w := range strings.Fields(strings.ToLower(a)) {
+		wordsA[w] = struct{}{}
+	}
+	for _, w := range strings.Fields(strings.ToLower(b)) {
+		wordsB[w] = struct{}{}
+	}
+	if len(wordsA) == 0 && len(wordsB) == 0 {
+		return 1
+	}
+	var common int
+	for w := range wordsA {
+		if _, ok := wordsB[w]; ok {
+			common++
+		}
+	}
+	return float64(common) / float64(len(wordsA)+len(wordsB)-common)
+}
+
+// chatCompletionText returns the text of the choices of the chat completion response body, or the body itself if it
+// isn't a chat completion.
+func chatCompletionText(body []byte) string {
+	var resp openai.ChatCompletionResponse
+	if err := json.Unmarshal(body, &resp); err != nil || len(resp.Choices) == 0 {
+		return string(body)
+	}
+	texts := make([]string, 0, len(resp.Choices))
+	for i := range resp.Choices {
+		if c := resp.Choices[i].Message.Content; c != nil {
+			texts = append(texts, *c)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// downstreamConnectionKey is the context key of the downstream connection of the response headers.
+type downstreamConnectionKey struct{}
+
+// downstreamConnection is the listener of Envoy that received the request.
+type downstreamConnection struct {
+	port int
+	tls  bool
+}
+
+// withDownstreamConnection stores the downstream connection found in the attributes of the response headers. These
+// are only sent to the router filter, which sends the requests to the migration backends to the same listener.
+func withDownstreamConnection(ctx context.Context, attributes map[string]*structpb.Struct) context.Context {
+	fields := attributes["envoy.filters.http.ext_proc"].GetFields()
+	port, ok := fields[internalapi.DownstreamLocalPortAttribute]
+	if !ok {
+		return ctx
+	}
+	conn := downstreamConnection{port: int(port.GetNumberValue()), tls: fields[internalapi.DownstreamTLSVersionAttribute].GetStringValue() != ""}
+	if s := port.GetStringValue(); s != "" {
+		conn.port, _ = strconv.Atoi(s)
+	}
+	return context.WithValue(ctx, downstreamConnectionKey{}, conn)
+}
+
+// verifyMigrationRequest verifies the nonce of the request sent to the migration backend. The requests setting the
+// "x-ai-eg-migration-rule" request header without a valid nonce are rejected, since the header would route them to
+// the migration backend. It returns the response rejecting the request, or nil if the request can proceed.
+func (r *routerProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) verifyMigrationRequest() *extprocv3.ProcessingResponse {
+	rule, ok := r.requestHeaders[internalapi.MigrationRuleHeader]
+	if !ok {
+		return nil
+	}
+	nonce := r.requestHeaders[internalapi.MigrationNonceHeader]
+	p := migrations.lookup(nonce)
+	if p == nil || rule != strconv.Itoa(p.migration.RuleIndex) {
+		r.logger.Info("rejecting request with invalid migration nonce")
+		return createUserFacingErrorResponse(403, "Forbidden", "invalid migration nonce")
+	}
+	r.migrationNonce, r.migrationShadow = nonce, p
+	return nil
+}
+
+// migrationOfRule returns the migration of the rule of the backend, or nil if the rule isn't migrated.
+func (u *upstreamProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) migrationOfRule() *filterapi.Migration {
+	if u.parent.config == nil {
+		return nil
+	}
+	ruleIndex, ok := internalapi.PerRouteRuleIndex(u.backendName)
+	if !ok {
+		return nil
+	}
+	for _, m := range u.parent.config.Migrations[u.routeName] {
+		if m.RuleIndex == ruleIndex {
+			return m
+		}
+	}
+	return nil
+}
+
+// compareMigrationResponse compares the response of the chat completion, whose body is sent to the client. The request of
+// a migrated rule is sampled and sent again to the migration backend, and the response of the migration backend is
+// compared with the one returned to the client.
+func (u *upstreamProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) compareMigrationResponse(ctx context.Context, body []byte) {
+	latency := time.Since(u.requestStart)
+	if u.parent.migrationShadow != nil {
+		migrations.compare(u.parent.migrationNonce, chatCompletionText(body), latency)
+		return
+	}
+	m := u.migrationOfRule()
+	if m == nil || u.downstream.port == 0 || migrationRandIntn(100) >= m.SamplePercent {
+		return
+	}
+	nonce, ok := migrations.start(&pendingMigration{migration: m, text: chatCompletionText(body), latency: latency})
+	if !ok {
+		u.logger.Info("skipping the migration of the request since too many are pending", slog.String("backend", m.BackendName))
+		return
+	}
+	// The request outlives the client request, and is bounded by the request timeout of the rule instead.
+	timeout := defaultMigrationRequestTimeout
+	if m.TimeoutMilliseconds > 0 {
+		timeout = time.Duration(m.TimeoutMilliseconds) * time.Millisecond
+	}
+	reqCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+	req, err := u.newMigrationRequest(reqCtx, m, nonce)
+	if err != nil {
+		cancel()
+		migrations.finish(nonce)
+		u.logger.Error("failed to create the migration request", slog.String("error", err.Error()))
+		return
+	}
+	serverName := ""
+	if u.downstream.tls {
+		serverName, _, _ = strings.Cut(u.parent.requestHeaders[":authority"], ":")
+	}
+	client := migrations.client(serverName)
+	logger := u.logger
+	go func() {
+		defer cancel()
+		defer migrations.finish(nonce)
+		resp, err := client.Do(req)
+		if err != nil {
+			logger.Info("failed to send the request to the migration backend", slog.String("backend", m.BackendName), slog.String("error", err.Error()))
+			return
+		}
+		defer func() { _ = resp.Body.Close() }()
+		_, _ = io.Copy(io.Discard, resp.Body)
+	}()
+}
+
+// newMigrationRequest creates the request sent again to the listener of Envoy that received the client request,
+// with the headers routing it to the migration backend. The route of the migration backend only matches the requests
+// with these headers, and it has no rate limits so that the request doesn't consume the quota of the client. The
+// request still carries the headers of the client, since it goes through the same authentication.
+func (u *upstreamProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) newMigrationRequest(ctx context.Context, m *filterapi.Migration, nonce string) (*http.Request, error) {
+	rp := u.parent
+	scheme := "http"
+	if u.downstream.tls {
+		scheme = "https"
+	}
+	path := cmp.Or(rp.requestHeaders[originalPathHeader], rp.requestHeaders[":path"])
+	url := fmt.Sprintf("%s://%s%s", scheme, net.JoinHostPort("127.0.0.1", strconv.Itoa(u.downstream.port)), path)
+	// The body is decompressed, so it is sent without the content encoding of the client.
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(rp.originalRequestBodyRaw))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range rp.requestHeaders {
+		switch {
+		case strings.HasPrefix(k, ":"), strings.HasPrefix(k, internalapi.EnvoyAIGatewayHeaderPrefix), strings.HasPrefix(k, "x-envoy-"):
+		case k == "content-length", k == "content-encoding", k == "transfer-encoding", k == "connection", k == "host":
+		default:
+			req.Header.Set(k, v)
+		}
+	}
+	req.Host = rp.requestHeaders[":authority"]
+	req.Header.Set(internalapi.MigrationRuleHeader, strconv.Itoa(m.RuleIndex))
+	req.Header.Set(internalapi.MigrationNonceHeader, nonce)
+	return req, nil
+}
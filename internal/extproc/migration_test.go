@@ -0,0 +1,248 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/envoyproxy/ai-gateway/internal/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+	"github.com/envoyproxy/ai-gateway/internal/json"
+)
+
+func TestTextSimilarity(t *testing.T) {
+	require.Equal(t, 1.0, textSimilarity("", ""))
+	require.Equal(t, 1.0, textSimilarity("Hello world", "world hello"))
+	require.Equal(t, 0.0, textSimilarity("hello", "bye"))
+	require.InDelta(t, 1.0/3, textSimilarity("hello world", "hello there"), 1e-9)
+}
+
+func TestChatCompletionText(t *testing.T) {
+	require.Equal(t, "a\nb", chatCompletionText([]byte(`{"choices":[{"message":{"content":"a"}},{"message":{"content":"b"}}]}`)))
+	require.Equal(t, "not json", chatCompletionText([]byte("not json")))
+}
+
+func TestWithDownstreamConnection(t *testing.T) {
+	require.Nil(t, t.Context().Value(downstreamConnectionKey{}))
+	ctx := withDownstreamConnection(t.Context(), map[string]*structpb.Struct{"envoy.filters.http.ext_proc": {Fields: map[string]*structpb.Value{
+		internalapi.DownstreamLocalPortAttribute:  structpb.NewNumberValue(10443),
+		internalapi.DownstreamTLSVersionAttribute: structpb.NewStringValue("TLSv1.3"),
+	}}})
+	require.Equal(t, downstreamConnection{port: 10443, tls: true}, ctx.Value(downstreamConnectionKey{}))
+	ctx = withDownstreamConnection(t.Context(), map[string]*structpb.Struct{"envoy.filters.http.ext_proc": {Fields: map[string]*structpb.Value{
+		internalapi.DownstreamLocalPortAttribute: structpb.NewStringValue("10080"),
+	}}})
+	require.Equal(t, downstreamConnection{port: 10080}, ctx.Value(downstreamConnectionKey{}))
+	require.Equal(t, t.Context(), withDownstreamConnection(t.Context(), nil))
+}
+
+func TestMigrationRecorder(t *testing.T) {
+	m := newMigrationRecorder()
+	migration := &filterapi.Migration{RouteName: "default/route", RuleIndex: 1, BackendName: "default/b/route/route/rule/3/ref/0"}
+	compared, ok := m.start(&pendingMigration{migration: migration, text: "hello world", latency: 100 * time.Millisecond})
+	require.True(t, ok)
+	failed, ok := m.start(&pendingMigration{migration: migration, text: "hello", latency: time.Second})
+	require.True(t, ok)
+	require.NotEqual(t, compared, failed)
+	require.NotNil(t, m.lookup(compared))
+	m.compare(compared, "hello there", 300*time.Millisecond)
+	m.finish(compared)
+	m.finish(failed)
+	require.Nil(t, m.lookup(compared))
+	require.Nil(t, m.lookup(failed))
+	// The nonces are single-use.
+	m.compare(failed, "hello", time.Second)
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, MigrationsPath, nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.JSONEq(t, `[{"route":"default/route","rule_index":1,"backend":"default/b/route/route/rule/3/ref/0",
+"sampled_requests":2,"compared_requests":1,"similarity_sum":0.3333333333333333,"latency_ms_sum":100,"migration_latency_ms_sum":300}]`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, MigrationsPath, nil))
+	require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+
+	t.Run("too many pending", func(t *testing.T) {
+		m := newMigrationRecorder()
+		for range maxPendingMigrations {
+			_, ok := m.start(&pendingMigration{migration: migration})
+			require.True(t, ok)
+		}
+		_, ok := m.start(&pendingMigration{migration: migration})
+		require.False(t, ok)
+	})
+}
+
+func Test_chatCompletionProcessorRouterFilter_verifyMigrationRequest(t *testing.T) {
+	m := newMigrationRecorder()
+	original := migrations
+	migrations = m
+	t.Cleanup(func() { migrations = original })
+	nonce, ok := m.start(&pendingMigration{migration: &filterapi.Migration{RouteName: "default/route", RuleIndex: 1}})
+	require.True(t, ok)
+
+	// The client requests proceed.
+	p := newTestUpstreamFilter(t, nil, "default/route", "").parent
+	require.Nil(t, p.verifyMigrationRequest())
+	require.Nil(t, p.migrationShadow)
+	// The requests sent again to the migration backend proceed.
+	p = newTestUpstreamFilter(t, nil, "default/route", "").parent
+	p.requestHeaders = map[string]string{internalapi.MigrationRuleHeader: "1", internalapi.MigrationNonceHeader: nonce}
+	require.Nil(t, p.verifyMigrationRequest())
+	require.Equal(t, m.lookup(nonce), p.migrationShadow)
+	require.Equal(t, nonce, p.migrationNonce)
+
+	for _, headers := range []map[string]string{
+		{internalapi.MigrationRuleHeader: "1"},
+		{internalapi.MigrationRuleHeader: "1", internalapi.MigrationNonceHeader: "invalid"},
+		{internalapi.MigrationRuleHeader: "0", internalapi.MigrationNonceHeader: nonce},
+	} {
+		p = newTestUpstreamFilter(t, nil, "default/route", "").parent
+		p.requestHeaders = headers
+		res := p.verifyMigrationRequest()
+		require.Equal(t, typev3.StatusCode_Forbidden, res.GetImmediateResponse().GetStatus().GetCode())
+	}
+}
+
+func Test_chatCompletionProcessorUpstreamFilter_compareMigrationResponse(t *testing.T) {
+	m := newMigrationRecorder()
+	originalMigrations, originalRandIntn := migrations, migrationRandIntn
+	migrations = m
+	migrationRandIntn = func(int) int { return 9 }
+	t.Cleanup(func() { migrations, migrationRandIntn = originalMigrations, originalRandIntn })
+
+	migration := &filterapi.Migration{
+		RouteName: "default/route", RuleIndex: 0, SamplePercent: 10,
+		BackendName: internalapi.PerRouteRuleRefBackendName("default", "new", "route", 2, 0),
+	}
+	config := &filterapi.RuntimeConfig{Migrations: map[string][]*filterapi.Migration{"default/route": {migration}}}
+
+	// The gateway receiving the request sent again, which compares the response of the migration backend.
+	received := make(chan *http.Request, 1)
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"model":"gpt-4o","messages":[]}`, string(body))
+		shadow := newTestUpstreamFilter(t, config, "default/route", "")
+		shadow.parent.migrationShadow = m.lookup(r.Header.Get(internalapi.MigrationNonceHeader))
+		shadow.parent.migrationNonce = r.Header.Get(internalapi.MigrationNonceHeader)
+		shadow.backendName, shadow.requestStart = migration.BackendName, time.Now().Add(-time.Second)
+		require.NotNil(t, shadow.parent.migrationShadow)
+		shadow.compareMigrationResponse(r.Context(), []byte(`{"choices":[{"message":{"content":"hello there"}}]}`))
+		received <- r
+	}))
+	defer gateway.Close()
+	u, err := url.Parse(gateway.URL)
+	require.NoError(t, err)
+	port, err := strconv.Atoi(u.Port())
+	require.NoError(t, err)
+
+	compare := func(backendName string) {
+		p := newTestUpstreamFilter(t, config, "default/route", `{"model":"gpt-4o","messages":[]}`)
+		p.parent.requestHeaders = map[string]string{
+			":path": "/v1/chat/completions", ":authority": "gateway.example.com", "authorization": "Bearer key",
+			"content-length": "32", internalapi.ModelNameHeaderKeyDefault: "gpt-4o",
+		}
+		p.backendName, p.requestStart = backendName, time.Now().Add(-500*time.Millisecond)
+		p.downstream = downstreamConnection{port: port}
+		p.compareMigrationResponse(t.Context(), []byte(`{"choices":[{"message":{"content":"hello world"}}]}`))
+	}
+
+	// The requests of the other rules are not sampled.
+	compare(internalapi.PerRouteRuleRefBackendName("default", "old", "route", 1, 0))
+	// The requests above the sample percentage are not sampled.
+	migrationRandIntn = func(int) int { return 10 }
+	compare(internalapi.PerRouteRuleRefBackendName("default", "old", "route", 0, 0))
+	select {
+	case <-received:
+		t.Fatal("unexpected migration request")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	migrationRandIntn = func(int) int { return 9 }
+	compare(internalapi.PerRouteRuleRefBackendName("default", "old", "route", 0, 0))
+	r := <-received
+	require.Equal(t, "/v1/chat/completions", r.URL.Path)
+	require.Equal(t, "gateway.example.com", r.Host)
+	require.Equal(t, "Bearer key", r.Header.Get("authorization"))
+	require.Equal(t, "0", r.Header.Get(internalapi.MigrationRuleHeader))
+	require.Empty(t, r.Header.Get(internalapi.ModelNameHeaderKeyDefault))
+
+	require.Eventually(t, func() bool {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		return len(m.pending) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, MigrationsPath, nil))
+	var stats []MigrationStats
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &stats))
+	require.Len(t, stats, 1)
+	require.Equal(t, int64(1), stats[0].SampledRequests)
+	require.Equal(t, int64(1), stats[0].ComparedRequests)
+	require.InDelta(t, 1.0/3, stats[0].SimilaritySum, 1e-9)
+	require.GreaterOrEqual(t, stats[0].LatencyMillisecondsSum, int64(500))
+	require.GreaterOrEqual(t, stats[0].MigrationLatencyMillisecondsSum, int64(1000))
+}
+
+func Test_chatCompletionProcessorUpstreamFilter_compareMigrationResponse_timeout(t *testing.T) {
+	m := newMigrationRecorder()
+	originalMigrations, originalRandIntn := migrations, migrationRandIntn
+	migrations = m
+	migrationRandIntn = func(int) int { return 0 }
+	t.Cleanup(func() { migrations, migrationRandIntn = originalMigrations, originalRandIntn })
+
+	// The migration backend never responds, so the request is cancelled at the request timeout of the rule.
+	cancelled := make(chan struct{})
+	gateway := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		// The server only watches the connection once the body is read.
+		_, _ = io.ReadAll(r.Body)
+		<-r.Context().Done()
+		close(cancelled)
+	}))
+	defer gateway.Close()
+	u, err := url.Parse(gateway.URL)
+	require.NoError(t, err)
+	port, err := strconv.Atoi(u.Port())
+	require.NoError(t, err)
+
+	migration := &filterapi.Migration{
+		RouteName: "default/route", RuleIndex: 0, SamplePercent: 100, TimeoutMilliseconds: 50,
+		BackendName: internalapi.PerRouteRuleRefBackendName("default", "new", "route", 2, 0),
+	}
+	p := newTestUpstreamFilter(t, &filterapi.RuntimeConfig{Migrations: map[string][]*filterapi.Migration{"default/route": {migration}}},
+		"default/route", `{"model":"gpt-4o","messages":[]}`)
+	p.parent.requestHeaders = map[string]string{":path": "/v1/chat/completions", ":authority": "gateway.example.com"}
+	p.backendName = internalapi.PerRouteRuleRefBackendName("default", "old", "route", 0, 0)
+	p.requestStart, p.downstream = time.Now(), downstreamConnection{port: port}
+	p.compareMigrationResponse(t.Context(), []byte(`{"choices":[{"message":{"content":"hello"}}]}`))
+	select {
+	case <-cancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the migration request was not cancelled at the timeout of the rule")
+	}
+	require.Eventually(t, func() bool {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		return len(m.pending) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// The certificate of the listener is verified.
+	transport := m.client("gateway.example.com").Transport.(*http.Transport)
+	require.False(t, transport.TLSClientConfig.InsecureSkipVerify)
+	require.Equal(t, "gateway.example.com", transport.TLSClientConfig.ServerName)
+}
@@ -15,6 +15,7 @@ import (
 	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc/metadata"
 
 	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
@@ -188,6 +189,17 @@ type mockMetrics struct {
 	interTokenLatency     float64
 	timeToFirstTokenMs    float64
 	interTokenLatencyMs   float64
+	// rateLimitHeaders is the response headers passed to the last RecordProviderRateLimits call.
+	rateLimitHeaders map[string]string
+	// truncationReasons are the reasons passed to the RecordResponseTruncated calls.
+	truncationReasons []metrics.ResponseTruncationReason
+	// contextLengthExceeded is the number of the RecordContextLengthExceeded calls.
+	contextLengthExceeded int
+	// phases and timedOutPhases are the phases passed to the RecordPhaseDuration calls.
+	phases         []metrics.UpstreamPhase
+	timedOutPhases []metrics.UpstreamPhase
+	// spanContext is the span context passed to SetSpanContext.
+	spanContext trace.SpanContext
 }
 
 // StartRequest implements [metrics.Metrics].
@@ -211,6 +223,9 @@ func (m *mockMetrics) SetResponseModel(responseModel internalapi.ResponseModel)
 // SetBackend implements [metrics.Metrics].
 func (m *mockMetrics) SetBackend(backend *filterapi.Backend) { m.backend = backend.Name }
 
+// SetSpanContext implements [metrics.Metrics].
+func (m *mockMetrics) SetSpanContext(spanContext trace.SpanContext) { m.spanContext = spanContext }
+
 // RecordTokenUsage implements [metrics.Metrics].
 func (m *mockMetrics) RecordTokenUsage(_ context.Context, usage metrics.TokenUsage, _ map[string]string) {
 	if input, ok := usage.InputTokens(); ok {
@@ -227,6 +242,30 @@ func (m *mockMetrics) RecordTokenUsage(_ context.Context, usage metrics.TokenUsa
 	}
 }
 
+// RecordProviderRateLimits implements [metrics.Metrics].
+func (m *mockMetrics) RecordProviderRateLimits(_ context.Context, responseHeaders map[string]string) {
+	m.rateLimitHeaders = responseHeaders
+}
+
+// RecordResponseTruncated implements [metrics.Metrics].
+func (m *mockMetrics) RecordResponseTruncated(_ context.Context, reason metrics.ResponseTruncationReason, _ map[string]string) {
+	m.truncationReasons = append(m.truncationReasons, reason)
+}
+
+// RecordContextLengthExceeded implements [metrics.Metrics].
+func (m *mockMetrics) RecordContextLengthExceeded(context.Context, map[string]string) {
+	m.contextLengthExceeded++
+}
+
+// RecordPhaseDuration implements [metrics.Metrics].
+func (m *mockMetrics) RecordPhaseDuration(_ context.Context, phase metrics.UpstreamPhase, timedOut bool, _ map[string]string) {
+	if timedOut {
+		m.timedOutPhases = append(m.timedOutPhases, phase)
+		return
+	}
+	m.phases = append(m.phases, phase)
+}
+
 // RecordTokenLatency implements [metrics.Metrics].
 // For streaming responses, this tracks output tokens incrementally to compute latency metrics.
 func (m *mockMetrics) RecordTokenLatency(_ context.Context, output uint32, _ bool, _ map[string]string) {
@@ -311,6 +350,17 @@ func (m *mockBackendAuthHandler) Do(context.Context, map[string]string, []byte)
 	return []internalapi.Header{{"foo", "mock-auth-handler"}}, nil
 }
 
+// mockObservingBackendAuthHandler implements [filterapi.BackendAuthResponseObserver] for testing.
+type mockObservingBackendAuthHandler struct {
+	mockBackendAuthHandler
+	statuses []int
+}
+
+// ObserveResponse implements [filterapi.BackendAuthResponseObserver.ObserveResponse].
+func (m *mockObservingBackendAuthHandler) ObserveResponse(_ context.Context, _ map[string]string, status int) {
+	m.statuses = append(m.statuses, status)
+}
+
 // mockBackendAuthHandlerError implements [filterapi.BackendAuthHandler] for testing auth errors.
 type mockBackendAuthHandlerError struct {
 	err error
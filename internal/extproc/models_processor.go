@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"log/slog"
 	"net"
+	"slices"
 	"strings"
 
 	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
@@ -49,6 +50,9 @@ func NewModelsProcessor(config *filterapi.RuntimeConfig, requestHeaders map[stri
 		Data:   make([]openai.Model, 0, len(selectedModels)),
 	}
 	for _, m := range selectedModels {
+		if !modelVisible(m.Visibility, requestHeaders) {
+			continue
+		}
 		modelList.Data = append(modelList.Data, openai.Model{
 			ID:      m.Name,
 			Object:  "model",
@@ -106,6 +110,15 @@ func requestHost(headers map[string]string) string {
 	return strings.ToLower(host)
 }
 
+// modelVisible returns true if a model with the given visibility is listed to the request with the given headers.
+func modelVisible(visibility *filterapi.ModelVisibility, requestHeaders map[string]string) bool {
+	if visibility == nil {
+		return true
+	}
+	tenant := strings.TrimSpace(requestHeaders[visibility.TenantHeader])
+	return tenant != "" && slices.Contains(visibility.Tenants, tenant)
+}
+
 // selectModelsForHost returns the models for the given host, falling back to the global list.
 func selectModelsForHost(host string, cfg *filterapi.RuntimeConfig) []filterapi.Model {
 	if host == "" || len(cfg.ModelsByHost) == 0 {
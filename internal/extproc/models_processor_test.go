@@ -61,6 +61,41 @@ func TestModels_ProcessRequestHeaders(t *testing.T) {
 	}
 }
 
+func TestModels_Visibility(t *testing.T) {
+	teamAB := &filterapi.ModelVisibility{TenantHeader: "x-tenant-id", Tenants: []string{"team-a", "team-b"}}
+	cfg := &filterapi.RuntimeConfig{DeclaredModels: []filterapi.Model{
+		{Name: "public"},
+		{Name: "restricted", Visibility: teamAB},
+		{Name: "team-c-only", Visibility: &filterapi.ModelVisibility{TenantHeader: "x-tenant-id", Tenants: []string{"team-c"}}},
+	}}
+
+	for _, tc := range []struct {
+		name      string
+		headers   map[string]string
+		expModels []string
+	}{
+		{name: "no tenant", headers: map[string]string{}, expModels: []string{"public"}},
+		{name: "allowed tenant", headers: map[string]string{"x-tenant-id": "team-b"}, expModels: []string{"public", "restricted"}},
+		{name: "other tenant", headers: map[string]string{"x-tenant-id": "team-c"}, expModels: []string{"public", "team-c-only"}},
+		{name: "unknown tenant", headers: map[string]string{"x-tenant-id": "team-z"}, expModels: []string{"public"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := NewModelsProcessor(cfg, tc.headers, slog.Default(), false, false)
+			require.NoError(t, err)
+			res, err := p.ProcessRequestHeaders(t.Context(), &corev3.HeaderMap{})
+			require.NoError(t, err)
+
+			var models openai.ModelList
+			require.NoError(t, json.Unmarshal(res.GetImmediateResponse().Body, &models))
+			var actual []string
+			for _, m := range models.Data {
+				actual = append(actual, m.ID)
+			}
+			require.Equal(t, tc.expModels, actual)
+		})
+	}
+}
+
 func headers(in []*corev3.HeaderValueOption) map[string]string {
 	h := make(map[string]string)
 	for _, v := range in {
@@ -0,0 +1,113 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/anthropic"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/filterapi"
+)
+
+const (
+	// parameterOverrideHeaderPrefix is the prefix of the request headers overriding the sampling parameters.
+	parameterOverrideHeaderPrefix = "x-aigw-param-"
+	// temperatureOverrideHeader overrides the temperature of the request.
+	temperatureOverrideHeader = parameterOverrideHeaderPrefix + "temperature"
+	// maxTokensOverrideHeader overrides the maximum number of the output tokens of the request.
+	maxTokensOverrideHeader = parameterOverrideHeaderPrefix + "max-tokens"
+)
+
+// parameterOverrideMaxTokensField returns the field of the request body holding the maximum number of the output
+// tokens, or empty if the sampling parameters of the request cannot be overridden.
+func parameterOverrideMaxTokensField(req any, body []byte) string {
+	switch req.(type) {
+	case *openai.ChatCompletionRequest:
+		// max_tokens is deprecated in favor of max_completion_tokens, which is kept if the client uses it.
+		if gjson.GetBytes(body, "max_completion_tokens").Exists() {
+			return "max_completion_tokens"
+		}
+		return "max_tokens"
+	case *openai.CompletionRequest, *anthropic.MessagesRequest:
+		return "max_tokens"
+	case *openai.ResponseRequest:
+		return "max_output_tokens"
+	default:
+		return ""
+	}
+}
+
+// overrideParameters returns the JSON request body with the sampling parameters overridden by the "x-aigw-param-*"
+// request headers, or nil if the request has none of them. The overridden values must be within the bounds of the
+// route, and the returned error is meant to be returned to the client.
+func overrideParameters(o *filterapi.ParameterOverrides, headers map[string]string, body []byte, maxTokensField string) ([]byte, error) {
+	var names []string
+	for k := range headers {
+		if strings.HasPrefix(k, parameterOverrideHeaderPrefix) {
+			names = append(names, k)
+		}
+	}
+	if len(names) == 0 {
+		return nil, nil
+	}
+	slices.Sort(names) // For the deterministic error messages.
+
+	out := slices.Clone(body)
+	for _, name := range names {
+		value := strings.TrimSpace(headers[name])
+		var err error
+		switch name {
+		case temperatureOverrideHeader:
+			b := o.Temperature
+			if b == nil {
+				return nil, fmt.Errorf("header %s is not allowed on this route", name)
+			}
+			t, parseErr := strconv.ParseFloat(value, 64)
+			if parseErr != nil || math.IsNaN(t) || t < b.Min || t > b.Max {
+				return nil, fmt.Errorf("header %s must be a number between %v and %v, got %q", name, b.Min, b.Max, value)
+			}
+			out, err = sjson.SetBytes(out, "temperature", t)
+		case maxTokensOverrideHeader:
+			b := o.MaxTokens
+			if b == nil {
+				return nil, fmt.Errorf("header %s is not allowed on this route", name)
+			}
+			n, parseErr := strconv.Atoi(value)
+			if parseErr != nil || n < b.Min || n > b.Max {
+				return nil, fmt.Errorf("header %s must be an integer between %d and %d, got %q", name, b.Min, b.Max, value)
+			}
+			out, err = sjson.SetBytes(out, maxTokensField, n)
+		default:
+			return nil, fmt.Errorf("unsupported parameter override header %s", name)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to apply header %s: %w", name, err)
+		}
+	}
+	return out, nil
+}
+
+// removeParameterOverrideHeaders removes the "x-aigw-param-*" request headers from the request sent to the backend,
+// whether the route allows them or not.
+func removeParameterOverrideHeaders(headerMutation *extprocv3.HeaderMutation, requestHeaders map[string]string) {
+	var names []string
+	for k := range requestHeaders {
+		if strings.HasPrefix(k, parameterOverrideHeaderPrefix) {
+			names = append(names, k)
+		}
+	}
+	slices.Sort(names)
+	headerMutation.RemoveHeaders = append(headerMutation.RemoveHeaders, names...)
+}
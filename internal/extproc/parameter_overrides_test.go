@@ -0,0 +1,160 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"testing"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/anthropic"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/endpointspec"
+	"github.com/envoyproxy/ai-gateway/internal/filterapi"
+)
+
+func TestParameterOverrideMaxTokensField(t *testing.T) {
+	require.Equal(t, "max_tokens", parameterOverrideMaxTokensField(&openai.ChatCompletionRequest{}, []byte(`{"model":"m"}`)))
+	require.Equal(t, "max_completion_tokens", parameterOverrideMaxTokensField(&openai.ChatCompletionRequest{},
+		[]byte(`{"model":"m","max_completion_tokens":10}`)))
+	require.Equal(t, "max_tokens", parameterOverrideMaxTokensField(&openai.CompletionRequest{}, nil))
+	require.Equal(t, "max_tokens", parameterOverrideMaxTokensField(&anthropic.MessagesRequest{}, nil))
+	require.Equal(t, "max_output_tokens", parameterOverrideMaxTokensField(&openai.ResponseRequest{}, nil))
+	require.Empty(t, parameterOverrideMaxTokensField(&openai.EmbeddingRequest{}, nil))
+}
+
+func TestOverrideParameters(t *testing.T) {
+	bounds := &filterapi.ParameterOverrides{
+		RouteName:   "default/route",
+		Temperature: &filterapi.TemperatureBounds{Min: 0, Max: 1.5},
+		MaxTokens:   &filterapi.MaxTokensBounds{Min: 1, Max: 1000},
+	}
+	const body = `{"model":"m","temperature":1}`
+	for _, tc := range []struct {
+		name    string
+		bounds  *filterapi.ParameterOverrides
+		headers map[string]string
+		exp     string
+		expErr  string
+	}{
+		{
+			name:    "no headers",
+			bounds:  bounds,
+			headers: map[string]string{"x-user-id": "alice"},
+		},
+		{
+			name:    "both",
+			bounds:  bounds,
+			headers: map[string]string{temperatureOverrideHeader: " 0.3", maxTokensOverrideHeader: "100"},
+			exp:     `{"model":"m","temperature":0.3,"max_tokens":100}`,
+		},
+		{
+			name:    "temperature out of bounds",
+			bounds:  bounds,
+			headers: map[string]string{temperatureOverrideHeader: "2"},
+			expErr:  `header x-aigw-param-temperature must be a number between 0 and 1.5, got "2"`,
+		},
+		{
+			name:    "max tokens not an integer",
+			bounds:  bounds,
+			headers: map[string]string{maxTokensOverrideHeader: "1e3"},
+			expErr:  `header x-aigw-param-max-tokens must be an integer between 1 and 1000, got "1e3"`,
+		},
+		{
+			name:    "not allowed",
+			bounds:  &filterapi.ParameterOverrides{RouteName: "default/route", Temperature: bounds.Temperature},
+			headers: map[string]string{maxTokensOverrideHeader: "100"},
+			expErr:  `header x-aigw-param-max-tokens is not allowed on this route`,
+		},
+		{
+			name:    "unsupported",
+			bounds:  bounds,
+			headers: map[string]string{"x-aigw-param-top-p": "0.9"},
+			expErr:  `unsupported parameter override header x-aigw-param-top-p`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := overrideParameters(tc.bounds, tc.headers, []byte(body), "max_tokens")
+			if tc.expErr != "" {
+				require.EqualError(t, err, tc.expErr)
+				return
+			}
+			require.NoError(t, err)
+			if tc.exp == "" {
+				require.Nil(t, out)
+				return
+			}
+			require.JSONEq(t, tc.exp, string(out))
+		})
+	}
+}
+
+func Test_chatCompletionProcessorUpstreamFilter_overrideParameters(t *testing.T) {
+	body := []byte(`{"model":"m","messages":[],"max_completion_tokens":2000}`)
+	r := &chatCompletionProcessorRouterFilter{
+		config: &filterapi.RuntimeConfig{ParameterOverrides: map[string]*filterapi.ParameterOverrides{
+			"default/route": {RouteName: "default/route", MaxTokens: &filterapi.MaxTokensBounds{Min: 1, Max: 1000}},
+		}},
+		originalRequestBodyRaw: body,
+		originalRequestBody:    &openai.ChatCompletionRequest{Model: "m", MaxCompletionTokens: ptr.To[int64](2000)},
+	}
+	p := &chatCompletionProcessorUpstreamFilter{
+		parent:         r,
+		routeName:      "default/route",
+		requestHeaders: map[string]string{maxTokensOverrideHeader: "500"},
+	}
+	require.NoError(t, p.overrideParameters())
+	require.JSONEq(t, `{"model":"m","messages":[],"max_completion_tokens":500}`, string(r.originalRequestBodyRaw))
+	require.Equal(t, ptr.To[int64](500), r.originalRequestBody.MaxCompletionTokens)
+	require.True(t, r.forceBodyMutation)
+
+	// The routes without the overrides ignore the headers.
+	r = &chatCompletionProcessorRouterFilter{config: r.config, originalRequestBodyRaw: body}
+	p = &chatCompletionProcessorUpstreamFilter{
+		parent:         r,
+		routeName:      "default/other",
+		requestHeaders: map[string]string{maxTokensOverrideHeader: "5000"},
+	}
+	require.NoError(t, p.overrideParameters())
+	require.Equal(t, body, r.originalRequestBodyRaw)
+	require.False(t, r.forceBodyMutation)
+}
+
+func TestRemoveParameterOverrideHeaders(t *testing.T) {
+	headerMutation := &extprocv3.HeaderMutation{}
+	removeParameterOverrideHeaders(headerMutation, map[string]string{
+		maxTokensOverrideHeader: "500", temperatureOverrideHeader: "0.5", "x-aigw-param-unknown": "1", "x-other": "v",
+	})
+	require.Equal(t, []string{maxTokensOverrideHeader, temperatureOverrideHeader, "x-aigw-param-unknown"}, headerMutation.RemoveHeaders)
+}
+
+func Test_chatCompletionProcessorUpstreamFilter_ProcessRequestHeaders_ParameterOverrides(t *testing.T) {
+	config := &filterapi.RuntimeConfig{ParameterOverrides: map[string]*filterapi.ParameterOverrides{
+		"default/route": {RouteName: "default/route", MaxTokens: &filterapi.MaxTokensBounds{Min: 1, Max: 1000}},
+	}}
+	for _, routeName := range []string{"default/route", "default/other"} {
+		t.Run(routeName, func(t *testing.T) {
+			p := newTestUpstreamFilter(t, config, routeName, `{"model":"m","messages":[],"max_tokens":2000}`)
+			p.parent.eh = endpointspec.ChatCompletionsEndpointSpec{}
+			p.requestHeaders[maxTokensOverrideHeader] = "500"
+			require.NoError(t, p.SetBackend(t.Context(), &filterapi.RuntimeBackend{Backend: &filterapi.Backend{
+				Name: "openai", Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI, Version: "v1"},
+			}}, routeName, p.parent))
+
+			resp, err := p.ProcessRequestHeaders(t.Context(), nil)
+			require.NoError(t, err)
+			// The headers are not sent to the backend, whether the route allows them or not.
+			headerMutation := resp.GetRequestHeaders().GetResponse().GetHeaderMutation()
+			require.Contains(t, headerMutation.GetRemoveHeaders(), maxTokensOverrideHeader)
+			if routeName == "default/route" {
+				require.JSONEq(t, `{"model":"m","messages":[],"max_tokens":500}`,
+					string(resp.GetRequestHeaders().GetResponse().GetBodyMutation().GetBody()))
+			}
+		})
+	}
+}
@@ -0,0 +1,376 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"runtime/debug"
+	"time"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/envoyproxy/ai-gateway/internal/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+)
+
+// PhaseTimeouts are the maximum durations of processing a single message of the request and the response phases
+// of a stream. The timeouts are propagated to the processors through the context, so they bound the calls made
+// while processing the message, such as fetching the credentials of the backend. Zero disables the timeout.
+type PhaseTimeouts struct {
+	// Request bounds the processing of the request headers and the request body.
+	Request time.Duration
+	// Response bounds the processing of the response headers and each chunk of the response body.
+	Response time.Duration
+}
+
+// streamPhase is the phase of an ext_proc stream. Envoy sends the messages of a request in the order of the
+// phases, so a stream only moves forward through them, staying in a phase for the chunks of a body.
+type streamPhase int
+
+const (
+	// streamPhaseInit is the phase of a stream before any message is received.
+	streamPhaseInit streamPhase = iota
+	streamPhaseRequestHeaders
+	streamPhaseRequestBody
+	streamPhaseResponseHeaders
+	streamPhaseResponseBody
+)
+
+// String implements [fmt.Stringer].
+func (p streamPhase) String() string {
+	switch p {
+	case streamPhaseInit:
+		return "init"
+	case streamPhaseRequestHeaders:
+		return "request headers"
+	case streamPhaseRequestBody:
+		return "request body"
+	case streamPhaseResponseHeaders:
+		return "response headers"
+	case streamPhaseResponseBody:
+		return "response body"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(p))
+	}
+}
+
+// phaseOf returns the phase of the message. This returns false for the message types that are not processed,
+// which are rejected by [Server.processMsg].
+func phaseOf(req *extprocv3.ProcessingRequest) (streamPhase, bool) {
+	switch req.Request.(type) {
+	case *extprocv3.ProcessingRequest_RequestHeaders:
+		return streamPhaseRequestHeaders, true
+	case *extprocv3.ProcessingRequest_RequestBody:
+		return streamPhaseRequestBody, true
+	case *extprocv3.ProcessingRequest_ResponseHeaders:
+		return streamPhaseResponseHeaders, true
+	case *extprocv3.ProcessingRequest_ResponseBody:
+		return streamPhaseResponseBody, true
+	default:
+		return streamPhaseInit, false
+	}
+}
+
+// processStream holds the state of a single ext_proc stream. It is only used by the goroutine serving the stream,
+// so nothing in it needs synchronization, and the only state shared with the other streams is the router
+// processor registered for the upstream filter streams of the same request.
+type processStream struct {
+	s      *Server
+	stream extprocv3.ExternalProcessor_ProcessServer
+	// ctx is the context of the stream carrying the request-scoped logger.
+	ctx   context.Context
+	phase streamPhase
+
+	// p is instantiated when the request headers are received. The :path header is used to determine the
+	// processor to use, based on the registered ones.
+	//
+	// If this extproc filter is invoked without going through a RequestHeaders phase, that means an earlier filter
+	// has already processed the request headers/bodies and decided to terminate the request by sending an
+	// immediate response. In this case, the passThroughProcessor passes the request through without any
+	// processing as there would be nothing to process from AI Gateway's perspective.
+	p                Processor
+	isUpstreamFilter bool
+	internalReqID    string
+	originalReqID    string
+	logger           *slog.Logger
+	requestHeaders   map[string]string
+	// modelLogged is set once the request-scoped logger of the router filter is bound to the model.
+	modelLogged bool
+	// releaseStream is set when this stream is counted against the stream concurrency limits.
+	releaseStream func()
+}
+
+func (s *Server) newProcessStream(stream extprocv3.ExternalProcessor_ProcessServer) *processStream {
+	return &processStream{
+		s:      s,
+		stream: stream,
+		// Seed the context with the server-level logger as a fallback so that loggerFromContext never returns nil
+		// in processMsg.
+		ctx:    context.WithValue(stream.Context(), loggerContextKey, s.logger),
+		p:      passThroughProcessor{},
+		logger: s.logger,
+	}
+}
+
+// run processes the messages of the stream until it is closed. A panic while processing a message fails the
+// stream instead of crashing the whole process.
+func (w *processStream) run() (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			w.logger.Error("panic while processing the stream", slog.String("phase", w.phase.String()),
+				slog.Any("panic", r), slog.String("stack", string(debug.Stack())))
+			err = status.Errorf(codes.Internal, "panic while processing the %s: %v", w.phase, r)
+		}
+	}()
+
+	for {
+		select {
+		case <-w.ctx.Done():
+			return w.ctx.Err()
+		default:
+		}
+
+		req, err := w.stream.Recv()
+		if errors.Is(err, io.EOF) || status.Code(err) == codes.Canceled {
+			return nil
+		} else if err != nil {
+			w.s.logger.Error("cannot receive stream request", slog.String("error", err.Error()))
+			return status.Errorf(codes.Unknown, "cannot receive stream request: %v", err)
+		}
+		if err = w.advance(req); err != nil {
+			w.logger.Error("unexpected message order", slog.String("error", err.Error()))
+			return err
+		}
+
+		resp, err := w.handle(req)
+		if err != nil {
+			return err
+		}
+		if err := w.stream.Send(resp); err != nil {
+			w.s.logger.Error("cannot send response", slog.String("error", err.Error()))
+			return status.Errorf(codes.Unknown, "cannot send response: %v", err)
+		}
+	}
+}
+
+// advance moves the stream to the phase of the message, rejecting the messages of an earlier phase.
+func (w *processStream) advance(req *extprocv3.ProcessingRequest) error {
+	phase, ok := phaseOf(req)
+	if !ok {
+		return nil
+	}
+	if phase < w.phase {
+		return status.Errorf(codes.FailedPrecondition, "unexpected %s message in the %s phase", phase, w.phase)
+	}
+	w.phase = phase
+	return nil
+}
+
+// handle processes a message in the current phase and returns the response to send.
+func (w *processStream) handle(req *extprocv3.ProcessingRequest) (*extprocv3.ProcessingResponse, error) {
+	ctx, cancel := w.phaseContext()
+	defer cancel()
+
+	// Note that `req.GetRequestHeaders()` will only return non-nil if the request is of type
+	// `ProcessingRequest_RequestHeaders`, so the processor will be instantiated only once per request.
+	if headers := req.GetRequestHeaders().GetHeaders(); headers != nil {
+		if err := w.setup(ctx, req, headersToMap(headers)); err != nil {
+			return nil, w.phaseError(ctx, err)
+		}
+		// The request-scoped logger is bound to the stream context by setup.
+		ctx = context.WithValue(ctx, loggerContextKey, w.logger)
+	}
+
+	// At this point, p is guaranteed to be a valid processor either from the concrete processor or the passThroughProcessor.
+	resp, err := w.s.processMsg(ctx, w.p, req, w.internalReqID, w.isUpstreamFilter)
+	if err != nil {
+		w.s.logger.Error("error processing request message", slog.String("error", err.Error()))
+		return nil, w.phaseError(ctx, status.Errorf(codes.Unknown, "error processing request message: %v", err))
+	}
+	// The model is only known once the router filter has parsed the request body.
+	if !w.isUpstreamFilter && !w.modelLogged && req.GetRequestBody() != nil {
+		if model := w.requestHeaders[modelNameHeaderKey(w.s.config)]; model != "" {
+			w.modelLogged = true
+			w.logger = w.logger.With("model", model)
+			w.ctx = context.WithValue(w.ctx, loggerContextKey, w.logger)
+		}
+	}
+	if !w.isUpstreamFilter && req.GetRequestBody() != nil {
+		resp = w.s.screenPromptInjection(w.ctx, w.p, resp, w.requestHeaders)
+		resp = w.s.applyQuotaSchedules(resp, time.Now())
+	}
+	if !w.isUpstreamFilter && w.releaseStream == nil && req.GetRequestBody() != nil {
+		var exceeded *filterapi.StreamConcurrencyLimit
+		w.releaseStream, exceeded = w.s.acquireStream(w.p, resp, w.requestHeaders)
+		if exceeded != nil {
+			w.logger.Info("rejecting streaming request exceeding the concurrency limit",
+				slog.Any("headers", exceeded.Headers), slog.Int("max_concurrent_streams", exceeded.MaxConcurrentStreams))
+			resp = streamLimitExceededResponse(exceeded)
+		}
+	}
+	if w.isUpstreamFilter {
+		resp = w.s.shedOverloadedBackend(w.ctx, w.p, req, resp)
+		resp = w.s.applyQuotaFallback(w.ctx, w.p, req, resp, w.requestHeaders)
+	} else {
+		w.s.recordBackendLoad(w.p, req)
+		w.s.chargeQuotaFallback(w.p, req, resp, w.requestHeaders)
+	}
+	return resp, nil
+}
+
+// setup instantiates the processor of the request from the request headers. The upstream filter streams are
+// also bound to the backend and to the router processor of the request.
+func (w *processStream) setup(ctx context.Context, req *extprocv3.ProcessingRequest, headersMap map[string]string) error {
+	w.requestHeaders = headersMap
+	w.originalReqID = headersMap["x-request-id"]
+	// Assume that when attributes are set, this stream is for the upstream filter level.
+	w.isUpstreamFilter = req.GetAttributes() != nil
+
+	if w.isUpstreamFilter {
+		// For upstream filter, use the internal request ID passed from the router filter
+		w.internalReqID = headersMap[internalReqIDHeader]
+		if w.internalReqID == "" {
+			return status.Errorf(codes.Internal, "missing internal request ID header from router filter")
+		}
+	} else {
+		// For router filter, create a unique internal request ID to avoid race conditions
+		// with duplicate x-request-id values by appending a UUID suffix to the original request ID
+		w.internalReqID = w.originalReqID + "-" + w.s.uuidFn()
+	}
+
+	_, isEndpointPicker := headersMap[internalapi.EndpointPickerHeaderKey]
+	// Create request-scoped logger with the request correlation fields before creating processor
+	// so that the logger passed to translators includes them.
+	w.logger = w.s.logger.With(requestLogAttrs(w.originalReqID, w.isUpstreamFilter, isEndpointPicker, req,
+		headersMap[modelNameHeaderKey(w.s.config)])...)
+	w.ctx = context.WithValue(w.ctx, loggerContextKey, w.logger)
+	ctx = context.WithValue(ctx, loggerContextKey, w.logger)
+
+	p, err := w.s.processorForPath(headersMap, w.isUpstreamFilter, w.logger)
+	if err != nil {
+		if errors.Is(err, errNoProcessor) {
+			path := headersMap[":path"]
+			_ = w.stream.Send(&extprocv3.ProcessingResponse{
+				Response: &extprocv3.ProcessingResponse_ImmediateResponse{
+					ImmediateResponse: &extprocv3.ImmediateResponse{
+						Status:     &typev3.HttpStatus{Code: typev3.StatusCode_NotFound},
+						Body:       fmt.Appendf(nil, "unsupported path: %s", path),
+						GrpcStatus: &extprocv3.GrpcStatus{Status: uint32(codes.NotFound)},
+					},
+				},
+			})
+			return status.Errorf(codes.NotFound, "unsupported path: %s", path)
+		}
+		w.s.logger.Error("cannot get processor", slog.String("error", err.Error()))
+		return status.Error(codes.NotFound, err.Error())
+	} else if p == nil {
+		return status.Errorf(codes.Internal, "no processor created for path: %s", headersMap[":path"])
+	}
+	if w.isUpstreamFilter {
+		if err = w.s.setBackend(ctx, p, w.internalReqID, isEndpointPicker, req); err != nil {
+			w.s.logger.Error("error processing request message", slog.String("error", err.Error()))
+			return status.Errorf(codes.Unknown, "error processing request message: %v", err)
+		}
+	} else {
+		w.s.registerRouterProcessor(w.internalReqID, p)
+	}
+	// The processor is only switched once it is fully set up so that a failed setup leaves the stream with the
+	// previous one.
+	w.p = p
+	return nil
+}
+
+// phaseContext returns the context of processing a message in the current phase, bounded by its timeout if any.
+func (w *processStream) phaseContext() (context.Context, context.CancelFunc) {
+	var timeout time.Duration
+	switch w.phase {
+	case streamPhaseRequestHeaders, streamPhaseRequestBody:
+		timeout = w.s.phaseTimeouts.Request
+	case streamPhaseResponseHeaders, streamPhaseResponseBody:
+		timeout = w.s.phaseTimeouts.Response
+	}
+	if timeout <= 0 {
+		return context.WithCancel(w.ctx)
+	}
+	return context.WithTimeout(w.ctx, timeout)
+}
+
+// phaseError returns the error of processing a message with the given context, reporting the phase timeout
+// rather than the error it caused.
+func (w *processStream) phaseError(ctx context.Context, err error) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) && w.ctx.Err() == nil {
+		return status.Errorf(codes.DeadlineExceeded, "processing the %s exceeded the timeout: %v", w.phase, err)
+	}
+	return err
+}
+
+// close releases the resources held by the stream. The router processor is notified so that it can finalize the
+// request if the stream is closed before the end of the response.
+func (w *processStream) close() {
+	if w.releaseStream != nil {
+		w.releaseStream()
+	}
+	if !w.isUpstreamFilter {
+		if h, ok := w.p.(streamCloseHandler); ok {
+			h.onStreamClosed(w.ctx)
+		}
+		w.s.unregisterRouterProcessor(w.internalReqID)
+	}
+}
+
+// registerRouterProcessor registers the router processor of the request so that the upstream filter streams of
+// the request can find it.
+func (s *Server) registerRouterProcessor(internalReqID string, p Processor) {
+	s.routerProcessorsPerReqIDMutex.Lock()
+	defer s.routerProcessorsPerReqIDMutex.Unlock()
+	s.routerProcessorsPerReqID[internalReqID] = p
+}
+
+// routerProcessor returns the router processor registered for the request, if any.
+func (s *Server) routerProcessor(internalReqID string) (Processor, bool) {
+	s.routerProcessorsPerReqIDMutex.RLock()
+	defer s.routerProcessorsPerReqIDMutex.RUnlock()
+	p, ok := s.routerProcessorsPerReqID[internalReqID]
+	return p, ok
+}
+
+// unregisterRouterProcessor removes the router processor of the request once its stream is closed.
+func (s *Server) unregisterRouterProcessor(internalReqID string) {
+	s.routerProcessorsPerReqIDMutex.Lock()
+	defer s.routerProcessorsPerReqIDMutex.Unlock()
+	delete(s.routerProcessorsPerReqID, internalReqID)
+}
+
+// setInternalReqIDHeader adds the internal request ID header to the response of the router filter so that the
+// upstream filter streams of the request can find its router processor.
+func setInternalReqIDHeader(resp *extprocv3.ProcessingResponse, internalReqID string) {
+	requestHeaders, ok := resp.GetResponse().(*extprocv3.ProcessingResponse_RequestHeaders)
+	if !ok {
+		return
+	}
+	// Ensure we have header mutation to add the internal request ID
+	if requestHeaders.RequestHeaders == nil {
+		requestHeaders.RequestHeaders = &extprocv3.HeadersResponse{}
+	}
+	if requestHeaders.RequestHeaders.Response == nil {
+		requestHeaders.RequestHeaders.Response = &extprocv3.CommonResponse{}
+	}
+	if requestHeaders.RequestHeaders.Response.HeaderMutation == nil {
+		requestHeaders.RequestHeaders.Response.HeaderMutation = &extprocv3.HeaderMutation{}
+	}
+	requestHeaders.RequestHeaders.Response.HeaderMutation.SetHeaders = append(
+		requestHeaders.RequestHeaders.Response.HeaderMutation.SetHeaders,
+		&corev3.HeaderValueOption{Header: &corev3.HeaderValue{Key: internalReqIDHeader, RawValue: []byte(internalReqID)}},
+	)
+}
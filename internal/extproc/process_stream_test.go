@@ -0,0 +1,147 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/envoyproxy/ai-gateway/internal/filterapi"
+)
+
+// requestHeadersFuncProcessor is a processor calling fn on the request headers.
+type requestHeadersFuncProcessor struct {
+	passThroughProcessor
+	fn func(ctx context.Context) (*extprocv3.ProcessingResponse, error)
+}
+
+// ProcessRequestHeaders implements [Processor.ProcessRequestHeaders].
+func (p requestHeadersFuncProcessor) ProcessRequestHeaders(ctx context.Context, _ *corev3.HeaderMap) (*extprocv3.ProcessingResponse, error) {
+	return p.fn(ctx)
+}
+
+func newRequestHeadersFuncServer(t *testing.T, fn func(ctx context.Context) (*extprocv3.ProcessingResponse, error)) *Server {
+	s, err := NewServer(slog.Default(), false)
+	require.NoError(t, err)
+	s.config = &filterapi.RuntimeConfig{}
+	s.Register("/", func(*filterapi.RuntimeConfig, map[string]string, *slog.Logger, bool, bool) (Processor, error) {
+		return requestHeadersFuncProcessor{fn: fn}, nil
+	})
+	return s
+}
+
+func newRequestHeadersRequest() *extprocv3.ProcessingRequest {
+	return &extprocv3.ProcessingRequest{Request: &extprocv3.ProcessingRequest_RequestHeaders{
+		RequestHeaders: &extprocv3.HttpHeaders{Headers: &corev3.HeaderMap{Headers: []*corev3.HeaderValue{{Key: ":path", Value: "/"}}}},
+	}}
+}
+
+func TestProcessStream_advance(t *testing.T) {
+	s, _ := requireNewServerWithMockProcessor(t)
+	w := s.newProcessStream(&mockExternalProcessingStream{t: t, ctx: t.Context()})
+	require.Equal(t, streamPhaseInit, w.phase)
+
+	requestBody := &extprocv3.ProcessingRequest{Request: &extprocv3.ProcessingRequest_RequestBody{}}
+	responseBody := &extprocv3.ProcessingRequest{Request: &extprocv3.ProcessingRequest_ResponseBody{}}
+	require.NoError(t, w.advance(newRequestHeadersRequest()))
+	require.Equal(t, streamPhaseRequestHeaders, w.phase)
+	require.NoError(t, w.advance(requestBody))
+	// The chunks of a body stay in the same phase.
+	require.NoError(t, w.advance(requestBody))
+	require.Equal(t, streamPhaseRequestBody, w.phase)
+	require.NoError(t, w.advance(responseBody))
+	require.Equal(t, streamPhaseResponseBody, w.phase)
+	// The messages that are not processed don't change the phase.
+	require.NoError(t, w.advance(&extprocv3.ProcessingRequest{}))
+	require.Equal(t, streamPhaseResponseBody, w.phase)
+
+	err := w.advance(requestBody)
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+	require.ErrorContains(t, err, "unexpected request body message in the response body phase")
+}
+
+func TestProcessStream_phaseContext(t *testing.T) {
+	s, _ := requireNewServerWithMockProcessor(t)
+	s.SetPhaseTimeouts(PhaseTimeouts{Request: time.Minute})
+	w := s.newProcessStream(&mockExternalProcessingStream{t: t, ctx: t.Context()})
+
+	for _, tc := range []struct {
+		phase       streamPhase
+		expDeadline bool
+	}{
+		{phase: streamPhaseInit},
+		{phase: streamPhaseRequestHeaders, expDeadline: true},
+		{phase: streamPhaseRequestBody, expDeadline: true},
+		{phase: streamPhaseResponseHeaders},
+		{phase: streamPhaseResponseBody},
+	} {
+		t.Run(tc.phase.String(), func(t *testing.T) {
+			w.phase = tc.phase
+			ctx, cancel := w.phaseContext()
+			defer cancel()
+			_, ok := ctx.Deadline()
+			require.Equal(t, tc.expDeadline, ok)
+			// The phase context carries the values of the stream context.
+			require.NotNil(t, loggerFromContext(ctx))
+		})
+	}
+}
+
+func TestServer_Process_phaseTimeout(t *testing.T) {
+	s := newRequestHeadersFuncServer(t, func(ctx context.Context) (*extprocv3.ProcessingResponse, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	s.SetPhaseTimeouts(PhaseTimeouts{Request: 10 * time.Millisecond})
+
+	ms := &mockExternalProcessingStream{t: t, ctx: t.Context(), retRecv: newRequestHeadersRequest()}
+	err := s.Process(ms)
+	require.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	require.ErrorContains(t, err, "processing the request headers exceeded the timeout")
+}
+
+func TestServer_Process_panic(t *testing.T) {
+	s := newRequestHeadersFuncServer(t, func(context.Context) (*extprocv3.ProcessingResponse, error) {
+		panic("boom")
+	})
+
+	ms := &mockExternalProcessingStream{t: t, ctx: t.Context(), retRecv: newRequestHeadersRequest()}
+	err := s.Process(ms)
+	require.Equal(t, codes.Internal, status.Code(err))
+	require.ErrorContains(t, err, "panic while processing the request headers: boom")
+	// The router processor is unregistered when the stream is closed.
+	require.Empty(t, s.routerProcessorsPerReqID)
+}
+
+func TestServer_Process_nilProcessor(t *testing.T) {
+	s, err := NewServer(slog.Default(), false)
+	require.NoError(t, err)
+	s.config = &filterapi.RuntimeConfig{}
+	s.Register("/", func(*filterapi.RuntimeConfig, map[string]string, *slog.Logger, bool, bool) (Processor, error) {
+		return nil, nil
+	})
+
+	ms := &mockExternalProcessingStream{t: t, ctx: t.Context(), retRecv: newRequestHeadersRequest()}
+	err = s.Process(ms)
+	require.Equal(t, codes.Internal, status.Code(err))
+	require.ErrorContains(t, err, "no processor created for path: /")
+}
+
+func Test_upstreamProcessor_SetBackend_unexpectedRouterProcessor(t *testing.T) {
+	p := &chatCompletionProcessorUpstreamFilter{requestHeaders: map[string]string{}, metrics: &mockMetrics{}}
+	backend := &filterapi.RuntimeBackend{Backend: &filterapi.Backend{Name: "openai"}}
+	// A router processor of another endpoint fails the request rather than crashing the process.
+	err := p.SetBackend(t.Context(), backend, "route", passThroughProcessor{})
+	require.ErrorContains(t, err, "expected routeProcessor to be of type")
+}
@@ -13,8 +13,11 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"net/http"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	extprocv3http "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ext_proc/v3"
@@ -24,6 +27,7 @@ import (
 	"google.golang.org/grpc/codes"
 	"google.golang.org/protobuf/types/known/structpb"
 
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
 	"github.com/envoyproxy/ai-gateway/internal/backendauth"
 	"github.com/envoyproxy/ai-gateway/internal/bodymutator"
 	"github.com/envoyproxy/ai-gateway/internal/endpointspec"
@@ -33,6 +37,8 @@ import (
 	"github.com/envoyproxy/ai-gateway/internal/json"
 	"github.com/envoyproxy/ai-gateway/internal/llmcostcel"
 	"github.com/envoyproxy/ai-gateway/internal/metrics"
+	"github.com/envoyproxy/ai-gateway/internal/promptinjection"
+	"github.com/envoyproxy/ai-gateway/internal/redaction"
 	"github.com/envoyproxy/ai-gateway/internal/tracing/tracingapi"
 	"github.com/envoyproxy/ai-gateway/internal/translator"
 )
@@ -94,6 +100,9 @@ type (
 		originalRequestBodyRaw []byte
 		originalModel          internalapi.OriginalModel
 		forceBodyMutation      bool
+		// requestContentEncoding is the content encoding of the request body sent by the client, if compressed.
+		// originalRequestBodyRaw is always the decompressed body.
+		requestContentEncoding string
 		// tracer is the tracer used for requests.
 		tracer tracingapi.RequestTracer[ReqT, RespT, RespChunkT]
 		// span is the tracing span for this request, created in ProcessRequestBody.
@@ -101,9 +110,33 @@ type (
 		// upstreamFilterCount is the number of upstream filters that have been processed.
 		// This is used to determine if the request is a retry request.
 		upstreamFilterCount int
-		stream              bool
-		debugLogEnabled     bool
-		enableRedaction     bool
+		// experiment and experimentVariant are the A/B experiment of the route and the variant assigned to the
+		// request. They are kept across the retries so that the variant doesn't change.
+		experiment        *filterapi.Experiment
+		experimentVariant *filterapi.ExperimentVariant
+		// contextLengthRetry is the strategy of the retry of the request after a backend rejected it for exceeding
+		// the context length of the model at the attempt contextLengthRetryAttempt. Empty if it wasn't rejected.
+		contextLengthRetry        filterapi.ContextLengthRetryStrategy
+		contextLengthRetryAttempt int
+		// historyElided is the number of the messages elided from the conversation history by the HistoryPolicy of
+		// the route on the first attempt.
+		historyElided int
+		// toolCallLoop is the name of the function repeatedly called in the conversation that the model was hinted
+		// about by the ToolCallLoopGuard of the route on the first attempt. Empty if it wasn't.
+		toolCallLoop string
+		// requestSHA256 is the hex-encoded SHA-256 of the request body as sent by the client, attested on the
+		// response. Empty unless the response attestation is configured.
+		requestSHA256 string
+		// migrationShadow is the pending comparison of the request sent again to a migration backend with the nonce
+		// migrationNonce. Nil unless the request is one.
+		migrationShadow *pendingMigration
+		migrationNonce  string
+		// conversation is the Responses API conversation stored once the response is received. Nil unless the
+		// conversation store is enabled for the request.
+		conversation    *responsesConversation
+		stream          bool
+		debugLogEnabled bool
+		enableRedaction bool
 	}
 	// upstreamProcessor implements [Processor] for the upstream filter for the standard LLM endpoints.
 	//
@@ -124,8 +157,64 @@ type (
 		backendName        string
 		routeName          string
 		handler            filterapi.BackendAuthHandler
+		// backendOverrideName is the AIServiceBackend (format "namespace/name") pinned by the selected backend header.
+		backendOverrideName string
+		// recompressRequest is true if the backend accepts the request bodies compressed with the content encoding
+		// of the client.
+		recompressRequest bool
+		// traceContextPropagation is the propagation policy of the trace context to the backend of the schema
+		// backendSchema.
+		traceContextPropagation filterapi.TraceContextPropagation
+		backendSchema           filterapi.APISchemaName
+		// contextLengthRetry is the strategy of the retry of the request if the backend rejects it for exceeding the
+		// context length of the model, and contextLengthCheckHeaders are the response headers received by the upstream
+		// filter to check it.
+		contextLengthRetry        filterapi.ContextLengthRetryStrategy
+		contextLengthCheckHeaders map[string]string
+		// failoverRetry is true if the route rule re-dispatches the requests failing mid-flight to another backend.
+		failoverRetry bool
+		// loadReporting configures the load reported by the backend in the headers of its responses. Optional.
+		loadReporting *filterapi.BackendLoadReporting
+		// upstreamHost is the address of the endpoint of the backend selected by Envoy, which the load reports are
+		// keyed by. Empty if unknown.
+		upstreamHost string
+		// extraBody configures the extra fields of the request body accepted by the backend. Optional.
+		extraBody *filterapi.ExtraBody
+		// streamStallTimeout is the maximum time between two tokens of the streaming chat completions. Zero disables it.
+		streamStallTimeout time.Duration
+		// unsupportedParameters are the request parameters dropped by the translator, which are listed in the
+		// response on the routes warning about them.
+		unsupportedParameters []string
+		// piiTokenizer is the request-scoped PII tokenizer. Nil if the backend doesn't configure PII tokenization.
+		piiTokenizer *redaction.Tokenizer
 		// cost is the cost of the request that is accumulated during the processing of the response.
 		costs metrics.TokenUsage
+		// streamQuota terminates the stream at the remaining quota. Nil unless a QuotaPolicy enables the cut-off.
+		streamQuota *streamQuota
+		// reasoningBudget terminates the stream at the reasoning budget. Nil unless the ReasoningBudget of the route
+		// enables the hard stop.
+		reasoningBudget *streamReasoningBudget
+		// watchdog terminates the stream when no token arrives within the stream stall timeout. Nil unless the route
+		// rule configures one.
+		watchdog *streamWatchdog
+		// truncation detects the streams ending without a terminal event. Nil unless the response is a chat
+		// completion stream.
+		truncation *streamTruncation
+		// coalescer coalesces the small events of the streaming response. Nil unless enabled by the config.
+		coalescer *streamCoalescer
+		// timing records the timing of the chunks of the streaming response. Nil unless the response is streamed.
+		timing *streamTiming
+		// streamingResponse is true if the response body is streamed, and responseEnded is true once its end has been
+		// processed. Together, they tell whether the ext_proc stream was closed in the middle of the response.
+		streamingResponse bool
+		responseEnded     bool
+		// spill buffers the large non-streaming response body received in chunks. Nil unless the response is spilled.
+		spill *responseSpill
+		// requestStart is the time the request was sent to the backend, and downstream the listener of Envoy that
+		// received it. compareMigration is true if the response is compared with the response of a migration backend.
+		requestStart     time.Time
+		downstream       downstreamConnection
+		compareMigration bool
 		// metrics tracking.
 		metrics metrics.Metrics
 	}
@@ -163,6 +252,12 @@ func newUpstreamProcessor[ReqT, RespT, RespChunkT any, EndpointSpecT endpointspe
 
 // ProcessResponseHeaders implements [Processor.ProcessResponseHeaders].
 func (r *routerProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) ProcessResponseHeaders(ctx context.Context, headerMap *corev3.HeaderMap) (*extprocv3.ProcessingResponse, error) {
+	if resp := r.fallbackResponse(ctx, headerMap); resp != nil {
+		return resp, nil
+	}
+	if resp := r.upstreamTimeoutResponse(ctx, headerMap); resp != nil {
+		return resp, nil
+	}
 	// If the request failed to route and/or immediate response was returned before the upstream filter was set,
 	// r.upstreamFilter can be nil.
 	if r.upstreamFilter != nil { // See the comment on the "upstreamFilter" field.
@@ -218,12 +313,34 @@ func (r *routerProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) ProcessRequest
 		mutatedOriginalBody []byte
 		err                 error
 	)
+	if res := r.verifyMigrationRequest(); res != nil {
+		return res, nil
+	}
+	// The compressed request bodies are decompressed for the translation. The upstream filter either compresses
+	// the translated body again or sends it uncompressed depending on the backend.
+	requestBody := rawBody.Body
+	if enc := requestContentEncoding(r.requestHeaders["content-encoding"]); enc != "" {
+		requestBody, err = decodeRequestBody(rawBody.Body, enc, MaxDecompressedRequestBodySize)
+		if err != nil {
+			r.logger.Info("rejecting request with undecodable body", slog.String("content_encoding", enc), slog.String("error", err.Error()))
+			return decodeRequestBodyErrorResponse(err), nil
+		}
+		r.requestContentEncoding = enc
+	}
+	conversationRestored := false
+	if _, isResponses := any(r.eh).(endpointspec.ResponsesEndpointSpec); isResponses && r.migrationShadow == nil {
+		var res *extprocv3.ProcessingResponse
+		requestBody, conversationRestored, r.conversation, res, err = restoreResponsesConversation(ctx, r.requestHeaders, requestBody)
+		if err != nil || res != nil {
+			return res, err
+		}
+	}
 	costConfigured := len(r.config.RequestCosts) > 0 || len(r.config.GlobalRequestCosts) > 0
 	contentType := r.requestHeaders["content-type"]
 	if strings.HasPrefix(strings.ToLower(contentType), "multipart/form-data") {
-		originalModel, body, stream, mutatedOriginalBody, err = r.eh.ParseMultipartBody(rawBody.Body, contentType, costConfigured)
+		originalModel, body, stream, mutatedOriginalBody, err = r.eh.ParseMultipartBody(requestBody, contentType, costConfigured)
 	} else {
-		originalModel, body, stream, mutatedOriginalBody, err = r.eh.ParseBody(rawBody.Body, costConfigured)
+		originalModel, body, stream, mutatedOriginalBody, err = r.eh.ParseBody(requestBody, costConfigured)
 	}
 	if err != nil {
 		if userFacingErr := internalapi.GetUserFacingError(err); userFacingErr != nil {
@@ -257,15 +374,17 @@ func (r *routerProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) ProcessRequest
 		r.originalRequestBodyRaw = mutatedOriginalBody
 		r.forceBodyMutation = true
 	} else {
-		r.originalRequestBodyRaw = rawBody.Body
+		r.originalRequestBodyRaw = requestBody
+		// The input of the restored conversation must be sent even if the translator doesn't change the body.
+		r.forceBodyMutation = r.forceBodyMutation || conversationRestored
 	}
 
-	r.requestHeaders[internalapi.ModelNameHeaderKeyDefault] = originalModel
+	r.requestHeaders[modelNameHeaderKey(r.config)] = originalModel
 
 	var additionalHeaders []*corev3.HeaderValueOption
 	additionalHeaders = append(additionalHeaders, &corev3.HeaderValueOption{
-		// Set the original model to the request header with the key `x-ai-eg-model`.
-		Header: &corev3.HeaderValue{Key: internalapi.ModelNameHeaderKeyDefault, RawValue: []byte(originalModel)},
+		// Set the original model to the model name header of the installation, `x-ai-eg-model` by default.
+		Header: &corev3.HeaderValue{Key: modelNameHeaderKey(r.config), RawValue: []byte(originalModel)},
 	})
 	originalPath := r.requestHeaders[":path"]
 	r.requestHeaders[originalPathHeader] = originalPath
@@ -279,6 +398,10 @@ func (r *routerProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) ProcessRequest
 		})
 	}
 	r.originalModel = originalModel
+	r.logger = r.logger.With("model", originalModel)
+	if r.config.ResponseAttestation != nil {
+		r.requestSHA256 = requestBodySHA256(rawBody.Body)
+	}
 	r.originalRequestBody = body
 	r.stream = stream
 
@@ -291,7 +414,7 @@ func (r *routerProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) ProcessRequest
 		r.requestHeaders,
 		&headerMutationCarrier{m: headerMutation},
 		body,
-		rawBody.Body,
+		requestBody,
 	)
 
 	return &extprocv3.ProcessingResponse{
@@ -306,6 +429,33 @@ func (r *routerProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) ProcessRequest
 	}, nil
 }
 
+// isStreamingRequest implements [streamingRequestProcessor].
+func (r *routerProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) isStreamingRequest() bool {
+	return r.stream
+}
+
+// promptInjectionRequestBody implements [promptInjectionProcessor].
+func (r *routerProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) promptInjectionRequestBody() []byte {
+	return r.originalRequestBodyRaw
+}
+
+// recordPromptInjection implements [promptInjectionProcessor].
+func (r *routerProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) recordPromptInjection(result promptinjection.Result, blocked bool, body []byte) {
+	if setter, ok := r.span.(tracingapi.SpanAttributeSetter); ok {
+		setter.SetAttributes(promptInjectionSpanAttributes(result, blocked)...)
+	}
+	if blocked && r.span != nil {
+		r.span.EndSpanOnError(http.StatusForbidden, body)
+	}
+}
+
+// onStreamClosed implements [streamCloseHandler].
+func (r *routerProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) onStreamClosed(ctx context.Context) {
+	if r.upstreamFilter != nil { // See the comment on the "upstreamFilter" field.
+		r.upstreamFilter.onStreamClosed(ctx)
+	}
+}
+
 func (u *upstreamProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) onRetry() bool {
 	return u.parent.upstreamFilterCount > 1
 }
@@ -325,16 +475,78 @@ func (u *upstreamProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) ProcessReque
 
 	// Start tracking metrics for this request.
 	u.metrics.StartRequest(u.requestHeaders)
+	u.requestStart = time.Now()
+	// Link the metrics of the request to its trace with exemplars.
+	if provider, ok := u.parent.span.(tracingapi.SpanContextProvider); ok {
+		u.metrics.SetSpanContext(provider.SpanContext())
+	}
 	// Set the original model from the request body before any overrides
 	u.metrics.SetOriginalModel(u.parent.originalModel)
 	// Set the request model for metrics from the original model or override if applied.
-	reqModel := cmp.Or(u.requestHeaders[internalapi.ModelNameHeaderKeyDefault], u.parent.originalModel)
+	reqModel := cmp.Or(u.requestHeaders[modelNameHeaderKey(u.parent.config)], u.parent.originalModel)
 	u.metrics.SetRequestModel(reqModel)
 
+	if res = u.checkAllowedEndpoint(); res != nil {
+		u.metrics.RecordRequestCompletion(ctx, false, u.requestHeaders)
+		return res, nil
+	}
+	// This is checked before the translation rewrites the path.
+	debugEcho := u.debugEchoRequested()
+	// The backend is pinned by Envoy before the upstream filter, so the pinned backend is verified on every attempt.
+	if res = u.verifyBackendOverride(); res != nil {
+		u.metrics.RecordRequestCompletion(ctx, false, u.requestHeaders)
+		return res, nil
+	}
+
 	// We force the body mutation in the following cases:
 	// * The request is a retry request because the body mutation might have happened the previous iteration.
 	// * The request is a streaming request, and the IncludeUsage option is set to false since we need to ensure that
 	//	the token usage is calculated correctly without being bypassed.
+	// The parameters are overridden on the first attempt, and the overridden body is reused on the retries.
+	if !u.onRetry() {
+		if err = u.overrideParameters(); err != nil {
+			u.logger.Info("rejecting request with invalid parameter overrides", slog.String("error", err.Error()))
+			u.metrics.RecordRequestCompletion(ctx, false, u.requestHeaders)
+			return createUserFacingErrorResponse(400, "BadRequest", err.Error()), nil
+		}
+		// The loops are detected before the history policy elides the earlier calls.
+		if res, err = u.checkToolCallLoop(); err != nil {
+			return nil, fmt.Errorf("failed to check the tool call loop: %w", err)
+		}
+		if res != nil {
+			u.metrics.RecordRequestCompletion(ctx, false, u.requestHeaders)
+			return res, nil
+		}
+		if err = u.applyHistoryPolicy(ctx); err != nil {
+			return nil, fmt.Errorf("failed to apply the history policy: %w", err)
+		}
+		if err = u.applyReasoningBudget(); err != nil {
+			return nil, fmt.Errorf("failed to apply the reasoning budget: %w", err)
+		}
+		if res, err = u.checkContextWindow(ctx); err != nil {
+			return nil, err
+		}
+		if res != nil {
+			u.metrics.RecordRequestCompletion(ctx, false, u.requestHeaders)
+			return res, nil
+		}
+	}
+
+	// The backend differs between the attempts, so the parameters it cannot honor and the extra fields it doesn't
+	// accept are checked on each of them.
+	if res, err = u.checkUnsupportedParameters(); err != nil {
+		return nil, err
+	}
+	if res == nil {
+		if res, err = u.checkExtraBody(); err != nil {
+			return nil, err
+		}
+	}
+	if res != nil {
+		u.metrics.RecordRequestCompletion(ctx, false, u.requestHeaders)
+		return res, nil
+	}
+
 	forceBodyMutation := u.onRetry() || u.parent.forceBodyMutation
 	newHeaders, newBody, err := u.translator.RequestBody(u.parent.originalRequestBodyRaw, u.parent.originalRequestBody, forceBodyMutation)
 	if err != nil {
@@ -365,6 +577,16 @@ func (u *upstreamProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) ProcessReque
 		}
 	}
 
+	setExperimentVariantHeader(headerMutation, u.requestHeaders)
+	if u.backendOverride() != nil {
+		removeBackendOverrideHeaders(u.parent.config, headerMutation, u.requestHeaders)
+	}
+	removeParameterOverrideHeaders(headerMutation, u.requestHeaders)
+	if u.parent.migrationShadow != nil {
+		headerMutation.RemoveHeaders = append(headerMutation.RemoveHeaders, internalapi.MigrationRuleHeader, internalapi.MigrationNonceHeader)
+	}
+	applyTraceContextPropagation(headerMutation, u.requestHeaders, u.traceContextPropagation, u.backendSchema)
+
 	// Decide whether the upstream filter should replace the request body at
 	// all. If the translator emitted no body, no backend HTTPBodyMutation is
 	// configured, and we're not forcing body replay (retry or
@@ -374,23 +596,46 @@ func (u *upstreamProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) ProcessReque
 	mutatorHasMutations := u.bodyMutator != nil && u.bodyMutator.HasMutations()
 	wantBodyReplace := bodyMutation != nil || forceBodyMutation || mutatorHasMutations
 
+	// The extra fields are restored or stripped before the body mutations, which take precedence over them.
+	if u.extraBody != nil {
+		var changed bool
+		bodyMutation, changed = u.applyExtraBody(bodyMutation)
+		wantBodyReplace = wantBodyReplace || changed
+	}
+
 	if wantBodyReplace {
 		// Apply body mutations from the route and also restore original body on retry.
 		bodyMutation = applyBodyMutation(u.bodyMutator, bodyMutation, u.parent.originalRequestBodyRaw, u.logger)
 	}
 
+	// Tokenize PII after all the body mutations so that the backend never sees the original values,
+	// and before the backend auth since it might sign the body.
+	if u.piiTokenizer != nil {
+		var tokenized bool
+		bodyMutation, tokenized = u.tokenizePII(bodyMutation)
+		wantBodyReplace = wantBodyReplace || tokenized
+	}
+
 	// Ensure bodyMutation is not nil for subsequent processing
 	if bodyMutation == nil {
 		bodyMutation = &extprocv3.BodyMutation{}
 	}
 
+	// The replaced body is decompressed, so it is compressed again before the backend auth since it might sign the
+	// body, or sent without the content encoding of the client.
+	if enc := u.parent.requestContentEncoding; enc != "" && wantBodyReplace {
+		if err = u.encodeRequestBody(bodyMutation, headerMutation, enc); err != nil {
+			return nil, err
+		}
+	}
+
 	for _, h := range headerMutation.SetHeaders {
 		u.requestHeaders[h.Header.Key] = string(h.Header.RawValue)
 	}
 
+	var authHeaders []internalapi.Header
 	if h := u.handler; h != nil {
-		var hdrs []internalapi.Header
-		hdrs, err = h.Do(ctx, u.requestHeaders, bodyMutation.GetBody())
+		authHeaders, err = h.Do(ctx, u.requestHeaders, bodyMutation.GetBody())
 		if err != nil {
 			if errors.Is(err, backendauth.ErrCredentialMissing) {
 				u.metrics.RecordRequestCompletion(ctx, false, u.requestHeaders)
@@ -398,7 +643,7 @@ func (u *upstreamProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) ProcessReque
 			}
 			return nil, fmt.Errorf("failed to do auth request: %w", err)
 		}
-		for _, h := range hdrs {
+		for _, h := range authHeaders {
 			headerMutation.SetHeaders = append(headerMutation.SetHeaders, &corev3.HeaderValueOption{
 				AppendAction: corev3.HeaderValueOption_OVERWRITE_IF_EXISTS_OR_ADD,
 				Header:       &corev3.HeaderValue{Key: h.Key(), RawValue: []byte(h.Value())},
@@ -406,6 +651,31 @@ func (u *upstreamProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) ProcessReque
 		}
 	}
 
+	if debugEcho {
+		body := u.parent.originalRequestBodyRaw
+		if wantBodyReplace {
+			body = bodyMutation.GetBody()
+		}
+		u.metrics.RecordRequestCompletion(ctx, true, u.requestHeaders)
+		return u.debugEchoResponse(headerMutation, authHeaders, body)
+	}
+
+	// The key-values of the metadata emitters are set first, so that the ones of the AI Gateway take precedence.
+	// They are not emitted for the requests sent again to the migration backends, which are not charged to the client.
+	namespace := metadataNamespace(u.parent.config)
+	var emitted *structpb.Struct
+	if u.parent.migrationShadow == nil {
+		emitted, err = buildEmittedDynamicMetadata(ctx, namespace, &MetadataEmitterRequest{
+			Headers:     u.requestHeaders,
+			BackendName: u.backendName,
+			RouteName:   u.routeName,
+			Model:       reqModel,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	if !wantBodyReplace {
 		// No body change -> no content-length restamp; emit CONTINUE so Envoy
 		// keeps whatever body the previous filter in the chain produced.
@@ -418,15 +688,18 @@ func (u *upstreamProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) ProcessReque
 					},
 				},
 			},
-			DynamicMetadata: buildRequestHeaderDynamicMetadata(u.requestHeaders),
+			DynamicMetadata: mergeDynamicMetadata(namespace, emitted, mergeDynamicMetadata(namespace,
+				buildRequestHeaderDynamicMetadata(namespace, u.requestHeaders), buildExperimentDynamicMetadata(namespace, u.requestHeaders))),
+			ModeOverride: u.contextLengthCheckMode(),
 		}, nil
 	}
 
-	var dm *structpb.Struct
+	dm := emitted
 	if bm := bodyMutation.GetBody(); bm != nil {
-		dm = buildContentLengthDynamicMetadataOnRequest(len(bm))
+		dm = mergeDynamicMetadata(namespace, dm, buildContentLengthDynamicMetadataOnRequest(namespace, len(bm)))
 	}
-	dm = mergeDynamicMetadata(dm, buildRequestHeaderDynamicMetadata(u.requestHeaders))
+	dm = mergeDynamicMetadata(namespace, dm, buildRequestHeaderDynamicMetadata(namespace, u.requestHeaders))
+	dm = mergeDynamicMetadata(namespace, dm, buildExperimentDynamicMetadata(namespace, u.requestHeaders))
 	return &extprocv3.ProcessingResponse{
 		Response: &extprocv3.ProcessingResponse_RequestHeaders{
 			RequestHeaders: &extprocv3.HeadersResponse{
@@ -437,12 +710,33 @@ func (u *upstreamProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) ProcessReque
 			},
 		},
 		DynamicMetadata: dm,
+		ModeOverride:    u.contextLengthCheckMode(),
 	}, nil
 }
 
+// encodeRequestBody compresses the replaced request body with the content encoding of the client if the
+// backend accepts it. Otherwise, the content-encoding header is removed so that the body is sent uncompressed.
+func (u *upstreamProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) encodeRequestBody(bodyMutation *extprocv3.BodyMutation, headerMutation *extprocv3.HeaderMutation, contentEncoding string) error {
+	if !u.recompressRequest {
+		headerMutation.RemoveHeaders = append(headerMutation.RemoveHeaders, "content-encoding")
+		delete(u.requestHeaders, "content-encoding")
+		return nil
+	}
+	body := bodyMutation.GetBody()
+	if body == nil {
+		return nil
+	}
+	encoded, err := encodeRequestBody(body, contentEncoding)
+	if err != nil {
+		return fmt.Errorf("failed to compress request body: %w", err)
+	}
+	bodyMutation.Mutation = &extprocv3.BodyMutation_Body{Body: encoded}
+	return nil
+}
+
 // ProcessRequestBody implements [Processor.ProcessRequestBody].
 func (u *upstreamProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) ProcessRequestBody(context.Context, *extprocv3.HttpBody) (res *extprocv3.ProcessingResponse, err error) {
-	panic("BUG: ProcessRequestBody should not be called in the upstream filter")
+	return nil, errors.New("BUG: ProcessRequestBody should not be called in the upstream filter")
 }
 
 // ProcessResponseHeaders implements [Processor.ProcessResponseHeaders].
@@ -454,6 +748,17 @@ func (u *upstreamProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) ProcessRespo
 	}()
 
 	u.responseHeaders = headersToMap(headers)
+	u.metrics.RecordPhaseDuration(ctx, metrics.UpstreamPhaseFirstByte, false, u.requestHeaders)
+	u.metrics.RecordProviderRateLimits(ctx, u.responseHeaders)
+	if o, ok := u.handler.(filterapi.BackendAuthResponseObserver); ok {
+		status, _ := strconv.Atoi(u.responseHeaders[":status"])
+		o.ObserveResponse(ctx, u.requestHeaders, status)
+	}
+	if setter, ok := u.parent.span.(tracingapi.SpanAttributeSetter); ok {
+		if attrs := providerRequestIDAttributes(u.responseHeaders); len(attrs) > 0 {
+			setter.SetAttributes(attrs...)
+		}
+	}
 	if enc := u.responseHeaders["content-encoding"]; enc != "" {
 		u.responseEncoding = enc
 	}
@@ -469,7 +774,57 @@ func (u *upstreamProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) ProcessRespo
 		// We only stream the response if the status code is 200 and the response is a stream.
 		mode = &extprocv3http.ProcessingMode{ResponseBodyMode: extprocv3http.ProcessingMode_STREAMED}
 	}
+	u.releaseResponseSpill()
+	if mode == nil && shouldSpillResponse(u.responseHeaders) {
+		// The large response is received in chunks and buffered in a temporary file rather than buffered by Envoy.
+		mode = &extprocv3http.ProcessingMode{ResponseBodyMode: extprocv3http.ProcessingMode_STREAMED}
+		u.spill = &responseSpill{}
+	}
+	u.streamQuota = nil
+	u.reasoningBudget = nil
+	u.watchdog = nil
+	u.truncation = nil
+	u.streamingResponse = mode != nil && u.spill == nil
+	u.responseEnded = false
+	if _, isChat := any(u.parent.originalRequestBody).(*openai.ChatCompletionRequest); isChat && u.streamingResponse {
+		u.truncation = &streamTruncation{}
+	}
+	u.timing = nil
+	if u.streamingResponse {
+		u.timing = newStreamTiming(time.Now)
+	}
+	u.downstream, _ = ctx.Value(downstreamConnectionKey{}).(downstreamConnection)
+	_, chat := any(u.parent.originalRequestBody).(*openai.ChatCompletionRequest)
+	u.compareMigration = chat && !u.parent.stream && u.responseHeaders[":status"] == "200" &&
+		(u.parent.migrationShadow != nil || u.migrationOfRule() != nil)
+	u.coalescer = nil
+	if u.streamingResponse && u.responseEncoding == "" && u.parent.config != nil && u.parent.config.StreamCoalescing != nil {
+		u.coalescer = newStreamCoalescer(u.parent.config.StreamCoalescing, time.Now)
+	}
+	if _, isChat := any(u.parent.originalRequestBody).(*openai.ChatCompletionRequest); isChat && mode != nil && u.responseEncoding == "" && u.parent.config != nil {
+		// The remaining quota is reported by the rate limit filter, which runs after this filter in the request path
+		// and hence before this filter in the response path.
+		u.streamQuota = newStreamQuota(u.parent.config.RequestCosts, u.requestHeaders[modelNameHeaderKey(u.parent.config)],
+			u.responseHeaders, u.backendName, u.routeName)
+		if u.streamingResponse {
+			u.reasoningBudget = newStreamReasoningBudget(u.parent.config.ReasoningBudgets[u.routeName])
+			u.watchdog = newStreamWatchdog(u.streamStallTimeout, time.Now)
+		}
+	}
 	headerMutation, _ := mutationsFromTranslationResult(newHeaders, nil)
+	setExperimentVariantHeader(headerMutation, u.requestHeaders)
+	setUnsupportedParametersHeader(headerMutation, u.unsupportedParameters)
+	setHistoryElidedHeader(headerMutation, u.parent.historyElided)
+	setToolCallLoopHeader(headerMutation, u.parent.toolCallLoop)
+	if err = u.setResponseAttestationHeader(headerMutation); err != nil {
+		return nil, err
+	}
+	u.setContextLengthRetryHeader(headerMutation)
+	u.setFailoverRetryHeader(headerMutation)
+	if u.spill != nil {
+		// The processed body is sent at the end of the response, so its length isn't known yet.
+		headerMutation.RemoveHeaders = append(headerMutation.RemoveHeaders, "content-length")
+	}
 	return &extprocv3.ProcessingResponse{Response: &extprocv3.ProcessingResponse_ResponseHeaders{
 		ResponseHeaders: &extprocv3.HeadersResponse{
 			Response: &extprocv3.CommonResponse{HeaderMutation: headerMutation},
@@ -481,7 +836,12 @@ func (u *upstreamProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) ProcessRespo
 func (u *upstreamProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) ProcessResponseBody(ctx context.Context, body *extprocv3.HttpBody) (res *extprocv3.ProcessingResponse, err error) {
 	recordRequestCompletionErr := false
 	defer func() {
+		if body.EndOfStream {
+			u.metrics.RecordPhaseDuration(ctx, metrics.UpstreamPhaseCompletion, false, u.requestHeaders)
+		}
 		if err != nil || recordRequestCompletionErr {
+			// The failure is already recorded, so that closing the stream doesn't record it again.
+			u.responseEnded = true
 			u.metrics.RecordRequestCompletion(ctx, false, u.requestHeaders)
 			return
 		}
@@ -490,6 +850,20 @@ func (u *upstreamProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) ProcessRespo
 		}
 	}()
 
+	if u.timing != nil {
+		u.timing.observe(body.Body)
+	}
+	spilled := u.spill != nil
+	if spilled {
+		var whole *extprocv3.HttpBody
+		if whole, err = u.spillResponseBody(body); err != nil {
+			return nil, err
+		} else if whole == nil {
+			return clearedChunkResponse, nil
+		}
+		body = whole
+	}
+
 	// Decompress the body if needed.
 	// For streaming responses with content-encoding, use stateful decompression
 	// that accumulates compressed bytes across chunks.
@@ -533,11 +907,84 @@ func (u *upstreamProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) ProcessRespo
 		}, nil
 	}
 
-	newHeaders, newBody, tokenUsage, responseModel, err := u.translator.ResponseBody(u.responseHeaders, decodingResult.reader, body.EndOfStream, u.parent.span)
+	reader := decodingResult.reader
+	var decoded *bytes.Buffer
+	if u.piiTokenizer != nil || u.streamQuota != nil || u.reasoningBudget != nil || u.watchdog != nil || u.truncation != nil || u.compareMigration || u.parent.conversation != nil {
+		// Capture the decoded body in case the translator doesn't mutate it, so that the placeholders can be restored,
+		// the stream can be cut off, its terminal event can be found and the response can be compared.
+		decoded = &bytes.Buffer{}
+		reader = io.TeeReader(reader, decoded)
+	}
+	newHeaders, newBody, tokenUsage, responseModel, err := u.translator.ResponseBody(u.responseHeaders, reader, body.EndOfStream, u.parent.span)
 	if err != nil {
 		return nil, fmt.Errorf("failed to transform response: %w", err)
 	}
 	headerMutation, bodyMutation := mutationsFromTranslationResult(newHeaders, newBody)
+	if spilled && bodyMutation == nil {
+		// The chunks were cleared, so the whole body is sent with the last one.
+		bodyMutation = &extprocv3.BodyMutation{Mutation: &extprocv3.BodyMutation_Body{Body: body.Body}}
+	}
+	if decoded != nil && newBody == nil {
+		if _, err = io.Copy(io.Discard, reader); err != nil {
+			return nil, fmt.Errorf("failed to read response body: %w", err)
+		}
+	}
+	if u.piiTokenizer != nil {
+		bodyMutation = u.detokenizePII(headerMutation, bodyMutation, decoded.Bytes(), body.EndOfStream)
+	}
+
+	// Translator reports the latest cumulative token usage which we use to override existing costs.
+	u.costs.Override(tokenUsage)
+
+	if u.streamQuota != nil {
+		bodyMutation, err = u.enforceStreamQuota(bodyMutation, decoded.Bytes())
+		if err != nil {
+			return nil, err
+		}
+	}
+	if u.reasoningBudget != nil && (u.streamQuota == nil || !u.streamQuota.cutOff) {
+		bodyMutation = u.enforceReasoningBudget(bodyMutation, decoded.Bytes())
+	}
+	if u.watchdog != nil && (u.streamQuota == nil || !u.streamQuota.cutOff) && (u.reasoningBudget == nil || !u.reasoningBudget.cutOff) {
+		bodyMutation = u.enforceStreamWatchdog(ctx, bodyMutation, decoded.Bytes())
+	}
+
+	if c := u.parent.conversation; c != nil {
+		out := decoded.Bytes()
+		if b := bodyMutation.GetBody(); b != nil {
+			out = b
+		}
+		c.observe(out, u.parent.stream)
+		if body.EndOfStream {
+			c.save(ctx, u.logger)
+		}
+	}
+
+	truncated := false
+	if u.truncation != nil {
+		chunk := decoded.Bytes()
+		if b := bodyMutation.GetBody(); b != nil {
+			chunk = b
+		}
+		u.truncation.observe(chunk)
+		if body.EndOfStream && !u.truncation.terminated {
+			truncated = true
+			bodyMutation = u.reportTruncatedStream(ctx, bodyMutation, chunk)
+		}
+	}
+	if u.coalescer != nil {
+		bodyMutation = u.coalescer.coalesceBodyMutation(bodyMutation, body.Body, body.EndOfStream)
+	}
+	if u.compareMigration && body.EndOfStream {
+		out := decoded.Bytes()
+		if b := bodyMutation.GetBody(); b != nil {
+			out = b
+		}
+		u.compareMigrationResponse(ctx, out)
+	}
+	if body.EndOfStream {
+		u.responseEnded = true
+	}
 
 	// Remove content-encoding header if original body encoded but was mutated in the processor.
 	headerMutation = removeContentEncodingIfNeeded(headerMutation, bodyMutation, decodingResult.isEncoded)
@@ -553,9 +1000,6 @@ func (u *upstreamProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) ProcessRespo
 		},
 	}
 
-	// Translator reports the latest cumulative token usage which we use to override existing costs.
-	u.costs.Override(tokenUsage)
-
 	// Set the response model for metrics
 	u.metrics.SetResponseModel(responseModel)
 
@@ -569,12 +1013,15 @@ func (u *upstreamProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) ProcessRespo
 		if body.EndOfStream {
 			u.metrics.RecordTokenUsage(ctx, u.costs, u.requestHeaders)
 		}
-	} else {
+	} else if u.parent.migrationShadow == nil {
+		// The token usage of the requests sent again to the migration backends, which are never streamed, isn't
+		// recorded since it would be attributed, e.g. billed, to the client.
 		u.metrics.RecordTokenUsage(ctx, u.costs, u.requestHeaders)
 	}
 
-	if body.EndOfStream && (len(u.parent.config.GlobalRequestCosts) > 0 || len(u.parent.config.RequestCosts) > 0) {
-		metadata, err := buildDynamicMetadata(u.parent.config.GlobalRequestCosts, u.parent.config.RequestCosts, &u.costs, u.requestHeaders, u.backendName, u.routeName, responseModel)
+	// The requests sent again to the migration backends are not charged to the client.
+	if body.EndOfStream && u.parent.migrationShadow == nil && (len(u.parent.config.GlobalRequestCosts) > 0 || len(u.parent.config.RequestCosts) > 0) {
+		metadata, err := buildDynamicMetadata(u.parent.config, &u.costs, u.requestHeaders, u.backendName, u.routeName, responseModel)
 		if err != nil {
 			return nil, fmt.Errorf("failed to build dynamic metadata: %w", err)
 		}
@@ -582,15 +1029,92 @@ func (u *upstreamProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) ProcessRespo
 			// Adding token latency information to metadata.
 			u.mergeWithTokenLatencyMetadata(metadata)
 		}
+		if u.streamQuota != nil && u.streamQuota.cutOff {
+			u.streamQuota.capCost(metadata, metadataNamespace(u.parent.config))
+		}
 		resp.DynamicMetadata = metadata
 	}
+	if body.EndOfStream && u.timing != nil {
+		namespace := metadataNamespace(u.parent.config)
+		resp.DynamicMetadata = mergeDynamicMetadata(namespace, resp.DynamicMetadata, u.timing.buildDynamicMetadata(namespace))
+	}
 
-	if body.EndOfStream && u.parent.span != nil {
+	if truncated {
+		// Mark so the deferred handler records failure.
+		recordRequestCompletionErr = true
+		if u.parent.span != nil {
+			u.parent.span.EndSpanOnError(http.StatusOK, []byte(streamTruncatedMessage))
+		}
+	} else if body.EndOfStream && u.parent.span != nil {
 		u.parent.span.EndSpan()
 	}
 	return resp, nil
 }
 
+// reportTruncatedStream records the stream that ended without a terminal event. If enabled by the config, the
+// stream truncated error event is appended to the chunk, which is either the one in the given bodyMutation or
+// the decoded upstream body if the translator didn't mutate it.
+func (u *upstreamProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) reportTruncatedStream(ctx context.Context, bodyMutation *extprocv3.BodyMutation, chunk []byte) *extprocv3.BodyMutation {
+	u.metrics.RecordResponseTruncated(ctx, metrics.ResponseTruncationReasonIncomplete, u.requestHeaders)
+	u.logger.Warn("the upstream stream ended without a terminal event", slog.String("backend", u.backendName))
+	if !u.parent.config.TruncatedStreamErrorEvent {
+		return bodyMutation
+	}
+	out := append(slices.Clone(chunk), streamTruncatedEvent()...)
+	return &extprocv3.BodyMutation{Mutation: &extprocv3.BodyMutation_Body{Body: out}}
+}
+
+// onStreamClosed records the streaming response as truncated if the ext_proc stream is closed before the end of
+// the response was processed, which happens when either the downstream or the upstream connection is reset.
+func (u *upstreamProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) onStreamClosed(ctx context.Context) {
+	u.releaseResponseSpill()
+	if !u.streamingResponse || u.responseEnded {
+		return
+	}
+	u.responseEnded = true
+	// The stream context is already canceled at this point.
+	ctx = context.WithoutCancel(ctx)
+	switch {
+	case u.watchdog != nil && u.watchdog.cutOff:
+		// Already recorded when the stream was terminated.
+	case u.watchdog != nil && u.watchdog.stalled():
+		// Envoy reset the silent stream at the per-try idle timeout bounded by the stream stall timeout.
+		u.metrics.RecordResponseTruncated(ctx, metrics.ResponseTruncationReasonStalled, u.requestHeaders)
+	default:
+		u.metrics.RecordResponseTruncated(ctx, metrics.ResponseTruncationReasonAborted, u.requestHeaders)
+	}
+	u.metrics.RecordRequestCompletion(ctx, false, u.requestHeaders)
+	if u.parent.span != nil {
+		u.parent.span.EndSpanOnError(http.StatusOK, []byte("the stream was aborted before the end of the response"))
+	}
+}
+
+// spillResponseBody buffers the chunk of the spilled response. At the end of the response, it returns the body with
+// the whole response, and nil before.
+func (u *upstreamProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) spillResponseBody(body *extprocv3.HttpBody) (*extprocv3.HttpBody, error) {
+	if err := u.spill.write(body.Body); err != nil {
+		u.releaseResponseSpill()
+		return nil, fmt.Errorf("failed to buffer response body: %w", err)
+	}
+	if !body.EndOfStream {
+		return nil, nil
+	}
+	whole, err := u.spill.bytes()
+	u.releaseResponseSpill()
+	if err != nil {
+		return nil, fmt.Errorf("failed to buffer response body: %w", err)
+	}
+	return &extprocv3.HttpBody{Body: whole, EndOfStream: true}, nil
+}
+
+// releaseResponseSpill removes the temporary file of the spilled response, if any.
+func (u *upstreamProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) releaseResponseSpill() {
+	if u.spill != nil {
+		u.spill.release()
+		u.spill = nil
+	}
+}
+
 // decodeStreamingContent handles decompression for streaming responses with content-encoding.
 // It accumulates raw compressed bytes across chunks and re-decompresses from the beginning each time,
 // returning only the newly decompressed data. This is necessary because gzip streams are stateful
@@ -623,19 +1147,36 @@ func (u *upstreamProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) SetBackend(c
 	}()
 	rp, ok := routeProcessor.(*routerProcessor[ReqT, RespT, RespChunkT, EndpointSpecT])
 	if !ok {
-		panic(fmt.Sprintf("BUG: expected routeProcessor to be of type *routerProcessor[%T], got %T", rp, routeProcessor))
+		// This happens when the original path of the request doesn't match the one of the router filter.
+		return fmt.Errorf("expected routeProcessor to be of type %T, got %T", rp, routeProcessor)
 	}
 	rp.upstreamFilterCount++
 	u.metrics.SetBackend(backend.Backend)
 	u.modelNameOverride = backend.Backend.ModelNameOverride
 	u.backendName = backend.Backend.Name
+	u.backendOverrideName = backend.Backend.BackendOverrideName
 	u.routeName = routeName
+	// The model of the experiment variant takes precedence over the one of the backend.
+	if v := u.assignExperiment(rp, routeName); v != nil && v.ModelNameOverride != "" {
+		u.modelNameOverride = v.ModelNameOverride
+	}
 	u.handler = backend.Handler
+	u.recompressRequest = backend.Backend.RecompressRequest
+	u.traceContextPropagation = backend.Backend.TraceContextPropagation
+	u.contextLengthRetry = backend.Backend.ContextLengthRetry
+	u.failoverRetry = backend.Backend.FailoverRetry
+	u.loadReporting = backend.Backend.LoadReporting
+	u.extraBody = backend.Backend.ExtraBody
+	u.streamStallTimeout = time.Duration(backend.Backend.StreamStallTimeoutMilliseconds) * time.Millisecond
+	u.backendSchema = backend.Backend.Schema.Name
+	if len(backend.PIIDetectors) > 0 {
+		u.piiTokenizer = redaction.NewTokenizer(backend.PIIDetectors)
+	}
 	u.headerMutator = headermutator.NewHeaderMutator(backend.Backend.HeaderMutation, rp.requestHeaders)
 	u.bodyMutator = bodymutator.NewBodyMutator(backend.Backend.BodyMutation, rp.originalRequestBodyRaw)
 	// Header-derived labels/CEL must be able to see the overridden request model.
 	if u.modelNameOverride != "" {
-		u.requestHeaders[internalapi.ModelNameHeaderKeyDefault] = u.modelNameOverride
+		u.requestHeaders[modelNameHeaderKey(rp.config)] = u.modelNameOverride
 	}
 	u.parent = rp // Set parent before GetTranslator so it can access rp.eh
 
@@ -666,19 +1207,243 @@ func (u *upstreamProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) SetBackend(c
 	return
 }
 
+// assignExperiment assigns the request to a variant of the A/B experiment of the route, if any, and records it in
+// the request headers. The variant assigned on the first attempt is kept on the retries.
+func (u *upstreamProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) assignExperiment(rp *routerProcessor[ReqT, RespT, RespChunkT, EndpointSpecT], routeName string) *filterapi.ExperimentVariant {
+	if rp.experimentVariant == nil {
+		if rp.config == nil {
+			return nil
+		}
+		e := rp.config.Experiments[routeName]
+		if e == nil {
+			return nil
+		}
+		v := assignExperimentVariant(e, rp.requestHeaders[e.StickyHeader])
+		if v == nil {
+			return nil
+		}
+		rp.experiment, rp.experimentVariant = e, v
+		if setter, ok := rp.span.(tracingapi.SpanAttributeSetter); ok {
+			setter.SetAttributes(experimentSpanAttributes(e, v)...)
+		}
+	}
+	u.requestHeaders[internalapi.ExperimentHeader] = rp.experiment.Name
+	u.requestHeaders[internalapi.ExperimentVariantHeader] = rp.experimentVariant.Name
+	return rp.experimentVariant
+}
+
+// overrideParameters overrides the sampling parameters of the request body with the "x-aigw-param-*" request
+// headers if the route allows it. The overridden body replaces the original one of the router processor so that
+// the translation and the body mutations apply to it.
+func (u *upstreamProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) overrideParameters() error {
+	rp := u.parent
+	if rp.config == nil {
+		return nil
+	}
+	o := rp.config.ParameterOverrides[u.routeName]
+	if o == nil {
+		return nil
+	}
+	field := parameterOverrideMaxTokensField(rp.originalRequestBody, rp.originalRequestBodyRaw)
+	if field == "" {
+		return nil
+	}
+	body, err := overrideParameters(o, u.requestHeaders, rp.originalRequestBodyRaw, field)
+	if err != nil || body == nil {
+		return err
+	}
+	// The original body already includes the usage in the streaming responses if the costs are configured.
+	_, req, _, _, err := rp.eh.ParseBody(body, false)
+	if err != nil {
+		return err
+	}
+	rp.originalRequestBodyRaw, rp.originalRequestBody = body, req
+	rp.forceBodyMutation = true
+	return nil
+}
+
+// backendOverride returns the backend override of the route, or nil if the route doesn't allow pinning a backend.
+func (u *upstreamProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) backendOverride() *filterapi.BackendOverride {
+	if u.parent.config == nil {
+		return nil
+	}
+	return u.parent.config.BackendOverrides[u.routeName]
+}
+
+// verifyBackendOverride verifies the backend pinned with the selected backend request header, "x-aigw-backend" by
+// default, if the route allows pinning a backend. It returns the response rejecting the request, or nil if the request can proceed.
+func (u *upstreamProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) verifyBackendOverride() *extprocv3.ProcessingResponse {
+	o := u.backendOverride()
+	if o == nil {
+		return nil
+	}
+	header := selectedBackendHeaderKey(u.parent.config)
+	backend, ok := u.requestHeaders[header]
+	if !ok {
+		return nil
+	}
+	signatureHeader := selectedBackendSignatureHeaderKey(u.parent.config)
+	if err := verifyBackendOverrideSignature(o.HMACKey, backend, signatureHeader, u.requestHeaders[signatureHeader], time.Now()); err != nil {
+		u.logger.Info("rejecting request with invalid backend override", slog.String("error", err.Error()))
+		return createUserFacingErrorResponse(403, "Forbidden", err.Error())
+	}
+	// Envoy falls back to the other backends when the pinned one is not a backend of the matched rule, in which case
+	// the request is rejected rather than served by another backend.
+	if u.backendOverrideName != backend {
+		return createUserFacingErrorResponse(400, "BadRequest",
+			fmt.Sprintf("backend %s of header %s is not a backend of this route", backend, header))
+	}
+	return nil
+}
+
+// checkUnsupportedParameters applies the behavior of the route on the request parameters that the translator to the
+// selected backend drops. It returns the response rejecting the request with the Error behavior, or nil if the
+// request can proceed. With the Warn behavior, the parameters are kept to be listed in the response headers.
+func (u *upstreamProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) checkUnsupportedParameters() (*extprocv3.ProcessingResponse, error) {
+	u.unsupportedParameters = nil
+	rp := u.parent
+	if rp.config == nil {
+		return nil, nil
+	}
+	behavior := rp.config.UnsupportedParameters[u.routeName]
+	if behavior == "" || behavior == filterapi.UnsupportedParameterBehaviorDrop {
+		return nil, nil
+	}
+	lister, ok := u.translator.(translator.UnsupportedParametersLister)
+	if !ok {
+		return nil, nil
+	}
+	params := lister.UnsupportedParameters(rp.originalRequestBodyRaw)
+	if len(params) == 0 {
+		return nil, nil
+	}
+	if behavior == filterapi.UnsupportedParameterBehaviorError {
+		u.logger.Info("rejecting request with unsupported parameters",
+			slog.String("backend", u.backendName), slog.Any("parameters", params))
+		return unsupportedParametersResponse(params)
+	}
+	u.logger.Info("dropping unsupported parameters", slog.String("backend", u.backendName), slog.Any("parameters", params))
+	u.unsupportedParameters = params
+	return nil, nil
+}
+
+// quotaFallbackTarget implements [quotaFallbackProcessor.quotaFallbackTarget].
+func (u *upstreamProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) quotaFallbackTarget() (backendName, routeName string) {
+	return u.backendName, u.routeName
+}
+
+// quotaFallbackTarget implements [quotaFallbackProcessor.quotaFallbackTarget].
+func (r *routerProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) quotaFallbackTarget() (backendName, routeName string) {
+	if r.upstreamFilter == nil {
+		return "", ""
+	}
+	return r.upstreamFilter.quotaFallbackTarget()
+}
+
+// backendLoadTarget implements [backendLoadProcessor.backendLoadTarget].
+func (u *upstreamProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) backendLoadTarget() (backendName, upstreamHost string, config *filterapi.BackendLoadReporting) {
+	return u.backendName, u.upstreamHost, u.loadReporting
+}
+
+// setUpstreamHost implements [upstreamHostProcessor.setUpstreamHost].
+func (u *upstreamProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) setUpstreamHost(host string) {
+	u.upstreamHost = host
+}
+
+// backendLoadTarget implements [backendLoadProcessor.backendLoadTarget].
+func (r *routerProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) backendLoadTarget() (backendName, upstreamHost string, config *filterapi.BackendLoadReporting) {
+	if r.upstreamFilter == nil {
+		return "", "", nil
+	}
+	return r.upstreamFilter.backendLoadTarget()
+}
+
+// tokenizePII replaces the PII in the request body sent to the backend with placeholders. The body is either
+// the one in the given bodyMutation or the original request body if there's no mutation. Multipart bodies are
+// left untouched.
+//
+// This returns the new body mutation and true if the body was tokenized.
+func (u *upstreamProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) tokenizePII(bodyMutation *extprocv3.BodyMutation) (*extprocv3.BodyMutation, bool) {
+	if strings.HasPrefix(strings.ToLower(u.requestHeaders["content-type"]), "multipart/form-data") {
+		return bodyMutation, false
+	}
+	body := bodyMutation.GetBody()
+	if body == nil {
+		body = u.parent.originalRequestBodyRaw
+	}
+	tokenized, ok := u.piiTokenizer.TokenizeJSON(body)
+	if !ok {
+		return bodyMutation, false
+	}
+	return &extprocv3.BodyMutation{Mutation: &extprocv3.BodyMutation_Body{Body: tokenized}}, true
+}
+
+// detokenizePII restores the original values of the PII placeholders in the response body. The body is either
+// the one in the given bodyMutation or the decoded upstream body if the translator didn't mutate it.
+//
+// For non-streaming responses, the content-length header is updated when the body is mutated.
+func (u *upstreamProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) detokenizePII(headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, decoded []byte, endOfStream bool) *extprocv3.BodyMutation {
+	body := decoded
+	if b := bodyMutation.GetBody(); b != nil {
+		body = b
+	}
+	var restored []byte
+	var changed bool
+	if u.parent.stream {
+		restored, changed = u.piiTokenizer.DetokenizeStream(body, endOfStream)
+	} else {
+		restored, changed = u.piiTokenizer.Detokenize(body)
+	}
+	if !changed {
+		return bodyMutation
+	}
+	if !u.parent.stream {
+		headerMutation.SetHeaders = slices.DeleteFunc(headerMutation.SetHeaders, func(h *corev3.HeaderValueOption) bool {
+			return strings.EqualFold(h.GetHeader().GetKey(), "content-length")
+		})
+		setHeader(headerMutation, "content-length", strconv.Itoa(len(restored)))
+	}
+	return &extprocv3.BodyMutation{Mutation: &extprocv3.BodyMutation_Body{Body: restored}}
+}
+
+// enforceStreamQuota terminates the stream once its cost reaches the remaining quota. The chunk is either the
+// one in the given bodyMutation or the decoded upstream body if the translator didn't mutate it. Once the stream
+// is terminated, the rest of the upstream response is dropped.
+func (u *upstreamProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) enforceStreamQuota(bodyMutation *extprocv3.BodyMutation, decoded []byte) (*extprocv3.BodyMutation, error) {
+	if u.streamQuota.cutOff {
+		return &extprocv3.BodyMutation{Mutation: &extprocv3.BodyMutation_ClearBody{ClearBody: true}}, nil
+	}
+	chunk := decoded
+	if b := bodyMutation.GetBody(); b != nil {
+		chunk = b
+	}
+	truncated, err := u.streamQuota.processChunk(chunk, &u.costs, u.backendName, u.routeName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to enforce the streaming quota: %w", err)
+	}
+	if truncated == nil {
+		return bodyMutation, nil
+	}
+	u.logger.Info("terminated the stream as the quota has been exhausted",
+		slog.String("backend", u.backendName), slog.Uint64("remaining_quota", u.streamQuota.remaining))
+	return &extprocv3.BodyMutation{Mutation: &extprocv3.BodyMutation_Body{Body: truncated}}, nil
+}
+
 func (u *upstreamProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) mergeWithTokenLatencyMetadata(metadata *structpb.Struct) {
 	timeToFirstTokenMs := u.metrics.GetTimeToFirstTokenMs()
 	interTokenLatencyMs := u.metrics.GetInterTokenLatencyMs()
-	innerVal := metadata.Fields[internalapi.AIGatewayFilterMetadataNamespace].GetStructValue()
+	namespace := metadataNamespace(u.parent.config)
+	innerVal := metadata.Fields[namespace].GetStructValue()
 	if innerVal == nil {
 		innerVal = &structpb.Struct{Fields: map[string]*structpb.Value{}}
-		metadata.Fields[internalapi.AIGatewayFilterMetadataNamespace] = structpb.NewStructValue(innerVal)
+		metadata.Fields[namespace] = structpb.NewStructValue(innerVal)
 	}
 	innerVal.Fields["token_latency_ttft"] = &structpb.Value{Kind: &structpb.Value_NumberValue{NumberValue: timeToFirstTokenMs}}
 	innerVal.Fields["token_latency_itl"] = &structpb.Value{Kind: &structpb.Value_NumberValue{NumberValue: interTokenLatencyMs}}
 }
 
-// buildContentLengthDynamicMetadataOnRequest builds dynamic metadata for the request with content length.
+// buildContentLengthDynamicMetadataOnRequest builds dynamic metadata in the namespace for the request with content
+// length.
 //
 // This is necessary to ensure that the content length can be set after the extproc filter has processed the request,
 // which will happen in the header mutation filter.
@@ -686,10 +1451,10 @@ func (u *upstreamProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) mergeWithTok
 // This is needed since the content length header is unconditionally cleared by Envoy as we use REPLACE_AND_CONTINUE
 // processing mode in the request headers phase at upstream filter. This is sort of a workaround, and it is necessary
 // for now.
-func buildContentLengthDynamicMetadataOnRequest(contentLength int) *structpb.Struct {
+func buildContentLengthDynamicMetadataOnRequest(namespace string, contentLength int) *structpb.Struct {
 	metadata := &structpb.Struct{
 		Fields: map[string]*structpb.Value{
-			internalapi.AIGatewayFilterMetadataNamespace: {
+			namespace: {
 				Kind: &structpb.Value_StructValue{
 					StructValue: &structpb.Struct{
 						Fields: map[string]*structpb.Value{
@@ -705,7 +1470,7 @@ func buildContentLengthDynamicMetadataOnRequest(contentLength int) *structpb.Str
 	return metadata
 }
 
-func buildRequestHeaderDynamicMetadata(requestHeaders map[string]string) *structpb.Struct {
+func buildRequestHeaderDynamicMetadata(namespace string, requestHeaders map[string]string) *structpb.Struct {
 	if len(LogRequestHeaderAttributes) == 0 {
 		return nil
 	}
@@ -722,7 +1487,7 @@ func buildRequestHeaderDynamicMetadata(requestHeaders map[string]string) *struct
 	}
 	return &structpb.Struct{
 		Fields: map[string]*structpb.Value{
-			internalapi.AIGatewayFilterMetadataNamespace: {
+			namespace: {
 				Kind: &structpb.Value_StructValue{
 					StructValue: &structpb.Struct{Fields: fields},
 				},
@@ -731,19 +1496,20 @@ func buildRequestHeaderDynamicMetadata(requestHeaders map[string]string) *struct
 	}
 }
 
-func mergeDynamicMetadata(base, extra *structpb.Struct) *structpb.Struct {
+// mergeDynamicMetadata merges the fields of the namespace of the extra dynamic metadata into the base one.
+func mergeDynamicMetadata(namespace string, base, extra *structpb.Struct) *structpb.Struct {
 	if base == nil {
 		return extra
 	}
 	if extra == nil {
 		return base
 	}
-	baseFields := base.Fields[internalapi.AIGatewayFilterMetadataNamespace].GetStructValue()
+	baseFields := base.Fields[namespace].GetStructValue()
 	if baseFields == nil {
 		baseFields = &structpb.Struct{Fields: map[string]*structpb.Value{}}
-		base.Fields[internalapi.AIGatewayFilterMetadataNamespace] = structpb.NewStructValue(baseFields)
+		base.Fields[namespace] = structpb.NewStructValue(baseFields)
 	}
-	extraFields := extra.Fields[internalapi.AIGatewayFilterMetadataNamespace].GetStructValue()
+	extraFields := extra.Fields[namespace].GetStructValue()
 	if extraFields == nil {
 		return base
 	}
@@ -754,7 +1520,7 @@ func mergeDynamicMetadata(base, extra *structpb.Struct) *structpb.Struct {
 }
 
 // evalCost is a helper function that computes the cost value based on the cost type and CEL program.
-func evalCost(costType filterapi.LLMRequestCostType, celProg cel.Program, costs *metrics.TokenUsage, requestHeaders map[string]string, backendName, routeName string) (uint64, error) {
+func evalCost(costType filterapi.LLMRequestCostType, celProg cel.Program, costs *metrics.TokenUsage, model, backendName, routeName string) (uint64, error) {
 	var cost uint64
 	switch costType {
 	case filterapi.LLMRequestCostTypeInputToken:
@@ -784,9 +1550,10 @@ func evalCost(costType filterapi.LLMRequestCostType, celProg cel.Program, costs
 		out, _ := costs.OutputTokens()
 		total, _ := costs.TotalTokens()
 		reasoning, _ := costs.ReasoningTokens()
+		images, _ := costs.Images()
 		cost, err = llmcostcel.EvaluateProgram(
 			celProg,
-			requestHeaders[internalapi.ModelNameHeaderKeyDefault],
+			model,
 			backendName,
 			routeName,
 			in,
@@ -795,6 +1562,9 @@ func evalCost(costType filterapi.LLMRequestCostType, celProg cel.Program, costs
 			out,
 			total,
 			reasoning,
+			images.Count,
+			images.Size,
+			images.Quality,
 		)
 		if err != nil {
 			return 0, fmt.Errorf("failed to evaluate CEL expression: %w", err)
@@ -806,13 +1576,13 @@ func evalCost(costType filterapi.LLMRequestCostType, celProg cel.Program, costs
 }
 
 // evalRuntimeGlobalRequestCost computes the cost value for a single global runtime cost rule.
-func evalRuntimeGlobalRequestCost(rc *filterapi.RuntimeGlobalRequestCost, costs *metrics.TokenUsage, requestHeaders map[string]string, backendName, routeName string) (uint64, error) {
-	return evalCost(rc.Type, rc.CELProg, costs, requestHeaders, backendName, routeName)
+func evalRuntimeGlobalRequestCost(rc *filterapi.RuntimeGlobalRequestCost, costs *metrics.TokenUsage, model, backendName, routeName string) (uint64, error) {
+	return evalCost(rc.Type, rc.CELProg, costs, model, backendName, routeName)
 }
 
 // evalRuntimeRequestCost computes the cost value for a single route-scoped runtime cost rule.
-func evalRuntimeRequestCost(rc *filterapi.RuntimeRequestCost, costs *metrics.TokenUsage, requestHeaders map[string]string, backendName, routeName string) (uint64, error) {
-	return evalCost(rc.Type, rc.CELProg, costs, requestHeaders, backendName, routeName)
+func evalRuntimeRequestCost(rc *filterapi.RuntimeRequestCost, costs *metrics.TokenUsage, model, backendName, routeName string) (uint64, error) {
+	return evalCost(rc.Type, rc.CELProg, costs, model, backendName, routeName)
 }
 
 // buildDynamicMetadata creates metadata for rate limiting and cost tracking.
@@ -821,7 +1591,9 @@ func evalRuntimeRequestCost(rc *filterapi.RuntimeRequestCost, costs *metrics.Tok
 // The metadata includes token usage costs and model information for downstream processing.
 // Two-tier precedence: for each metadataKey, check route-scoped requestCosts first (matching RouteName == routeName).
 // If found, use it. Otherwise, fall back to globalRequestCosts. If neither exists, the key is not emitted.
-func buildDynamicMetadata(globalRequestCosts []filterapi.RuntimeGlobalRequestCost, requestCosts []filterapi.RuntimeRequestCost, costs *metrics.TokenUsage, requestHeaders map[string]string, backendName, routeName, responseModel string) (*structpb.Struct, error) {
+// The model is read from the model name header and the metadata is set in the namespace of the config.
+func buildDynamicMetadata(config *filterapi.RuntimeConfig, costs *metrics.TokenUsage, requestHeaders map[string]string, backendName, routeName, responseModel string) (*structpb.Struct, error) {
+	globalRequestCosts, requestCosts := config.GlobalRequestCosts, config.RequestCosts
 	metadata := make(map[string]*structpb.Value, len(requestCosts)+len(globalRequestCosts)+3)
 
 	// Track which metadata keys have been populated by route-scoped costs.
@@ -832,7 +1604,7 @@ func buildDynamicMetadata(globalRequestCosts []filterapi.RuntimeGlobalRequestCos
 		shortBackend = parts[0] + "/" + parts[1]
 	}
 
-	actualModel := requestHeaders[internalapi.ModelNameHeaderKeyDefault]
+	actualModel := requestHeaders[modelNameHeaderKey(config)]
 
 	// First, process route-scoped costs that match this route.
 	// Route-scoped costs must have a RouteName set (validated at runtime config creation).
@@ -847,7 +1619,7 @@ func buildDynamicMetadata(globalRequestCosts []filterapi.RuntimeGlobalRequestCos
 		if rc.Model != "" && rc.Model != actualModel {
 			continue
 		}
-		cost, err := evalRuntimeRequestCost(rc, costs, requestHeaders, backendName, routeName)
+		cost, err := evalRuntimeRequestCost(rc, costs, actualModel, backendName, routeName)
 		if err != nil {
 			return nil, err
 		}
@@ -861,7 +1633,7 @@ func buildDynamicMetadata(globalRequestCosts []filterapi.RuntimeGlobalRequestCos
 		if _, exists := populatedKeys[rc.MetadataKey]; exists {
 			continue // Route-scoped cost already set this key.
 		}
-		cost, err := evalRuntimeGlobalRequestCost(rc, costs, requestHeaders, backendName, routeName)
+		cost, err := evalRuntimeGlobalRequestCost(rc, costs, actualModel, backendName, routeName)
 		if err != nil {
 			return nil, err
 		}
@@ -892,13 +1664,24 @@ func buildDynamicMetadata(globalRequestCosts []filterapi.RuntimeGlobalRequestCos
 		metadata["response_model"] = &structpb.Value{Kind: &structpb.Value_StringValue{StringValue: responseModel}}
 	}
 
+	// The usage of the generated images is normalized across the providers, see metrics.ImageUsage.
+	if images, ok := costs.Images(); ok {
+		metadata["image_count"] = &structpb.Value{Kind: &structpb.Value_NumberValue{NumberValue: float64(images.Count)}}
+		if images.Size != "" {
+			metadata["image_size"] = &structpb.Value{Kind: &structpb.Value_StringValue{StringValue: images.Size}}
+		}
+		if images.Quality != "" {
+			metadata["image_quality"] = &structpb.Value{Kind: &structpb.Value_StringValue{StringValue: images.Quality}}
+		}
+	}
+
 	if len(metadata) == 0 {
 		return nil, nil
 	}
 
 	return &structpb.Struct{
 		Fields: map[string]*structpb.Value{
-			internalapi.AIGatewayFilterMetadataNamespace: {
+			metadataNamespace(config): {
 				Kind: &structpb.Value_StructValue{
 					StructValue: &structpb.Struct{Fields: metadata},
 				},
@@ -7,13 +7,16 @@ package extproc
 
 import (
 	"bytes"
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"mime/multipart"
+	"strconv"
 	"testing"
+	"time"
 
 	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	extprocv3http "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ext_proc/v3"
@@ -22,6 +25,7 @@ import (
 	"github.com/google/cel-go/cel"
 	"github.com/stretchr/testify/require"
 	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/protobuf/types/known/structpb"
 
 	anthropicschema "github.com/envoyproxy/ai-gateway/internal/apischema/anthropic"
@@ -35,6 +39,7 @@ import (
 	"github.com/envoyproxy/ai-gateway/internal/json"
 	"github.com/envoyproxy/ai-gateway/internal/llmcostcel"
 	"github.com/envoyproxy/ai-gateway/internal/metrics"
+	"github.com/envoyproxy/ai-gateway/internal/redaction"
 	"github.com/envoyproxy/ai-gateway/internal/testing/testotel"
 	"github.com/envoyproxy/ai-gateway/internal/tracing/tracingapi"
 )
@@ -81,6 +86,30 @@ type (
 	messagesProcessorUpstreamFilter       = upstreamProcessor[anthropicschema.MessagesRequest, anthropicschema.MessagesResponse, anthropicschema.MessagesStreamChunk, endpointspec.MessagesEndpointSpec]
 )
 
+// newTestUpstreamFilter returns the upstream filter of a chat completion request routed to the route, whose router
+// filter has the config and the request body if not empty. The tests set the other fields they need.
+func newTestUpstreamFilter(t *testing.T, config *filterapi.RuntimeConfig, routeName, body string) *chatCompletionProcessorUpstreamFilter {
+	t.Helper()
+	logger := slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{}))
+	r := &chatCompletionProcessorRouterFilter{
+		config:         cmp.Or(config, &filterapi.RuntimeConfig{}),
+		logger:         logger,
+		requestHeaders: map[string]string{},
+	}
+	if body != "" {
+		var req openai.ChatCompletionRequest
+		require.NoError(t, json.Unmarshal([]byte(body), &req))
+		r.originalRequestBodyRaw, r.originalRequestBody, r.originalModel = []byte(body), &req, req.Model
+	}
+	return &chatCompletionProcessorUpstreamFilter{
+		parent:         r,
+		logger:         logger,
+		routeName:      routeName,
+		requestHeaders: map[string]string{},
+		metrics:        &mockMetrics{},
+	}
+}
+
 type mockTracer struct {
 	tracingapi.NoopChatCompletionTracer
 	startSpanCalled bool
@@ -227,6 +256,7 @@ func Test_chatCompletionProcessorUpstreamFilter_ProcessResponseHeaders(t *testin
 		p := &chatCompletionProcessorUpstreamFilter{
 			translator: mt,
 			metrics:    mm,
+			parent:     &chatCompletionProcessorRouterFilter{},
 		}
 		mt.retErr = errors.New("test error")
 		_, err := p.ProcessResponseHeaders(t.Context(), nil)
@@ -251,6 +281,7 @@ func Test_chatCompletionProcessorUpstreamFilter_ProcessResponseHeaders(t *testin
 		require.Empty(t, commonRes.HeaderMutation.SetHeaders)
 		mm.RequireRequestNotCompleted(t)
 		require.Nil(t, res.ModeOverride)
+		require.Equal(t, expHeaders, mm.rateLimitHeaders)
 	})
 	t.Run("ok/streaming", func(t *testing.T) {
 		inHeaders := &corev3.HeaderMap{
@@ -280,6 +311,20 @@ func Test_chatCompletionProcessorUpstreamFilter_ProcessResponseHeaders(t *testin
 		require.Empty(t, commonRes.HeaderMutation)
 		require.Nil(t, res.ModeOverride)
 	})
+	t.Run("backend auth observes the status", func(t *testing.T) {
+		inHeaders := &corev3.HeaderMap{Headers: []*corev3.HeaderValue{{Key: ":status", Value: "401"}}}
+		h := &mockObservingBackendAuthHandler{}
+		mt := &mockTranslator{t: t, expHeaders: map[string]string{":status": "401"}}
+		p := &chatCompletionProcessorUpstreamFilter{
+			translator: mt,
+			metrics:    &mockMetrics{},
+			handler:    h,
+			parent:     &chatCompletionProcessorRouterFilter{},
+		}
+		_, err := p.ProcessResponseHeaders(t.Context(), inHeaders)
+		require.NoError(t, err)
+		require.Equal(t, []int{401}, h.statuses)
+	})
 }
 
 func Test_chatCompletionProcessorUpstreamFilter_ProcessResponseBody(t *testing.T) {
@@ -441,6 +486,135 @@ func Test_chatCompletionProcessorUpstreamFilter_ProcessResponseBody(t *testing.T
 	})
 }
 
+func Test_chatCompletionProcessorUpstreamFilter_TruncatedStream(t *testing.T) {
+	// startStream returns the upstream filter of a streaming request whose response headers are processed.
+	startStream := func(mm *mockMetrics, errorEvent bool) *chatCompletionProcessorUpstreamFilter {
+		p := newTestUpstreamFilter(t, &filterapi.RuntimeConfig{TruncatedStreamErrorEvent: errorEvent},
+			"default/route", `{"model":"m","messages":[],"stream":true}`)
+		p.parent.stream = true
+		p.translator, p.metrics = &mockTranslator{t: t, expHeaders: map[string]string{":status": "200"}}, mm
+		_, err := p.ProcessResponseHeaders(t.Context(), &corev3.HeaderMap{Headers: []*corev3.HeaderValue{{Key: ":status", Value: "200"}}})
+		require.NoError(t, err)
+		return p
+	}
+	const firstChunk = "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hi\"}}]}\n\n"
+
+	t.Run("completed", func(t *testing.T) {
+		mm := &mockMetrics{}
+		p := startStream(mm, true)
+		_, err := p.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{Body: []byte(firstChunk)})
+		require.NoError(t, err)
+		res, err := p.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{Body: []byte("data: [DONE]\n\n"), EndOfStream: true})
+		require.NoError(t, err)
+		require.Nil(t, res.GetResponseBody().GetResponse().GetBodyMutation())
+		require.Empty(t, mm.truncationReasons)
+		mm.RequireRequestSuccess(t)
+
+		p.onStreamClosed(t.Context())
+		require.Empty(t, mm.truncationReasons)
+	})
+	t.Run("incomplete", func(t *testing.T) {
+		mm := &mockMetrics{}
+		p := startStream(mm, false)
+		_, err := p.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{Body: []byte(firstChunk)})
+		require.NoError(t, err)
+		res, err := p.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{EndOfStream: true})
+		require.NoError(t, err)
+		require.Nil(t, res.GetResponseBody().GetResponse().GetBodyMutation())
+		require.Equal(t, []metrics.ResponseTruncationReason{metrics.ResponseTruncationReasonIncomplete}, mm.truncationReasons)
+		mm.RequireRequestFailure(t)
+	})
+	t.Run("incomplete with error event", func(t *testing.T) {
+		mm := &mockMetrics{}
+		p := startStream(mm, true)
+		res, err := p.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{Body: []byte(firstChunk), EndOfStream: true})
+		require.NoError(t, err)
+		require.Equal(t, firstChunk+`data: {"type":"error","error":{"type":"stream_truncated","message":"the upstream stream ended before the final event"}}`+"\n\n",
+			string(res.GetResponseBody().GetResponse().GetBodyMutation().GetBody()))
+		require.Equal(t, []metrics.ResponseTruncationReason{metrics.ResponseTruncationReasonIncomplete}, mm.truncationReasons)
+		mm.RequireRequestFailure(t)
+	})
+	t.Run("aborted", func(t *testing.T) {
+		mm := &mockMetrics{}
+		p := startStream(mm, true)
+		_, err := p.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{Body: []byte(firstChunk)})
+		require.NoError(t, err)
+		p.parent.upstreamFilter = p
+		p.parent.onStreamClosed(t.Context())
+		require.Equal(t, []metrics.ResponseTruncationReason{metrics.ResponseTruncationReasonAborted}, mm.truncationReasons)
+		mm.RequireRequestFailure(t)
+		// Closing the stream twice records nothing more.
+		p.onStreamClosed(t.Context())
+		require.Len(t, mm.truncationReasons, 1)
+	})
+	t.Run("stalled", func(t *testing.T) {
+		mm := &mockMetrics{}
+		p := startStream(mm, true)
+		now := time.Now()
+		p.watchdog = newStreamWatchdog(time.Second, func() time.Time { return now })
+		_, err := p.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{Body: []byte(firstChunk)})
+		require.NoError(t, err)
+		// Envoy resets the silent stream at the per-try idle timeout.
+		now = now.Add(time.Second)
+		p.onStreamClosed(t.Context())
+		require.Equal(t, []metrics.ResponseTruncationReason{metrics.ResponseTruncationReasonStalled}, mm.truncationReasons)
+		mm.RequireRequestFailure(t)
+	})
+	t.Run("terminated by the watchdog", func(t *testing.T) {
+		mm := &mockMetrics{}
+		p := startStream(mm, true)
+		now := time.Now()
+		p.watchdog = newStreamWatchdog(time.Second, func() time.Time { return now })
+		_, err := p.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{Body: []byte(firstChunk)})
+		require.NoError(t, err)
+		now = now.Add(time.Second)
+		res, err := p.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{Body: []byte(": keep-alive\n\n")})
+		require.NoError(t, err)
+		require.Contains(t, string(res.GetResponseBody().GetResponse().GetBodyMutation().GetBody()), "event: stream_stalled")
+		require.Equal(t, []metrics.ResponseTruncationReason{metrics.ResponseTruncationReasonStalled}, mm.truncationReasons)
+		// The rest of the upstream response is dropped, and closing the stream records nothing more.
+		res, err = p.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{Body: []byte(firstChunk)})
+		require.NoError(t, err)
+		require.True(t, res.GetResponseBody().GetResponse().GetBodyMutation().GetClearBody())
+		p.onStreamClosed(t.Context())
+		require.Len(t, mm.truncationReasons, 1)
+	})
+}
+
+func Test_chatCompletionProcessorUpstreamFilter_StreamCoalescing(t *testing.T) {
+	mm := &mockMetrics{}
+	p := &chatCompletionProcessorUpstreamFilter{
+		translator: &mockTranslator{t: t, expHeaders: map[string]string{":status": "200"}},
+		metrics:    mm,
+		logger:     slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{})),
+		parent: &chatCompletionProcessorRouterFilter{
+			stream:              true,
+			originalRequestBody: &openai.ChatCompletionRequest{Stream: true},
+			config:              &filterapi.RuntimeConfig{StreamCoalescing: &filterapi.StreamCoalescing{MinBytes: 1024}},
+		},
+	}
+	_, err := p.ProcessResponseHeaders(t.Context(), &corev3.HeaderMap{Headers: []*corev3.HeaderValue{{Key: ":status", Value: "200"}}})
+	require.NoError(t, err)
+	require.NotNil(t, p.coalescer)
+
+	const chunk = "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hi\"}}]}\n\n"
+	var res *extprocv3.ProcessingResponse
+	for range 3 {
+		res, err = p.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{Body: []byte(chunk)})
+		require.NoError(t, err)
+		require.True(t, res.GetResponseBody().GetResponse().GetBodyMutation().GetClearBody())
+	}
+	const usage = "data: {\"choices\":[{\"index\":0,\"delta\":{},\"finish_reason\":\"stop\"}],\"usage\":{\"prompt_tokens\":1}}\n\ndata: [DONE]\n\n"
+	res, err = p.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{Body: []byte(usage), EndOfStream: true})
+	require.NoError(t, err)
+	require.Equal(t, chunk+chunk+chunk+usage, string(res.GetResponseBody().GetResponse().GetBodyMutation().GetBody()))
+	require.Empty(t, mm.truncationReasons)
+	mm.RequireRequestSuccess(t)
+	// The timing of the stream counts the upstream chunks rather than the coalesced ones.
+	md := res.GetDynamicMetadata().GetFields()[internalapi.AIGatewayFilterMetadataNamespace].GetStructValue()
+	require.Equal(t, float64(4), md.GetFields()[streamChunkCountMetadataKey].GetNumberValue())
+}
+
 func bodyFromModel(t *testing.T, model string, stream bool, streamOptions *openai.StreamOptions) []byte {
 	openAIReq := &openai.ChatCompletionRequest{}
 	openAIReq.Model = model
@@ -1061,6 +1235,34 @@ func TestChatCompletionProcessorRouterFilter_ProcessResponseBody_SpanHandling(t
 	})
 }
 
+func Test_upstreamProcessor_ProcessRequestHeaders_spanContext(t *testing.T) {
+	someBody := bodyFromModel(t, "some-model", false, nil)
+	var body openai.ChatCompletionRequest
+	require.NoError(t, json.Unmarshal(someBody, &body))
+	spanContext := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x01},
+		SpanID:     trace.SpanID{0x02},
+		TraceFlags: trace.FlagsSampled,
+	})
+	mm := &mockMetrics{}
+	p := &chatCompletionProcessorUpstreamFilter{
+		parent: &chatCompletionProcessorRouterFilter{
+			config:                 &filterapi.RuntimeConfig{},
+			logger:                 slog.Default(),
+			originalRequestBodyRaw: someBody,
+			originalRequestBody:    &body,
+			originalModel:          "some-model",
+			span:                   &testotel.MockSpan{SpanCtx: spanContext},
+		},
+		requestHeaders: map[string]string{":path": "/foo"},
+		metrics:        mm,
+		translator:     &mockTranslator{t: t, expRequestBody: &body},
+	}
+	_, err := p.ProcessRequestHeaders(t.Context(), nil)
+	require.NoError(t, err)
+	require.Equal(t, spanContext, mm.spanContext)
+}
+
 func Test_chatCompletionProcessorUpstreamFilter_SensitiveHeaders_RemoveAndRestore(t *testing.T) {
 	headerMutation := filterapi.HTTPHeaderMutation{
 		Remove: []string{"authorization", "x-api-key"},
@@ -1662,7 +1864,7 @@ func Test_buildDynamicMetadata(t *testing.T) {
 		costs := &metrics.TokenUsage{}
 		headers := map[string]string{internalapi.ModelNameHeaderKeyDefault: "gpt-4"}
 
-		md, err := buildDynamicMetadata(nil, []filterapi.RuntimeRequestCost{}, costs, headers, "", "", "")
+		md, err := buildDynamicMetadata(&filterapi.RuntimeConfig{}, costs, headers, "", "", "")
 		require.NoError(t, err)
 		require.NotNil(t, md)
 
@@ -1675,7 +1877,7 @@ func Test_buildDynamicMetadata(t *testing.T) {
 		// After backend override, the header contains the backend-specific model name.
 		headers := map[string]string{internalapi.ModelNameHeaderKeyDefault: "us.anthropic.claude-sonnet-4.5-v2"}
 
-		md, err := buildDynamicMetadata(nil, []filterapi.RuntimeRequestCost{}, costs, headers, "default/my-backend", "", "")
+		md, err := buildDynamicMetadata(&filterapi.RuntimeConfig{}, costs, headers, "default/my-backend", "", "")
 		require.NoError(t, err)
 		require.NotNil(t, md)
 
@@ -1687,7 +1889,7 @@ func Test_buildDynamicMetadata(t *testing.T) {
 		costs := &metrics.TokenUsage{}
 		headers := map[string]string{internalapi.ModelNameHeaderKeyDefault: "gpt-4"}
 
-		md, err := buildDynamicMetadata(nil, []filterapi.RuntimeRequestCost{}, costs, headers, "ns/backend-a", "", "")
+		md, err := buildDynamicMetadata(&filterapi.RuntimeConfig{}, costs, headers, "ns/backend-a", "", "")
 		require.NoError(t, err)
 		require.NotNil(t, md)
 
@@ -1699,7 +1901,7 @@ func Test_buildDynamicMetadata(t *testing.T) {
 		costs := &metrics.TokenUsage{}
 		headers := map[string]string{internalapi.ModelNameHeaderKeyDefault: "gpt-4"}
 
-		md, err := buildDynamicMetadata(nil, []filterapi.RuntimeRequestCost{}, costs, headers, "", "", "")
+		md, err := buildDynamicMetadata(&filterapi.RuntimeConfig{}, costs, headers, "", "", "")
 		require.NoError(t, err)
 		require.NotNil(t, md)
 
@@ -1719,7 +1921,7 @@ func Test_buildDynamicMetadata(t *testing.T) {
 		costs.SetInputTokens(50)
 		headers := map[string]string{internalapi.ModelNameHeaderKeyDefault: "claude-sonnet"}
 
-		md, err := buildDynamicMetadata(nil, config.RequestCosts, costs, headers, "default/backend", "", "")
+		md, err := buildDynamicMetadata(&filterapi.RuntimeConfig{RequestCosts: config.RequestCosts}, costs, headers, "default/backend", "", "")
 		require.NoError(t, err)
 		require.NotNil(t, md)
 
@@ -1730,11 +1932,34 @@ func Test_buildDynamicMetadata(t *testing.T) {
 		require.Equal(t, float64(50), inner.Fields["input_tokens"].GetNumberValue())
 	})
 
+	t.Run("includes the image usage and its cost", func(t *testing.T) {
+		prog, err := llmcostcel.NewProgram("image_quality == 'high' ? image_count * 80u : image_count * 40u")
+		require.NoError(t, err)
+		requestCosts := []filterapi.RuntimeRequestCost{
+			{LLMRequestCost: &filterapi.LLMRequestCost{Type: filterapi.LLMRequestCostTypeCEL, MetadataKey: "image_cost"}, CELProg: prog},
+		}
+		costs := &metrics.TokenUsage{}
+		costs.SetImages(metrics.ImageUsage{Count: 2, Size: "1024x1024", Quality: metrics.ImageQualityHigh})
+
+		md, err := buildDynamicMetadata(&filterapi.RuntimeConfig{RequestCosts: requestCosts}, costs, map[string]string{}, "", "", "")
+		require.NoError(t, err)
+		inner := md.Fields[internalapi.AIGatewayFilterMetadataNamespace].GetStructValue()
+		require.Equal(t, float64(160), inner.Fields["image_cost"].GetNumberValue())
+		require.Equal(t, float64(2), inner.Fields["image_count"].GetNumberValue())
+		require.Equal(t, "1024x1024", inner.Fields["image_size"].GetStringValue())
+		require.Equal(t, "high", inner.Fields["image_quality"].GetStringValue())
+
+		// The requests without images don't have the image usage.
+		md, err = buildDynamicMetadata(&filterapi.RuntimeConfig{}, &metrics.TokenUsage{}, map[string]string{}, "", "", "")
+		require.NoError(t, err)
+		require.Nil(t, md.Fields[internalapi.AIGatewayFilterMetadataNamespace].GetStructValue().Fields["image_count"])
+	})
+
 	t.Run("model_name_override is empty string when header not set", func(t *testing.T) {
 		costs := &metrics.TokenUsage{}
 		headers := map[string]string{}
 
-		md, err := buildDynamicMetadata(nil, []filterapi.RuntimeRequestCost{}, costs, headers, "", "", "")
+		md, err := buildDynamicMetadata(&filterapi.RuntimeConfig{}, costs, headers, "", "", "")
 		require.NoError(t, err)
 		require.NotNil(t, md)
 
@@ -1755,7 +1980,7 @@ func Test_mergeDynamicMetadata(t *testing.T) {
 				}),
 			},
 		}
-		result := mergeDynamicMetadata(nil, extra)
+		result := mergeDynamicMetadata(internalapi.AIGatewayFilterMetadataNamespace, nil, extra)
 		require.Equal(t, extra, result)
 	})
 
@@ -1769,7 +1994,7 @@ func Test_mergeDynamicMetadata(t *testing.T) {
 				}),
 			},
 		}
-		result := mergeDynamicMetadata(base, nil)
+		result := mergeDynamicMetadata(internalapi.AIGatewayFilterMetadataNamespace, base, nil)
 		require.Equal(t, base, result)
 	})
 
@@ -1792,7 +2017,7 @@ func Test_mergeDynamicMetadata(t *testing.T) {
 				}),
 			},
 		}
-		result := mergeDynamicMetadata(base, extra)
+		result := mergeDynamicMetadata(internalapi.AIGatewayFilterMetadataNamespace, base, extra)
 		inner := result.Fields[internalapi.AIGatewayFilterMetadataNamespace].GetStructValue()
 		require.Equal(t, "ns/backend", inner.Fields["backend_name"].GetStringValue())
 		require.Equal(t, "gpt-4", inner.Fields["model_name_override"].GetStringValue())
@@ -1817,7 +2042,7 @@ func Test_mergeDynamicMetadata(t *testing.T) {
 				}),
 			},
 		}
-		result := mergeDynamicMetadata(base, extra)
+		result := mergeDynamicMetadata(internalapi.AIGatewayFilterMetadataNamespace, base, extra)
 		inner := result.Fields[internalapi.AIGatewayFilterMetadataNamespace].GetStructValue()
 		require.Equal(t, "new-value", inner.Fields["key"].GetStringValue())
 	})
@@ -1960,7 +2185,7 @@ func TestBuildDynamicMetadata_routeScoped(t *testing.T) {
 			tu.SetInputTokens(tt.inputTokens)
 			tu.SetTotalTokens(tt.totalTokens)
 
-			md, err := buildDynamicMetadata(nil, tt.requestCosts, &tu, tt.requestHeaders, tt.backendName, tt.routeName, "")
+			md, err := buildDynamicMetadata(&filterapi.RuntimeConfig{RequestCosts: tt.requestCosts}, &tu, tt.requestHeaders, tt.backendName, tt.routeName, "")
 			require.NoError(t, err)
 
 			ns := md.Fields[internalapi.AIGatewayFilterMetadataNamespace].GetStructValue().Fields
@@ -2129,7 +2354,7 @@ func TestBuildDynamicMetadata_GlobalAndRouteScoped(t *testing.T) {
 			tu.SetOutputTokens(tt.outputTokens)
 			tu.SetTotalTokens(tt.totalTokens)
 
-			md, err := buildDynamicMetadata(tt.globalCosts, tt.routeCosts, &tu, tt.requestHeaders, tt.backendName, tt.routeName, "")
+			md, err := buildDynamicMetadata(&filterapi.RuntimeConfig{GlobalRequestCosts: tt.globalCosts, RequestCosts: tt.routeCosts}, &tu, tt.requestHeaders, tt.backendName, tt.routeName, "")
 			require.NoError(t, err)
 
 			ns := md.Fields[internalapi.AIGatewayFilterMetadataNamespace].GetStructValue().Fields
@@ -2151,3 +2376,62 @@ func mustCompileCEL(t *testing.T, expr string) cel.Program {
 	require.NoError(t, err)
 	return prog
 }
+
+func Test_chatCompletionProcessorUpstreamFilter_PIITokenization(t *testing.T) {
+	email, ok := redaction.BuiltinDetector(redaction.DetectorEmail)
+	require.True(t, ok)
+	someBody := []byte(`{"model":"some-model","messages":[{"role":"user","content":"I am alice@example.com"}]}`)
+	var expBody openai.ChatCompletionRequest
+	require.NoError(t, json.Unmarshal(someBody, &expBody))
+
+	mt := &mockTranslator{t: t, expRequestBody: &expBody}
+	p := &chatCompletionProcessorUpstreamFilter{
+		parent: &chatCompletionProcessorRouterFilter{
+			config:                 &filterapi.RuntimeConfig{},
+			logger:                 slog.Default(),
+			originalRequestBodyRaw: someBody,
+			originalRequestBody:    &expBody,
+		},
+		requestHeaders:  map[string]string{":path": "/v1/chat/completions", "content-type": "application/json"},
+		responseHeaders: map[string]string{":status": "200"},
+		metrics:         &mockMetrics{},
+		translator:      mt,
+		bodyMutator:     bodymutator.NewBodyMutator(nil, someBody),
+		piiTokenizer:    redaction.NewTokenizer([]redaction.Detector{email}),
+	}
+
+	resp, err := p.ProcessRequestHeaders(t.Context(), nil)
+	require.NoError(t, err)
+	commonRes := resp.Response.(*extprocv3.ProcessingResponse_RequestHeaders).RequestHeaders.Response
+	require.Equal(t, extprocv3.CommonResponse_CONTINUE_AND_REPLACE, commonRes.Status)
+	require.JSONEq(t, `{"model":"some-model","messages":[{"role":"user","content":"I am [PII_EMAIL_1]"}]}`,
+		string(commonRes.BodyMutation.GetBody()))
+
+	// The translator doesn't mutate the response, so the placeholders are restored in the upstream body.
+	respBody := []byte(`{"choices":[{"message":{"content":"Hello [PII_EMAIL_1]"}}]}`)
+	mt.expResponseBody = &extprocv3.HttpBody{Body: respBody, EndOfStream: true}
+	resp, err = p.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{Body: respBody, EndOfStream: true})
+	require.NoError(t, err)
+	commonRes = resp.Response.(*extprocv3.ProcessingResponse_ResponseBody).ResponseBody.Response
+	expRestored := `{"choices":[{"message":{"content":"Hello alice@example.com"}}]}`
+	require.Equal(t, expRestored, string(commonRes.BodyMutation.GetBody()))
+	require.Len(t, commonRes.HeaderMutation.SetHeaders, 1)
+	require.Equal(t, "content-length", commonRes.HeaderMutation.SetHeaders[0].Header.Key)
+	require.Equal(t, strconv.Itoa(len(expRestored)), string(commonRes.HeaderMutation.SetHeaders[0].Header.RawValue))
+
+	// For streaming responses, a placeholder split across two events is restored in the second one.
+	p.parent.stream = true
+	var streamed []byte
+	for _, chunk := range []*extprocv3.HttpBody{
+		{Body: []byte("data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Bye [PII_EM\"}}]}\n\n")},
+		{Body: []byte("data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"AIL_1]\"}}]}\n\ndata: [DONE]\n\n"), EndOfStream: true},
+	} {
+		mt.expResponseBody = chunk
+		resp, err = p.ProcessResponseBody(t.Context(), chunk)
+		require.NoError(t, err)
+		commonRes = resp.Response.(*extprocv3.ProcessingResponse_ResponseBody).ResponseBody.Response
+		streamed = append(streamed, commonRes.BodyMutation.GetBody()...)
+	}
+	require.Equal(t, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Bye \"}}]}\n\n"+
+		"data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"alice@example.com\"}}]}\n\ndata: [DONE]\n\n", string(streamed))
+}
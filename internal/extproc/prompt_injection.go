@@ -0,0 +1,125 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"go.opentelemetry.io/otel/attribute"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/envoyproxy/ai-gateway/internal/promptinjection"
+)
+
+const (
+	// promptInjectionScoreMetadataKey and promptInjectionRulesMetadataKey are the keys of the risk score and the
+	// comma-separated matched rules in the dynamic metadata.
+	promptInjectionScoreMetadataKey = "prompt_injection_score"
+	promptInjectionRulesMetadataKey = "prompt_injection_rules"
+	// promptInjectionAttributeScore, promptInjectionAttributeRules and promptInjectionAttributeBlocked are the
+	// span attributes of the screening result.
+	promptInjectionAttributeScore   = "prompt_injection.score"
+	promptInjectionAttributeRules   = "prompt_injection.rules"
+	promptInjectionAttributeBlocked = "prompt_injection.blocked"
+)
+
+// promptInjectionProcessor is implemented by the router processors whose request body can be screened for prompt
+// injection attempts.
+type promptInjectionProcessor interface {
+	// promptInjectionRequestBody returns the decompressed request body sent by the client.
+	promptInjectionRequestBody() []byte
+	// recordPromptInjection records the result of the screening on the span of the request. The span is ended
+	// if the request is blocked with the given response body.
+	recordPromptInjection(result promptinjection.Result, blocked bool, body []byte)
+}
+
+// screenPromptInjection scores the request accepted by the router processor for prompt injection attempts. The
+// score is set in the dynamic metadata of the response, and the request is rejected if the score reaches the
+// block threshold.
+func (s *Server) screenPromptInjection(ctx context.Context, p Processor, resp *extprocv3.ProcessingResponse, requestHeaders map[string]string) *extprocv3.ProcessingResponse {
+	config := s.config
+	if config == nil || config.PromptInjectionDetection == nil {
+		return resp
+	}
+	if _, ok := resp.GetResponse().(*extprocv3.ProcessingResponse_RequestBody); !ok {
+		return resp // The request has been rejected by the processor.
+	}
+	pp, ok := p.(promptInjectionProcessor)
+	if !ok {
+		return resp
+	}
+
+	result := promptinjection.Score(pp.promptInjectionRequestBody())
+	threshold := config.PromptInjectionDetection.BlockThreshold
+	blocked := threshold > 0 && result.Score >= threshold
+	if m := s.promptInjectionMetrics; m != nil {
+		m.RecordScore(ctx, result.Score, blocked, requestHeaders[modelNameHeaderKey(config)])
+	}
+	if !blocked {
+		pp.recordPromptInjection(result, false, nil)
+		resp.DynamicMetadata = mergeDynamicMetadata(metadataNamespace(config), resp.DynamicMetadata,
+			buildPromptInjectionDynamicMetadata(metadataNamespace(config), result))
+		return resp
+	}
+
+	loggerFromContext(ctx).Info("rejecting request scored as a prompt injection attempt",
+		slog.Int("score", result.Score), slog.Any("rules", result.Rules))
+	blockedResp := promptInjectionBlockedResponse()
+	pp.recordPromptInjection(result, true, blockedResp.GetImmediateResponse().GetBody())
+	return blockedResp
+}
+
+// promptInjectionSpanAttributes returns the span attributes of the screening result.
+func promptInjectionSpanAttributes(result promptinjection.Result, blocked bool) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		attribute.Int(promptInjectionAttributeScore, result.Score),
+		attribute.Bool(promptInjectionAttributeBlocked, blocked),
+	}
+	if len(result.Rules) > 0 {
+		attrs = append(attrs, attribute.StringSlice(promptInjectionAttributeRules, result.Rules))
+	}
+	return attrs
+}
+
+// buildPromptInjectionDynamicMetadata builds the dynamic metadata of the screening result, so that it can be
+// logged in the access logs.
+func buildPromptInjectionDynamicMetadata(namespace string, result promptinjection.Result) *structpb.Struct {
+	return &structpb.Struct{
+		Fields: map[string]*structpb.Value{
+			namespace: structpb.NewStructValue(&structpb.Struct{
+				Fields: map[string]*structpb.Value{
+					promptInjectionScoreMetadataKey: structpb.NewNumberValue(float64(result.Score)),
+					promptInjectionRulesMetadataKey: structpb.NewStringValue(strings.Join(result.Rules, ",")),
+				},
+			}),
+		},
+	}
+}
+
+// promptInjectionBlockedResponse returns the response rejecting a request scored as a prompt injection attempt.
+func promptInjectionBlockedResponse() *extprocv3.ProcessingResponse {
+	const statusCode = 403
+	body := formatUserFacingErrorJSON("Forbidden", statusCode, "the request was blocked as a potential prompt injection")
+	headerMutation := &extprocv3.HeaderMutation{}
+	setHeader(headerMutation, "content-type", "application/json")
+	setHeader(headerMutation, "content-length", strconv.Itoa(len(body)))
+	return &extprocv3.ProcessingResponse{
+		Response: &extprocv3.ProcessingResponse_ImmediateResponse{
+			ImmediateResponse: &extprocv3.ImmediateResponse{
+				Status:     &typev3.HttpStatus{Code: typev3.StatusCode_Forbidden},
+				Headers:    headerMutation,
+				Body:       body,
+				GrpcStatus: &extprocv3.GrpcStatus{Status: uint32(codes.PermissionDenied)},
+			},
+		},
+	}
+}
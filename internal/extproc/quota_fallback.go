@@ -0,0 +1,293 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/envoyproxy/ai-gateway/internal/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/metrics"
+)
+
+const (
+	// quotaFallbackProbeInterval is the interval between the health checks of the quota rate limit service.
+	quotaFallbackProbeInterval = 5 * time.Second
+	// quotaFallbackProbeTimeout is the timeout of a single health check of the quota rate limit service.
+	quotaFallbackProbeTimeout = time.Second
+	// quotaFallbackFailureThreshold is the number of consecutive failed health checks after which the quota rate
+	// limit service is considered unreachable. A single successful health check recovers from the degraded mode.
+	quotaFallbackFailureThreshold = 2
+)
+
+// quotaFallbackProcessor is implemented by the upstream processors that know the backend and the route serving
+// the request after [Processor.SetBackend].
+type quotaFallbackProcessor interface {
+	quotaFallbackTarget() (backendName, routeName string)
+}
+
+// quotaFallback enforces [filterapi.QuotaFallback] while the quota rate limit service is unreachable. Since the
+// rate limit filter fails open by default, this keeps some protection during the outages with a local token
+// bucket per limit, approximating the quotas of the whole gateway with the per-pod limits set by the controller.
+//
+// This is owned by the Server rather than the runtime config so that the health of the rate limit service and
+// the buckets survive the config reloads.
+type quotaFallback struct {
+	logger *slog.Logger
+	// metrics records the degraded mode and the rejected requests. Optional.
+	metrics metrics.QuotaFallbackMetrics
+	// newHealthClient creates the health client of the rate limit service at the given address.
+	newHealthClient func(addr string) (grpc_health_v1.HealthClient, io.Closer, error)
+	probeInterval   time.Duration
+	now             func() time.Time
+
+	// degraded is true while the rate limit service is considered unreachable.
+	degraded atomic.Bool
+
+	mu sync.Mutex
+	// addr is the address of the rate limit service being probed, and stop stops probing it.
+	addr string
+	stop context.CancelFunc
+	// buckets are the token buckets keyed by the limits.
+	buckets map[filterapi.QuotaFallbackLimit]*quotaFallbackBucket
+}
+
+// quotaFallbackBucket is a token bucket refilled continuously at the rate of the limit per window.
+type quotaFallbackBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newQuotaFallback(logger *slog.Logger) *quotaFallback {
+	return &quotaFallback{
+		logger:          logger,
+		newHealthClient: newRateLimitHealthClient,
+		probeInterval:   quotaFallbackProbeInterval,
+		now:             time.Now,
+		buckets:         make(map[filterapi.QuotaFallbackLimit]*quotaFallbackBucket),
+	}
+}
+
+// newRateLimitHealthClient creates a gRPC health client of the rate limit service at the given address.
+func newRateLimitHealthClient(addr string) (grpc_health_v1.HealthClient, io.Closer, error) {
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create the client of the quota rate limit service: %w", err)
+	}
+	return grpc_health_v1.NewHealthClient(conn), conn, nil
+}
+
+// update starts probing the rate limit service of the given config, stopping the probes of the previous one.
+// Probing stops when the config is nil.
+func (q *quotaFallback) update(config *filterapi.QuotaFallback) {
+	var addr string
+	if config != nil {
+		addr = config.RateLimitServiceAddress
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if addr == q.addr {
+		return
+	}
+	if q.stop != nil {
+		q.stop()
+		q.stop = nil
+	}
+	q.addr = addr
+	q.setDegraded(false, nil)
+	if addr == "" {
+		return
+	}
+	client, closer, err := q.newHealthClient(addr)
+	if err != nil {
+		q.logger.Error("cannot probe the quota rate limit service", slog.String("address", addr), slog.String("error", err.Error()))
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	q.stop = cancel
+	go func() {
+		defer func() { _ = closer.Close() }()
+		q.probe(ctx, client)
+	}()
+}
+
+// probe checks the health of the rate limit service until ctx is done, and switches to the degraded mode after
+// quotaFallbackFailureThreshold consecutive failures.
+func (q *quotaFallback) probe(ctx context.Context, client grpc_health_v1.HealthClient) {
+	ticker := time.NewTicker(q.probeInterval)
+	defer ticker.Stop()
+	var failures int
+	for {
+		checkCtx, cancel := context.WithTimeout(ctx, quotaFallbackProbeTimeout)
+		resp, err := client.Check(checkCtx, &grpc_health_v1.HealthCheckRequest{})
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+		if err == nil && resp.GetStatus() != grpc_health_v1.HealthCheckResponse_SERVING {
+			err = fmt.Errorf("status is %s", resp.GetStatus())
+		}
+		if err == nil {
+			failures = 0
+		} else {
+			failures++
+		}
+		q.mu.Lock()
+		// Checking ctx with mu held ensures that a stopped probe doesn't override the state reset by update.
+		if ctx.Err() == nil && (err == nil || failures >= quotaFallbackFailureThreshold) {
+			q.setDegraded(err != nil, err)
+		}
+		q.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// setDegraded switches the degraded mode, logging the transitions. Each outage starts with full buckets.
+// This must be called with mu held.
+func (q *quotaFallback) setDegraded(degraded bool, err error) {
+	if q.degraded.Swap(degraded) == degraded {
+		return
+	}
+	if degraded {
+		clear(q.buckets)
+		q.logger.Warn("the quota rate limit service is unreachable, enforcing the local fallback limits",
+			slog.String("error", err.Error()))
+	} else {
+		q.logger.Info("the quota rate limit service is reachable again, disabling the local fallback limits")
+	}
+	if q.metrics != nil {
+		q.metrics.RecordDegraded(context.Background(), degraded)
+	}
+}
+
+// admit checks the request to the backend and model on the route against the fallback limits while degraded.
+// A request is admitted if none of the matching buckets is exhausted, in which case a token is taken from the
+// buckets counting the requests. Otherwise, nothing is taken and the exhausted limit is returned.
+func (q *quotaFallback) admit(limits []filterapi.QuotaFallbackLimit, backendName, routeName, model string) *filterapi.QuotaFallbackLimit {
+	if !q.degraded.Load() {
+		return nil
+	}
+	backend := shortBackendName(backendName)
+	now := q.now()
+	var matched []*quotaFallbackBucket
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i := range limits {
+		l := &limits[i]
+		if l.RouteName != routeName || l.Backend != backend || l.Model != model {
+			continue
+		}
+		b := q.bucket(l, now)
+		// The buckets counting the cost are charged after the response, so they may be in debt.
+		if (l.MetadataKey == "" && b.tokens < 1) || b.tokens <= 0 {
+			return l
+		}
+		if l.MetadataKey == "" {
+			matched = append(matched, b)
+		}
+	}
+	for _, b := range matched {
+		b.tokens--
+	}
+	return nil
+}
+
+// charge takes the costs stored in the dynamic metadata namespace of the filter of the completed request from the
+// buckets counting them while degraded.
+func (q *quotaFallback) charge(limits []filterapi.QuotaFallbackLimit, backendName, routeName, model string, metadata *structpb.Value) {
+	if !q.degraded.Load() {
+		return
+	}
+	costs := metadata.GetStructValue().GetFields()
+	if len(costs) == 0 {
+		return
+	}
+	backend := shortBackendName(backendName)
+	now := q.now()
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i := range limits {
+		l := &limits[i]
+		if l.MetadataKey == "" || l.RouteName != routeName || l.Backend != backend || l.Model != model {
+			continue
+		}
+		if cost, ok := costs[l.MetadataKey]; ok {
+			q.bucket(l, now).tokens -= cost.GetNumberValue()
+		}
+	}
+}
+
+// bucket returns the bucket of the limit refilled up to now. This must be called with mu held.
+func (q *quotaFallback) bucket(l *filterapi.QuotaFallbackLimit, now time.Time) *quotaFallbackBucket {
+	b, ok := q.buckets[*l]
+	if !ok {
+		b = &quotaFallbackBucket{tokens: float64(l.Limit), last: now}
+		q.buckets[*l] = b
+		return b
+	}
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		rate := float64(l.Limit) / float64(max(l.WindowSeconds, 1))
+		b.tokens = math.Min(float64(l.Limit), b.tokens+elapsed*rate)
+		b.last = now
+	}
+	return b
+}
+
+// shortBackendName returns the "namespace/name" of the AIServiceBackend from the full backend name.
+func shortBackendName(backendName string) string {
+	if parts := strings.SplitN(backendName, "/", 3); len(parts) >= 2 {
+		return parts[0] + "/" + parts[1]
+	}
+	return backendName
+}
+
+// quotaFallbackExceededResponse returns the 429 response for the request rejected by the given fallback limit.
+func quotaFallbackExceededResponse(l *filterapi.QuotaFallbackLimit) *extprocv3.ProcessingResponse {
+	const statusCode = 429
+	body := formatUserFacingErrorJSON("TooManyRequests", statusCode, "quota exceeded for model "+l.Model)
+	headerMutation := &extprocv3.HeaderMutation{}
+	setHeader(headerMutation, "content-type", "application/json")
+	setHeader(headerMutation, "content-length", strconv.Itoa(len(body)))
+	setHeader(headerMutation, "retry-after", strconv.FormatInt(quotaFallbackRetryAfter(l), 10))
+	return &extprocv3.ProcessingResponse{
+		Response: &extprocv3.ProcessingResponse_ImmediateResponse{
+			ImmediateResponse: &extprocv3.ImmediateResponse{
+				Status:     &typev3.HttpStatus{Code: typev3.StatusCode_TooManyRequests},
+				Headers:    headerMutation,
+				Body:       body,
+				GrpcStatus: &extprocv3.GrpcStatus{Status: uint32(codes.ResourceExhausted)},
+			},
+		},
+	}
+}
+
+// quotaFallbackRetryAfter returns the number of seconds to refill a token of the limit.
+func quotaFallbackRetryAfter(l *filterapi.QuotaFallbackLimit) int64 {
+	if l.Limit == 0 {
+		return max(l.WindowSeconds, 1)
+	}
+	return max(int64(math.Ceil(float64(l.WindowSeconds)/float64(l.Limit))), 1)
+}
@@ -0,0 +1,238 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/envoyproxy/ai-gateway/internal/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+)
+
+// fakeHealthClient fails the health checks with err if set, and reports SERVING otherwise.
+type fakeHealthClient struct {
+	grpc_health_v1.HealthClient
+	err atomic.Pointer[error]
+}
+
+func (f *fakeHealthClient) Check(context.Context, *grpc_health_v1.HealthCheckRequest, ...grpc.CallOption) (*grpc_health_v1.HealthCheckResponse, error) {
+	if err := f.err.Load(); err != nil {
+		return nil, *err
+	}
+	return &grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_SERVING}, nil
+}
+
+type nopCloser struct{}
+
+func (nopCloser) Close() error { return nil }
+
+func newTestQuotaFallback(t *testing.T, client grpc_health_v1.HealthClient) *quotaFallback {
+	q := newQuotaFallback(slog.Default())
+	q.probeInterval = 10 * time.Millisecond
+	q.newHealthClient = func(string) (grpc_health_v1.HealthClient, io.Closer, error) { return client, nopCloser{}, nil }
+	t.Cleanup(func() { q.update(nil) })
+	return q
+}
+
+func TestQuotaFallback_update(t *testing.T) {
+	client := &fakeHealthClient{}
+	q := newTestQuotaFallback(t, client)
+	q.update(&filterapi.QuotaFallback{RateLimitServiceAddress: "ratelimit:8081"})
+	require.Equal(t, "ratelimit:8081", q.addr)
+	require.False(t, q.degraded.Load())
+
+	// The service is considered unreachable after consecutive failures.
+	unavailable := errors.New("connection refused")
+	client.err.Store(&unavailable)
+	require.Eventually(t, q.degraded.Load, time.Second, 5*time.Millisecond)
+
+	// A single successful check recovers.
+	client.err.Store(nil)
+	require.Eventually(t, func() bool { return !q.degraded.Load() }, time.Second, 5*time.Millisecond)
+
+	// Removing the config stops probing and leaves the degraded mode.
+	client.err.Store(&unavailable)
+	require.Eventually(t, q.degraded.Load, time.Second, 5*time.Millisecond)
+	q.update(nil)
+	require.Empty(t, q.addr)
+	require.Nil(t, q.stop)
+	require.False(t, q.degraded.Load())
+}
+
+func TestQuotaFallback_admit(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	q := newQuotaFallback(slog.Default())
+	q.now = func() time.Time { return now }
+	limits := []filterapi.QuotaFallbackLimit{
+		{RouteName: "default/route", Backend: "default/openai", Model: "gpt-4o", Limit: 2, WindowSeconds: 60},
+	}
+	const backendName = "default/openai/route/route/rule/0/ref/0"
+
+	// Nothing is limited unless degraded.
+	for range 5 {
+		require.Nil(t, q.admit(limits, backendName, "default/route", "gpt-4o"))
+	}
+	require.Empty(t, q.buckets)
+
+	q.degraded.Store(true)
+	require.Nil(t, q.admit(limits, backendName, "default/route", "gpt-4o"))
+	require.Nil(t, q.admit(limits, backendName, "default/route", "gpt-4o"))
+	require.Equal(t, &limits[0], q.admit(limits, backendName, "default/route", "gpt-4o"))
+	// Other models, backends and routes are not limited.
+	require.Nil(t, q.admit(limits, backendName, "default/route", "gpt-4o-mini"))
+	require.Nil(t, q.admit(limits, "default/anthropic/route/route/rule/0/ref/0", "default/route", "gpt-4o"))
+	require.Nil(t, q.admit(limits, backendName, "default/other", "gpt-4o"))
+
+	// A request is refilled every 30 seconds.
+	now = now.Add(30 * time.Second)
+	require.Nil(t, q.admit(limits, backendName, "default/route", "gpt-4o"))
+	require.Equal(t, &limits[0], q.admit(limits, backendName, "default/route", "gpt-4o"))
+
+	// Each outage starts with full buckets.
+	q.mu.Lock()
+	q.setDegraded(false, nil)
+	q.setDegraded(true, errors.New("connection refused"))
+	q.mu.Unlock()
+	require.Empty(t, q.buckets)
+}
+
+func TestQuotaFallbackExceededResponse(t *testing.T) {
+	resp := quotaFallbackExceededResponse(&filterapi.QuotaFallbackLimit{Model: "gpt-4o", Limit: 10, WindowSeconds: 60})
+	ir := resp.GetImmediateResponse()
+	require.NotNil(t, ir)
+	require.Equal(t, typev3.StatusCode_TooManyRequests, ir.Status.Code)
+	require.JSONEq(t, `{"type":"error","error":{"type":"TooManyRequests","code":"429","message":"quota exceeded for model gpt-4o"}}`, string(ir.Body))
+	headers := map[string]string{}
+	for _, h := range ir.Headers.SetHeaders {
+		headers[h.Header.Key] = string(h.Header.RawValue)
+	}
+	require.Equal(t, "6", headers["retry-after"])
+}
+
+type fakeQuotaFallbackProcessor struct {
+	passThroughProcessor
+	// responseBody is returned for the response body if set.
+	responseBody *extprocv3.ProcessingResponse
+}
+
+// ProcessResponseBody implements [Processor.ProcessResponseBody].
+func (f fakeQuotaFallbackProcessor) ProcessResponseBody(ctx context.Context, body *extprocv3.HttpBody) (*extprocv3.ProcessingResponse, error) {
+	if f.responseBody != nil {
+		return f.responseBody, nil
+	}
+	return f.passThroughProcessor.ProcessResponseBody(ctx, body)
+}
+
+func (fakeQuotaFallbackProcessor) quotaFallbackTarget() (string, string) {
+	return "default/openai/route/route/rule/0/ref/0", "default/route"
+}
+
+func TestServer_applyQuotaFallback(t *testing.T) {
+	s, err := NewServer(slog.Default(), false)
+	require.NoError(t, err)
+	headers := map[string]string{internalapi.ModelNameHeaderKeyDefault: "gpt-4o"}
+	ctx := context.WithValue(t.Context(), loggerContextKey, slog.Default())
+	headersReq := &extprocv3.ProcessingRequest{Request: &extprocv3.ProcessingRequest_RequestHeaders{RequestHeaders: &extprocv3.HttpHeaders{}}}
+	accepted := &extprocv3.ProcessingResponse{Response: &extprocv3.ProcessingResponse_RequestHeaders{}}
+	s.quotaFallback.degraded.Store(true)
+
+	// No config or no limits.
+	require.Equal(t, accepted, s.applyQuotaFallback(ctx, fakeQuotaFallbackProcessor{}, headersReq, accepted, headers))
+	s.config = &filterapi.RuntimeConfig{QuotaFallback: &filterapi.QuotaFallback{}}
+	require.Equal(t, accepted, s.applyQuotaFallback(ctx, fakeQuotaFallbackProcessor{}, headersReq, accepted, headers))
+
+	s.config = &filterapi.RuntimeConfig{QuotaFallback: &filterapi.QuotaFallback{Limits: []filterapi.QuotaFallbackLimit{
+		{RouteName: "default/route", Backend: "default/openai", Model: "gpt-4o", Limit: 1, WindowSeconds: 60},
+	}}}
+	// The processors without the backend and the rejected requests are not counted.
+	require.Equal(t, accepted, s.applyQuotaFallback(ctx, passThroughProcessor{}, headersReq, accepted, headers))
+	rejected := createUserFacingErrorResponse(400, "BadRequest", "bad")
+	require.Equal(t, rejected, s.applyQuotaFallback(ctx, fakeQuotaFallbackProcessor{}, headersReq, rejected, headers))
+
+	require.Equal(t, accepted, s.applyQuotaFallback(ctx, fakeQuotaFallbackProcessor{}, headersReq, accepted, headers))
+	resp := s.applyQuotaFallback(ctx, fakeQuotaFallbackProcessor{}, headersReq, accepted, headers)
+	require.Equal(t, typev3.StatusCode_TooManyRequests, resp.GetImmediateResponse().GetStatus().GetCode())
+}
+
+// scriptedProcessingStream is an [extprocv3.ExternalProcessor_ProcessServer] receiving the given requests in order
+// and then io.EOF, recording the sent responses.
+type scriptedProcessingStream struct {
+	extprocv3.ExternalProcessor_ProcessServer
+	ctx       context.Context
+	requests  []*extprocv3.ProcessingRequest
+	responses []*extprocv3.ProcessingResponse
+}
+
+func (s *scriptedProcessingStream) Context() context.Context { return s.ctx }
+
+func (s *scriptedProcessingStream) Recv() (*extprocv3.ProcessingRequest, error) {
+	if len(s.requests) == 0 {
+		return nil, io.EOF
+	}
+	req := s.requests[0]
+	s.requests = s.requests[1:]
+	return req, nil
+}
+
+func (s *scriptedProcessingStream) Send(resp *extprocv3.ProcessingResponse) error {
+	s.responses = append(s.responses, resp)
+	return nil
+}
+
+func TestServer_Process_quotaFallbackCharge(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	s, err := NewServer(slog.Default(), false)
+	require.NoError(t, err)
+	s.quotaFallback.now = func() time.Time { return now }
+	s.quotaFallback.degraded.Store(true)
+	limits := []filterapi.QuotaFallbackLimit{
+		{RouteName: "default/route", Backend: "default/openai", Model: "gpt-4o", MetadataKey: "quota_total_tokens", Limit: 100, WindowSeconds: 60},
+	}
+	s.config = &filterapi.RuntimeConfig{QuotaFallback: &filterapi.QuotaFallback{Limits: limits}}
+	responseBody := &extprocv3.ProcessingResponse{
+		Response: &extprocv3.ProcessingResponse_ResponseBody{},
+		DynamicMetadata: &structpb.Struct{Fields: map[string]*structpb.Value{
+			internalapi.AIGatewayFilterMetadataNamespace: structpb.NewStructValue(&structpb.Struct{Fields: map[string]*structpb.Value{
+				"quota_total_tokens": structpb.NewNumberValue(250),
+			}}),
+		}},
+	}
+	s.Register("/", func(*filterapi.RuntimeConfig, map[string]string, *slog.Logger, bool, bool) (Processor, error) {
+		return fakeQuotaFallbackProcessor{responseBody: responseBody}, nil
+	})
+
+	// The router filter stream charges the cost in the metadata of the end of the response body.
+	stream := &scriptedProcessingStream{ctx: t.Context(), requests: []*extprocv3.ProcessingRequest{
+		{Request: &extprocv3.ProcessingRequest_RequestHeaders{RequestHeaders: &extprocv3.HttpHeaders{Headers: &corev3.HeaderMap{Headers: []*corev3.HeaderValue{
+			{Key: ":path", Value: "/"}, {Key: internalapi.ModelNameHeaderKeyDefault, Value: "gpt-4o"},
+		}}}}},
+		{Request: &extprocv3.ProcessingRequest_ResponseBody{ResponseBody: &extprocv3.HttpBody{Body: []byte("{}")}}},
+		{Request: &extprocv3.ProcessingRequest_ResponseBody{ResponseBody: &extprocv3.HttpBody{EndOfStream: true}}},
+	}}
+	require.NoError(t, s.Process(stream))
+	require.Len(t, stream.responses, 3)
+
+	// The bucket is in debt after a single charge, so the next request is rejected until it is refilled.
+	const backendName = "default/openai/route/route/rule/0/ref/0"
+	now = now.Add(60 * time.Second)
+	require.Equal(t, &limits[0], s.quotaFallback.admit(limits, backendName, "default/route", "gpt-4o"))
+	now = now.Add(60 * time.Second)
+	require.Nil(t, s.quotaFallback.admit(limits, backendName, "default/route", "gpt-4o"))
+}
@@ -0,0 +1,75 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"slices"
+	"time"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/envoyproxy/ai-gateway/internal/filterapi"
+)
+
+// applyQuotaSchedules sets the active window of each quota schedule in the dynamic metadata of the request accepted
+// by the router processor. The quota rate limit filter runs after this filter in the request path, and its actions
+// read the window to select the limit of the quotas with schedules.
+func (s *Server) applyQuotaSchedules(resp *extprocv3.ProcessingResponse, now time.Time) *extprocv3.ProcessingResponse {
+	config := s.config
+	if config == nil || len(config.QuotaSchedules) == 0 {
+		return resp
+	}
+	if _, ok := resp.GetResponse().(*extprocv3.ProcessingResponse_RequestBody); !ok {
+		return resp // The request has been rejected by the processor.
+	}
+	fields := make(map[string]*structpb.Value, len(config.QuotaSchedules))
+	for i := range config.QuotaSchedules {
+		qs := &config.QuotaSchedules[i]
+		fields[qs.MetadataKey] = structpb.NewStringValue(activeQuotaScheduleWindow(qs, now))
+	}
+	namespace := metadataNamespace(config)
+	resp.DynamicMetadata = mergeDynamicMetadata(namespace, resp.DynamicMetadata, &structpb.Struct{
+		Fields: map[string]*structpb.Value{
+			namespace: structpb.NewStructValue(&structpb.Struct{Fields: fields}),
+		},
+	})
+	return resp
+}
+
+// activeQuotaScheduleWindow returns the name of the first window of the schedule that is active at the given time,
+// or the default window if none is.
+func activeQuotaScheduleWindow(qs *filterapi.RuntimeQuotaSchedule, now time.Time) string {
+	for i := range qs.Windows {
+		if quotaScheduleWindowActive(&qs.Windows[i], now) {
+			return qs.Windows[i].Name
+		}
+	}
+	return qs.DefaultWindow
+}
+
+// quotaScheduleWindowActive returns true if the window is active at the given time. A window whose end isn't after
+// its start ends on the next day, so it is active after its start on the days it starts and before its end on the
+// following days.
+func quotaScheduleWindowActive(w *filterapi.RuntimeQuotaScheduleWindow, now time.Time) bool {
+	local := now.In(w.Location)
+	// The wall clock time rather than the elapsed time since midnight, which differs on the days of the DST changes.
+	sinceMidnight := time.Duration(local.Hour())*time.Hour + time.Duration(local.Minute())*time.Minute +
+		time.Duration(local.Second())*time.Second
+	startsOn := func(day time.Weekday) bool {
+		return len(w.Days) == 0 || slices.Contains(w.Days, day)
+	}
+	switch {
+	case w.Start < w.End:
+		return sinceMidnight >= w.Start && sinceMidnight < w.End && startsOn(local.Weekday())
+	case sinceMidnight >= w.Start:
+		return startsOn(local.Weekday())
+	case sinceMidnight < w.End:
+		return startsOn((local.Weekday() + 6) % 7)
+	default:
+		return false
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"testing"
+	"time"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/internal/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+)
+
+func TestQuotaScheduleWindowActive(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	require.NoError(t, err)
+	businessHours := &filterapi.RuntimeQuotaScheduleWindow{
+		Days:     []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+		Start:    9 * time.Hour,
+		End:      17 * time.Hour,
+		Location: time.UTC,
+	}
+	overnight := &filterapi.RuntimeQuotaScheduleWindow{
+		Days:     []time.Weekday{time.Friday},
+		Start:    22 * time.Hour,
+		End:      6 * time.Hour,
+		Location: time.UTC,
+	}
+	tokyoMornings := &filterapi.RuntimeQuotaScheduleWindow{Start: 9 * time.Hour, End: 12 * time.Hour, Location: tokyo}
+
+	for _, tc := range []struct {
+		name   string
+		window *filterapi.RuntimeQuotaScheduleWindow
+		now    time.Time
+		exp    bool
+	}{
+		// 2025-01-06 is a Monday.
+		{name: "business hours", window: businessHours, now: time.Date(2025, 1, 6, 10, 0, 0, 0, time.UTC), exp: true},
+		{name: "at start", window: businessHours, now: time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC), exp: true},
+		{name: "at end", window: businessHours, now: time.Date(2025, 1, 6, 17, 0, 0, 0, time.UTC)},
+		{name: "before start", window: businessHours, now: time.Date(2025, 1, 6, 8, 59, 59, 0, time.UTC)},
+		{name: "weekend", window: businessHours, now: time.Date(2025, 1, 11, 10, 0, 0, 0, time.UTC)},
+		{name: "overnight start day", window: overnight, now: time.Date(2025, 1, 10, 23, 0, 0, 0, time.UTC), exp: true},
+		{name: "overnight next day", window: overnight, now: time.Date(2025, 1, 11, 5, 0, 0, 0, time.UTC), exp: true},
+		{name: "overnight after end", window: overnight, now: time.Date(2025, 1, 11, 6, 0, 0, 0, time.UTC)},
+		{name: "overnight other day", window: overnight, now: time.Date(2025, 1, 9, 23, 0, 0, 0, time.UTC)},
+		{name: "overnight morning of start day", window: overnight, now: time.Date(2025, 1, 10, 5, 0, 0, 0, time.UTC)},
+		{name: "time zone", window: tokyoMornings, now: time.Date(2025, 1, 6, 1, 0, 0, 0, time.UTC), exp: true},
+		{name: "time zone outside", window: tokyoMornings, now: time.Date(2025, 1, 6, 10, 0, 0, 0, time.UTC)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.exp, quotaScheduleWindowActive(tc.window, tc.now))
+		})
+	}
+}
+
+func TestActiveQuotaScheduleWindow(t *testing.T) {
+	qs := &filterapi.RuntimeQuotaSchedule{
+		QuotaSchedule: &filterapi.QuotaSchedule{DefaultWindow: "__default"},
+		Windows: []filterapi.RuntimeQuotaScheduleWindow{
+			{Name: "lunch", Start: 12 * time.Hour, End: 13 * time.Hour, Location: time.UTC},
+			{Name: "day", Start: 9 * time.Hour, End: 17 * time.Hour, Location: time.UTC},
+		},
+	}
+	day := time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC)
+	require.Equal(t, "day", activeQuotaScheduleWindow(qs, day.Add(10*time.Hour)))
+	// The first active window takes precedence.
+	require.Equal(t, "lunch", activeQuotaScheduleWindow(qs, day.Add(12*time.Hour)))
+	require.Equal(t, "__default", activeQuotaScheduleWindow(qs, day.Add(20*time.Hour)))
+}
+
+func TestServer_applyQuotaSchedules(t *testing.T) {
+	s := &Server{config: &filterapi.RuntimeConfig{QuotaSchedules: []filterapi.RuntimeQuotaSchedule{
+		{
+			QuotaSchedule: &filterapi.QuotaSchedule{MetadataKey: "quota_schedule/ns/policy/0", DefaultWindow: "__default"},
+			Windows: []filterapi.RuntimeQuotaScheduleWindow{
+				{Name: "business-hours", Start: 9 * time.Hour, End: 17 * time.Hour, Location: time.UTC},
+			},
+		},
+	}}}
+	now := time.Date(2025, 1, 6, 10, 0, 0, 0, time.UTC)
+
+	resp := s.applyQuotaSchedules(&extprocv3.ProcessingResponse{
+		Response: &extprocv3.ProcessingResponse_RequestBody{RequestBody: &extprocv3.BodyResponse{}},
+	}, now)
+	md := resp.DynamicMetadata.Fields[internalapi.AIGatewayFilterMetadataNamespace].GetStructValue()
+	require.Equal(t, "business-hours", md.Fields["quota_schedule/ns/policy/0"].GetStringValue())
+
+	// The rejected requests are left as is.
+	rejected := &extprocv3.ProcessingResponse{
+		Response: &extprocv3.ProcessingResponse_ImmediateResponse{ImmediateResponse: &extprocv3.ImmediateResponse{}},
+	}
+	require.Nil(t, s.applyQuotaSchedules(rejected, now).DynamicMetadata)
+
+	// Nothing is set without schedules.
+	s.config = &filterapi.RuntimeConfig{}
+	require.Nil(t, s.applyQuotaSchedules(&extprocv3.ProcessingResponse{
+		Response: &extprocv3.ProcessingResponse_RequestBody{RequestBody: &extprocv3.BodyResponse{}},
+	}, now).DynamicMetadata)
+}
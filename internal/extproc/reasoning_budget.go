@@ -0,0 +1,223 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/anthropic"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/metrics"
+)
+
+// reasoningBudgetExceededEventType is the server-sent event type emitted after the final chunk of a stream cut off
+// at the reasoning budget. OpenAI clients ignore the events with an unknown type.
+const reasoningBudgetExceededEventType = "reasoning_budget_exceeded"
+
+// applyReasoningBudget lowers the thinking budget and the reasoning effort of the request above the caps of the
+// ReasoningBudget of the route, if any. Like the parameter overrides, the rewritten body replaces the original one of
+// the router processor so that it is reused on the retries.
+func (u *upstreamProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) applyReasoningBudget() error {
+	rp := u.parent
+	if rp.config == nil {
+		return nil
+	}
+	budget := rp.config.ReasoningBudgets[u.routeName]
+	if budget == nil {
+		return nil
+	}
+	body, err := capReasoning(rp.originalRequestBody, rp.originalRequestBodyRaw, budget)
+	if err != nil || body == nil {
+		return err
+	}
+	_, req, _, _, err := rp.eh.ParseBody(body, false)
+	if err != nil {
+		return err
+	}
+	rp.originalRequestBodyRaw, rp.originalRequestBody = body, req
+	rp.forceBodyMutation = true
+	return nil
+}
+
+// capReasoning returns the JSON request body with the thinking budget and the reasoning effort lowered to the caps
+// of the budget, or nil if the request is within them. The requests not setting them are left as is, since setting
+// them would fail on the models without reasoning.
+func capReasoning(req any, body []byte, budget *filterapi.ReasoningBudget) ([]byte, error) {
+	var budgetField, effortField string
+	switch req.(type) {
+	case *openai.ChatCompletionRequest:
+		budgetField, effortField = "thinking.budget_tokens", "reasoning_effort"
+	case *anthropic.MessagesRequest:
+		budgetField = "thinking.budget_tokens"
+	case *openai.ResponseRequest:
+		effortField = "reasoning.effort"
+	default:
+		return nil, nil
+	}
+
+	out := slices.Clone(body)
+	changed := false
+	if budgetField != "" && budget.MaxReasoningTokens > 0 && gjson.GetBytes(body, "thinking.type").String() == "enabled" {
+		if tokens := gjson.GetBytes(body, budgetField); tokens.Exists() && tokens.Int() > int64(budget.MaxReasoningTokens) {
+			var err error
+			if out, err = sjson.SetBytes(out, budgetField, budget.MaxReasoningTokens); err != nil {
+				return nil, fmt.Errorf("failed to cap %s: %w", budgetField, err)
+			}
+			changed = true
+		}
+	}
+	if effortField != "" && budget.MaxEffort != "" {
+		// The unknown efforts are left to the backend to reject.
+		effort := slices.Index(filterapi.ReasoningEfforts, gjson.GetBytes(body, effortField).String())
+		if effort > slices.Index(filterapi.ReasoningEfforts, budget.MaxEffort) {
+			var err error
+			if out, err = sjson.SetBytes(out, effortField, budget.MaxEffort); err != nil {
+				return nil, fmt.Errorf("failed to cap %s: %w", effortField, err)
+			}
+			changed = true
+		}
+	}
+	if !changed {
+		return nil, nil
+	}
+	return out, nil
+}
+
+// streamReasoningBudget terminates a streaming chat completion once its reasoning exceeds the maximum reasoning
+// tokens, as enabled by [filterapi.ReasoningBudget.HardStop].
+type streamReasoningBudget struct {
+	maxTokens uint32
+	// pending holds the incomplete event at the end of the previous chunk.
+	pending []byte
+	// reasoningChars is the number of characters generated in the reasoning deltas so far.
+	reasoningChars int
+	// id, model and created are taken from the chunks so far to build the final chunk.
+	id      string
+	model   string
+	created openai.JSONUNIXTime
+	// choices are the indexes of the choices seen so far.
+	choices []int64
+	// finished is true once the backend finished generating, after which the stream is no longer cut off.
+	finished bool
+	// cutOff is true once the stream has been terminated.
+	cutOff bool
+}
+
+// newStreamReasoningBudget returns a streamReasoningBudget if the budget enables the hard stop. Otherwise, this
+// returns nil.
+func newStreamReasoningBudget(budget *filterapi.ReasoningBudget) *streamReasoningBudget {
+	if budget == nil || !budget.HardStop || budget.MaxReasoningTokens <= 0 {
+		return nil
+	}
+	return &streamReasoningBudget{maxTokens: uint32(budget.MaxReasoningTokens)} // #nosec G115
+}
+
+// processChunk inspects the events in the chunk sent to the client and checks the reasoning tokens of the stream
+// so far against the budget. When the budget is exceeded, this returns the chunk truncated right after the exceeding
+// event followed by the final chunk, the reasoning budget exceeded event and the terminating [DONE] event.
+// Otherwise, this returns nil, meaning the chunk is sent as is.
+func (b *streamReasoningBudget) processChunk(chunk []byte, costs *metrics.TokenUsage) []byte {
+	buf := append(b.pending, chunk...)
+	start := 0
+	for {
+		i := bytes.Index(buf[start:], sseEventSeparator)
+		if i < 0 {
+			break
+		}
+		end := start + i + len(sseEventSeparator)
+		b.observe(buf[start : start+i])
+		start = end
+		if b.finished || b.reasoningTokens(costs) <= b.maxTokens {
+			continue
+		}
+		b.cutOff = true
+		// The events before the exceeding one in the pending bytes were already sent with the previous chunk.
+		out := slices.Clone(chunk[:end-len(b.pending)])
+		b.pending = nil
+		return append(out, streamTerminationEvents(b.id, b.model, b.created, b.choices, reasoningBudgetExceededEventType,
+			"the stream was terminated because the reasoning exceeded the budget of the route")...)
+	}
+	b.pending = slices.Clone(buf[start:])
+	return nil
+}
+
+// reasoningTokens returns the reasoning tokens of the stream so far, estimated from the observed reasoning content
+// unless the backend already reported more.
+func (b *streamReasoningBudget) reasoningTokens(costs *metrics.TokenUsage) uint32 {
+	estimated := uint32((b.reasoningChars + charsPerOutputToken - 1) / charsPerOutputToken) // #nosec G115
+	if reported, _ := costs.ReasoningTokens(); reported > estimated {
+		return reported
+	}
+	return estimated
+}
+
+// observe records the reasoning and the identity of the chat completion chunk in the event. The reasoning content
+// is either the text object of the translated responses or the string of the OpenAI compatible backends.
+// Events that are not chat completion chunks are ignored.
+func (b *streamReasoningBudget) observe(event []byte) {
+	for line := range bytes.SplitSeq(event, []byte("\n")) {
+		data, ok := bytes.CutPrefix(line, sseDataPrefix)
+		if !ok {
+			continue
+		}
+		data = bytes.TrimSpace(data)
+		if bytes.Equal(data, sseDone) || !gjson.ValidBytes(data) {
+			continue
+		}
+		chunk := gjson.ParseBytes(data)
+		if id := chunk.Get("id").String(); id != "" {
+			b.id = id
+		}
+		if model := chunk.Get("model").String(); model != "" {
+			b.model = model
+		}
+		if created := chunk.Get("created").Int(); created != 0 {
+			b.created = openai.JSONUNIXTime(time.Unix(created, 0))
+		}
+		for _, choice := range chunk.Get("choices").Array() {
+			if index := choice.Get("index").Int(); !slices.Contains(b.choices, index) {
+				b.choices = append(b.choices, index)
+			}
+			if choice.Get("finish_reason").String() != "" {
+				b.finished = true
+			}
+			reasoning := choice.Get("delta.reasoning_content")
+			if reasoning.IsObject() {
+				reasoning = reasoning.Get("text")
+			}
+			b.reasoningChars += len(reasoning.String())
+		}
+	}
+}
+
+// enforceReasoningBudget terminates the stream once its reasoning exceeds the budget of the route. The chunk is
+// either the one in the given bodyMutation or the decoded upstream body if the translator didn't mutate it. Once the
+// stream is terminated, the rest of the upstream response is dropped.
+func (u *upstreamProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) enforceReasoningBudget(bodyMutation *extprocv3.BodyMutation, decoded []byte) *extprocv3.BodyMutation {
+	if u.reasoningBudget.cutOff {
+		return &extprocv3.BodyMutation{Mutation: &extprocv3.BodyMutation_ClearBody{ClearBody: true}}
+	}
+	chunk := decoded
+	if b := bodyMutation.GetBody(); b != nil {
+		chunk = b
+	}
+	truncated := u.reasoningBudget.processChunk(chunk, &u.costs)
+	if truncated == nil {
+		return bodyMutation
+	}
+	u.logger.Info("terminated the stream as the reasoning exceeded the budget",
+		slog.String("backend", u.backendName), slog.Uint64("max_reasoning_tokens", uint64(u.reasoningBudget.maxTokens)))
+	return &extprocv3.BodyMutation{Mutation: &extprocv3.BodyMutation_Body{Body: truncated}}
+}
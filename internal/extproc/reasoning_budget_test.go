@@ -0,0 +1,156 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/anthropic"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/metrics"
+)
+
+func TestCapReasoning(t *testing.T) {
+	budget := &filterapi.ReasoningBudget{MaxReasoningTokens: 2048, MaxEffort: "medium"}
+	for _, tc := range []struct {
+		name string
+		req  any
+		body string
+		exp  string
+	}{
+		{
+			name: "chat effort above the cap",
+			req:  &openai.ChatCompletionRequest{},
+			body: `{"model":"o3","reasoning_effort":"high"}`,
+			exp:  `{"model":"o3","reasoning_effort":"medium"}`,
+		},
+		{name: "chat effort within the cap", req: &openai.ChatCompletionRequest{}, body: `{"model":"o3","reasoning_effort":"low"}`},
+		{name: "chat without effort", req: &openai.ChatCompletionRequest{}, body: `{"model":"o3"}`},
+		{name: "chat unknown effort", req: &openai.ChatCompletionRequest{}, body: `{"model":"o3","reasoning_effort":"extreme"}`},
+		{
+			name: "chat thinking budget above the cap",
+			req:  &openai.ChatCompletionRequest{},
+			body: `{"model":"gemini-2.5-pro","thinking":{"type":"enabled","budget_tokens":8192}}`,
+			exp:  `{"model":"gemini-2.5-pro","thinking":{"type":"enabled","budget_tokens":2048}}`,
+		},
+		{
+			name: "disabled thinking",
+			req:  &openai.ChatCompletionRequest{},
+			body: `{"model":"gemini-2.5-pro","thinking":{"type":"disabled","budget_tokens":8192}}`,
+		},
+		{
+			name: "anthropic thinking budget above the cap",
+			req:  &anthropic.MessagesRequest{},
+			body: `{"model":"claude","thinking":{"type":"enabled","budget_tokens":4096},"reasoning_effort":"high"}`,
+			exp:  `{"model":"claude","thinking":{"type":"enabled","budget_tokens":2048},"reasoning_effort":"high"}`,
+		},
+		{
+			name: "responses effort above the cap",
+			req:  &openai.ResponseRequest{},
+			body: `{"model":"o3","reasoning":{"effort":"xhigh"}}`,
+			exp:  `{"model":"o3","reasoning":{"effort":"medium"}}`,
+		},
+		{name: "embeddings", req: &openai.EmbeddingRequest{}, body: `{"model":"e","reasoning_effort":"high"}`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := capReasoning(tc.req, []byte(tc.body), budget)
+			require.NoError(t, err)
+			if tc.exp == "" {
+				require.Nil(t, out)
+				return
+			}
+			require.JSONEq(t, tc.exp, string(out))
+		})
+	}
+}
+
+func Test_chatCompletionProcessorUpstreamFilter_applyReasoningBudget(t *testing.T) {
+	const body = `{"model":"o3","reasoning_effort":"high","messages":[{"role":"user","content":"hi"}]}`
+	var req openai.ChatCompletionRequest
+	require.NoError(t, json.Unmarshal([]byte(body), &req))
+	r := &chatCompletionProcessorRouterFilter{
+		config: &filterapi.RuntimeConfig{ReasoningBudgets: map[string]*filterapi.ReasoningBudget{
+			"default/route": {RouteName: "default/route", MaxEffort: "low"},
+		}},
+		originalRequestBodyRaw: []byte(body),
+		originalRequestBody:    &req,
+	}
+	p := &chatCompletionProcessorUpstreamFilter{parent: r, routeName: "default/route", logger: slog.Default()}
+	require.NoError(t, p.applyReasoningBudget())
+	require.JSONEq(t, `{"model":"o3","reasoning_effort":"low","messages":[{"role":"user","content":"hi"}]}`, string(r.originalRequestBodyRaw))
+	require.Equal(t, openai.ReasoningEffortLow, r.originalRequestBody.ReasoningEffort)
+	require.True(t, r.forceBodyMutation)
+
+	// The routes without a budget keep the reasoning parameters.
+	r = &chatCompletionProcessorRouterFilter{config: r.config, originalRequestBodyRaw: []byte(body), originalRequestBody: &req}
+	p = &chatCompletionProcessorUpstreamFilter{parent: r, routeName: "default/other", logger: slog.Default()}
+	require.NoError(t, p.applyReasoningBudget())
+	require.Equal(t, body, string(r.originalRequestBodyRaw))
+	require.False(t, r.forceBodyMutation)
+}
+
+func TestNewStreamReasoningBudget(t *testing.T) {
+	require.Nil(t, newStreamReasoningBudget(nil))
+	require.Nil(t, newStreamReasoningBudget(&filterapi.ReasoningBudget{MaxReasoningTokens: 10}))
+	require.Nil(t, newStreamReasoningBudget(&filterapi.ReasoningBudget{MaxEffort: "low", HardStop: true}))
+	require.Equal(t, uint32(10), newStreamReasoningBudget(&filterapi.ReasoningBudget{MaxReasoningTokens: 10, HardStop: true}).maxTokens)
+}
+
+func TestStreamReasoningBudget_processChunk(t *testing.T) {
+	const (
+		role     = `data: {"id":"chatcmpl-1","object":"chat.completion.chunk","created":1731000000,"model":"o3","choices":[{"index":0,"delta":{"role":"assistant"}}]}` + "\n\n"
+		thinking = `data: {"id":"chatcmpl-1","object":"chat.completion.chunk","created":1731000000,"model":"o3","choices":[{"index":0,"delta":{"reasoning_content":{"text":"Let me think hard"}}}]}` + "\n\n"
+		content  = `data: {"id":"chatcmpl-1","object":"chat.completion.chunk","created":1731000000,"model":"o3","choices":[{"index":0,"delta":{"content":"The answer is a long sentence."}}]}` + "\n\n"
+		stop     = `data: {"id":"chatcmpl-1","object":"chat.completion.chunk","created":1731000000,"model":"o3","choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}` + "\n\n"
+		final    = `data: {"id":"chatcmpl-1","choices":[{"index":0,"delta":{},"finish_reason":"length"}],"created":1731000000,"model":"o3","object":"chat.completion.chunk"}` + "\n\n" +
+			"event: reasoning_budget_exceeded\n" +
+			`data: {"type":"error","error":{"type":"reasoning_budget_exceeded","message":"the stream was terminated because the reasoning exceeded the budget of the route"}}` + "\n\n" +
+			"data: [DONE]\n\n"
+	)
+
+	t.Run("within budget", func(t *testing.T) {
+		b := &streamReasoningBudget{maxTokens: 5}
+		// The content is not counted as reasoning.
+		for _, chunk := range []string{role, thinking, content, stop, "data: [DONE]\n\n"} {
+			require.Nil(t, b.processChunk([]byte(chunk), &metrics.TokenUsage{}))
+		}
+		require.False(t, b.cutOff)
+	})
+
+	t.Run("cut off", func(t *testing.T) {
+		b := &streamReasoningBudget{maxTokens: 5}
+		require.Nil(t, b.processChunk([]byte(role+thinking), &metrics.TokenUsage{}))
+		// "Let me think hard" twice is estimated as 9 tokens, which exceeds the budget.
+		require.Equal(t, thinking+final, string(b.processChunk([]byte(thinking+content), &metrics.TokenUsage{})))
+		require.True(t, b.cutOff)
+	})
+
+	t.Run("string reasoning content", func(t *testing.T) {
+		b := &streamReasoningBudget{maxTokens: 5}
+		chunk := `data: {"id":"chatcmpl-1","choices":[{"index":0,"delta":{"reasoning_content":"Let me think harder and longer"}}]}` + "\n\n"
+		require.Contains(t, string(b.processChunk([]byte(chunk), &metrics.TokenUsage{})), "event: reasoning_budget_exceeded")
+	})
+
+	t.Run("reported usage exceeds estimate", func(t *testing.T) {
+		b := &streamReasoningBudget{maxTokens: 5}
+		var usage metrics.TokenUsage
+		usage.SetReasoningTokens(6)
+		require.Contains(t, string(b.processChunk([]byte(role), &usage)), `"finish_reason":"length"`)
+	})
+
+	t.Run("finished stream is not cut off", func(t *testing.T) {
+		b := &streamReasoningBudget{maxTokens: 5}
+		var usage metrics.TokenUsage
+		require.Nil(t, b.processChunk([]byte(stop), &usage))
+		usage.SetReasoningTokens(100)
+		require.Nil(t, b.processChunk([]byte(`data: {"id":"chatcmpl-1","choices":[],"usage":{"completion_tokens":100}}`+"\n\ndata: [DONE]\n\n"), &usage))
+	})
+}
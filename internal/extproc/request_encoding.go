@@ -0,0 +1,110 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+)
+
+// MaxDecompressedRequestBodySize is the maximum size in bytes of a compressed request body after decompression.
+// This protects the processor from the decompression bombs, since the buffer limit of Envoy only applies to the
+// compressed body.
+var MaxDecompressedRequestBodySize int64 = 64 << 20
+
+var (
+	// errRequestBodyTooLarge is returned when the decompressed request body exceeds MaxDecompressedRequestBodySize.
+	errRequestBodyTooLarge = errors.New("decompressed request body is too large")
+	// errUnsupportedRequestEncoding is returned for the content encodings of the request body that are not supported.
+	errUnsupportedRequestEncoding = errors.New("unsupported content encoding")
+)
+
+// requestContentEncoding returns the normalized content encoding of the request from the content-encoding header.
+// The identity encoding is returned as the empty string.
+func requestContentEncoding(header string) string {
+	enc := strings.ToLower(strings.TrimSpace(header))
+	if enc == "identity" {
+		return ""
+	}
+	return enc
+}
+
+// decodeRequestBody decompresses the request body compressed with the given content encoding, which is either
+// "gzip" or "deflate". The decompressed body is limited to limit bytes.
+func decodeRequestBody(body []byte, contentEncoding string, limit int64) ([]byte, error) {
+	if len(body) == 0 {
+		return body, nil
+	}
+	var reader io.Reader
+	switch contentEncoding {
+	case "gzip":
+		gr, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode gzip: %w", err)
+		}
+		reader = gr
+	case "deflate":
+		// The "deflate" content encoding is the zlib format, but some clients send the raw deflate stream.
+		zr, err := zlib.NewReader(bytes.NewReader(body))
+		if err != nil {
+			reader = flate.NewReader(bytes.NewReader(body))
+		} else {
+			reader = zr
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s", errUnsupportedRequestEncoding, contentEncoding)
+	}
+	decoded, err := io.ReadAll(io.LimitReader(reader, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", contentEncoding, err)
+	}
+	if int64(len(decoded)) > limit {
+		return nil, fmt.Errorf("%w: exceeds %d bytes", errRequestBodyTooLarge, limit)
+	}
+	return decoded, nil
+}
+
+// encodeRequestBody compresses the request body with the given content encoding, which is either "gzip" or
+// "deflate".
+func encodeRequestBody(body []byte, contentEncoding string) ([]byte, error) {
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch contentEncoding {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "deflate":
+		w = zlib.NewWriter(&buf)
+	default:
+		return nil, fmt.Errorf("%w: %s", errUnsupportedRequestEncoding, contentEncoding)
+	}
+	if _, err := w.Write(body); err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %w", contentEncoding, err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %w", contentEncoding, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeRequestBodyErrorResponse returns the response rejecting the request whose body cannot be decompressed.
+func decodeRequestBodyErrorResponse(err error) *extprocv3.ProcessingResponse {
+	switch {
+	case errors.Is(err, errRequestBodyTooLarge):
+		return createUserFacingErrorResponse(413, "PayloadTooLarge", err.Error())
+	case errors.Is(err, errUnsupportedRequestEncoding):
+		return createUserFacingErrorResponse(415, "UnsupportedMediaType", err.Error())
+	default:
+		return createUserFacingErrorResponse(400, "BadRequest", "malformed request body: "+err.Error())
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"bytes"
+	"compress/flate"
+	"log/slog"
+	"testing"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/json"
+	"github.com/envoyproxy/ai-gateway/internal/tracing/tracingapi"
+)
+
+func TestRequestContentEncoding(t *testing.T) {
+	require.Empty(t, requestContentEncoding(""))
+	require.Empty(t, requestContentEncoding("identity"))
+	require.Equal(t, "gzip", requestContentEncoding(" GZIP "))
+	require.Equal(t, "deflate", requestContentEncoding("deflate"))
+}
+
+func TestDecodeRequestBody(t *testing.T) {
+	body := []byte(`{"model":"text-embedding-3-small","input":["a","b","c"]}`)
+
+	for _, enc := range []string{"gzip", "deflate"} {
+		t.Run(enc, func(t *testing.T) {
+			encoded, err := encodeRequestBody(body, enc)
+			require.NoError(t, err)
+			require.NotEqual(t, body, encoded)
+
+			decoded, err := decodeRequestBody(encoded, enc, int64(len(body)))
+			require.NoError(t, err)
+			require.Equal(t, body, decoded)
+
+			// The limit applies to the decompressed body.
+			_, err = decodeRequestBody(encoded, enc, int64(len(body)-1))
+			require.ErrorIs(t, err, errRequestBodyTooLarge)
+
+			_, err = decodeRequestBody([]byte("not compressed"), enc, 1024)
+			require.Error(t, err)
+		})
+	}
+
+	t.Run("raw deflate", func(t *testing.T) {
+		var buf bytes.Buffer
+		w, err := flate.NewWriter(&buf, flate.DefaultCompression)
+		require.NoError(t, err)
+		_, err = w.Write(body)
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+		decoded, err := decodeRequestBody(buf.Bytes(), "deflate", 1024)
+		require.NoError(t, err)
+		require.Equal(t, body, decoded)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		decoded, err := decodeRequestBody(nil, "gzip", 1024)
+		require.NoError(t, err)
+		require.Empty(t, decoded)
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		_, err := decodeRequestBody(body, "br", 1024)
+		require.ErrorIs(t, err, errUnsupportedRequestEncoding)
+		_, err = encodeRequestBody(body, "br")
+		require.ErrorIs(t, err, errUnsupportedRequestEncoding)
+	})
+}
+
+func TestDecodeRequestBodyErrorResponse(t *testing.T) {
+	for _, tc := range []struct {
+		err     error
+		expCode typev3.StatusCode
+	}{
+		{err: errRequestBodyTooLarge, expCode: typev3.StatusCode_PayloadTooLarge},
+		{err: errUnsupportedRequestEncoding, expCode: typev3.StatusCode_UnsupportedMediaType},
+		{err: flate.CorruptInputError(0), expCode: typev3.StatusCode_BadRequest},
+	} {
+		resp := decodeRequestBodyErrorResponse(tc.err)
+		require.Equal(t, tc.expCode, resp.GetImmediateResponse().GetStatus().GetCode())
+	}
+}
+
+func Test_chatCompletionProcessorRouterFilter_ProcessRequestBody_Compressed(t *testing.T) {
+	raw := bodyFromModel(t, "some-model", false, nil)
+	encoded, err := encodeRequestBody(raw, "gzip")
+	require.NoError(t, err)
+
+	p := &chatCompletionProcessorRouterFilter{
+		config:         &filterapi.RuntimeConfig{},
+		requestHeaders: map[string]string{":path": "/v1/chat/completions", "content-encoding": "gzip"},
+		logger:         slog.Default(),
+		tracer:         tracingapi.NoopTracer[openai.ChatCompletionRequest, openai.ChatCompletionResponse, openai.ChatCompletionResponseChunk]{},
+	}
+	resp, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: encoded})
+	require.NoError(t, err)
+	require.NotNil(t, resp.GetRequestBody())
+	require.Equal(t, "some-model", p.originalModel)
+	require.Equal(t, raw, p.originalRequestBodyRaw)
+	require.Equal(t, "gzip", p.requestContentEncoding)
+
+	t.Run("too large", func(t *testing.T) {
+		orig := MaxDecompressedRequestBodySize
+		t.Cleanup(func() { MaxDecompressedRequestBodySize = orig })
+		MaxDecompressedRequestBodySize = 8
+		resp, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: encoded})
+		require.NoError(t, err)
+		require.Equal(t, typev3.StatusCode_PayloadTooLarge, resp.GetImmediateResponse().GetStatus().GetCode())
+	})
+}
+
+func Test_chatCompletionProcessorUpstreamFilter_ProcessRequestHeaders_Compressed(t *testing.T) {
+	raw := []byte(`{"model":"some-model","messages":[{"role":"user","content":"hello"}]}`)
+	var req openai.ChatCompletionRequest
+	require.NoError(t, json.Unmarshal(raw, &req))
+	translated := []byte(`{"model":"other-model","messages":[{"role":"user","content":"hello"}]}`)
+
+	newUpstream := func(recompress bool, retBody []byte) *chatCompletionProcessorUpstreamFilter {
+		p := newTestUpstreamFilter(t, nil, "default/route", string(raw))
+		p.parent.requestContentEncoding = "gzip"
+		p.requestHeaders = map[string]string{":path": "/v1/chat/completions", "content-encoding": "gzip"}
+		p.translator = &mockTranslator{t: t, expRequestBody: &req, retBodyMutation: retBody}
+		p.recompressRequest = recompress
+		return p
+	}
+
+	t.Run("decompress", func(t *testing.T) {
+		p := newUpstream(false, translated)
+		resp, err := p.ProcessRequestHeaders(t.Context(), nil)
+		require.NoError(t, err)
+		commonRes := resp.GetRequestHeaders().GetResponse()
+		require.Equal(t, translated, commonRes.GetBodyMutation().GetBody())
+		require.Contains(t, commonRes.GetHeaderMutation().GetRemoveHeaders(), "content-encoding")
+		require.NotContains(t, p.requestHeaders, "content-encoding")
+	})
+
+	t.Run("recompress", func(t *testing.T) {
+		p := newUpstream(true, translated)
+		resp, err := p.ProcessRequestHeaders(t.Context(), nil)
+		require.NoError(t, err)
+		commonRes := resp.GetRequestHeaders().GetResponse()
+		require.NotContains(t, commonRes.GetHeaderMutation().GetRemoveHeaders(), "content-encoding")
+		decoded, err := decodeRequestBody(commonRes.GetBodyMutation().GetBody(), "gzip", 1024)
+		require.NoError(t, err)
+		require.Equal(t, translated, decoded)
+	})
+
+	t.Run("not modified", func(t *testing.T) {
+		// The body compressed by the client is sent as is.
+		p := newUpstream(false, nil)
+		resp, err := p.ProcessRequestHeaders(t.Context(), nil)
+		require.NoError(t, err)
+		commonRes := resp.GetRequestHeaders().GetResponse()
+		require.Equal(t, extprocv3.CommonResponse_CONTINUE, commonRes.GetStatus())
+		require.NotContains(t, commonRes.GetHeaderMutation().GetRemoveHeaders(), "content-encoding")
+	})
+}
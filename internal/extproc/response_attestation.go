@@ -0,0 +1,78 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+
+	"github.com/envoyproxy/ai-gateway/internal/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+	"github.com/envoyproxy/ai-gateway/internal/json"
+)
+
+// responseAttestationJWSHeader is the base64url-encoded protected header of the response attestations.
+var responseAttestationJWSHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"EdDSA","typ":"JWT"}`))
+
+// responseAttestationClaims are the claims of a response attestation.
+type responseAttestationClaims struct {
+	// Issuer is the identity of the gateway.
+	Issuer string `json:"iss"`
+	// IssuedAt is the Unix time in seconds at which the response was attested.
+	IssuedAt int64 `json:"iat"`
+	// Model is the model of the request.
+	Model string `json:"model"`
+	// RequestSHA256 is the hex-encoded SHA-256 of the request body as sent by the client.
+	RequestSHA256 string `json:"request_sha256"`
+}
+
+// requestBodySHA256 returns the hex-encoded SHA-256 of the request body.
+func requestBodySHA256(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// signResponseAttestation returns the response attestation as a JWS in the compact serialization, signed with the
+// Ed25519 key of the attestation.
+func signResponseAttestation(a *filterapi.RuntimeResponseAttestation, model, requestSHA256 string, now time.Time) (string, error) {
+	claims, err := json.Marshal(responseAttestationClaims{
+		Issuer:        a.Identity,
+		IssuedAt:      now.Unix(),
+		Model:         model,
+		RequestSHA256: requestSHA256,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal the response attestation claims: %w", err)
+	}
+	signingInput := responseAttestationJWSHeader + "." + base64.RawURLEncoding.EncodeToString(claims)
+	signature := ed25519.Sign(a.Key, []byte(signingInput))
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// setResponseAttestationHeader sets the ResponseAttestationHeader on the response if the response attestation is
+// configured.
+func (u *upstreamProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) setResponseAttestationHeader(headerMutation *extprocv3.HeaderMutation) error {
+	rp := u.parent
+	if rp.config == nil || rp.config.ResponseAttestation == nil {
+		return nil
+	}
+	attestation, err := signResponseAttestation(rp.config.ResponseAttestation, rp.originalModel, rp.requestSHA256, time.Now())
+	if err != nil {
+		return err
+	}
+	headerMutation.SetHeaders = append(headerMutation.SetHeaders, &corev3.HeaderValueOption{
+		AppendAction: corev3.HeaderValueOption_OVERWRITE_IF_EXISTS_OR_ADD,
+		Header:       &corev3.HeaderValue{Key: internalapi.ResponseAttestationHeader, RawValue: []byte(attestation)},
+	})
+	return nil
+}
@@ -0,0 +1,87 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/internal/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+)
+
+func newTestResponseAttestation(t *testing.T) (*filterapi.RuntimeResponseAttestation, ed25519.PublicKey) {
+	pub, key, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	return &filterapi.RuntimeResponseAttestation{
+		ResponseAttestation: &filterapi.ResponseAttestation{Identity: "gw.example.com"},
+		Key:                 key,
+	}, pub
+}
+
+// verifyResponseAttestation verifies the signature of the attestation and returns its decoded header and claims.
+func verifyResponseAttestation(t *testing.T, pub ed25519.PublicKey, attestation string) (header, claims string) {
+	parts := strings.Split(attestation, ".")
+	require.Len(t, parts, 3)
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	require.NoError(t, err)
+	require.True(t, ed25519.Verify(pub, []byte(parts[0]+"."+parts[1]), signature))
+	h, err := base64.RawURLEncoding.DecodeString(parts[0])
+	require.NoError(t, err)
+	c, err := base64.RawURLEncoding.DecodeString(parts[1])
+	require.NoError(t, err)
+	return string(h), string(c)
+}
+
+func TestSignResponseAttestation(t *testing.T) {
+	a, pub := newTestResponseAttestation(t)
+	requestSHA256 := requestBodySHA256([]byte(`{"model":"gpt-4o"}`))
+	require.Len(t, requestSHA256, 64)
+
+	attestation, err := signResponseAttestation(a, "gpt-4o", requestSHA256, time.Unix(1700000000, 0))
+	require.NoError(t, err)
+	header, claims := verifyResponseAttestation(t, pub, attestation)
+	require.JSONEq(t, `{"alg":"EdDSA","typ":"JWT"}`, header)
+	require.JSONEq(t, `{"iss":"gw.example.com","iat":1700000000,"model":"gpt-4o","request_sha256":"`+requestSHA256+`"}`, claims)
+
+	// Another key doesn't verify the attestation.
+	otherPub, _, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	parts := strings.Split(attestation, ".")
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	require.NoError(t, err)
+	require.False(t, ed25519.Verify(otherPub, []byte(parts[0]+"."+parts[1]), signature))
+}
+
+func Test_chatCompletionProcessorUpstreamFilter_setResponseAttestationHeader(t *testing.T) {
+	a, pub := newTestResponseAttestation(t)
+	r := &chatCompletionProcessorRouterFilter{
+		config:        &filterapi.RuntimeConfig{ResponseAttestation: a},
+		originalModel: "gpt-4o",
+		requestSHA256: requestBodySHA256([]byte("body")),
+	}
+	p := &chatCompletionProcessorUpstreamFilter{parent: r}
+	headerMutation := &extprocv3.HeaderMutation{}
+	require.NoError(t, p.setResponseAttestationHeader(headerMutation))
+	require.Len(t, headerMutation.SetHeaders, 1)
+	require.Equal(t, corev3.HeaderValueOption_OVERWRITE_IF_EXISTS_OR_ADD, headerMutation.SetHeaders[0].AppendAction)
+	require.Equal(t, internalapi.ResponseAttestationHeader, headerMutation.SetHeaders[0].Header.Key)
+	_, claims := verifyResponseAttestation(t, pub, string(headerMutation.SetHeaders[0].Header.RawValue))
+	require.Contains(t, claims, `"request_sha256":"`+r.requestSHA256+`"`)
+
+	// The responses aren't attested without the response attestation.
+	r.config = &filterapi.RuntimeConfig{}
+	headerMutation = &extprocv3.HeaderMutation{}
+	require.NoError(t, p.setResponseAttestationHeader(headerMutation))
+	require.Empty(t, headerMutation.SetHeaders)
+}
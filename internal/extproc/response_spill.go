@@ -0,0 +1,151 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync/atomic"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+)
+
+// ResponseSpillConfig configures the buffering of the large non-streaming responses in temporary files rather than
+// in memory, so that the memory of the processor is bounded while many large responses, e.g. base64 images or long
+// documents, are received concurrently.
+//
+// The spilled responses are received in chunks rather than buffered by Envoy, and the processed response is sent to
+// the client at the end, so only a response being processed is held in memory.
+type ResponseSpillConfig struct {
+	// Threshold is the size in bytes above which a response is written to a temporary file. Zero disables the spill.
+	Threshold int64
+	// Dir is the directory of the temporary files. Empty uses the default directory for temporary files.
+	Dir string
+	// MaxResponseSize is the maximum size in bytes of a spilled response. Larger responses fail.
+	MaxResponseSize int64
+	// MaxTotalSize is the maximum total size in bytes of the temporary files of the responses received
+	// concurrently. The responses exceeding it fail.
+	MaxTotalSize int64
+}
+
+// ResponseSpill is the configuration of the spill of the large non-streaming responses. It is disabled by default.
+var ResponseSpill ResponseSpillConfig
+
+// responseSpillUsage is the total size in bytes of the temporary files of the spilled responses.
+var responseSpillUsage atomic.Int64
+
+var (
+	// errResponseTooLarge is returned when a spilled response exceeds ResponseSpillConfig.MaxResponseSize.
+	errResponseTooLarge = errors.New("response is too large")
+	// errResponseSpillQuotaExceeded is returned when a spilled response exceeds ResponseSpillConfig.MaxTotalSize.
+	errResponseSpillQuotaExceeded = errors.New("response spill quota is exceeded")
+)
+
+// shouldSpillResponse returns true if the non-streaming response with the headers is spilled. The compressed
+// responses are not, since their headers can't be changed once the body is processed in chunks, and neither are
+// the responses known to be small.
+func shouldSpillResponse(responseHeaders map[string]string) bool {
+	if ResponseSpill.Threshold <= 0 || responseHeaders[":status"] != "200" || responseHeaders["content-encoding"] != "" {
+		return false
+	}
+	if cl, err := strconv.ParseInt(responseHeaders["content-length"], 10, 64); err == nil && cl <= ResponseSpill.Threshold {
+		return false
+	}
+	return true
+}
+
+// responseSpill buffers a response body in memory up to the threshold, and in a temporary file beyond.
+type responseSpill struct {
+	// mem holds the body until it exceeds the threshold, and file holds it beyond.
+	mem  []byte
+	file *os.File
+	// size is the size of the body, and reserved is the size of the file counted in responseSpillUsage.
+	size, reserved int64
+}
+
+// write appends the chunk to the body.
+func (s *responseSpill) write(chunk []byte) error {
+	n := int64(len(chunk))
+	if limit := ResponseSpill.MaxResponseSize; limit > 0 && s.size+n > limit {
+		return errResponseTooLarge
+	}
+	if s.file == nil && s.size+n <= ResponseSpill.Threshold {
+		s.mem = append(s.mem, chunk...)
+		s.size += n
+		return nil
+	}
+	if s.file == nil {
+		if err := s.reserve(s.size); err != nil {
+			return err
+		}
+		f, err := os.CreateTemp(ResponseSpill.Dir, "aigw-response-*")
+		if err != nil {
+			return fmt.Errorf("failed to create the response spill file: %w", err)
+		}
+		s.file = f
+		if _, err = f.Write(s.mem); err != nil {
+			return fmt.Errorf("failed to write the response spill file: %w", err)
+		}
+		s.mem = nil
+	}
+	if err := s.reserve(n); err != nil {
+		return err
+	}
+	if _, err := s.file.Write(chunk); err != nil {
+		return fmt.Errorf("failed to write the response spill file: %w", err)
+	}
+	s.size += n
+	return nil
+}
+
+// reserve counts n more bytes of the file in responseSpillUsage, failing if it exceeds the total size.
+func (s *responseSpill) reserve(n int64) error {
+	if total := responseSpillUsage.Add(n); ResponseSpill.MaxTotalSize > 0 && total > ResponseSpill.MaxTotalSize {
+		responseSpillUsage.Add(-n)
+		return errResponseSpillQuotaExceeded
+	}
+	s.reserved += n
+	return nil
+}
+
+// bytes returns the whole body.
+func (s *responseSpill) bytes() ([]byte, error) {
+	if s.file == nil {
+		return s.mem, nil
+	}
+	body := make([]byte, s.size)
+	if _, err := s.file.ReadAt(body, 0); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to read the response spill file: %w", err)
+	}
+	return body, nil
+}
+
+// release removes the temporary file, if any. The spill must not be used afterward.
+func (s *responseSpill) release() {
+	if s.file != nil {
+		_ = s.file.Close()
+		_ = os.Remove(s.file.Name())
+		s.file = nil
+	}
+	responseSpillUsage.Add(-s.reserved)
+	s.reserved = 0
+	s.mem = nil
+}
+
+// clearedChunkResponse is the response to a chunk of a spilled response body, which is sent to the client at the
+// end of the response instead.
+var clearedChunkResponse = &extprocv3.ProcessingResponse{
+	Response: &extprocv3.ProcessingResponse_ResponseBody{
+		ResponseBody: &extprocv3.BodyResponse{
+			Response: &extprocv3.CommonResponse{
+				BodyMutation: &extprocv3.BodyMutation{Mutation: &extprocv3.BodyMutation_ClearBody{ClearBody: true}},
+			},
+		},
+	},
+}
@@ -0,0 +1,148 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"os"
+	"testing"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3http "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ext_proc/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/stretchr/testify/require"
+)
+
+// setResponseSpill sets the response spill config for the duration of the test.
+func setResponseSpill(t *testing.T, cfg ResponseSpillConfig) {
+	prev := ResponseSpill
+	ResponseSpill = cfg
+	t.Cleanup(func() { ResponseSpill = prev })
+}
+
+func TestShouldSpillResponse(t *testing.T) {
+	setResponseSpill(t, ResponseSpillConfig{Threshold: 10})
+	require.True(t, shouldSpillResponse(map[string]string{":status": "200"}))
+	require.True(t, shouldSpillResponse(map[string]string{":status": "200", "content-length": "11"}))
+	require.False(t, shouldSpillResponse(map[string]string{":status": "200", "content-length": "10"}))
+	require.False(t, shouldSpillResponse(map[string]string{":status": "200", "content-encoding": "gzip"}))
+	require.False(t, shouldSpillResponse(map[string]string{":status": "500"}))
+
+	setResponseSpill(t, ResponseSpillConfig{})
+	require.False(t, shouldSpillResponse(map[string]string{":status": "200"}))
+}
+
+func TestResponseSpill(t *testing.T) {
+	dir := t.TempDir()
+	setResponseSpill(t, ResponseSpillConfig{Threshold: 4, Dir: dir, MaxResponseSize: 16, MaxTotalSize: 20})
+
+	t.Run("in memory", func(t *testing.T) {
+		s := &responseSpill{}
+		require.NoError(t, s.write([]byte("ab")))
+		require.NoError(t, s.write([]byte("cd")))
+		require.Nil(t, s.file)
+		b, err := s.bytes()
+		require.NoError(t, err)
+		require.Equal(t, "abcd", string(b))
+		s.release()
+		require.Zero(t, responseSpillUsage.Load())
+	})
+	t.Run("on disk", func(t *testing.T) {
+		s := &responseSpill{}
+		require.NoError(t, s.write([]byte("abc")))
+		require.NoError(t, s.write([]byte("defgh")))
+		require.NotNil(t, s.file)
+		require.Equal(t, int64(8), responseSpillUsage.Load())
+		b, err := s.bytes()
+		require.NoError(t, err)
+		require.Equal(t, "abcdefgh", string(b))
+
+		s.release()
+		require.Zero(t, responseSpillUsage.Load())
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		require.Empty(t, entries)
+	})
+	t.Run("too large", func(t *testing.T) {
+		s := &responseSpill{}
+		defer s.release()
+		require.NoError(t, s.write([]byte("0123456789")))
+		require.ErrorIs(t, s.write([]byte("0123456789")), errResponseTooLarge)
+	})
+	t.Run("quota exceeded", func(t *testing.T) {
+		s1, s2 := &responseSpill{}, &responseSpill{}
+		defer s1.release()
+		defer s2.release()
+		require.NoError(t, s1.write([]byte("0123456789abcdef")))
+		require.ErrorIs(t, s2.write([]byte("0123456789")), errResponseSpillQuotaExceeded)
+		require.Equal(t, int64(16), responseSpillUsage.Load())
+
+		s1.release()
+		require.NoError(t, s2.write([]byte("0123456789")))
+	})
+}
+
+func Test_upstreamProcessor_responseSpill(t *testing.T) {
+	dir := t.TempDir()
+	setResponseSpill(t, ResponseSpillConfig{Threshold: 4, Dir: dir, MaxResponseSize: 1024, MaxTotalSize: 1024})
+
+	headers := &corev3.HeaderMap{Headers: []*corev3.HeaderValue{{Key: ":status", Value: "200"}, {Key: "content-length", Value: "12"}}}
+
+	t.Run("ok", func(t *testing.T) {
+		mm := &mockMetrics{}
+		mt := &mockTranslator{
+			t: t, expHeaders: map[string]string{":status": "200", "content-length": "12"},
+			expResponseBody: &extprocv3.HttpBody{Body: []byte("abcdefghijkl")},
+		}
+		p := newTestUpstreamFilter(t, nil, "default/route", "")
+		p.translator, p.metrics = mt, mm
+		res, err := p.ProcessResponseHeaders(t.Context(), headers)
+		require.NoError(t, err)
+		require.Equal(t, extprocv3http.ProcessingMode_STREAMED, res.ModeOverride.ResponseBodyMode)
+		require.Contains(t, res.GetResponseHeaders().Response.HeaderMutation.RemoveHeaders, "content-length")
+		require.False(t, p.streamingResponse)
+
+		for _, chunk := range []string{"abcd", "efgh"} {
+			res, err = p.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{Body: []byte(chunk)})
+			require.NoError(t, err)
+			require.True(t, res.GetResponseBody().Response.BodyMutation.GetClearBody())
+		}
+		res, err = p.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{Body: []byte("ijkl"), EndOfStream: true})
+		require.NoError(t, err)
+		require.Equal(t, "abcdefghijkl", string(res.GetResponseBody().Response.BodyMutation.GetBody()))
+		require.Nil(t, p.spill)
+		mm.RequireRequestSuccess(t)
+
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		require.Empty(t, entries)
+	})
+	t.Run("small response", func(t *testing.T) {
+		p := newTestUpstreamFilter(t, nil, "default/route", "")
+		p.translator = &mockTranslator{t: t, expHeaders: map[string]string{":status": "200", "content-length": "2"}}
+		res, err := p.ProcessResponseHeaders(t.Context(), &corev3.HeaderMap{Headers: []*corev3.HeaderValue{
+			{Key: ":status", Value: "200"}, {Key: "content-length", Value: "2"},
+		}})
+		require.NoError(t, err)
+		require.Nil(t, res.ModeOverride)
+		require.Nil(t, p.spill)
+	})
+	t.Run("stream closed", func(t *testing.T) {
+		p := newTestUpstreamFilter(t, nil, "default/route", "")
+		p.translator = &mockTranslator{t: t, expHeaders: map[string]string{":status": "200", "content-length": "12"}}
+		_, err := p.ProcessResponseHeaders(t.Context(), headers)
+		require.NoError(t, err)
+		_, err = p.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{Body: []byte("abcdefgh")})
+		require.NoError(t, err)
+		require.NotZero(t, responseSpillUsage.Load())
+
+		p.onStreamClosed(t.Context())
+		require.Nil(t, p.spill)
+		require.Zero(t, responseSpillUsage.Load())
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		require.Empty(t, entries)
+	})
+}
@@ -0,0 +1,172 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+
+	"github.com/envoyproxy/ai-gateway/internal/conversationstore"
+)
+
+// previousResponseNotFoundErrorCode is the code of the OpenAI error rejecting the requests continuing an unknown
+// response.
+const previousResponseNotFoundErrorCode = "previous_response_not_found"
+
+// ResponsesConversationsConfig configures the store of the conversations of the Responses API requests.
+//
+// When enabled, the gateway rather than the backend keeps the state of the conversations: the input of a request
+// with "previous_response_id" is prefixed with the input and output items of the previous responses before the
+// request is sent to the backend, and the items of each successful response are stored under its ID.
+type ResponsesConversationsConfig struct {
+	// Store stores the conversations. Nil disables the conversation store, and the requests are sent as is.
+	Store conversationstore.Store
+	// TenantHeader is the request header holding the tenant the conversations are isolated per. The requests
+	// without it share the conversations of the empty tenant.
+	TenantHeader string
+	// Timeout bounds each operation of the store.
+	Timeout time.Duration
+}
+
+// ResponsesConversations is the configuration of the store of the Responses API conversations. It is disabled by
+// default.
+var ResponsesConversations ResponsesConversationsConfig
+
+// responsesConversation is the conversation of a Responses API request, stored once its response is received.
+type responsesConversation struct {
+	store   conversationstore.Store
+	timeout time.Duration
+	tenant  string
+	// items is the JSON array of the input items of the request, prefixed with those of the previous responses.
+	items []byte
+	// pending holds the incomplete event at the end of the previous chunk of a streaming response.
+	pending []byte
+	// response is the response object received so far.
+	response []byte
+}
+
+// restoreResponsesConversation prefixes the input of the request body with the items of the conversation of the
+// previous response, if any. It returns the body sent to the backend, true if it was changed, and the conversation
+// of the request unless it isn't stored. An immediate response is returned instead if the previous response is
+// unknown to the tenant.
+func restoreResponsesConversation(ctx context.Context, requestHeaders map[string]string, body []byte) (
+	newBody []byte, restored bool, conversation *responsesConversation, res *extprocv3.ProcessingResponse, err error,
+) {
+	config := ResponsesConversations
+	if config.Store == nil {
+		return body, false, nil, nil, nil
+	}
+	var tenant string
+	if config.TenantHeader != "" {
+		tenant = requestHeaders[config.TenantHeader]
+	}
+	items := responsesInputItems(gjson.GetBytes(body, "input"))
+	if previousID := gjson.GetBytes(body, "previous_response_id").String(); previousID != "" {
+		storeCtx, cancel := context.WithTimeout(ctx, config.Timeout)
+		previous, err := config.Store.Get(storeCtx, tenant, previousID)
+		cancel()
+		if errors.Is(err, conversationstore.ErrNotFound) {
+			res, err = invalidRequestErrorResponse(previousResponseNotFoundErrorCode,
+				fmt.Sprintf("Previous response with id '%s' not found.", previousID), "previous_response_id")
+			return nil, false, nil, res, err
+		} else if err != nil {
+			return nil, false, nil, nil, fmt.Errorf("failed to get the previous response %s: %w", previousID, err)
+		}
+		items = concatJSONArrays(previous, items)
+		if body, err = sjson.SetRawBytes(body, "input", items); err != nil {
+			return nil, false, nil, nil, fmt.Errorf("failed to set the input: %w", err)
+		}
+		if body, err = sjson.DeleteBytes(body, "previous_response_id"); err != nil {
+			return nil, false, nil, nil, fmt.Errorf("failed to delete the previous response ID: %w", err)
+		}
+		restored = true
+	}
+	// Like OpenAI, the responses are stored unless the request opts out.
+	if store := gjson.GetBytes(body, "store"); !store.Exists() || store.Bool() {
+		conversation = &responsesConversation{store: config.Store, timeout: config.Timeout, tenant: tenant, items: items}
+	}
+	return body, restored, conversation, nil, nil
+}
+
+// responsesInputItems returns the JSON array of the input items of the request. A text input is a user message.
+func responsesInputItems(input gjson.Result) []byte {
+	switch {
+	case input.Type == gjson.String:
+		items, _ := sjson.SetBytes([]byte(`[{"role":"user"}]`), "0.content", input.String())
+		return items
+	case input.IsArray():
+		return []byte(input.Raw)
+	default:
+		return []byte("[]")
+	}
+}
+
+// concatJSONArrays returns the JSON array of the elements of the JSON arrays a and b.
+func concatJSONArrays(a, b []byte) []byte {
+	out := []byte{'['}
+	for _, array := range [][]byte{a, b} {
+		gjson.ParseBytes(array).ForEach(func(_, item gjson.Result) bool {
+			if len(out) > 1 {
+				out = append(out, ',')
+			}
+			out = append(out, item.Raw...)
+			return true
+		})
+	}
+	return append(out, ']')
+}
+
+// observe records the chunk of the successful response. The response object is the body of a non-streaming
+// response, and the one of the response.completed event of a streaming response.
+func (c *responsesConversation) observe(chunk []byte, stream bool) {
+	if !stream {
+		c.response = append(c.response, chunk...)
+		return
+	}
+	buf := append(c.pending, chunk...)
+	for {
+		i := bytes.Index(buf, sseEventSeparator)
+		if i < 0 {
+			break
+		}
+		for line := range bytes.SplitSeq(buf[:i], []byte("\n")) {
+			data, ok := bytes.CutPrefix(line, sseDataPrefix)
+			if !ok {
+				continue
+			}
+			if event := gjson.ParseBytes(bytes.TrimSpace(data)); event.Get("type").String() == "response.completed" {
+				c.response = []byte(event.Get("response").Raw)
+			}
+		}
+		buf = buf[i+len(sseEventSeparator):]
+	}
+	c.pending = slices.Clone(buf)
+}
+
+// save stores the conversation under the ID of the response once the response has ended. A failure is logged
+// rather than failing the response, so only the requests continuing the response fail.
+func (c *responsesConversation) save(ctx context.Context, logger *slog.Logger) {
+	response := gjson.ParseBytes(c.response)
+	id := response.Get("id").String()
+	if id == "" {
+		return
+	}
+	items := concatJSONArrays(c.items, []byte(response.Get("output").Raw))
+	storeCtx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	if err := c.store.Put(storeCtx, c.tenant, id, items); err != nil {
+		logger.Warn("failed to store the conversation of the response", slog.String("response_id", id), slog.String("error", err.Error()))
+	}
+}
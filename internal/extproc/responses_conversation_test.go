@@ -0,0 +1,142 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/internal/conversationstore"
+)
+
+func setTestResponsesConversations(t *testing.T) conversationstore.Store {
+	store, err := conversationstore.New(t.Context(), conversationstore.Config{URL: "memory:", Retention: time.Hour})
+	require.NoError(t, err)
+	ResponsesConversations = ResponsesConversationsConfig{Store: store, TenantHeader: "x-tenant-id", Timeout: time.Second}
+	t.Cleanup(func() { ResponsesConversations = ResponsesConversationsConfig{} })
+	return store
+}
+
+func TestRestoreResponsesConversation(t *testing.T) {
+	const body = `{"model":"gpt-4o","previous_response_id":"resp_1","input":"And Italy?"}`
+	headers := map[string]string{"x-tenant-id": "acme"}
+
+	t.Run("disabled", func(t *testing.T) {
+		newBody, restored, conversation, res, err := restoreResponsesConversation(t.Context(), headers, []byte(body))
+		require.NoError(t, err)
+		require.Nil(t, res)
+		require.False(t, restored)
+		require.Nil(t, conversation)
+		require.JSONEq(t, body, string(newBody))
+	})
+
+	store := setTestResponsesConversations(t)
+	require.NoError(t, store.Put(t.Context(), "acme", "resp_1",
+		[]byte(`[{"role":"user","content":"Capital of France?"},{"type":"message","role":"assistant","content":[{"type":"output_text","text":"Paris."}]}]`)))
+
+	t.Run("restored", func(t *testing.T) {
+		newBody, restored, conversation, res, err := restoreResponsesConversation(t.Context(), headers, []byte(body))
+		require.NoError(t, err)
+		require.Nil(t, res)
+		require.True(t, restored)
+		const expItems = `[
+  {"role":"user","content":"Capital of France?"},
+  {"type":"message","role":"assistant","content":[{"type":"output_text","text":"Paris."}]},
+  {"role":"user","content":"And Italy?"}
+]`
+		require.JSONEq(t, `{"model":"gpt-4o","input":`+expItems+`}`, string(newBody))
+		require.Equal(t, "acme", conversation.tenant)
+		require.JSONEq(t, expItems, string(conversation.items))
+	})
+
+	t.Run("no previous response", func(t *testing.T) {
+		const body = `{"model":"gpt-4o","input":[{"role":"user","content":"Hi"}]}`
+		newBody, restored, conversation, res, err := restoreResponsesConversation(t.Context(), headers, []byte(body))
+		require.NoError(t, err)
+		require.Nil(t, res)
+		require.False(t, restored)
+		require.Equal(t, body, string(newBody))
+		require.JSONEq(t, `[{"role":"user","content":"Hi"}]`, string(conversation.items))
+	})
+
+	t.Run("not stored", func(t *testing.T) {
+		_, restored, conversation, res, err := restoreResponsesConversation(t.Context(), headers,
+			[]byte(`{"model":"gpt-4o","previous_response_id":"resp_1","input":"Hi","store":false}`))
+		require.NoError(t, err)
+		require.Nil(t, res)
+		require.True(t, restored)
+		require.Nil(t, conversation)
+	})
+
+	t.Run("other tenant", func(t *testing.T) {
+		_, _, _, res, err := restoreResponsesConversation(t.Context(), map[string]string{"x-tenant-id": "other"}, []byte(body))
+		require.NoError(t, err)
+		require.Equal(t, typev3.StatusCode_BadRequest, res.GetImmediateResponse().GetStatus().GetCode())
+		require.JSONEq(t, `{
+  "type": "error",
+  "error": {
+    "type": "invalid_request_error",
+    "code": "previous_response_not_found",
+    "message": "Previous response with id 'resp_1' not found.",
+    "param": "previous_response_id"
+  }
+}`, string(res.GetImmediateResponse().GetBody()))
+	})
+}
+
+func TestResponsesConversation_save(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{}))
+	const output = `[{"type":"message","role":"assistant","content":[{"type":"output_text","text":"Rome."}]}]`
+	expItems := `[{"role":"user","content":"And Italy?"},` + output[1:]
+
+	t.Run("non-streaming", func(t *testing.T) {
+		store := setTestResponsesConversations(t)
+		c := &responsesConversation{store: store, timeout: time.Second, tenant: "acme", items: []byte(`[{"role":"user","content":"And Italy?"}]`)}
+		c.observe([]byte(`{"id":"resp_2","object":"response",`), false)
+		c.observe([]byte(`"output":`+output+`}`), false)
+		c.save(t.Context(), logger)
+		items, err := store.Get(t.Context(), "acme", "resp_2")
+		require.NoError(t, err)
+		require.JSONEq(t, expItems, string(items))
+	})
+
+	t.Run("streaming", func(t *testing.T) {
+		store := setTestResponsesConversations(t)
+		c := &responsesConversation{store: store, timeout: time.Second, tenant: "acme", items: []byte(`[{"role":"user","content":"And Italy?"}]`)}
+		completed := `event: response.completed
+data: {"type":"response.completed","response":{"id":"resp_3","output":` + output + `}}
+
+`
+		c.observe([]byte("event: response.created\ndata: {\"type\":\"response.created\",\"response\":{\"id\":\"resp_3\"}}\n\n"), true)
+		// The event is split across the chunks.
+		c.observe([]byte(completed[:40]), true)
+		c.observe([]byte(completed[40:]), true)
+		c.save(t.Context(), logger)
+		items, err := store.Get(t.Context(), "acme", "resp_3")
+		require.NoError(t, err)
+		require.JSONEq(t, expItems, string(items))
+	})
+
+	t.Run("no response", func(t *testing.T) {
+		store := setTestResponsesConversations(t)
+		c := &responsesConversation{store: store, timeout: time.Second, items: []byte(`[]`)}
+		c.observe([]byte("event: response.created\ndata: {\"type\":\"response.created\",\"response\":{\"id\":\"resp_4\"}}\n\n"), true)
+		c.save(t.Context(), logger)
+		_, err := store.Get(t.Context(), "", "resp_4")
+		require.ErrorIs(t, err, conversationstore.ErrNotFound)
+	})
+}
+
+func TestConcatJSONArrays(t *testing.T) {
+	require.Equal(t, `[]`, string(concatJSONArrays([]byte(`[]`), nil)))
+	require.Equal(t, `[1,{"a":2},3]`, string(concatJSONArrays([]byte(`[1, {"a":2}]`), []byte(`[3]`))))
+	require.Equal(t, `[3]`, string(concatJSONArrays([]byte(`[]`), []byte(`[3]`))))
+}
@@ -9,16 +9,15 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"io"
 	"log/slog"
 	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"unicode/utf8"
 
 	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
-	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
 	"github.com/google/uuid"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/health/grpc_health_v1"
@@ -28,6 +27,7 @@ import (
 	"github.com/envoyproxy/ai-gateway/internal/backendauth"
 	"github.com/envoyproxy/ai-gateway/internal/filterapi"
 	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+	"github.com/envoyproxy/ai-gateway/internal/metrics"
 	"github.com/envoyproxy/ai-gateway/internal/redaction"
 )
 
@@ -61,6 +61,14 @@ type Server struct {
 	routerProcessorsPerReqID      map[string]Processor
 	routerProcessorsPerReqIDMutex sync.RWMutex
 	uuidFn                        func() string
+	streamLimiter                 *streamLimiter
+	quotaFallback                 *quotaFallback
+	backendLoad                   *backendLoad
+	promptInjectionMetrics        metrics.PromptInjectionMetrics
+	phaseTimeouts                 PhaseTimeouts
+	// configLoaded is set once the first config and the backend auth handlers it references are loaded. Until then,
+	// the health service reports NOT_SERVING since every request would fail.
+	configLoaded atomic.Bool
 }
 
 // NewServer creates a new external processor server.
@@ -73,6 +81,9 @@ func NewServer(logger *slog.Logger, enableRedaction bool) (*Server, error) {
 		processorFactories:       make(map[string]ProcessorFactory),
 		routerProcessorsPerReqID: make(map[string]Processor),
 		uuidFn:                   uuid.NewString,
+		streamLimiter:            newStreamLimiter(),
+		quotaFallback:            newQuotaFallback(logger),
+		backendLoad:              newBackendLoad(),
 	}
 	return srv, nil
 }
@@ -84,9 +95,28 @@ func (s *Server) LoadConfig(ctx context.Context, config *filterapi.Config) error
 		return fmt.Errorf("cannot create runtime filter config: %w", err)
 	}
 	s.config = newConfig // This is racey, but we don't care.
+	s.quotaFallback.update(config.QuotaFallback)
+	s.configLoaded.Store(true)
 	return nil
 }
 
+// SetQuotaFallbackMetrics sets the metrics recording the local rate limiting applied while the quota rate limit
+// service is unreachable.
+func (s *Server) SetQuotaFallbackMetrics(m metrics.QuotaFallbackMetrics) {
+	s.quotaFallback.metrics = m
+}
+
+// SetPromptInjectionMetrics sets the metrics recording the results of screening the requests for prompt injection
+// attempts.
+func (s *Server) SetPromptInjectionMetrics(m metrics.PromptInjectionMetrics) {
+	s.promptInjectionMetrics = m
+}
+
+// SetPhaseTimeouts sets the timeouts of processing the messages of each phase of the streams.
+func (s *Server) SetPhaseTimeouts(timeouts PhaseTimeouts) {
+	s.phaseTimeouts = timeouts
+}
+
 // Register a new processor for the given request path.
 func (s *Server) Register(path string, newProcessor ProcessorFactory) {
 	s.logger.Info("Registering processor", slog.String("path", path))
@@ -125,126 +155,84 @@ const originalPathHeader = internalapi.OriginalPathHeader
 const internalReqIDHeader = internalapi.EnvoyAIGatewayHeaderPrefix + "internal-req-id"
 
 // Process implements [extprocv3.ExternalProcessorServer].
+//
+// Each stream is served by its own [processStream] holding the state of the request, so that a failure in the
+// middle of a stream only fails that stream.
 func (s *Server) Process(stream extprocv3.ExternalProcessor_ProcessServer) error {
-	ctx := stream.Context()
 	if s.debugLogEnabled {
 		s.logger.Debug("handling a new stream", slog.Any("config_uuid", s.config.UUID))
 	}
+	w := s.newProcessStream(stream)
+	defer w.close()
+	return w.run()
+}
 
-	// The processor will be instantiated when the first message containing the request headers is received.
-	// The :path header is used to determine the processor to use, based on the registered ones.
-	//
-	// If this extproc filter is invoked without going through a RequestHeaders phase, that means
-	// an earlier filter has already processed the request headers/bodies and decided to terminate
-	// the request by sending an immediate response. In this case, we will use the passThroughProcessor
-	// to pass the request through without any processing as there would be nothing to process from AI Gateway's perspective.
-	var p Processor = passThroughProcessor{}
-	var isUpstreamFilter bool
-	var internalReqID string
-	var originalReqID string
-	var logger *slog.Logger
-	// Seed the context with the server-level logger as a fallback so that loggerFromContext never returns nil in processMsg.
-	ctx = context.WithValue(ctx, loggerContextKey, s.logger)
-	defer func() {
-		if !isUpstreamFilter {
-			s.routerProcessorsPerReqIDMutex.Lock()
-			defer s.routerProcessorsPerReqIDMutex.Unlock()
-			delete(s.routerProcessorsPerReqID, internalReqID)
-		}
-	}()
-
-	for {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-		}
-
-		req, err := stream.Recv()
-		if errors.Is(err, io.EOF) || status.Code(err) == codes.Canceled {
-			return nil
-		} else if err != nil {
-			s.logger.Error("cannot receive stream request", slog.String("error", err.Error()))
-			return status.Errorf(codes.Unknown, "cannot receive stream request: %v", err)
-		}
-
-		// If we're processing the request headers, read the :path header to instantiate the
-		// right processor.
-		// Note that `req.GetRequestHeaders()` will only return non-nil if the request is
-		// of type `ProcessingRequest_RequestHeaders`, so this will be executed only once per
-		// request, and the processor will be instantiated only once.
-		if headers := req.GetRequestHeaders().GetHeaders(); headers != nil {
-			headersMap := headersToMap(headers)
-			originalReqID = headersMap["x-request-id"]
-			// Assume that when attributes are set, this stream is for the upstream filter level.
-			isUpstreamFilter = req.GetAttributes() != nil
-
-			if isUpstreamFilter {
-				// For upstream filter, use the internal request ID passed from the router filter
-				internalReqID = headersMap[internalReqIDHeader]
-				if internalReqID == "" {
-					return status.Errorf(codes.Internal, "missing internal request ID header from router filter")
-				}
-			} else {
-				// For router filter, create a unique internal request ID to avoid race conditions
-				// with duplicate x-request-id values by appending a UUID suffix to the original request ID
-				internalReqID = originalReqID + "-" + s.uuidFn()
-			}
+// acquireStream counts the request against the stream concurrency limits if it is a streaming request accepted
+// by the router processor. See [streamLimiter.acquire] for the return values.
+func (s *Server) acquireStream(p Processor, resp *extprocv3.ProcessingResponse, requestHeaders map[string]string) (func(), *filterapi.StreamConcurrencyLimit) {
+	config := s.config
+	if config == nil || len(config.StreamConcurrencyLimits) == 0 {
+		return nil, nil
+	}
+	if _, ok := resp.GetResponse().(*extprocv3.ProcessingResponse_RequestBody); !ok {
+		return nil, nil // The request has been rejected by the processor.
+	}
+	if sp, ok := p.(streamingRequestProcessor); !ok || !sp.isStreamingRequest() {
+		return nil, nil
+	}
+	return s.streamLimiter.acquire(config.StreamConcurrencyLimits, requestHeaders)
+}
 
-			// Create request-scoped logger with request_id before creating processor
-			// so that the logger passed to translators includes the request_id field.
-			if logger == nil {
-				logger = s.logger.With("request_id", originalReqID, "is_upstream_filter", isUpstreamFilter)
-			}
-			// Add logger to context so processMsg can access it
-			ctx = context.WithValue(ctx, loggerContextKey, logger)
-
-			p, err = s.processorForPath(headersMap, isUpstreamFilter, logger)
-			if err != nil {
-				if errors.Is(err, errNoProcessor) {
-					path := headersMap[":path"]
-					_ = stream.Send(&extprocv3.ProcessingResponse{
-						Response: &extprocv3.ProcessingResponse_ImmediateResponse{
-							ImmediateResponse: &extprocv3.ImmediateResponse{
-								Status:     &typev3.HttpStatus{Code: typev3.StatusCode_NotFound},
-								Body:       fmt.Appendf(nil, "unsupported path: %s", path),
-								GrpcStatus: &extprocv3.GrpcStatus{Status: uint32(codes.NotFound)},
-							},
-						},
-					})
-					return status.Errorf(codes.NotFound, "unsupported path: %s", path)
-				}
-				s.logger.Error("cannot get processor", slog.String("error", err.Error()))
-				return status.Error(codes.NotFound, err.Error())
-			}
-			_, isEndpoinPicker := headersMap[internalapi.EndpointPickerHeaderKey]
-			if isUpstreamFilter {
-				if err = s.setBackend(ctx, p, internalReqID, isEndpoinPicker, req); err != nil {
-					s.logger.Error("error processing request message", slog.String("error", err.Error()))
-					return status.Errorf(codes.Unknown, "error processing request message: %v", err)
-				}
-			} else {
-				s.routerProcessorsPerReqIDMutex.Lock()
-				s.routerProcessorsPerReqID[internalReqID] = p
-				s.routerProcessorsPerReqIDMutex.Unlock()
-			}
+// applyQuotaFallback enforces the local fallback limits of the quotas on the upstream request while the quota
+// rate limit service is unreachable. The request is rejected with the request headers if the limits are exceeded.
+func (s *Server) applyQuotaFallback(ctx context.Context, p Processor, req *extprocv3.ProcessingRequest, resp *extprocv3.ProcessingResponse, requestHeaders map[string]string) *extprocv3.ProcessingResponse {
+	config := s.config
+	if config == nil || config.QuotaFallback == nil || len(config.QuotaFallback.Limits) == 0 || req.GetRequestHeaders() == nil {
+		return resp
+	}
+	if _, accepted := resp.GetResponse().(*extprocv3.ProcessingResponse_RequestHeaders); !accepted {
+		return resp
+	}
+	qp, ok := p.(quotaFallbackProcessor)
+	if !ok {
+		return resp
+	}
+	backendName, routeName := qp.quotaFallbackTarget()
+	model := requestHeaders[modelNameHeaderKey(config)]
+	if exceeded := s.quotaFallback.admit(config.QuotaFallback.Limits, backendName, routeName, model); exceeded != nil {
+		loggerFromContext(ctx).Info("rejecting request exceeding the local fallback quota",
+			slog.String("backend", exceeded.Backend), slog.String("model", exceeded.Model), slog.Uint64("limit", exceeded.Limit))
+		if m := s.quotaFallback.metrics; m != nil {
+			m.RecordRejected(ctx, exceeded.Backend, exceeded.Model)
 		}
+		return quotaFallbackExceededResponse(exceeded)
+	}
+	return resp
+}
 
-		// At this point, p is guaranteed to be a valid processor either from the concrete processor or the passThroughProcessor.
-		resp, err := s.processMsg(ctx, p, req, internalReqID, isUpstreamFilter)
-		if err != nil {
-			s.logger.Error("error processing request message", slog.String("error", err.Error()))
-			return status.Errorf(codes.Unknown, "error processing request message: %v", err)
-		}
-		if err := stream.Send(resp); err != nil {
-			s.logger.Error("cannot send response", slog.String("error", err.Error()))
-			return status.Errorf(codes.Unknown, "cannot send response: %v", err)
-		}
+// chargeQuotaFallback charges the cost of the completed request to the local fallback limits of the quotas. This
+// is called on the router filter stream since the upstream filter doesn't receive the response body, and the
+// costs are only known in the dynamic metadata of the end of the response body.
+func (s *Server) chargeQuotaFallback(p Processor, req *extprocv3.ProcessingRequest, resp *extprocv3.ProcessingResponse, requestHeaders map[string]string) {
+	config := s.config
+	if config == nil || config.QuotaFallback == nil || len(config.QuotaFallback.Limits) == 0 || !req.GetResponseBody().GetEndOfStream() {
+		return
 	}
+	qp, ok := p.(quotaFallbackProcessor)
+	if !ok {
+		return
+	}
+	backendName, routeName := qp.quotaFallbackTarget()
+	s.quotaFallback.charge(config.QuotaFallback.Limits, backendName, routeName,
+		requestHeaders[modelNameHeaderKey(config)], resp.GetDynamicMetadata().GetFields()[metadataNamespace(config)])
 }
 
 func (s *Server) processMsg(ctx context.Context, p Processor, req *extprocv3.ProcessingRequest, internalReqID string, isUpstreamFilter bool) (*extprocv3.ProcessingResponse, error) {
 	l := loggerFromContext(ctx)
+	if isUpstreamFilter && (req.GetResponseHeaders() != nil || req.GetResponseBody() != nil) {
+		// The upstream filter only receives the responses checked for the context length errors.
+		return processUpstreamResponse(ctx, p, req)
+	}
 	switch value := req.Request.(type) {
 	case *extprocv3.ProcessingRequest_RequestHeaders:
 		requestHdrs := req.GetRequestHeaders().Headers
@@ -263,30 +251,7 @@ func (s *Server) processMsg(ctx context.Context, p Processor, req *extprocv3.Pro
 
 		// For router filter, inject the internal request ID header so upstream filter can use it
 		if !isUpstreamFilter && resp != nil {
-			if requestHeaders, ok := resp.Response.(*extprocv3.ProcessingResponse_RequestHeaders); ok {
-				// Ensure we have header mutation to add the internal request ID
-				if requestHeaders.RequestHeaders == nil {
-					requestHeaders.RequestHeaders = &extprocv3.HeadersResponse{}
-				}
-				if requestHeaders.RequestHeaders.Response == nil {
-					requestHeaders.RequestHeaders.Response = &extprocv3.CommonResponse{}
-				}
-				if requestHeaders.RequestHeaders.Response.HeaderMutation == nil {
-					requestHeaders.RequestHeaders.Response.HeaderMutation = &extprocv3.HeaderMutation{}
-				}
-
-				// Add the internal request ID header
-				internalReqIDHeaderValue := &corev3.HeaderValueOption{
-					Header: &corev3.HeaderValue{
-						Key:      internalReqIDHeader,
-						RawValue: []byte(internalReqID),
-					},
-				}
-				requestHeaders.RequestHeaders.Response.HeaderMutation.SetHeaders = append(
-					requestHeaders.RequestHeaders.Response.HeaderMutation.SetHeaders,
-					internalReqIDHeaderValue,
-				)
-			}
+			setInternalReqIDHeader(resp, internalReqID)
 		}
 		if s.debugLogEnabled && resp != nil && resp.Response != nil {
 			var logContent any
@@ -328,6 +293,10 @@ func (s *Server) processMsg(ctx context.Context, p Processor, req *extprocv3.Pro
 		if s.debugLogEnabled {
 			l.Debug("response headers processing", slog.Any("response_headers", responseHdrs))
 		}
+		// Thread the response code details into context so the router filter can tell the upstream timeouts apart,
+		// and the downstream connection so that it can send the requests to the migration backends.
+		ctx = withResponseCodeDetails(ctx, req.GetAttributes())
+		ctx = withDownstreamConnection(ctx, req.GetAttributes())
 		resp, err := p.ProcessResponseHeaders(ctx, responseHdrs)
 		if err != nil {
 			return nil, fmt.Errorf("cannot process response headers: %w", err)
@@ -382,14 +351,16 @@ func (s *Server) setBackend(ctx context.Context, p Processor, internalReqID stri
 	}
 	routeName := resolveRouteName(attributes)
 
-	backend, ok := s.config.Backends[backendName]
+	config := s.config
+	if config == nil {
+		return status.Error(codes.Unavailable, "no config loaded")
+	}
+	backend, ok := config.Backends[backendName]
 	if !ok {
 		return status.Errorf(codes.Internal, "unknown backend: %s", backendName)
 	}
 
-	s.routerProcessorsPerReqIDMutex.RLock()
-	defer s.routerProcessorsPerReqIDMutex.RUnlock()
-	routerProcessor, ok := s.routerProcessorsPerReqID[internalReqID]
+	routerProcessor, ok := s.routerProcessor(internalReqID)
 	if !ok {
 		return status.Errorf(codes.Internal, "no router processor found, request_id=%s, backend=%s",
 			internalReqID, backendName)
@@ -398,9 +369,33 @@ func (s *Server) setBackend(ctx context.Context, p Processor, internalReqID stri
 	if err := p.SetBackend(ctx, backend, routeName, routerProcessor); err != nil {
 		return status.Errorf(codes.Internal, "cannot set backend: %v", err)
 	}
+	if hp, ok := p.(upstreamHostProcessor); ok {
+		hp.setUpstreamHost(resolveUpstreamHost(attributes))
+	}
 	return nil
 }
 
+// requestLogAttrs returns the fields bound to the logger of a stream to correlate its logs with the request.
+// The upstream filter streams are also bound to the route, backend and model, which are all known at the
+// request headers. The router filter streams are bound to the model after the request body is parsed.
+func requestLogAttrs(requestID string, isUpstreamFilter, isEndpointPicker bool, req *extprocv3.ProcessingRequest, model string) []any {
+	attrs := []any{"request_id", requestID, "is_upstream_filter", isUpstreamFilter}
+	if !isUpstreamFilter {
+		return attrs
+	}
+	if attributes := req.GetAttributes()["envoy.filters.http.ext_proc"]; attributes != nil {
+		attrs = append(attrs, "route", resolveRouteName(attributes))
+		// The missing backend is reported by setBackend.
+		if backendName, err := resolveBackendName(isEndpointPicker, attributes); err == nil {
+			attrs = append(attrs, "backend", backendName)
+		}
+	}
+	if model != "" {
+		attrs = append(attrs, "model", model)
+	}
+	return attrs
+}
+
 func resolveBackendName(isEndpointPicker bool, attributes *structpb.Struct) (string, error) {
 	var backendNamePath string
 	if isEndpointPicker {
@@ -424,6 +419,12 @@ func resolveBackendName(isEndpointPicker bool, attributes *structpb.Struct) (str
 	return "", status.Errorf(codes.Internal, "missing backend name in attributes at path: %s", backendNamePath)
 }
 
+// resolveUpstreamHost returns the address of the endpoint selected by Envoy, or empty if the attribute is missing,
+// e.g. with the filter configs generated by a previous version of the extension server.
+func resolveUpstreamHost(attributes *structpb.Struct) string {
+	return attributes.Fields[internalapi.UpstreamAddressAttribute].GetStringValue()
+}
+
 func resolveRouteName(attributes *structpb.Struct) string {
 	if routeName, ok := attributes.Fields[internalapi.XDSRouteMetadataRouteNamePath]; ok {
 		return routeName.GetStringValue()
@@ -434,9 +435,17 @@ func resolveRouteName(attributes *structpb.Struct) string {
 	return ""
 }
 
-// Check implements [grpc_health_v1.HealthServer].
+// Check implements [grpc_health_v1.HealthServer]. This reports NOT_SERVING until the first config is loaded.
 func (s *Server) Check(context.Context, *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
-	return &grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_SERVING}, nil
+	return &grpc_health_v1.HealthCheckResponse{Status: s.healthStatus()}, nil
+}
+
+// healthStatus returns the status of the health service.
+func (s *Server) healthStatus() grpc_health_v1.HealthCheckResponse_ServingStatus {
+	if !s.configLoaded.Load() {
+		return grpc_health_v1.HealthCheckResponse_NOT_SERVING
+	}
+	return grpc_health_v1.HealthCheckResponse_SERVING
 }
 
 // Watch implements [grpc_health_v1.HealthServer].
@@ -447,7 +456,7 @@ func (s *Server) Watch(*grpc_health_v1.HealthCheckRequest, grpc_health_v1.Health
 // List implements [grpc_health_v1.HealthServer].
 func (s *Server) List(context.Context, *grpc_health_v1.HealthListRequest) (*grpc_health_v1.HealthListResponse, error) {
 	return &grpc_health_v1.HealthListResponse{Statuses: map[string]*grpc_health_v1.HealthCheckResponse{
-		"extproc": {Status: grpc_health_v1.HealthCheckResponse_SERVING},
+		"extproc": {Status: s.healthStatus()},
 	}}, nil
 }
 
@@ -46,18 +46,34 @@ func requireNewServerWithMockProcessor(t *testing.T) (*Server, *mockProcessor) {
 
 func TestServer_LoadConfig(t *testing.T) {
 	config := &filterapi.Config{}
-	s := &Server{}
+	s := &Server{quotaFallback: newQuotaFallback(slog.Default())}
 	err := s.LoadConfig(t.Context(), config)
 	require.NoError(t, err)
 	require.NotNil(t, s.config)
+	require.True(t, s.configLoaded.Load())
 }
 
 func TestServer_Check(t *testing.T) {
 	s, _ := requireNewServerWithMockProcessor(t)
 
+	// The server isn't serving until the first config is loaded.
 	res, err := s.Check(t.Context(), nil)
 	require.NoError(t, err)
 	require.NotNil(t, res)
+	require.Equal(t, grpc_health_v1.HealthCheckResponse_NOT_SERVING, res.Status)
+
+	// The config failing to load keeps the server not serving.
+	err = s.LoadConfig(t.Context(), &filterapi.Config{Backends: []filterapi.Backend{
+		{Name: "invalid", Auth: &filterapi.BackendAuth{AWSAuth: &filterapi.AWSAuth{CredentialFileLiteral: "invalid"}}},
+	}})
+	require.Error(t, err)
+	res, err = s.Check(t.Context(), nil)
+	require.NoError(t, err)
+	require.Equal(t, grpc_health_v1.HealthCheckResponse_NOT_SERVING, res.Status)
+
+	require.NoError(t, s.LoadConfig(t.Context(), &filterapi.Config{}))
+	res, err = s.Check(t.Context(), nil)
+	require.NoError(t, err)
 	require.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, res.Status)
 }
 
@@ -75,6 +91,11 @@ func TestServer_List(t *testing.T) {
 	res, err := s.List(t.Context(), nil)
 	require.NoError(t, err)
 	require.NotNil(t, res)
+	require.Equal(t, grpc_health_v1.HealthCheckResponse_NOT_SERVING, res.Statuses["extproc"].Status)
+
+	require.NoError(t, s.LoadConfig(t.Context(), &filterapi.Config{}))
+	res, err = s.List(t.Context(), nil)
+	require.NoError(t, err)
 	require.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, res.Statuses["extproc"].Status)
 }
 
@@ -342,6 +363,31 @@ func TestServer_setBackend(t *testing.T) {
 	}
 }
 
+func TestRequestLogAttrs(t *testing.T) {
+	t.Run("router filter", func(t *testing.T) {
+		attrs := requestLogAttrs("req-1", false, false, &extprocv3.ProcessingRequest{}, "gpt-4")
+		require.Equal(t, []any{"request_id", "req-1", "is_upstream_filter", false}, attrs)
+	})
+	t.Run("upstream filter", func(t *testing.T) {
+		attrs := requestLogAttrs("req-1", true, false, &extprocv3.ProcessingRequest{
+			Attributes: map[string]*structpb.Struct{
+				"envoy.filters.http.ext_proc": {Fields: map[string]*structpb.Value{
+					internalapi.XDSUpstreamHostMetadataBackendNamePath: structpb.NewStringValue("openai"),
+					internalapi.XDSRouteMetadataRouteNamePath:          structpb.NewStringValue("route-a"),
+				}},
+			},
+		}, "gpt-4")
+		require.Equal(t, []any{
+			"request_id", "req-1", "is_upstream_filter", true,
+			"route", "route-a", "backend", "openai", "model", "gpt-4",
+		}, attrs)
+	})
+	t.Run("upstream filter without attributes", func(t *testing.T) {
+		attrs := requestLogAttrs("req-1", true, false, &extprocv3.ProcessingRequest{}, "")
+		require.Equal(t, []any{"request_id", "req-1", "is_upstream_filter", true}, attrs)
+	})
+}
+
 func TestResolveBackendName(t *testing.T) {
 	const backendName = "default/openai/route/aigw-run/rule/0/ref/0"
 
@@ -415,6 +461,16 @@ func TestResolveRouteName(t *testing.T) {
 	require.Empty(t, actual)
 }
 
+func TestResolveUpstreamHost(t *testing.T) {
+	attributes := &structpb.Struct{
+		Fields: map[string]*structpb.Value{
+			internalapi.UpstreamAddressAttribute: structpb.NewStringValue("10.0.0.1:8000"),
+		},
+	}
+	require.Equal(t, "10.0.0.1:8000", resolveUpstreamHost(attributes))
+	require.Empty(t, resolveUpstreamHost(&structpb.Struct{Fields: map[string]*structpb.Value{}}))
+}
+
 func TestServer_ProcessorSelection(t *testing.T) {
 	s, err := NewServer(slog.Default(), false)
 	require.NoError(t, err)
@@ -0,0 +1,94 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"bytes"
+	"time"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+
+	"github.com/envoyproxy/ai-gateway/internal/filterapi"
+)
+
+// streamCoalescer coalesces the small events of a streaming response into fewer body chunks, as configured by
+// [filterapi.Config.StreamCoalescing]. Since the external processor can only send a chunk in the response to an
+// upstream chunk, the events held back are sent with a later upstream chunk, at the latest the last one.
+//
+// Only whole events are sent before the end of the stream, so that the events are never split across the chunks
+// more than they were upstream, and the order of the bytes is preserved.
+type streamCoalescer struct {
+	minBytes      int
+	flushInterval time.Duration
+	now           func() time.Time
+	// buf holds the bytes not sent yet.
+	buf []byte
+	// lastFlush is the time the events were last sent, or the creation time of the coalescer.
+	lastFlush time.Time
+}
+
+func newStreamCoalescer(config *filterapi.StreamCoalescing, now func() time.Time) *streamCoalescer {
+	return &streamCoalescer{
+		minBytes:      config.MinBytes,
+		flushInterval: time.Duration(config.FlushIntervalMilliseconds) * time.Millisecond,
+		now:           now,
+		lastFlush:     now(),
+	}
+}
+
+// coalesce buffers the chunk and returns the bytes to send to the client, which are empty when the events are
+// held back. At the end of the stream, all the buffered bytes are returned.
+func (s *streamCoalescer) coalesce(chunk []byte, endOfStream bool) []byte {
+	s.buf = append(s.buf, chunk...)
+	if endOfStream {
+		out := s.buf
+		s.buf = nil
+		return out
+	}
+	end := lastSSEEventEnd(s.buf)
+	if end == 0 {
+		return nil
+	}
+	now := s.now()
+	if (s.minBytes <= 0 || end < s.minBytes) && (s.flushInterval <= 0 || now.Sub(s.lastFlush) < s.flushInterval) {
+		return nil
+	}
+	out := s.buf[:end:end]
+	s.buf = append([]byte(nil), s.buf[end:]...)
+	s.lastFlush = now
+	return out
+}
+
+// coalesceBodyMutation coalesces the chunk sent to the client, which is either the one in the given bodyMutation
+// or the upstream body if the processor didn't mutate it, and returns the body mutation sending the coalesced
+// bytes instead.
+func (s *streamCoalescer) coalesceBodyMutation(bodyMutation *extprocv3.BodyMutation, upstream []byte, endOfStream bool) *extprocv3.BodyMutation {
+	chunk := upstream
+	switch m := bodyMutation.GetMutation().(type) {
+	case *extprocv3.BodyMutation_Body:
+		chunk = m.Body
+	case *extprocv3.BodyMutation_ClearBody:
+		chunk = nil
+	}
+	out := s.coalesce(chunk, endOfStream)
+	if len(out) == 0 {
+		return &extprocv3.BodyMutation{Mutation: &extprocv3.BodyMutation_ClearBody{ClearBody: true}}
+	}
+	return &extprocv3.BodyMutation{Mutation: &extprocv3.BodyMutation_Body{Body: out}}
+}
+
+// lastSSEEventEnd returns the index right after the last blank line terminating an event in buf, or zero if buf
+// has no complete event.
+func lastSSEEventEnd(buf []byte) int {
+	end := 0
+	if i := bytes.LastIndex(buf, []byte("\n\n")); i >= 0 {
+		end = i + 2
+	}
+	if i := bytes.LastIndex(buf, []byte("\r\n\r\n")); i >= 0 && i+4 > end {
+		end = i + 4
+	}
+	return end
+}
@@ -0,0 +1,60 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"testing"
+	"time"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/internal/filterapi"
+)
+
+func TestStreamCoalescer_coalesce(t *testing.T) {
+	const event = "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"a\"}}]}\n\n" // 57 bytes.
+
+	t.Run("min bytes", func(t *testing.T) {
+		s := newStreamCoalescer(&filterapi.StreamCoalescing{MinBytes: 100}, time.Now)
+		require.Empty(t, s.coalesce([]byte(event), false))
+		// The event split across the chunks is held back until it is complete.
+		require.Empty(t, s.coalesce([]byte(event[:10]), false))
+		require.Equal(t, event+event, string(s.coalesce([]byte(event[10:]), false)))
+		require.Empty(t, s.coalesce([]byte(event), false))
+		require.Equal(t, event+"data: [DONE]\n\n", string(s.coalesce([]byte("data: [DONE]\n\n"), true)))
+	})
+	t.Run("flush interval", func(t *testing.T) {
+		now := time.Unix(0, 0)
+		s := newStreamCoalescer(&filterapi.StreamCoalescing{FlushIntervalMilliseconds: 50}, func() time.Time { return now })
+		require.Empty(t, s.coalesce([]byte(event), false))
+		now = now.Add(20 * time.Millisecond)
+		require.Empty(t, s.coalesce([]byte(event), false))
+		now = now.Add(30 * time.Millisecond)
+		require.Equal(t, event+event+event, string(s.coalesce([]byte(event+"data: {"), false)))
+		now = now.Add(10 * time.Millisecond)
+		require.Empty(t, s.coalesce([]byte("}\n\n"), false))
+		require.Equal(t, "data: {}\n\n", string(s.coalesce(nil, true)))
+	})
+	t.Run("crlf", func(t *testing.T) {
+		s := newStreamCoalescer(&filterapi.StreamCoalescing{MinBytes: 1}, time.Now)
+		require.Equal(t, "data: a\r\n\r\n", string(s.coalesce([]byte("data: a\r\n\r\ndata: b"), false)))
+		require.Equal(t, "data: b\r\n\r\n", string(s.coalesce([]byte("\r\n\r\n"), false)))
+	})
+}
+
+func TestStreamCoalescer_coalesceBodyMutation(t *testing.T) {
+	s := newStreamCoalescer(&filterapi.StreamCoalescing{MinBytes: 16}, time.Now)
+	// The upstream body is coalesced when the chunk isn't mutated.
+	res := s.coalesceBodyMutation(nil, []byte("data: a\n\n"), false)
+	require.True(t, res.GetClearBody())
+	// The mutated body is coalesced rather than the upstream one.
+	res = s.coalesceBodyMutation(&extprocv3.BodyMutation{Mutation: &extprocv3.BodyMutation_Body{Body: []byte("data: b\n\n")}}, []byte("ignored"), false)
+	require.Equal(t, "data: a\n\ndata: b\n\n", string(res.GetBody()))
+	// The cleared chunk adds nothing.
+	res = s.coalesceBodyMutation(&extprocv3.BodyMutation{Mutation: &extprocv3.BodyMutation_ClearBody{ClearBody: true}}, []byte("ignored"), true)
+	require.True(t, res.GetClearBody())
+}
@@ -0,0 +1,112 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"google.golang.org/grpc/codes"
+
+	"github.com/envoyproxy/ai-gateway/internal/filterapi"
+)
+
+// streamingRequestProcessor is implemented by the router processors that know whether the request is streaming
+// after processing the request body.
+type streamingRequestProcessor interface {
+	isStreamingRequest() bool
+}
+
+// streamLimiter counts the in-flight streaming requests per client to enforce [filterapi.StreamConcurrencyLimit].
+//
+// This is owned by the Server rather than the runtime config so that the counts of the in-flight streams
+// survive the config reloads.
+type streamLimiter struct {
+	mu sync.Mutex
+	// inFlight is the number of in-flight streams keyed by the limit headers and the header values.
+	inFlight map[string]int
+}
+
+func newStreamLimiter() *streamLimiter {
+	return &streamLimiter{inFlight: make(map[string]int)}
+}
+
+// acquire counts a new stream against all the limits applicable to the request headers. If any of the limits
+// is exceeded, nothing is counted and the exceeded limit is returned. Otherwise, the returned release function
+// must be called when the stream ends.
+func (s *streamLimiter) acquire(limits []filterapi.StreamConcurrencyLimit, headers map[string]string) (release func(), exceeded *filterapi.StreamConcurrencyLimit) {
+	keys := make([]string, 0, len(limits))
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range limits {
+		l := &limits[i]
+		key, ok := streamLimitKey(l, headers)
+		if !ok {
+			continue
+		}
+		if s.inFlight[key] >= l.MaxConcurrentStreams {
+			return nil, l
+		}
+		keys = append(keys, key)
+	}
+	for _, key := range keys {
+		s.inFlight[key]++
+	}
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			for _, key := range keys {
+				if s.inFlight[key]--; s.inFlight[key] <= 0 {
+					delete(s.inFlight, key)
+				}
+			}
+		})
+	}, nil
+}
+
+// streamLimitKey returns the key of the counter for the given limit and request headers. This returns false
+// if the request doesn't have all the headers of the limit.
+func streamLimitKey(l *filterapi.StreamConcurrencyLimit, headers map[string]string) (string, bool) {
+	var b strings.Builder
+	for _, h := range l.Headers {
+		v, ok := headers[h]
+		if !ok || v == "" {
+			return "", false
+		}
+		// Include the header names so that the limits with different headers don't share the counters.
+		b.WriteString(h)
+		b.WriteByte(0)
+		b.WriteString(v)
+		b.WriteByte(0)
+	}
+	return b.String(), true
+}
+
+// streamLimitExceededResponse returns the 429 response for the request rejected by the given limit.
+func streamLimitExceededResponse(l *filterapi.StreamConcurrencyLimit) *extprocv3.ProcessingResponse {
+	const statusCode = 429
+	body := formatUserFacingErrorJSON("TooManyRequests", statusCode,
+		"too many concurrent streaming requests, limit is "+strconv.Itoa(l.MaxConcurrentStreams))
+	headerMutation := &extprocv3.HeaderMutation{}
+	setHeader(headerMutation, "content-type", "application/json")
+	setHeader(headerMutation, "content-length", strconv.Itoa(len(body)))
+	setHeader(headerMutation, "retry-after", strconv.Itoa(max(l.RetryAfterSeconds, 1)))
+	return &extprocv3.ProcessingResponse{
+		Response: &extprocv3.ProcessingResponse_ImmediateResponse{
+			ImmediateResponse: &extprocv3.ImmediateResponse{
+				Status:     &typev3.HttpStatus{Code: typev3.StatusCode_TooManyRequests},
+				Headers:    headerMutation,
+				Body:       body,
+				GrpcStatus: &extprocv3.GrpcStatus{Status: uint32(codes.ResourceExhausted)},
+			},
+		},
+	}
+}
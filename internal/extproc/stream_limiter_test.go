@@ -0,0 +1,121 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"log/slog"
+	"testing"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/internal/filterapi"
+)
+
+func TestStreamLimiter(t *testing.T) {
+	perUser := filterapi.StreamConcurrencyLimit{Headers: []string{"x-user-id"}, MaxConcurrentStreams: 2}
+	perTenant := filterapi.StreamConcurrencyLimit{Headers: []string{"x-tenant-id"}, MaxConcurrentStreams: 3}
+	limits := []filterapi.StreamConcurrencyLimit{perUser, perTenant}
+	alice := map[string]string{"x-user-id": "alice", "x-tenant-id": "acme"}
+	bob := map[string]string{"x-user-id": "bob", "x-tenant-id": "acme"}
+
+	s := newStreamLimiter()
+	release1, exceeded := s.acquire(limits, alice)
+	require.Nil(t, exceeded)
+	release2, exceeded := s.acquire(limits, alice)
+	require.Nil(t, exceeded)
+
+	// The third stream of alice exceeds the per-user limit.
+	_, exceeded = s.acquire(limits, alice)
+	require.Equal(t, &limits[0], exceeded)
+
+	// bob is under the per-user limit, and the tenant reaches its limit.
+	release3, exceeded := s.acquire(limits, bob)
+	require.Nil(t, exceeded)
+	_, exceeded = s.acquire(limits, bob)
+	require.Equal(t, &limits[1], exceeded)
+
+	// Requests without the headers are not limited.
+	for range 5 {
+		_, exceeded = s.acquire(limits, map[string]string{})
+		require.Nil(t, exceeded)
+	}
+
+	// Releasing twice must not release the other streams.
+	release1()
+	release1()
+	_, exceeded = s.acquire(limits, bob)
+	require.Nil(t, exceeded)
+	_, exceeded = s.acquire(limits, alice)
+	require.Equal(t, &limits[1], exceeded)
+
+	release2()
+	release3()
+	require.Len(t, s.inFlight, 2) // The remaining stream of bob.
+}
+
+func TestStreamLimitExceededResponse(t *testing.T) {
+	resp := streamLimitExceededResponse(&filterapi.StreamConcurrencyLimit{MaxConcurrentStreams: 2, RetryAfterSeconds: 5})
+	ir := resp.GetImmediateResponse()
+	require.NotNil(t, ir)
+	require.Equal(t, typev3.StatusCode_TooManyRequests, ir.Status.Code)
+	require.JSONEq(t, `{"type":"error","error":{"type":"TooManyRequests","code":"429","message":"too many concurrent streaming requests, limit is 2"}}`, string(ir.Body))
+	headers := map[string]string{}
+	for _, h := range ir.Headers.SetHeaders {
+		headers[h.Header.Key] = string(h.Header.RawValue)
+	}
+	require.Equal(t, "5", headers["retry-after"])
+}
+
+type fakeStreamingProcessor struct {
+	passThroughProcessor
+	stream bool
+}
+
+func (f fakeStreamingProcessor) isStreamingRequest() bool { return f.stream }
+
+func TestServer_acquireStream(t *testing.T) {
+	s, err := NewServer(slog.Default(), false)
+	require.NoError(t, err)
+	headers := map[string]string{"x-user-id": "alice"}
+	accepted := &extprocv3.ProcessingResponse{Response: &extprocv3.ProcessingResponse_RequestBody{}}
+
+	// No config or no limits.
+	release, exceeded := s.acquireStream(fakeStreamingProcessor{stream: true}, accepted, headers)
+	require.Nil(t, release)
+	require.Nil(t, exceeded)
+	s.config = &filterapi.RuntimeConfig{}
+	release, exceeded = s.acquireStream(fakeStreamingProcessor{stream: true}, accepted, headers)
+	require.Nil(t, release)
+	require.Nil(t, exceeded)
+
+	s.config = &filterapi.RuntimeConfig{StreamConcurrencyLimits: []filterapi.StreamConcurrencyLimit{
+		{Headers: []string{"x-user-id"}, MaxConcurrentStreams: 1},
+	}}
+	// Non-streaming requests, rejected requests, and the processors without streaming support are not counted.
+	release, _ = s.acquireStream(fakeStreamingProcessor{}, accepted, headers)
+	require.Nil(t, release)
+	release, _ = s.acquireStream(fakeStreamingProcessor{stream: true}, createUserFacingErrorResponse(400, "BadRequest", "bad"), headers)
+	require.Nil(t, release)
+	release, _ = s.acquireStream(passThroughProcessor{}, accepted, headers)
+	require.Nil(t, release)
+
+	release, exceeded = s.acquireStream(fakeStreamingProcessor{stream: true}, accepted, headers)
+	require.NotNil(t, release)
+	require.Nil(t, exceeded)
+	_, exceeded = s.acquireStream(fakeStreamingProcessor{stream: true}, accepted, headers)
+	require.NotNil(t, exceeded)
+
+	// The counts survive config reloads.
+	s.config = &filterapi.RuntimeConfig{StreamConcurrencyLimits: s.config.StreamConcurrencyLimits}
+	_, exceeded = s.acquireStream(fakeStreamingProcessor{stream: true}, accepted, headers)
+	require.NotNil(t, exceeded)
+	release()
+	release, exceeded = s.acquireStream(fakeStreamingProcessor{stream: true}, accepted, headers)
+	require.NotNil(t, release)
+	require.Nil(t, exceeded)
+}
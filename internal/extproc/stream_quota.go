@@ -0,0 +1,246 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/json"
+	"github.com/envoyproxy/ai-gateway/internal/metrics"
+)
+
+const (
+	// rateLimitRemainingHeader is the response header set by the quota rate limit filter which reports the
+	// remaining quota of the most restrictive descriptor applied to the request.
+	rateLimitRemainingHeader = "x-ratelimit-remaining"
+	// quotaExceededEventType is the server-sent event type emitted after the final chunk of a stream cut off at
+	// the quota. OpenAI clients ignore the events with an unknown type.
+	quotaExceededEventType = "quota_exceeded"
+	// charsPerOutputToken is the rough number of characters per token used to estimate the output tokens of
+	// a stream before the backend reports the usage, which usually only happens at the end of the stream.
+	charsPerOutputToken = 4
+)
+
+var (
+	sseEventSeparator = []byte("\n\n")
+	sseDataPrefix     = []byte("data:")
+	sseDone           = []byte("[DONE]")
+)
+
+// streamQuota terminates a streaming chat completion once its cost reaches the remaining quota, as enabled by
+// [filterapi.LLMRequestCost.StreamCutOff].
+//
+// This is created per response since the remaining quota is read from the response headers.
+type streamQuota struct {
+	cost      *filterapi.RuntimeRequestCost
+	remaining uint64
+	// requestModel is the model of the request the cost is evaluated with.
+	requestModel string
+	// pending holds the incomplete event at the end of the previous chunk.
+	pending []byte
+	// outputChars is the number of characters generated in the content, reasoning and tool call deltas so far.
+	outputChars int
+	// id, model and created are taken from the chunks so far to build the final chunk.
+	id      string
+	model   string
+	created openai.JSONUNIXTime
+	// choices are the indexes of the choices seen so far.
+	choices []int64
+	// finished is true once the backend finished generating, after which the stream is no longer cut off.
+	finished bool
+	// cutOff is true once the stream has been terminated.
+	cutOff bool
+}
+
+// newStreamQuota returns a streamQuota if one of the request costs matching the backend, route and model has
+// the stream cut-off enabled and the rate limit filter reported the remaining quota. Otherwise, this returns nil.
+func newStreamQuota(requestCosts []filterapi.RuntimeRequestCost, requestModel string, responseHeaders map[string]string, backendName, routeName string) *streamQuota {
+	remaining, err := strconv.ParseUint(strings.TrimSpace(responseHeaders[rateLimitRemainingHeader]), 10, 64)
+	if err != nil {
+		return nil
+	}
+	shortBackend := backendName
+	if parts := strings.SplitN(backendName, "/", 3); len(parts) >= 2 {
+		shortBackend = parts[0] + "/" + parts[1]
+	}
+	for i := range requestCosts {
+		rc := &requestCosts[i]
+		if !rc.StreamCutOff || rc.RouteName != routeName {
+			continue
+		}
+		if rc.Backend != "" && rc.Backend != shortBackend {
+			continue
+		}
+		if rc.Model != "" && rc.Model != requestModel {
+			continue
+		}
+		return &streamQuota{cost: rc, remaining: remaining, requestModel: requestModel}
+	}
+	return nil
+}
+
+// processChunk inspects the events in the chunk sent to the client and checks the cost of the stream so far
+// against the remaining quota. When the quota is exhausted, this returns the chunk truncated right after the
+// exhausting event followed by the final chunk, the quota exceeded event and the terminating [DONE] event.
+// Otherwise, this returns nil, meaning the chunk is sent as is.
+func (q *streamQuota) processChunk(chunk []byte, costs *metrics.TokenUsage, backendName, routeName string) ([]byte, error) {
+	buf := append(q.pending, chunk...)
+	start := 0
+	for {
+		i := bytes.Index(buf[start:], sseEventSeparator)
+		if i < 0 {
+			break
+		}
+		end := start + i + len(sseEventSeparator)
+		q.observe(buf[start : start+i])
+		start = end
+		if q.finished {
+			continue
+		}
+
+		exhausted, err := q.exhausted(costs, backendName, routeName)
+		if err != nil {
+			return nil, err
+		}
+		if exhausted {
+			q.cutOff = true
+			// The events before the exhausting one in the pending bytes were already sent with the previous chunk.
+			out := slices.Clone(chunk[:end-len(q.pending)])
+			q.pending = nil
+			return append(out, q.finalEvents()...), nil
+		}
+	}
+	q.pending = slices.Clone(buf[start:])
+	return nil, nil
+}
+
+// observe records the output and the identity of the chat completion chunk in the event.
+// Events that are not chat completion chunks are ignored.
+func (q *streamQuota) observe(event []byte) {
+	for line := range bytes.SplitSeq(event, []byte("\n")) {
+		data, ok := bytes.CutPrefix(line, sseDataPrefix)
+		if !ok {
+			continue
+		}
+		data = bytes.TrimSpace(data)
+		if bytes.Equal(data, sseDone) {
+			continue
+		}
+		var chunk openai.ChatCompletionResponseChunk
+		if err := json.Unmarshal(data, &chunk); err != nil {
+			continue
+		}
+		if chunk.ID != "" {
+			q.id = chunk.ID
+		}
+		if chunk.Model != "" {
+			q.model = chunk.Model
+		}
+		if !time.Time(chunk.Created).IsZero() {
+			q.created = chunk.Created
+		}
+		for j := range chunk.Choices {
+			choice := &chunk.Choices[j]
+			if !slices.Contains(q.choices, choice.Index) {
+				q.choices = append(q.choices, choice.Index)
+			}
+			if choice.FinishReason != "" {
+				q.finished = true
+			}
+			if choice.Delta == nil {
+				continue
+			}
+			if choice.Delta.Content != nil {
+				q.outputChars += len(*choice.Delta.Content)
+			}
+			if choice.Delta.ReasoningContent != nil {
+				q.outputChars += len(choice.Delta.ReasoningContent.Text)
+			}
+			for k := range choice.Delta.ToolCalls {
+				q.outputChars += len(choice.Delta.ToolCalls[k].Function.Arguments)
+			}
+		}
+	}
+}
+
+// exhausted returns true if the cost of the stream so far reaches the remaining quota. The output tokens
+// are estimated from the observed output unless the backend already reported more.
+func (q *streamQuota) exhausted(costs *metrics.TokenUsage, backendName, routeName string) (bool, error) {
+	usage := *costs
+	estimated := uint32((q.outputChars + charsPerOutputToken - 1) / charsPerOutputToken) // #nosec G115
+	if out, _ := usage.OutputTokens(); estimated > out {
+		in, _ := usage.InputTokens()
+		usage.SetOutputTokens(estimated)
+		usage.SetTotalTokens(in + estimated)
+	}
+	cost, err := evalRuntimeRequestCost(q.cost, &usage, q.requestModel, backendName, routeName)
+	if err != nil {
+		return false, fmt.Errorf("failed to evaluate the stream cost: %w", err)
+	}
+	return cost >= q.remaining, nil
+}
+
+// finalEvents returns the events terminating the stream: a chunk finishing all the choices with the "length"
+// finish reason, the quota exceeded event and the [DONE] event.
+func (q *streamQuota) finalEvents() []byte {
+	return streamTerminationEvents(q.id, q.model, q.created, q.choices, quotaExceededEventType,
+		"the stream was terminated because the quota has been exhausted")
+}
+
+// streamTerminationEvents returns the events terminating a streaming chat completion cut off by the gateway: a chunk
+// finishing the choices with the "length" finish reason, the event of the given type explaining why, and the [DONE]
+// event. The chunk takes the id, model and creation time of the chunks streamed so far.
+func streamTerminationEvents(id, model string, created openai.JSONUNIXTime, choices []int64, eventType, message string) []byte {
+	choices = slices.Clone(choices)
+	if len(choices) == 0 {
+		choices = []int64{0}
+	}
+	slices.Sort(choices)
+	final := openai.ChatCompletionResponseChunk{
+		ID:      id,
+		Model:   model,
+		Created: created,
+		Object:  "chat.completion.chunk",
+	}
+	for _, index := range choices {
+		final.Choices = append(final.Choices, openai.ChatCompletionResponseChunkChoice{
+			Index:        index,
+			Delta:        &openai.ChatCompletionResponseChunkChoiceDelta{},
+			FinishReason: openai.ChatCompletionChoicesFinishReasonLength,
+		})
+	}
+	finalJSON, _ := json.Marshal(final)
+	eventJSON, _ := json.Marshal(openai.Error{
+		Type:  "error",
+		Error: openai.ErrorType{Type: eventType, Message: message},
+	})
+
+	var out bytes.Buffer
+	out.WriteString("data: ")
+	out.Write(finalJSON)
+	out.WriteString("\n\nevent: " + eventType + "\ndata: ")
+	out.Write(eventJSON)
+	out.WriteString("\n\ndata: [DONE]\n\n")
+	return out.Bytes()
+}
+
+// capCost caps the cost stored in the metadata namespace at the remaining quota, so that a stream cut off at the
+// quota doesn't take it below zero even though the backend kept generating after the cut-off.
+func (q *streamQuota) capCost(metadata *structpb.Struct, namespace string) {
+	fields := metadata.GetFields()[namespace].GetStructValue().GetFields()
+	if v, ok := fields[q.cost.MetadataKey]; ok && v.GetNumberValue() > float64(q.remaining) {
+		fields[q.cost.MetadataKey] = structpb.NewNumberValue(float64(q.remaining))
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/envoyproxy/ai-gateway/internal/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+	"github.com/envoyproxy/ai-gateway/internal/metrics"
+)
+
+func TestNewStreamQuota(t *testing.T) {
+	costs := []filterapi.RuntimeRequestCost{
+		{LLMRequestCost: &filterapi.LLMRequestCost{MetadataKey: "other", RouteName: "ns/route", Type: filterapi.LLMRequestCostTypeOutputToken}},
+		{LLMRequestCost: &filterapi.LLMRequestCost{
+			MetadataKey: "quota_cost", RouteName: "ns/route", Backend: "ns/backend", Model: "gpt-4o",
+			Type: filterapi.LLMRequestCostTypeOutputToken, StreamCutOff: true,
+		}},
+	}
+	responseHeaders := map[string]string{rateLimitRemainingHeader: "42"}
+
+	q := newStreamQuota(costs, "gpt-4o", responseHeaders, "ns/backend/route/ns/route/rule/0/ref/0", "ns/route")
+	require.NotNil(t, q)
+	require.Equal(t, &costs[1], q.cost)
+	require.Equal(t, uint64(42), q.remaining)
+
+	for _, tc := range []struct {
+		name            string
+		requestModel    string
+		responseHeaders map[string]string
+		backendName     string
+		routeName       string
+	}{
+		{name: "no remaining header", requestModel: "gpt-4o", responseHeaders: map[string]string{}, backendName: "ns/backend", routeName: "ns/route"},
+		{name: "invalid remaining header", requestModel: "gpt-4o", responseHeaders: map[string]string{rateLimitRemainingHeader: "-1"}, backendName: "ns/backend", routeName: "ns/route"},
+		{name: "other backend", requestModel: "gpt-4o", responseHeaders: responseHeaders, backendName: "ns/other", routeName: "ns/route"},
+		{name: "other route", requestModel: "gpt-4o", responseHeaders: responseHeaders, backendName: "ns/backend", routeName: "ns/other"},
+		{name: "other model", requestModel: "gpt-4o-mini", responseHeaders: responseHeaders, backendName: "ns/backend", routeName: "ns/route"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Nil(t, newStreamQuota(costs, tc.requestModel, tc.responseHeaders, tc.backendName, tc.routeName))
+		})
+	}
+}
+
+func TestStreamQuota_processChunk(t *testing.T) {
+	newQuota := func(remaining uint64) *streamQuota {
+		return &streamQuota{
+			cost:      &filterapi.RuntimeRequestCost{LLMRequestCost: &filterapi.LLMRequestCost{MetadataKey: "quota_cost", Type: filterapi.LLMRequestCostTypeOutputToken}},
+			remaining: remaining,
+		}
+	}
+	const (
+		role  = `data: {"id":"chatcmpl-1","object":"chat.completion.chunk","created":1731000000,"model":"gpt-4o","choices":[{"index":0,"delta":{"role":"assistant"}}]}` + "\n\n"
+		hello = `data: {"id":"chatcmpl-1","object":"chat.completion.chunk","created":1731000000,"model":"gpt-4o","choices":[{"index":0,"delta":{"content":"Hello, "}}]}` + "\n\n"
+		world = `data: {"id":"chatcmpl-1","object":"chat.completion.chunk","created":1731000000,"model":"gpt-4o","choices":[{"index":0,"delta":{"content":"wonderful world!"}}]}` + "\n\n"
+		stop  = `data: {"id":"chatcmpl-1","object":"chat.completion.chunk","created":1731000000,"model":"gpt-4o","choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}` + "\n\n"
+		final = `data: {"id":"chatcmpl-1","choices":[{"index":0,"delta":{},"finish_reason":"length"}],"created":1731000000,"model":"gpt-4o","object":"chat.completion.chunk"}` + "\n\n" +
+			"event: quota_exceeded\n" +
+			`data: {"type":"error","error":{"type":"quota_exceeded","message":"the stream was terminated because the quota has been exhausted"}}` + "\n\n" +
+			"data: [DONE]\n\n"
+	)
+
+	t.Run("under quota", func(t *testing.T) {
+		q := newQuota(100)
+		for _, chunk := range []string{role, hello, world, stop, "data: [DONE]\n\n"} {
+			out, err := q.processChunk([]byte(chunk), &metrics.TokenUsage{}, "", "")
+			require.NoError(t, err)
+			require.Nil(t, out)
+		}
+		require.False(t, q.cutOff)
+	})
+
+	t.Run("cut off", func(t *testing.T) {
+		q := newQuota(3)
+		out, err := q.processChunk([]byte(role+hello), &metrics.TokenUsage{}, "", "")
+		require.NoError(t, err)
+		require.Nil(t, out)
+		// "Hello, wonderful world!" is estimated as 6 tokens, which exhausts the quota.
+		out, err = q.processChunk([]byte(world+stop), &metrics.TokenUsage{}, "", "")
+		require.NoError(t, err)
+		require.Equal(t, world+final, string(out))
+		require.True(t, q.cutOff)
+	})
+
+	t.Run("cut off with event split across chunks", func(t *testing.T) {
+		q := newQuota(3)
+		split := len(world) / 2
+		out, err := q.processChunk([]byte(role+hello+world[:split]), &metrics.TokenUsage{}, "", "")
+		require.NoError(t, err)
+		require.Nil(t, out)
+		out, err = q.processChunk([]byte(world[split:]+stop), &metrics.TokenUsage{}, "", "")
+		require.NoError(t, err)
+		require.Equal(t, world[split:]+final, string(out))
+	})
+
+	t.Run("reported usage exceeds estimate", func(t *testing.T) {
+		q := newQuota(10)
+		var usage metrics.TokenUsage
+		usage.SetOutputTokens(10)
+		out, err := q.processChunk([]byte(role), &usage, "", "")
+		require.NoError(t, err)
+		require.Contains(t, string(out), `"finish_reason":"length"`)
+	})
+
+	t.Run("finished stream is not cut off", func(t *testing.T) {
+		q := newQuota(1)
+		var usage metrics.TokenUsage
+		out, err := q.processChunk([]byte(stop), &usage, "", "")
+		require.NoError(t, err)
+		require.Nil(t, out)
+		usage.SetOutputTokens(100)
+		out, err = q.processChunk([]byte(`data: {"id":"chatcmpl-1","choices":[],"usage":{"completion_tokens":100}}`+"\n\ndata: [DONE]\n\n"), &usage, "", "")
+		require.NoError(t, err)
+		require.Nil(t, out)
+	})
+}
+
+func TestStreamQuota_capCost(t *testing.T) {
+	q := &streamQuota{
+		cost:      &filterapi.RuntimeRequestCost{LLMRequestCost: &filterapi.LLMRequestCost{MetadataKey: "quota_cost"}},
+		remaining: 10,
+	}
+	metadata := &structpb.Struct{Fields: map[string]*structpb.Value{
+		internalapi.AIGatewayFilterMetadataNamespace: structpb.NewStructValue(&structpb.Struct{Fields: map[string]*structpb.Value{
+			"quota_cost": structpb.NewNumberValue(25),
+			"other":      structpb.NewNumberValue(25),
+		}}),
+	}}
+	q.capCost(metadata, internalapi.AIGatewayFilterMetadataNamespace)
+	inner := metadata.Fields[internalapi.AIGatewayFilterMetadataNamespace].GetStructValue()
+	require.Equal(t, 10.0, inner.Fields["quota_cost"].GetNumberValue())
+	require.Equal(t, 25.0, inner.Fields["other"].GetNumberValue())
+}
@@ -0,0 +1,73 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"time"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	// streamStartMetadataKey is the dynamic metadata key of the Unix time in milliseconds at which the headers of
+	// the streaming response were received from the backend.
+	streamStartMetadataKey = "stream_start_unix_ms"
+	// streamFirstChunkMetadataKey is the dynamic metadata key of the Unix time in milliseconds at which the first
+	// chunk of the streaming response, hence its first tokens, was received from the backend.
+	streamFirstChunkMetadataKey = "stream_first_chunk_unix_ms"
+	// streamLastChunkMetadataKey is the dynamic metadata key of the Unix time in milliseconds at which the last
+	// chunk of the streaming response was received from the backend.
+	streamLastChunkMetadataKey = "stream_last_chunk_unix_ms"
+	// streamChunkCountMetadataKey is the dynamic metadata key of the number of chunks of the streaming response.
+	streamChunkCountMetadataKey = "stream_chunk_count"
+)
+
+// streamTiming records when the chunks of a streaming response are received from the backend, so that the stalls
+// of the stream can be diagnosed from the access logs.
+type streamTiming struct {
+	now func() time.Time
+	// start is the time the response headers were received, and firstChunk and lastChunk the times the first and
+	// the last non-empty chunks of the body were received. The chunk times are zero until one is received.
+	start, firstChunk, lastChunk time.Time
+	// chunks is the number of non-empty chunks received.
+	chunks int
+}
+
+func newStreamTiming(now func() time.Time) *streamTiming {
+	return &streamTiming{now: now, start: now()}
+}
+
+// observe records the chunk of the response body received from the backend. The empty chunks, such as the one only
+// ending the stream, are ignored.
+func (s *streamTiming) observe(chunk []byte) {
+	if len(chunk) == 0 {
+		return
+	}
+	now := s.now()
+	if s.chunks == 0 {
+		s.firstChunk = now
+	}
+	s.lastChunk = now
+	s.chunks++
+}
+
+// buildDynamicMetadata builds the dynamic metadata of the timing of the stream in the namespace. The chunk times are
+// omitted if no chunk was received.
+func (s *streamTiming) buildDynamicMetadata(namespace string) *structpb.Struct {
+	fields := map[string]*structpb.Value{
+		streamStartMetadataKey:      structpb.NewNumberValue(float64(s.start.UnixMilli())),
+		streamChunkCountMetadataKey: structpb.NewNumberValue(float64(s.chunks)),
+	}
+	if s.chunks > 0 {
+		fields[streamFirstChunkMetadataKey] = structpb.NewNumberValue(float64(s.firstChunk.UnixMilli()))
+		fields[streamLastChunkMetadataKey] = structpb.NewNumberValue(float64(s.lastChunk.UnixMilli()))
+	}
+	return &structpb.Struct{
+		Fields: map[string]*structpb.Value{
+			namespace: structpb.NewStructValue(&structpb.Struct{Fields: fields}),
+		},
+	}
+}
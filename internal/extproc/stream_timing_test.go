@@ -0,0 +1,41 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+)
+
+func TestStreamTiming(t *testing.T) {
+	now := time.UnixMilli(1000)
+	s := newStreamTiming(func() time.Time { return now })
+	requireFields := func(expected map[string]any) {
+		exp, err := structpb.NewStruct(expected)
+		require.NoError(t, err)
+		require.Equal(t, exp.AsMap(), s.buildDynamicMetadata(internalapi.AIGatewayFilterMetadataNamespace).Fields[internalapi.AIGatewayFilterMetadataNamespace].GetStructValue().AsMap())
+	}
+	requireFields(map[string]any{streamStartMetadataKey: 1000, streamChunkCountMetadataKey: 0})
+
+	now = now.Add(250 * time.Millisecond)
+	s.observe([]byte("data: a\n\n"))
+	now = now.Add(time.Second)
+	s.observe([]byte("data: b\n\n"))
+	now = now.Add(time.Second)
+	// The empty chunk ending the stream is ignored.
+	s.observe(nil)
+	requireFields(map[string]any{
+		streamStartMetadataKey:      1000,
+		streamFirstChunkMetadataKey: 1250,
+		streamLastChunkMetadataKey:  2250,
+		streamChunkCountMetadataKey: 2,
+	})
+}
@@ -0,0 +1,76 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"bytes"
+	"context"
+	"slices"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/json"
+)
+
+const (
+	// streamTruncatedErrorType is the error type of the event sent to the client when the upstream stream ended
+	// before its terminal event, as enabled by [filterapi.Config.TruncatedStreamErrorEvent].
+	streamTruncatedErrorType = "stream_truncated"
+	// streamTruncatedMessage is the message of the stream truncated error event and span status.
+	streamTruncatedMessage = "the upstream stream ended before the final event"
+)
+
+// streamTerminalMarkers are the markers of the events terminating a streaming chat completion: either the
+// [DONE] event or a chunk with a finish reason.
+var streamTerminalMarkers = [][]byte{sseDone, []byte(`"finish_reason":"`), []byte(`"finish_reason": "`)}
+
+// streamCloseHandler is implemented by the router processors that need to be notified when the ext_proc stream
+// is closed, which is the only signal of a downstream or upstream connection reset in the middle of the response.
+type streamCloseHandler interface {
+	onStreamClosed(ctx context.Context)
+}
+
+// streamTruncation detects the streaming chat completions that end without a terminal event, e.g. because the
+// upstream connection was reset in the middle of the response.
+type streamTruncation struct {
+	// tail holds the end of the previous chunk so that the markers split across chunks are found.
+	tail []byte
+	// terminated is true once a terminal event has been seen.
+	terminated bool
+}
+
+// observe inspects the chunk sent to the client for a terminal event.
+func (s *streamTruncation) observe(chunk []byte) {
+	if s.terminated || len(chunk) == 0 {
+		return
+	}
+	buf := append(s.tail, chunk...)
+	for _, marker := range streamTerminalMarkers {
+		if bytes.Contains(buf, marker) {
+			s.terminated = true
+			s.tail = nil
+			return
+		}
+	}
+	const maxTail = len(`"finish_reason": "`) - 1
+	s.tail = slices.Clone(buf[max(0, len(buf)-maxTail):])
+}
+
+// streamTruncatedEvent returns the error event appended to a truncated stream. OpenAI clients raise the events
+// with an error field as an API error.
+func streamTruncatedEvent() []byte {
+	eventJSON, _ := json.Marshal(openai.Error{
+		Type: "error",
+		Error: openai.ErrorType{
+			Type:    streamTruncatedErrorType,
+			Message: streamTruncatedMessage,
+		},
+	})
+	var out bytes.Buffer
+	out.WriteString("data: ")
+	out.Write(eventJSON)
+	out.WriteString("\n\n")
+	return out.Bytes()
+}
@@ -0,0 +1,36 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamTruncation_observe(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		chunks []string
+		exp    bool
+	}{
+		{name: "no chunk", exp: false},
+		{name: "content only", chunks: []string{`data: {"choices":[{"delta":{"content":"hi"},"finish_reason":null}]}` + "\n\n"}, exp: false},
+		{name: "done", chunks: []string{"data: {}\n\n", "data: [DONE]\n\n"}, exp: true},
+		{name: "finish reason", chunks: []string{`data: {"choices":[{"delta":{},"finish_reason":"stop"}]}` + "\n\n"}, exp: true},
+		{name: "finish reason with space", chunks: []string{`data: {"choices":[{"finish_reason": "length"}]}` + "\n\n"}, exp: true},
+		{name: "done split across chunks", chunks: []string{"data: [DO", "NE]\n\n"}, exp: true},
+		{name: "finish reason split across chunks", chunks: []string{`data: {"choices":[{"finish_re`, `ason":"stop"}]}`, "\n\n"}, exp: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := &streamTruncation{}
+			for _, c := range tc.chunks {
+				s.observe([]byte(c))
+			}
+			require.Equal(t, tc.exp, s.terminated)
+		})
+	}
+}
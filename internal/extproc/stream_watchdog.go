@@ -0,0 +1,157 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"slices"
+	"time"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/tidwall/gjson"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/metrics"
+)
+
+// streamStalledEventType is the server-sent event type emitted after the final chunk of a stream terminated because
+// no token arrived within the stream stall timeout. OpenAI clients ignore the events with an unknown type.
+const streamStalledEventType = "stream_stalled"
+
+// streamWatchdog terminates a streaming chat completion once no token has arrived within the stream stall timeout
+// of the route rule. Unlike the idle timeout of Envoy, the events without any token, e.g. the keep-alive comments and
+// the empty deltas, don't reset it.
+type streamWatchdog struct {
+	timeout time.Duration
+	now     func() time.Time
+	// lastToken is the time the last token arrived, or the time the response headers arrived until the first one.
+	lastToken time.Time
+	// pending holds the incomplete event at the end of the previous chunk.
+	pending []byte
+	// id, model and created are taken from the chunks so far to build the final chunk.
+	id      string
+	model   string
+	created openai.JSONUNIXTime
+	// choices are the indexes of the choices seen so far.
+	choices []int64
+	// finished is true once the backend finished generating, after which the stream is no longer cut off.
+	finished bool
+	// cutOff is true once the stream has been terminated.
+	cutOff bool
+}
+
+// newStreamWatchdog returns a streamWatchdog with the timeout starting now. This returns nil if the timeout is not
+// positive.
+func newStreamWatchdog(timeout time.Duration, now func() time.Time) *streamWatchdog {
+	if timeout <= 0 {
+		return nil
+	}
+	return &streamWatchdog{timeout: timeout, now: now, lastToken: now()}
+}
+
+// stalled returns true if the stream was terminated by the watchdog, or if no token has arrived within the timeout,
+// e.g. when Envoy reset the silent stream at its per-try idle timeout.
+func (w *streamWatchdog) stalled() bool {
+	return w.cutOff || (!w.finished && w.now().Sub(w.lastToken) >= w.timeout)
+}
+
+// processChunk inspects the events in the chunk sent to the client. When no token arrived within the timeout, this
+// returns the chunk truncated after its last complete event followed by the final chunk, the stream stalled event
+// and the terminating [DONE] event. Otherwise, this returns nil, meaning the chunk is sent as is.
+func (w *streamWatchdog) processChunk(chunk []byte) []byte {
+	buf := append(w.pending, chunk...)
+	start := 0
+	for {
+		i := bytes.Index(buf[start:], sseEventSeparator)
+		if i < 0 {
+			break
+		}
+		w.observe(buf[start : start+i])
+		start += i + len(sseEventSeparator)
+	}
+	if w.finished || w.now().Sub(w.lastToken) < w.timeout {
+		w.pending = slices.Clone(buf[start:])
+		return nil
+	}
+	w.cutOff = true
+	// The incomplete event at the end is dropped, and the ones in the pending bytes were already sent.
+	var out []byte
+	if sent := start - len(w.pending); sent > 0 {
+		out = slices.Clone(chunk[:sent])
+	}
+	w.pending = nil
+	return append(out, streamTerminationEvents(w.id, w.model, w.created, w.choices, streamStalledEventType,
+		"the stream was terminated because no token arrived within the stream stall timeout of the route")...)
+}
+
+// observe records the tokens and the identity of the chat completion chunk in the event. Events that are not chat
+// completion chunks are ignored.
+func (w *streamWatchdog) observe(event []byte) {
+	for line := range bytes.SplitSeq(event, []byte("\n")) {
+		data, ok := bytes.CutPrefix(line, sseDataPrefix)
+		if !ok {
+			continue
+		}
+		data = bytes.TrimSpace(data)
+		if bytes.Equal(data, sseDone) {
+			w.finished = true
+			continue
+		}
+		if !gjson.ValidBytes(data) {
+			continue
+		}
+		chunk := gjson.ParseBytes(data)
+		if id := chunk.Get("id").String(); id != "" {
+			w.id = id
+		}
+		if model := chunk.Get("model").String(); model != "" {
+			w.model = model
+		}
+		if created := chunk.Get("created").Int(); created != 0 {
+			w.created = openai.JSONUNIXTime(time.Unix(created, 0))
+		}
+		for _, choice := range chunk.Get("choices").Array() {
+			if index := choice.Get("index").Int(); !slices.Contains(w.choices, index) {
+				w.choices = append(w.choices, index)
+			}
+			if choice.Get("finish_reason").String() != "" {
+				w.finished = true
+			}
+			delta := choice.Get("delta")
+			reasoning := delta.Get("reasoning_content")
+			if reasoning.IsObject() {
+				reasoning = reasoning.Get("text")
+			}
+			if delta.Get("content").String() != "" || delta.Get("refusal").String() != "" || reasoning.String() != "" ||
+				len(delta.Get("tool_calls").Array()) > 0 {
+				w.lastToken = w.now()
+			}
+		}
+	}
+}
+
+// enforceStreamWatchdog terminates the stream once no token arrived within the stream stall timeout. The chunk is
+// either the one in the given bodyMutation or the decoded upstream body if the translator didn't mutate it. Once the
+// stream is terminated, the rest of the upstream response is dropped.
+func (u *upstreamProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) enforceStreamWatchdog(ctx context.Context, bodyMutation *extprocv3.BodyMutation, decoded []byte) *extprocv3.BodyMutation {
+	if u.watchdog.cutOff {
+		return &extprocv3.BodyMutation{Mutation: &extprocv3.BodyMutation_ClearBody{ClearBody: true}}
+	}
+	chunk := decoded
+	if b := bodyMutation.GetBody(); b != nil {
+		chunk = b
+	}
+	truncated := u.watchdog.processChunk(chunk)
+	if truncated == nil {
+		return bodyMutation
+	}
+	u.metrics.RecordResponseTruncated(ctx, metrics.ResponseTruncationReasonStalled, u.requestHeaders)
+	u.logger.Warn("terminated the stream as no token arrived within the stream stall timeout",
+		slog.String("backend", u.backendName), slog.Duration("stream_stall_timeout", u.watchdog.timeout))
+	return &extprocv3.BodyMutation{Mutation: &extprocv3.BodyMutation_Body{Body: truncated}}
+}
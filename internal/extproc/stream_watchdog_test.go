@@ -0,0 +1,77 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewStreamWatchdog(t *testing.T) {
+	require.Nil(t, newStreamWatchdog(0, time.Now))
+	now := time.Unix(1731000000, 0)
+	w := newStreamWatchdog(time.Second, func() time.Time { return now })
+	require.Equal(t, now, w.lastToken)
+	require.False(t, w.stalled())
+	now = now.Add(time.Second)
+	require.True(t, w.stalled())
+}
+
+func TestStreamWatchdog_processChunk(t *testing.T) {
+	const (
+		role    = `data: {"id":"chatcmpl-1","object":"chat.completion.chunk","created":1731000000,"model":"gpt-4o","choices":[{"index":0,"delta":{"role":"assistant"}}]}` + "\n\n"
+		content = `data: {"id":"chatcmpl-1","object":"chat.completion.chunk","created":1731000000,"model":"gpt-4o","choices":[{"index":0,"delta":{"content":"Hello"}}]}` + "\n\n"
+		empty   = `data: {"id":"chatcmpl-1","object":"chat.completion.chunk","created":1731000000,"model":"gpt-4o","choices":[{"index":0,"delta":{"content":""}}]}` + "\n\n"
+		ping    = ": keep-alive\n\n"
+		stop    = `data: {"id":"chatcmpl-1","object":"chat.completion.chunk","created":1731000000,"model":"gpt-4o","choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}` + "\n\n"
+		final   = `data: {"id":"chatcmpl-1","choices":[{"index":0,"delta":{},"finish_reason":"length"}],"created":1731000000,"model":"gpt-4o","object":"chat.completion.chunk"}` + "\n\n" +
+			"event: stream_stalled\n" +
+			`data: {"type":"error","error":{"type":"stream_stalled","message":"the stream was terminated because no token arrived within the stream stall timeout of the route"}}` + "\n\n" +
+			"data: [DONE]\n\n"
+	)
+	newWatchdog := func() (*streamWatchdog, *time.Time) {
+		now := time.Unix(1731000000, 0)
+		return newStreamWatchdog(10*time.Second, func() time.Time { return now }), &now
+	}
+
+	t.Run("tokens reset the timeout", func(t *testing.T) {
+		w, now := newWatchdog()
+		for _, chunk := range []string{role, content, content, stop, "data: [DONE]\n\n"} {
+			*now = now.Add(9 * time.Second)
+			require.Nil(t, w.processChunk([]byte(chunk)))
+		}
+		require.False(t, w.cutOff)
+	})
+
+	t.Run("stalled", func(t *testing.T) {
+		w, now := newWatchdog()
+		require.Nil(t, w.processChunk([]byte(role+content)))
+		*now = now.Add(5 * time.Second)
+		require.Nil(t, w.processChunk([]byte(ping+empty)))
+		// The pings and the empty deltas don't reset the timeout, and the incomplete event is dropped.
+		*now = now.Add(5 * time.Second)
+		require.Equal(t, ping+final, string(w.processChunk([]byte(ping+`data: {"id":"chatcmpl-1"`))))
+		require.True(t, w.cutOff)
+		require.True(t, w.stalled())
+	})
+
+	t.Run("stalled within a pending event", func(t *testing.T) {
+		w, now := newWatchdog()
+		require.Nil(t, w.processChunk([]byte(role+empty[:20])))
+		*now = now.Add(10 * time.Second)
+		require.Equal(t, empty[20:]+final, string(w.processChunk([]byte(empty[20:]))))
+	})
+
+	t.Run("finished stream is not cut off", func(t *testing.T) {
+		w, now := newWatchdog()
+		require.Nil(t, w.processChunk([]byte(role+stop)))
+		*now = now.Add(time.Minute)
+		require.Nil(t, w.processChunk([]byte(`data: {"id":"chatcmpl-1","choices":[],"usage":{"completion_tokens":1}}`+"\n\ndata: [DONE]\n\n")))
+		require.False(t, w.stalled())
+	})
+}
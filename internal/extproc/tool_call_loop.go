@@ -0,0 +1,169 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"bytes"
+	stdjson "encoding/json" // nolint: depguard
+	"fmt"
+	"log/slog"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+	"github.com/envoyproxy/ai-gateway/internal/json"
+)
+
+// toolCallLoopErrorCode is the code of the OpenAI error rejecting the requests of the conversations stuck in a tool
+// call loop.
+const toolCallLoopErrorCode = "tool_call_loop_detected"
+
+// toolCall is a function call made by the model in a conversation.
+type toolCall struct {
+	name string
+	// arguments are the JSON arguments of the call in a canonical form, so that the calls only differing in the
+	// whitespaces or the order of the keys are identical.
+	arguments string
+}
+
+// detectToolCallLoop returns the most recent function call of the conversation of the chat completion or responses
+// request and the number of times it was made with the same arguments in the conversation, along with the name of
+// the parameter holding the conversation. The repetitions are zero if the conversation has no function call or
+// continued with a user message since the most recent one.
+func detectToolCallLoop(req any, body []byte) (call toolCall, repetitions int, param string) {
+	switch req.(type) {
+	case *openai.ChatCompletionRequest:
+		param = "messages"
+	case *openai.ResponseRequest:
+		param = "input"
+	default:
+		return
+	}
+
+	var calls []toolCall
+	pending := false
+	for _, item := range gjson.GetBytes(body, param).Array() {
+		if item.Get("role").String() == openai.ChatMessageRoleUser {
+			pending = false
+			continue
+		}
+		// The calls are in the "tool_calls" of the assistant messages of the chat completions, and are the
+		// "function_call" items of the input of the responses.
+		for _, c := range item.Get("tool_calls").Array() {
+			if name := c.Get("function.name").String(); name != "" {
+				calls = append(calls, toolCall{name: name, arguments: canonicalArguments(c.Get("function.arguments").String())})
+				pending = true
+			}
+		}
+		if item.Get("type").String() == "function_call" {
+			calls = append(calls, toolCall{name: item.Get("name").String(), arguments: canonicalArguments(item.Get("arguments").String())})
+			pending = true
+		}
+	}
+	if !pending {
+		return toolCall{}, 0, param
+	}
+	call = calls[len(calls)-1]
+	for _, c := range calls {
+		if c == call {
+			repetitions++
+		}
+	}
+	return call, repetitions, param
+}
+
+// canonicalArguments returns the JSON arguments re-encoded with the keys of the objects sorted and without the
+// insignificant whitespaces. The arguments that aren't valid JSON are returned as is.
+// This uses encoding/json since it sorts the keys of the maps, unlike internal/json.
+func canonicalArguments(arguments string) string {
+	decoder := stdjson.NewDecoder(bytes.NewReader([]byte(arguments)))
+	decoder.UseNumber()
+	var v any
+	if err := decoder.Decode(&v); err != nil {
+		return arguments
+	}
+	out, err := stdjson.Marshal(v)
+	if err != nil {
+		return arguments
+	}
+	return string(out)
+}
+
+// checkToolCallLoop applies the ToolCallLoopGuard of the route, if any, to the conversation of the request. It
+// returns the response rejecting the request with the Reject action, or nil if the request can proceed. With the
+// Hint action, the hint is appended to the conversation, and like the parameter overrides, the rewritten body
+// replaces the original one of the router processor so that it is reused on the retries.
+func (u *upstreamProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) checkToolCallLoop() (*extprocv3.ProcessingResponse, error) {
+	rp := u.parent
+	if rp.config == nil {
+		return nil, nil
+	}
+	guard := rp.config.ToolCallLoopGuards[u.routeName]
+	if guard == nil {
+		return nil, nil
+	}
+	call, repetitions, param := detectToolCallLoop(rp.originalRequestBody, rp.originalRequestBodyRaw)
+	if repetitions < guard.MaxRepetitions {
+		return nil, nil
+	}
+
+	if guard.Action == filterapi.ToolCallLoopActionReject {
+		u.logger.Info("rejecting request of a conversation stuck in a tool call loop",
+			slog.String("function", call.name), slog.Int("repetitions", repetitions))
+		return invalidRequestErrorResponse(toolCallLoopErrorCode,
+			fmt.Sprintf("The function %s was called %d times with the same arguments in this conversation, reaching "+
+				"the limit of %d repetitions.", call.name, repetitions, guard.MaxRepetitions), param)
+	}
+	u.logger.Info("hinting the model of a conversation stuck in a tool call loop",
+		slog.String("function", call.name), slog.Int("repetitions", repetitions))
+	body, err := appendToolCallLoopHint(rp.originalRequestBodyRaw, param, call.name, repetitions)
+	if err != nil {
+		return nil, err
+	}
+	_, req, _, _, err := rp.eh.ParseBody(body, false)
+	if err != nil {
+		return nil, err
+	}
+	rp.originalRequestBodyRaw, rp.originalRequestBody = body, req
+	rp.forceBodyMutation = true
+	rp.toolCallLoop = call.name
+	return nil, nil
+}
+
+// appendToolCallLoopHint returns the body with a system message telling the model that it is repeating the call of
+// the function appended to the conversation of the param.
+func appendToolCallLoopHint(body []byte, param, function string, repetitions int) ([]byte, error) {
+	hint, err := json.Marshal(map[string]string{
+		"role": openai.ChatMessageRoleSystem,
+		"content": fmt.Sprintf("You have called the function %s %d times with the same arguments in this conversation. "+
+			"Do not call it again with these arguments: use the results you already have, try a different approach, "+
+			"or reply to the user.", function, repetitions),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal the tool call loop hint: %w", err)
+	}
+	out, err := sjson.SetRawBytes(body, param+".-1", hint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to append the tool call loop hint: %w", err)
+	}
+	return out, nil
+}
+
+// setToolCallLoopHeader sets the ToolCallLoopHeader on the response if the model was hinted about a tool call loop.
+func setToolCallLoopHeader(headerMutation *extprocv3.HeaderMutation, function string) {
+	if function == "" {
+		return
+	}
+	headerMutation.SetHeaders = append(headerMutation.SetHeaders, &corev3.HeaderValueOption{
+		AppendAction: corev3.HeaderValueOption_OVERWRITE_IF_EXISTS_OR_ADD,
+		Header:       &corev3.HeaderValue{Key: internalapi.ToolCallLoopHeader, RawValue: []byte(function)},
+	})
+}
@@ -0,0 +1,175 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"testing"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+)
+
+const toolCallLoopTestBody = `{"model":"m","messages":[` +
+	`{"role":"user","content":"weather in Paris?"},` +
+	`{"role":"assistant","tool_calls":[{"id":"1","type":"function","function":{"name":"weather","arguments":"{\"city\":\"Paris\",\"unit\":\"C\"}"}}]},` +
+	`{"role":"tool","tool_call_id":"1","content":"error"},` +
+	`{"role":"assistant","tool_calls":[{"id":"2","type":"function","function":{"name":"weather","arguments":"{\"unit\": \"C\", \"city\": \"Paris\"}"}}]},` +
+	`{"role":"tool","tool_call_id":"2","content":"error"},` +
+	`{"role":"assistant","tool_calls":[` +
+	`{"id":"3","type":"function","function":{"name":"time","arguments":"{}"}},` +
+	`{"id":"4","type":"function","function":{"name":"weather","arguments":"{\"city\":\"Paris\",\"unit\":\"C\"}"}}]},` +
+	`{"role":"tool","tool_call_id":"3","content":"noon"},` +
+	`{"role":"tool","tool_call_id":"4","content":"error"}]}`
+
+func TestDetectToolCallLoop(t *testing.T) {
+	chat := &openai.ChatCompletionRequest{}
+	for _, tc := range []struct {
+		name           string
+		req            any
+		body           string
+		expCall        toolCall
+		expRepetitions int
+		expParam       string
+	}{
+		{
+			name:           "chat completion",
+			req:            chat,
+			body:           toolCallLoopTestBody,
+			expCall:        toolCall{name: "weather", arguments: `{"city":"Paris","unit":"C"}`},
+			expRepetitions: 3,
+			expParam:       "messages",
+		},
+		{
+			name: "different arguments",
+			req:  chat,
+			body: `{"messages":[{"role":"user","content":"hi"},` +
+				`{"role":"assistant","tool_calls":[{"id":"1","type":"function","function":{"name":"weather","arguments":"{\"city\":\"Paris\"}"}}]},` +
+				`{"role":"assistant","tool_calls":[{"id":"2","type":"function","function":{"name":"weather","arguments":"{\"city\":\"Rome\"}"}}]}]}`,
+			expCall:        toolCall{name: "weather", arguments: `{"city":"Rome"}`},
+			expRepetitions: 1,
+			expParam:       "messages",
+		},
+		{
+			name: "interrupted by the user",
+			req:  chat,
+			body: `{"messages":[` +
+				`{"role":"assistant","tool_calls":[{"id":"1","type":"function","function":{"name":"weather","arguments":"{}"}}]},` +
+				`{"role":"assistant","tool_calls":[{"id":"2","type":"function","function":{"name":"weather","arguments":"{}"}}]},` +
+				`{"role":"user","content":"stop"}]}`,
+			expParam: "messages",
+		},
+		{
+			name:     "no calls",
+			req:      chat,
+			body:     `{"messages":[{"role":"user","content":"hi"}]}`,
+			expParam: "messages",
+		},
+		{
+			name: "responses",
+			req:  &openai.ResponseRequest{},
+			body: `{"input":[{"role":"user","content":"weather?"},` +
+				`{"type":"function_call","call_id":"1","name":"weather","arguments":"{\"city\":\"Paris\"}"},` +
+				`{"type":"function_call_output","call_id":"1","output":"error"},` +
+				`{"type":"function_call","call_id":"2","name":"weather","arguments":"{\"city\":\"Paris\"}"},` +
+				`{"type":"function_call_output","call_id":"2","output":"error"}]}`,
+			expCall:        toolCall{name: "weather", arguments: `{"city":"Paris"}`},
+			expRepetitions: 2,
+			expParam:       "input",
+		},
+		{
+			name: "other endpoint",
+			req:  &openai.EmbeddingRequest{},
+			body: `{"input":"hi"}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			call, repetitions, param := detectToolCallLoop(tc.req, []byte(tc.body))
+			require.Equal(t, tc.expCall, call)
+			require.Equal(t, tc.expRepetitions, repetitions)
+			require.Equal(t, tc.expParam, param)
+		})
+	}
+}
+
+func Test_canonicalArguments(t *testing.T) {
+	require.Equal(t, `{"a":[1,{"b":12345678901234567890,"c":null}],"d":"e"}`,
+		canonicalArguments(`{"d": "e", "a": [1, {"c": null, "b": 12345678901234567890}]}`))
+	require.Equal(t, "not json", canonicalArguments("not json"))
+	require.Empty(t, canonicalArguments(""))
+}
+
+func Test_chatCompletionProcessorUpstreamFilter_checkToolCallLoop(t *testing.T) {
+	t.Run("reject", func(t *testing.T) {
+		p := newTestUpstreamFilter(t, &filterapi.RuntimeConfig{ToolCallLoopGuards: map[string]*filterapi.ToolCallLoopGuard{
+			"default/route": {RouteName: "default/route", MaxRepetitions: 3, Action: filterapi.ToolCallLoopActionReject},
+		}}, "default/route", toolCallLoopTestBody)
+		r := p.parent
+		res, err := p.checkToolCallLoop()
+		require.NoError(t, err)
+		require.NotNil(t, res)
+		immediate := res.GetImmediateResponse()
+		require.Equal(t, 400, int(immediate.Status.Code))
+		require.Equal(t, "tool_call_loop_detected", gjson.GetBytes(immediate.Body, "error.code").String())
+		require.Equal(t, "messages", gjson.GetBytes(immediate.Body, "error.param").String())
+		require.Equal(t, "The function weather was called 3 times with the same arguments in this conversation, "+
+			"reaching the limit of 3 repetitions.", gjson.GetBytes(immediate.Body, "error.message").String())
+		require.Equal(t, toolCallLoopTestBody, string(r.originalRequestBodyRaw))
+	})
+
+	t.Run("hint", func(t *testing.T) {
+		p := newTestUpstreamFilter(t, &filterapi.RuntimeConfig{ToolCallLoopGuards: map[string]*filterapi.ToolCallLoopGuard{
+			"default/route": {RouteName: "default/route", MaxRepetitions: 2, Action: filterapi.ToolCallLoopActionHint},
+		}}, "default/route", toolCallLoopTestBody)
+		r := p.parent
+		res, err := p.checkToolCallLoop()
+		require.NoError(t, err)
+		require.Nil(t, res)
+		require.Equal(t, []string{"user", "assistant", "tool", "assistant", "tool", "assistant", "tool", "tool", "system"}, roles(r.originalRequestBodyRaw))
+		require.Contains(t, gjson.GetBytes(r.originalRequestBodyRaw, "messages.8.content").String(),
+			"You have called the function weather 3 times with the same arguments in this conversation.")
+		require.Len(t, r.originalRequestBody.Messages, 9)
+		require.True(t, r.forceBodyMutation)
+		require.Equal(t, "weather", r.toolCallLoop)
+
+		headerMutation := &extprocv3.HeaderMutation{}
+		setToolCallLoopHeader(headerMutation, r.toolCallLoop)
+		require.Equal(t, internalapi.ToolCallLoopHeader, headerMutation.SetHeaders[0].Header.Key)
+		require.Equal(t, "weather", string(headerMutation.SetHeaders[0].Header.RawValue))
+	})
+
+	t.Run("below the limit", func(t *testing.T) {
+		p := newTestUpstreamFilter(t, &filterapi.RuntimeConfig{ToolCallLoopGuards: map[string]*filterapi.ToolCallLoopGuard{
+			"default/route": {RouteName: "default/route", MaxRepetitions: 4, Action: filterapi.ToolCallLoopActionReject},
+		}}, "default/route", toolCallLoopTestBody)
+		r := p.parent
+		res, err := p.checkToolCallLoop()
+		require.NoError(t, err)
+		require.Nil(t, res)
+		require.Equal(t, toolCallLoopTestBody, string(r.originalRequestBodyRaw))
+		require.False(t, r.forceBodyMutation)
+	})
+
+	t.Run("no guard", func(t *testing.T) {
+		p := newTestUpstreamFilter(t, &filterapi.RuntimeConfig{ToolCallLoopGuards: map[string]*filterapi.ToolCallLoopGuard{
+			"default/route": {RouteName: "default/route", MaxRepetitions: 2, Action: filterapi.ToolCallLoopActionReject},
+		}}, "default/route", toolCallLoopTestBody)
+		r := p.parent
+		p.routeName = "default/other"
+		res, err := p.checkToolCallLoop()
+		require.NoError(t, err)
+		require.Nil(t, res)
+		require.Empty(t, r.toolCallLoop)
+
+		headerMutation := &extprocv3.HeaderMutation{}
+		setToolCallLoopHeader(headerMutation, r.toolCallLoop)
+		require.Empty(t, headerMutation.SetHeaders)
+	})
+}
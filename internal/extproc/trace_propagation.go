@@ -0,0 +1,95 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/envoyproxy/ai-gateway/internal/filterapi"
+)
+
+// traceContextHeaders are the W3C trace context headers injected by the router filter.
+var traceContextHeaders = []string{"traceparent", "tracestate"}
+
+// providerRequestIDHeaders are the response headers carrying the IDs that the providers assign to the requests,
+// e.g. x-request-id for OpenAI and x-amzn-requestid for AWS. They are recorded on the span so that a request can
+// be looked up in the logs of the provider.
+var providerRequestIDHeaders = []string{"x-request-id", "request-id", "x-amzn-requestid", "apim-request-id"}
+
+// applyTraceContextPropagation mutates the trace context headers of the request to the backend according to the
+// propagation policy of the backend, and records the mutations in requestHeaders.
+func applyTraceContextPropagation(headerMutation *extprocv3.HeaderMutation, requestHeaders map[string]string,
+	policy filterapi.TraceContextPropagation, schema filterapi.APISchemaName,
+) {
+	switch policy {
+	case filterapi.TraceContextPropagationNone:
+		for _, h := range traceContextHeaders {
+			if _, ok := requestHeaders[h]; ok {
+				headerMutation.RemoveHeaders = append(headerMutation.RemoveHeaders, h)
+				delete(requestHeaders, h)
+			}
+		}
+	case filterapi.TraceContextPropagationProviderHeaders:
+		key, value := providerTraceHeader(schema, requestHeaders)
+		if key == "" {
+			return
+		}
+		headerMutation.SetHeaders = append(headerMutation.SetHeaders, &corev3.HeaderValueOption{
+			AppendAction: corev3.HeaderValueOption_OVERWRITE_IF_EXISTS_OR_ADD,
+			Header:       &corev3.HeaderValue{Key: key, RawValue: []byte(value)},
+		})
+		requestHeaders[key] = value
+	}
+}
+
+// providerTraceHeader returns the correlation header of the provider of the given schema derived from the W3C
+// trace context in the request headers. The key is empty if the request has no valid trace context or the
+// provider has no such header.
+func providerTraceHeader(schema filterapi.APISchemaName, requestHeaders map[string]string) (key, value string) {
+	sc := trace.SpanContextFromContext(propagation.TraceContext{}.Extract(context.Background(),
+		propagation.MapCarrier(requestHeaders)))
+	if !sc.IsValid() {
+		return "", ""
+	}
+	traceID, spanID := sc.TraceID().String(), sc.SpanID()
+	sampled := "0"
+	if sc.IsSampled() {
+		sampled = "1"
+	}
+	switch schema {
+	case filterapi.APISchemaAWSBedrock, filterapi.APISchemaAWSAnthropic:
+		// The AWS X-Ray trace header splits the trace ID into the epoch and the unique parts.
+		return "x-amzn-trace-id", fmt.Sprintf("Root=1-%s-%s;Parent=%s;Sampled=%s", traceID[:8], traceID[8:], spanID, sampled)
+	case filterapi.APISchemaGCPVertexAI, filterapi.APISchemaGCPAnthropic:
+		// The Google Cloud trace header has the span ID in decimal.
+		return "x-cloud-trace-context", fmt.Sprintf("%s/%d;o=%s", traceID, binary.BigEndian.Uint64(spanID[:]), sampled)
+	case filterapi.APISchemaOpenAI:
+		return "x-client-request-id", traceID
+	case filterapi.APISchemaAzureOpenAI:
+		return "x-ms-client-request-id", traceID
+	}
+	return "", ""
+}
+
+// providerRequestIDAttributes returns the span attributes of the request IDs in the response headers of the
+// provider, named after the OpenTelemetry semantic conventions of the HTTP response headers.
+func providerRequestIDAttributes(responseHeaders map[string]string) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	for _, h := range providerRequestIDHeaders {
+		if v := responseHeaders[h]; v != "" {
+			attrs = append(attrs, attribute.StringSlice("http.response.header."+h, []string{v}))
+		}
+	}
+	return attrs
+}
@@ -0,0 +1,91 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"testing"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/envoyproxy/ai-gateway/internal/filterapi"
+)
+
+const testTraceParent = "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"
+
+func TestProviderTraceHeader(t *testing.T) {
+	headers := map[string]string{"traceparent": testTraceParent}
+	for _, tc := range []struct {
+		schema           filterapi.APISchemaName
+		expKey, expValue string
+	}{
+		{
+			schema:   filterapi.APISchemaAWSBedrock,
+			expKey:   "x-amzn-trace-id",
+			expValue: "Root=1-0af76519-16cd43dd8448eb211c80319c;Parent=b7ad6b7169203331;Sampled=1",
+		},
+		{
+			schema:   filterapi.APISchemaGCPVertexAI,
+			expKey:   "x-cloud-trace-context",
+			expValue: "0af7651916cd43dd8448eb211c80319c/13235353014750950193;o=1",
+		},
+		{schema: filterapi.APISchemaOpenAI, expKey: "x-client-request-id", expValue: "0af7651916cd43dd8448eb211c80319c"},
+		{schema: filterapi.APISchemaAzureOpenAI, expKey: "x-ms-client-request-id", expValue: "0af7651916cd43dd8448eb211c80319c"},
+		{schema: filterapi.APISchemaAnthropic},
+	} {
+		t.Run(string(tc.schema), func(t *testing.T) {
+			key, value := providerTraceHeader(tc.schema, headers)
+			require.Equal(t, tc.expKey, key)
+			require.Equal(t, tc.expValue, value)
+		})
+	}
+
+	t.Run("no trace context", func(t *testing.T) {
+		key, _ := providerTraceHeader(filterapi.APISchemaAWSBedrock, map[string]string{"traceparent": "invalid"})
+		require.Empty(t, key)
+	})
+}
+
+func TestApplyTraceContextPropagation(t *testing.T) {
+	newHeaders := func() map[string]string {
+		return map[string]string{"traceparent": testTraceParent, "tracestate": "vendor=value"}
+	}
+
+	t.Run("default", func(t *testing.T) {
+		headers, mutation := newHeaders(), &extprocv3.HeaderMutation{}
+		applyTraceContextPropagation(mutation, headers, "", filterapi.APISchemaAWSBedrock)
+		require.Empty(t, mutation.SetHeaders)
+		require.Empty(t, mutation.RemoveHeaders)
+		require.Equal(t, newHeaders(), headers)
+	})
+	t.Run("provider headers", func(t *testing.T) {
+		headers, mutation := newHeaders(), &extprocv3.HeaderMutation{}
+		applyTraceContextPropagation(mutation, headers, filterapi.TraceContextPropagationProviderHeaders, filterapi.APISchemaAWSBedrock)
+		require.Len(t, mutation.SetHeaders, 1)
+		require.Equal(t, "x-amzn-trace-id", mutation.SetHeaders[0].Header.Key)
+		require.Equal(t, string(mutation.SetHeaders[0].Header.RawValue), headers["x-amzn-trace-id"])
+		require.Equal(t, testTraceParent, headers["traceparent"])
+	})
+	t.Run("none", func(t *testing.T) {
+		headers, mutation := newHeaders(), &extprocv3.HeaderMutation{}
+		applyTraceContextPropagation(mutation, headers, filterapi.TraceContextPropagationNone, filterapi.APISchemaAWSBedrock)
+		require.Equal(t, []string{"traceparent", "tracestate"}, mutation.RemoveHeaders)
+		require.Empty(t, headers)
+	})
+}
+
+func TestProviderRequestIDAttributes(t *testing.T) {
+	require.Nil(t, providerRequestIDAttributes(map[string]string{":status": "200"}))
+	require.Equal(t, []attribute.KeyValue{
+		attribute.StringSlice("http.response.header.x-request-id", []string{"req_123"}),
+		attribute.StringSlice("http.response.header.x-amzn-requestid", []string{"abc-def"}),
+	}, providerRequestIDAttributes(map[string]string{
+		":status":          "200",
+		"x-request-id":     "req_123",
+		"x-amzn-requestid": "abc-def",
+	}))
+}
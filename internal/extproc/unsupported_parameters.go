@@ -0,0 +1,38 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"fmt"
+	"strings"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+)
+
+// unsupportedParametersErrorCode is the code of the OpenAI error rejecting the requests with unsupported parameters.
+const unsupportedParametersErrorCode = "unsupported_parameter"
+
+// unsupportedParametersResponse returns the immediate response rejecting a request with the parameters that the
+// selected backend cannot honor. The body is an OpenAI invalid request error whose param is the first of them, so
+// that the OpenAI clients surface it as they do for the errors of OpenAI itself.
+func unsupportedParametersResponse(params []string) (*extprocv3.ProcessingResponse, error) {
+	return invalidRequestErrorResponse(unsupportedParametersErrorCode,
+		fmt.Sprintf("the selected backend does not support the parameters: %s", strings.Join(params, ", ")), params[0])
+}
+
+// setUnsupportedParametersHeader sets the response header listing the parameters dropped by the translation, if any.
+func setUnsupportedParametersHeader(headerMutation *extprocv3.HeaderMutation, params []string) {
+	if len(params) == 0 {
+		return
+	}
+	headerMutation.SetHeaders = append(headerMutation.SetHeaders, &corev3.HeaderValueOption{
+		AppendAction: corev3.HeaderValueOption_OVERWRITE_IF_EXISTS_OR_ADD,
+		Header:       &corev3.HeaderValue{Key: internalapi.UnsupportedParametersHeader, RawValue: []byte(strings.Join(params, ","))},
+	})
+}
@@ -0,0 +1,79 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"testing"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/internal/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+	"github.com/envoyproxy/ai-gateway/internal/translator"
+)
+
+func TestUnsupportedParametersResponse(t *testing.T) {
+	res, err := unsupportedParametersResponse([]string{"logit_bias", "seed"})
+	require.NoError(t, err)
+	require.Equal(t, typev3.StatusCode_BadRequest, res.GetImmediateResponse().GetStatus().GetCode())
+	require.JSONEq(t, `{
+  "type": "error",
+  "error": {
+    "type": "invalid_request_error",
+    "code": "unsupported_parameter",
+    "message": "the selected backend does not support the parameters: logit_bias, seed",
+    "param": "logit_bias"
+  }
+}`, string(res.GetImmediateResponse().GetBody()))
+}
+
+func TestSetUnsupportedParametersHeader(t *testing.T) {
+	headerMutation := &extprocv3.HeaderMutation{}
+	setUnsupportedParametersHeader(headerMutation, nil)
+	require.Empty(t, headerMutation.SetHeaders)
+
+	setUnsupportedParametersHeader(headerMutation, []string{"logit_bias", "seed"})
+	require.Len(t, headerMutation.SetHeaders, 1)
+	require.Equal(t, internalapi.UnsupportedParametersHeader, headerMutation.SetHeaders[0].Header.Key)
+	require.Equal(t, "logit_bias,seed", string(headerMutation.SetHeaders[0].Header.RawValue))
+}
+
+func Test_chatCompletionProcessorUpstreamFilter_checkUnsupportedParameters(t *testing.T) {
+	config := &filterapi.RuntimeConfig{UnsupportedParameters: map[string]filterapi.UnsupportedParameterBehavior{
+		"default/drop":  filterapi.UnsupportedParameterBehaviorDrop,
+		"default/warn":  filterapi.UnsupportedParameterBehaviorWarn,
+		"default/error": filterapi.UnsupportedParameterBehaviorError,
+	}}
+	const body = `{"model":"m","messages":[],"seed":1,"n":1}`
+	bedrock := translator.NewChatCompletionOpenAIToAWSBedrockTranslator("")
+	for _, tc := range []struct {
+		routeName  string
+		translator translator.OpenAIChatCompletionTranslator
+		expParams  []string
+		expReject  bool
+	}{
+		{routeName: "default/drop", translator: bedrock},
+		{routeName: "default/other", translator: bedrock},
+		// The translators to the OpenAI compatible backends drop no parameter.
+		{routeName: "default/error", translator: translator.NewChatCompletionOpenAIToOpenAITranslator("v1", "")},
+		{routeName: "default/warn", translator: bedrock, expParams: []string{"seed"}},
+		{routeName: "default/error", translator: bedrock, expReject: true},
+	} {
+		p := newTestUpstreamFilter(t, config, tc.routeName, body)
+		p.backendName, p.translator = "default/bedrock/route/rule/0/ref/0", tc.translator
+		res, err := p.checkUnsupportedParameters()
+		require.NoError(t, err)
+		require.Equal(t, tc.expParams, p.unsupportedParameters)
+		if !tc.expReject {
+			require.Nil(t, res)
+			continue
+		}
+		require.Equal(t, typev3.StatusCode_BadRequest, res.GetImmediateResponse().GetStatus().GetCode())
+		require.Contains(t, string(res.GetImmediateResponse().GetBody()), `"param":"seed"`)
+	}
+}
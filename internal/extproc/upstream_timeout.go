@@ -0,0 +1,116 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+	"github.com/envoyproxy/ai-gateway/internal/json"
+	"github.com/envoyproxy/ai-gateway/internal/metrics"
+)
+
+// responseCodeDetailsKey is the context key of the response code details of the response headers.
+type responseCodeDetailsKey struct{}
+
+// withResponseCodeDetails stores the response code details found in the attributes of the response headers. These
+// are only sent to the router filter, so that the local replies of Envoy can be told apart from the responses of the
+// backends.
+func withResponseCodeDetails(ctx context.Context, attributes map[string]*structpb.Struct) context.Context {
+	details, ok := attributes["envoy.filters.http.ext_proc"].GetFields()[internalapi.ResponseCodeDetailsAttribute]
+	if !ok {
+		return ctx
+	}
+	return context.WithValue(ctx, responseCodeDetailsKey{}, details.GetStringValue())
+}
+
+// upstreamTimeoutPhase returns the phase of the request to the backend that timed out according to the response code
+// details of Envoy, or false if the response isn't a timeout. The responseStarted is true if the response headers of
+// the backend were received, since the per-try idle timeout also fires between two chunks of the response body.
+func upstreamTimeoutPhase(details string, responseStarted bool) (metrics.UpstreamPhase, bool) {
+	switch {
+	case strings.Contains(details, "connection_timeout"):
+		// e.g. "upstream_reset_before_response_started{connection_timeout}".
+		return metrics.UpstreamPhaseConnect, true
+	case details == "upstream_per_try_idle_timeout":
+		// The backend stalled in the middle of the response, e.g. of a buffered body not sent to the client yet.
+		if responseStarted {
+			return metrics.UpstreamPhaseCompletion, true
+		}
+		return metrics.UpstreamPhaseFirstByte, true
+	case details == "upstream_per_try_timeout", details == "upstream_response_timeout":
+		return metrics.UpstreamPhaseCompletion, true
+	default:
+		return "", false
+	}
+}
+
+// upstreamTimeoutMessages are the messages of the errors returned when a phase of the request times out.
+var upstreamTimeoutMessages = map[metrics.UpstreamPhase]string{
+	metrics.UpstreamPhaseConnect:    "timed out connecting to the backend",
+	metrics.UpstreamPhaseFirstByte:  "timed out waiting for the first byte of the response of the backend",
+	metrics.UpstreamPhaseCompletion: "timed out waiting for the completion of the response of the backend",
+}
+
+// upstreamTimeoutResponse returns the immediate response replacing the local reply of Envoy when a phase of the
+// request to the backend timed out, or nil otherwise. The error code names the phase, e.g. "first_byte_timeout", so
+// that the clients can tell a slow connection apart from a slow generation.
+func (r *routerProcessor[ReqT, RespT, RespChunkT, EndpointSpecT]) upstreamTimeoutResponse(ctx context.Context, headerMap *corev3.HeaderMap) *extprocv3.ProcessingResponse {
+	details, _ := ctx.Value(responseCodeDetailsKey{}).(string)
+	phase, ok := upstreamTimeoutPhase(details, r.upstreamFilter != nil && r.upstreamFilter.responseHeaders != nil)
+	if !ok {
+		return nil
+	}
+
+	const code = http.StatusGatewayTimeout
+	errorCode := string(phase) + "_timeout"
+	body, err := json.Marshal(openai.Error{
+		Type: "error",
+		Error: openai.ErrorType{
+			Type:    strings.ReplaceAll(http.StatusText(code), " ", ""),
+			Code:    &errorCode,
+			Message: upstreamTimeoutMessages[phase],
+		},
+	})
+	if err != nil { // coverage-ignore
+		r.logger.Error("failed to build the upstream timeout response", slog.String("error", err.Error()))
+		return nil
+	}
+
+	r.logger.Info("backend timed out", slog.String("phase", string(phase)),
+		slog.String("status", headersToMap(headerMap)[":status"]))
+	if u := r.upstreamFilter; u != nil && !u.responseEnded {
+		u.responseEnded = true
+		u.metrics.RecordPhaseDuration(ctx, phase, true, u.requestHeaders)
+		u.metrics.RecordRequestCompletion(ctx, false, u.requestHeaders)
+	}
+	if r.span != nil {
+		r.span.EndSpanOnError(code, body)
+	}
+
+	headerMutation := &extprocv3.HeaderMutation{}
+	setHeader(headerMutation, "content-type", "application/json")
+	setHeader(headerMutation, "content-length", strconv.Itoa(len(body)))
+	return &extprocv3.ProcessingResponse{
+		Response: &extprocv3.ProcessingResponse_ImmediateResponse{
+			ImmediateResponse: &extprocv3.ImmediateResponse{
+				Status:  &typev3.HttpStatus{Code: typev3.StatusCode_GatewayTimeout},
+				Headers: headerMutation,
+				Body:    body,
+			},
+		},
+	}
+}
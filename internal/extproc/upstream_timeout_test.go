@@ -0,0 +1,88 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+	"github.com/envoyproxy/ai-gateway/internal/metrics"
+)
+
+func TestUpstreamTimeoutPhase(t *testing.T) {
+	for _, tc := range []struct {
+		details         string
+		responseStarted bool
+		exp             metrics.UpstreamPhase
+	}{
+		{details: "upstream_reset_before_response_started{connection_timeout}", exp: metrics.UpstreamPhaseConnect},
+		{details: "upstream_per_try_idle_timeout", exp: metrics.UpstreamPhaseFirstByte},
+		{details: "upstream_per_try_idle_timeout", responseStarted: true, exp: metrics.UpstreamPhaseCompletion},
+		{details: "upstream_per_try_timeout", exp: metrics.UpstreamPhaseCompletion},
+		{details: "upstream_response_timeout", exp: metrics.UpstreamPhaseCompletion},
+		{details: "via_upstream"},
+		{details: "upstream_reset_before_response_started{connection_failure}"},
+		{},
+	} {
+		t.Run(tc.details, func(t *testing.T) {
+			phase, ok := upstreamTimeoutPhase(tc.details, tc.responseStarted)
+			require.Equal(t, tc.exp != "", ok)
+			require.Equal(t, tc.exp, phase)
+		})
+	}
+}
+
+func Test_routerProcessor_upstreamTimeoutResponse(t *testing.T) {
+	headers := &corev3.HeaderMap{Headers: []*corev3.HeaderValue{{Key: ":status", Value: "504"}}}
+	ctxWithDetails := func(t *testing.T, details string) context.Context {
+		return withResponseCodeDetails(t.Context(), map[string]*structpb.Struct{
+			"envoy.filters.http.ext_proc": {Fields: map[string]*structpb.Value{
+				internalapi.ResponseCodeDetailsAttribute: structpb.NewStringValue(details),
+			}},
+		})
+	}
+
+	t.Run("timeout", func(t *testing.T) {
+		mm := &mockMetrics{}
+		u := &chatCompletionProcessorUpstreamFilter{metrics: mm}
+		p := &chatCompletionProcessorRouterFilter{logger: slog.Default(), upstreamFilter: u}
+		resp, err := p.ProcessResponseHeaders(ctxWithDetails(t, "upstream_per_try_idle_timeout"), headers)
+		require.NoError(t, err)
+		ir, ok := resp.Response.(*extprocv3.ProcessingResponse_ImmediateResponse)
+		require.True(t, ok)
+		require.Equal(t, typev3.StatusCode_GatewayTimeout, ir.ImmediateResponse.Status.Code)
+		require.JSONEq(t, `{"type":"error","error":{"type":"GatewayTimeout","code":"first_byte_timeout","message":"timed out waiting for the first byte of the response of the backend"}}`,
+			string(ir.ImmediateResponse.Body))
+		require.True(t, u.responseEnded)
+		require.Equal(t, []metrics.UpstreamPhase{metrics.UpstreamPhaseFirstByte}, mm.timedOutPhases)
+		mm.RequireRequestFailure(t)
+	})
+	t.Run("idle timeout after the response headers", func(t *testing.T) {
+		mm := &mockMetrics{}
+		u := &chatCompletionProcessorUpstreamFilter{metrics: mm, responseHeaders: map[string]string{":status": "200"}}
+		p := &chatCompletionProcessorRouterFilter{logger: slog.Default(), upstreamFilter: u}
+		resp, err := p.ProcessResponseHeaders(ctxWithDetails(t, "upstream_per_try_idle_timeout"), headers)
+		require.NoError(t, err)
+		ir, ok := resp.Response.(*extprocv3.ProcessingResponse_ImmediateResponse)
+		require.True(t, ok)
+		require.JSONEq(t, `{"type":"error","error":{"type":"GatewayTimeout","code":"completion_timeout","message":"timed out waiting for the completion of the response of the backend"}}`,
+			string(ir.ImmediateResponse.Body))
+		require.Equal(t, []metrics.UpstreamPhase{metrics.UpstreamPhaseCompletion}, mm.timedOutPhases)
+	})
+	t.Run("not a timeout", func(t *testing.T) {
+		p := &chatCompletionProcessorRouterFilter{logger: slog.Default()}
+		require.Nil(t, p.upstreamTimeoutResponse(ctxWithDetails(t, "via_upstream"), headers))
+		require.Nil(t, p.upstreamTimeoutResponse(t.Context(), headers))
+	})
+}
@@ -11,13 +11,17 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"strconv"
 
 	"github.com/andybalholm/brotli"
 	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
 
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
 	"github.com/envoyproxy/ai-gateway/internal/bodymutator"
 	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+	"github.com/envoyproxy/ai-gateway/internal/json"
 )
 
 // contentDecodingResult contains the result of content decoding operation.
@@ -167,3 +171,32 @@ func (c *headerMutationCarrier) Set(key, value string) {
 func (c *headerMutationCarrier) Keys() []string {
 	panic("unexpected as this carrier is write-only for injection")
 }
+
+// invalidRequestErrorResponse returns the immediate response rejecting a request with an OpenAI invalid request
+// error of the code about the param.
+func invalidRequestErrorResponse(code, message, param string) (*extprocv3.ProcessingResponse, error) {
+	body, err := json.Marshal(openai.Error{
+		Type: "error",
+		Error: openai.ErrorType{
+			Type:    "invalid_request_error",
+			Code:    &code,
+			Message: message,
+			Param:   &param,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal the %s error: %w", code, err)
+	}
+	headerMutation := &extprocv3.HeaderMutation{}
+	setHeader(headerMutation, "content-type", "application/json")
+	setHeader(headerMutation, "content-length", strconv.Itoa(len(body)))
+	return &extprocv3.ProcessingResponse{
+		Response: &extprocv3.ProcessingResponse_ImmediateResponse{
+			ImmediateResponse: &extprocv3.ImmediateResponse{
+				Status:  &typev3.HttpStatus{Code: typev3.StatusCode_BadRequest},
+				Headers: headerMutation,
+				Body:    body,
+			},
+		},
+	}, nil
+}
@@ -6,10 +6,13 @@
 package filterapi
 
 import (
+	"bytes"
+	"compress/gzip"
 	"crypto/sha256"
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"io"
 	"strings"
 	"time"
 
@@ -18,15 +21,21 @@ import (
 
 const ConfigBundleIndexFileName = "index.yaml"
 
+// ConfigBundleEncodingGzip is the Encoding of the bundles whose payload is the gzip compressed config.
+const ConfigBundleEncodingGzip = "gzip"
+
 var ErrBundleChecksumMismatch = errors.New("bundle checksum mismatch")
 
 // ConfigBundleIndex describes where to find sharded filter config parts and how to validate them.
 type ConfigBundleIndex struct {
-	Version   string             `json:"version" yaml:"version"`
-	UUID      string             `json:"uuid" yaml:"uuid"`
-	Checksum  string             `json:"checksum" yaml:"checksum"`
-	Parts     []ConfigBundlePart `json:"parts" yaml:"parts"`
-	CreatedAt *time.Time         `json:"createdAt,omitempty" yaml:"createdAt,omitempty"`
+	Version  string             `json:"version" yaml:"version"`
+	UUID     string             `json:"uuid" yaml:"uuid"`
+	Checksum string             `json:"checksum" yaml:"checksum"`
+	Parts    []ConfigBundlePart `json:"parts" yaml:"parts"`
+	// Encoding is the encoding of the payload split into the parts, which is the config YAML when empty.
+	// The Checksum is the one of the encoded payload.
+	Encoding  string     `json:"encoding,omitempty" yaml:"encoding,omitempty"`
+	CreatedAt *time.Time `json:"createdAt,omitempty" yaml:"createdAt,omitempty"`
 }
 
 type ConfigBundlePart struct {
@@ -64,6 +73,19 @@ func ConfigBundleChecksum(raw []byte) string {
 
 // ReassembleBundleConfig rebuilds a full filter config from sharded parts and verifies integrity.
 func ReassembleBundleConfig(index *ConfigBundleIndex, readPart func(part ConfigBundlePart) ([]byte, error)) (*Config, error) {
+	raw, err := ReassembleBundle(index, readPart)
+	if err != nil {
+		return nil, err
+	}
+	var cfg Config
+	if err := yaml.Unmarshal(raw, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal bundled config: %w", err)
+	}
+	return &cfg, nil
+}
+
+// ReassembleBundle rebuilds the filter config YAML from sharded parts and verifies integrity.
+func ReassembleBundle(index *ConfigBundleIndex, readPart func(part ConfigBundlePart) ([]byte, error)) ([]byte, error) {
 	var payload []byte
 	for i := 0; i < len(index.Parts); i++ {
 		p := ConfigBundlePart{
@@ -83,9 +105,40 @@ func ReassembleBundleConfig(index *ConfigBundleIndex, readPart func(part ConfigB
 		return nil, fmt.Errorf("%w: expected %s got %s", ErrBundleChecksumMismatch, expected, actual)
 	}
 
-	var cfg Config
-	if err := yaml.Unmarshal(payload, &cfg); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal bundled config: %w", err)
+	switch index.Encoding {
+	case "":
+	case ConfigBundleEncodingGzip:
+		var err error
+		if payload, err = decompressConfigBundle(payload); err != nil {
+			return nil, fmt.Errorf("failed to decompress bundled config: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unknown bundled config encoding %q", index.Encoding)
 	}
-	return &cfg, nil
+	return payload, nil
+}
+
+// CompressConfigBundle compresses the config YAML into the payload of a bundle with the ConfigBundleEncodingGzip.
+func CompressConfigBundle(raw []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = w.Write(raw); err != nil {
+		return nil, err
+	}
+	if err = w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func decompressConfigBundle(payload []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = r.Close() }()
+	return io.ReadAll(r)
 }
@@ -35,6 +35,25 @@ func TestReassembleBundleConfig(t *testing.T) {
 	require.Len(t, cfg.Backends, 1)
 }
 
+func TestReassembleBundleConfig_Gzip(t *testing.T) {
+	cfgRaw := []byte("version: dev\nbackends:\n- name: openai\n")
+	payload, err := CompressConfigBundle(cfgRaw)
+	require.NoError(t, err)
+	index := &ConfigBundleIndex{
+		Checksum: ConfigBundleChecksum(payload),
+		Parts:    []ConfigBundlePart{{Name: "p0", Path: "parts/000"}},
+		Encoding: ConfigBundleEncodingGzip,
+	}
+	cfg, err := ReassembleBundleConfig(index, func(ConfigBundlePart) ([]byte, error) { return payload, nil })
+	require.NoError(t, err)
+	require.Equal(t, "dev", cfg.Version)
+	require.Len(t, cfg.Backends, 1)
+
+	index.Encoding = "zstd"
+	_, err = ReassembleBundleConfig(index, func(ConfigBundlePart) ([]byte, error) { return payload, nil })
+	require.ErrorContains(t, err, `unknown bundled config encoding "zstd"`)
+}
+
 func TestReassembleBundleConfig_ChecksumMismatch(t *testing.T) {
 	index := &ConfigBundleIndex{
 		Checksum: ConfigBundleChecksum([]byte("different")),
@@ -52,6 +52,395 @@ type Config struct {
 	UnscopedModels []Model `json:"unscopedModels,omitempty"`
 	// MCPConfig is the configuration for the MCPRoute implementations.
 	MCPConfig *MCPConfig `json:"mcpConfig,omitempty"`
+	// StreamConcurrencyLimits limits the number of concurrent streaming requests per client. Optional.
+	StreamConcurrencyLimits []StreamConcurrencyLimit `json:"streamConcurrencyLimits,omitempty"`
+	// TruncatedStreamErrorEvent enables sending an error event to the client when a streaming chat completion
+	// ends without its terminating event. Optional.
+	TruncatedStreamErrorEvent bool `json:"truncatedStreamErrorEvent,omitempty"`
+	// StreamCoalescing coalesces the small events of the streaming responses into fewer body chunks. Optional.
+	StreamCoalescing *StreamCoalescing `json:"streamCoalescing,omitempty"`
+	// QuotaFallback configures the local rate limiting applied while the quota rate limit service is unreachable.
+	// Optional.
+	QuotaFallback *QuotaFallback `json:"quotaFallback,omitempty"`
+	// QuotaSchedules is the list of the schedules of the quotas whose limits vary with the time of the request.
+	// Optional.
+	QuotaSchedules []QuotaSchedule `json:"quotaSchedules,omitempty"`
+	// Experiments is the list of the A/B experiments of the routes. Optional.
+	Experiments []Experiment `json:"experiments,omitempty"`
+	// PromptInjectionDetection enables scoring the requests for prompt injection and jailbreak attempts. Optional.
+	PromptInjectionDetection *PromptInjectionDetection `json:"promptInjectionDetection,omitempty"`
+	// ParameterOverrides is the list of the bounds of the sampling parameters that the callers of the routes can
+	// override with request headers. Optional.
+	ParameterOverrides []ParameterOverrides `json:"parameterOverrides,omitempty"`
+	// BackendOverrides is the list of the keys verifying the signed requests pinning a backend of the routes.
+	// Optional.
+	BackendOverrides []BackendOverride `json:"backendOverrides,omitempty"`
+	// Migrations is the list of the rules of the routes compared with the backends they are migrated to. Optional.
+	Migrations []Migration `json:"migrations,omitempty"`
+	// FallbackResponse is the synthetic response returned instead of the error responses when no backend can serve
+	// a request. Optional.
+	FallbackResponse *FallbackResponse `json:"fallbackResponse,omitempty"`
+	// UnsupportedParameters is the list of the behaviors of the routes on the request parameters that the selected
+	// backend cannot honor. The routes not listed drop these parameters. Optional.
+	UnsupportedParameters []UnsupportedParameters `json:"unsupportedParameters,omitempty"`
+	// HistoryPolicies is the list of the bounds of the conversation history of the chat completion requests of the
+	// routes. Optional.
+	HistoryPolicies []HistoryPolicy `json:"historyPolicies,omitempty"`
+	// ReasoningBudgets is the list of the caps on the reasoning of the requests of the routes. Optional.
+	ReasoningBudgets []ReasoningBudget `json:"reasoningBudgets,omitempty"`
+	// ToolCallLoopGuards is the list of the detections of the tool call loops in the conversations of the routes.
+	// Optional.
+	ToolCallLoopGuards []ToolCallLoopGuard `json:"toolCallLoopGuards,omitempty"`
+	// AllowedEndpoints is the list of the API endpoints served by the routes. The routes not listed serve all of
+	// them. Optional.
+	AllowedEndpoints []AllowedEndpoints `json:"allowedEndpoints,omitempty"`
+	// ContextWindows is the list of the context windows of the models declared by the routes. Optional.
+	ContextWindows []ContextWindow `json:"contextWindows,omitempty"`
+	// ResponseAttestation signs the attestations attached to the responses of the backends. Optional.
+	ResponseAttestation *ResponseAttestation `json:"responseAttestation,omitempty"`
+	// DebugEcho enables returning the translated requests instead of sending them to the backends on the requests
+	// asking for it. Optional.
+	DebugEcho bool `json:"debugEcho,omitempty"`
+	// ModelNameHeaderKey is the request header set to the model name for the routing, which lets the installations
+	// integrating with existing Envoy filter chains avoid a collision. Empty means
+	// internalapi.ModelNameHeaderKeyDefault. Optional.
+	ModelNameHeaderKey string `json:"modelNameHeaderKey,omitempty"`
+	// MetadataNamespace is the namespace of the dynamic metadata emitted by the filter, e.g. the token usage and the
+	// costs. Empty means internalapi.AIGatewayFilterMetadataNamespace. Optional.
+	MetadataNamespace string `json:"metadataNamespace,omitempty"`
+	// SelectedBackendHeaderKey is the request header selecting the backend on the AIGatewayRoutes configuring a
+	// BackendOverride, whose signature is in the header of the same name suffixed with
+	// internalapi.BackendOverrideSignatureHeaderSuffix. Empty means internalapi.BackendOverrideHeader. Optional.
+	SelectedBackendHeaderKey string `json:"selectedBackendHeaderKey,omitempty"`
+}
+
+// ResponseAttestation signs the attestations attached to the responses, which allow the downstream systems to verify
+// that a response transited the gateway.
+type ResponseAttestation struct {
+	// Identity is the identity of the gateway set in the "iss" claim of the attestations.
+	Identity string `json:"identity"`
+	// PrivateKey is the PEM-encoded PKCS #8 Ed25519 private key signing the attestations.
+	PrivateKey string `json:"privateKey"`
+}
+
+// HistoryElisionStrategy corresponds to HistoryElisionStrategy in api/v1beta1/ai_gateway_route.go.
+type HistoryElisionStrategy string
+
+const (
+	// HistoryElisionStrategyTruncate drops the elided turns.
+	HistoryElisionStrategyTruncate HistoryElisionStrategy = "Truncate"
+	// HistoryElisionStrategySummarize replaces the elided turns with a system message summarizing them.
+	HistoryElisionStrategySummarize HistoryElisionStrategy = "Summarize"
+)
+
+// HistoryPolicy bounds the conversation history of the chat completion requests of a route.
+type HistoryPolicy struct {
+	// RouteName is the AIGatewayRoute (format "namespace/name") this policy applies to.
+	RouteName string `json:"routeName"`
+	// MaxTurns is the maximum number of the turns sent to the backend. Zero disables the bound.
+	MaxTurns int `json:"maxTurns,omitempty"`
+	// MaxInputTokens is the maximum number of the estimated input tokens of the messages sent to the backend.
+	// Zero disables the bound.
+	MaxInputTokens int `json:"maxInputTokens,omitempty"`
+	// Strategy is how the turns beyond the bounds are elided.
+	Strategy HistoryElisionStrategy `json:"strategy"`
+	// Summarization configures the model summarizing the elided turns. Required by HistoryElisionStrategySummarize.
+	Summarization *HistorySummarization `json:"summarization,omitempty"`
+}
+
+// HistorySummarization configures the model summarizing the elided turns of the conversations.
+type HistorySummarization struct {
+	// URL is the URL of the OpenAI compatible chat completions endpoint of the model.
+	URL string `json:"url"`
+	// Model is the name of the model.
+	Model string `json:"model"`
+	// TimeoutMilliseconds is the timeout of the summarization of a request.
+	TimeoutMilliseconds int `json:"timeoutMilliseconds"`
+}
+
+// ReasoningEfforts are the reasoning efforts of the requests in the increasing order.
+var ReasoningEfforts = []string{"none", "minimal", "low", "medium", "high", "xhigh", "max"}
+
+// ReasoningBudget caps the reasoning of the requests of a route independently of their output tokens.
+type ReasoningBudget struct {
+	// RouteName is the AIGatewayRoute (format "namespace/name") this budget applies to.
+	RouteName string `json:"routeName"`
+	// MaxReasoningTokens is the maximum number of the reasoning tokens of a request. Zero disables the cap.
+	MaxReasoningTokens int `json:"maxReasoningTokens,omitempty"`
+	// MaxEffort is the maximum reasoning effort of a request, one of ReasoningEfforts. Empty disables the cap.
+	MaxEffort string `json:"maxEffort,omitempty"`
+	// HardStop terminates the streaming chat completions once their reasoning exceeds MaxReasoningTokens.
+	HardStop bool `json:"hardStop,omitempty"`
+}
+
+// ToolCallLoopAction corresponds to ToolCallLoopAction in api/v1beta1/ai_gateway_route.go.
+type ToolCallLoopAction string
+
+const (
+	// ToolCallLoopActionReject rejects the requests of the looping conversations.
+	ToolCallLoopActionReject ToolCallLoopAction = "Reject"
+	// ToolCallLoopActionHint appends a system message telling the model that it is repeating the call.
+	ToolCallLoopActionHint ToolCallLoopAction = "Hint"
+)
+
+// ToolCallLoopGuard detects the tool call loops in the conversations of the requests of a route.
+type ToolCallLoopGuard struct {
+	// RouteName is the AIGatewayRoute (format "namespace/name") this guard applies to.
+	RouteName string `json:"routeName"`
+	// MaxRepetitions is the number of the identical calls of a function in a conversation at which it is looping.
+	MaxRepetitions int `json:"maxRepetitions"`
+	// Action is what is done with the requests of the looping conversations.
+	Action ToolCallLoopAction `json:"action"`
+}
+
+// Endpoint corresponds to AIGatewayRouteEndpoint in api/v1beta1/ai_gateway_route.go.
+type Endpoint string
+
+const (
+	// EndpointChat is the chat completions, completions, responses and messages endpoints.
+	EndpointChat Endpoint = "Chat"
+	// EndpointEmbeddings is the embeddings endpoint.
+	EndpointEmbeddings Endpoint = "Embeddings"
+	// EndpointImages is the image generation endpoint.
+	EndpointImages Endpoint = "Images"
+	// EndpointAudio is the speech, transcription and translation endpoints.
+	EndpointAudio Endpoint = "Audio"
+	// EndpointModerations is the moderations endpoint.
+	EndpointModerations Endpoint = "Moderations"
+	// EndpointRerank is the rerank endpoints.
+	EndpointRerank Endpoint = "Rerank"
+	// EndpointTokenize is the tokenize endpoint.
+	EndpointTokenize Endpoint = "Tokenize"
+	// EndpointModels is the models endpoint. The models of the routes not allowing it are left out of Models by the
+	// controller, so it is not checked by the external processor.
+	EndpointModels Endpoint = "Models"
+)
+
+// Endpoints are all the known endpoints.
+var Endpoints = []Endpoint{
+	EndpointChat, EndpointEmbeddings, EndpointImages, EndpointAudio, EndpointModerations, EndpointRerank, EndpointTokenize, EndpointModels,
+}
+
+// AllowedEndpoints limits the API endpoints served by a route.
+type AllowedEndpoints struct {
+	// RouteName is the AIGatewayRoute (format "namespace/name") these endpoints apply to.
+	RouteName string `json:"routeName"`
+	// Endpoints are the endpoints served by the route.
+	Endpoints []Endpoint `json:"endpoints"`
+}
+
+// ContextWindow is the context window of a model declared by a route, as configured by the ModelsContextWindows of
+// the AIGatewayRoute rule declaring it.
+type ContextWindow struct {
+	// RouteName is the AIGatewayRoute (format "namespace/name") declaring the model.
+	RouteName string `json:"routeName"`
+	// Model is the name of the model.
+	Model string `json:"model"`
+	// Tokens is the context window of the model in tokens.
+	Tokens int `json:"tokens"`
+}
+
+// UnsupportedParameterBehavior is the behavior of a route on the request parameters that the selected backend
+// cannot honor.
+type UnsupportedParameterBehavior string
+
+const (
+	// UnsupportedParameterBehaviorDrop silently drops the parameters.
+	UnsupportedParameterBehaviorDrop UnsupportedParameterBehavior = "Drop"
+	// UnsupportedParameterBehaviorWarn drops the parameters and lists them in the
+	// "x-ai-eg-unsupported-parameters" response header.
+	UnsupportedParameterBehaviorWarn UnsupportedParameterBehavior = "Warn"
+	// UnsupportedParameterBehaviorError rejects the requests with 400 Bad Request listing the parameters.
+	UnsupportedParameterBehaviorError UnsupportedParameterBehavior = "Error"
+)
+
+// UnsupportedParameters configures the behavior of a route on the request parameters that the selected backend
+// cannot honor.
+type UnsupportedParameters struct {
+	// RouteName is the AIGatewayRoute (format "namespace/name") this behavior applies to.
+	RouteName string `json:"routeName"`
+	// Behavior is the behavior of the route.
+	Behavior UnsupportedParameterBehavior `json:"behavior"`
+}
+
+// FallbackResponseType is the type of a FallbackResponse.
+type FallbackResponseType string
+
+const (
+	// FallbackResponseTypeError returns an OpenAI error with the message and the status code of the replaced response.
+	FallbackResponseTypeError FallbackResponseType = "Error"
+	// FallbackResponseTypeMessage returns a successful chat completion with the message to the chat completion
+	// requests, and falls back to FallbackResponseTypeError for the other endpoints.
+	FallbackResponseTypeMessage FallbackResponseType = "Message"
+)
+
+// FallbackResponse replaces the error responses returned when no backend can serve a request, e.g. when every
+// backend is unhealthy or over quota.
+type FallbackResponse struct {
+	// StatusCodes are the status codes of the responses replaced by the fallback response.
+	StatusCodes []int `json:"statusCodes"`
+	// Type is the type of the fallback response.
+	Type FallbackResponseType `json:"type"`
+	// Message is the text/template of the message of the fallback response. It is executed with the Model of the
+	// request and the StatusCode of the replaced response.
+	Message string `json:"message"`
+}
+
+// ParameterOverrides bounds the sampling parameters that the callers of a route can override with the
+// "x-aigw-param-*" request headers.
+type ParameterOverrides struct {
+	// RouteName is the AIGatewayRoute (format "namespace/name") these overrides apply to.
+	RouteName string `json:"routeName"`
+	// Temperature bounds the temperature overridden with the "x-aigw-param-temperature" header.
+	// When nil, the requests with the header are rejected.
+	Temperature *TemperatureBounds `json:"temperature,omitempty"`
+	// MaxTokens bounds the maximum number of the output tokens overridden with the "x-aigw-param-max-tokens" header.
+	// When nil, the requests with the header are rejected.
+	MaxTokens *MaxTokensBounds `json:"maxTokens,omitempty"`
+}
+
+// BackendOverride allows the callers of a route to pin the backend of a request with the "x-aigw-backend" request
+// header set to the BackendOverrideName of the backend, signed with the "x-aigw-backend-signature" request header.
+type BackendOverride struct {
+	// RouteName is the AIGatewayRoute (format "namespace/name") this override applies to.
+	RouteName string `json:"routeName"`
+	// HMACKey is the key of the HMAC-SHA256 signatures. When empty, e.g. when the secret holding the key
+	// cannot be read, the requests pinning a backend are rejected.
+	HMACKey string `json:"hmacKey,omitempty"`
+}
+
+// Migration samples the requests of a rule of a route to send them again to the backend the rule is migrated to, so
+// that the responses of both are compared.
+type Migration struct {
+	// RouteName is the AIGatewayRoute (format "namespace/name") of the rule.
+	RouteName string `json:"routeName"`
+	// RuleIndex is the index of the rule in the AIGatewayRoute.
+	RuleIndex int `json:"ruleIndex"`
+	// BackendName is the name of the migration backend, which is a backend of the migration rule of the generated
+	// HTTPRoute.
+	BackendName string `json:"backendName"`
+	// SamplePercent is the percentage of the requests of the rule sent again to the migration backend.
+	SamplePercent int `json:"samplePercent"`
+	// TimeoutMilliseconds is the request timeout of the rule, which bounds the requests sent again to the migration
+	// backend. Zero means the rule has no request timeout.
+	TimeoutMilliseconds int `json:"timeoutMilliseconds,omitempty"`
+}
+
+// TemperatureBounds are the inclusive bounds of an overridden temperature.
+type TemperatureBounds struct {
+	Min float64 `json:"min"`
+	Max float64 `json:"max"`
+}
+
+// MaxTokensBounds are the inclusive bounds of an overridden maximum number of the output tokens.
+type MaxTokensBounds struct {
+	Min int `json:"min"`
+	Max int `json:"max"`
+}
+
+// PromptInjectionDetection configures the heuristic detection of prompt injection and jailbreak attempts.
+type PromptInjectionDetection struct {
+	// BlockThreshold is the risk score from 1 to 100 at or above which the requests are rejected.
+	// Zero means the requests are only annotated with their score.
+	BlockThreshold int `json:"blockThreshold,omitempty"`
+}
+
+// Experiment assigns the requests of a route to the variants of an A/B experiment.
+type Experiment struct {
+	// RouteName is the AIGatewayRoute (format "namespace/name") this experiment applies to.
+	RouteName string `json:"routeName"`
+	// Name is the name of the experiment.
+	Name string `json:"name"`
+	// StickyHeader is the lower-cased name of the request header whose value is hashed to assign the variant.
+	StickyHeader string `json:"stickyHeader"`
+	// Variants is the list of the variants of the experiment.
+	Variants []ExperimentVariant `json:"variants"`
+}
+
+// ExperimentVariant is a variant of an Experiment.
+type ExperimentVariant struct {
+	// Name is the name of the variant.
+	Name string `json:"name"`
+	// Percentage is the percentage of the requests assigned to this variant.
+	Percentage int32 `json:"percentage"`
+	// ModelNameOverride is the model name sent to the backend for the requests assigned to this variant.
+	// When empty, the ModelNameOverride of the backend applies.
+	ModelNameOverride string `json:"modelNameOverride,omitempty"`
+}
+
+// StreamConcurrencyLimit limits the number of concurrent streaming requests per client identified by request headers.
+type StreamConcurrencyLimit struct {
+	// Headers is the list of lower-cased request header names whose values identify the client.
+	// Requests that don't have all of the headers are not subject to this limit.
+	Headers []string `json:"headers"`
+	// MaxConcurrentStreams is the maximum number of concurrent streaming requests per client.
+	MaxConcurrentStreams int `json:"maxConcurrentStreams"`
+	// RetryAfterSeconds is the value of the Retry-After header set on the rejected requests.
+	RetryAfterSeconds int `json:"retryAfterSeconds,omitempty"`
+}
+
+// StreamCoalescing configures when the coalesced events of a streaming response are sent to the client. The
+// events are sent as soon as either of the set conditions is met.
+type StreamCoalescing struct {
+	// MinBytes is the size of the coalesced events at or above which they are sent. Zero disables the condition.
+	MinBytes int `json:"minBytes,omitempty"`
+	// FlushIntervalMilliseconds is the time since the events were last sent after which the coalesced events are
+	// sent. Zero disables the condition.
+	FlushIntervalMilliseconds int `json:"flushIntervalMilliseconds,omitempty"`
+}
+
+// QuotaFallback configures the local token buckets enforced by the filter while the quota rate limit service
+// is unreachable. This is set by the controller from the QuotaPolicies with the local fallback enabled.
+type QuotaFallback struct {
+	// RateLimitServiceAddress is the host:port of the quota rate limit service whose gRPC health is probed.
+	RateLimitServiceAddress string `json:"rateLimitServiceAddress"`
+	// Limits is the list of the limits enforced during an outage of the rate limit service.
+	Limits []QuotaFallbackLimit `json:"limits,omitempty"`
+}
+
+// QuotaFallbackLimit is the local approximation of the default bucket of a QuotaPolicy for a model served by
+// a backend on a route.
+type QuotaFallbackLimit struct {
+	// RouteName is the AIGatewayRoute (format "namespace/name") this limit applies to.
+	RouteName string `json:"routeName"`
+	// Backend is the short name (format "namespace/name") of the AIServiceBackend this limit applies to.
+	Backend string `json:"backend"`
+	// Model is the name of the model this limit applies to.
+	Model string `json:"model"`
+	// MetadataKey is the key of the request cost counted by this limit. When empty, this limit counts the
+	// requests instead.
+	MetadataKey string `json:"metadataKey,omitempty"`
+	// Limit is the number of requests or the cost allowed per window on each filter instance.
+	Limit uint64 `json:"limit"`
+	// WindowSeconds is the length of the window in seconds.
+	WindowSeconds int64 `json:"windowSeconds"`
+}
+
+// QuotaSchedule is the schedule of a quota whose limit varies with the time of the request. The filter stores the
+// name of the active window in the dynamic metadata, from which the rate limit actions of the quota select the
+// limit. This is set by the controller from the QuotaPolicies with schedules.
+type QuotaSchedule struct {
+	// MetadataKey is the key of the dynamic metadata where the name of the active window is stored.
+	MetadataKey string `json:"metadataKey"`
+	// Windows are the windows of the schedule in the order of precedence.
+	Windows []QuotaScheduleWindow `json:"windows"`
+	// DefaultWindow is the name stored when none of the windows is active.
+	DefaultWindow string `json:"defaultWindow"`
+}
+
+// QuotaScheduleWindow is a recurring window of time of a QuotaSchedule.
+type QuotaScheduleWindow struct {
+	// Name is the name of the window stored in the dynamic metadata while it is active.
+	Name string `json:"name"`
+	// Days are the days of the week, e.g. "Monday", on which the window starts. Empty means every day.
+	Days []string `json:"days,omitempty"`
+	// Start is the time of the day at which the window starts, inclusive, in the "HH:MM" format.
+	Start string `json:"start"`
+	// End is the time of the day at which the window ends, exclusive, in the "HH:MM" format. The window ends on
+	// the next day if this isn't after Start.
+	End string `json:"end"`
+	// TimeZone is the IANA time zone of Days, Start and End. Empty means UTC.
+	TimeZone string `json:"timeZone,omitempty"`
 }
 
 // Model corresponds to the OpenAI model object in the OpenAI-compatible APIs
@@ -63,6 +452,17 @@ type Model struct {
 	OwnedBy string
 	// createdAt will be exported as the field of "Created" in OpenAI-compatible API "/models".
 	CreatedAt time.Time
+	// Visibility restricts the tenants to which the model is listed in the "/models" endpoint.
+	// Nil means that the model is listed to every caller.
+	Visibility *ModelVisibility `json:"Visibility,omitempty"`
+}
+
+// ModelVisibility restricts the listing of a model to a set of tenants.
+type ModelVisibility struct {
+	// TenantHeader is the name of the request header carrying the tenant of the caller.
+	TenantHeader string `json:"tenantHeader"`
+	// Tenants are the tenants to which the model is listed.
+	Tenants []string `json:"tenants"`
 }
 
 // GlobalLLMRequestCost specifies gateway-level default request cost configuration.
@@ -107,6 +507,11 @@ type LLMRequestCost struct {
 	// only evaluated when the request's model name matches. This allows a single
 	// metadata key to be shared across models without conflicting overwrites.
 	Model string `json:"model,omitempty"`
+	// StreamCutOff is an optional flag set exclusively by the QuotaPolicy controller.
+	// It is NOT exposed in any user-facing CRD. When true, a streaming chat completion whose cost
+	// reaches the remaining quota reported by the rate limit filter is terminated with the "length"
+	// finish reason, and the cost stored under the metadata key is capped at the remaining quota.
+	StreamCutOff bool `json:"streamCutOff,omitempty"`
 }
 
 // LLMRequestCostType specifies the kind of the request cost calculation.
@@ -178,6 +583,14 @@ const (
 	// Used for Claude models hosted on AWS Bedrock. Supports both OpenAI and Anthropic input formats
 	// depending on the endpoint path, similar to APISchemaGCPAnthropic.
 	APISchemaAWSAnthropic APISchemaName = "AWSAnthropic"
+	// APISchemaSAPAICore represents the SAP AI Core API schema.
+	// Used for OpenAI models hosted on SAP AI Core, which are served from deployment-scoped endpoints.
+	APISchemaSAPAICore APISchemaName = "SAPAICore"
+	// APISchemaIBMWatsonx represents the IBM watsonx.ai API schema.
+	// Used for models deployed on IBM watsonx.ai, which are served from deployment-scoped endpoints.
+	APISchemaIBMWatsonx APISchemaName = "IBMWatsonx"
+	// APISchemaMock represents the mock OpenAI provider built into the external processor.
+	APISchemaMock APISchemaName = "Mock"
 )
 
 // RouteRuleName is the name of the route rule.
@@ -189,6 +602,9 @@ type Backend struct {
 	// Name of the backend including the route name as well as the route rule index.
 	Name              string                        `json:"name"`
 	ModelNameOverride internalapi.ModelNameOverride `json:"modelNameOverride"`
+	// BackendOverrideName is the AIServiceBackend (format "namespace/name") of the backend, which the requests pin
+	// with the selected backend header. Only set for the AIServiceBackends of the routes with a BackendOverride.
+	BackendOverrideName string `json:"backendOverrideName,omitempty"`
 	// Schema specifies the API schema of the output format of requests from.
 	Schema VersionedAPISchema `json:"schema"`
 	// Auth is the authn/z configuration for the backend. Optional.
@@ -197,6 +613,105 @@ type Backend struct {
 	HeaderMutation *HTTPHeaderMutation `json:"httpHeaderMutation,omitempty"`
 	// Body mutations to be applied to the request before sending to the backend. Optional.
 	BodyMutation *HTTPBodyMutation `json:"httpBodyMutation,omitempty"`
+	// PIITokenization configures the reversible tokenization of PII in the request body. Optional.
+	PIITokenization *PIITokenization `json:"piiTokenization,omitempty"`
+	// RecompressRequest is true if the backend accepts the request bodies compressed with the content encoding of
+	// the client. Otherwise, the compressed request bodies modified by the gateway are sent uncompressed.
+	RecompressRequest bool `json:"recompressRequest,omitempty"`
+	// TraceContextPropagation specifies how the trace context of the request is propagated to the backend.
+	// Defaults to TraceContextPropagationTraceContext.
+	TraceContextPropagation TraceContextPropagation `json:"traceContextPropagation,omitempty"`
+	// ContextLengthRetry is how the requests exceeding the context length of the model are retried, as configured
+	// on the route rule of this backend. Empty means they are not retried.
+	ContextLengthRetry ContextLengthRetryStrategy `json:"contextLengthRetry,omitempty"`
+	// FailoverRetry is true if the route rule of this backend re-dispatches the requests failing mid-flight.
+	FailoverRetry bool `json:"failoverRetry,omitempty"`
+	// StreamStallTimeoutMilliseconds is the maximum time between two tokens of the streaming chat completions, as
+	// configured on the route rule of this backend. Zero disables the stall detection.
+	StreamStallTimeoutMilliseconds int `json:"streamStallTimeoutMilliseconds,omitempty"`
+	// LoadReporting configures the load reported by the backend in the headers of its responses. Optional.
+	LoadReporting *BackendLoadReporting `json:"loadReporting,omitempty"`
+	// ExtraBody configures the extra fields of the request bodies accepted by the backend. Optional.
+	ExtraBody *ExtraBody `json:"extraBody,omitempty"`
+}
+
+// ExtraBody corresponds to BackendExtraBody in api/v1beta1/ai_service_backend.go.
+//
+// The extra fields of a request body are its top-level fields that are not part of the API of its endpoint.
+type ExtraBody struct {
+	// AllowedFields is the list of the extra fields sent to the backend as-is.
+	AllowedFields []string `json:"allowedFields,omitempty"`
+	// Policy specifies how the other extra fields are handled. Defaults to ExtraBodyPolicyStrip.
+	Policy ExtraBodyPolicy `json:"policy,omitempty"`
+}
+
+// ExtraBodyPolicy corresponds to ExtraBodyPolicy in api/v1beta1/ai_service_backend.go.
+type ExtraBodyPolicy string
+
+const (
+	// ExtraBodyPolicyStrip removes the extra fields that are not allowed from the request body.
+	ExtraBodyPolicyStrip ExtraBodyPolicy = "Strip"
+	// ExtraBodyPolicyReject rejects the requests with the extra fields that are not allowed.
+	ExtraBodyPolicyReject ExtraBodyPolicy = "Reject"
+)
+
+// BackendLoadReporting corresponds to BackendLoadReporting in api/v1beta1/ai_service_backend.go, with the
+// defaults of the optional fields applied by the controller.
+type BackendLoadReporting struct {
+	// QueueDepthHeader is the lower-cased response header holding the queue depth of the backend.
+	QueueDepthHeader string `json:"queueDepthHeader"`
+	// LoadHeader is the lower-cased response header holding the load of the backend.
+	LoadHeader string `json:"loadHeader"`
+	// MaxQueueDepth is the queue depth from which the requests are shed. Zero disables the threshold.
+	MaxQueueDepth int `json:"maxQueueDepth,omitempty"`
+	// MaxLoadPercent is the load percentage from which the requests are shed. Zero disables the threshold.
+	MaxLoadPercent int `json:"maxLoadPercent,omitempty"`
+	// ReportTTLMilliseconds is the time for which the last report of the backend is used.
+	ReportTTLMilliseconds int `json:"reportTTLMilliseconds"`
+}
+
+// ContextLengthRetryStrategy corresponds to ContextLengthRetryStrategy in api/v1beta1/ai_gateway_route.go.
+type ContextLengthRetryStrategy string
+
+const (
+	// ContextLengthRetryStrategyFallback retries the request on the backend of the next priority of the rule.
+	ContextLengthRetryStrategyFallback ContextLengthRetryStrategy = "Fallback"
+	// ContextLengthRetryStrategyDropOldestMessages retries the chat completion request with the oldest half of the
+	// messages dropped.
+	ContextLengthRetryStrategyDropOldestMessages ContextLengthRetryStrategy = "DropOldestMessages"
+)
+
+// TraceContextPropagation specifies how the trace context of a request is propagated to a backend.
+type TraceContextPropagation string
+
+const (
+	// TraceContextPropagationTraceContext propagates the W3C traceparent and tracestate headers as-is.
+	TraceContextPropagationTraceContext TraceContextPropagation = "TraceContext"
+	// TraceContextPropagationProviderHeaders additionally sets the correlation headers of the provider derived from
+	// the W3C trace context, e.g. x-amzn-trace-id for AWS.
+	TraceContextPropagationProviderHeaders TraceContextPropagation = "ProviderHeaders"
+	// TraceContextPropagationNone removes the trace context headers from the requests to the backend.
+	TraceContextPropagationNone TraceContextPropagation = "None"
+)
+
+// PIITokenization corresponds to PIITokenization in api/v1beta1/ai_service_backend.go.
+//
+// When configured, the detected PII in the JSON string values of the request body is replaced with stable
+// placeholders such as "[PII_EMAIL_1]" before the request is sent to the backend, and the placeholders in the
+// response body are restored to the original values. The mapping is kept only in the request-scoped memory.
+type PIITokenization struct {
+	// Detectors is the list of built-in detector names, e.g. "Email". See redaction.BuiltinDetector.
+	Detectors []string `json:"detectors,omitempty"`
+	// CustomPatterns is the list of user-defined detectors.
+	CustomPatterns []PIIPattern `json:"customPatterns,omitempty"`
+}
+
+// PIIPattern is a user-defined PII detector backed by a regular expression.
+type PIIPattern struct {
+	// Name is the category name used in the placeholders.
+	Name string `json:"name"`
+	// Regex is the RE2 regular expression matching the PII.
+	Regex string `json:"regex"`
 }
 
 // BackendAuth corresponds partially to BackendSecurityPolicy in api/v1alpha1/api.go.
@@ -213,6 +728,10 @@ type BackendAuth struct {
 	AzureAuth *AzureAuth `json:"azure,omitempty"`
 	// GCPAuth specifies the location of GCP credential file.
 	GCPAuth *GCPAuth `json:"gcp,omitempty"`
+	// IBMCloudAuth is the IBM Cloud IAM access token used to access IBM watsonx.ai.
+	IBMCloudAuth *IBMCloudAuth `json:"ibmCloud,omitempty"`
+	// SAPAICoreAuth is the access token and the resource group used to access SAP AI Core.
+	SAPAICoreAuth *SAPAICoreAuth `json:"sapAICore,omitempty"`
 	// CredentialOverride, when non-nil, sources the credential per-request instead of the
 	// static credential above. nil disables per-request sourcing (the default).
 	CredentialOverride *CredentialOverride `json:"credentialOverride,omitempty"`
@@ -246,6 +765,8 @@ type AWSAuth struct {
 	// [default]\naws_access_key_id = <access-key-id>\naws_secret_access_key = <secret-access-key>\naws_session_token = <session-token>.
 	CredentialFileLiteral string `json:"credentialFileLiteral,omitempty"`
 	Region                string `json:"region"`
+	// RegionSet is the set of the regions the requests are signed for with SigV4a instead of SigV4. Optional.
+	RegionSet []string `json:"regionSet,omitempty"`
 }
 
 // LogValue implements slog.LogValuer for AWSAuth to redact sensitive information.
@@ -253,18 +774,44 @@ func (a AWSAuth) LogValue() slog.Value {
 	return slog.GroupValue(
 		slog.String("credentialFileLiteral", "[REDACTED]"),
 		slog.String("region", a.Region),
+		slog.Any("regionSet", a.RegionSet),
 	)
 }
 
 // APIKeyAuth defines the file that will be mounted to the external proc.
 type APIKeyAuth struct {
+	// Key is the API key as a literal string. Empty when Pool is set.
+	Key string `json:"key"`
+	// Pool is the list of the API keys across which the requests are spread instead of Key. Optional.
+	Pool []APIKeyPoolEntry `json:"pool,omitempty"`
+	// Organization is the OpenAI organization ID set in the OpenAI-Organization header. Optional.
+	Organization string `json:"organization,omitempty"`
+	// Project is the OpenAI project ID set in the OpenAI-Project header. Optional.
+	Project string `json:"project,omitempty"`
+}
+
+// APIKeyPoolEntry is an API key of an APIKeyAuth pool.
+type APIKeyPoolEntry struct {
+	// Name identifies the key in the metrics, in the "namespace/policy/name" format.
+	Name string `json:"name"`
 	// Key is the API key as a literal string.
 	Key string `json:"key"`
+	// Weight is the proportion of the requests sent with the key. Zero means the key is not used.
+	Weight int `json:"weight"`
 }
 
 // LogValue implements slog.LogValuer for APIKeyAuth to redact sensitive information.
 func (a APIKeyAuth) LogValue() slog.Value {
-	return slog.GroupValue(slog.String("key", "[REDACTED]"))
+	pool := make([]string, len(a.Pool))
+	for i := range a.Pool {
+		pool[i] = a.Pool[i].Name
+	}
+	return slog.GroupValue(
+		slog.String("key", "[REDACTED]"),
+		slog.Any("pool", pool),
+		slog.String("organization", a.Organization),
+		slog.String("project", a.Project),
+	)
 }
 
 // AzureAPIKeyAuth defines the Azure OpenAI API key.
@@ -325,6 +872,36 @@ func (g GCPAuth) LogValue() slog.Value {
 	)
 }
 
+// IBMCloudAuth defines the IBM Cloud IAM access token exchanged for the API key by the controller.
+type IBMCloudAuth struct {
+	// AccessToken is the access token as a literal string.
+	// The token is automatically rotated by the BackendSecurityPolicy controller before expiration.
+	AccessToken string `json:"accessToken"`
+}
+
+// LogValue implements slog.LogValuer for IBMCloudAuth to redact sensitive information.
+func (i IBMCloudAuth) LogValue() slog.Value {
+	return slog.GroupValue(slog.String("accessToken", "[REDACTED]"))
+}
+
+// SAPAICoreAuth defines the SAP AI Core access token obtained with the client credentials of the service key
+// by the controller, and the resource group of the deployments.
+type SAPAICoreAuth struct {
+	// AccessToken is the access token as a literal string.
+	// The token is automatically rotated by the BackendSecurityPolicy controller before expiration.
+	AccessToken string `json:"accessToken"`
+	// ResourceGroup is the resource group sent in the "AI-Resource-Group" header.
+	ResourceGroup string `json:"resourceGroup"`
+}
+
+// LogValue implements slog.LogValuer for SAPAICoreAuth to redact sensitive information.
+func (s SAPAICoreAuth) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("accessToken", "[REDACTED]"),
+		slog.String("resourceGroup", s.ResourceGroup),
+	)
+}
+
 // HTTPHeaderMutation defines the mutation of HTTP headers that will be applied to the request
 type HTTPHeaderMutation struct {
 	// Set overwrites the request with the given header (name, value)
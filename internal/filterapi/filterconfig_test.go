@@ -73,17 +73,25 @@ func logAttrs(v slog.Value) map[string]string {
 }
 
 func TestAWSAuthLogValue(t *testing.T) {
-	a := filterapi.AWSAuth{CredentialFileLiteral: "secret-creds", Region: "us-east-1"}
+	a := filterapi.AWSAuth{CredentialFileLiteral: "secret-creds", Region: "us-east-1", RegionSet: []string{"us-east-1", "us-west-2"}}
 	attrs := logAttrs(a.LogValue())
 	require.Equal(t, "[REDACTED]", attrs["credentialFileLiteral"])
 	require.Equal(t, "us-east-1", attrs["region"])
+	require.Equal(t, "[us-east-1 us-west-2]", attrs["regionSet"])
 }
 
 func TestAPIKeyAuthLogValue(t *testing.T) {
-	a := filterapi.APIKeyAuth{Key: "my-api-key"}
+	a := filterapi.APIKeyAuth{Key: "my-api-key", Organization: "org-abc123", Project: "proj_abc123"}
 	attrs := logAttrs(a.LogValue())
 	require.Equal(t, "[REDACTED]", attrs["key"])
 	require.NotContains(t, attrs["key"], "my-api-key")
+	require.Equal(t, "org-abc123", attrs["organization"])
+	require.Equal(t, "proj_abc123", attrs["project"])
+
+	// Only the names of the keys of the pool are logged.
+	a = filterapi.APIKeyAuth{Pool: []filterapi.APIKeyPoolEntry{{Name: "ns/bsp/a", Key: "key-a", Weight: 1}}}
+	attrs = logAttrs(a.LogValue())
+	require.Equal(t, "[ns/bsp/a]", attrs["pool"])
 }
 
 func TestAzureAPIKeyAuthLogValue(t *testing.T) {
@@ -112,6 +120,19 @@ func TestGCPAuthLogValue(t *testing.T) {
 	require.Equal(t, "my-project", attrs["projectName"])
 }
 
+func TestIBMCloudAuthLogValue(t *testing.T) {
+	i := filterapi.IBMCloudAuth{AccessToken: "iam-token"}
+	attrs := logAttrs(i.LogValue())
+	require.Equal(t, "[REDACTED]", attrs["accessToken"])
+}
+
+func TestSAPAICoreAuthLogValue(t *testing.T) {
+	a := filterapi.SAPAICoreAuth{AccessToken: "xsuaa-token", ResourceGroup: "default"}
+	attrs := logAttrs(a.LogValue())
+	require.Equal(t, "[REDACTED]", attrs["accessToken"])
+	require.Equal(t, "default", attrs["resourceGroup"])
+}
+
 func TestHTTPHeaderLogValue(t *testing.T) {
 	h := filterapi.HTTPHeader{Name: "authorization", Value: "Bearer secret-token"}
 	attrs := logAttrs(h.LogValue())
@@ -6,13 +6,21 @@
 package filterapi
 
 import (
+	"cmp"
 	"context"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
 	"fmt"
+	"text/template"
+	"time"
 
 	"github.com/google/cel-go/cel"
 
 	"github.com/envoyproxy/ai-gateway/internal/internalapi"
 	"github.com/envoyproxy/ai-gateway/internal/llmcostcel"
+	"github.com/envoyproxy/ai-gateway/internal/redaction"
 )
 
 // BackendAuthHandler is the interface that deals with the backend auth for a specific backend.
@@ -22,6 +30,13 @@ type BackendAuthHandler interface {
 	Do(ctx context.Context, requestHeaders map[string]string, mutatedBody []byte) ([]internalapi.Header, error)
 }
 
+// BackendAuthResponseObserver is implemented by the BackendAuthHandlers that observe the status of the responses to
+// the requests they authenticated, e.g. to stop using the credentials rejected by the backend.
+type BackendAuthResponseObserver interface {
+	// ObserveResponse is called with the request headers passed to Do and the status of the response.
+	ObserveResponse(ctx context.Context, requestHeaders map[string]string, status int)
+}
+
 // NewBackendAuthHandlerFunc is a function type that creates a new BackendAuthHandler for a given BackendAuth configuration.
 type NewBackendAuthHandlerFunc func(ctx context.Context, auth *BackendAuth) (BackendAuthHandler, error)
 
@@ -45,6 +60,85 @@ type RuntimeConfig struct {
 	UnscopedModels []Model
 	// Backends is the map of backends by name.
 	Backends map[string]*RuntimeBackend
+	// StreamConcurrencyLimits is the list of per-client concurrent streaming request limits.
+	StreamConcurrencyLimits []StreamConcurrencyLimit
+	// TruncatedStreamErrorEvent enables sending an error event to the client when a streaming chat completion
+	// ends without its terminating event.
+	TruncatedStreamErrorEvent bool
+	// StreamCoalescing coalesces the small events of the streaming responses. Nil if not configured.
+	StreamCoalescing *StreamCoalescing
+	// QuotaFallback is the local rate limiting applied while the quota rate limit service is unreachable.
+	QuotaFallback *QuotaFallback
+	// QuotaSchedules is the list of the schedules of the quotas with their windows parsed.
+	QuotaSchedules []RuntimeQuotaSchedule
+	// Experiments is the map of the A/B experiments by route name.
+	Experiments map[string]*Experiment
+	// PromptInjectionDetection is the detection of prompt injection attempts. Nil if not configured.
+	PromptInjectionDetection *PromptInjectionDetection
+	// ParameterOverrides is the map of the bounds of the overridable sampling parameters by route name.
+	ParameterOverrides map[string]*ParameterOverrides
+	// BackendOverrides is the map of the keys verifying the requests pinning a backend by route name.
+	BackendOverrides map[string]*BackendOverride
+	// Migrations is the map of the migrations of the rules by route name.
+	Migrations map[string][]*Migration
+	// FallbackResponse is the fallback response with its compiled message template. Nil if not configured.
+	FallbackResponse *RuntimeFallbackResponse
+	// UnsupportedParameters is the map of the behaviors on the unsupported request parameters by route name.
+	UnsupportedParameters map[string]UnsupportedParameterBehavior
+	// HistoryPolicies is the map of the bounds of the conversation history by route name.
+	HistoryPolicies map[string]*HistoryPolicy
+	// ReasoningBudgets is the map of the caps on the reasoning of the requests by route name.
+	ReasoningBudgets map[string]*ReasoningBudget
+	// ToolCallLoopGuards is the map of the detections of the tool call loops by route name.
+	ToolCallLoopGuards map[string]*ToolCallLoopGuard
+	// AllowedEndpoints is the map of the API endpoints served by route name. The routes not in the map serve all of
+	// them.
+	AllowedEndpoints map[string][]Endpoint
+	// ContextWindows is the map of the context windows in tokens by route name and model name. When a route declares
+	// a model several times, the smallest context window is used.
+	ContextWindows map[string]map[string]int
+	// ResponseAttestation is the response attestation with its parsed private key. Nil if not configured.
+	ResponseAttestation *RuntimeResponseAttestation
+	// DebugEcho enables returning the translated requests instead of sending them on the requests asking for it.
+	DebugEcho bool
+	// ModelNameHeaderKey is the request header set to the model name for the routing.
+	ModelNameHeaderKey string
+	// MetadataNamespace is the namespace of the dynamic metadata emitted by the filter.
+	MetadataNamespace string
+	// SelectedBackendHeaderKey is the request header selecting the backend on the routes configuring a
+	// BackendOverride.
+	SelectedBackendHeaderKey string
+}
+
+// RuntimeResponseAttestation is the filterapi.ResponseAttestation with its parsed private key.
+type RuntimeResponseAttestation struct {
+	*ResponseAttestation
+	Key ed25519.PrivateKey
+}
+
+// RuntimeFallbackResponse is the filterapi.FallbackResponse with its compiled message template.
+type RuntimeFallbackResponse struct {
+	*FallbackResponse
+	Template *template.Template
+}
+
+// RuntimeQuotaSchedule is the filterapi.QuotaSchedule with its windows parsed.
+type RuntimeQuotaSchedule struct {
+	*QuotaSchedule
+	// Windows are the parsed windows in the order of precedence.
+	Windows []RuntimeQuotaScheduleWindow
+}
+
+// RuntimeQuotaScheduleWindow is the parsed filterapi.QuotaScheduleWindow.
+type RuntimeQuotaScheduleWindow struct {
+	// Name is the name of the window.
+	Name string
+	// Days are the days of the week on which the window starts. Empty means every day.
+	Days []time.Weekday
+	// Start and End are the times of the day at which the window starts and ends, as the duration since midnight.
+	Start, End time.Duration
+	// Location is the time zone of Days, Start and End.
+	Location *time.Location
 }
 
 // RuntimeBackend is a filter backend with its auth handler that is derived from the filterapi.Backend configuration.
@@ -53,6 +147,8 @@ type RuntimeBackend struct {
 	Backend *Backend
 	// Handler is the backend auth handler.
 	Handler BackendAuthHandler
+	// PIIDetectors is the list of detectors compiled from Backend.PIITokenization. Empty if not configured.
+	PIIDetectors []redaction.Detector
 }
 
 // RuntimeGlobalRequestCost is the configuration for gateway-level default request costs.
@@ -83,7 +179,12 @@ func NewRuntimeConfig(ctx context.Context, config *Config, fn NewBackendAuthHand
 			}
 		}
 
-		backends[b.Name] = &RuntimeBackend{Backend: b, Handler: h}
+		detectors, err := newPIIDetectors(b.PIITokenization)
+		if err != nil {
+			return nil, fmt.Errorf("cannot create PII detectors for backend %s: %w", b.Name, err)
+		}
+
+		backends[b.Name] = &RuntimeBackend{Backend: b, Handler: h, PIIDetectors: detectors}
 	}
 
 	// Compile CEL programs for GlobalLLMRequestCosts (gateway-level defaults).
@@ -123,13 +224,211 @@ func NewRuntimeConfig(ctx context.Context, config *Config, fn NewBackendAuthHand
 		costs = append(costs, RuntimeRequestCost{LLMRequestCost: c, CELProg: prog})
 	}
 
+	experiments := make(map[string]*Experiment, len(config.Experiments))
+	for i := range config.Experiments {
+		e := &config.Experiments[i]
+		experiments[e.RouteName] = e
+	}
+
+	parameterOverrides := make(map[string]*ParameterOverrides, len(config.ParameterOverrides))
+	for i := range config.ParameterOverrides {
+		o := &config.ParameterOverrides[i]
+		parameterOverrides[o.RouteName] = o
+	}
+
+	backendOverrides := make(map[string]*BackendOverride, len(config.BackendOverrides))
+	for i := range config.BackendOverrides {
+		o := &config.BackendOverrides[i]
+		backendOverrides[o.RouteName] = o
+	}
+
+	migrations := make(map[string][]*Migration, len(config.Migrations))
+	for i := range config.Migrations {
+		m := &config.Migrations[i]
+		migrations[m.RouteName] = append(migrations[m.RouteName], m)
+	}
+
+	unsupportedParameters := make(map[string]UnsupportedParameterBehavior, len(config.UnsupportedParameters))
+	for _, u := range config.UnsupportedParameters {
+		unsupportedParameters[u.RouteName] = u.Behavior
+	}
+
+	historyPolicies := make(map[string]*HistoryPolicy, len(config.HistoryPolicies))
+	for i := range config.HistoryPolicies {
+		h := &config.HistoryPolicies[i]
+		historyPolicies[h.RouteName] = h
+	}
+
+	reasoningBudgets := make(map[string]*ReasoningBudget, len(config.ReasoningBudgets))
+	for i := range config.ReasoningBudgets {
+		b := &config.ReasoningBudgets[i]
+		reasoningBudgets[b.RouteName] = b
+	}
+
+	toolCallLoopGuards := make(map[string]*ToolCallLoopGuard, len(config.ToolCallLoopGuards))
+	for i := range config.ToolCallLoopGuards {
+		g := &config.ToolCallLoopGuards[i]
+		toolCallLoopGuards[g.RouteName] = g
+	}
+
+	allowedEndpoints := make(map[string][]Endpoint, len(config.AllowedEndpoints))
+	for i := range config.AllowedEndpoints {
+		a := &config.AllowedEndpoints[i]
+		allowedEndpoints[a.RouteName] = a.Endpoints
+	}
+
+	contextWindows := make(map[string]map[string]int)
+	for i := range config.ContextWindows {
+		w := &config.ContextWindows[i]
+		if contextWindows[w.RouteName] == nil {
+			contextWindows[w.RouteName] = make(map[string]int)
+		}
+		if tokens, ok := contextWindows[w.RouteName][w.Model]; !ok || w.Tokens < tokens {
+			contextWindows[w.RouteName][w.Model] = w.Tokens
+		}
+	}
+
+	quotaSchedules := make([]RuntimeQuotaSchedule, 0, len(config.QuotaSchedules))
+	for i := range config.QuotaSchedules {
+		qs := &config.QuotaSchedules[i]
+		windows := make([]RuntimeQuotaScheduleWindow, 0, len(qs.Windows))
+		for j := range qs.Windows {
+			w, err := newRuntimeQuotaScheduleWindow(&qs.Windows[j])
+			if err != nil {
+				return nil, fmt.Errorf("cannot parse window %q of quota schedule %s: %w", qs.Windows[j].Name, qs.MetadataKey, err)
+			}
+			windows = append(windows, w)
+		}
+		quotaSchedules = append(quotaSchedules, RuntimeQuotaSchedule{QuotaSchedule: qs, Windows: windows})
+	}
+
+	var fallback *RuntimeFallbackResponse
+	if f := config.FallbackResponse; f != nil {
+		tmpl, err := template.New("fallback").Parse(f.Message)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse fallback response message template: %w", err)
+		}
+		fallback = &RuntimeFallbackResponse{FallbackResponse: f, Template: tmpl}
+	}
+
+	var attestation *RuntimeResponseAttestation
+	if a := config.ResponseAttestation; a != nil {
+		key, err := ParseEd25519PrivateKey(a.PrivateKey)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse response attestation private key: %w", err)
+		}
+		attestation = &RuntimeResponseAttestation{ResponseAttestation: a, Key: key}
+	}
+
 	return &RuntimeConfig{
-		UUID:               config.UUID,
-		Backends:           backends,
-		GlobalRequestCosts: globalCosts,
-		RequestCosts:       costs,
-		DeclaredModels:     config.Models,
-		ModelsByHost:       config.ModelsByHost,
-		UnscopedModels:     config.UnscopedModels,
+		UUID:                      config.UUID,
+		Backends:                  backends,
+		GlobalRequestCosts:        globalCosts,
+		RequestCosts:              costs,
+		DeclaredModels:            config.Models,
+		ModelsByHost:              config.ModelsByHost,
+		UnscopedModels:            config.UnscopedModels,
+		StreamConcurrencyLimits:   config.StreamConcurrencyLimits,
+		TruncatedStreamErrorEvent: config.TruncatedStreamErrorEvent,
+		StreamCoalescing:          config.StreamCoalescing,
+		QuotaFallback:             config.QuotaFallback,
+		QuotaSchedules:            quotaSchedules,
+		Experiments:               experiments,
+		PromptInjectionDetection:  config.PromptInjectionDetection,
+		ParameterOverrides:        parameterOverrides,
+		BackendOverrides:          backendOverrides,
+		Migrations:                migrations,
+		FallbackResponse:          fallback,
+		UnsupportedParameters:     unsupportedParameters,
+		HistoryPolicies:           historyPolicies,
+		ReasoningBudgets:          reasoningBudgets,
+		ToolCallLoopGuards:        toolCallLoopGuards,
+		AllowedEndpoints:          allowedEndpoints,
+		ContextWindows:            contextWindows,
+		ResponseAttestation:       attestation,
+		DebugEcho:                 config.DebugEcho,
+		ModelNameHeaderKey:        cmp.Or(config.ModelNameHeaderKey, internalapi.ModelNameHeaderKeyDefault),
+		MetadataNamespace:         cmp.Or(config.MetadataNamespace, internalapi.AIGatewayFilterMetadataNamespace),
+		SelectedBackendHeaderKey:  cmp.Or(config.SelectedBackendHeaderKey, internalapi.BackendOverrideHeader),
 	}, nil
 }
+
+// ParseEd25519PrivateKey parses the PEM-encoded PKCS #8 Ed25519 private key.
+func ParseEd25519PrivateKey(pemKey string) (ed25519.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(pemKey))
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	edKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("expected an Ed25519 private key, got %T", key)
+	}
+	return edKey, nil
+}
+
+// weekdays maps the names of the days of QuotaScheduleWindow.Days to their time.Weekday.
+var weekdays = map[string]time.Weekday{
+	"Sunday": time.Sunday, "Monday": time.Monday, "Tuesday": time.Tuesday, "Wednesday": time.Wednesday,
+	"Thursday": time.Thursday, "Friday": time.Friday, "Saturday": time.Saturday,
+}
+
+// newRuntimeQuotaScheduleWindow parses the days, the times and the time zone of the window.
+func newRuntimeQuotaScheduleWindow(w *QuotaScheduleWindow) (RuntimeQuotaScheduleWindow, error) {
+	rw := RuntimeQuotaScheduleWindow{Name: w.Name, Location: time.UTC}
+	for _, d := range w.Days {
+		day, ok := weekdays[d]
+		if !ok {
+			return rw, fmt.Errorf("unknown day %q", d)
+		}
+		rw.Days = append(rw.Days, day)
+	}
+	var err error
+	if rw.Start, err = parseTimeOfDay(w.Start); err != nil {
+		return rw, fmt.Errorf("invalid start: %w", err)
+	}
+	if rw.End, err = parseTimeOfDay(w.End); err != nil {
+		return rw, fmt.Errorf("invalid end: %w", err)
+	}
+	if w.TimeZone != "" {
+		if rw.Location, err = time.LoadLocation(w.TimeZone); err != nil {
+			return rw, fmt.Errorf("invalid time zone: %w", err)
+		}
+	}
+	return rw, nil
+}
+
+// parseTimeOfDay parses a "HH:MM" time of the day into the duration since midnight.
+func parseTimeOfDay(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// newPIIDetectors compiles the detectors for the given PIITokenization configuration.
+func newPIIDetectors(cfg *PIITokenization) ([]redaction.Detector, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	detectors := make([]redaction.Detector, 0, len(cfg.Detectors)+len(cfg.CustomPatterns))
+	for _, name := range cfg.Detectors {
+		d, ok := redaction.BuiltinDetector(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown PII detector: %s", name)
+		}
+		detectors = append(detectors, d)
+	}
+	for _, p := range cfg.CustomPatterns {
+		d, err := redaction.NewRegexDetector(p.Name, p.Regex)
+		if err != nil {
+			return nil, err
+		}
+		detectors = append(detectors, d)
+	}
+	return detectors, nil
+}
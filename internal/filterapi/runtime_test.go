@@ -7,11 +7,18 @@ package filterapi
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
 	"github.com/envoyproxy/ai-gateway/internal/llmcostcel"
 )
 
@@ -41,6 +48,12 @@ func TestServer_LoadConfig(t *testing.T) {
 					CreatedAt: now,
 				},
 			},
+			ContextWindows: []ContextWindow{
+				{RouteName: "ns/test-route", Model: "gpt4.4444", Tokens: 128000},
+				// The smallest context window of a model declared several times by a route is used.
+				{RouteName: "ns/test-route", Model: "gpt4.4444", Tokens: 32000},
+				{RouteName: "ns/other-route", Model: "gpt4.4444", Tokens: 64000},
+			},
 		}
 		rc, err := NewRuntimeConfig(t.Context(), config, func(_ context.Context, b *BackendAuth) (BackendAuthHandler, error) {
 			require.NotNil(t, b)
@@ -59,10 +72,28 @@ func TestServer_LoadConfig(t *testing.T) {
 		require.Equal(t, "1 + 1", rc.RequestCosts[1].CEL)
 		prog := rc.RequestCosts[1].CELProg
 		require.NotNil(t, prog)
-		val, err := llmcostcel.EvaluateProgram(prog, "", "", "", 1, 1, 1, 1, 1, 0)
+		val, err := llmcostcel.EvaluateProgram(prog, "", "", "", 1, 1, 1, 1, 1, 0, 0, "", "")
 		require.NoError(t, err)
 		require.Equal(t, uint64(2), val)
 		require.Equal(t, config.Models, rc.DeclaredModels)
+		require.Equal(t, map[string]map[string]int{
+			"ns/test-route":  {"gpt4.4444": 32000},
+			"ns/other-route": {"gpt4.4444": 64000},
+		}, rc.ContextWindows)
+		// The defaults of the installation names are resolved.
+		require.Equal(t, internalapi.ModelNameHeaderKeyDefault, rc.ModelNameHeaderKey)
+		require.Equal(t, internalapi.AIGatewayFilterMetadataNamespace, rc.MetadataNamespace)
+		require.Equal(t, internalapi.BackendOverrideHeader, rc.SelectedBackendHeaderKey)
+	})
+
+	t.Run("installation names", func(t *testing.T) {
+		rc, err := NewRuntimeConfig(t.Context(), &Config{
+			ModelNameHeaderKey: "x-model", MetadataNamespace: "io.example.gateway", SelectedBackendHeaderKey: "x-backend",
+		}, nil)
+		require.NoError(t, err)
+		require.Equal(t, "x-model", rc.ModelNameHeaderKey)
+		require.Equal(t, "io.example.gateway", rc.MetadataNamespace)
+		require.Equal(t, "x-backend", rc.SelectedBackendHeaderKey)
 	})
 
 	t.Run("with global costs", func(t *testing.T) {
@@ -131,4 +162,123 @@ func TestServer_LoadConfig(t *testing.T) {
 		require.Contains(t, err.Error(), "must have non-empty RouteName")
 		require.Contains(t, err.Error(), "missing_route")
 	})
+
+	t.Run("pii tokenization", func(t *testing.T) {
+		config := &Config{
+			Backends: []Backend{
+				{Name: "openai", Schema: VersionedAPISchema{Name: APISchemaOpenAI}, PIITokenization: &PIITokenization{
+					Detectors:      []string{"Email"},
+					CustomPatterns: []PIIPattern{{Name: "employee", Regex: `E-\d{6}`}},
+				}},
+				{Name: "kserve", Schema: VersionedAPISchema{Name: APISchemaOpenAI}},
+			},
+		}
+		rc, err := NewRuntimeConfig(t.Context(), config, nil)
+		require.NoError(t, err)
+		require.Len(t, rc.Backends["openai"].PIIDetectors, 2)
+		require.Equal(t, "EMAIL", rc.Backends["openai"].PIIDetectors[0].Category())
+		require.Equal(t, "EMPLOYEE", rc.Backends["openai"].PIIDetectors[1].Category())
+		require.Empty(t, rc.Backends["kserve"].PIIDetectors)
+	})
+
+	t.Run("experiments", func(t *testing.T) {
+		config := &Config{
+			Experiments: []Experiment{
+				{RouteName: "ns/route1", Name: "exp", StickyHeader: "x-user-id", Variants: []ExperimentVariant{
+					{Name: "control", Percentage: 50},
+					{Name: "treatment", Percentage: 50, ModelNameOverride: "gpt-4o-mini"},
+				}},
+			},
+		}
+		rc, err := NewRuntimeConfig(t.Context(), config, nil)
+		require.NoError(t, err)
+		require.Len(t, rc.Experiments, 1)
+		require.Equal(t, &config.Experiments[0], rc.Experiments["ns/route1"])
+	})
+
+	t.Run("fallback response", func(t *testing.T) {
+		config := &Config{FallbackResponse: &FallbackResponse{
+			StatusCodes: []int{503},
+			Type:        FallbackResponseTypeError,
+			Message:     "{{.Model}} is unavailable",
+		}}
+		rc, err := NewRuntimeConfig(t.Context(), config, nil)
+		require.NoError(t, err)
+		require.Equal(t, config.FallbackResponse, rc.FallbackResponse.FallbackResponse)
+		require.NotNil(t, rc.FallbackResponse.Template)
+
+		config.FallbackResponse.Message = "{{.Model"
+		_, err = NewRuntimeConfig(t.Context(), config, nil)
+		require.ErrorContains(t, err, "cannot parse fallback response message template")
+	})
+
+	t.Run("quota schedules", func(t *testing.T) {
+		config := &Config{QuotaSchedules: []QuotaSchedule{{
+			MetadataKey:   "quota_schedule/ns/policy/0",
+			DefaultWindow: "__default",
+			Windows: []QuotaScheduleWindow{
+				{Name: "business-hours", Days: []string{"Monday", "Friday"}, Start: "09:00", End: "17:30", TimeZone: "America/New_York"},
+				{Name: "night", Start: "22:00", End: "06:00"},
+			},
+		}}}
+		rc, err := NewRuntimeConfig(t.Context(), config, nil)
+		require.NoError(t, err)
+		require.Len(t, rc.QuotaSchedules, 1)
+		require.Equal(t, "__default", rc.QuotaSchedules[0].DefaultWindow)
+		windows := rc.QuotaSchedules[0].Windows
+		require.Len(t, windows, 2)
+		require.Equal(t, []time.Weekday{time.Monday, time.Friday}, windows[0].Days)
+		require.Equal(t, 9*time.Hour, windows[0].Start)
+		require.Equal(t, 17*time.Hour+30*time.Minute, windows[0].End)
+		require.Equal(t, "America/New_York", windows[0].Location.String())
+		require.Empty(t, windows[1].Days)
+		require.Equal(t, time.UTC, windows[1].Location)
+
+		config.QuotaSchedules[0].Windows[1].End = "6am"
+		_, err = NewRuntimeConfig(t.Context(), config, nil)
+		require.ErrorContains(t, err, `cannot parse window "night" of quota schedule quota_schedule/ns/policy/0: invalid end`)
+	})
+
+	t.Run("response attestation", func(t *testing.T) {
+		_, key, err := ed25519.GenerateKey(nil)
+		require.NoError(t, err)
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		require.NoError(t, err)
+		config := &Config{ResponseAttestation: &ResponseAttestation{
+			Identity:   "gw.example.com",
+			PrivateKey: string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		}}
+		rc, err := NewRuntimeConfig(t.Context(), config, nil)
+		require.NoError(t, err)
+		require.Equal(t, config.ResponseAttestation, rc.ResponseAttestation.ResponseAttestation)
+		require.Equal(t, key, rc.ResponseAttestation.Key)
+
+		ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		der, err = x509.MarshalPKCS8PrivateKey(ecKey)
+		require.NoError(t, err)
+		config.ResponseAttestation.PrivateKey = string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+		_, err = NewRuntimeConfig(t.Context(), config, nil)
+		require.ErrorContains(t, err, "cannot parse response attestation private key: expected an Ed25519 private key, got *ecdsa.PrivateKey")
+	})
+
+	t.Run("error - unknown PII detector", func(t *testing.T) {
+		config := &Config{
+			Backends: []Backend{
+				{Name: "openai", PIITokenization: &PIITokenization{Detectors: []string{"Unknown"}}},
+			},
+		}
+		_, err := NewRuntimeConfig(t.Context(), config, nil)
+		require.ErrorContains(t, err, "unknown PII detector: Unknown")
+	})
+
+	t.Run("error - invalid PII pattern", func(t *testing.T) {
+		config := &Config{
+			Backends: []Backend{
+				{Name: "openai", PIITokenization: &PIITokenization{CustomPatterns: []PIIPattern{{Name: "bad", Regex: "("}}}},
+			},
+		}
+		_, err := NewRuntimeConfig(t.Context(), config, nil)
+		require.ErrorContains(t, err, "cannot create PII detectors for backend openai")
+	})
 }
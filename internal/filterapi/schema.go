@@ -0,0 +1,75 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package filterapi
+
+import (
+	"reflect"
+
+	"github.com/google/jsonschema-go/jsonschema"
+
+	"github.com/envoyproxy/ai-gateway/internal/json"
+)
+
+// JSONSchemaID is the identifier of the JSON Schema of the Config returned by JSONSchema.
+const JSONSchemaID = "https://aigateway.envoyproxy.io/schemas/filterapi-config.schema.json"
+
+// JSONSchema returns the JSON Schema of the Config, which is derived from the Go types so that it stays in sync
+// with the filter. The schema checks the structure of the config such as the field names and types, while
+// Validate checks the semantics.
+//
+// The fields are not required in the schema, since the missing fields are decoded as the zero values.
+func JSONSchema() ([]byte, error) {
+	s, err := jsonschema.For[Config](&jsonschema.ForOptions{
+		TypeSchemas: map[reflect.Type]*jsonschema.Schema{
+			reflect.TypeFor[LLMRequestCostType](): enumSchema(
+				LLMRequestCostTypeOutputToken,
+				LLMRequestCostTypeInputToken,
+				LLMRequestCostTypeCachedInputToken,
+				LLMRequestCostTypeCacheCreationInputToken,
+				LLMRequestCostTypeTotalToken,
+				LLMRequestCostTypeReasoningToken,
+				LLMRequestCostTypeCEL,
+			),
+			reflect.TypeFor[AuthorizationAction](): enumSchema(AuthorizationActionAllow, AuthorizationActionDeny),
+			reflect.TypeFor[JWTClaimValueType]():   enumSchema(JWTClaimValueTypeString, JWTClaimValueTypeStringArray),
+			reflect.TypeFor[TraceContextPropagation](): enumSchema(
+				TraceContextPropagationTraceContext,
+				TraceContextPropagationProviderHeaders,
+				TraceContextPropagationNone,
+			),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.Schema = "https://json-schema.org/draft/2020-12/schema"
+	s.ID = JSONSchemaID
+	s.Title = "Envoy AI Gateway filter configuration"
+	dropRequired(s)
+	return json.MarshalIndent(s, "", "  ")
+}
+
+// enumSchema returns the schema of a string type with the given values.
+func enumSchema[T ~string](values ...T) *jsonschema.Schema {
+	s := &jsonschema.Schema{Type: "string"}
+	for _, v := range values {
+		s.Enum = append(s.Enum, string(v))
+	}
+	return s
+}
+
+// dropRequired removes the required properties from s and its subschemas.
+func dropRequired(s *jsonschema.Schema) {
+	if s == nil {
+		return
+	}
+	s.Required = nil
+	for _, p := range s.Properties {
+		dropRequired(p)
+	}
+	dropRequired(s.Items)
+	dropRequired(s.AdditionalProperties)
+}
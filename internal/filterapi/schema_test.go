@@ -0,0 +1,71 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package filterapi
+
+import (
+	"testing"
+
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/internal/json"
+)
+
+func TestJSONSchema(t *testing.T) {
+	raw, err := JSONSchema()
+	require.NoError(t, err)
+
+	var schema jsonschema.Schema
+	require.NoError(t, json.Unmarshal(raw, &schema))
+	require.Equal(t, JSONSchemaID, schema.ID)
+	resolved, err := schema.Resolve(nil)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name   string
+		config string
+		expErr string
+	}{
+		{
+			name: "ok",
+			config: `{
+				"version": "dev",
+				"backends": [{"name": "openai", "schema": {"name": "OpenAI"}, "auth": {"apiKey": {"key": "dummy"}}}],
+				"llmRequestCosts": [{"metadataKey": "key", "routeName": "ns/route", "type": "OutputToken"}],
+				"models": [{"Name": "gpt-4", "OwnedBy": "openai", "CreatedAt": "2025-01-01T00:00:00Z"}]
+			}`,
+		},
+		{
+			name:   "unknown field",
+			config: `{"backends": [{"name": "openai", "weight": 1}]}`,
+			expErr: "weight",
+		},
+		{
+			name:   "unknown cost type",
+			config: `{"llmRequestCosts": [{"metadataKey": "key", "type": "Unknown"}]}`,
+			expErr: "Unknown",
+		},
+		{
+			name:   "wrong type",
+			config: `{"streamConcurrencyLimits": [{"headers": ["x-user-id"], "maxConcurrentStreams": "2"}]}`,
+			expErr: `want "integer"`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var instance any
+			require.NoError(t, json.Unmarshal([]byte(tc.config), &instance))
+			err := resolved.Validate(instance)
+			if tc.expErr == "" {
+				require.NoError(t, err)
+				// The configs valid against the schema can be decoded into the Config.
+				var cfg Config
+				require.NoError(t, json.Unmarshal([]byte(tc.config), &cfg))
+			} else {
+				require.ErrorContains(t, err, tc.expErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,459 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package filterapi
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/envoyproxy/ai-gateway/internal/llmcostcel"
+	"github.com/envoyproxy/ai-gateway/internal/redaction"
+)
+
+// FieldError is a validation error of a single field of the Config.
+type FieldError struct {
+	// Path is the JSON path of the invalid field, e.g. "backends[0].schema.name".
+	Path string
+	// Message describes why the field is invalid.
+	Message string
+}
+
+// Error implements the error interface.
+func (e *FieldError) Error() string {
+	return e.Path + ": " + e.Message
+}
+
+// Validate validates the given Config without loading it, so that the configs written by hand or by other tools
+// can be checked before deployment. The returned error joins a *FieldError for each invalid field.
+func Validate(config *Config) error {
+	v := &validator{}
+	for i := range config.Backends {
+		v.backend(fmt.Sprintf("backends[%d]", i), &config.Backends[i])
+	}
+	v.unique("backends", len(config.Backends), func(i int) string { return config.Backends[i].Name }, "name")
+	for i := range config.GlobalLLMRequestCosts {
+		c := &config.GlobalLLMRequestCosts[i]
+		v.requestCost(fmt.Sprintf("globalLLMRequestCosts[%d]", i), c.MetadataKey, c.Type, c.CEL)
+	}
+	for i := range config.LLMRequestCosts {
+		c := &config.LLMRequestCosts[i]
+		path := fmt.Sprintf("llmRequestCosts[%d]", i)
+		v.required(path+".routeName", c.RouteName)
+		v.requestCost(path, c.MetadataKey, c.Type, c.CEL)
+	}
+	for i := range config.Models {
+		v.model(fmt.Sprintf("models[%d]", i), &config.Models[i])
+	}
+	for host, models := range config.ModelsByHost {
+		for i := range models {
+			v.model(fmt.Sprintf("modelsByHost[%q][%d]", host, i), &models[i])
+		}
+	}
+	for i := range config.UnscopedModels {
+		v.model(fmt.Sprintf("unscopedModels[%d]", i), &config.UnscopedModels[i])
+	}
+	for i := range config.StreamConcurrencyLimits {
+		l := &config.StreamConcurrencyLimits[i]
+		path := fmt.Sprintf("streamConcurrencyLimits[%d]", i)
+		if len(l.Headers) == 0 {
+			v.add(path+".headers", "must not be empty")
+		}
+		if l.MaxConcurrentStreams <= 0 {
+			v.add(path+".maxConcurrentStreams", "must be positive")
+		}
+		if l.RetryAfterSeconds < 0 {
+			v.add(path+".retryAfterSeconds", "must not be negative")
+		}
+	}
+	if f := config.QuotaFallback; f != nil {
+		if len(f.Limits) > 0 {
+			v.required("quotaFallback.rateLimitServiceAddress", f.RateLimitServiceAddress)
+		}
+		for i := range f.Limits {
+			path := fmt.Sprintf("quotaFallback.limits[%d]", i)
+			v.required(path+".routeName", f.Limits[i].RouteName)
+			if f.Limits[i].WindowSeconds <= 0 {
+				v.add(path+".windowSeconds", "must be positive")
+			}
+		}
+	}
+	for i := range config.QuotaSchedules {
+		v.quotaSchedule(fmt.Sprintf("quotaSchedules[%d]", i), &config.QuotaSchedules[i])
+	}
+	v.unique("quotaSchedules", len(config.QuotaSchedules),
+		func(i int) string { return config.QuotaSchedules[i].MetadataKey }, "metadataKey")
+	if d := config.PromptInjectionDetection; d != nil && (d.BlockThreshold < 0 || d.BlockThreshold > 100) {
+		v.add("promptInjectionDetection.blockThreshold", "must be between 0 and 100")
+	}
+	for i := range config.Experiments {
+		v.experiment(fmt.Sprintf("experiments[%d]", i), &config.Experiments[i])
+	}
+	v.unique("experiments", len(config.Experiments), func(i int) string { return config.Experiments[i].RouteName }, "routeName")
+	for i := range config.ParameterOverrides {
+		v.parameterOverrides(fmt.Sprintf("parameterOverrides[%d]", i), &config.ParameterOverrides[i])
+	}
+	v.unique("parameterOverrides", len(config.ParameterOverrides),
+		func(i int) string { return config.ParameterOverrides[i].RouteName }, "routeName")
+	for i := range config.BackendOverrides {
+		v.required(fmt.Sprintf("backendOverrides[%d].routeName", i), config.BackendOverrides[i].RouteName)
+	}
+	v.unique("backendOverrides", len(config.BackendOverrides),
+		func(i int) string { return config.BackendOverrides[i].RouteName }, "routeName")
+	for i := range config.Migrations {
+		v.migration(fmt.Sprintf("migrations[%d]", i), &config.Migrations[i])
+	}
+	if f := config.FallbackResponse; f != nil {
+		v.fallbackResponse("fallbackResponse", f)
+	}
+	for i := range config.UnsupportedParameters {
+		v.unsupportedParameters(fmt.Sprintf("unsupportedParameters[%d]", i), &config.UnsupportedParameters[i])
+	}
+	v.unique("unsupportedParameters", len(config.UnsupportedParameters),
+		func(i int) string { return config.UnsupportedParameters[i].RouteName }, "routeName")
+	for i := range config.HistoryPolicies {
+		v.historyPolicy(fmt.Sprintf("historyPolicies[%d]", i), &config.HistoryPolicies[i])
+	}
+	v.unique("historyPolicies", len(config.HistoryPolicies),
+		func(i int) string { return config.HistoryPolicies[i].RouteName }, "routeName")
+	for i := range config.ReasoningBudgets {
+		v.reasoningBudget(fmt.Sprintf("reasoningBudgets[%d]", i), &config.ReasoningBudgets[i])
+	}
+	v.unique("reasoningBudgets", len(config.ReasoningBudgets),
+		func(i int) string { return config.ReasoningBudgets[i].RouteName }, "routeName")
+	for i := range config.ToolCallLoopGuards {
+		v.toolCallLoopGuard(fmt.Sprintf("toolCallLoopGuards[%d]", i), &config.ToolCallLoopGuards[i])
+	}
+	v.unique("toolCallLoopGuards", len(config.ToolCallLoopGuards),
+		func(i int) string { return config.ToolCallLoopGuards[i].RouteName }, "routeName")
+	for i := range config.AllowedEndpoints {
+		v.allowedEndpoints(fmt.Sprintf("allowedEndpoints[%d]", i), &config.AllowedEndpoints[i])
+	}
+	v.unique("allowedEndpoints", len(config.AllowedEndpoints),
+		func(i int) string { return config.AllowedEndpoints[i].RouteName }, "routeName")
+	for i := range config.ContextWindows {
+		w := &config.ContextWindows[i]
+		path := fmt.Sprintf("contextWindows[%d]", i)
+		v.required(path+".routeName", w.RouteName)
+		v.required(path+".model", w.Model)
+		if w.Tokens <= 0 {
+			v.add(path+".tokens", "must be positive")
+		}
+	}
+	if a := config.ResponseAttestation; a != nil {
+		v.required("responseAttestation.identity", a.Identity)
+		if _, err := ParseEd25519PrivateKey(a.PrivateKey); err != nil {
+			v.add("responseAttestation.privateKey", err.Error())
+		}
+	}
+	if k := config.ModelNameHeaderKey; k != strings.ToLower(k) {
+		v.add("modelNameHeaderKey", "must be lowercase")
+	}
+	if k := config.SelectedBackendHeaderKey; k != strings.ToLower(k) {
+		v.add("selectedBackendHeaderKey", "must be lowercase")
+	}
+	if config.MCPConfig != nil {
+		for i := range config.MCPConfig.Routes {
+			r := &config.MCPConfig.Routes[i]
+			path := fmt.Sprintf("mcpConfig.routes[%d]", i)
+			v.required(path+".name", r.Name)
+			for j := range r.Backends {
+				v.required(fmt.Sprintf("%s.backends[%d].name", path, j), r.Backends[j].Name)
+			}
+		}
+	}
+	return errors.Join(v.errs...)
+}
+
+// validator accumulates the errors of Validate.
+type validator struct {
+	errs []error
+}
+
+func (v *validator) add(path, message string) {
+	v.errs = append(v.errs, &FieldError{Path: path, Message: message})
+}
+
+func (v *validator) required(path, value string) {
+	if value == "" {
+		v.add(path, "must not be empty")
+	}
+}
+
+// unique reports the duplicated non-empty values of the given field of the list at path.
+func (v *validator) unique(path string, n int, value func(int) string, field string) {
+	seen := make(map[string]int, n)
+	for i := range n {
+		val := value(i)
+		if val == "" {
+			continue
+		}
+		if first, ok := seen[val]; ok {
+			v.add(fmt.Sprintf("%s[%d].%s", path, i, field), fmt.Sprintf("duplicates %s[%d].%s %q", path, first, field, val))
+			continue
+		}
+		seen[val] = i
+	}
+}
+
+func (v *validator) backend(path string, b *Backend) {
+	v.required(path+".name", b.Name)
+	// The custom API schemas can be registered to the translator registry, so only the presence is checked.
+	v.required(path+".schema.name", string(b.Schema.Name))
+	if a := b.Auth; a != nil {
+		var n int
+		for _, set := range []bool{a.APIKey != nil, a.AWSAuth != nil, a.AzureAPIKey != nil, a.AnthropicAPIKey != nil, a.AzureAuth != nil, a.GCPAuth != nil} {
+			if set {
+				n++
+			}
+		}
+		if n > 1 {
+			v.add(path+".auth", "at most one of apiKey, aws, azureAPIKey, anthropicAPIKey, azure and gcp can be set")
+		}
+		if o := a.CredentialOverride; o != nil && (o.HeaderName == "") == (o.DynamicMetadataNamespace == "") {
+			v.add(path+".auth.credentialOverride", "exactly one of headerName and dynamicMetadataNamespace must be set")
+		}
+	}
+	if m := b.HeaderMutation; m != nil {
+		for i := range m.Set {
+			v.required(fmt.Sprintf("%s.httpHeaderMutation.set[%d].name", path, i), m.Set[i].Name)
+		}
+	}
+	if m := b.BodyMutation; m != nil {
+		for i := range m.Set {
+			v.required(fmt.Sprintf("%s.httpBodyMutation.set[%d].path", path, i), m.Set[i].Path)
+		}
+	}
+	switch b.TraceContextPropagation {
+	case "", TraceContextPropagationTraceContext, TraceContextPropagationProviderHeaders, TraceContextPropagationNone:
+	default:
+		v.add(path+".traceContextPropagation", fmt.Sprintf("unknown trace context propagation %q", b.TraceContextPropagation))
+	}
+	if e := b.ExtraBody; e != nil {
+		for i, field := range e.AllowedFields {
+			v.required(fmt.Sprintf("%s.extraBody.allowedFields[%d]", path, i), field)
+		}
+		switch e.Policy {
+		case "", ExtraBodyPolicyStrip, ExtraBodyPolicyReject:
+		default:
+			v.add(path+".extraBody.policy", fmt.Sprintf("unknown extra body policy %q", e.Policy))
+		}
+	}
+	if p := b.PIITokenization; p != nil {
+		for i, name := range p.Detectors {
+			if _, ok := redaction.BuiltinDetector(name); !ok {
+				v.add(fmt.Sprintf("%s.piiTokenization.detectors[%d]", path, i), fmt.Sprintf("unknown PII detector %q", name))
+			}
+		}
+		for i := range p.CustomPatterns {
+			cp := &p.CustomPatterns[i]
+			v.required(fmt.Sprintf("%s.piiTokenization.customPatterns[%d].name", path, i), cp.Name)
+			if _, err := redaction.NewRegexDetector(cp.Name, cp.Regex); err != nil {
+				v.add(fmt.Sprintf("%s.piiTokenization.customPatterns[%d]", path, i), err.Error())
+			}
+		}
+	}
+}
+
+func (v *validator) model(path string, m *Model) {
+	v.required(path+".Name", m.Name)
+	if vis := m.Visibility; vis != nil {
+		v.required(path+".Visibility.tenantHeader", vis.TenantHeader)
+		if len(vis.Tenants) == 0 {
+			v.add(path+".Visibility.tenants", "must not be empty")
+		}
+	}
+}
+
+func (v *validator) requestCost(path, metadataKey string, typ LLMRequestCostType, expr string) {
+	v.required(path+".metadataKey", metadataKey)
+	switch typ {
+	case LLMRequestCostTypeOutputToken, LLMRequestCostTypeInputToken, LLMRequestCostTypeCachedInputToken,
+		LLMRequestCostTypeCacheCreationInputToken, LLMRequestCostTypeTotalToken, LLMRequestCostTypeReasoningToken:
+	case LLMRequestCostTypeCEL:
+		if expr == "" {
+			v.add(path+".cel", "must not be empty for the CEL type")
+		} else if _, err := llmcostcel.NewProgram(expr); err != nil {
+			v.add(path+".cel", err.Error())
+		}
+	default:
+		v.add(path+".type", fmt.Sprintf("unknown request cost type %q", typ))
+	}
+}
+
+func (v *validator) parameterOverrides(path string, o *ParameterOverrides) {
+	v.required(path+".routeName", o.RouteName)
+	if o.Temperature == nil && o.MaxTokens == nil {
+		v.add(path, "at least one of temperature or maxTokens must be set")
+	}
+	if t := o.Temperature; t != nil {
+		if t.Min < 0 {
+			v.add(path+".temperature.min", "must not be negative")
+		}
+		if t.Min > t.Max {
+			v.add(path+".temperature", fmt.Sprintf("min %v must not exceed max %v", t.Min, t.Max))
+		}
+	}
+	if m := o.MaxTokens; m != nil {
+		if m.Min <= 0 {
+			v.add(path+".maxTokens.min", "must be positive")
+		}
+		if m.Min > m.Max {
+			v.add(path+".maxTokens", fmt.Sprintf("min %d must not exceed max %d", m.Min, m.Max))
+		}
+	}
+}
+
+func (v *validator) migration(path string, m *Migration) {
+	v.required(path+".routeName", m.RouteName)
+	v.required(path+".backendName", m.BackendName)
+	if m.SamplePercent < 1 || m.SamplePercent > 100 {
+		v.add(path+".samplePercent", "must be between 1 and 100")
+	}
+}
+
+func (v *validator) fallbackResponse(path string, f *FallbackResponse) {
+	if len(f.StatusCodes) == 0 {
+		v.add(path+".statusCodes", "must not be empty")
+	}
+	for i, code := range f.StatusCodes {
+		if code < 400 || code > 599 {
+			v.add(fmt.Sprintf("%s.statusCodes[%d]", path, i), fmt.Sprintf("must be an error status code, got %d", code))
+		}
+	}
+	switch f.Type {
+	case FallbackResponseTypeError, FallbackResponseTypeMessage:
+	default:
+		v.add(path+".type", fmt.Sprintf("unknown fallback response type %q", f.Type))
+	}
+	if f.Message == "" {
+		v.add(path+".message", "must not be empty")
+	} else if _, err := template.New("fallback").Parse(f.Message); err != nil {
+		v.add(path+".message", err.Error())
+	}
+}
+
+func (v *validator) unsupportedParameters(path string, u *UnsupportedParameters) {
+	v.required(path+".routeName", u.RouteName)
+	switch u.Behavior {
+	case UnsupportedParameterBehaviorDrop, UnsupportedParameterBehaviorWarn, UnsupportedParameterBehaviorError:
+	default:
+		v.add(path+".behavior", fmt.Sprintf("unknown unsupported parameter behavior %q", u.Behavior))
+	}
+}
+
+func (v *validator) historyPolicy(path string, h *HistoryPolicy) {
+	v.required(path+".routeName", h.RouteName)
+	if h.MaxTurns < 0 {
+		v.add(path+".maxTurns", "must not be negative")
+	}
+	if h.MaxInputTokens < 0 {
+		v.add(path+".maxInputTokens", "must not be negative")
+	}
+	if h.MaxTurns == 0 && h.MaxInputTokens == 0 {
+		v.add(path, "must set maxTurns or maxInputTokens")
+	}
+	switch h.Strategy {
+	case HistoryElisionStrategyTruncate:
+	case HistoryElisionStrategySummarize:
+		if h.Summarization == nil {
+			v.add(path+".summarization", "must be set with the Summarize strategy")
+			return
+		}
+		v.required(path+".summarization.url", h.Summarization.URL)
+		v.required(path+".summarization.model", h.Summarization.Model)
+		if h.Summarization.TimeoutMilliseconds <= 0 {
+			v.add(path+".summarization.timeoutMilliseconds", "must be positive")
+		}
+	default:
+		v.add(path+".strategy", fmt.Sprintf("unknown history elision strategy %q", h.Strategy))
+	}
+}
+
+func (v *validator) reasoningBudget(path string, b *ReasoningBudget) {
+	v.required(path+".routeName", b.RouteName)
+	if b.MaxReasoningTokens < 0 {
+		v.add(path+".maxReasoningTokens", "must not be negative")
+	}
+	if b.MaxEffort != "" && !slices.Contains(ReasoningEfforts, b.MaxEffort) {
+		v.add(path+".maxEffort", fmt.Sprintf("unknown reasoning effort %q", b.MaxEffort))
+	}
+	if b.MaxReasoningTokens == 0 && b.MaxEffort == "" {
+		v.add(path, "must set maxReasoningTokens or maxEffort")
+	}
+	if b.HardStop && b.MaxReasoningTokens == 0 {
+		v.add(path+".hardStop", "requires maxReasoningTokens")
+	}
+}
+
+func (v *validator) toolCallLoopGuard(path string, g *ToolCallLoopGuard) {
+	v.required(path+".routeName", g.RouteName)
+	if g.MaxRepetitions < 2 {
+		v.add(path+".maxRepetitions", "must be at least 2")
+	}
+	switch g.Action {
+	case ToolCallLoopActionReject, ToolCallLoopActionHint:
+	default:
+		v.add(path+".action", fmt.Sprintf("unknown tool call loop action %q", g.Action))
+	}
+}
+
+func (v *validator) allowedEndpoints(path string, a *AllowedEndpoints) {
+	v.required(path+".routeName", a.RouteName)
+	if len(a.Endpoints) == 0 {
+		v.add(path+".endpoints", "must not be empty")
+	}
+	for i, e := range a.Endpoints {
+		if !slices.Contains(Endpoints, e) {
+			v.add(fmt.Sprintf("%s.endpoints[%d]", path, i), fmt.Sprintf("unknown endpoint %q", e))
+		}
+	}
+}
+
+func (v *validator) quotaSchedule(path string, s *QuotaSchedule) {
+	v.required(path+".metadataKey", s.MetadataKey)
+	v.required(path+".defaultWindow", s.DefaultWindow)
+	if len(s.Windows) == 0 {
+		v.add(path+".windows", "must not be empty")
+	}
+	for i := range s.Windows {
+		w := &s.Windows[i]
+		windowPath := fmt.Sprintf("%s.windows[%d]", path, i)
+		v.required(windowPath+".name", w.Name)
+		if w.Name == s.DefaultWindow {
+			v.add(windowPath+".name", fmt.Sprintf("must not be the default window %q", s.DefaultWindow))
+		}
+		if _, err := newRuntimeQuotaScheduleWindow(w); err != nil {
+			v.add(windowPath, err.Error())
+		}
+	}
+	v.unique(path+".windows", len(s.Windows), func(i int) string { return s.Windows[i].Name }, "name")
+}
+
+func (v *validator) experiment(path string, e *Experiment) {
+	v.required(path+".routeName", e.RouteName)
+	v.required(path+".name", e.Name)
+	v.required(path+".stickyHeader", e.StickyHeader)
+	if len(e.Variants) == 0 {
+		v.add(path+".variants", "must not be empty")
+		return
+	}
+	var total int32
+	for i := range e.Variants {
+		vp := fmt.Sprintf("%s.variants[%d]", path, i)
+		v.required(vp+".name", e.Variants[i].Name)
+		if e.Variants[i].Percentage < 0 {
+			v.add(vp+".percentage", "must not be negative")
+		}
+		total += e.Variants[i].Percentage
+	}
+	if total != 100 {
+		v.add(path+".variants", fmt.Sprintf("percentages must sum to 100, got %d", total))
+	}
+	v.unique(path+".variants", len(e.Variants), func(i int) string { return e.Variants[i].Name }, "name")
+}
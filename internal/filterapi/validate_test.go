@@ -0,0 +1,328 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package filterapi
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		config := &Config{
+			Backends: []Backend{
+				{Name: "openai", Schema: VersionedAPISchema{Name: APISchemaOpenAI}, Auth: &BackendAuth{APIKey: &APIKeyAuth{Key: "dummy"}}},
+				// The custom schemas registered to the translator registry are allowed.
+				{Name: "custom", Schema: VersionedAPISchema{Name: "MyCustomSchema"}},
+			},
+			GlobalLLMRequestCosts: []GlobalLLMRequestCost{{MetadataKey: "total", Type: LLMRequestCostTypeTotalToken}},
+			LLMRequestCosts: []LLMRequestCost{
+				{MetadataKey: "cel", RouteName: "ns/route", Type: LLMRequestCostTypeCEL, CEL: "input_tokens + output_tokens"},
+			},
+			Models:                  []Model{{Name: "gpt-4"}},
+			StreamConcurrencyLimits: []StreamConcurrencyLimit{{Headers: []string{"x-user-id"}, MaxConcurrentStreams: 2}},
+			Experiments: []Experiment{{
+				RouteName: "ns/route", Name: "exp", StickyHeader: "x-user-id",
+				Variants: []ExperimentVariant{{Name: "a", Percentage: 30}, {Name: "b", Percentage: 70}},
+			}},
+		}
+		require.NoError(t, Validate(config))
+		require.NoError(t, Validate(&Config{}))
+	})
+
+	for _, tc := range []struct {
+		name      string
+		config    *Config
+		expErrors []string
+	}{
+		{
+			name: "backends",
+			config: &Config{Backends: []Backend{
+				{Name: "openai", Schema: VersionedAPISchema{Name: APISchemaOpenAI}},
+				{Name: "openai", TraceContextPropagation: "Unknown", ExtraBody: &ExtraBody{AllowedFields: []string{"top_k", ""}, Policy: "Unknown"}},
+				{
+					Schema: VersionedAPISchema{Name: APISchemaOpenAI},
+					Auth: &BackendAuth{
+						APIKey:             &APIKeyAuth{Key: "dummy"},
+						AWSAuth:            &AWSAuth{Region: "us-east-1"},
+						CredentialOverride: &CredentialOverride{},
+					},
+					PIITokenization: &PIITokenization{
+						Detectors:      []string{"Email", "Unknown"},
+						CustomPatterns: []PIIPattern{{Name: "id", Regex: "("}},
+					},
+				},
+			}},
+			expErrors: []string{
+				`backends[1].schema.name: must not be empty`,
+				`backends[1].traceContextPropagation: unknown trace context propagation "Unknown"`,
+				`backends[1].extraBody.allowedFields[1]: must not be empty`,
+				`backends[1].extraBody.policy: unknown extra body policy "Unknown"`,
+				`backends[2].name: must not be empty`,
+				`backends[2].auth: at most one of apiKey, aws, azureAPIKey, anthropicAPIKey, azure and gcp can be set`,
+				`backends[2].auth.credentialOverride: exactly one of headerName and dynamicMetadataNamespace must be set`,
+				`backends[2].piiTokenization.detectors[1]: unknown PII detector "Unknown"`,
+				"backends[2].piiTokenization.customPatterns[0]: invalid regular expression for \"id\": error parsing regexp: missing closing ): `(`",
+				`backends[1].name: duplicates backends[0].name "openai"`,
+			},
+		},
+		{
+			name: "costs",
+			config: &Config{
+				GlobalLLMRequestCosts: []GlobalLLMRequestCost{{Type: "Unknown"}},
+				LLMRequestCosts: []LLMRequestCost{
+					{MetadataKey: "key", Type: LLMRequestCostTypeCEL},
+				},
+			},
+			expErrors: []string{
+				`globalLLMRequestCosts[0].metadataKey: must not be empty`,
+				`globalLLMRequestCosts[0].type: unknown request cost type "Unknown"`,
+				`llmRequestCosts[0].routeName: must not be empty`,
+				`llmRequestCosts[0].cel: must not be empty for the CEL type`,
+			},
+		},
+		{
+			name: "limits",
+			config: &Config{
+				StreamConcurrencyLimits:  []StreamConcurrencyLimit{{RetryAfterSeconds: -1}},
+				QuotaFallback:            &QuotaFallback{Limits: []QuotaFallbackLimit{{Limit: 10}}},
+				PromptInjectionDetection: &PromptInjectionDetection{BlockThreshold: 101},
+			},
+			expErrors: []string{
+				`streamConcurrencyLimits[0].headers: must not be empty`,
+				`streamConcurrencyLimits[0].maxConcurrentStreams: must be positive`,
+				`streamConcurrencyLimits[0].retryAfterSeconds: must not be negative`,
+				`quotaFallback.rateLimitServiceAddress: must not be empty`,
+				`quotaFallback.limits[0].routeName: must not be empty`,
+				`quotaFallback.limits[0].windowSeconds: must be positive`,
+				`promptInjectionDetection.blockThreshold: must be between 0 and 100`,
+			},
+		},
+		{
+			name: "experiments",
+			config: &Config{Experiments: []Experiment{
+				{RouteName: "ns/route", Name: "exp", StickyHeader: "x-user-id", Variants: []ExperimentVariant{
+					{Name: "a", Percentage: 50}, {Name: "a", Percentage: 40},
+				}},
+				{RouteName: "ns/route"},
+			}},
+			expErrors: []string{
+				`experiments[0].variants: percentages must sum to 100, got 90`,
+				`experiments[0].variants[1].name: duplicates experiments[0].variants[0].name "a"`,
+				`experiments[1].name: must not be empty`,
+				`experiments[1].stickyHeader: must not be empty`,
+				`experiments[1].variants: must not be empty`,
+				`experiments[1].routeName: duplicates experiments[0].routeName "ns/route"`,
+			},
+		},
+		{
+			name: "parameter overrides",
+			config: &Config{ParameterOverrides: []ParameterOverrides{
+				{
+					RouteName:   "ns/route",
+					Temperature: &TemperatureBounds{Min: 1.5, Max: 1},
+					MaxTokens:   &MaxTokensBounds{Max: 100},
+				},
+				{RouteName: "ns/route"},
+			}},
+			expErrors: []string{
+				`parameterOverrides[0].temperature: min 1.5 must not exceed max 1`,
+				`parameterOverrides[0].maxTokens.min: must be positive`,
+				`parameterOverrides[1]: at least one of temperature or maxTokens must be set`,
+				`parameterOverrides[1].routeName: duplicates parameterOverrides[0].routeName "ns/route"`,
+			},
+		},
+		{
+			name: "backend overrides",
+			config: &Config{BackendOverrides: []BackendOverride{
+				{RouteName: "ns/route", HMACKey: "key"},
+				{RouteName: "ns/route"},
+				{HMACKey: "key"},
+			}},
+			expErrors: []string{
+				`backendOverrides[2].routeName: must not be empty`,
+				`backendOverrides[1].routeName: duplicates backendOverrides[0].routeName "ns/route"`,
+			},
+		},
+		{
+			name: "migrations",
+			config: &Config{Migrations: []Migration{
+				{RouteName: "ns/route", BackendName: "ns/b/route/route/rule/3/ref/0", SamplePercent: 10},
+				{RouteName: "ns/route", SamplePercent: 101},
+			}},
+			expErrors: []string{
+				`migrations[1].backendName: must not be empty`,
+				`migrations[1].samplePercent: must be between 1 and 100`,
+			},
+		},
+		{
+			name: "fallback response",
+			config: &Config{FallbackResponse: &FallbackResponse{
+				StatusCodes: []int{503, 200},
+				Type:        "Canned",
+				Message:     "{{.Model",
+			}},
+			expErrors: []string{
+				`fallbackResponse.statusCodes[1]: must be an error status code, got 200`,
+				`fallbackResponse.type: unknown fallback response type "Canned"`,
+				`fallbackResponse.message: template: fallback:1: unclosed action`,
+			},
+		},
+		{
+			name: "unsupported parameters",
+			config: &Config{UnsupportedParameters: []UnsupportedParameters{
+				{RouteName: "ns/route", Behavior: UnsupportedParameterBehaviorError},
+				{RouteName: "ns/route", Behavior: "Ignore"},
+				{Behavior: UnsupportedParameterBehaviorWarn},
+			}},
+			expErrors: []string{
+				`unsupportedParameters[1].behavior: unknown unsupported parameter behavior "Ignore"`,
+				`unsupportedParameters[2].routeName: must not be empty`,
+				`unsupportedParameters[1].routeName: duplicates unsupportedParameters[0].routeName "ns/route"`,
+			},
+		},
+		{
+			name: "history policies",
+			config: &Config{HistoryPolicies: []HistoryPolicy{
+				{RouteName: "ns/route", MaxTurns: 10, Strategy: HistoryElisionStrategyTruncate},
+				{RouteName: "ns/route", MaxInputTokens: -1, Strategy: "Compress"},
+				{RouteName: "ns/unbounded", Strategy: HistoryElisionStrategyTruncate},
+				{MaxTurns: 10, Strategy: HistoryElisionStrategySummarize},
+				{RouteName: "ns/other", MaxTurns: 10, Strategy: HistoryElisionStrategySummarize, Summarization: &HistorySummarization{}},
+			}},
+			expErrors: []string{
+				`historyPolicies[1].maxInputTokens: must not be negative`,
+				`historyPolicies[1].strategy: unknown history elision strategy "Compress"`,
+				`historyPolicies[2]: must set maxTurns or maxInputTokens`,
+				`historyPolicies[3].routeName: must not be empty`,
+				`historyPolicies[3].summarization: must be set with the Summarize strategy`,
+				`historyPolicies[4].summarization.url: must not be empty`,
+				`historyPolicies[4].summarization.model: must not be empty`,
+				`historyPolicies[4].summarization.timeoutMilliseconds: must be positive`,
+				`historyPolicies[1].routeName: duplicates historyPolicies[0].routeName "ns/route"`,
+			},
+		},
+		{
+			name: "reasoning budgets",
+			config: &Config{ReasoningBudgets: []ReasoningBudget{
+				{RouteName: "ns/route", MaxReasoningTokens: 1024, MaxEffort: "medium", HardStop: true},
+				{RouteName: "ns/route", MaxReasoningTokens: -1, MaxEffort: "extreme"},
+				{RouteName: "ns/unbounded"},
+				{MaxEffort: "low", HardStop: true},
+			}},
+			expErrors: []string{
+				`reasoningBudgets[1].maxReasoningTokens: must not be negative`,
+				`reasoningBudgets[1].maxEffort: unknown reasoning effort "extreme"`,
+				`reasoningBudgets[2]: must set maxReasoningTokens or maxEffort`,
+				`reasoningBudgets[3].routeName: must not be empty`,
+				`reasoningBudgets[3].hardStop: requires maxReasoningTokens`,
+				`reasoningBudgets[1].routeName: duplicates reasoningBudgets[0].routeName "ns/route"`,
+			},
+		},
+		{
+			name: "tool call loop guards",
+			config: &Config{ToolCallLoopGuards: []ToolCallLoopGuard{
+				{RouteName: "ns/route", MaxRepetitions: 3, Action: ToolCallLoopActionHint},
+				{RouteName: "ns/route", MaxRepetitions: 1, Action: "Ignore"},
+				{MaxRepetitions: 2, Action: ToolCallLoopActionReject},
+			}},
+			expErrors: []string{
+				`toolCallLoopGuards[1].maxRepetitions: must be at least 2`,
+				`toolCallLoopGuards[1].action: unknown tool call loop action "Ignore"`,
+				`toolCallLoopGuards[2].routeName: must not be empty`,
+				`toolCallLoopGuards[1].routeName: duplicates toolCallLoopGuards[0].routeName "ns/route"`,
+			},
+		},
+		{
+			name: "allowed endpoints",
+			config: &Config{AllowedEndpoints: []AllowedEndpoints{
+				{RouteName: "ns/route", Endpoints: []Endpoint{EndpointEmbeddings, EndpointModels}},
+				{RouteName: "ns/route", Endpoints: []Endpoint{"Video"}},
+				{},
+			}},
+			expErrors: []string{
+				`allowedEndpoints[1].endpoints[0]: unknown endpoint "Video"`,
+				`allowedEndpoints[2].routeName: must not be empty`,
+				`allowedEndpoints[2].endpoints: must not be empty`,
+				`allowedEndpoints[1].routeName: duplicates allowedEndpoints[0].routeName "ns/route"`,
+			},
+		},
+		{
+			name: "context windows",
+			config: &Config{ContextWindows: []ContextWindow{
+				{RouteName: "ns/route", Model: "gpt-4o", Tokens: 128000},
+				{Tokens: -1},
+			}},
+			expErrors: []string{
+				`contextWindows[1].routeName: must not be empty`,
+				`contextWindows[1].model: must not be empty`,
+				`contextWindows[1].tokens: must be positive`,
+			},
+		},
+		{
+			name:   "response attestation",
+			config: &Config{ResponseAttestation: &ResponseAttestation{PrivateKey: "key"}},
+			expErrors: []string{
+				`responseAttestation.identity: must not be empty`,
+				`responseAttestation.privateKey: no PEM block found`,
+			},
+		},
+		{
+			name:      "model name header key",
+			config:    &Config{ModelNameHeaderKey: "X-Model", SelectedBackendHeaderKey: "X-Backend"},
+			expErrors: []string{`modelNameHeaderKey: must be lowercase`, `selectedBackendHeaderKey: must be lowercase`},
+		},
+		{
+			name: "quota schedules",
+			config: &Config{QuotaSchedules: []QuotaSchedule{
+				{MetadataKey: "quota_schedule/ns/policy/0", DefaultWindow: "__default", Windows: []QuotaScheduleWindow{
+					{Name: "night", Start: "22:00", End: "06:00", TimeZone: "Europe/Paris"},
+					{Name: "night", Days: []string{"Someday"}, Start: "24:00", End: "06:00", TimeZone: "Mars/Olympus"},
+					{Name: "__default", Start: "09:00", End: "17:00"},
+				}},
+				{MetadataKey: "quota_schedule/ns/policy/0"},
+			}},
+			expErrors: []string{
+				`quotaSchedules[0].windows[1]: unknown day "Someday"`,
+				`quotaSchedules[0].windows[2].name: must not be the default window "__default"`,
+				`quotaSchedules[0].windows[1].name: duplicates quotaSchedules[0].windows[0].name "night"`,
+				`quotaSchedules[1].defaultWindow: must not be empty`,
+				`quotaSchedules[1].windows: must not be empty`,
+				`quotaSchedules[1].metadataKey: duplicates quotaSchedules[0].metadataKey "quota_schedule/ns/policy/0"`,
+			},
+		},
+		{
+			name: "models",
+			config: &Config{
+				Models:         []Model{{}, {Name: "gpt-4", Visibility: &ModelVisibility{}}},
+				UnscopedModels: []Model{{}},
+				MCPConfig:      &MCPConfig{Routes: []MCPRoute{{Backends: []MCPBackend{{}}}}},
+			},
+			expErrors: []string{
+				`models[0].Name: must not be empty`,
+				`models[1].Visibility.tenantHeader: must not be empty`,
+				`models[1].Visibility.tenants: must not be empty`,
+				`unscopedModels[0].Name: must not be empty`,
+				`mcpConfig.routes[0].name: must not be empty`,
+				`mcpConfig.routes[0].backends[0].name: must not be empty`,
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Validate(tc.config)
+			require.Error(t, err)
+			var actual []string
+			for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+				var fe *FieldError
+				require.True(t, errors.As(e, &fe))
+				actual = append(actual, fe.Error())
+			}
+			require.Equal(t, tc.expErrors, actual)
+		})
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"maps"
 	"slices"
+	"strconv"
 	"strings"
 
 	aigv1b1 "github.com/envoyproxy/ai-gateway/api/v1beta1"
@@ -29,6 +30,54 @@ const (
 	InternalMetadataBackendNameKey = "per_route_rule_backend_name"
 	// InternalMetadataRouteNameKey is the key used to store the route name.
 	InternalMetadataRouteNameKey = "aigw_route_name"
+	// ExperimentHeader is the header set on the upstream request to the name of the A/B experiment of the route.
+	ExperimentHeader = EnvoyAIGatewayHeaderPrefix + "experiment"
+	// ExperimentVariantHeader is the header set on the upstream request and the response to the name of the
+	// variant of the A/B experiment assigned to the request.
+	ExperimentVariantHeader = EnvoyAIGatewayHeaderPrefix + "experiment-variant"
+	// ContextLengthExceededHeader is the header set by the upstream filter on the response of a backend rejecting the
+	// request for exceeding its context length, which makes Envoy retry the request.
+	ContextLengthExceededHeader = EnvoyAIGatewayHeaderPrefix + "context-length-exceeded"
+	// ContextLengthRetryHeader is the header set on the response to the context length retry strategy applied to the
+	// request after a backend rejected it for exceeding its context length.
+	ContextLengthRetryHeader = EnvoyAIGatewayHeaderPrefix + "context-length-retry"
+	// FailoverRetryHeader is the header set on the response to the number of times the request was re-dispatched
+	// after failing mid-flight on a rule configuring FailoverRetry.
+	FailoverRetryHeader = EnvoyAIGatewayHeaderPrefix + "failover-retry"
+	// HistoryElidedHeader is the header set on the response to the number of the messages elided from the
+	// conversation history of the request by the HistoryPolicy of the AIGatewayRoute.
+	HistoryElidedHeader = EnvoyAIGatewayHeaderPrefix + "history-elided"
+	// ToolCallLoopHeader is the header set on the response to the name of the function repeatedly called with the
+	// same arguments in the conversation of the request, when the ToolCallLoopGuard of the AIGatewayRoute hinted
+	// the model about it.
+	ToolCallLoopHeader = EnvoyAIGatewayHeaderPrefix + "tool-call-loop"
+	// ResponseAttestationHeader is the header set on the response to the signed attestation of the response,
+	// when the GatewayConfig enables the ResponseAttestation.
+	ResponseAttestationHeader = EnvoyAIGatewayHeaderPrefix + "attestation"
+	// BackendOverloadedHeader is the header set by the upstream filter on the response shedding a request to a
+	// backend reporting a load above its thresholds, which makes Envoy retry the request on another endpoint.
+	BackendOverloadedHeader = EnvoyAIGatewayHeaderPrefix + "backend-overloaded"
+	// UnsupportedParametersHeader is the header set on the response to the comma-separated list of the request
+	// parameters that the backend cannot honor, on the AIGatewayRoutes warning about them.
+	UnsupportedParametersHeader = EnvoyAIGatewayHeaderPrefix + "unsupported-parameters"
+	// DebugEchoHeader is the request header asking for the request as translated for the backend to be returned
+	// instead of being sent, on the Gateways enabling the debug echo mode.
+	DebugEchoHeader = EnvoyAIGatewayHeaderPrefix + "debug-echo"
+	// BackendOverrideHeader is the request header pinning the backend of a request to the AIServiceBackend of the
+	// given name on the AIGatewayRoutes configuring a BackendOverride.
+	BackendOverrideHeader = "x-aigw-backend"
+	// BackendOverrideSignatureHeader is the request header holding the signature of the BackendOverrideHeader.
+	BackendOverrideSignatureHeader = BackendOverrideHeader + BackendOverrideSignatureHeaderSuffix
+	// BackendOverrideSignatureHeaderSuffix is appended to the header pinning the backend to name the header holding
+	// its signature, so that the installations configuring another header keep a matching pair.
+	BackendOverrideSignatureHeaderSuffix = "-signature"
+	// MigrationRuleHeader is the request header of the requests sent again by the external processor to the backend
+	// a rule is migrated to, whose value is the index of the rule in the AIGatewayRoute. The generated HTTPRoute
+	// routes these requests to the migration backend, see MigrationRules.
+	MigrationRuleHeader = EnvoyAIGatewayHeaderPrefix + "migration-rule"
+	// MigrationNonceHeader is the request header holding the single-use nonce of a request sent again to the
+	// migration backend, which tells it apart from the client requests setting the MigrationRuleHeader.
+	MigrationNonceHeader = EnvoyAIGatewayHeaderPrefix + "migration-nonce"
 	// MCPBackendHeader is the special header key used to specify the target backend name.
 	MCPBackendHeader = EnvoyAIGatewayHeaderPrefix + "mcp-backend"
 	// MCPRouteHeader is the special header key used to identify the mcp route.
@@ -83,6 +132,17 @@ const (
 	XDSUpstreamHostMetadataBackendNamePath = "xds.upstream_host_metadata.filter_metadata['aigateway.envoy.io']['per_route_rule_backend_name']"
 	// XDSRouteMetadataRouteNamePath is the full attribute path to access the route name in route metadata in xDS attributes.
 	XDSRouteMetadataRouteNamePath = "xds.route_metadata.filter_metadata['aigateway.envoy.io']['aigw_route_name']"
+	// UpstreamAddressAttribute is the attribute of the request sent to the upstream filter, which tells the address
+	// of the endpoint selected by Envoy among the ones of the backend.
+	UpstreamAddressAttribute = "upstream.address"
+	// ResponseCodeDetailsAttribute is the attribute of the response sent to the router filter, which tells the
+	// upstream timeouts of Envoy apart, e.g. "upstream_per_try_idle_timeout".
+	ResponseCodeDetailsAttribute = "response.code_details"
+	// DownstreamLocalPortAttribute and DownstreamTLSVersionAttribute are the attributes of the response sent to the
+	// router filter, which tell the port of the listener that received the request and whether it was received over
+	// TLS, so that the requests to the migration backends can be sent to the same listener.
+	DownstreamLocalPortAttribute  = "destination.port"
+	DownstreamTLSVersionAttribute = "connection.tls_version"
 )
 
 // PerRouteRuleRefBackendName generates a unique backend name for a per-route rule,
@@ -92,6 +152,58 @@ func PerRouteRuleRefBackendName(namespace, name, routeName string, routeRuleInde
 	return fmt.Sprintf("%s/%s/route/%s/rule/%d/ref/%d", namespace, name, routeName, routeRuleIndex, refIndex)
 }
 
+// PerRouteRuleIndex returns the index of the route rule of the backend name generated by PerRouteRuleRefBackendName,
+// or false if the name isn't one.
+func PerRouteRuleIndex(backendName string) (int, bool) {
+	parts := strings.Split(backendName, "/")
+	if len(parts) != 8 || parts[2] != "route" || parts[4] != "rule" || parts[6] != "ref" {
+		return 0, false
+	}
+	i, err := strconv.Atoi(parts[5])
+	if err != nil {
+		return 0, false
+	}
+	return i, true
+}
+
+// MigrationRule is the rule of the generated HTTPRoute routing the requests sent again to the backend a rule of an
+// AIGatewayRoute is migrated to.
+type MigrationRule struct {
+	// RuleIndex is the index of the migrated rule in the AIGatewayRoute.
+	RuleIndex int
+	// HTTPRouteRuleIndex is the index of the rule in the generated HTTPRoute. The migration rules are placed after
+	// the rules of the AIGatewayRoute and the route-not-found rule.
+	HTTPRouteRuleIndex int
+	// Rule is the migrated rule with the migration backend as its only backend.
+	Rule aigv1b1.AIGatewayRouteRule
+}
+
+// MigrationRules returns the migration rules of the rules of an AIGatewayRoute, in the order of the rules. The
+// controller, the extension server and the filter config all derive the migration rules with this function so that
+// they agree on their indexes.
+func MigrationRules(rules []aigv1b1.AIGatewayRouteRule) []MigrationRule {
+	var out []MigrationRule
+	for i := range rules {
+		m := rules[i].Migration
+		if m == nil {
+			continue
+		}
+		rule := *rules[i].DeepCopy()
+		ref := m.BackendRef
+		ref.Weight, ref.Priority = nil, nil
+		rule.BackendRefs = []aigv1b1.AIGatewayRouteRuleBackendRef{ref}
+		rule.Migration = nil
+		rule.ContextLengthRetry = nil
+		rule.FailoverRetry = nil
+		out = append(out, MigrationRule{
+			RuleIndex:          i,
+			HTTPRouteRuleIndex: len(rules) + 1 + len(out), // +1 for the route-not-found rule.
+			Rule:               rule,
+		})
+	}
+	return out
+}
+
 const (
 	// AIGatewayGeneratedHTTPRouteAnnotation is the annotation key used to mark
 	// HTTPRoute resources that are generated by the AI Gateway controller.
@@ -10,6 +10,8 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	aigv1b1 "github.com/envoyproxy/ai-gateway/api/v1beta1"
 )
 
 func TestParseEndpointPrefixes_Success(t *testing.T) {
@@ -100,6 +102,42 @@ func TestPerRouteRuleRefBackendName(t *testing.T) {
 	}
 }
 
+func TestPerRouteRuleIndex(t *testing.T) {
+	i, ok := PerRouteRuleIndex(PerRouteRuleRefBackendName("default", "backend1", "route1", 3, 1))
+	require.True(t, ok)
+	require.Equal(t, 3, i)
+	for _, name := range []string{"", "default/backend1", "default/backend1/route/route1/rule/x/ref/0"} {
+		_, ok = PerRouteRuleIndex(name)
+		require.False(t, ok, name)
+	}
+}
+
+func TestMigrationRules(t *testing.T) {
+	rules := []aigv1b1.AIGatewayRouteRule{
+		{BackendRefs: []aigv1b1.AIGatewayRouteRuleBackendRef{{Name: "a"}}},
+		{
+			BackendRefs:        []aigv1b1.AIGatewayRouteRuleBackendRef{{Name: "b"}, {Name: "c"}},
+			ContextLengthRetry: &aigv1b1.AIGatewayRouteRuleContextLengthRetry{},
+			FailoverRetry:      &aigv1b1.AIGatewayRouteRuleFailoverRetry{},
+			Migration:          &aigv1b1.AIGatewayRouteRuleMigration{BackendRef: aigv1b1.AIGatewayRouteRuleBackendRef{Name: "d"}},
+		},
+	}
+	migrationRules := MigrationRules(rules)
+	require.Len(t, migrationRules, 1)
+	require.Equal(t, 1, migrationRules[0].RuleIndex)
+	// After the two rules and the route-not-found rule.
+	require.Equal(t, 3, migrationRules[0].HTTPRouteRuleIndex)
+	require.Equal(t, []aigv1b1.AIGatewayRouteRuleBackendRef{{Name: "d"}}, migrationRules[0].Rule.BackendRefs)
+	require.Nil(t, migrationRules[0].Rule.Migration)
+	require.Nil(t, migrationRules[0].Rule.ContextLengthRetry)
+	require.Nil(t, migrationRules[0].Rule.FailoverRetry)
+	// The rules of the route are left as is.
+	require.Len(t, rules[1].BackendRefs, 2)
+	require.NotNil(t, rules[1].Migration)
+
+	require.Empty(t, MigrationRules(rules[:1]))
+}
+
 func TestConstants(t *testing.T) {
 	// Test that constants have expected values
 	require.Equal(t, "aigateway.envoy.io", InternalEndpointMetadataNamespace)
@@ -20,6 +20,8 @@ var (
 	Unmarshal = config.Unmarshal
 	// Marshal is equivalent to encoding/json.Marshal.
 	Marshal = config.Marshal
+	// MarshalIndent is equivalent to encoding/json.MarshalIndent.
+	MarshalIndent = config.MarshalIndent
 	// NewEncoder is equivalent to encoding/json.NewEncoder.
 	NewEncoder = config.NewEncoder
 	// NewDecoder is equivalent to encoding/json.NewDecoder.
@@ -25,6 +25,9 @@ const (
 	celOutputTokensKey             = "output_tokens"
 	celTotalTokensKey              = "total_tokens"
 	celReasoningTokensKey          = "reasoning_tokens"
+	celImageCountKey               = "image_count"
+	celImageSizeKey                = "image_size"
+	celImageQualityKey             = "image_quality"
 )
 
 var env *cel.Env
@@ -41,6 +44,9 @@ func init() {
 		cel.Variable(celOutputTokensKey, cel.UintType),
 		cel.Variable(celTotalTokensKey, cel.UintType),
 		cel.Variable(celReasoningTokensKey, cel.UintType),
+		cel.Variable(celImageCountKey, cel.UintType),
+		cel.Variable(celImageSizeKey, cel.StringType),
+		cel.Variable(celImageQualityKey, cel.StringType),
 	)
 	if err != nil {
 		panic(fmt.Sprintf("cannot create CEL environment: %v", err))
@@ -60,15 +66,16 @@ func NewProgram(expr string) (prog cel.Program, err error) {
 	}
 
 	// Sanity check by evaluating the expression with some dummy values.
-	_, err = EvaluateProgram(prog, "dummy", "dummy", "dummy", 0, 0, 0, 0, 0, 0)
+	_, err = EvaluateProgram(prog, "dummy", "dummy", "dummy", 0, 0, 0, 0, 0, 0, 0, "", "")
 	if err != nil {
 		return nil, fmt.Errorf("failed to evaluate CEL expression: %w", err)
 	}
 	return prog, nil
 }
 
-// EvaluateProgram evaluates the given CEL program with the given variables.
-func EvaluateProgram(prog cel.Program, modelName, backend, routeName string, inputTokens, cachedInputTokens, cacheCreationInputTokens, outputTokens, totalTokens, reasoningTokens uint32) (uint64, error) {
+// EvaluateProgram evaluates the given CEL program with the given variables. The image variables are only set by the
+// image generation requests, see metrics.ImageUsage.
+func EvaluateProgram(prog cel.Program, modelName, backend, routeName string, inputTokens, cachedInputTokens, cacheCreationInputTokens, outputTokens, totalTokens, reasoningTokens, imageCount uint32, imageSize, imageQuality string) (uint64, error) {
 	out, _, err := prog.Eval(map[string]any{
 		celModelNameKey:                modelName,
 		celBackendKey:                  backend,
@@ -79,6 +86,9 @@ func EvaluateProgram(prog cel.Program, modelName, backend, routeName string, inp
 		celOutputTokensKey:             outputTokens,
 		celTotalTokensKey:              totalTokens,
 		celReasoningTokensKey:          reasoningTokens,
+		celImageCountKey:               imageCount,
+		celImageSizeKey:                imageSize,
+		celImageQualityKey:             imageQuality,
 	})
 	if err != nil || out == nil {
 		return 0, fmt.Errorf("failed to evaluate CEL expression: %w", err)
@@ -0,0 +1,86 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package metrics
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const (
+	// Backend auth credential reloads is a counter of the reloads of the backend auth credentials after the files
+	// they are loaded from changed, e.g. when a mounted secret is rotated.
+	//
+	// Dimensions:
+	// - backend_auth.type
+	// - backend_auth.reload.success
+	backendAuthCredentialReloads = "backend_auth.credential.reloads"
+	// Backend auth API key requests is a counter of the requests authenticated with each API key of the pools of
+	// the APIKey BackendSecurityPolicies.
+	//
+	// Dimensions:
+	// - backend_auth.api_key.name
+	backendAuthAPIKeyRequests = "backend_auth.api_key.requests"
+	// Backend auth API key removals is a counter of the removals of the API keys of the pools from the rotation
+	// after the backend rejected them.
+	//
+	// Dimensions:
+	// - backend_auth.api_key.name
+	backendAuthAPIKeyRemovals = "backend_auth.api_key.removals"
+
+	backendAuthAttributeType          = "backend_auth.type"
+	backendAuthAttributeReloadSuccess = "backend_auth.reload.success"
+	backendAuthAttributeAPIKeyName    = "backend_auth.api_key.name"
+)
+
+// BackendAuthMetrics records the events of the backend auth handlers.
+type BackendAuthMetrics interface {
+	// RecordCredentialReload records a reload of the credentials of the auth type, e.g. "aws", and whether the
+	// new credentials were loaded. The previous credentials are kept when the reload fails.
+	RecordCredentialReload(ctx context.Context, authType string, success bool)
+	// RecordAPIKeyRequest records a request authenticated with the API key of the given name of a pool.
+	RecordAPIKeyRequest(ctx context.Context, name string)
+	// RecordAPIKeyRemoval records the removal of the API key of the given name from the rotation of its pool.
+	RecordAPIKeyRemoval(ctx context.Context, name string)
+}
+
+type backendAuth struct {
+	reloads        metric.Float64Counter
+	apiKeyRequests metric.Float64Counter
+	apiKeyRemovals metric.Float64Counter
+}
+
+// NewBackendAuth creates a new BackendAuthMetrics instance.
+func NewBackendAuth(meter metric.Meter) BackendAuthMetrics {
+	return &backendAuth{
+		reloads: mustRegisterCounter(meter, backendAuthCredentialReloads,
+			metric.WithDescription("The number of reloads of the backend auth credentials after their files changed")),
+		apiKeyRequests: mustRegisterCounter(meter, backendAuthAPIKeyRequests,
+			metric.WithDescription("The number of requests authenticated with each API key of the pools")),
+		apiKeyRemovals: mustRegisterCounter(meter, backendAuthAPIKeyRemovals,
+			metric.WithDescription("The number of removals of the API keys of the pools rejected by the backends")),
+	}
+}
+
+// RecordCredentialReload implements [BackendAuthMetrics.RecordCredentialReload].
+func (b *backendAuth) RecordCredentialReload(ctx context.Context, authType string, success bool) {
+	b.reloads.Add(ctx, 1, metric.WithAttributes(
+		attribute.String(backendAuthAttributeType, authType),
+		attribute.Bool(backendAuthAttributeReloadSuccess, success),
+	))
+}
+
+// RecordAPIKeyRequest implements [BackendAuthMetrics.RecordAPIKeyRequest].
+func (b *backendAuth) RecordAPIKeyRequest(ctx context.Context, name string) {
+	b.apiKeyRequests.Add(ctx, 1, metric.WithAttributes(attribute.String(backendAuthAttributeAPIKeyName, name)))
+}
+
+// RecordAPIKeyRemoval implements [BackendAuthMetrics.RecordAPIKeyRemoval].
+func (b *backendAuth) RecordAPIKeyRemoval(ctx context.Context, name string) {
+	b.apiKeyRemovals.Add(ctx, 1, metric.WithAttributes(attribute.String(backendAuthAttributeAPIKeyName, name)))
+}
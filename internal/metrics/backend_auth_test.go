@@ -0,0 +1,44 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/metric"
+
+	"github.com/envoyproxy/ai-gateway/internal/testing/testotel"
+)
+
+func TestBackendAuth(t *testing.T) {
+	t.Parallel()
+	var (
+		mr    = metric.NewManualReader()
+		meter = metric.NewMeterProvider(metric.WithReader(mr)).Meter("test")
+		ba    = NewBackendAuth(meter)
+	)
+
+	ba.RecordCredentialReload(t.Context(), "aws", true)
+	ba.RecordCredentialReload(t.Context(), "aws", true)
+	ba.RecordCredentialReload(t.Context(), "gcp", false)
+	require.Equal(t, 2.0, testotel.GetCounterValue(t, mr, backendAuthCredentialReloads, attribute.NewSet(
+		attribute.String(backendAuthAttributeType, "aws"),
+		attribute.Bool(backendAuthAttributeReloadSuccess, true),
+	)))
+	require.Equal(t, 1.0, testotel.GetCounterValue(t, mr, backendAuthCredentialReloads, attribute.NewSet(
+		attribute.String(backendAuthAttributeType, "gcp"),
+		attribute.Bool(backendAuthAttributeReloadSuccess, false),
+	)))
+
+	ba.RecordAPIKeyRequest(t.Context(), "ns/bsp/a")
+	ba.RecordAPIKeyRequest(t.Context(), "ns/bsp/a")
+	ba.RecordAPIKeyRemoval(t.Context(), "ns/bsp/a")
+	keyAttrs := attribute.NewSet(attribute.String(backendAuthAttributeAPIKeyName, "ns/bsp/a"))
+	require.Equal(t, 2.0, testotel.GetCounterValue(t, mr, backendAuthAPIKeyRequests, keyAttrs))
+	require.Equal(t, 1.0, testotel.GetCounterValue(t, mr, backendAuthAPIKeyRemovals, keyAttrs))
+}
@@ -15,6 +15,21 @@ const (
 	genaiMetricServerRequestDuration    = "gen_ai.server.request.duration"
 	genaiMetricServerTimeToFirstToken   = "gen_ai.server.time_to_first_token"   //nolint:gosec // metric name, not credential
 	genaiMetricServerTimePerOutputToken = "gen_ai.server.time_per_output_token" //nolint:gosec // metric name, not credential
+	// genaiMetricResponseTruncated is not part of the Semantic Conventions. It counts the streaming responses
+	// that ended before their terminating event.
+	genaiMetricResponseTruncated = "gen_ai.response.truncated"
+	// genaiMetricContextLengthExceeded is not part of the Semantic Conventions. It counts the requests rejected
+	// because their estimated input tokens exceed the context window of the model.
+	genaiMetricContextLengthExceeded = "gen_ai.request.context_length_exceeded"
+	// genaiMetricServerPhaseDuration is not part of the Semantic Conventions. It is the duration of the phases of the
+	// attempts of the requests to the backends, see UpstreamPhase.
+	genaiMetricServerPhaseDuration = "gen_ai.server.phase.duration"
+	// genaiMetricClientTokenCacheSavings is not part of the Semantic Conventions. It counts the input tokens saved by
+	// the prompt cache hits, see promptCacheReadDiscounts.
+	genaiMetricClientTokenCacheSavings = "gen_ai.client.token.cache_savings" //nolint:gosec // metric name, not credential
+	// genaiMetricClientImageCount is not part of the Semantic Conventions. It counts the images generated by the
+	// image generation requests, see ImageUsage.
+	genaiMetricClientImageCount = "gen_ai.client.image.count"
 
 	genaiAttributeOperationName = "gen_ai.operation.name"
 	genaiAttributeProviderName  = "gen_ai.provider.name"
@@ -23,6 +38,18 @@ const (
 	genaiAttributeResponseModel = "gen_ai.response.model"
 	genaiAttributeTokenType     = "gen_ai.token.type" //nolint:gosec // metric name, not credential
 	genaiAttributeErrorType     = "error.type"
+	// genaiAttributeTruncationReason is the reason of a truncated response, see ResponseTruncationReason.
+	genaiAttributeTruncationReason = "gen_ai.response.truncation.reason"
+	// genaiAttributePhase is the phase of the attempt of a request to a backend, see UpstreamPhase.
+	genaiAttributePhase = "gen_ai.server.phase"
+	// genaiAttributeExperimentName and genaiAttributeExperimentVariant are not part of the Semantic Conventions.
+	// They identify the variant of the A/B experiment of the AIGatewayRoute assigned to the request.
+	genaiAttributeExperimentName    = "experiment.name"
+	genaiAttributeExperimentVariant = "experiment.variant"
+	// genaiAttributeImageSize and genaiAttributeImageQuality are not part of the Semantic Conventions. They are the
+	// size and the quality tier of the generated images, see ImageUsage.
+	genaiAttributeImageSize    = "gen_ai.image.size"
+	genaiAttributeImageQuality = "gen_ai.image.quality"
 
 	GenAIOperationChat            GenAIOperation = "chat"
 	GenAIOperationCompletion      GenAIOperation = "completion"
@@ -35,6 +62,8 @@ const (
 	GenAIOperationTranslation     GenAIOperation = "translation"
 	GenAIOperationRerank          GenAIOperation = "rerank"
 	GenAIOperationTokenize        GenAIOperation = "tokenize"
+	GenAIOperationModeration      GenAIOperation = "moderation"
+	GenAIOperationCountTokens     GenAIOperation = "count_tokens"
 
 	// Provider names according to the Semantic Conventions for Generative AI Metrics.
 	// See: https://opentelemetry.io/docs/specs/semconv/attributes-registry/gen-ai/
@@ -46,6 +75,8 @@ const (
 	genaiProviderGCPAnthropic = "gcp.anthropic"
 	genaiProviderAnthropic    = "anthropic"
 	genaiProviderCohere       = "cohere"
+	genaiProviderIBMWatsonx   = "ibm.watsonx.ai"
+	genaiProviderMock         = "mock"
 
 	genaiTokenTypeInput  = "input"
 	genaiTokenTypeOutput = "output"
@@ -62,6 +93,34 @@ const (
 // GenAIOperation represents the type of generative AI operation i.e. the endpoint being called.
 type GenAIOperation string
 
+// ResponseTruncationReason is the reason why a streaming response is considered truncated.
+type ResponseTruncationReason string
+
+const (
+	// ResponseTruncationReasonIncomplete is used when the response stream ended without its terminating event,
+	// e.g. the [DONE] event or a chunk with a finish reason for chat completions.
+	ResponseTruncationReasonIncomplete ResponseTruncationReason = "incomplete"
+	// ResponseTruncationReasonAborted is used when the response stream was aborted before its end, e.g. because
+	// the connection to the backend or to the client was reset.
+	ResponseTruncationReasonAborted ResponseTruncationReason = "aborted"
+	// ResponseTruncationReasonStalled is used when the response stream was terminated because no token arrived
+	// within the stream stall timeout of the route rule.
+	ResponseTruncationReasonStalled ResponseTruncationReason = "stalled"
+)
+
+// UpstreamPhase is a phase of an attempt of a request to a backend, each of which can have its own timeout.
+type UpstreamPhase string
+
+const (
+	// UpstreamPhaseConnect is the establishment of the connection to the backend. Its duration is only recorded when
+	// it times out, since the connection is established before the request reaches the upstream filter.
+	UpstreamPhaseConnect UpstreamPhase = "connect"
+	// UpstreamPhaseFirstByte is the time between the request sent to the backend and the first byte of its response.
+	UpstreamPhaseFirstByte UpstreamPhase = "first_byte"
+	// UpstreamPhaseCompletion is the time between the request sent to the backend and the end of its response.
+	UpstreamPhaseCompletion UpstreamPhase = "completion"
+)
+
 // genAI holds metrics according to the Semantic Conventions for Generative AI Metrics.
 // See: https://opentelemetry.io/docs/specs/semconv/gen-ai/gen-ai-metrics/.
 type genAI struct {
@@ -83,6 +142,33 @@ type genAI struct {
 	// Calculated by: (request_duration - time_to_first_token) / (output_tokens - 1)
 	// See: https://opentelemetry.io/docs/specs/semconv/gen-ai/gen-ai-metrics/#metric-gen_aiservertime_per_output_token
 	outputTokenLatency metric.Float64Histogram
+	// rateLimitLimit, rateLimitRemaining and rateLimitReset reflect the rate limit headers returned by the providers.
+	// These are not part of the Semantic Conventions. See provider_ratelimit.go.
+	rateLimitLimit     metric.Float64Gauge
+	rateLimitRemaining metric.Float64Gauge
+	rateLimitReset     metric.Float64Gauge
+	// responseTruncated is the number of streaming responses that ended before their terminating event.
+	responseTruncated metric.Float64Counter
+	// contextLengthExceeded is the number of requests rejected because they exceed the context window of the model.
+	contextLengthExceeded metric.Float64Counter
+	// phaseLatency is the duration of the phases of the attempts of the requests to the backends.
+	// Measured from the start of the received request headers in the upstream extproc filter.
+	phaseLatency metric.Float64Histogram
+	// cacheSavings is the number of input tokens saved by the prompt cache hits, i.e. the cached input tokens
+	// weighted by the discount of their price.
+	cacheSavings metric.Float64Counter
+	// imageCount is the number of the generated images by size and quality tier.
+	imageCount metric.Float64Counter
+}
+
+// promptCacheReadDiscounts are the discounts of the price of the cached input tokens relative to the regular input
+// tokens per provider, used to compute the prompt cache savings. The Claude models bill the cache reads at 10% of
+// the input price on all their providers. The providers whose discount depends on the model are not listed.
+var promptCacheReadDiscounts = map[string]float64{
+	genaiProviderAnthropic:    0.9,
+	genaiProviderGCPAnthropic: 0.9,
+	genaiProviderAWSAnthropic: 0.9,
+	genaiProviderAWSBedrock:   0.9,
 }
 
 // newGenAI creates a new genAI metrics instance.
@@ -112,5 +198,46 @@ func newGenAI(meter metric.Meter) *genAI {
 			metric.WithUnit("s"),
 			metric.WithExplicitBucketBoundaries(0.01, 0.025, 0.05, 0.075, 0.1, 0.15, 0.2, 0.3, 0.4, 0.5, 0.75, 1.0, 2.5),
 		),
+		rateLimitLimit: mustRegisterGauge(meter,
+			providerRateLimitLimit,
+			metric.WithDescription("Rate limit of the provider as reported in the last response headers."),
+			metric.WithUnit("{request_or_token}"),
+		),
+		rateLimitRemaining: mustRegisterGauge(meter,
+			providerRateLimitRemaining,
+			metric.WithDescription("Remaining provider rate limit as reported in the last response headers."),
+			metric.WithUnit("{request_or_token}"),
+		),
+		rateLimitReset: mustRegisterGauge(meter,
+			providerRateLimitReset,
+			metric.WithDescription("Unix timestamp at which the provider rate limit resets as reported in the last response headers."),
+			metric.WithUnit("s"),
+		),
+		responseTruncated: mustRegisterCounter(meter,
+			genaiMetricResponseTruncated,
+			metric.WithDescription("Number of streaming responses that ended before their terminating event."),
+			metric.WithUnit("{response}"),
+		),
+		contextLengthExceeded: mustRegisterCounter(meter,
+			genaiMetricContextLengthExceeded,
+			metric.WithDescription("Number of requests rejected because their input exceeds the context window of the model."),
+			metric.WithUnit("{request}"),
+		),
+		phaseLatency: mustRegisterHistogram(meter,
+			genaiMetricServerPhaseDuration,
+			metric.WithDescription("Duration of the connect, first byte and completion phases of the requests to the backends."),
+			metric.WithUnit("s"),
+			metric.WithExplicitBucketBoundaries(0.01, 0.02, 0.04, 0.08, 0.16, 0.32, 0.64, 1.28, 2.56, 5.12, 10.24, 20.48, 40.96, 81.92),
+		),
+		cacheSavings: mustRegisterCounter(meter,
+			genaiMetricClientTokenCacheSavings,
+			metric.WithDescription("Number of input tokens saved by the prompt cache hits."),
+			metric.WithUnit("token"),
+		),
+		imageCount: mustRegisterCounter(meter,
+			genaiMetricClientImageCount,
+			metric.WithDescription("Number of images generated."),
+			metric.WithUnit("{image}"),
+		),
 	}
 }
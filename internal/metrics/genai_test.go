@@ -89,6 +89,7 @@ func TestGenAiConstants(t *testing.T) {
 	assert.Equal(t, "gen_ai.server.request.duration", genaiMetricServerRequestDuration)
 	assert.Equal(t, "gen_ai.server.time_to_first_token", genaiMetricServerTimeToFirstToken)
 	assert.Equal(t, "gen_ai.server.time_per_output_token", genaiMetricServerTimePerOutputToken)
+	assert.Equal(t, "gen_ai.client.token.cache_savings", genaiMetricClientTokenCacheSavings)
 
 	assert.Equal(t, "openai", genaiProviderOpenAI)
 	assert.Equal(t, "azure.openai", genaiProviderAzureOpenAI)
@@ -15,6 +15,7 @@ import (
 	"go.opentelemetry.io/otel/metric"
 	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
 	"go.opentelemetry.io/otel/sdk/resource"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/envoyproxy/ai-gateway/internal/filterapi"
 	"github.com/envoyproxy/ai-gateway/internal/internalapi"
@@ -109,12 +110,21 @@ type Metrics interface {
 	// SetBackend sets the selected backend when the routing decision has been made. This is usually called
 	// after parsing the request body to determine the model and invoke the routing logic.
 	SetBackend(backend *filterapi.Backend)
+	// SetSpanContext sets the span context of the request, whose trace ID is attached as an exemplar to the
+	// latency and token usage histograms, so that a metric can be linked to the trace of the request.
+	SetSpanContext(spanContext trace.SpanContext)
 	// RecordRequestCompletion records the completion of the request, including success status.
 	RecordRequestCompletion(ctx context.Context, success bool, requestHeaders map[string]string)
 	// RecordTokenUsage records token usage metrics.
 	//
 	// Depending on the endpoint, some token types are not available and should be passed as OptUint32None.
 	RecordTokenUsage(ctx context.Context, usage TokenUsage, requestHeaders map[string]string)
+	// RecordProviderRateLimits records the provider rate limits found in the response headers,
+	// e.g. x-ratelimit-remaining-tokens, per backend.
+	RecordProviderRateLimits(ctx context.Context, responseHeaders map[string]string)
+	// RecordPhaseDuration records the duration of a phase of the request to the backend since StartRequest.
+	// timedOut is true if the phase exceeded its timeout, which is then recorded as the error type.
+	RecordPhaseDuration(ctx context.Context, phase UpstreamPhase, timedOut bool, requestHeaders map[string]string)
 
 	// Streaming-specific metrics methods, not used by all implementations.
 
@@ -124,6 +134,11 @@ type Metrics interface {
 	GetInterTokenLatencyMs() float64
 	// RecordTokenLatency records latency metrics for token generation.
 	RecordTokenLatency(ctx context.Context, accumulatedOutputToken uint32, endOfStream bool, requestHeaders map[string]string)
+	// RecordResponseTruncated records a streaming response that ended before its terminating event.
+	RecordResponseTruncated(ctx context.Context, reason ResponseTruncationReason, requestHeaders map[string]string)
+	// RecordContextLengthExceeded records a request rejected because its estimated input tokens exceed the context
+	// window of the model.
+	RecordContextLengthExceeded(ctx context.Context, requestHeaders map[string]string)
 }
 
 // Factory is a closure that creates a new Metrics instance for a given operation.
@@ -154,9 +169,33 @@ type TokenUsage struct {
 	// ReasoningTokens is the number of reasoning tokens consumed.
 	reasoningTokens uint32
 
-	inputTokenSet, outputTokenSet, totalTokenSet, cachedInputTokenSet, cacheCreationInputTokenSet, reasoningTokenSet bool
+	// images is the usage of the generated images, set by the image generation endpoints.
+	images ImageUsage
+
+	inputTokenSet, outputTokenSet, totalTokenSet, cachedInputTokenSet, cacheCreationInputTokenSet, reasoningTokenSet, imagesSet bool
+}
+
+// ImageUsage is the usage of the images generated by a request, normalized across the providers.
+type ImageUsage struct {
+	// Count is the number of the generated images.
+	Count uint32
+	// Size is the size of the generated images in the "<width>x<height>" form, or empty if unknown.
+	Size string
+	// Quality is the quality tier of the generated images, one of the ImageQuality* values, or empty if unknown.
+	Quality string
 }
 
+const (
+	// ImageQualityLow is the quality tier of the low quality images, e.g. "low" of gpt-image-1.
+	ImageQualityLow = "low"
+	// ImageQualityStandard is the quality tier of the default quality images, e.g. "standard" of DALL-E and
+	// Amazon Titan Image Generator, or "medium" of gpt-image-1.
+	ImageQualityStandard = "standard"
+	// ImageQualityHigh is the quality tier of the high quality images, e.g. "hd" of DALL-E 3, "high" of
+	// gpt-image-1 or "premium" of Amazon Titan Image Generator.
+	ImageQualityHigh = "high"
+)
+
 // InputTokens returns the number of input tokens and whether it was set.
 func (u *TokenUsage) InputTokens() (uint32, bool) {
 	return u.inputTokens, u.inputTokenSet
@@ -223,6 +262,17 @@ func (u *TokenUsage) SetReasoningTokens(tokens uint32) {
 	u.reasoningTokenSet = true
 }
 
+// Images returns the usage of the generated images and whether it was set.
+func (u *TokenUsage) Images() (ImageUsage, bool) {
+	return u.images, u.imagesSet
+}
+
+// SetImages sets the usage of the generated images and marks the field as set.
+func (u *TokenUsage) SetImages(images ImageUsage) {
+	u.images = images
+	u.imagesSet = true
+}
+
 // AddInputTokens increments the recorded input tokens and marks the field as set.
 func (u *TokenUsage) AddInputTokens(tokens uint32) {
 	u.inputTokenSet = true
@@ -280,6 +330,10 @@ func (u *TokenUsage) Override(other TokenUsage) {
 		u.reasoningTokens = other.reasoningTokens
 		u.reasoningTokenSet = true
 	}
+	if other.imagesSet {
+		u.images = other.images
+		u.imagesSet = true
+	}
 }
 
 // ExtractTokenUsageFromExplicitCaching extracts the correct token usage from upstream Anthropic or AWS Bedrock token usage response.
@@ -11,6 +11,7 @@ import (
 
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/envoyproxy/ai-gateway/internal/filterapi"
 	"github.com/envoyproxy/ai-gateway/internal/internalapi"
@@ -32,6 +33,7 @@ func (f *metricsImplFactory) NewMetrics() Metrics {
 		requestModel:                  "unknown",
 		responseModel:                 "unknown",
 		backend:                       "unknown",
+		backendName:                   "unknown",
 		requestHeaderAttributeMapping: f.requestHeaderAttributeMapping,
 	}
 }
@@ -48,9 +50,13 @@ type metricsImpl struct {
 	// requestModel is the original model from the request body.
 	requestModel string
 	// responseModel is the model that ultimately generated the response (may differ due to backend override).
-	responseModel                 string
-	backend                       string
+	responseModel string
+	backend       string
+	// backendName is the name of the selected backend, used for the per-backend provider rate limit gauges.
+	backendName                   string
 	requestHeaderAttributeMapping map[string]string // maps HTTP headers to metric attribute names.
+	// spanContext is the span context of the request, attached as an exemplar to the histograms.
+	spanContext trace.SpanContext
 
 	// Fields for streaming token latency calculation, not used for non-streaming requests.
 
@@ -84,6 +90,7 @@ func (b *metricsImpl) SetResponseModel(responseModel internalapi.ResponseModel)
 // SetBackend sets the name of the backend to be reported in the metrics according to:
 // https://opentelemetry.io/docs/specs/semconv/gen-ai/gen-ai-metrics/
 func (b *metricsImpl) SetBackend(backend *filterapi.Backend) {
+	b.backendName = backend.Name
 	switch backend.Schema.Name {
 	case filterapi.APISchemaOpenAI:
 		b.backend = genaiProviderOpenAI
@@ -101,11 +108,29 @@ func (b *metricsImpl) SetBackend(backend *filterapi.Backend) {
 		b.backend = genaiProviderAnthropic
 	case filterapi.APISchemaCohere:
 		b.backend = genaiProviderCohere
+	case filterapi.APISchemaIBMWatsonx:
+		b.backend = genaiProviderIBMWatsonx
+	case filterapi.APISchemaMock:
+		b.backend = genaiProviderMock
 	default:
 		b.backend = backend.Name
 	}
 }
 
+// SetSpanContext implements [Metrics.SetSpanContext].
+func (b *metricsImpl) SetSpanContext(spanContext trace.SpanContext) {
+	b.spanContext = spanContext
+}
+
+// withSpanContext returns the context carrying the span context of the request, if any, so that the SDK attaches
+// its trace ID as an exemplar to the recorded measurement. The span context already in ctx takes precedence.
+func (b *metricsImpl) withSpanContext(ctx context.Context) context.Context {
+	if !b.spanContext.IsValid() || trace.SpanContextFromContext(ctx).IsValid() {
+		return ctx
+	}
+	return trace.ContextWithSpanContext(ctx, b.spanContext)
+}
+
 // buildBaseAttributes creates the base attributes for metrics recording.
 func (b *metricsImpl) buildBaseAttributes(headers map[string]string) attribute.Set {
 	opt := attribute.Key(genaiAttributeOperationName).String(b.operation)
@@ -113,12 +138,20 @@ func (b *metricsImpl) buildBaseAttributes(headers map[string]string) attribute.S
 	origModel := attribute.Key(genaiAttributeOriginalModel).String(b.originalModel)
 	reqModel := attribute.Key(genaiAttributeRequestModel).String(b.requestModel)
 	respModel := attribute.Key(genaiAttributeResponseModel).String(b.responseModel)
-	if len(b.requestHeaderAttributeMapping) == 0 {
+	variant := headers[internalapi.ExperimentVariantHeader]
+	if len(b.requestHeaderAttributeMapping) == 0 && variant == "" {
 		return attribute.NewSet(opt, provider, origModel, reqModel, respModel)
 	}
 
-	// Add header values as attributes based on the header mapping if headers are provided.
 	attrs := []attribute.KeyValue{opt, provider, origModel, reqModel, respModel}
+	// Add the experiment variant assigned to the request so that the variants can be compared.
+	if variant != "" {
+		attrs = append(attrs,
+			attribute.Key(genaiAttributeExperimentName).String(headers[internalapi.ExperimentHeader]),
+			attribute.Key(genaiAttributeExperimentVariant).String(variant),
+		)
+	}
+	// Add header values as attributes based on the header mapping if headers are provided.
 	for headerName, labelName := range b.requestHeaderAttributeMapping {
 		if headerValue, exists := headers[headerName]; exists {
 			attrs = append(attrs, attribute.Key(labelName).String(headerValue))
@@ -129,6 +162,7 @@ func (b *metricsImpl) buildBaseAttributes(headers map[string]string) attribute.S
 
 // RecordRequestCompletion records the completion of a request with success/failure status.
 func (b *metricsImpl) RecordRequestCompletion(ctx context.Context, success bool, requestHeaders map[string]string) {
+	ctx = b.withSpanContext(ctx)
 	attrs := b.buildBaseAttributes(requestHeaders)
 
 	if success {
@@ -146,6 +180,7 @@ func (b *metricsImpl) RecordRequestCompletion(ctx context.Context, success bool,
 
 // RecordTokenUsage records token usage metrics.
 func (b *metricsImpl) RecordTokenUsage(ctx context.Context, usage TokenUsage, requestHeaders map[string]string) {
+	ctx = b.withSpanContext(ctx)
 	attrs := b.buildBaseAttributes(requestHeaders)
 
 	if inputTokens, ok := usage.InputTokens(); ok {
@@ -159,6 +194,9 @@ func (b *metricsImpl) RecordTokenUsage(ctx context.Context, usage TokenUsage, re
 			metric.WithAttributeSet(attrs),
 			metric.WithAttributes(attribute.Key(genaiAttributeTokenType).String(genaiTokenTypeCachedInput)),
 		)
+		if discount := promptCacheReadDiscounts[b.backend]; discount > 0 && cachedInputTokens > 0 {
+			b.metrics.cacheSavings.Add(ctx, float64(cachedInputTokens)*discount, metric.WithAttributeSet(attrs))
+		}
 	}
 	if cacheCreationInputTokens, ok := usage.CacheCreationInputTokens(); ok {
 		b.metrics.tokenUsage.Record(ctx, float64(cacheCreationInputTokens),
@@ -178,6 +216,61 @@ func (b *metricsImpl) RecordTokenUsage(ctx context.Context, usage TokenUsage, re
 			metric.WithAttributes(attribute.Key(genaiAttributeTokenType).String(genaiTokenTypeReasoning)),
 		)
 	}
+	if images, ok := usage.Images(); ok && images.Count > 0 {
+		b.metrics.imageCount.Add(ctx, float64(images.Count),
+			metric.WithAttributeSet(attrs),
+			metric.WithAttributes(
+				attribute.Key(genaiAttributeImageSize).String(images.Size),
+				attribute.Key(genaiAttributeImageQuality).String(images.Quality),
+			),
+		)
+	}
+}
+
+// RecordProviderRateLimits implements [Metrics.RecordProviderRateLimits].
+func (b *metricsImpl) RecordProviderRateLimits(ctx context.Context, responseHeaders map[string]string) {
+	for _, rl := range parseProviderRateLimits(responseHeaders, time.Now()) {
+		attrs := metric.WithAttributes(
+			attribute.Key(genaiAttributeProviderName).String(b.backend),
+			attribute.Key(providerRateLimitAttributeBackend).String(b.backendName),
+			attribute.Key(providerRateLimitAttributeType).String(rl.typ),
+		)
+		if rl.hasLimit {
+			b.metrics.rateLimitLimit.Record(ctx, rl.limit, attrs)
+		}
+		if rl.hasRemaining {
+			b.metrics.rateLimitRemaining.Record(ctx, rl.remaining, attrs)
+		}
+		if !rl.reset.IsZero() {
+			b.metrics.rateLimitReset.Record(ctx, float64(rl.reset.Unix()), attrs)
+		}
+	}
+}
+
+// RecordResponseTruncated implements [Metrics.RecordResponseTruncated].
+func (b *metricsImpl) RecordResponseTruncated(ctx context.Context, reason ResponseTruncationReason, requestHeaders map[string]string) {
+	b.metrics.responseTruncated.Add(ctx, 1,
+		metric.WithAttributeSet(b.buildBaseAttributes(requestHeaders)),
+		metric.WithAttributes(attribute.Key(genaiAttributeTruncationReason).String(string(reason))),
+	)
+}
+
+// RecordContextLengthExceeded implements [Metrics.RecordContextLengthExceeded].
+func (b *metricsImpl) RecordContextLengthExceeded(ctx context.Context, requestHeaders map[string]string) {
+	b.metrics.contextLengthExceeded.Add(ctx, 1, metric.WithAttributeSet(b.buildBaseAttributes(requestHeaders)))
+}
+
+// RecordPhaseDuration implements [Metrics.RecordPhaseDuration].
+func (b *metricsImpl) RecordPhaseDuration(ctx context.Context, phase UpstreamPhase, timedOut bool, requestHeaders map[string]string) {
+	ctx = b.withSpanContext(ctx)
+	attrs := []attribute.KeyValue{attribute.Key(genaiAttributePhase).String(string(phase))}
+	if timedOut {
+		attrs = append(attrs, attribute.Key(genaiAttributeErrorType).String(string(phase)+"_timeout"))
+	}
+	b.metrics.phaseLatency.Record(ctx, time.Since(b.requestStart).Seconds(),
+		metric.WithAttributeSet(b.buildBaseAttributes(requestHeaders)),
+		metric.WithAttributes(attrs...),
+	)
 }
 
 // GetTimeToFirstTokenMs implements [Metrics.GetTimeToFirstTokenMs].
@@ -192,6 +285,7 @@ func (b *metricsImpl) GetInterTokenLatencyMs() float64 {
 
 // RecordTokenLatency implements [CompletionMetrics.RecordTokenLatency].
 func (b *metricsImpl) RecordTokenLatency(ctx context.Context, tokens uint32, endOfStream bool, requestHeaders map[string]string) {
+	ctx = b.withSpanContext(ctx)
 	attrs := b.buildBaseAttributes(requestHeaders)
 
 	// Record time to first token on the first call for streaming responses.
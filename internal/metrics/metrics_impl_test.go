@@ -15,6 +15,7 @@ import (
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/sdk/metric"
 	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/envoyproxy/ai-gateway/internal/filterapi"
 	"github.com/envoyproxy/ai-gateway/internal/internalapi"
@@ -103,6 +104,70 @@ func TestRecordTokenUsage(t *testing.T) {
 	assert.Equal(t, 5.0, sum)
 }
 
+func TestRecordTokenUsage_CacheSavings(t *testing.T) {
+	t.Parallel()
+	var (
+		mr    = metric.NewManualReader()
+		meter = metric.NewMeterProvider(metric.WithReader(mr)).Meter("test")
+		usage TokenUsage
+	)
+	usage.SetInputTokens(100)
+	usage.SetCachedInputTokens(80)
+
+	// The providers with a known discount record the savings.
+	pm := NewMetricsFactory(meter, nil, GenAIOperationMessages).NewMetrics().(*metricsImpl)
+	pm.SetBackend(&filterapi.Backend{Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaAnthropic}})
+	pm.RecordTokenUsage(t.Context(), usage, nil)
+	pm.RecordTokenUsage(t.Context(), usage, nil)
+	attrs := attribute.NewSet(
+		attribute.Key(genaiAttributeOperationName).String(string(GenAIOperationMessages)),
+		attribute.Key(genaiAttributeProviderName).String(genaiProviderAnthropic),
+		attribute.Key(genaiAttributeOriginalModel).String("unknown"),
+		attribute.Key(genaiAttributeRequestModel).String("unknown"),
+		attribute.Key(genaiAttributeResponseModel).String("unknown"),
+	)
+	assert.InDelta(t, 144.0, testotel.GetCounterValue(t, mr, genaiMetricClientTokenCacheSavings, attrs), 1e-9)
+
+	// The others don't.
+	pm = NewMetricsFactory(meter, nil, GenAIOperationChat).NewMetrics().(*metricsImpl)
+	pm.SetBackend(&filterapi.Backend{Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}})
+	pm.RecordTokenUsage(t.Context(), usage, nil)
+	var data metricdata.ResourceMetrics
+	require.NoError(t, mr.Collect(t.Context(), &data))
+	for _, sm := range data.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name == genaiMetricClientTokenCacheSavings {
+				require.Len(t, m.Data.(metricdata.Sum[float64]).DataPoints, 1)
+			}
+		}
+	}
+}
+
+func TestRecordTokenUsage_Images(t *testing.T) {
+	t.Parallel()
+	var (
+		mr    = metric.NewManualReader()
+		meter = metric.NewMeterProvider(metric.WithReader(mr)).Meter("test")
+		usage TokenUsage
+	)
+	usage.SetImages(ImageUsage{Count: 2, Size: "1024x1024", Quality: ImageQualityHigh})
+
+	pm := NewMetricsFactory(meter, nil, GenAIOperationImageGeneration).NewMetrics().(*metricsImpl)
+	pm.SetBackend(&filterapi.Backend{Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}})
+	pm.RecordTokenUsage(t.Context(), usage, nil)
+	pm.RecordTokenUsage(t.Context(), usage, nil)
+	attrs := attribute.NewSet(
+		attribute.Key(genaiAttributeOperationName).String(string(GenAIOperationImageGeneration)),
+		attribute.Key(genaiAttributeProviderName).String(genaiProviderOpenAI),
+		attribute.Key(genaiAttributeOriginalModel).String("unknown"),
+		attribute.Key(genaiAttributeRequestModel).String("unknown"),
+		attribute.Key(genaiAttributeResponseModel).String("unknown"),
+		attribute.Key(genaiAttributeImageSize).String("1024x1024"),
+		attribute.Key(genaiAttributeImageQuality).String(ImageQualityHigh),
+	)
+	assert.Equal(t, 4.0, testotel.GetCounterValue(t, mr, genaiMetricClientImageCount, attrs))
+}
+
 func TestRecordTokenLatency(t *testing.T) {
 	synctest.Test(t, testRecordTokenLatency)
 }
@@ -190,6 +255,104 @@ func testRecordRequestCompletion(t *testing.T) {
 	assert.Equal(t, 2*10*time.Millisecond.Seconds(), sum)
 }
 
+func TestRecordResponseTruncated(t *testing.T) {
+	t.Parallel()
+	var (
+		mr    = metric.NewManualReader()
+		meter = metric.NewMeterProvider(metric.WithReader(mr)).Meter("test")
+		pm    = NewMetricsFactory(meter, nil, GenAIOperationChat).NewMetrics().(*metricsImpl)
+		attrs = []attribute.KeyValue{
+			attribute.Key(genaiAttributeOperationName).String(string(GenAIOperationChat)),
+			attribute.Key(genaiAttributeProviderName).String(genaiProviderOpenAI),
+			attribute.Key(genaiAttributeOriginalModel).String("test-model"),
+			attribute.Key(genaiAttributeRequestModel).String("test-model"),
+			attribute.Key(genaiAttributeResponseModel).String("test-model"),
+		}
+		attrsIncomplete = attribute.NewSet(append(attrs, attribute.Key(genaiAttributeTruncationReason).String("incomplete"))...)
+		attrsAborted    = attribute.NewSet(append(attrs, attribute.Key(genaiAttributeTruncationReason).String("aborted"))...)
+	)
+
+	pm.SetOriginalModel("test-model")
+	pm.SetRequestModel("test-model")
+	pm.SetResponseModel("test-model")
+	pm.SetBackend(&filterapi.Backend{Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}})
+
+	pm.RecordResponseTruncated(t.Context(), ResponseTruncationReasonIncomplete, nil)
+	pm.RecordResponseTruncated(t.Context(), ResponseTruncationReasonAborted, nil)
+	pm.RecordResponseTruncated(t.Context(), ResponseTruncationReasonAborted, nil)
+	assert.Equal(t, 1.0, testotel.GetCounterValue(t, mr, genaiMetricResponseTruncated, attrsIncomplete))
+	assert.Equal(t, 2.0, testotel.GetCounterValue(t, mr, genaiMetricResponseTruncated, attrsAborted))
+}
+
+func TestRecordContextLengthExceeded(t *testing.T) {
+	t.Parallel()
+	var (
+		mr    = metric.NewManualReader()
+		meter = metric.NewMeterProvider(metric.WithReader(mr)).Meter("test")
+		pm    = NewMetricsFactory(meter, nil, GenAIOperationChat).NewMetrics().(*metricsImpl)
+		attrs = attribute.NewSet(
+			attribute.Key(genaiAttributeOperationName).String(string(GenAIOperationChat)),
+			attribute.Key(genaiAttributeProviderName).String(genaiProviderOpenAI),
+			attribute.Key(genaiAttributeOriginalModel).String("test-model"),
+			attribute.Key(genaiAttributeRequestModel).String("test-model"),
+			attribute.Key(genaiAttributeResponseModel).String("test-model"),
+		)
+	)
+
+	pm.SetOriginalModel("test-model")
+	pm.SetRequestModel("test-model")
+	pm.SetResponseModel("test-model")
+	pm.SetBackend(&filterapi.Backend{Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}})
+
+	pm.RecordContextLengthExceeded(t.Context(), nil)
+	pm.RecordContextLengthExceeded(t.Context(), nil)
+	assert.Equal(t, 2.0, testotel.GetCounterValue(t, mr, genaiMetricContextLengthExceeded, attrs))
+}
+
+func TestRecordPhaseDuration(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		var (
+			mr    = metric.NewManualReader()
+			meter = metric.NewMeterProvider(metric.WithReader(mr)).Meter("test")
+			pm    = NewMetricsFactory(meter, nil, GenAIOperationChat).NewMetrics().(*metricsImpl)
+			attrs = []attribute.KeyValue{
+				attribute.Key(genaiAttributeOperationName).String(string(GenAIOperationChat)),
+				attribute.Key(genaiAttributeProviderName).String(genaiProviderOpenAI),
+				attribute.Key(genaiAttributeOriginalModel).String("test-model"),
+				attribute.Key(genaiAttributeRequestModel).String("test-model"),
+				attribute.Key(genaiAttributeResponseModel).String("test-model"),
+			}
+			attrsFirstByte  = attribute.NewSet(append(attrs, attribute.Key(genaiAttributePhase).String("first_byte"))...)
+			attrsCompletion = attribute.NewSet(append(attrs, attribute.Key(genaiAttributePhase).String("completion"))...)
+			attrsTimeout    = attribute.NewSet(append(attrs,
+				attribute.Key(genaiAttributePhase).String("completion"),
+				attribute.Key(genaiAttributeErrorType).String("completion_timeout"),
+			)...)
+		)
+
+		pm.StartRequest(nil)
+		pm.SetOriginalModel("test-model")
+		pm.SetRequestModel("test-model")
+		pm.SetResponseModel("test-model")
+		pm.SetBackend(&filterapi.Backend{Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}})
+
+		time.Sleep(10 * time.Millisecond)
+		pm.RecordPhaseDuration(t.Context(), UpstreamPhaseFirstByte, false, nil)
+		time.Sleep(20 * time.Millisecond)
+		pm.RecordPhaseDuration(t.Context(), UpstreamPhaseCompletion, false, nil)
+		pm.RecordPhaseDuration(t.Context(), UpstreamPhaseCompletion, true, nil)
+
+		count, sum := testotel.GetHistogramValues(t, mr, genaiMetricServerPhaseDuration, attrsFirstByte)
+		assert.Equal(t, uint64(1), count)
+		assert.Equal(t, 10*time.Millisecond.Seconds(), sum)
+		count, sum = testotel.GetHistogramValues(t, mr, genaiMetricServerPhaseDuration, attrsCompletion)
+		assert.Equal(t, uint64(1), count)
+		assert.Equal(t, 30*time.Millisecond.Seconds(), sum)
+		count, _ = testotel.GetHistogramValues(t, mr, genaiMetricServerPhaseDuration, attrsTimeout)
+		assert.Equal(t, uint64(1), count)
+	})
+}
+
 func TestGetTimeToFirstTokenMsAndGetInterTokenLatencyMs(t *testing.T) {
 	t.Parallel()
 	c := metricsImpl{timeToFirstToken: 1 * time.Second, interTokenLatencySec: 2}
@@ -247,6 +410,38 @@ func TestHeaderLabelMapping(t *testing.T) {
 	assert.Equal(t, uint64(1), count)
 }
 
+func TestExperimentAttributes(t *testing.T) {
+	t.Parallel()
+	var (
+		mr    = metric.NewManualReader()
+		meter = metric.NewMeterProvider(metric.WithReader(mr)).Meter("test")
+		pm    = NewMetricsFactory(meter, nil, GenAIOperationChat).NewMetrics().(*metricsImpl)
+	)
+
+	requestHeaders := map[string]string{
+		internalapi.ExperimentHeader:        "gpt-5-rollout",
+		internalapi.ExperimentVariantHeader: "treatment",
+	}
+	pm.StartRequest(requestHeaders)
+	pm.SetOriginalModel("gpt-4o")
+	pm.SetRequestModel("gpt-5")
+	pm.SetResponseModel("gpt-5")
+	pm.SetBackend(&filterapi.Backend{Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}})
+	pm.RecordRequestCompletion(t.Context(), true, requestHeaders)
+
+	attrs := attribute.NewSet(
+		attribute.Key(genaiAttributeOperationName).String(string(GenAIOperationChat)),
+		attribute.Key(genaiAttributeProviderName).String(genaiProviderOpenAI),
+		attribute.Key(genaiAttributeOriginalModel).String("gpt-4o"),
+		attribute.Key(genaiAttributeRequestModel).String("gpt-5"),
+		attribute.Key(genaiAttributeResponseModel).String("gpt-5"),
+		attribute.Key(genaiAttributeExperimentName).String("gpt-5-rollout"),
+		attribute.Key(genaiAttributeExperimentVariant).String("treatment"),
+	)
+	count, _ := testotel.GetHistogramValues(t, mr, genaiMetricServerRequestDuration, attrs)
+	assert.Equal(t, uint64(1), count)
+}
+
 // TestModelNameHeaderKey tests that the model used in metrics is taken from
 // the internalapi.ModelNameHeaderKey when present, which allows backend-specific
 // model overrides to be tracked in metrics.
@@ -353,6 +548,70 @@ func TestLabels_SetModel_RequestAndResponseDiffer(t *testing.T) {
 	assert.Equal(t, 3.0, sum)
 }
 
+func TestSetSpanContext(t *testing.T) {
+	t.Parallel()
+	var (
+		mr          = metric.NewManualReader()
+		meter       = metric.NewMeterProvider(metric.WithReader(mr)).Meter("test")
+		pm          = NewMetricsFactory(meter, nil, GenAIOperationChat).NewMetrics().(*metricsImpl)
+		spanContext = trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID:    trace.TraceID{0x01, 0x02, 0x03},
+			SpanID:     trace.SpanID{0x04, 0x05, 0x06},
+			TraceFlags: trace.FlagsSampled,
+		})
+		usage TokenUsage
+	)
+	usage.SetInputTokens(10)
+
+	pm.StartRequest(nil)
+	pm.SetBackend(&filterapi.Backend{Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}})
+	// No exemplar is attached without a span context.
+	pm.RecordRequestCompletion(t.Context(), true, nil)
+	require.Empty(t, getHistogramExemplarTraceIDs(t, mr)[genaiMetricServerRequestDuration])
+
+	pm.SetSpanContext(spanContext)
+	pm.RecordRequestCompletion(t.Context(), true, nil)
+	pm.RecordTokenUsage(t.Context(), usage, nil)
+	pm.RecordPhaseDuration(t.Context(), UpstreamPhaseFirstByte, false, nil)
+	pm.RecordTokenLatency(t.Context(), 1, false, nil)
+	traceIDs := getHistogramExemplarTraceIDs(t, mr)
+	for _, name := range []string{
+		genaiMetricServerRequestDuration,
+		genaiMetricClientTokenUsage,
+		genaiMetricServerPhaseDuration,
+		genaiMetricServerTimeToFirstToken,
+	} {
+		require.Equal(t, []trace.TraceID{spanContext.TraceID()}, traceIDs[name], name)
+	}
+
+	// The span context of the context takes precedence.
+	otherSpanContext := spanContext.WithTraceID(trace.TraceID{0x07})
+	pm.RecordTokenUsage(trace.ContextWithSpanContext(t.Context(), otherSpanContext), usage, nil)
+	require.Contains(t, getHistogramExemplarTraceIDs(t, mr)[genaiMetricClientTokenUsage], otherSpanContext.TraceID())
+}
+
+// getHistogramExemplarTraceIDs returns the trace IDs of the exemplars of the histogram metrics by name.
+func getHistogramExemplarTraceIDs(t *testing.T, reader metric.Reader) map[string][]trace.TraceID {
+	var data metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(t.Context(), &data))
+
+	traceIDs := make(map[string][]trace.TraceID)
+	for _, sm := range data.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			histogram, ok := m.Data.(metricdata.Histogram[float64])
+			if !ok {
+				continue
+			}
+			for _, dp := range histogram.DataPoints {
+				for _, e := range dp.Exemplars {
+					traceIDs[m.Name] = append(traceIDs[m.Name], trace.TraceID(e.TraceID))
+				}
+			}
+		}
+	}
+	return traceIDs
+}
+
 // getHistogramValues returns the count and sum of a histogram metric with the given attributes.
 func getHistogramValues(t *testing.T, reader metric.Reader, metric string, attrs attribute.Set) (uint64, float64) {
 	var data metricdata.ResourceMetrics
@@ -676,6 +935,11 @@ func TestSetBackendProviderName(t *testing.T) {
 			schema:           filterapi.APISchemaCohere,
 			expectedProvider: "cohere",
 		},
+		{
+			name:             "Mock schema",
+			schema:           filterapi.APISchemaMock,
+			expectedProvider: "mock",
+		},
 		{
 			name:             "Unknown schema falls back to backend name",
 			schema:           "UnknownSchema",
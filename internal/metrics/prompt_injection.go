@@ -0,0 +1,53 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package metrics
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const (
+	// Prompt injection score is a histogram of the risk scores of the requests screened for prompt injection and
+	// jailbreak attempts, from 0 to 100.
+	//
+	// Dimensions:
+	// - gen_ai.request.model
+	// - prompt_injection.blocked
+	promptInjectionScore = "gen_ai.prompt_injection.score"
+
+	promptInjectionAttributeBlocked = "prompt_injection.blocked"
+)
+
+// PromptInjectionMetrics records the results of screening the requests for prompt injection attempts.
+type PromptInjectionMetrics interface {
+	// RecordScore records the risk score of a request to the model, and whether the request was blocked.
+	RecordScore(ctx context.Context, score int, blocked bool, model string)
+}
+
+type promptInjection struct {
+	score metric.Float64Histogram
+}
+
+// NewPromptInjection creates a new PromptInjectionMetrics instance.
+func NewPromptInjection(meter metric.Meter) PromptInjectionMetrics {
+	return &promptInjection{
+		score: mustRegisterHistogram(meter, promptInjectionScore,
+			metric.WithDescription("The risk scores of the requests screened for prompt injection attempts"),
+			metric.WithExplicitBucketBoundaries(0, 10, 20, 30, 40, 50, 60, 70, 80, 90, 100),
+		),
+	}
+}
+
+// RecordScore implements [PromptInjectionMetrics.RecordScore].
+func (p *promptInjection) RecordScore(ctx context.Context, score int, blocked bool, model string) {
+	p.score.Record(ctx, float64(score), metric.WithAttributes(
+		attribute.String(genaiAttributeRequestModel, model),
+		attribute.Bool(promptInjectionAttributeBlocked, blocked),
+	))
+}
@@ -0,0 +1,42 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/metric"
+
+	"github.com/envoyproxy/ai-gateway/internal/testing/testotel"
+)
+
+func TestPromptInjection(t *testing.T) {
+	t.Parallel()
+	var (
+		mr    = metric.NewManualReader()
+		meter = metric.NewMeterProvider(metric.WithReader(mr)).Meter("test")
+		pi    = NewPromptInjection(meter)
+	)
+
+	pi.RecordScore(t.Context(), 0, false, "gpt-4o")
+	pi.RecordScore(t.Context(), 40, false, "gpt-4o")
+	pi.RecordScore(t.Context(), 90, true, "gpt-4o")
+
+	count, sum := testotel.GetHistogramValues(t, mr, promptInjectionScore, attribute.NewSet(
+		attribute.String(genaiAttributeRequestModel, "gpt-4o"),
+		attribute.Bool(promptInjectionAttributeBlocked, false),
+	))
+	require.Equal(t, uint64(2), count)
+	require.Equal(t, 40.0, sum)
+	count, sum = testotel.GetHistogramValues(t, mr, promptInjectionScore, attribute.NewSet(
+		attribute.String(genaiAttributeRequestModel, "gpt-4o"),
+		attribute.Bool(promptInjectionAttributeBlocked, true),
+	))
+	require.Equal(t, uint64(1), count)
+	require.Equal(t, 90.0, sum)
+}
@@ -0,0 +1,83 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package metrics
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Provider rate limit gauges. These are not part of the Semantic Conventions for Generative AI Metrics, and
+	// reflect the x-ratelimit-* (OpenAI, Azure OpenAI) and anthropic-ratelimit-* (Anthropic) response headers.
+	//
+	// Dimensions:
+	// - gen_ai.provider.name
+	// - backend
+	// - ratelimit.type
+	providerRateLimitLimit     = "gen_ai.provider.ratelimit.limit"
+	providerRateLimitRemaining = "gen_ai.provider.ratelimit.remaining"
+	// providerRateLimitReset is the Unix timestamp in seconds at which the provider rate limit resets.
+	providerRateLimitReset = "gen_ai.provider.ratelimit.reset"
+
+	providerRateLimitAttributeBackend = "backend"
+	providerRateLimitAttributeType    = "ratelimit.type"
+)
+
+// providerRateLimitTypes are the rate limit types reported by providers, in the form used in the header names.
+var providerRateLimitTypes = []string{"requests", "tokens", "input-tokens", "output-tokens"}
+
+// providerRateLimit is the provider rate limit state of a single type, e.g. "requests" or "tokens".
+type providerRateLimit struct {
+	// typ is the rate limit type, e.g. "requests" or "input_tokens".
+	typ                    string
+	limit, remaining       float64
+	hasLimit, hasRemaining bool
+	reset                  time.Time
+}
+
+// parseProviderRateLimits extracts the provider rate limits from the response headers. The reset headers are
+// either a duration relative to now (OpenAI, Azure OpenAI, e.g. "6m0s") or an RFC 3339 timestamp (Anthropic).
+func parseProviderRateLimits(headers map[string]string, now time.Time) []providerRateLimit {
+	var ret []providerRateLimit
+	for _, typ := range providerRateLimitTypes {
+		rl := providerRateLimit{typ: strings.ReplaceAll(typ, "-", "_")}
+		for _, names := range [...][3]string{
+			{"x-ratelimit-limit-" + typ, "x-ratelimit-remaining-" + typ, "x-ratelimit-reset-" + typ},
+			{"anthropic-ratelimit-" + typ + "-limit", "anthropic-ratelimit-" + typ + "-remaining", "anthropic-ratelimit-" + typ + "-reset"},
+		} {
+			if v, err := strconv.ParseFloat(headers[names[0]], 64); err == nil {
+				rl.limit, rl.hasLimit = v, true
+			}
+			if v, err := strconv.ParseFloat(headers[names[1]], 64); err == nil {
+				rl.remaining, rl.hasRemaining = v, true
+			}
+			if v := headers[names[2]]; v != "" {
+				rl.reset = parseProviderRateLimitReset(v, now)
+			}
+		}
+		if rl.hasLimit || rl.hasRemaining || !rl.reset.IsZero() {
+			ret = append(ret, rl)
+		}
+	}
+	return ret
+}
+
+// parseProviderRateLimitReset parses the reset header value. This returns the zero time if the value is invalid.
+func parseProviderRateLimitReset(v string, now time.Time) time.Time {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t
+	}
+	if d, err := time.ParseDuration(v); err == nil {
+		return now.Add(d)
+	}
+	// Some providers return the number of seconds without a unit.
+	if secs, err := strconv.ParseFloat(v, 64); err == nil {
+		return now.Add(time.Duration(secs * float64(time.Second)))
+	}
+	return time.Time{}
+}
@@ -0,0 +1,136 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	"github.com/envoyproxy/ai-gateway/internal/filterapi"
+)
+
+func Test_parseProviderRateLimits(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0).UTC()
+	for _, tc := range []struct {
+		name    string
+		headers map[string]string
+		exp     []providerRateLimit
+	}{
+		{name: "no headers", headers: map[string]string{":status": "200"}},
+		{
+			name: "openai",
+			headers: map[string]string{
+				"x-ratelimit-limit-requests":     "60",
+				"x-ratelimit-remaining-requests": "59",
+				"x-ratelimit-reset-requests":     "1s",
+				"x-ratelimit-limit-tokens":       "150000",
+				"x-ratelimit-remaining-tokens":   "149984",
+				"x-ratelimit-reset-tokens":       "6m0s",
+			},
+			exp: []providerRateLimit{
+				{typ: "requests", limit: 60, hasLimit: true, remaining: 59, hasRemaining: true, reset: now.Add(time.Second)},
+				{typ: "tokens", limit: 150000, hasLimit: true, remaining: 149984, hasRemaining: true, reset: now.Add(6 * time.Minute)},
+			},
+		},
+		{
+			name: "azure openai remaining only",
+			headers: map[string]string{
+				"x-ratelimit-remaining-requests": "9",
+				"x-ratelimit-remaining-tokens":   "9000",
+			},
+			exp: []providerRateLimit{
+				{typ: "requests", remaining: 9, hasRemaining: true},
+				{typ: "tokens", remaining: 9000, hasRemaining: true},
+			},
+		},
+		{
+			name: "anthropic",
+			headers: map[string]string{
+				"anthropic-ratelimit-requests-limit":         "50",
+				"anthropic-ratelimit-requests-remaining":     "49",
+				"anthropic-ratelimit-requests-reset":         "2023-11-14T22:13:21Z",
+				"anthropic-ratelimit-input-tokens-limit":     "40000",
+				"anthropic-ratelimit-input-tokens-remaining": "39000",
+				"anthropic-ratelimit-output-tokens-reset":    "2023-11-14T22:14:20Z",
+			},
+			exp: []providerRateLimit{
+				{typ: "requests", limit: 50, hasLimit: true, remaining: 49, hasRemaining: true, reset: now.Add(time.Second)},
+				{typ: "input_tokens", limit: 40000, hasLimit: true, remaining: 39000, hasRemaining: true},
+				{typ: "output_tokens", reset: now.Add(time.Minute)},
+			},
+		},
+		{
+			name: "invalid values are ignored",
+			headers: map[string]string{
+				"x-ratelimit-limit-requests": "many",
+				"x-ratelimit-reset-requests": "soon",
+				"x-ratelimit-reset-tokens":   "1.5",
+			},
+			exp: []providerRateLimit{
+				{typ: "tokens", reset: now.Add(1500 * time.Millisecond)},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := parseProviderRateLimits(tc.headers, now)
+			require.Len(t, got, len(tc.exp))
+			for i := range tc.exp {
+				require.Equal(t, tc.exp[i].typ, got[i].typ)
+				require.Equal(t, tc.exp[i].limit, got[i].limit)
+				require.Equal(t, tc.exp[i].hasLimit, got[i].hasLimit)
+				require.Equal(t, tc.exp[i].remaining, got[i].remaining)
+				require.Equal(t, tc.exp[i].hasRemaining, got[i].hasRemaining)
+				require.True(t, tc.exp[i].reset.Equal(got[i].reset), "expected %s, got %s", tc.exp[i].reset, got[i].reset)
+			}
+		})
+	}
+}
+
+func TestRecordProviderRateLimits(t *testing.T) {
+	t.Parallel()
+	var (
+		mr    = metric.NewManualReader()
+		meter = metric.NewMeterProvider(metric.WithReader(mr)).Meter("test")
+		pm    = NewMetricsFactory(meter, nil, GenAIOperationChat).NewMetrics().(*metricsImpl)
+	)
+	pm.SetBackend(&filterapi.Backend{Name: "openai-backend", Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}})
+	pm.RecordProviderRateLimits(t.Context(), map[string]string{
+		"x-ratelimit-limit-tokens":     "1000",
+		"x-ratelimit-remaining-tokens": "750",
+		"x-ratelimit-reset-tokens":     "1m0s",
+	})
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, mr.Collect(t.Context(), &rm))
+	gauges := map[string]metricdata.DataPoint[float64]{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			g, ok := m.Data.(metricdata.Gauge[float64])
+			if !ok {
+				continue
+			}
+			require.Len(t, g.DataPoints, 1)
+			gauges[m.Name] = g.DataPoints[0]
+		}
+	}
+
+	expAttrs := attribute.NewSet(
+		attribute.Key(genaiAttributeProviderName).String(genaiProviderOpenAI),
+		attribute.Key(providerRateLimitAttributeBackend).String("openai-backend"),
+		attribute.Key(providerRateLimitAttributeType).String("tokens"),
+	)
+	require.Len(t, gauges, 3)
+	require.Equal(t, 1000.0, gauges[providerRateLimitLimit].Value)
+	require.Equal(t, expAttrs, gauges[providerRateLimitLimit].Attributes)
+	require.Equal(t, 750.0, gauges[providerRateLimitRemaining].Value)
+	require.Equal(t, expAttrs, gauges[providerRateLimitRemaining].Attributes)
+	require.InDelta(t, float64(time.Now().Add(time.Minute).Unix()), gauges[providerRateLimitReset].Value, 5)
+}
@@ -0,0 +1,68 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package metrics
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const (
+	// Quota fallback degraded is a gauge set to 1 while the quota rate limit service is unreachable and the local
+	// fallback limits of the QuotaPolicies are enforced, and to 0 otherwise.
+	quotaFallbackDegraded = "quota.fallback.degraded"
+	// Quota fallback rejected is a counter of the requests rejected by the local fallback limits.
+	//
+	// Dimensions:
+	// - backend
+	// - gen_ai.request.model
+	quotaFallbackRejected = "quota.fallback.rejected"
+
+	quotaFallbackAttributeBackend = "backend"
+)
+
+// QuotaFallbackMetrics records the state of the local rate limiting applied while the quota rate limit service
+// is unreachable.
+type QuotaFallbackMetrics interface {
+	// RecordDegraded records whether the local fallback limits are enforced.
+	RecordDegraded(ctx context.Context, degraded bool)
+	// RecordRejected records a request to the backend and model rejected by the local fallback limits.
+	RecordRejected(ctx context.Context, backend, model string)
+}
+
+type quotaFallback struct {
+	degraded metric.Float64Gauge
+	rejected metric.Float64Counter
+}
+
+// NewQuotaFallback creates a new QuotaFallbackMetrics instance.
+func NewQuotaFallback(meter metric.Meter) QuotaFallbackMetrics {
+	return &quotaFallback{
+		degraded: mustRegisterGauge(meter, quotaFallbackDegraded,
+			metric.WithDescription("Whether the quota rate limit service is unreachable and the local fallback limits are enforced")),
+		rejected: mustRegisterCounter(meter, quotaFallbackRejected,
+			metric.WithDescription("The number of requests rejected by the local fallback limits of the quotas")),
+	}
+}
+
+// RecordDegraded implements [QuotaFallbackMetrics.RecordDegraded].
+func (q *quotaFallback) RecordDegraded(ctx context.Context, degraded bool) {
+	var v float64
+	if degraded {
+		v = 1
+	}
+	q.degraded.Record(ctx, v)
+}
+
+// RecordRejected implements [QuotaFallbackMetrics.RecordRejected].
+func (q *quotaFallback) RecordRejected(ctx context.Context, backend, model string) {
+	q.rejected.Add(ctx, 1, metric.WithAttributes(
+		attribute.String(quotaFallbackAttributeBackend, backend),
+		attribute.String(genaiAttributeRequestModel, model),
+	))
+}
@@ -0,0 +1,54 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	"github.com/envoyproxy/ai-gateway/internal/testing/testotel"
+)
+
+func TestQuotaFallback(t *testing.T) {
+	t.Parallel()
+	var (
+		mr    = metric.NewManualReader()
+		meter = metric.NewMeterProvider(metric.WithReader(mr)).Meter("test")
+		qf    = NewQuotaFallback(meter)
+	)
+
+	degraded := func() float64 {
+		var rm metricdata.ResourceMetrics
+		require.NoError(t, mr.Collect(t.Context(), &rm))
+		for _, sm := range rm.ScopeMetrics {
+			for _, m := range sm.Metrics {
+				if m.Name == quotaFallbackDegraded {
+					g := m.Data.(metricdata.Gauge[float64])
+					require.Len(t, g.DataPoints, 1)
+					return g.DataPoints[0].Value
+				}
+			}
+		}
+		t.Fatalf("no gauge value found for metric %s", quotaFallbackDegraded)
+		return 0
+	}
+
+	qf.RecordDegraded(t.Context(), true)
+	require.Equal(t, 1.0, degraded())
+	qf.RecordDegraded(t.Context(), false)
+	require.Equal(t, 0.0, degraded())
+
+	qf.RecordRejected(t.Context(), "default/openai", "gpt-4o")
+	qf.RecordRejected(t.Context(), "default/openai", "gpt-4o")
+	require.Equal(t, 2.0, testotel.GetCounterValue(t, mr, quotaFallbackRejected, attribute.NewSet(
+		attribute.String(quotaFallbackAttributeBackend, "default/openai"),
+		attribute.String(genaiAttributeRequestModel, "gpt-4o"),
+	)))
+}
@@ -24,3 +24,12 @@ func mustRegisterHistogram(meter metric.Meter, name string, options ...metric.Fl
 	}
 	return h
 }
+
+// mustRegisterGauge registers a Gauge with the meter and panics if it fails.
+func mustRegisterGauge(meter metric.Meter, name string, options ...metric.Float64GaugeOption) metric.Float64Gauge {
+	g, err := meter.Float64Gauge(name, options...)
+	if err != nil {
+		panic(err)
+	}
+	return g
+}
@@ -0,0 +1,482 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+// Package mockprovider implements the mock OpenAI provider served by the external processor for the
+// AIServiceBackends with the Mock API schema. Unlike the test upstream, the responses are not scripted by the
+// tests but derived from the requests, so that the same request always gets the same completion. This makes the
+// mock provider usable for the e2e tests, the demos and the canary environments without any real provider.
+package mockprovider
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tidwall/gjson"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/json"
+)
+
+const (
+	// LatencyHeader overrides Config.Latency for the request, e.g. "250ms".
+	LatencyHeader = "x-mock-latency"
+	// TokensPerSecondHeader overrides Config.TokensPerSecond for the request, e.g. "50".
+	TokensPerSecondHeader = "x-mock-tokens-per-second"
+	// StatusHeader makes the mock provider fail the request with the status code, e.g. "429" or "503", to
+	// exercise the retries and the fallbacks.
+	StatusHeader = "x-mock-status"
+
+	// Model is the model listed by /v1/models. Any model is accepted by the other endpoints.
+	Model = "mock"
+	// defaultEmbeddingDimensions is the size of the embeddings when the request doesn't set the dimensions.
+	defaultEmbeddingDimensions = 16
+	// maxEmbeddingDimensions bounds the dimensions requested.
+	maxEmbeddingDimensions = 4096
+	// minCompletionTokens and maxCompletionTokens bound the number of tokens of the completions unless the
+	// request sets a lower maximum.
+	minCompletionTokens = 8
+	maxCompletionTokens = 32
+	// bytesPerToken is the number of bytes of the input counted as a token.
+	bytesPerToken = 4
+	// maxRequestBodySize is the maximum size of the request bodies read.
+	maxRequestBodySize = 32 << 20
+)
+
+// words are the tokens of the completions.
+var words = strings.Fields(`the gateway routes every request to a backend and the backend answers with a ` +
+	`completion made of tokens which are counted for the metrics and the costs while the stream keeps the ` +
+	`client waiting until the last token arrives so that latency and throughput can be observed end to end`)
+
+// Config is the default behavior of the mock provider, which the requests can override with the headers.
+type Config struct {
+	// Latency is the delay before the first token of the streams and before the non-streaming responses.
+	Latency time.Duration
+	// TokensPerSecond is the rate the completion tokens are generated at. Zero generates them all at once.
+	TokensPerSecond float64
+}
+
+// handler serves the mock OpenAI API.
+type handler struct {
+	logger *slog.Logger
+	config Config
+	// sleep waits for the duration unless the request is canceled. This is replaced in the tests.
+	sleep func(r *http.Request, d time.Duration) bool
+}
+
+// NewHandler returns the handler of the mock OpenAI API, serving /v1/chat/completions, /v1/completions,
+// /v1/embeddings and /v1/models.
+func NewHandler(logger *slog.Logger, config Config) http.Handler {
+	h := &handler{logger: logger, config: config, sleep: sleep}
+	return h.routes()
+}
+
+// routes returns the mux of the endpoints.
+func (h *handler) routes() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/chat/completions", h.chatCompletions)
+	mux.HandleFunc("POST /v1/completions", h.completions)
+	mux.HandleFunc("POST /v1/embeddings", h.embeddings)
+	mux.HandleFunc("GET /v1/models", h.models)
+	return mux
+}
+
+func sleep(r *http.Request, d time.Duration) bool {
+	if d <= 0 {
+		return r.Context().Err() == nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-r.Context().Done():
+		return false
+	}
+}
+
+// completion is the deterministic completion of a request.
+type completion struct {
+	id           string
+	model        string
+	tokens       []string
+	promptTokens int
+	finishReason openai.ChatCompletionChoicesFinishReason
+	stream       bool
+	includeUsage bool
+}
+
+// usage returns the usage of the completion.
+func (c *completion) usage() openai.Usage {
+	return openai.Usage{
+		PromptTokens:     c.promptTokens,
+		CompletionTokens: len(c.tokens),
+		TotalTokens:      c.promptTokens + len(c.tokens),
+	}
+}
+
+// newCompletion derives the completion from the request body: the same model, prompt, seed and max tokens always
+// give the same completion.
+func newCompletion(body []byte, prompt, maxTokensPath string) *completion {
+	req := gjson.ParseBytes(body)
+	seed := hash(req.Get("model").String(), prompt, req.Get("seed").Raw)
+	n := minCompletionTokens + int(seed%(maxCompletionTokens-minCompletionTokens+1))
+	c := &completion{
+		id:           strconv.FormatUint(seed, 16),
+		model:        req.Get("model").String(),
+		promptTokens: max((len(prompt)+bytesPerToken-1)/bytesPerToken, 1),
+		finishReason: openai.ChatCompletionChoicesFinishReasonStop,
+		stream:       req.Get("stream").Bool(),
+		includeUsage: req.Get("stream_options.include_usage").Bool(),
+	}
+	if maxTokens := req.Get(maxTokensPath); maxTokens.Exists() && maxTokens.Int() > 0 && int(maxTokens.Int()) < n {
+		n = int(maxTokens.Int())
+		c.finishReason = openai.ChatCompletionChoicesFinishReasonLength
+	}
+	c.tokens = make([]string, n)
+	for i := range c.tokens {
+		word := words[(seed>>(i%8*8)+uint64(i)*7)%uint64(len(words))]
+		if i > 0 {
+			word = " " + word
+		}
+		c.tokens[i] = word
+	}
+	if c.finishReason == openai.ChatCompletionChoicesFinishReasonStop {
+		c.tokens[n-1] += "."
+	}
+	return c
+}
+
+// hash returns the FNV-1a hash of the parts.
+func hash(parts ...string) uint64 {
+	h := fnv.New64a()
+	for _, p := range parts {
+		_, _ = h.Write([]byte(p))
+		_, _ = h.Write([]byte{0})
+	}
+	return h.Sum64()
+}
+
+// chatCompletions serves /v1/chat/completions.
+func (h *handler) chatCompletions(w http.ResponseWriter, r *http.Request) {
+	body, ok := h.readBody(w, r)
+	if !ok {
+		return
+	}
+	var prompt strings.Builder
+	for _, m := range gjson.GetBytes(body, "messages").Array() {
+		prompt.WriteString(m.Get("role").String())
+		prompt.WriteString(": ")
+		prompt.WriteString(m.Get("content").String())
+		prompt.WriteString("\n")
+	}
+	maxTokensPath := "max_completion_tokens"
+	if !gjson.GetBytes(body, maxTokensPath).Exists() {
+		maxTokensPath = "max_tokens"
+	}
+	c := newCompletion(body, prompt.String(), maxTokensPath)
+	c.id = "chatcmpl-" + c.id
+	created := openai.JSONUNIXTime(time.Now())
+
+	if !c.stream {
+		if !h.generate(w, r, c, nil, nil) {
+			return
+		}
+		content := strings.Join(c.tokens, "")
+		h.writeJSON(w, &openai.ChatCompletionResponse{
+			ID:      c.id,
+			Object:  "chat.completion",
+			Created: created,
+			Model:   c.model,
+			Choices: []openai.ChatCompletionResponseChoice{{
+				FinishReason: c.finishReason,
+				Message:      openai.ChatCompletionResponseChoiceMessage{Role: openai.ChatMessageRoleAssistant, Content: &content},
+			}},
+			Usage: c.usage(),
+		})
+		return
+	}
+
+	chunk := func(delta *openai.ChatCompletionResponseChunkChoiceDelta, finishReason openai.ChatCompletionChoicesFinishReason) any {
+		return &openai.ChatCompletionResponseChunk{
+			ID:      c.id,
+			Object:  "chat.completion.chunk",
+			Created: created,
+			Model:   c.model,
+			Choices: []openai.ChatCompletionResponseChunkChoice{{Delta: delta, FinishReason: finishReason}},
+		}
+	}
+	h.generate(w, r, c, func(i int) any {
+		if i < 0 {
+			return chunk(&openai.ChatCompletionResponseChunkChoiceDelta{Role: openai.ChatMessageRoleAssistant}, "")
+		}
+		if i == len(c.tokens) {
+			return chunk(&openai.ChatCompletionResponseChunkChoiceDelta{}, c.finishReason)
+		}
+		return chunk(&openai.ChatCompletionResponseChunkChoiceDelta{Content: &c.tokens[i]}, "")
+	}, func() any {
+		usage := c.usage()
+		return &openai.ChatCompletionResponseChunk{
+			ID: c.id, Object: "chat.completion.chunk", Created: created, Model: c.model,
+			Choices: []openai.ChatCompletionResponseChunkChoice{}, Usage: &usage,
+		}
+	})
+}
+
+// completions serves /v1/completions.
+func (h *handler) completions(w http.ResponseWriter, r *http.Request) {
+	body, ok := h.readBody(w, r)
+	if !ok {
+		return
+	}
+	c := newCompletion(body, gjson.GetBytes(body, "prompt").String(), "max_tokens")
+	c.id = "cmpl-" + c.id
+	created := openai.JSONUNIXTime(time.Now())
+	index := 0
+	response := func(text, finishReason string, usage *openai.Usage) any {
+		return &openai.CompletionResponse{
+			ID:      c.id,
+			Object:  "text_completion",
+			Created: created,
+			Model:   c.model,
+			Choices: []openai.CompletionChoice{{Text: text, Index: &index, FinishReason: finishReason}},
+			Usage:   usage,
+		}
+	}
+
+	if !c.stream {
+		if !h.generate(w, r, c, nil, nil) {
+			return
+		}
+		usage := c.usage()
+		h.writeJSON(w, response(strings.Join(c.tokens, ""), string(c.finishReason), &usage))
+		return
+	}
+	h.generate(w, r, c, func(i int) any {
+		switch {
+		case i < 0:
+			return nil
+		case i == len(c.tokens):
+			return response("", string(c.finishReason), nil)
+		default:
+			return response(c.tokens[i], "", nil)
+		}
+	}, func() any {
+		usage := c.usage()
+		return &openai.CompletionResponse{
+			ID: c.id, Object: "text_completion", Created: created, Model: c.model,
+			Choices: []openai.CompletionChoice{}, Usage: &usage,
+		}
+	})
+}
+
+// generate waits for the latency and the generation of the tokens of the completion. If event isn't nil, the
+// completion is streamed as server-sent events: event returns the event of the role (-1), of each token and of the
+// end of the completion (len(tokens)), or nil to skip it, and usage returns the event of the usage sent when the
+// request includes it. This returns false if the request was canceled or failed.
+func (h *handler) generate(w http.ResponseWriter, r *http.Request, c *completion, event func(i int) any, usage func() any) bool {
+	tokensPerSecond := h.config.TokensPerSecond
+	if v := r.Header.Get(TokensPerSecondHeader); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil && f >= 0 && !math.IsInf(f, 0) {
+			tokensPerSecond = f
+		}
+	}
+	var interval time.Duration
+	if tokensPerSecond > 0 {
+		interval = time.Duration(float64(time.Second) / tokensPerSecond)
+	}
+
+	if !h.sleep(r, h.latency(r)) {
+		return false
+	}
+	if event == nil {
+		return h.sleep(r, interval*time.Duration(len(c.tokens)))
+	}
+
+	w.Header().Set("content-type", "text/event-stream")
+	w.Header().Set("cache-control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	send := func(e any) bool {
+		if e == nil {
+			return true
+		}
+		data, err := json.Marshal(e)
+		if err != nil {
+			h.logger.Error("failed to marshal the mock event", slog.String("error", err.Error()))
+			return false
+		}
+		if _, err = fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+			return false
+		}
+		_ = http.NewResponseController(w).Flush()
+		return true
+	}
+	if !send(event(-1)) {
+		return false
+	}
+	for i := range c.tokens {
+		if (i > 0 && !h.sleep(r, interval)) || !send(event(i)) {
+			return false
+		}
+	}
+	if !send(event(len(c.tokens))) {
+		return false
+	}
+	if c.includeUsage && !send(usage()) {
+		return false
+	}
+	_, err := io.WriteString(w, "data: [DONE]\n\n")
+	return err == nil
+}
+
+// embeddings serves /v1/embeddings. The embeddings are unit vectors derived from the inputs.
+func (h *handler) embeddings(w http.ResponseWriter, r *http.Request) {
+	body, ok := h.readBody(w, r)
+	if !ok {
+		return
+	}
+	input := gjson.GetBytes(body, "input")
+	var inputs []gjson.Result
+	if input.IsArray() && !input.Get("0").IsArray() && input.Get("0").Type != gjson.Number {
+		inputs = input.Array()
+	} else {
+		inputs = []gjson.Result{input}
+	}
+	dimensions := defaultEmbeddingDimensions
+	if d := gjson.GetBytes(body, "dimensions").Int(); d > 0 {
+		dimensions = int(min(d, maxEmbeddingDimensions))
+	}
+	if !h.sleep(r, h.latency(r)) {
+		return
+	}
+
+	type embedding struct {
+		Object    string    `json:"object"`
+		Embedding []float64 `json:"embedding"`
+		Index     int       `json:"index"`
+	}
+	resp := struct {
+		Object string                `json:"object"`
+		Data   []embedding           `json:"data"`
+		Model  string                `json:"model"`
+		Usage  openai.EmbeddingUsage `json:"usage"`
+	}{Object: "list", Data: make([]embedding, len(inputs)), Model: gjson.GetBytes(body, "model").String()}
+	for i, in := range inputs {
+		resp.Data[i] = embedding{Object: "embedding", Embedding: embeddingVector(in.Raw, dimensions), Index: i}
+		resp.Usage.PromptTokens += max((len(in.String())+bytesPerToken-1)/bytesPerToken, 1)
+	}
+	resp.Usage.TotalTokens = resp.Usage.PromptTokens
+	h.writeJSON(w, &resp)
+}
+
+// latency returns the latency of the request.
+func (h *handler) latency(r *http.Request) time.Duration {
+	if v := r.Header.Get(LatencyHeader); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			return d
+		}
+	}
+	return h.config.Latency
+}
+
+// embeddingVector returns the unit vector of the dimensions derived from the input.
+func embeddingVector(input string, dimensions int) []float64 {
+	v := make([]float64, dimensions)
+	var norm float64
+	h := fnv.New64a()
+	var buf [8]byte
+	for i := range v {
+		h.Reset()
+		binary.LittleEndian.PutUint64(buf[:], uint64(i))
+		_, _ = h.Write(buf[:])
+		_, _ = h.Write([]byte(input))
+		v[i] = float64(h.Sum64()%2001)/1000 - 1
+		norm += v[i] * v[i]
+	}
+	if norm == 0 {
+		v[0], norm = 1, 1
+	}
+	norm = math.Sqrt(norm)
+	for i := range v {
+		v[i] /= norm
+	}
+	return v
+}
+
+// models serves /v1/models.
+func (h *handler) models(w http.ResponseWriter, r *http.Request) {
+	if status := failureStatus(r); status != 0 {
+		writeError(w, status)
+		return
+	}
+	h.writeJSON(w, &openai.ModelList{
+		Object: "list",
+		Data:   []openai.Model{{ID: Model, Object: "model", OwnedBy: "envoy-ai-gateway"}},
+	})
+}
+
+// readBody reads the JSON body of the request. This returns false after writing the error response if the body
+// is invalid or the request must fail with the StatusHeader.
+func (h *handler) readBody(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	if status := failureStatus(r); status != 0 {
+		writeError(w, status)
+		return nil, false
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestBodySize))
+	if err != nil {
+		h.logger.Warn("failed to read the mock request body", slog.String("error", err.Error()))
+		writeError(w, http.StatusBadRequest)
+		return nil, false
+	}
+	if !gjson.ValidBytes(body) {
+		writeError(w, http.StatusBadRequest)
+		return nil, false
+	}
+	return body, true
+}
+
+// failureStatus returns the error status code set by the StatusHeader, or zero.
+func failureStatus(r *http.Request) int {
+	status, err := strconv.Atoi(r.Header.Get(StatusHeader))
+	if err != nil || status < 400 || status > 599 {
+		return 0
+	}
+	return status
+}
+
+// writeError writes the OpenAI error response of the status code.
+func writeError(w http.ResponseWriter, status int) {
+	errorType := "invalid_request_error"
+	if status >= 500 {
+		errorType = "server_error"
+	}
+	code := strconv.Itoa(status)
+	body, _ := json.Marshal(&openai.Error{
+		Type:  "error",
+		Error: openai.ErrorType{Type: errorType, Code: &code, Message: "mock provider: " + http.StatusText(status)},
+	})
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+}
+
+// writeJSON writes the JSON response.
+func (h *handler) writeJSON(w http.ResponseWriter, v any) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		h.logger.Error("failed to marshal the mock response", slog.String("error", err.Error()))
+		writeError(w, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("content-type", "application/json")
+	_, _ = w.Write(body)
+}
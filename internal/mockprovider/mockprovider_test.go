@@ -0,0 +1,175 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package mockprovider
+
+import (
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+// newTestHandler returns the handler recording the durations it waits for instead of waiting.
+func newTestHandler(config Config) (http.Handler, *[]time.Duration) {
+	var slept []time.Duration
+	h := &handler{logger: slog.Default(), config: config, sleep: func(_ *http.Request, d time.Duration) bool {
+		slept = append(slept, d)
+		return true
+	}}
+	return h.routes(), &slept
+}
+
+func serve(h http.Handler, method, path, body string, headers ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestChatCompletions(t *testing.T) {
+	h, slept := newTestHandler(Config{Latency: 100 * time.Millisecond, TokensPerSecond: 10})
+	const body = `{"model":"gpt-4o","messages":[{"role":"user","content":"hello"}]}`
+
+	rec := serve(h, http.MethodPost, "/v1/chat/completions", body)
+	require.Equal(t, http.StatusOK, rec.Code)
+	resp := gjson.Parse(rec.Body.String())
+	require.Equal(t, "gpt-4o", resp.Get("model").String())
+	require.Equal(t, "assistant", resp.Get("choices.0.message.role").String())
+	require.Equal(t, "stop", resp.Get("choices.0.finish_reason").String())
+	content := resp.Get("choices.0.message.content").String()
+	require.True(t, strings.HasSuffix(content, "."))
+	completionTokens := resp.Get("usage.completion_tokens").Int()
+	require.Len(t, strings.Fields(content), int(completionTokens))
+	require.GreaterOrEqual(t, completionTokens, int64(minCompletionTokens))
+	require.LessOrEqual(t, completionTokens, int64(maxCompletionTokens))
+	require.Equal(t, int64(3), resp.Get("usage.prompt_tokens").Int())
+	require.Equal(t, []time.Duration{100 * time.Millisecond, time.Duration(completionTokens) * 100 * time.Millisecond}, *slept)
+
+	// The same request gets the same completion, while another seed gets another one.
+	require.Equal(t, content, gjson.Get(serve(h, http.MethodPost, "/v1/chat/completions", body).Body.String(),
+		"choices.0.message.content").String())
+	require.NotEqual(t, content, gjson.Get(serve(h, http.MethodPost, "/v1/chat/completions",
+		`{"model":"gpt-4o","seed":42,"messages":[{"role":"user","content":"hello"}]}`).Body.String(),
+		"choices.0.message.content").String())
+
+	// The completion is truncated to the max tokens.
+	rec = serve(h, http.MethodPost, "/v1/chat/completions",
+		`{"model":"gpt-4o","max_completion_tokens":2,"messages":[{"role":"user","content":"hello"}]}`)
+	require.Equal(t, "length", gjson.Get(rec.Body.String(), "choices.0.finish_reason").String())
+	require.Equal(t, strings.Join(strings.Fields(content)[:2], " "), gjson.Get(rec.Body.String(), "choices.0.message.content").String())
+
+	// The headers override the latency and the token rate.
+	*slept = nil
+	serve(h, http.MethodPost, "/v1/chat/completions", body, LatencyHeader, "1s", TokensPerSecondHeader, "0")
+	require.Equal(t, []time.Duration{time.Second, 0}, *slept)
+}
+
+func TestChatCompletions_Streaming(t *testing.T) {
+	h, slept := newTestHandler(Config{TokensPerSecond: 100})
+	const body = `{"model":"gpt-4o","messages":[{"role":"user","content":"hello"}]}`
+	expContent := gjson.Get(serve(h, http.MethodPost, "/v1/chat/completions", body).Body.String(), "choices.0.message.content").String()
+
+	*slept = nil
+	rec := serve(h, http.MethodPost, "/v1/chat/completions",
+		`{"model":"gpt-4o","stream":true,"stream_options":{"include_usage":true},"messages":[{"role":"user","content":"hello"}]}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "text/event-stream", rec.Header().Get("content-type"))
+	events := strings.Split(strings.TrimSuffix(rec.Body.String(), "\n\n"), "\n\n")
+	require.Equal(t, "data: [DONE]", events[len(events)-1])
+
+	var content strings.Builder
+	for _, e := range events[1 : len(events)-3] {
+		content.WriteString(gjson.Get(strings.TrimPrefix(e, "data: "), "choices.0.delta.content").String())
+	}
+	require.Equal(t, expContent, content.String())
+	require.Equal(t, "assistant", gjson.Get(strings.TrimPrefix(events[0], "data: "), "choices.0.delta.role").String())
+	require.Equal(t, "stop", gjson.Get(strings.TrimPrefix(events[len(events)-3], "data: "), "choices.0.finish_reason").String())
+	usage := gjson.Get(strings.TrimPrefix(events[len(events)-2], "data: "), "usage")
+	require.Equal(t, int64(len(events)-4), usage.Get("completion_tokens").Int())
+	// The latency precedes the first token, and the interval of the token rate separates the tokens.
+	require.Len(t, *slept, len(events)-4)
+	require.Equal(t, 10*time.Millisecond, (*slept)[1])
+}
+
+func TestCompletions(t *testing.T) {
+	h, _ := newTestHandler(Config{})
+	rec := serve(h, http.MethodPost, "/v1/completions", `{"model":"m","prompt":"once upon a time","max_tokens":3}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	resp := gjson.Parse(rec.Body.String())
+	require.Equal(t, "text_completion", resp.Get("object").String())
+	require.Len(t, strings.Fields(resp.Get("choices.0.text").String()), 3)
+	require.Equal(t, "length", resp.Get("choices.0.finish_reason").String())
+	require.Equal(t, int64(4), resp.Get("usage.prompt_tokens").Int())
+
+	rec = serve(h, http.MethodPost, "/v1/completions", `{"model":"m","prompt":"once upon a time","max_tokens":3,"stream":true}`)
+	events := strings.Split(strings.TrimSuffix(rec.Body.String(), "\n\n"), "\n\n")
+	require.Len(t, events, 5)
+	require.Equal(t, resp.Get("choices.0.text").String(), gjson.Get(strings.TrimPrefix(events[0], "data: "), "choices.0.text").String()+
+		gjson.Get(strings.TrimPrefix(events[1], "data: "), "choices.0.text").String()+
+		gjson.Get(strings.TrimPrefix(events[2], "data: "), "choices.0.text").String())
+}
+
+func TestEmbeddings(t *testing.T) {
+	h, _ := newTestHandler(Config{})
+	rec := serve(h, http.MethodPost, "/v1/embeddings", `{"model":"e","input":["a","b"],"dimensions":4}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	resp := gjson.Parse(rec.Body.String())
+	require.Len(t, resp.Get("data").Array(), 2)
+	a := resp.Get("data.0.embedding").Array()
+	require.Len(t, a, 4)
+	var norm float64
+	for _, f := range a {
+		norm += f.Float() * f.Float()
+	}
+	require.InDelta(t, 1, math.Sqrt(norm), 1e-9)
+	require.NotEqual(t, resp.Get("data.0.embedding").Raw, resp.Get("data.1.embedding").Raw)
+	require.Equal(t, int64(2), resp.Get("usage.prompt_tokens").Int())
+
+	rec = serve(h, http.MethodPost, "/v1/embeddings", `{"model":"e","input":"a"}`)
+	require.Len(t, gjson.Get(rec.Body.String(), "data.0.embedding").Array(), defaultEmbeddingDimensions)
+}
+
+func TestModelsAndErrors(t *testing.T) {
+	h, _ := newTestHandler(Config{})
+	rec := serve(h, http.MethodGet, "/v1/models", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, Model, gjson.Get(rec.Body.String(), "data.0.id").String())
+
+	rec = serve(h, http.MethodPost, "/v1/chat/completions", `{"model":"m"}`, StatusHeader, "429")
+	require.Equal(t, http.StatusTooManyRequests, rec.Code)
+	require.JSONEq(t, `{"type":"error","error":{"type":"invalid_request_error","code":"429","message":"mock provider: Too Many Requests"}}`,
+		rec.Body.String())
+	rec = serve(h, http.MethodPost, "/v1/embeddings", `{"model":"m"}`, StatusHeader, "503")
+	require.Equal(t, "server_error", gjson.Get(rec.Body.String(), "error.type").String())
+	// The invalid statuses are ignored.
+	rec = serve(h, http.MethodGet, "/v1/models", "", StatusHeader, "200")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	rec = serve(h, http.MethodPost, "/v1/chat/completions", `{"model":`)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestNewHandler(t *testing.T) {
+	server := httptest.NewServer(NewHandler(slog.Default(), Config{TokensPerSecond: 1000}))
+	defer server.Close()
+	resp, err := http.Post(server.URL+"/v1/chat/completions", "application/json", //nolint:noctx
+		strings.NewReader(`{"model":"m","stream":true,"messages":[{"role":"user","content":"hi"}]}`))
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.True(t, strings.HasSuffix(string(body), "data: [DONE]\n\n"))
+}
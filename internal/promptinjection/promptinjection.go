@@ -0,0 +1,146 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+// Package promptinjection scores the request bodies for prompt injection and jailbreak attempts with lightweight
+// pattern and heuristic rules.
+//
+// The rules are meant for the security monitoring of the traffic rather than as a complete defense: they catch
+// the well-known phrasings of the attacks at a negligible cost, and the score tells how many of them a request
+// combines.
+package promptinjection
+
+import (
+	"regexp"
+
+	"github.com/envoyproxy/ai-gateway/internal/json"
+)
+
+// MaxScore is the maximum risk score of a request.
+const MaxScore = 100
+
+// rule is a heuristic adding its weight to the score of the requests matching its pattern.
+type rule struct {
+	name   string
+	weight int
+	re     *regexp.Regexp
+}
+
+// rules are the heuristics in the order they are reported in [Result.Rules].
+var rules = []rule{
+	{
+		name:   "ignore_instructions",
+		weight: 50,
+		re: regexp.MustCompile(`(?is)\b(ignore|disregard|forget|override|bypass)\b.{0,40}` +
+			`\b(previous|prior|above|earlier|preceding|all|any|your|system)\b.{0,40}` +
+			`\b(instructions?|prompts?|rules|directions|guidelines|context)\b`),
+	},
+	{
+		name:   "system_prompt_extraction",
+		weight: 40,
+		re: regexp.MustCompile(`(?is)\b(reveal|print|show|repeat|output|display|leak|tell me)\b.{0,40}` +
+			`\b(system (prompt|message)|(initial|hidden|original) (instructions|prompt)|your instructions)\b`),
+	},
+	{
+		name:   "jailbreak_persona",
+		weight: 40,
+		// DAN is matched case-sensitively so that the name Dan doesn't match.
+		re: regexp.MustCompile(`\bDAN\b|(?i:\b(do anything now|developer mode|jailbreak(ed)?|jailbroken|god mode)\b)`),
+	},
+	{
+		name:   "restriction_removal",
+		weight: 30,
+		re: regexp.MustCompile(`(?is)\b(without|no|free of|remove|disable|bypass)\b.{0,20}` +
+			`\b(restrictions|filters|guardrails|safety (rules|guidelines)|censorship|content polic(y|ies)|ethical guidelines)\b`),
+	},
+	{
+		name:   "role_override",
+		weight: 15,
+		re:     regexp.MustCompile(`(?i)\b(you are now|from now on,? you|pretend (to be|you are)|act as (an? )?(unrestricted|unfiltered|evil))\b`),
+	},
+	{
+		name:   "chat_template_injection",
+		weight: 40,
+		re:     regexp.MustCompile(`<\|im_start\|>|<\|im_end\|>|<\|system\|>|<\|endoftext\|>|\[/?INST\]|<</?SYS>>`),
+	},
+	{
+		name:   "invisible_characters",
+		weight: 15,
+		re:     regexp.MustCompile(`[\x{200B}-\x{200F}\x{202A}-\x{202E}\x{2060}-\x{2064}\x{FEFF}]`),
+	},
+}
+
+// excludedKeys are the object keys whose values are not screened. The system prompts and the instructions are set
+// by the application rather than the user, and often tell the model not to reveal them, which would match the rules.
+var excludedKeys = map[string]struct{}{
+	"model":        {},
+	"role":         {},
+	"system":       {},
+	"instructions": {},
+}
+
+// excludedRoles are the roles of the messages that are not screened for the same reason as excludedKeys.
+var excludedRoles = map[string]struct{}{
+	"system":    {},
+	"developer": {},
+}
+
+// Result is the result of scoring a request.
+type Result struct {
+	// Score is the risk score from 0 to MaxScore, which is the sum of the weights of the matched rules.
+	Score int
+	// Rules are the names of the matched rules.
+	Rules []string
+}
+
+// Score returns the risk score of the JSON request body. The string values of the body are screened except the
+// system prompts, so this works with any of the supported API schemas. The bodies that are not JSON, such as the
+// multipart audio uploads, are scored zero.
+func Score(body []byte) Result {
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		return Result{}
+	}
+	matched := make([]bool, len(rules))
+	walk(v, func(s string) {
+		for i := range rules {
+			if !matched[i] && rules[i].re.MatchString(s) {
+				matched[i] = true
+			}
+		}
+	})
+
+	var ret Result
+	for i := range rules {
+		if matched[i] {
+			ret.Score += rules[i].weight
+			ret.Rules = append(ret.Rules, rules[i].name)
+		}
+	}
+	ret.Score = min(ret.Score, MaxScore)
+	return ret
+}
+
+// walk calls fn with each string value in v that is screened.
+func walk(v any, fn func(string)) {
+	switch v := v.(type) {
+	case string:
+		fn(v)
+	case []any:
+		for _, e := range v {
+			walk(e, fn)
+		}
+	case map[string]any:
+		if role, _ := v["role"].(string); role != "" {
+			if _, ok := excludedRoles[role]; ok {
+				return
+			}
+		}
+		for k, e := range v {
+			if _, ok := excludedKeys[k]; !ok {
+				walk(e, fn)
+			}
+		}
+	}
+}
@@ -31,6 +31,18 @@ const (
 	// This matches the descriptor key sent by the rate limit MetaData action that reads
 	// the model name from model_name_override in dynamic metadata set by the ext_proc filter.
 	ModelNameDescriptorKey = "model_name_override"
+
+	// QuotaCostMetadataKey is the dynamic metadata key where ext_proc stores the cost of the
+	// request computed with the QuotaPolicy's CostExpression.
+	QuotaCostMetadataKey = "quota_cost"
+
+	// QuotaScheduleDescriptorKey is the descriptor key of the schedule window active at the time of the
+	// request, which selects the limit of a default bucket with schedules.
+	QuotaScheduleDescriptorKey = "quota_schedule"
+
+	// DefaultQuotaScheduleWindow is the QuotaScheduleDescriptorKey value outside of every schedule window.
+	// The schedule names can't start with an underscore, so this never conflicts with them.
+	DefaultQuotaScheduleWindow = "__default"
 )
 
 // KeyedDescriptor pairs a leaf rate limit descriptor with a comparable key that
@@ -88,6 +100,50 @@ func DefaultBucketDescriptorKey(numRules int) string {
 	return fmt.Sprintf("rule-%d-match--1", numRules)
 }
 
+// QuotaUnitDescriptorKey returns the key of the descriptor that holds the limit of the given unit under
+// its bucket descriptor. The limits of the "Cost" unit are set on the bucket descriptor itself, so an
+// empty string is returned for it. Nesting the other units keeps the descriptors of the existing
+// cost limits unchanged while allowing limits of multiple units for the same bucket.
+func QuotaUnitDescriptorKey(unit aigv1a1.QuotaUnit) string {
+	if unit == "" || unit == aigv1a1.QuotaUnitCost {
+		return ""
+	}
+	return "quota-unit-" + strings.ToLower(string(unit))
+}
+
+// QuotaUnitMetadataKey returns the dynamic metadata key where ext_proc stores how much of the given unit
+// a request consumed, which the stream-done rate limits read as their hits addend. Requests are counted
+// when they are admitted, so an empty string is returned for the "Requests" unit.
+func QuotaUnitMetadataKey(unit aigv1a1.QuotaUnit) string {
+	switch unit {
+	case aigv1a1.QuotaUnitRequests:
+		return ""
+	case aigv1a1.QuotaUnitInputTokens:
+		return "quota_input_tokens"
+	case aigv1a1.QuotaUnitOutputTokens:
+		return "quota_output_tokens"
+	case aigv1a1.QuotaUnitTotalTokens:
+		return "quota_total_tokens"
+	default:
+		return QuotaCostMetadataKey
+	}
+}
+
+// QuotaScheduleMetadataKey returns the dynamic metadata key where ext_proc stores the name of the active
+// schedule window of the quota at the given index of the PerModelQuotas of a QuotaPolicy.
+func QuotaScheduleMetadataKey(policyNamespace, policyName string, quotaIndex int) string {
+	return fmt.Sprintf("quota_schedule/%s/%s/%d", policyNamespace, policyName, quotaIndex)
+}
+
+// quotaUnitKeySuffix returns the comparable key suffix for the unit descriptor at the given depth.
+func quotaUnitKeySuffix(unit aigv1a1.QuotaUnit, depth int) string {
+	key := QuotaUnitDescriptorKey(unit)
+	if key == "" {
+		return ""
+	}
+	return "/" + ComparableKeySegment(key, depth, "")
+}
+
 // BuildRateLimitConfigs translates a QuotaPolicy and its resolved target
 // AIServiceBackends into a single rate limit service configuration.
 // All backends share the same domain, distinguished by backend_name descriptors.
@@ -168,6 +224,13 @@ func buildBackendDescriptorKeyed(
 		return nil, nil, nil
 	}
 
+	// In the Shadow mode, every limit of the policy is reported but never enforced.
+	if policy.Spec.Mode == aigv1a1.QuotaPolicyModeShadow {
+		for _, kd := range allKeyed {
+			kd.Descriptor.ShadowMode = true
+		}
+	}
+
 	return &rlsconfv3.RateLimitDescriptor{
 		Key:         BackendNameDescriptorKey,
 		Value:       backendValue,
@@ -220,16 +283,11 @@ func buildPerModelDescriptorKeyed(descriptorModelName string, quota *aigv1a1.Quo
 	}
 
 	if len(quota.BucketRules) == 0 {
-		policy, err := quotaValueToPolicy(&quota.DefaultBucket)
+		keyed, err := setDefaultBucketLimits(desc, quota, modelPrefix, 2)
 		if err != nil {
 			return nil, nil, err
 		}
-		desc.RateLimit = policy
-		desc.QuotaMode = true
-		return desc, []KeyedDescriptor{{
-			ComparableKey: modelPrefix,
-			Descriptor:    desc,
-		}}, nil
+		return desc, keyed, nil
 	}
 
 	var nested []*rlsconfv3.RateLimitDescriptor
@@ -246,10 +304,12 @@ func buildPerModelDescriptorKeyed(descriptorModelName string, quota *aigv1a1.Quo
 		leafKey := modelPrefix
 		if len(headers) == 0 {
 			leafKey += "/" + ComparableKeySegment("__catch_all", 2, "")
+			leafKey += quotaUnitKeySuffix(rule.Quota.Unit, 3)
 		} else {
 			for depth, header := range headers {
 				leafKey += "/" + ComparableKeySegment(header.Name, depth+2, headerComparableValue(header))
 			}
+			leafKey += quotaUnitKeySuffix(rule.Quota.Unit, len(headers)+2)
 		}
 		for _, rd := range ruleDescs {
 			keyed = append(keyed, KeyedDescriptor{
@@ -260,22 +320,17 @@ func buildPerModelDescriptorKeyed(descriptorModelName string, quota *aigv1a1.Quo
 	}
 
 	if quota.DefaultBucket.Limit > 0 {
-		defaultPolicy, err := quotaValueToPolicy(&quota.DefaultBucket)
-		if err != nil {
-			return nil, nil, err
-		}
 		defaultKey := DefaultBucketDescriptorKey(len(quota.BucketRules))
 		defaultDesc := &rlsconfv3.RateLimitDescriptor{
-			Key:       defaultKey,
-			Value:     defaultKey,
-			RateLimit: defaultPolicy,
-			QuotaMode: true,
+			Key:   defaultKey,
+			Value: defaultKey,
+		}
+		defaultKeyed, err := setDefaultBucketLimits(defaultDesc, quota, modelPrefix+"/"+ComparableKeySegment("__default", 2, ""), 3)
+		if err != nil {
+			return nil, nil, err
 		}
 		nested = append(nested, defaultDesc)
-		keyed = append(keyed, KeyedDescriptor{
-			ComparableKey: modelPrefix + "/" + ComparableKeySegment("__default", 2, ""),
-			Descriptor:    defaultDesc,
-		})
+		keyed = append(keyed, defaultKeyed...)
 	}
 
 	desc.Descriptors = nested
@@ -294,15 +349,11 @@ func findLeafDescriptor(desc *rlsconfv3.RateLimitDescriptor) *rlsconfv3.RateLimi
 // all models (when no PerModelQuota matches). Uses only the key without a
 // specific value so that any model name will match.
 func buildServiceQuotaDescriptor(sq *aigv1a1.ServiceQuotaDefinition) (*rlsconfv3.RateLimitDescriptor, error) {
-	policy, err := quotaValueToPolicy(&sq.Quota)
-	if err != nil {
+	desc := &rlsconfv3.RateLimitDescriptor{Key: ModelNameDescriptorKey}
+	if _, err := setQuotaLimit(desc, &sq.Quota, false); err != nil {
 		return nil, err
 	}
-	return &rlsconfv3.RateLimitDescriptor{
-		Key:       ModelNameDescriptorKey,
-		RateLimit: policy,
-		QuotaMode: true,
-	}, nil
+	return desc, nil
 }
 
 // buildBucketRuleDescriptors creates a nested chain of descriptors for a single
@@ -317,10 +368,6 @@ func buildServiceQuotaDescriptor(sq *aigv1a1.ServiceQuotaDefinition) (*rlsconfv3
 //     HeaderValueMatch action sends the fixed DescriptorValue (not the actual header
 //     value), so the service config must match that same fixed string.
 func buildBucketRuleDescriptors(ruleIndex int, rule *aigv1a1.QuotaRule) ([]*rlsconfv3.RateLimitDescriptor, error) {
-	policy, err := quotaValueToPolicy(&rule.Quota)
-	if err != nil {
-		return nil, err
-	}
 	shadowMode := rule.ShadowMode != nil && *rule.ShadowMode
 
 	// Flatten and sort all header matches across all ClientSelectors.
@@ -329,13 +376,11 @@ func buildBucketRuleDescriptors(ruleIndex int, rule *aigv1a1.QuotaRule) ([]*rlsc
 	// No headers: single catch-all descriptor for this rule.
 	if len(allHeaders) == 0 {
 		key := BucketRuleDescriptorKey(ruleIndex, 0, "", "")
-		return []*rlsconfv3.RateLimitDescriptor{{
-			Key:        key,
-			Value:      key,
-			RateLimit:  policy,
-			ShadowMode: shadowMode,
-			QuotaMode:  true,
-		}}, nil
+		desc := &rlsconfv3.RateLimitDescriptor{Key: key, Value: key}
+		if _, err := setQuotaLimit(desc, &rule.Quota, shadowMode); err != nil {
+			return nil, err
+		}
+		return []*rlsconfv3.RateLimitDescriptor{desc}, nil
 	}
 
 	// Build a nested chain of descriptors. The rate limit, shadow mode, and
@@ -356,13 +401,82 @@ func buildBucketRuleDescriptors(ruleIndex int, rule *aigv1a1.QuotaRule) ([]*rlsc
 		}
 		leaf = desc
 	}
-	leaf.RateLimit = policy
-	leaf.ShadowMode = shadowMode
-	leaf.QuotaMode = true
+	if _, err := setQuotaLimit(leaf, &rule.Quota, shadowMode); err != nil {
+		return nil, err
+	}
 
 	return []*rlsconfv3.RateLimitDescriptor{root}, nil
 }
 
+// setDefaultBucketLimits sets the limits of the default bucket of the quota on the bucket descriptor at the
+// given depth, and returns the KeyedDescriptor entries of the descriptors holding them. Without schedules, the
+// limit is set with setQuotaLimit. With schedules, the bucket descriptor gets a child descriptor keyed by
+// QuotaScheduleDescriptorKey per schedule window holding the limit of the window, plus one for the time
+// outside of every window holding the limit of the default bucket:
+//
+//	key: model_name_override
+//	value: "gpt-4"
+//	descriptors:
+//	  - key: quota_schedule
+//	    value: business-hours
+//	    rate_limit: ...                  ← the limit of the "business-hours" window
+//	  - key: quota_schedule
+//	    value: __default
+//	    rate_limit: ...                  ← the limit of the default bucket
+func setDefaultBucketLimits(desc *rlsconfv3.RateLimitDescriptor, quota *aigv1a1.QuotaDefinition, keyPrefix string, depth int) ([]KeyedDescriptor, error) {
+	bucket := &quota.DefaultBucket
+	if len(quota.Schedules) == 0 {
+		leaf, err := setQuotaLimit(desc, bucket, false)
+		if err != nil {
+			return nil, err
+		}
+		return []KeyedDescriptor{{ComparableKey: keyPrefix + quotaUnitKeySuffix(bucket.Unit, depth), Descriptor: leaf}}, nil
+	}
+
+	windows := make([]string, 0, len(quota.Schedules)+1)
+	values := make([]aigv1a1.QuotaValue, 0, len(quota.Schedules)+1)
+	for i := range quota.Schedules {
+		schedule := &quota.Schedules[i]
+		windows = append(windows, schedule.Name)
+		values = append(values, aigv1a1.QuotaValue{Limit: schedule.Limit, Duration: schedule.Duration, Unit: bucket.Unit})
+	}
+	windows = append(windows, DefaultQuotaScheduleWindow)
+	values = append(values, *bucket)
+
+	keyed := make([]KeyedDescriptor, 0, len(windows))
+	for i, window := range windows {
+		windowDesc := &rlsconfv3.RateLimitDescriptor{Key: QuotaScheduleDescriptorKey, Value: window}
+		leaf, err := setQuotaLimit(windowDesc, &values[i], false)
+		if err != nil {
+			return nil, fmt.Errorf("schedule %q: %w", window, err)
+		}
+		desc.Descriptors = append(desc.Descriptors, windowDesc)
+		keyed = append(keyed, KeyedDescriptor{
+			ComparableKey: keyPrefix + "/" + ComparableKeySegment(QuotaScheduleDescriptorKey, depth, window) + quotaUnitKeySuffix(bucket.Unit, depth+1),
+			Descriptor:    leaf,
+		})
+	}
+	return keyed, nil
+}
+
+// setQuotaLimit sets the rate limit of the quota value on the bucket descriptor, or on a child descriptor
+// keyed by the unit for the units other than "Cost". It returns the descriptor holding the rate limit.
+func setQuotaLimit(desc *rlsconfv3.RateLimitDescriptor, qv *aigv1a1.QuotaValue, shadowMode bool) (*rlsconfv3.RateLimitDescriptor, error) {
+	policy, err := quotaValueToPolicy(qv)
+	if err != nil {
+		return nil, err
+	}
+	if key := QuotaUnitDescriptorKey(qv.Unit); key != "" {
+		child := &rlsconfv3.RateLimitDescriptor{Key: key, Value: key}
+		desc.Descriptors = append(desc.Descriptors, child)
+		desc = child
+	}
+	desc.RateLimit = policy
+	desc.ShadowMode = shadowMode
+	desc.QuotaMode = true
+	return desc, nil
+}
+
 // headerMatchValue returns the value to include in a BucketRuleDescriptorKey for a header.
 // Distinct headers return empty (the value is per-request, not known at config time).
 // Exact/Regex headers return the configured value.
@@ -416,6 +530,23 @@ func parseDuration(s string) (rlsconfv3.RateLimitUnit, error) {
 	}
 }
 
+// QuotaWindowSeconds returns the length in seconds of the window of the quota duration. This returns false if
+// the duration is not one of "1s", "1m", "1h", or "1d".
+func QuotaWindowSeconds(duration string) (int64, bool) {
+	switch duration {
+	case "1s":
+		return 1, true
+	case "1m":
+		return 60, true
+	case "1h":
+		return 3600, true
+	case "1d":
+		return 86400, true
+	default:
+		return 0, false
+	}
+}
+
 // BackendNameFromDomain extracts the namespace and backend name from a BackendDomainValue string.
 func BackendNameFromDomain(domain string) (namespace, name string, ok bool) {
 	parts := strings.SplitN(domain, "/", 2)
@@ -59,6 +59,26 @@ func TestDefaultBucketDescriptorKey(t *testing.T) {
 	require.Equal(t, "rule-0-match--1", DefaultBucketDescriptorKey(0))
 }
 
+func TestQuotaUnitDescriptorKey(t *testing.T) {
+	require.Empty(t, QuotaUnitDescriptorKey(""))
+	require.Empty(t, QuotaUnitDescriptorKey(aigv1a1.QuotaUnitCost))
+	require.Equal(t, "quota-unit-requests", QuotaUnitDescriptorKey(aigv1a1.QuotaUnitRequests))
+	require.Equal(t, "quota-unit-totaltokens", QuotaUnitDescriptorKey(aigv1a1.QuotaUnitTotalTokens))
+}
+
+func TestQuotaUnitMetadataKey(t *testing.T) {
+	require.Equal(t, QuotaCostMetadataKey, QuotaUnitMetadataKey(""))
+	require.Equal(t, QuotaCostMetadataKey, QuotaUnitMetadataKey(aigv1a1.QuotaUnitCost))
+	require.Empty(t, QuotaUnitMetadataKey(aigv1a1.QuotaUnitRequests))
+	require.Equal(t, "quota_input_tokens", QuotaUnitMetadataKey(aigv1a1.QuotaUnitInputTokens))
+	require.Equal(t, "quota_output_tokens", QuotaUnitMetadataKey(aigv1a1.QuotaUnitOutputTokens))
+	require.Equal(t, "quota_total_tokens", QuotaUnitMetadataKey(aigv1a1.QuotaUnitTotalTokens))
+}
+
+func TestQuotaScheduleMetadataKey(t *testing.T) {
+	require.Equal(t, "quota_schedule/default/policy/2", QuotaScheduleMetadataKey("default", "policy", 2))
+}
+
 func TestParseDuration(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -212,6 +232,99 @@ func TestBuildPerModelDescriptor(t *testing.T) {
 		require.Equal(t, DefaultBucketDescriptorKey(3), defaultDesc.Key)
 	})
 
+	t.Run("non-cost unit nests the limit under the model", func(t *testing.T) {
+		quota := &aigv1a1.QuotaDefinition{
+			DefaultBucket: aigv1a1.QuotaValue{Limit: 60, Duration: "1m", Unit: aigv1a1.QuotaUnitRequests},
+		}
+		desc, err := buildPerModelDescriptor("gpt-4", quota)
+		require.NoError(t, err)
+		require.Nil(t, desc.RateLimit)
+		require.Len(t, desc.Descriptors, 1)
+		unitDesc := desc.Descriptors[0]
+		require.Equal(t, "quota-unit-requests", unitDesc.Key)
+		require.Equal(t, "quota-unit-requests", unitDesc.Value)
+		require.Equal(t, uint32(60), unitDesc.RateLimit.RequestsPerUnit)
+		require.True(t, unitDesc.QuotaMode)
+	})
+
+	t.Run("requests and tokens limits of the same model are merged", func(t *testing.T) {
+		_, rpm, err := buildPerModelDescriptorKeyed("gpt-4", &aigv1a1.QuotaDefinition{
+			DefaultBucket: aigv1a1.QuotaValue{Limit: 60, Duration: "1m", Unit: aigv1a1.QuotaUnitRequests},
+		}, "backend")
+		require.NoError(t, err)
+		_, tpm, err := buildPerModelDescriptorKeyed("gpt-4", &aigv1a1.QuotaDefinition{
+			DefaultBucket: aigv1a1.QuotaValue{Limit: 100000, Duration: "1m", Unit: aigv1a1.QuotaUnitTotalTokens},
+		}, "backend")
+		require.NoError(t, err)
+		require.Len(t, rpm, 1)
+		require.Len(t, tpm, 1)
+		require.NotEqual(t, rpm[0].ComparableKey, tpm[0].ComparableKey)
+		require.Equal(t, "quota-unit-requests", rpm[0].Descriptor.Key)
+		require.Equal(t, "quota-unit-totaltokens", tpm[0].Descriptor.Key)
+	})
+
+	t.Run("schedules nest the windows under the model", func(t *testing.T) {
+		quota := &aigv1a1.QuotaDefinition{
+			DefaultBucket: aigv1a1.QuotaValue{Limit: 1000, Duration: "1h", Unit: aigv1a1.QuotaUnitRequests},
+			Schedules: []aigv1a1.QuotaSchedule{
+				{Name: "business-hours", Start: "09:00", End: "17:00", Limit: 100, Duration: "1m"},
+			},
+		}
+		desc, keyed, err := buildPerModelDescriptorKeyed("gpt-4", quota, "backend")
+		require.NoError(t, err)
+		require.Nil(t, desc.RateLimit)
+		require.Len(t, desc.Descriptors, 2)
+		require.Len(t, keyed, 2)
+
+		business := desc.Descriptors[0]
+		require.Equal(t, QuotaScheduleDescriptorKey, business.Key)
+		require.Equal(t, "business-hours", business.Value)
+		require.Equal(t, "quota-unit-requests", business.Descriptors[0].Key)
+		require.Equal(t, uint32(100), business.Descriptors[0].RateLimit.RequestsPerUnit)
+		require.Equal(t, rlsconfv3.RateLimitUnit_MINUTE, business.Descriptors[0].RateLimit.Unit)
+		require.Same(t, business.Descriptors[0], keyed[0].Descriptor)
+		require.Equal(t, "backend/model_name_override_1_gpt-4/quota_schedule_2_business-hours/quota-unit-requests_3_", keyed[0].ComparableKey)
+
+		outside := desc.Descriptors[1]
+		require.Equal(t, DefaultQuotaScheduleWindow, outside.Value)
+		require.Equal(t, uint32(1000), outside.Descriptors[0].RateLimit.RequestsPerUnit)
+		require.Equal(t, rlsconfv3.RateLimitUnit_HOUR, outside.Descriptors[0].RateLimit.Unit)
+		require.Equal(t, "backend/model_name_override_1_gpt-4/quota_schedule_2___default/quota-unit-requests_3_", keyed[1].ComparableKey)
+	})
+
+	t.Run("schedules nest the windows under the default bucket of the bucket rules", func(t *testing.T) {
+		quota := &aigv1a1.QuotaDefinition{
+			BucketRules: []aigv1a1.QuotaRule{
+				{Quota: aigv1a1.QuotaValue{Limit: 200, Duration: "1m"}},
+			},
+			DefaultBucket: aigv1a1.QuotaValue{Limit: 50, Duration: "1m"},
+			Schedules: []aigv1a1.QuotaSchedule{
+				{Name: "night", Start: "22:00", End: "06:00", Limit: 500, Duration: "1m"},
+			},
+		}
+		desc, keyed, err := buildPerModelDescriptorKeyed("gpt-4", quota, "")
+		require.NoError(t, err)
+		require.Len(t, desc.Descriptors, 2)
+		require.Len(t, keyed, 3)
+		defaultDesc := desc.Descriptors[1]
+		require.Equal(t, DefaultBucketDescriptorKey(1), defaultDesc.Key)
+		require.Nil(t, defaultDesc.RateLimit)
+		require.Len(t, defaultDesc.Descriptors, 2)
+		require.Equal(t, "night", defaultDesc.Descriptors[0].Value)
+		require.Equal(t, uint32(500), defaultDesc.Descriptors[0].RateLimit.RequestsPerUnit)
+		require.Equal(t, uint32(50), defaultDesc.Descriptors[1].RateLimit.RequestsPerUnit)
+		require.Equal(t, "model_name_override_1_gpt-4/__default_2_/quota_schedule_3_night", keyed[1].ComparableKey)
+	})
+
+	t.Run("invalid duration in schedule", func(t *testing.T) {
+		quota := &aigv1a1.QuotaDefinition{
+			DefaultBucket: aigv1a1.QuotaValue{Limit: 100, Duration: "1m"},
+			Schedules:     []aigv1a1.QuotaSchedule{{Name: "peak", Limit: 10, Duration: "1w"}},
+		}
+		_, err := buildPerModelDescriptor("gpt-4", quota)
+		require.ErrorContains(t, err, `schedule "peak"`)
+	})
+
 	t.Run("invalid duration in default bucket", func(t *testing.T) {
 		quota := &aigv1a1.QuotaDefinition{
 			DefaultBucket: aigv1a1.QuotaValue{Limit: 100, Duration: "invalid"},
@@ -481,6 +594,46 @@ func TestBuildBackendDescriptor(t *testing.T) {
 		require.Nil(t, desc)
 	})
 
+	t.Run("shadow mode applies to every limit", func(t *testing.T) {
+		policy := &aigv1a1.QuotaPolicy{
+			Spec: aigv1a1.QuotaPolicySpec{
+				Mode: aigv1a1.QuotaPolicyModeShadow,
+				PerModelQuotas: []aigv1a1.PerModelQuota{
+					{
+						ModelName: ptr.To("gpt-4"),
+						Quota: aigv1a1.QuotaDefinition{
+							BucketRules: []aigv1a1.QuotaRule{
+								{Quota: aigv1a1.QuotaValue{Limit: 200, Duration: "1m"}},
+							},
+							DefaultBucket: aigv1a1.QuotaValue{Limit: 100, Duration: "1m"},
+						},
+					},
+				},
+				ServiceQuota: aigv1a1.ServiceQuotaDefinition{
+					Quota: aigv1a1.QuotaValue{Limit: 1000, Duration: "1h"},
+				},
+			},
+		}
+		backend := &aigv1b1.AIServiceBackend{}
+		backend.Namespace = "default"
+		backend.Name = "my-backend"
+
+		_, keyed, err := buildBackendDescriptorKeyed(policy, backend)
+		require.NoError(t, err)
+		require.Len(t, keyed, 3) // 1 bucket rule + 1 default + 1 service quota
+		for _, kd := range keyed {
+			require.True(t, kd.Descriptor.ShadowMode, kd.ComparableKey)
+			require.NotNil(t, kd.Descriptor.RateLimit, kd.ComparableKey)
+		}
+
+		policy.Spec.Mode = aigv1a1.QuotaPolicyModeEnforce
+		_, keyed, err = buildBackendDescriptorKeyed(policy, backend)
+		require.NoError(t, err)
+		for _, kd := range keyed {
+			require.False(t, kd.Descriptor.ShadowMode, kd.ComparableKey)
+		}
+	})
+
 	t.Run("per-model quota with nil model name is skipped", func(t *testing.T) {
 		policy := &aigv1a1.QuotaPolicy{
 			Spec: aigv1a1.QuotaPolicySpec{
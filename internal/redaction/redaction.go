@@ -4,7 +4,8 @@
 // the root of the repo.
 
 // Package redaction provides utilities for redacting sensitive information
-// from requests and responses for safe debug logging.
+// from requests and responses for safe debug logging, as well as the reversible
+// tokenization of personally identifiable information (PII) in request bodies.
 package redaction
 
 import (
@@ -0,0 +1,330 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package redaction
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// streamState is the state of the Tokenizer.DetokenizeStream across the chunks of a response.
+type streamState struct {
+	// started is true once the first non-empty chunk was received and notSSE is set.
+	started bool
+	// notSSE is true if the response is not a stream of server-sent events, in which case the chunks are
+	// detokenized independently with only a trailing partial placeholder held back.
+	notSSE bool
+	// pending is the incomplete event, or the held back bytes of a non SSE stream, at the end of the last chunk.
+	pending []byte
+	// held is the last event whose values end with the beginning of a placeholder, if any.
+	held *streamEvent
+}
+
+// streamEvent is a server-sent event carrying a JSON data.
+type streamEvent struct {
+	// raw is the event including its trailing separator.
+	raw []byte
+	// dataStart and dataEnd are the offsets of the JSON data in raw.
+	dataStart, dataEnd int
+	// fragments are the trailing fragments of the values that might be the beginning of a placeholder,
+	// by the key of the field.
+	fragments map[string]streamFragment
+}
+
+// streamFragment is a trailing fragment of a string value held back in a streamEvent.
+type streamFragment struct {
+	// path is the sjson path of the value in the data.
+	path string
+	// value is the escaped content of the value without the fragment.
+	value []byte
+	// fragment is the trailing fragment of the value.
+	fragment []byte
+}
+
+// streamField is a string value in the JSON data of a server-sent event.
+type streamField struct {
+	// key identifies the field across the events. This is the path of the value in the data, where the objects
+	// having an "index" such as the choices, the content blocks or the tool calls are annotated with it so that
+	// the interleaved fields are not mixed.
+	key string
+	// path is the sjson path of the value in the data.
+	path string
+	// value is the escaped content of the value.
+	value []byte
+}
+
+// DetokenizeStream is the streaming variant of Detokenize for the server-sent events returned to the client.
+//
+// The models emit the placeholders token by token, so a placeholder is usually split across the deltas of
+// consecutive events. The string values of the JSON data of the events are therefore detokenized per field: when
+// a value ends with a fragment that might be the beginning of a placeholder, the event is held back until the next
+// one. If the next event continues the same field, the fragment is moved to the beginning of its value and the
+// placeholder is restored there. Otherwise, e.g. when the next event finishes the choice, the held back event is
+// sent as-is. Everything held back is flushed when endOfStream is true.
+//
+// The events are separated by a blank line, and an incomplete event at the end of a chunk is held back until the
+// next chunk. The responses that are not server-sent events are detokenized per chunk, and only the placeholders
+// split across two chunks are restored.
+//
+// This returns the bytes to send downstream and true if they differ from the given chunk.
+func (t *Tokenizer) DetokenizeStream(chunk []byte, endOfStream bool) ([]byte, bool) {
+	s := &t.stream
+	if len(t.originals) == 0 && len(s.pending) == 0 && s.held == nil {
+		return chunk, false
+	}
+	buf := chunk
+	if len(s.pending) > 0 {
+		buf = append(s.pending, chunk...)
+		s.pending = nil
+	}
+	if !s.started && len(buf) > 0 {
+		s.started = true
+		s.notSSE = !isServerSentEvents(buf)
+	}
+
+	var out []byte
+	if s.notSSE {
+		out, _ = t.Detokenize(buf)
+		if !endOfStream {
+			if n := t.partialPlaceholderSuffixLen(out); n > 0 {
+				s.pending = append([]byte(nil), out[len(out)-n:]...)
+				out = out[:len(out)-n]
+			}
+		}
+	} else {
+		out = make([]byte, 0, len(buf))
+		for len(buf) > 0 {
+			end := eventEnd(buf)
+			if end < 0 {
+				break
+			}
+			out = t.detokenizeEvent(out, buf[:end], endOfStream)
+			buf = buf[end:]
+		}
+		if len(buf) > 0 {
+			if endOfStream {
+				out = t.flushHeldEvent(out, nil)
+				restored, _ := t.Detokenize(buf)
+				out = append(out, restored...)
+			} else {
+				s.pending = append([]byte(nil), buf...)
+			}
+		} else if endOfStream {
+			out = t.flushHeldEvent(out, nil)
+		}
+	}
+	return out, !bytes.Equal(out, chunk)
+}
+
+// isServerSentEvents reports whether b starts with a server-sent event field or comment.
+func isServerSentEvents(b []byte) bool {
+	for _, field := range []string{"data:", "event:", "id:", "retry:", ":"} {
+		if bytes.HasPrefix(b, []byte(field)) || (len(b) < len(field) && strings.HasPrefix(field, string(b))) {
+			return true
+		}
+	}
+	return false
+}
+
+// eventEnd returns the offset right after the blank line ending the first event of b, or -1 if the event is not
+// complete.
+func eventEnd(b []byte) int {
+	lf := bytes.Index(b, []byte("\n\n"))
+	crlf := bytes.Index(b, []byte("\r\n\r\n"))
+	switch {
+	case lf < 0 && crlf < 0:
+		return -1
+	case crlf < 0 || (lf >= 0 && lf < crlf):
+		return lf + 2
+	default:
+		return crlf + 4
+	}
+}
+
+// detokenizeEvent appends the detokenized event to out, along with the previously held back event, unless the
+// event itself is held back.
+func (t *Tokenizer) detokenizeEvent(out, raw []byte, endOfStream bool) []byte {
+	ev := parseStreamEvent(raw)
+	if ev == nil {
+		out = t.flushHeldEvent(out, nil)
+		restored, _ := t.Detokenize(raw)
+		return append(out, restored...)
+	}
+
+	data := raw[ev.dataStart:ev.dataEnd]
+	fields := collectStreamFields(gjson.ParseBytes(data), "", "", nil)
+	carried := make(map[string][]byte)
+	for _, f := range fields {
+		if held := t.stream.held; held != nil {
+			if fragment, ok := held.fragments[f.key]; ok {
+				carried[f.key] = fragment.fragment
+			}
+		}
+	}
+	out = t.flushHeldEvent(out, carried)
+
+	newData := data
+	for _, f := range fields {
+		value := f.value
+		if fragment, ok := carried[f.key]; ok {
+			value = append(append([]byte(nil), fragment...), value...)
+		}
+		value, _ = t.Detokenize(value)
+		if !endOfStream {
+			if n := t.partialPlaceholderSuffixLen(value); n > 0 {
+				if ev.fragments == nil {
+					ev.fragments = make(map[string]streamFragment)
+				}
+				ev.fragments[f.key] = streamFragment{
+					path:     f.path,
+					value:    bytes.Clone(value[:len(value)-n]),
+					fragment: bytes.Clone(value[len(value)-n:]),
+				}
+			}
+		}
+		if bytes.Equal(value, f.value) {
+			continue
+		}
+		if updated, err := sjson.SetRawBytes(newData, f.path, quote(value)); err == nil {
+			newData = updated
+		}
+	}
+	if !bytes.Equal(newData, data) {
+		ev.raw = spliceData(raw, ev.dataStart, ev.dataEnd, newData)
+		ev.dataEnd = ev.dataStart + len(newData)
+	}
+	if len(ev.fragments) > 0 {
+		// The event outlives the chunk it was received in.
+		ev.raw = bytes.Clone(ev.raw)
+		t.stream.held = ev
+		return out
+	}
+	return append(out, ev.raw...)
+}
+
+// flushHeldEvent appends the held back event, if any, to out. The fragments of the fields in continued are
+// removed from the values of the event since they are carried to the next event.
+func (t *Tokenizer) flushHeldEvent(out []byte, continued map[string][]byte) []byte {
+	held := t.stream.held
+	if held == nil {
+		return out
+	}
+	t.stream.held = nil
+	data := held.raw[held.dataStart:held.dataEnd]
+	newData := data
+	for key, f := range held.fragments {
+		if _, ok := continued[key]; !ok {
+			continue
+		}
+		if updated, err := sjson.SetRawBytes(newData, f.path, quote(f.value)); err == nil {
+			newData = updated
+		}
+	}
+	if bytes.Equal(newData, data) {
+		return append(out, held.raw...)
+	}
+	return append(out, spliceData(held.raw, held.dataStart, held.dataEnd, newData)...)
+}
+
+// parseStreamEvent returns the event of raw if it has a single data line holding a JSON object or array, or nil
+// otherwise.
+func parseStreamEvent(raw []byte) *streamEvent {
+	var ev *streamEvent
+	for start := 0; start < len(raw); {
+		end := bytes.IndexByte(raw[start:], '\n')
+		if end < 0 {
+			end = len(raw)
+		} else {
+			end += start
+		}
+		line := bytes.TrimSuffix(raw[start:end], []byte("\r"))
+		if bytes.HasPrefix(line, []byte("data:")) {
+			if ev != nil {
+				return nil
+			}
+			dataStart := start + len("data:")
+			if dataStart < len(raw) && raw[dataStart] == ' ' {
+				dataStart++
+			}
+			dataEnd := start + len(line)
+			data := raw[dataStart:dataEnd]
+			if len(data) == 0 || (data[0] != '{' && data[0] != '[') || !gjson.ValidBytes(data) {
+				return nil
+			}
+			ev = &streamEvent{raw: raw, dataStart: dataStart, dataEnd: dataEnd}
+		}
+		start = end + 1
+	}
+	return ev
+}
+
+// collectStreamFields appends the string values of v to fields.
+func collectStreamFields(v gjson.Result, path, key string, fields []streamField) []streamField {
+	switch {
+	case v.IsObject():
+		if index := v.Get("index"); index.Type == gjson.Number {
+			key += "[" + index.Raw + "]"
+		}
+		v.ForEach(func(k, e gjson.Result) bool {
+			name := k.String()
+			fields = collectStreamFields(e, joinPath(path, escapePathComponent(name)), key+"."+name, fields)
+			return true
+		})
+	case v.IsArray():
+		i := 0
+		v.ForEach(func(_, e gjson.Result) bool {
+			fields = collectStreamFields(e, joinPath(path, strconv.Itoa(i)), key+"."+strconv.Itoa(i), fields)
+			i++
+			return true
+		})
+	case v.Type == gjson.String:
+		fields = append(fields, streamField{key: key, path: path, value: []byte(v.Raw[1 : len(v.Raw)-1])})
+	}
+	return fields
+}
+
+// joinPath joins the sjson path with the component.
+func joinPath(path, component string) string {
+	if path == "" {
+		return component
+	}
+	return path + "." + component
+}
+
+// escapePathComponent escapes the characters of the object key that have a special meaning in the sjson paths.
+func escapePathComponent(name string) string {
+	if !strings.ContainsAny(name, `.*?|#@\!:`) {
+		return name
+	}
+	var b strings.Builder
+	for _, r := range name {
+		if strings.ContainsRune(`.*?|#@\!:`, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// quote returns the escaped content of a JSON string as a string literal.
+func quote(value []byte) []byte {
+	out := make([]byte, 0, len(value)+2)
+	out = append(out, '"')
+	out = append(out, value...)
+	return append(out, '"')
+}
+
+// spliceData returns a copy of raw with the data at [start, end) replaced with data.
+func spliceData(raw []byte, start, end int, data []byte) []byte {
+	out := make([]byte, 0, len(raw)-(end-start)+len(data))
+	out = append(out, raw[:start]...)
+	out = append(out, data...)
+	return append(out, raw[end:]...)
+}
@@ -0,0 +1,146 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package redaction
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenizer_DetokenizeStream(t *testing.T) {
+	tk := NewTokenizer(builtin(t, DetectorEmail))
+	_, ok := tk.TokenizeJSON([]byte(`{"content":"alice@example.com"}`))
+	require.True(t, ok)
+
+	var out []byte
+	for _, c := range []struct {
+		chunk       string
+		endOfStream bool
+		mutated     bool
+	}{
+		{chunk: "data: {\"content\":\"Hello [PII_EM", mutated: true},
+		{chunk: "AIL_1]!\"}\n\n", mutated: true},
+		{chunk: "data: {\"content\":\"[x]\"}\n\n", mutated: false},
+		{chunk: "data: {\"content\":\"[PII_EMAIL_1", mutated: true},
+		{chunk: "", endOfStream: true, mutated: true},
+	} {
+		actual, mutated := tk.DetokenizeStream([]byte(c.chunk), c.endOfStream)
+		require.Equal(t, c.mutated, mutated, c.chunk)
+		out = append(out, actual...)
+	}
+	require.Equal(t, "data: {\"content\":\"Hello alice@example.com!\"}\n\ndata: {\"content\":\"[x]\"}\n\ndata: {\"content\":\"[PII_EMAIL_1", string(out))
+}
+
+// detokenizeStream feeds the chunks to the tokenizer, the last one with endOfStream, and returns the output.
+func detokenizeStream(tk *Tokenizer, chunks ...string) string {
+	var out []byte
+	for i, c := range chunks {
+		actual, _ := tk.DetokenizeStream([]byte(c), i == len(chunks)-1)
+		out = append(out, actual...)
+	}
+	return string(out)
+}
+
+func TestTokenizer_DetokenizeStream_events(t *testing.T) {
+	newTokenizer := func(t *testing.T) *Tokenizer {
+		tk := NewTokenizer(builtin(t, DetectorEmail))
+		_, ok := tk.TokenizeJSON([]byte(`{"content":"alice@example.com bob@example.com"}`))
+		require.True(t, ok)
+		return tk
+	}
+
+	for _, tc := range []struct {
+		name   string
+		chunks []string
+		exp    string
+	}{
+		{
+			name: "placeholder split across two data events",
+			chunks: []string{
+				"data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hello [PII_EM\"}}]}\n\n",
+				"data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"AIL_1], bye\"}}]}\n\n",
+				"data: [DONE]\n\n",
+			},
+			exp: "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hello \"}}]}\n\n" +
+				"data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"alice@example.com, bye\"}}]}\n\n" +
+				"data: [DONE]\n\n",
+		},
+		{
+			name: "placeholder split across many events and chunks",
+			chunks: []string{
+				"data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"[\"}}]}\n\ndata: {\"choices\":[{\"index\":0,\"delta\":",
+				"{\"content\":\"PII\"}}]}\n\ndata: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"_EMAIL_2\"}}]}\n\n",
+				"data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"]\"}}]}\n\n",
+				"",
+			},
+			exp: "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"\"}}]}\n\n" +
+				"data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"\"}}]}\n\n" +
+				"data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"\"}}]}\n\n" +
+				"data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"bob@example.com\"}}]}\n\n",
+		},
+		{
+			name: "interleaved choices",
+			chunks: []string{
+				"data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"[PII_EMAIL\"}}]}\n\n",
+				"data: {\"choices\":[{\"index\":1,\"delta\":{\"content\":\"_1]\"}}]}\n\n",
+				"",
+			},
+			exp: "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"[PII_EMAIL\"}}]}\n\n" +
+				"data: {\"choices\":[{\"index\":1,\"delta\":{\"content\":\"_1]\"}}]}\n\n",
+		},
+		{
+			name: "held back fragment not continued",
+			chunks: []string{
+				"data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"see [\"}}]}\n\n",
+				"data: {\"choices\":[{\"index\":0,\"delta\":{},\"finish_reason\":\"stop\"}]}\n\n",
+				"",
+			},
+			exp: "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"see [\"}}]}\n\n" +
+				"data: {\"choices\":[{\"index\":0,\"delta\":{},\"finish_reason\":\"stop\"}]}\n\n",
+		},
+		{
+			name: "held back at the end of the stream",
+			chunks: []string{
+				"data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"[PII_\"}}]}\n\n",
+				"",
+			},
+			exp: "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"[PII_\"}}]}\n\n",
+		},
+		{
+			name: "anthropic content block deltas",
+			chunks: []string{
+				"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"to [PII_EMAIL_\"}}\n\n",
+				"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"2]\"}}\n\n",
+				"event: content_block_stop\ndata: {\"type\":\"content_block_stop\",\"index\":0}\n\n",
+			},
+			exp: "event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"to \"}}\n\n" +
+				"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"bob@example.com\"}}\n\n" +
+				"event: content_block_stop\ndata: {\"type\":\"content_block_stop\",\"index\":0}\n\n",
+		},
+		{
+			name: "not server-sent events",
+			chunks: []string{
+				"{\"content\":\"[PII_EMA",
+				"IL_1]\"}",
+				"",
+			},
+			exp: "{\"content\":\"alice@example.com\"}",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.exp, detokenizeStream(newTokenizer(t), tc.chunks...))
+		})
+	}
+}
+
+func TestTokenizer_DetokenizeStream_nothingTokenized(t *testing.T) {
+	tk := NewTokenizer(builtin(t, DetectorEmail))
+	chunk := []byte("data: {\"content\":\"[PII_EM\"}\n\n")
+	actual, mutated := tk.DetokenizeStream(chunk, false)
+	require.False(t, mutated)
+	require.Equal(t, chunk, actual)
+}
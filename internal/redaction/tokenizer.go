@@ -0,0 +1,285 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package redaction
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// Detector finds personally identifiable information (PII) in a byte slice.
+//
+// Implementations must be safe for concurrent use since a single Detector is shared
+// by all the requests served by the same backend.
+type Detector interface {
+	// Category returns the upper-case category name embedded into placeholders, e.g. "EMAIL".
+	Category() string
+	// FindAllIndex returns the [start, end) byte offsets of every match in b.
+	FindAllIndex(b []byte) [][]int
+}
+
+// Built-in detector names. These correspond to PIIDetectorType in api/v1beta1.
+const (
+	DetectorEmail                  = "Email"
+	DetectorPhoneNumber            = "PhoneNumber"
+	DetectorCreditCard             = "CreditCard"
+	DetectorUSSocialSecurityNumber = "USSocialSecurityNumber"
+	DetectorIPv4Address            = "IPv4Address"
+)
+
+var builtinDetectors = map[string]Detector{
+	DetectorEmail: &regexDetector{
+		category: "EMAIL",
+		re:       regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`),
+	},
+	DetectorPhoneNumber: &regexDetector{
+		category: "PHONE",
+		re:       regexp.MustCompile(`(?:\+\d{1,3}[ .\-]?)?(?:\(\d{3}\)|\d{3})[ .\-]\d{3}[ .\-]\d{4}\b`),
+	},
+	DetectorCreditCard: &regexDetector{
+		category: "CREDIT_CARD",
+		re:       regexp.MustCompile(`\b(?:\d[ \-]?){12,18}\d\b`),
+		validate: luhnValid,
+	},
+	DetectorUSSocialSecurityNumber: &regexDetector{
+		category: "SSN",
+		re:       regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`),
+	},
+	DetectorIPv4Address: &regexDetector{
+		category: "IPV4",
+		re:       regexp.MustCompile(`\b(?:(?:25[0-5]|2[0-4]\d|1?\d?\d)\.){3}(?:25[0-5]|2[0-4]\d|1?\d?\d)\b`),
+	},
+}
+
+// BuiltinDetector returns the built-in detector for the given name, or false if there's no such detector.
+func BuiltinDetector(name string) (Detector, bool) {
+	d, ok := builtinDetectors[name]
+	return d, ok
+}
+
+// NewRegexDetector creates a Detector that matches the given RE2 regular expression.
+// The name is upper-cased and used as the placeholder category.
+func NewRegexDetector(name, expr string) (Detector, error) {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regular expression for %q: %w", name, err)
+	}
+	return &regexDetector{category: strings.ToUpper(name), re: re}, nil
+}
+
+// regexDetector implements [Detector] backed by a regular expression.
+type regexDetector struct {
+	category string
+	re       *regexp.Regexp
+	// validate is an optional post-match check, e.g. the Luhn checksum for credit card numbers.
+	validate func([]byte) bool
+}
+
+// Category implements [Detector.Category].
+func (r *regexDetector) Category() string { return r.category }
+
+// FindAllIndex implements [Detector.FindAllIndex].
+func (r *regexDetector) FindAllIndex(b []byte) [][]int {
+	matches := r.re.FindAllIndex(b, -1)
+	if r.validate == nil {
+		return matches
+	}
+	valid := matches[:0]
+	for _, m := range matches {
+		if r.validate(b[m[0]:m[1]]) {
+			valid = append(valid, m)
+		}
+	}
+	return valid
+}
+
+// luhnValid reports whether the digits in b pass the Luhn checksum. Non-digit characters are ignored.
+func luhnValid(b []byte) bool {
+	var sum, n int
+	for i := len(b) - 1; i >= 0; i-- {
+		c := b[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if n%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+	}
+	return n > 0 && sum%10 == 0
+}
+
+// placeholderPrefix is the prefix of every placeholder generated by the Tokenizer. Square brackets and
+// upper-case letters are chosen since they are never escaped by JSON encoders and are preserved well by LLMs.
+const placeholderPrefix = "[PII_"
+
+// Tokenizer replaces PII in request bodies with stable placeholders and restores the original values
+// in the response bodies. The same value is always replaced with the same placeholder for the lifetime
+// of the Tokenizer, so that the model can still reason about repeated references.
+//
+// The mapping is kept only in memory and a Tokenizer must be created per request. This is not thread-safe.
+type Tokenizer struct {
+	detectors    []Detector
+	placeholders map[string]string // original value -> placeholder.
+	originals    map[string]string // placeholder -> original value.
+	counters     map[string]int
+	// stream is the state of DetokenizeStream.
+	stream streamState
+}
+
+// NewTokenizer creates a new Tokenizer with the given detectors.
+func NewTokenizer(detectors []Detector) *Tokenizer {
+	return &Tokenizer{
+		detectors:    detectors,
+		placeholders: make(map[string]string),
+		originals:    make(map[string]string),
+		counters:     make(map[string]int),
+	}
+}
+
+// TokenizeJSON replaces PII found in the string values of the given JSON document with placeholders.
+// Object keys and non-string values are left untouched so that the document stays valid.
+//
+// The detectors run on the unescaped values, so that the escape sequences such as "\n" right before
+// the PII don't prevent the matches, and the values with placeholders are escaped again in the document.
+//
+// This returns the new body and true if at least one value was replaced. Otherwise, it returns the
+// original body and false, including when the body is not a valid JSON document.
+func (t *Tokenizer) TokenizeJSON(body []byte) ([]byte, bool) {
+	if !gjson.ValidBytes(body) {
+		return body, false
+	}
+	out, changed := body, false
+	for _, f := range collectStreamFields(gjson.ParseBytes(body), "", "", nil) {
+		if f.path == "" {
+			continue // The document itself is a string, which is not a request body.
+		}
+		value := f.value
+		if bytes.IndexByte(value, '\\') >= 0 {
+			value = []byte(gjson.ParseBytes(quote(value)).String())
+		}
+		replaced, ok := t.tokenize(value)
+		if !ok {
+			continue
+		}
+		if updated, err := sjson.SetBytes(out, f.path, string(replaced)); err == nil {
+			out, changed = updated, true
+		}
+	}
+	return out, changed
+}
+
+// tokenize replaces every detected PII in the unescaped value s with its placeholder.
+func (t *Tokenizer) tokenize(s []byte) ([]byte, bool) {
+	type span struct {
+		start, end int
+		category   string
+	}
+	var spans []span
+	for _, d := range t.detectors {
+		for _, m := range d.FindAllIndex(s) {
+			spans = append(spans, span{start: m[0], end: m[1], category: d.Category()})
+		}
+	}
+	if len(spans) == 0 {
+		return s, false
+	}
+	// Earlier matches win, and on the same start the longer match wins. Overlapping matches are dropped.
+	sort.SliceStable(spans, func(i, j int) bool {
+		if spans[i].start != spans[j].start {
+			return spans[i].start < spans[j].start
+		}
+		return spans[i].end > spans[j].end
+	})
+	var out []byte
+	last := 0
+	for _, sp := range spans {
+		if sp.start < last {
+			continue
+		}
+		out = append(out, s[last:sp.start]...)
+		out = append(out, t.placeholderFor(string(s[sp.start:sp.end]), sp.category)...)
+		last = sp.end
+	}
+	return append(out, s[last:]...), true
+}
+
+// placeholderFor returns the stable placeholder for the given original value.
+func (t *Tokenizer) placeholderFor(original, category string) string {
+	if p, ok := t.placeholders[original]; ok {
+		return p
+	}
+	t.counters[category]++
+	p := fmt.Sprintf("%s%s_%d]", placeholderPrefix, category, t.counters[category])
+	t.placeholders[original] = p
+	// The placeholders are restored within the JSON strings of the responses, hence the escaped original value.
+	escaped := gjson.AppendJSONString(nil, original)
+	t.originals[p] = string(escaped[1 : len(escaped)-1])
+	return p
+}
+
+// Detokenize restores the original values of all the placeholders in body.
+//
+// This returns the new body and true if at least one placeholder was restored. Otherwise, it returns the
+// original body and false.
+func (t *Tokenizer) Detokenize(body []byte) ([]byte, bool) {
+	if len(t.originals) == 0 || !bytes.Contains(body, []byte(placeholderPrefix)) {
+		return body, false
+	}
+	var out []byte
+	last := 0
+	for i := 0; i < len(body); {
+		idx := bytes.Index(body[i:], []byte(placeholderPrefix))
+		if idx < 0 {
+			break
+		}
+		start := i + idx
+		end := bytes.IndexByte(body[start:], ']')
+		if end < 0 {
+			break
+		}
+		end += start + 1
+		if original, ok := t.originals[string(body[start:end])]; ok {
+			out = append(out, body[last:start]...)
+			out = append(out, original...)
+			last = end
+			i = end
+		} else {
+			i = start + 1
+		}
+	}
+	if out == nil {
+		return body, false
+	}
+	return append(out, body[last:]...), true
+}
+
+// partialPlaceholderSuffixLen returns the length of the longest suffix of b that is a proper prefix of
+// one of the known placeholders.
+func (t *Tokenizer) partialPlaceholderSuffixLen(b []byte) int {
+	idx := bytes.LastIndexByte(b, '[')
+	if idx < 0 {
+		return 0
+	}
+	suffix := string(b[idx:])
+	for p := range t.originals {
+		if len(suffix) < len(p) && strings.HasPrefix(p, suffix) {
+			return len(suffix)
+		}
+	}
+	return 0
+}
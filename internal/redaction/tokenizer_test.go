@@ -0,0 +1,137 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package redaction
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func builtin(t *testing.T, names ...string) []Detector {
+	var ret []Detector
+	for _, n := range names {
+		d, ok := BuiltinDetector(n)
+		require.True(t, ok, n)
+		ret = append(ret, d)
+	}
+	return ret
+}
+
+func TestBuiltinDetectors(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{name: DetectorEmail, input: "mail alice@example.com or bob.smith+ai@corp.example.co.jp", expected: []string{"alice@example.com", "bob.smith+ai@corp.example.co.jp"}},
+		{name: DetectorPhoneNumber, input: "call 555-123-4567 or (555) 123-4567 or +1 555.123.4567", expected: []string{"555-123-4567", "(555) 123-4567", "+1 555.123.4567"}},
+		{name: DetectorCreditCard, input: "card 4111 1111 1111 1111 and 4111111111111112", expected: []string{"4111 1111 1111 1111"}},
+		{name: DetectorUSSocialSecurityNumber, input: "ssn 123-45-6789, not 1234-56-7890", expected: []string{"123-45-6789"}},
+		{name: DetectorIPv4Address, input: "hosts 10.0.0.1 and 256.1.1.1", expected: []string{"10.0.0.1"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			d := builtin(t, tc.name)[0]
+			var actual []string
+			for _, m := range d.FindAllIndex([]byte(tc.input)) {
+				actual = append(actual, tc.input[m[0]:m[1]])
+			}
+			require.Equal(t, tc.expected, actual)
+		})
+	}
+
+	_, ok := BuiltinDetector("Unknown")
+	require.False(t, ok)
+}
+
+func TestNewRegexDetector(t *testing.T) {
+	d, err := NewRegexDetector("employeeID", `E-\d{6}`)
+	require.NoError(t, err)
+	require.Equal(t, "EMPLOYEEID", d.Category())
+	require.Equal(t, [][]int{{3, 11}}, d.FindAllIndex([]byte("id E-123456")))
+
+	_, err = NewRegexDetector("bad", "(")
+	require.ErrorContains(t, err, `invalid regular expression for "bad"`)
+}
+
+func TestTokenizer_TokenizeJSON(t *testing.T) {
+	t.Run("string values only", func(t *testing.T) {
+		tk := NewTokenizer(builtin(t, DetectorEmail, DetectorPhoneNumber))
+		body := []byte(`{"alice@example.com": 1, "messages":[{"role":"user","content":"I am alice@example.com, call 555-123-4567. Again: alice@example.com"}],"max_tokens":5551234567}`)
+		actual, ok := tk.TokenizeJSON(body)
+		require.True(t, ok)
+		require.JSONEq(t, `{"alice@example.com": 1, "messages":[{"role":"user","content":"I am [PII_EMAIL_1], call [PII_PHONE_1]. Again: [PII_EMAIL_1]"}],"max_tokens":5551234567}`, string(actual))
+	})
+
+	t.Run("escaped quotes", func(t *testing.T) {
+		tk := NewTokenizer(builtin(t, DetectorEmail))
+		actual, ok := tk.TokenizeJSON([]byte(`{"content":"say \"bob@example.com\"", "other": "carol@example.com"}`))
+		require.True(t, ok)
+		require.JSONEq(t, `{"content":"say \"[PII_EMAIL_1]\"", "other": "[PII_EMAIL_2]"}`, string(actual))
+	})
+
+	t.Run("escape sequences before PII", func(t *testing.T) {
+		tk := NewTokenizer(builtin(t, DetectorEmail, DetectorPhoneNumber, DetectorUSSocialSecurityNumber))
+		actual, ok := tk.TokenizeJSON([]byte(`{"content":"Contact:\nalice@example.com\t555-123-4567\n123-45-6789\u0021"}`))
+		require.True(t, ok)
+		require.JSONEq(t, `{"content":"Contact:\n[PII_EMAIL_1]\t[PII_PHONE_1]\n[PII_SSN_1]!"}`, string(actual))
+	})
+
+	t.Run("escaped original restored escaped", func(t *testing.T) {
+		custom, err := NewRegexDetector("quoted", `"[a-z]+"`)
+		require.NoError(t, err)
+		tk := NewTokenizer([]Detector{custom})
+		actual, ok := tk.TokenizeJSON([]byte(`{"content":"name \"alice\" here"}`))
+		require.True(t, ok)
+		require.JSONEq(t, `{"content":"name [PII_QUOTED_1] here"}`, string(actual))
+		restored, ok := tk.Detokenize([]byte(`{"content":"Hi [PII_QUOTED_1]"}`))
+		require.True(t, ok)
+		require.JSONEq(t, `{"content":"Hi \"alice\""}`, string(restored))
+	})
+
+	t.Run("overlapping matches", func(t *testing.T) {
+		custom, err := NewRegexDetector("domain", `example\.com`)
+		require.NoError(t, err)
+		tk := NewTokenizer(append(builtin(t, DetectorEmail), custom))
+		actual, ok := tk.TokenizeJSON([]byte(`{"content":"alice@example.com example.com"}`))
+		require.True(t, ok)
+		require.JSONEq(t, `{"content":"[PII_EMAIL_1] [PII_DOMAIN_1]"}`, string(actual))
+	})
+
+	t.Run("no match", func(t *testing.T) {
+		tk := NewTokenizer(builtin(t, DetectorEmail))
+		body := []byte(`{"content":"nothing to see"}`)
+		actual, ok := tk.TokenizeJSON(body)
+		require.False(t, ok)
+		require.Equal(t, body, actual)
+	})
+
+	t.Run("unterminated string", func(t *testing.T) {
+		tk := NewTokenizer(builtin(t, DetectorEmail))
+		body := []byte(`{"content":"alice@example.com`)
+		actual, ok := tk.TokenizeJSON(body)
+		require.False(t, ok)
+		require.Equal(t, body, actual)
+	})
+}
+
+func TestTokenizer_Detokenize(t *testing.T) {
+	tk := NewTokenizer(builtin(t, DetectorEmail))
+	_, ok := tk.Detokenize([]byte(`[PII_EMAIL_1]`))
+	require.False(t, ok, "nothing has been tokenized yet")
+
+	_, ok = tk.TokenizeJSON([]byte(`{"content":"alice@example.com bob@example.com"}`))
+	require.True(t, ok)
+
+	actual, ok := tk.Detokenize([]byte(`{"content":"Hi [PII_EMAIL_2], cc [PII_EMAIL_1] and [PII_EMAIL_3] [PII_"}`))
+	require.True(t, ok)
+	require.Equal(t, `{"content":"Hi bob@example.com, cc alice@example.com and [PII_EMAIL_3] [PII_"}`, string(actual))
+
+	body := []byte(`{"content":"no placeholders"}`)
+	actual, ok = tk.Detokenize(body)
+	require.False(t, ok)
+	require.Equal(t, body, actual)
+}
@@ -6,6 +6,9 @@
 package testotel
 
 import (
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
 	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
 )
 
@@ -16,6 +19,15 @@ type MockSpan struct {
 	ErrorStatus   int
 	ErrBody       string
 	EndSpanCalled bool
+	Attributes    []attribute.KeyValue
+	Events        []MockSpanEvent
+	SpanCtx       trace.SpanContext
+}
+
+// MockSpanEvent is an event recorded to a MockSpan.
+type MockSpanEvent struct {
+	Name       string
+	Attributes []attribute.KeyValue
 }
 
 // RecordResponseChunk implements tracingapi.ChatCompletionSpan.
@@ -38,3 +50,18 @@ func (s *MockSpan) EndSpanOnError(statusCode int, body []byte) {
 func (s *MockSpan) EndSpan() {
 	s.EndSpanCalled = true
 }
+
+// SetAttributes implements tracingapi.SpanAttributeSetter.
+func (s *MockSpan) SetAttributes(attrs ...attribute.KeyValue) {
+	s.Attributes = append(s.Attributes, attrs...)
+}
+
+// AddEvent implements tracingapi.SpanEventRecorder.
+func (s *MockSpan) AddEvent(name string, attrs ...attribute.KeyValue) {
+	s.Events = append(s.Events, MockSpanEvent{Name: name, Attributes: attrs})
+}
+
+// SpanContext implements tracingapi.SpanContextProvider.
+func (s *MockSpan) SpanContext() trace.SpanContext {
+	return s.SpanCtx
+}
@@ -0,0 +1,92 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package anthropic
+
+import (
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/anthropic"
+	"github.com/envoyproxy/ai-gateway/internal/json"
+	"github.com/envoyproxy/ai-gateway/internal/tracing/openinference"
+	"github.com/envoyproxy/ai-gateway/internal/tracing/tracingapi"
+)
+
+// CountTokensRecorder implements recorders for OpenInference count tokens spans.
+type CountTokensRecorder struct {
+	traceConfig                            *openinference.TraceConfig
+	tracingapi.NoopChunkRecorder[struct{}] // Count tokens operations don't have streaming chunks
+}
+
+// NewCountTokensRecorderFromEnv creates an tracingapi.CountTokensRecorder
+// from environment variables using the OpenInference configuration specification.
+//
+// See: https://github.com/Arize-ai/openinference/blob/main/spec/configuration.md
+func NewCountTokensRecorderFromEnv() tracingapi.CountTokensRecorder {
+	return NewCountTokensRecorder(nil)
+}
+
+// NewCountTokensRecorder creates a tracingapi.CountTokensRecorder with the
+// given config using the OpenInference configuration specification.
+//
+// Parameters:
+//   - config: configuration for redaction. Defaults to NewTraceConfigFromEnv().
+//
+// See: https://github.com/Arize-ai/openinference/blob/main/spec/configuration.md
+func NewCountTokensRecorder(config *openinference.TraceConfig) tracingapi.CountTokensRecorder {
+	if config == nil {
+		config = openinference.NewTraceConfigFromEnv()
+	}
+	return &CountTokensRecorder{traceConfig: config}
+}
+
+// StartParams implements the same method as defined in tracingapi.CountTokensRecorder.
+func (r *CountTokensRecorder) StartParams(*anthropic.MessagesRequest, []byte) (spanName string, opts []trace.SpanStartOption) {
+	return "CountTokens", startOpts
+}
+
+// RecordRequest implements the same method as defined in tracingapi.CountTokensRecorder.
+func (r *CountTokensRecorder) RecordRequest(span trace.Span, req *anthropic.MessagesRequest, body []byte) {
+	attrs := []attribute.KeyValue{
+		attribute.String(openinference.SpanKind, openinference.SpanKindLLM),
+		attribute.String(openinference.LLMSystem, openinference.LLMSystemAnthropic),
+		attribute.String(openinference.LLMModelName, req.Model),
+		attribute.Int("count_tokens.message_count", len(req.Messages)),
+	}
+	if r.traceConfig.HideInputs {
+		attrs = append(attrs, attribute.String(openinference.InputValue, openinference.RedactedValue))
+	} else {
+		attrs = append(attrs,
+			attribute.String(openinference.InputValue, string(body)),
+			attribute.String(openinference.InputMimeType, openinference.MimeTypeJSON),
+		)
+	}
+	span.SetAttributes(attrs...)
+}
+
+// RecordResponseOnError implements the same method as defined in tracingapi.CountTokensRecorder.
+func (r *CountTokensRecorder) RecordResponseOnError(span trace.Span, statusCode int, body []byte) {
+	openinference.RecordResponseError(span, statusCode, string(body))
+}
+
+// RecordResponse implements the same method as defined in tracingapi.CountTokensRecorder.
+func (r *CountTokensRecorder) RecordResponse(span trace.Span, resp *anthropic.CountTokensResponse) {
+	attrs := []attribute.KeyValue{
+		attribute.Int64(openinference.LLMTokenCountPrompt, resp.InputTokens),
+		attribute.String(openinference.OutputMimeType, openinference.MimeTypeJSON),
+	}
+	bodyString := openinference.RedactedValue
+	if !r.traceConfig.HideOutputs {
+		marshaled, err := json.Marshal(resp)
+		if err == nil {
+			bodyString = string(marshaled)
+		}
+	}
+	attrs = append(attrs, attribute.String(openinference.OutputValue, bodyString))
+	span.SetAttributes(attrs...)
+	span.SetStatus(codes.Ok, "")
+}
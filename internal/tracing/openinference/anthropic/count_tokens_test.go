@@ -0,0 +1,93 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package anthropic
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	oteltrace "go.opentelemetry.io/otel/trace"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/anthropic"
+	"github.com/envoyproxy/ai-gateway/internal/testing/testotel"
+	"github.com/envoyproxy/ai-gateway/internal/tracing/openinference"
+)
+
+func TestCountTokensRecorder_StartParams(t *testing.T) {
+	spanName, opts := NewCountTokensRecorderFromEnv().StartParams(&anthropic.MessagesRequest{}, nil)
+	require.Equal(t, "CountTokens", spanName)
+	actualSpan := testotel.RecordNewSpan(t, spanName, opts...)
+	require.Equal(t, oteltrace.SpanKindInternal, actualSpan.SpanKind)
+}
+
+func TestCountTokensRecorder_RecordRequest(t *testing.T) {
+	req := &anthropic.MessagesRequest{
+		Model:    "claude-sonnet-4",
+		Messages: []anthropic.MessageParam{{Role: anthropic.MessageRoleUser, Content: anthropic.MessageContent{Text: "Hello"}}},
+	}
+	body := []byte(`{"model":"claude-sonnet-4","messages":[{"role":"user","content":"Hello"}]}`)
+
+	actualSpan := testotel.RecordWithSpan(t, func(span oteltrace.Span) bool {
+		NewCountTokensRecorderFromEnv().RecordRequest(span, req, body)
+		return false
+	})
+	openinference.RequireAttributesEqual(t, []attribute.KeyValue{
+		attribute.String(openinference.SpanKind, openinference.SpanKindLLM),
+		attribute.String(openinference.LLMSystem, openinference.LLMSystemAnthropic),
+		attribute.String(openinference.LLMModelName, "claude-sonnet-4"),
+		attribute.Int("count_tokens.message_count", 1),
+		attribute.String(openinference.InputValue, string(body)),
+		attribute.String(openinference.InputMimeType, openinference.MimeTypeJSON),
+	}, actualSpan.Attributes)
+
+	actualSpan = testotel.RecordWithSpan(t, func(span oteltrace.Span) bool {
+		NewCountTokensRecorder(&openinference.TraceConfig{HideInputs: true}).RecordRequest(span, req, body)
+		return false
+	})
+	openinference.RequireAttributesEqual(t, []attribute.KeyValue{
+		attribute.String(openinference.SpanKind, openinference.SpanKindLLM),
+		attribute.String(openinference.LLMSystem, openinference.LLMSystemAnthropic),
+		attribute.String(openinference.LLMModelName, "claude-sonnet-4"),
+		attribute.Int("count_tokens.message_count", 1),
+		attribute.String(openinference.InputValue, openinference.RedactedValue),
+	}, actualSpan.Attributes)
+}
+
+func TestCountTokensRecorder_RecordResponse(t *testing.T) {
+	resp := &anthropic.CountTokensResponse{InputTokens: 42}
+	for _, tc := range []struct {
+		name           string
+		config         *openinference.TraceConfig
+		expectedOutput string
+	}{
+		{name: "outputs visible", config: &openinference.TraceConfig{}, expectedOutput: `{"input_tokens":42}`},
+		{name: "outputs hidden", config: &openinference.TraceConfig{HideOutputs: true}, expectedOutput: openinference.RedactedValue},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actualSpan := testotel.RecordWithSpan(t, func(span oteltrace.Span) bool {
+				NewCountTokensRecorder(tc.config).RecordResponse(span, resp)
+				return false
+			})
+			openinference.RequireAttributesEqual(t, []attribute.KeyValue{
+				attribute.Int64(openinference.LLMTokenCountPrompt, 42),
+				attribute.String(openinference.OutputMimeType, openinference.MimeTypeJSON),
+				attribute.String(openinference.OutputValue, tc.expectedOutput),
+			}, actualSpan.Attributes)
+			require.Equal(t, codes.Ok, actualSpan.Status.Code)
+		})
+	}
+}
+
+func TestCountTokensRecorder_RecordResponseOnError(t *testing.T) {
+	actualSpan := testotel.RecordWithSpan(t, func(span oteltrace.Span) bool {
+		NewCountTokensRecorderFromEnv().RecordResponseOnError(span, 400, []byte(`{"type":"error"}`))
+		return false
+	})
+	require.Equal(t, codes.Error, actualSpan.Status.Code)
+	require.Len(t, actualSpan.Events, 1)
+}
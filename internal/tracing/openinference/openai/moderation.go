@@ -0,0 +1,82 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package openai
+
+import (
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/json"
+	"github.com/envoyproxy/ai-gateway/internal/tracing/openinference"
+	"github.com/envoyproxy/ai-gateway/internal/tracing/tracingapi"
+)
+
+// ModerationRecorder implements recorders for OpenInference moderation spans.
+type ModerationRecorder struct {
+	tracingapi.NoopChunkRecorder[struct{}]
+	traceConfig *openinference.TraceConfig
+}
+
+// NewModerationRecorderFromEnv creates a tracingapi.ModerationRecorder
+// from environment variables using the OpenInference configuration specification.
+func NewModerationRecorderFromEnv() tracingapi.ModerationRecorder {
+	return NewModerationRecorder(nil)
+}
+
+// NewModerationRecorder creates a tracingapi.ModerationRecorder with the
+// given config using the OpenInference configuration specification.
+func NewModerationRecorder(config *openinference.TraceConfig) tracingapi.ModerationRecorder {
+	if config == nil {
+		config = openinference.NewTraceConfigFromEnv()
+	}
+	return &ModerationRecorder{traceConfig: config}
+}
+
+var moderationStartOpts = []trace.SpanStartOption{trace.WithSpanKind(trace.SpanKindInternal)}
+
+// StartParams implements the same method as defined in tracingapi.ModerationRecorder.
+func (r *ModerationRecorder) StartParams(*openai.ModerationRequest, []byte) (spanName string, opts []trace.SpanStartOption) {
+	return "Moderation", moderationStartOpts
+}
+
+// RecordRequest implements the same method as defined in tracingapi.ModerationRecorder.
+func (r *ModerationRecorder) RecordRequest(span trace.Span, req *openai.ModerationRequest, body []byte) {
+	attrs := []attribute.KeyValue{
+		attribute.String(openinference.SpanKind, openinference.SpanKindGuardrail),
+		attribute.String(openinference.LLMSystem, openinference.LLMSystemOpenAI),
+	}
+	if req.Model != "" {
+		attrs = append(attrs, attribute.String(openinference.LLMModelName, req.Model))
+	}
+	if r.traceConfig.HideInputs {
+		attrs = append(attrs, attribute.String(openinference.InputValue, openinference.RedactedValue))
+	} else {
+		attrs = append(attrs,
+			attribute.String(openinference.InputValue, string(body)),
+			attribute.String(openinference.InputMimeType, openinference.MimeTypeJSON))
+	}
+	span.SetAttributes(attrs...)
+}
+
+// RecordResponse implements the same method as defined in tracingapi.ModerationRecorder.
+func (r *ModerationRecorder) RecordResponse(span trace.Span, resp *openai.ModerationResponse) {
+	if !r.traceConfig.HideOutputs && resp != nil {
+		if b, err := json.Marshal(resp); err == nil {
+			span.SetAttributes(
+				attribute.String(openinference.OutputValue, string(b)),
+				attribute.String(openinference.OutputMimeType, openinference.MimeTypeJSON),
+			)
+		}
+	}
+	span.SetStatus(codes.Ok, "")
+}
+
+// RecordResponseOnError implements the same method as defined in tracingapi.ModerationRecorder.
+func (r *ModerationRecorder) RecordResponseOnError(span trace.Span, statusCode int, body []byte) {
+	openinference.RecordResponseError(span, statusCode, string(body))
+}
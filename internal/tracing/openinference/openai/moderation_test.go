@@ -0,0 +1,108 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package openai
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace"
+	oteltrace "go.opentelemetry.io/otel/trace"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/testing/testotel"
+	"github.com/envoyproxy/ai-gateway/internal/tracing/openinference"
+)
+
+var (
+	moderationReqBody = []byte(`{"model":"omni-moderation-latest","input":["I want to kill them."]}`)
+	moderationReq     = &openai.ModerationRequest{Model: "omni-moderation-latest", Input: openai.ModerationInput{OfStringArray: []string{"I want to kill them."}}}
+	moderationResp    = &openai.ModerationResponse{
+		ID:    "modr-123",
+		Model: "omni-moderation-latest",
+		Results: []openai.ModerationResult{{
+			Flagged:        true,
+			Categories:     map[string]bool{openai.ModerationCategoryViolence: true},
+			CategoryScores: map[string]float64{openai.ModerationCategoryViolence: 0.9},
+		}},
+	}
+)
+
+func TestModerationRecorder_StartParams(t *testing.T) {
+	recorder := NewModerationRecorderFromEnv()
+	spanName, opts := recorder.StartParams(moderationReq, moderationReqBody)
+	actualSpan := testotel.RecordNewSpan(t, spanName, opts...)
+
+	require.Equal(t, "Moderation", actualSpan.Name)
+	require.Equal(t, oteltrace.SpanKindInternal, actualSpan.SpanKind)
+}
+
+func TestModerationRecorder_RecordRequest(t *testing.T) {
+	tests := []struct {
+		name          string
+		config        *openinference.TraceConfig
+		expectedAttrs []attribute.KeyValue
+	}{
+		{
+			name:   "basic request",
+			config: &openinference.TraceConfig{},
+			expectedAttrs: []attribute.KeyValue{
+				attribute.String(openinference.SpanKind, openinference.SpanKindGuardrail),
+				attribute.String(openinference.LLMSystem, openinference.LLMSystemOpenAI),
+				attribute.String(openinference.LLMModelName, "omni-moderation-latest"),
+				attribute.String(openinference.InputValue, string(moderationReqBody)),
+				attribute.String(openinference.InputMimeType, openinference.MimeTypeJSON),
+			},
+		},
+		{
+			name:   "hidden inputs",
+			config: &openinference.TraceConfig{HideInputs: true},
+			expectedAttrs: []attribute.KeyValue{
+				attribute.String(openinference.SpanKind, openinference.SpanKindGuardrail),
+				attribute.String(openinference.LLMSystem, openinference.LLMSystemOpenAI),
+				attribute.String(openinference.LLMModelName, "omni-moderation-latest"),
+				attribute.String(openinference.InputValue, openinference.RedactedValue),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := NewModerationRecorder(tt.config)
+			actualSpan := testotel.RecordWithSpan(t, func(span oteltrace.Span) bool {
+				recorder.RecordRequest(span, moderationReq, moderationReqBody)
+				return false
+			})
+			openinference.RequireAttributesEqual(t, tt.expectedAttrs, actualSpan.Attributes)
+		})
+	}
+}
+
+func TestModerationRecorder_RecordResponse(t *testing.T) {
+	t.Run("outputs", func(t *testing.T) {
+		recorder := NewModerationRecorder(&openinference.TraceConfig{})
+		actualSpan := testotel.RecordWithSpan(t, func(span oteltrace.Span) bool {
+			recorder.RecordResponse(span, moderationResp)
+			return false
+		})
+		openinference.RequireAttributesEqual(t, []attribute.KeyValue{
+			attribute.String(openinference.OutputValue, `{"id":"modr-123","model":"omni-moderation-latest","results":[{"flagged":true,"categories":{"violence":true},"category_scores":{"violence":0.9}}]}`),
+			attribute.String(openinference.OutputMimeType, openinference.MimeTypeJSON),
+		}, actualSpan.Attributes)
+		require.Equal(t, trace.Status{Code: codes.Ok, Description: ""}, actualSpan.Status)
+	})
+	t.Run("hidden outputs", func(t *testing.T) {
+		recorder := NewModerationRecorder(&openinference.TraceConfig{HideOutputs: true})
+		actualSpan := testotel.RecordWithSpan(t, func(span oteltrace.Span) bool {
+			recorder.RecordResponse(span, moderationResp)
+			return false
+		})
+		require.Empty(t, actualSpan.Attributes)
+		require.Equal(t, trace.Status{Code: codes.Ok, Description: ""}, actualSpan.Status)
+	})
+}
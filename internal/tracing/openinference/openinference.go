@@ -22,6 +22,9 @@ const (
 
 	// SpanKindEmbedding indicates an Embedding operation.
 	SpanKindEmbedding = "EMBEDDING"
+
+	// SpanKindGuardrail indicates a Guardrail operation, e.g. a moderation.
+	SpanKindGuardrail = "GUARDRAIL"
 )
 
 // LLM Operation constants.
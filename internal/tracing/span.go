@@ -6,6 +6,7 @@
 package tracing
 
 import (
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 
 	anthropicschema "github.com/envoyproxy/ai-gateway/internal/apischema/anthropic"
@@ -45,6 +46,21 @@ func (s *span[RespT, ChunkT]) EndSpanOnError(statusCode int, body []byte) {
 	s.span.End()
 }
 
+// SetAttributes implements [tracingapi.SpanAttributeSetter.SetAttributes]
+func (s *span[RespT, ChunkT]) SetAttributes(attrs ...attribute.KeyValue) {
+	s.span.SetAttributes(attrs...)
+}
+
+// AddEvent implements [tracingapi.SpanEventRecorder.AddEvent]
+func (s *span[RespT, ChunkT]) AddEvent(name string, attrs ...attribute.KeyValue) {
+	s.span.AddEvent(name, trace.WithAttributes(attrs...))
+}
+
+// SpanContext implements [tracingapi.SpanContextProvider.SpanContext]
+func (s *span[RespT, ChunkT]) SpanContext() trace.SpanContext {
+	return s.span.SpanContext()
+}
+
 // Type aliases tying generic implementations to concrete recorder contracts.
 type (
 	chatCompletionSpan  = span[openai.ChatCompletionResponse, openai.ChatCompletionResponseChunk]
@@ -56,6 +72,8 @@ type (
 	transcriptionSpan   = span[openai.TranscriptionResponse, openai.TranscriptionStreamEvent]
 	translationSpan     = span[openai.TranslationResponse, struct{}]
 	rerankSpan          = span[cohereschema.RerankV2Response, struct{}]
+	moderationSpan      = span[openai.ModerationResponse, struct{}]
 	messageSpan         = span[anthropicschema.MessagesResponse, anthropicschema.MessagesStreamChunk]
 	tokenizeSpan        = span[tokenize.Response, struct{}]
+	countTokensSpan     = span[anthropicschema.CountTokensResponse, struct{}]
 )
@@ -15,6 +15,7 @@ import (
 	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
 	"github.com/envoyproxy/ai-gateway/internal/json"
 	"github.com/envoyproxy/ai-gateway/internal/testing/testotel"
+	"github.com/envoyproxy/ai-gateway/internal/tracing/tracingapi"
 )
 
 func TestChatCompletionSpan_RecordResponseChunk(t *testing.T) {
@@ -74,6 +75,43 @@ func TestChatCompletionSpan_EndSpan(t *testing.T) {
 	}, actualSpan.Attributes)
 }
 
+func TestChatCompletionSpan_SetAttributes(t *testing.T) {
+	actualSpan := testotel.RecordWithSpan(t, func(span oteltrace.Span) bool {
+		var s tracingapi.SpanAttributeSetter = &chatCompletionSpan{span: span}
+		s.SetAttributes(attribute.String("experiment.name", "exp"), attribute.String("experiment.variant", "treatment"))
+		return false
+	})
+
+	require.Equal(t, []attribute.KeyValue{
+		attribute.String("experiment.name", "exp"),
+		attribute.String("experiment.variant", "treatment"),
+	}, actualSpan.Attributes)
+}
+
+func TestChatCompletionSpan_AddEvent(t *testing.T) {
+	actualSpan := testotel.RecordWithSpan(t, func(span oteltrace.Span) bool {
+		var s tracingapi.SpanEventRecorder = &chatCompletionSpan{span: span}
+		s.AddEvent("context_length_retry", attribute.String("strategy", "Fallback"))
+		return false
+	})
+
+	require.Len(t, actualSpan.Events, 1)
+	require.Equal(t, "context_length_retry", actualSpan.Events[0].Name)
+	require.Equal(t, []attribute.KeyValue{attribute.String("strategy", "Fallback")}, actualSpan.Events[0].Attributes)
+}
+
+func TestChatCompletionSpan_SpanContext(t *testing.T) {
+	var spanContext oteltrace.SpanContext
+	actualSpan := testotel.RecordWithSpan(t, func(span oteltrace.Span) bool {
+		var s tracingapi.SpanContextProvider = &chatCompletionSpan{span: span}
+		spanContext = s.SpanContext()
+		return false
+	})
+
+	require.True(t, spanContext.IsValid())
+	require.Equal(t, actualSpan.SpanContext, spanContext)
+}
+
 func TestEmbeddingsSpan_EndSpanOnError(t *testing.T) {
 	msg := "embeddings error occurred"
 	actualSpan := testotel.RecordWithSpan(t, func(span oteltrace.Span) bool {
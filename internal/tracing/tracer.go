@@ -40,6 +40,7 @@ var (
 	_ tracingapi.TranscriptionTracer   = (*transcriptionTracer)(nil)
 	_ tracingapi.TranslationTracer     = (*translationTracer)(nil)
 	_ tracingapi.RerankTracer          = (*rerankTracer)(nil)
+	_ tracingapi.ModerationTracer      = (*moderationTracer)(nil)
 )
 
 type (
@@ -52,6 +53,7 @@ type (
 	transcriptionTracer   = requestTracerImpl[openai.TranscriptionRequest, openai.TranscriptionResponse, openai.TranscriptionStreamEvent]
 	translationTracer     = requestTracerImpl[openai.TranslationRequest, openai.TranslationResponse, struct{}]
 	rerankTracer          = requestTracerImpl[cohereschema.RerankV2Request, cohereschema.RerankV2Response, struct{}]
+	moderationTracer      = requestTracerImpl[openai.ModerationRequest, openai.ModerationResponse, struct{}]
 )
 
 func newRequestTracer[ReqT any, RespT any, RespChunkT any](
@@ -216,6 +218,18 @@ func newRerankTracer(tracer trace.Tracer, propagator propagation.TextMapPropagat
 	)
 }
 
+func newModerationTracer(tracer trace.Tracer, propagator propagation.TextMapPropagator, recorder tracingapi.ModerationRecorder, headerAttributes map[string]string) tracingapi.ModerationTracer {
+	return newRequestTracer(
+		tracer,
+		propagator,
+		recorder,
+		headerAttributes,
+		func(span trace.Span, recorder tracingapi.ModerationRecorder) tracingapi.ModerationSpan {
+			return &moderationSpan{span: span, recorder: recorder}
+		},
+	)
+}
+
 func newMessageTracer(tracer trace.Tracer, propagator propagation.TextMapPropagator, recorder tracingapi.MessageRecorder, headerAttributes map[string]string) tracingapi.MessageTracer {
 	return newRequestTracer(
 		tracer,
@@ -239,3 +253,15 @@ func newTokenizeTracer(tracer trace.Tracer, propagator propagation.TextMapPropag
 		},
 	)
 }
+
+func newCountTokensTracer(tracer trace.Tracer, propagator propagation.TextMapPropagator, recorder tracingapi.CountTokensRecorder, headerAttributes map[string]string) tracingapi.CountTokensTracer {
+	return newRequestTracer(
+		tracer,
+		propagator,
+		recorder,
+		headerAttributes,
+		func(span trace.Span, recorder tracingapi.CountTokensRecorder) tracingapi.CountTokensSpan {
+			return &countTokensSpan{span: span, recorder: recorder}
+		},
+	)
+}
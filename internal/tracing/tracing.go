@@ -37,8 +37,10 @@ type tracingImpl struct {
 	transcriptionTracer   tracingapi.TranscriptionTracer
 	translationTracer     tracingapi.TranslationTracer
 	rerankTracer          tracingapi.RerankTracer
+	moderationTracer      tracingapi.ModerationTracer
 	messageTracer         tracingapi.MessageTracer
 	tokenizeTracer        tracingapi.TokenizeTracer
+	countTokensTracer     tracingapi.CountTokensTracer
 	mcpTracer             tracingapi.MCPTracer
 	// shutdown is nil when we didn't create tp.
 	shutdown func(context.Context) error
@@ -89,6 +91,11 @@ func (t *tracingImpl) RerankTracer() tracingapi.RerankTracer {
 	return t.rerankTracer
 }
 
+// ModerationTracer implements the same method as documented on tracingapi.Tracing.
+func (t *tracingImpl) ModerationTracer() tracingapi.ModerationTracer {
+	return t.moderationTracer
+}
+
 // MCPTracer implements the same method as documented on tracingapi.Tracing.
 func (t *tracingImpl) MCPTracer() tracingapi.MCPTracer {
 	return t.mcpTracer
@@ -104,6 +111,11 @@ func (t *tracingImpl) TokenizeTracer() tracingapi.TokenizeTracer {
 	return t.tokenizeTracer
 }
 
+// CountTokensTracer implements the same method as documented on tracingapi.Tracing.
+func (t *tracingImpl) CountTokensTracer() tracingapi.CountTokensTracer {
+	return t.countTokensTracer
+}
+
 // Shutdown implements the same method as documented on tracingapi.Tracing.
 func (t *tracingImpl) Shutdown(ctx context.Context) error {
 	if t.shutdown != nil {
@@ -226,8 +238,10 @@ func NewTracingFromEnv(ctx context.Context, stdout io.Writer, headerAttributeMap
 	transcriptionRecorder := openai.NewTranscriptionRecorderFromEnv()
 	translationRecorder := openai.NewTranslationRecorderFromEnv()
 	rerankRecorder := cohere.NewRerankRecorderFromEnv()
+	moderationRecorder := openai.NewModerationRecorderFromEnv()
 	messageRecorder := anthropic.NewMessageRecorderFromEnv()
 	tokenizeRecorder := openai.NewTokenizeRecorderFromEnv()
+	countTokensRecorder := anthropic.NewCountTokensRecorderFromEnv()
 
 	tracer := tp.Tracer("envoyproxy/ai-gateway")
 	return &tracingImpl{
@@ -284,6 +298,12 @@ func NewTracingFromEnv(ctx context.Context, stdout io.Writer, headerAttributeMap
 			rerankRecorder,
 			headerAttrs,
 		),
+		moderationTracer: newModerationTracer(
+			tracer,
+			propagator,
+			moderationRecorder,
+			headerAttrs,
+		),
 		messageTracer: newMessageTracer(
 			tracer,
 			propagator,
@@ -296,6 +316,12 @@ func NewTracingFromEnv(ctx context.Context, stdout io.Writer, headerAttributeMap
 			tokenizeRecorder,
 			headerAttrs,
 		),
+		countTokensTracer: newCountTokensTracer(
+			tracer,
+			propagator,
+			countTokensRecorder,
+			headerAttrs,
+		),
 		mcpTracer: newMCPTracer(tracer, propagator, headerAttrs),
 		shutdown:  tp.Shutdown, // we have to shut down what we create.
 	}, nil
@@ -868,6 +868,12 @@ func TestTracingImpl_Getters_ImageGenerationAndRerank(t *testing.T) {
 	require.Equal(t, rr, ti.RerankTracer())
 }
 
+func TestTracingImpl_Getters_Moderation(t *testing.T) {
+	m := tracingapi.NoopTracer[openai.ModerationRequest, openai.ModerationResponse, struct{}]{}
+	ti := &tracingImpl{moderationTracer: m}
+	require.Equal(t, m, ti.ModerationTracer())
+}
+
 func TestTracingImpl_Getters_TranscriptionAndTranslation(t *testing.T) {
 	tr := tracingapi.NoopTracer[openai.TranscriptionRequest, openai.TranscriptionResponse, openai.TranscriptionStreamEvent]{}
 	tl := tracingapi.NoopTracer[openai.TranslationRequest, openai.TranslationResponse, struct{}]{}
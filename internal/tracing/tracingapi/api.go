@@ -10,6 +10,7 @@ package tracingapi
 import (
 	"context"
 
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/trace"
 
@@ -41,10 +42,14 @@ type (
 		TranslationTracer() TranslationTracer
 		// RerankTracer creates spans for rerank requests.
 		RerankTracer() RerankTracer
+		// ModerationTracer creates spans for OpenAI moderation requests on /v1/moderations endpoint.
+		ModerationTracer() ModerationTracer
 		// MessageTracer creates spans for Anthropic messages requests.
 		MessageTracer() MessageTracer
 		// TokenizeTracer creates spans for tokenize requests.
 		TokenizeTracer() TokenizeTracer
+		// CountTokensTracer creates spans for Anthropic count tokens requests.
+		CountTokensTracer() CountTokensTracer
 		// MCPTracer creates spans for MCP requests.
 		MCPTracer() MCPTracer
 		// Shutdown shuts down the tracer, flushing any buffered spans.
@@ -87,10 +92,14 @@ type (
 	TranslationTracer = RequestTracer[openai.TranslationRequest, openai.TranslationResponse, struct{}]
 	// RerankTracer creates spans for rerank requests.
 	RerankTracer = RequestTracer[cohere.RerankV2Request, cohere.RerankV2Response, struct{}]
+	// ModerationTracer creates spans for OpenAI moderation requests.
+	ModerationTracer = RequestTracer[openai.ModerationRequest, openai.ModerationResponse, struct{}]
 	// MessageTracer creates spans for Anthropic messages requests.
 	MessageTracer = RequestTracer[anthropicschema.MessagesRequest, anthropicschema.MessagesResponse, anthropicschema.MessagesStreamChunk]
 	// TokenizeTracer creates spans for tokenize requests.
 	TokenizeTracer = RequestTracer[tokenize.RequestUnion, tokenize.Response, struct{}]
+	// CountTokensTracer creates spans for Anthropic count tokens requests.
+	CountTokensTracer = RequestTracer[anthropicschema.MessagesRequest, anthropicschema.CountTokensResponse, struct{}]
 )
 
 type (
@@ -105,6 +114,24 @@ type (
 		// EndSpan finalizes and ends the span.
 		EndSpan()
 	}
+	// SpanAttributeSetter is optionally implemented by a Span to record the attributes only known after the span
+	// started, e.g. the ones depending on the route selected for the request.
+	SpanAttributeSetter interface {
+		// SetAttributes records the attributes to the span.
+		SetAttributes(attrs ...attribute.KeyValue)
+	}
+	// SpanEventRecorder is optionally implemented by a Span to record the events happening while the request is
+	// processed, e.g. its retries.
+	SpanEventRecorder interface {
+		// AddEvent records the event with the attributes to the span.
+		AddEvent(name string, attrs ...attribute.KeyValue)
+	}
+	// SpanContextProvider is optionally implemented by a Span to expose its span context, e.g. to attach the trace
+	// ID as an exemplar to the metrics of the request.
+	SpanContextProvider interface {
+		// SpanContext returns the span context of the span.
+		SpanContext() trace.SpanContext
+	}
 	// ChatCompletionSpan represents an OpenAI chat completion.
 	ChatCompletionSpan = Span[openai.ChatCompletionResponse, openai.ChatCompletionResponseChunk]
 	// CompletionSpan represents an OpenAI completion request.
@@ -124,10 +151,14 @@ type (
 	TranslationSpan = Span[openai.TranslationResponse, struct{}]
 	// RerankSpan represents a rerank request span.
 	RerankSpan = Span[cohere.RerankV2Response, struct{}]
+	// ModerationSpan represents an OpenAI moderation request span. The chunk type is unused and therefore set to struct{}.
+	ModerationSpan = Span[openai.ModerationResponse, struct{}]
 	// MessageSpan represents an Anthropic messages request span.
 	MessageSpan = Span[anthropicschema.MessagesResponse, anthropicschema.MessagesStreamChunk]
 	// TokenizeSpan represents a tokenize request span. The chunk type is unused and therefore set to struct{}.
 	TokenizeSpan = Span[tokenize.Response, struct{}]
+	// CountTokensSpan represents an Anthropic count tokens request span. The chunk type is unused and therefore set to struct{}.
+	CountTokensSpan = Span[anthropicschema.CountTokensResponse, struct{}]
 )
 
 type (
@@ -175,10 +206,14 @@ type (
 	TranslationRecorder = SpanRecorder[openai.TranslationRequest, openai.TranslationResponse, struct{}]
 	// RerankRecorder records attributes to a span according to a semantic convention.
 	RerankRecorder = SpanRecorder[cohere.RerankV2Request, cohere.RerankV2Response, struct{}]
+	// ModerationRecorder records attributes to a span according to a semantic convention.
+	ModerationRecorder = SpanRecorder[openai.ModerationRequest, openai.ModerationResponse, struct{}]
 	// MessageRecorder records attributes to a span according to a semantic convention.
 	MessageRecorder = SpanRecorder[anthropicschema.MessagesRequest, anthropicschema.MessagesResponse, anthropicschema.MessagesStreamChunk]
 	// TokenizeRecorder records attributes to a span according to a semantic convention.
 	TokenizeRecorder = SpanRecorder[tokenize.RequestUnion, tokenize.Response, struct{}]
+	// CountTokensRecorder records attributes to a span according to a semantic convention.
+	CountTokensRecorder = SpanRecorder[anthropicschema.MessagesRequest, anthropicschema.CountTokensResponse, struct{}]
 )
 
 // NoopChunkRecorder provides a no-op RecordResponseChunks implementation for recorders that don't emit streaming chunks.
@@ -239,6 +274,11 @@ func (NoopTracing) RerankTracer() RerankTracer {
 	return NoopRerankTracer{}
 }
 
+// ModerationTracer implements Tracing.ModerationTracer.
+func (NoopTracing) ModerationTracer() ModerationTracer {
+	return NoopModerationTracer{}
+}
+
 func (NoopTracing) MessageTracer() MessageTracer {
 	return NoopMessageTracer{}
 }
@@ -248,6 +288,11 @@ func (NoopTracing) TokenizeTracer() TokenizeTracer {
 	return NoopTokenizeTracer{}
 }
 
+// CountTokensTracer implements Tracing.CountTokensTracer.
+func (NoopTracing) CountTokensTracer() CountTokensTracer {
+	return NoopCountTokensTracer{}
+}
+
 // Shutdown implements Tracing.Shutdown.
 func (NoopTracing) Shutdown(context.Context) error {
 	return nil
@@ -274,10 +319,14 @@ type (
 	NoopTranslationTracer = NoopTracer[openai.TranslationRequest, openai.TranslationResponse, struct{}]
 	// NoopRerankTracer implements RerankTracer.
 	NoopRerankTracer = NoopTracer[cohere.RerankV2Request, cohere.RerankV2Response, struct{}]
+	// NoopModerationTracer implements ModerationTracer.
+	NoopModerationTracer = NoopTracer[openai.ModerationRequest, openai.ModerationResponse, struct{}]
 	// NoopMessageTracer implements MessageTracer.
 	NoopMessageTracer = NoopTracer[anthropicschema.MessagesRequest, anthropicschema.MessagesResponse, anthropicschema.MessagesStreamChunk]
 	// NoopTokenizeTracer implements TokenizeTracer.
 	NoopTokenizeTracer = NoopTracer[tokenize.RequestUnion, tokenize.Response, struct{}]
+	// NoopCountTokensTracer implements CountTokensTracer.
+	NoopCountTokensTracer = NoopTracer[anthropicschema.MessagesRequest, anthropicschema.CountTokensResponse, struct{}]
 )
 
 // StartSpanAndInjectHeaders implements RequestTracer.StartSpanAndInjectHeaders.
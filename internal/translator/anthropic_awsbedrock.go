@@ -228,14 +228,17 @@ func (a *anthropicToAWSBedrockTranslator) convertUserMessage(msg *anthropicschem
 			bedrockMsg.Content = append(bedrockMsg.Content, &awsbedrock.ContentBlock{
 				Text: ptr.To(block.Text.Text),
 			})
+			bedrockMsg.Content = appendAnthropicCachePoint(bedrockMsg.Content, block.Text.CacheControl)
 		case block.Image != nil:
 			imgBlock, err := a.convertImageBlock(block.Image)
 			if err != nil {
 				return nil, err
 			}
 			bedrockMsg.Content = append(bedrockMsg.Content, imgBlock)
+			bedrockMsg.Content = appendAnthropicCachePoint(bedrockMsg.Content, block.Image.CacheControl)
 		case block.ToolResult != nil:
 			bedrockMsg.Content = append(bedrockMsg.Content, a.convertToolResultBlock(block.ToolResult))
+			bedrockMsg.Content = appendAnthropicCachePoint(bedrockMsg.Content, block.ToolResult.CacheControl)
 		}
 	}
 	return bedrockMsg, nil
@@ -257,6 +260,7 @@ func (a *anthropicToAWSBedrockTranslator) convertAssistantMessage(msg *anthropic
 			bedrockMsg.Content = append(bedrockMsg.Content, &awsbedrock.ContentBlock{
 				Text: ptr.To(block.Text.Text),
 			})
+			bedrockMsg.Content = appendAnthropicCachePoint(bedrockMsg.Content, block.Text.CacheControl)
 		case block.Thinking != nil:
 			bedrockMsg.Content = append(bedrockMsg.Content, &awsbedrock.ContentBlock{
 				ReasoningContent: &awsbedrock.ReasoningContentBlock{
@@ -274,6 +278,7 @@ func (a *anthropicToAWSBedrockTranslator) convertAssistantMessage(msg *anthropic
 					Input:     block.ToolUse.Input,
 				},
 			})
+			bedrockMsg.Content = appendAnthropicCachePoint(bedrockMsg.Content, block.ToolUse.CacheControl)
 		case block.RedactedThinking != nil:
 			bedrockMsg.Content = append(bedrockMsg.Content, &awsbedrock.ContentBlock{
 				ReasoningContent: &awsbedrock.ReasoningContentBlock{
@@ -292,6 +297,7 @@ func (a *anthropicToAWSBedrockTranslator) convertToolResultMessage(msg *anthropi
 		block := &msg.Content.Array[i]
 		if block.ToolResult != nil {
 			bedrockMsg.Content = append(bedrockMsg.Content, a.convertToolResultBlock(block.ToolResult))
+			bedrockMsg.Content = appendAnthropicCachePoint(bedrockMsg.Content, block.ToolResult.CacheControl)
 		}
 	}
 	return bedrockMsg
@@ -365,6 +371,9 @@ func (a *anthropicToAWSBedrockTranslator) convertSystemPrompt(system *anthropics
 	for i := range system.Texts {
 		text := system.Texts[i].Text
 		blocks = append(blocks, &awsbedrock.SystemContentBlock{Text: &text})
+		if cachePoint := anthropicCachePoint(system.Texts[i].CacheControl); cachePoint != nil {
+			blocks = append(blocks, &awsbedrock.SystemContentBlock{CachePoint: cachePoint})
+		}
 	}
 	return blocks
 }
@@ -390,6 +399,9 @@ func (a *anthropicToAWSBedrockTranslator) convertTools(body *anthropicschema.Mes
 				},
 			}
 			tools = append(tools, tool)
+			if cachePoint := anthropicCachePoint(tu.Tool.CacheControl); cachePoint != nil {
+				tools = append(tools, &awsbedrock.Tool{CachePoint: cachePoint})
+			}
 		}
 	}
 	bedrockReq.ToolConfig.Tools = tools
@@ -417,6 +429,25 @@ func (a *anthropicToAWSBedrockTranslator) convertTools(body *anthropicschema.Mes
 	}
 }
 
+// anthropicCachePoint returns the Bedrock cache point equivalent to the cache control of an Anthropic block, or nil
+// if it is not set. Bedrock caches the content preceding the cache point, like Anthropic caches the content up to
+// the block with the cache control.
+func anthropicCachePoint(cacheControl *anthropicschema.CacheControl) *awsbedrock.CachePointBlock {
+	if cacheControl == nil || cacheControl.Ephemeral == nil {
+		return nil
+	}
+	return &awsbedrock.CachePointBlock{Type: "default"}
+}
+
+// appendAnthropicCachePoint appends the Bedrock cache point equivalent to the cache control of the last converted
+// Anthropic block, if set.
+func appendAnthropicCachePoint(content []*awsbedrock.ContentBlock, cacheControl *anthropicschema.CacheControl) []*awsbedrock.ContentBlock {
+	if cachePoint := anthropicCachePoint(cacheControl); cachePoint != nil {
+		content = append(content, &awsbedrock.ContentBlock{CachePoint: cachePoint})
+	}
+	return content
+}
+
 // ResponseHeaders implements [AnthropicMessagesTranslator.ResponseHeaders].
 func (a *anthropicToAWSBedrockTranslator) ResponseHeaders(headers map[string]string) (
 	newHeaders []internalapi.Header, err error,
@@ -586,9 +617,15 @@ func (a *anthropicToAWSBedrockTranslator) convertEventToAnthropicSSE(event *awsb
 			return
 		}
 		a.role = *event.Role
-		inputTokens := 0
+		usage := map[string]any{"input_tokens": 0, "output_tokens": 0}
 		if a.streamingUsage != nil {
-			inputTokens = int(a.streamingUsage.InputTokens)
+			usage["input_tokens"] = int(a.streamingUsage.InputTokens)
+			if a.streamingUsage.CacheReadInputTokens != nil {
+				usage["cache_read_input_tokens"] = *a.streamingUsage.CacheReadInputTokens
+			}
+			if a.streamingUsage.CacheWriteInputTokens != nil {
+				usage["cache_creation_input_tokens"] = *a.streamingUsage.CacheWriteInputTokens
+			}
 		}
 		msgStart := map[string]any{
 			"type": "message_start",
@@ -600,10 +637,7 @@ func (a *anthropicToAWSBedrockTranslator) convertEventToAnthropicSSE(event *awsb
 				"model":         a.requestModel,
 				"stop_reason":   nil,
 				"stop_sequence": nil,
-				"usage": map[string]any{
-					"input_tokens":  inputTokens,
-					"output_tokens": 0,
-				},
+				"usage":         usage,
 			},
 		}
 		a.writeSSEEvent("message_start", msgStart, out)
@@ -433,6 +433,40 @@ func TestAnthropicToAWSBedrockTranslator_ResponseBody_Streaming(t *testing.T) {
 	assert.Contains(t, bodyStr, `"text":""`)
 }
 
+func TestAnthropicToAWSBedrockTranslator_ResponseBody_StreamingCacheUsage(t *testing.T) {
+	translator := NewAnthropicToAWSBedrockTranslator("")
+	req := &anthropicschema.MessagesRequest{
+		Model:     "test-model",
+		MaxTokens: 100,
+		Stream:    true,
+		Messages: []anthropicschema.MessageParam{
+			{Role: anthropicschema.MessageRoleUser, Content: anthropicschema.MessageContent{Text: "Hi"}},
+		},
+	}
+	rawBody, _ := json.Marshal(req)
+	_, _, _ = translator.RequestBody(rawBody, req, false)
+
+	var eventStreamData bytes.Buffer
+	writeEventStreamMessage(t, &eventStreamData, "messageStart", []byte(`{"role":"assistant"}`))
+	writeEventStreamMessage(t, &eventStreamData, "messageStop", []byte(`{"stopReason":"end_turn"}`))
+	writeEventStreamMessage(t, &eventStreamData, "metadata",
+		[]byte(`{"usage":{"inputTokens":5,"outputTokens":3,"totalTokens":1508,"cacheReadInputTokens":1200,"cacheWriteInputTokens":300}}`))
+
+	_, body, tokenUsage, _, err := translator.ResponseBody(nil, &eventStreamData, true, nil)
+	require.NoError(t, err)
+	cachedInputTokens, ok := tokenUsage.CachedInputTokens()
+	require.True(t, ok)
+	assert.Equal(t, uint32(1200), cachedInputTokens)
+	cacheCreationInputTokens, ok := tokenUsage.CacheCreationInputTokens()
+	require.True(t, ok)
+	assert.Equal(t, uint32(300), cacheCreationInputTokens)
+
+	// The cache usage is reported in the message_start event like Anthropic does.
+	require.Contains(t, string(body), `"type":"message_start"`)
+	assert.Contains(t, string(body), `"cache_read_input_tokens":1200`)
+	assert.Contains(t, string(body), `"cache_creation_input_tokens":300`)
+}
+
 func TestAnthropicToAWSBedrockTranslator_ResponseBody_StreamingThinking(t *testing.T) {
 	translator := NewAnthropicToAWSBedrockTranslator("")
 	req := &anthropicschema.MessagesRequest{
@@ -687,6 +721,64 @@ func TestAnthropicToAWSBedrockTranslator_RequestBody_SystemPrompt(t *testing.T)
 	})
 }
 
+func TestAnthropicToAWSBedrockTranslator_RequestBody_CacheControl(t *testing.T) {
+	ephemeral := &anthropicschema.CacheControl{Ephemeral: &anthropicschema.CacheControlEphemeral{Type: "ephemeral"}}
+	translator := NewAnthropicToAWSBedrockTranslator("")
+	req := &anthropicschema.MessagesRequest{
+		Model:     "test-model",
+		MaxTokens: 100,
+		System: &anthropicschema.SystemPrompt{
+			Texts: []anthropicschema.TextBlockParam{
+				{Text: "Long instructions.", Type: "text", CacheControl: ephemeral},
+				{Text: "Uncached instructions.", Type: "text"},
+			},
+		},
+		Tools: []anthropicschema.ToolUnion{
+			{Tool: &anthropicschema.Tool{Type: "custom", Name: "search", InputSchema: anthropicschema.ToolInputSchema{Type: "object"}, CacheControl: ephemeral}},
+		},
+		Messages: []anthropicschema.MessageParam{
+			{Role: anthropicschema.MessageRoleUser, Content: anthropicschema.MessageContent{Array: []anthropicschema.ContentBlockParam{
+				{Text: &anthropicschema.TextBlockParam{Text: "Long document.", Type: "text", CacheControl: ephemeral}},
+				{Text: &anthropicschema.TextBlockParam{Text: "Question?", Type: "text"}},
+			}}},
+			{Role: anthropicschema.MessageRoleAssistant, Content: anthropicschema.MessageContent{Array: []anthropicschema.ContentBlockParam{
+				{ToolUse: &anthropicschema.ToolUseBlockParam{Type: "tool_use", ID: "tool-1", Name: "search", Input: map[string]any{}, CacheControl: ephemeral}},
+			}}},
+			{Role: anthropicschema.MessageRoleUser, Content: anthropicschema.MessageContent{Array: []anthropicschema.ContentBlockParam{
+				{ToolResult: &anthropicschema.ToolResultBlockParam{Type: "tool_result", ToolUseID: "tool-1", CacheControl: ephemeral}},
+			}}},
+		},
+	}
+	rawBody, _ := json.Marshal(req)
+	_, body, err := translator.RequestBody(rawBody, req, false)
+	require.NoError(t, err)
+
+	var bedrockReq awsbedrock.ConverseInput
+	require.NoError(t, json.Unmarshal(body, &bedrockReq))
+	cachePoint := &awsbedrock.CachePointBlock{Type: "default"}
+
+	require.Len(t, bedrockReq.System, 3)
+	assert.Equal(t, "Long instructions.", *bedrockReq.System[0].Text)
+	assert.Equal(t, cachePoint, bedrockReq.System[1].CachePoint)
+	assert.Equal(t, "Uncached instructions.", *bedrockReq.System[2].Text)
+
+	require.Len(t, bedrockReq.ToolConfig.Tools, 2)
+	assert.Equal(t, "search", *bedrockReq.ToolConfig.Tools[0].ToolSpec.Name)
+	assert.Equal(t, cachePoint, bedrockReq.ToolConfig.Tools[1].CachePoint)
+
+	require.Len(t, bedrockReq.Messages, 3)
+	require.Len(t, bedrockReq.Messages[0].Content, 3)
+	assert.Equal(t, "Long document.", *bedrockReq.Messages[0].Content[0].Text)
+	assert.Equal(t, cachePoint, bedrockReq.Messages[0].Content[1].CachePoint)
+	assert.Equal(t, "Question?", *bedrockReq.Messages[0].Content[2].Text)
+	require.Len(t, bedrockReq.Messages[1].Content, 2)
+	assert.NotNil(t, bedrockReq.Messages[1].Content[0].ToolUse)
+	assert.Equal(t, cachePoint, bedrockReq.Messages[1].Content[1].CachePoint)
+	require.Len(t, bedrockReq.Messages[2].Content, 2)
+	assert.NotNil(t, bedrockReq.Messages[2].Content[0].ToolResult)
+	assert.Equal(t, cachePoint, bedrockReq.Messages[2].Content[1].CachePoint)
+}
+
 func TestAnthropicToAWSBedrockTranslator_RequestBody_Tools(t *testing.T) {
 	translator := NewAnthropicToAWSBedrockTranslator("")
 	temp := 0.7
@@ -0,0 +1,310 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"maps"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	anthropicVertex "github.com/anthropics/anthropic-sdk-go/vertex"
+	"github.com/tidwall/sjson"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/anthropic"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/awsbedrock"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai/tokenize"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+	"github.com/envoyproxy/ai-gateway/internal/json"
+	"github.com/envoyproxy/ai-gateway/internal/metrics"
+	"github.com/envoyproxy/ai-gateway/internal/tracing/tracingapi"
+)
+
+// NewAnthropicCountTokensToAnthropicTranslator creates a passthrough translator for the Anthropic count tokens API.
+// The prefix defaults to "v1" via schemaToFilterAPI, producing "/v1/messages/count_tokens".
+func NewAnthropicCountTokensToAnthropicTranslator(prefix string, modelNameOverride internalapi.ModelNameOverride) AnthropicCountTokensTranslator {
+	return &anthropicCountTokensToAnthropicTranslator{
+		modelNameOverride: modelNameOverride,
+		path:              path.Join("/", prefix, "messages/count_tokens"),
+	}
+}
+
+type anthropicCountTokensToAnthropicTranslator struct {
+	modelNameOverride internalapi.ModelNameOverride
+	path              string
+	requestModel      internalapi.RequestModel
+}
+
+// RequestBody implements [AnthropicCountTokensTranslator.RequestBody].
+func (a *anthropicCountTokensToAnthropicTranslator) RequestBody(original []byte, body *anthropic.MessagesRequest, forceBodyMutation bool) (
+	newHeaders []internalapi.Header, newBody []byte, err error,
+) {
+	a.requestModel = body.Model
+	if a.modelNameOverride != "" {
+		newBody, err = sjson.SetBytesOptions(original, "model", a.modelNameOverride, sjsonOptions)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to set model name: %w", err)
+		}
+		a.requestModel = a.modelNameOverride
+	}
+
+	if forceBodyMutation && len(newBody) == 0 {
+		newBody = original
+	}
+
+	newHeaders = []internalapi.Header{{pathHeaderName, a.path}}
+	if len(newBody) > 0 {
+		newHeaders = append(newHeaders, internalapi.Header{contentLengthHeaderName, strconv.Itoa(len(newBody))})
+	}
+	return
+}
+
+// ResponseHeaders implements [AnthropicCountTokensTranslator.ResponseHeaders].
+func (a *anthropicCountTokensToAnthropicTranslator) ResponseHeaders(map[string]string) (newHeaders []internalapi.Header, err error) {
+	return nil, nil
+}
+
+// ResponseBody implements [AnthropicCountTokensTranslator.ResponseBody].
+// The response is passed through unchanged. It does not contain a model field, so the request model is used
+// for metrics and tracing. The counted tokens are not reported as token usage, since nothing is generated.
+func (a *anthropicCountTokensToAnthropicTranslator) ResponseBody(_ map[string]string, body io.Reader, _ bool, span tracingapi.CountTokensSpan) (
+	newHeaders []internalapi.Header, newBody []byte, tokenUsage metrics.TokenUsage, responseModel string, err error,
+) {
+	resp := &anthropic.CountTokensResponse{}
+	if err = json.NewDecoder(body).Decode(resp); err != nil {
+		return nil, nil, tokenUsage, responseModel, fmt.Errorf("failed to unmarshal body: %w", err)
+	}
+	responseModel = a.requestModel
+	if span != nil {
+		span.RecordResponse(resp)
+	}
+	return
+}
+
+// ResponseError implements [AnthropicCountTokensTranslator.ResponseError].
+// The errors of the count tokens API are in the same format as the ones of the messages API.
+func (a *anthropicCountTokensToAnthropicTranslator) ResponseError(respHeaders map[string]string, body io.Reader) (
+	newHeaders []internalapi.Header, newBody []byte, err error,
+) {
+	return (&anthropicToAnthropicTranslator{}).ResponseError(respHeaders, body)
+}
+
+// NewAnthropicCountTokensToGCPAnthropicTranslator creates a translator for the Anthropic count tokens API
+// to the count tokens API of GCP Vertex AI Anthropic.
+func NewAnthropicCountTokensToGCPAnthropicTranslator(apiVersion string, modelNameOverride internalapi.ModelNameOverride) AnthropicCountTokensTranslator {
+	return &anthropicCountTokensToGCPAnthropicTranslator{
+		anthropicCountTokensToAnthropicTranslator: anthropicCountTokensToAnthropicTranslator{modelNameOverride: modelNameOverride},
+		apiVersion: apiVersion,
+	}
+}
+
+type anthropicCountTokensToGCPAnthropicTranslator struct {
+	anthropicCountTokensToAnthropicTranslator
+	apiVersion string
+}
+
+// RequestBody implements [AnthropicCountTokensTranslator.RequestBody] for GCP Anthropic.
+func (a *anthropicCountTokensToGCPAnthropicTranslator) RequestBody(original []byte, body *anthropic.MessagesRequest, _ bool) (
+	newHeaders []internalapi.Header, newBody []byte, err error,
+) {
+	// GCP Vertex AI's count-tokens endpoint does not accept "@default" or "@latest"
+	// version aliases, same as for the tokenize requests.
+	a.requestModel = cmp.Or(a.modelNameOverride, body.Model)
+	a.requestModel = strings.TrimSuffix(a.requestModel, "@default")
+	a.requestModel = strings.TrimSuffix(a.requestModel, "@latest")
+
+	// Unlike the messages API, the model is specified in the body while the path uses "count-tokens"
+	// as a virtual model name.
+	// See: https://cloud.google.com/vertex-ai/generative-ai/docs/partner-models/claude/count-tokens
+	newBody, err = sjson.SetBytesOptions(original, "model", a.requestModel, sjsonOptions)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to set model name: %w", err)
+	}
+	newBody, err = sjson.SetBytesOptions(newBody, anthropicVersionKey, cmp.Or(a.apiVersion, anthropicVertex.DefaultVersion), sjsonOptionsInPlace)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to set anthropic_version: %w", err)
+	}
+
+	newHeaders = []internalapi.Header{
+		{pathHeaderName, buildGCPModelPathSuffix(gcpModelPublisherAnthropic, "count-tokens", gcpMethodRawPredict)},
+		{contentLengthHeaderName, strconv.Itoa(len(newBody))},
+	}
+	return
+}
+
+// NewAnthropicCountTokensToAWSAnthropicTranslator creates a translator for the Anthropic count tokens API
+// to AWS Bedrock CountTokens API using the InvokeModel format.
+func NewAnthropicCountTokensToAWSAnthropicTranslator(apiVersion string, modelNameOverride internalapi.ModelNameOverride) AnthropicCountTokensTranslator {
+	return &anthropicCountTokensToAWSAnthropicTranslator{
+		anthropicCountTokensToAnthropicTranslator: anthropicCountTokensToAnthropicTranslator{modelNameOverride: modelNameOverride},
+		apiVersion: apiVersion,
+	}
+}
+
+type anthropicCountTokensToAWSAnthropicTranslator struct {
+	anthropicCountTokensToAnthropicTranslator
+	apiVersion string
+}
+
+// RequestBody implements [AnthropicCountTokensTranslator.RequestBody] for AWS Anthropic.
+func (a *anthropicCountTokensToAWSAnthropicTranslator) RequestBody(original []byte, body *anthropic.MessagesRequest, _ bool) (
+	newHeaders []internalapi.Header, newBody []byte, err error,
+) {
+	a.requestModel = cmp.Or(a.modelNameOverride, body.Model)
+
+	anthropicBody, err := sjson.SetBytesOptions(original, anthropicVersionKey, cmp.Or(a.apiVersion, BedrockDefaultVersion), sjsonOptions)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to set anthropic_version: %w", err)
+	}
+	// Bedrock validates the InvokeModel body as a real messages request, which requires max_tokens
+	// while the count tokens requests don't have it. It doesn't affect the count.
+	anthropicBody, err = sjson.SetBytesOptions(anthropicBody, "max_tokens", 1, sjsonOptionsInPlace)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to set max_tokens: %w", err)
+	}
+	anthropicBody, _ = sjson.DeleteBytesOptions(anthropicBody, "model", sjsonOptionsInPlace)
+
+	countTokensReq := &awsbedrock.CountTokensInvokeModelRequest{}
+	countTokensReq.Input.InvokeModel.Body = base64.StdEncoding.EncodeToString(anthropicBody)
+	newBody, err = json.Marshal(countTokensReq)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error marshaling count tokens request: %w", err)
+	}
+
+	// The CountTokens API does not support the cross-region inference model IDs, so the geography prefix
+	// is dropped the same way as for the tokenize requests.
+	pathModel := a.requestModel
+	if i := strings.Index(pathModel, "anthropic."); i > 0 {
+		pathModel = pathModel[i:]
+	}
+	newHeaders = []internalapi.Header{
+		{pathHeaderName, fmt.Sprintf("/model/%s/count-tokens", url.PathEscape(pathModel))},
+		{contentLengthHeaderName, strconv.Itoa(len(newBody))},
+	}
+	return
+}
+
+// ResponseBody implements [AnthropicCountTokensTranslator.ResponseBody] for AWS Anthropic.
+func (a *anthropicCountTokensToAWSAnthropicTranslator) ResponseBody(_ map[string]string, body io.Reader, _ bool, span tracingapi.CountTokensSpan) (
+	newHeaders []internalapi.Header, newBody []byte, tokenUsage metrics.TokenUsage, responseModel string, err error,
+) {
+	bedrockResp := &awsbedrock.CountTokensResponse{}
+	if err = json.NewDecoder(body).Decode(bedrockResp); err != nil {
+		return nil, nil, tokenUsage, responseModel, fmt.Errorf("failed to unmarshal body: %w", err)
+	}
+	responseModel = a.requestModel
+	resp := &anthropic.CountTokensResponse{InputTokens: int64(bedrockResp.InputTokens)}
+	newBody, err = json.Marshal(resp)
+	if err != nil {
+		return nil, nil, tokenUsage, responseModel, fmt.Errorf("error marshaling response: %w", err)
+	}
+	if span != nil {
+		span.RecordResponse(resp)
+	}
+	newHeaders = []internalapi.Header{{contentLengthHeaderName, strconv.Itoa(len(newBody))}}
+	return
+}
+
+// NewAnthropicCountTokensToTokenizeTranslator creates a translator emulating the Anthropic count tokens API
+// with the given tokenize translator, for the backends without a native count tokens API.
+// The request is converted to a chat tokenize request the same way as the messages are converted to
+// the chat completion requests, so the count is that of the tokenizer of the backend.
+func NewAnthropicCountTokensToTokenizeTranslator(tokenizeTranslator TokenizeTranslator, modelNameOverride internalapi.ModelNameOverride) AnthropicCountTokensTranslator {
+	return &anthropicCountTokensToTokenizeTranslator{tokenizeTranslator: tokenizeTranslator, modelNameOverride: modelNameOverride}
+}
+
+type anthropicCountTokensToTokenizeTranslator struct {
+	tokenizeTranslator TokenizeTranslator
+	modelNameOverride  internalapi.ModelNameOverride
+	requestModel       internalapi.RequestModel
+}
+
+// RequestBody implements [AnthropicCountTokensTranslator.RequestBody].
+func (a *anthropicCountTokensToTokenizeTranslator) RequestBody(_ []byte, body *anthropic.MessagesRequest, _ bool) (
+	newHeaders []internalapi.Header, newBody []byte, err error,
+) {
+	a.requestModel = cmp.Or(a.modelNameOverride, body.Model)
+
+	// The tokenize translator applies the model name override.
+	chatReq := buildOpenAIChatCompletionRequest(body, "")
+	tokenizeReq := &tokenize.RequestUnion{ChatRequest: &tokenize.ChatRequest{
+		Model:    chatReq.Model,
+		Messages: chatReq.Messages,
+		Tools:    chatReq.Tools,
+	}}
+	tokenizeBody, err := json.Marshal(tokenizeReq.ChatRequest)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal tokenize request: %w", err)
+	}
+	// The body is always mutated since the original one is in the Anthropic format.
+	return a.tokenizeTranslator.RequestBody(tokenizeBody, tokenizeReq, true)
+}
+
+// ResponseHeaders implements [AnthropicCountTokensTranslator.ResponseHeaders].
+func (a *anthropicCountTokensToTokenizeTranslator) ResponseHeaders(headers map[string]string) (newHeaders []internalapi.Header, err error) {
+	return a.tokenizeTranslator.ResponseHeaders(headers)
+}
+
+// ResponseBody implements [AnthropicCountTokensTranslator.ResponseBody].
+func (a *anthropicCountTokensToTokenizeTranslator) ResponseBody(respHeaders map[string]string, body io.Reader, endOfStream bool, span tracingapi.CountTokensSpan) (
+	newHeaders []internalapi.Header, newBody []byte, tokenUsage metrics.TokenUsage, responseModel string, err error,
+) {
+	buf, err := io.ReadAll(body)
+	if err != nil {
+		return nil, nil, tokenUsage, responseModel, fmt.Errorf("failed to read body: %w", err)
+	}
+	_, tokenizeBody, _, _, err := a.tokenizeTranslator.ResponseBody(respHeaders, bytes.NewReader(buf), endOfStream, nil)
+	if err != nil {
+		return nil, nil, tokenUsage, responseModel, err
+	}
+	// The tokenize translators passing the response through don't return a new body.
+	if tokenizeBody == nil {
+		tokenizeBody = buf
+	}
+	tokenizeResp := &tokenize.Response{}
+	if err = json.Unmarshal(tokenizeBody, tokenizeResp); err != nil {
+		return nil, nil, tokenUsage, responseModel, fmt.Errorf("failed to unmarshal tokenize response: %w", err)
+	}
+
+	responseModel = a.requestModel
+	resp := &anthropic.CountTokensResponse{InputTokens: int64(tokenizeResp.Count)}
+	newBody, err = json.Marshal(resp)
+	if err != nil {
+		return nil, nil, tokenUsage, responseModel, fmt.Errorf("error marshaling response: %w", err)
+	}
+	if span != nil {
+		span.RecordResponse(resp)
+	}
+	newHeaders = []internalapi.Header{{contentLengthHeaderName, strconv.Itoa(len(newBody))}}
+	return
+}
+
+// ResponseError implements [AnthropicCountTokensTranslator.ResponseError].
+// The errors are first translated to the OpenAI format by the tokenize translator, then to the Anthropic format.
+func (a *anthropicCountTokensToTokenizeTranslator) ResponseError(respHeaders map[string]string, body io.Reader) (
+	newHeaders []internalapi.Header, newBody []byte, err error,
+) {
+	buf, err := io.ReadAll(body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read error body: %w", err)
+	}
+	_, openAIError, err := a.tokenizeTranslator.ResponseError(respHeaders, bytes.NewReader(buf))
+	if err != nil {
+		return nil, nil, err
+	}
+	if openAIError != nil {
+		buf = openAIError
+		respHeaders = maps.Clone(respHeaders)
+		respHeaders[contentTypeHeaderName] = jsonContentType
+	}
+	return openAIErrorToAnthropic(respHeaders, bytes.NewReader(buf))
+}
@@ -0,0 +1,155 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	anthropicVertex "github.com/anthropics/anthropic-sdk-go/vertex"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/anthropic"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+)
+
+const countTokensTestBody = `{"model":"claude-sonnet-4","system":"Be brief.","messages":[{"role":"user","content":"Hello"}]}`
+
+func countTokensTestRequest() *anthropic.MessagesRequest {
+	return &anthropic.MessagesRequest{
+		Model:    "claude-sonnet-4",
+		System:   &anthropic.SystemPrompt{Text: "Be brief."},
+		Messages: []anthropic.MessageParam{{Role: anthropic.MessageRoleUser, Content: anthropic.MessageContent{Text: "Hello"}}},
+	}
+}
+
+func TestAnthropicCountTokensToAnthropicTranslator(t *testing.T) {
+	t.Run("passthrough", func(t *testing.T) {
+		translator := NewAnthropicCountTokensToAnthropicTranslator("v1", "")
+		headers, body, err := translator.RequestBody([]byte(countTokensTestBody), countTokensTestRequest(), false)
+		require.NoError(t, err)
+		require.Equal(t, []internalapi.Header{{pathHeaderName, "/v1/messages/count_tokens"}}, headers)
+		require.Nil(t, body)
+
+		headers, body, _, responseModel, err := translator.ResponseBody(nil, strings.NewReader(`{"input_tokens":14}`), true, nil)
+		require.NoError(t, err)
+		require.Nil(t, headers)
+		require.Nil(t, body)
+		require.Equal(t, "claude-sonnet-4", responseModel)
+	})
+
+	t.Run("model override", func(t *testing.T) {
+		translator := NewAnthropicCountTokensToAnthropicTranslator("", "claude-opus-4")
+		headers, body, err := translator.RequestBody([]byte(countTokensTestBody), countTokensTestRequest(), false)
+		require.NoError(t, err)
+		require.Equal(t, "/messages/count_tokens", headers[0].Value())
+		require.Equal(t, "claude-opus-4", gjson.GetBytes(body, "model").String())
+
+		_, _, _, responseModel, err := translator.ResponseBody(nil, strings.NewReader(`{"input_tokens":14}`), true, nil)
+		require.NoError(t, err)
+		require.Equal(t, "claude-opus-4", responseModel)
+	})
+
+	t.Run("invalid response", func(t *testing.T) {
+		translator := NewAnthropicCountTokensToAnthropicTranslator("v1", "")
+		_, _, _, _, err := translator.ResponseBody(nil, strings.NewReader("not json"), true, nil)
+		require.ErrorContains(t, err, "failed to unmarshal body")
+	})
+
+	t.Run("error", func(t *testing.T) {
+		translator := NewAnthropicCountTokensToAnthropicTranslator("v1", "")
+		_, body, err := translator.ResponseError(map[string]string{statusHeaderName: "429"}, strings.NewReader("slow down"))
+		require.NoError(t, err)
+		require.JSONEq(t, `{"type":"error","request_id":"","error":{"type":"rate_limit_error","message":"slow down"}}`, string(body))
+	})
+}
+
+func TestAnthropicCountTokensToGCPAnthropicTranslator(t *testing.T) {
+	translator := NewAnthropicCountTokensToGCPAnthropicTranslator("", "claude-sonnet-4@default")
+	headers, body, err := translator.RequestBody([]byte(countTokensTestBody), countTokensTestRequest(), false)
+	require.NoError(t, err)
+	require.Equal(t, "publishers/anthropic/models/count-tokens:rawPredict", headers[0].Value())
+	require.Equal(t, "claude-sonnet-4", gjson.GetBytes(body, "model").String())
+	require.Equal(t, anthropicVertex.DefaultVersion, gjson.GetBytes(body, anthropicVersionKey).String())
+	require.Equal(t, "Hello", gjson.GetBytes(body, "messages.0.content").String())
+
+	// GCP Anthropic returns the native count tokens response.
+	_, newBody, _, responseModel, err := translator.ResponseBody(nil, strings.NewReader(`{"input_tokens":14}`), true, nil)
+	require.NoError(t, err)
+	require.Nil(t, newBody)
+	require.Equal(t, "claude-sonnet-4", responseModel)
+}
+
+func TestAnthropicCountTokensToAWSAnthropicTranslator(t *testing.T) {
+	translator := NewAnthropicCountTokensToAWSAnthropicTranslator("", "us.anthropic.claude-sonnet-4-v1:0")
+	headers, body, err := translator.RequestBody([]byte(countTokensTestBody), countTokensTestRequest(), false)
+	require.NoError(t, err)
+	require.Equal(t, "/model/anthropic.claude-sonnet-4-v1:0/count-tokens", headers[0].Value())
+
+	invokeModelBody, err := base64.StdEncoding.DecodeString(gjson.GetBytes(body, "input.invokeModel.body").String())
+	require.NoError(t, err)
+	require.JSONEq(t, `{"anthropic_version":"bedrock-2023-05-31","max_tokens":1,"system":"Be brief.","messages":[{"role":"user","content":"Hello"}]}`, string(invokeModelBody))
+
+	headers, newBody, _, responseModel, err := translator.ResponseBody(nil, strings.NewReader(`{"inputTokens":14}`), true, nil)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"input_tokens":14}`, string(newBody))
+	require.Equal(t, []internalapi.Header{{contentLengthHeaderName, "19"}}, headers)
+	require.Equal(t, "us.anthropic.claude-sonnet-4-v1:0", responseModel)
+}
+
+func TestAnthropicCountTokensToTokenizeTranslator(t *testing.T) {
+	t.Run("openai", func(t *testing.T) {
+		translator := NewAnthropicCountTokensToTokenizeTranslator(NewTokenizeTranslator("gpt-4o"), "gpt-4o")
+		headers, body, err := translator.RequestBody([]byte(countTokensTestBody), countTokensTestRequest(), false)
+		require.NoError(t, err)
+		require.Equal(t, "/tokenize", headers[0].Value())
+		require.JSONEq(t, `{"model":"gpt-4o","messages":[{"role":"system","content":"Be brief."},{"role":"user","content":"Hello"}]}`, string(body))
+
+		headers, newBody, _, responseModel, err := translator.ResponseBody(nil, strings.NewReader(`{"count":12,"max_model_len":128000,"tokens":[1,2]}`), true, nil)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"input_tokens":12}`, string(newBody))
+		require.Equal(t, []internalapi.Header{{contentLengthHeaderName, "19"}}, headers)
+		require.Equal(t, "gpt-4o", responseModel)
+	})
+
+	t.Run("gcp vertex ai", func(t *testing.T) {
+		translator := NewAnthropicCountTokensToTokenizeTranslator(NewTokenizeToGCPVertexAITranslator(""), "")
+		headers, _, err := translator.RequestBody([]byte(countTokensTestBody), countTokensTestRequest(), false)
+		require.NoError(t, err)
+		require.Contains(t, headers[0].Value(), "claude-sonnet-4:countTokens")
+
+		_, newBody, _, responseModel, err := translator.ResponseBody(nil, strings.NewReader(`{"totalTokens":9}`), true, nil)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"input_tokens":9}`, string(newBody))
+		require.Equal(t, "claude-sonnet-4", responseModel)
+	})
+
+	t.Run("invalid response", func(t *testing.T) {
+		translator := NewAnthropicCountTokensToTokenizeTranslator(NewTokenizeTranslator(""), "")
+		_, _, err := translator.RequestBody(nil, countTokensTestRequest(), false)
+		require.NoError(t, err)
+		_, _, _, _, err = translator.ResponseBody(nil, bytes.NewReader([]byte("not json")), true, nil)
+		require.ErrorContains(t, err, "failed to unmarshal body")
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		translator := NewAnthropicCountTokensToTokenizeTranslator(NewTokenizeTranslator(""), "")
+		// The JSON errors of OpenAI are translated to the Anthropic format.
+		_, body, err := translator.ResponseError(map[string]string{statusHeaderName: "400", contentTypeHeaderName: jsonContentType},
+			strings.NewReader(`{"type":"error","error":{"type":"invalid_request_error","message":"bad model"}}`))
+		require.NoError(t, err)
+		require.JSONEq(t, `{"type":"error","request_id":"","error":{"type":"invalid_request_error","message":"bad model"}}`, string(body))
+
+		// The other errors are first wrapped in the OpenAI format by the tokenize translator.
+		_, body, err = translator.ResponseError(map[string]string{statusHeaderName: "503"}, strings.NewReader("unavailable"))
+		require.NoError(t, err)
+		require.Equal(t, "unavailable", gjson.GetBytes(body, "error.message").String())
+		require.Equal(t, "error", gjson.GetBytes(body, "type").String())
+	})
+}
@@ -797,6 +797,8 @@ var (
 
 // streamingToolCall holds the state for a single tool call that is being streamed.
 type streamingToolCall struct {
+	// index is the OpenAI tool call index assigned to this tool call.
+	index     int64
 	id        string
 	name      string
 	inputJSON string
@@ -807,13 +809,17 @@ type streamingToolCall struct {
 type anthropicStreamParser struct {
 	buffer          bytes.Buffer
 	activeMessageID string
+	// activeToolCalls maps the Anthropic content block index to the tool call being streamed in that block.
+	// Anthropic content block indexes count all the blocks (text, thinking, etc.), whereas OpenAI tool call
+	// indexes only count the tool calls, so the deltas must be routed by the content block index.
 	activeToolCalls map[int64]*streamingToolCall
-	toolIndex       int64
-	tokenUsage      metrics.TokenUsage
-	stopReason      anthropic.StopReason
-	requestModel    internalapi.RequestModel
-	sentFirstChunk  bool
-	created         openai.JSONUNIXTime
+	// toolIndex is the OpenAI index of the last tool call started in the current message.
+	toolIndex      int64
+	tokenUsage     metrics.TokenUsage
+	stopReason     anthropic.StopReason
+	requestModel   internalapi.RequestModel
+	sentFirstChunk bool
+	created        openai.JSONUNIXTime
 }
 
 // newAnthropicStreamParser creates a new parser for a streaming request.
@@ -965,30 +971,10 @@ func (p *anthropicStreamParser) Process(body io.Reader, endOfStream bool, span t
 			Model: p.requestModel,
 		}
 
-		// Add active tool calls to the final chunk.
-		var toolCalls []openai.ChatCompletionChunkChoiceDeltaToolCall
-		for toolIndex, tool := range p.activeToolCalls {
-			toolCalls = append(toolCalls, openai.ChatCompletionChunkChoiceDeltaToolCall{
-				ID:   &tool.id,
-				Type: openai.ChatCompletionMessageToolCallTypeFunction,
-				Function: openai.ChatCompletionMessageToolCallFunctionParam{
-					Name:      tool.name,
-					Arguments: tool.inputJSON,
-				},
-				Index: toolIndex,
-			})
-		}
-
-		if len(toolCalls) > 0 {
-			delta := openai.ChatCompletionResponseChunkChoiceDelta{
-				ToolCalls: toolCalls,
-			}
-			finalChunk.Choices = append(finalChunk.Choices, openai.ChatCompletionResponseChunkChoice{
-				Delta: &delta,
-			})
-		}
-
-		if finalChunk.Usage.PromptTokens > 0 || finalChunk.Usage.CompletionTokens > 0 || len(finalChunk.Choices) > 0 {
+		// Note that the tool calls without content_block_stop are not re-sent here: their names and
+		// arguments have already been streamed as deltas, and re-sending them would duplicate the
+		// arguments on the client side which concatenates the deltas per tool call index.
+		if finalChunk.Usage.PromptTokens > 0 || finalChunk.Usage.CompletionTokens > 0 {
 			err := serializeOpenAIChatCompletionChunk(&finalChunk, &newBody)
 			if err != nil {
 				return nil, nil, metrics.TokenUsage{}, "", fmt.Errorf("failed to marshal final stream chunk: %w", err)
@@ -1054,8 +1040,9 @@ func (p *anthropicStreamParser) handleAnthropicStreamEvent(eventType []byte, dat
 			p.tokenUsage.SetCacheCreationInputTokens(cacheCreation)
 		}
 
-		// reset the toolIndex for each message
+		// reset the tool call state for each message
 		p.toolIndex = -1
+		clear(p.activeToolCalls)
 		return nil, nil
 
 	case string(constant.ValueOf[constant.ContentBlockStart]()):
@@ -1087,7 +1074,8 @@ func (p *anthropicStreamParser) handleAnthropicStreamEvent(eventType []byte, dat
 			}
 
 			// Store the complete input JSON in our state.
-			p.activeToolCalls[p.toolIndex] = &streamingToolCall{
+			p.activeToolCalls[event.Index] = &streamingToolCall{
+				index:     p.toolIndex,
 				id:        event.ContentBlock.ID,
 				name:      event.ContentBlock.Name,
 				inputJSON: argsJSON,
@@ -1167,14 +1155,19 @@ func (p *anthropicStreamParser) handleAnthropicStreamEvent(eventType []byte, dat
 			delta := openai.ChatCompletionResponseChunkChoiceDelta{Content: &event.Delta.Text}
 			return p.constructOpenAIChatCompletionChunk(delta, ""), nil
 		case string(constant.ValueOf[constant.InputJSONDelta]()):
-			tool, ok := p.activeToolCalls[p.toolIndex]
+			tool, ok := p.activeToolCalls[event.Index]
 			if !ok {
-				return nil, fmt.Errorf("received input_json_delta for unknown tool at index %d", p.toolIndex)
+				return nil, fmt.Errorf("received input_json_delta for unknown tool at content block index %d", event.Index)
+			}
+			if event.Delta.PartialJSON == "" {
+				// Anthropic may send an empty partial_json, e.g., as the first delta of a tool call.
+				// Skip it since the empty arguments delta carries no information.
+				return nil, nil
 			}
 			delta := openai.ChatCompletionResponseChunkChoiceDelta{
 				ToolCalls: []openai.ChatCompletionChunkChoiceDeltaToolCall{
 					{
-						Index: p.toolIndex,
+						Index: tool.index,
 						Function: openai.ChatCompletionMessageToolCallFunctionParam{
 							Arguments: event.Delta.PartialJSON,
 						},
@@ -1191,7 +1184,7 @@ func (p *anthropicStreamParser) handleAnthropicStreamEvent(eventType []byte, dat
 		if err := json.Unmarshal(data, &event); err != nil {
 			return nil, fmt.Errorf("unmarshal content_block_stop: %w", err)
 		}
-		delete(p.activeToolCalls, p.toolIndex)
+		delete(p.activeToolCalls, event.Index)
 		return nil, nil
 
 	case string(constant.ValueOf[constant.MessageStop]()):
@@ -7,6 +7,8 @@ package translator
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -18,6 +20,7 @@ import (
 
 	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
 	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+	"github.com/envoyproxy/ai-gateway/internal/json"
 	"github.com/envoyproxy/ai-gateway/internal/metrics"
 )
 
@@ -493,6 +496,11 @@ func TestFinishReasonTranslation(t *testing.T) {
 			input:                anthropic.StopReasonRefusal,
 			expectedFinishReason: openai.ChatCompletionChoicesFinishReasonContentFilter,
 		},
+		{
+			name:                 "tool use stop reason",
+			input:                anthropic.StopReasonToolUse,
+			expectedFinishReason: openai.ChatCompletionChoicesFinishReasonToolCalls,
+		},
 	}
 
 	for _, tt := range tests {
@@ -1742,3 +1750,97 @@ func TestOpenAIToAnthropicMessages_ToolResultCacheControl(t *testing.T) {
 		require.Equal(t, ephemeral, msgs[0].Content[0].OfToolResult.CacheControl)
 	})
 }
+
+// anthropicToolUseConformanceResult is the OpenAI message assembled from the stream chunks the same way
+// as the OpenAI clients do, i.e. by concatenating the deltas per tool call index.
+type anthropicToolUseConformanceResult struct {
+	Content      string                                   `json:"content,omitempty"`
+	FinishReason openai.ChatCompletionChoicesFinishReason `json:"finish_reason"`
+	ToolCalls    []anthropicToolUseConformanceToolCall    `json:"tool_calls"`
+}
+
+type anthropicToolUseConformanceToolCall struct {
+	Index     int64  `json:"index"`
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+// TestAnthropicStreamParser_ToolUseConformance runs the Anthropic streams in testdata/anthropic_tool_use/*.sse
+// through the parser, and compares the assembled OpenAI message with the corresponding *.json file.
+func TestAnthropicStreamParser_ToolUseConformance(t *testing.T) {
+	const dir = "testdata/anthropic_tool_use"
+	files, err := filepath.Glob(filepath.Join(dir, "*.sse"))
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".sse")
+		t.Run(name, func(t *testing.T) {
+			events, err := os.ReadFile(file)
+			require.NoError(t, err)
+			expected, err := os.ReadFile(filepath.Join(dir, name+".json"))
+			require.NoError(t, err)
+
+			parser := newAnthropicStreamParser("claude-sonnet-4-6")
+			var body []byte
+			chunks := splitSSEEvents(string(events))
+			for i, chunk := range chunks {
+				_, b, _, _, err := parser.Process(strings.NewReader(chunk), i == len(chunks)-1, nil)
+				require.NoError(t, err)
+				body = append(body, b...)
+			}
+
+			var actual anthropicToolUseConformanceResult
+			for event := range strings.SplitSeq(string(body), "\n\n") {
+				data, ok := strings.CutPrefix(event, string(sseDataPrefix))
+				if !ok || data == string(sseDoneMessage) {
+					continue
+				}
+				var chunk openai.ChatCompletionResponseChunk
+				require.NoError(t, json.Unmarshal([]byte(data), &chunk))
+				for _, choice := range chunk.Choices {
+					if choice.FinishReason != "" {
+						require.Empty(t, actual.FinishReason, "finish_reason must be sent only once")
+						actual.FinishReason = choice.FinishReason
+					}
+					if choice.Delta == nil {
+						continue
+					}
+					if choice.Delta.Content != nil {
+						actual.Content += *choice.Delta.Content
+					}
+					for _, tc := range choice.Delta.ToolCalls {
+						if tc.Index == int64(len(actual.ToolCalls)) {
+							// The first delta of a tool call carries its ID and name.
+							require.NotNil(t, tc.ID)
+							require.NotEmpty(t, tc.Function.Name)
+							actual.ToolCalls = append(actual.ToolCalls, anthropicToolUseConformanceToolCall{
+								Index: tc.Index, ID: *tc.ID, Name: tc.Function.Name,
+							})
+						} else {
+							require.Less(t, tc.Index, int64(len(actual.ToolCalls)), "tool call indexes must be contiguous")
+							require.Nil(t, tc.ID)
+							require.Empty(t, tc.Function.Name)
+						}
+						actual.ToolCalls[tc.Index].Arguments += tc.Function.Arguments
+					}
+				}
+			}
+			actualJSON, err := json.Marshal(actual)
+			require.NoError(t, err)
+			require.JSONEq(t, string(expected), string(actualJSON))
+		})
+	}
+}
+
+func TestAnthropicStreamParser_InputJSONDeltaForUnknownBlock(t *testing.T) {
+	parser := newAnthropicStreamParser("claude-sonnet-4-6")
+	_, _, _, _, err := parser.Process(strings.NewReader(`event: content_block_start
+data: {"type":"content_block_start","index":0,"content_block":{"type":"tool_use","id":"toolu_01","name":"get_weather","input":{}}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{}"}}
+
+`), false, nil)
+	require.ErrorContains(t, err, "received input_json_delta for unknown tool at content block index 1")
+}
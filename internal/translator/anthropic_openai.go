@@ -188,6 +188,15 @@ func (a *anthropicToOpenAIV1ChatCompletionTranslator) ResponseError(respHeaders
 	newHeaders []internalapi.Header,
 	mutatedBody []byte,
 	err error,
+) {
+	return openAIErrorToAnthropic(respHeaders, r)
+}
+
+// openAIErrorToAnthropic translates an error response of an OpenAI backend to the Anthropic error format.
+func openAIErrorToAnthropic(respHeaders map[string]string, r io.Reader) (
+	newHeaders []internalapi.Header,
+	mutatedBody []byte,
+	err error,
 ) {
 	statusCode := respHeaders[statusHeaderName]
 	var anthropicError anthropic.ErrorResponse
@@ -0,0 +1,136 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/awsbedrock"
+	cohereschema "github.com/envoyproxy/ai-gateway/internal/apischema/cohere"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+	"github.com/envoyproxy/ai-gateway/internal/json"
+	"github.com/envoyproxy/ai-gateway/internal/metrics"
+	"github.com/envoyproxy/ai-gateway/internal/tracing/tracingapi"
+)
+
+const (
+	// awsBedrockInputTokenCountHeaderName is the InvokeModel response header reporting the number of input tokens.
+	awsBedrockInputTokenCountHeaderName = "x-amzn-bedrock-input-token-count"
+	// awsBedrockCohereRerankModelMarker identifies the Cohere rerank models, which require the api_version field.
+	awsBedrockCohereRerankModelMarker = "cohere.rerank"
+	// awsBedrockCohereRerankAPIVersion is the only Cohere API version supported by the Cohere rerank models.
+	awsBedrockCohereRerankAPIVersion = 2
+)
+
+// NewRerankCohereToAWSBedrockTranslator implements [Factory] for Cohere Rerank v2 to AWS Bedrock translation.
+func NewRerankCohereToAWSBedrockTranslator(modelNameOverride internalapi.ModelNameOverride) CohereRerankTranslator {
+	return &cohereToAWSBedrockTranslatorV2Rerank{modelNameOverride: modelNameOverride}
+}
+
+// cohereToAWSBedrockTranslatorV2Rerank translates Cohere Rerank v2 requests to the AWS Bedrock InvokeModel requests
+// of the rerank models, e.g. amazon.rerank-v1:0 and cohere.rerank-v3-5:0:
+// https://docs.aws.amazon.com/bedrock/latest/userguide/rerank-use.html
+type cohereToAWSBedrockTranslatorV2Rerank struct {
+	modelNameOverride internalapi.ModelNameOverride
+	requestModel      internalapi.RequestModel
+	// topN and documents are taken from the request to build the response.
+	topN      *int
+	documents int
+}
+
+// RequestBody implements [CohereRerankTranslator.RequestBody].
+func (t *cohereToAWSBedrockTranslatorV2Rerank) RequestBody(_ []byte, req *cohereschema.RerankV2Request, _ bool) (
+	newHeaders []internalapi.Header, newBody []byte, err error,
+) {
+	model := req.Model
+	if t.modelNameOverride != "" {
+		model = t.modelNameOverride
+	}
+	t.requestModel = model
+	t.topN = req.TopN
+	t.documents = len(req.Documents)
+
+	bedrockReq := awsbedrock.RerankRequest{Query: req.Query, Documents: req.Documents, TopN: req.TopN}
+	if strings.Contains(model, awsBedrockCohereRerankModelMarker) {
+		bedrockReq.APIVersion = awsBedrockCohereRerankAPIVersion
+		bedrockReq.MaxTokensPerDoc = req.MaxTokensPerDoc
+	}
+	newBody, err = json.Marshal(bedrockReq)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal body: %w", err)
+	}
+	newHeaders = []internalapi.Header{
+		{pathHeaderName, fmt.Sprintf("/model/%s/invoke", url.PathEscape(model))},
+		{contentLengthHeaderName, strconv.Itoa(len(newBody))},
+	}
+	return
+}
+
+// ResponseHeaders implements [CohereRerankTranslator.ResponseHeaders].
+func (t *cohereToAWSBedrockTranslatorV2Rerank) ResponseHeaders(map[string]string) (newHeaders []internalapi.Header, err error) {
+	return nil, nil
+}
+
+// ResponseBody implements [CohereRerankTranslator.ResponseBody].
+// The input tokens are reported by the InvokeModel response headers rather than the body.
+func (t *cohereToAWSBedrockTranslatorV2Rerank) ResponseBody(respHeaders map[string]string, body io.Reader, _ bool, span tracingapi.RerankSpan) (
+	newHeaders []internalapi.Header, newBody []byte, tokenUsage metrics.TokenUsage, responseModel internalapi.ResponseModel, err error,
+) {
+	var bedrockResp awsbedrock.RerankResponse
+	if err = json.NewDecoder(body).Decode(&bedrockResp); err != nil {
+		return nil, nil, tokenUsage, t.requestModel, fmt.Errorf("failed to unmarshal body: %w", err)
+	}
+
+	results := make([]*cohereschema.RerankV2Result, 0, len(bedrockResp.Results))
+	for _, r := range bedrockResp.Results {
+		results = append(results, &cohereschema.RerankV2Result{Index: r.Index, RelevanceScore: r.RelevanceScore})
+	}
+	resp := newRerankV2Response(results, t.topN, t.documents)
+	resp.ID = bedrockResp.ID
+	if input, parseErr := strconv.ParseUint(respHeaders[awsBedrockInputTokenCountHeaderName], 10, 32); parseErr == nil {
+		inputTokens := float64(input)
+		resp.Meta.Tokens = &cohereschema.RerankV2Tokens{InputTokens: &inputTokens}
+		tokenUsage.SetInputTokens(uint32(input)) //nolint:gosec
+		tokenUsage.SetTotalTokens(uint32(input)) //nolint:gosec
+	}
+
+	newBody, err = json.Marshal(resp)
+	if err != nil {
+		return nil, nil, tokenUsage, t.requestModel, fmt.Errorf("failed to marshal body: %w", err)
+	}
+	if span != nil {
+		span.RecordResponse(&resp)
+	}
+	newHeaders = []internalapi.Header{{contentLengthHeaderName, strconv.Itoa(len(newBody))}}
+	responseModel = t.requestModel
+	return
+}
+
+// ResponseError implements [CohereRerankTranslator.ResponseError].
+// Translates the AWS Bedrock exceptions to the Cohere error format.
+func (t *cohereToAWSBedrockTranslatorV2Rerank) ResponseError(respHeaders map[string]string, body io.Reader) (
+	newHeaders []internalapi.Header, newBody []byte, err error,
+) {
+	buf, err := io.ReadAll(body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read error body: %w", err)
+	}
+	message := string(buf)
+	if isJSON(respHeaders[contentTypeHeaderName]) {
+		var bedrockErr awsbedrock.BedrockException
+		if json.Unmarshal(buf, &bedrockErr) == nil && bedrockErr.Message != "" {
+			message = bedrockErr.Message
+		}
+	}
+	if awsErrorType := respHeaders[awsErrorTypeHeaderName]; awsErrorType != "" {
+		message = fmt.Sprintf("%s: %s", awsErrorType, message)
+	}
+	return rerankV2ErrorBody(message)
+}
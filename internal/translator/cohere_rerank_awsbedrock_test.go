@@ -0,0 +1,136 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+
+	cohereschema "github.com/envoyproxy/ai-gateway/internal/apischema/cohere"
+)
+
+func TestCohereToAWSBedrockTranslatorV2Rerank_RequestBody(t *testing.T) {
+	for _, tc := range []struct {
+		name              string
+		model             string
+		modelNameOverride string
+		expPath           string
+		expBody           string
+	}{
+		{
+			name:    "amazon",
+			model:   "amazon.rerank-v1:0",
+			expPath: "/model/amazon.rerank-v1:0/invoke",
+			expBody: `{"query":"reset password","documents":["doc1","doc2"],"top_n":1}`,
+		},
+		{
+			name:    "cohere",
+			model:   "cohere.rerank-v3-5:0",
+			expPath: "/model/cohere.rerank-v3-5:0/invoke",
+			expBody: `{"query":"reset password","documents":["doc1","doc2"],"top_n":1,"api_version":2,"max_tokens_per_doc":512}`,
+		},
+		{
+			name:              "model name override with an ARN",
+			model:             "rerank",
+			modelNameOverride: "arn:aws:bedrock:us-west-2::foundation-model/cohere.rerank-v3-5:0",
+			expPath:           "/model/arn:aws:bedrock:us-west-2::foundation-model%2Fcohere.rerank-v3-5:0/invoke",
+			expBody:           `{"query":"reset password","documents":["doc1","doc2"],"top_n":1,"api_version":2,"max_tokens_per_doc":512}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			translator := NewRerankCohereToAWSBedrockTranslator(tc.modelNameOverride)
+			req := &cohereschema.RerankV2Request{
+				Model: tc.model, Query: "reset password", Documents: []string{"doc1", "doc2"},
+				TopN: ptr.To(1), MaxTokensPerDoc: ptr.To(512),
+			}
+			headers, body, err := translator.RequestBody(nil, req, false)
+			require.NoError(t, err)
+			require.JSONEq(t, tc.expBody, string(body))
+			require.Len(t, headers, 2)
+			require.Equal(t, pathHeaderName, headers[0].Key())
+			require.Equal(t, tc.expPath, headers[0].Value())
+			require.Equal(t, contentLengthHeaderName, headers[1].Key())
+		})
+	}
+}
+
+func TestCohereToAWSBedrockTranslatorV2Rerank_ResponseBody(t *testing.T) {
+	translator := NewRerankCohereToAWSBedrockTranslator("")
+	_, _, err := translator.RequestBody(nil, &cohereschema.RerankV2Request{
+		Model: "cohere.rerank-v3-5:0", Query: "q", Documents: []string{"a", "b", "c"}, TopN: ptr.To(2),
+	}, false)
+	require.NoError(t, err)
+
+	t.Run("ok", func(t *testing.T) {
+		body := `{"id":"abc","results":[{"index":2,"relevance_score":0.2},{"index":0,"relevance_score":1.3},{"index":1,"relevance_score":0.7}]}`
+		headers, newBody, usage, model, err := translator.ResponseBody(
+			map[string]string{awsBedrockInputTokenCountHeaderName: "42"}, strings.NewReader(body), true, nil)
+		require.NoError(t, err)
+		require.JSONEq(t, `{
+			"id":"abc",
+			"results":[{"index":0,"relevance_score":1},{"index":1,"relevance_score":0.7}],
+			"meta":{"billed_units":{"search_units":1},"tokens":{"input_tokens":42}}
+		}`, string(newBody))
+		require.Len(t, headers, 1)
+		require.Equal(t, contentLengthHeaderName, headers[0].Key())
+		in, ok := usage.InputTokens()
+		require.True(t, ok)
+		require.Equal(t, uint32(42), in)
+		require.Equal(t, "cohere.rerank-v3-5:0", model)
+	})
+
+	t.Run("without the token count", func(t *testing.T) {
+		_, newBody, usage, _, err := translator.ResponseBody(nil, strings.NewReader(`{"results":[]}`), true, nil)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"results":[],"meta":{"billed_units":{"search_units":1}}}`, string(newBody))
+		_, ok := usage.InputTokens()
+		require.False(t, ok)
+	})
+
+	t.Run("invalid body", func(t *testing.T) {
+		_, _, _, _, err := translator.ResponseBody(nil, bytes.NewReader([]byte("{")), true, nil)
+		require.ErrorContains(t, err, "failed to unmarshal body")
+	})
+}
+
+func TestCohereToAWSBedrockTranslatorV2Rerank_ResponseError(t *testing.T) {
+	translator := NewRerankCohereToAWSBedrockTranslator("")
+	for _, tc := range []struct {
+		name    string
+		headers map[string]string
+		body    string
+		expBody string
+	}{
+		{
+			name: "bedrock exception",
+			headers: map[string]string{
+				statusHeaderName: "400", contentTypeHeaderName: jsonContentType, awsErrorTypeHeaderName: "ValidationException",
+			},
+			body:    `{"message":"too many documents"}`,
+			expBody: `{"message":"ValidationException: too many documents"}`,
+		},
+		{
+			name:    "plain text",
+			headers: map[string]string{statusHeaderName: "503", contentTypeHeaderName: "text/plain"},
+			body:    "service unavailable",
+			expBody: `{"message":"service unavailable"}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			headers, body, err := translator.ResponseError(tc.headers, strings.NewReader(tc.body))
+			require.NoError(t, err)
+			require.JSONEq(t, tc.expBody, string(body))
+			require.Len(t, headers, 2)
+		})
+	}
+
+	_, _, err := translator.ResponseError(nil, alwaysErrReader{})
+	require.ErrorContains(t, err, "failed to read error body")
+}
@@ -0,0 +1,133 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	cohereschema "github.com/envoyproxy/ai-gateway/internal/apischema/cohere"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/gcp"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+	"github.com/envoyproxy/ai-gateway/internal/json"
+	"github.com/envoyproxy/ai-gateway/internal/metrics"
+	"github.com/envoyproxy/ai-gateway/internal/tracing/tracingapi"
+)
+
+// gcpRankPathSuffix is the path of the default ranking config of the Vertex AI Ranking API, following the
+// "/v1/projects/{project}/locations/{location}" prefix added by the GCP auth handler.
+const gcpRankPathSuffix = "rankingConfigs/default_ranking_config:rank"
+
+// NewRerankCohereToGCPVertexAITranslator implements [Factory] for Cohere Rerank v2 to the Vertex AI Ranking API
+// translation.
+func NewRerankCohereToGCPVertexAITranslator(modelNameOverride internalapi.ModelNameOverride) CohereRerankTranslator {
+	return &cohereToGCPVertexAITranslatorV2Rerank{modelNameOverride: modelNameOverride}
+}
+
+// cohereToGCPVertexAITranslatorV2Rerank translates Cohere Rerank v2 requests to the Vertex AI Ranking API, which
+// is served by the Discovery Engine rather than the Vertex AI endpoint:
+// https://cloud.google.com/generative-ai-app-builder/docs/ranking
+type cohereToGCPVertexAITranslatorV2Rerank struct {
+	modelNameOverride internalapi.ModelNameOverride
+	requestModel      internalapi.RequestModel
+	// topN and documents are taken from the request to build the response.
+	topN      *int
+	documents int
+}
+
+// RequestBody implements [CohereRerankTranslator.RequestBody].
+// The records are identified by the index of the documents so that the results can be mapped back.
+func (t *cohereToGCPVertexAITranslatorV2Rerank) RequestBody(_ []byte, req *cohereschema.RerankV2Request, _ bool) (
+	newHeaders []internalapi.Header, newBody []byte, err error,
+) {
+	model := req.Model
+	if t.modelNameOverride != "" {
+		model = t.modelNameOverride
+	}
+	t.requestModel = model
+	t.topN = req.TopN
+	t.documents = len(req.Documents)
+
+	rankReq := gcp.RankRequest{
+		Model:                         model,
+		Query:                         req.Query,
+		Records:                       make([]gcp.RankingRecord, 0, len(req.Documents)),
+		IgnoreRecordDetailsInResponse: true,
+	}
+	for i, doc := range req.Documents {
+		rankReq.Records = append(rankReq.Records, gcp.RankingRecord{ID: strconv.Itoa(i), Content: doc})
+	}
+	if req.TopN != nil {
+		rankReq.TopN = *req.TopN
+	}
+	newBody, err = json.Marshal(rankReq)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal body: %w", err)
+	}
+	newHeaders = []internalapi.Header{
+		{pathHeaderName, gcpRankPathSuffix},
+		{contentLengthHeaderName, strconv.Itoa(len(newBody))},
+	}
+	return
+}
+
+// ResponseHeaders implements [CohereRerankTranslator.ResponseHeaders].
+func (t *cohereToGCPVertexAITranslatorV2Rerank) ResponseHeaders(map[string]string) (newHeaders []internalapi.Header, err error) {
+	return nil, nil
+}
+
+// ResponseBody implements [CohereRerankTranslator.ResponseBody].
+// The Ranking API does not report token usage, so only the billed search units are set.
+func (t *cohereToGCPVertexAITranslatorV2Rerank) ResponseBody(_ map[string]string, body io.Reader, _ bool, span tracingapi.RerankSpan) (
+	newHeaders []internalapi.Header, newBody []byte, tokenUsage metrics.TokenUsage, responseModel internalapi.ResponseModel, err error,
+) {
+	var rankResp gcp.RankResponse
+	if err = json.NewDecoder(body).Decode(&rankResp); err != nil {
+		return nil, nil, tokenUsage, t.requestModel, fmt.Errorf("failed to unmarshal body: %w", err)
+	}
+
+	results := make([]*cohereschema.RerankV2Result, 0, len(rankResp.Records))
+	for _, r := range rankResp.Records {
+		index, convErr := strconv.Atoi(r.ID)
+		if convErr != nil {
+			return nil, nil, tokenUsage, t.requestModel, fmt.Errorf("unexpected record id %q: %w", r.ID, convErr)
+		}
+		results = append(results, &cohereschema.RerankV2Result{Index: index, RelevanceScore: r.Score})
+	}
+	resp := newRerankV2Response(results, t.topN, t.documents)
+
+	newBody, err = json.Marshal(resp)
+	if err != nil {
+		return nil, nil, tokenUsage, t.requestModel, fmt.Errorf("failed to marshal body: %w", err)
+	}
+	if span != nil {
+		span.RecordResponse(&resp)
+	}
+	newHeaders = []internalapi.Header{{contentLengthHeaderName, strconv.Itoa(len(newBody))}}
+	responseModel = t.requestModel
+	return
+}
+
+// ResponseError implements [CohereRerankTranslator.ResponseError].
+// Translates the GCP errors to the Cohere error format.
+func (t *cohereToGCPVertexAITranslatorV2Rerank) ResponseError(_ map[string]string, body io.Reader) (
+	newHeaders []internalapi.Header, newBody []byte, err error,
+) {
+	buf, err := io.ReadAll(body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read error body: %w", err)
+	}
+	message := string(buf)
+	var gcpError gcpVertexAIError
+	if json.Unmarshal(buf, &gcpError) == nil && gcpError.Error.Message != "" {
+		message = gcpError.Error.Message
+		if gcpError.Error.Status != "" {
+			message = fmt.Sprintf("%s: %s", gcpError.Error.Status, message)
+		}
+	}
+	return rerankV2ErrorBody(message)
+}
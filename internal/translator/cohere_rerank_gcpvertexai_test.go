@@ -0,0 +1,89 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+
+	cohereschema "github.com/envoyproxy/ai-gateway/internal/apischema/cohere"
+)
+
+func TestCohereToGCPVertexAITranslatorV2Rerank_RequestBody(t *testing.T) {
+	for _, tc := range []struct {
+		name              string
+		modelNameOverride string
+		topN              *int
+		expBody           string
+	}{
+		{
+			name: "all records",
+			expBody: `{"model":"semantic-ranker-default@latest","query":"q","ignoreRecordDetailsInResponse":true,
+				"records":[{"id":"0","content":"a"},{"id":"1","content":"b"}]}`,
+		},
+		{
+			name:              "top n with model name override",
+			modelNameOverride: "semantic-ranker-fast@latest",
+			topN:              ptr.To(1),
+			expBody: `{"model":"semantic-ranker-fast@latest","query":"q","ignoreRecordDetailsInResponse":true,"topN":1,
+				"records":[{"id":"0","content":"a"},{"id":"1","content":"b"}]}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			translator := NewRerankCohereToGCPVertexAITranslator(tc.modelNameOverride)
+			headers, body, err := translator.RequestBody(nil, &cohereschema.RerankV2Request{
+				Model: "semantic-ranker-default@latest", Query: "q", Documents: []string{"a", "b"}, TopN: tc.topN,
+			}, false)
+			require.NoError(t, err)
+			require.JSONEq(t, tc.expBody, string(body))
+			require.Len(t, headers, 2)
+			require.Equal(t, pathHeaderName, headers[0].Key())
+			require.Equal(t, "rankingConfigs/default_ranking_config:rank", headers[0].Value())
+		})
+	}
+}
+
+func TestCohereToGCPVertexAITranslatorV2Rerank_ResponseBody(t *testing.T) {
+	translator := NewRerankCohereToGCPVertexAITranslator("")
+	documents := make([]string, 150)
+	_, _, err := translator.RequestBody(nil, &cohereschema.RerankV2Request{
+		Model: "semantic-ranker-default@latest", Query: "q", Documents: documents,
+	}, false)
+	require.NoError(t, err)
+
+	t.Run("ok", func(t *testing.T) {
+		body := `{"records":[{"id":"3","score":0.9},{"id":"1","score":0.4},{"id":"7","score":0.9}]}`
+		_, newBody, usage, model, err := translator.ResponseBody(nil, strings.NewReader(body), true, nil)
+		require.NoError(t, err)
+		require.JSONEq(t, `{
+			"results":[{"index":3,"relevance_score":0.9},{"index":7,"relevance_score":0.9},{"index":1,"relevance_score":0.4}],
+			"meta":{"billed_units":{"search_units":2}}
+		}`, string(newBody))
+		_, ok := usage.InputTokens()
+		require.False(t, ok)
+		require.Equal(t, "semantic-ranker-default@latest", model)
+	})
+
+	t.Run("unexpected record id", func(t *testing.T) {
+		_, _, _, _, err := translator.ResponseBody(nil, strings.NewReader(`{"records":[{"id":"doc","score":0.9}]}`), true, nil)
+		require.ErrorContains(t, err, `unexpected record id "doc"`)
+	})
+}
+
+func TestCohereToGCPVertexAITranslatorV2Rerank_ResponseError(t *testing.T) {
+	translator := NewRerankCohereToGCPVertexAITranslator("")
+	_, body, err := translator.ResponseError(nil,
+		strings.NewReader(`{"error":{"code":403,"message":"permission denied","status":"PERMISSION_DENIED"}}`))
+	require.NoError(t, err)
+	require.JSONEq(t, `{"message":"PERMISSION_DENIED: permission denied"}`, string(body))
+
+	_, body, err = translator.ResponseError(nil, strings.NewReader("bad gateway"))
+	require.NoError(t, err)
+	require.JSONEq(t, `{"message":"bad gateway"}`, string(body))
+}
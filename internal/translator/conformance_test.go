@@ -0,0 +1,300 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/backendauth"
+	"github.com/envoyproxy/ai-gateway/internal/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+	"github.com/envoyproxy/ai-gateway/internal/json"
+	"github.com/envoyproxy/ai-gateway/internal/metrics"
+)
+
+var (
+	updateConformanceGolden = flag.Bool("update", false, "rewrite the golden files of TestChatCompletionConformance")
+	recordConformance       = flag.Bool("record", false, "re-record the cassettes of TestChatCompletionConformance from the live providers")
+)
+
+// conformanceDir is the directory of the conformance fixtures. Each provider has a subdirectory with a
+// <case>.cassette.json and a <case>.golden.json file per case. See testdata/conformance/README.md.
+const conformanceDir = "testdata/conformance"
+
+// conformanceProvider is a provider of the chat completion conformance tests.
+type conformanceProvider struct {
+	newTranslator func() OpenAIChatCompletionTranslator
+	// recordTarget returns the base URL and the backend auth used to record the cassettes, or ok=false if the
+	// credentials of the provider are not set in the environment.
+	recordTarget func() (baseURL string, auth *filterapi.BackendAuth, ok bool)
+}
+
+var conformanceProviders = map[string]conformanceProvider{
+	"openai": {
+		newTranslator: func() OpenAIChatCompletionTranslator { return NewChatCompletionOpenAIToOpenAITranslator("v1", "") },
+		recordTarget: func() (string, *filterapi.BackendAuth, bool) {
+			key := os.Getenv("OPENAI_API_KEY")
+			return "https://api.openai.com", &filterapi.BackendAuth{APIKey: &filterapi.APIKeyAuth{Key: key}}, key != ""
+		},
+	},
+	"awsbedrock": {
+		newTranslator: func() OpenAIChatCompletionTranslator { return NewChatCompletionOpenAIToAWSBedrockTranslator("") },
+		recordTarget: func() (string, *filterapi.BackendAuth, bool) {
+			region := os.Getenv("AWS_REGION")
+			return fmt.Sprintf("https://bedrock-runtime.%s.amazonaws.com", region),
+				&filterapi.BackendAuth{AWSAuth: &filterapi.AWSAuth{Region: region}}, region != ""
+		},
+	},
+	"gcpanthropic": {
+		newTranslator: func() OpenAIChatCompletionTranslator { return NewChatCompletionOpenAIToGCPAnthropicTranslator("", "") },
+		recordTarget:  gcpConformanceRecordTarget,
+	},
+	"gcpvertexai": {
+		newTranslator: func() OpenAIChatCompletionTranslator { return NewChatCompletionOpenAIToGCPVertexAITranslator("") },
+		recordTarget:  gcpConformanceRecordTarget,
+	},
+}
+
+func gcpConformanceRecordTarget() (string, *filterapi.BackendAuth, bool) {
+	token, region, project := os.Getenv("GCP_ACCESS_TOKEN"), os.Getenv("GCP_REGION"), os.Getenv("GCP_PROJECT")
+	return fmt.Sprintf("https://%s-aiplatform.googleapis.com", region),
+		&filterapi.BackendAuth{GCPAuth: &filterapi.GCPAuth{AccessToken: token, Region: region, ProjectName: project}},
+		token != "" && region != "" && project != ""
+}
+
+// conformanceCassette is a recorded raw response of a provider to the translated request.
+type conformanceCassette struct {
+	// Request is the OpenAI chat completion request sent by the client.
+	Request json.RawMessage `json:"request"`
+	// Status is the HTTP status code of the response.
+	Status int `json:"status"`
+	// Headers are the response headers used by the translators.
+	Headers map[string]string `json:"headers"`
+	// Chunks are the response body chunks in the order and with the boundaries they were received.
+	Chunks []string `json:"chunks"`
+	// Base64 is true if the chunks are base64 encoded, e.g. for the binary AWS event streams.
+	Base64 bool `json:"base64,omitempty"`
+}
+
+// conformanceGolden is the expected output of a translator for a cassette.
+type conformanceGolden struct {
+	// Headers are the headers set by ResponseHeaders, or by ResponseError for the error responses.
+	Headers []internalapi.Header `json:"headers,omitempty"`
+	// Chunks are the response body chunks sent to the client, one for each chunk of the cassette.
+	Chunks []string `json:"chunks"`
+	// ResponseModel is the response model returned for the last chunk.
+	ResponseModel string `json:"responseModel,omitempty"`
+	// Usage is the token usage accumulated over the chunks.
+	Usage conformanceUsage `json:"usage"`
+}
+
+type conformanceUsage struct {
+	InputTokens  uint32 `json:"inputTokens"`
+	OutputTokens uint32 `json:"outputTokens"`
+	TotalTokens  uint32 `json:"totalTokens"`
+}
+
+// createdPattern matches the creation timestamps, which some translators set to the current time.
+var createdPattern = regexp.MustCompile(`"created":\s*\d+`)
+
+// TestChatCompletionConformance replays the recorded provider responses in testdata/conformance through the
+// chat completion translators, and compares the translated chunks byte by byte with the golden files.
+//
+// Run with -update to rewrite the golden files, or with -record to re-record the cassettes from the live
+// providers whose credentials are set in the environment.
+func TestChatCompletionConformance(t *testing.T) {
+	for name, provider := range conformanceProviders {
+		files, err := filepath.Glob(filepath.Join(conformanceDir, name, "*.cassette.json"))
+		require.NoError(t, err)
+		for _, file := range files {
+			caseName := strings.TrimSuffix(filepath.Base(file), ".cassette.json")
+			t.Run(name+"/"+caseName, func(t *testing.T) {
+				goldenFile := filepath.Join(conformanceDir, name, caseName+".golden.json")
+				cassette := readConformanceFile[conformanceCassette](t, file)
+				if *recordConformance {
+					recordConformanceCassette(t, provider, cassette)
+					writeConformanceFile(t, file, cassette)
+				}
+
+				actual := replayConformanceCassette(t, provider.newTranslator(), cassette)
+				if *updateConformanceGolden || *recordConformance {
+					writeConformanceFile(t, goldenFile, actual)
+					return
+				}
+				expected := readConformanceFile[conformanceGolden](t, goldenFile)
+				require.Equal(t, expected.Headers, actual.Headers)
+				require.Len(t, actual.Chunks, len(expected.Chunks))
+				for i := range expected.Chunks {
+					require.Equal(t, expected.Chunks[i], actual.Chunks[i], "chunk %d", i)
+				}
+				require.Equal(t, expected.ResponseModel, actual.ResponseModel)
+				require.Equal(t, expected.Usage, actual.Usage)
+			})
+		}
+	}
+}
+
+// replayConformanceCassette runs the cassette through the translator as the external processor does.
+func replayConformanceCassette(t *testing.T, tr OpenAIChatCompletionTranslator, cassette *conformanceCassette) *conformanceGolden {
+	var req openai.ChatCompletionRequest
+	require.NoError(t, json.Unmarshal(cassette.Request, &req))
+	_, _, err := tr.RequestBody(cassette.Request, &req, false)
+	require.NoError(t, err)
+
+	chunks := cassette.decodedChunks(t)
+	respHeaders := map[string]string{statusHeaderName: strconv.Itoa(cassette.Status)}
+	maps.Copy(respHeaders, cassette.Headers)
+
+	ret := &conformanceGolden{}
+	if cassette.Status >= 300 {
+		original := bytes.Join(chunks, nil)
+		headers, body, err := tr.ResponseError(respHeaders, bytes.NewReader(original))
+		require.NoError(t, err)
+		ret.Headers = headers
+		ret.Chunks = []string{normalizeConformanceChunk(body, original)}
+		return ret
+	}
+
+	ret.Headers, err = tr.ResponseHeaders(respHeaders)
+	require.NoError(t, err)
+	var usage metrics.TokenUsage
+	for i, chunk := range chunks {
+		_, body, tokenUsage, responseModel, err := tr.ResponseBody(respHeaders, bytes.NewReader(chunk), i == len(chunks)-1, nil)
+		require.NoError(t, err)
+		usage.Override(tokenUsage)
+		ret.ResponseModel = responseModel
+		ret.Chunks = append(ret.Chunks, normalizeConformanceChunk(body, chunk))
+	}
+	ret.Usage.InputTokens, _ = usage.InputTokens()
+	ret.Usage.OutputTokens, _ = usage.OutputTokens()
+	ret.Usage.TotalTokens, _ = usage.TotalTokens()
+	return ret
+}
+
+// normalizeConformanceChunk returns the chunk sent to the client, which is the original chunk if the translator
+// didn't mutate the body, with the creation timestamps zeroed.
+func normalizeConformanceChunk(mutated, original []byte) string {
+	if mutated == nil {
+		mutated = original
+	}
+	return createdPattern.ReplaceAllString(string(mutated), `"created":0`)
+}
+
+func (c *conformanceCassette) decodedChunks(t *testing.T) [][]byte {
+	ret := make([][]byte, len(c.Chunks))
+	for i, chunk := range c.Chunks {
+		if !c.Base64 {
+			ret[i] = []byte(chunk)
+			continue
+		}
+		var err error
+		ret[i], err = base64.StdEncoding.DecodeString(chunk)
+		require.NoError(t, err)
+	}
+	return ret
+}
+
+// recordConformanceCassette sends the translated request of the cassette to the live provider, and records the
+// response chunks as they are received. The test is skipped if the credentials of the provider are not set.
+func recordConformanceCassette(t *testing.T, provider conformanceProvider, cassette *conformanceCassette) {
+	baseURL, auth, ok := provider.recordTarget()
+	if !ok {
+		t.Skip("the credentials of the provider are not set")
+	}
+	var req openai.ChatCompletionRequest
+	require.NoError(t, json.Unmarshal(cassette.Request, &req))
+	headers, body, err := provider.newTranslator().RequestBody(cassette.Request, &req, true)
+	require.NoError(t, err)
+
+	requestHeaders := map[string]string{":method": http.MethodPost}
+	for _, h := range headers {
+		requestHeaders[h.Key()] = h.Value()
+	}
+	handler, err := backendauth.NewHandler(t.Context(), auth)
+	require.NoError(t, err)
+	authHeaders, err := handler.Do(t.Context(), requestHeaders, body)
+	require.NoError(t, err)
+
+	httpReq, err := http.NewRequestWithContext(t.Context(), http.MethodPost, baseURL+requestHeaders[pathHeaderName], bytes.NewReader(body))
+	require.NoError(t, err)
+	for _, h := range append(headers, authHeaders...) {
+		if !strings.HasPrefix(h.Key(), ":") && h.Key() != contentLengthHeaderName {
+			httpReq.Header.Set(h.Key(), h.Value())
+		}
+	}
+	httpReq.Header.Set(contentTypeHeaderName, jsonContentType)
+	// Disable the transparent decompression so that the chunks are recorded as sent by the provider.
+	httpReq.Header.Set("accept-encoding", "identity")
+	resp, err := http.DefaultClient.Do(httpReq)
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+
+	cassette.Status = resp.StatusCode
+	cassette.Headers = map[string]string{}
+	for _, key := range []string{contentTypeHeaderName, awsErrorTypeHeaderName} {
+		if v := resp.Header.Get(key); v != "" {
+			cassette.Headers[key] = v
+		}
+	}
+	var chunks [][]byte
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := resp.Body.Read(buf)
+		if n > 0 {
+			chunks = append(chunks, bytes.Clone(buf[:n]))
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+	}
+
+	cassette.Base64 = false
+	for _, chunk := range chunks {
+		if !utf8.Valid(chunk) {
+			cassette.Base64 = true
+		}
+	}
+	cassette.Chunks = make([]string, len(chunks))
+	for i, chunk := range chunks {
+		if cassette.Base64 {
+			cassette.Chunks[i] = base64.StdEncoding.EncodeToString(chunk)
+		} else {
+			cassette.Chunks[i] = string(chunk)
+		}
+	}
+}
+
+func readConformanceFile[T any](t *testing.T, file string) *T {
+	raw, err := os.ReadFile(file)
+	require.NoError(t, err)
+	var v T
+	require.NoError(t, json.Unmarshal(raw, &v))
+	return &v
+}
+
+func writeConformanceFile(t *testing.T, file string, v any) {
+	raw, err := json.MarshalIndent(v, "", "  ")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(file, append(raw, '\n'), 0o600))
+}
@@ -0,0 +1,54 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"math"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+// embeddingEncodingFormatBase64 is the OpenAI encoding_format returning the embeddings as base64 strings.
+const embeddingEncodingFormatBase64 = "base64"
+
+// formatEmbedding converts an embedding vector returned by a backend into the shape requested by an OpenAI client.
+//
+// When the vector is longer than the requested dimensions, i.e. the backend does not support them natively,
+// it is truncated and L2-normalized again, which is how OpenAI shortens the text-embedding-3 embeddings.
+// When the base64 encoding format is requested, the vector is encoded the way OpenAI does: the little-endian
+// float32 values encoded in standard base64.
+func formatEmbedding(vector []float64, dimensions *int, encodingFormat *string) openai.EmbeddingUnion {
+	if dimensions != nil && *dimensions > 0 && len(vector) > *dimensions {
+		vector = normalizeEmbedding(vector[:*dimensions])
+	}
+	if encodingFormat != nil && *encodingFormat == embeddingEncodingFormatBase64 {
+		buf := make([]byte, 4*len(vector))
+		for i, v := range vector {
+			binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(float32(v)))
+		}
+		return openai.EmbeddingUnion{Value: base64.StdEncoding.EncodeToString(buf)}
+	}
+	return openai.EmbeddingUnion{Value: vector}
+}
+
+// normalizeEmbedding returns a copy of the vector scaled to unit L2 norm.
+func normalizeEmbedding(vector []float64) []float64 {
+	var sum float64
+	for _, v := range vector {
+		sum += v * v
+	}
+	normalized := make([]float64, len(vector))
+	if sum == 0 {
+		return normalized
+	}
+	norm := math.Sqrt(sum)
+	for i, v := range vector {
+		normalized[i] = v / norm
+	}
+	return normalized
+}
@@ -0,0 +1,77 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+)
+
+func TestFormatEmbedding(t *testing.T) {
+	for _, tc := range []struct {
+		name           string
+		vector         []float64
+		dimensions     *int
+		encodingFormat *string
+		want           any
+	}{
+		{
+			name:   "unchanged",
+			vector: []float64{0.1, 0.2, 0.3},
+			want:   []float64{0.1, 0.2, 0.3},
+		},
+		{
+			name:           "float encoding format",
+			vector:         []float64{0.1, 0.2},
+			encodingFormat: ptr.To("float"),
+			want:           []float64{0.1, 0.2},
+		},
+		{
+			name:       "dimensions already honored by the backend",
+			vector:     []float64{0.1, 0.2},
+			dimensions: ptr.To(2),
+			want:       []float64{0.1, 0.2},
+		},
+		{
+			name:       "dimensions emulated",
+			vector:     []float64{3, 4, 12},
+			dimensions: ptr.To(2),
+			want:       []float64{0.6, 0.8},
+		},
+		{
+			name:       "dimensions emulated on a zero vector",
+			vector:     []float64{0, 0, 1},
+			dimensions: ptr.To(2),
+			want:       []float64{0, 0},
+		},
+		{
+			name:           "base64",
+			vector:         []float64{1, -2},
+			encodingFormat: ptr.To("base64"),
+			want:           "AACAPwAAAMA=",
+		},
+		{
+			name:           "dimensions emulated and base64",
+			vector:         []float64{3, 4, 12},
+			dimensions:     ptr.To(2),
+			encodingFormat: ptr.To("base64"),
+			want:           "mpkZP83MTD8=",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := formatEmbedding(tc.vector, tc.dimensions, tc.encodingFormat)
+			if want, ok := tc.want.([]float64); ok {
+				gotVec, ok := got.Value.([]float64)
+				require.True(t, ok)
+				require.InDeltaSlice(t, want, gotVec, 1e-9)
+				return
+			}
+			require.Equal(t, tc.want, got.Value)
+		})
+	}
+}
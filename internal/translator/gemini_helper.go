@@ -571,6 +571,23 @@ func openAIToolChoiceToGeminiToolConfig(toolChoice *openai.ChatCompletionToolCho
 	}
 }
 
+// geminiStrictToolConfig returns the tool config constraining the function calls to the declared schemas for the
+// strict function tools. The AUTO mode, which is the default, is replaced with the VALIDATED mode, while the ANY mode
+// already constrains the function calls and the NONE mode disables them.
+func geminiStrictToolConfig(toolConfig *genai.ToolConfig) *genai.ToolConfig {
+	if toolConfig == nil {
+		toolConfig = &genai.ToolConfig{}
+	}
+	if toolConfig.FunctionCallingConfig == nil {
+		toolConfig.FunctionCallingConfig = &genai.FunctionCallingConfig{}
+	}
+	switch toolConfig.FunctionCallingConfig.Mode {
+	case "", genai.FunctionCallingConfigModeUnspecified, genai.FunctionCallingConfigModeAuto:
+		toolConfig.FunctionCallingConfig.Mode = genai.FunctionCallingConfigModeValidated
+	}
+	return toolConfig
+}
+
 // ------------------------------------------------------------
 // Gemini Version-Specific Feature Availability Functions
 // ------------------------------------------------------------
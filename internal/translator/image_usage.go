@@ -0,0 +1,46 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/envoyproxy/ai-gateway/internal/metrics"
+)
+
+// normalizeImageQuality maps the image quality of a provider to its quality tier, so that the images generated by
+// the different providers are accounted alike. This returns an empty string for the unknown qualities, e.g. "auto".
+func normalizeImageQuality(quality string) string {
+	switch strings.ToLower(strings.TrimSpace(quality)) {
+	case "low":
+		return metrics.ImageQualityLow
+	case "standard", "medium":
+		return metrics.ImageQualityStandard
+	case "hd", "high", "premium":
+		return metrics.ImageQualityHigh
+	default:
+		return ""
+	}
+}
+
+// normalizeImageSize returns the image size in the "<width>x<height>" form, or an empty string if the size isn't
+// in that form, e.g. "auto".
+func normalizeImageSize(size string) string {
+	w, h, ok := strings.Cut(strings.ToLower(strings.TrimSpace(size)), "x")
+	if !ok {
+		return ""
+	}
+	width, err := strconv.Atoi(w)
+	if err != nil || width <= 0 {
+		return ""
+	}
+	height, err := strconv.Atoi(h)
+	if err != nil || height <= 0 {
+		return ""
+	}
+	return strconv.Itoa(width) + "x" + strconv.Itoa(height)
+}
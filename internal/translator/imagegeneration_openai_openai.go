@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"io"
 	"path"
+	"strings"
 
 	"github.com/tidwall/sjson"
 
@@ -33,6 +34,9 @@ type openAIToOpenAIImageGenerationTranslator struct {
 	// requestModel stores the effective model for this request (override or provided)
 	// so we can attribute metrics later; the OpenAI Images response omits a model field.
 	requestModel internalapi.RequestModel
+	// requestSize and requestQuality are the size and the quality of the images of the request, which the
+	// responses of the DALL-E models omit.
+	requestSize, requestQuality string
 }
 
 // RequestBody implements [ImageGenerationTranslator.RequestBody].
@@ -49,6 +53,12 @@ func (o *openAIToOpenAIImageGenerationTranslator) RequestBody(original []byte, p
 	// Persist the effective model used. The Images endpoint omits model in responses,
 	// so we derive it from the request (or override) for downstream metrics.
 	o.requestModel = cmp.Or(o.modelNameOverride, p.Model)
+	o.requestSize, o.requestQuality = p.Size, p.Quality
+	if strings.HasPrefix(o.requestModel, "dall-e") {
+		// The DALL-E models default to the standard quality and the 1024x1024 size.
+		o.requestSize = cmp.Or(o.requestSize, "1024x1024")
+		o.requestQuality = cmp.Or(o.requestQuality, "standard")
+	}
 
 	// Always set the path header to the images generations endpoint so that the request is routed correctly.
 	if forceBodyMutation && len(newBody) == 0 {
@@ -91,6 +101,15 @@ func (o *openAIToOpenAIImageGenerationTranslator) ResponseBody(_ map[string]stri
 		tokenUsage.SetTotalTokens(uint32(resp.Usage.TotalTokens))   //nolint:gosec
 	}
 
+	if len(resp.Data) > 0 {
+		// The responses of gpt-image-1 report the size and the quality chosen for "auto".
+		tokenUsage.SetImages(metrics.ImageUsage{
+			Count:   uint32(len(resp.Data)), //nolint:gosec
+			Size:    normalizeImageSize(cmp.Or(resp.Size, o.requestSize)),
+			Quality: normalizeImageQuality(cmp.Or(resp.Quality, o.requestQuality)),
+		})
+	}
+
 	// There is no response model field, so use the request one.
 	responseModel = o.requestModel
 
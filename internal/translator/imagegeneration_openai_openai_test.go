@@ -13,6 +13,7 @@ import (
 
 	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
 	"github.com/envoyproxy/ai-gateway/internal/json"
+	"github.com/envoyproxy/ai-gateway/internal/metrics"
 )
 
 func TestOpenAIToOpenAIImageTranslator_RequestBody_ModelOverrideAndPath(t *testing.T) {
@@ -179,3 +180,78 @@ func TestOpenAIToOpenAIImageTranslator_ResponseBody_Usage(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, tokenUsageFrom(40, -1, -1, 60, 100, -1), usage)
 }
+
+func TestOpenAIToOpenAIImageTranslator_ResponseBody_Images(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		req  *openai.ImageGenerationRequest
+		resp *openai.ImageGenerationResponse
+		exp  metrics.ImageUsage
+	}{
+		{
+			name: "dall-e defaults",
+			req:  &openai.ImageGenerationRequest{Model: "dall-e-2", Prompt: "a cat", N: 2},
+			resp: &openai.ImageGenerationResponse{Data: make([]openai.ImageGenerationResponseData, 2)},
+			exp:  metrics.ImageUsage{Count: 2, Size: "1024x1024", Quality: metrics.ImageQualityStandard},
+		},
+		{
+			name: "dall-e-3 hd",
+			req:  &openai.ImageGenerationRequest{Model: "dall-e-3", Prompt: "a cat", Size: "1792x1024", Quality: "hd"},
+			resp: &openai.ImageGenerationResponse{Data: make([]openai.ImageGenerationResponseData, 1)},
+			exp:  metrics.ImageUsage{Count: 1, Size: "1792x1024", Quality: metrics.ImageQualityHigh},
+		},
+		{
+			name: "gpt-image-1 auto reported in the response",
+			req:  &openai.ImageGenerationRequest{Model: "gpt-image-1", Prompt: "a cat", Size: "auto", Quality: "auto"},
+			resp: &openai.ImageGenerationResponse{Data: make([]openai.ImageGenerationResponseData, 1), Size: "1536x1024", Quality: "medium"},
+			exp:  metrics.ImageUsage{Count: 1, Size: "1536x1024", Quality: metrics.ImageQualityStandard},
+		},
+		{
+			name: "gpt-image-1 auto not reported",
+			req:  &openai.ImageGenerationRequest{Model: "gpt-image-1", Prompt: "a cat"},
+			resp: &openai.ImageGenerationResponse{Data: make([]openai.ImageGenerationResponseData, 3)},
+			exp:  metrics.ImageUsage{Count: 3},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tr := NewImageGenerationOpenAIToOpenAITranslator("v1", "")
+			original, _ := json.Marshal(tc.req)
+			_, _, err := tr.RequestBody(original, tc.req, false)
+			require.NoError(t, err)
+			buf, _ := json.Marshal(tc.resp)
+			_, _, usage, _, err := tr.ResponseBody(map[string]string{}, bytes.NewReader(buf), true, nil)
+			require.NoError(t, err)
+			images, ok := usage.Images()
+			require.True(t, ok)
+			require.Equal(t, tc.exp, images)
+		})
+	}
+}
+
+func TestNormalizeImageQuality(t *testing.T) {
+	for in, exp := range map[string]string{
+		"low":      metrics.ImageQualityLow,
+		"medium":   metrics.ImageQualityStandard,
+		"standard": metrics.ImageQualityStandard,
+		"HD":       metrics.ImageQualityHigh,
+		"high":     metrics.ImageQualityHigh,
+		"premium":  metrics.ImageQualityHigh,
+		"auto":     "",
+		"":         "",
+	} {
+		require.Equal(t, exp, normalizeImageQuality(in), in)
+	}
+}
+
+func TestNormalizeImageSize(t *testing.T) {
+	for in, exp := range map[string]string{
+		"1024x1024":  "1024x1024",
+		" 1792X1024": "1792x1024",
+		"auto":       "",
+		"0x1024":     "",
+		"1024x":      "",
+		"":           "",
+	} {
+		require.Equal(t, exp, normalizeImageSize(in), in)
+	}
+}
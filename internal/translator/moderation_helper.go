@@ -0,0 +1,84 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/google/uuid"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+	"github.com/envoyproxy/ai-gateway/internal/json"
+	"github.com/envoyproxy/ai-gateway/internal/tracing/tracingapi"
+)
+
+// moderationCategories are the categories of the OpenAI moderation results. The results translated from the other
+// safety providers report all of them, like the OpenAI ones, with the categories the provider doesn't assess unflagged.
+var moderationCategories = []string{
+	openai.ModerationCategoryHarassment,
+	openai.ModerationCategoryHarassmentThreatening,
+	openai.ModerationCategoryHate,
+	openai.ModerationCategoryHateThreatening,
+	openai.ModerationCategoryIllicit,
+	openai.ModerationCategoryIllicitViolent,
+	openai.ModerationCategorySelfHarm,
+	openai.ModerationCategorySelfHarmIntent,
+	openai.ModerationCategorySelfHarmInstructions,
+	openai.ModerationCategorySexual,
+	openai.ModerationCategorySexualMinors,
+	openai.ModerationCategoryViolence,
+	openai.ModerationCategoryViolenceGraphic,
+}
+
+// newModerationResult returns the moderation result with all the categories unflagged.
+func newModerationResult() openai.ModerationResult {
+	result := openai.ModerationResult{
+		Categories:     make(map[string]bool, len(moderationCategories)),
+		CategoryScores: make(map[string]float64, len(moderationCategories)),
+	}
+	for _, c := range moderationCategories {
+		result.Categories[c] = false
+		result.CategoryScores[c] = 0
+	}
+	return result
+}
+
+// setModerationCategory sets the score of the category to the highest one reported so far, and flags it if flagged.
+// The result is flagged if any of its categories is.
+func setModerationCategory(result *openai.ModerationResult, category string, score float64, flagged bool) {
+	result.CategoryScores[category] = max(result.CategoryScores[category], score)
+	if flagged {
+		result.Categories[category] = true
+		result.Flagged = true
+	}
+}
+
+// moderationSingleTextInput returns the text of the moderation request whose input is a single text, which is the only
+// input the safety providers other than OpenAI can assess in a request.
+func moderationSingleTextInput(req *openai.ModerationRequest, provider string) (string, error) {
+	texts, ok := req.Input.Texts()
+	if !ok || len(texts) != 1 {
+		return "", fmt.Errorf("%w: %s only supports a single text input", internalapi.ErrInvalidRequestBody, provider)
+	}
+	return texts[0], nil
+}
+
+// moderationResponseBody marshals the moderation response with the single result, and records it in the span.
+func moderationResponseBody(model internalapi.RequestModel, result openai.ModerationResult, span tracingapi.ModerationSpan) (
+	newHeaders []internalapi.Header, newBody []byte, err error,
+) {
+	resp := openai.ModerationResponse{ID: "modr-" + uuid.NewString(), Model: model, Results: []openai.ModerationResult{result}}
+	newBody, err = json.Marshal(resp)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal body: %w", err)
+	}
+	if span != nil {
+		span.RecordResponse(&resp)
+	}
+	return []internalapi.Header{{contentLengthHeaderName, strconv.Itoa(len(newBody))}}, newBody, nil
+}
@@ -50,6 +50,11 @@ type openAIToAWSAnthropicTranslatorV1ChatCompletion struct {
 	bufferedBody      []byte
 }
 
+// UnsupportedParameters implements [UnsupportedParametersLister.UnsupportedParameters].
+func (o *openAIToAWSAnthropicTranslatorV1ChatCompletion) UnsupportedParameters(raw []byte) []string {
+	return presentUnsupportedParameters(raw, anthropicUnsupportedParameters)
+}
+
 // RequestBody implements [OpenAIChatCompletionTranslator.RequestBody] for AWS Anthropic.
 func (o *openAIToAWSAnthropicTranslatorV1ChatCompletion) RequestBody(_ []byte, openAIReq *openai.ChatCompletionRequest, _ bool) (
 	newHeaders []internalapi.Header, newBody []byte, err error,
@@ -41,6 +41,9 @@ type openAIToAWSBedrockTranslatorV1ChatCompletion struct {
 	stream            bool
 	bufferedBody      []byte
 	events            []awsbedrock.ConverseStreamEvent
+	// eventStreamReader and eventStreamDecoder are reused across the chunks of the stream.
+	eventStreamReader  bytes.Reader
+	eventStreamDecoder *eventstream.Decoder
 	// role is from MessageStartEvent in chunked messages, and used for all openai chat completion chunk choices.
 	// Translator is created for each request/response stream inside external processor, accordingly the role is not reused by multiple streams.
 	role             string
@@ -99,6 +102,11 @@ func getCachePoint(fields *openai.AnthropicContentFields) *awsbedrock.CachePoint
 	return nil
 }
 
+// UnsupportedParameters implements [UnsupportedParametersLister.UnsupportedParameters].
+func (o *openAIToAWSBedrockTranslatorV1ChatCompletion) UnsupportedParameters(raw []byte) []string {
+	return presentUnsupportedParameters(raw, bedrockUnsupportedParameters)
+}
+
 // RequestBody implements [OpenAIChatCompletionTranslator.RequestBody].
 func (o *openAIToAWSBedrockTranslatorV1ChatCompletion) RequestBody(_ []byte, openAIReq *openai.ChatCompletionRequest, _ bool) (
 	newHeaders []internalapi.Header, newBody []byte, err error,
@@ -192,6 +200,10 @@ func (o *openAIToAWSBedrockTranslatorV1ChatCompletion) openAIToolsToBedrockToolC
 			if toolDefinition.Function.Description != "" {
 				toolDesc = &toolDefinition.Function.Description
 			}
+			var strict *bool
+			if toolDefinition.Function.Strict {
+				strict = ptr.To(true)
+			}
 			tool := &awsbedrock.Tool{
 				ToolSpec: &awsbedrock.ToolSpecification{
 					Name:        &toolName,
@@ -199,6 +211,7 @@ func (o *openAIToAWSBedrockTranslatorV1ChatCompletion) openAIToolsToBedrockToolC
 					InputSchema: &awsbedrock.ToolInputSchema{
 						JSON: toolDefinition.Function.Parameters,
 					},
+					Strict: strict,
 				},
 				CachePoint: getCachePoint(toolDefinition.Function.AnthropicContentFields),
 			}
@@ -725,13 +738,16 @@ func (o *openAIToAWSBedrockTranslatorV1ChatCompletion) ResponseBody(_ map[string
 	responseModel = o.requestModel
 	if o.stream {
 		newBody = make([]byte, 0)
-		var buf []byte
-		buf, err = io.ReadAll(body)
+		var chunk *bytes.Buffer
+		chunk, err = readStreamingChunk(o.bufferedBody, body)
 		if err != nil {
 			return nil, nil, metrics.TokenUsage{}, "", fmt.Errorf("failed to read body: %w", err)
 		}
-		o.bufferedBody = append(o.bufferedBody, buf...)
+		defer releaseStreamingChunk(chunk)
+		o.bufferedBody = chunk.Bytes()
 		o.extractAmazonEventStreamEvents()
+		// The incomplete event is kept for the next chunk out of the pooled buffer.
+		o.bufferedBody = bytes.Clone(o.bufferedBody)
 
 		for i := range o.events {
 			event := &o.events[i]
@@ -865,13 +881,18 @@ func (o *openAIToAWSBedrockTranslatorV1ChatCompletion) ResponseBody(_ map[string
 // extractAmazonEventStreamEvents extracts [awsbedrock.ConverseStreamEvent] from the buffered body.
 // The extracted events are stored in the processor's events field.
 func (o *openAIToAWSBedrockTranslatorV1ChatCompletion) extractAmazonEventStreamEvents() {
-	// TODO: Maybe reuse the reader and decoder.
-	r := bytes.NewReader(o.bufferedBody)
-	dec := eventstream.NewDecoder()
+	r := &o.eventStreamReader
+	r.Reset(o.bufferedBody)
+	if o.eventStreamDecoder == nil {
+		o.eventStreamDecoder = eventstream.NewDecoder()
+	}
+	dec := o.eventStreamDecoder
 	clear(o.events)
 	o.events = o.events[:0]
 	var lastRead int64
 	for {
+		// Each payload gets its own buffer, since the strings of the events unmarshaled from it are kept until all
+		// the events of the chunk are converted.
 		msg, err := dec.Decode(r, nil)
 		if err != nil {
 			o.bufferedBody = o.bufferedBody[lastRead:]
@@ -29,8 +29,18 @@ func NewEmbeddingOpenAIToAWSBedrockTranslator(modelNameOverride internalapi.Mode
 type openAIToAWSBedrockTranslatorV1Embedding struct {
 	modelNameOverride internalapi.ModelNameOverride
 	requestModel      internalapi.RequestModel
+	// dimensions and encodingFormat are the ones requested by the client, emulated on the response
+	// when Titan does not support them natively.
+	dimensions     *int
+	encodingFormat *string
 }
 
+// titanEmbeddingV1ModelPrefix is the prefix of the Titan Embed Text v1 models, which do not support dimensions.
+const titanEmbeddingV1ModelPrefix = "amazon.titan-embed-text-v1"
+
+// titanEmbeddingDimensions are the output dimensions supported natively by the Titan Embed Text v2 models.
+var titanEmbeddingDimensions = map[int]struct{}{256: {}, 512: {}, 1024: {}}
+
 // RequestBody implements [OpenAIEmbeddingTranslator.RequestBody].
 func (o *openAIToAWSBedrockTranslatorV1Embedding) RequestBody(_ []byte, req *openai.EmbeddingRequest, _ bool) (
 	newHeaders []internalapi.Header, mutatedBody []byte, err error,
@@ -40,6 +50,8 @@ func (o *openAIToAWSBedrockTranslatorV1Embedding) RequestBody(_ []byte, req *ope
 		model = o.modelNameOverride
 	}
 	o.requestModel = model
+	o.dimensions = req.Dimensions
+	o.encodingFormat = req.EncodingFormat
 
 	if req.OfCompletion == nil {
 		return nil, nil, fmt.Errorf("%w: AWS Bedrock Titan requires an input-based embedding request (messages not supported)", internalapi.ErrInvalidRequestBody)
@@ -59,9 +71,12 @@ func (o *openAIToAWSBedrockTranslatorV1Embedding) RequestBody(_ []byte, req *ope
 		return nil, nil, fmt.Errorf("%w: unsupported input type %T", internalapi.ErrInvalidRequestBody, req.OfCompletion.Input.Value)
 	}
 
-	bedrockReq := awsbedrock.TitanEmbeddingRequest{
-		InputText:  inputText,
-		Dimensions: req.Dimensions,
+	bedrockReq := awsbedrock.TitanEmbeddingRequest{InputText: inputText}
+	// Other dimensions are emulated by truncating the default embedding in ResponseBody.
+	if req.Dimensions != nil && !strings.HasPrefix(model, titanEmbeddingV1ModelPrefix) {
+		if _, ok := titanEmbeddingDimensions[*req.Dimensions]; ok {
+			bedrockReq.Dimensions = req.Dimensions
+		}
 	}
 
 	mutatedBody, err = json.Marshal(bedrockReq)
@@ -102,7 +117,7 @@ func (o *openAIToAWSBedrockTranslatorV1Embedding) ResponseBody(_ map[string]stri
 			{
 				Object:    "embedding",
 				Index:     0,
-				Embedding: openai.EmbeddingUnion{Value: titanResp.Embedding},
+				Embedding: formatEmbedding(titanResp.Embedding, o.dimensions, o.encodingFormat),
 			},
 		},
 		Usage: openai.EmbeddingUsage{
@@ -128,6 +128,33 @@ func TestEmbeddingOpenAIToAWSBedrockTranslator_RequestBody(t *testing.T) {
 			wantPath:         "/model/amazon.titan-embed-text-v2:0/invoke",
 			wantBodyContains: []string{`"inputText":"test"`, `"dimensions":256`},
 		},
+		{
+			// Dimensions not supported by Titan are emulated on the response.
+			name: "v2 model - unsupported dimensions omitted from body",
+			input: openai.EmbeddingRequest{
+				EmbeddingBaseRequest: openai.EmbeddingBaseRequest{Model: "amazon.titan-embed-text-v2:0", Dimensions: &[]int{64}[0]},
+				OfCompletion: &openai.EmbeddingCompletionRequest{
+					EmbeddingBaseRequest: openai.EmbeddingBaseRequest{Model: "amazon.titan-embed-text-v2:0", Dimensions: &[]int{64}[0]},
+					Input:                openai.EmbeddingRequestInput{Value: "test"},
+				},
+			},
+			wantPath:            "/model/amazon.titan-embed-text-v2:0/invoke",
+			wantBodyContains:    []string{`"inputText":"test"`},
+			wantBodyNotContains: []string{`"dimensions"`},
+		},
+		{
+			name: "v1 model - dimensions omitted from body",
+			input: openai.EmbeddingRequest{
+				EmbeddingBaseRequest: openai.EmbeddingBaseRequest{Model: "amazon.titan-embed-text-v1", Dimensions: &[]int{256}[0]},
+				OfCompletion: &openai.EmbeddingCompletionRequest{
+					EmbeddingBaseRequest: openai.EmbeddingBaseRequest{Model: "amazon.titan-embed-text-v1", Dimensions: &[]int{256}[0]},
+					Input:                openai.EmbeddingRequestInput{Value: "test"},
+				},
+			},
+			wantPath:            "/model/amazon.titan-embed-text-v1/invoke",
+			wantBodyContains:    []string{`"inputText":"test"`},
+			wantBodyNotContains: []string{`"dimensions"`},
+		},
 		{
 			name:              "model name override applied",
 			modelNameOverride: "amazon.titan-embed-text-v1:2",
@@ -441,6 +468,28 @@ func TestEmbeddingOpenAIToAWSBedrockTranslator_ResponseBody_SpanRecorded(t *test
 	require.Equal(t, "amazon.titan-embed-text-v2:0", span.recordedResponse.Model)
 }
 
+func TestEmbeddingOpenAIToAWSBedrockTranslator_EmulatedDimensionsAndEncoding(t *testing.T) {
+	translator := NewEmbeddingOpenAIToAWSBedrockTranslator("")
+	req := openai.EmbeddingRequest{
+		EmbeddingBaseRequest: openai.EmbeddingBaseRequest{
+			Model:          "amazon.titan-embed-text-v1",
+			Dimensions:     &[]int{2}[0],
+			EncodingFormat: &[]string{"base64"}[0],
+		},
+		OfCompletion: &openai.EmbeddingCompletionRequest{Input: openai.EmbeddingRequestInput{Value: "test"}},
+	}
+	_, _, err := translator.RequestBody(nil, &req, false)
+	require.NoError(t, err)
+
+	_, body, _, _, err := translator.ResponseBody(nil, strings.NewReader(`{"embedding":[3,4,12],"inputTextTokenCount":1}`), true, nil)
+	require.NoError(t, err)
+	var resp openai.EmbeddingResponse
+	require.NoError(t, json.Unmarshal(body, &resp))
+	require.Len(t, resp.Data, 1)
+	// [3, 4] normalized to [0.6, 0.8] as little-endian float32.
+	require.Equal(t, "mpkZP83MTD8=", resp.Data[0].Embedding.Value)
+}
+
 func TestEmbeddingOpenAIToAWSBedrockTranslator_ResponseError(t *testing.T) {
 	tests := []struct {
 		name           string
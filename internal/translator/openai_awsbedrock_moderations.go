@@ -0,0 +1,152 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/awsbedrock"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+	"github.com/envoyproxy/ai-gateway/internal/json"
+	"github.com/envoyproxy/ai-gateway/internal/metrics"
+	"github.com/envoyproxy/ai-gateway/internal/tracing/tracingapi"
+)
+
+const (
+	// awsBedrockGuardrailDraftVersion is the version of the guardrail used when the model doesn't specify one.
+	awsBedrockGuardrailDraftVersion = "DRAFT"
+	// awsBedrockGuardrailFilterBlocked is the action of a content filter that blocked the content.
+	awsBedrockGuardrailFilterBlocked = "BLOCKED"
+)
+
+var (
+	// awsBedrockGuardrailCategories maps the content filters of the Bedrock guardrails to the OpenAI moderation
+	// categories. The prompt attack filter has no equivalent category, but still flags the result when it intervenes.
+	awsBedrockGuardrailCategories = map[string]string{
+		"HATE":       openai.ModerationCategoryHate,
+		"INSULTS":    openai.ModerationCategoryHarassment,
+		"SEXUAL":     openai.ModerationCategorySexual,
+		"VIOLENCE":   openai.ModerationCategoryViolence,
+		"MISCONDUCT": openai.ModerationCategoryIllicit,
+	}
+	// awsBedrockGuardrailConfidenceScores maps the confidences of the content filters to the scores.
+	awsBedrockGuardrailConfidenceScores = map[string]float64{
+		"NONE":   0,
+		"LOW":    1.0 / 3,
+		"MEDIUM": 2.0 / 3,
+		"HIGH":   1,
+	}
+)
+
+// NewModerationOpenAIToAWSBedrockTranslator implements [Factory] for OpenAI to AWS Bedrock Guardrails translation
+// for moderations.
+func NewModerationOpenAIToAWSBedrockTranslator(modelNameOverride internalapi.ModelNameOverride) OpenAIModerationTranslator {
+	return &openAIToAWSBedrockTranslatorV1Moderation{modelNameOverride: modelNameOverride}
+}
+
+// openAIToAWSBedrockTranslatorV1Moderation translates the OpenAI moderation requests to the ApplyGuardrail requests
+// of AWS Bedrock: https://docs.aws.amazon.com/bedrock/latest/APIReference/API_runtime_ApplyGuardrail.html
+//
+// The model is the identifier of the guardrail, optionally followed by its version after a colon, e.g.
+// "gr-abc123:1". The draft version is used by default. The content filters are scored by their confidence and
+// flagged when they block the content, and the result is flagged when the guardrail intervenes.
+type openAIToAWSBedrockTranslatorV1Moderation struct {
+	modelNameOverride internalapi.ModelNameOverride
+	requestModel      internalapi.RequestModel
+}
+
+// RequestBody implements [OpenAIModerationTranslator.RequestBody].
+func (o *openAIToAWSBedrockTranslatorV1Moderation) RequestBody(_ []byte, req *openai.ModerationRequest, _ bool) (
+	newHeaders []internalapi.Header, newBody []byte, err error,
+) {
+	o.requestModel = req.Model
+	if o.modelNameOverride != "" {
+		o.requestModel = o.modelNameOverride
+	}
+	if o.requestModel == "" {
+		return nil, nil, fmt.Errorf("%w: the model must be the identifier of the AWS Bedrock guardrail", internalapi.ErrInvalidRequestBody)
+	}
+	text, err := moderationSingleTextInput(req, "AWS Bedrock Guardrails")
+	if err != nil {
+		return nil, nil, err
+	}
+	guardrailID, version, ok := strings.Cut(o.requestModel, ":")
+	if !ok || version == "" {
+		version = awsBedrockGuardrailDraftVersion
+	}
+
+	newBody, err = json.Marshal(awsbedrock.ApplyGuardrailRequest{
+		Source:  awsbedrock.GuardrailSourceInput,
+		Content: []awsbedrock.ApplyGuardrailContentBlock{{Text: &awsbedrock.GuardrailConverseTextBlock{Text: &text}}},
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal body: %w", err)
+	}
+	newHeaders = []internalapi.Header{
+		{pathHeaderName, fmt.Sprintf("/guardrail/%s/version/%s/apply", url.PathEscape(guardrailID), url.PathEscape(version))},
+		{contentLengthHeaderName, strconv.Itoa(len(newBody))},
+	}
+	return
+}
+
+// ResponseHeaders implements [OpenAIModerationTranslator.ResponseHeaders].
+func (o *openAIToAWSBedrockTranslatorV1Moderation) ResponseHeaders(map[string]string) (newHeaders []internalapi.Header, err error) {
+	return nil, nil
+}
+
+// ResponseBody implements [OpenAIModerationTranslator.ResponseBody].
+func (o *openAIToAWSBedrockTranslatorV1Moderation) ResponseBody(_ map[string]string, body io.Reader, _ bool, span tracingapi.ModerationSpan) (
+	newHeaders []internalapi.Header, newBody []byte, tokenUsage metrics.TokenUsage, responseModel internalapi.ResponseModel, err error,
+) {
+	var bedrockResp awsbedrock.ApplyGuardrailResponse
+	if err = json.NewDecoder(body).Decode(&bedrockResp); err != nil {
+		return nil, nil, tokenUsage, o.requestModel, fmt.Errorf("failed to unmarshal body: %w", err)
+	}
+
+	result := newModerationResult()
+	for _, a := range bedrockResp.Assessments {
+		if a.ContentPolicy == nil {
+			continue
+		}
+		for _, f := range a.ContentPolicy.Filters {
+			if category, ok := awsBedrockGuardrailCategories[f.Type]; ok {
+				setModerationCategory(&result, category, awsBedrockGuardrailConfidenceScores[f.Confidence], f.Action == awsBedrockGuardrailFilterBlocked)
+			}
+		}
+	}
+	if bedrockResp.Action == awsbedrock.GuardrailActionIntervened {
+		result.Flagged = true
+	}
+	newHeaders, newBody, err = moderationResponseBody(o.requestModel, result, span)
+	return newHeaders, newBody, tokenUsage, o.requestModel, err
+}
+
+// ResponseError implements [OpenAIModerationTranslator.ResponseError].
+// Translates the AWS Bedrock exceptions to the OpenAI error format.
+func (o *openAIToAWSBedrockTranslatorV1Moderation) ResponseError(respHeaders map[string]string, body io.Reader) ([]internalapi.Header, []byte, error) {
+	rawBody, err := io.ReadAll(body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read error body: %w", err)
+	}
+	var openaiErr openai.Error
+	if isJSON(respHeaders[contentTypeHeaderName]) {
+		if openaiErr, err = translateBedrockJSONError(rawBody, respHeaders[awsErrorTypeHeaderName], respHeaders[statusHeaderName]); err != nil {
+			return nil, nil, err
+		}
+	} else {
+		openaiErr = buildGenericError(string(rawBody), respHeaders[statusHeaderName])
+	}
+	newBody, err := json.Marshal(openaiErr)
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshal error body: %w", err)
+	}
+	return buildHeaders(newBody), newBody, nil
+}
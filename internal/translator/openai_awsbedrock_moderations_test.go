@@ -0,0 +1,126 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+	"github.com/envoyproxy/ai-gateway/internal/json"
+)
+
+func TestOpenAIToAWSBedrockTranslatorV1ModerationRequestBody(t *testing.T) {
+	text := "some text"
+	for _, tc := range []struct {
+		name              string
+		model             string
+		modelNameOverride internalapi.ModelNameOverride
+		expPath           string
+	}{
+		{name: "draft version", model: "gr-abc123", expPath: "/guardrail/gr-abc123/version/DRAFT/apply"},
+		{name: "version", model: "gr-abc123:2", expPath: "/guardrail/gr-abc123/version/2/apply"},
+		{name: "model name override", model: "moderation", modelNameOverride: "gr-xyz:1", expPath: "/guardrail/gr-xyz/version/1/apply"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := &openai.ModerationRequest{Model: tc.model, Input: openai.ModerationInput{OfString: &text}}
+			headers, body, err := NewModerationOpenAIToAWSBedrockTranslator(tc.modelNameOverride).RequestBody(nil, req, false)
+			require.NoError(t, err)
+			require.JSONEq(t, `{"source":"INPUT","content":[{"text":{"text":"some text"}}]}`, string(body))
+			require.Equal(t, []internalapi.Header{{pathHeaderName, tc.expPath}, {contentLengthHeaderName, "60"}}, headers)
+		})
+	}
+
+	t.Run("no model", func(t *testing.T) {
+		_, _, err := NewModerationOpenAIToAWSBedrockTranslator("").RequestBody(nil, &openai.ModerationRequest{Input: openai.ModerationInput{OfString: &text}}, false)
+		require.ErrorIs(t, err, internalapi.ErrInvalidRequestBody)
+	})
+	t.Run("multiple texts", func(t *testing.T) {
+		req := &openai.ModerationRequest{Model: "gr-abc123", Input: openai.ModerationInput{OfStringArray: []string{"a", "b"}}}
+		_, _, err := NewModerationOpenAIToAWSBedrockTranslator("").RequestBody(nil, req, false)
+		require.ErrorIs(t, err, internalapi.ErrInvalidRequestBody)
+		require.ErrorContains(t, err, "AWS Bedrock Guardrails only supports a single text input")
+	})
+}
+
+func TestOpenAIToAWSBedrockTranslatorV1ModerationResponseBody(t *testing.T) {
+	text := "some text"
+	req := &openai.ModerationRequest{Model: "gr-abc123:1", Input: openai.ModerationInput{OfString: &text}}
+
+	for _, tc := range []struct {
+		name       string
+		body       string
+		expFlagged bool
+		expFlags   map[string]bool
+		expScores  map[string]float64
+	}{
+		{
+			name:      "none",
+			body:      `{"action":"NONE","assessments":[{"contentPolicy":{"filters":[{"type":"HATE","confidence":"LOW","action":"NONE"}]}}]}`,
+			expFlags:  map[string]bool{},
+			expScores: map[string]float64{openai.ModerationCategoryHate: 1.0 / 3},
+		},
+		{
+			name: "intervened",
+			body: `{"action":"GUARDRAIL_INTERVENED","assessments":[{"contentPolicy":{"filters":[` +
+				`{"type":"VIOLENCE","confidence":"HIGH","action":"BLOCKED"},{"type":"INSULTS","confidence":"MEDIUM","action":"BLOCKED"},` +
+				`{"type":"MISCONDUCT","confidence":"LOW","action":"NONE"}]}}]}`,
+			expFlagged: true,
+			expFlags:   map[string]bool{openai.ModerationCategoryViolence: true, openai.ModerationCategoryHarassment: true},
+			expScores: map[string]float64{
+				openai.ModerationCategoryViolence:   1,
+				openai.ModerationCategoryHarassment: 2.0 / 3,
+				openai.ModerationCategoryIllicit:    1.0 / 3,
+			},
+		},
+		{
+			name:       "intervened by other policy",
+			body:       `{"action":"GUARDRAIL_INTERVENED","assessments":[{"wordPolicy":{"customWords":[{"match":"some","action":"BLOCKED"}]}}]}`,
+			expFlagged: true,
+			expFlags:   map[string]bool{},
+			expScores:  map[string]float64{},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tr := NewModerationOpenAIToAWSBedrockTranslator("")
+			_, _, err := tr.RequestBody(nil, req, false)
+			require.NoError(t, err)
+
+			span := &mockModerationSpan{}
+			_, body, _, responseModel, err := tr.ResponseBody(nil, strings.NewReader(tc.body), true, span)
+			require.NoError(t, err)
+			require.Equal(t, "gr-abc123:1", responseModel)
+
+			var resp openai.ModerationResponse
+			require.NoError(t, json.Unmarshal(body, &resp))
+			require.Len(t, resp.Results, 1)
+			result := resp.Results[0]
+			require.Equal(t, tc.expFlagged, result.Flagged)
+			for _, c := range moderationCategories {
+				require.Equal(t, tc.expFlags[c], result.Categories[c], c)
+				require.InDelta(t, tc.expScores[c], result.CategoryScores[c], 1e-9, c)
+			}
+			require.NotNil(t, span.resp)
+		})
+	}
+}
+
+func TestOpenAIToAWSBedrockTranslatorV1ModerationResponseError(t *testing.T) {
+	tr := NewModerationOpenAIToAWSBedrockTranslator("")
+	_, body, err := tr.ResponseError(map[string]string{
+		statusHeaderName:       "404",
+		contentTypeHeaderName:  "application/json",
+		awsErrorTypeHeaderName: "ResourceNotFoundException",
+	}, strings.NewReader(`{"message":"guardrail not found"}`))
+	require.NoError(t, err)
+	var openAIErr openai.Error
+	require.NoError(t, json.Unmarshal(body, &openAIErr))
+	require.Equal(t, "guardrail not found", openAIErr.Error.Message)
+	require.Equal(t, "ResourceNotFoundException", openAIErr.Error.Type)
+}
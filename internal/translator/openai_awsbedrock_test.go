@@ -2055,6 +2055,42 @@ func TestOpenAIToAWSBedrockTranslatorExtractAmazonEventStreamEvents(t *testing.T
 		require.Equal(t, eventBytes[offsets[2]:offsets[2]+5], o.bufferedBody)
 	})
 
+	t.Run("several deltas in a chunk", func(t *testing.T) {
+		// The strings of the events point into their payloads, so these must not share a buffer.
+		delta := awsbedrock.ConverseStreamEventTypeContentBlockDelta.String()
+		chunk := bytes.NewBuffer(nil)
+		for _, data := range []awsbedrock.ConverseStreamEvent{
+			{EventType: delta, Delta: &awsbedrock.ConverseStreamEventContentBlockDelta{Text: ptr.To("Hello")}},
+			{EventType: delta, Delta: &awsbedrock.ConverseStreamEventContentBlockDelta{Text: ptr.To(", world")}},
+			{EventType: delta, Delta: &awsbedrock.ConverseStreamEventContentBlockDelta{ToolUse: &awsbedrock.ToolUseBlockDelta{Input: `{"a":1}`}}},
+			{EventType: delta, Delta: &awsbedrock.ConverseStreamEventContentBlockDelta{Text: ptr.To("!")}},
+		} {
+			eventPayload, err := json.Marshal(data)
+			require.NoError(t, err)
+			require.NoError(t, e.Encode(chunk, eventstream.Message{
+				Headers: eventstream.Headers{{Name: ":event-type", Value: eventstream.StringValue(delta)}},
+				Payload: eventPayload,
+			}))
+		}
+		o := &openAIToAWSBedrockTranslatorV1ChatCompletion{stream: true}
+		_, body, _, _, err := o.ResponseBody(nil, chunk, false, nil)
+		require.NoError(t, err)
+		var contents, arguments []string
+		for line := range bytes.Lines(body) {
+			var c openai.ChatCompletionResponseChunk
+			if err := json.Unmarshal(bytes.TrimPrefix(bytes.TrimSpace(line), sseDataPrefix), &c); err != nil || len(c.Choices) == 0 {
+				continue
+			}
+			if delta := c.Choices[0].Delta; delta.Content != nil {
+				contents = append(contents, *delta.Content)
+			} else if len(delta.ToolCalls) > 0 {
+				arguments = append(arguments, delta.ToolCalls[0].Function.Arguments)
+			}
+		}
+		require.Equal(t, []string{"Hello", ", world", "!"}, contents)
+		require.Equal(t, []string{`{"a":1}`}, arguments)
+	})
+
 	t.Run("real events", func(t *testing.T) {
 		o := &openAIToAWSBedrockTranslatorV1ChatCompletion{}
 		var err error
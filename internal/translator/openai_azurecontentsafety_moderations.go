@@ -0,0 +1,110 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/azure"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+	"github.com/envoyproxy/ai-gateway/internal/json"
+	"github.com/envoyproxy/ai-gateway/internal/metrics"
+	"github.com/envoyproxy/ai-gateway/internal/tracing/tracingapi"
+)
+
+const (
+	// azureContentSafetyAPIVersion is the version of the Azure AI Content Safety API the moderations are sent to.
+	azureContentSafetyAPIVersion = "2024-09-01"
+	// azureContentSafetyMaxSeverity is the highest severity of the EightSeverityLevels output type.
+	azureContentSafetyMaxSeverity = 7
+	// azureContentSafetyFlaggedSeverity is the lowest severity that flags a category, i.e. the medium severity.
+	azureContentSafetyFlaggedSeverity = 4
+)
+
+// azureContentSafetyCategories maps the Azure AI Content Safety harm categories to the OpenAI moderation categories.
+var azureContentSafetyCategories = map[string]string{
+	azure.ContentSafetyCategoryHate:     openai.ModerationCategoryHate,
+	azure.ContentSafetyCategorySelfHarm: openai.ModerationCategorySelfHarm,
+	azure.ContentSafetyCategorySexual:   openai.ModerationCategorySexual,
+	azure.ContentSafetyCategoryViolence: openai.ModerationCategoryViolence,
+}
+
+// NewModerationOpenAIToAzureContentSafetyTranslator implements [Factory] for OpenAI to Azure AI Content Safety
+// translation for moderations.
+func NewModerationOpenAIToAzureContentSafetyTranslator(modelNameOverride internalapi.ModelNameOverride) OpenAIModerationTranslator {
+	return &openAIToAzureContentSafetyTranslatorV1Moderation{modelNameOverride: modelNameOverride}
+}
+
+// openAIToAzureContentSafetyTranslatorV1Moderation translates the OpenAI moderation requests to the text:analyze
+// requests of Azure AI Content Safety:
+// https://learn.microsoft.com/en-us/rest/api/contentsafety/text-operations/analyze-text
+//
+// The severities from 0 to 7 are scored from 0 to 1, and the categories of the medium severity or higher are flagged.
+// The text matching a blocklist flags the result without flagging a category.
+type openAIToAzureContentSafetyTranslatorV1Moderation struct {
+	modelNameOverride internalapi.ModelNameOverride
+	requestModel      internalapi.RequestModel
+}
+
+// RequestBody implements [OpenAIModerationTranslator.RequestBody].
+func (o *openAIToAzureContentSafetyTranslatorV1Moderation) RequestBody(_ []byte, req *openai.ModerationRequest, _ bool) (
+	newHeaders []internalapi.Header, newBody []byte, err error,
+) {
+	o.requestModel = req.Model
+	if o.modelNameOverride != "" {
+		o.requestModel = o.modelNameOverride
+	}
+	text, err := moderationSingleTextInput(req, "Azure AI Content Safety")
+	if err != nil {
+		return nil, nil, err
+	}
+	newBody, err = json.Marshal(azure.AnalyzeTextRequest{Text: text, OutputType: azure.ContentSafetyOutputTypeEightSeverityLevels})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal body: %w", err)
+	}
+	newHeaders = []internalapi.Header{
+		{pathHeaderName, "/contentsafety/text:analyze?api-version=" + azureContentSafetyAPIVersion},
+		{contentLengthHeaderName, strconv.Itoa(len(newBody))},
+	}
+	return
+}
+
+// ResponseHeaders implements [OpenAIModerationTranslator.ResponseHeaders].
+func (o *openAIToAzureContentSafetyTranslatorV1Moderation) ResponseHeaders(map[string]string) (newHeaders []internalapi.Header, err error) {
+	return nil, nil
+}
+
+// ResponseBody implements [OpenAIModerationTranslator.ResponseBody].
+func (o *openAIToAzureContentSafetyTranslatorV1Moderation) ResponseBody(_ map[string]string, body io.Reader, _ bool, span tracingapi.ModerationSpan) (
+	newHeaders []internalapi.Header, newBody []byte, tokenUsage metrics.TokenUsage, responseModel internalapi.ResponseModel, err error,
+) {
+	var azureResp azure.AnalyzeTextResponse
+	if err = json.NewDecoder(body).Decode(&azureResp); err != nil {
+		return nil, nil, tokenUsage, o.requestModel, fmt.Errorf("failed to unmarshal body: %w", err)
+	}
+
+	result := newModerationResult()
+	for _, a := range azureResp.CategoriesAnalysis {
+		if category, ok := azureContentSafetyCategories[a.Category]; ok {
+			setModerationCategory(&result, category,
+				float64(a.Severity)/azureContentSafetyMaxSeverity, a.Severity >= azureContentSafetyFlaggedSeverity)
+		}
+	}
+	if len(azureResp.BlocklistsMatch) > 0 {
+		result.Flagged = true
+	}
+	newHeaders, newBody, err = moderationResponseBody(o.requestModel, result, span)
+	return newHeaders, newBody, tokenUsage, o.requestModel, err
+}
+
+// ResponseError implements [OpenAIModerationTranslator.ResponseError].
+// The Azure AI Content Safety errors have the same shape as the OpenAI ones.
+func (o *openAIToAzureContentSafetyTranslatorV1Moderation) ResponseError(respHeaders map[string]string, body io.Reader) ([]internalapi.Header, []byte, error) {
+	return convertErrorOpenAIToOpenAIError(respHeaders, body)
+}
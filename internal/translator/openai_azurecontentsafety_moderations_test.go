@@ -0,0 +1,111 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+	"github.com/envoyproxy/ai-gateway/internal/json"
+)
+
+func TestOpenAIToAzureContentSafetyTranslatorV1ModerationRequestBody(t *testing.T) {
+	t.Run("single text", func(t *testing.T) {
+		text := "some text"
+		req := &openai.ModerationRequest{Model: "content-safety", Input: openai.ModerationInput{OfString: &text}}
+		headers, body, err := NewModerationOpenAIToAzureContentSafetyTranslator("").RequestBody(nil, req, false)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"text":"some text","outputType":"EightSeverityLevels"}`, string(body))
+		require.Equal(t, []internalapi.Header{
+			{pathHeaderName, "/contentsafety/text:analyze?api-version=2024-09-01"},
+			{contentLengthHeaderName, "55"},
+		}, headers)
+	})
+	for _, input := range []openai.ModerationInput{
+		{OfStringArray: []string{"a", "b"}},
+		{OfMultiModal: []openai.ModerationMultiModalInput{{Type: openai.ModerationInputTypeImageURL, ImageURL: &openai.ModerationImageURL{URL: "https://example.com/a.png"}}}},
+	} {
+		_, _, err := NewModerationOpenAIToAzureContentSafetyTranslator("").RequestBody(nil, &openai.ModerationRequest{Input: input}, false)
+		require.ErrorIs(t, err, internalapi.ErrInvalidRequestBody)
+		require.ErrorContains(t, err, "Azure AI Content Safety only supports a single text input")
+	}
+}
+
+func TestOpenAIToAzureContentSafetyTranslatorV1ModerationResponseBody(t *testing.T) {
+	text := "some text"
+	req := &openai.ModerationRequest{Model: "content-safety", Input: openai.ModerationInput{OfStringArray: []string{text}}}
+
+	for _, tc := range []struct {
+		name       string
+		body       string
+		expFlagged bool
+		expFlags   map[string]bool
+		expScores  map[string]float64
+	}{
+		{
+			name:      "clean",
+			body:      `{"blocklistsMatch":[],"categoriesAnalysis":[{"category":"Hate","severity":0},{"category":"Violence","severity":2}]}`,
+			expFlags:  map[string]bool{},
+			expScores: map[string]float64{openai.ModerationCategoryViolence: 2.0 / 7},
+		},
+		{
+			name:       "flagged",
+			body:       `{"categoriesAnalysis":[{"category":"SelfHarm","severity":6},{"category":"Sexual","severity":4}]}`,
+			expFlagged: true,
+			expFlags:   map[string]bool{openai.ModerationCategorySelfHarm: true, openai.ModerationCategorySexual: true},
+			expScores:  map[string]float64{openai.ModerationCategorySelfHarm: 6.0 / 7, openai.ModerationCategorySexual: 4.0 / 7},
+		},
+		{
+			name:       "blocklist match",
+			body:       `{"blocklistsMatch":[{"blocklistName":"words","blocklistItemId":"1","blocklistItemText":"some"}],"categoriesAnalysis":[]}`,
+			expFlagged: true,
+			expFlags:   map[string]bool{},
+			expScores:  map[string]float64{},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tr := NewModerationOpenAIToAzureContentSafetyTranslator("")
+			_, _, err := tr.RequestBody(nil, req, false)
+			require.NoError(t, err)
+
+			span := &mockModerationSpan{}
+			headers, body, _, responseModel, err := tr.ResponseBody(nil, strings.NewReader(tc.body), true, span)
+			require.NoError(t, err)
+			require.Equal(t, "content-safety", responseModel)
+			require.Equal(t, contentLengthHeaderName, headers[0].Key())
+
+			var resp openai.ModerationResponse
+			require.NoError(t, json.Unmarshal(body, &resp))
+			require.Equal(t, "content-safety", resp.Model)
+			require.True(t, strings.HasPrefix(resp.ID, "modr-"))
+			require.Len(t, resp.Results, 1)
+			result := resp.Results[0]
+			require.Equal(t, tc.expFlagged, result.Flagged)
+			require.Len(t, result.Categories, len(moderationCategories))
+			for _, c := range moderationCategories {
+				require.Equal(t, tc.expFlags[c], result.Categories[c], c)
+				require.InDelta(t, tc.expScores[c], result.CategoryScores[c], 1e-9, c)
+			}
+			require.Equal(t, resp.Results, span.resp.Results)
+		})
+	}
+
+	t.Run("invalid body", func(t *testing.T) {
+		_, _, _, _, err := NewModerationOpenAIToAzureContentSafetyTranslator("").ResponseBody(nil, strings.NewReader("not json"), true, nil)
+		require.ErrorContains(t, err, "failed to unmarshal body")
+	})
+}
+
+func TestOpenAIToAzureContentSafetyTranslatorV1ModerationResponseError(t *testing.T) {
+	tr := NewModerationOpenAIToAzureContentSafetyTranslator("")
+	_, body, err := tr.ResponseError(map[string]string{statusHeaderName: "401", contentTypeHeaderName: "text/plain"}, strings.NewReader("access denied"))
+	require.NoError(t, err)
+	require.Contains(t, string(body), "access denied")
+}
@@ -52,6 +52,11 @@ type openAIToGCPAnthropicTranslatorV1ChatCompletion struct {
 	logger          *slog.Logger
 }
 
+// UnsupportedParameters implements [UnsupportedParametersLister.UnsupportedParameters].
+func (o *openAIToGCPAnthropicTranslatorV1ChatCompletion) UnsupportedParameters(raw []byte) []string {
+	return presentUnsupportedParameters(raw, gcpAnthropicUnsupportedParameters)
+}
+
 // RequestBody implements [OpenAIChatCompletionTranslator.RequestBody] for GCP.
 func (o *openAIToGCPAnthropicTranslatorV1ChatCompletion) RequestBody(_ []byte, openAIReq *openai.ChatCompletionRequest, _ bool) (
 	newHeaders []internalapi.Header, newBody []byte, err error,
@@ -14,6 +14,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/google/jsonschema-go/jsonschema"
 	"github.com/google/uuid"
 	"google.golang.org/genai"
 	"k8s.io/utils/ptr"
@@ -88,12 +89,20 @@ type openAIToGCPVertexAITranslatorV1ChatCompletion struct {
 	// longer carries the functionCall part, so the finish_reason must be derived
 	// from the whole stream, not just the current chunk.
 	streamedToolCall bool
+	// strictToolSchemas is the parameters schemas of the strict function tools that the tool calls in the
+	// response are validated against, for the models that cannot constrain the function calls to the schemas.
+	strictToolSchemas map[string]*jsonschema.Resolved
 	// Redaction configuration for debug logging
 	debugLogEnabled bool
 	enableRedaction bool
 	logger          *slog.Logger
 }
 
+// UnsupportedParameters implements [UnsupportedParametersLister.UnsupportedParameters].
+func (o *openAIToGCPVertexAITranslatorV1ChatCompletion) UnsupportedParameters(raw []byte) []string {
+	return presentUnsupportedParameters(raw, geminiUnsupportedParameters)
+}
+
 // RequestBody implements [OpenAIChatCompletionTranslator.RequestBody] for GCP Gemini.
 // This method translates an OpenAI ChatCompletion request to a GCP Gemini API request.
 func (o *openAIToGCPVertexAITranslatorV1ChatCompletion) RequestBody(_ []byte, openAIReq *openai.ChatCompletionRequest, _ bool) (
@@ -282,7 +291,8 @@ func (o *openAIToGCPVertexAITranslatorV1ChatCompletion) handleStreamingResponse(
 func (o *openAIToGCPVertexAITranslatorV1ChatCompletion) parseGCPStreamingChunks(body io.Reader) ([]genai.GenerateContentResponse, error) {
 	var chunks []genai.GenerateContentResponse
 
-	// Read all data from buffered body and new input into memory.
+	// Read all data from buffered body and new input into memory. This is not a pooled buffer, since the strings of
+	// the chunks decoded from it are kept by the span until the end of the stream.
 	bodyReader := io.MultiReader(bytes.NewReader(o.bufferedBody), body)
 	allData, err := io.ReadAll(bodyReader)
 	if err != nil {
@@ -299,16 +309,14 @@ func (o *openAIToGCPVertexAITranslatorV1ChatCompletion) parseGCPStreamingChunks(
 		o.streamDelimiter = detectSSEDelimiter(allData)
 	}
 
-	// Split by the detected delimiter.
-	var parts [][]byte
-	if o.streamDelimiter != nil {
-		parts = bytes.Split(allData, o.streamDelimiter)
-	} else {
-		parts = [][]byte{allData}
-	}
-
-	// Process all complete chunks (all but the last part).
-	for _, part := range parts {
+	// Process the parts split by the detected delimiter.
+	for remaining, more := allData, true; more; {
+		var part []byte
+		if o.streamDelimiter != nil {
+			part, remaining, more = bytes.Cut(remaining, o.streamDelimiter)
+		} else {
+			part, more = remaining, false
+		}
 		part = bytes.TrimSpace(part)
 		if len(part) == 0 {
 			continue
@@ -351,6 +359,9 @@ func (o *openAIToGCPVertexAITranslatorV1ChatCompletion) extractToolCallsFromGemi
 		if err != nil {
 			return nil, "", fmt.Errorf("failed to marshal function arguments: %w", err)
 		}
+		if err = validateStrictToolCall(o.strictToolSchemas, part.FunctionCall.Name, string(args)); err != nil {
+			return nil, "", err
+		}
 
 		// Generate a random ID for the tool call.
 		toolCallID := uuid.New().String()
@@ -544,6 +555,16 @@ func (o *openAIToGCPVertexAITranslatorV1ChatCompletion) openAIMessageToGeminiMes
 		return nil, fmt.Errorf("invalid tool configs: %w", err)
 	}
 
+	// The strict function tools are enforced with the constrained decoding of the models supporting the JSON schema
+	// parameters. For the other models, the tool calls in the response are validated instead.
+	if hasStrictFunctionTool(openAIReq.Tools) {
+		if parametersJSONSchemaAvailable {
+			toolConfig = geminiStrictToolConfig(toolConfig)
+		} else if o.strictToolSchemas, err = strictToolSchemas(openAIReq.Tools); err != nil {
+			return nil, fmt.Errorf("invalid tools: %w", err)
+		}
+	}
+
 	// Convert generation config.
 	generationConfig, responseMode, err := openAIReqToGeminiGenerationConfig(openAIReq, requestModel)
 	if err != nil {
@@ -591,6 +612,9 @@ func (o *openAIToGCPVertexAITranslatorV1ChatCompletion) applyVendorSpecificField
 	if gcpVendorFields.SafetySettings != nil {
 		gcr.SafetySettings = gcpVendorFields.SafetySettings
 	}
+	if gcpVendorFields.CachedContent != "" {
+		gcr.CachedContent = gcpVendorFields.CachedContent
+	}
 }
 
 func (o *openAIToGCPVertexAITranslatorV1ChatCompletion) geminiResponseToOpenAIMessage(gcr *genai.GenerateContentResponse, responseModel string) (*openai.ChatCompletionResponse, error) {
@@ -599,6 +623,13 @@ func (o *openAIToGCPVertexAITranslatorV1ChatCompletion) geminiResponseToOpenAIMe
 	if err != nil {
 		return nil, fmt.Errorf("error converting choices: %w", err)
 	}
+	for i := range choices {
+		for _, tc := range choices[i].Message.ToolCalls {
+			if err = validateStrictToolCall(o.strictToolSchemas, tc.Function.Name, tc.Function.Arguments); err != nil {
+				return nil, err
+			}
+		}
+	}
 
 	// Set up the OpenAI response.
 	openaiResp := &openai.ChatCompletionResponse{
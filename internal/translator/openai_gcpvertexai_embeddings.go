@@ -45,6 +45,10 @@ type openAIToGCPVertexAITranslatorV1Embedding struct {
 	requestModel      internalapi.RequestModel
 	modelNameOverride internalapi.ModelNameOverride
 	useEmbedContent   bool
+	// dimensions and encodingFormat are the ones requested by the client. Vertex AI supports the dimensions
+	// natively, but not the base64 encoding format, which is emulated on the response.
+	dimensions     *int
+	encodingFormat *string
 }
 
 // createInstancesFromEmbeddingInputItem converts an EmbeddingInputItem to GCP Instance(s).
@@ -290,6 +294,8 @@ func (o *openAIToGCPVertexAITranslatorV1Embedding) RequestBody(_ []byte, req *op
 		// Use modelName override if set.
 		o.requestModel = o.modelNameOverride
 	}
+	o.dimensions = req.Dimensions
+	o.encodingFormat = req.EncodingFormat
 
 	var path string
 
@@ -409,7 +415,7 @@ func (o *openAIToGCPVertexAITranslatorV1Embedding) parsePredictResponse(respBody
 				openaiResp.Data[i] = openai.Embedding{
 					Object:    "embedding",
 					Index:     i,
-					Embedding: openai.EmbeddingUnion{Value: float64Values},
+					Embedding: formatEmbedding(float64Values, o.dimensions, o.encodingFormat),
 				}
 				if prediction.Embeddings.Statistics != nil {
 					promptTokens += prediction.Embeddings.Statistics.TokenCount
@@ -445,7 +451,7 @@ func (o *openAIToGCPVertexAITranslatorV1Embedding) parseEmbedContentResponse(res
 		openaiResp.Data = []openai.Embedding{{
 			Object:    "embedding",
 			Index:     0,
-			Embedding: openai.EmbeddingUnion{Value: float64Values},
+			Embedding: formatEmbedding(float64Values, o.dimensions, o.encodingFormat),
 			Truncated: gcpResp.Truncated,
 		}}
 		if gcpResp.UsageMetadata != nil {
@@ -217,6 +217,24 @@ func TestOpenAIToGCPVertexAITranslatorV1ChatCompletion_RequestBody(t *testing.T)
     "safetySettings": [{"category": "HARM_CATEGORY_HARASSMENT", "threshold": "BLOCK_ONLY_HIGH"}]
 }`)
 
+	wantBdyWithCachedContent := []byte(`{
+    "contents": [
+        {
+            "parts": [
+                {
+                    "text": "Summarize the cached document"
+                }
+            ],
+            "role": "user"
+        }
+    ],
+    "tools": null,
+    "generationConfig": {
+        "maxOutputTokens": 1024
+    },
+    "cachedContent": "projects/test-project/locations/us-central1/cachedContents/123"
+}`)
+
 	wantBdyWithMediaResolutionFields := []byte(`{
     "contents": [
         {
@@ -629,6 +647,30 @@ func TestOpenAIToGCPVertexAITranslatorV1ChatCompletion_RequestBody(t *testing.T)
 			},
 			wantBody: wantBdyWithSafetySettingFields,
 		},
+		{
+			name: "Request with gcp cached content",
+			input: openai.ChatCompletionRequest{
+				Model:     "gemini-1.5-pro",
+				MaxTokens: ptr.To(int64(1024)),
+				Messages: []openai.ChatCompletionMessageParamUnion{
+					{
+						OfUser: &openai.ChatCompletionUserMessageParam{
+							Role:    openai.ChatMessageRoleUser,
+							Content: openai.StringOrUserRoleContentUnion{Value: "Summarize the cached document"},
+						},
+					},
+				},
+				GCPVertexAIVendorFields: &openai.GCPVertexAIVendorFields{
+					CachedContent: "projects/test-project/locations/us-central1/cachedContents/123",
+				},
+			},
+			onRetry:   false,
+			wantError: false,
+			wantHeaderMut: []internalapi.Header{
+				{":path", "publishers/google/models/gemini-1.5-pro:generateContent"},
+			},
+			wantBody: wantBdyWithCachedContent,
+		},
 		{
 			name: "Request with media resolution fields",
 			input: openai.ChatCompletionRequest{
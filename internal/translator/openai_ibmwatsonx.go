@@ -0,0 +1,244 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/tidwall/sjson"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+	"github.com/envoyproxy/ai-gateway/internal/json"
+	"github.com/envoyproxy/ai-gateway/internal/metrics"
+	"github.com/envoyproxy/ai-gateway/internal/tracing/tracingapi"
+)
+
+const (
+	ibmWatsonxBackendError = "IBMWatsonxBackendError"
+	// ibmWatsonxDefaultAPIVersion is the watsonx.ai API version used when the schema doesn't specify one.
+	ibmWatsonxDefaultAPIVersion = "2024-05-31"
+)
+
+// NewChatCompletionOpenAIToIBMWatsonxTranslator implements [Factory] for OpenAI to IBM watsonx.ai translations.
+// The requests are sent to the deployment-scoped text chat endpoints, where the deployment ID or serving name
+// is the model name: https://cloud.ibm.com/apidocs/watsonx-ai#deployments-text-chat
+func NewChatCompletionOpenAIToIBMWatsonxTranslator(apiVersion string, modelNameOverride internalapi.ModelNameOverride) OpenAIChatCompletionTranslator {
+	return &openAIToIBMWatsonxTranslatorV1ChatCompletion{
+		apiVersion:        cmp.Or(apiVersion, ibmWatsonxDefaultAPIVersion),
+		modelNameOverride: modelNameOverride,
+	}
+}
+
+// openAIToIBMWatsonxTranslatorV1ChatCompletion translates the OpenAI chat completions to the watsonx.ai text chat.
+//
+// The watsonx.ai text chat request and response bodies are close to the OpenAI ones, but the model is identified
+// by the path, the tool choice modes are in a separate field, and the response reports the model as "model_id".
+// The streaming responses are server-sent events with "id" and "event" fields, which the OpenAI clients don't
+// expect, and without the terminating [DONE] event.
+type openAIToIBMWatsonxTranslatorV1ChatCompletion struct {
+	apiVersion        string
+	modelNameOverride internalapi.ModelNameOverride
+	requestModel      internalapi.RequestModel
+	responseModel     internalapi.ResponseModel
+	stream            bool
+	// buffered holds the incomplete event at the end of the previous chunk of a streaming response.
+	buffered []byte
+}
+
+// ibmWatsonxChatCompletionResponse is the response of the watsonx.ai text chat endpoint.
+type ibmWatsonxChatCompletionResponse struct {
+	openai.ChatCompletionResponse
+	ModelID string `json:"model_id,omitempty"`
+}
+
+// ibmWatsonxChatCompletionChunk is a server-sent event of the watsonx.ai text chat stream endpoint.
+type ibmWatsonxChatCompletionChunk struct {
+	openai.ChatCompletionResponseChunk
+	ModelID string `json:"model_id,omitempty"`
+}
+
+// ibmWatsonxError is the error response of the watsonx.ai API.
+type ibmWatsonxError struct {
+	Errors []struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"errors"`
+	Trace string `json:"trace"`
+}
+
+// RequestBody implements [OpenAIChatCompletionTranslator.RequestBody].
+func (o *openAIToIBMWatsonxTranslatorV1ChatCompletion) RequestBody(raw []byte, req *openai.ChatCompletionRequest, _ bool) (
+	newHeaders []internalapi.Header, newBody []byte, err error,
+) {
+	deploymentID := cmp.Or(o.modelNameOverride, req.Model)
+	o.requestModel = deploymentID
+	o.stream = req.Stream
+
+	newBody = raw
+	// The deployment is identified by the path, and the streaming is selected by the endpoint.
+	for _, key := range []string{"model", "stream", "stream_options", "max_completion_tokens"} {
+		if newBody, err = sjson.DeleteBytes(newBody, key); err != nil {
+			return nil, nil, fmt.Errorf("failed to delete %s: %w", key, err)
+		}
+	}
+	if req.MaxCompletionTokens != nil && req.MaxTokens == nil {
+		if newBody, err = sjson.SetBytesOptions(newBody, "max_tokens", *req.MaxCompletionTokens, sjsonOptions); err != nil {
+			return nil, nil, fmt.Errorf("failed to set max_tokens: %w", err)
+		}
+	}
+	// watsonx.ai only accepts the named tool choice in "tool_choice", and the modes in "tool_choice_option".
+	if req.ToolChoice != nil {
+		if mode, ok := req.ToolChoice.Value.(string); ok {
+			if newBody, err = sjson.DeleteBytes(newBody, "tool_choice"); err != nil {
+				return nil, nil, fmt.Errorf("failed to delete tool_choice: %w", err)
+			}
+			if newBody, err = sjson.SetBytesOptions(newBody, "tool_choice_option", mode, sjsonOptions); err != nil {
+				return nil, nil, fmt.Errorf("failed to set tool_choice_option: %w", err)
+			}
+		}
+	}
+
+	operation := "chat"
+	if o.stream {
+		operation = "chat_stream"
+	}
+	newHeaders = []internalapi.Header{
+		{pathHeaderName, fmt.Sprintf("/ml/v1/deployments/%s/text/%s?version=%s", deploymentID, operation, o.apiVersion)},
+		{contentLengthHeaderName, strconv.Itoa(len(newBody))},
+	}
+	return
+}
+
+// ResponseHeaders implements [OpenAIChatCompletionTranslator.ResponseHeaders].
+func (o *openAIToIBMWatsonxTranslatorV1ChatCompletion) ResponseHeaders(map[string]string) (newHeaders []internalapi.Header, err error) {
+	return nil, nil
+}
+
+// ResponseBody implements [OpenAIChatCompletionTranslator.ResponseBody].
+func (o *openAIToIBMWatsonxTranslatorV1ChatCompletion) ResponseBody(_ map[string]string, body io.Reader, endOfStream bool, span tracingapi.ChatCompletionSpan) (
+	newHeaders []internalapi.Header, newBody []byte, tokenUsage metrics.TokenUsage, responseModel string, err error,
+) {
+	if o.stream {
+		var buf []byte
+		buf, err = io.ReadAll(body)
+		if err != nil {
+			return nil, nil, tokenUsage, o.requestModel, fmt.Errorf("failed to read body: %w", err)
+		}
+		o.buffered = append(o.buffered, buf...)
+		newBody, tokenUsage = o.convertBufferedEvents(span)
+		if endOfStream {
+			newBody = append(newBody, sseDoneFullLine...)
+			newBody = append(newBody, '\n')
+		}
+		if newBody == nil {
+			// Send an empty chunk rather than the raw events until a complete event is received.
+			newBody = []byte{}
+		}
+		return nil, newBody, tokenUsage, cmp.Or(o.responseModel, o.requestModel), nil
+	}
+
+	var resp ibmWatsonxChatCompletionResponse
+	if err = json.NewDecoder(body).Decode(&resp); err != nil {
+		return nil, nil, tokenUsage, o.requestModel, fmt.Errorf("failed to unmarshal body: %w", err)
+	}
+	resp.Model = cmp.Or(resp.Model, resp.ModelID, o.requestModel)
+	resp.Object = "chat.completion"
+	setIBMWatsonxTokenUsage(&tokenUsage, &resp.Usage)
+	if span != nil {
+		span.RecordResponse(&resp.ChatCompletionResponse)
+	}
+	newBody, err = json.Marshal(&resp.ChatCompletionResponse)
+	if err != nil {
+		return nil, nil, tokenUsage, resp.Model, fmt.Errorf("failed to marshal body: %w", err)
+	}
+	newHeaders = []internalapi.Header{{contentLengthHeaderName, strconv.Itoa(len(newBody))}}
+	return newHeaders, newBody, tokenUsage, resp.Model, nil
+}
+
+// convertBufferedEvents converts the complete events in the buffer to the OpenAI chunks, and returns them with
+// the latest token usage found in the events.
+func (o *openAIToIBMWatsonxTranslatorV1ChatCompletion) convertBufferedEvents(span tracingapi.ChatCompletionSpan) (out []byte, tokenUsage metrics.TokenUsage) {
+	for {
+		i := bytes.Index(o.buffered, []byte("\n\n"))
+		if i == -1 {
+			return
+		}
+		event := o.buffered[:i]
+		o.buffered = o.buffered[i+2:]
+		for line := range bytes.SplitSeq(event, []byte("\n")) {
+			data, ok := bytes.CutPrefix(line, []byte("data:"))
+			if !ok {
+				continue
+			}
+			var chunk ibmWatsonxChatCompletionChunk
+			if err := json.Unmarshal(bytes.TrimSpace(data), &chunk); err != nil {
+				continue
+			}
+			chunk.Model = cmp.Or(chunk.Model, chunk.ModelID, o.requestModel)
+			chunk.Object = "chat.completion.chunk"
+			o.responseModel = chunk.Model
+			if chunk.Usage != nil {
+				setIBMWatsonxTokenUsage(&tokenUsage, chunk.Usage)
+			}
+			if span != nil {
+				span.RecordResponseChunk(&chunk.ChatCompletionResponseChunk)
+			}
+			converted, err := json.Marshal(&chunk.ChatCompletionResponseChunk)
+			if err != nil {
+				continue
+			}
+			out = append(out, sseDataPrefix...)
+			out = append(out, converted...)
+			out = append(out, '\n', '\n')
+		}
+	}
+}
+
+func setIBMWatsonxTokenUsage(tokenUsage *metrics.TokenUsage, usage *openai.Usage) {
+	tokenUsage.SetInputTokens(uint32(usage.PromptTokens))      //nolint:gosec
+	tokenUsage.SetOutputTokens(uint32(usage.CompletionTokens)) //nolint:gosec
+	tokenUsage.SetTotalTokens(uint32(usage.TotalTokens))       //nolint:gosec
+}
+
+// ResponseError implements [OpenAIChatCompletionTranslator.ResponseError].
+// Translates the watsonx.ai errors to the OpenAI error type.
+func (o *openAIToIBMWatsonxTranslatorV1ChatCompletion) ResponseError(respHeaders map[string]string, body io.Reader) (
+	newHeaders []internalapi.Header, newBody []byte, err error,
+) {
+	var buf []byte
+	buf, err = io.ReadAll(body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read error body: %w", err)
+	}
+	statusCode := respHeaders[statusHeaderName]
+	openaiError := openai.Error{
+		Type: "error",
+		Error: openai.ErrorType{
+			Type:    ibmWatsonxBackendError,
+			Code:    &statusCode,
+			Message: string(buf),
+		},
+	}
+	var watsonxError ibmWatsonxError
+	if err = json.Unmarshal(buf, &watsonxError); err == nil && len(watsonxError.Errors) > 0 {
+		openaiError.Error.Type = watsonxError.Errors[0].Code
+		openaiError.Error.Message = watsonxError.Errors[0].Message
+	}
+	newBody, err = json.Marshal(openaiError)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal error body: %w", err)
+	}
+	newHeaders = []internalapi.Header{
+		{contentTypeHeaderName, jsonContentType},
+		{contentLengthHeaderName, strconv.Itoa(len(newBody))},
+	}
+	return
+}
@@ -0,0 +1,159 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/json"
+)
+
+func TestOpenAIToIBMWatsonxTranslatorV1ChatCompletion_RequestBody(t *testing.T) {
+	maxCompletionTokens := int64(100)
+	for _, tc := range []struct {
+		name         string
+		raw          string
+		req          *openai.ChatCompletionRequest
+		override     string
+		expPath      string
+		expBody      string
+		expStreaming bool
+	}{
+		{
+			name:    "basic",
+			raw:     `{"model":"my-deployment","messages":[{"role":"user","content":"hi"}]}`,
+			req:     &openai.ChatCompletionRequest{Model: "my-deployment"},
+			expPath: "/ml/v1/deployments/my-deployment/text/chat?version=2024-05-31",
+			expBody: `{"messages":[{"role":"user","content":"hi"}]}`,
+		},
+		{
+			name: "streaming with max completion tokens and tool choice mode",
+			raw:  `{"model":"my-deployment","stream":true,"stream_options":{"include_usage":true},"max_completion_tokens":100,"tool_choice":"auto","messages":[]}`,
+			req: &openai.ChatCompletionRequest{
+				Model: "my-deployment", Stream: true, MaxCompletionTokens: &maxCompletionTokens,
+				ToolChoice: &openai.ChatCompletionToolChoiceUnion{Value: "auto"},
+			},
+			override:     "overridden",
+			expPath:      "/ml/v1/deployments/overridden/text/chat_stream?version=2024-05-31",
+			expBody:      `{"messages":[],"max_tokens":100,"tool_choice_option":"auto"}`,
+			expStreaming: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			o := NewChatCompletionOpenAIToIBMWatsonxTranslator("", tc.override).(*openAIToIBMWatsonxTranslatorV1ChatCompletion)
+			hm, bm, err := o.RequestBody([]byte(tc.raw), tc.req, false)
+			require.NoError(t, err)
+			require.Equal(t, tc.expStreaming, o.stream)
+			require.JSONEq(t, tc.expBody, string(bm))
+			require.Len(t, hm, 2)
+			require.Equal(t, pathHeaderName, hm[0].Key())
+			require.Equal(t, tc.expPath, hm[0].Value())
+			require.Equal(t, contentLengthHeaderName, hm[1].Key())
+		})
+	}
+}
+
+func TestOpenAIToIBMWatsonxTranslatorV1ChatCompletion_ResponseBody(t *testing.T) {
+	t.Run("non-streaming", func(t *testing.T) {
+		o := NewChatCompletionOpenAIToIBMWatsonxTranslator("2025-02-11", "").(*openAIToIBMWatsonxTranslatorV1ChatCompletion)
+		_, _, err := o.RequestBody([]byte(`{"model":"my-deployment"}`), &openai.ChatCompletionRequest{Model: "my-deployment"}, false)
+		require.NoError(t, err)
+
+		body := `{"id":"chat-1","model_id":"ibm/granite-3-8b-instruct","created":1731000000,` +
+			`"choices":[{"index":0,"message":{"role":"assistant","content":"Hello!"},"finish_reason":"stop"}],` +
+			`"usage":{"prompt_tokens":10,"completion_tokens":3,"total_tokens":13}}`
+		hm, bm, usage, model, err := o.ResponseBody(nil, strings.NewReader(body), true, nil)
+		require.NoError(t, err)
+		require.Equal(t, "ibm/granite-3-8b-instruct", model)
+		require.Len(t, hm, 1)
+		require.Equal(t, contentLengthHeaderName, hm[0].Key())
+
+		var resp openai.ChatCompletionResponse
+		require.NoError(t, json.Unmarshal(bm, &resp))
+		require.Equal(t, "chat.completion", resp.Object)
+		require.Equal(t, "ibm/granite-3-8b-instruct", resp.Model)
+		require.NotContains(t, string(bm), "model_id")
+
+		in, _ := usage.InputTokens()
+		out, _ := usage.OutputTokens()
+		total, _ := usage.TotalTokens()
+		require.Equal(t, uint32(10), in)
+		require.Equal(t, uint32(3), out)
+		require.Equal(t, uint32(13), total)
+	})
+
+	t.Run("streaming", func(t *testing.T) {
+		o := NewChatCompletionOpenAIToIBMWatsonxTranslator("", "").(*openAIToIBMWatsonxTranslatorV1ChatCompletion)
+		_, _, err := o.RequestBody([]byte(`{"model":"my-deployment","stream":true}`), &openai.ChatCompletionRequest{Model: "my-deployment", Stream: true}, false)
+		require.NoError(t, err)
+
+		events := "id: 1\nevent: message\n" +
+			`data: {"id":"chat-1","model_id":"ibm/granite-3-8b-instruct","created":1731000000,"choices":[{"index":0,"delta":{"role":"assistant","content":"Hel"}}]}` + "\n\n" +
+			"id: 2\nevent: message\n" +
+			`data: {"id":"chat-1","model_id":"ibm/granite-3-8b-instruct","created":1731000000,"choices":[{"index":0,"delta":{"content":"lo"},"finish_reason":"stop"}],` +
+			`"usage":{"prompt_tokens":10,"completion_tokens":2,"total_tokens":12}}` + "\n\n"
+		split := strings.Index(events, "id: 2") + 10
+
+		// The incomplete event is held until the rest of it is received.
+		_, bm, _, model, err := o.ResponseBody(nil, strings.NewReader(events[:split]), false, nil)
+		require.NoError(t, err)
+		require.Equal(t, "ibm/granite-3-8b-instruct", model)
+		require.Equal(t, 1, bytes.Count(bm, sseDataPrefix))
+		require.NotContains(t, string(bm), "event:")
+
+		_, bm, usage, _, err := o.ResponseBody(nil, strings.NewReader(events[split:]), true, nil)
+		require.NoError(t, err)
+		parts := strings.Split(strings.TrimSpace(string(bm)), "\n\n")
+		require.Len(t, parts, 2)
+		require.Equal(t, "data: [DONE]", parts[1])
+
+		var chunk openai.ChatCompletionResponseChunk
+		require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(parts[0], "data: ")), &chunk))
+		require.Equal(t, "chat.completion.chunk", chunk.Object)
+		require.Equal(t, "ibm/granite-3-8b-instruct", chunk.Model)
+		out, _ := usage.OutputTokens()
+		require.Equal(t, uint32(2), out)
+	})
+}
+
+func TestOpenAIToIBMWatsonxTranslatorV1ChatCompletion_ResponseError(t *testing.T) {
+	o := NewChatCompletionOpenAIToIBMWatsonxTranslator("", "")
+	for _, tc := range []struct {
+		name    string
+		body    string
+		expType string
+		expMsg  string
+	}{
+		{
+			name:    "watsonx error",
+			body:    `{"errors":[{"code":"model_not_supported","message":"Model 'foo' is not supported"}],"trace":"abc","status_code":404}`,
+			expType: "model_not_supported",
+			expMsg:  "Model 'foo' is not supported",
+		},
+		{
+			name:    "plain text",
+			body:    "upstream connect error",
+			expType: ibmWatsonxBackendError,
+			expMsg:  "upstream connect error",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			hm, bm, err := o.ResponseError(map[string]string{statusHeaderName: "404"}, strings.NewReader(tc.body))
+			require.NoError(t, err)
+			require.Len(t, hm, 2)
+			var openaiError openai.Error
+			require.NoError(t, json.Unmarshal(bm, &openaiError))
+			require.Equal(t, tc.expType, openaiError.Error.Type)
+			require.Equal(t, tc.expMsg, openaiError.Error.Message)
+			require.Equal(t, "404", *openaiError.Error.Code)
+		})
+	}
+}
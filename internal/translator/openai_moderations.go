@@ -0,0 +1,81 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+
+	"github.com/tidwall/sjson"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+	"github.com/envoyproxy/ai-gateway/internal/json"
+	"github.com/envoyproxy/ai-gateway/internal/metrics"
+	"github.com/envoyproxy/ai-gateway/internal/tracing/tracingapi"
+)
+
+// NewModerationOpenAIToOpenAITranslator implements [Factory] for OpenAI to OpenAI translation for moderations.
+func NewModerationOpenAIToOpenAITranslator(prefix string, modelNameOverride internalapi.ModelNameOverride) OpenAIModerationTranslator {
+	return &openAIToOpenAITranslatorV1Moderation{modelNameOverride: modelNameOverride, path: path.Join("/", prefix, "moderations")}
+}
+
+// openAIToOpenAITranslatorV1Moderation is a passthrough translator for OpenAI Moderations API.
+// May apply model overrides but otherwise preserves the OpenAI format:
+// https://platform.openai.com/docs/api-reference/moderations/create
+type openAIToOpenAITranslatorV1Moderation struct {
+	modelNameOverride internalapi.ModelNameOverride
+	// The path of the moderations endpoint to be used for the request. It is prefixed with the OpenAI path prefix.
+	path string
+}
+
+// RequestBody implements [OpenAIModerationTranslator.RequestBody].
+func (o *openAIToOpenAITranslatorV1Moderation) RequestBody(original []byte, _ *openai.ModerationRequest, onRetry bool) (
+	newHeaders []internalapi.Header, newBody []byte, err error,
+) {
+	if o.modelNameOverride != "" {
+		newBody, err = sjson.SetBytesOptions(original, "model", o.modelNameOverride, sjsonOptions)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to set model name: %w", err)
+		}
+	}
+	if onRetry && len(newBody) == 0 {
+		newBody = original
+	}
+	newHeaders = []internalapi.Header{{pathHeaderName, o.path}}
+	if len(newBody) > 0 {
+		newHeaders = append(newHeaders, internalapi.Header{contentLengthHeaderName, strconv.Itoa(len(newBody))})
+	}
+	return
+}
+
+// ResponseHeaders implements [OpenAIModerationTranslator.ResponseHeaders].
+func (o *openAIToOpenAITranslatorV1Moderation) ResponseHeaders(map[string]string) (newHeaders []internalapi.Header, err error) {
+	return nil, nil
+}
+
+// ResponseBody implements [OpenAIModerationTranslator.ResponseBody].
+// The moderations are free and don't report the token usage.
+func (o *openAIToOpenAITranslatorV1Moderation) ResponseBody(_ map[string]string, body io.Reader, _ bool, span tracingapi.ModerationSpan) (
+	newHeaders []internalapi.Header, newBody []byte, tokenUsage metrics.TokenUsage, responseModel internalapi.ResponseModel, err error,
+) {
+	var resp openai.ModerationResponse
+	if err = json.NewDecoder(body).Decode(&resp); err != nil {
+		return nil, nil, tokenUsage, "", fmt.Errorf("failed to unmarshal body: %w", err)
+	}
+	if span != nil {
+		span.RecordResponse(&resp)
+	}
+	responseModel = resp.Model
+	return
+}
+
+// ResponseError implements [OpenAIModerationTranslator.ResponseError].
+func (o *openAIToOpenAITranslatorV1Moderation) ResponseError(respHeaders map[string]string, body io.Reader) ([]internalapi.Header, []byte, error) {
+	return convertErrorOpenAIToOpenAIError(respHeaders, body)
+}
@@ -0,0 +1,75 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+	"github.com/envoyproxy/ai-gateway/internal/json"
+	"github.com/envoyproxy/ai-gateway/internal/tracing/tracingapi"
+)
+
+func TestOpenAIToOpenAITranslatorV1ModerationRequestBody(t *testing.T) {
+	originalBody := []byte(`{"model":"omni-moderation-latest","input":"some text"}`)
+	var req openai.ModerationRequest
+	require.NoError(t, json.Unmarshal(originalBody, &req))
+
+	t.Run("passthrough", func(t *testing.T) {
+		headers, body, err := NewModerationOpenAIToOpenAITranslator("v1", "").RequestBody(originalBody, &req, false)
+		require.NoError(t, err)
+		require.Equal(t, []internalapi.Header{{pathHeaderName, "/v1/moderations"}}, headers)
+		require.Nil(t, body)
+	})
+	t.Run("model name override", func(t *testing.T) {
+		headers, body, err := NewModerationOpenAIToOpenAITranslator("custom/v1", "text-moderation-stable").RequestBody(originalBody, &req, false)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"model":"text-moderation-stable","input":"some text"}`, string(body))
+		require.Equal(t, []internalapi.Header{{pathHeaderName, "/custom/v1/moderations"}, {contentLengthHeaderName, "54"}}, headers)
+	})
+	t.Run("on retry", func(t *testing.T) {
+		headers, body, err := NewModerationOpenAIToOpenAITranslator("v1", "").RequestBody(originalBody, &req, true)
+		require.NoError(t, err)
+		require.Equal(t, originalBody, body)
+		require.Len(t, headers, 2)
+	})
+}
+
+func TestOpenAIToOpenAITranslatorV1ModerationResponseBody(t *testing.T) {
+	tr := NewModerationOpenAIToOpenAITranslator("v1", "")
+	span := &mockModerationSpan{}
+	headers, body, tokenUsage, responseModel, err := tr.ResponseBody(nil, strings.NewReader(
+		`{"id":"modr-1","model":"omni-moderation-2024-09-26","results":[{"flagged":true,"categories":{"violence":true},"category_scores":{"violence":0.9}}]}`,
+	), true, span)
+	require.NoError(t, err)
+	require.Nil(t, headers)
+	require.Nil(t, body)
+	require.Equal(t, "omni-moderation-2024-09-26", responseModel)
+	_, ok := tokenUsage.InputTokens()
+	require.False(t, ok)
+	require.True(t, span.resp.Results[0].Categories[openai.ModerationCategoryViolence])
+
+	_, _, _, _, err = tr.ResponseBody(nil, strings.NewReader("not json"), true, nil)
+	require.ErrorContains(t, err, "failed to unmarshal body")
+}
+
+// mockModerationSpan is a test implementation of tracingapi.ModerationSpan.
+type mockModerationSpan struct {
+	resp *openai.ModerationResponse
+}
+
+func (m *mockModerationSpan) RecordResponseChunk(_ *struct{}) {}
+func (m *mockModerationSpan) RecordResponse(resp *openai.ModerationResponse) {
+	m.resp = resp
+}
+func (m *mockModerationSpan) EndSpanOnError(_ int, _ []byte) {}
+func (m *mockModerationSpan) EndSpan()                       {}
+
+var _ tracingapi.ModerationSpan = (*mockModerationSpan)(nil)
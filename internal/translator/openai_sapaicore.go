@@ -0,0 +1,105 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/tidwall/sjson"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+)
+
+// NewChatCompletionOpenAIToSAPAICoreTranslator implements [Factory] for OpenAI to SAP AI Core translations.
+// SAP AI Core serves the OpenAI models from deployment-scoped endpoints with the OpenAI compatible request and
+// response formats, so only the RequestBody method differs from NewChatCompletionOpenAIToOpenAITranslator's:
+// https://help.sap.com/docs/sap-ai-core/sap-ai-core-service-guide/consume-generative-ai-models-using-sap-ai-core
+func NewChatCompletionOpenAIToSAPAICoreTranslator(apiVersion string, modelNameOverride internalapi.ModelNameOverride) OpenAIChatCompletionTranslator {
+	return &openAIToSAPAICoreTranslatorV1ChatCompletion{
+		apiVersion: apiVersion,
+		openAIToOpenAITranslatorV1ChatCompletion: openAIToOpenAITranslatorV1ChatCompletion{
+			modelNameOverride: modelNameOverride,
+		},
+	}
+}
+
+// NewEmbeddingOpenAIToSAPAICoreTranslator implements [Factory] for OpenAI to SAP AI Core translation
+// for embeddings.
+func NewEmbeddingOpenAIToSAPAICoreTranslator(apiVersion string, modelNameOverride internalapi.ModelNameOverride) OpenAIEmbeddingTranslator {
+	return &openAIToSAPAICoreTranslatorV1Embedding{
+		apiVersion: apiVersion,
+		openAIToOpenAITranslatorV1Embedding: openAIToOpenAITranslatorV1Embedding{
+			modelNameOverride: modelNameOverride,
+		},
+	}
+}
+
+// openAIToSAPAICoreTranslatorV1ChatCompletion adapts OpenAI requests for SAP AI Core.
+// Like Azure OpenAI, the deployment is identified by the URI path, so the model name is used as the deployment ID.
+type openAIToSAPAICoreTranslatorV1ChatCompletion struct {
+	apiVersion string
+	openAIToOpenAITranslatorV1ChatCompletion
+}
+
+// RequestBody implements [OpenAIChatCompletionTranslator.RequestBody].
+func (o *openAIToSAPAICoreTranslatorV1ChatCompletion) RequestBody(raw []byte, req *openai.ChatCompletionRequest, forceBodyMutation bool) (
+	newHeaders []internalapi.Header, newBody []byte, err error,
+) {
+	deploymentID := req.Model
+	if o.modelNameOverride != "" {
+		deploymentID = o.modelNameOverride
+	}
+	o.requestModel = deploymentID
+	if req.Stream {
+		o.stream = true
+	}
+
+	newHeaders = []internalapi.Header{{pathHeaderName, sapAICoreDeploymentPath(deploymentID, "chat/completions", o.apiVersion)}}
+	// On retry, the path might have changed to a different provider. So, this will ensure that the path is always set to SAP AI Core.
+	if forceBodyMutation {
+		newHeaders = append(newHeaders, internalapi.Header{contentLengthHeaderName, strconv.Itoa(len(raw))})
+	}
+	return
+}
+
+// openAIToSAPAICoreTranslatorV1Embedding implements [OpenAIEmbeddingTranslator] for /embeddings.
+type openAIToSAPAICoreTranslatorV1Embedding struct {
+	apiVersion string
+	openAIToOpenAITranslatorV1Embedding
+}
+
+// RequestBody implements [OpenAIEmbeddingTranslator.RequestBody].
+func (o *openAIToSAPAICoreTranslatorV1Embedding) RequestBody(original []byte, req *openai.EmbeddingRequest, onRetry bool) (
+	newHeaders []internalapi.Header, newBody []byte, err error,
+) {
+	deploymentID := req.Model
+	if o.modelNameOverride != "" {
+		newBody, err = sjson.SetBytesOptions(original, "model", o.modelNameOverride, sjsonOptions)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to set model name: %w", err)
+		}
+		deploymentID = o.modelNameOverride
+	}
+	if onRetry && len(newBody) == 0 {
+		newBody = original
+	}
+	newHeaders = []internalapi.Header{{pathHeaderName, sapAICoreDeploymentPath(deploymentID, "embeddings", o.apiVersion)}}
+	if len(newBody) > 0 {
+		newHeaders = append(newHeaders, internalapi.Header{contentLengthHeaderName, strconv.Itoa(len(newBody))})
+	}
+	return
+}
+
+// sapAICoreDeploymentPath returns the path of the operation of the SAP AI Core deployment.
+func sapAICoreDeploymentPath(deploymentID, operation, apiVersion string) string {
+	p := fmt.Sprintf("/v2/inference/deployments/%s/%s", deploymentID, operation)
+	if apiVersion != "" {
+		p += "?api-version=" + apiVersion
+	}
+	return p
+}
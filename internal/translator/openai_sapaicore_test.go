@@ -0,0 +1,59 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+func TestOpenAIToSAPAICoreTranslatorV1ChatCompletion_RequestBody(t *testing.T) {
+	for _, stream := range []bool{true, false} {
+		t.Run(fmt.Sprintf("stream=%t", stream), func(t *testing.T) {
+			o := NewChatCompletionOpenAIToSAPAICoreTranslator("2024-10-21", "").(*openAIToSAPAICoreTranslatorV1ChatCompletion)
+			hm, bm, err := o.RequestBody(nil, &openai.ChatCompletionRequest{Model: "d1234", Stream: stream}, false)
+			require.NoError(t, err)
+			require.Nil(t, bm)
+			require.Equal(t, stream, o.stream)
+			require.Len(t, hm, 1)
+			require.Equal(t, pathHeaderName, hm[0].Key())
+			require.Equal(t, "/v2/inference/deployments/d1234/chat/completions?api-version=2024-10-21", hm[0].Value())
+		})
+	}
+	t.Run("model override without version", func(t *testing.T) {
+		o := NewChatCompletionOpenAIToSAPAICoreTranslator("", "d5678")
+		raw := []byte(`{"model":"gpt-4o"}`)
+		hm, _, err := o.RequestBody(raw, &openai.ChatCompletionRequest{Model: "gpt-4o"}, true)
+		require.NoError(t, err)
+		require.Len(t, hm, 2)
+		require.Equal(t, "/v2/inference/deployments/d5678/chat/completions", hm[0].Value())
+		require.Equal(t, contentLengthHeaderName, hm[1].Key())
+	})
+}
+
+func TestOpenAIToSAPAICoreTranslatorV1Embedding_RequestBody(t *testing.T) {
+	t.Run("valid body", func(t *testing.T) {
+		o := NewEmbeddingOpenAIToSAPAICoreTranslator("2024-10-21", "")
+		hm, bm, err := o.RequestBody([]byte(`{"model":"d1234","input":"hi"}`), &openai.EmbeddingRequest{EmbeddingBaseRequest: openai.EmbeddingBaseRequest{Model: "d1234"}}, false)
+		require.NoError(t, err)
+		require.Nil(t, bm)
+		require.Len(t, hm, 1)
+		require.Equal(t, "/v2/inference/deployments/d1234/embeddings?api-version=2024-10-21", hm[0].Value())
+	})
+	t.Run("model override", func(t *testing.T) {
+		o := NewEmbeddingOpenAIToSAPAICoreTranslator("2024-10-21", "d5678")
+		hm, bm, err := o.RequestBody([]byte(`{"model":"d1234","input":"hi"}`), &openai.EmbeddingRequest{EmbeddingBaseRequest: openai.EmbeddingBaseRequest{Model: "d1234"}}, false)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"model":"d5678","input":"hi"}`, string(bm))
+		require.Len(t, hm, 2)
+		require.Equal(t, "/v2/inference/deployments/d5678/embeddings?api-version=2024-10-21", hm[0].Value())
+		require.Equal(t, contentLengthHeaderName, hm[1].Key())
+	})
+}
@@ -0,0 +1,61 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+
+	cohereschema "github.com/envoyproxy/ai-gateway/internal/apischema/cohere"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+	"github.com/envoyproxy/ai-gateway/internal/json"
+)
+
+// rerankDocumentsPerSearchUnit is the number of documents billed as a single search unit by Cohere. The Vertex AI
+// Ranking API bills the queries the same way.
+const rerankDocumentsPerSearchUnit = 100
+
+// newRerankV2Response builds the Cohere Rerank v2 response from the results returned by a non-Cohere ranker.
+//
+// The scores are clamped to [0, 1], which is the range of the Cohere relevance scores, and the results are sorted by
+// descending score, limited to topN if set. As the other rankers do not report the usage the way Cohere does, the
+// billed search units are computed from the number of documents.
+func newRerankV2Response(results []*cohereschema.RerankV2Result, topN *int, documents int) cohereschema.RerankV2Response {
+	for _, r := range results {
+		r.RelevanceScore = min(max(r.RelevanceScore, 0), 1)
+	}
+	slices.SortStableFunc(results, func(a, b *cohereschema.RerankV2Result) int {
+		if c := cmp.Compare(b.RelevanceScore, a.RelevanceScore); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Index, b.Index)
+	})
+	if topN != nil && *topN >= 0 && len(results) > *topN {
+		results = results[:*topN]
+	}
+	searchUnits := float64((documents + rerankDocumentsPerSearchUnit - 1) / rerankDocumentsPerSearchUnit)
+	return cohereschema.RerankV2Response{
+		Results: results,
+		Meta: &cohereschema.RerankV2Meta{
+			BilledUnits: &cohereschema.RerankV2BilledUnits{SearchUnits: &searchUnits},
+		},
+	}
+}
+
+// rerankV2ErrorBody returns the Cohere Rerank v2 error body with the given message and the headers to send it.
+func rerankV2ErrorBody(message string) (newHeaders []internalapi.Header, newBody []byte, err error) {
+	newBody, err = json.Marshal(cohereschema.RerankV2Error{Message: &message})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal error body: %w", err)
+	}
+	newHeaders = []internalapi.Header{
+		{contentTypeHeaderName, jsonContentType},
+		{contentLengthHeaderName, strconv.Itoa(len(newBody))},
+	}
+	return
+}
@@ -0,0 +1,246 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"runtime"
+	"strconv"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws/protocol/eventstream"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/anthropic"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+var checkAllocBudgets = flag.Bool("alloc-budget", false,
+	"enforce the allocation budgets of TestStreamingTranslationAllocBudgets, which are only meaningful without -race and -cover")
+
+// streamingTextDeltas is the number of text deltas in the streams of the streaming translation benchmarks.
+const streamingTextDeltas = 32
+
+// streamingTranslationCase is a streaming response translated chunk by chunk, as the chunks are received by the
+// external processor.
+type streamingTranslationCase struct {
+	name string
+	// newStream initializes a translator for a streaming request, and returns the translation of a response chunk.
+	newStream func() (translate func(chunk []byte, endOfStream bool) ([]byte, error), err error)
+	chunks    [][]byte
+	// allocBudget is the maximum average number of heap allocations per chunk.
+	allocBudget float64
+}
+
+// newStreamingTranslation initializes the translator with the streaming request, and returns the translation of a
+// response chunk.
+func newStreamingTranslation[ReqT, SpanT any](t Translator[ReqT, SpanT], req *ReqT) (func(chunk []byte, endOfStream bool) ([]byte, error), error) {
+	if _, _, err := t.RequestBody(nil, req, false); err != nil {
+		return nil, err
+	}
+	var span SpanT
+	return func(chunk []byte, endOfStream bool) ([]byte, error) {
+		_, body, _, _, err := t.ResponseBody(nil, bytes.NewReader(chunk), endOfStream, span)
+		return body, err
+	}, nil
+}
+
+var streamingChatCompletionRequest = &openai.ChatCompletionRequest{
+	Model:     "model",
+	Stream:    true,
+	MaxTokens: ptr.To(int64(1024)),
+	Messages: []openai.ChatCompletionMessageParamUnion{
+		{OfUser: &openai.ChatCompletionUserMessageParam{Content: openai.StringOrUserRoleContentUnion{Value: "Hello"}, Role: openai.ChatMessageRoleUser}},
+	},
+}
+
+// streamingTranslationCases returns the streaming translation of each hot path.
+func streamingTranslationCases(tb testing.TB) []streamingTranslationCase {
+	return []streamingTranslationCase{
+		{
+			name: "openai-awsbedrock",
+			newStream: func() (func([]byte, bool) ([]byte, error), error) {
+				return newStreamingTranslation(NewChatCompletionOpenAIToAWSBedrockTranslator(""), streamingChatCompletionRequest)
+			},
+			chunks:      awsBedrockStreamingChunks(tb),
+			allocBudget: 80,
+		},
+		{
+			name: "openai-gcpvertexai",
+			newStream: func() (func([]byte, bool) ([]byte, error), error) {
+				return newStreamingTranslation(NewChatCompletionOpenAIToGCPVertexAITranslator(""), streamingChatCompletionRequest)
+			},
+			chunks:      geminiStreamingChunks(),
+			allocBudget: 40,
+		},
+		{
+			name: "openai-gcpanthropic",
+			newStream: func() (func([]byte, bool) ([]byte, error), error) {
+				return newStreamingTranslation(NewChatCompletionOpenAIToGCPAnthropicTranslator("", ""), streamingChatCompletionRequest)
+			},
+			chunks:      anthropicStreamingChunks(),
+			allocBudget: 60,
+		},
+		{
+			name: "anthropic-openai",
+			newStream: func() (func([]byte, bool) ([]byte, error), error) {
+				return newStreamingTranslation(NewAnthropicToChatCompletionOpenAITranslator("v1", ""), &anthropic.MessagesRequest{
+					Model:     "model",
+					MaxTokens: 1024,
+					Stream:    true,
+					Messages:  []anthropic.MessageParam{{Role: anthropic.MessageRoleUser, Content: anthropic.MessageContent{Text: "Hello"}}},
+				})
+			},
+			chunks:      openAIStreamingChunks(),
+			allocBudget: 32,
+		},
+	}
+}
+
+// translateStream translates the chunks of the case with a new translator.
+func (c *streamingTranslationCase) translateStream() error {
+	translate, err := c.newStream()
+	if err != nil {
+		return err
+	}
+	for i, chunk := range c.chunks {
+		if _, err = translate(chunk, i == len(c.chunks)-1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// allocsPerChunk returns the average number of heap allocations per chunk of translating the stream, excluding the
+// initialization of the translator.
+func (c *streamingTranslationCase) allocsPerChunk(runs int) (float64, error) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(1))
+	var mallocs uint64
+	for range runs {
+		translate, err := c.newStream()
+		if err != nil {
+			return 0, err
+		}
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+		for i, chunk := range c.chunks {
+			if _, err = translate(chunk, i == len(c.chunks)-1); err != nil {
+				return 0, err
+			}
+		}
+		runtime.ReadMemStats(&after)
+		mallocs += after.Mallocs - before.Mallocs
+	}
+	return float64(mallocs) / float64(runs*len(c.chunks)), nil
+}
+
+func BenchmarkStreamingTranslation(b *testing.B) {
+	for _, c := range streamingTranslationCases(b) {
+		b.Run(c.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				if err := c.translateStream(); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*len(c.chunks)), "ns/chunk")
+		})
+	}
+}
+
+// TestStreamingTranslationAllocBudgets fails if the translation of a streaming chunk allocates more than the budget
+// of its path, which keeps the GC pressure of the external processor at high streaming concurrency in check. The
+// budgets are only enforced with -alloc-budget, since the race detector and the coverage instrumentation allocate.
+func TestStreamingTranslationAllocBudgets(t *testing.T) {
+	for _, c := range streamingTranslationCases(t) {
+		t.Run(c.name, func(t *testing.T) {
+			allocs, err := c.allocsPerChunk(20)
+			require.NoError(t, err)
+			t.Logf("%.1f allocs/chunk (budget %.0f)", allocs, c.allocBudget)
+			if *checkAllocBudgets {
+				require.LessOrEqual(t, allocs, c.allocBudget, "the translation of a streaming chunk exceeds the allocation budget")
+			}
+		})
+	}
+}
+
+// awsBedrockStreamingChunks returns the event stream of a Converse response, one event per chunk.
+func awsBedrockStreamingChunks(tb testing.TB) [][]byte {
+	payloads := []struct{ eventType, payload string }{
+		{"messageStart", `{"role":"assistant"}`},
+	}
+	for i := range streamingTextDeltas {
+		payloads = append(payloads, struct{ eventType, payload string }{
+			"contentBlockDelta", `{"contentBlockIndex":0,"delta":{"text":"token ` + strconv.Itoa(i) + ` "}}`,
+		})
+	}
+	payloads = append(payloads,
+		struct{ eventType, payload string }{"contentBlockStop", `{"contentBlockIndex":0}`},
+		struct{ eventType, payload string }{"messageStop", `{"stopReason":"end_turn"}`},
+		struct{ eventType, payload string }{"metadata", `{"usage":{"inputTokens":10,"outputTokens":32,"totalTokens":42},"metrics":{"latencyMs":100}}`},
+	)
+	encoder := eventstream.NewEncoder()
+	chunks := make([][]byte, len(payloads))
+	for i, p := range payloads {
+		var buf bytes.Buffer
+		require.NoError(tb, encoder.Encode(&buf, eventstream.Message{
+			Headers: eventstream.Headers{
+				{Name: ":event-type", Value: eventstream.StringValue(p.eventType)},
+				{Name: ":content-type", Value: eventstream.StringValue("application/json")},
+				{Name: ":message-type", Value: eventstream.StringValue("event")},
+			},
+			Payload: []byte(p.payload),
+		}))
+		chunks[i] = buf.Bytes()
+	}
+	return chunks
+}
+
+// geminiStreamingChunks returns the server-sent events of a streamGenerateContent response, one event per chunk.
+func geminiStreamingChunks() [][]byte {
+	var chunks [][]byte
+	for i := range streamingTextDeltas {
+		chunks = append(chunks, fmt.Appendf(nil,
+			`data: {"candidates":[{"content":{"parts":[{"text":"token %d "}],"role":"model"},"index":0}],"modelVersion":"gemini-2.5-flash","responseId":"id"}`+"\r\n\r\n", i))
+	}
+	return append(chunks, []byte(`data: {"candidates":[{"content":{"parts":[{"text":""}],"role":"model"},"finishReason":"STOP","index":0}],`+
+		`"usageMetadata":{"promptTokenCount":10,"candidatesTokenCount":32,"totalTokenCount":42},"modelVersion":"gemini-2.5-flash","responseId":"id"}`+"\r\n\r\n"))
+}
+
+// anthropicStreamingChunks returns the server-sent events of a Messages response, one event per chunk.
+func anthropicStreamingChunks() [][]byte {
+	chunks := [][]byte{
+		[]byte("event: message_start\ndata: {\"type\":\"message_start\",\"message\":{\"id\":\"msg_1\",\"type\":\"message\",\"role\":\"assistant\"," +
+			"\"model\":\"claude-sonnet-4\",\"content\":[],\"stop_reason\":null,\"usage\":{\"input_tokens\":10,\"output_tokens\":1}}}\n\n"),
+		[]byte("event: content_block_start\ndata: {\"type\":\"content_block_start\",\"index\":0,\"content_block\":{\"type\":\"text\",\"text\":\"\"}}\n\n"),
+	}
+	for i := range streamingTextDeltas {
+		chunks = append(chunks, fmt.Appendf(nil,
+			"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"token %d \"}}\n\n", i))
+	}
+	return append(chunks,
+		[]byte("event: content_block_stop\ndata: {\"type\":\"content_block_stop\",\"index\":0}\n\n"),
+		[]byte("event: message_delta\ndata: {\"type\":\"message_delta\",\"delta\":{\"stop_reason\":\"end_turn\",\"stop_sequence\":null},\"usage\":{\"output_tokens\":32}}\n\n"),
+		[]byte("event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n"),
+	)
+}
+
+// openAIStreamingChunks returns the server-sent events of a chat completion response, one event per chunk.
+func openAIStreamingChunks() [][]byte {
+	var chunks [][]byte
+	for i := range streamingTextDeltas {
+		chunks = append(chunks, fmt.Appendf(nil,
+			"data: {\"id\":\"chatcmpl-1\",\"object\":\"chat.completion.chunk\",\"created\":1,\"model\":\"gpt-4o\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"token %d \"}}]}\n\n", i))
+	}
+	return append(chunks,
+		[]byte("data: {\"id\":\"chatcmpl-1\",\"object\":\"chat.completion.chunk\",\"created\":1,\"model\":\"gpt-4o\",\"choices\":[{\"index\":0,\"delta\":{},\"finish_reason\":\"stop\"}]}\n\n"),
+		[]byte("data: {\"id\":\"chatcmpl-1\",\"object\":\"chat.completion.chunk\",\"created\":1,\"model\":\"gpt-4o\",\"choices\":[],\"usage\":{\"prompt_tokens\":10,\"completion_tokens\":32,\"total_tokens\":42}}\n\n"),
+		[]byte("data: [DONE]\n\n"),
+	)
+}
@@ -0,0 +1,82 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/jsonschema-go/jsonschema"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+	"github.com/envoyproxy/ai-gateway/internal/json"
+)
+
+// errStrictToolCallMismatch is returned when the arguments of a tool call do not match the parameters of the
+// function tool with the strict mode enabled, and the backend cannot constrain the tool calls by itself.
+var errStrictToolCallMismatch = errors.New("tool call arguments do not match the strict function schema")
+
+// strictToolSchemas returns the resolved parameters schemas of the function tools with `strict: true` keyed by
+// the function name, or nil if there's no such tool.
+//
+// This is used to emulate the strict mode for the backends without the constrained decoding of the tool calls,
+// by validating the tool calls in the response against the schemas.
+func strictToolSchemas(tools []openai.Tool) (map[string]*jsonschema.Resolved, error) {
+	var schemas map[string]*jsonschema.Resolved
+	for i := range tools {
+		fn := tools[i].Function
+		if tools[i].Type != openai.ToolTypeFunction || fn == nil || !fn.Strict {
+			continue
+		}
+		raw, err := json.Marshal(fn.Parameters)
+		if err != nil {
+			return nil, fmt.Errorf("%w: failed to marshal parameters of tool %s: %w", internalapi.ErrInvalidRequestBody, fn.Name, err)
+		}
+		var schema jsonschema.Schema
+		if fn.Parameters != nil {
+			if err = json.Unmarshal(raw, &schema); err != nil {
+				return nil, fmt.Errorf("%w: invalid parameters schema of tool %s: %w", internalapi.ErrInvalidRequestBody, fn.Name, err)
+			}
+		}
+		resolved, err := schema.Resolve(nil)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid parameters schema of tool %s: %w", internalapi.ErrInvalidRequestBody, fn.Name, err)
+		}
+		if schemas == nil {
+			schemas = make(map[string]*jsonschema.Resolved)
+		}
+		schemas[fn.Name] = resolved
+	}
+	return schemas, nil
+}
+
+// hasStrictFunctionTool returns true if any of the function tools has `strict: true`.
+func hasStrictFunctionTool(tools []openai.Tool) bool {
+	for i := range tools {
+		if tools[i].Type == openai.ToolTypeFunction && tools[i].Function != nil && tools[i].Function.Strict {
+			return true
+		}
+	}
+	return false
+}
+
+// validateStrictToolCall validates the arguments of the call to the given function against its schema in schemas.
+// The calls to the functions without the strict mode are not validated.
+func validateStrictToolCall(schemas map[string]*jsonschema.Resolved, name, arguments string) error {
+	schema, ok := schemas[name]
+	if !ok {
+		return nil
+	}
+	var instance any
+	if err := json.Unmarshal([]byte(arguments), &instance); err != nil {
+		return fmt.Errorf("%w: tool %s: %w", errStrictToolCallMismatch, name, err)
+	}
+	if err := schema.Validate(instance); err != nil {
+		return fmt.Errorf("%w: tool %s: %w", errStrictToolCallMismatch, name, err)
+	}
+	return nil
+}
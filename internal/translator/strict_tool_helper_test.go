@@ -0,0 +1,156 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genai"
+	"k8s.io/utils/ptr"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/awsbedrock"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/gcp"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+	"github.com/envoyproxy/ai-gateway/internal/json"
+)
+
+// newStrictWeatherTools returns the function tools where only get_weather has the strict mode enabled.
+func newStrictWeatherTools() []openai.Tool {
+	return []openai.Tool{
+		{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:   "get_weather",
+				Strict: true,
+				Parameters: map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"location": map[string]any{"type": "string"},
+					},
+					"required":             []any{"location"},
+					"additionalProperties": false,
+				},
+			},
+		},
+		{
+			Type:     openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{Name: "get_time", Parameters: map[string]any{"type": "object"}},
+		},
+	}
+}
+
+func TestStrictToolSchemas(t *testing.T) {
+	schemas, err := strictToolSchemas(nil)
+	require.NoError(t, err)
+	require.Nil(t, schemas)
+
+	tools := newStrictWeatherTools()
+	require.True(t, hasStrictFunctionTool(tools))
+	require.False(t, hasStrictFunctionTool(tools[1:]))
+
+	schemas, err = strictToolSchemas(tools)
+	require.NoError(t, err)
+	require.Len(t, schemas, 1)
+	require.Contains(t, schemas, "get_weather")
+
+	_, err = strictToolSchemas([]openai.Tool{{
+		Type:     openai.ToolTypeFunction,
+		Function: &openai.FunctionDefinition{Name: "bad", Strict: true, Parameters: map[string]any{"$ref": "#/$defs/missing"}},
+	}})
+	require.ErrorIs(t, err, internalapi.ErrInvalidRequestBody)
+}
+
+func TestValidateStrictToolCall(t *testing.T) {
+	schemas, err := strictToolSchemas(newStrictWeatherTools())
+	require.NoError(t, err)
+
+	require.NoError(t, validateStrictToolCall(schemas, "get_weather", `{"location":"Paris"}`))
+	// The tools without the strict mode are not validated.
+	require.NoError(t, validateStrictToolCall(schemas, "get_time", `{"unknown":1}`))
+
+	for _, args := range []string{`{}`, `{"location":"Paris","unit":"celsius"}`, `{"location":1}`, `not json`} {
+		t.Run(args, func(t *testing.T) {
+			require.ErrorIs(t, validateStrictToolCall(schemas, "get_weather", args), errStrictToolCallMismatch)
+		})
+	}
+}
+
+func TestGeminiStrictToolConfig(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		input   *genai.ToolConfig
+		expMode genai.FunctionCallingConfigMode
+	}{
+		{name: "default", expMode: genai.FunctionCallingConfigModeValidated},
+		{name: "auto", input: &genai.ToolConfig{FunctionCallingConfig: &genai.FunctionCallingConfig{Mode: genai.FunctionCallingConfigModeAuto}}, expMode: genai.FunctionCallingConfigModeValidated},
+		{name: "any", input: &genai.ToolConfig{FunctionCallingConfig: &genai.FunctionCallingConfig{Mode: genai.FunctionCallingConfigModeAny}}, expMode: genai.FunctionCallingConfigModeAny},
+		{name: "none", input: &genai.ToolConfig{FunctionCallingConfig: &genai.FunctionCallingConfig{Mode: genai.FunctionCallingConfigModeNone}}, expMode: genai.FunctionCallingConfigModeNone},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expMode, geminiStrictToolConfig(tc.input).FunctionCallingConfig.Mode)
+		})
+	}
+}
+
+func TestOpenAIToGCPVertexAITranslatorV1ChatCompletion_StrictTools(t *testing.T) {
+	newRequest := func(model string) *openai.ChatCompletionRequest {
+		return &openai.ChatCompletionRequest{
+			Model: model,
+			Messages: []openai.ChatCompletionMessageParamUnion{{OfUser: &openai.ChatCompletionUserMessageParam{
+				Role: openai.ChatMessageRoleUser, Content: openai.StringOrUserRoleContentUnion{Value: "weather in Paris?"},
+			}}},
+			Tools: newStrictWeatherTools(),
+		}
+	}
+	newResponse := func(args string) []byte {
+		return []byte(`{"candidates":[{"content":{"role":"model","parts":[{"functionCall":{"name":"get_weather","args":` + args + `}}]},"finishReason":"STOP"}]}`)
+	}
+
+	t.Run("constrained decoding", func(t *testing.T) {
+		translator := NewChatCompletionOpenAIToGCPVertexAITranslator("").(*openAIToGCPVertexAITranslatorV1ChatCompletion)
+		_, body, err := translator.RequestBody(nil, newRequest("gemini-2.5-flash"), false)
+		require.NoError(t, err)
+		var req gcp.GenerateContentRequest
+		require.NoError(t, json.Unmarshal(body, &req))
+		require.Equal(t, genai.FunctionCallingConfigModeValidated, req.ToolConfig.FunctionCallingConfig.Mode)
+		require.Nil(t, translator.strictToolSchemas)
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		translator := NewChatCompletionOpenAIToGCPVertexAITranslator("").(*openAIToGCPVertexAITranslatorV1ChatCompletion)
+		_, body, err := translator.RequestBody(nil, newRequest("gemini-2.0-flash"), false)
+		require.NoError(t, err)
+		var req gcp.GenerateContentRequest
+		require.NoError(t, json.Unmarshal(body, &req))
+		require.Nil(t, req.ToolConfig)
+
+		_, _, _, _, err = translator.ResponseBody(nil, bytes.NewReader(newResponse(`{"location":"Paris"}`)), true, nil)
+		require.NoError(t, err)
+		_, _, _, _, err = translator.ResponseBody(nil, bytes.NewReader(newResponse(`{"city":"Paris"}`)), true, nil)
+		require.ErrorIs(t, err, errStrictToolCallMismatch)
+	})
+}
+
+func TestOpenAIToAWSBedrockTranslatorV1ChatCompletion_StrictTools(t *testing.T) {
+	translator := &openAIToAWSBedrockTranslatorV1ChatCompletion{}
+	_, body, err := translator.RequestBody(nil, &openai.ChatCompletionRequest{
+		Model: "anthropic.claude-sonnet-4",
+		Messages: []openai.ChatCompletionMessageParamUnion{{OfUser: &openai.ChatCompletionUserMessageParam{
+			Role: openai.ChatMessageRoleUser, Content: openai.StringOrUserRoleContentUnion{Value: "weather in Paris?"},
+		}}},
+		Tools: newStrictWeatherTools(),
+	}, false)
+	require.NoError(t, err)
+
+	var req awsbedrock.ConverseInput
+	require.NoError(t, json.Unmarshal(body, &req))
+	require.Len(t, req.ToolConfig.Tools, 2)
+	require.Equal(t, ptr.To(true), req.ToolConfig.Tools[0].ToolSpec.Strict)
+	require.Nil(t, req.ToolConfig.Tools[1].ToolSpec.Strict)
+}
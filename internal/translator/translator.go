@@ -90,6 +90,13 @@ type RequestHeadersSetter interface {
 	SetRequestHeaders(headers map[string]string)
 }
 
+// UnsupportedParametersLister is an optional interface for translators that drop the request parameters the
+// backend cannot honor.
+type UnsupportedParametersLister interface {
+	// UnsupportedParameters returns the top-level fields of the raw request body that the backend cannot honor.
+	UnsupportedParameters(raw []byte) []string
+}
+
 // ResponseRedactor is an optional interface that translators can implement
 // to support response body redaction for debug logging.
 type ResponseRedactor interface {
@@ -123,6 +130,8 @@ type (
 	CohereRerankTranslator = Translator[cohereschema.RerankV2Request, tracingapi.RerankSpan]
 	// AnthropicMessagesTranslator translates the Anthropic's /messages endpoint.
 	AnthropicMessagesTranslator = Translator[anthropicschema.MessagesRequest, tracingapi.MessageSpan]
+	// AnthropicCountTokensTranslator translates the Anthropic's /messages/count_tokens endpoint.
+	AnthropicCountTokensTranslator = Translator[anthropicschema.MessagesRequest, tracingapi.CountTokensSpan]
 	// OpenAIImageGenerationTranslator translates the OpenAI's /images/generations endpoint.
 	OpenAIImageGenerationTranslator = Translator[openai.ImageGenerationRequest, tracingapi.ImageGenerationSpan]
 	// OpenAIResponsesTranslator translates the OpenAI's /responses endpoint.
@@ -133,6 +142,8 @@ type (
 	OpenAIAudioTranscriptionTranslator = Translator[openai.TranscriptionRequest, tracingapi.TranscriptionSpan]
 	// OpenAIAudioTranslationTranslator translates the OpenAI's /v1/audio/translations endpoint.
 	OpenAIAudioTranslationTranslator = Translator[openai.TranslationRequest, tracingapi.TranslationSpan]
+	// OpenAIModerationTranslator translates the OpenAI's /v1/moderations endpoint.
+	OpenAIModerationTranslator = Translator[openai.ModerationRequest, tracingapi.ModerationSpan]
 	// TokenizeTranslator translates the tokenize endpoint.
 	TokenizeTranslator = Translator[tokenize.RequestUnion, tracingapi.TokenizeSpan]
 )
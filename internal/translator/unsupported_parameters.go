@@ -0,0 +1,90 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import "github.com/tidwall/gjson"
+
+// unsupportedParameter is a top-level field of the OpenAI chat completion request that a translator drops.
+type unsupportedParameter struct {
+	name string
+	// neutral is the value of the field that has no effect on the response, e.g. 1 for "n", which the backend
+	// honors by ignoring the field. Nil if any value has an effect.
+	neutral any
+}
+
+// The chat completion parameters dropped by the translators to the backends that aren't OpenAI compatible.
+var (
+	// bedrockUnsupportedParameters are the parameters dropped by the AWS Bedrock Converse translator.
+	bedrockUnsupportedParameters = []unsupportedParameter{
+		{name: "frequency_penalty", neutral: float64(0)},
+		{name: "logit_bias"},
+		{name: "logprobs", neutral: false},
+		{name: "top_logprobs"},
+		{name: "n", neutral: float64(1)},
+		{name: "presence_penalty", neutral: float64(0)},
+		{name: "response_format"},
+		{name: "seed"},
+		{name: "verbosity"},
+		{name: "parallel_tool_calls", neutral: true},
+		{name: "modalities"},
+		{name: "audio"},
+		{name: "prediction"},
+		{name: "web_search_options"},
+		{name: "guided_choice"},
+		{name: "guided_regex"},
+		{name: "guided_json"},
+	}
+	// anthropicUnsupportedParameters are the parameters dropped by the translators to the Anthropic Messages API.
+	anthropicUnsupportedParameters = []unsupportedParameter{
+		{name: "frequency_penalty", neutral: float64(0)},
+		{name: "logit_bias"},
+		{name: "logprobs", neutral: false},
+		{name: "top_logprobs"},
+		{name: "n", neutral: float64(1)},
+		{name: "presence_penalty", neutral: float64(0)},
+		{name: "seed"},
+		{name: "service_tier"},
+		{name: "verbosity"},
+		{name: "modalities"},
+		{name: "audio"},
+		{name: "prediction"},
+		{name: "web_search_options"},
+		{name: "guided_choice"},
+		{name: "guided_regex"},
+		{name: "guided_json"},
+	}
+	// gcpAnthropicUnsupportedParameters are the parameters dropped by the GCP Anthropic translator, which doesn't
+	// support the structured outputs of the Anthropic API.
+	gcpAnthropicUnsupportedParameters = append([]unsupportedParameter{{name: "response_format"}}, anthropicUnsupportedParameters...)
+	// geminiUnsupportedParameters are the parameters dropped by the GCP Vertex AI Gemini translator.
+	geminiUnsupportedParameters = []unsupportedParameter{
+		{name: "logit_bias"},
+		{name: "service_tier"},
+		{name: "verbosity"},
+		{name: "parallel_tool_calls", neutral: true},
+		{name: "modalities"},
+		{name: "audio"},
+		{name: "prediction"},
+		{name: "web_search_options"},
+	}
+)
+
+// presentUnsupportedParameters returns the names of the parameters set in the raw request body to a value
+// other than null and their neutral value.
+func presentUnsupportedParameters(raw []byte, params []unsupportedParameter) []string {
+	var names []string
+	for _, p := range params {
+		v := gjson.GetBytes(raw, p.name)
+		if !v.Exists() || v.Type == gjson.Null {
+			continue
+		}
+		if p.neutral != nil && v.Value() == p.neutral {
+			continue
+		}
+		names = append(names, p.name)
+	}
+	return names
+}
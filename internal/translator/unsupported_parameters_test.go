@@ -0,0 +1,56 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPresentUnsupportedParameters(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		body string
+		exp  []string
+	}{
+		{name: "none", body: `{"model":"m","messages":[],"temperature":0.5}`},
+		{name: "null", body: `{"model":"m","logit_bias":null,"seed":null}`},
+		{name: "neutral", body: `{"model":"m","n":1,"logprobs":false,"presence_penalty":0,"parallel_tool_calls":true}`},
+		{
+			name: "unsupported",
+			body: `{"model":"m","seed":42,"n":2,"logit_bias":{"50256":-100},"parallel_tool_calls":false}`,
+			exp:  []string{"logit_bias", "n", "seed", "parallel_tool_calls"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.exp, presentUnsupportedParameters([]byte(tc.body), bedrockUnsupportedParameters))
+		})
+	}
+}
+
+func TestUnsupportedParametersLister(t *testing.T) {
+	body := []byte(`{"model":"m","seed":42,"response_format":{"type":"json_object"},"service_tier":"auto","logit_bias":{}}`)
+	for _, tc := range []struct {
+		name       string
+		translator OpenAIChatCompletionTranslator
+		exp        []string
+	}{
+		{name: "aws bedrock", translator: NewChatCompletionOpenAIToAWSBedrockTranslator(""), exp: []string{"logit_bias", "response_format", "seed"}},
+		{name: "aws anthropic", translator: NewChatCompletionOpenAIToAWSAnthropicTranslator("", ""), exp: []string{"logit_bias", "seed", "service_tier"}},
+		{name: "gcp anthropic", translator: NewChatCompletionOpenAIToGCPAnthropicTranslator("", ""), exp: []string{"response_format", "logit_bias", "seed", "service_tier"}},
+		{name: "gcp vertex ai", translator: NewChatCompletionOpenAIToGCPVertexAITranslator(""), exp: []string{"logit_bias", "service_tier"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			lister, ok := tc.translator.(UnsupportedParametersLister)
+			require.True(t, ok)
+			require.Equal(t, tc.exp, lister.UnsupportedParameters(body))
+		})
+	}
+
+	_, ok := NewChatCompletionOpenAIToOpenAITranslator("v1", "").(UnsupportedParametersLister)
+	require.False(t, ok)
+}
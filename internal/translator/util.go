@@ -6,9 +6,12 @@
 package translator
 
 import (
+	"bytes"
 	"encoding/base64"
 	"fmt"
+	"io"
 	"regexp"
+	"sync"
 
 	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
 	"github.com/envoyproxy/ai-gateway/internal/json"
@@ -30,6 +33,34 @@ var (
 	sseDoneFullLine = append(append(sseDataPrefix, sseDoneMessage...), '\n')
 )
 
+// maxPooledStreamingChunkSize is the capacity above which the buffers of the streaming chunks are not pooled, so that
+// an unusually large chunk does not stay in memory.
+const maxPooledStreamingChunkSize = 1 << 20
+
+// streamingChunkPool pools the buffers the chunks of the streaming responses are read into. These would otherwise be
+// allocated for each chunk, which dominates the GC pressure of the external processor at high streaming concurrency.
+var streamingChunkPool = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+
+// readStreamingChunk reads the data buffered from the previous chunks followed by the body of a streaming chunk into
+// a pooled buffer. The buffer must be released with releaseStreamingChunk once its bytes are no longer referenced.
+func readStreamingChunk(buffered []byte, body io.Reader) (*bytes.Buffer, error) {
+	buf := streamingChunkPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	buf.Write(buffered)
+	if _, err := buf.ReadFrom(body); err != nil {
+		releaseStreamingChunk(buf)
+		return nil, err
+	}
+	return buf, nil
+}
+
+// releaseStreamingChunk returns the buffer of a streaming chunk to the pool.
+func releaseStreamingChunk(buf *bytes.Buffer) {
+	if buf.Cap() <= maxPooledStreamingChunkSize {
+		streamingChunkPool.Put(buf)
+	}
+}
+
 // regDataURI follows the web uri regex definition.
 // https://developer.mozilla.org/en-US/docs/Web/URI/Schemes/data#syntax
 var regDataURI = regexp.MustCompile(`\Adata:(.+?)?(;base64)?,`)
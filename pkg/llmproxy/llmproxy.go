@@ -0,0 +1,298 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+// Package llmproxy exposes the request and response translation logic of the AI Gateway external processor
+// as an embeddable Go API. This allows users to reuse the OpenAI <-> provider translation in their own gRPC or
+// HTTP services without running the external processor binary.
+//
+// A [Processor] is created per request and translates the request body sent by the client into the one
+// expected by the backend, and then the backend's response back into the client's schema:
+//
+//	p, err := llmproxy.NewChatCompletionProcessor(llmproxy.APISchema{Name: llmproxy.APISchemaAWSBedrock}, "")
+//	...
+//	req, err := p.RequestBody(clientRequestBody)
+//	// Send req.Body to the backend with req.Headers applied, e.g. the new ":path".
+//	...
+//	resp, err := p.ResponseBody(backendResponseHeaders, backendResponseBody, true)
+//	// Send resp.Body to the client.
+//
+// Authentication against the backend, e.g. AWS SigV4 signing, is not done by this package.
+package llmproxy
+
+import (
+	"fmt"
+	"io"
+
+	cohereschema "github.com/envoyproxy/ai-gateway/internal/apischema/cohere"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/endpointspec"
+	"github.com/envoyproxy/ai-gateway/internal/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+	"github.com/envoyproxy/ai-gateway/internal/metrics"
+	"github.com/envoyproxy/ai-gateway/internal/tracing/tracingapi"
+	"github.com/envoyproxy/ai-gateway/internal/translator"
+)
+
+// APISchemaName is the name of an API schema.
+type APISchemaName string
+
+// Supported API schema names.
+const (
+	APISchemaOpenAI       APISchemaName = "OpenAI"
+	APISchemaCohere       APISchemaName = "Cohere"
+	APISchemaAWSBedrock   APISchemaName = "AWSBedrock"
+	APISchemaAzureOpenAI  APISchemaName = "AzureOpenAI"
+	APISchemaGCPVertexAI  APISchemaName = "GCPVertexAI"
+	APISchemaGCPAnthropic APISchemaName = "GCPAnthropic"
+	APISchemaAnthropic    APISchemaName = "Anthropic"
+	APISchemaAWSAnthropic APISchemaName = "AWSAnthropic"
+	APISchemaSAPAICore    APISchemaName = "SAPAICore"
+	APISchemaIBMWatsonx   APISchemaName = "IBMWatsonx"
+	APISchemaMock         APISchemaName = "Mock"
+)
+
+// APISchema is the API schema of a backend, optionally with its version or path prefix.
+type APISchema struct {
+	// Name is the name of the API schema.
+	Name APISchemaName
+	// Version is the version of the API schema, e.g. the API version of Azure OpenAI. Optional.
+	Version string
+	// Prefix is the path prefix of the API schema, e.g. "/v1" for OpenAI. Optional.
+	Prefix string
+}
+
+func (s APISchema) filterAPI() filterapi.VersionedAPISchema {
+	return filterapi.VersionedAPISchema{Name: filterapi.APISchemaName(s.Name), Version: s.Version, Prefix: s.Prefix}
+}
+
+// Header is a header key-value pair to be set on the request or response, e.g. Header{":path", "/v1/chat"}.
+type Header [2]string
+
+// Key returns the header key.
+func (h Header) Key() string {
+	return h[0]
+}
+
+// Value returns the header value.
+func (h Header) Value() string {
+	return h[1]
+}
+
+func newHeaders(headers []internalapi.Header) []Header {
+	if headers == nil {
+		return nil
+	}
+	ret := make([]Header, len(headers))
+	for i, h := range headers {
+		ret[i] = Header(h)
+	}
+	return ret
+}
+
+// TokenUsage is the token usage extracted from a response. Each count is only set if reported by the backend.
+type TokenUsage struct {
+	inputTokens, outputTokens, totalTokens, cachedInputTokens, cacheCreationInputTokens, reasoningTokens optionalCount
+}
+
+type optionalCount struct {
+	value uint32
+	set   bool
+}
+
+func newTokenUsage(u metrics.TokenUsage) TokenUsage {
+	var ret TokenUsage
+	ret.inputTokens.value, ret.inputTokens.set = u.InputTokens()
+	ret.outputTokens.value, ret.outputTokens.set = u.OutputTokens()
+	ret.totalTokens.value, ret.totalTokens.set = u.TotalTokens()
+	ret.cachedInputTokens.value, ret.cachedInputTokens.set = u.CachedInputTokens()
+	ret.cacheCreationInputTokens.value, ret.cacheCreationInputTokens.set = u.CacheCreationInputTokens()
+	ret.reasoningTokens.value, ret.reasoningTokens.set = u.ReasoningTokens()
+	return ret
+}
+
+// InputTokens returns the number of tokens consumed from the input, and whether it is set.
+func (u TokenUsage) InputTokens() (uint32, bool) {
+	return u.inputTokens.value, u.inputTokens.set
+}
+
+// OutputTokens returns the number of tokens consumed from the output, and whether it is set.
+func (u TokenUsage) OutputTokens() (uint32, bool) {
+	return u.outputTokens.value, u.outputTokens.set
+}
+
+// TotalTokens returns the total number of tokens consumed, and whether it is set.
+func (u TokenUsage) TotalTokens() (uint32, bool) {
+	return u.totalTokens.value, u.totalTokens.set
+}
+
+// CachedInputTokens returns the number of input tokens read from the cache, and whether it is set.
+func (u TokenUsage) CachedInputTokens() (uint32, bool) {
+	return u.cachedInputTokens.value, u.cachedInputTokens.set
+}
+
+// CacheCreationInputTokens returns the number of input tokens written to the cache, and whether it is set.
+func (u TokenUsage) CacheCreationInputTokens() (uint32, bool) {
+	return u.cacheCreationInputTokens.value, u.cacheCreationInputTokens.set
+}
+
+// ReasoningTokens returns the number of reasoning tokens consumed, and whether it is set.
+func (u TokenUsage) ReasoningTokens() (uint32, bool) {
+	return u.reasoningTokens.value, u.reasoningTokens.set
+}
+
+// RequestResult is the result of [Processor.RequestBody].
+type RequestResult struct {
+	// Headers are the headers to set on the upstream request, e.g. ":path" and "content-length".
+	Headers []Header
+	// Body is the request body to send upstream. This is nil when the original body can be sent as-is.
+	Body []byte
+	// Model is the model name specified in the original request.
+	Model string
+	// Stream is true if the client requested a streaming response.
+	Stream bool
+}
+
+// ResponseResult is the result of [Processor.ResponseBody] and [Processor.ResponseError].
+type ResponseResult struct {
+	// Headers are the headers to set on the response sent to the client.
+	Headers []Header
+	// Body is the response body to send to the client. This is nil when the original body can be sent as-is.
+	Body []byte
+	// Usage is the token usage reported by the backend. This is only populated by [Processor.ResponseBody].
+	Usage TokenUsage
+	// Model is the model name reported by the backend. This is only populated by [Processor.ResponseBody].
+	Model string
+}
+
+// Processor translates a single request and its response between the client's and the backend's API schemas.
+//
+// This is created per request and is not thread-safe.
+type Processor struct {
+	impl processor
+}
+
+// processor is implemented by the [typedProcessor] of each endpoint, whose request and response types are internal.
+type processor interface {
+	setRequestHeaders(headers map[string]string)
+	requestBody(raw []byte) (*RequestResult, error)
+	responseHeaders(headers map[string]string) ([]Header, error)
+	responseBody(headers map[string]string, body io.Reader, endOfStream bool) (*ResponseResult, error)
+	responseError(headers map[string]string, body io.Reader) (*ResponseResult, error)
+}
+
+// typedProcessor is the [processor] of an endpoint with the given request and response types.
+type typedProcessor[ReqT, RespT, RespChunkT any] struct {
+	spec       endpointspec.Spec[ReqT, RespT, RespChunkT]
+	translator translator.Translator[ReqT, tracingapi.Span[RespT, RespChunkT]]
+}
+
+// NewChatCompletionProcessor creates a [Processor] that translates OpenAI chat completion requests
+// into the given backend schema. The modelNameOverride, if non-empty, replaces the model name in the request.
+func NewChatCompletionProcessor(schema APISchema, modelNameOverride string) (*Processor, error) {
+	return newProcessor[openai.ChatCompletionRequest, openai.ChatCompletionResponse, openai.ChatCompletionResponseChunk](
+		endpointspec.ChatCompletionsEndpointSpec{}, schema, modelNameOverride)
+}
+
+// NewEmbeddingProcessor creates a [Processor] that translates OpenAI embedding requests
+// into the given backend schema. The modelNameOverride, if non-empty, replaces the model name in the request.
+func NewEmbeddingProcessor(schema APISchema, modelNameOverride string) (*Processor, error) {
+	return newProcessor[openai.EmbeddingRequest, openai.EmbeddingResponse, struct{}](
+		endpointspec.EmbeddingsEndpointSpec{}, schema, modelNameOverride)
+}
+
+// NewRerankProcessor creates a [Processor] that translates Cohere rerank requests
+// into the given backend schema. The modelNameOverride, if non-empty, replaces the model name in the request.
+func NewRerankProcessor(schema APISchema, modelNameOverride string) (*Processor, error) {
+	return newProcessor[cohereschema.RerankV2Request, cohereschema.RerankV2Response, struct{}](
+		endpointspec.RerankEndpointSpec{}, schema, modelNameOverride)
+}
+
+func newProcessor[ReqT, RespT, RespChunkT any](spec endpointspec.Spec[ReqT, RespT, RespChunkT], schema APISchema,
+	modelNameOverride string,
+) (*Processor, error) {
+	t, err := spec.GetTranslator(schema.filterAPI(), modelNameOverride)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create translator: %w", err)
+	}
+	return &Processor{impl: &typedProcessor[ReqT, RespT, RespChunkT]{spec: spec, translator: t}}, nil
+}
+
+// SetRequestHeaders passes the original request headers to the translator. Some translators use them to
+// derive provider-specific request fields. This must be called before [Processor.RequestBody] if used.
+func (p *Processor) SetRequestHeaders(headers map[string]string) {
+	p.impl.setRequestHeaders(headers)
+}
+
+// RequestBody parses the request body sent by the client and translates it into the backend's schema.
+func (p *Processor) RequestBody(raw []byte) (*RequestResult, error) {
+	return p.impl.requestBody(raw)
+}
+
+// ResponseHeaders translates the response headers returned by the backend.
+func (p *Processor) ResponseHeaders(headers map[string]string) ([]Header, error) {
+	return p.impl.responseHeaders(headers)
+}
+
+// ResponseBody translates the successful response body returned by the backend into the client's schema.
+//
+// For streaming responses, this is called for each chunk of the body with endOfStream set on the last one.
+func (p *Processor) ResponseBody(headers map[string]string, body io.Reader, endOfStream bool) (*ResponseResult, error) {
+	return p.impl.responseBody(headers, body, endOfStream)
+}
+
+// ResponseError translates the error response body (non-2xx status code) returned by the backend into the
+// client's schema.
+func (p *Processor) ResponseError(headers map[string]string, body io.Reader) (*ResponseResult, error) {
+	return p.impl.responseError(headers, body)
+}
+
+func (p *typedProcessor[ReqT, RespT, RespChunkT]) setRequestHeaders(headers map[string]string) {
+	if s, ok := p.translator.(translator.RequestHeadersSetter); ok {
+		s.SetRequestHeaders(headers)
+	}
+}
+
+func (p *typedProcessor[ReqT, RespT, RespChunkT]) requestBody(raw []byte) (*RequestResult, error) {
+	model, req, stream, mutated, err := p.spec.ParseBody(raw, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse request body: %w", err)
+	}
+	forceBodyMutation := mutated != nil
+	if forceBodyMutation {
+		raw = mutated
+	}
+	headers, body, err := p.translator.RequestBody(raw, req, forceBodyMutation)
+	if err != nil {
+		return nil, fmt.Errorf("failed to translate request body: %w", err)
+	}
+	if body == nil && forceBodyMutation {
+		body = mutated
+	}
+	return &RequestResult{Headers: newHeaders(headers), Body: body, Model: model, Stream: stream}, nil
+}
+
+func (p *typedProcessor[ReqT, RespT, RespChunkT]) responseHeaders(headers map[string]string) ([]Header, error) {
+	translated, err := p.translator.ResponseHeaders(headers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to translate response headers: %w", err)
+	}
+	return newHeaders(translated), nil
+}
+
+func (p *typedProcessor[ReqT, RespT, RespChunkT]) responseBody(headers map[string]string, body io.Reader, endOfStream bool) (*ResponseResult, error) {
+	translated, newBody, usage, model, err := p.translator.ResponseBody(headers, body, endOfStream, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to translate response body: %w", err)
+	}
+	return &ResponseResult{Headers: newHeaders(translated), Body: newBody, Usage: newTokenUsage(usage), Model: model}, nil
+}
+
+func (p *typedProcessor[ReqT, RespT, RespChunkT]) responseError(headers map[string]string, body io.Reader) (*ResponseResult, error) {
+	translated, newBody, err := p.translator.ResponseError(headers, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to translate response error: %w", err)
+	}
+	return &ResponseResult{Headers: newHeaders(translated), Body: newBody}, nil
+}
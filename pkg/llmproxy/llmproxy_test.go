@@ -0,0 +1,121 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package llmproxy
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/internal/filterapi"
+)
+
+func headerValue(headers []Header, key string) (string, bool) {
+	for _, h := range headers {
+		if h.Key() == key {
+			return h.Value(), true
+		}
+	}
+	return "", false
+}
+
+func TestNewChatCompletionProcessor(t *testing.T) {
+	t.Run("unsupported schema", func(t *testing.T) {
+		_, err := NewChatCompletionProcessor(APISchema{Name: APISchemaCohere}, "")
+		require.ErrorContains(t, err, "failed to create translator")
+	})
+
+	t.Run("openai", func(t *testing.T) {
+		p, err := NewChatCompletionProcessor(APISchema{Name: APISchemaOpenAI, Prefix: "/v1"}, "gpt-4o-mini")
+		require.NoError(t, err)
+
+		req, err := p.RequestBody([]byte(`{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}]}`))
+		require.NoError(t, err)
+		require.Equal(t, "gpt-4o", req.Model)
+		require.False(t, req.Stream)
+		path, ok := headerValue(req.Headers, ":path")
+		require.True(t, ok)
+		require.Equal(t, "/v1/chat/completions", path)
+		require.Contains(t, string(req.Body), `"model":"gpt-4o-mini"`)
+
+		resp, err := p.ResponseBody(map[string]string{":status": "200"}, strings.NewReader(
+			`{"model":"gpt-4o-mini-2024","choices":[],"usage":{"prompt_tokens":3,"completion_tokens":5,"total_tokens":8}}`), true)
+		require.NoError(t, err)
+		require.Equal(t, "gpt-4o-mini-2024", resp.Model)
+		in, _ := resp.Usage.InputTokens()
+		out, _ := resp.Usage.OutputTokens()
+		require.Equal(t, uint32(3), in)
+		require.Equal(t, uint32(5), out)
+	})
+
+	t.Run("aws bedrock", func(t *testing.T) {
+		p, err := NewChatCompletionProcessor(APISchema{Name: APISchemaAWSBedrock}, "")
+		require.NoError(t, err)
+
+		req, err := p.RequestBody([]byte(`{"model":"anthropic.claude-3","stream":true,"messages":[{"role":"user","content":"hi"}]}`))
+		require.NoError(t, err)
+		require.True(t, req.Stream)
+		path, ok := headerValue(req.Headers, ":path")
+		require.True(t, ok)
+		require.Equal(t, "/model/anthropic.claude-3/converse-stream", path)
+		require.NotNil(t, req.Body)
+	})
+
+	t.Run("invalid body", func(t *testing.T) {
+		p, err := NewChatCompletionProcessor(APISchema{Name: APISchemaOpenAI}, "")
+		require.NoError(t, err)
+		_, err = p.RequestBody([]byte(`{`))
+		require.ErrorContains(t, err, "failed to parse request body")
+	})
+}
+
+func TestNewEmbeddingProcessor(t *testing.T) {
+	p, err := NewEmbeddingProcessor(APISchema{Name: APISchemaAzureOpenAI, Version: "2024-10-21"}, "")
+	require.NoError(t, err)
+
+	req, err := p.RequestBody([]byte(`{"model":"text-embedding-3-small","input":"hello"}`))
+	require.NoError(t, err)
+	require.Equal(t, "text-embedding-3-small", req.Model)
+	path, ok := headerValue(req.Headers, ":path")
+	require.True(t, ok)
+	require.Equal(t, "/openai/deployments/text-embedding-3-small/embeddings?api-version=2024-10-21", path)
+
+	resp, err := p.ResponseError(map[string]string{":status": "500", "content-type": "text/plain"},
+		bytes.NewReader([]byte("internal error")))
+	require.NoError(t, err)
+	require.Contains(t, string(resp.Body), "internal error")
+}
+
+func TestNewRerankProcessor(t *testing.T) {
+	_, err := NewRerankProcessor(APISchema{Name: APISchemaAnthropic}, "")
+	require.ErrorContains(t, err, "failed to create translator")
+
+	p, err := NewRerankProcessor(APISchema{Name: APISchemaCohere, Version: "v2"}, "")
+	require.NoError(t, err)
+	req, err := p.RequestBody([]byte(`{"model":"rerank-v3.5","query":"q","documents":["a","b"]}`))
+	require.NoError(t, err)
+	require.Equal(t, "rerank-v3.5", req.Model)
+}
+
+func TestAPISchemaNames(t *testing.T) {
+	for name, expected := range map[APISchemaName]filterapi.APISchemaName{
+		APISchemaOpenAI:       filterapi.APISchemaOpenAI,
+		APISchemaCohere:       filterapi.APISchemaCohere,
+		APISchemaAWSBedrock:   filterapi.APISchemaAWSBedrock,
+		APISchemaAzureOpenAI:  filterapi.APISchemaAzureOpenAI,
+		APISchemaGCPVertexAI:  filterapi.APISchemaGCPVertexAI,
+		APISchemaGCPAnthropic: filterapi.APISchemaGCPAnthropic,
+		APISchemaAnthropic:    filterapi.APISchemaAnthropic,
+		APISchemaAWSAnthropic: filterapi.APISchemaAWSAnthropic,
+		APISchemaSAPAICore:    filterapi.APISchemaSAPAICore,
+		APISchemaIBMWatsonx:   filterapi.APISchemaIBMWatsonx,
+		APISchemaMock:         filterapi.APISchemaMock,
+	} {
+		require.Equal(t, expected, APISchema{Name: name}.filterAPI().Name)
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+// Package translatorsdk allows adding translators for API schemas that are not built into the AI Gateway,
+// e.g. proprietary providers, without modifying the AI Gateway itself.
+//
+// A translator is registered for an API schema name at the initialization of a custom external processor
+// binary, which then runs the AI Gateway external processor as usual:
+//
+//	func main() {
+//		if err := translatorsdk.RegisterChatCompletionTranslator("MyProvider", newMyProviderTranslator); err != nil {
+//			log.Fatal(err)
+//		}
+//		mainlib.Main(ctx, os.Args[1:], os.Stderr)
+//	}
+//
+// The registered translators are used for the backends whose schema name matches the registered one, both in the
+// external processor and in the [llmproxy] package. Built-in API schemas cannot be replaced.
+//
+// The translatortest package provides the helpers to check the translators, notably their handling of the
+// streaming responses split at arbitrary points.
+//
+// [llmproxy]: https://pkg.go.dev/github.com/envoyproxy/ai-gateway/pkg/llmproxy
+package translatorsdk
+
+import (
+	"io"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/endpointspec"
+	"github.com/envoyproxy/ai-gateway/internal/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+	"github.com/envoyproxy/ai-gateway/internal/metrics"
+	"github.com/envoyproxy/ai-gateway/internal/tracing/tracingapi"
+	"github.com/envoyproxy/ai-gateway/internal/translator"
+)
+
+type (
+	// APISchema is the API schema of a backend, optionally with its version or path prefix.
+	APISchema = filterapi.VersionedAPISchema
+	// APISchemaName is the name of an API schema.
+	APISchemaName = filterapi.APISchemaName
+	// Header is a header key-value pair to be set on the request or response, e.g. Header{":path", "/v1/chat"}.
+	Header = internalapi.Header
+	// TokenUsage is the token usage extracted from a response.
+	TokenUsage = metrics.TokenUsage
+
+	// ChatCompletionRequest is the request of the OpenAI /v1/chat/completions endpoint.
+	ChatCompletionRequest = openai.ChatCompletionRequest
+	// ChatCompletionResponse is the response of the OpenAI /v1/chat/completions endpoint.
+	ChatCompletionResponse = openai.ChatCompletionResponse
+	// ChatCompletionResponseChunk is the streaming response chunk of the OpenAI /v1/chat/completions endpoint.
+	ChatCompletionResponseChunk = openai.ChatCompletionResponseChunk
+	// EmbeddingRequest is the request of the OpenAI /v1/embeddings endpoint.
+	EmbeddingRequest = openai.EmbeddingRequest
+	// EmbeddingResponse is the response of the OpenAI /v1/embeddings endpoint.
+	EmbeddingResponse = openai.EmbeddingResponse
+	// ModerationRequest is the request of the OpenAI /v1/moderations endpoint.
+	ModerationRequest = openai.ModerationRequest
+	// ModerationResponse is the response of the OpenAI /v1/moderations endpoint.
+	ModerationResponse = openai.ModerationResponse
+	// Error is the OpenAI error response, which the translated error responses are expected to be.
+	Error = openai.Error
+)
+
+// Translator translates a single request of the type ReqT and its response between the OpenAI schema and
+// the backend's one. A new Translator is created per request, so it can keep the state of the request,
+// e.g. whether the response is streamed.
+type Translator[ReqT any] interface {
+	// RequestBody translates the request body. The raw is the original request body, and req is the parsed one.
+	//
+	// It returns the headers to set on the upstream request, e.g. ":path" and "content-length", and the new body,
+	// or nil to send the original body as-is. When forceBodyMutation is true, e.g. on retries to another backend,
+	// the body must be returned even if it is unchanged.
+	RequestBody(raw []byte, req *ReqT, forceBodyMutation bool) (newHeaders []Header, newBody []byte, err error)
+	// ResponseHeaders translates the response headers returned by the backend.
+	ResponseHeaders(headers map[string]string) (newHeaders []Header, err error)
+	// ResponseBody translates the successful response body returned by the backend.
+	//
+	// For streaming responses, this is called for each chunk of the body with endOfStream set on the last one.
+	// The chunks are split at arbitrary points, so the incomplete events must be buffered until the rest is
+	// received. A nil newBody sends the original chunk as-is, so an empty non-nil one must be returned instead
+	// when there is nothing to send yet.
+	ResponseBody(headers map[string]string, body io.Reader, endOfStream bool) (
+		newHeaders []Header, newBody []byte, tokenUsage TokenUsage, responseModel string, err error,
+	)
+	// ResponseError translates the error response body (non-2xx status code) returned by the backend into
+	// the OpenAI [Error].
+	ResponseError(headers map[string]string, body io.Reader) (newHeaders []Header, newBody []byte, err error)
+}
+
+// RequestHeadersSetter is an optional interface of [Translator] to receive the original request headers
+// before [Translator.RequestBody] is called.
+type RequestHeadersSetter interface {
+	SetRequestHeaders(headers map[string]string)
+}
+
+type (
+	// ChatCompletionTranslator is the [Translator] for the OpenAI /v1/chat/completions endpoint.
+	ChatCompletionTranslator = Translator[ChatCompletionRequest]
+	// EmbeddingTranslator is the [Translator] for the OpenAI /v1/embeddings endpoint.
+	EmbeddingTranslator = Translator[EmbeddingRequest]
+	// ModerationTranslator is the [Translator] for the OpenAI /v1/moderations endpoint, e.g. to a proprietary
+	// safety provider.
+	ModerationTranslator = Translator[ModerationRequest]
+)
+
+// Factory creates a [Translator] for a request to the backend of the given schema. The modelNameOverride,
+// if non-empty, is expected to replace the model name in the request.
+type Factory[ReqT any] func(schema APISchema, modelNameOverride string) (Translator[ReqT], error)
+
+// RegisterChatCompletionTranslator registers the factory of the [ChatCompletionTranslator] for the API schema name.
+// This must be called before the external processor starts, and fails if the name is built in or already registered.
+func RegisterChatCompletionTranslator(name APISchemaName, factory Factory[ChatCompletionRequest]) error {
+	return endpointspec.RegisterChatCompletionTranslator(name, adaptFactory[ChatCompletionRequest, tracingapi.ChatCompletionSpan](factory))
+}
+
+// RegisterEmbeddingTranslator registers the factory of the [EmbeddingTranslator] for the API schema name.
+// This must be called before the external processor starts, and fails if the name is built in or already registered.
+func RegisterEmbeddingTranslator(name APISchemaName, factory Factory[EmbeddingRequest]) error {
+	return endpointspec.RegisterEmbeddingTranslator(name, adaptFactory[EmbeddingRequest, tracingapi.EmbeddingsSpan](factory))
+}
+
+// RegisterModerationTranslator registers the factory of the [ModerationTranslator] for the API schema name.
+// This must be called before the external processor starts, and fails if the name is built in or already registered.
+func RegisterModerationTranslator(name APISchemaName, factory Factory[ModerationRequest]) error {
+	return endpointspec.RegisterModerationTranslator(name, adaptFactory[ModerationRequest, tracingapi.ModerationSpan](factory))
+}
+
+// adaptFactory adapts the factory of the public [Translator] to the one of the internal translator.
+func adaptFactory[ReqT, SpanT any](factory Factory[ReqT]) endpointspec.TranslatorFactory[translator.Translator[ReqT, SpanT]] {
+	if factory == nil {
+		return nil
+	}
+	return func(schema APISchema, modelNameOverride string) (translator.Translator[ReqT, SpanT], error) {
+		t, err := factory(schema, modelNameOverride)
+		if err != nil {
+			return nil, err
+		}
+		return &translatorAdapter[ReqT, SpanT]{Translator: t}, nil
+	}
+}
+
+// translatorAdapter implements the internal translator interfaces with the public [Translator].
+//
+// The public interface doesn't take the tracing span, so the responses of the registered translators are not
+// recorded in the spans, while the requests still are.
+type translatorAdapter[ReqT, SpanT any] struct {
+	Translator[ReqT]
+}
+
+// ResponseBody implements [translator.Translator.ResponseBody].
+func (a *translatorAdapter[ReqT, SpanT]) ResponseBody(headers map[string]string, body io.Reader, endOfStream bool, _ SpanT) (
+	newHeaders []Header, newBody []byte, tokenUsage TokenUsage, responseModel string, err error,
+) {
+	return a.Translator.ResponseBody(headers, body, endOfStream)
+}
+
+// SetRequestHeaders implements [translator.RequestHeadersSetter].
+func (a *translatorAdapter[ReqT, SpanT]) SetRequestHeaders(headers map[string]string) {
+	if s, ok := a.Translator.(RequestHeadersSetter); ok {
+		s.SetRequestHeaders(headers)
+	}
+}
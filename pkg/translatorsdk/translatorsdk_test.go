@@ -0,0 +1,91 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translatorsdk
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/pkg/llmproxy"
+)
+
+type fakeTranslator struct {
+	schema   APISchema
+	override string
+	headers  map[string]string
+}
+
+func (f *fakeTranslator) SetRequestHeaders(headers map[string]string) { f.headers = headers }
+
+func (f *fakeTranslator) RequestBody(_ []byte, req *ChatCompletionRequest, _ bool) ([]Header, []byte, error) {
+	return []Header{{":path", "/" + f.schema.Version + "/" + f.override + "/" + req.Model}}, []byte(`{"translated":true}`), nil
+}
+
+func (f *fakeTranslator) ResponseHeaders(map[string]string) ([]Header, error) { return nil, nil }
+
+func (f *fakeTranslator) ResponseBody(_ map[string]string, body io.Reader, _ bool) ([]Header, []byte, TokenUsage, string, error) {
+	var usage TokenUsage
+	usage.SetInputTokens(7)
+	b, err := io.ReadAll(body)
+	return nil, []byte(strings.ToUpper(string(b))), usage, "fake-model", err
+}
+
+func (f *fakeTranslator) ResponseError(map[string]string, io.Reader) ([]Header, []byte, error) {
+	return nil, []byte(`{"type":"error"}`), nil
+}
+
+func TestRegisterChatCompletionTranslator(t *testing.T) {
+	var created *fakeTranslator
+	err := RegisterChatCompletionTranslator("FakeChat", func(schema APISchema, modelNameOverride string) (ChatCompletionTranslator, error) {
+		created = &fakeTranslator{schema: schema, override: modelNameOverride}
+		return created, nil
+	})
+	require.NoError(t, err)
+
+	p, err := llmproxy.NewChatCompletionProcessor(llmproxy.APISchema{Name: "FakeChat", Version: "v2"}, "override")
+	require.NoError(t, err)
+	p.SetRequestHeaders(map[string]string{"x-foo": "bar"})
+	require.Equal(t, map[string]string{"x-foo": "bar"}, created.headers)
+
+	req, err := p.RequestBody([]byte(`{"model":"m","messages":[]}`))
+	require.NoError(t, err)
+	require.Equal(t, []llmproxy.Header{{":path", "/v2/override/m"}}, req.Headers)
+	require.JSONEq(t, `{"translated":true}`, string(req.Body))
+
+	resp, err := p.ResponseBody(nil, strings.NewReader("ok"), true)
+	require.NoError(t, err)
+	require.Equal(t, "OK", string(resp.Body))
+	require.Equal(t, "fake-model", resp.Model)
+	in, _ := resp.Usage.InputTokens()
+	require.Equal(t, uint32(7), in)
+
+	t.Run("duplicate", func(t *testing.T) {
+		err := RegisterChatCompletionTranslator("FakeChat", func(APISchema, string) (ChatCompletionTranslator, error) { return nil, nil })
+		require.ErrorContains(t, err, "already registered")
+	})
+	t.Run("built in", func(t *testing.T) {
+		err := RegisterChatCompletionTranslator(APISchemaName(llmproxy.APISchemaOpenAI), func(APISchema, string) (ChatCompletionTranslator, error) { return nil, nil })
+		require.ErrorContains(t, err, "built in")
+	})
+	t.Run("nil factory", func(t *testing.T) {
+		require.Error(t, RegisterChatCompletionTranslator("NilFactory", nil))
+	})
+	t.Run("factory error", func(t *testing.T) {
+		require.NoError(t, RegisterChatCompletionTranslator("FailingChat", func(APISchema, string) (ChatCompletionTranslator, error) {
+			return nil, errors.New("invalid schema")
+		}))
+		_, err := llmproxy.NewChatCompletionProcessor(llmproxy.APISchema{Name: "FailingChat"}, "")
+		require.ErrorContains(t, err, "invalid schema")
+	})
+	t.Run("not registered for embeddings", func(t *testing.T) {
+		_, err := llmproxy.NewEmbeddingProcessor(llmproxy.APISchema{Name: "FakeChat"}, "")
+		require.ErrorContains(t, err, "unsupported API schema")
+	})
+}
@@ -0,0 +1,121 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+// Package translatortest provides the helpers to test the translators written with the translatorsdk package.
+package translatortest
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/internal/endpointspec"
+	"github.com/envoyproxy/ai-gateway/internal/json"
+	"github.com/envoyproxy/ai-gateway/pkg/translatorsdk"
+)
+
+// streamChunkSizes are the sizes of the chunks the upstream stream is split into, in addition to the whole stream.
+// The small ones split every event, and the odd ones split them at different offsets.
+var streamChunkSizes = []int{1, 2, 3, 7, 16, 61, 256}
+
+// StreamResult is the client-facing result of a translated streaming response.
+type StreamResult struct {
+	// Chunks are the chat completion chunks sent to the client, in order, excluding the terminating [DONE].
+	Chunks []*translatorsdk.ChatCompletionResponseChunk
+	// Content is the concatenated content of the deltas per choice index.
+	Content map[int64]string
+	// FinishReasons are the finish reasons per choice index.
+	FinishReasons map[int64]string
+	// Usage is the token usage accumulated from the translator the same way as the external processor does.
+	Usage translatorsdk.TokenUsage
+	// Model is the last response model returned by the translator.
+	Model string
+}
+
+// CheckChatCompletionStream translates the streaming chat completion response upstreamStream of the request
+// requestBody, and checks that the translator returned by newTranslator produces a valid OpenAI stream:
+//
+//   - Every event sent to the client is a "data:" event with a chat completion chunk, and the stream is
+//     terminated by "data: [DONE]" with nothing after it.
+//   - The content, finish reasons, usage and model are the same regardless of how the upstream stream is split
+//     into the chunks, including single bytes.
+//
+// A new translator is created for each split. The result of the translation of the whole stream is returned for
+// further assertions.
+func CheckChatCompletionStream(t testing.TB, newTranslator func() translatorsdk.ChatCompletionTranslator, requestBody, upstreamStream []byte) *StreamResult {
+	t.Helper()
+	expected := translateStream(t, newTranslator(), requestBody, upstreamStream, len(upstreamStream))
+	for _, size := range streamChunkSizes {
+		if size >= len(upstreamStream) {
+			break
+		}
+		actual := translateStream(t, newTranslator(), requestBody, upstreamStream, size)
+		require.Equal(t, expected.Content, actual.Content, "content differs when the stream is split into %d-byte chunks", size)
+		require.Equal(t, expected.FinishReasons, actual.FinishReasons, "finish reasons differ when the stream is split into %d-byte chunks", size)
+		require.Equal(t, expected.Usage, actual.Usage, "usage differs when the stream is split into %d-byte chunks", size)
+		require.Equal(t, expected.Model, actual.Model, "model differs when the stream is split into %d-byte chunks", size)
+	}
+	return expected
+}
+
+// translateStream translates the upstream stream split into the chunks of the given size, and parses the output.
+func translateStream(t testing.TB, tr translatorsdk.ChatCompletionTranslator, requestBody, upstreamStream []byte, chunkSize int) *StreamResult {
+	t.Helper()
+	_, req, stream, mutated, err := endpointspec.ChatCompletionsEndpointSpec{}.ParseBody(requestBody, false)
+	require.NoError(t, err, "failed to parse the request body")
+	require.True(t, stream, "the request must be a streaming one")
+	raw := requestBody
+	if mutated != nil {
+		raw = mutated
+	}
+	_, _, err = tr.RequestBody(raw, req, mutated != nil)
+	require.NoError(t, err, "failed to translate the request body")
+
+	headers := map[string]string{":status": "200", "content-type": "text/event-stream"}
+	_, err = tr.ResponseHeaders(headers)
+	require.NoError(t, err, "failed to translate the response headers")
+
+	result := &StreamResult{Content: map[int64]string{}, FinishReasons: map[int64]string{}}
+	var out []byte
+	for i := 0; i < len(upstreamStream) || i == 0; i += chunkSize {
+		end := min(i+chunkSize, len(upstreamStream))
+		chunk := upstreamStream[i:end]
+		_, body, usage, model, err := tr.ResponseBody(headers, bytes.NewReader(chunk), end == len(upstreamStream))
+		require.NoError(t, err, "failed to translate the response body at offset %d", i)
+		if body == nil {
+			// The original chunk is sent as-is.
+			body = chunk
+		}
+		out = append(out, body...)
+		result.Usage.Override(usage)
+		if model != "" {
+			result.Model = model
+		}
+	}
+
+	events := strings.Split(strings.TrimRight(string(out), "\n"), "\n\n")
+	require.NotEmpty(t, events, "no events were sent to the client")
+	require.Equal(t, "data: [DONE]", events[len(events)-1], "the stream must be terminated by [DONE]")
+	for _, event := range events[:len(events)-1] {
+		data, ok := strings.CutPrefix(event, "data: ")
+		require.True(t, ok, "event %q is not a data event", event)
+		require.NotEqual(t, "[DONE]", data, "[DONE] must be the last event")
+		chunk := &translatorsdk.ChatCompletionResponseChunk{}
+		require.NoError(t, json.Unmarshal([]byte(data), chunk), "event %q is not a chat completion chunk", event)
+		require.Equal(t, "chat.completion.chunk", chunk.Object, "event %q is not a chat completion chunk", event)
+		result.Chunks = append(result.Chunks, chunk)
+		for _, choice := range chunk.Choices {
+			if choice.Delta != nil && choice.Delta.Content != nil {
+				result.Content[choice.Index] += *choice.Delta.Content
+			}
+			if choice.FinishReason != "" {
+				result.FinishReasons[choice.Index] = string(choice.FinishReason)
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,118 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translatortest
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/internal/json"
+	"github.com/envoyproxy/ai-gateway/pkg/translatorsdk"
+)
+
+// lineTranslator translates a stream of the newline-delimited {"text":"...","done":bool} objects.
+// When buffered is false, it incorrectly assumes that every chunk contains complete lines.
+type lineTranslator struct {
+	buffered bool
+	buf      []byte
+}
+
+func (l *lineTranslator) RequestBody([]byte, *translatorsdk.ChatCompletionRequest, bool) ([]translatorsdk.Header, []byte, error) {
+	return []translatorsdk.Header{{":path", "/generate"}}, nil, nil
+}
+
+func (l *lineTranslator) ResponseHeaders(map[string]string) ([]translatorsdk.Header, error) {
+	return nil, nil
+}
+
+func (l *lineTranslator) ResponseBody(_ map[string]string, body io.Reader, endOfStream bool) (
+	[]translatorsdk.Header, []byte, translatorsdk.TokenUsage, string, error,
+) {
+	var usage translatorsdk.TokenUsage
+	b, err := io.ReadAll(body)
+	if err != nil {
+		return nil, nil, usage, "", err
+	}
+	l.buf = append(l.buf, b...)
+	out := []byte{}
+	for {
+		i := bytes.IndexByte(l.buf, '\n')
+		if i == -1 {
+			if !l.buffered {
+				l.buf = nil
+			}
+			break
+		}
+		line := l.buf[:i]
+		l.buf = l.buf[i+1:]
+		var event struct {
+			Text string `json:"text"`
+			Done bool   `json:"done"`
+		}
+		if err = json.Unmarshal(line, &event); err != nil {
+			return nil, nil, usage, "", err
+		}
+		finishReason := ""
+		if event.Done {
+			finishReason = `,"finish_reason":"stop"`
+			usage.SetOutputTokens(2)
+		}
+		out = fmt.Appendf(out, `data: {"object":"chat.completion.chunk","choices":[{"index":0,"delta":{"content":%q}%s}]}`+"\n\n", event.Text, finishReason)
+	}
+	if endOfStream {
+		out = append(out, "data: [DONE]\n\n"...)
+	}
+	return nil, out, usage, "line-model", nil
+}
+
+func (l *lineTranslator) ResponseError(map[string]string, io.Reader) ([]translatorsdk.Header, []byte, error) {
+	return nil, nil, nil
+}
+
+func TestCheckChatCompletionStream(t *testing.T) {
+	requestBody := []byte(`{"model":"line-model","stream":true,"messages":[{"role":"user","content":"hi"}]}`)
+	upstream := []byte(`{"text":"Hello"}` + "\n" + `{"text":", world"}` + "\n" + `{"text":"!","done":true}` + "\n")
+
+	result := CheckChatCompletionStream(t, func() translatorsdk.ChatCompletionTranslator {
+		return &lineTranslator{buffered: true}
+	}, requestBody, upstream)
+	require.Len(t, result.Chunks, 3)
+	require.Equal(t, map[int64]string{0: "Hello, world!"}, result.Content)
+	require.Equal(t, map[int64]string{0: "stop"}, result.FinishReasons)
+	require.Equal(t, "line-model", result.Model)
+	out, _ := result.Usage.OutputTokens()
+	require.Equal(t, uint32(2), out)
+
+	t.Run("unbuffered translator is detected", func(t *testing.T) {
+		mockT := &mockTB{TB: t}
+		func() {
+			defer func() { _ = recover() }()
+			CheckChatCompletionStream(mockT, func() translatorsdk.ChatCompletionTranslator {
+				return &lineTranslator{}
+			}, requestBody, upstream)
+		}()
+		require.True(t, mockT.failed)
+	})
+}
+
+// mockTB records the failures instead of failing the test, and stops the check like the testing.T does.
+type mockTB struct {
+	testing.TB
+	failed bool
+}
+
+func (m *mockTB) Errorf(string, ...any) { m.failed = true }
+
+func (m *mockTB) FailNow() {
+	m.failed = true
+	panic("FailNow")
+}
+
+func (m *mockTB) Helper() {}
@@ -0,0 +1,46 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+// Package x contains the experimental extension points of the AI Gateway external processor, which allow adding
+// custom behavior without forking its processors. The API of this package may change in the future releases.
+//
+// The extensions are registered at the initialization of a custom external processor binary, which then runs the
+// AI Gateway external processor as usual:
+//
+//	func main() {
+//		if err := x.RegisterMetadataEmitter("business-unit", businessUnitEmitter{}); err != nil {
+//			log.Fatal(err)
+//		}
+//		mainlib.Main(ctx, os.Args[1:], os.Stderr)
+//	}
+package x
+
+import (
+	"github.com/envoyproxy/ai-gateway/internal/extproc"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+)
+
+// MetadataNamespace is the namespace of the dynamic metadata the key-values of the [MetadataEmitter] are set in.
+// The rate limiting, the access logs and the billing read the dynamic metadata of the requests from it.
+const MetadataNamespace = internalapi.AIGatewayFilterMetadataNamespace
+
+type (
+	// MetadataEmitter contributes the key-values of a request to its dynamic metadata in the [MetadataNamespace],
+	// e.g. a business unit code derived from the headers.
+	//
+	// The emitters are called for each attempt of a request, when it is sent to the backend, and must be safe for
+	// concurrent use. The keys set by the AI Gateway itself, such as the costs and the backend name, take
+	// precedence. An error fails the request.
+	MetadataEmitter = extproc.MetadataEmitter
+	// MetadataEmitterRequest is the request whose metadata is emitted by a [MetadataEmitter].
+	MetadataEmitterRequest = extproc.MetadataEmitterRequest
+)
+
+// RegisterMetadataEmitter registers the [MetadataEmitter] with the name, which must be unique. The emitters are
+// called in the order of the registration, so the later ones take precedence over the earlier ones for the same
+// keys. This must be called before the external processor starts.
+func RegisterMetadataEmitter(name string, emitter MetadataEmitter) error {
+	return extproc.RegisterMetadataEmitter(name, emitter)
+}
@@ -0,0 +1,25 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package x
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type businessUnitEmitter struct{}
+
+func (businessUnitEmitter) EmitMetadata(_ context.Context, req *MetadataEmitterRequest) (map[string]string, error) {
+	return map[string]string{"business_unit": req.Headers["x-business-unit"]}, nil
+}
+
+func TestRegisterMetadataEmitter(t *testing.T) {
+	require.NoError(t, RegisterMetadataEmitter("business-unit", businessUnitEmitter{}))
+	require.ErrorContains(t, RegisterMetadataEmitter("business-unit", businessUnitEmitter{}), "already registered")
+	require.Equal(t, "io.envoy.ai_gateway", MetadataNamespace)
+}
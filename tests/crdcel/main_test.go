@@ -97,9 +97,10 @@ func TestAIServiceBackends(t *testing.T) {
 		{name: "anthropic-schema.yaml"},
 		{name: "basic-eg-backend-aws.yaml"},
 		{name: "basic-eg-backend-azure.yaml"},
+		{name: "custom_schema.yaml"},
 		{
-			name:   "unknown_schema.yaml",
-			expErr: "spec.schema.name: Unsupported value: \"SomeRandomVendor\": supported values: \"OpenAI\", \"Cohere\", \"AWSBedrock\", \"AzureOpenAI\", \"GCPVertexAI\", \"GCPAnthropic\", \"Anthropic\"",
+			name:   "invalid_schema_name.yaml",
+			expErr: "spec.schema.name in body should match '^[A-Za-z][A-Za-z0-9]*$'",
 		},
 		{name: "k8s-svc.yaml", expErr: "BackendRef must be a Backend resource of Envoy Gateway"},
 	} {
@@ -193,6 +194,25 @@ func TestBackendSecurityPolicies(t *testing.T) {
 		{name: "aws_oidc.yaml"},
 		{name: "gcp_oidc.yaml"},
 		{name: "anthropic-apikey.yaml"},
+		{name: "ibm_cloud_apikey.yaml"},
+		{
+			name:   "ibm_cloud_apikey_with_apikey.yaml",
+			expErr: "When type is IBMCloudAPIKey, only ibmCloudAPIKey field should be set",
+		},
+		{name: "sap_ai_core_credentials.yaml"},
+		{
+			name:   "sap_ai_core_credentials_with_apikey.yaml",
+			expErr: "When type is SAPAICoreCredentials, only sapAICoreCredentials field should be set",
+		},
+		{name: "apikey_pool.yaml"},
+		{
+			name:   "apikey_pool_with_secret_ref.yaml",
+			expErr: "exactly one of secretRef or pool must be set",
+		},
+		{
+			name:   "apikey_pool_zero_weights.yaml",
+			expErr: "at least one key must have a positive weight",
+		},
 		{name: "targetrefs_basic.yaml"},
 		{name: "targetrefs_multiple.yaml"},
 		{name: "targetrefs_inferencepool.yaml"},